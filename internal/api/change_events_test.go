@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/repository/sqlite"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/internal/service/contract"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+// listeningRepository reports the changes sent to its channel, as the PostgreSQL LISTEN does
+type listeningRepository struct {
+	repository.Repository
+	changes chan topology.Change
+}
+
+func (r *listeningRepository) ListenChanges(ctx context.Context, handle func(topology.Change)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case change := <-r.changes:
+			handle(change)
+		}
+	}
+}
+
+// observingVisualization records the changes forwarded to a cached visualization service
+type observingVisualization struct {
+	contract.Visualization
+	observed chan topology.Change
+}
+
+func (v *observingVisualization) ObserveChange(change topology.Change) {
+	select {
+	case v.observed <- change:
+	default:
+	}
+}
+
+func TestChangeEvents(t *testing.T) {
+	base, err := repository.NewRepository(repository.Config{
+		Type:   "sqlite",
+		SQLite: sqlite.Config{Path: filepath.Join(t.TempDir(), "api.db")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	t.Cleanup(func() { base.Close() })
+	if err := base.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate repository: %v", err)
+	}
+
+	repo := &listeningRepository{Repository: base, changes: make(chan topology.Change)}
+	observer := &observingVisualization{
+		Visualization: service.NewVisualizationService(repo),
+		observed:      make(chan topology.Change, 100),
+	}
+	server := NewServer(repo, repo, logger.New("error"), WithVisualizationService(observer))
+	// ストリームはリクエストの時間予算を超えても切断されない
+	server.SetRequestTimeout(50 * time.Millisecond)
+	server.StartChangeListener()
+
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL+"/api/v1/events/topology", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open the change stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("Expected an event stream, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	// ready の後の変更は届く
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case repo.changes <- topology.Change{Table: topology.ChangeTableDevices, Op: topology.ChangeOpUpdate, ID: "leaf-01", ReceivedAt: time.Now()}:
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(resp.Body)
+	var event, data string
+	var ready bool
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			event = strings.TrimPrefix(line, "event: ")
+		}
+		if strings.HasPrefix(line, "data: ") && event == "ready" {
+			ready = true
+			continue
+		}
+		if strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(line, "data: ")
+			break
+		}
+	}
+	if !ready {
+		t.Error("Expected the stream to start with a ready event")
+	}
+	if event != "topology.change" || !strings.Contains(data, `"id":"leaf-01"`) {
+		t.Fatalf("Expected a topology.change event for leaf-01, got event %q data %q (%v)", event, data, scanner.Err())
+	}
+
+	select {
+	case change := <-observer.observed:
+		if change.ID != "leaf-01" {
+			t.Errorf("Expected the observer to receive leaf-01, got %+v", change)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected the change to be forwarded to the visualization service")
+	}
+
+	cancel()
+	server.stopChangeFeed()
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+// blockingRepository holds device listings until their context ends, like a query on a locked table
+type blockingRepository struct {
+	repository.Repository
+	cancelled chan error
+}
+
+func (r *blockingRepository) GetDevices(ctx context.Context, opts topology.PaginationOptions) ([]topology.Device, *topology.PaginationResult, error) {
+	<-ctx.Done()
+	r.cancelled <- ctx.Err()
+	return nil, nil, ctx.Err()
+}
+
+func TestRequestDeadline(t *testing.T) {
+	base := newSQLiteTestServer(t)
+	repo := &blockingRepository{Repository: base.topologyRepo.(repository.Repository), cancelled: make(chan error, 1)}
+	server := NewServer(repo, repo, logger.New("error"))
+	server.SetRequestTimeout(50 * time.Millisecond)
+
+	started := time.Now()
+	resp := serve(server.Handler(), http.MethodGet, "/api/v1/devices", "")
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("Expected the request to end at its deadline, took %v", elapsed)
+	}
+	if resp.Code != http.StatusGatewayTimeout {
+		t.Fatalf("Expected 504, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var body ErrorModel
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode error: %v", err)
+	}
+	if body.Code != "timeout" {
+		t.Errorf("Expected error code timeout, got %q", body.Code)
+	}
+
+	select {
+	case err := <-repo.cancelled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the repository query to be cancelled by the deadline, got %v", err)
+		}
+	default:
+		t.Error("Expected the repository query to see the deadline")
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import "github.com/danielgtaylor/huma/v2"
+
+// apiTags describes the operation tags so the generated OpenAPI groups operations with an explanation
+var apiTags = []*huma.Tag{
+	{Name: "topology", Description: "Devices and links collected from LLDP"},
+	{Name: "topology-search", Description: "Reachability, shortest paths, asymmetric links and what-if simulations"},
+	{Name: "analysis", Description: "Reports computed over the whole topology, such as spine/leaf balance, reachability matrices and reverse path validation"},
+	{Name: "devices", Description: "Bulk operations on devices"},
+	{Name: "visualization", Description: "Graph views of the topology for rendering, with optional grouping of similar devices. " +
+		"Grouping collapses devices sharing a name prefix (group_by_prefix), a type (group_by_type) or a depth (group_by_depth) " +
+		"into one node once at least min_group_size devices match, for nodes deeper than max_group_depth."},
+	{Name: "classification", Description: "Hierarchy layers, manual classifications and rules that classify devices automatically. " +
+		"Rule conditions match the device field name (the device ID), hardware or type with the operator contains, starts_with, " +
+		"ends_with, equals (all case-insensitive) or regex; conditions are combined with AND or OR."},
+	{Name: "provisioning", Description: "Planned devices and their comparison with discovered devices"},
+	{Name: "display-names", Description: "Overrides of the names shown for devices"},
+	{Name: "starting-views", Description: "The topology each role lands on after login"},
+	{Name: "shares", Description: "Signed, expiring read-only links to starting views"},
+	{Name: "fabrics", Description: "Named device sets whose members are assigned by conditions"},
+	{Name: "circuits", Description: "Cable and circuit IDs attached to links"},
+	{Name: "port-reservations", Description: "Ports reserved for future cabling and the interface inventory of devices"},
+	{Name: "maintenance", Description: "Maintenance windows and previews of the devices that would lose every path during a window"},
+	{Name: "identities", Description: "Canonical identities of the devices described by several sources under different IDs and the manual resolution of conflicts"},
+	{Name: "racks", Description: "Rack placement of devices and rack elevations with the cabling of each port"},
+	{Name: "icons", Description: "Versioned manifest of the icons shown for device types and vendors"},
+	{Name: "sync", Description: "Dry runs of the Prometheus synchronization and the topology changes of each sync cycle"},
+	{Name: "jobs", Description: "Durable queue of long-running tasks such as snapshots and reports"},
+	{Name: "exports", Description: "GraphML, CSV and snapshot exports written to object storage by jobs and downloaded with signed URLs"},
+	{Name: "metrics", Description: "Whitelisted device and interface metrics from Prometheus, cached and rate limited"},
+	{Name: "schemas", Description: "JSON Schemas of change event payloads for validation and code generation"},
+	{Name: "diagnostics", Description: "Admin-only query plans of topology requests for tuning database indexes"},
+	{Name: "health", Description: "Service and database health"},
+}
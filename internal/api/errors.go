@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+)
+
+// ErrorModel is the error body of every API response. Code is a stable,
+// machine-readable error code (e.g. "fabric_not_found") clients can branch on.
+type ErrorModel struct {
+	huma.ErrorModel
+	Code string `json:"code,omitempty" example:"not_found" doc:"Machine-readable error code"`
+}
+
+func init() {
+	// エラー応答の生成を差し替える（操作の登録より前に設定が必要）
+	huma.NewError = newError
+}
+
+// newError builds the API error body. Typed domain errors in errs decide the error code,
+// and turn a generic 500 into the status of their kind; errors whose message is already
+// the detail are not repeated in the error list.
+func newError(status int, msg string, errs ...error) huma.StatusError {
+	code := ""
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if typed, ok := apperror.From(err); ok {
+			if status == http.StatusInternalServerError {
+				status = kindStatus(typed.Kind)
+			}
+			code = typed.Code
+			break
+		}
+		if status == http.StatusInternalServerError {
+			switch {
+			case errors.Is(err, context.DeadlineExceeded), isQueryCanceled(err):
+				status, code = http.StatusGatewayTimeout, "timeout"
+			case isUnavailable(err):
+				status, code = http.StatusServiceUnavailable, string(apperror.KindDependencyUnavailable)
+			}
+		}
+	}
+	if code == "" {
+		code = statusCode(status)
+	}
+
+	model := &ErrorModel{
+		ErrorModel: huma.ErrorModel{
+			Status: status,
+			Title:  http.StatusText(status),
+			Detail: msg,
+		},
+		Code: code,
+	}
+	for _, err := range errs {
+		if err == nil || err.Error() == msg {
+			continue
+		}
+		model.Add(err)
+	}
+	return model
+}
+
+// kindStatus maps an error kind to its HTTP status
+func kindStatus(kind apperror.Kind) int {
+	switch kind {
+	case apperror.KindNotFound:
+		return http.StatusNotFound
+	case apperror.KindConflict:
+		return http.StatusConflict
+	case apperror.KindValidation:
+		return http.StatusBadRequest
+	case apperror.KindDependencyUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// statusCode is the error code of errors without a typed domain error
+func statusCode(status int) string {
+	switch status {
+	case 0:
+		return ""
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return string(apperror.KindValidation)
+	case http.StatusNotFound:
+		return string(apperror.KindNotFound)
+	case http.StatusConflict:
+		return string(apperror.KindConflict)
+	case http.StatusServiceUnavailable:
+		return string(apperror.KindDependencyUnavailable)
+	case http.StatusInternalServerError:
+		return "internal_error"
+	}
+	// その他は "precondition_failed" のようにステータス名から生成する
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// isUnavailable reports whether err comes from an unreachable database or backend
+func isUnavailable(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone)
+}
+
+// isQueryCanceled reports whether err is a database query cancelled by the server
+// (SQLSTATE 57014), which is how the Postgres driver reports a query stopped by its context
+func isQueryCanceled(err error) bool {
+	var stateErr interface{ SQLState() string }
+	return errors.As(err, &stateErr) && stateErr.SQLState() == "57014"
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+)
+
+// sqlStateError is a database error carrying a SQLSTATE code like the Postgres driver's
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "pq: error " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestNewError(t *testing.T) {
+	errFabricNotFound := apperror.NotFound("fabric_not_found", "fabric not found")
+	errInvalidFabric := apperror.Validation("invalid_fabric", "invalid fabric")
+	invalid := fmt.Errorf("%w: name is required", errInvalidFabric)
+
+	tests := []struct {
+		name       string
+		err        huma.StatusError
+		wantStatus int
+		wantCode   string
+		wantErrors int
+	}{
+		{"typed error maps a generic 500", huma.Error500InternalServerError("Failed to get fabric", fmt.Errorf("failed to get fabric: %w", errFabricNotFound)), http.StatusNotFound, "fabric_not_found", 1},
+		{"explicit status is kept", huma.Error400BadRequest(invalid.Error(), invalid), http.StatusBadRequest, "invalid_fabric", 0},
+		{"untyped error", huma.Error500InternalServerError("Failed to list devices", errors.New("boom")), http.StatusInternalServerError, "internal_error", 1},
+		{"untyped status", huma.Error404NotFound("Device not found"), http.StatusNotFound, "not_found", 0},
+		{"other status", huma.Error412PreconditionFailed("confirmation required"), http.StatusPreconditionFailed, "precondition_failed", 0},
+		{"deadline", huma.Error500InternalServerError("Failed to get topology", fmt.Errorf("query: %w", context.DeadlineExceeded)), http.StatusGatewayTimeout, "timeout", 1},
+		{"cancelled query", huma.Error500InternalServerError("Failed to get topology", fmt.Errorf("query: %w", sqlStateError("57014"))), http.StatusGatewayTimeout, "timeout", 1},
+		{"other database error", huma.Error500InternalServerError("Failed to get topology", sqlStateError("23505")), http.StatusInternalServerError, "internal_error", 1},
+		{"unreachable database", huma.Error500InternalServerError("Failed to get devices", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), http.StatusServiceUnavailable, "dependency_unavailable", 1},
+	}
+	for _, tt := range tests {
+		model, ok := tt.err.(*ErrorModel)
+		if !ok {
+			t.Fatalf("%s: expected *ErrorModel, got %T", tt.name, tt.err)
+		}
+		if model.GetStatus() != tt.wantStatus || model.Code != tt.wantCode {
+			t.Errorf("%s: expected %d %s, got %d %s", tt.name, tt.wantStatus, tt.wantCode, model.GetStatus(), model.Code)
+		}
+		if len(model.Errors) != tt.wantErrors {
+			t.Errorf("%s: expected %d error details, got %d", tt.name, tt.wantErrors, len(model.Errors))
+		}
+	}
+}
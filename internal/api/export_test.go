@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/job"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/repository/sqlite"
+	"github.com/servak/topology-manager/internal/storage"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+// queueRepository adds an in-memory job queue to a repository, as the PostgreSQL repository has
+type queueRepository struct {
+	repository.Repository
+	mu   sync.Mutex
+	jobs map[string]*job.Job
+}
+
+func (r *queueRepository) EnqueueJob(ctx context.Context, queued job.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queued.CreatedAt = time.Now()
+	r.jobs[queued.ID] = &queued
+	return nil
+}
+
+func (r *queueRepository) ClaimJob(ctx context.Context, worker string, kinds []string, visibility time.Duration) (*job.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, queued := range r.jobs {
+		if queued.Status == job.StatusQueued {
+			queued.Status = job.StatusRunning
+			queued.LockedBy = worker
+			queued.Attempts++
+			claimed := *queued
+			return &claimed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *queueRepository) ExtendJobLease(ctx context.Context, id, worker string, visibility time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (r *queueRepository) CompleteJob(ctx context.Context, id, worker string, result json.RawMessage) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[id].Status = job.StatusSucceeded
+	r.jobs[id].Result = result
+	return true, nil
+}
+
+func (r *queueRepository) FailJob(ctx context.Context, id, worker, message string, retryDelay time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[id].Status = job.StatusFailed
+	r.jobs[id].Error = message
+	return true, nil
+}
+
+func (r *queueRepository) GetJob(ctx context.Context, id string) (*job.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if found, ok := r.jobs[id]; ok {
+		copied := *found
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (r *queueRepository) ListJobs(ctx context.Context, status string, limit int) ([]job.Job, error) {
+	return nil, nil
+}
+
+func TestExports(t *testing.T) {
+	ctx := context.Background()
+	base, err := repository.NewRepository(repository.Config{
+		Type:   "sqlite",
+		SQLite: sqlite.Config{Path: filepath.Join(t.TempDir(), "api.db")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	t.Cleanup(func() { base.Close() })
+	if err := base.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate repository: %v", err)
+	}
+	if err := base.BulkAddDevices(ctx, []topology.Device{{ID: "leaf-01", Type: "switch"}, {ID: "spine-01", Type: "switch"}}); err != nil {
+		t.Fatalf("Failed to add devices: %v", err)
+	}
+
+	repo := &queueRepository{Repository: base, jobs: make(map[string]*job.Job)}
+	store, err := storage.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	server := NewServer(repo, repo, logger.New("error"))
+	server.SetExportStore(store, 0)
+	server.SetShareSecret([]byte("secret"))
+	handler := server.Handler()
+
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := call(http.MethodPost, "/api/v1/exports", `{"format":"pdf"}`); rec.Code != http.StatusUnprocessableEntity && rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown format to be rejected, got %d", rec.Code)
+	}
+
+	rec := call(http.MethodPost, "/api/v1/exports", `{"format":"devices_csv"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var submitted job.Job
+	json.Unmarshal(rec.Body.Bytes(), &submitted)
+
+	// 実行前はダウンロードURLを発行できない
+	if rec := call(http.MethodPost, "/api/v1/exports/"+submitted.ID+"/download-url", `{}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 before the job ran, got %d", rec.Code)
+	}
+
+	if ran, err := server.jobService.RunNext(ctx, "test"); !ran || err != nil {
+		t.Fatalf("Expected the export job to run, got %v %v", ran, err)
+	}
+
+	rec = call(http.MethodPost, "/api/v1/exports/"+submitted.ID+"/download-url", `{"expires_in":"1h"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var link struct {
+		Path string              `json:"path"`
+		File topology.ExportFile `json:"file"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &link)
+	if link.File.Devices != 2 || !strings.HasPrefix(link.File.Filename, "devices-") {
+		t.Errorf("Expected an export of 2 devices, got %+v", link.File)
+	}
+
+	rec = call(http.MethodGet, link.Path, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body, _ := io.ReadAll(rec.Body)
+	if !strings.Contains(string(body), "leaf-01,switch") || !strings.Contains(rec.Header().Get("Content-Disposition"), link.File.Filename) {
+		t.Errorf("Expected the CSV as an attachment, got %q (%s)", body, rec.Header().Get("Content-Disposition"))
+	}
+
+	if rec := call(http.MethodGet, link.Path+"x", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a tampered URL to be rejected with 403, got %d", rec.Code)
+	}
+}
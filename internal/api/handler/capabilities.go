@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	apimiddleware "github.com/servak/topology-manager/internal/api/middleware"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+// Capabilities tells clients which parts of the API this deployment offers
+type Capabilities struct {
+	ReadOnly bool   `json:"read_only" doc:"The server rejects changes; synchronization is the only writer"`
+	CanWrite bool   `json:"can_write" doc:"The calling user may change the topology, classifications and layers"`
+	Auth     string `json:"auth" enum:"none,basic,sso" doc:"How users authenticate"`
+	// 任意の機能（リポジトリやサーバー設定によって提供されないことがある）
+	Features map[string]bool `json:"features" doc:"Optional features by name, e.g. provisioning, share_links, metrics_proxy, sync_preview"`
+}
+
+type CapabilitiesHandler struct {
+	capabilities func() Capabilities
+	logger       *logger.Logger
+}
+
+// NewCapabilitiesHandler serves the capabilities returned by capabilities, which is called for
+// every request so features enabled after registration are included
+func NewCapabilitiesHandler(capabilities func() Capabilities, appLogger *logger.Logger) *CapabilitiesHandler {
+	return &CapabilitiesHandler{
+		capabilities: capabilities,
+		logger:       appLogger.WithComponent("capabilities_handler"),
+	}
+}
+
+func (h *CapabilitiesHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-capabilities",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/capabilities",
+		Summary:     "Get server capabilities",
+		Description: "Report whether the server is read-only, whether the calling user may write and which optional features are available, " +
+			"so the UI can hide what it cannot use.",
+		Tags: []string{"health"},
+	}, h.GetCapabilities)
+}
+
+func (h *CapabilitiesHandler) GetCapabilities(ctx context.Context, input *struct{}) (*struct {
+	Body Capabilities
+}, error) {
+	capabilities := h.capabilities()
+	capabilities.CanWrite = !capabilities.ReadOnly
+	// 認証ありの場合はロールでも書き込みが制限される
+	if principal, ok := apimiddleware.PrincipalFromContext(ctx); ok && !principal.Role.CanWrite() {
+		capabilities.CanWrite = false
+	}
+	return &struct {
+		Body Capabilities
+	}{Body: capabilities}, nil
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/sse"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+// ChangeEventsPath streams the topology changes; it is exempt from the request deadline
+const ChangeEventsPath = "/api/v1/events/topology"
+
+// ChangeStreamReady is the first event of a change stream: every change after Since is sent, so
+// clients load what they display after receiving it without missing changes
+type ChangeStreamReady struct {
+	Since time.Time `json:"since"`
+}
+
+type ChangeEventsHandler struct {
+	feed   *service.ChangeFeed
+	logger *logger.Logger
+}
+
+func NewChangeEventsHandler(feed *service.ChangeFeed, appLogger *logger.Logger) *ChangeEventsHandler {
+	return &ChangeEventsHandler{
+		feed:   feed,
+		logger: appLogger.WithComponent("change_events_handler"),
+	}
+}
+
+func (h *ChangeEventsHandler) Register(api huma.API) {
+	sse.Register(api, huma.Operation{
+		OperationID: "stream-topology-changes",
+		Method:      http.MethodGet,
+		Path:        ChangeEventsPath,
+		Summary:     "Stream topology changes",
+		Description: "Server-sent events for every device and link inserted, updated or deleted by any writer: synchronization, " +
+			"another API instance or manual SQL. Changes to last_seen alone are not sent. The stream starts with a ready event once subscribed. " +
+			"A RESYNC change means changes may have been missed and clients should reload what they display.",
+		Tags: []string{"topology"},
+	}, map[string]any{
+		"ready":           ChangeStreamReady{},
+		"topology.change": topology.Change{},
+	}, h.StreamChanges)
+}
+
+func (h *ChangeEventsHandler) StreamChanges(ctx context.Context, input *struct{}, send sse.Sender) {
+	changes, unsubscribe := h.feed.Subscribe()
+	defer unsubscribe()
+
+	if err := send.Data(ChangeStreamReady{Since: time.Now()}); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change := <-changes:
+			if err := send.Data(change); err != nil {
+				h.logger.Debug("Change stream closed", "error", err)
+				return
+			}
+		}
+	}
+}
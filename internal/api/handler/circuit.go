@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type CircuitHandler struct {
+	circuitService *service.CircuitService
+	logger         *logger.Logger
+}
+
+func NewCircuitHandler(circuitService *service.CircuitService, appLogger *logger.Logger) *CircuitHandler {
+	return &CircuitHandler{
+		circuitService: circuitService,
+		logger:         appLogger.WithComponent("circuit_handler"),
+	}
+}
+
+// CircuitRequest creates or replaces a circuit
+type CircuitRequest struct {
+	CircuitID string `path:"circuitId" doc:"Circuit or cable ID"`
+	Body      struct {
+		Provider    string `json:"provider,omitempty" doc:"Carrier or cabling vendor"`
+		ADevice     string `json:"a_device" doc:"Device of the A end"`
+		APort       string `json:"a_port,omitempty" doc:"Port of the A end (empty matches any port)"`
+		ZDevice     string `json:"z_device" doc:"Device of the Z end"`
+		ZPort       string `json:"z_port,omitempty" doc:"Port of the Z end (empty matches any port)"`
+		LinkID      string `json:"link_id,omitempty" doc:"Link to attach to (default: the link between the ends)"`
+		Description string `json:"description,omitempty" doc:"Free-form description (e.g. rack and patch panel)"`
+	}
+}
+
+// ImportCircuitsRequest uploads circuits as CSV
+type ImportCircuitsRequest struct {
+	RawBody []byte `contentType:"text/csv" doc:"CSV with header circuit_id,provider,a_device,a_port,z_device,z_port,description"`
+}
+
+type CircuitResponse struct {
+	Body topology.Circuit
+}
+
+type CircuitsResponse struct {
+	Body struct {
+		Circuits []topology.Circuit `json:"circuits"`
+		Count    int                `json:"count"`
+	}
+}
+
+type CircuitImportResponse struct {
+	Body topology.CircuitImportResult
+}
+
+type LinkDetailResponse struct {
+	Body topology.LinkDetail
+}
+
+func (h *CircuitHandler) Register(api huma.API) {
+	// ケーブル・回線ID API
+	huma.Register(api, huma.Operation{
+		OperationID: "search-circuits",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/circuits",
+		Summary:     "Search circuits",
+		Description: "Search circuits by circuit ID, provider, end device or description",
+		Tags:        []string{"circuits"},
+	}, h.SearchCircuits)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-circuit",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/circuits/{circuitId}",
+		Summary:     "Get circuit",
+		Tags:        []string{"circuits"},
+	}, h.GetCircuit)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "set-circuit",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/circuits/{circuitId}",
+		Summary:     "Create or update circuit",
+		Tags:        []string{"circuits"},
+	}, h.SetCircuit)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-circuit",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/circuits/{circuitId}",
+		Summary:     "Delete circuit",
+		Tags:        []string{"circuits"},
+	}, h.DeleteCircuit)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "import-circuits",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/circuits/import",
+		Summary:     "Import circuits from CSV",
+		Description: "Create or update circuits from a CSV export and attach them to the links between their ends",
+		Tags:        []string{"circuits"},
+	}, h.ImportCircuits)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-link-detail",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/links/{linkId}",
+		Summary:     "Get link detail",
+		Description: "Get a link with the circuits attached to it",
+		Tags:        []string{"circuits", "topology"},
+	}, h.GetLinkDetail)
+}
+
+func (h *CircuitHandler) SearchCircuits(ctx context.Context, req *struct {
+	Query string `query:"q" doc:"Text contained in the circuit ID, provider, end devices or description"`
+	Limit int    `query:"limit" default:"100" doc:"Maximum number of circuits to return"`
+}) (*CircuitsResponse, error) {
+	limit := req.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	circuits, err := h.circuitService.SearchCircuits(ctx, req.Query, limit)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to search circuits", err)
+	}
+
+	resp := &CircuitsResponse{}
+	resp.Body.Circuits = circuits
+	resp.Body.Count = len(circuits)
+	return resp, nil
+}
+
+func (h *CircuitHandler) GetCircuit(ctx context.Context, req *struct {
+	CircuitID string `path:"circuitId" doc:"Circuit or cable ID"`
+}) (*CircuitResponse, error) {
+	circuit, err := h.circuitService.GetCircuit(ctx, req.CircuitID)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get circuit", err)
+	}
+	if circuit == nil {
+		return nil, huma.Error404NotFound("Circuit not found")
+	}
+
+	return &CircuitResponse{Body: *circuit}, nil
+}
+
+func (h *CircuitHandler) SetCircuit(ctx context.Context, req *CircuitRequest) (*CircuitResponse, error) {
+	circuit, err := h.circuitService.SaveCircuit(ctx, topology.Circuit{
+		ID:          req.CircuitID,
+		Provider:    req.Body.Provider,
+		ADevice:     req.Body.ADevice,
+		APort:       req.Body.APort,
+		ZDevice:     req.Body.ZDevice,
+		ZPort:       req.Body.ZPort,
+		LinkID:      req.Body.LinkID,
+		Description: req.Body.Description,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCircuit) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to save circuit", "circuit_id", req.CircuitID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to save circuit", err)
+	}
+
+	return &CircuitResponse{Body: *circuit}, nil
+}
+
+func (h *CircuitHandler) DeleteCircuit(ctx context.Context, req *struct {
+	CircuitID string `path:"circuitId" doc:"Circuit or cable ID"`
+}) (*struct{}, error) {
+	if err := h.circuitService.DeleteCircuit(ctx, req.CircuitID); err != nil {
+		return nil, huma.Error500InternalServerError("Failed to delete circuit", err)
+	}
+
+	return &struct{}{}, nil
+}
+
+func (h *CircuitHandler) ImportCircuits(ctx context.Context, req *ImportCircuitsRequest) (*CircuitImportResponse, error) {
+	result, err := h.circuitService.ImportCircuits(ctx, bytes.NewReader(req.RawBody))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCircuit) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to import circuits", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to import circuits", err)
+	}
+
+	return &CircuitImportResponse{Body: *result}, nil
+}
+
+func (h *CircuitHandler) GetLinkDetail(ctx context.Context, req *struct {
+	LinkID string `path:"linkId" doc:"Link ID"`
+}) (*LinkDetailResponse, error) {
+	detail, err := h.circuitService.GetLinkDetail(ctx, req.LinkID)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get link", err)
+	}
+	if detail == nil {
+		return nil, huma.Error404NotFound("Link not found")
+	}
+
+	return &LinkDetailResponse{Body: *detail}, nil
+}
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
 	"github.com/servak/topology-manager/internal/service"
 	"github.com/servak/topology-manager/pkg/logger"
 )
@@ -25,9 +28,10 @@ func NewClassificationHandler(classificationService *service.ClassificationServi
 // Request/Response types for device classification
 type ClassifyDeviceRequest struct {
 	Body struct {
-		DeviceID   string `json:"device_id" doc:"Device ID to classify"`
-		Layer      int    `json:"layer" doc:"Network layer (0-5)"`
-		DeviceType string `json:"device_type" doc:"Device type (e.g., router, switch, server)"`
+		DeviceID   string `json:"device_id" example:"leaf-01" doc:"Device ID to classify"`
+		Layer      int    `json:"layer" example:"41" doc:"Hierarchy layer ID (see /api/v1/classification/layers)"`
+		DeviceType string `json:"device_type" example:"switch" doc:"Device type (e.g., router, switch, server)"`
+		Reason     string `json:"reason,omitempty" doc:"Reason recorded in the classification history"`
 	}
 }
 
@@ -35,16 +39,27 @@ type DeviceClassificationResponse struct {
 	Body classification.DeviceClassification
 }
 
-type UnclassifiedDevicesResponse struct {
+type ClassificationHistoryResponse struct {
 	Body struct {
-		Devices []UnclassifiedDevice `json:"devices"`
-		Count   int                  `json:"count"`
-		Total   int                  `json:"total"`
-		Limit   int                  `json:"limit"`
-		Offset  int                  `json:"offset"`
+		DeviceID string                                `json:"device_id"`
+		Changes  []classification.ClassificationChange `json:"changes"`
+		Count    int                                   `json:"count"`
 	}
 }
 
+type UnclassifiedDevicesResponse struct {
+	Body UnclassifiedDevicesBody
+}
+
+type UnclassifiedDevicesBody struct {
+	Devices    []UnclassifiedDevice `json:"devices"`
+	Count      int                  `json:"count"`
+	Total      int                  `json:"total"` // カーソル方式では数えない（-1）
+	Limit      int                  `json:"limit"`
+	Offset     int                  `json:"offset"`
+	NextCursor string               `json:"next_cursor,omitempty"` // カーソル方式: 次のページのカーソル（最後のページでは空）
+}
+
 type UnclassifiedDevice struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
@@ -55,28 +70,30 @@ type UnclassifiedDevice struct {
 // Request/Response types for classification rules
 type CreateRuleRequest struct {
 	Body struct {
-		Name          string                         `json:"name" doc:"Rule name"`
+		Name          string                         `json:"name" example:"Leaf switches" doc:"Rule name"`
 		Description   string                         `json:"description" doc:"Rule description"`
-		LogicOperator string                         `json:"logic" doc:"Logic operator for multiple conditions (AND, OR)" default:"AND"`
+		LogicOperator string                         `json:"logic" enum:"AND,OR" doc:"Logic operator for multiple conditions" default:"AND"`
 		Conditions    []classification.RuleCondition `json:"conditions" doc:"Multiple conditions for the rule"`
-		Layer         int                            `json:"layer" doc:"Target layer"`
-		DeviceType    string                         `json:"device_type" doc:"Target device type"`
+		Layer         int                            `json:"layer" example:"41" doc:"Target hierarchy layer ID"`
+		DeviceType    string                         `json:"device_type" example:"switch" doc:"Target device type"`
 		Priority      int                            `json:"priority" doc:"Rule priority (higher = applied first)"`
 		IsActive      bool                           `json:"is_active" doc:"Whether rule is active"`
+		Shadow        bool                           `json:"shadow,omitempty" doc:"Evaluate the rule without changing devices; the classifications it would make are recorded for the shadow report"`
 	}
 }
 
 type UpdateRuleRequest struct {
 	RuleID string `path:"rule_id" doc:"Rule ID"`
 	Body   struct {
-		Name          string                         `json:"name" doc:"Rule name"`
+		Name          string                         `json:"name" example:"Leaf switches" doc:"Rule name"`
 		Description   string                         `json:"description" doc:"Rule description"`
-		LogicOperator string                         `json:"logic" doc:"Logic operator for multiple conditions (AND, OR)" default:"AND"`
+		LogicOperator string                         `json:"logic" enum:"AND,OR" doc:"Logic operator for multiple conditions" default:"AND"`
 		Conditions    []classification.RuleCondition `json:"conditions" doc:"Multiple conditions for the rule"`
-		Layer         int                            `json:"layer" doc:"Target layer"`
-		DeviceType    string                         `json:"device_type" doc:"Target device type"`
+		Layer         int                            `json:"layer" example:"41" doc:"Target hierarchy layer ID"`
+		DeviceType    string                         `json:"device_type" example:"switch" doc:"Target device type"`
 		Priority      int                            `json:"priority" doc:"Rule priority (higher = applied first)"`
 		IsActive      bool                           `json:"is_active" doc:"Whether rule is active"`
+		Shadow        bool                           `json:"shadow,omitempty" doc:"Evaluate the rule without changing devices; the classifications it would make are recorded for the shadow report"`
 	}
 }
 
@@ -84,10 +101,17 @@ type ClassificationRuleResponse struct {
 	Body classification.ClassificationRule
 }
 
+type RuleSchemaResponse struct {
+	Body classification.RuleSchema
+}
+
 type ClassificationRulesResponse struct {
 	Body struct {
-		Rules []classification.ClassificationRule `json:"rules"`
-		Count int                                 `json:"count"`
+		Rules  []classification.ClassificationRule `json:"rules"`
+		Count  int                                 `json:"count"`
+		Total  int                                 `json:"total" doc:"Number of rules matching the search"`
+		Limit  int                                 `json:"limit"`
+		Offset int                                 `json:"offset"`
 	}
 }
 
@@ -103,15 +127,73 @@ type ClassificationSuggestionsResponse struct {
 	Body struct {
 		Suggestions []classification.ClassificationSuggestion `json:"suggestions"`
 		Count       int                                       `json:"count"`
+		Total       int                                       `json:"total" doc:"Number of suggestions matching the search"`
+		Limit       int                                       `json:"limit"`
+		Offset      int                                       `json:"offset"`
+	}
+}
+
+// ListParams selects a page of classification rules or suggestions
+type ListParams struct {
+	Search  string `query:"q" doc:"Only list rules whose name or description contains this text (case-insensitive)"`
+	Reverse bool   `query:"reverse" default:"false" doc:"Reverse the sort order"`
+	Limit   int    `query:"limit" default:"0" minimum:"0" maximum:"1000" doc:"Maximum number of items to return (0 = all)"`
+	Offset  int    `query:"offset" default:"0" minimum:"0" doc:"Number of matching items to skip"`
+}
+
+// query converts the parameters into a list query sorted by sort
+func (p ListParams) query(sort string) classification.ListQuery {
+	return classification.ListQuery{
+		Search:  strings.TrimSpace(p.Search),
+		Sort:    classification.ListSort(sort),
+		Reverse: p.Reverse,
+		Limit:   p.Limit,
+		Offset:  p.Offset,
 	}
 }
 
 type SuggestionActionRequest struct {
 	Body struct {
-		Action string `json:"action" doc:"Action to take (accept, reject)"`
+		Action string `json:"action" enum:"accept,reject" doc:"Action to take"`
+	}
+}
+
+// BulkSuggestionActionRequest accepts or rejects the pending suggestions matching the filters
+type BulkSuggestionActionRequest struct {
+	Body struct {
+		Action        string  `json:"action" enum:"accept,reject" doc:"Action to take"`
+		MinConfidence float64 `json:"min_confidence,omitempty" example:"0.9" doc:"Only suggestions with at least this confidence (0-1)"`
+		DeviceType    string  `json:"device_type,omitempty" example:"switch" doc:"Only suggestions whose rule sets this device type"`
 	}
 }
 
+type BulkSuggestionActionResponse struct {
+	Body classification.BulkSuggestionResult
+}
+
+// Request/Response types for coverage gates
+type CoverageRequest struct {
+	Threshold float64 `query:"threshold" doc:"Required classification coverage in percent (0-100, omitted = classification.coverage.threshold)"`
+	Types     string  `query:"types" doc:"Comma-separated device types in scope (omitted = classification.coverage.device_types, empty = all devices)"`
+
+	hasThreshold bool
+	hasTypes     bool
+}
+
+// Resolve records which gate parameters the request sets, since an explicit threshold=0 differs
+// from an omitted one
+func (r *CoverageRequest) Resolve(ctx huma.Context) []error {
+	url := ctx.URL()
+	query := url.Query()
+	r.hasThreshold = query.Has("threshold")
+	r.hasTypes = query.Has("types")
+	return nil
+}
+
+type CoverageResponse struct {
+	Body classification.CoverageReport
+}
+
 // Request/Response types for hierarchy layers
 type HierarchyLayersResponse struct {
 	Body struct {
@@ -129,7 +211,7 @@ type CreateHierarchyLayerRequest struct {
 		Name        string `json:"name" doc:"Layer name"`
 		Description string `json:"description" doc:"Layer description"`
 		Order       int    `json:"order" doc:"Display order"`
-		Color       string `json:"color" doc:"Display color (hex format)"`
+		Color       string `json:"color" example:"#2ecc71" doc:"Display color (hex format)"`
 	}
 }
 
@@ -139,7 +221,7 @@ type UpdateHierarchyLayerRequest struct {
 		Name        string `json:"name" doc:"Layer name"`
 		Description string `json:"description" doc:"Layer description"`
 		Order       int    `json:"order" doc:"Display order"`
-		Color       string `json:"color" doc:"Display color (hex format)"`
+		Color       string `json:"color" example:"#2ecc71" doc:"Display color (hex format)"`
 	}
 }
 
@@ -169,8 +251,10 @@ func (h *ClassificationHandler) RegisterRoutes(api huma.API) {
 		Method:      http.MethodGet,
 		Path:        "/api/v1/classification/devices/unclassified",
 		Summary:     "List unclassified devices",
-		Description: "Get all devices that haven't been classified yet",
-		Tags:        []string{"classification"},
+		Description: "Get all devices that haven't been classified yet. " +
+			"With pagination=cursor, devices are listed in ID order and each page returns next_cursor, " +
+			"passed as cursor to get the following page without counting or skipping the earlier devices.",
+		Tags: []string{"classification"},
 	}, h.ListUnclassifiedDevices)
 
 	huma.Register(api, huma.Operation{
@@ -191,6 +275,24 @@ func (h *ClassificationHandler) RegisterRoutes(api huma.API) {
 		Tags:        []string{"classification"},
 	}, h.DeleteDeviceClassification)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "get-device-classification-history",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/classification/devices/{device_id}/history",
+		Summary:     "Get device classification history",
+		Description: "List previous classifications of a device with the source and reason of each change, newest first",
+		Tags:        []string{"classification"},
+	}, h.GetClassificationHistory)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "check-classification-coverage",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/classification/coverage",
+		Summary:     "Check classification coverage",
+		Description: "Evaluate whether classification coverage meets the threshold and list offending devices",
+		Tags:        []string{"classification"},
+	}, h.CheckCoverage)
+
 	// Classification rules endpoints
 	huma.Register(api, huma.Operation{
 		OperationID: "create-classification-rule",
@@ -206,10 +308,19 @@ func (h *ClassificationHandler) RegisterRoutes(api huma.API) {
 		Method:      http.MethodGet,
 		Path:        "/api/v1/classification/rules",
 		Summary:     "List classification rules",
-		Description: "Get all classification rules",
+		Description: "Get a page of the classification rules with their quality: how many devices each rule classified and how many of those users later overrode. Search matches the rule name and description.",
 		Tags:        []string{"classification"},
 	}, h.ListClassificationRules)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "get-classification-rule-schema",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/classification/rules/schema",
+		Summary:     "Get rule condition schema",
+		Description: "List the fields rule conditions can match, including every metadata key found on devices (metadata.<key>), the operators each field supports and its most common values, so rule builders do not hardcode them.",
+		Tags:        []string{"classification"},
+	}, h.GetRuleSchema)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "update-classification-rule",
 		Method:      http.MethodPut,
@@ -252,7 +363,7 @@ func (h *ClassificationHandler) RegisterRoutes(api huma.API) {
 		Method:      http.MethodGet,
 		Path:        "/api/v1/classification/suggestions",
 		Summary:     "List rule suggestions",
-		Description: "Get all pending rule suggestions",
+		Description: "Get a page of the pending rule suggestions, searchable by the name and description of the suggested rule",
 		Tags:        []string{"classification"},
 	}, h.ListRuleSuggestions)
 
@@ -265,6 +376,25 @@ func (h *ClassificationHandler) RegisterRoutes(api huma.API) {
 		Tags:        []string{"classification"},
 	}, h.HandleSuggestion)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "list-suggestion-affected-devices",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/classification/suggestions/{suggestion_id}/affected-devices",
+		Summary:     "List devices affected by a rule suggestion",
+		Description: "Get a page of the devices the suggested rule would classify, with the total count",
+		Tags:        []string{"classification"},
+	}, h.ListSuggestionAffectedDevices)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "bulk-handle-suggestions",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/classification/suggestions/bulk-action",
+		Summary:     "Accept or reject rule suggestions in bulk",
+		Description: "Accept or reject every pending suggestion matching the filters in one transaction. " +
+			"Suggestions that cannot be resolved are listed in failed without undoing the others.",
+		Tags: []string{"classification"},
+	}, h.HandleSuggestionsBulk)
+
 	// Hierarchy layers endpoints
 	huma.Register(api, huma.Operation{
 		OperationID: "list-hierarchy-layers",
@@ -310,15 +440,18 @@ func (h *ClassificationHandler) RegisterRoutes(api huma.API) {
 		Description: "Delete a hierarchy layer",
 		Tags:        []string{"classification"},
 	}, h.DeleteHierarchyLayer)
+
+	h.registerHardwareCatalogRoutes(api)
+	h.registerHardwareLifecycleRoutes(api)
+	h.registerShadowRoutes(api)
 }
 
 // Device classification handlers
 
 func (h *ClassificationHandler) ClassifyDevice(ctx context.Context, req *ClassifyDeviceRequest) (*DeviceClassificationResponse, error) {
-	// TODO: Get user ID from context/auth
-	userID := "admin"
+	userID := requestUser(ctx)
 
-	err := h.classificationService.ClassifyDevice(ctx, req.Body.DeviceID, req.Body.Layer, req.Body.DeviceType, userID)
+	err := h.classificationService.ClassifyDevice(ctx, req.Body.DeviceID, req.Body.Layer, req.Body.DeviceType, userID, req.Body.Reason)
 	if err != nil {
 		return nil, huma.Error400BadRequest("Failed to classify device", err)
 	}
@@ -346,8 +479,10 @@ func (h *ClassificationHandler) GetDeviceClassification(ctx context.Context, req
 }
 
 func (h *ClassificationHandler) ListUnclassifiedDevices(ctx context.Context, req *struct {
-	Limit  int `query:"limit" doc:"Maximum number of devices to return (default: 100, max: 1000)" default:"100"`
-	Offset int `query:"offset" doc:"Number of devices to skip (default: 0)" default:"0"`
+	Limit      int    `query:"limit" doc:"Maximum number of devices to return (default: 100, max: 1000)" default:"100"`
+	Offset     int    `query:"offset" doc:"Number of devices to skip (default: 0, offset pagination only)" default:"0"`
+	Pagination string `query:"pagination" enum:"offset,cursor" default:"offset" doc:"offset pages by offset with a total; cursor pages by next_cursor in ID order without counting"`
+	Cursor     string `query:"cursor" doc:"next_cursor of the previous page (implies pagination=cursor)"`
 }) (*UnclassifiedDevicesResponse, error) {
 	// デフォルト値とバリデーション
 	limit := req.Limit
@@ -362,36 +497,35 @@ func (h *ClassificationHandler) ListUnclassifiedDevices(ctx context.Context, req
 		offset = 0
 	}
 
-	devices, total, err := h.classificationService.ListUnclassifiedDevicesWithPagination(ctx, limit, offset)
+	body := UnclassifiedDevicesBody{Limit: limit}
+	var devices []topology.Device
+	var err error
+	if req.Pagination == "cursor" || req.Cursor != "" {
+		devices, body.NextCursor, err = h.classificationService.ListUnclassifiedDevicesByCursor(ctx, req.Cursor, limit)
+		body.Total = -1
+	} else {
+		devices, body.Total, err = h.classificationService.ListUnclassifiedDevicesWithPagination(ctx, limit, offset)
+		body.Offset = offset
+	}
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
 		return nil, huma.Error500InternalServerError("Failed to list unclassified devices", err)
 	}
 
-	unclassifiedDevices := make([]UnclassifiedDevice, len(devices))
+	body.Devices = make([]UnclassifiedDevice, len(devices))
 	for i, device := range devices {
-		unclassifiedDevices[i] = UnclassifiedDevice{
+		body.Devices[i] = UnclassifiedDevice{
 			ID:       device.ID,
 			Name:     device.ID, // DeviceにNameがないため、IDを使用
 			Type:     device.Type,
 			Hardware: device.Hardware,
 		}
 	}
+	body.Count = len(body.Devices)
 
-	return &UnclassifiedDevicesResponse{
-		Body: struct {
-			Devices []UnclassifiedDevice `json:"devices"`
-			Count   int                  `json:"count"`
-			Total   int                  `json:"total"`
-			Limit   int                  `json:"limit"`
-			Offset  int                  `json:"offset"`
-		}{
-			Devices: unclassifiedDevices,
-			Count:   len(unclassifiedDevices),
-			Total:   total,
-			Limit:   limit,
-			Offset:  offset,
-		},
-	}, nil
+	return &UnclassifiedDevicesResponse{Body: body}, nil
 }
 
 func (h *ClassificationHandler) ListDeviceClassifications(ctx context.Context, req *struct{}) (*DeviceClassificationsResponse, error) {
@@ -422,6 +556,64 @@ func (h *ClassificationHandler) DeleteDeviceClassification(ctx context.Context,
 	return &struct{}{}, nil
 }
 
+func (h *ClassificationHandler) GetClassificationHistory(ctx context.Context, req *struct {
+	DeviceID string `path:"device_id" doc:"Device ID"`
+	Limit    int    `query:"limit" doc:"Maximum number of changes to return (max: 1000)" default:"100"`
+}) (*ClassificationHistoryResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	} else if limit > 1000 {
+		limit = 1000
+	}
+
+	changes, err := h.classificationService.GetClassificationHistory(ctx, req.DeviceID, limit)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get classification history", err)
+	}
+	if changes == nil {
+		changes = []classification.ClassificationChange{}
+	}
+
+	resp := &ClassificationHistoryResponse{}
+	resp.Body.DeviceID = req.DeviceID
+	resp.Body.Changes = changes
+	resp.Body.Count = len(changes)
+	return resp, nil
+}
+
+func (h *ClassificationHandler) CheckCoverage(ctx context.Context, req *CoverageRequest) (*CoverageResponse, error) {
+	// 省略されたパラメータは設定のゲートを使う
+	gate := h.classificationService.CoverageGate()
+	if req.hasThreshold {
+		gate.Threshold = req.Threshold
+	}
+	if req.hasTypes {
+		gate.DeviceTypes = splitCommaList(req.Types)
+	}
+	if gate.Threshold < 0 || gate.Threshold > 100 {
+		return nil, huma.Error400BadRequest("Threshold must be between 0 and 100")
+	}
+
+	report, err := h.classificationService.EvaluateCoverage(ctx, gate)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to evaluate classification coverage", err)
+	}
+
+	return &CoverageResponse{Body: *report}, nil
+}
+
+// splitCommaList splits a comma-separated query value, dropping empty entries
+func splitCommaList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // Classification rules handlers
 
 func (h *ClassificationHandler) CreateClassificationRule(ctx context.Context, req *CreateRuleRequest) (*ClassificationRuleResponse, error) {
@@ -444,7 +636,8 @@ func (h *ClassificationHandler) CreateClassificationRule(ctx context.Context, re
 		DeviceType:    req.Body.DeviceType,
 		Priority:      req.Body.Priority,
 		IsActive:      req.Body.IsActive,
-		CreatedBy:     "admin", // TODO: Get from auth context
+		Shadow:        req.Body.Shadow,
+		CreatedBy:     requestUser(ctx),
 	}
 
 	err := h.classificationService.SaveClassificationRule(ctx, rule)
@@ -476,10 +669,14 @@ func (h *ClassificationHandler) UpdateClassificationRule(ctx context.Context, re
 		DeviceType:    req.Body.DeviceType,
 		Priority:      req.Body.Priority,
 		IsActive:      req.Body.IsActive,
+		Shadow:        req.Body.Shadow,
 	}
 
 	err := h.classificationService.UpdateClassificationRule(ctx, rule)
 	if err != nil {
+		if errors.Is(err, service.ErrConfigManagedRule) {
+			return nil, huma.Error409Conflict(err.Error(), err)
+		}
 		return nil, huma.Error500InternalServerError("Failed to update classification rule", err)
 	}
 
@@ -500,27 +697,44 @@ func (h *ClassificationHandler) DeleteClassificationRule(ctx context.Context, re
 }) (*struct{}, error) {
 	err := h.classificationService.DeleteClassificationRule(ctx, req.RuleID)
 	if err != nil {
+		if errors.Is(err, service.ErrConfigManagedRule) {
+			return nil, huma.Error409Conflict(err.Error(), err)
+		}
 		return nil, huma.Error500InternalServerError("Failed to delete classification rule", err)
 	}
 
 	return &struct{}{}, nil
 }
 
-func (h *ClassificationHandler) ListClassificationRules(ctx context.Context, req *struct{}) (*ClassificationRulesResponse, error) {
-	rules, err := h.classificationService.ListClassificationRules(ctx)
+func (h *ClassificationHandler) ListClassificationRules(ctx context.Context, req *struct {
+	Sort string `query:"sort" enum:"priority,confidence,created_at,quality" default:"priority" doc:"Order of the rules: highest priority, highest confidence or newest first; quality lists the rules users override most first"`
+	ListParams
+}) (*ClassificationRulesResponse, error) {
+	rules, total, err := h.classificationService.ListClassificationRules(ctx, req.ListParams.query(req.Sort))
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidListQuery) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
 		return nil, huma.Error500InternalServerError("Failed to list classification rules", err)
 	}
 
-	return &ClassificationRulesResponse{
-		Body: struct {
-			Rules []classification.ClassificationRule `json:"rules"`
-			Count int                                 `json:"count"`
-		}{
-			Rules: rules,
-			Count: len(rules),
-		},
-	}, nil
+	resp := &ClassificationRulesResponse{}
+	resp.Body.Rules = rules
+	resp.Body.Count = len(rules)
+	resp.Body.Total = total
+	resp.Body.Limit = req.Limit
+	resp.Body.Offset = req.Offset
+	return resp, nil
+}
+
+func (h *ClassificationHandler) GetRuleSchema(ctx context.Context, req *struct {
+	Samples int `query:"samples" minimum:"0" maximum:"100" default:"10" doc:"Number of sample values per field"`
+}) (*RuleSchemaResponse, error) {
+	schema, err := h.classificationService.GetRuleSchema(ctx, req.Samples)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get rule schema", err)
+	}
+	return &RuleSchemaResponse{Body: *schema}, nil
 }
 
 func (h *ClassificationHandler) ApplyClassificationRules(ctx context.Context, req *struct{}) (*struct{}, error) {
@@ -552,38 +766,69 @@ func (h *ClassificationHandler) GenerateRuleSuggestions(ctx context.Context, req
 		return nil, huma.Error500InternalServerError("Failed to generate rule suggestions", err)
 	}
 
-	return &ClassificationSuggestionsResponse{
-		Body: struct {
-			Suggestions []classification.ClassificationSuggestion `json:"suggestions"`
-			Count       int                                       `json:"count"`
-		}{
-			Suggestions: suggestions,
-			Count:       len(suggestions),
-		},
-	}, nil
+	resp := &ClassificationSuggestionsResponse{}
+	resp.Body.Suggestions = suggestions
+	resp.Body.Count = len(suggestions)
+	resp.Body.Total = len(suggestions)
+	return resp, nil
 }
 
-func (h *ClassificationHandler) ListRuleSuggestions(ctx context.Context, req *struct{}) (*ClassificationSuggestionsResponse, error) {
-	suggestions, err := h.classificationService.ListPendingSuggestions(ctx)
+func (h *ClassificationHandler) ListRuleSuggestions(ctx context.Context, req *struct {
+	Sort string `query:"sort" enum:"confidence,priority,created_at" default:"confidence" doc:"Order of the suggestions: highest confidence, highest rule priority or newest first"`
+	ListParams
+}) (*ClassificationSuggestionsResponse, error) {
+	suggestions, total, err := h.classificationService.ListPendingSuggestions(ctx, req.ListParams.query(req.Sort))
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidListQuery) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
 		return nil, huma.Error500InternalServerError("Failed to list rule suggestions", err)
 	}
 
-	return &ClassificationSuggestionsResponse{
-		Body: struct {
-			Suggestions []classification.ClassificationSuggestion `json:"suggestions"`
-			Count       int                                       `json:"count"`
-		}{
-			Suggestions: suggestions,
-			Count:       len(suggestions),
-		},
-	}, nil
+	resp := &ClassificationSuggestionsResponse{}
+	resp.Body.Suggestions = suggestions
+	resp.Body.Count = len(suggestions)
+	resp.Body.Total = total
+	resp.Body.Limit = req.Limit
+	resp.Body.Offset = req.Offset
+	return resp, nil
+}
+
+// SuggestionAffectedDevicesResponse is a page of the devices affected by a suggestion
+type SuggestionAffectedDevicesResponse struct {
+	Body struct {
+		DeviceIDs []string `json:"device_ids"`
+		Total     int      `json:"total"`
+		Limit     int      `json:"limit"`
+		Offset    int      `json:"offset"`
+	}
+}
+
+func (h *ClassificationHandler) ListSuggestionAffectedDevices(ctx context.Context, req *struct {
+	SuggestionID string `path:"suggestion_id" doc:"Suggestion ID"`
+	Limit        int    `query:"limit" default:"100" minimum:"1" maximum:"1000" doc:"Maximum number of devices to return"`
+	Offset       int    `query:"offset" default:"0" minimum:"0" doc:"Number of devices to skip"`
+}) (*SuggestionAffectedDevicesResponse, error) {
+	deviceIDs, total, err := h.classificationService.ListSuggestionAffectedDevices(ctx, req.SuggestionID, req.Limit, req.Offset)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to list affected devices", err)
+	}
+	if deviceIDs == nil {
+		return nil, huma.Error404NotFound("Suggestion not found")
+	}
+
+	resp := &SuggestionAffectedDevicesResponse{}
+	resp.Body.DeviceIDs = deviceIDs
+	resp.Body.Total = total
+	resp.Body.Limit = req.Limit
+	resp.Body.Offset = req.Offset
+	return resp, nil
 }
 
 func (h *ClassificationHandler) HandleSuggestion(ctx context.Context, req *struct {
 	SuggestionID string `path:"suggestion_id" doc:"Suggestion ID"`
 	Body         struct {
-		Action string `json:"action" doc:"Action to take (accept, reject)"`
+		Action string `json:"action" enum:"accept,reject" doc:"Action to take"`
 	}
 }) (*struct{}, error) {
 	switch req.Body.Action {
@@ -604,6 +849,29 @@ func (h *ClassificationHandler) HandleSuggestion(ctx context.Context, req *struc
 	return &struct{}{}, nil
 }
 
+func (h *ClassificationHandler) HandleSuggestionsBulk(ctx context.Context, req *BulkSuggestionActionRequest) (*BulkSuggestionActionResponse, error) {
+	filter := classification.SuggestionFilter{
+		MinConfidence: req.Body.MinConfidence,
+		DeviceType:    req.Body.DeviceType,
+	}
+
+	result, err := h.classificationService.HandleSuggestionsBulk(ctx, req.Body.Action, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSuggestionAction):
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		case errors.Is(err, service.ErrBulkSuggestionsUnsupported):
+			return nil, huma.Error501NotImplemented(err.Error())
+		}
+		h.logger.Error("Failed to handle suggestions in bulk", "action", req.Body.Action, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to handle suggestions", err)
+	}
+
+	h.logger.Info("Handled suggestions in bulk", "action", result.Action, "matched", result.Matched,
+		"accepted", result.Accepted, "rejected", result.Rejected, "failed", len(result.Failed))
+	return &BulkSuggestionActionResponse{Body: *result}, nil
+}
+
 // Hierarchy layers handlers
 
 func (h *ClassificationHandler) ListHierarchyLayers(ctx context.Context, req *struct{}) (*HierarchyLayersResponse, error) {
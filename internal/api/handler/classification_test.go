@@ -11,65 +11,64 @@ import (
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
 	"github.com/go-chi/chi/v5"
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
 	"github.com/servak/topology-manager/internal/service"
 	"github.com/servak/topology-manager/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func setupClassificationHandler(t *testing.T) (*ClassificationHandler, *testutil.TestSetup, huma.API) {
+func setupClassificationHandler(t *testing.T) (*service.ClassificationService, *testutil.TestSetup, http.Handler) {
 	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
 	setup.SeedTestData(t)
 
-	// Create services
-	classificationService := service.NewClassificationService(setup.Repo, setup.Logger)
-
-	// Create handler
+	classificationService := service.NewClassificationService(setup.Repo, setup.Repo)
 	handler := NewClassificationHandler(classificationService, setup.Logger)
 
-	// Create test API
 	router := chi.NewRouter()
-	config := huma.DefaultConfig("Test API", "1.0.0")
-	api := humachi.New(router, config)
-
-	// Register routes
+	api := humachi.New(router, huma.DefaultConfig("Test API", "1.0.0"))
 	handler.RegisterRoutes(api)
 
-	return handler, setup, api
+	return classificationService, setup, router
 }
 
-func TestClassificationHandler_ListHierarchyLayers(t *testing.T) {
-	handler, setup, api := setupClassificationHandler(t)
-	defer setup.Cleanup()
+func serveJSON(t *testing.T, router http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
 
-	// Create test request
-	req := httptest.NewRequest("GET", "/api/v1/classification/layers", nil)
+	var req *http.Request
+	if body == nil {
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		jsonData, err := json.Marshal(body)
+		require.NoError(t, err)
+		req = httptest.NewRequest(method, path, strings.NewReader(string(jsonData)))
+		req.Header.Set("Content-Type", "application/json")
+	}
 	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
 
-	// Execute request
-	api.ServeHTTP(resp, req)
+func TestClassificationHandler_ListHierarchyLayers(t *testing.T) {
+	_, _, router := setupClassificationHandler(t)
 
-	// Check response
-	assert.Equal(t, http.StatusOK, resp.Code)
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/classification/layers", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
 
-	// Parse response body
 	var response struct {
 		Layers []map[string]interface{} `json:"layers"`
 		Count  int                      `json:"count"`
 	}
-	err := json.Unmarshal(resp.Body.Bytes(), &response)
-	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
 
-	// Should have default layers from migration
-	assert.GreaterOrEqual(t, response.Count, 1)
-	assert.GreaterOrEqual(t, len(response.Layers), 1)
-
-	// Check that we have the expected Core layer
+	assert.Equal(t, len(response.Layers), response.Count)
 	foundCore := false
 	for _, layer := range response.Layers {
 		if name, ok := layer["name"].(string); ok && name == "Core" {
 			foundCore = true
-			assert.Equal(t, "Core network layer - backbone switches and routers", layer["description"])
 			break
 		}
 	}
@@ -77,40 +76,21 @@ func TestClassificationHandler_ListHierarchyLayers(t *testing.T) {
 }
 
 func TestClassificationHandler_ListClassificationRules(t *testing.T) {
-	handler, setup, api := setupClassificationHandler(t)
-	defer setup.Cleanup()
+	classificationService, _, router := setupClassificationHandler(t)
 
-	// Add a test rule first
-	ctx := context.Background()
 	testRule := testutil.CreateTestClassificationRule("test-rule-001", "Test API Rule")
-	// Create classification service to add test rule
-	classificationService := service.NewClassificationService(setup.Repo, setup.Logger)
-	err := classificationService.SaveClassificationRule(ctx, testRule)
-	require.NoError(t, err)
+	require.NoError(t, classificationService.SaveClassificationRule(context.Background(), testRule))
 
-	// Create test request
-	req := httptest.NewRequest("GET", "/api/v1/classification/rules", nil)
-	resp := httptest.NewRecorder()
-
-	// Execute request
-	api.ServeHTTP(resp, req)
-
-	// Check response
-	assert.Equal(t, http.StatusOK, resp.Code)
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/classification/rules", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
 
-	// Parse response body
 	var response struct {
 		Rules []map[string]interface{} `json:"rules"`
 		Count int                      `json:"count"`
+		Total int                      `json:"total"`
 	}
-	err = json.Unmarshal(resp.Body.Bytes(), &response)
-	require.NoError(t, err)
-
-	// Should have at least our test rule
-	assert.GreaterOrEqual(t, response.Count, 1)
-	assert.GreaterOrEqual(t, len(response.Rules), 1)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
 
-	// Find our test rule
 	foundTestRule := false
 	for _, rule := range response.Rules {
 		if id, ok := rule["id"].(string); ok && id == "test-rule-001" {
@@ -121,23 +101,20 @@ func TestClassificationHandler_ListClassificationRules(t *testing.T) {
 		}
 	}
 	assert.True(t, foundTestRule, "Should find test rule in response")
+	assert.Equal(t, response.Total, response.Count)
 }
 
 func TestClassificationHandler_ListUnclassifiedDevices(t *testing.T) {
-	handler, setup, api := setupClassificationHandler(t)
-	defer setup.Cleanup()
+	_, setup, router := setupClassificationHandler(t)
 
-	// Create test request with query parameters
-	req := httptest.NewRequest("GET", "/api/v1/classification/devices/unclassified?limit=10&offset=0", nil)
-	resp := httptest.NewRecorder()
-
-	// Execute request
-	api.ServeHTTP(resp, req)
+	device := testutil.CreateTestDevice("new-001")
+	device.LayerID = nil
+	device.ClassifiedBy = ""
+	require.NoError(t, setup.Repo.BulkAddDevices(context.Background(), []topology.Device{device}))
 
-	// Check response
-	assert.Equal(t, http.StatusOK, resp.Code)
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/classification/devices/unclassified?limit=10&offset=0", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
 
-	// Parse response body
 	var response struct {
 		Devices []map[string]interface{} `json:"devices"`
 		Count   int                      `json:"count"`
@@ -145,63 +122,132 @@ func TestClassificationHandler_ListUnclassifiedDevices(t *testing.T) {
 		Limit   int                      `json:"limit"`
 		Offset  int                      `json:"offset"`
 	}
-	err := json.Unmarshal(resp.Body.Bytes(), &response)
-	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
 
-	// Should have our test devices (they're unclassified by default)
-	assert.GreaterOrEqual(t, response.Count, 3)
-	assert.GreaterOrEqual(t, len(response.Devices), 3)
+	// シードデータのデバイスは分類済み
+	assert.Equal(t, 1, response.Total)
+	require.Len(t, response.Devices, 1)
+	assert.Equal(t, "new-001", response.Devices[0]["id"])
+	assert.NotEmpty(t, response.Devices[0]["hardware"], "Device should have hardware")
 	assert.Equal(t, 10, response.Limit)
 	assert.Equal(t, 0, response.Offset)
+}
 
-	// Check that devices have expected properties
-	deviceIDs := make(map[string]bool)
-	for _, device := range response.Devices {
-		if id, ok := device["id"].(string); ok {
-			deviceIDs[id] = true
-			assert.NotEmpty(t, device["type"], "Device should have type")
-			assert.NotEmpty(t, device["hardware"], "Device should have hardware")
-		}
+func TestClassificationHandler_ListUnclassifiedDevicesByCursor(t *testing.T) {
+	_, setup, router := setupClassificationHandler(t)
+
+	var devices []topology.Device
+	for _, id := range []string{"new-001", "new-002", "new-003"} {
+		device := testutil.CreateTestDevice(id)
+		device.LayerID = nil
+		device.ClassifiedBy = ""
+		devices = append(devices, device)
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(context.Background(), devices))
+
+	type page struct {
+		Devices    []map[string]interface{} `json:"devices"`
+		Count      int                      `json:"count"`
+		NextCursor string                   `json:"next_cursor"`
+	}
+	get := func(path string) page {
+		t.Helper()
+		resp := serveJSON(t, router, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var response page
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response
 	}
 
-	// Should find our seeded test devices
-	assert.True(t, deviceIDs["device-001"] || deviceIDs["device-002"] || deviceIDs["device-003"], 
-		"Should find at least one of our test devices")
+	first := get("/api/v1/classification/devices/unclassified?pagination=cursor&limit=2")
+	require.Equal(t, 2, first.Count)
+	assert.Equal(t, "new-001", first.Devices[0]["id"])
+	assert.Equal(t, "new-002", first.Devices[1]["id"])
+	require.NotEmpty(t, first.NextCursor)
+
+	last := get("/api/v1/classification/devices/unclassified?limit=2&cursor=" + first.NextCursor)
+	require.Equal(t, 1, last.Count)
+	assert.Equal(t, "new-003", last.Devices[0]["id"])
+	assert.Empty(t, last.NextCursor)
+
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/classification/devices/unclassified?cursor=%21%21", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
 }
 
-func TestClassificationHandler_ListClassifiedDevices(t *testing.T) {
-	handler, setup, api := setupClassificationHandler(t)
-	defer setup.Cleanup()
+func TestClassificationHandler_ClassifyDevice(t *testing.T) {
+	_, setup, router := setupClassificationHandler(t)
 
-	// Create test request
-	req := httptest.NewRequest("GET", "/api/v1/classification/devices/classified", nil)
-	resp := httptest.NewRecorder()
+	resp := serveJSON(t, router, http.MethodPost, "/api/v1/classification/devices", map[string]interface{}{
+		"device_id":   "device-001",
+		"layer":       2,
+		"device_type": "router",
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
 
-	// Execute request
-	api.ServeHTTP(resp, req)
+	device, err := setup.Repo.GetDevice(context.Background(), "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, "router", device.DeviceType)
+	require.NotNil(t, device.LayerID)
+	assert.Equal(t, 2, *device.LayerID)
 
-	// Check response
-	assert.Equal(t, http.StatusOK, resp.Code)
+	resp = serveJSON(t, router, http.MethodGet, "/api/v1/classification/devices/classified", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
 
-	// Parse response body
 	var response struct {
 		Classifications []map[string]interface{} `json:"classifications"`
 		Count           int                      `json:"count"`
 	}
-	err := json.Unmarshal(resp.Body.Bytes(), &response)
-	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+
+	found := false
+	for _, item := range response.Classifications {
+		if item["device_id"] == "device-001" {
+			found = true
+			assert.Equal(t, "router", item["device_type"])
+		}
+	}
+	assert.True(t, found, "Should list the manual classification")
+}
 
-	// Initially should have 0 classified devices (all test devices are unclassified)
-	assert.Equal(t, 0, response.Count)
-	assert.Equal(t, 0, len(response.Classifications))
+func TestClassificationHandler_GetClassificationHistory(t *testing.T) {
+	_, _, router := setupClassificationHandler(t)
+
+	for _, deviceType := range []string{"router", "firewall"} {
+		resp := serveJSON(t, router, http.MethodPost, "/api/v1/classification/devices", map[string]interface{}{
+			"device_id":   "device-001",
+			"layer":       2,
+			"device_type": deviceType,
+			"reason":      "replaced by a " + deviceType,
+		})
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	}
+
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/classification/devices/device-001/history?limit=1", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var response struct {
+		DeviceID string                                `json:"device_id"`
+		Changes  []classification.ClassificationChange `json:"changes"`
+		Count    int                                   `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+	assert.Equal(t, "device-001", response.DeviceID)
+	require.Equal(t, 1, response.Count)
+	assert.Equal(t, "router", response.Changes[0].PreviousDeviceType)
+	assert.Equal(t, "firewall", response.Changes[0].DeviceType)
+	assert.Equal(t, "replaced by a firewall", response.Changes[0].Reason)
+	assert.Equal(t, classification.ChangeSourceUser, response.Changes[0].Source)
+
+	// 履歴のないデバイスは空の一覧を返す
+	resp = serveJSON(t, router, http.MethodGet, "/api/v1/classification/devices/device-003/history", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	assert.Contains(t, resp.Body.String(), `"changes":[]`)
 }
 
 func TestClassificationHandler_CreateClassificationRule(t *testing.T) {
-	handler, setup, api := setupClassificationHandler(t)
-	defer setup.Cleanup()
+	_, _, router := setupClassificationHandler(t)
 
-	// Create test rule data
-	ruleData := map[string]interface{}{
+	resp := serveJSON(t, router, http.MethodPost, "/api/v1/classification/rules", map[string]interface{}{
 		"name":        "API Test Rule",
 		"description": "Rule created via API test",
 		"conditions": []map[string]interface{}{
@@ -211,107 +257,147 @@ func TestClassificationHandler_CreateClassificationRule(t *testing.T) {
 				"value":    "Cisco",
 			},
 		},
-		"logic_operator": "AND",
-		"layer":          2,
-		"device_type":    "cisco-switch",
-		"priority":       150,
-		"is_active":      true,
-		"created_by":     "api-test",
-	}
+		"logic":       "AND",
+		"layer":       2,
+		"device_type": "cisco-switch",
+		"priority":    150,
+		"is_active":   true,
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
 
-	// Convert to JSON
-	jsonData, err := json.Marshal(ruleData)
-	require.NoError(t, err)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+	assert.Equal(t, "API Test Rule", response["name"])
+	assert.Equal(t, true, response["is_active"])
 
-	// Create test request
-	req := httptest.NewRequest("POST", "/api/v1/classification/rules", strings.NewReader(string(jsonData)))
-	req.Header.Set("Content-Type", "application/json")
-	resp := httptest.NewRecorder()
+	resp = serveJSON(t, router, http.MethodGet, "/api/v1/classification/rules?q=API", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
 
-	// Execute request
-	api.ServeHTTP(resp, req)
+	var rules struct {
+		Total int `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &rules))
+	assert.Equal(t, 1, rules.Total, "The created rule should be stored")
+}
 
-	// Check response
-	assert.Equal(t, http.StatusCreated, resp.Code)
+func TestClassificationHandler_ConfigManagedRules(t *testing.T) {
+	classificationService, _, router := setupClassificationHandler(t)
 
-	// Parse response
-	var response map[string]interface{}
-	err = json.Unmarshal(resp.Body.Bytes(), &response)
+	cfg := &config.Config{}
+	cfg.Hierarchy.DeviceTypes = map[string]int{"core": 1}
+	cfg.Hierarchy.NamingRules = []config.NamingRule{{Pattern: "^core-.*", Type: "core"}}
+	_, err := classificationService.SyncConfigRules(context.Background(), cfg.GetNamingClassificationRules(), false)
 	require.NoError(t, err)
+	ruleID := classification.ConfigRuleID("^core-.*", "core")
+	path := "/api/v1/classification/rules/" + ruleID
+
+	resp := serveJSON(t, router, http.MethodPut, path, map[string]interface{}{
+		"name":        "edited",
+		"description": "edited through the API",
+		"conditions":  []map[string]interface{}{{"field": "name", "operator": "contains", "value": "core"}},
+		"logic":       "AND",
+		"layer":       2,
+		"device_type": "core",
+		"priority":    10,
+		"is_active":   true,
+	})
+	assert.Equal(t, http.StatusConflict, resp.Code, resp.Body.String())
 
-	// Should have created rule with generated ID
-	assert.NotEmpty(t, response["id"])
-	assert.Equal(t, "API Test Rule", response["name"])
-	assert.Equal(t, true, response["is_active"])
+	resp = serveJSON(t, router, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusConflict, resp.Code, resp.Body.String())
+
+	rule, err := classificationService.GetClassificationRule(context.Background(), ruleID)
+	require.NoError(t, err)
+	require.NotNil(t, rule)
+	assert.Equal(t, 1, rule.Layer, "the rule should stay as imported")
 }
 
 func TestClassificationHandler_CreateHierarchyLayer(t *testing.T) {
-	handler, setup, api := setupClassificationHandler(t)
-	defer setup.Cleanup()
+	_, _, router := setupClassificationHandler(t)
 
-	// Create test layer data
-	layerData := map[string]interface{}{
+	resp := serveJSON(t, router, http.MethodPost, "/api/v1/classification/layers", map[string]interface{}{
 		"name":        "Test API Layer",
 		"description": "Layer created via API test",
 		"order":       10,
 		"color":       "#00FF00",
-	}
-
-	// Convert to JSON
-	jsonData, err := json.Marshal(layerData)
-	require.NoError(t, err)
-
-	// Create test request
-	req := httptest.NewRequest("POST", "/api/v1/classification/layers", strings.NewReader(string(jsonData)))
-	req.Header.Set("Content-Type", "application/json")
-	resp := httptest.NewRecorder()
-
-	// Execute request
-	api.ServeHTTP(resp, req)
-
-	// Check response
-	assert.Equal(t, http.StatusCreated, resp.Code)
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
 
-	// Parse response
 	var response map[string]interface{}
-	err = json.Unmarshal(resp.Body.Bytes(), &response)
-	require.NoError(t, err)
-
-	// Should have created layer
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
 	assert.NotEmpty(t, response["id"])
 	assert.Equal(t, "Test API Layer", response["name"])
 	assert.Equal(t, "Layer created via API test", response["description"])
-	assert.Equal(t, float64(10), response["order"]) // JSON numbers are float64
-	assert.Equal(t, "#00FF00", response["color"])
 }
 
 func TestClassificationHandler_ErrorHandling(t *testing.T) {
-	handler, setup, api := setupClassificationHandler(t)
-	defer setup.Cleanup()
+	_, _, router := setupClassificationHandler(t)
 
 	t.Run("Invalid JSON for rule creation", func(t *testing.T) {
-		req := httptest.NewRequest("POST", "/api/v1/classification/rules", strings.NewReader("invalid json"))
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/classification/rules", strings.NewReader("invalid json"))
 		req.Header.Set("Content-Type", "application/json")
 		resp := httptest.NewRecorder()
-
-		api.ServeHTTP(resp, req)
+		router.ServeHTTP(resp, req)
 
 		assert.Equal(t, http.StatusBadRequest, resp.Code)
 	})
 
-	t.Run("Missing required fields for rule", func(t *testing.T) {
-		incompleteRule := map[string]interface{}{
+	t.Run("Rule without conditions", func(t *testing.T) {
+		resp := serveJSON(t, router, http.MethodPost, "/api/v1/classification/rules", map[string]interface{}{
 			"name": "Incomplete Rule",
-			// Missing required fields like conditions, layer, device_type
-		}
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	})
 
-		jsonData, _ := json.Marshal(incompleteRule)
-		req := httptest.NewRequest("POST", "/api/v1/classification/rules", strings.NewReader(string(jsonData)))
-		req.Header.Set("Content-Type", "application/json")
-		resp := httptest.NewRecorder()
+	t.Run("Unknown device", func(t *testing.T) {
+		resp := serveJSON(t, router, http.MethodGet, "/api/v1/classification/devices/no-such-device", nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
 
-		api.ServeHTTP(resp, req)
+func TestClassificationHandler_CheckCoverage(t *testing.T) {
+	classificationService, setup, router := setupClassificationHandler(t)
+
+	unclassified := testutil.CreateTestDevice("new-001")
+	unclassified.Type = "router"
+	unclassified.LayerID = nil
+	unclassified.ClassifiedBy = ""
+	require.NoError(t, setup.Repo.BulkAddDevices(context.Background(), []topology.Device{unclassified}))
+
+	check := func(path string) classification.CoverageReport {
+		t.Helper()
+		resp := serveJSON(t, router, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var report classification.CoverageReport
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+		return report
+	}
 
-		assert.Equal(t, http.StatusBadRequest, resp.Code)
-	})
-}
\ No newline at end of file
+	// 省略時は設定のゲートで評価する
+	report := check("/api/v1/classification/coverage")
+	assert.Equal(t, float64(classification.DefaultCoverageThreshold), report.Threshold)
+	assert.Equal(t, 4, report.TotalDevices)
+	assert.False(t, report.Passed)
+
+	classificationService.SetCoverageGate(classification.CoverageGate{Threshold: 0, DeviceTypes: []string{"router"}})
+	report = check("/api/v1/classification/coverage")
+	assert.Zero(t, report.Threshold)
+	assert.Equal(t, []string{"router"}, report.DeviceTypes)
+	assert.Equal(t, 1, report.TotalDevices)
+	assert.True(t, report.Passed)
+
+	// 明示したパラメータは設定より優先し、threshold=0 も有効な値として扱う
+	report = check("/api/v1/classification/coverage?threshold=80&types=")
+	assert.Equal(t, float64(80), report.Threshold)
+	assert.Empty(t, report.DeviceTypes)
+	assert.Equal(t, 4, report.TotalDevices)
+	assert.False(t, report.Passed)
+
+	classificationService.SetCoverageGate(classification.CoverageGate{Threshold: 100})
+	report = check("/api/v1/classification/coverage?threshold=0")
+	assert.Zero(t, report.Threshold)
+	assert.True(t, report.Passed)
+
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/classification/coverage?threshold=101", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
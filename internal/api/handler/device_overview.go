@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type DeviceOverviewHandler struct {
+	overviewService *service.DeviceOverviewService
+	logger          *logger.Logger
+}
+
+func NewDeviceOverviewHandler(overviewService *service.DeviceOverviewService, appLogger *logger.Logger) *DeviceOverviewHandler {
+	return &DeviceOverviewHandler{
+		overviewService: overviewService,
+		logger:          appLogger.WithComponent("device_overview_handler"),
+	}
+}
+
+type DeviceOverviewResponse struct {
+	Body service.DeviceOverview
+}
+
+func (h *DeviceOverviewHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-device-overview",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/devices/{deviceId}/overview",
+		Summary:     "Get device overview",
+		Description: "Get everything the device detail view needs in one response: the device record, its classification " +
+			"and hierarchy layer, its connections classified into uplinks, downlinks and peers, and recent " +
+			"classification and workflow changes",
+		Tags: []string{"devices"},
+	}, h.GetDeviceOverview)
+}
+
+func (h *DeviceOverviewHandler) GetDeviceOverview(ctx context.Context, req *struct {
+	DeviceID    string `path:"deviceId" doc:"Device ID"`
+	EventsLimit int    `query:"events_limit" default:"20" minimum:"1" maximum:"200" doc:"Maximum number of recent events"`
+}) (*DeviceOverviewResponse, error) {
+	overview, err := h.overviewService.GetDeviceOverview(ctx, req.DeviceID, req.EventsLimit)
+	if err != nil {
+		if errors.Is(err, service.ErrOverviewDeviceNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		h.logger.Error("Failed to get device overview", "device_id", req.DeviceID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to get device overview", err)
+	}
+
+	return &DeviceOverviewResponse{Body: *overview}, nil
+}
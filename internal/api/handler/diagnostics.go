@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type DiagnosticsHandler struct {
+	diagnosticsService *service.QueryDiagnosticsService
+	logger             *logger.Logger
+}
+
+func NewDiagnosticsHandler(diagnosticsService *service.QueryDiagnosticsService, appLogger *logger.Logger) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		diagnosticsService: diagnosticsService,
+		logger:             appLogger.WithComponent("diagnostics_handler"),
+	}
+}
+
+type SubTopologyExplanationResponse struct {
+	Body topology.SubTopologyExplanation
+}
+
+func (h *DiagnosticsHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "explain-topology",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/diagnostics/topology/{deviceId}/explain",
+		Summary:     "Explain topology queries",
+		Description: "Run the SQL statements of a topology or visualization request for the same device, depth and filters " +
+			"with EXPLAIN ANALYZE and return each plan with its actual row counts and timings, to tune indexes for " +
+			"the data distribution without access to the database. total_ms estimates the database time of the request. " +
+			"The statements are executed, so this costs as much as the request itself. Requires the admin role when authentication is enabled.",
+		Tags: []string{"diagnostics"},
+	}, h.ExplainTopology)
+}
+
+func (h *DiagnosticsHandler) ExplainTopology(ctx context.Context, input *struct {
+	DeviceID string `path:"deviceId" doc:"Device ID" example:"core-01"`
+	Depth    int    `query:"depth" default:"3" doc:"Exploration depth from the root device in hops"`
+	DeviceFilterParams
+}) (*SubTopologyExplanationResponse, error) {
+	if !requestIsAdmin(ctx) {
+		return nil, huma.Error403Forbidden("diagnostics require the admin role")
+	}
+	filter, err := input.DeviceFilterParams.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	explanation, err := h.diagnosticsService.ExplainSubTopology(ctx, input.DeviceID, input.Depth, filter)
+	if err != nil {
+		if errors.Is(err, service.ErrExplainDeviceNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		h.logger.Error("Failed to explain topology queries", "device_id", input.DeviceID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to explain topology queries", err)
+	}
+
+	h.logger.Info("Topology queries explained", "device_id", input.DeviceID, "user", requestUser(ctx))
+	return &SubTopologyExplanationResponse{Body: *explanation}, nil
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type DisplayNameHandler struct {
+	displayNameService *service.DisplayNameService
+	logger             *logger.Logger
+}
+
+func NewDisplayNameHandler(displayNameService *service.DisplayNameService, appLogger *logger.Logger) *DisplayNameHandler {
+	return &DisplayNameHandler{
+		displayNameService: displayNameService,
+		logger:             appLogger.WithComponent("display_name_handler"),
+	}
+}
+
+// DisplayNameRequest creates or updates a display-name override
+type DisplayNameRequest struct {
+	Body struct {
+		DeviceID string `json:"device_id,omitempty" doc:"Device ID to rename (exclusive with pattern)"`
+		Pattern  string `json:"pattern,omitempty" doc:"Regular expression matched against device IDs (exclusive with device_id)"`
+		Name     string `json:"name" doc:"Display name; may reference pattern captures such as $1"`
+		Priority int    `json:"priority,omitempty" doc:"Pattern priority (higher = tried first)"`
+	}
+}
+
+type DisplayNameResponse struct {
+	Body topology.DisplayNameOverride
+}
+
+type DisplayNamesResponse struct {
+	Body struct {
+		Overrides []topology.DisplayNameOverride `json:"overrides"`
+		Count     int                            `json:"count"`
+	}
+}
+
+func (h *DisplayNameHandler) Register(api huma.API) {
+	// 表示名の上書き API
+	huma.Register(api, huma.Operation{
+		OperationID: "list-display-names",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/display-names",
+		Summary:     "List display-name overrides",
+		Tags:        []string{"display-names"},
+	}, h.ListDisplayNames)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "create-display-name",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/display-names",
+		Summary:     "Create a display-name override",
+		Description: "Set a human-friendly name for a device or for every device matching a pattern; the device ID is unchanged",
+		Tags:        []string{"display-names"},
+	}, h.CreateDisplayName)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "update-display-name",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/display-names/{override_id}",
+		Summary:     "Update a display-name override",
+		Tags:        []string{"display-names"},
+	}, h.UpdateDisplayName)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-display-name",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/display-names/{override_id}",
+		Summary:     "Delete a display-name override",
+		Tags:        []string{"display-names"},
+	}, h.DeleteDisplayName)
+}
+
+func (h *DisplayNameHandler) ListDisplayNames(ctx context.Context, req *struct{}) (*DisplayNamesResponse, error) {
+	overrides, err := h.displayNameService.ListOverrides(ctx)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to list display names", err)
+	}
+	if overrides == nil {
+		overrides = []topology.DisplayNameOverride{}
+	}
+
+	resp := &DisplayNamesResponse{}
+	resp.Body.Overrides = overrides
+	resp.Body.Count = len(overrides)
+	return resp, nil
+}
+
+func (h *DisplayNameHandler) CreateDisplayName(ctx context.Context, req *DisplayNameRequest) (*DisplayNameResponse, error) {
+	return h.saveDisplayName(ctx, "", req)
+}
+
+func (h *DisplayNameHandler) UpdateDisplayName(ctx context.Context, req *struct {
+	OverrideID string `path:"override_id" doc:"Override ID"`
+	DisplayNameRequest
+}) (*DisplayNameResponse, error) {
+	return h.saveDisplayName(ctx, req.OverrideID, &req.DisplayNameRequest)
+}
+
+func (h *DisplayNameHandler) saveDisplayName(ctx context.Context, overrideID string, req *DisplayNameRequest) (*DisplayNameResponse, error) {
+	userID := requestUser(ctx)
+
+	override, err := h.displayNameService.SaveOverride(ctx, topology.DisplayNameOverride{
+		ID:       overrideID,
+		DeviceID: req.Body.DeviceID,
+		Pattern:  req.Body.Pattern,
+		Name:     req.Body.Name,
+		Priority: req.Body.Priority,
+	}, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDisplayName) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to save display name", "device_id", req.Body.DeviceID, "pattern", req.Body.Pattern, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to save display name", err)
+	}
+	if override == nil {
+		return nil, huma.Error404NotFound("Display name override not found")
+	}
+
+	return &DisplayNameResponse{Body: *override}, nil
+}
+
+func (h *DisplayNameHandler) DeleteDisplayName(ctx context.Context, req *struct {
+	OverrideID string `path:"override_id" doc:"Override ID"`
+}) (*struct{}, error) {
+	if err := h.displayNameService.DeleteOverride(ctx, req.OverrideID); err != nil {
+		return nil, huma.Error500InternalServerError("Failed to delete display name", err)
+	}
+
+	return &struct{}{}, nil
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/job"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type ExportHandler struct {
+	exportService *service.ExportService
+	logger        *logger.Logger
+}
+
+func NewExportHandler(exportService *service.ExportService, appLogger *logger.Logger) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+		logger:        appLogger.WithComponent("export_handler"),
+	}
+}
+
+// SubmitExportRequest starts an export job
+type SubmitExportRequest struct {
+	Body topology.ExportRequest
+}
+
+type ExportJobResponse struct {
+	Body job.Job
+}
+
+type ExportLinkResponse struct {
+	Body service.ExportLink
+}
+
+func (h *ExportHandler) Register(api huma.API) {
+	// 大きなエクスポートはジョブで作成し、署名付きURLから取得する
+	huma.Register(api, huma.Operation{
+		OperationID: "submit-export",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/exports",
+		Summary:     "Submit export",
+		Description: "Export the whole topology as GraphML, device or link CSV, or a JSON snapshot. The file is written " +
+			"to object storage by an export job; poll /api/v1/jobs/{id} until it succeeded, then request a download URL. " +
+			"include adds operational context (classification, notes, maintenance, ownership) as attributes of devices and links.",
+		Tags:          []string{"exports", "jobs"},
+		DefaultStatus: http.StatusAccepted,
+	}, h.SubmitExport)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "create-export-download-url",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/exports/{jobId}/download-url",
+		Summary:     "Create export download URL",
+		Description: "Create a signed, time-limited URL to download the file of a succeeded export job. The URL needs no account to open.",
+		Tags:        []string{"exports"},
+	}, h.CreateDownloadLink)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "download-export",
+		Method:      http.MethodGet,
+		Path:        service.ExportDownloadPathPrefix + "{token}",
+		Summary:     "Download export",
+		Description: "Download an export file with a signed URL. Tampered or expired URLs are rejected with 403.",
+		Tags:        []string{"exports"},
+	}, h.Download)
+}
+
+func (h *ExportHandler) SubmitExport(ctx context.Context, req *SubmitExportRequest) (*ExportJobResponse, error) {
+	submitted, err := h.exportService.SubmitExport(ctx, req.Body, requestUser(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidExport) || errors.Is(err, service.ErrInvalidJob) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to submit export", "format", req.Body.Format, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to submit export", err)
+	}
+
+	return &ExportJobResponse{Body: *submitted}, nil
+}
+
+func (h *ExportHandler) CreateDownloadLink(ctx context.Context, req *struct {
+	JobID string `path:"jobId" doc:"ID of the export job"`
+	Body  struct {
+		ExpiresIn string `json:"expires_in,omitempty" example:"1h" doc:"Validity of the URL as a Go duration (default 15m, max 168h)"`
+	}
+}) (*ExportLinkResponse, error) {
+	var ttl time.Duration
+	if req.Body.ExpiresIn != "" {
+		parsed, err := time.ParseDuration(req.Body.ExpiresIn)
+		if err != nil {
+			return nil, huma.Error400BadRequest("Invalid expires_in", err)
+		}
+		ttl = parsed
+	}
+
+	link, err := h.exportService.CreateDownloadLink(ctx, req.JobID, ttl)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidExport):
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		case errors.Is(err, service.ErrExportNotFound):
+			return nil, huma.Error404NotFound(err.Error(), err)
+		case errors.Is(err, service.ErrExportNotReady):
+			return nil, huma.Error409Conflict(err.Error(), err)
+		}
+		h.logger.Error("Failed to create export download URL", "job", req.JobID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to create download URL", err)
+	}
+
+	h.logger.Info("Export download URL created", "job", req.JobID, "expires_at", link.ExpiresAt, "user", requestUser(ctx))
+	return &ExportLinkResponse{Body: *link}, nil
+}
+
+func (h *ExportHandler) Download(ctx context.Context, req *struct {
+	Token string `path:"token" doc:"Signed download token"`
+}) (*huma.StreamResponse, error) {
+	content, grant, object, err := h.exportService.OpenDownload(ctx, req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidDownloadLink):
+			return nil, huma.Error403Forbidden(err.Error(), err)
+		case errors.Is(err, service.ErrExportNotFound):
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		h.logger.Error("Failed to open export", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to open export", err)
+	}
+
+	return &huma.StreamResponse{
+		Body: func(hctx huma.Context) {
+			defer content.Close()
+			hctx.SetHeader("Content-Type", grant.ContentType)
+			hctx.SetHeader("Content-Length", strconv.FormatInt(object.Size, 10))
+			hctx.SetHeader("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": grant.Filename}))
+			if _, err := io.Copy(hctx.BodyWriter(), content); err != nil {
+				h.logger.Warn("Export download interrupted", "key", grant.Key, "error", err)
+			}
+		},
+	}, nil
+}
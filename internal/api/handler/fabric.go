@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type FabricHandler struct {
+	fabricService *service.FabricService
+	logger        *logger.Logger
+}
+
+func NewFabricHandler(fabricService *service.FabricService, appLogger *logger.Logger) *FabricHandler {
+	return &FabricHandler{
+		fabricService: fabricService,
+		logger:        appLogger.WithComponent("fabric_handler"),
+	}
+}
+
+// FabricRequest creates or replaces a fabric
+type FabricRequest struct {
+	Name string `path:"name" doc:"Fabric name (e.g. prod-fabric-a)"`
+	Body struct {
+		Description   string                     `json:"description,omitempty" doc:"Free-form description"`
+		LogicOperator string                     `json:"logic,omitempty" doc:"Logic operator for multiple conditions (AND, OR)" default:"AND"`
+		Conditions    []topology.FabricCondition `json:"conditions" doc:"Member selection conditions (fields: name, hardware, type, metadata.<key>)"`
+		Priority      int                        `json:"priority,omitempty" doc:"Devices matching several fabrics join the one with the highest priority"`
+	}
+}
+
+type FabricResponse struct {
+	Body topology.Fabric
+}
+
+type FabricsResponse struct {
+	Body struct {
+		Fabrics []topology.Fabric `json:"fabrics"`
+		Count   int               `json:"count"`
+	}
+}
+
+type FabricDevicesResponse struct {
+	Body struct {
+		Devices []topology.Device `json:"devices"`
+		Count   int               `json:"count"`
+	}
+}
+
+type FabricReportResponse struct {
+	Body topology.FabricReport
+}
+
+type FabricTopologyResponse struct {
+	Body visualTopologyBody
+}
+
+func (h *FabricHandler) Register(api huma.API) {
+	// ファブリック管理 API
+	huma.Register(api, huma.Operation{
+		OperationID: "list-fabrics",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/fabrics",
+		Summary:     "List fabrics",
+		Description: "List all fabrics with the number of devices currently assigned to each",
+		Tags:        []string{"fabrics"},
+	}, h.ListFabrics)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-fabric",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/fabrics/{name}",
+		Summary:     "Get fabric",
+		Tags:        []string{"fabrics"},
+	}, h.GetFabric)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "set-fabric",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/fabrics/{name}",
+		Summary:     "Create or update fabric",
+		Description: "Define a fabric by member conditions. Each device is assigned to the highest-priority fabric it matches.",
+		Tags:        []string{"fabrics"},
+	}, h.SetFabric)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-fabric",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/fabrics/{name}",
+		Summary:     "Delete fabric",
+		Tags:        []string{"fabrics"},
+	}, h.DeleteFabric)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-fabric-devices",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/fabrics/{name}/devices",
+		Summary:     "List fabric devices",
+		Tags:        []string{"fabrics"},
+	}, h.ListFabricDevices)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-fabric-topology",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/fabrics/{name}/topology",
+		Summary:     "Get fabric topology",
+		Description: "Get the visual topology of the fabric members and the links between them",
+		Tags:        []string{"fabrics", "visualization"},
+	}, h.GetFabricTopology)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-fabric-report",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/fabrics/{name}/report",
+		Summary:     "Get fabric report",
+		Description: "Summarize the fabric members by type and layer and count its internal and external links",
+		Tags:        []string{"fabrics"},
+	}, h.GetFabricReport)
+}
+
+func (h *FabricHandler) ListFabrics(ctx context.Context, req *struct{}) (*FabricsResponse, error) {
+	fabrics, err := h.fabricService.ListFabrics(ctx)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to list fabrics", err)
+	}
+
+	resp := &FabricsResponse{}
+	resp.Body.Fabrics = fabrics
+	resp.Body.Count = len(fabrics)
+	return resp, nil
+}
+
+func (h *FabricHandler) GetFabric(ctx context.Context, req *struct {
+	Name string `path:"name" doc:"Fabric name"`
+}) (*FabricResponse, error) {
+	fabric, err := h.fabricService.GetFabric(ctx, req.Name)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get fabric", err)
+	}
+	if fabric == nil {
+		return nil, huma.Error404NotFound("Fabric not found", err)
+	}
+
+	return &FabricResponse{Body: *fabric}, nil
+}
+
+func (h *FabricHandler) SetFabric(ctx context.Context, req *FabricRequest) (*FabricResponse, error) {
+	userID := requestUser(ctx)
+
+	fabric, err := h.fabricService.SaveFabric(ctx, topology.Fabric{
+		Name:          req.Name,
+		Description:   req.Body.Description,
+		LogicOperator: req.Body.LogicOperator,
+		Conditions:    req.Body.Conditions,
+		Priority:      req.Body.Priority,
+	}, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFabric) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to save fabric", "name", req.Name, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to save fabric", err)
+	}
+
+	return &FabricResponse{Body: *fabric}, nil
+}
+
+func (h *FabricHandler) DeleteFabric(ctx context.Context, req *struct {
+	Name string `path:"name" doc:"Fabric name"`
+}) (*struct{}, error) {
+	if err := h.fabricService.DeleteFabric(ctx, req.Name); err != nil {
+		return nil, huma.Error500InternalServerError("Failed to delete fabric", err)
+	}
+
+	return &struct{}{}, nil
+}
+
+func (h *FabricHandler) ListFabricDevices(ctx context.Context, req *struct {
+	Name string `path:"name" doc:"Fabric name"`
+}) (*FabricDevicesResponse, error) {
+	devices, err := h.fabricService.ListFabricMembers(ctx, req.Name)
+	if err != nil {
+		if errors.Is(err, service.ErrFabricNotFound) {
+			return nil, huma.Error404NotFound("Fabric not found", err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to list fabric devices", err)
+	}
+
+	resp := &FabricDevicesResponse{}
+	resp.Body.Devices = devices
+	resp.Body.Count = len(devices)
+	return resp, nil
+}
+
+func (h *FabricHandler) GetFabricTopology(ctx context.Context, req *struct {
+	Name string `path:"name" doc:"Fabric name"`
+	EdgeLabelParams
+	EdgeBundleParams
+	SchemaVersionParams
+}) (*FabricTopologyResponse, error) {
+	fabricTopology, err := h.fabricService.GetFabricTopology(ctx, req.Name)
+	if err != nil {
+		if errors.Is(err, service.ErrFabricNotFound) {
+			return nil, huma.Error404NotFound("Fabric not found", err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to get fabric topology", err)
+	}
+	req.EdgeLabelParams.apply(fabricTopology)
+	req.EdgeBundleParams.apply(fabricTopology)
+
+	body, err := newVisualTopologyBody(fabricTopology, nil, req.SchemaVersion)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to render topology", err)
+	}
+
+	return &FabricTopologyResponse{Body: *body}, nil
+}
+
+func (h *FabricHandler) GetFabricReport(ctx context.Context, req *struct {
+	Name string `path:"name" doc:"Fabric name"`
+}) (*FabricReportResponse, error) {
+	report, err := h.fabricService.GetFabricReport(ctx, req.Name)
+	if err != nil {
+		if errors.Is(err, service.ErrFabricNotFound) {
+			return nil, huma.Error404NotFound("Fabric not found", err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to get fabric report", err)
+	}
+
+	return &FabricReportResponse{Body: *report}, nil
+}
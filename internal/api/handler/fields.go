@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// Response shaping for the `fields` query parameter (e.g. fields=id,type,layer)
+
+// deviceFieldAliases maps short field names accepted by device endpoints to JSON keys
+var deviceFieldAliases = map[string]string{
+	"layer": "layer_id",
+}
+
+// parseFieldSelection validates a comma-separated field list against the JSON fields of model.
+// An empty value selects all fields and returns nil.
+func parseFieldSelection(value string, model interface{}, aliases map[string]string) ([]string, error) {
+	requested := splitCommaList(value)
+	if len(requested) == 0 {
+		return nil, nil
+	}
+
+	allowed := jsonFieldNames(reflect.TypeOf(model))
+	fields := make([]string, 0, len(requested))
+	seen := make(map[string]bool)
+	for _, field := range requested {
+		if alias, ok := aliases[field]; ok {
+			field = alias
+		}
+		if !allowed[field] {
+			names := make([]string, 0, len(allowed))
+			for name := range allowed {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, huma.Error400BadRequest(fmt.Sprintf("unknown field %q (available: %s)", field, strings.Join(names, ", ")))
+		}
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+// selectFields returns items restricted to the given JSON fields, or the items unchanged when fields is nil
+func selectFields[T any](items []T, fields []string) (interface{}, error) {
+	if fields == nil {
+		return items, nil
+	}
+
+	shaped := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		selected := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := all[field]; ok {
+				selected[field] = value
+			}
+		}
+		shaped = append(shaped, selected)
+	}
+
+	return shaped, nil
+}
+
+// jsonFieldNames returns the JSON keys of a struct type
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		names[name] = true
+	}
+	return names
+}
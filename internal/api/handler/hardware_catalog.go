@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/service"
+)
+
+// Request/Response types for the hardware catalog
+type HardwareCatalogResponse struct {
+	Body struct {
+		Entries []classification.HardwareCatalogEntry `json:"entries"`
+		Count   int                                   `json:"count"`
+	}
+}
+
+type HardwareCatalogEntryResponse struct {
+	Body classification.HardwareCatalogEntry
+}
+
+type CreateHardwareCatalogEntryRequest struct {
+	Body struct {
+		LayerID     int    `json:"layer_id" doc:"Hierarchy layer ID"`
+		Model       string `json:"model" doc:"Approved hardware model; glob wildcards such as DCS-7280* are allowed"`
+		Description string `json:"description,omitempty" doc:"Free-form description"`
+	}
+}
+
+type HardwareComplianceResponse struct {
+	Body classification.HardwareComplianceReport
+}
+
+func (h *ClassificationHandler) registerHardwareCatalogRoutes(api huma.API) {
+	// ハードウェアカタログとコンプライアンスチェック
+	huma.Register(api, huma.Operation{
+		OperationID: "list-hardware-catalog",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/classification/hardware-catalog",
+		Summary:     "List hardware catalog",
+		Description: "List the hardware models approved for each hierarchy layer",
+		Tags:        []string{"classification"},
+	}, h.ListHardwareCatalog)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "create-hardware-catalog-entry",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/classification/hardware-catalog",
+		Summary:     "Approve a hardware model for a layer",
+		Tags:        []string{"classification"},
+	}, h.CreateHardwareCatalogEntry)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-hardware-catalog-entry",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/classification/hardware-catalog/{entry_id}",
+		Summary:     "Delete a hardware catalog entry",
+		Tags:        []string{"classification"},
+	}, h.DeleteHardwareCatalogEntry)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "check-hardware-compliance",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/classification/hardware-compliance",
+		Summary:     "Check hardware compliance",
+		Description: "List classified devices whose hardware is not approved for their layer (layers without catalog entries are not checked)",
+		Tags:        []string{"classification"},
+	}, h.CheckHardwareCompliance)
+}
+
+func (h *ClassificationHandler) ListHardwareCatalog(ctx context.Context, req *struct{}) (*HardwareCatalogResponse, error) {
+	entries, err := h.classificationService.ListHardwareCatalog(ctx)
+	if err != nil {
+		return nil, hardwareCatalogError("Failed to list hardware catalog", err)
+	}
+	if entries == nil {
+		entries = []classification.HardwareCatalogEntry{}
+	}
+
+	resp := &HardwareCatalogResponse{}
+	resp.Body.Entries = entries
+	resp.Body.Count = len(entries)
+	return resp, nil
+}
+
+func (h *ClassificationHandler) CreateHardwareCatalogEntry(ctx context.Context, req *CreateHardwareCatalogEntryRequest) (*HardwareCatalogEntryResponse, error) {
+	userID := requestUser(ctx)
+
+	entry, err := h.classificationService.AddHardwareCatalogEntry(ctx, classification.HardwareCatalogEntry{
+		LayerID:     req.Body.LayerID,
+		Model:       req.Body.Model,
+		Description: req.Body.Description,
+	}, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCatalogEntry) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to save hardware catalog entry", "layer_id", req.Body.LayerID, "model", req.Body.Model, "error", err)
+		return nil, hardwareCatalogError("Failed to save hardware catalog entry", err)
+	}
+
+	return &HardwareCatalogEntryResponse{Body: *entry}, nil
+}
+
+func (h *ClassificationHandler) DeleteHardwareCatalogEntry(ctx context.Context, req *struct {
+	EntryID string `path:"entry_id" doc:"Catalog entry ID"`
+}) (*struct{}, error) {
+	if err := h.classificationService.DeleteHardwareCatalogEntry(ctx, req.EntryID); err != nil {
+		return nil, hardwareCatalogError("Failed to delete hardware catalog entry", err)
+	}
+
+	return &struct{}{}, nil
+}
+
+func (h *ClassificationHandler) CheckHardwareCompliance(ctx context.Context, req *struct{}) (*HardwareComplianceResponse, error) {
+	report, err := h.classificationService.EvaluateHardwareCompliance(ctx)
+	if err != nil {
+		return nil, hardwareCatalogError("Failed to evaluate hardware compliance", err)
+	}
+
+	return &HardwareComplianceResponse{Body: *report}, nil
+}
+
+// hardwareCatalogError maps hardware catalog errors to HTTP errors
+func hardwareCatalogError(msg string, err error) error {
+	if errors.Is(err, service.ErrHardwareCatalogUnsupported) {
+		return huma.Error501NotImplemented(err.Error())
+	}
+	return huma.Error500InternalServerError(msg, err)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/service"
+)
+
+// lifecycleDateLayout is the format of lifecycle dates in requests
+const lifecycleDateLayout = "2006-01-02"
+
+// Request/Response types for hardware lifecycle dates
+type HardwareLifecycleResponse struct {
+	Body struct {
+		Entries []classification.HardwareLifecycleEntry `json:"entries"`
+		Count   int                                     `json:"count"`
+	}
+}
+
+type HardwareLifecycleEntryResponse struct {
+	Body classification.HardwareLifecycleEntry
+}
+
+type SetHardwareLifecycleRequest struct {
+	Body struct {
+		Model       string `json:"model" doc:"Hardware model; matched ignoring case and punctuation, glob wildcards such as EX2300* are allowed" example:"DCS-7280SR"`
+		EndOfSale   string `json:"end_of_sale,omitempty" format:"date" doc:"End-of-sale date (YYYY-MM-DD)"`
+		EndOfLife   string `json:"end_of_life,omitempty" format:"date" doc:"End-of-life date (YYYY-MM-DD)"`
+		Description string `json:"description,omitempty" doc:"Free-form description (e.g. the vendor bulletin)"`
+	}
+}
+
+type HardwareLifecycleReportResponse struct {
+	Body classification.HardwareLifecycleReport
+}
+
+func (h *ClassificationHandler) registerHardwareLifecycleRoutes(api huma.API) {
+	// ハードウェアの販売終了（EoS）・サポート終了（EoL）管理
+	huma.Register(api, huma.Operation{
+		OperationID: "list-hardware-lifecycle",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/classification/hardware-lifecycle",
+		Summary:     "List hardware lifecycle dates",
+		Description: "List the end-of-sale and end-of-life dates of hardware models",
+		Tags:        []string{"classification"},
+	}, h.ListHardwareLifecycle)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "set-hardware-lifecycle",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/classification/hardware-lifecycle",
+		Summary:     "Set hardware lifecycle dates",
+		Description: "Record the end-of-sale/end-of-life dates of a hardware model; an existing entry of the same model is updated",
+		Tags:        []string{"classification"},
+	}, h.SetHardwareLifecycle)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-hardware-lifecycle-entry",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/classification/hardware-lifecycle/{entry_id}",
+		Summary:     "Delete hardware lifecycle dates",
+		Tags:        []string{"classification"},
+	}, h.DeleteHardwareLifecycleEntry)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-hardware-lifecycle-report",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/classification/hardware-lifecycle/report",
+		Summary:     "Report devices running end-of-life hardware",
+		Description: "List devices whose hardware matches a model past its end of life, grouped by layer and site. " +
+			"Pass a future as_of to see what reaches end of life by then",
+		Tags: []string{"classification"},
+	}, h.GetHardwareLifecycleReport)
+}
+
+func (h *ClassificationHandler) ListHardwareLifecycle(ctx context.Context, req *struct{}) (*HardwareLifecycleResponse, error) {
+	entries, err := h.classificationService.ListHardwareLifecycle(ctx)
+	if err != nil {
+		return nil, hardwareCatalogError("Failed to list hardware lifecycle", err)
+	}
+	if entries == nil {
+		entries = []classification.HardwareLifecycleEntry{}
+	}
+
+	resp := &HardwareLifecycleResponse{}
+	resp.Body.Entries = entries
+	resp.Body.Count = len(entries)
+	return resp, nil
+}
+
+func (h *ClassificationHandler) SetHardwareLifecycle(ctx context.Context, req *SetHardwareLifecycleRequest) (*HardwareLifecycleEntryResponse, error) {
+	userID := requestUser(ctx)
+
+	endOfSale, err := parseLifecycleDate("end_of_sale", req.Body.EndOfSale)
+	if err != nil {
+		return nil, err
+	}
+	endOfLife, err := parseLifecycleDate("end_of_life", req.Body.EndOfLife)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := h.classificationService.SetHardwareLifecycle(ctx, classification.HardwareLifecycleEntry{
+		Model:       req.Body.Model,
+		EndOfSale:   endOfSale,
+		EndOfLife:   endOfLife,
+		Description: req.Body.Description,
+	}, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLifecycleEntry) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to save hardware lifecycle entry", "model", req.Body.Model, "error", err)
+		return nil, hardwareCatalogError("Failed to save hardware lifecycle entry", err)
+	}
+
+	return &HardwareLifecycleEntryResponse{Body: *entry}, nil
+}
+
+func (h *ClassificationHandler) DeleteHardwareLifecycleEntry(ctx context.Context, req *struct {
+	EntryID string `path:"entry_id" doc:"Lifecycle entry ID"`
+}) (*struct{}, error) {
+	if err := h.classificationService.DeleteHardwareLifecycleEntry(ctx, req.EntryID); err != nil {
+		return nil, hardwareCatalogError("Failed to delete hardware lifecycle entry", err)
+	}
+
+	return &struct{}{}, nil
+}
+
+func (h *ClassificationHandler) GetHardwareLifecycleReport(ctx context.Context, req *struct {
+	AsOf             string `query:"as_of" format:"date" doc:"Evaluate the lifecycle status on this date (YYYY-MM-DD, default today)"`
+	SiteKey          string `query:"site_key" default:"site" doc:"Device metadata key that holds the site"`
+	IncludeEndOfSale bool   `query:"include_end_of_sale" default:"false" doc:"Also list devices past their end of sale"`
+}) (*HardwareLifecycleReportResponse, error) {
+	asOf := time.Now()
+	if req.AsOf != "" {
+		parsed, err := parseLifecycleDate("as_of", req.AsOf)
+		if err != nil {
+			return nil, err
+		}
+		asOf = *parsed
+	}
+
+	report, err := h.classificationService.EvaluateHardwareLifecycle(ctx, asOf, req.SiteKey, req.IncludeEndOfSale)
+	if err != nil {
+		return nil, hardwareCatalogError("Failed to evaluate hardware lifecycle", err)
+	}
+
+	return &HardwareLifecycleReportResponse{Body: *report}, nil
+}
+
+// parseLifecycleDate parses an optional YYYY-MM-DD date; an empty value returns nil
+func parseLifecycleDate(field, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(lifecycleDateLayout, value)
+	if err != nil {
+		return nil, huma.Error400BadRequest("Invalid "+field+" (expected YYYY-MM-DD)", err)
+	}
+	return &date, nil
+}
@@ -18,6 +18,8 @@ type HealthResponse struct {
 	Status   string `json:"status"`
 	Message  string `json:"message,omitempty"`
 	Database string `json:"database"`
+	// 読み取りレプリカの状態（レプリカを使うリポジトリのみ）。全台停止中も読み取りはプライマリで継続する
+	Replicas *topology.ReplicaStatus `json:"replicas,omitempty"`
 }
 
 func NewHealthHandler(topologyRepo topology.Repository, appLogger *logger.Logger) *HealthHandler {
@@ -44,6 +46,11 @@ func (h *HealthHandler) HealthCheck(ctx context.Context, input *struct{}) (*stru
 		Status:   "healthy",
 		Database: "healthy",
 	}
+	if replicaRepo, ok := h.topologyRepo.(topology.ReplicaStatusRepository); ok {
+		if status := replicaRepo.ReplicaStatus(); status.Configured > 0 {
+			response.Replicas = &status
+		}
+	}
 
 	if err := h.topologyRepo.Health(ctx); err != nil {
 		response.Status = "unhealthy"
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type IconHandler struct {
+	iconService *service.IconService
+	logger      *logger.Logger
+}
+
+func NewIconHandler(iconService *service.IconService, appLogger *logger.Logger) *IconHandler {
+	return &IconHandler{
+		iconService: iconService,
+		logger:      appLogger.WithComponent("icon_handler"),
+	}
+}
+
+// IconMappingRequest creates or replaces an icon mapping
+type IconMappingRequest struct {
+	ID   string `path:"id" doc:"Mapping ID (lowercase letters, digits, '-' and '_')"`
+	Body struct {
+		DeviceType string `json:"device_type,omitempty" example:"switch" doc:"Device type the mapping applies to"`
+		Vendor     string `json:"vendor,omitempty" example:"Cisco" doc:"Vendor, matched case-insensitively against the beginning of the hardware"`
+		Icon       string `json:"icon" example:"switch-cisco" doc:"Icon identifier rendered by frontends"`
+		SVG        string `json:"svg,omitempty" doc:"Optional embedded <svg> element served in the sprite"`
+	}
+}
+
+type IconMappingResponse struct {
+	Body visualization.IconMapping
+}
+
+type IconManifestResponse struct {
+	ETag string `header:"ETag"`
+	Body visualization.IconManifest
+}
+
+type IconSpriteResponse struct {
+	ContentType string `header:"Content-Type"`
+	ETag        string `header:"ETag"`
+	Body        []byte
+}
+
+func (h *IconHandler) Register(api huma.API) {
+	// アイコンマニフェスト API
+	huma.Register(api, huma.Operation{
+		OperationID: "get-icon-manifest",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/icons/manifest",
+		Summary:     "Get icon manifest",
+		Description: "Get the versioned mapping of device types and vendors to icon identifiers. " +
+			"Frontends pick the most specific matching mapping (device type and vendor, then device type, then vendor) " +
+			"and fall back to default_icon. The version is returned as ETag; If-None-Match answers 304 when unchanged.",
+		Tags: []string{"icons"},
+	}, h.GetManifest)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-icon-sprite",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/icons/sprite.svg",
+		Summary:     "Get icon sprite",
+		Description: "Get the embedded SVGs as one SVG sprite with a <symbol> per icon identifier",
+		Tags:        []string{"icons"},
+	}, h.GetSprite)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "set-icon-mapping",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/icons/mappings/{id}",
+		Summary:     "Set icon mapping",
+		Tags:        []string{"icons"},
+	}, h.SetIconMapping)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-icon-mapping",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/icons/mappings/{id}",
+		Summary:     "Delete icon mapping",
+		Tags:        []string{"icons"},
+	}, h.DeleteIconMapping)
+}
+
+// manifestETag quotes the manifest version as a strong entity tag
+func manifestETag(version string) string {
+	return `"` + version + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *IconHandler) GetManifest(ctx context.Context, req *struct {
+	IncludeSVG  bool   `query:"include_svg" default:"false" doc:"Include the embedded SVGs in the mappings"`
+	IfNoneMatch string `header:"If-None-Match" doc:"Manifest version previously returned as ETag"`
+}) (*IconManifestResponse, error) {
+	manifest, err := h.iconService.GetManifest(ctx)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get icon manifest", err)
+	}
+
+	etag := manifestETag(manifest.Version)
+	if etagMatches(req.IfNoneMatch, etag) {
+		return nil, huma.Status304NotModified()
+	}
+	if !req.IncludeSVG {
+		manifest = manifest.WithoutSVG()
+	}
+
+	return &IconManifestResponse{ETag: etag, Body: manifest}, nil
+}
+
+func (h *IconHandler) GetSprite(ctx context.Context, req *struct {
+	IfNoneMatch string `header:"If-None-Match" doc:"Manifest version previously returned as ETag"`
+}) (*IconSpriteResponse, error) {
+	manifest, err := h.iconService.GetManifest(ctx)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get icon sprite", err)
+	}
+
+	etag := manifestETag(manifest.Version)
+	if etagMatches(req.IfNoneMatch, etag) {
+		return nil, huma.Status304NotModified()
+	}
+
+	return &IconSpriteResponse{
+		ContentType: "image/svg+xml",
+		ETag:        etag,
+		Body:        []byte(manifest.Sprite()),
+	}, nil
+}
+
+func (h *IconHandler) SetIconMapping(ctx context.Context, req *IconMappingRequest) (*IconMappingResponse, error) {
+	userID := requestUser(ctx)
+
+	mapping, err := h.iconService.SaveIconMapping(ctx, visualization.IconMapping{
+		ID:         req.ID,
+		DeviceType: req.Body.DeviceType,
+		Vendor:     req.Body.Vendor,
+		Icon:       req.Body.Icon,
+		SVG:        req.Body.SVG,
+	}, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidIconMapping) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to save icon mapping", "id", req.ID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to save icon mapping", err)
+	}
+
+	return &IconMappingResponse{Body: *mapping}, nil
+}
+
+func (h *IconHandler) DeleteIconMapping(ctx context.Context, req *struct {
+	ID string `path:"id" doc:"Mapping ID"`
+}) (*struct{}, error) {
+	if err := h.iconService.DeleteIconMapping(ctx, req.ID); err != nil {
+		if errors.Is(err, service.ErrIconMappingNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to delete icon mapping", err)
+	}
+
+	return &struct{}{}, nil
+}
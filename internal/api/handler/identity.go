@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type IdentityHandler struct {
+	identityService *service.IdentityService
+	logger          *logger.Logger
+}
+
+func NewIdentityHandler(identityService *service.IdentityService, appLogger *logger.Logger) *IdentityHandler {
+	return &IdentityHandler{
+		identityService: identityService,
+		logger:          appLogger.WithComponent("identity_handler"),
+	}
+}
+
+// IdentityDecisionRequest resolves a conflict manually
+type IdentityDecisionRequest struct {
+	Body struct {
+		Devices     []string `json:"devices" minItems:"2" doc:"Devices of the conflict"`
+		CanonicalID string   `json:"canonical_id,omitempty" doc:"The ID the devices are known by when they are the same box; empty when they are distinct boxes"`
+		Note        string   `json:"note,omitempty" doc:"Reason of the decision"`
+	}
+}
+
+type IdentityMapResponse struct {
+	Body topology.IdentityMap
+}
+
+type IdentityConflictsResponse struct {
+	Body struct {
+		Conflicts []topology.IdentityConflict `json:"conflicts"`
+		Count     int                         `json:"count"`
+	}
+}
+
+type DeviceIdentityResponse struct {
+	Body struct {
+		DeviceID    string                   `json:"device_id"`
+		CanonicalID string                   `json:"canonical_id"`
+		Identity    *topology.DeviceIdentity `json:"identity,omitempty" doc:"Every ID of the box, absent when the device has no aliases"`
+	}
+}
+
+type IdentityDecisionResponse struct {
+	Body topology.IdentityDecision
+}
+
+type IdentityDecisionsResponse struct {
+	Body struct {
+		Decisions []topology.IdentityDecision `json:"decisions"`
+		Count     int                         `json:"count"`
+	}
+}
+
+func (h *IdentityHandler) Register(api huma.API) {
+	// 機器の同一性 API
+	huma.Register(api, huma.Operation{
+		OperationID: "get-identity-map",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/identities",
+		Summary:     "Get device identity map",
+		Description: "List the boxes that NetBox, Prometheus and LLDP describe under different device IDs, with the canonical ID " +
+			"and how each alias was matched. Devices sharing a serial number, chassis MAC or management IP are merged " +
+			"automatically; groups with differing serial numbers and devices sharing only a similar hostname are listed " +
+			"as conflicts until decided.",
+		Tags: []string{"identities"},
+	}, h.GetMap)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-identity-conflicts",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/identities/conflicts",
+		Summary:     "List unresolved identity conflicts",
+		Tags:        []string{"identities"},
+	}, h.ListConflicts)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-device-identity",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/devices/{deviceId}/identity",
+		Summary:     "Get canonical identity of device",
+		Tags:        []string{"identities"},
+	}, h.GetDeviceIdentity)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-identity-decisions",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/identities/decisions",
+		Summary:     "List identity decisions",
+		Tags:        []string{"identities"},
+	}, h.ListDecisions)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "decide-identity",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/identities/decisions",
+		Summary:     "Resolve identity conflict",
+		Description: "Declare the devices the same box known by canonical_id, or distinct boxes when canonical_id is empty. " +
+			"A decision replaces an earlier one on the same devices. Devices not matched automatically can be merged as well.",
+		Tags: []string{"identities"},
+	}, h.Decide)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-identity-decision",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/identities/decisions/{key}",
+		Summary:     "Delete identity decision",
+		Tags:        []string{"identities"},
+	}, h.DeleteDecision)
+}
+
+func (h *IdentityHandler) GetMap(ctx context.Context, req *struct{}) (*IdentityMapResponse, error) {
+	identities, err := h.identityService.Map(ctx)
+	if err != nil {
+		h.logger.Error("Failed to resolve identities", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to resolve identities", err)
+	}
+
+	return &IdentityMapResponse{Body: *identities}, nil
+}
+
+func (h *IdentityHandler) ListConflicts(ctx context.Context, req *struct{}) (*IdentityConflictsResponse, error) {
+	identities, err := h.identityService.Map(ctx)
+	if err != nil {
+		h.logger.Error("Failed to resolve identities", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to resolve identities", err)
+	}
+
+	resp := &IdentityConflictsResponse{}
+	resp.Body.Conflicts = identities.Conflicts
+	resp.Body.Count = len(identities.Conflicts)
+	return resp, nil
+}
+
+func (h *IdentityHandler) GetDeviceIdentity(ctx context.Context, req *struct {
+	DeviceID string `path:"deviceId" doc:"Device ID"`
+}) (*DeviceIdentityResponse, error) {
+	identities, err := h.identityService.Map(ctx)
+	if err != nil {
+		h.logger.Error("Failed to resolve identities", "device_id", req.DeviceID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to resolve identities", err)
+	}
+
+	resp := &DeviceIdentityResponse{}
+	resp.Body.DeviceID = req.DeviceID
+	resp.Body.CanonicalID, resp.Body.Identity = identities.Lookup(req.DeviceID)
+	return resp, nil
+}
+
+func (h *IdentityHandler) ListDecisions(ctx context.Context, req *struct{}) (*IdentityDecisionsResponse, error) {
+	decisions, err := h.identityService.Decisions(ctx)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to list identity decisions", err)
+	}
+
+	resp := &IdentityDecisionsResponse{}
+	resp.Body.Decisions = decisions
+	resp.Body.Count = len(decisions)
+	return resp, nil
+}
+
+func (h *IdentityHandler) Decide(ctx context.Context, req *IdentityDecisionRequest) (*IdentityDecisionResponse, error) {
+	decision, err := h.identityService.Decide(ctx, topology.IdentityDecision{
+		Devices:     req.Body.Devices,
+		CanonicalID: req.Body.CanonicalID,
+		Note:        req.Body.Note,
+	}, requestUser(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidIdentityDecision) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to store identity decision", "devices", req.Body.Devices, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to store identity decision", err)
+	}
+
+	h.logger.Info("Identity decision stored", "key", decision.Key, "canonical_id", decision.CanonicalID, "user", decision.DecidedBy)
+	return &IdentityDecisionResponse{Body: *decision}, nil
+}
+
+func (h *IdentityHandler) DeleteDecision(ctx context.Context, req *struct {
+	Key string `path:"key" doc:"Decision key, the device IDs sorted and joined by commas"`
+}) (*struct{}, error) {
+	if err := h.identityService.DeleteDecision(ctx, req.Key); err != nil {
+		if errors.Is(err, service.ErrIdentityDecisionNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		h.logger.Error("Failed to delete identity decision", "key", req.Key, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to delete identity decision", err)
+	}
+
+	h.logger.Info("Identity decision deleted", "key", req.Key, "user", requestUser(ctx))
+	return &struct{}{}, nil
+}
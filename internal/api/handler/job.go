@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/job"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type JobHandler struct {
+	jobService *service.JobService
+	logger     *logger.Logger
+}
+
+func NewJobHandler(jobService *service.JobService, appLogger *logger.Logger) *JobHandler {
+	return &JobHandler{
+		jobService: jobService,
+		logger:     appLogger.WithComponent("job_handler"),
+	}
+}
+
+// SubmitJobRequest enqueues a job
+type SubmitJobRequest struct {
+	Body struct {
+		Kind        string          `json:"kind" example:"snapshot" doc:"Job kind (snapshot, report.consistency, report.hardware_compliance, report.metadata_compliance, export)"`
+		Payload     json.RawMessage `json:"payload,omitempty" doc:"Kind-specific parameters"`
+		MaxAttempts int             `json:"max_attempts,omitempty" doc:"Attempts before the job is marked failed (default 3)"`
+	}
+}
+
+type JobResponse struct {
+	Body job.Job
+}
+
+type JobListResponse struct {
+	Body struct {
+		Jobs  []job.Job `json:"jobs"`
+		Count int       `json:"count"`
+	}
+}
+
+func (h *JobHandler) Register(api huma.API) {
+	// ジョブキュー API
+	huma.Register(api, huma.Operation{
+		OperationID: "submit-job",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/jobs",
+		Summary:     "Submit job",
+		Description: "Enqueue a long-running task. Jobs are stored in the database and run by any API instance, " +
+			"survive restarts and are retried with exponential backoff; poll the job until it succeeded or failed.",
+		Tags: []string{"jobs"},
+	}, h.SubmitJob)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-jobs",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/jobs",
+		Summary:     "List jobs",
+		Description: "List the newest jobs, optionally restricted to a status",
+		Tags:        []string{"jobs"},
+	}, h.ListJobs)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-job",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/jobs/{id}",
+		Summary:     "Get job",
+		Description: "Get the status of a job and, once it succeeded, its result",
+		Tags:        []string{"jobs"},
+	}, h.GetJob)
+}
+
+func (h *JobHandler) SubmitJob(ctx context.Context, req *SubmitJobRequest) (*JobResponse, error) {
+	submitted, err := h.jobService.Submit(ctx, req.Body.Kind, req.Body.Payload, req.Body.MaxAttempts, requestUser(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidJob) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to submit job", "kind", req.Body.Kind, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to submit job", err)
+	}
+
+	return &JobResponse{Body: *submitted}, nil
+}
+
+func (h *JobHandler) ListJobs(ctx context.Context, req *struct {
+	Status string `query:"status" enum:"queued,running,succeeded,failed" doc:"Only return jobs with this status"`
+	Limit  int    `query:"limit" default:"50" minimum:"1" maximum:"500"`
+}) (*JobListResponse, error) {
+	jobs, err := h.jobService.ListJobs(ctx, req.Status, req.Limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidJob) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to list jobs", err)
+	}
+
+	resp := &JobListResponse{}
+	resp.Body.Jobs = jobs
+	resp.Body.Count = len(jobs)
+	return resp, nil
+}
+
+func (h *JobHandler) GetJob(ctx context.Context, req *struct {
+	ID string `path:"id" doc:"Job ID"`
+}) (*JobResponse, error) {
+	found, err := h.jobService.GetJob(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrJobNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to get job", err)
+	}
+
+	return &JobResponse{Body: *found}, nil
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type MaintenanceHandler struct {
+	maintenanceService *service.MaintenanceService
+	logger             *logger.Logger
+}
+
+func NewMaintenanceHandler(maintenanceService *service.MaintenanceService, appLogger *logger.Logger) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceService: maintenanceService,
+		logger:             appLogger.WithComponent("maintenance_handler"),
+	}
+}
+
+// ScheduleMaintenanceRequest stores a maintenance window
+type ScheduleMaintenanceRequest struct {
+	Body struct {
+		Ticket      string    `json:"ticket" example:"CHG-1234" doc:"Change ticket of the maintenance"`
+		Description string    `json:"description,omitempty" doc:"Free-form description"`
+		StartsAt    time.Time `json:"starts_at" doc:"Start of the window"`
+		EndsAt      time.Time `json:"ends_at" doc:"End of the window"`
+		Devices     []string  `json:"devices,omitempty" doc:"Devices taken down"`
+		Links       []string  `json:"links,omitempty" doc:"Links taken down, by link ID"`
+	}
+}
+
+// MaintenanceImpactRequest previews the impact of a window
+type MaintenanceImpactRequest struct {
+	Body struct {
+		StartsAt time.Time          `json:"starts_at" doc:"Start of the window"`
+		EndsAt   time.Time          `json:"ends_at" doc:"End of the window"`
+		Devices  []string           `json:"devices,omitempty" doc:"Devices taken down"`
+		Links    []string           `json:"links,omitempty" doc:"Links taken down, by link ID"`
+		WindowID string             `json:"window_id,omitempty" doc:"ID of a scheduled window being re-evaluated, so it is not counted twice"`
+		Targets  topology.DeviceSet `json:"targets" doc:"Devices that must keep a path, e.g. the server layer"`
+		Uplinks  topology.DeviceSet `json:"uplinks" doc:"Devices the targets must keep reaching, e.g. borders or core"`
+	}
+}
+
+type MaintenanceWindowResponse struct {
+	Body topology.MaintenanceWindow
+}
+
+type MaintenanceWindowsResponse struct {
+	Body struct {
+		Windows []topology.MaintenanceWindow `json:"windows"`
+		Count   int                          `json:"count"`
+	}
+}
+
+type MaintenanceImpactResponse struct {
+	Body topology.MaintenanceImpact
+}
+
+func (h *MaintenanceHandler) Register(api huma.API) {
+	// 保守作業 API
+	huma.Register(api, huma.Operation{
+		OperationID: "list-maintenance-windows",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/maintenance/windows",
+		Summary:     "List maintenance windows",
+		Description: "List scheduled maintenance windows, optionally only those active at some time between from and to",
+		Tags:        []string{"maintenance"},
+	}, h.ListWindows)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "schedule-maintenance-window",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/maintenance/windows",
+		Summary:     "Schedule maintenance window",
+		Description: "Schedule a period during which devices and links are taken down. Scheduled windows are counted as down " +
+			"when previewing the impact of windows overlapping them.",
+		Tags: []string{"maintenance"},
+	}, h.ScheduleWindow)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "cancel-maintenance-window",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/maintenance/windows/{windowId}",
+		Summary:     "Cancel maintenance window",
+		Tags:        []string{"maintenance"},
+	}, h.CancelWindow)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "preview-maintenance-impact",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/maintenance/impact",
+		Summary:     "Preview maintenance impact",
+		Description: "List the targets that reach an uplink today but would lose every path to all uplinks while the window " +
+			"and the scheduled windows overlapping it are active. Targets keeping any redundant path are not reported. " +
+			"The cause is down (the target itself is taken down), planned (the window alone cuts it off) or overlap " +
+			"(only together with the listed overlapping windows). Nothing is stored.",
+		Tags: []string{"maintenance", "analysis"},
+	}, h.PreviewImpact)
+}
+
+func (h *MaintenanceHandler) ListWindows(ctx context.Context, req *struct {
+	From time.Time `query:"from" doc:"Only windows ending after this time (RFC 3339)"`
+	To   time.Time `query:"to" doc:"Only windows starting before this time (RFC 3339)"`
+}) (*MaintenanceWindowsResponse, error) {
+	windows, err := h.maintenanceService.ListWindows(ctx, req.From, req.To)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to list maintenance windows", err)
+	}
+
+	resp := &MaintenanceWindowsResponse{}
+	resp.Body.Windows = windows
+	resp.Body.Count = len(windows)
+	return resp, nil
+}
+
+func (h *MaintenanceHandler) ScheduleWindow(ctx context.Context, req *ScheduleMaintenanceRequest) (*MaintenanceWindowResponse, error) {
+	window, err := h.maintenanceService.ScheduleWindow(ctx, topology.MaintenanceWindow{
+		Ticket:      req.Body.Ticket,
+		Description: req.Body.Description,
+		StartsAt:    req.Body.StartsAt,
+		EndsAt:      req.Body.EndsAt,
+		Devices:     req.Body.Devices,
+		Links:       req.Body.Links,
+	}, requestUser(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMaintenanceWindow) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to schedule maintenance window", "ticket", req.Body.Ticket, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to schedule maintenance window", err)
+	}
+
+	h.logger.Info("Maintenance window scheduled", "id", window.ID, "ticket", window.Ticket, "user", window.CreatedBy)
+	return &MaintenanceWindowResponse{Body: *window}, nil
+}
+
+func (h *MaintenanceHandler) CancelWindow(ctx context.Context, req *struct {
+	WindowID string `path:"windowId" doc:"Maintenance window ID"`
+}) (*struct{}, error) {
+	if err := h.maintenanceService.CancelWindow(ctx, req.WindowID); err != nil {
+		if errors.Is(err, service.ErrMaintenanceWindowNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		h.logger.Error("Failed to cancel maintenance window", "id", req.WindowID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to cancel maintenance window", err)
+	}
+
+	h.logger.Info("Maintenance window cancelled", "id", req.WindowID, "user", requestUser(ctx))
+	return &struct{}{}, nil
+}
+
+func (h *MaintenanceHandler) PreviewImpact(ctx context.Context, req *MaintenanceImpactRequest) (*MaintenanceImpactResponse, error) {
+	impact, err := h.maintenanceService.PreviewImpact(ctx, topology.MaintenanceWindow{
+		ID:       req.Body.WindowID,
+		StartsAt: req.Body.StartsAt,
+		EndsAt:   req.Body.EndsAt,
+		Devices:  req.Body.Devices,
+		Links:    req.Body.Links,
+	}, req.Body.Targets, req.Body.Uplinks)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMaintenanceWindow) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to preview maintenance impact", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to preview maintenance impact", err)
+	}
+
+	return &MaintenanceImpactResponse{Body: *impact}, nil
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type ManagementReachabilityHandler struct {
+	managementService *service.ManagementReachabilityService
+	logger            *logger.Logger
+}
+
+func NewManagementReachabilityHandler(managementService *service.ManagementReachabilityService, appLogger *logger.Logger) *ManagementReachabilityHandler {
+	return &ManagementReachabilityHandler{
+		managementService: managementService,
+		logger:            appLogger.WithComponent("management_reachability_handler"),
+	}
+}
+
+func (h *ManagementReachabilityHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-management-reachability",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/analysis/management-reachability",
+		Summary:     "Get monitored but unmanageable devices",
+		Description: "List the devices seen by monitoring within monitored_within whose management address accepted neither SSH nor NETCONF " +
+			"at the last check of the worker (worker --enable-mgmt-check). Management-plane reachability is recorded apart from the data-plane status.",
+		Tags: []string{"analysis"},
+	}, h.GetReport)
+}
+
+type ManagementReachabilityResponse struct {
+	Body topology.ManagementReachabilityReport
+}
+
+func (h *ManagementReachabilityHandler) GetReport(ctx context.Context, input *struct {
+	MonitoredWithin string `query:"monitored_within" default:"24h" doc:"Only devices seen by monitoring within this duration"`
+}) (*ManagementReachabilityResponse, error) {
+	monitoredWithin, err := time.ParseDuration(input.MonitoredWithin)
+	if err != nil {
+		return nil, huma.Error400BadRequest("Invalid monitored_within", err)
+	}
+
+	report, err := h.managementService.Report(ctx, monitoredWithin)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMonitoredWithin) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to build management reachability report", err)
+	}
+
+	return &ManagementReachabilityResponse{Body: *report}, nil
+}
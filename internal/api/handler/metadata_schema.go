@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type MetadataComplianceHandler struct {
+	complianceService *service.MetadataComplianceService
+	logger            *logger.Logger
+}
+
+func NewMetadataComplianceHandler(complianceService *service.MetadataComplianceService, appLogger *logger.Logger) *MetadataComplianceHandler {
+	return &MetadataComplianceHandler{
+		complianceService: complianceService,
+		logger:            appLogger.WithComponent("metadata_compliance_handler"),
+	}
+}
+
+func (h *MetadataComplianceHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-metadata-compliance",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/analysis/metadata",
+		Summary:     "Get required metadata violations",
+		Description: "List the devices lacking the metadata keys required for their device type by lint.metadata in the " +
+			"config file. A device is checked as its classified device type, or as its discovered type while unclassified; " +
+			"blank values count as missing. Devices of types without requirements are not checked.",
+		Tags: []string{"analysis"},
+	}, h.GetReport)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-metadata-schema",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/analysis/metadata/schema",
+		Summary:     "Get required metadata per device type",
+		Tags:        []string{"analysis"},
+	}, h.GetSchema)
+}
+
+type MetadataComplianceResponse struct {
+	Body topology.MetadataComplianceReport
+}
+
+type MetadataSchemaResponse struct {
+	Body topology.MetadataSchema
+}
+
+func (h *MetadataComplianceHandler) GetReport(ctx context.Context, input *struct {
+	DeviceType string `query:"device_type" doc:"Only devices of this device type"`
+}) (*MetadataComplianceResponse, error) {
+	report, err := h.complianceService.Report(ctx, input.DeviceType)
+	if err != nil {
+		h.logger.Error("Failed to check required metadata", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to build metadata compliance report", err)
+	}
+
+	return &MetadataComplianceResponse{Body: *report}, nil
+}
+
+func (h *MetadataComplianceHandler) GetSchema(ctx context.Context, input *struct{}) (*MetadataSchemaResponse, error) {
+	return &MetadataSchemaResponse{Body: h.complianceService.Schema()}, nil
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type MetricsHandler struct {
+	metricsService *service.MetricsProxyService
+	logger         *logger.Logger
+}
+
+func NewMetricsHandler(metricsService *service.MetricsProxyService, appLogger *logger.Logger) *MetricsHandler {
+	return &MetricsHandler{
+		metricsService: metricsService,
+		logger:         appLogger.WithComponent("metrics_handler"),
+	}
+}
+
+// DeviceMetricRequest selects a whitelisted metric of a device
+type DeviceMetricRequest struct {
+	DeviceID  string `path:"deviceId" doc:"Device ID"`
+	Metric    string `path:"metric" example:"traffic_in" doc:"Metric name (see GET /api/v1/metrics)"`
+	Interface string `query:"interface" example:"Ethernet1" doc:"Interface, required by per-interface metrics"`
+	Range     string `query:"range" example:"1h" doc:"Time range as a Go duration (empty = current value)"`
+	Step      string `query:"step" example:"1m" doc:"Resolution of a range query as a Go duration (default: range / max_points, at least 15s)"`
+}
+
+type MetricDefinitionsResponse struct {
+	Body struct {
+		Metrics []topology.MetricDefinition `json:"metrics"`
+		Count   int                         `json:"count"`
+	}
+}
+
+type DeviceMetricResponse struct {
+	Body topology.DeviceMetric
+}
+
+func (h *MetricsHandler) Register(api huma.API) {
+	// フロントエンド向けのメトリクス取得 API（許可されたクエリのみ）
+	huma.Register(api, huma.Operation{
+		OperationID: "list-metrics",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/metrics",
+		Summary:     "List metrics",
+		Description: "List the metrics that can be fetched for devices and interfaces",
+		Tags:        []string{"metrics"},
+	}, h.ListMetrics)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-device-metric",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/devices/{deviceId}/metrics/{metric}",
+		Summary:     "Get device metric",
+		Description: "Fetch a whitelisted metric of a device or interface from Prometheus. Results are cached; " +
+			"queries that miss the cache are rate limited and rejected with 429 when the budget is exhausted.",
+		Tags: []string{"metrics"},
+	}, h.GetDeviceMetric)
+}
+
+func (h *MetricsHandler) ListMetrics(ctx context.Context, req *struct{}) (*MetricDefinitionsResponse, error) {
+	resp := &MetricDefinitionsResponse{}
+	resp.Body.Metrics = h.metricsService.ListMetrics()
+	resp.Body.Count = len(resp.Body.Metrics)
+	return resp, nil
+}
+
+func (h *MetricsHandler) GetDeviceMetric(ctx context.Context, req *DeviceMetricRequest) (*DeviceMetricResponse, error) {
+	query := service.MetricQuery{
+		DeviceID:  req.DeviceID,
+		Metric:    req.Metric,
+		Interface: req.Interface,
+	}
+	if req.Range != "" {
+		parsed, err := time.ParseDuration(req.Range)
+		if err != nil {
+			return nil, huma.Error400BadRequest("Invalid range", err)
+		}
+		query.Range = parsed
+	}
+	if req.Step != "" {
+		parsed, err := time.ParseDuration(req.Step)
+		if err != nil {
+			return nil, huma.Error400BadRequest("Invalid step", err)
+		}
+		query.Step = parsed
+	}
+
+	metric, err := h.metricsService.GetDeviceMetric(ctx, query)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMetricQuery):
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		case errors.Is(err, service.ErrUnknownMetric), errors.Is(err, service.ErrMetricDeviceNotFound):
+			return nil, huma.Error404NotFound(err.Error(), err)
+		case errors.Is(err, service.ErrMetricsRateLimited):
+			return nil, huma.Error429TooManyRequests("Too many metric queries; retry later", err)
+		}
+		if typed, ok := apperror.From(err); ok && typed.Kind == apperror.KindDependencyUnavailable {
+			h.logger.Warn("Prometheus query failed", "device_id", req.DeviceID, "metric", req.Metric, "error", err)
+			return nil, huma.Error503ServiceUnavailable("Prometheus unavailable", err)
+		}
+		h.logger.Error("Failed to get device metric", "device_id", req.DeviceID, "metric", req.Metric, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to get device metric", err)
+	}
+
+	return &DeviceMetricResponse{Body: *metric}, nil
+}
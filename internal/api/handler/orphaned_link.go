@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type OrphanedLinkHandler struct {
+	orphanService *service.OrphanedLinkService
+	logger        *logger.Logger
+}
+
+func NewOrphanedLinkHandler(orphanService *service.OrphanedLinkService, appLogger *logger.Logger) *OrphanedLinkHandler {
+	return &OrphanedLinkHandler{
+		orphanService: orphanService,
+		logger:        appLogger.WithComponent("orphaned_link_handler"),
+	}
+}
+
+func (h *OrphanedLinkHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "list-orphaned-links",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/links/orphaned",
+		Summary:     "List links still reported for deleted devices",
+		Description: "List the devices deleted within sync.orphan_links.grace_period, oldest deletion first, with the links " +
+			"their neighbors still reported in the latest sync. The worker drops those links instead of recreating the devices " +
+			"as placeholders until expires_at; links reported after that are stored again.",
+		Tags: []string{"analysis"},
+	}, h.ListOrphanedLinks)
+}
+
+type OrphanedLinkResponse struct {
+	Body topology.OrphanedLinkReport
+}
+
+func (h *OrphanedLinkHandler) ListOrphanedLinks(ctx context.Context, input *struct{}) (*OrphanedLinkResponse, error) {
+	report, err := h.orphanService.Report(ctx, time.Now())
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to list deleted devices", err)
+	}
+	return &OrphanedLinkResponse{Body: *report}, nil
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type OverviewHandler struct {
+	topologyService *service.TopologyService
+	fabricService   *service.FabricService // nil = ファブリック単位の集約なし
+	logger          *logger.Logger
+}
+
+func NewOverviewHandler(topologyService *service.TopologyService, fabricService *service.FabricService, appLogger *logger.Logger) *OverviewHandler {
+	return &OverviewHandler{
+		topologyService: topologyService,
+		fabricService:   fabricService,
+		logger:          appLogger.WithComponent("overview_handler"),
+	}
+}
+
+func (h *OverviewHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-topology-overview",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/topology/overview",
+		Summary:     "Get multi-site overview",
+		Description: "Collapse each site (devices sharing the metadata value site_key) or each fabric into a single node and aggregate " +
+			"the links between them with their count and total bandwidth. The small graph serves as a landing page before drilling into a site.",
+		Tags: []string{"visualization"},
+	}, h.GetOverview)
+}
+
+type OverviewResponse struct {
+	Body topology.Overview
+}
+
+func (h *OverviewHandler) GetOverview(ctx context.Context, input *struct {
+	GroupBy string `query:"group_by" enum:"site,fabric" default:"site" doc:"Collapse devices by site metadata or by fabric"`
+	SiteKey string `query:"site_key" default:"site" doc:"Device metadata key holding the site (group_by=site)"`
+}) (*OverviewResponse, error) {
+	var overview *topology.Overview
+	var err error
+	switch input.GroupBy {
+	case "fabric":
+		if h.fabricService == nil {
+			return nil, huma.Error501NotImplemented("Fabrics are not supported by this repository")
+		}
+		overview, err = h.fabricService.GetFabricOverview(ctx)
+	default:
+		overview, err = h.topologyService.GetSiteOverview(ctx, input.SiteKey)
+	}
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to build topology overview", err)
+	}
+
+	return &OverviewResponse{Body: *overview}, nil
+}
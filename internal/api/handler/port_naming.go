@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type PortNamingHandler struct {
+	lintService *service.PortNamingLintService
+	logger      *logger.Logger
+}
+
+func NewPortNamingHandler(lintService *service.PortNamingLintService, appLogger *logger.Logger) *PortNamingHandler {
+	return &PortNamingHandler{
+		lintService: lintService,
+		logger:      appLogger.WithComponent("port_naming_handler"),
+	}
+}
+
+func (h *PortNamingHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-port-naming-report",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/analysis/port-naming",
+		Summary:     "Get port naming violations",
+		Description: "Check the port names and descriptions of the discovered links against the per-site conventions of " +
+			"lint.port_naming in the config file. The first policy matching the site and type of a device applies. " +
+			"Descriptions are only known for the remote end of an LLDP neighbor. Port counts cover every site.",
+		Tags: []string{"analysis"},
+	}, h.GetReport)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-port-naming-policies",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/analysis/port-naming/policies",
+		Summary:     "Get port naming policies",
+		Tags:        []string{"analysis"},
+	}, h.GetPolicies)
+}
+
+type PortNamingReportResponse struct {
+	Body topology.PortNamingReport
+}
+
+type PortNamingPoliciesResponse struct {
+	Body topology.PortNamingLint
+}
+
+func (h *PortNamingHandler) GetReport(ctx context.Context, input *struct {
+	Site string `query:"site" doc:"Only violations of this site"`
+}) (*PortNamingReportResponse, error) {
+	report, err := h.lintService.Report(ctx, input.Site)
+	if err != nil {
+		h.logger.Error("Failed to lint port naming", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to build port naming report", err)
+	}
+
+	return &PortNamingReportResponse{Body: *report}, nil
+}
+
+func (h *PortNamingHandler) GetPolicies(ctx context.Context, input *struct{}) (*PortNamingPoliciesResponse, error) {
+	return &PortNamingPoliciesResponse{Body: h.lintService.Policies()}, nil
+}
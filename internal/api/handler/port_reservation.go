@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type PortReservationHandler struct {
+	reservationService *service.PortReservationService
+	logger             *logger.Logger
+}
+
+func NewPortReservationHandler(reservationService *service.PortReservationService, appLogger *logger.Logger) *PortReservationHandler {
+	return &PortReservationHandler{
+		reservationService: reservationService,
+		logger:             appLogger.WithComponent("port_reservation_handler"),
+	}
+}
+
+// ReservePortRequest reserves a port for future cabling
+type ReservePortRequest struct {
+	Body struct {
+		DeviceID    string    `json:"device_id" example:"leaf-01" doc:"Device of the reserved port"`
+		Port        string    `json:"port" example:"Ethernet12" doc:"Reserved port"`
+		PeerDevice  string    `json:"peer_device,omitempty" example:"server-42" doc:"Planned neighbor; without it any neighbor discovered on the port is a conflict"`
+		PeerPort    string    `json:"peer_port,omitempty" example:"eth0" doc:"Port of the planned neighbor (empty matches any port)"`
+		Ticket      string    `json:"ticket" example:"CHG-1234" doc:"Change ticket of the cabling work"`
+		Description string    `json:"description,omitempty" doc:"Free-form description"`
+		ExpiresAt   time.Time `json:"expires_at" doc:"When the reservation lapses and the port is free again"`
+	}
+}
+
+type PortReservationResponse struct {
+	Body topology.PortReservation
+}
+
+type PortReservationsResponse struct {
+	Body struct {
+		Reservations []topology.PortReservation `json:"reservations"`
+		Count        int                        `json:"count"`
+	}
+}
+
+type InterfaceInventoryResponse struct {
+	Body struct {
+		DeviceID string                `json:"device_id"`
+		Ports    []topology.DevicePort `json:"ports"`
+		Count    int                   `json:"count"`
+	}
+}
+
+func (h *PortReservationHandler) Register(api huma.API) {
+	// ポート予約 API
+	huma.Register(api, huma.Operation{
+		OperationID: "list-port-reservations",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/port-reservations",
+		Summary:     "List port reservations",
+		Description: "List port reservations with their status: reserved (port free), fulfilled (the planned neighbor was discovered), " +
+			"conflict (a different neighbor was discovered) or expired",
+		Tags: []string{"port-reservations"},
+	}, h.ListReservations)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "reserve-port",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/port-reservations",
+		Summary:     "Reserve port",
+		Description: "Reserve a device port for future cabling. A port holds one active reservation at a time",
+		Tags:        []string{"port-reservations"},
+	}, h.ReservePort)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "release-port-reservation",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/port-reservations/{reservationId}",
+		Summary:     "Release port reservation",
+		Tags:        []string{"port-reservations"},
+	}, h.ReleaseReservation)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-interface-inventory",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/devices/{deviceId}/interfaces",
+		Summary:     "Get interface inventory",
+		Description: "List the ports of a device known from its links and its active reservations, with the discovered neighbor and the reservation of each port",
+		Tags:        []string{"port-reservations", "devices"},
+	}, h.GetInterfaceInventory)
+}
+
+func (h *PortReservationHandler) ListReservations(ctx context.Context, req *struct {
+	DeviceID string `query:"device_id" doc:"Only reservations of this device"`
+	Status   string `query:"status" enum:"reserved,fulfilled,conflict,expired" doc:"Only reservations in this status"`
+}) (*PortReservationsResponse, error) {
+	reservations, err := h.reservationService.ListReservations(ctx, req.DeviceID, req.Status)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to list port reservations", err)
+	}
+
+	resp := &PortReservationsResponse{}
+	resp.Body.Reservations = reservations
+	resp.Body.Count = len(reservations)
+	return resp, nil
+}
+
+func (h *PortReservationHandler) ReservePort(ctx context.Context, req *ReservePortRequest) (*PortReservationResponse, error) {
+	reservation, err := h.reservationService.ReservePort(ctx, topology.PortReservation{
+		DeviceID:    req.Body.DeviceID,
+		Port:        req.Body.Port,
+		PeerDevice:  req.Body.PeerDevice,
+		PeerPort:    req.Body.PeerPort,
+		Ticket:      req.Body.Ticket,
+		Description: req.Body.Description,
+		ExpiresAt:   req.Body.ExpiresAt,
+	}, requestUser(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPortReservation):
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		case errors.Is(err, service.ErrReservationDeviceNotFound):
+			return nil, huma.Error404NotFound(err.Error(), err)
+		case errors.Is(err, service.ErrPortAlreadyReserved):
+			return nil, huma.Error409Conflict(err.Error(), err)
+		}
+		h.logger.Error("Failed to reserve port", "device_id", req.Body.DeviceID, "port", req.Body.Port, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to reserve port", err)
+	}
+
+	return &PortReservationResponse{Body: *reservation}, nil
+}
+
+func (h *PortReservationHandler) ReleaseReservation(ctx context.Context, req *struct {
+	ReservationID string `path:"reservationId" doc:"Reservation ID"`
+}) (*struct{}, error) {
+	if err := h.reservationService.ReleaseReservation(ctx, req.ReservationID); err != nil {
+		if errors.Is(err, service.ErrPortReservationNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to release port reservation", err)
+	}
+
+	return &struct{}{}, nil
+}
+
+func (h *PortReservationHandler) GetInterfaceInventory(ctx context.Context, req *struct {
+	DeviceID string `path:"deviceId" doc:"Device ID"`
+}) (*InterfaceInventoryResponse, error) {
+	ports, err := h.reservationService.GetInterfaceInventory(ctx, req.DeviceID)
+	if err != nil {
+		if errors.Is(err, service.ErrReservationDeviceNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to get interface inventory", err)
+	}
+
+	resp := &InterfaceInventoryResponse{}
+	resp.Body.DeviceID = req.DeviceID
+	resp.Body.Ports = ports
+	resp.Body.Count = len(ports)
+	return resp, nil
+}
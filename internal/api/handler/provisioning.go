@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type ProvisioningHandler struct {
+	provisioningService *service.ProvisioningService
+	logger              *logger.Logger
+}
+
+func NewProvisioningHandler(provisioningService *service.ProvisioningService, appLogger *logger.Logger) *ProvisioningHandler {
+	return &ProvisioningHandler{
+		provisioningService: provisioningService,
+		logger:              appLogger.WithComponent("provisioning_handler"),
+	}
+}
+
+// PlanDeviceRequest registers a planned device from a template
+type PlanDeviceRequest struct {
+	Body struct {
+		ID       string                  `json:"id" doc:"Device ID the device is expected to report"`
+		Template topology.DeviceTemplate `json:"template" doc:"Expected type, layer and uplinks"`
+	}
+}
+
+type PlannedDeviceResponse struct {
+	Body topology.PlannedDevice
+}
+
+type PlannedDevicesResponse struct {
+	Body struct {
+		Devices []topology.PlannedDevice `json:"devices"`
+		Count   int                      `json:"count"`
+	}
+}
+
+func (h *ProvisioningHandler) Register(api huma.API) {
+	// 計画デバイス（プロビジョニングスタブ）API
+	huma.Register(api, huma.Operation{
+		OperationID: "plan-device",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/provisioning/devices",
+		Summary:     "Register a planned device",
+		Description: "Pre-register a device from a template before it appears in monitoring; sync merges it with the discovered device and flags mismatches",
+		Tags:        []string{"provisioning"},
+	}, h.PlanDevice)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-planned-devices",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/provisioning/devices",
+		Summary:     "List planned devices",
+		Description: "List planned devices and their reconciliation status",
+		Tags:        []string{"provisioning"},
+	}, h.ListPlannedDevices)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-planned-device",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/provisioning/devices/{device_id}",
+		Summary:     "Get planned device",
+		Description: "Get a planned device including template mismatches",
+		Tags:        []string{"provisioning"},
+	}, h.GetPlannedDevice)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-planned-device",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/provisioning/devices/{device_id}",
+		Summary:     "Delete planned device",
+		Description: "Delete a planned device record (the discovered device is kept)",
+		Tags:        []string{"provisioning"},
+	}, h.DeletePlannedDevice)
+}
+
+func (h *ProvisioningHandler) PlanDevice(ctx context.Context, req *PlanDeviceRequest) (*PlannedDeviceResponse, error) {
+	userID := requestUser(ctx)
+
+	planned, err := h.provisioningService.PlanDevice(ctx, topology.PlannedDevice{
+		ID:       req.Body.ID,
+		Template: req.Body.Template,
+	}, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPlannedDevice) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to register planned device", "device_id", req.Body.ID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to register planned device", err)
+	}
+
+	return &PlannedDeviceResponse{Body: *planned}, nil
+}
+
+func (h *ProvisioningHandler) ListPlannedDevices(ctx context.Context, req *struct {
+	Status string `query:"status" enum:"planned,discovered,mismatch" doc:"Filter by status"`
+}) (*PlannedDevicesResponse, error) {
+	devices, err := h.provisioningService.ListPlannedDevices(ctx, topology.PlannedDeviceStatus(req.Status))
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to list planned devices", err)
+	}
+	if devices == nil {
+		devices = []topology.PlannedDevice{}
+	}
+
+	resp := &PlannedDevicesResponse{}
+	resp.Body.Devices = devices
+	resp.Body.Count = len(devices)
+	return resp, nil
+}
+
+func (h *ProvisioningHandler) GetPlannedDevice(ctx context.Context, req *struct {
+	DeviceID string `path:"device_id" doc:"Device ID"`
+}) (*PlannedDeviceResponse, error) {
+	planned, err := h.provisioningService.GetPlannedDevice(ctx, req.DeviceID)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get planned device", err)
+	}
+	if planned == nil {
+		return nil, huma.Error404NotFound("Planned device not found")
+	}
+
+	return &PlannedDeviceResponse{Body: *planned}, nil
+}
+
+func (h *ProvisioningHandler) DeletePlannedDevice(ctx context.Context, req *struct {
+	DeviceID string `path:"device_id" doc:"Device ID"`
+}) (*struct{}, error) {
+	if err := h.provisioningService.DeletePlannedDevice(ctx, req.DeviceID); err != nil {
+		return nil, huma.Error500InternalServerError("Failed to delete planned device", err)
+	}
+
+	return &struct{}{}, nil
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type RackHandler struct {
+	rackService *service.RackService
+	logger      *logger.Logger
+}
+
+func NewRackHandler(rackService *service.RackService, appLogger *logger.Logger) *RackHandler {
+	return &RackHandler{
+		rackService: rackService,
+		logger:      appLogger.WithComponent("rack_handler"),
+	}
+}
+
+// SetDeviceRackRequest places a device in a rack
+type SetDeviceRackRequest struct {
+	DeviceID string `path:"deviceId" doc:"Device ID"`
+	Body     struct {
+		Rack     string `json:"rack" example:"tyo1-r12" doc:"Rack name; empty to remove the device from its rack"`
+		Position *int   `json:"position,omitempty" example:"40" doc:"Lowest rack unit the device occupies (1-based); omit when unknown"`
+		Units    int    `json:"units,omitempty" example:"1" doc:"Height in rack units (default 1)"`
+	}
+}
+
+// ImportRacksRequest uploads rack placements as CSV
+type ImportRacksRequest struct {
+	RawBody []byte `contentType:"text/csv" doc:"CSV with header device_id,rack,position,units"`
+}
+
+type RackElevationsResponse struct {
+	Body struct {
+		Racks []topology.RackElevation `json:"racks"`
+		Count int                      `json:"count"`
+	}
+}
+
+type RackElevationResponse struct {
+	Body topology.RackElevation
+}
+
+type RackImportResponse struct {
+	Body topology.RackImportResult
+}
+
+func (h *RackHandler) Register(api huma.API) {
+	// ラック図 API
+	huma.Register(api, huma.Operation{
+		OperationID: "list-rack-elevations",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/racks",
+		Summary:     "List rack elevations",
+		Description: "List every rack with its devices sorted by rack unit, lowest first. Devices without a known " +
+			"position come last, and devices sharing rack units list each other in overlaps.",
+		Tags: []string{"racks"},
+	}, h.ListElevations)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-rack-elevation",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/racks/{rack}",
+		Summary:     "Get rack elevation",
+		Description: "Get the devices of a rack sorted by rack unit with the connections of each port, including " +
+			"the rack of the device at the other end, so server-to-switch cabling can be drawn next to the rack.",
+		Tags: []string{"racks"},
+	}, h.GetElevation)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "set-device-rack",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/devices/{deviceId}/rack",
+		Summary:     "Set rack placement of device",
+		Description: "Set the rack, rack unit and height of a device. Syncs do not change the placement.",
+		Tags:        []string{"racks"},
+	}, h.SetDeviceRack)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "import-racks",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/racks/import",
+		Summary:     "Import rack placements from CSV",
+		Description: "Set the rack placements of a CSV export. Rows with an empty rack remove the device from its rack; " +
+			"unknown devices are skipped and listed.",
+		Tags: []string{"racks"},
+	}, h.ImportRacks)
+}
+
+func (h *RackHandler) ListElevations(ctx context.Context, req *struct{}) (*RackElevationsResponse, error) {
+	elevations, err := h.rackService.Elevations(ctx)
+	if err != nil {
+		h.logger.Error("Failed to build rack elevations", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to build rack elevations", err)
+	}
+
+	resp := &RackElevationsResponse{}
+	resp.Body.Racks = elevations
+	resp.Body.Count = len(elevations)
+	return resp, nil
+}
+
+func (h *RackHandler) GetElevation(ctx context.Context, req *struct {
+	Rack string `path:"rack" doc:"Rack name"`
+}) (*RackElevationResponse, error) {
+	elevation, err := h.rackService.Elevation(ctx, req.Rack)
+	if err != nil {
+		if errors.Is(err, service.ErrRackNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		h.logger.Error("Failed to build rack elevation", "rack", req.Rack, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to build rack elevation", err)
+	}
+
+	return &RackElevationResponse{Body: *elevation}, nil
+}
+
+func (h *RackHandler) SetDeviceRack(ctx context.Context, req *SetDeviceRackRequest) (*struct{}, error) {
+	err := h.rackService.SetPlacement(ctx, topology.RackPlacement{
+		DeviceID: req.DeviceID,
+		Rack:     req.Body.Rack,
+		Position: req.Body.Position,
+		Units:    req.Body.Units,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRackPlacement):
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		case errors.Is(err, service.ErrRackDeviceNotFound):
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		h.logger.Error("Failed to set device rack", "device_id", req.DeviceID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to set device rack", err)
+	}
+
+	h.logger.Info("Device rack set", "device_id", req.DeviceID, "rack", req.Body.Rack, "user", requestUser(ctx))
+	return &struct{}{}, nil
+}
+
+func (h *RackHandler) ImportRacks(ctx context.Context, req *ImportRacksRequest) (*RackImportResponse, error) {
+	result, err := h.rackService.ImportPlacements(ctx, bytes.NewReader(req.RawBody))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRackPlacement) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to import rack placements", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to import rack placements", err)
+	}
+
+	return &RackImportResponse{Body: *result}, nil
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/job"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+// jsonSchemaDialect is the JSON Schema version of the exported schemas
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// eventSchema is the payload of a change event that consumers receive or poll
+type eventSchema struct {
+	name        string
+	description string
+	payload     reflect.Type
+}
+
+// eventSchemas lists the change events of the topology
+var eventSchemas = []eventSchema{
+	{"device.event", "One entry of the timeline of a device (classification or workflow change), as in the device overview", reflect.TypeOf(topology.DeviceEvent{})},
+	{"device.workflow_transition", "A change of the workflow state of a device", reflect.TypeOf(topology.WorkflowTransition{})},
+	{"classification.change", "A change of the classification of a device, made by a user or a rule", reflect.TypeOf(classification.ClassificationChange{})},
+	{"classification.suggestions", "New high-confidence rule suggestions of scheduled generation, as posted to the worker's --suggestion-webhook-url", reflect.TypeOf(classification.SuggestionNotification{})},
+	{"sync.diff", "The devices and links a Prometheus synchronization adds, updates or stops refreshing", reflect.TypeOf(topology.SyncDiff{})},
+	{"job", "The state of a queued job and its result", reflect.TypeOf(job.Job{})},
+	{"topology.change", "A device or link written by any writer, as streamed by /api/v1/events/topology", reflect.TypeOf(topology.Change{})},
+}
+
+var rxComponentRef = regexp.MustCompile(`#/components/schemas/([^"]+)`)
+
+type SchemaHandler struct {
+	registry huma.Registry
+	names    map[string]string // イベント名 → スキーマ名
+	logger   *logger.Logger
+}
+
+func NewSchemaHandler(appLogger *logger.Logger) *SchemaHandler {
+	return &SchemaHandler{
+		names:  make(map[string]string),
+		logger: appLogger.WithComponent("schema_handler"),
+	}
+}
+
+// EventSchemaSummary describes one event and where its schema is
+type EventSchemaSummary struct {
+	Name        string `json:"name" example:"device.event"`
+	Description string `json:"description"`
+	Ref         string `json:"ref" example:"#/$defs/DeviceEvent" doc:"Reference of the payload schema in $defs"`
+	URL         string `json:"url" example:"/api/v1/schemas/device.event" doc:"Standalone schema of the payload"`
+}
+
+type EventSchemaListResponse struct {
+	Body struct {
+		Schema string                     `json:"$schema"`
+		Events []EventSchemaSummary       `json:"events"`
+		Defs   map[string]json.RawMessage `json:"$defs" doc:"Payload schemas and the schemas they reference"`
+	}
+}
+
+type GetEventSchemaRequest struct {
+	Name string `path:"name" example:"device.event"`
+}
+
+type EventSchemaResponse struct {
+	Body json.RawMessage
+}
+
+func (h *SchemaHandler) Register(api huma.API) {
+	// ペイロードの型を OpenAPI のコンポーネントとして登録（/schemas/{Name}.json でも参照できる）
+	h.registry = api.OpenAPI().Components.Schemas
+	for _, event := range eventSchemas {
+		schema := h.registry.Schema(event.payload, true, "")
+		h.names[event.name] = strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+	}
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-event-schemas",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/schemas",
+		Summary:     "List event schemas",
+		Description: "JSON Schemas (draft 2020-12) of all change event payloads in one document, for validating and generating code against topology change events.",
+		Tags:        []string{"schemas"},
+	}, h.ListEventSchemas)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-event-schema",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/schemas/{name}",
+		Summary:     "Get event schema",
+		Description: "Standalone JSON Schema of one change event payload, including the schemas it references in $defs.",
+		Tags:        []string{"schemas"},
+	}, h.GetEventSchema)
+}
+
+func (h *SchemaHandler) ListEventSchemas(ctx context.Context, input *struct{}) (*EventSchemaListResponse, error) {
+	resp := &EventSchemaListResponse{}
+	resp.Body.Schema = jsonSchemaDialect
+	resp.Body.Events = make([]EventSchemaSummary, 0, len(eventSchemas))
+
+	var roots []string
+	for _, event := range eventSchemas {
+		name := h.names[event.name]
+		roots = append(roots, name)
+		resp.Body.Events = append(resp.Body.Events, EventSchemaSummary{
+			Name:        event.name,
+			Description: event.description,
+			Ref:         "#/$defs/" + name,
+			URL:         "/api/v1/schemas/" + event.name,
+		})
+	}
+
+	defs, err := h.collectDefs(roots)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to export event schemas", err)
+	}
+	resp.Body.Defs = defs
+	return resp, nil
+}
+
+func (h *SchemaHandler) GetEventSchema(ctx context.Context, input *GetEventSchemaRequest) (*EventSchemaResponse, error) {
+	var event *eventSchema
+	for i := range eventSchemas {
+		if eventSchemas[i].name == input.Name {
+			event = &eventSchemas[i]
+		}
+	}
+	if event == nil {
+		return nil, huma.Error404NotFound("Unknown event: " + input.Name)
+	}
+
+	name := h.names[event.name]
+	defs, err := h.collectDefs([]string{name})
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to export event schema", err)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(defs[name], &document); err != nil {
+		return nil, huma.Error500InternalServerError("Failed to export event schema", err)
+	}
+	delete(defs, name)
+	document["$schema"] = jsonSchemaDialect
+	document["$id"] = "/api/v1/schemas/" + event.name
+	document["title"] = name
+	document["description"] = event.description
+	if len(defs) > 0 {
+		document["$defs"] = defs
+	}
+
+	body, err := json.Marshal(document)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to export event schema", err)
+	}
+	return &EventSchemaResponse{Body: body}, nil
+}
+
+// collectDefs returns the named schemas and every schema they reference, with references
+// rewritten to #/$defs so the result is self-contained
+func (h *SchemaHandler) collectDefs(names []string) (map[string]json.RawMessage, error) {
+	schemas := h.registry.Map()
+	defs := make(map[string]json.RawMessage)
+	queue := append([]string(nil), names...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if _, done := defs[name]; done {
+			continue
+		}
+		schema, ok := schemas[name]
+		if !ok {
+			continue
+		}
+
+		data, err := json.Marshal(schema)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range rxComponentRef.FindAllSubmatch(data, -1) {
+			queue = append(queue, string(match[1]))
+		}
+		defs[name] = rxComponentRef.ReplaceAll(data, []byte("#/$$defs/$1"))
+	}
+	return defs, nil
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+	"github.com/servak/topology-manager/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSchemaHandler(t *testing.T) http.Handler {
+	router := chi.NewRouter()
+	api := humachi.New(router, huma.DefaultConfig("Test API", "1.0.0"))
+	NewSchemaHandler(logger.New("error")).Register(api)
+	return router
+}
+
+var rxRef = regexp.MustCompile(`"\$ref":"([^"]*)"`)
+
+// assertSelfContained checks that every reference in the schemas points into $defs
+func assertSelfContained(t *testing.T, data []byte, defs map[string]json.RawMessage) {
+	t.Helper()
+	for _, match := range rxRef.FindAllSubmatch(data, -1) {
+		ref := string(match[1])
+		require.Regexp(t, `^#/\$defs/`, ref)
+		_, ok := defs[ref[len("#/$defs/"):]]
+		assert.True(t, ok, "reference %s is not in $defs", ref)
+	}
+}
+
+func TestSchemaHandler_ListEventSchemas(t *testing.T) {
+	router := setupSchemaHandler(t)
+
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/schemas", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var body struct {
+		Schema string                     `json:"$schema"`
+		Events []EventSchemaSummary       `json:"events"`
+		Defs   map[string]json.RawMessage `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, jsonSchemaDialect, body.Schema)
+	require.Len(t, body.Events, len(eventSchemas))
+
+	for _, event := range body.Events {
+		assert.Equal(t, "/api/v1/schemas/"+event.Name, event.URL)
+		_, ok := body.Defs[event.Ref[len("#/$defs/"):]]
+		assert.True(t, ok, "payload of %s is not in $defs", event.Name)
+	}
+	assert.NotContains(t, resp.Body.String(), "#/components/schemas/")
+	assertSelfContained(t, resp.Body.Bytes(), body.Defs)
+}
+
+func TestSchemaHandler_GetEventSchema(t *testing.T) {
+	router := setupSchemaHandler(t)
+
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/schemas/device.workflow_transition", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var schema struct {
+		Schema     string                     `json:"$schema"`
+		ID         string                     `json:"$id"`
+		Type       string                     `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]json.RawMessage `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &schema))
+	assert.Equal(t, jsonSchemaDialect, schema.Schema)
+	assert.Equal(t, "/api/v1/schemas/device.workflow_transition", schema.ID)
+	assert.Equal(t, "object", schema.Type)
+	for _, field := range []string{"device_id", "from", "to", "changed_by", "changed_at"} {
+		assert.Contains(t, schema.Properties, field)
+	}
+
+	// 参照先のスキーマも同じドキュメントに含める
+	resp = serveJSON(t, router, http.MethodGet, "/api/v1/schemas/sync.diff", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	schema.Defs = nil
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &schema))
+	assert.NotEmpty(t, schema.Defs)
+	assertSelfContained(t, resp.Body.Bytes(), schema.Defs)
+
+	resp = serveJSON(t, router, http.MethodGet, "/api/v1/schemas/no.such.event", nil)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/service"
+)
+
+type ShadowReportResponse struct {
+	Body classification.ShadowReport
+}
+
+func (h *ClassificationHandler) registerShadowRoutes(api huma.API) {
+	// シャドウルール: デバイスを変更せずに新しいルールの結果を確認する
+	huma.Register(api, huma.Operation{
+		OperationID: "evaluate-shadow-rules",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/classification/shadow/evaluate",
+		Summary:     "Evaluate shadow rules",
+		Description: "Evaluate the active rules against all devices without changing them, record the classifications " +
+			"the shadow rules would make and compare them with the current classifications",
+		Tags: []string{"classification"},
+	}, h.EvaluateShadowRules)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-shadow-report",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/classification/shadow/report",
+		Summary:     "Compare shadow classifications",
+		Description: "Compare the classifications recorded for shadow rules (by the last evaluation or rule application) " +
+			"with the current classifications, per rule and per device",
+		Tags: []string{"classification"},
+	}, h.GetShadowReport)
+}
+
+func (h *ClassificationHandler) EvaluateShadowRules(ctx context.Context, req *struct{}) (*ShadowReportResponse, error) {
+	report, err := h.classificationService.EvaluateShadowRules(ctx)
+	if err != nil {
+		return nil, shadowClassificationError("Failed to evaluate shadow rules", err)
+	}
+
+	return &ShadowReportResponse{Body: *report}, nil
+}
+
+func (h *ClassificationHandler) GetShadowReport(ctx context.Context, req *struct{}) (*ShadowReportResponse, error) {
+	report, err := h.classificationService.GetShadowReport(ctx)
+	if err != nil {
+		return nil, shadowClassificationError("Failed to get shadow report", err)
+	}
+
+	return &ShadowReportResponse{Body: *report}, nil
+}
+
+func shadowClassificationError(msg string, err error) error {
+	if errors.Is(err, service.ErrShadowClassificationUnsupported) {
+		return huma.Error501NotImplemented(err.Error())
+	}
+	return huma.Error500InternalServerError(msg, err)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	apimiddleware "github.com/servak/topology-manager/internal/api/middleware"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type ShareHandler struct {
+	shareService *service.ShareService
+	logger       *logger.Logger
+}
+
+func NewShareHandler(shareService *service.ShareService, appLogger *logger.Logger) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+		logger:       appLogger.WithComponent("share_handler"),
+	}
+}
+
+// ShareLinkRequest creates a share link for the starting view of a role
+type ShareLinkRequest struct {
+	Role string `path:"role" doc:"Role or team name"`
+	Body struct {
+		ExpiresIn string `json:"expires_in,omitempty" example:"72h" doc:"Validity of the link as a Go duration (default 24h, max 720h)"`
+	}
+}
+
+type ShareLinkResponse struct {
+	Body visualization.ShareLink
+}
+
+type SharedTopologyResponse struct {
+	Body visualTopologyBody
+}
+
+func (h *ShareHandler) Register(api huma.API) {
+	// 閲覧専用の共有リンク API
+	huma.Register(api, huma.Operation{
+		OperationID: "create-share-link",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/starting-views/{role}/share",
+		Summary:     "Create share link",
+		Description: "Create a signed, read-only link to the saved starting view of a role. The link expires and needs no account to open.",
+		Tags:        []string{"starting-views", "shares"},
+	}, h.CreateShareLink)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-shared-topology",
+		Method:      http.MethodGet,
+		Path:        service.SharedPathPrefix + "{token}",
+		Summary:     "Get shared topology",
+		Description: "Get the topology of a share link. The token is verified by middleware; expired or tampered tokens are rejected with 401.",
+		Tags:        []string{"shares"},
+	}, h.GetSharedTopology)
+}
+
+func (h *ShareHandler) CreateShareLink(ctx context.Context, req *ShareLinkRequest) (*ShareLinkResponse, error) {
+	userID := requestUser(ctx)
+
+	var ttl time.Duration
+	if req.Body.ExpiresIn != "" {
+		parsed, err := time.ParseDuration(req.Body.ExpiresIn)
+		if err != nil {
+			return nil, huma.Error400BadRequest("Invalid expires_in", err)
+		}
+		ttl = parsed
+	}
+
+	link, err := h.shareService.CreateShareLink(ctx, req.Role, ttl, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidShareLink):
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		case errors.Is(err, service.ErrStartingViewNotFound):
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		h.logger.Error("Failed to create share link", "role", req.Role, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to create share link", err)
+	}
+
+	h.logger.Info("Share link created", "role", link.Role, "expires_at", link.ExpiresAt, "user", userID)
+	return &ShareLinkResponse{Body: *link}, nil
+}
+
+func (h *ShareHandler) GetSharedTopology(ctx context.Context, req *struct {
+	Token string `path:"token" doc:"Share token"`
+	SchemaVersionParams
+}) (*SharedTopologyResponse, error) {
+	// トークンはミドルウェアで検証済み（ミドルウェアを通らない呼び出しは拒否する）
+	claims, ok := apimiddleware.ShareClaimsFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Share token not verified")
+	}
+
+	topology, err := h.shareService.GetSharedTopology(ctx, claims)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get shared topology", err)
+	}
+
+	body, err := newVisualTopologyBody(topology, nil, req.SchemaVersion)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to render topology", err)
+	}
+
+	return &SharedTopologyResponse{Body: *body}, nil
+}
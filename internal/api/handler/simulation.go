@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type SimulationHandler struct {
+	simulationService *service.SimulationService
+	logger            *logger.Logger
+}
+
+func NewSimulationHandler(simulationService *service.SimulationService, appLogger *logger.Logger) *SimulationHandler {
+	return &SimulationHandler{
+		simulationService: simulationService,
+		logger:            appLogger.WithComponent("simulation_handler"),
+	}
+}
+
+func (h *SimulationHandler) Register(api huma.API) {
+	// What-if シミュレーションAPI
+	huma.Register(api, huma.Operation{
+		OperationID: "simulate-topology-changes",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/simulate",
+		Summary:     "Simulate hypothetical topology changes",
+		Description: "Apply hypothetical device/link additions and removals on top of the current topology and compare paths, reachability and oversubscription",
+		Tags:        []string{"topology-search"},
+	}, h.Simulate)
+}
+
+func (h *SimulationHandler) Simulate(ctx context.Context, input *struct {
+	Body topology.SimulationRequest
+}) (*struct {
+	Body topology.SimulationResult
+}, error) {
+	result, err := h.simulationService.Simulate(ctx, input.Body)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSimulation) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to run simulation", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to run simulation", err)
+	}
+
+	return &struct {
+		Body topology.SimulationResult
+	}{
+		Body: *result,
+	}, nil
+}
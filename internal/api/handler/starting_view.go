@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type StartingViewHandler struct {
+	startingViewService *service.StartingViewService
+	logger              *logger.Logger
+}
+
+func NewStartingViewHandler(startingViewService *service.StartingViewService, appLogger *logger.Logger) *StartingViewHandler {
+	return &StartingViewHandler{
+		startingViewService: startingViewService,
+		logger:              appLogger.WithComponent("starting_view_handler"),
+	}
+}
+
+// StartingViewRequest sets the starting view of a role
+type StartingViewRequest struct {
+	Role string `path:"role" doc:"Role or team name ('default' applies to roles without a view)"`
+	Body struct {
+		RootDevice  string                         `json:"root_device" example:"core-01" doc:"Device the topology is centered on"`
+		Depth       int                            `json:"depth,omitempty" doc:"Exploration depth (default 3)"`
+		Grouping    *visualization.GroupingOptions `json:"grouping,omitempty" doc:"Grouping options applied to the view"`
+		Description string                         `json:"description,omitempty" doc:"Free-form description"`
+	}
+}
+
+type StartingViewResponse struct {
+	Body visualization.StartingView
+}
+
+type StartingViewsResponse struct {
+	Body struct {
+		Views []visualization.StartingView `json:"views"`
+		Count int                          `json:"count"`
+	}
+}
+
+func (h *StartingViewHandler) Register(api huma.API) {
+	// ロール別の開始ビュー API
+	huma.Register(api, huma.Operation{
+		OperationID: "list-starting-views",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/starting-views",
+		Summary:     "List starting views",
+		Description: "List the configured starting views of all roles",
+		Tags:        []string{"starting-views"},
+	}, h.ListStartingViews)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-starting-view",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/starting-views/{role}",
+		Summary:     "Get starting view for a role",
+		Description: "Get the topology a role lands on after login, falling back to the 'default' view when the role has none",
+		Tags:        []string{"starting-views"},
+	}, h.GetStartingView)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "set-starting-view",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/starting-views/{role}",
+		Summary:     "Set starting view for a role",
+		Tags:        []string{"starting-views"},
+	}, h.SetStartingView)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-starting-view",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/starting-views/{role}",
+		Summary:     "Delete starting view for a role",
+		Tags:        []string{"starting-views"},
+	}, h.DeleteStartingView)
+}
+
+func (h *StartingViewHandler) ListStartingViews(ctx context.Context, req *struct{}) (*StartingViewsResponse, error) {
+	views, err := h.startingViewService.ListStartingViews(ctx)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to list starting views", err)
+	}
+	if views == nil {
+		views = []visualization.StartingView{}
+	}
+
+	resp := &StartingViewsResponse{}
+	resp.Body.Views = views
+	resp.Body.Count = len(views)
+	return resp, nil
+}
+
+func (h *StartingViewHandler) GetStartingView(ctx context.Context, req *struct {
+	Role string `path:"role" doc:"Role or team name"`
+}) (*StartingViewResponse, error) {
+	view, err := h.startingViewService.ResolveStartingView(ctx, req.Role)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get starting view", err)
+	}
+	if view == nil {
+		return nil, huma.Error404NotFound("Starting view not found")
+	}
+
+	return &StartingViewResponse{Body: *view}, nil
+}
+
+func (h *StartingViewHandler) SetStartingView(ctx context.Context, req *StartingViewRequest) (*StartingViewResponse, error) {
+	userID := requestUser(ctx)
+
+	view, err := h.startingViewService.SaveStartingView(ctx, visualization.StartingView{
+		Role:        req.Role,
+		RootDevice:  req.Body.RootDevice,
+		Depth:       req.Body.Depth,
+		Grouping:    req.Body.Grouping,
+		Description: req.Body.Description,
+	}, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStartingView) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to save starting view", "role", req.Role, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to save starting view", err)
+	}
+
+	return &StartingViewResponse{Body: *view}, nil
+}
+
+func (h *StartingViewHandler) DeleteStartingView(ctx context.Context, req *struct {
+	Role string `path:"role" doc:"Role or team name"`
+}) (*struct{}, error) {
+	if err := h.startingViewService.DeleteStartingView(ctx, req.Role); err != nil {
+		return nil, huma.Error500InternalServerError("Failed to delete starting view", err)
+	}
+
+	return &struct{}{}, nil
+}
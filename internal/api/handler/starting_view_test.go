@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStartingViewHandler(t *testing.T) http.Handler {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+
+	startingViewRepo, ok := setup.Repo.(visualization.StartingViewRepository)
+	require.True(t, ok)
+	handler := NewStartingViewHandler(service.NewStartingViewService(startingViewRepo, setup.Repo), setup.Logger)
+
+	router := chi.NewRouter()
+	api := humachi.New(router, huma.DefaultConfig("Test API", "1.0.0"))
+	handler.Register(api)
+
+	return router
+}
+
+func TestStartingViewHandler(t *testing.T) {
+	router := setupStartingViewHandler(t)
+
+	getView := func(role string) (int, visualization.StartingView) {
+		t.Helper()
+		resp := serveJSON(t, router, http.MethodGet, "/api/v1/starting-views/"+role, nil)
+		var view visualization.StartingView
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &view))
+		}
+		return resp.Code, view
+	}
+
+	code, _ := getView("network-core")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	resp := serveJSON(t, router, http.MethodPut, "/api/v1/starting-views/default", map[string]interface{}{
+		"root_device": "device-001",
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	resp = serveJSON(t, router, http.MethodPut, "/api/v1/starting-views/network-core", map[string]interface{}{
+		"root_device": "device-002",
+		"depth":       2,
+		"grouping":    visualization.GroupingOptions{Enabled: true, MinGroupSize: 3, GroupByPrefix: true},
+	})
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	var saved visualization.StartingView
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &saved))
+	assert.Equal(t, "network-core", saved.Role)
+	assert.Equal(t, "admin", saved.UpdatedBy, "changes are recorded as admin without authentication")
+
+	code, view := getView("network-core")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "device-002", view.RootDevice)
+	assert.Equal(t, 2, view.Depth)
+	require.NotNil(t, view.Grouping)
+	assert.True(t, view.Grouping.GroupByPrefix)
+
+	// ビューの無いロールは default にフォールバックする
+	code, view = getView("dc-ops")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "default", view.Role)
+	assert.Equal(t, "device-001", view.RootDevice)
+
+	resp = serveJSON(t, router, http.MethodGet, "/api/v1/starting-views", nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+	var list struct {
+		Views []visualization.StartingView `json:"views"`
+		Count int                          `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	assert.Equal(t, 2, list.Count)
+
+	resp = serveJSON(t, router, http.MethodDelete, "/api/v1/starting-views/network-core", nil)
+	require.Less(t, resp.Code, 300, resp.Body.String())
+	code, view = getView("network-core")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "default", view.Role)
+}
+
+func TestStartingViewHandler_InvalidViews(t *testing.T) {
+	router := setupStartingViewHandler(t)
+
+	for name, body := range map[string]map[string]interface{}{
+		"unknown root device": {"root_device": "missing"},
+		"depth too large":     {"root_device": "device-001", "depth": 11},
+		"blank root device":   {"root_device": " "},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := serveJSON(t, router, http.MethodPut, "/api/v1/starting-views/dc-ops", body)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, resp.Body.String())
+		})
+	}
+
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/starting-views", nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"views":[]`)
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type StyleRuleHandler struct {
+	styleRuleService *service.StyleRuleService
+	logger           *logger.Logger
+}
+
+func NewStyleRuleHandler(styleRuleService *service.StyleRuleService, appLogger *logger.Logger) *StyleRuleHandler {
+	return &StyleRuleHandler{
+		styleRuleService: styleRuleService,
+		logger:           appLogger.WithComponent("style_rule_handler"),
+	}
+}
+
+// StyleRuleRequest creates or replaces a style rule
+type StyleRuleRequest struct {
+	ID   string `path:"id" doc:"Rule ID (lowercase letters, digits, '-' and '_')"`
+	Body struct {
+		Description   string                           `json:"description,omitempty" doc:"Free-form description, e.g. shown in a legend"`
+		Target        string                           `json:"target" enum:"node,edge" doc:"Whether the rule styles device nodes or link edges"`
+		LogicOperator string                           `json:"logic,omitempty" doc:"Logic operator for multiple conditions (AND, OR)" default:"AND"`
+		Conditions    []visualization.StyleCondition   `json:"conditions" minItems:"1"`
+		Priority      int                              `json:"priority,omitempty" doc:"Rules are applied in ascending priority, so the highest priority wins on a property set by several rules"`
+		NodeStyle     *visualization.NodeStyleOverride `json:"node_style,omitempty" doc:"Node style properties to override (node rules)"`
+		EdgeStyle     *visualization.EdgeStyleOverride `json:"edge_style,omitempty" doc:"Edge style properties to override (edge rules)"`
+	}
+}
+
+type StyleRuleResponse struct {
+	Body visualization.StyleRule
+}
+
+type StyleRulesResponse struct {
+	Body struct {
+		Rules []visualization.StyleRule `json:"rules"`
+		Count int                       `json:"count"`
+	}
+}
+
+func (h *StyleRuleHandler) Register(api huma.API) {
+	// スタイルルール API
+	huma.Register(api, huma.Operation{
+		OperationID: "list-style-rules",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/style-rules",
+		Summary:     "List style rules",
+		Description: "List the rules overriding the styles of visualized nodes and edges, in the order they are applied",
+		Tags:        []string{"visualization"},
+	}, h.ListStyleRules)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "set-style-rule",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/style-rules/{id}",
+		Summary:     "Set style rule",
+		Description: "Create or replace a rule overriding the style of the matching nodes or edges in every topology view, " +
+			"e.g. a dashed gray border for devices with metadata.env=staging. Only the set style properties are overridden; " +
+			"the IDs of the applied rules are listed in style_rules of each node and edge.",
+		Tags: []string{"visualization"},
+	}, h.SetStyleRule)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-style-rule",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/style-rules/{id}",
+		Summary:     "Delete style rule",
+		Tags:        []string{"visualization"},
+	}, h.DeleteStyleRule)
+}
+
+func (h *StyleRuleHandler) ListStyleRules(ctx context.Context, req *struct{}) (*StyleRulesResponse, error) {
+	rules, err := h.styleRuleService.ListStyleRules(ctx)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to list style rules", err)
+	}
+
+	resp := &StyleRulesResponse{}
+	resp.Body.Rules = rules
+	resp.Body.Count = len(rules)
+	return resp, nil
+}
+
+func (h *StyleRuleHandler) SetStyleRule(ctx context.Context, req *StyleRuleRequest) (*StyleRuleResponse, error) {
+	rule, err := h.styleRuleService.SaveStyleRule(ctx, visualization.StyleRule{
+		ID:            req.ID,
+		Description:   req.Body.Description,
+		Target:        req.Body.Target,
+		LogicOperator: req.Body.LogicOperator,
+		Conditions:    req.Body.Conditions,
+		Priority:      req.Body.Priority,
+		NodeStyle:     req.Body.NodeStyle,
+		EdgeStyle:     req.Body.EdgeStyle,
+	}, requestUser(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStyleRule) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to save style rule", "id", req.ID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to save style rule", err)
+	}
+
+	return &StyleRuleResponse{Body: *rule}, nil
+}
+
+func (h *StyleRuleHandler) DeleteStyleRule(ctx context.Context, req *struct {
+	ID string `path:"id" doc:"Rule ID"`
+}) (*struct{}, error) {
+	if err := h.styleRuleService.DeleteStyleRule(ctx, req.ID); err != nil {
+		if errors.Is(err, service.ErrStyleRuleNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to delete style rule", err)
+	}
+
+	return &struct{}{}, nil
+}
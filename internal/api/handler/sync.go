@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+// SyncPreviewer computes what a synchronization would write without writing it
+type SyncPreviewer interface {
+	DryRun(ctx context.Context) (*topology.SyncDiff, error)
+}
+
+type SyncHandler struct {
+	previewer SyncPreviewer
+	logger    *logger.Logger
+}
+
+func NewSyncHandler(previewer SyncPreviewer, appLogger *logger.Logger) *SyncHandler {
+	return &SyncHandler{
+		previewer: previewer,
+		logger:    appLogger.WithComponent("sync_handler"),
+	}
+}
+
+// SyncPreviewResponse is a sync diff in the requested format
+type SyncPreviewResponse struct {
+	ContentType string `header:"Content-Type"`
+	Body        []byte
+}
+
+func (h *SyncHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "preview-sync",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/sync/preview",
+		Summary:     "Preview synchronization (dry run)",
+		Description: "Extract devices and links from Prometheus and compare them with the database without writing anything. " +
+			"Reports the devices and links a sync would add, update or no longer refresh, the placeholders it would create " +
+			"and what the ingestion quota would skip. format=text returns a human-readable diff.",
+		Tags: []string{"sync"},
+	}, h.PreviewSync)
+}
+
+func (h *SyncHandler) PreviewSync(ctx context.Context, input *struct {
+	Format string `query:"format" default:"json" enum:"json,text" doc:"Output format"`
+}) (*SyncPreviewResponse, error) {
+	diff, err := h.previewer.DryRun(ctx)
+	if err != nil {
+		h.logger.Error("Failed to preview sync", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to preview sync", err)
+	}
+
+	if input.Format == "text" {
+		var buf bytes.Buffer
+		if err := diff.WriteText(&buf); err != nil {
+			return nil, huma.Error500InternalServerError("Failed to render sync diff", err)
+		}
+		return &SyncPreviewResponse{
+			ContentType: "text/plain; charset=utf-8",
+			Body:        buf.Bytes(),
+		}, nil
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to encode sync diff", err)
+	}
+	return &SyncPreviewResponse{
+		ContentType: "application/json",
+		Body:        data,
+	}, nil
+}
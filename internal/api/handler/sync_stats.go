@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+// MetricsPath serves the synchronization metrics to Prometheus
+const MetricsPath = "/metrics"
+
+type SyncStatsHandler struct {
+	statsService *service.SyncStatsService
+	logger       *logger.Logger
+}
+
+func NewSyncStatsHandler(statsService *service.SyncStatsService, appLogger *logger.Logger) *SyncStatsHandler {
+	return &SyncStatsHandler{
+		statsService: statsService,
+		logger:       appLogger.WithComponent("sync_stats_handler"),
+	}
+}
+
+type SyncStatsResponse struct {
+	Body struct {
+		Cycles []topology.SyncCycleStats `json:"cycles"`
+		Count  int                       `json:"count"`
+	}
+}
+
+// PrometheusMetricsResponse is a scrape in the Prometheus text exposition format
+type PrometheusMetricsResponse struct {
+	ContentType string `header:"Content-Type"`
+	Body        []byte
+}
+
+func (h *SyncStatsHandler) Register(api huma.API) {
+	huma.Register(api, huma.Operation{
+		OperationID: "get-sync-stats",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/sync/stats",
+		Summary:     "Get topology changes per sync cycle",
+		Description: "List the devices appearing and disappearing and the links added and removed by each synchronization " +
+			"cycle of the worker, compared with the cycle before, oldest first. Sudden spikes point to a misbehaving exporter " +
+			"or a network event. The first cycle after a worker start is a baseline without changes.",
+		Tags: []string{"sync"},
+	}, h.GetHistory)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-prometheus-metrics",
+		Method:      http.MethodGet,
+		Path:        MetricsPath,
+		Summary:     "Get Prometheus metrics",
+		Description: "The counts of the last synchronization cycle as gauges in the Prometheus text exposition format (tm_sync_cycle_*).",
+		Tags:        []string{"sync"},
+	}, h.GetMetrics)
+}
+
+func (h *SyncStatsHandler) GetHistory(ctx context.Context, input *struct {
+	Since string `query:"since" default:"24h" doc:"Only cycles finished within this duration"`
+	Limit int    `query:"limit" default:"0" minimum:"0" doc:"Only the last cycles (0 = all)"`
+}) (*SyncStatsResponse, error) {
+	since, err := time.ParseDuration(input.Since)
+	if err != nil {
+		return nil, huma.Error400BadRequest("Invalid since", err)
+	}
+
+	cycles, err := h.statsService.History(ctx, since, input.Limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSyncStatsRange) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to list sync stats", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to list sync stats", err)
+	}
+
+	resp := &SyncStatsResponse{}
+	resp.Body.Cycles = cycles
+	resp.Body.Count = len(cycles)
+	return resp, nil
+}
+
+func (h *SyncStatsHandler) GetMetrics(ctx context.Context, input *struct{}) (*PrometheusMetricsResponse, error) {
+	var buf bytes.Buffer
+	if err := h.statsService.WriteMetrics(ctx, &buf); err != nil {
+		h.logger.Error("Failed to write metrics", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to write metrics", err)
+	}
+
+	return &PrometheusMetricsResponse{
+		ContentType: "text/plain; version=0.0.4; charset=utf-8",
+		Body:        buf.Bytes(),
+	}, nil
+}
@@ -2,7 +2,10 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/servak/topology-manager/internal/domain/topology"
@@ -29,9 +32,34 @@ func (h *TopologyHandler) Register(api huma.API) {
 		Method:      http.MethodGet,
 		Path:        "/api/v1/devices/search",
 		Summary:     "Search devices by ID, name, or IP address",
-		Tags:        []string{"devices"},
+		Description: "Set provenance to restrict results to devices first seen that way, e.g. lldp-placeholder for devices " +
+			"only inferred from LLDP neighbors, or workflow_state, e.g. onboarding for gear being turned up; q may then " +
+			"be empty to list all of them. Set awaiting_enrichment to list only the placeholders monitoring has not described yet.",
+		Tags: []string{"devices"},
 	}, h.SearchDevices)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "list-devices",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/devices",
+		Summary:     "List devices",
+		Description: "List devices page by page, newest first. On large tables total_count is estimated from the " +
+			"PostgreSQL table statistics (pagination.estimated is then true); set exact to count every row. " +
+			"With pagination=cursor, devices are listed in ID order and each page returns pagination.next_cursor, " +
+			"passed as cursor to get the following page; deep pages then cost as much as the first, unlike high page numbers.",
+		Tags: []string{"devices"},
+	}, h.ListDevices)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-topology-counts",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/counts",
+		Summary:     "Count devices and links",
+		Description: "Return the number of devices and links. Tables of 100000 rows or more are estimated from the " +
+			"table statistics, which costs nothing but may lag behind recent syncs; set exact to count every row.",
+		Tags: []string{"devices"},
+	}, h.CountTopology)
+
 	// トポロジー検索API（フロントエンドで使用中）
 	huma.Register(api, huma.Operation{
 		OperationID: "find-reachable-devices",
@@ -48,21 +76,100 @@ func (h *TopologyHandler) Register(api huma.API) {
 		Summary:     "Find shortest path between two devices",
 		Tags:        []string{"topology-search"},
 	}, h.FindShortestPath)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "find-asymmetric-links",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/links/asymmetric",
+		Summary:     "Find links seen from only one side",
+		Description: "List LLDP links reported by one endpoint but never by the other, which usually indicates LLDP disabled or filtered on the silent device",
+		Tags:        []string{"topology-search"},
+	}, h.FindAsymmetricLinks)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "analyze-spine-leaf-balance",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/analysis/spine-leaf-balance",
+		Summary:     "Report spine/leaf balance",
+		Description: "Report the servers per leaf, leaves per spine and uplink weights, flagging devices more than ratio times above or below the median of their tier",
+		Tags:        []string{"analysis"},
+	}, h.AnalyzeSpineLeafBalance)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-reachability-matrix",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/analysis/reachability-matrix",
+		Summary:     "Get reachability matrix",
+		Description: "Report whether every target is reachable from every source, with hop count and the number of link-disjoint paths (redundancy). " +
+			"Sets are given as device IDs and/or layer IDs, e.g. all leaves against all borders.",
+		Tags: []string{"analysis"},
+	}, h.GetReachabilityMatrix)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-path-symmetry",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/analysis/path-symmetry",
+		Summary:     "Get reverse path validation report",
+		Description: "Compare the weighted shortest paths A->B and B->A between every source and target and report the pairs " +
+			"whose return traffic takes a path the forward traffic would not. A link weight is the cost of leaving its reporting side, " +
+			"so links reported from both ends carry one weight per direction; hops whose weights differ are listed as mismatches. " +
+			"Equal-cost alternatives are not reported.",
+		Tags: []string{"analysis"},
+	}, h.GetPathSymmetry)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "find-cross-links",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/analysis/cross-links",
+		Summary:     "Find links between two device groups",
+		Description: "List only the links crossing between the devices matching filter a and those matching filter b, " +
+			"with per device pair and total bandwidth, e.g. the spines of two sites for DCI capacity reviews.",
+		Tags: []string{"analysis"},
+	}, h.FindCrossLinks)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "compare-subtopologies",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/analysis/subtopology-compare",
+		Summary:     "Compare subtopologies of two root devices",
+		Description: "Extract the subtopologies below root a and root b and diff their structure: device counts per layer, " +
+			"device types, hardware mix, link count and uplink patterns (devices per layer and number of uplinks to each " +
+			"upper layer, e.g. \"41->32 x2\"). Devices of layers above the root are left out, so the tier a pod hangs off " +
+			"is not compared. Useful to validate that a newly built pod mirrors a reference pod.",
+		Tags: []string{"analysis"},
+	}, h.CompareSubTopologies)
+
+	// 一括削除API
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-devices",
+		Method:      http.MethodDelete,
+		Path:        "/api/v1/devices",
+		Summary:     "Delete devices matching a filter",
+		Description: "Delete every device matching the filter together with its links. " +
+			"Use dry_run to preview counts; deleting more than 50 devices requires the confirmation_token returned by a dry run.",
+		Tags: []string{"devices"},
+	}, h.DeleteDevices)
 }
 
 // トポロジー検索ハンドラー
 func (h *TopologyHandler) FindReachableDevices(ctx context.Context, input *struct {
-	DeviceID  string `path:"deviceId"`
-	Algorithm string `query:"algorithm" enum:"bfs,dfs" default:"bfs"`
-	MaxHops   int    `query:"max_hops" default:"5"`
+	DeviceID  string `path:"deviceId" doc:"Device ID" example:"core-01"`
+	Algorithm string `query:"algorithm" enum:"bfs,dfs" default:"bfs" doc:"Search algorithm: bfs (breadth-first) or dfs (depth-first)"`
+	MaxHops   int    `query:"max_hops" default:"5" doc:"Maximum number of hops from the device"`
+	Fields    string `query:"fields" doc:"Comma-separated device fields to return (e.g. id,type,layer)"`
 }) (*struct {
 	Body struct {
-		Devices   []topology.Device `json:"devices"`
-		Algorithm string            `json:"algorithm"`
-		MaxHops   int               `json:"max_hops"`
-		Count     int               `json:"count"`
+		Devices   interface{} `json:"devices" doc:"Devices, restricted to the requested fields"`
+		Algorithm string      `json:"algorithm"`
+		MaxHops   int         `json:"max_hops"`
+		Count     int         `json:"count"`
 	}
 }, error) {
+	fields, err := parseFieldSelection(input.Fields, topology.Device{}, deviceFieldAliases)
+	if err != nil {
+		return nil, err
+	}
+
 	var algorithm topology.SearchAlgorithm
 	switch input.Algorithm {
 	case "dfs":
@@ -79,21 +186,26 @@ func (h *TopologyHandler) FindReachableDevices(ctx context.Context, input *struc
 		return nil, huma.Error500InternalServerError("Failed to find reachable devices", err)
 	}
 
+	shaped, err := selectFields(devices, fields)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to select device fields", err)
+	}
+
 	return &struct {
 		Body struct {
-			Devices   []topology.Device `json:"devices"`
-			Algorithm string            `json:"algorithm"`
-			MaxHops   int               `json:"max_hops"`
-			Count     int               `json:"count"`
+			Devices   interface{} `json:"devices" doc:"Devices, restricted to the requested fields"`
+			Algorithm string      `json:"algorithm"`
+			MaxHops   int         `json:"max_hops"`
+			Count     int         `json:"count"`
 		}
 	}{
 		Body: struct {
-			Devices   []topology.Device `json:"devices"`
-			Algorithm string            `json:"algorithm"`
-			MaxHops   int               `json:"max_hops"`
-			Count     int               `json:"count"`
+			Devices   interface{} `json:"devices" doc:"Devices, restricted to the requested fields"`
+			Algorithm string      `json:"algorithm"`
+			MaxHops   int         `json:"max_hops"`
+			Count     int         `json:"count"`
 		}{
-			Devices:   devices,
+			Devices:   shaped,
 			Algorithm: input.Algorithm,
 			MaxHops:   input.MaxHops,
 			Count:     len(devices),
@@ -102,9 +214,9 @@ func (h *TopologyHandler) FindReachableDevices(ctx context.Context, input *struc
 }
 
 func (h *TopologyHandler) FindShortestPath(ctx context.Context, input *struct {
-	FromID    string `path:"fromId"`
-	ToID      string `path:"toId"`
-	Algorithm string `query:"algorithm" enum:"dijkstra,k_shortest" default:"dijkstra"`
+	FromID    string `path:"fromId" doc:"Source device ID" example:"leaf-01"`
+	ToID      string `path:"toId" doc:"Destination device ID" example:"border-01"`
+	Algorithm string `query:"algorithm" enum:"dijkstra,k_shortest" default:"dijkstra" doc:"Path algorithm: dijkstra (lowest total link weight) or k_shortest"`
 }) (*struct {
 	Body topology.Path
 }, error) {
@@ -132,31 +244,338 @@ func (h *TopologyHandler) FindShortestPath(ctx context.Context, input *struct {
 
 // SearchDevices searches for devices by ID, name, or IP address
 func (h *TopologyHandler) SearchDevices(ctx context.Context, input *struct {
-	Query string `query:"q"`
-	Limit int    `query:"limit" default:"20"`
+	Query              string `query:"q"`
+	Provenance         string `query:"provenance" enum:"prometheus,lldp-placeholder,import,manual,netbox,mac-table" doc:"Only return devices first seen this way"`
+	WorkflowState      string `query:"workflow_state" enum:"discovered,onboarding,active,quarantined,decommissioned" doc:"Only return devices in this workflow state"`
+	AwaitingEnrichment bool   `query:"awaiting_enrichment" doc:"Only return LLDP placeholders that still have the placeholder type and hardware (overrides provenance and workflow_state)"`
+	Limit              int    `query:"limit" default:"20"`
+	Fields             string `query:"fields" doc:"Comma-separated device fields to return (e.g. id,type,layer)"`
 }) (*struct {
 	Body struct {
-		Devices []topology.Device `json:"devices"`
-		Count   int               `json:"count"`
+		Devices interface{} `json:"devices" doc:"Devices, restricted to the requested fields"`
+		Count   int         `json:"count"`
 	}
 }, error) {
-	devices, err := h.topologyService.SearchDevices(ctx, input.Query, input.Limit)
+	fields, err := parseFieldSelection(input.Fields, topology.Device{}, deviceFieldAliases)
 	if err != nil {
+		return nil, err
+	}
+
+	var devices []topology.Device
+	if input.AwaitingEnrichment {
+		devices, err = h.topologyService.SearchPlaceholdersAwaitingEnrichment(ctx, input.Query, input.Limit)
+	} else if input.Provenance != "" || input.WorkflowState != "" {
+		filter := topology.DeviceFilter{Provenance: input.Provenance, WorkflowState: input.WorkflowState}
+		devices, err = h.topologyService.SearchDevicesByFilter(ctx, input.Query, filter, input.Limit)
+	} else {
+		devices, err = h.topologyService.SearchDevices(ctx, input.Query, input.Limit)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidDeviceFilter) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
 		return nil, huma.Error500InternalServerError("Failed to search devices", err)
 	}
 
+	shaped, err := selectFields(devices, fields)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to select device fields", err)
+	}
+
 	return &struct {
 		Body struct {
-			Devices []topology.Device `json:"devices"`
-			Count   int               `json:"count"`
+			Devices interface{} `json:"devices" doc:"Devices, restricted to the requested fields"`
+			Count   int         `json:"count"`
 		}
 	}{
 		Body: struct {
-			Devices []topology.Device `json:"devices"`
-			Count   int               `json:"count"`
+			Devices interface{} `json:"devices" doc:"Devices, restricted to the requested fields"`
+			Count   int         `json:"count"`
 		}{
-			Devices: devices,
+			Devices: shaped,
 			Count:   len(devices),
 		},
 	}, nil
 }
+
+type AsymmetricLinksResponse struct {
+	Body struct {
+		Links []topology.AsymmetricLink `json:"links"`
+		Count int                       `json:"count"`
+	}
+}
+
+func (h *TopologyHandler) FindAsymmetricLinks(ctx context.Context, input *struct {
+	DeviceID string `query:"device_id" doc:"Only analyze links of this device (empty = whole topology)"`
+}) (*AsymmetricLinksResponse, error) {
+	links, err := h.topologyService.FindAsymmetricLinks(ctx, input.DeviceID)
+	if err != nil {
+		h.logger.Error("Failed to find asymmetric links", "device_id", input.DeviceID, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to find asymmetric links", err)
+	}
+	if links == nil {
+		links = []topology.AsymmetricLink{}
+	}
+
+	resp := &AsymmetricLinksResponse{}
+	resp.Body.Links = links
+	resp.Body.Count = len(links)
+	return resp, nil
+}
+
+type SpineLeafBalanceResponse struct {
+	Body topology.BalanceReport
+}
+
+func (h *TopologyHandler) AnalyzeSpineLeafBalance(ctx context.Context, input *struct {
+	SpineLayers  string  `query:"spine_layers" doc:"Comma-separated layer IDs of spines (default 30,31,32)"`
+	LeafLayers   string  `query:"leaf_layers" doc:"Comma-separated layer IDs of leaves (default 40,41)"`
+	ServerLayers string  `query:"server_layers" doc:"Comma-separated layer IDs of servers (default 50,51)"`
+	Ratio        float64 `query:"ratio" default:"1.5" doc:"Flag devices more than ratio times above or below the median of their tier"`
+}) (*SpineLeafBalanceResponse, error) {
+	tiers := topology.DefaultBalanceTiers
+	for _, param := range []struct {
+		name   string
+		value  string
+		layers *[]int
+	}{
+		{"spine_layers", input.SpineLayers, &tiers.SpineLayers},
+		{"leaf_layers", input.LeafLayers, &tiers.LeafLayers},
+		{"server_layers", input.ServerLayers, &tiers.ServerLayers},
+	} {
+		if param.value == "" {
+			continue
+		}
+		layers, err := parseLayerList(param.value)
+		if err != nil {
+			return nil, huma.Error400BadRequest(fmt.Sprintf("Invalid %s: %v", param.name, err))
+		}
+		*param.layers = layers
+	}
+	if input.Ratio <= 1 {
+		return nil, huma.Error400BadRequest("ratio must be greater than 1")
+	}
+
+	report, err := h.topologyService.AnalyzeSpineLeafBalance(ctx, tiers, input.Ratio)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to analyze spine/leaf balance", err)
+	}
+
+	return &SpineLeafBalanceResponse{Body: *report}, nil
+}
+
+type ReachabilityMatrixResponse struct {
+	Body topology.ReachabilityMatrix
+}
+
+// DeviceSetParams selects the source and target devices of a pairwise analysis
+type DeviceSetParams struct {
+	Sources      string `query:"sources" doc:"Comma-separated source device IDs"`
+	SourceLayers string `query:"source_layers" doc:"Comma-separated layer IDs whose devices are sources"`
+	Targets      string `query:"targets" doc:"Comma-separated target device IDs"`
+	TargetLayers string `query:"target_layers" doc:"Comma-separated layer IDs whose devices are targets"`
+}
+
+// sets parses the parameters into source and target device sets
+func (p DeviceSetParams) sets() (topology.DeviceSet, topology.DeviceSet, error) {
+	sources := topology.DeviceSet{DeviceIDs: splitCommaList(p.Sources)}
+	targets := topology.DeviceSet{DeviceIDs: splitCommaList(p.Targets)}
+	for _, param := range []struct {
+		name   string
+		value  string
+		layers *[]int
+	}{
+		{"source_layers", p.SourceLayers, &sources.Layers},
+		{"target_layers", p.TargetLayers, &targets.Layers},
+	} {
+		layers, err := parseLayerList(param.value)
+		if err != nil {
+			return sources, targets, huma.Error400BadRequest(fmt.Sprintf("Invalid %s: %v", param.name, err))
+		}
+		*param.layers = layers
+	}
+	return sources, targets, nil
+}
+
+func (h *TopologyHandler) GetReachabilityMatrix(ctx context.Context, input *struct {
+	DeviceSetParams
+	MaxHops  int `query:"max_hops" default:"10" doc:"Pairs farther apart than this are reported unreachable (max 20)"`
+	MaxPaths int `query:"max_paths" default:"4" doc:"Stop counting disjoint paths of a pair at this number (max 16)"`
+}) (*ReachabilityMatrixResponse, error) {
+	sources, targets, err := input.DeviceSetParams.sets()
+	if err != nil {
+		return nil, err
+	}
+
+	matrix, err := h.topologyService.BuildReachabilityMatrix(ctx, sources, targets, input.MaxHops, input.MaxPaths)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidReachabilityQuery) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to build reachability matrix", err)
+	}
+
+	return &ReachabilityMatrixResponse{Body: *matrix}, nil
+}
+
+type PathSymmetryResponse struct {
+	Body topology.PathSymmetryReport
+}
+
+func (h *TopologyHandler) GetPathSymmetry(ctx context.Context, input *struct {
+	DeviceSetParams
+}) (*PathSymmetryResponse, error) {
+	sources, targets, err := input.DeviceSetParams.sets()
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := h.topologyService.AnalyzePathSymmetry(ctx, sources, targets)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidReachabilityQuery) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to analyze path symmetry", err)
+	}
+
+	return &PathSymmetryResponse{Body: *report}, nil
+}
+
+type CrossLinksResponse struct {
+	Body topology.CrossLinkReport
+}
+
+func (h *TopologyHandler) FindCrossLinks(ctx context.Context, input *struct {
+	A string `query:"a" required:"true" doc:"Device filter of the first group" example:"device_type=spine,metadata.site=A"`
+	B string `query:"b" required:"true" doc:"Device filter of the second group" example:"device_type=spine,metadata.site=B"`
+}) (*CrossLinksResponse, error) {
+	report, err := h.topologyService.FindCrossLinks(ctx, input.A, input.B)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCrossLinkQuery) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to find cross links", err)
+	}
+
+	return &CrossLinksResponse{Body: *report}, nil
+}
+
+type SubTopologyComparisonResponse struct {
+	Body topology.SubTopologyComparison
+}
+
+func (h *TopologyHandler) CompareSubTopologies(ctx context.Context, input *struct {
+	A     string `query:"a" required:"true" doc:"Root device of the reference subtopology" example:"pod1-spine-01"`
+	B     string `query:"b" required:"true" doc:"Root device of the compared subtopology" example:"pod2-spine-01"`
+	Depth int    `query:"depth" default:"3" minimum:"1" maximum:"10" doc:"Hops from each root"`
+}) (*SubTopologyComparisonResponse, error) {
+	comparison, err := h.topologyService.CompareSubTopologies(ctx, input.A, input.B, input.Depth)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSubTopologyComparison):
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		case errors.Is(err, service.ErrComparisonRootNotFound):
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to compare subtopologies", err)
+	}
+
+	return &SubTopologyComparisonResponse{Body: *comparison}, nil
+}
+
+type ListDevicesResponse struct {
+	Body struct {
+		Devices    []topology.Device         `json:"devices"`
+		Pagination topology.PaginationResult `json:"pagination"`
+	}
+}
+
+func (h *TopologyHandler) ListDevices(ctx context.Context, input *struct {
+	Page       int    `query:"page" default:"1" minimum:"1" doc:"Page number (offset pagination only)"`
+	PageSize   int    `query:"page_size" default:"100" minimum:"1" maximum:"1000" doc:"Devices per page"`
+	Exact      bool   `query:"exact" doc:"Count every row instead of estimating the total of large tables"`
+	Pagination string `query:"pagination" enum:"offset,cursor" default:"offset" doc:"offset pages by page number, newest first; cursor pages by next_cursor in ID order"`
+	Cursor     string `query:"cursor" doc:"next_cursor of the previous page (implies pagination=cursor)"`
+}) (*ListDevicesResponse, error) {
+	var devices []topology.Device
+	var pagination *topology.PaginationResult
+	var err error
+	if input.Pagination == "cursor" || input.Cursor != "" {
+		devices, pagination, err = h.topologyService.ListDevicesByCursor(ctx, input.Cursor, input.PageSize, input.Exact)
+	} else {
+		devices, pagination, err = h.topologyService.ListDevices(ctx, input.Page, input.PageSize, input.Exact)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		}
+		h.logger.Error("Failed to list devices", "page", input.Page, "cursor", input.Cursor, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to list devices", err)
+	}
+
+	resp := &ListDevicesResponse{}
+	resp.Body.Devices = devices
+	resp.Body.Pagination = *pagination
+	return resp, nil
+}
+
+type TopologyCountsResponse struct {
+	Body service.TopologyCounts
+}
+
+func (h *TopologyHandler) CountTopology(ctx context.Context, input *struct {
+	Exact bool `query:"exact" doc:"Count every row instead of estimating large tables"`
+}) (*TopologyCountsResponse, error) {
+	counts, err := h.topologyService.CountTopology(ctx, input.Exact)
+	if err != nil {
+		if errors.Is(err, service.ErrCountsUnsupported) {
+			return nil, huma.Error501NotImplemented(err.Error())
+		}
+		h.logger.Error("Failed to count devices and links", "error", err)
+		return nil, huma.Error500InternalServerError("Failed to count devices and links", err)
+	}
+
+	return &TopologyCountsResponse{Body: *counts}, nil
+}
+
+// parseLayerList parses comma-separated layer IDs
+func parseLayerList(value string) ([]int, error) {
+	var layers []int
+	for _, item := range splitCommaList(value) {
+		layer, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a layer ID", item)
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+type DeleteDevicesResponse struct {
+	Body topology.DeviceDeletionResult
+}
+
+func (h *TopologyHandler) DeleteDevices(ctx context.Context, input *struct {
+	Filter  string `query:"filter" required:"true" doc:"Comma-separated terms: type=X, hardware=X, provenance=X, workflow_state=X, metadata.KEY=X, last_seen<DURATION (e.g. 30d)"`
+	DryRun  bool   `query:"dry_run" doc:"Only report what would be deleted"`
+	Confirm string `query:"confirm" doc:"Confirmation token from a dry run (required for large deletions)"`
+}) (*DeleteDevicesResponse, error) {
+	result, err := h.topologyService.DeleteDevices(ctx, input.Filter, input.DryRun, input.Confirm)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidDeviceFilter):
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		case errors.Is(err, service.ErrDeletionConfirmationRequired):
+			return nil, huma.Error412PreconditionFailed(err.Error(), err)
+		case errors.Is(err, service.ErrBulkDeleteUnsupported):
+			return nil, huma.Error501NotImplemented(err.Error())
+		}
+		h.logger.Error("Failed to delete devices", "filter", input.Filter, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to delete devices", err)
+	}
+	if !input.DryRun {
+		h.logger.Info("Deleted devices", "filter", input.Filter, "devices", result.Devices, "links", result.Links)
+	}
+
+	return &DeleteDevicesResponse{Body: *result}, nil
+}
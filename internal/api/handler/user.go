@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"context"
+
+	apimiddleware "github.com/servak/topology-manager/internal/api/middleware"
+)
+
+// requestUser returns the authenticated user recorded as author of changes,
+// or "admin" when authentication is disabled
+func requestUser(ctx context.Context) string {
+	if principal, ok := apimiddleware.PrincipalFromContext(ctx); ok {
+		return principal.Username
+	}
+	return "admin"
+}
+
+// requestIsAdmin reports whether the request may use the diagnostics endpoints; every request
+// may when authentication is disabled
+func requestIsAdmin(ctx context.Context) bool {
+	if principal, ok := apimiddleware.PrincipalFromContext(ctx); ok {
+		return principal.Role.IsAdmin()
+	}
+	return true
+}
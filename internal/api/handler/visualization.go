@@ -2,26 +2,236 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"reflect"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
 	"github.com/servak/topology-manager/internal/domain/visualization"
 	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/internal/service/contract"
 	"github.com/servak/topology-manager/pkg/logger"
 )
 
+// visualTopologyBody is a visual topology whose nodes may be restricted to the requested fields,
+// rendered in the requested schema version
+type visualTopologyBody struct {
+	visualization.VisualTopology
+	Nodes interface{} `json:"nodes" doc:"Nodes, restricted to the requested fields"`
+
+	v1 *visualization.VisualTopologyV1 // schema_version=1 を要求された場合のみ
+}
+
+func newVisualTopologyBody(topology *visualization.VisualTopology, fields []string, version int) (*visualTopologyBody, error) {
+	topology.SchemaVersion = visualization.CurrentSchemaVersion
+	if version == visualization.SchemaVersion1 {
+		v1 := topology.V1()
+		nodes, err := selectFields(v1.Nodes, fields)
+		if err != nil {
+			return nil, err
+		}
+		return &visualTopologyBody{VisualTopology: *topology, Nodes: nodes, v1: v1}, nil
+	}
+
+	nodes, err := selectFields(topology.Nodes, fields)
+	if err != nil {
+		return nil, err
+	}
+	return &visualTopologyBody{
+		VisualTopology: *topology,
+		Nodes:          nodes,
+	}, nil
+}
+
+// MarshalJSON renders the body in the requested schema version. The OpenAPI schema always
+// describes the current version.
+func (b visualTopologyBody) MarshalJSON() ([]byte, error) {
+	if b.v1 != nil {
+		return json.Marshal(struct {
+			*visualization.VisualTopologyV1
+			Nodes interface{} `json:"nodes"`
+		}{b.v1, b.Nodes})
+	}
+	return json.Marshal(struct {
+		visualization.VisualTopology
+		Nodes interface{} `json:"nodes"`
+	}{b.VisualTopology, b.Nodes})
+}
+
+// Schema describes the body as the current version. Providing the schema inline also keeps
+// huma's $schema link transformer from copying the exported fields into a new struct, which
+// would bypass MarshalJSON and always render the current version.
+func (b visualTopologyBody) Schema(r huma.Registry) *huma.Schema {
+	return r.Schema(reflect.TypeOf(visualization.VisualTopology{}), true, "VisualTopology")
+}
+
+// SchemaVersionParams selects the schema version a topology payload is rendered in
+type SchemaVersionParams struct {
+	SchemaVersion int `query:"schema_version" default:"2" minimum:"1" maximum:"2" doc:"Payload schema version. 1 omits workflow states, edge labels, bundles, layer bands, layout patches and degree statistics"`
+}
+
+// nodeModel returns the node type whose fields can be selected in the requested version
+func (p SchemaVersionParams) nodeModel() interface{} {
+	if p.SchemaVersion == visualization.SchemaVersion1 {
+		return visualization.VisualNodeV1{}
+	}
+	return visualization.VisualNode{}
+}
+
+// EdgeLabelParams controls the edge labels composed from link metadata
+type EdgeLabelParams struct {
+	EdgeLabels      bool   `query:"edge_labels" default:"true" doc:"Include edge labels (disable for dense graphs)"`
+	EdgeLabelFormat string `query:"edge_label_format" doc:"Label template of link metadata keys, local_port and remote_port (default '{speed} {link_type}')"`
+}
+
+// apply labels the edges of topology according to the parameters
+func (p EdgeLabelParams) apply(topology *visualization.VisualTopology) {
+	if !p.EdgeLabels {
+		topology.LabelEdges("")
+		return
+	}
+	format := p.EdgeLabelFormat
+	if format == "" {
+		format = visualization.DefaultEdgeLabelFormat
+	}
+	topology.LabelEdges(format)
+}
+
+// EdgeColorParams selects what the edge colors encode
+type EdgeColorParams struct {
+	ColorBy string `query:"color_by" default:"status" enum:"status,utilization" doc:"Color edges by link status, or by recent utilization (green < 40%, yellow < 80%, red >= 80%, gray when the speed or traffic is unknown) computed from the cached interface traffic of the metrics proxy"`
+}
+
+// EdgeUtilizationSource computes the recent utilization of edges in percent, keyed by edge ID.
+// It is implemented by *service.MetricsProxyService.
+type EdgeUtilizationSource interface {
+	EdgeUtilization(ctx context.Context, edges []visualization.VisualEdge) (map[string]float64, error)
+}
+
+// apply colors the edges of topology by utilization when requested
+func (p EdgeColorParams) apply(ctx context.Context, source EdgeUtilizationSource, topology *visualization.VisualTopology) error {
+	if p.ColorBy != visualization.EdgeColorByUtilization {
+		return nil
+	}
+	if source == nil {
+		return huma.Error501NotImplemented("Coloring edges by utilization requires the metrics proxy")
+	}
+
+	utilization, err := source.EdgeUtilization(ctx, topology.Edges)
+	if err != nil {
+		if errors.Is(err, service.ErrMetricsRateLimited) {
+			return huma.Error429TooManyRequests("Too many metric queries; retry later", err)
+		}
+		if typed, ok := apperror.From(err); ok && typed.Kind == apperror.KindDependencyUnavailable {
+			return huma.Error503ServiceUnavailable("Prometheus unavailable", err)
+		}
+		return huma.Error500InternalServerError("Failed to compute edge utilization", err)
+	}
+	topology.ColorEdgesByUtilization(utilization)
+	return nil
+}
+
+// visualizationError maps a visualization service error to 404 for a missing root device or group,
+// and to 500 with msg otherwise
+func visualizationError(msg string, err error) error {
+	if errors.Is(err, service.ErrRootDeviceNotFound) || errors.Is(err, service.ErrGroupNotFound) {
+		return huma.Error404NotFound(err.Error(), err)
+	}
+	return huma.Error500InternalServerError(msg, err)
+}
+
+// EdgeBundleParams controls the edge bundling hints for dense layer pairs
+type EdgeBundleParams struct {
+	EdgeBundles    bool `query:"edge_bundles" default:"false" doc:"Assign edges between densely connected layers to bundles (bundles, edge.bundle) so they can be drawn as bundled curves"`
+	BundleMinEdges int  `query:"bundle_min_edges" default:"8" minimum:"1" doc:"Minimum number of edges between two layers to form a bundle"`
+}
+
+// apply adds the edge bundles to topology when requested
+func (p EdgeBundleParams) apply(topology *visualization.VisualTopology) {
+	if !p.EdgeBundles {
+		topology.BundleEdges(0)
+		return
+	}
+	topology.BundleEdges(p.BundleMinEdges)
+}
+
+// LayerBandParams controls the layer bands added to the hierarchical layout
+type LayerBandParams struct {
+	LayerBands bool `query:"layer_bands" default:"false" doc:"Add a labeled band (y-range, hierarchy layer name and color) per layer to layout.bands for drawing swimlanes"`
+}
+
+// apply adds the layer bands to the layout of topology when requested
+func (p LayerBandParams) apply(ctx context.Context, visualizationService contract.Visualization, topology *visualization.VisualTopology) error {
+	if !p.LayerBands {
+		return nil
+	}
+	if err := visualizationService.AddLayerBands(ctx, topology); err != nil {
+		return huma.Error500InternalServerError("Failed to compute layer bands", err)
+	}
+	return nil
+}
+
+// DeviceFilterParams includes or excludes the devices around the root by attribute. The
+// filter is applied by the database while extracting the sub-topology.
+type DeviceFilterParams struct {
+	Type            string `query:"type" doc:"Only show devices of these types (comma-separated)"`
+	ExcludeType     string `query:"exclude_type" doc:"Hide devices of these types (comma-separated)"`
+	Hardware        string `query:"hardware" doc:"Only show devices whose hardware matches this regular expression" example:"^DCS-7050"`
+	ExcludeHardware string `query:"exclude_hardware" doc:"Hide devices whose hardware matches this regular expression"`
+	Metadata        string `query:"metadata" doc:"Only show devices having all of these metadata values (comma-separated key=value)" example:"site=tyo1"`
+	ExcludeMetadata string `query:"exclude_metadata" doc:"Hide devices having any of these metadata values (comma-separated key=value)"`
+	Status          string `query:"status" enum:"active,stale" doc:"Only show devices seen within the last 24h (active) or not (stale)"`
+	WorkflowState   string `query:"workflow_state" doc:"Only show devices in these workflow states (comma-separated: discovered, onboarding, active, quarantined, decommissioned)"`
+	ExcludeWorkflow string `query:"exclude_workflow_state" doc:"Hide devices in these workflow states (comma-separated)" example:"decommissioned"`
+}
+
+// filter converts the parameters into a sub-topology filter
+func (p DeviceFilterParams) filter() (topology.SubTopologyFilter, error) {
+	filter := topology.SubTopologyFilter{
+		IncludeTypes:    splitCommaList(p.Type),
+		ExcludeTypes:    splitCommaList(p.ExcludeType),
+		HardwareRegex:   p.Hardware,
+		ExcludeHardware: p.ExcludeHardware,
+		Status:          topology.DeviceStatus(p.Status),
+
+		IncludeWorkflowStates: splitCommaList(p.WorkflowState),
+		ExcludeWorkflowStates: splitCommaList(p.ExcludeWorkflow),
+	}
+
+	var err error
+	if filter.IncludeMetadata, err = topology.ParseMetadataTerms(splitCommaList(p.Metadata)); err != nil {
+		return filter, huma.Error400BadRequest(err.Error(), err)
+	}
+	if filter.ExcludeMetadata, err = topology.ParseMetadataTerms(splitCommaList(p.ExcludeMetadata)); err != nil {
+		return filter, huma.Error400BadRequest(err.Error(), err)
+	}
+	if err := filter.Validate(); err != nil {
+		return filter, huma.Error400BadRequest(err.Error(), err)
+	}
+	return filter, nil
+}
+
 type VisualizationHandler struct {
-	visualizationService *service.VisualizationService
+	visualizationService contract.Visualization
+	utilizationSource    EdgeUtilizationSource // nil = 利用率での色分けなし
 	logger               *logger.Logger
 }
 
-func NewVisualizationHandler(visualizationService *service.VisualizationService, appLogger *logger.Logger) *VisualizationHandler {
+func NewVisualizationHandler(visualizationService contract.Visualization, appLogger *logger.Logger) *VisualizationHandler {
 	return &VisualizationHandler{
 		visualizationService: visualizationService,
 		logger:               appLogger.WithComponent("visualization_handler"),
 	}
 }
 
+// SetUtilizationSource enables color_by=utilization with the edge utilization of source
+func (h *VisualizationHandler) SetUtilizationSource(source EdgeUtilizationSource) {
+	h.utilizationSource = source
+}
+
 func (h *VisualizationHandler) Register(api huma.API) {
 	// フロントエンドで使用中: /api/topology/{deviceId}
 	huma.Register(api, huma.Operation{
@@ -49,20 +259,92 @@ func (h *VisualizationHandler) Register(api huma.API) {
 		Summary:     "Get topology expanding from specific device",
 		Tags:        []string{"visualization"},
 	}, h.ExpandFromDevice)
+
+	// グループ展開API（既存ノードの位置を保ち、新規ノードのみ layout_patch で返す）
+	huma.Register(api, huma.Operation{
+		OperationID: "expand-topology-group",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/topology/{deviceId}/groups/{groupId}/expand",
+		Summary:     "Expand a group incrementally",
+		Description: "Expand a group keeping existing node positions; positions of new nodes are returned in layout_patch, anchored near the expanded group",
+		Tags:        []string{"visualization"},
+	}, h.ExpandGroup)
+
+	// グルーピング調整用のプレビューAPI（ノード・エッジ・レイアウトは計算しない）
+	huma.Register(api, huma.Operation{
+		OperationID: "preview-topology-groups",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/topology/{deviceId}/groups/preview",
+		Summary:     "Preview groups for grouping options",
+		Description: "Return only the groups (counts, prefixes, member IDs) that would be formed, without building nodes, edges or layout",
+		Tags:        []string{"visualization"},
+	}, h.PreviewGroups)
+
+	// グループノードのメンバー一覧（展開せずにメンバーと外部接続を確認）
+	huma.Register(api, huma.Operation{
+		OperationID: "get-topology-group-members",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/topology/groups/{groupId}/members",
+		Summary:     "List members of a group node",
+		Description: "List the member devices of a group with their classification and a summary of the links leaving the group. The group is addressed by the root device and grouping options the topology was requested with",
+		Tags:        []string{"visualization"},
+	}, h.GetGroupMembers)
+
+	// ポートグラフ（並行リンクがどのポート同士を結ぶかを表示）
+	huma.Register(api, huma.Operation{
+		OperationID: "get-port-topology",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/topology/{deviceId}/ports",
+		Summary:     "Get interface-level topology",
+		Description: "Get the sub-topology as a port graph: interfaces are nodes whose parent is their device and edges connect ports. Use peer to show only the links between two devices",
+		Tags:        []string{"visualization"},
+	}, h.GetPortTopology)
+
+	// ドキュメント貼り付け用のエクスポート（format=mermaid で Mermaid flowchart）
+	huma.Register(api, huma.Operation{
+		OperationID: "export-topology",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/topology/{deviceId}/export",
+		Summary:     "Export a sub-topology",
+		Description: "Export the sub-topology around a device as JSON or as a Mermaid flowchart for Markdown docs and wikis",
+		Tags:        []string{"visualization"},
+	}, h.ExportTopology)
+}
+
+// ExportTopologyResponse is an exported topology in the requested format
+type ExportTopologyResponse struct {
+	ContentType string `header:"Content-Type"`
+	Body        []byte
 }
 
 func (h *VisualizationHandler) GetTopology(ctx context.Context, input *struct {
-	DeviceID       string `path:"deviceId"`
-	Depth          int    `query:"depth" default:"3"`
-	EnableGrouping bool   `query:"enable_grouping" default:"true"`
-	MinGroupSize   int    `query:"min_group_size" default:"3"`
-	MaxGroupDepth  int    `query:"max_group_depth" default:"2"`
-	GroupByPrefix  bool   `query:"group_by_prefix" default:"true"`
-	GroupByType    bool   `query:"group_by_type" default:"false"`
-	PrefixMinLen   int    `query:"prefix_min_len" default:"3"`
+	DeviceID       string `path:"deviceId" doc:"Device ID" example:"core-01"`
+	Depth          int    `query:"depth" default:"3" doc:"Exploration depth from the root device in hops"`
+	EnableGrouping bool   `query:"enable_grouping" default:"true" doc:"Collapse similar devices into group nodes"`
+	MinGroupSize   int    `query:"min_group_size" default:"3" doc:"Minimum number of devices that form a group"`
+	MaxGroupDepth  int    `query:"max_group_depth" default:"2" doc:"Only nodes deeper than this depth are grouped"`
+	GroupByPrefix  bool   `query:"group_by_prefix" default:"true" doc:"Group devices sharing a common name prefix (e.g. access-01..access-48)"`
+	GroupByType    bool   `query:"group_by_type" default:"false" doc:"Group devices of the same type"`
+	PrefixMinLen   int    `query:"prefix_min_len" default:"3" doc:"Minimum length of a common name prefix"`
+	Fields         string `query:"fields" doc:"Comma-separated node fields to return (e.g. id,type,layer)"`
+	EdgeLabelParams
+	EdgeColorParams
+	EdgeBundleParams
+	SchemaVersionParams
+	LayerBandParams
+	DeviceFilterParams
 }) (*struct {
-	Body visualization.VisualTopology
+	Body visualTopologyBody
 }, error) {
+	fields, err := parseFieldSelection(input.Fields, input.SchemaVersionParams.nodeModel(), nil)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := input.DeviceFilterParams.filter()
+	if err != nil {
+		return nil, err
+	}
+
 	groupingOpts := visualization.GroupingOptions{
 		Enabled:       input.EnableGrouping,
 		MinGroupSize:  input.MinGroupSize,
@@ -72,51 +354,196 @@ func (h *VisualizationHandler) GetTopology(ctx context.Context, input *struct {
 		PrefixMinLen:  input.PrefixMinLen,
 	}
 
-	visualTopology, err := h.visualizationService.GetVisualTopologyWithGrouping(ctx, input.DeviceID, input.Depth, groupingOpts)
+	visualTopology, err := h.visualizationService.GetFilteredVisualTopology(ctx, input.DeviceID, input.Depth, groupingOpts, filter)
+	if err != nil {
+		return nil, visualizationError("Failed to get visual topology", err)
+	}
+	input.EdgeLabelParams.apply(visualTopology)
+	input.EdgeBundleParams.apply(visualTopology)
+	if err := input.EdgeColorParams.apply(ctx, h.utilizationSource, visualTopology); err != nil {
+		return nil, err
+	}
+	if err := input.LayerBandParams.apply(ctx, h.visualizationService, visualTopology); err != nil {
+		return nil, err
+	}
+
+	body, err := newVisualTopologyBody(visualTopology, fields, input.SchemaVersion)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to select node fields", err)
+	}
+
+	return &struct {
+		Body visualTopologyBody
+	}{
+		Body: *body,
+	}, nil
+}
+
+func (h *VisualizationHandler) ExpandGroup(ctx context.Context, input *struct {
+	DeviceID      string `path:"deviceId" doc:"Device ID" example:"core-01"`
+	GroupID       string `path:"groupId" doc:"Group node ID returned in a visual topology" example:"group_prefix_access"`
+	Depth         int    `query:"depth" default:"3" doc:"Exploration depth from the root device in hops"`
+	ExpandDepth   int    `query:"expand_depth" default:"2" doc:"Depth explored below the expanded group"`
+	MinGroupSize  int    `query:"min_group_size" default:"3" doc:"Minimum number of devices that form a group"`
+	MaxGroupDepth int    `query:"max_group_depth" default:"2" doc:"Only nodes deeper than this depth are grouped"`
+	GroupByPrefix bool   `query:"group_by_prefix" default:"true" doc:"Group devices sharing a common name prefix (e.g. access-01..access-48)"`
+	GroupByType   bool   `query:"group_by_type" default:"false" doc:"Group devices of the same type"`
+	PrefixMinLen  int    `query:"prefix_min_len" default:"3" doc:"Minimum length of a common name prefix"`
+	EdgeLabelParams
+	EdgeColorParams
+	EdgeBundleParams
+	SchemaVersionParams
+	LayerBandParams
+	Body struct {
+		Positions map[string]visualization.Position `json:"positions,omitempty" doc:"Node positions currently shown by the client"`
+	} `required:"false"`
+}) (*struct {
+	Body visualTopologyBody
+}, error) {
+	groupingOpts := visualization.GroupingOptions{
+		Enabled:       true,
+		MinGroupSize:  input.MinGroupSize,
+		MaxDepth:      input.MaxGroupDepth,
+		GroupByPrefix: input.GroupByPrefix,
+		GroupByType:   input.GroupByType,
+		PrefixMinLen:  input.PrefixMinLen,
+	}
+
+	visualTopology, err := h.visualizationService.ExpandGroup(ctx, input.DeviceID, input.GroupID, input.Depth, input.ExpandDepth, groupingOpts, input.Body.Positions)
+	if err != nil {
+		return nil, visualizationError("Failed to expand group", err)
+	}
+	input.EdgeLabelParams.apply(visualTopology)
+	input.EdgeBundleParams.apply(visualTopology)
+	if err := input.EdgeColorParams.apply(ctx, h.utilizationSource, visualTopology); err != nil {
+		return nil, err
+	}
+	if err := input.LayerBandParams.apply(ctx, h.visualizationService, visualTopology); err != nil {
+		return nil, err
+	}
+
+	body, err := newVisualTopologyBody(visualTopology, nil, input.SchemaVersion)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to get visual topology", err)
+		return nil, huma.Error500InternalServerError("Failed to render topology", err)
 	}
 
 	return &struct {
-		Body visualization.VisualTopology
+		Body visualTopologyBody
 	}{
-		Body: *visualTopology,
+		Body: *body,
+	}, nil
+}
+
+func (h *VisualizationHandler) PreviewGroups(ctx context.Context, input *struct {
+	DeviceID      string `path:"deviceId" doc:"Device ID" example:"core-01"`
+	Depth         int    `query:"depth" default:"3" doc:"Exploration depth from the root device in hops"`
+	MinGroupSize  int    `query:"min_group_size" default:"3" doc:"Minimum number of devices that form a group"`
+	MaxGroupDepth int    `query:"max_group_depth" default:"2" doc:"Only nodes deeper than this depth are grouped"`
+	GroupByPrefix bool   `query:"group_by_prefix" default:"true" doc:"Group devices sharing a common name prefix (e.g. access-01..access-48)"`
+	GroupByType   bool   `query:"group_by_type" default:"false" doc:"Group devices of the same type"`
+	PrefixMinLen  int    `query:"prefix_min_len" default:"3" doc:"Minimum length of a common name prefix"`
+}) (*struct {
+	Body visualization.GroupingPreview
+}, error) {
+	groupingOpts := visualization.GroupingOptions{
+		Enabled:       true,
+		MinGroupSize:  input.MinGroupSize,
+		MaxDepth:      input.MaxGroupDepth,
+		GroupByPrefix: input.GroupByPrefix,
+		GroupByType:   input.GroupByType,
+		PrefixMinLen:  input.PrefixMinLen,
+	}
+
+	preview, err := h.visualizationService.PreviewGroups(ctx, input.DeviceID, input.Depth, groupingOpts)
+	if err != nil {
+		return nil, visualizationError("Failed to preview groups", err)
+	}
+
+	return &struct {
+		Body visualization.GroupingPreview
+	}{
+		Body: *preview,
 	}, nil
 }
 
 // GetVisualTopology returns topology data optimized for hierarchical display
 func (h *VisualizationHandler) GetVisualTopology(ctx context.Context, input *struct {
-	DeviceID string `path:"deviceId"`
-	Depth    int    `query:"depth" default:"3"`
+	DeviceID string `path:"deviceId" doc:"Device ID" example:"core-01"`
+	Depth    int    `query:"depth" default:"3" doc:"Exploration depth from the root device in hops"`
+	Fields   string `query:"fields" doc:"Comma-separated node fields to return (e.g. id,type,layer)"`
+	EdgeLabelParams
+	EdgeColorParams
+	EdgeBundleParams
+	SchemaVersionParams
+	LayerBandParams
+	DeviceFilterParams
 }) (*struct {
-	Body visualization.VisualTopology
+	Body visualTopologyBody
 }, error) {
+	fields, err := parseFieldSelection(input.Fields, input.SchemaVersionParams.nodeModel(), nil)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := input.DeviceFilterParams.filter()
+	if err != nil {
+		return nil, err
+	}
+
 	// シンプルなビジュアルトポロジー取得（グループ化なし）
-	visualTopology, err := h.visualizationService.GetSimpleVisualTopology(ctx, input.DeviceID, input.Depth)
+	visualTopology, err := h.visualizationService.GetSimpleVisualTopology(ctx, input.DeviceID, input.Depth, filter)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to get visual topology", err)
+		return nil, visualizationError("Failed to get visual topology", err)
+	}
+	input.EdgeLabelParams.apply(visualTopology)
+	input.EdgeBundleParams.apply(visualTopology)
+	if err := input.EdgeColorParams.apply(ctx, h.utilizationSource, visualTopology); err != nil {
+		return nil, err
+	}
+	if err := input.LayerBandParams.apply(ctx, h.visualizationService, visualTopology); err != nil {
+		return nil, err
+	}
+
+	body, err := newVisualTopologyBody(visualTopology, fields, input.SchemaVersion)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to select node fields", err)
 	}
 
 	return &struct {
-		Body visualization.VisualTopology
+		Body visualTopologyBody
 	}{
-		Body: *visualTopology,
+		Body: *body,
 	}, nil
 }
 
 func (h *VisualizationHandler) ExpandFromDevice(ctx context.Context, input *struct {
-	DeviceID       string `path:"deviceId"`
-	Depth          int    `query:"depth" default:"2"`
-	EnableGrouping bool   `query:"enable_grouping" default:"true"`
-	MinGroupSize   int    `query:"min_group_size" default:"3"`
-	MaxGroupDepth  int    `query:"max_group_depth" default:"2"`
-	GroupByPrefix  bool   `query:"group_by_prefix" default:"true"`
-	GroupByType    bool   `query:"group_by_type" default:"false"`
-	GroupByDepth   bool   `query:"group_by_depth" default:"false"`
-	PrefixMinLen   int    `query:"prefix_min_len" default:"3"`
+	DeviceID       string `path:"deviceId" doc:"Device ID" example:"core-01"`
+	Depth          int    `query:"depth" default:"2" doc:"Exploration depth from the root device in hops"`
+	EnableGrouping bool   `query:"enable_grouping" default:"true" doc:"Collapse similar devices into group nodes"`
+	MinGroupSize   int    `query:"min_group_size" default:"3" doc:"Minimum number of devices that form a group"`
+	MaxGroupDepth  int    `query:"max_group_depth" default:"2" doc:"Only nodes deeper than this depth are grouped"`
+	GroupByPrefix  bool   `query:"group_by_prefix" default:"true" doc:"Group devices sharing a common name prefix (e.g. access-01..access-48)"`
+	GroupByType    bool   `query:"group_by_type" default:"false" doc:"Group devices of the same type"`
+	GroupByDepth   bool   `query:"group_by_depth" default:"false" doc:"Group devices at the same depth"`
+	PrefixMinLen   int    `query:"prefix_min_len" default:"3" doc:"Minimum length of a common name prefix"`
+	Fields         string `query:"fields" doc:"Comma-separated node fields to return (e.g. id,type,layer)"`
+	EdgeLabelParams
+	EdgeColorParams
+	EdgeBundleParams
+	SchemaVersionParams
+	LayerBandParams
+	DeviceFilterParams
 }) (*struct {
-	Body visualization.VisualTopology
+	Body visualTopologyBody
 }, error) {
+	fields, err := parseFieldSelection(input.Fields, input.SchemaVersionParams.nodeModel(), nil)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := input.DeviceFilterParams.filter()
+	if err != nil {
+		return nil, err
+	}
+
 	groupingOpts := visualization.GroupingOptions{
 		Enabled:       input.EnableGrouping,
 		MinGroupSize:  input.MinGroupSize,
@@ -127,14 +554,157 @@ func (h *VisualizationHandler) ExpandFromDevice(ctx context.Context, input *stru
 		PrefixMinLen:  input.PrefixMinLen,
 	}
 
-	visualTopology, err := h.visualizationService.GetVisualTopologyWithGrouping(ctx, input.DeviceID, input.Depth, groupingOpts)
+	visualTopology, err := h.visualizationService.GetFilteredVisualTopology(ctx, input.DeviceID, input.Depth, groupingOpts, filter)
+	if err != nil {
+		return nil, visualizationError("Failed to get visual topology", err)
+	}
+	input.EdgeLabelParams.apply(visualTopology)
+	input.EdgeBundleParams.apply(visualTopology)
+	if err := input.EdgeColorParams.apply(ctx, h.utilizationSource, visualTopology); err != nil {
+		return nil, err
+	}
+	if err := input.LayerBandParams.apply(ctx, h.visualizationService, visualTopology); err != nil {
+		return nil, err
+	}
+
+	body, err := newVisualTopologyBody(visualTopology, fields, input.SchemaVersion)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to select node fields", err)
+	}
+
+	return &struct {
+		Body visualTopologyBody
+	}{
+		Body: *body,
+	}, nil
+}
+
+func (h *VisualizationHandler) GetGroupMembers(ctx context.Context, input *struct {
+	GroupID       string `path:"groupId" doc:"Group node ID returned in a visual topology" example:"group_prefix_access"`
+	Root          string `query:"root" required:"true" doc:"Root device of the grouped topology"`
+	Depth         int    `query:"depth" default:"3" doc:"Exploration depth from the root device in hops"`
+	MinGroupSize  int    `query:"min_group_size" default:"3" doc:"Minimum number of devices that form a group"`
+	MaxGroupDepth int    `query:"max_group_depth" default:"2" doc:"Only nodes deeper than this depth are grouped"`
+	GroupByPrefix bool   `query:"group_by_prefix" default:"true" doc:"Group devices sharing a common name prefix (e.g. access-01..access-48)"`
+	GroupByType   bool   `query:"group_by_type" default:"false" doc:"Group devices of the same type"`
+	GroupByDepth  bool   `query:"group_by_depth" default:"false" doc:"Group devices at the same depth"`
+	PrefixMinLen  int    `query:"prefix_min_len" default:"3" doc:"Minimum length of a common name prefix"`
+}) (*struct {
+	Body visualization.GroupMembers
+}, error) {
+	groupingOpts := visualization.GroupingOptions{
+		Enabled:       true,
+		MinGroupSize:  input.MinGroupSize,
+		MaxDepth:      input.MaxGroupDepth,
+		GroupByPrefix: input.GroupByPrefix,
+		GroupByType:   input.GroupByType,
+		GroupByDepth:  input.GroupByDepth,
+		PrefixMinLen:  input.PrefixMinLen,
+	}
+
+	members, err := h.visualizationService.GetGroupMembers(ctx, input.Root, input.GroupID, input.Depth, groupingOpts)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to get visual topology", err)
+		return nil, visualizationError("Failed to get group members", err)
 	}
 
 	return &struct {
-		Body visualization.VisualTopology
+		Body visualization.GroupMembers
 	}{
-		Body: *visualTopology,
+		Body: *members,
+	}, nil
+}
+
+func (h *VisualizationHandler) GetPortTopology(ctx context.Context, input *struct {
+	DeviceID string `path:"deviceId" doc:"Device ID" example:"core-01"`
+	Depth    int    `query:"depth" default:"1" doc:"Exploration depth from the root device in hops"`
+	Peer     string `query:"peer" doc:"Only show links between the device and this peer device"`
+	EdgeLabelParams
+	EdgeBundleParams
+	SchemaVersionParams
+}) (*struct {
+	Body visualTopologyBody
+}, error) {
+	visualTopology, err := h.visualizationService.GetPortTopology(ctx, input.DeviceID, input.Depth, input.Peer)
+	if err != nil {
+		return nil, visualizationError("Failed to get port topology", err)
+	}
+	input.EdgeLabelParams.apply(visualTopology)
+	input.EdgeBundleParams.apply(visualTopology)
+
+	body, err := newVisualTopologyBody(visualTopology, nil, input.SchemaVersion)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to render topology", err)
+	}
+
+	return &struct {
+		Body visualTopologyBody
+	}{
+		Body: *body,
+	}, nil
+}
+
+func (h *VisualizationHandler) ExportTopology(ctx context.Context, input *struct {
+	DeviceID       string `path:"deviceId" doc:"Device ID" example:"core-01"`
+	Format         string `query:"format" default:"json" enum:"json,mermaid" doc:"Export format"`
+	Direction      string `query:"direction" default:"TB" doc:"Mermaid flowchart direction (TB, BT, LR, RL)"`
+	Depth          int    `query:"depth" default:"3" doc:"Exploration depth from the root device in hops"`
+	EnableGrouping bool   `query:"enable_grouping" default:"false" doc:"Collapse similar devices into group nodes"`
+	MinGroupSize   int    `query:"min_group_size" default:"3" doc:"Minimum number of devices that form a group"`
+	MaxGroupDepth  int    `query:"max_group_depth" default:"2" doc:"Only nodes deeper than this depth are grouped"`
+	GroupByPrefix  bool   `query:"group_by_prefix" default:"true" doc:"Group devices sharing a common name prefix (e.g. access-01..access-48)"`
+	GroupByType    bool   `query:"group_by_type" default:"false" doc:"Group devices of the same type"`
+	PrefixMinLen   int    `query:"prefix_min_len" default:"3" doc:"Minimum length of a common name prefix"`
+	EdgeLabelParams
+	EdgeColorParams
+	EdgeBundleParams
+	SchemaVersionParams
+	DeviceFilterParams
+}) (*ExportTopologyResponse, error) {
+	filter, err := input.DeviceFilterParams.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	groupingOpts := visualization.GroupingOptions{
+		Enabled:       input.EnableGrouping,
+		MinGroupSize:  input.MinGroupSize,
+		MaxDepth:      input.MaxGroupDepth,
+		GroupByPrefix: input.GroupByPrefix,
+		GroupByType:   input.GroupByType,
+		PrefixMinLen:  input.PrefixMinLen,
+	}
+
+	visualTopology, err := h.visualizationService.GetFilteredVisualTopology(ctx, input.DeviceID, input.Depth, groupingOpts, filter)
+	if err != nil {
+		return nil, visualizationError("Failed to get visual topology", err)
+	}
+	input.EdgeLabelParams.apply(visualTopology)
+	input.EdgeBundleParams.apply(visualTopology)
+	if err := input.EdgeColorParams.apply(ctx, h.utilizationSource, visualTopology); err != nil {
+		return nil, err
+	}
+
+	if input.Format == "mermaid" {
+		diagram, err := visualization.RenderMermaid(visualTopology, input.Direction)
+		if err != nil {
+			return nil, huma.Error400BadRequest(err.Error())
+		}
+		return &ExportTopologyResponse{
+			ContentType: "text/plain; charset=utf-8",
+			Body:        []byte(diagram),
+		}, nil
+	}
+
+	body, err := newVisualTopologyBody(visualTopology, nil, input.SchemaVersion)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to render topology", err)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("Failed to encode topology", err)
+	}
+	return &ExportTopologyResponse{
+		ContentType: "application/json",
+		Body:        data,
 	}, nil
 }
@@ -1,339 +1,302 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
-	"net/http/httptest"
 	"testing"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
 	"github.com/go-chi/chi/v5"
+	"github.com/servak/topology-manager/internal/domain/topology"
 	"github.com/servak/topology-manager/internal/service"
 	"github.com/servak/topology-manager/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func setupVisualizationHandler(t *testing.T) (*VisualizationHandler, *testutil.TestSetup, huma.API) {
+func setupVisualizationHandler(t *testing.T) http.Handler {
 	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
 	setup.SeedTestData(t)
+	return visualizationRouter(setup)
+}
 
-	// Create services
-	visualizationService := service.NewVisualizationService(setup.Repo, setup.Logger)
-
-	// Create handler
-	handler := NewVisualizationHandler(visualizationService, setup.Logger)
+func visualizationRouter(setup *testutil.TestSetup) http.Handler {
+	handler := NewVisualizationHandler(service.NewVisualizationService(setup.Repo), setup.Logger)
 
-	// Create test API
 	router := chi.NewRouter()
-	config := huma.DefaultConfig("Test API", "1.0.0")
-	api := humachi.New(router, config)
-
-	// Register routes
+	api := humachi.New(router, huma.DefaultConfig("Test API", "1.0.0"))
 	handler.Register(api)
 
-	return handler, setup, api
+	return router
 }
 
-func TestVisualizationHandler_GetTopology(t *testing.T) {
-	handler, setup, api := setupVisualizationHandler(t)
-	defer setup.Cleanup()
-
-	// Create test request for device-001 with default depth
-	req := httptest.NewRequest("GET", "/api/v1/topology/device-001", nil)
-	resp := httptest.NewRecorder()
+type visualTopologyResponse struct {
+	SchemaVersion int    `json:"schema_version"`
+	RootDevice    string `json:"root_device"`
+	Depth         int    `json:"depth"`
+	Stats         struct {
+		TotalNodes int            `json:"total_nodes"`
+		TotalEdges int            `json:"total_edges"`
+		Layers     map[string]int `json:"layers"`
+	} `json:"stats"`
+	Nodes []map[string]interface{} `json:"nodes"`
+	Edges []struct {
+		ID     string  `json:"id"`
+		Source string  `json:"source"`
+		Target string  `json:"target"`
+		Weight float64 `json:"weight"`
+		Label  string  `json:"label"`
+		Style  struct {
+			Color string `json:"color"`
+		} `json:"style"`
+	} `json:"edges"`
+}
 
-	// Execute request
-	api.ServeHTTP(resp, req)
+func getVisualTopology(t *testing.T, router http.Handler, path string) visualTopologyResponse {
+	t.Helper()
 
-	// Check response
-	assert.Equal(t, http.StatusOK, resp.Code)
+	resp := serveJSON(t, router, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
 	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
 
-	// Parse response body
-	var response struct {
-		RootDevice string `json:"root_device"`
-		Depth      int    `json:"depth"`
-		Stats      struct {
-			TotalNodes int `json:"total_nodes"`
-			TotalEdges int `json:"total_edges"`
-			MaxDepth   int `json:"max_depth"`
-		} `json:"stats"`
-		Nodes []struct {
-			ID       string `json:"id"`
-			Label    string `json:"label"`
-			Position struct {
-				X float64 `json:"x"`
-				Y float64 `json:"y"`
-			} `json:"position"`
-			Style struct {
-				BackgroundColor string `json:"backgroundColor"`
-				Shape          string `json:"shape"`
-			} `json:"style"`
-			Data struct {
-				Type     string `json:"type"`
-				Hardware string `json:"hardware"`
-			} `json:"data"`
-		} `json:"nodes"`
-		Edges []struct {
-			ID     string `json:"id"`
-			Source string `json:"source"`
-			Target string `json:"target"`
-			Style  struct {
-				LineColor string `json:"lineColor"`
-			} `json:"style"`
-			Data struct {
-				Weight float64 `json:"weight"`
-			} `json:"data"`
-		} `json:"edges"`
-	}
-	err := json.Unmarshal(resp.Body.Bytes(), &response)
-	require.NoError(t, err)
-
-	// Verify basic topology structure
-	assert.Equal(t, "device-001", response.RootDevice)
-	assert.GreaterOrEqual(t, response.Depth, 0)
-	assert.GreaterOrEqual(t, response.Stats.TotalNodes, 1)
-	assert.GreaterOrEqual(t, len(response.Nodes), 1)
-
-	// Check that root device is in nodes
-	foundRootDevice := false
-	for _, node := range response.Nodes {
-		if node.ID == "device-001" {
-			foundRootDevice = true
-			assert.NotEmpty(t, node.Label)
-			assert.NotEmpty(t, node.Style.BackgroundColor)
-			assert.NotEmpty(t, node.Data.Type)
-			break
-		}
-	}
-	assert.True(t, foundRootDevice, "Should find root device in nodes")
-
-	// Verify stats consistency
+	var response visualTopologyResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
 	assert.Equal(t, len(response.Nodes), response.Stats.TotalNodes)
 	assert.Equal(t, len(response.Edges), response.Stats.TotalEdges)
+	return response
 }
 
-func TestVisualizationHandler_GetTopologyWithDepth(t *testing.T) {
-	handler, setup, api := setupVisualizationHandler(t)
-	defer setup.Cleanup()
-
-	// Create test request with specific depth
-	req := httptest.NewRequest("GET", "/api/v1/topology/device-002?depth=1", nil)
-	resp := httptest.NewRecorder()
-
-	// Execute request
-	api.ServeHTTP(resp, req)
-
-	// Check response
-	assert.Equal(t, http.StatusOK, resp.Code)
-
-	var response struct {
-		RootDevice string `json:"root_device"`
-		Depth      int    `json:"depth"`
-		Stats      struct {
-			TotalNodes int `json:"total_nodes"`
-			TotalEdges int `json:"total_edges"`
-			MaxDepth   int `json:"max_depth"`
-		} `json:"stats"`
-		Nodes []map[string]interface{} `json:"nodes"`
-		Edges []map[string]interface{} `json:"edges"`
+func nodeIDs(response visualTopologyResponse) map[string]bool {
+	ids := make(map[string]bool)
+	for _, node := range response.Nodes {
+		ids[node["id"].(string)] = true
 	}
-	err := json.Unmarshal(resp.Body.Bytes(), &response)
-	require.NoError(t, err)
+	return ids
+}
+
+func TestVisualizationHandler_GetTopology(t *testing.T) {
+	router := setupVisualizationHandler(t)
+
+	// device-002 は device-001 と device-003 の両方とつながっている
+	response := getVisualTopology(t, router, "/api/v1/topology/device-002?depth=1")
 
-	// Should have device-002 as root with depth 1
 	assert.Equal(t, "device-002", response.RootDevice)
 	assert.Equal(t, 1, response.Depth)
-	assert.Equal(t, 1, response.Stats.MaxDepth)
-
-	// Should have multiple nodes (device-002 plus connected devices)
-	assert.GreaterOrEqual(t, response.Stats.TotalNodes, 2)
-	assert.GreaterOrEqual(t, len(response.Nodes), 2)
+	assert.Equal(t, map[string]bool{"device-001": true, "device-002": true, "device-003": true}, nodeIDs(response))
+	assert.Len(t, response.Edges, 2)
+	for _, edge := range response.Edges {
+		assert.True(t, edge.Source == "device-002" || edge.Target == "device-002", "Edge %s should touch the root", edge.ID)
+		assert.NotEmpty(t, edge.Style.Color)
+	}
 
-	// Should have edges representing connections
-	assert.GreaterOrEqual(t, response.Stats.TotalEdges, 1)
-	assert.GreaterOrEqual(t, len(response.Edges), 1)
+	for _, node := range response.Nodes {
+		if node["id"] == "device-002" {
+			assert.Equal(t, true, node["is_root"])
+			assert.NotEmpty(t, node["hardware"])
+		}
+	}
 }
 
-func TestVisualizationHandler_GetTopologyWithDepthZero(t *testing.T) {
-	handler, setup, api := setupVisualizationHandler(t)
-	defer setup.Cleanup()
-
-	// Create test request with depth 0
-	req := httptest.NewRequest("GET", "/api/v1/topology/device-001?depth=0", nil)
-	resp := httptest.NewRecorder()
-
-	// Execute request
-	api.ServeHTTP(resp, req)
-
-	// Check response
-	assert.Equal(t, http.StatusOK, resp.Code)
-
-	var response struct {
-		RootDevice string `json:"root_device"`
-		Depth      int    `json:"depth"`
-		Stats      struct {
-			TotalNodes int `json:"total_nodes"`
-			TotalEdges int `json:"total_edges"`
-		} `json:"stats"`
-		Nodes []map[string]interface{} `json:"nodes"`
-		Edges []map[string]interface{} `json:"edges"`
-	}
-	err := json.Unmarshal(resp.Body.Bytes(), &response)
-	require.NoError(t, err)
+func TestVisualizationHandler_GetTopologyDefaultDepth(t *testing.T) {
+	router := setupVisualizationHandler(t)
 
-	// Should have only the root device
+	// depth を省略または 0 にすると既定の深さで探索する
+	response := getVisualTopology(t, router, "/api/v1/topology/device-001")
 	assert.Equal(t, "device-001", response.RootDevice)
-	assert.Equal(t, 0, response.Depth)
-	assert.Equal(t, 1, response.Stats.TotalNodes)
-	assert.Equal(t, 1, len(response.Nodes))
-	assert.Equal(t, 0, response.Stats.TotalEdges)
-	assert.Equal(t, 0, len(response.Edges))
+	assert.True(t, nodeIDs(response)["device-001"], "Should find root device in nodes")
+	assert.True(t, nodeIDs(response)["device-002"], "Should find the neighbor of the root device")
+
+	response = getVisualTopology(t, router, "/api/v1/topology/device-001?depth=0")
+	assert.Equal(t, 3, response.Depth)
 }
 
-func TestVisualizationHandler_GetTopologyNonExistentDevice(t *testing.T) {
-	handler, setup, api := setupVisualizationHandler(t)
-	defer setup.Cleanup()
-
-	// Create test request for non-existent device
-	req := httptest.NewRequest("GET", "/api/v1/topology/non-existent-device", nil)
-	resp := httptest.NewRecorder()
-
-	// Execute request
-	api.ServeHTTP(resp, req)
-
-	// Should return error or empty topology
-	if resp.Code == http.StatusNotFound || resp.Code == http.StatusBadRequest {
-		// Error response is acceptable
-		var errorResponse struct {
-			Title  string `json:"title"`
-			Status int    `json:"status"`
-			Detail string `json:"detail"`
-		}
-		err := json.Unmarshal(resp.Body.Bytes(), &errorResponse)
-		require.NoError(t, err)
-		assert.Contains(t, errorResponse.Detail, "not found")
-	} else if resp.Code == http.StatusOK {
-		// Empty topology is also acceptable
-		var response struct {
-			Stats struct {
-				TotalNodes int `json:"total_nodes"`
-			} `json:"stats"`
-		}
-		err := json.Unmarshal(resp.Body.Bytes(), &response)
-		require.NoError(t, err)
-		assert.Equal(t, 0, response.Stats.TotalNodes)
-	} else {
-		t.Errorf("Unexpected response code: %d", resp.Code)
-	}
+func TestVisualizationHandler_GetTopologyWithFilter(t *testing.T) {
+	router := setupVisualizationHandler(t)
+
+	response := getVisualTopology(t, router, "/api/v1/topology/device-002?depth=1&exclude_hardware=device-003")
+	assert.Equal(t, map[string]bool{"device-001": true, "device-002": true}, nodeIDs(response))
+	require.Len(t, response.Edges, 1)
 }
 
-func TestVisualizationHandler_GetTopologyInvalidDepth(t *testing.T) {
-	handler, setup, api := setupVisualizationHandler(t)
-	defer setup.Cleanup()
+func TestVisualizationHandler_GetTopologyWithMetadataFilter(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+	setup.SeedSiteMetadata(t)
+	router := visualizationRouter(setup)
+
+	response := getVisualTopology(t, router, "/api/v1/topology/device-002?depth=1&metadata=site=tokyo")
+	assert.Equal(t, map[string]bool{"device-001": true, "device-002": true}, nodeIDs(response))
 
-	t.Run("Negative depth", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/v1/topology/device-001?depth=-1", nil)
-		resp := httptest.NewRecorder()
+	response = getVisualTopology(t, router, "/api/v1/topology/device-002?depth=1&exclude_metadata=site=tokyo")
+	assert.Equal(t, map[string]bool{"device-002": true, "device-003": true}, nodeIDs(response))
+}
 
-		api.ServeHTTP(resp, req)
+func TestVisualizationHandler_GetTopologyNonExistentDevice(t *testing.T) {
+	router := setupVisualizationHandler(t)
+
+	for _, path := range []string{
+		"/api/v1/topology/non-existent-device",
+		"/api/v1/topology/visual/non-existent-device",
+		"/api/v1/topology/non-existent-device/groups/preview",
+		"/api/v1/topology/non-existent-device/ports",
+	} {
+		resp := serveJSON(t, router, http.MethodGet, path, nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code, path)
+	}
+}
 
-		// Should handle gracefully (either use default or return error)
-		assert.True(t, resp.Code == http.StatusOK || resp.Code == http.StatusBadRequest)
-	})
+func TestVisualizationHandler_GetTopologyInvalidParameters(t *testing.T) {
+	router := setupVisualizationHandler(t)
 
 	t.Run("Invalid depth format", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/v1/topology/device-001?depth=invalid", nil)
-		resp := httptest.NewRecorder()
-
-		api.ServeHTTP(resp, req)
+		resp := serveJSON(t, router, http.MethodGet, "/api/v1/topology/device-001?depth=invalid", nil)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+	})
 
-		// Should handle gracefully
-		assert.True(t, resp.Code == http.StatusOK || resp.Code == http.StatusBadRequest)
+	t.Run("Unknown field", func(t *testing.T) {
+		resp := serveJSON(t, router, http.MethodGet, "/api/v1/topology/device-001?fields=colour", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
 	})
 
 	t.Run("Very large depth", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/v1/topology/device-001?depth=1000", nil)
-		resp := httptest.NewRecorder()
-
-		api.ServeHTTP(resp, req)
-
-		// Should handle gracefully (might cap the depth)
+		resp := serveJSON(t, router, http.MethodGet, "/api/v1/topology/device-001?depth=1000", nil)
 		assert.Equal(t, http.StatusOK, resp.Code)
 	})
 }
 
 func TestVisualizationHandler_GetTopologyResponseStructure(t *testing.T) {
-	handler, setup, api := setupVisualizationHandler(t)
-	defer setup.Cleanup()
+	router := setupVisualizationHandler(t)
+
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/topology/device-002?depth=1", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+
+	for _, key := range []string{"schema_version", "root_device", "depth", "stats", "nodes", "edges", "layout"} {
+		assert.Contains(t, response, key)
+	}
+
+	stats := response["stats"].(map[string]interface{})
+	for _, key := range []string{"total_nodes", "total_edges", "layers", "degree"} {
+		assert.Contains(t, stats, key)
+	}
+
+	nodes := response["nodes"].([]interface{})
+	require.NotEmpty(t, nodes)
+	node := nodes[0].(map[string]interface{})
+	for _, key := range []string{"id", "name", "type", "hardware", "layer", "position", "style"} {
+		assert.Contains(t, node, key)
+	}
 
-	// Create test request
-	req := httptest.NewRequest("GET", "/api/v1/topology/device-002?depth=1", nil)
-	resp := httptest.NewRecorder()
+	edges := response["edges"].([]interface{})
+	require.NotEmpty(t, edges)
+	edge := edges[0].(map[string]interface{})
+	for _, key := range []string{"id", "source", "target", "weight", "style"} {
+		assert.Contains(t, edge, key)
+	}
+}
 
-	// Execute request
-	api.ServeHTTP(resp, req)
+func TestVisualizationHandler_GetTopologySchemaVersion1(t *testing.T) {
+	router := setupVisualizationHandler(t)
 
-	// Check response
-	assert.Equal(t, http.StatusOK, resp.Code)
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/topology/device-002?depth=1&schema_version=1", nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
 
-	// Parse and validate detailed response structure
 	var response map[string]interface{}
-	err := json.Unmarshal(resp.Body.Bytes(), &response)
-	require.NoError(t, err)
-
-	// Check required top-level fields
-	assert.Contains(t, response, "root_device")
-	assert.Contains(t, response, "depth")
-	assert.Contains(t, response, "stats")
-	assert.Contains(t, response, "nodes")
-	assert.Contains(t, response, "edges")
-
-	// Check stats structure
-	stats, ok := response["stats"].(map[string]interface{})
-	require.True(t, ok, "Stats should be an object")
-	assert.Contains(t, stats, "total_nodes")
-	assert.Contains(t, stats, "total_edges")
-	assert.Contains(t, stats, "max_depth")
-
-	// Check nodes structure
-	nodes, ok := response["nodes"].([]interface{})
-	require.True(t, ok, "Nodes should be an array")
-	
-	if len(nodes) > 0 {
-		node := nodes[0].(map[string]interface{})
-		assert.Contains(t, node, "id")
-		assert.Contains(t, node, "label")
-		assert.Contains(t, node, "position")
-		assert.Contains(t, node, "style")
-		assert.Contains(t, node, "data")
-
-		// Check position structure
-		position := node["position"].(map[string]interface{})
-		assert.Contains(t, position, "x")
-		assert.Contains(t, position, "y")
-
-		// Check style structure
-		style := node["style"].(map[string]interface{})
-		assert.Contains(t, style, "backgroundColor")
-
-		// Check data structure
-		data := node["data"].(map[string]interface{})
-		assert.Contains(t, data, "type")
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+	assert.Equal(t, float64(1), response["schema_version"])
+	assert.NotContains(t, response["stats"], "degree", "Version 1 has no degree statistics")
+
+	nodes := response["nodes"].([]interface{})
+	require.NotEmpty(t, nodes)
+	assert.NotContains(t, nodes[0], "workflow_state", "Version 1 nodes have no workflow state")
+}
+
+func TestVisualizationHandler_EdgeLabels(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+	labeled := testutil.CreateTestLink("link-001", "device-001", "device-002")
+	labeled.Metadata = map[string]string{"speed": "100G", "link_type": "L3"}
+	require.NoError(t, setup.Repo.BulkAddLinks(context.Background(), []topology.Link{labeled}))
+	router := visualizationRouter(setup)
+
+	labels := func(path string) map[string]string {
+		t.Helper()
+		labels := make(map[string]string)
+		for _, edge := range getVisualTopology(t, router, path).Edges {
+			labels[edge.ID] = edge.Label
+		}
+		return labels
 	}
 
-	// Check edges structure if any edges exist
-	edges, ok := response["edges"].([]interface{})
-	require.True(t, ok, "Edges should be an array")
-
-	if len(edges) > 0 {
-		edge := edges[0].(map[string]interface{})
-		assert.Contains(t, edge, "id")
-		assert.Contains(t, edge, "source")
-		assert.Contains(t, edge, "target")
-		assert.Contains(t, edge, "style")
-		assert.Contains(t, edge, "data")
+	// 既定のフォーマットは速度とリンク種別。値の無いリンクはラベルなし
+	assert.Equal(t, map[string]string{"link-001": "100G L3", "link-002": ""}, labels("/api/v1/topology/device-002?depth=1"))
+
+	assert.Equal(t, map[string]string{"link-001": "100G", "link-002": "link-002"},
+		labels("/api/v1/topology/device-002?depth=1&edge_label_format=%7Bspeed%7D%20%7Bid%7D"))
+
+	// 密なグラフ向けにラベルを外せる
+	assert.Equal(t, map[string]string{"link-001": "", "link-002": ""},
+		labels("/api/v1/topology/device-002?depth=1&edge_labels=false&edge_label_format=%7Bspeed%7D"))
+
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/topology/device-002?depth=1&schema_version=1", nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), `"label"`, "Version 1 edges have no labels")
+}
+
+func TestVisualizationHandler_GroupMembers(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+	ctx := context.Background()
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, []topology.Device{testutil.CreateTestDevice("device-004")}))
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, []topology.Link{testutil.CreateTestLink("link-003", "device-002", "device-004")}))
+	router := visualizationRouter(setup)
+
+	// device-002 の隣接 3 台が device- のグループになる
+	params := "root=device-002&depth=1&min_group_size=3&max_group_depth=1"
+	resp := serveJSON(t, router, http.MethodGet, "/api/v1/topology/groups/group-prefix-0/members?"+params, nil)
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var members struct {
+		GroupID string `json:"group_id"`
+		Count   int    `json:"count"`
+		Members []struct {
+			DeviceID      string `json:"device_id"`
+			Hardware      string `json:"hardware"`
+			ClassifiedBy  string `json:"classified_by"`
+			ExternalLinks int    `json:"external_links"`
+		} `json:"members"`
+		InternalLinks int `json:"internal_links"`
+		ExternalPeers []struct {
+			PeerID    string   `json:"peer_id"`
+			LinkCount int      `json:"link_count"`
+			Members   []string `json:"members"`
+		} `json:"external_peers"`
 	}
-}
\ No newline at end of file
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &members))
+	assert.Equal(t, "group-prefix-0", members.GroupID)
+	assert.Equal(t, 3, members.Count)
+	require.Len(t, members.Members, 3)
+	assert.Equal(t, "device-001", members.Members[0].DeviceID)
+	assert.Equal(t, "Test Hardware device-001", members.Members[0].Hardware)
+	assert.Equal(t, "rule:Test Rule", members.Members[0].ClassifiedBy)
+	assert.Equal(t, 1, members.Members[0].ExternalLinks)
+	assert.Equal(t, 0, members.InternalLinks)
+	require.Len(t, members.ExternalPeers, 1)
+	assert.Equal(t, "device-002", members.ExternalPeers[0].PeerID)
+	assert.Equal(t, 3, members.ExternalPeers[0].LinkCount)
+	assert.Equal(t, []string{"device-001", "device-003", "device-004"}, members.ExternalPeers[0].Members)
+
+	resp = serveJSON(t, router, http.MethodGet, "/api/v1/topology/groups/group-prefix-9/members?"+params, nil)
+	assert.Equal(t, http.StatusNotFound, resp.Code, resp.Body.String())
+
+	resp = serveJSON(t, router, http.MethodGet, "/api/v1/topology/groups/group-prefix-0/members", nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.Code, "root is required")
+}
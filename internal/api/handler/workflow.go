@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+type WorkflowHandler struct {
+	workflowService *service.WorkflowService
+	logger          *logger.Logger
+}
+
+func NewWorkflowHandler(workflowService *service.WorkflowService, appLogger *logger.Logger) *WorkflowHandler {
+	return &WorkflowHandler{
+		workflowService: workflowService,
+		logger:          appLogger.WithComponent("workflow_handler"),
+	}
+}
+
+// TransitionWorkflowRequest moves a device to another workflow state
+type TransitionWorkflowRequest struct {
+	DeviceID string `path:"deviceId" doc:"Device ID"`
+	Body     struct {
+		State  string `json:"state" enum:"discovered,onboarding,active,quarantined,decommissioned" doc:"Workflow state to move to"`
+		Reason string `json:"reason,omitempty" doc:"Why the device changes state (e.g. a ticket ID)"`
+	}
+}
+
+type DeviceWorkflowResponse struct {
+	Body topology.DeviceWorkflow
+}
+
+func (h *WorkflowHandler) Register(api huma.API) {
+	// デバイスの構築ワークフロー API
+	huma.Register(api, huma.Operation{
+		OperationID: "get-device-workflow",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/devices/{deviceId}/workflow",
+		Summary:     "Get device workflow",
+		Description: "Get the workflow state of a device (discovered, onboarding, active, quarantined, decommissioned), " +
+			"the states it may move to and its transition history",
+		Tags: []string{"devices"},
+	}, h.GetDeviceWorkflow)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "transition-device-workflow",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/devices/{deviceId}/workflow",
+		Summary:     "Change device workflow state",
+		Description: "Move a device to another workflow state. Only allowed transitions are accepted " +
+			"(e.g. discovered → onboarding → active); syncs never change the state.",
+		Tags: []string{"devices"},
+	}, h.TransitionDeviceWorkflow)
+}
+
+func (h *WorkflowHandler) GetDeviceWorkflow(ctx context.Context, req *struct {
+	DeviceID string `path:"deviceId" doc:"Device ID"`
+}) (*DeviceWorkflowResponse, error) {
+	workflow, err := h.workflowService.GetDeviceWorkflow(ctx, req.DeviceID)
+	if err != nil {
+		if errors.Is(err, service.ErrWorkflowDeviceNotFound) {
+			return nil, huma.Error404NotFound(err.Error(), err)
+		}
+		return nil, huma.Error500InternalServerError("Failed to get device workflow", err)
+	}
+
+	return &DeviceWorkflowResponse{Body: *workflow}, nil
+}
+
+func (h *WorkflowHandler) TransitionDeviceWorkflow(ctx context.Context, req *TransitionWorkflowRequest) (*DeviceWorkflowResponse, error) {
+	workflow, err := h.workflowService.Transition(ctx, req.DeviceID, req.Body.State, req.Body.Reason, requestUser(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrWorkflowDeviceNotFound):
+			return nil, huma.Error404NotFound(err.Error(), err)
+		case errors.Is(err, service.ErrInvalidWorkflowTransition):
+			return nil, huma.Error400BadRequest(err.Error(), err)
+		case errors.Is(err, service.ErrWorkflowStateChanged):
+			return nil, huma.Error409Conflict(err.Error(), err)
+		}
+		h.logger.Error("Failed to change device workflow state", "device_id", req.DeviceID, "state", req.Body.State, "error", err)
+		return nil, huma.Error500InternalServerError("Failed to change device workflow state", err)
+	}
+
+	h.logger.Info("Device workflow state changed", "device_id", req.DeviceID, "state", workflow.State, "user", requestUser(ctx))
+	return &DeviceWorkflowResponse{Body: *workflow}, nil
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/auth"
+)
+
+type principalKey struct{}
+
+// Authenticate requires HTTP basic credentials on requests under /api/, checked by
+// authenticator. Paths with one of publicPrefixes are passed through (health checks, share
+// links with their own token). Users whose role cannot write may only use GET, HEAD and OPTIONS.
+func Authenticate(authenticator auth.Authenticator, publicPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, "/api/") || hasAnyPrefix(r.URL.Path, publicPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			username, password, ok := r.BasicAuth()
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="topology-manager", charset="UTF-8"`)
+				writeProblem(w, http.StatusUnauthorized, "authentication_required", "authentication required")
+				return
+			}
+
+			principal, err := authenticator.Authenticate(r.Context(), username, password)
+			if err != nil {
+				if errors.Is(err, auth.ErrInvalidCredentials) {
+					w.Header().Set("WWW-Authenticate", `Basic realm="topology-manager", charset="UTF-8"`)
+					writeProblem(w, http.StatusUnauthorized, "invalid_credentials", "invalid username or password")
+					return
+				}
+				// 認証基盤の障害は資格情報の誤りと区別する
+				writeProblem(w, http.StatusServiceUnavailable, "authentication_unavailable", "authentication backend unavailable")
+				return
+			}
+
+			serveAs(w, r, next, principal)
+		})
+	}
+}
+
+// serveAs passes the request on as principal, unless it changes something and the role of
+// principal is read-only
+func serveAs(w http.ResponseWriter, r *http.Request, next http.Handler, principal *auth.Principal) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if !principal.Role.CanWrite() {
+			writeProblem(w, http.StatusForbidden, "insufficient_role", "role '"+string(principal.Role)+"' is read-only")
+			return
+		}
+	}
+
+	next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+}
+
+// PrincipalFromContext returns the user authenticated by Authenticate
+func PrincipalFromContext(ctx context.Context) (*auth.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*auth.Principal)
+	return principal, ok
+}
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Deadline limits the total time a request may spend in handlers. The request context is
+// cancelled when the budget runs out or the client disconnects, which stops repository
+// queries still in flight. A zero timeout disables the budget. Paths starting with one of
+// streamPrefixes (long-lived event streams) have no budget.
+func Deadline(timeout time.Duration, streamPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hasAnyPrefix(r.URL.Path, streamPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// deadlineOf serves path through the middleware and returns the handler's view of the request context
+func deadlineOf(t *testing.T, mw func(http.Handler) http.Handler, path string) (time.Duration, bool) {
+	t.Helper()
+
+	var remaining time.Duration
+	var ok bool
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		deadline, ok = r.Context().Deadline()
+		remaining = time.Until(deadline)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	return remaining, ok
+}
+
+func TestDeadline_LimitsRequests(t *testing.T) {
+	remaining, ok := deadlineOf(t, Deadline(time.Minute, "/api/v1/events"), "/api/v1/devices")
+	if !ok {
+		t.Fatal("Expected the request context to have a deadline")
+	}
+	if remaining <= 0 || remaining > time.Minute {
+		t.Errorf("Expected the deadline within a minute, got %v", remaining)
+	}
+}
+
+func TestDeadline_Exemptions(t *testing.T) {
+	// ストリームには期限を付けない
+	if _, ok := deadlineOf(t, Deadline(time.Minute, "/api/v1/events", "/api/v1/exports/"), "/api/v1/exports/abc/download"); ok {
+		t.Error("Expected stream paths to have no deadline")
+	}
+	// 0 は無効化
+	if _, ok := deadlineOf(t, Deadline(0), "/api/v1/devices"); ok {
+		t.Error("Expected a zero timeout to disable the deadline")
+	}
+}
+
+func TestDeadline_CancelsSlowHandlers(t *testing.T) {
+	var handlerErr error
+	handler := Deadline(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			handlerErr = r.Context().Err()
+		case <-time.After(5 * time.Second):
+		}
+	}))
+
+	started := time.Now()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/devices", nil))
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected the handler to be cancelled at the deadline, took %v", elapsed)
+	}
+	if !errors.Is(handlerErr, context.DeadlineExceeded) {
+		t.Errorf("Expected the request context to expire, got %v", handlerErr)
+	}
+}
+
+func TestDeadline_KeepsClientCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var handlerErr error
+	handler := Deadline(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerErr = r.Context().Err()
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/devices", nil).WithContext(ctx))
+	if !errors.Is(handlerErr, context.Canceled) {
+		t.Errorf("Expected a disconnected client to cancel the request, got %v", handlerErr)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ReadOnly rejects every API request that could change something, for deployments where the
+// synchronization is the only writer. GET, HEAD and OPTIONS pass, as do the POST operations
+// matching one of readPatterns (path.Match patterns of operations that only compute a result).
+func ReadOnly(readPatterns ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, "/api/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			case http.MethodPost:
+				if matchesAny(r.URL.Path, readPatterns) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			writeProblem(w, http.StatusForbidden, "read_only_mode", "the server is read-only; changes come from synchronization only")
+		})
+	}
+}
+
+func matchesAny(urlPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, urlPath); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+type shareClaimsKey struct{}
+
+// ShareToken guards the public share links under prefix. The token is the path segment
+// after prefix; requests with a missing, tampered or expired token are rejected, and only
+// GET and HEAD are allowed so a share link can never change anything.
+func ShareToken(prefix string, verify func(token string) (*visualization.ShareClaims, error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				w.Header().Set("Allow", "GET, HEAD")
+				writeProblem(w, http.StatusMethodNotAllowed, "share_link_read_only", "share links are read-only")
+				return
+			}
+
+			token, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, prefix), "/")
+			claims, err := verify(token)
+			if err != nil {
+				code := "invalid_share_token"
+				if errors.Is(err, visualization.ErrShareTokenExpired) {
+					code = "share_token_expired"
+				}
+				writeProblem(w, http.StatusUnauthorized, code, err.Error())
+				return
+			}
+
+			// 共有リンクの閲覧結果をキャッシュや検索エンジンに残さない
+			w.Header().Set("Cache-Control", "no-store")
+			w.Header().Set("X-Robots-Tag", "noindex")
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), shareClaimsKey{}, claims)))
+		})
+	}
+}
+
+// ShareClaimsFromContext returns the claims of the share token verified by ShareToken
+func ShareClaimsFromContext(ctx context.Context) (*visualization.ShareClaims, bool) {
+	claims, ok := ctx.Value(shareClaimsKey{}).(*visualization.ShareClaims)
+	return claims, ok
+}
+
+// writeProblem writes an error in the same shape as the API error responses
+func writeProblem(w http.ResponseWriter, status int, code, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"title":  http.StatusText(status),
+		"detail": detail,
+		"code":   code,
+	})
+}
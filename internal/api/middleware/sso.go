@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/auth"
+)
+
+// SSO paths served by the middleware (the callback path comes from the redirect URL)
+const (
+	SSOLoginPath   = "/auth/login"
+	SSOLogoutPath  = "/auth/logout"
+	SSOSessionPath = "/auth/session"
+)
+
+// SessionCookieName is the cookie holding the single sign-on session
+const SessionCookieName = "tm_session"
+
+// SSO protects the web UI and the API with single sign-on and serves the login flow:
+// GET /auth/login?return_to=/path redirects to the provider, the callback path of the redirect
+// URL starts the session cookie, POST /auth/logout ends it and GET /auth/session returns the
+// user. Without a session, API requests get 401 and pages are redirected to the login. Paths
+// with one of publicPrefixes are passed through. Users whose role cannot write may only use
+// GET, HEAD and OPTIONS.
+func SSO(sso *auth.OIDCAuthenticator, publicPrefixes ...string) func(http.Handler) http.Handler {
+	config := sso.Config()
+	callbackPath := config.CallbackPath()
+	cookie := func(value string, maxAge int) *http.Cookie {
+		return &http.Cookie{
+			Name:     SessionCookieName,
+			Value:    value,
+			Path:     "/",
+			MaxAge:   maxAge,
+			HttpOnly: true,
+			Secure:   config.SecureCookies(),
+			// 他サイトからの POST にはクッキーを送らせない（CSRF 対策）
+			SameSite: http.SameSiteLaxMode,
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case SSOLoginPath:
+				location, err := sso.BeginLogin(r.Context(), safeReturnTo(r.URL.Query().Get("return_to")))
+				if err != nil {
+					writeProblem(w, http.StatusServiceUnavailable, "authentication_unavailable", "single sign-on provider unavailable")
+					return
+				}
+				http.Redirect(w, r, location, http.StatusFound)
+				return
+
+			case callbackPath:
+				query := r.URL.Query()
+				if providerError := query.Get("error"); providerError != "" {
+					writeProblem(w, http.StatusUnauthorized, "sso_login_failed", providerError+": "+query.Get("error_description"))
+					return
+				}
+				sessionID, returnTo, err := sso.CompleteLogin(r.Context(), query.Get("state"), query.Get("code"))
+				switch {
+				case errors.Is(err, auth.ErrInvalidLogin):
+					writeProblem(w, http.StatusBadRequest, "invalid_login", "login expired or was not started here; sign in again")
+					return
+				case errors.Is(err, auth.ErrInvalidCredentials):
+					writeProblem(w, http.StatusUnauthorized, "invalid_credentials", "sign-in was rejected")
+					return
+				case err != nil:
+					writeProblem(w, http.StatusServiceUnavailable, "authentication_unavailable", "single sign-on provider unavailable")
+					return
+				}
+				http.SetCookie(w, cookie(sessionID, int(config.SessionTTL.Seconds())))
+				http.Redirect(w, r, returnTo, http.StatusFound)
+				return
+
+			case SSOLogoutPath:
+				if r.Method != http.MethodPost {
+					w.Header().Set("Allow", "POST")
+					writeProblem(w, http.StatusMethodNotAllowed, "method_not_allowed", "sign out with POST")
+					return
+				}
+				if c, err := r.Cookie(SessionCookieName); err == nil {
+					sso.EndSession(c.Value)
+				}
+				http.SetCookie(w, cookie("", -1))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if r.URL.Path != SSOSessionPath && hasAnyPrefix(r.URL.Path, publicPrefixes) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var principal *auth.Principal
+			err := auth.ErrSessionNotFound
+			if c, cookieErr := r.Cookie(SessionCookieName); cookieErr == nil {
+				principal, err = sso.Session(r.Context(), c.Value)
+			}
+			if err != nil {
+				if !errors.Is(err, auth.ErrSessionNotFound) {
+					// 認証基盤の障害は未ログインと区別する
+					writeProblem(w, http.StatusServiceUnavailable, "authentication_unavailable", "single sign-on provider unavailable")
+					return
+				}
+				if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") && r.URL.Path != SSOSessionPath {
+					// 画面の表示はログインへ誘導し、ログイン後に元のページへ戻す
+					http.Redirect(w, r, SSOLoginPath+"?return_to="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+					return
+				}
+				writeProblem(w, http.StatusUnauthorized, "authentication_required", "sign in at "+SSOLoginPath)
+				return
+			}
+
+			if r.URL.Path == SSOSessionPath {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Cache-Control", "no-store")
+				json.NewEncoder(w).Encode(principal)
+				return
+			}
+			serveAs(w, r, next, principal)
+		})
+	}
+}
+
+// safeReturnTo accepts only local paths, so the login cannot be used to redirect elsewhere
+func safeReturnTo(returnTo string) string {
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.HasPrefix(returnTo, "/\\") {
+		return "/"
+	}
+	return returnTo
+}
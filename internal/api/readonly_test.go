@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/repository/sqlite"
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+func newSQLiteTestServer(t *testing.T) *Server {
+	repo, err := repository.NewRepository(repository.Config{
+		Type:   "sqlite",
+		SQLite: sqlite.Config{Path: filepath.Join(t.TempDir(), "api.db")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if err := repo.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate repository: %v", err)
+	}
+	return NewServer(repo, repo, logger.New("error"))
+}
+
+func serve(handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	server := newSQLiteTestServer(t)
+	server.SetReadOnly(true)
+	handler := server.Handler()
+
+	rejected := []struct{ method, path, body string }{
+		{http.MethodPost, "/api/v1/classification/layers", `{"name":"core","order":1}`},
+		{http.MethodPut, "/api/v1/classification/layers/1", `{"name":"core","order":1}`},
+		{http.MethodDelete, "/api/v1/classification/rules/rule-1", ""},
+		{http.MethodPost, "/api/v1/classification/rules/apply", `{}`},
+	}
+	for _, tt := range rejected {
+		resp := serve(handler, tt.method, tt.path, tt.body)
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("Expected %s %s to be rejected with 403, got %d: %s", tt.method, tt.path, resp.Code, resp.Body.String())
+		}
+	}
+
+	if resp := serve(handler, http.MethodGet, "/api/v1/classification/layers", ""); resp.Code != http.StatusOK {
+		t.Errorf("Expected reads to pass, got %d: %s", resp.Code, resp.Body.String())
+	}
+	// シミュレーションは何も変更しないので読み取り専用でも使える
+	if resp := serve(handler, http.MethodPost, "/api/v1/simulate", `{}`); resp.Code == http.StatusForbidden {
+		t.Errorf("Expected simulations to pass the read-only check, got %s", resp.Body.String())
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	for _, readOnly := range []bool{false, true} {
+		server := newSQLiteTestServer(t)
+		server.SetReadOnly(readOnly)
+
+		resp := serve(server.Handler(), http.MethodGet, "/api/v1/capabilities", "")
+		if resp.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", resp.Code, resp.Body.String())
+		}
+
+		var body struct {
+			ReadOnly bool            `json:"read_only"`
+			CanWrite bool            `json:"can_write"`
+			Auth     string          `json:"auth"`
+			Features map[string]bool `json:"features"`
+		}
+		if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode capabilities: %v", err)
+		}
+		if body.ReadOnly != readOnly || body.CanWrite == readOnly {
+			t.Errorf("Expected read_only=%v and can_write=%v, got %+v", readOnly, !readOnly, body)
+		}
+		if body.Auth != "none" {
+			t.Errorf("Expected auth none, got %s", body.Auth)
+		}
+		if _, ok := body.Features["sync_preview"]; !ok || body.Features["sync_preview"] {
+			t.Errorf("Expected sync_preview to be reported as unavailable, got %v", body.Features)
+		}
+	}
+}
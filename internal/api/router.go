@@ -2,10 +2,13 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
@@ -13,9 +16,15 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/servak/topology-manager/internal/api/handler"
 	apimiddleware "github.com/servak/topology-manager/internal/api/middleware"
+	"github.com/servak/topology-manager/internal/auth"
 	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/job"
 	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/prometheus"
 	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/internal/service/contract"
+	"github.com/servak/topology-manager/internal/storage"
 	"github.com/servak/topology-manager/pkg/logger"
 )
 
@@ -23,14 +32,66 @@ type Server struct {
 	api                   huma.API
 	router                chi.Router
 	topologyService       *service.TopologyService
-	visualizationService  *service.VisualizationService
+	visualizationService  contract.Visualization
 	classificationService *service.ClassificationService
+	simulationService     *service.SimulationService
+	deviceOverviewService *service.DeviceOverviewService
+	provisioningService   *service.ProvisioningService
+	displayNameService    *service.DisplayNameService
+	startingViewService   *service.StartingViewService
+	shareService          *service.ShareService
+	fabricService         *service.FabricService
+	circuitService        *service.CircuitService
+	reservationService    *service.PortReservationService
+	managementService     *service.ManagementReachabilityService
+	maintenanceService    *service.MaintenanceService
+	syncStatsService      *service.SyncStatsService
+	diagnosticsService    *service.QueryDiagnosticsService
+	identityService       *service.IdentityService
+	rackService           *service.RackService
+	iconService           *service.IconService
+	styleRuleService      *service.StyleRuleService
+	workflowService       *service.WorkflowService
+	visualizationHandler  *handler.VisualizationHandler
+	jobService            *service.JobService
+	exportService         *service.ExportService // nil = 非同期エクスポートなし
+	stopJobs              func()                 // nil = ジョブ実行なし
+	changeFeed            *service.ChangeFeed    // nil = 他の書き込みの変更を受け取れない
+	stopChangeFeed        func()                 // nil = 変更の受信なし
+	stopViewRequests      func()                 // nil = ビューのリクエスト数を書き込まない
 	topologyRepo          topology.Repository
 	classificationRepo    classification.Repository
+	requestTimeout        time.Duration
+	authenticator         auth.Authenticator      // nil = 認証なし
+	sso                   *auth.OIDCAuthenticator // nil = シングルサインオンなし
+	readOnly              bool                    // true = 変更APIを拒否し、同期のみが書き込む
+	metricsProxy          bool
+	syncPreview           bool
+	portNamingLint        bool
+	metadataSchema        bool
+	orphanedLinks         bool
 	logger                *logger.Logger
 }
 
-func NewServer(topologyRepo topology.Repository, classificationRepo classification.Repository, appLogger *logger.Logger) *Server {
+// DefaultRequestTimeout is the time budget of an API request unless changed with SetRequestTimeout
+const DefaultRequestTimeout = 30 * time.Second
+
+// ServerOption replaces a service of the server, e.g. with a cached, mocked or remote implementation
+type ServerOption func(*serverOptions)
+
+type serverOptions struct {
+	visualizationService contract.Visualization
+}
+
+// WithVisualizationService serves visual topologies from visualizationService instead of the
+// repository-backed VisualizationService
+func WithVisualizationService(visualizationService contract.Visualization) ServerOption {
+	return func(o *serverOptions) {
+		o.visualizationService = visualizationService
+	}
+}
+
+func NewServer(topologyRepo topology.Repository, classificationRepo classification.Repository, appLogger *logger.Logger, opts ...ServerOption) *Server {
 	router := chi.NewRouter()
 
 	// ミドルウェア
@@ -43,21 +104,169 @@ func NewServer(topologyRepo topology.Repository, classificationRepo classificati
 	config := huma.DefaultConfig("Network Topology Management API", "1.0.0")
 	config.DocsPath = "/docs"
 	config.Info.Description = "API for managing network topology and visualization"
+	config.Tags = apiTags
 	api := humachi.New(router, config)
 
 	// サービス層の初期化
 	topologyService := service.NewTopologyService(topologyRepo)
 	visualizationService := service.NewVisualizationService(topologyRepo)
 	classificationService := service.NewClassificationService(classificationRepo, topologyRepo)
+	simulationService := service.NewSimulationService(topologyRepo)
+	deviceOverviewService := service.NewDeviceOverviewService(topologyRepo, visualizationService, classificationService)
+
+	// 可視化サービスは差し替え可能（デバイス概要は表示名の解決に具象サービスを使う）
+	options := serverOptions{visualizationService: visualizationService}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// 計画デバイスの保存に対応していないリポジトリではプロビジョニングAPIを提供しない
+	var provisioningService *service.ProvisioningService
+	if provisioningRepo, ok := topologyRepo.(topology.ProvisioningRepository); ok {
+		provisioningService = service.NewProvisioningService(provisioningRepo, topologyRepo)
+	}
+
+	// 表示名の上書きに対応していないリポジトリでは表示名APIを提供しない
+	var displayNameService *service.DisplayNameService
+	if displayNameRepo, ok := topologyRepo.(topology.DisplayNameRepository); ok {
+		displayNameService = service.NewDisplayNameService(displayNameRepo)
+	}
+
+	// 開始ビューの保存に対応していないリポジトリでは開始ビューAPIを提供しない
+	var startingViewService *service.StartingViewService
+	if startingViewRepo, ok := topologyRepo.(visualization.StartingViewRepository); ok {
+		startingViewService = service.NewStartingViewService(startingViewRepo, topologyRepo)
+	}
+
+	// 共有リンクは保存済みの開始ビューを対象にするため、開始ビューに対応したリポジトリでのみ提供する
+	var shareService *service.ShareService
+	if startingViewRepo, ok := topologyRepo.(visualization.StartingViewRepository); ok {
+		shareService = service.NewShareService(startingViewRepo, options.visualizationService)
+	}
+
+	// ファブリック定義の保存に対応していないリポジトリではファブリックAPIを提供しない
+	var fabricService *service.FabricService
+	if fabricRepo, ok := topologyRepo.(topology.FabricRepository); ok {
+		fabricService = service.NewFabricService(fabricRepo, topologyRepo, options.visualizationService)
+	}
+
+	// 回線IDの保存に対応していないリポジトリでは回線APIを提供しない
+	var circuitService *service.CircuitService
+	if circuitRepo, ok := topologyRepo.(topology.CircuitRepository); ok {
+		circuitService = service.NewCircuitService(circuitRepo, topologyRepo)
+	}
+
+	// ポート予約の保存に対応していないリポジトリではポート予約APIを提供しない
+	var reservationService *service.PortReservationService
+	if reservationRepo, ok := topologyRepo.(topology.PortReservationRepository); ok {
+		reservationService = service.NewPortReservationService(reservationRepo, topologyRepo)
+	}
+
+	// 管理プレーンの到達性を記録しないリポジトリでは到達性レポートを提供しない
+	var managementService *service.ManagementReachabilityService
+	if managementRepo, ok := topologyRepo.(topology.ManagementReachabilityRepository); ok {
+		managementService = service.NewManagementReachabilityService(managementRepo, topologyRepo)
+	}
+
+	// 保守作業の保存に対応していないリポジトリでは保守作業APIを提供しない
+	var maintenanceService *service.MaintenanceService
+	if maintenanceRepo, ok := topologyRepo.(topology.MaintenanceRepository); ok {
+		maintenanceService = service.NewMaintenanceService(maintenanceRepo, topologyService)
+	}
+
+	// 同期サイクルの履歴を保存しないリポジトリでは変化量の履歴とメトリクスを提供しない
+	var syncStatsService *service.SyncStatsService
+	if statsRepo, ok := topologyRepo.(topology.SyncStatsRepository); ok {
+		syncStatsService = service.NewSyncStatsService(statsRepo)
+	}
+
+	// 実行計画を取得できないリポジトリではクエリ診断APIを提供しない
+	var diagnosticsService *service.QueryDiagnosticsService
+	if explainRepo, ok := topologyRepo.(topology.QueryExplainRepository); ok {
+		diagnosticsService = service.NewQueryDiagnosticsService(explainRepo)
+	}
+
+	// 同一性の判断を保存しないリポジトリでは機器の同一性APIを提供しない
+	var identityService *service.IdentityService
+	if identityRepo, ok := topologyRepo.(topology.IdentityRepository); ok {
+		identityService = service.NewIdentityService(identityRepo, topologyService)
+	}
+
+	// ラック位置の保存に対応していないリポジトリではラック図APIを提供しない
+	var rackService *service.RackService
+	if rackRepo, ok := topologyRepo.(topology.RackRepository); ok {
+		rackService = service.NewRackService(rackRepo, topologyRepo)
+	}
+
+	// アイコンの保存に対応していないリポジトリではアイコンAPIを提供しない
+	var iconService *service.IconService
+	if iconRepo, ok := topologyRepo.(visualization.IconRepository); ok {
+		iconService = service.NewIconService(iconRepo)
+	}
+
+	// スタイルルールの保存に対応していないリポジトリではスタイルルールAPIを提供しない
+	var styleRuleService *service.StyleRuleService
+	if styleRepo, ok := topologyRepo.(visualization.StyleRuleRepository); ok {
+		styleRuleService = service.NewStyleRuleService(styleRepo)
+	}
+
+	// ワークフロー状態の保存に対応していないリポジトリではワークフローAPIを提供しない
+	var workflowService *service.WorkflowService
+	if workflowRepo, ok := topologyRepo.(topology.WorkflowRepository); ok {
+		workflowService = service.NewWorkflowService(workflowRepo, topologyRepo)
+	}
+
+	// ジョブキューに対応していないリポジトリではジョブAPIを提供しない
+	var jobService *service.JobService
+	if jobRepo, ok := topologyRepo.(job.Repository); ok {
+		jobService = service.NewJobService(jobRepo)
+		consistencyService := service.NewConsistencyService(topologyRepo, classificationRepo)
+		jobService.Register(service.JobKindSnapshot, func(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+			return topologyService.TakeSnapshot(ctx)
+		})
+		jobService.Register(service.JobKindConsistencyReport, func(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+			return consistencyService.Check(ctx, nil, true)
+		})
+		jobService.Register(service.JobKindHardwareComplianceReport, func(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+			return classificationService.EvaluateHardwareCompliance(ctx)
+		})
+	}
+
+	// 変更通知に対応していないリポジトリでは変更イベントを提供しない
+	var changeFeed *service.ChangeFeed
+	if listener, ok := topologyRepo.(topology.ChangeListener); ok {
+		changeFeed = service.NewChangeFeed(listener)
+	}
 
 	server := &Server{
 		api:                   api,
 		router:                router,
 		topologyService:       topologyService,
-		visualizationService:  visualizationService,
+		visualizationService:  options.visualizationService,
 		classificationService: classificationService,
+		simulationService:     simulationService,
+		deviceOverviewService: deviceOverviewService,
+		provisioningService:   provisioningService,
+		displayNameService:    displayNameService,
+		startingViewService:   startingViewService,
+		shareService:          shareService,
+		fabricService:         fabricService,
+		circuitService:        circuitService,
+		reservationService:    reservationService,
+		managementService:     managementService,
+		maintenanceService:    maintenanceService,
+		syncStatsService:      syncStatsService,
+		diagnosticsService:    diagnosticsService,
+		identityService:       identityService,
+		rackService:           rackService,
+		iconService:           iconService,
+		styleRuleService:      styleRuleService,
+		workflowService:       workflowService,
+		jobService:            jobService,
+		changeFeed:            changeFeed,
 		topologyRepo:          topologyRepo,
 		classificationRepo:    classificationRepo,
+		requestTimeout:        DefaultRequestTimeout,
 		logger:                appLogger,
 	}
 
@@ -69,15 +278,115 @@ func NewServer(topologyRepo topology.Repository, classificationRepo classificati
 func (s *Server) registerRoutes() {
 	// ハンドラーの初期化
 	topologyHandler := handler.NewTopologyHandler(s.topologyService, s.logger)
-	visualizationHandler := handler.NewVisualizationHandler(s.visualizationService, s.logger)
+	s.visualizationHandler = handler.NewVisualizationHandler(s.visualizationService, s.logger)
 	classificationHandler := handler.NewClassificationHandler(s.classificationService, s.logger)
+	simulationHandler := handler.NewSimulationHandler(s.simulationService, s.logger)
+	deviceOverviewHandler := handler.NewDeviceOverviewHandler(s.deviceOverviewService, s.logger)
 	healthHandler := handler.NewHealthHandler(s.topologyRepo, s.logger)
+	capabilitiesHandler := handler.NewCapabilitiesHandler(s.capabilities, s.logger)
+	overviewHandler := handler.NewOverviewHandler(s.topologyService, s.fabricService, s.logger)
+	schemaHandler := handler.NewSchemaHandler(s.logger)
 
 	// ルート登録
 	topologyHandler.Register(s.api)
-	visualizationHandler.Register(s.api)
+	s.visualizationHandler.Register(s.api)
 	classificationHandler.RegisterRoutes(s.api)
+	simulationHandler.Register(s.api)
+	deviceOverviewHandler.Register(s.api)
 	healthHandler.Register(s.api)
+	capabilitiesHandler.Register(s.api)
+	overviewHandler.Register(s.api)
+	schemaHandler.Register(s.api)
+
+	if s.provisioningService != nil {
+		provisioningHandler := handler.NewProvisioningHandler(s.provisioningService, s.logger)
+		provisioningHandler.Register(s.api)
+	}
+
+	if s.displayNameService != nil {
+		displayNameHandler := handler.NewDisplayNameHandler(s.displayNameService, s.logger)
+		displayNameHandler.Register(s.api)
+	}
+
+	if s.startingViewService != nil {
+		startingViewHandler := handler.NewStartingViewHandler(s.startingViewService, s.logger)
+		startingViewHandler.Register(s.api)
+	}
+
+	if s.shareService != nil {
+		shareHandler := handler.NewShareHandler(s.shareService, s.logger)
+		shareHandler.Register(s.api)
+	}
+
+	if s.fabricService != nil {
+		fabricHandler := handler.NewFabricHandler(s.fabricService, s.logger)
+		fabricHandler.Register(s.api)
+	}
+
+	if s.circuitService != nil {
+		circuitHandler := handler.NewCircuitHandler(s.circuitService, s.logger)
+		circuitHandler.Register(s.api)
+	}
+
+	if s.reservationService != nil {
+		reservationHandler := handler.NewPortReservationHandler(s.reservationService, s.logger)
+		reservationHandler.Register(s.api)
+	}
+
+	if s.managementService != nil {
+		managementHandler := handler.NewManagementReachabilityHandler(s.managementService, s.logger)
+		managementHandler.Register(s.api)
+	}
+
+	if s.maintenanceService != nil {
+		maintenanceHandler := handler.NewMaintenanceHandler(s.maintenanceService, s.logger)
+		maintenanceHandler.Register(s.api)
+	}
+
+	if s.syncStatsService != nil {
+		syncStatsHandler := handler.NewSyncStatsHandler(s.syncStatsService, s.logger)
+		syncStatsHandler.Register(s.api)
+	}
+
+	if s.diagnosticsService != nil {
+		diagnosticsHandler := handler.NewDiagnosticsHandler(s.diagnosticsService, s.logger)
+		diagnosticsHandler.Register(s.api)
+	}
+
+	if s.identityService != nil {
+		identityHandler := handler.NewIdentityHandler(s.identityService, s.logger)
+		identityHandler.Register(s.api)
+	}
+
+	if s.rackService != nil {
+		rackHandler := handler.NewRackHandler(s.rackService, s.logger)
+		rackHandler.Register(s.api)
+	}
+
+	if s.changeFeed != nil {
+		changeEventsHandler := handler.NewChangeEventsHandler(s.changeFeed, s.logger)
+		changeEventsHandler.Register(s.api)
+	}
+
+	if s.iconService != nil {
+		iconHandler := handler.NewIconHandler(s.iconService, s.logger)
+		iconHandler.Register(s.api)
+	}
+
+	if s.styleRuleService != nil {
+		styleRuleHandler := handler.NewStyleRuleHandler(s.styleRuleService, s.logger)
+		styleRuleHandler.Register(s.api)
+	}
+
+	if s.workflowService != nil {
+		workflowHandler := handler.NewWorkflowHandler(s.workflowService, s.logger)
+		workflowHandler.Register(s.api)
+	}
+
+	if s.jobService != nil {
+		jobHandler := handler.NewJobHandler(s.jobService, s.logger)
+		jobHandler.Register(s.api)
+	}
 
 	// 静的ファイル配信（Web UI）- SPAルーティング対応
 	s.setupSPARouting()
@@ -134,10 +443,330 @@ func (s *Server) spaHandler(staticDir string) http.HandlerFunc {
 	}
 }
 
+// SetRequestTimeout changes the time budget of each request (0 = no limit).
+// It must be called before Handler.
+func (s *Server) SetRequestTimeout(timeout time.Duration) {
+	s.requestTimeout = timeout
+}
+
+// SetShareSecret changes the secret share links and export download URLs are signed with, each under
+// its own derived key so neither token is accepted as the other. Without it they are signed with a
+// random key and stop working when the server restarts. It must be called before Handler.
+func (s *Server) SetShareSecret(secret []byte) {
+	if s.shareService != nil {
+		s.shareService.SetSecret(secret)
+	}
+	if s.exportService != nil {
+		s.exportService.SetSecret(secret)
+	}
+}
+
+// SetAuthenticator requires API requests to be authenticated by authenticator, except the
+// health check and share links. It must be called before Handler.
+func (s *Server) SetAuthenticator(authenticator auth.Authenticator) {
+	s.authenticator = authenticator
+}
+
+// SetSSO protects the web UI and the API with single sign-on by sso, except the health check
+// and share links. It must be called before Handler.
+func (s *Server) SetSSO(sso *auth.OIDCAuthenticator) {
+	s.sso = sso
+}
+
+// SetPlaceholderDefaults tells the API which attributes the sync worker gives LLDP placeholders,
+// so that /api/v1/devices/search?awaiting_enrichment=true recognizes them and planned device
+// templates complete them. It must be called before Handler.
+func (s *Server) SetPlaceholderDefaults(defaults topology.PlaceholderDefaults) {
+	s.topologyService.SetPlaceholderDefaults(defaults)
+	if s.provisioningService != nil {
+		s.provisioningService.SetPlaceholderDefaults(defaults)
+	}
+}
+
+// SetMetricsProxy serves the whitelisted metrics of config from querier under
+// /api/v1/metrics and /api/v1/devices/{deviceId}/metrics, and enables color_by=utilization on
+// the topology endpoints. It must be called at most once.
+func (s *Server) SetMetricsProxy(querier service.MetricsQuerier, config prometheus.ProxyConfig) {
+	if config.Disabled {
+		return
+	}
+	metricsProxyService := service.NewMetricsProxyService(querier, s.topologyRepo, config)
+	handler.NewMetricsHandler(metricsProxyService, s.logger).Register(s.api)
+	s.visualizationHandler.SetUtilizationSource(metricsProxyService)
+	s.metricsProxy = true
+}
+
+// SetExportStore writes exports to store with export jobs and serves them under /api/v1/exports.
+// Every instance must use the same store. It does nothing without a job queue and must be called
+// at most once, before StartJobRunner and SetShareSecret.
+func (s *Server) SetExportStore(store storage.ObjectStore, retention time.Duration) {
+	if s.jobService == nil || s.exportService != nil {
+		return
+	}
+	s.exportService = service.NewExportService(store, s.jobService, s.topologyService)
+	s.exportService.SetRetention(retention)
+	s.exportService.SetAnnotationSources(s.classificationService, s.maintenanceService)
+	handler.NewExportHandler(s.exportService, s.logger).Register(s.api)
+}
+
+// SetPortNamingLint serves the violations of the port naming conventions of lint under
+// /api/v1/analysis/port-naming. It does nothing without policies and must be called at most once.
+func (s *Server) SetPortNamingLint(lint topology.PortNamingLint) {
+	if len(lint.Policies) == 0 {
+		return
+	}
+	lintService := service.NewPortNamingLintService(s.topologyService, lint)
+	handler.NewPortNamingHandler(lintService, s.logger).Register(s.api)
+	s.portNamingLint = true
+}
+
+// SetMetadataSchema serves the devices lacking the metadata required for their device type under
+// /api/v1/analysis/metadata and as report.metadata_compliance jobs, and rejects manual
+// classifications that break an enforced schema. It does nothing without requirements and must be
+// called at most once, before StartJobRunner.
+func (s *Server) SetMetadataSchema(schema topology.MetadataSchema) {
+	if len(schema.Required) == 0 {
+		return
+	}
+	s.classificationService.SetMetadataSchema(schema)
+	if s.provisioningService != nil {
+		s.provisioningService.SetMetadataSchema(schema)
+	}
+	complianceService := service.NewMetadataComplianceService(s.topologyRepo, schema)
+	handler.NewMetadataComplianceHandler(complianceService, s.logger).Register(s.api)
+	if s.jobService != nil {
+		s.jobService.Register(service.JobKindMetadataComplianceReport, func(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+			return complianceService.Report(ctx, "")
+		})
+	}
+	s.metadataSchema = true
+}
+
+// SetCoverageGate sets the classification coverage gate that /api/v1/classification/coverage
+// evaluates when a request omits threshold or types
+func (s *Server) SetCoverageGate(gate classification.CoverageGate) {
+	s.classificationService.SetCoverageGate(gate)
+}
+
+// SetOrphanedLinkPolicy serves the recently deleted devices and the links the worker dropped for
+// them under /api/v1/links/orphaned. It does nothing when the repository does not record deletions
+// and must be called at most once.
+func (s *Server) SetOrphanedLinkPolicy(policy topology.OrphanedLinkPolicy) {
+	orphanRepo, ok := s.topologyRepo.(topology.OrphanedLinkRepository)
+	if !ok {
+		return
+	}
+	orphanService := service.NewOrphanedLinkService(orphanRepo, s.topologyRepo, policy)
+	handler.NewOrphanedLinkHandler(orphanService, s.logger).Register(s.api)
+	s.orphanedLinks = true
+}
+
+// SetIdentityOptions changes the device metadata keys matched when resolving device identities
+func (s *Server) SetIdentityOptions(options topology.IdentityOptions) {
+	if s.identityService != nil {
+		s.identityService.SetOptions(options)
+	}
+}
+
+// SetSyncPreviewer serves dry runs of the synchronization from previewer under /api/v1/sync/preview.
+// It must be called at most once.
+func (s *Server) SetSyncPreviewer(previewer handler.SyncPreviewer) {
+	handler.NewSyncHandler(previewer, s.logger).Register(s.api)
+	s.syncPreview = true
+}
+
+// SetReadOnly turns the server into a viewer: every API request that would change the topology,
+// classifications or layers is rejected with 403, leaving the synchronization as the only writer.
+// Read-only computations such as simulations and share links stay available. It must be called before Handler.
+func (s *Server) SetReadOnly(readOnly bool) {
+	s.readOnly = readOnly
+}
+
+// readOnlyOperations are the POST operations that only compute a result and stay available in read-only mode
+var readOnlyOperations = []string{
+	"/api/v1/simulate",
+	"/api/v1/topology/*/groups/*/expand",
+	"/api/v1/starting-views/*/share",
+	"/api/v1/exports",
+	"/api/v1/exports/*/download-url",
+	"/api/v1/maintenance/impact",
+}
+
+// capabilities reports the mode and optional features of the server for /api/v1/capabilities
+func (s *Server) capabilities() handler.Capabilities {
+	authMode := "none"
+	if s.sso != nil {
+		authMode = "sso"
+	} else if s.authenticator != nil {
+		authMode = "basic"
+	}
+
+	return handler.Capabilities{
+		ReadOnly: s.readOnly,
+		Auth:     authMode,
+		Features: map[string]bool{
+			"provisioning":      s.provisioningService != nil,
+			"display_names":     s.displayNameService != nil,
+			"starting_views":    s.startingViewService != nil,
+			"share_links":       s.shareService != nil,
+			"fabrics":           s.fabricService != nil,
+			"circuits":          s.circuitService != nil,
+			"port_reservations": s.reservationService != nil,
+			"mgmt_reachability": s.managementService != nil,
+			"maintenance":       s.maintenanceService != nil,
+			"sync_stats":        s.syncStatsService != nil,
+			"query_explain":     s.diagnosticsService != nil,
+			"identities":        s.identityService != nil,
+			"racks":             s.rackService != nil,
+			"icons":             s.iconService != nil,
+			"style_rules":       s.styleRuleService != nil,
+			"workflow":          s.workflowService != nil,
+			"jobs":              s.jobService != nil,
+			"exports":           s.exportService != nil,
+			"metrics_proxy":     s.metricsProxy,
+			"sync_preview":      s.syncPreview,
+			"port_naming_lint":  s.portNamingLint,
+			"metadata_schema":   s.metadataSchema,
+			"orphaned_links":    s.orphanedLinks,
+			"change_events":     s.changeFeed != nil,
+		},
+	}
+}
+
+// StartJobRunner runs queued jobs in the background as workerID until Shutdown. Several
+// instances may run jobs from the same queue; each job runs on one instance at a time.
+func (s *Server) StartJobRunner(workerID string) {
+	if s.jobService == nil || s.stopJobs != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.jobService.RunJobs(ctx, workerID, service.DefaultJobPollInterval, func(err error) {
+			s.logger.Warn("Job queue error", "worker", workerID, "error", err)
+		})
+	}()
+	s.stopJobs = func() {
+		cancel()
+		<-done
+	}
+	s.logger.Info("Job runner started", "worker", workerID, "kinds", s.jobService.Kinds())
+}
+
+// StartChangeListener receives the device and link changes made by any writer until Shutdown,
+// streams them to /api/v1/events/topology and forwards them to an injected visualization
+// service that implements contract.ChangeObserver
+func (s *Server) StartChangeListener() {
+	if s.changeFeed == nil || s.stopChangeFeed != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.changeFeed.Run(ctx, service.DefaultChangeListenRetry, func(err error) {
+			s.logger.Warn("Change listener error", "error", err)
+		})
+	}()
+
+	// キャッシュを持つ可視化サービスには変更を伝え、古いエントリを捨てさせる
+	if observer, ok := s.visualizationService.(contract.ChangeObserver); ok {
+		changes, unsubscribe := s.changeFeed.Subscribe()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for change := range changes {
+				observer.ObserveChange(change)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			unsubscribe()
+		}()
+	}
+
+	s.stopChangeFeed = func() {
+		cancel()
+		wg.Wait()
+	}
+	s.logger.Info("Change listener started")
+}
+
+// StartViewRequestFlusher writes the view request counts buffered by the visualization service
+// every interval and once more on Shutdown, so that GET requests do not write to the database.
+// It does nothing when the visualization service does not buffer them.
+func (s *Server) StartViewRequestFlusher(interval time.Duration) {
+	flusher, ok := s.visualizationService.(contract.ViewRequestFlusher)
+	if !ok || s.stopViewRequests != nil {
+		return
+	}
+
+	flush := func(ctx context.Context) {
+		if _, err := flusher.FlushViewRequests(ctx); err != nil {
+			s.logger.Warn("Failed to record view requests", "error", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				flush(ctx)
+			}
+		}
+	}()
+	s.stopViewRequests = func() {
+		cancel()
+		<-done
+		// 停止前に溜まった分を書き込む
+		flush(context.Background())
+	}
+}
+
 func (s *Server) Handler() http.Handler {
-	return s.router
+	var h http.Handler = s.router
+	// 読み取り専用モードでは認証済みでも変更を拒否する
+	if s.readOnly {
+		h = apimiddleware.ReadOnly(readOnlyOperations...)(h)
+	}
+	// 共有リンクはトークンを検証し、読み取り専用でのみ通す
+	if s.shareService != nil {
+		h = apimiddleware.ShareToken(service.SharedPathPrefix, s.shareService.VerifyToken)(h)
+	}
+	// 共有リンク、署名付きダウンロードURL、ヘルスチェックとメトリクスはログインなしで利用できる
+	if s.authenticator != nil {
+		h = apimiddleware.Authenticate(s.authenticator, service.SharedPathPrefix, service.ExportDownloadPathPrefix, "/api/v1/health", handler.MetricsPath)(h)
+	}
+	if s.sso != nil {
+		h = apimiddleware.SSO(s.sso, service.SharedPathPrefix, service.ExportDownloadPathPrefix, "/api/v1/health", handler.MetricsPath)(h)
+	}
+	// リクエスト全体の時間予算（超過またはクライアント切断でDBクエリもキャンセル）。大きなファイルのダウンロードは対象外
+	return apimiddleware.Deadline(s.requestTimeout, handler.ChangeEventsPath, service.ExportDownloadPathPrefix)(h)
 }
 
+// Shutdown stops the background work started on the server. The repositories are left open:
+// they belong to the caller, which closes them once nothing else uses them.
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.topologyRepo.Close()
+	// 実行中のジョブは記録せずに止め、リースが切れた後に再実行させる
+	if s.stopJobs != nil {
+		s.stopJobs()
+	}
+	if s.stopChangeFeed != nil {
+		s.stopChangeFeed()
+	}
+	if s.stopViewRequests != nil {
+		s.stopViewRequests()
+	}
+	return nil
 }
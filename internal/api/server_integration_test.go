@@ -0,0 +1,109 @@
+//go:build integration
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.RunWithPostgres(m))
+}
+
+func newPostgresTestServer(t *testing.T) http.Handler {
+	setup := testutil.NewPostgresTestSetup(t)
+	setup.SeedTestData(t)
+	return NewServer(setup.Repo, setup.Repo, setup.Logger).Handler()
+}
+
+func doRequest(t *testing.T, handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestPostgresClassificationAPI(t *testing.T) {
+	server := newPostgresTestServer(t)
+
+	t.Run("Default Layers From Migrations", func(t *testing.T) {
+		resp := doRequest(t, server, http.MethodGet, "/api/v1/classification/layers", "")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		var body struct {
+			Count int `json:"count"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		assert.Greater(t, body.Count, 0)
+	})
+
+	t.Run("Create And List Rules", func(t *testing.T) {
+		resp := doRequest(t, server, http.MethodPost, "/api/v1/classification/rules", `{
+			"name": "integration-rule",
+			"description": "created by the integration test",
+			"logic": "AND",
+			"conditions": [{"field": "name", "operator": "starts_with", "value": "device-"}],
+			"layer": 1,
+			"device_type": "network-switch",
+			"priority": 10,
+			"is_active": true
+		}`)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		resp = doRequest(t, server, http.MethodGet, "/api/v1/classification/rules", "")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		var body struct {
+			Rules []struct {
+				Name string `json:"name"`
+			} `json:"rules"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+
+		var names []string
+		for _, rule := range body.Rules {
+			names = append(names, rule.Name)
+		}
+		assert.Contains(t, names, "integration-rule")
+		assert.Contains(t, names, "Test Switch Rule")
+	})
+
+	t.Run("Unknown Device Classification", func(t *testing.T) {
+		resp := doRequest(t, server, http.MethodGet, "/api/v1/classification/devices/no-such-device", "")
+		assert.Equal(t, http.StatusNotFound, resp.Code, resp.Body.String())
+	})
+}
+
+func TestPostgresVisualizationAPI(t *testing.T) {
+	server := newPostgresTestServer(t)
+
+	resp := doRequest(t, server, http.MethodGet, "/api/v1/topology/device-001?depth=3&enable_grouping=false", "")
+	require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+	var body struct {
+		RootDevice string `json:"root_device"`
+		Nodes      []struct {
+			ID string `json:"id"`
+		} `json:"nodes"`
+		Edges []struct {
+			ID string `json:"id"`
+		} `json:"edges"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+
+	assert.Equal(t, "device-001", body.RootDevice)
+	assert.Len(t, body.Nodes, 3)
+	assert.Len(t, body.Edges, 2)
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestShutdown_LeavesRepositoryOpen(t *testing.T) {
+	server := newSQLiteTestServer(t)
+	server.StartViewRequestFlusher(time.Hour)
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Unexpected shutdown error: %v", err)
+	}
+	// リポジトリを閉じるのは作成した側（ワーカーが停止した後）
+	if err := server.topologyRepo.Health(context.Background()); err != nil {
+		t.Errorf("Expected the repository to stay open after Shutdown, got %v", err)
+	}
+}
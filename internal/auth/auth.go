@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Role is what an authenticated user may do
+type Role string
+
+const (
+	// RoleViewer may only read
+	RoleViewer Role = "viewer"
+	// RoleEditor may also change topology, classification and views
+	RoleEditor Role = "editor"
+	// RoleAdmin may also use the diagnostics endpoints
+	RoleAdmin Role = "admin"
+)
+
+// Roles lists the valid roles from least to most privileged
+var Roles = []Role{RoleViewer, RoleEditor, RoleAdmin}
+
+// IsValidRole reports whether role is one of Roles
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if string(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// rank orders roles so the most privileged of several can be chosen
+func (r Role) rank() int {
+	for i, role := range Roles {
+		if role == r {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// CanWrite reports whether the role may use non-read-only API methods
+func (r Role) CanWrite() bool {
+	return r.rank() >= RoleEditor.rank()
+}
+
+// IsAdmin reports whether the role may use the diagnostics endpoints
+func (r Role) IsAdmin() bool {
+	return r.rank() >= RoleAdmin.rank()
+}
+
+// roleForGroups returns the most privileged role that groupRoles maps one of groups to, or
+// defaultRole. matches reports whether a group of the user is a group of groupRoles.
+func roleForGroups(groupRoles map[string]string, defaultRole string, groups []string, matches func(member, group string) bool) (Role, bool) {
+	var best Role
+	for group, role := range groupRoles {
+		for _, member := range groups {
+			if matches(member, group) && Role(role).rank() > best.rank() {
+				best = Role(role)
+			}
+		}
+	}
+	if best == "" && defaultRole != "" {
+		best = Role(defaultRole)
+	}
+	return best, best != ""
+}
+
+var (
+	// ErrInvalidCredentials is returned for unknown users, wrong passwords and users without a role
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is an authenticated user
+type Principal struct {
+	Username string   `json:"username"`
+	Name     string   `json:"name,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	Role     Role     `json:"role"`
+	Backend  string   `json:"backend"`
+}
+
+// Authenticator verifies a username and password against an identity backend
+type Authenticator interface {
+	// Name identifies the backend (e.g. "ldap")
+	Name() string
+	// Authenticate returns ErrInvalidCredentials when the credentials are rejected;
+	// other errors mean the backend could not be asked
+	Authenticate(ctx context.Context, username, password string) (*Principal, error)
+}
+
+// DefaultCacheTTL is how long a successful authentication is reused unless configured
+const DefaultCacheTTL = 5 * time.Minute
+
+// Config selects and configures the authentication backend
+type Config struct {
+	Backend  string        `yaml:"backend"`   // none（デフォルト）, ldap, oidc
+	CacheTTL time.Duration `yaml:"cache_ttl"` // 認証成功を再利用する期間（0 = デフォルト）
+	LDAP     LDAPConfig    `yaml:"ldap"`
+	OIDC     OIDCConfig    `yaml:"oidc"`
+}
+
+// Backends lists the supported values of Config.Backend
+var Backends = []string{"none", "ldap", "oidc"}
+
+// Enabled reports whether API requests must be authenticated
+func (c Config) Enabled() bool {
+	return c.Backend != "" && c.Backend != "none"
+}
+
+// Validate checks the settings of the selected backend
+func (c Config) Validate() error {
+	switch c.Backend {
+	case "", "none":
+		return nil
+	case "ldap":
+		return c.LDAP.Validate()
+	case "oidc":
+		return c.OIDC.Validate()
+	default:
+		return fmt.Errorf("unknown backend '%s' (expected one of %s)", c.Backend, strings.Join(Backends, ", "))
+	}
+}
+
+// New creates the configured authenticator, wrapped in a cache of successful authentications.
+// It returns nil when authentication is disabled or done by single sign-on (see NewSSO).
+func New(c Config) (Authenticator, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var authenticator Authenticator
+	switch c.Backend {
+	case "ldap":
+		authenticator = NewLDAPAuthenticator(c.LDAP)
+	default:
+		return nil, nil
+	}
+
+	ttl := c.CacheTTL
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	return NewCachingAuthenticator(authenticator, ttl), nil
+}
+
+// NewSSO creates the single sign-on authenticator of the oidc backend. It returns nil when
+// another backend is configured.
+func NewSSO(c Config) (*OIDCAuthenticator, error) {
+	if c.Backend != "oidc" {
+		return nil, nil
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return NewOIDCAuthenticator(c.OIDC), nil
+}
+
+// CachingAuthenticator remembers successful authentications for a while so that not every
+// API request reaches the identity backend. Failures are never cached.
+type CachingAuthenticator struct {
+	next Authenticator
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[[sha256.Size]byte]cachedPrincipal
+}
+
+type cachedPrincipal struct {
+	principal *Principal
+	expires   time.Time
+}
+
+// NewCachingAuthenticator wraps next with a cache of the given TTL
+func NewCachingAuthenticator(next Authenticator, ttl time.Duration) *CachingAuthenticator {
+	return &CachingAuthenticator{
+		next:    next,
+		ttl:     ttl,
+		entries: make(map[[sha256.Size]byte]cachedPrincipal),
+	}
+}
+
+func (c *CachingAuthenticator) Name() string {
+	return c.next.Name()
+}
+
+func (c *CachingAuthenticator) Authenticate(ctx context.Context, username, password string) (*Principal, error) {
+	// パスワードを平文で保持しないようハッシュをキーにする
+	key := sha256.Sum256([]byte(username + "\x00" + password))
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.principal, nil
+	}
+
+	principal, err := c.next.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cachedPrincipal{principal: principal, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+
+	return principal, nil
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingAuthenticator accepts one password and counts the authentications that reach it
+type countingAuthenticator struct {
+	password string
+	calls    int
+}
+
+func (a *countingAuthenticator) Name() string {
+	return "counting"
+}
+
+func (a *countingAuthenticator) Authenticate(ctx context.Context, username, password string) (*Principal, error) {
+	a.calls++
+	if password != a.password {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{Username: username, Role: RoleEditor}, nil
+}
+
+func TestCachingAuthenticator(t *testing.T) {
+	backend := &countingAuthenticator{password: "hunter2"}
+	authenticator := NewCachingAuthenticator(backend, time.Hour)
+	ctx := context.Background()
+
+	// 成功した認証は再利用し、ディレクトリへの bind を繰り返さない
+	for i := 0; i < 3; i++ {
+		principal, err := authenticator.Authenticate(ctx, "taro.yamada", "hunter2")
+		if err != nil || principal.Username != "taro.yamada" {
+			t.Fatalf("Expected taro.yamada to authenticate, got %+v, %v", principal, err)
+		}
+	}
+	if backend.calls != 1 {
+		t.Errorf("Expected one backend authentication, got %d", backend.calls)
+	}
+
+	// 別のパスワードはキャッシュに当たらず、失敗は記録しない
+	for i := 0; i < 2; i++ {
+		if _, err := authenticator.Authenticate(ctx, "taro.yamada", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Expected invalid credentials, got %v", err)
+		}
+	}
+	if backend.calls != 3 {
+		t.Errorf("Expected failed authentications to reach the backend every time, got %d calls", backend.calls)
+	}
+	if authenticator.Name() != "counting" {
+		t.Errorf("Expected the backend name, got %s", authenticator.Name())
+	}
+}
+
+func TestCachingAuthenticator_Expires(t *testing.T) {
+	backend := &countingAuthenticator{password: "hunter2"}
+	authenticator := NewCachingAuthenticator(backend, time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := authenticator.Authenticate(ctx, "jdoe", "hunter2"); err != nil {
+		t.Fatalf("Expected jdoe to authenticate, got %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	// 期限切れ後はパスワード変更などを反映するため再認証する
+	backend.password = "changed"
+	if _, err := authenticator.Authenticate(ctx, "jdoe", "hunter2"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected the expired entry to be authenticated again, got %v", err)
+	}
+	if backend.calls != 2 {
+		t.Errorf("Expected two backend authentications, got %d", backend.calls)
+	}
+}
+
+func TestNew_CachesLDAP(t *testing.T) {
+	authenticator, err := New(Config{Backend: "ldap", LDAP: LDAPConfig{URL: "ldap://127.0.0.1:389", UserBaseDN: "dc=example,dc=com", DefaultRole: "viewer"}})
+	if err != nil {
+		t.Fatalf("Expected a valid config, got %v", err)
+	}
+	caching, ok := authenticator.(*CachingAuthenticator)
+	if !ok {
+		t.Fatalf("Expected the LDAP authenticator to be cached, got %T", authenticator)
+	}
+	if caching.ttl != DefaultCacheTTL {
+		t.Errorf("Expected the default TTL, got %v", caching.ttl)
+	}
+
+	if authenticator, err := New(Config{}); err != nil || authenticator != nil {
+		t.Errorf("Expected no authenticator without a backend, got %v, %v", authenticator, err)
+	}
+}
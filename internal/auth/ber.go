@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// LDAP で使う BER の最小実装（定長エンコーディングのみ）
+
+// BER タグ
+const (
+	berBoolean     = 0x01
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berEnumerated  = 0x0a
+	berSequence    = 0x30
+	berSet         = 0x31
+)
+
+// maxBERLength bounds a single element read from the server
+const maxBERLength = 16 << 20
+
+// berElement is a decoded tag-length-value
+type berElement struct {
+	tag     byte
+	content []byte
+}
+
+func berEncode(tag byte, content []byte) []byte {
+	out := []byte{tag}
+	out = append(out, berLength(len(content))...)
+	return append(out, content...)
+}
+
+func berLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var digits []byte
+	for v := n; v > 0; v >>= 8 {
+		digits = append([]byte{byte(v)}, digits...)
+	}
+	return append([]byte{0x80 | byte(len(digits))}, digits...)
+}
+
+func berConstructed(tag byte, children ...[]byte) []byte {
+	var content []byte
+	for _, child := range children {
+		content = append(content, child...)
+	}
+	return berEncode(tag, content)
+}
+
+func berString(tag byte, value string) []byte {
+	return berEncode(tag, []byte(value))
+}
+
+func berInt(tag byte, value int) []byte {
+	// 最小バイト数の2の補数表現（LDAP で扱う値は非負）
+	content := []byte{byte(value)}
+	for v := value >> 8; v > 0; v >>= 8 {
+		content = append([]byte{byte(v)}, content...)
+	}
+	if content[0]&0x80 != 0 {
+		content = append([]byte{0}, content...)
+	}
+	return berEncode(tag, content)
+}
+
+func berBool(value bool) []byte {
+	if value {
+		return berEncode(berBoolean, []byte{0xff})
+	}
+	return berEncode(berBoolean, []byte{0x00})
+}
+
+// readBERElement reads one element from r
+func readBERElement(r *bufio.Reader) (berElement, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return berElement{}, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return berElement{}, err
+	}
+
+	length := int(first)
+	if first&0x80 != 0 {
+		octets := int(first & 0x7f)
+		if octets == 0 || octets > 4 {
+			return berElement{}, fmt.Errorf("unsupported BER length encoding 0x%02x", first)
+		}
+		length = 0
+		for i := 0; i < octets; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return berElement{}, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxBERLength {
+		return berElement{}, fmt.Errorf("BER element of %d bytes exceeds limit", length)
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return berElement{}, err
+	}
+	return berElement{tag: tag, content: content}, nil
+}
+
+// children decodes the elements contained in a constructed element
+func (e berElement) children() ([]berElement, error) {
+	var children []berElement
+	rest := e.content
+	for len(rest) > 0 {
+		if len(rest) < 2 {
+			return nil, errors.New("truncated BER element")
+		}
+		tag, first := rest[0], rest[1]
+		rest = rest[2:]
+
+		length := int(first)
+		if first&0x80 != 0 {
+			octets := int(first & 0x7f)
+			if octets == 0 || octets > 4 || len(rest) < octets {
+				return nil, errors.New("invalid BER length")
+			}
+			length = 0
+			for _, b := range rest[:octets] {
+				length = length<<8 | int(b)
+			}
+			rest = rest[octets:]
+		}
+		if length > len(rest) {
+			return nil, errors.New("truncated BER element")
+		}
+		children = append(children, berElement{tag: tag, content: rest[:length]})
+		rest = rest[length:]
+	}
+	return children, nil
+}
+
+func (e berElement) int() int {
+	value := 0
+	for _, b := range e.content {
+		value = value<<8 | int(b)
+	}
+	return value
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func readBER(data []byte) (berElement, error) {
+	return readBERElement(bufio.NewReader(bytes.NewReader(data)))
+}
+
+func TestReadBERElement(t *testing.T) {
+	// 短形式・長形式の長さを読み戻せる
+	for _, size := range []int{0, 1, 127, 128, 300, 70000} {
+		content := bytes.Repeat([]byte{'x'}, size)
+		element, err := readBER(berEncode(berOctetString, content))
+		if err != nil {
+			t.Fatalf("Expected %d bytes to decode, got %v", size, err)
+		}
+		if element.tag != berOctetString || !bytes.Equal(element.content, content) {
+			t.Errorf("Expected %d bytes of content, got tag 0x%02x with %d bytes", size, element.tag, len(element.content))
+		}
+	}
+
+	for _, value := range []int{0, 1, 127, 128, 255, 256, 65535, 1 << 24} {
+		element, err := readBER(berInt(berInteger, value))
+		if err != nil || element.int() != value {
+			t.Errorf("Expected %d to round-trip, got %d, %v", value, element.int(), err)
+		}
+	}
+}
+
+func TestReadBERElement_Truncated(t *testing.T) {
+	message := berConstructed(berSequence, berInt(berInteger, 1), berString(berOctetString, "uid=jdoe"))
+	long := berEncode(berOctetString, bytes.Repeat([]byte{'x'}, 300))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"tag only", message[:1]},
+		{"short content", message[:len(message)-1]},
+		{"long length octets", long[:3]},
+		{"long content", long[:len(long)-10]},
+	}
+	for _, tt := range tests {
+		_, err := readBER(tt.data)
+		if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%s: expected an EOF error, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestReadBERElement_Malformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		// 不定長形式は LDAP では使わない
+		{"indefinite length", []byte{berSequence, 0x80, 0x00, 0x00}, "unsupported BER length"},
+		{"too many length octets", []byte{berSequence, 0x85, 1, 0, 0, 0, 0}, "unsupported BER length"},
+		// 巨大な長さは読み込む前に拒否する
+		{"over limit", []byte{berOctetString, 0x84, 0x7f, 0xff, 0xff, 0xff}, "exceeds limit"},
+	}
+	for _, tt := range tests {
+		_, err := readBER(tt.data)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestBERElementChildren(t *testing.T) {
+	valid := berElement{tag: berSequence, content: append(berInt(berInteger, 7), berString(berOctetString, strings.Repeat("a", 200))...)}
+	children, err := valid.children()
+	if err != nil || len(children) != 2 || children[0].int() != 7 || len(children[1].content) != 200 {
+		t.Fatalf("Expected an integer and a 200 byte string, got %+v, %v", children, err)
+	}
+
+	malformed := map[string][]byte{
+		"truncated header":      {berInteger},
+		"missing length octets": {berOctetString, 0x82, 0x01},
+		"indefinite length":     {berOctetString, 0x80},
+		"too many octets":       {berOctetString, 0x85, 0, 0, 0, 0, 1},
+		"short content":         {berOctetString, 0x05, 'a', 'b'},
+		"short long content":    {berOctetString, 0x81, 0x90, 'a'},
+	}
+	for name, content := range malformed {
+		if _, err := (berElement{tag: berSequence, content: content}).children(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func newTestLDAPConn(data ...[]byte) *ldapConn {
+	return &ldapConn{reader: bufio.NewReader(bytes.NewReader(bytes.Join(data, nil)))}
+}
+
+func TestLDAPConnReceive_Malformed(t *testing.T) {
+	bindResponse := berConstructed(ldapBindResponse, berInt(berEnumerated, ldapSuccess), berString(berOctetString, ""), berString(berOctetString, ""))
+
+	// 別の ID（切断通知など）は読み飛ばす
+	conn := newTestLDAPConn(
+		berConstructed(berSequence, berInt(berInteger, 0), berConstructed(ldapExtendedResponse)),
+		berConstructed(berSequence, berInt(berInteger, 1), bindResponse),
+	)
+	op, err := conn.receive(1)
+	if err != nil || op.tag != ldapBindResponse {
+		t.Fatalf("Expected the bind response, got 0x%02x, %v", op.tag, err)
+	}
+	if code, _, err := ldapResult(op); err != nil || code != ldapSuccess {
+		t.Errorf("Expected success, got %d, %v", code, err)
+	}
+
+	malformed := map[string][]byte{
+		"not a sequence":     berConstructed(berSet, berInt(berInteger, 1), bindResponse),
+		"missing operation":  berConstructed(berSequence, berInt(berInteger, 1)),
+		"broken children":    berEncode(berSequence, []byte{berInteger, 0x05, 1}),
+		"truncated message":  berConstructed(berSequence, berInt(berInteger, 1), bindResponse)[:6],
+		"connection dropped": nil,
+	}
+	for name, data := range malformed {
+		if _, err := newTestLDAPConn(data).receive(1); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLDAPResponses_Malformed(t *testing.T) {
+	if _, _, err := ldapResult(berElement{tag: ldapBindResponse, content: berInt(berEnumerated, 0)}); err == nil {
+		t.Error("Expected a result without diagnostic message to be rejected")
+	}
+
+	entries := map[string][]byte{
+		"no attributes":          berString(berOctetString, "uid=jdoe"),
+		"broken attribute list":  append(berString(berOctetString, "uid=jdoe"), berSequence, 0x05, 0x04),
+		"attribute without vals": append(berString(berOctetString, "uid=jdoe"), berConstructed(berSequence, berConstructed(berSequence, berString(berOctetString, "cn")))...),
+		"broken value set": append(berString(berOctetString, "uid=jdoe"),
+			berConstructed(berSequence, berConstructed(berSequence, berString(berOctetString, "cn"), berEncode(berSet, []byte{berOctetString, 0x09})))...),
+	}
+	for name, content := range entries {
+		if _, err := decodeLDAPEntry(berElement{tag: ldapSearchResultEntry, content: content}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	entry, err := decodeLDAPEntry(berElement{tag: ldapSearchResultEntry, content: append(berString(berOctetString, "uid=jdoe"),
+		berConstructed(berSequence, berConstructed(berSequence, berString(berOctetString, "memberOf"), berConstructed(berSet, berString(berOctetString, "cn=netops"))))...)})
+	if err != nil || entry.dn != "uid=jdoe" || len(entry.attributes["memberof"]) != 1 {
+		t.Errorf("Expected the entry with lower-cased attribute names, got %+v, %v", entry, err)
+	}
+}
@@ -0,0 +1,591 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// LDAP プロトコル（RFC 4511）のタグ
+const (
+	ldapBindRequest       = 0x60
+	ldapBindResponse      = 0x61
+	ldapUnbindRequest     = 0x42
+	ldapSearchRequest     = 0x63
+	ldapSearchResultEntry = 0x64
+	ldapSearchResultDone  = 0x65
+	ldapSearchResultRef   = 0x73
+	ldapExtendedRequest   = 0x77
+	ldapExtendedResponse  = 0x78
+	ldapSimpleAuth        = 0x80
+	ldapExtendedName      = 0x80
+)
+
+// LDAP の結果コード
+const (
+	ldapSuccess            = 0
+	ldapSizeLimitExceeded  = 4
+	ldapInvalidCredentials = 49
+)
+
+const (
+	ldapStartTLSOID         = "1.3.6.1.4.1.1466.20037"
+	ldapUsernamePlaceholder = "{username}"
+	defaultLDAPUserFilter   = "(uid={username})"
+	defaultLDAPGroupAttr    = "memberOf"
+	defaultLDAPNameAttr     = "displayName"
+	defaultLDAPTimeout      = 10 * time.Second
+)
+
+// LDAPConfig configures authentication against an LDAP directory or Active Directory.
+// Users are looked up with the service account, then authenticated by binding with their
+// own DN and password; their groups are mapped to roles.
+type LDAPConfig struct {
+	URL                string            `yaml:"url"`                  // ldap://host:389 または ldaps://host:636
+	StartTLS           bool              `yaml:"start_tls"`            // ldap:// で StartTLS を使う
+	InsecureSkipVerify bool              `yaml:"insecure_skip_verify"` // 検証用。本番では使わない
+	CAFile             string            `yaml:"ca_file"`              // 社内CAの証明書（PEM）
+	BindDN             string            `yaml:"bind_dn"`              // 検索用サービスアカウント（空 = 匿名）
+	BindPassword       string            `yaml:"bind_password"`
+	UserBaseDN         string            `yaml:"user_base_dn"`
+	UserFilter         string            `yaml:"user_filter"`     // {username} を置換。AD は (sAMAccountName={username})
+	GroupAttribute     string            `yaml:"group_attribute"` // ユーザーの所属グループ属性（デフォルト memberOf）
+	NameAttribute      string            `yaml:"name_attribute"`  // 表示名の属性（デフォルト displayName）
+	GroupRoles         map[string]string `yaml:"group_roles"`     // グループDNまたはCN → ロール
+	DefaultRole        string            `yaml:"default_role"`    // どのグループにも該当しないユーザーのロール（空 = 拒否）
+	Timeout            time.Duration     `yaml:"timeout"`
+}
+
+// Validate checks that the directory can be addressed and every role is known
+func (c LDAPConfig) Validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		return fmt.Errorf("ldap.url must be an ldap:// or ldaps:// URL, got '%s'", c.URL)
+	}
+	if u.Scheme == "ldaps" && c.StartTLS {
+		return fmt.Errorf("ldap.start_tls cannot be used with an ldaps:// URL")
+	}
+	if c.UserBaseDN == "" {
+		return fmt.Errorf("ldap.user_base_dn is required")
+	}
+	filter := c.userFilter()
+	if !strings.Contains(filter, ldapUsernamePlaceholder) {
+		return fmt.Errorf("ldap.user_filter must contain %s", ldapUsernamePlaceholder)
+	}
+	if _, err := encodeLDAPFilter(strings.ReplaceAll(filter, ldapUsernamePlaceholder, "user")); err != nil {
+		return fmt.Errorf("invalid ldap.user_filter: %w", err)
+	}
+	if c.CAFile != "" {
+		if _, err := os.Stat(c.CAFile); err != nil {
+			return fmt.Errorf("ldap.ca_file: %w", err)
+		}
+	}
+	for group, role := range c.GroupRoles {
+		if !IsValidRole(role) {
+			return fmt.Errorf("ldap.group_roles: unknown role '%s' for group '%s'", role, group)
+		}
+	}
+	if c.DefaultRole != "" && !IsValidRole(c.DefaultRole) {
+		return fmt.Errorf("ldap.default_role: unknown role '%s'", c.DefaultRole)
+	}
+	if len(c.GroupRoles) == 0 && c.DefaultRole == "" {
+		return fmt.Errorf("ldap.group_roles or ldap.default_role is required, otherwise nobody can sign in")
+	}
+	return nil
+}
+
+func (c LDAPConfig) userFilter() string {
+	if c.UserFilter == "" {
+		return defaultLDAPUserFilter
+	}
+	return c.UserFilter
+}
+
+// LDAPAuthenticator authenticates users against an LDAP directory
+type LDAPAuthenticator struct {
+	config LDAPConfig
+}
+
+// NewLDAPAuthenticator creates an authenticator; the directory is contacted on each authentication
+func NewLDAPAuthenticator(config LDAPConfig) *LDAPAuthenticator {
+	if config.GroupAttribute == "" {
+		config.GroupAttribute = defaultLDAPGroupAttr
+	}
+	if config.NameAttribute == "" {
+		config.NameAttribute = defaultLDAPNameAttr
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultLDAPTimeout
+	}
+	return &LDAPAuthenticator{config: config}
+}
+
+func (a *LDAPAuthenticator) Name() string {
+	return "ldap"
+}
+
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, username, password string) (*Principal, error) {
+	// 空パスワードの bind は「認証なし bind」として成功してしまうため必ず拒否する
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := a.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.close()
+
+	if a.config.BindDN != "" {
+		if code, message, err := conn.bind(a.config.BindDN, a.config.BindPassword); err != nil {
+			return nil, err
+		} else if code != ldapSuccess {
+			return nil, fmt.Errorf("ldap service account bind failed: result code %d: %s", code, message)
+		}
+	}
+
+	filter := strings.ReplaceAll(a.config.userFilter(), ldapUsernamePlaceholder, escapeLDAPFilterValue(username))
+	entries, err := conn.search(a.config.UserBaseDN, filter, []string{a.config.GroupAttribute, a.config.NameAttribute}, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) != 1 {
+		// 0件・複数件のどちらもユーザーを特定できない
+		return nil, fmt.Errorf("%w: user lookup matched %d entries", ErrInvalidCredentials, len(entries))
+	}
+	entry := entries[0]
+
+	code, _, err := conn.bind(entry.dn, password)
+	if err != nil {
+		return nil, err
+	}
+	if code == ldapInvalidCredentials {
+		return nil, ErrInvalidCredentials
+	}
+	if code != ldapSuccess {
+		return nil, fmt.Errorf("ldap user bind failed: result code %d", code)
+	}
+
+	groups := entry.attributes[strings.ToLower(a.config.GroupAttribute)]
+	role, ok := a.roleFor(groups)
+	if !ok {
+		return nil, fmt.Errorf("%w: user is not in any group mapped to a role", ErrInvalidCredentials)
+	}
+
+	principal := &Principal{
+		Username: username,
+		Groups:   groups,
+		Role:     role,
+		Backend:  a.Name(),
+	}
+	if names := entry.attributes[strings.ToLower(a.config.NameAttribute)]; len(names) > 0 {
+		principal.Name = names[0]
+	}
+	return principal, nil
+}
+
+// roleFor returns the most privileged role mapped from groups, matching group DNs or their CN
+// case-insensitively, or the default role
+func (a *LDAPAuthenticator) roleFor(groups []string) (Role, bool) {
+	return roleForGroups(a.config.GroupRoles, a.config.DefaultRole, groups, func(member, group string) bool {
+		return strings.EqualFold(member, group) || strings.EqualFold(firstRDNValue(member), group)
+	})
+}
+
+// firstRDNValue returns "netops" for "CN=netops,OU=Groups,DC=example,DC=com"
+func firstRDNValue(dn string) string {
+	rdn, _, _ := strings.Cut(dn, ",")
+	_, value, ok := strings.Cut(rdn, "=")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+func (a *LDAPAuthenticator) dial(ctx context.Context) (*ldapConn, error) {
+	u, err := url.Parse(a.config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ldap url: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "389"
+		if u.Scheme == "ldaps" {
+			port = "636"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	tlsConfig, err := a.tlsConfig(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: a.config.Timeout}
+	raw, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ldap server: %w", err)
+	}
+
+	// 1回の認証全体にタイムアウト（リクエストの期限が短ければそちら）を適用
+	deadline := time.Now().Add(a.config.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	raw.SetDeadline(deadline)
+
+	if u.Scheme == "ldaps" {
+		tlsConn := tls.Client(raw, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			raw.Close()
+			return nil, fmt.Errorf("ldap tls handshake failed: %w", err)
+		}
+		return newLDAPConn(tlsConn), nil
+	}
+
+	conn := newLDAPConn(raw)
+	if a.config.StartTLS {
+		if err := conn.startTLS(ctx, tlsConfig); err != nil {
+			conn.close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (a *LDAPAuthenticator) tlsConfig(serverName string) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: a.config.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if a.config.CAFile != "" {
+		pem, err := os.ReadFile(a.config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ldap ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ldap ca_file %s", a.config.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// ldapConn is a minimal synchronous LDAPv3 client: one outstanding request at a time
+type ldapConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int
+}
+
+// ldapEntry is a search result; attribute names are lower-cased
+type ldapEntry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+func newLDAPConn(conn net.Conn) *ldapConn {
+	return &ldapConn{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (c *ldapConn) close() {
+	c.send(berEncode(ldapUnbindRequest, nil))
+	c.conn.Close()
+}
+
+func (c *ldapConn) send(op []byte) (int, error) {
+	c.nextID++
+	message := berConstructed(berSequence, berInt(berInteger, c.nextID), op)
+	if _, err := c.conn.Write(message); err != nil {
+		return 0, fmt.Errorf("failed to send ldap request: %w", err)
+	}
+	return c.nextID, nil
+}
+
+// receive reads the next message of request id and returns its protocol operation
+func (c *ldapConn) receive(id int) (berElement, error) {
+	for {
+		message, err := readBERElement(c.reader)
+		if err != nil {
+			return berElement{}, fmt.Errorf("failed to read ldap response: %w", err)
+		}
+		parts, err := message.children()
+		if err != nil || message.tag != berSequence || len(parts) < 2 {
+			return berElement{}, errors.New("malformed ldap message")
+		}
+		// 切断通知（ID 0）などは読み飛ばす
+		if parts[0].int() != id {
+			continue
+		}
+		return parts[1], nil
+	}
+}
+
+// result decodes the LDAPResult of a response operation
+func ldapResult(op berElement) (int, string, error) {
+	parts, err := op.children()
+	if err != nil || len(parts) < 3 {
+		return 0, "", errors.New("malformed ldap result")
+	}
+	return parts[0].int(), string(parts[2].content), nil
+}
+
+// bind performs a simple bind and returns the LDAP result code
+func (c *ldapConn) bind(dn, password string) (int, string, error) {
+	id, err := c.send(berConstructed(ldapBindRequest,
+		berInt(berInteger, 3),
+		berString(berOctetString, dn),
+		berString(ldapSimpleAuth, password),
+	))
+	if err != nil {
+		return 0, "", err
+	}
+
+	op, err := c.receive(id)
+	if err != nil {
+		return 0, "", err
+	}
+	if op.tag != ldapBindResponse {
+		return 0, "", fmt.Errorf("unexpected ldap response 0x%02x to bind", op.tag)
+	}
+	return ldapResult(op)
+}
+
+func (c *ldapConn) startTLS(ctx context.Context, config *tls.Config) error {
+	id, err := c.send(berConstructed(ldapExtendedRequest, berString(ldapExtendedName, ldapStartTLSOID)))
+	if err != nil {
+		return err
+	}
+	op, err := c.receive(id)
+	if err != nil {
+		return err
+	}
+	if op.tag != ldapExtendedResponse {
+		return fmt.Errorf("unexpected ldap response 0x%02x to StartTLS", op.tag)
+	}
+	if code, message, err := ldapResult(op); err != nil {
+		return err
+	} else if code != ldapSuccess {
+		return fmt.Errorf("ldap StartTLS refused: result code %d: %s", code, message)
+	}
+
+	tlsConn := tls.Client(c.conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return fmt.Errorf("ldap tls handshake failed: %w", err)
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+// search runs a subtree search and returns at most sizeLimit entries
+func (c *ldapConn) search(baseDN, filter string, attributes []string, sizeLimit int) ([]ldapEntry, error) {
+	encodedFilter, err := encodeLDAPFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	var attrs [][]byte
+	for _, attribute := range attributes {
+		attrs = append(attrs, berString(berOctetString, attribute))
+	}
+
+	id, err := c.send(berConstructed(ldapSearchRequest,
+		berString(berOctetString, baseDN),
+		berInt(berEnumerated, 2), // wholeSubtree
+		berInt(berEnumerated, 0), // neverDerefAliases
+		berInt(berInteger, sizeLimit),
+		berInt(berInteger, 0),
+		berBool(false),
+		encodedFilter,
+		berConstructed(berSequence, attrs...),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ldapEntry
+	for {
+		op, err := c.receive(id)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.tag {
+		case ldapSearchResultEntry:
+			entry, err := decodeLDAPEntry(op)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		case ldapSearchResultRef:
+			// 他サーバーへの参照は追わない
+		case ldapSearchResultDone:
+			code, message, err := ldapResult(op)
+			if err != nil {
+				return nil, err
+			}
+			if code != ldapSuccess && code != ldapSizeLimitExceeded {
+				return nil, fmt.Errorf("ldap search failed: result code %d: %s", code, message)
+			}
+			return entries, nil
+		default:
+			return nil, fmt.Errorf("unexpected ldap response 0x%02x to search", op.tag)
+		}
+	}
+}
+
+func decodeLDAPEntry(op berElement) (ldapEntry, error) {
+	parts, err := op.children()
+	if err != nil || len(parts) < 2 {
+		return ldapEntry{}, errors.New("malformed ldap search entry")
+	}
+	entry := ldapEntry{dn: string(parts[0].content), attributes: make(map[string][]string)}
+
+	attributes, err := parts[1].children()
+	if err != nil {
+		return ldapEntry{}, errors.New("malformed ldap search entry")
+	}
+	for _, attribute := range attributes {
+		fields, err := attribute.children()
+		if err != nil || len(fields) < 2 {
+			return ldapEntry{}, errors.New("malformed ldap attribute")
+		}
+		values, err := fields[1].children()
+		if err != nil {
+			return ldapEntry{}, errors.New("malformed ldap attribute")
+		}
+		name := strings.ToLower(string(fields[0].content))
+		for _, value := range values {
+			entry.attributes[name] = append(entry.attributes[name], string(value.content))
+		}
+	}
+	return entry, nil
+}
+
+// escapeLDAPFilterValue escapes a value for use in a search filter (RFC 4515)
+func escapeLDAPFilterValue(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '*', '(', ')', '\\', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// encodeLDAPFilter encodes a search filter. The and (&), or (|), not (!), equality and
+// presence (attr=*) forms are supported, which covers typical user lookup filters.
+func encodeLDAPFilter(filter string) ([]byte, error) {
+	encoded, rest, err := parseLDAPFilter(strings.TrimSpace(filter))
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("unexpected '%s' after filter", rest)
+	}
+	return encoded, nil
+}
+
+func parseLDAPFilter(filter string) ([]byte, string, error) {
+	if !strings.HasPrefix(filter, "(") {
+		return nil, "", fmt.Errorf("filter must start with '(' at '%s'", filter)
+	}
+	filter = filter[1:]
+	if filter == "" {
+		return nil, "", errors.New("unterminated filter")
+	}
+
+	switch filter[0] {
+	case '&', '|':
+		tag := byte(0xa0) // and
+		if filter[0] == '|' {
+			tag = 0xa1 // or
+		}
+		rest := filter[1:]
+		var children [][]byte
+		for strings.HasPrefix(rest, "(") {
+			child, next, err := parseLDAPFilter(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			children = append(children, child)
+			rest = next
+		}
+		if len(children) == 0 {
+			return nil, "", errors.New("empty filter list")
+		}
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", errors.New("unterminated filter list")
+		}
+		return berConstructed(tag, children...), rest[1:], nil
+	case '!':
+		child, rest, err := parseLDAPFilter(filter[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", errors.New("unterminated not filter")
+		}
+		return berConstructed(0xa2, child), rest[1:], nil
+	}
+
+	end := strings.IndexByte(filter, ')')
+	if end < 0 {
+		return nil, "", errors.New("unterminated filter item")
+	}
+	item, rest := filter[:end], filter[end+1:]
+
+	attribute, value, ok := strings.Cut(item, "=")
+	if !ok || attribute == "" {
+		return nil, "", fmt.Errorf("invalid filter item '%s'", item)
+	}
+	if strings.ContainsAny(attribute[len(attribute)-1:], "<>~:") {
+		return nil, "", fmt.Errorf("unsupported filter item '%s' (only = is supported)", item)
+	}
+	if value == "*" {
+		return berString(0x87, attribute), rest, nil // present
+	}
+	if strings.Contains(value, "*") {
+		return nil, "", fmt.Errorf("unsupported substring filter '%s'", item)
+	}
+
+	decoded, err := unescapeLDAPFilterValue(value)
+	if err != nil {
+		return nil, "", err
+	}
+	return berConstructed(0xa3, berString(berOctetString, attribute), berString(berOctetString, decoded)), rest, nil
+}
+
+func unescapeLDAPFilterValue(value string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			b.WriteByte(value[i])
+			continue
+		}
+		if i+3 > len(value) {
+			return "", fmt.Errorf("invalid escape in filter value '%s'", value)
+		}
+		decoded, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in filter value '%s'", value)
+		}
+		b.Write(decoded)
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestEncodeLDAPFilter(t *testing.T) {
+	encoded, err := encodeLDAPFilter("(uid=jdoe)")
+	if err != nil {
+		t.Fatalf("Expected filter to encode, got %v", err)
+	}
+	expected := []byte{0xa3, 0x0b, 0x04, 0x03, 'u', 'i', 'd', 0x04, 0x04, 'j', 'd', 'o', 'e'}
+	if !bytes.Equal(encoded, expected) {
+		t.Errorf("Expected % x, got % x", expected, encoded)
+	}
+
+	valid := []string{
+		"(&(objectClass=user)(sAMAccountName=jdoe))",
+		"(|(uid=a)(!(uid=b)))",
+		"(mail=*)",
+		"(cn=" + escapeLDAPFilterValue("a*b(c)\\") + ")",
+	}
+	for _, filter := range valid {
+		if _, err := encodeLDAPFilter(filter); err != nil {
+			t.Errorf("Expected %q to encode, got %v", filter, err)
+		}
+	}
+
+	invalid := []string{"uid=jdoe", "(uid=jdoe", "(uid=j*)", "(uid>=1)", "(&)", "(uid=a)(uid=b)", "(cn=\\zz)"}
+	for _, filter := range invalid {
+		if _, err := encodeLDAPFilter(filter); err == nil {
+			t.Errorf("Expected %q to be rejected", filter)
+		}
+	}
+}
+
+// fakeDirectory answers bind and search requests like a directory holding one user
+type fakeDirectory struct {
+	serviceDN, servicePassword string
+	userDN, userPassword       string
+	groups                     []string
+}
+
+func (d fakeDirectory) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(conn)
+	}
+}
+
+func (d fakeDirectory) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		message, err := readBERElement(reader)
+		if err != nil {
+			return
+		}
+		parts, _ := message.children()
+		id, op := parts[0].int(), parts[1]
+		reply := func(response []byte) {
+			conn.Write(berConstructed(berSequence, berInt(berInteger, id), response))
+		}
+		result := func(tag byte, code int) []byte {
+			return berConstructed(tag, berInt(berEnumerated, code), berString(berOctetString, ""), berString(berOctetString, ""))
+		}
+
+		switch op.tag {
+		case ldapBindRequest:
+			fields, _ := op.children()
+			dn, password := string(fields[1].content), string(fields[2].content)
+			code := ldapInvalidCredentials
+			if (dn == d.serviceDN && password == d.servicePassword) || (dn == d.userDN && password == d.userPassword) {
+				code = ldapSuccess
+			}
+			reply(result(ldapBindResponse, code))
+		case ldapSearchRequest:
+			fields, _ := op.children()
+			filter, _ := encodeLDAPFilter("(uid=jdoe)")
+			raw := append([]byte{fields[6].tag}, berLength(len(fields[6].content))...)
+			if bytes.Equal(append(raw, fields[6].content...), filter) {
+				var groups [][]byte
+				for _, group := range d.groups {
+					groups = append(groups, berString(berOctetString, group))
+				}
+				reply(berConstructed(ldapSearchResultEntry,
+					berString(berOctetString, d.userDN),
+					berConstructed(berSequence,
+						berConstructed(berSequence, berString(berOctetString, "memberOf"), berConstructed(berSet, groups...)),
+						berConstructed(berSequence, berString(berOctetString, "displayName"), berConstructed(berSet, berString(berOctetString, "Jane Doe"))),
+					),
+				))
+			}
+			reply(result(ldapSearchResultDone, ldapSuccess))
+		case ldapUnbindRequest:
+			return
+		}
+	}
+}
+
+func TestLDAPAuthenticator(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer listener.Close()
+
+	directory := fakeDirectory{
+		serviceDN:       "cn=tm,ou=svc,dc=example,dc=com",
+		servicePassword: "svc-secret",
+		userDN:          "uid=jdoe,ou=people,dc=example,dc=com",
+		userPassword:    "hunter2",
+		groups:          []string{"CN=netops,OU=Groups,DC=example,DC=com", "CN=staff,OU=Groups,DC=example,DC=com"},
+	}
+	go directory.serve(listener)
+
+	config := LDAPConfig{
+		URL:          "ldap://" + listener.Addr().String(),
+		BindDN:       directory.serviceDN,
+		BindPassword: directory.servicePassword,
+		UserBaseDN:   "ou=people,dc=example,dc=com",
+		GroupRoles: map[string]string{
+			"staff":                                 "viewer",
+			"cn=netops,ou=groups,dc=example,dc=com": "editor",
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Expected valid config, got %v", err)
+	}
+	authenticator := NewLDAPAuthenticator(config)
+	ctx := context.Background()
+
+	principal, err := authenticator.Authenticate(ctx, "jdoe", "hunter2")
+	if err != nil {
+		t.Fatalf("Expected jdoe to authenticate, got %v", err)
+	}
+	if principal.Role != RoleEditor || principal.Name != "Jane Doe" || len(principal.Groups) != 2 {
+		t.Errorf("Unexpected principal: %+v", principal)
+	}
+
+	for _, credentials := range [][2]string{{"jdoe", "wrong"}, {"jdoe", ""}, {"nobody", "hunter2"}} {
+		if _, err := authenticator.Authenticate(ctx, credentials[0], credentials[1]); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Expected invalid credentials for %v, got %v", credentials, err)
+		}
+	}
+
+	// どのグループにも該当しない場合は default_role、なければ拒否
+	config.GroupRoles = map[string]string{"admins": "editor"}
+	if _, err := NewLDAPAuthenticator(config).Authenticate(ctx, "jdoe", "hunter2"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected user without mapped group to be rejected, got %v", err)
+	}
+	config.DefaultRole = "viewer"
+	if principal, err := NewLDAPAuthenticator(config).Authenticate(ctx, "jdoe", "hunter2"); err != nil || principal.Role != RoleViewer {
+		t.Errorf("Expected default role viewer, got %+v, %v", principal, err)
+	}
+
+	// 接続できない場合は資格情報の誤りとして扱わない
+	config.URL = "ldap://127.0.0.1:1"
+	if _, err := NewLDAPAuthenticator(config).Authenticate(ctx, "jdoe", "hunter2"); err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected a backend error for an unreachable server, got %v", err)
+	}
+}
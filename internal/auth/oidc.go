@@ -0,0 +1,765 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultOIDCUsernameClaim = "preferred_username"
+	defaultOIDCGroupsClaim   = "groups"
+	defaultOIDCSessionTTL    = 12 * time.Hour
+	defaultOIDCTimeout       = 10 * time.Second
+	oidcLoginTimeout         = 10 * time.Minute // ログイン開始からコールバックまでの猶予
+	oidcClockSkew            = time.Minute
+	oidcJWKSRefetchInterval  = time.Minute // 未知の鍵IDによる再取得の最短間隔
+	oidcMaxPendingLogins     = 10000       // 未完了のログインの上限（超えると最も古いものから捨てる）
+	oidcMaxSessions          = 100000      // セッションの上限（超えると最も早く期限が切れるものから捨てる）
+)
+
+var defaultOIDCScopes = []string{"openid", "profile", "email", "offline_access"}
+
+// OIDCConfig configures single sign-on for the web UI with an OpenID Connect provider
+// (Azure AD, Okta, Keycloak, ...) using the authorization code flow. Users sign in through
+// the browser and receive a session cookie; their group claims are mapped to roles.
+type OIDCConfig struct {
+	Issuer        string            `yaml:"issuer"` // https://login.example.com/realms/corp など（.well-known の前まで）
+	ClientID      string            `yaml:"client_id"`
+	ClientSecret  string            `yaml:"client_secret"`
+	RedirectURL   string            `yaml:"redirect_url"`   // https://tm.example.com/auth/callback（IdP に登録したもの）
+	Scopes        []string          `yaml:"scopes"`         // デフォルト openid profile email offline_access
+	UsernameClaim string            `yaml:"username_claim"` // デフォルト preferred_username（なければ email, sub）
+	GroupsClaim   string            `yaml:"groups_claim"`   // デフォルト groups
+	GroupRoles    map[string]string `yaml:"group_roles"`    // グループ名 → ロール
+	DefaultRole   string            `yaml:"default_role"`   // どのグループにも該当しないユーザーのロール（空 = 拒否）
+	SessionTTL    time.Duration     `yaml:"session_ttl"`    // トークンを更新し続けても再ログインが必要になるまでの期間
+	Timeout       time.Duration     `yaml:"timeout"`
+}
+
+// Validate checks that the provider and the redirect URL can be addressed and every role is known
+func (c OIDCConfig) Validate() error {
+	if u, err := url.Parse(c.Issuer); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("oidc.issuer must be an http:// or https:// URL, got '%s'", c.Issuer)
+	}
+	if c.ClientID == "" {
+		return fmt.Errorf("oidc.client_id is required")
+	}
+	if u, err := url.Parse(c.RedirectURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.Path == "" || u.Path == "/" {
+		return fmt.Errorf("oidc.redirect_url must be an http:// or https:// URL with a callback path, got '%s'", c.RedirectURL)
+	}
+	for group, role := range c.GroupRoles {
+		if !IsValidRole(role) {
+			return fmt.Errorf("oidc.group_roles: unknown role '%s' for group '%s'", role, group)
+		}
+	}
+	if c.DefaultRole != "" && !IsValidRole(c.DefaultRole) {
+		return fmt.Errorf("oidc.default_role: unknown role '%s'", c.DefaultRole)
+	}
+	if len(c.GroupRoles) == 0 && c.DefaultRole == "" {
+		return fmt.Errorf("oidc.group_roles or oidc.default_role is required, otherwise nobody can sign in")
+	}
+	if c.SessionTTL < 0 {
+		return fmt.Errorf("oidc.session_ttl must not be negative")
+	}
+	return nil
+}
+
+// CallbackPath is the path of the redirect URL, which the login flow must be served under
+func (c OIDCConfig) CallbackPath() string {
+	u, err := url.Parse(c.RedirectURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// SecureCookies reports whether session cookies are only sent over HTTPS
+func (c OIDCConfig) SecureCookies() bool {
+	return strings.HasPrefix(c.RedirectURL, "https://")
+}
+
+var (
+	// ErrSessionNotFound is returned for unknown, expired and revoked sessions
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrInvalidLogin is returned when a login callback does not belong to a login started here
+	ErrInvalidLogin = errors.New("invalid or expired login")
+)
+
+// OIDCAuthenticator signs users in with an OpenID Connect provider and keeps their sessions in
+// memory. Tokens are refreshed with the refresh token when they expire, so group changes take
+// effect without signing in again; sessions are lost when the server restarts.
+//
+// Pending logins and sessions live in the process that started them, so OIDC supports a single
+// API instance only: behind a load balancer the callback and later requests must reach the same
+// instance (sticky sessions). Both are capped, and the oldest are dropped once the cap is reached,
+// so that logins that are never completed cannot exhaust memory.
+type OIDCAuthenticator struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	provider  *oidcProviderMetadata // nil = 未取得
+	keys      map[string]crypto.PublicKey
+	keysFetch time.Time
+	logins    map[string]oidcLogin    // state → 開始したログイン
+	sessions  map[string]*oidcSession // セッションID → セッション
+
+	maxLogins   int // 未完了のログインの上限
+	maxSessions int // セッションの上限
+}
+
+type oidcProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcLogin struct {
+	nonce    string
+	verifier string // PKCE
+	returnTo string
+	expires  time.Time
+}
+
+type oidcSession struct {
+	mu           sync.Mutex // 更新中の同時リクエストは完了を待つ
+	principal    *Principal
+	refreshToken string
+	tokenExpires time.Time
+	expires      time.Time
+}
+
+type oidcTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// NewOIDCAuthenticator creates an authenticator; the provider is discovered on the first login
+func NewOIDCAuthenticator(config OIDCConfig) *OIDCAuthenticator {
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if len(config.Scopes) == 0 {
+		config.Scopes = defaultOIDCScopes
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = defaultOIDCUsernameClaim
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = defaultOIDCGroupsClaim
+	}
+	if config.SessionTTL == 0 {
+		config.SessionTTL = defaultOIDCSessionTTL
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultOIDCTimeout
+	}
+	return &OIDCAuthenticator{
+		config:      config,
+		client:      &http.Client{Timeout: config.Timeout},
+		keys:        make(map[string]crypto.PublicKey),
+		logins:      make(map[string]oidcLogin),
+		sessions:    make(map[string]*oidcSession),
+		maxLogins:   oidcMaxPendingLogins,
+		maxSessions: oidcMaxSessions,
+	}
+}
+
+func (a *OIDCAuthenticator) Name() string {
+	return "oidc"
+}
+
+// Config returns the configuration with defaults applied
+func (a *OIDCAuthenticator) Config() OIDCConfig {
+	return a.config
+}
+
+// BeginLogin starts a login and returns the URL of the provider to send the browser to.
+// returnTo is the local path to go back to after the login.
+func (a *OIDCAuthenticator) BeginLogin(ctx context.Context, returnTo string) (string, error) {
+	provider, err := a.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	login := oidcLogin{
+		nonce:    randomToken(),
+		verifier: randomToken(),
+		returnTo: returnTo,
+		expires:  time.Now().Add(oidcLoginTimeout),
+	}
+	state := randomToken()
+
+	a.mu.Lock()
+	a.pruneLogins(time.Now())
+	a.logins[state] = login
+	a.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(login.verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {a.config.ClientID},
+		"redirect_uri":          {a.config.RedirectURL},
+		"scope":                 {strings.Join(a.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {login.nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return provider.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// CompleteLogin exchanges the authorization code of a login callback for tokens and starts a
+// session. It returns the session ID and the path given to BeginLogin. Callbacks of unknown or
+// expired logins fail with ErrInvalidLogin, users without a role with ErrInvalidCredentials.
+func (a *OIDCAuthenticator) CompleteLogin(ctx context.Context, state, code string) (string, string, error) {
+	a.mu.Lock()
+	login, ok := a.logins[state]
+	delete(a.logins, state) // state は一度だけ使える
+	a.mu.Unlock()
+	if !ok || time.Now().After(login.expires) {
+		return "", "", ErrInvalidLogin
+	}
+
+	tokens, err := a.requestTokens(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {a.config.RedirectURL},
+		"code_verifier": {login.verifier},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if tokens.IDToken == "" {
+		return "", "", fmt.Errorf("oidc token response has no id_token")
+	}
+
+	claims, err := a.verifyIDToken(ctx, tokens.IDToken, login.nonce)
+	if err != nil {
+		return "", "", err
+	}
+	principal, err := a.principalFor(claims)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	session := &oidcSession{
+		principal:    principal,
+		refreshToken: tokens.RefreshToken,
+		tokenExpires: tokenExpiry(tokens, claims, now),
+		expires:      now.Add(a.config.SessionTTL),
+	}
+	sessionID := randomToken()
+
+	a.mu.Lock()
+	a.pruneSessions(now)
+	a.sessions[sessionID] = session
+	a.mu.Unlock()
+
+	return sessionID, login.returnTo, nil
+}
+
+// Session returns the user of a session, refreshing its tokens when they have expired. Unknown
+// and expired sessions, and sessions whose refresh is rejected, fail with ErrSessionNotFound;
+// other errors mean the provider could not be asked.
+func (a *OIDCAuthenticator) Session(ctx context.Context, sessionID string) (*Principal, error) {
+	a.mu.Lock()
+	session, ok := a.sessions[sessionID]
+	a.mu.Unlock()
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	now := time.Now()
+	if now.After(session.expires) {
+		a.EndSession(sessionID)
+		return nil, ErrSessionNotFound
+	}
+	if now.Before(session.tokenExpires) {
+		return session.principal, nil
+	}
+
+	if err := a.refresh(ctx, session); err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			a.EndSession(sessionID)
+			return nil, fmt.Errorf("%w: %v", ErrSessionNotFound, err)
+		}
+		return nil, err
+	}
+	return session.principal, nil
+}
+
+// EndSession signs a session out
+func (a *OIDCAuthenticator) EndSession(sessionID string) {
+	a.mu.Lock()
+	delete(a.sessions, sessionID)
+	a.mu.Unlock()
+}
+
+// pruneLogins drops the expired pending logins, and the oldest ones while the cap is reached, to
+// make room for one more. a.mu must be held.
+func (a *OIDCAuthenticator) pruneLogins(now time.Time) {
+	for key, pending := range a.logins {
+		if now.After(pending.expires) {
+			delete(a.logins, key)
+		}
+	}
+	for len(a.logins) > 0 && len(a.logins) >= a.maxLogins {
+		var oldest string
+		for key, pending := range a.logins {
+			if oldest == "" || pending.expires.Before(a.logins[oldest].expires) {
+				oldest = key
+			}
+		}
+		delete(a.logins, oldest)
+	}
+}
+
+// pruneSessions drops the expired sessions, and those expiring first while the cap is reached, to
+// make room for one more. a.mu must be held.
+func (a *OIDCAuthenticator) pruneSessions(now time.Time) {
+	for id, existing := range a.sessions {
+		if now.After(existing.expires) {
+			delete(a.sessions, id)
+		}
+	}
+	for len(a.sessions) > 0 && len(a.sessions) >= a.maxSessions {
+		var oldest string
+		for id, existing := range a.sessions {
+			if oldest == "" || existing.expires.Before(a.sessions[oldest].expires) {
+				oldest = id
+			}
+		}
+		delete(a.sessions, oldest)
+	}
+}
+
+// refresh renews the tokens of a session and maps its groups again. Rejected refreshes and
+// users who lost their role fail with ErrInvalidCredentials.
+func (a *OIDCAuthenticator) refresh(ctx context.Context, session *oidcSession) error {
+	if session.refreshToken == "" {
+		return fmt.Errorf("%w: session has no refresh token", ErrInvalidCredentials)
+	}
+
+	tokens, err := a.requestTokens(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {session.refreshToken},
+	})
+	if err != nil {
+		return err
+	}
+
+	var claims map[string]interface{}
+	if tokens.IDToken != "" {
+		// 更新時の ID トークンには nonce が含まれない場合がある
+		if claims, err = a.verifyIDToken(ctx, tokens.IDToken, ""); err != nil {
+			return err
+		}
+		principal, err := a.principalFor(claims)
+		if err != nil {
+			return err
+		}
+		if principal.Username != session.principal.Username {
+			return fmt.Errorf("%w: refreshed id_token is for another user", ErrInvalidCredentials)
+		}
+		session.principal = principal
+	}
+	if tokens.RefreshToken != "" {
+		session.refreshToken = tokens.RefreshToken
+	}
+	session.tokenExpires = tokenExpiry(tokens, claims, time.Now())
+	return nil
+}
+
+// requestTokens calls the token endpoint. Requests rejected by the provider (HTTP 400/401) fail
+// with ErrInvalidCredentials.
+func (a *OIDCAuthenticator) requestTokens(ctx context.Context, form url.Values) (*oidcTokenResponse, error) {
+	provider, err := a.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(a.config.ClientID), url.QueryEscape(a.config.ClientSecret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read oidc token response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		var tokenError struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		json.Unmarshal(body, &tokenError)
+		return nil, fmt.Errorf("%w: token request rejected: %s %s", ErrInvalidCredentials, tokenError.Error, tokenError.Description)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token endpoint returned HTTP %d", resp.StatusCode)
+	}
+
+	var tokens oidcTokenResponse
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode oidc token response: %w", err)
+	}
+	return &tokens, nil
+}
+
+// principalFor maps the claims of an ID token to a user
+func (a *OIDCAuthenticator) principalFor(claims map[string]interface{}) (*Principal, error) {
+	username := claimString(claims, a.config.UsernameClaim)
+	if username == "" {
+		username = claimString(claims, "email")
+	}
+	if username == "" {
+		username = claimString(claims, "sub")
+	}
+	if username == "" {
+		return nil, fmt.Errorf("%w: id_token identifies no user", ErrInvalidCredentials)
+	}
+
+	groups := claimStrings(claims, a.config.GroupsClaim)
+	role, ok := roleForGroups(a.config.GroupRoles, a.config.DefaultRole, groups, strings.EqualFold)
+	if !ok {
+		return nil, fmt.Errorf("%w: user is not in any group mapped to a role", ErrInvalidCredentials)
+	}
+
+	return &Principal{
+		Username: username,
+		Name:     claimString(claims, "name"),
+		Groups:   groups,
+		Role:     role,
+		Backend:  a.Name(),
+	}, nil
+}
+
+// discover fetches the provider metadata once
+func (a *OIDCAuthenticator) discover(ctx context.Context) (*oidcProviderMetadata, error) {
+	a.mu.Lock()
+	provider := a.provider
+	a.mu.Unlock()
+	if provider != nil {
+		return provider, nil
+	}
+
+	provider = &oidcProviderMetadata{}
+	if err := a.getJSON(ctx, a.config.Issuer+"/.well-known/openid-configuration", provider); err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != a.config.Issuer {
+		return nil, fmt.Errorf("oidc discovery returned issuer '%s', expected '%s'", provider.Issuer, a.config.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery document lacks authorization, token or jwks endpoint")
+	}
+
+	a.mu.Lock()
+	a.provider = provider
+	a.mu.Unlock()
+	return provider, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and (unless empty) nonce of an
+// ID token and returns its claims. Invalid tokens fail with ErrInvalidCredentials.
+func (a *OIDCAuthenticator) verifyIDToken(ctx context.Context, rawToken, nonce string) (map[string]interface{}, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed id_token", ErrInvalidCredentials)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed id_token header", ErrInvalidCredentials)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed id_token signature", ErrInvalidCredentials)
+	}
+	key, err := a.signingKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed id_token claims", ErrInvalidCredentials)
+	}
+
+	now := time.Now()
+	if strings.TrimSuffix(claimString(claims, "iss"), "/") != a.config.Issuer {
+		return nil, fmt.Errorf("%w: id_token issuer mismatch", ErrInvalidCredentials)
+	}
+	if !containsString(claimStrings(claims, "aud"), a.config.ClientID) {
+		return nil, fmt.Errorf("%w: id_token audience mismatch", ErrInvalidCredentials)
+	}
+	if exp, ok := claimTime(claims, "exp"); !ok || now.After(exp.Add(oidcClockSkew)) {
+		return nil, fmt.Errorf("%w: id_token expired", ErrInvalidCredentials)
+	}
+	if nonce != "" && subtle.ConstantTimeCompare([]byte(claimString(claims, "nonce")), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: id_token nonce mismatch", ErrInvalidCredentials)
+	}
+	return claims, nil
+}
+
+// signingKey returns the provider key with the given ID, fetching the key set again when the
+// key is unknown (the provider rotated its keys)
+func (a *OIDCAuthenticator) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	a.mu.Lock()
+	key, ok := a.keys[kid]
+	recentlyFetched := time.Since(a.keysFetch) < oidcJWKSRefetchInterval
+	a.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if recentlyFetched {
+		return nil, fmt.Errorf("%w: unknown id_token key '%s'", ErrInvalidCredentials, kid)
+	}
+
+	provider, err := a.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := a.getJSON(ctx, provider.JWKSURI, &keySet); err != nil {
+		return nil, fmt.Errorf("failed to fetch oidc signing keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if publicKey, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = publicKey
+		}
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.keysFetch = time.Now()
+	a.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown id_token key '%s'", ErrInvalidCredentials, kid)
+}
+
+func (a *OIDCAuthenticator) getJSON(ctx context.Context, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", rawURL, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// jsonWebKey is an RSA or EC public key of a JSON Web Key Set (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+// verifyJWTSignature checks an RS256/RS384/RS512/ES256/ES384 signature of signed
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported id_token algorithm '%s'", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %s does not match an RSA key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+			return fmt.Errorf("invalid id_token signature")
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(signature) != 2*size {
+			return fmt.Errorf("algorithm %s does not match an EC key", alg)
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("invalid id_token signature")
+		}
+	default:
+		return fmt.Errorf("unsupported key type")
+	}
+	return nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// tokenExpiry returns when the tokens of a response must be refreshed: the expiry of the
+// access token, or of the ID token when the response has none
+func tokenExpiry(tokens *oidcTokenResponse, claims map[string]interface{}, now time.Time) time.Time {
+	if tokens.ExpiresIn > 0 {
+		return now.Add(time.Duration(tokens.ExpiresIn) * time.Second)
+	}
+	if exp, ok := claimTime(claims, "exp"); ok {
+		return exp
+	}
+	return now.Add(5 * time.Minute)
+}
+
+func claimString(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// claimStrings returns a claim that is a string or a list of strings
+func claimStrings(claims map[string]interface{}, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func claimTime(claims map[string]interface{}, name string) (time.Time, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+// randomToken returns 32 random bytes, URL-safe encoded
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate random token: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,300 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeProvider is an OpenID Connect provider that signs users in without asking
+type fakeProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu        sync.Mutex
+	groups    []string
+	codes     map[string]url.Values // code → 認可リクエスト
+	refreshes int
+	expiresIn int64
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	p := &fakeProvider{key: key, codes: make(map[string]url.Values), expiresIn: 300}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if clientID, secret, _ := r.BasicAuth(); clientID != "tm" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		nonce := ""
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			authorize, ok := p.codes[r.Form.Get("code")]
+			delete(p.codes, r.Form.Get("code"))
+			challenge := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if !ok || authorize.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			nonce = authorize.Get("nonce")
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			p.refreshes++
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "access",
+			"refresh_token": "refresh-1",
+			"expires_in":    p.expiresIn,
+			"id_token": p.sign(t, map[string]interface{}{
+				"iss":                p.server.URL,
+				"aud":                "tm",
+				"sub":                "u-123",
+				"exp":                time.Now().Add(time.Hour).Unix(),
+				"nonce":              nonce,
+				"preferred_username": "jdoe",
+				"name":               "Jane Doe",
+				"groups":             p.groups,
+			}),
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+func (p *fakeProvider) sign(t *testing.T, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// authorize follows the redirect to the provider and returns the state and code of the callback
+func (p *fakeProvider) authorize(t *testing.T, location string) (string, string) {
+	u, err := url.Parse(location)
+	if err != nil {
+		t.Fatalf("Invalid login URL %q: %v", location, err)
+	}
+	query := u.Query()
+	p.mu.Lock()
+	p.codes["code-"+query.Get("state")] = query
+	p.mu.Unlock()
+	return query.Get("state"), "code-" + query.Get("state")
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	provider := newFakeProvider(t)
+	defer provider.server.Close()
+	provider.groups = []string{"NetOps", "staff"}
+
+	config := OIDCConfig{
+		Issuer:       provider.server.URL,
+		ClientID:     "tm",
+		ClientSecret: "s3cret",
+		RedirectURL:  "https://tm.example.com/auth/callback",
+		GroupRoles:   map[string]string{"netops": "editor", "staff": "viewer"},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Expected valid config, got %v", err)
+	}
+	authenticator := NewOIDCAuthenticator(config)
+	ctx := context.Background()
+
+	location, err := authenticator.BeginLogin(ctx, "/topology/spine-01")
+	if err != nil {
+		t.Fatalf("Expected login to start, got %v", err)
+	}
+	state, code := provider.authorize(t, location)
+
+	sessionID, returnTo, err := authenticator.CompleteLogin(ctx, state, code)
+	if err != nil {
+		t.Fatalf("Expected login to complete, got %v", err)
+	}
+	if returnTo != "/topology/spine-01" {
+		t.Errorf("Expected return path to be kept, got %q", returnTo)
+	}
+	principal, err := authenticator.Session(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("Expected session to be valid, got %v", err)
+	}
+	if principal.Username != "jdoe" || principal.Name != "Jane Doe" || principal.Role != RoleEditor || principal.Backend != "oidc" {
+		t.Errorf("Unexpected principal: %+v", principal)
+	}
+
+	// state は一度だけ使える
+	if _, _, err := authenticator.CompleteLogin(ctx, state, code); !errors.Is(err, ErrInvalidLogin) {
+		t.Errorf("Expected a replayed callback to be rejected, got %v", err)
+	}
+	if _, err := authenticator.Session(ctx, "unknown"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected unknown session to be rejected, got %v", err)
+	}
+
+	// トークンの期限切れで更新し、グループの変更を反映する
+	provider.groups = []string{"staff"}
+	authenticator.sessions[sessionID].tokenExpires = time.Now().Add(-time.Second)
+	if principal, err := authenticator.Session(ctx, sessionID); err != nil || principal.Role != RoleViewer {
+		t.Errorf("Expected refreshed session with role viewer, got %+v, %v", principal, err)
+	}
+	if provider.refreshes != 1 {
+		t.Errorf("Expected 1 refresh, got %d", provider.refreshes)
+	}
+
+	// ロールを失ったユーザーのセッションは終了する
+	provider.groups = []string{"contractors"}
+	authenticator.sessions[sessionID].tokenExpires = time.Now().Add(-time.Second)
+	if _, err := authenticator.Session(ctx, sessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected session to end when the user lost the role, got %v", err)
+	}
+
+	authenticator.EndSession(sessionID)
+	if _, err := authenticator.Session(ctx, sessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ended session to be rejected, got %v", err)
+	}
+}
+
+func TestOIDCAuthenticator_VerifyIDToken(t *testing.T) {
+	provider := newFakeProvider(t)
+	defer provider.server.Close()
+
+	authenticator := NewOIDCAuthenticator(OIDCConfig{Issuer: provider.server.URL, ClientID: "tm"})
+	ctx := context.Background()
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   provider.server.URL,
+			"aud":   []string{"other", "tm"},
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": "n-1",
+		}
+	}
+
+	if _, err := authenticator.verifyIDToken(ctx, provider.sign(t, valid()), "n-1"); err != nil {
+		t.Fatalf("Expected valid token, got %v", err)
+	}
+
+	tests := map[string]func(claims map[string]interface{}){
+		"wrong issuer":   func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c map[string]interface{}) { c["aud"] = "other" },
+		"expired":        func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"wrong nonce":    func(c map[string]interface{}) { c["nonce"] = "n-2" },
+	}
+	for name, tamper := range tests {
+		claims := valid()
+		tamper(claims)
+		if _, err := authenticator.verifyIDToken(ctx, provider.sign(t, claims), "n-1"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Expected %s to be rejected, got %v", name, err)
+		}
+	}
+
+	token := provider.sign(t, valid())
+	tampered := token[:len(token)-4] + "AAAA"
+	if _, err := authenticator.verifyIDToken(ctx, tampered, "n-1"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected a tampered signature to be rejected, got %v", err)
+	}
+}
+
+func TestOIDCAuthenticator_CapsPendingLoginsAndSessions(t *testing.T) {
+	provider := newFakeProvider(t)
+	defer provider.server.Close()
+	provider.groups = []string{"staff"}
+
+	authenticator := NewOIDCAuthenticator(OIDCConfig{
+		Issuer:       provider.server.URL,
+		ClientID:     "tm",
+		ClientSecret: "s3cret",
+		RedirectURL:  "https://tm.example.com/auth/callback",
+		GroupRoles:   map[string]string{"staff": "viewer"},
+	})
+	authenticator.maxLogins = 3
+	authenticator.maxSessions = 2
+	ctx := context.Background()
+
+	// 完了しないログインが溜まっても上限を超えず、最も古いものから捨てる
+	var states []string
+	var codes []string
+	for i := 0; i < 5; i++ {
+		location, err := authenticator.BeginLogin(ctx, "/")
+		if err != nil {
+			t.Fatalf("Expected login to start, got %v", err)
+		}
+		state, code := provider.authorize(t, location)
+		states = append(states, state)
+		codes = append(codes, code)
+		// 開始時刻（期限）の順序を確実にする
+		time.Sleep(time.Millisecond)
+	}
+	if len(authenticator.logins) != 3 {
+		t.Fatalf("Expected 3 pending logins, got %d", len(authenticator.logins))
+	}
+	if _, _, err := authenticator.CompleteLogin(ctx, states[0], codes[0]); !errors.Is(err, ErrInvalidLogin) {
+		t.Errorf("Expected the evicted login to be rejected, got %v", err)
+	}
+
+	var sessionIDs []string
+	for i := 2; i < 5; i++ {
+		sessionID, _, err := authenticator.CompleteLogin(ctx, states[i], codes[i])
+		if err != nil {
+			t.Fatalf("Expected login %d to complete, got %v", i, err)
+		}
+		sessionIDs = append(sessionIDs, sessionID)
+		time.Sleep(time.Millisecond)
+	}
+	if len(authenticator.sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(authenticator.sessions))
+	}
+	if _, err := authenticator.Session(ctx, sessionIDs[0]); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected the session expiring first to be evicted, got %v", err)
+	}
+	for _, sessionID := range sessionIDs[1:] {
+		if _, err := authenticator.Session(ctx, sessionID); err != nil {
+			t.Errorf("Expected the newest sessions to be kept, got %v", err)
+		}
+	}
+}
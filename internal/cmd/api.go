@@ -9,25 +9,35 @@ import (
 	"time"
 
 	"github.com/servak/topology-manager/internal/api"
+	"github.com/servak/topology-manager/internal/auth"
 	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/prometheus"
 	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/internal/storage"
 	"github.com/servak/topology-manager/pkg/logger"
 	"github.com/spf13/cobra"
 )
 
 var (
-	apiPort string
+	apiPort           string
+	apiRequestTimeout int
+	apiShareSecret    string
+	apiReadOnly       bool
 )
 
 var apiCmd = &cobra.Command{
 	Use:   "api",
 	Short: "Start the API server",
-	Long:  "Start the REST API server for topology queries and device information",
+	Long:  "Start the REST API server for topology queries and device information. Use \"server\" to run the synchronization worker in the same process.",
 	Run:   runAPI,
 }
 
 func init() {
 	apiCmd.Flags().StringVarP(&apiPort, "port", "p", "8080", "API server port")
+	apiCmd.Flags().IntVar(&apiRequestTimeout, "request-timeout", int(api.DefaultRequestTimeout/time.Second), "Time budget of each API request in seconds (0 = no limit)")
+	apiCmd.Flags().StringVar(&apiShareSecret, "share-secret", os.Getenv("TM_SHARE_SECRET"), "Key used to sign share links (default $TM_SHARE_SECRET; random if unset)")
+	apiCmd.Flags().BoolVar(&apiReadOnly, "read-only", false, "Reject all changes through the API (default: api.read_only from the config file)")
 }
 
 func runAPI(cmd *cobra.Command, args []string) {
@@ -54,9 +64,47 @@ func runAPI(cmd *cobra.Command, args []string) {
 
 	appLogger.Info("Connected to PostgreSQL")
 
+	stopHeartbeat := startSchemaHeartbeat(repo, "api", func(err error) {
+		appLogger.Warn("Failed to report schema version", "error", err)
+	})
+	defer stopHeartbeat()
+
+	if err := bootstrapOnStartup(context.Background(), repo, config, appLogger); err != nil {
+		appLogger.Error("Failed to bootstrap", "error", err)
+		os.Exit(1)
+	}
+
 	// Repository includes both topology and classification interfaces
 	// APIサーバーの初期化
 	server := api.NewServer(repo, repo, appLogger)
+	server.SetRequestTimeout(time.Duration(apiRequestTimeout) * time.Second)
+	server.SetPlaceholderDefaults(config.GetPlaceholderDefaults())
+	server.SetPortNamingLint(config.GetPortNamingLint())
+	server.SetMetadataSchema(config.GetMetadataSchema())
+	server.SetCoverageGate(config.GetCoverageGate())
+	server.SetOrphanedLinkPolicy(config.GetOrphanedLinkPolicy())
+	server.SetIdentityOptions(config.GetIdentityOptions())
+	if err := configureExports(server, config, appLogger); err != nil {
+		appLogger.Error("Failed to configure exports", "error", err)
+		os.Exit(1)
+	}
+	server.StartJobRunner(localInstanceID())
+	server.StartChangeListener()
+	server.StartViewRequestFlusher(service.DefaultViewRequestFlushInterval)
+	if apiShareSecret != "" {
+		server.SetShareSecret([]byte(apiShareSecret))
+	} else {
+		appLogger.Warn("No share secret configured; share links will stop working when the server restarts")
+	}
+	if err := configureAuth(server, config, appLogger); err != nil {
+		appLogger.Error("Failed to configure authentication", "error", err)
+		os.Exit(1)
+	}
+	configureMetricsProxy(server, prometheus.NewClient(config.GetPrometheusConfig()), config, appLogger)
+	if cmd.Flags().Changed("read-only") {
+		config.API.ReadOnly = apiReadOnly
+	}
+	configureReadOnly(server, config, appLogger)
 
 	// HTTPサーバーの設定
 	httpServer := &http.Server{
@@ -94,3 +142,63 @@ func runAPI(cmd *cobra.Command, args []string) {
 
 	appLogger.Info("API server stopped")
 }
+
+// configureAuth enables the authentication backend selected in the config file
+func configureAuth(server *api.Server, cfg *config.Config, appLogger *logger.Logger) error {
+	sso, err := auth.NewSSO(cfg.Auth)
+	if err != nil {
+		return err
+	}
+	if sso != nil {
+		server.SetSSO(sso)
+		appLogger.Info("Single sign-on enabled", "backend", sso.Name(), "issuer", sso.Config().Issuer)
+		return nil
+	}
+
+	authenticator, err := auth.New(cfg.Auth)
+	if err != nil {
+		return err
+	}
+	if authenticator == nil {
+		appLogger.Warn("Authentication disabled; every API client can read and change the topology")
+		return nil
+	}
+
+	server.SetAuthenticator(authenticator)
+	appLogger.Info("Authentication enabled", "backend", authenticator.Name())
+	return nil
+}
+
+// configureMetricsProxy serves the whitelisted device metrics unless disabled in the config file
+func configureMetricsProxy(server *api.Server, promClient *prometheus.Client, cfg *config.Config, appLogger *logger.Logger) {
+	proxyConfig := cfg.GetProxyConfig()
+	if proxyConfig.Disabled {
+		appLogger.Info("Metrics proxy disabled")
+		return
+	}
+	server.SetMetricsProxy(promClient, proxyConfig)
+	appLogger.Info("Metrics proxy enabled", "metrics", len(proxyConfig.Metrics), "rate_limit", proxyConfig.RateLimit, "cache_ttl", proxyConfig.CacheTTL)
+}
+
+// configureExports enables exports when api.exports.dir is set. It must run before the job runner starts.
+func configureExports(server *api.Server, cfg *config.Config, appLogger *logger.Logger) error {
+	if cfg.API.Exports.Dir == "" {
+		return nil
+	}
+	store, err := storage.NewFileStore(cfg.API.Exports.Dir)
+	if err != nil {
+		return err
+	}
+	server.SetExportStore(store, cfg.API.Exports.Retention)
+	appLogger.Info("Exports enabled", "dir", cfg.API.Exports.Dir, "retention", cfg.API.Exports.Retention)
+	return nil
+}
+
+// configureReadOnly rejects changes through the API when the deployment is read-only
+func configureReadOnly(server *api.Server, cfg *config.Config, appLogger *logger.Logger) {
+	if !cfg.API.ReadOnly {
+		return
+	}
+	server.SetReadOnly(true)
+	appLogger.Info("Read-only mode enabled; changes come from synchronization only")
+}
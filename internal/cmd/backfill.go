@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/prometheus"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/worker"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backfillFrom          string
+	backfillTo            string
+	backfillStep          time.Duration
+	backfillOutputDir     string
+	backfillPrometheusURL string
+)
+
+var backfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Reconstruct past topology from Prometheus into snapshots",
+	Long: `Query Prometheus at historical timestamps and write the topology as it was
+at each timestamp to a JSON snapshot, for post-incident analysis.
+
+Snapshots are written to the output directory as snapshot-<UTC time>.json.
+The database is not modified; it is only read to carry over the current
+layer and type of known devices. Data older than the Prometheus retention
+cannot be reconstructed.
+
+Example:
+  topology-manager worker backfill --from 2025-03-01T09:00:00Z --to 2025-03-01T12:00:00Z --step 30m -o ./snapshots`,
+	RunE: runBackfill,
+}
+
+func init() {
+	backfillCmd.Flags().StringVar(&backfillFrom, "from", "", "Start time (RFC3339)")
+	backfillCmd.Flags().StringVar(&backfillTo, "to", "", "End time (RFC3339, default: same as --from)")
+	backfillCmd.Flags().DurationVar(&backfillStep, "step", time.Hour, "Interval between snapshots")
+	backfillCmd.Flags().StringVarP(&backfillOutputDir, "output-dir", "o", ".", "Directory to write snapshots to")
+	backfillCmd.Flags().StringVarP(&backfillPrometheusURL, "prometheus-url", "p", "", "Prometheus server URL (default: from config)")
+	backfillCmd.MarkFlagRequired("from")
+
+	workerCmd.AddCommand(backfillCmd)
+}
+
+func runBackfill(cmd *cobra.Command, args []string) error {
+	from, err := time.Parse(time.RFC3339, backfillFrom)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	to := from
+	if backfillTo != "" {
+		if to, err = time.Parse(time.RFC3339, backfillTo); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+	if to.After(time.Now()) {
+		return fmt.Errorf("--to must not be in the future")
+	}
+	timestamps, err := topology.BackfillTimes(from, to, backfillStep)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if backfillPrometheusURL != "" {
+		cfg.Prometheus.URL = backfillPrometheusURL
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	promClient := prometheus.NewClient(cfg.GetPrometheusConfig())
+	if err := promClient.Health(ctx); err != nil {
+		return fmt.Errorf("prometheus health check failed: %w", err)
+	}
+
+	if err := os.MkdirAll(backfillOutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", backfillOutputDir, err)
+	}
+
+	logger := log.New(os.Stderr, "[BACKFILL] ", log.LstdFlags)
+	sync := worker.NewPrometheusSync(promClient, cfg.GetMetricsConfig(), repo, repo, worker.DefaultPrometheusSyncConfig(), logger)
+
+	written := 0
+	for _, at := range timestamps {
+		snapshot, err := sync.Backfill(ctx, at)
+		if err != nil {
+			// データのない時刻はスキップして残りの時刻を続ける
+			logger.Printf("Skipping %s: %v", at.Format(time.RFC3339), err)
+			continue
+		}
+
+		data, err := json.MarshalIndent(snapshot, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode snapshot: %w", err)
+		}
+		path := filepath.Join(backfillOutputDir, snapshot.FileName())
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written++
+		fmt.Printf("%s: %d devices, %d links -> %s\n", at.Format(time.RFC3339), len(snapshot.Devices), len(snapshot.Links), path)
+	}
+
+	if written == 0 {
+		return fmt.Errorf("no snapshots were written; is the time range within the Prometheus retention?")
+	}
+	fmt.Printf("Wrote %d of %d snapshots to %s\n", written, len(timestamps), backfillOutputDir)
+	return nil
+}
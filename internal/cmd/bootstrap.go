@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bootstrapDryRun  bool
+	bootstrapNoRules bool
+)
+
+var bootstrapCmd = &cobra.Command{
+	Use:   "bootstrap",
+	Short: "Install the default hierarchy layers and starter classification rules",
+	Long: `Install the hierarchy layers (border, core, spine, leaf, access, server) and
+starter rules classifying devices by their name prefix, or those declared in
+classification.bootstrap of the config file, on a fresh deployment.
+
+Only missing layers and rules are created: layers are matched by name and rules
+by a stable ID, and existing ones are never modified, so running it again is
+safe. Starter rules have priority 0, so other rules take precedence, and may be
+edited or deleted through the API afterwards. Set
+classification.bootstrap.on_startup to apply it whenever the api or server
+command starts.`,
+	RunE: runBootstrap,
+}
+
+func init() {
+	bootstrapCmd.Flags().BoolVar(&bootstrapDryRun, "dry-run", false, "Show what would be installed without writing it")
+	bootstrapCmd.Flags().BoolVar(&bootstrapNoRules, "no-rules", false, "Install the layers only")
+	addOutputFlag(bootstrapCmd)
+	addDeprecatedFormatFlag(bootstrapCmd)
+
+	rootCmd.AddCommand(bootstrapCmd)
+}
+
+func runBootstrap(cmd *cobra.Command, args []string) error {
+	output, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	pack := cfg.GetBootstrapPack()
+	if bootstrapNoRules {
+		pack.Rules = nil
+	}
+
+	classificationService := service.NewClassificationService(repo, repo)
+	result, err := classificationService.Bootstrap(context.Background(), pack, bootstrapDryRun)
+	if err != nil {
+		return err
+	}
+
+	if output != outputTable {
+		return printOutput(output, result)
+	}
+
+	if result.DryRun {
+		fmt.Println("Dry run: no changes written")
+	}
+	fmt.Printf("Layers created: %d, existing: %d\n", len(result.LayersCreated), len(result.LayersExisting))
+	for _, name := range result.LayersCreated {
+		fmt.Printf("  + %s\n", name)
+	}
+	fmt.Printf("Rules created: %d, existing: %d\n", len(result.RulesCreated), len(result.RulesExisting))
+	for _, id := range result.RulesCreated {
+		fmt.Printf("  + %s\n", id)
+	}
+	return nil
+}
+
+// bootstrapOnStartup installs the missing layers and starter rules when classification.bootstrap.on_startup is set
+func bootstrapOnStartup(ctx context.Context, repo repository.Repository, cfg *config.Config, appLogger *logger.Logger) error {
+	if !cfg.Classification.Bootstrap.OnStartup {
+		return nil
+	}
+
+	result, err := service.NewClassificationService(repo, repo).Bootstrap(ctx, cfg.GetBootstrapPack(), false)
+	if err != nil {
+		return err
+	}
+	if len(result.LayersCreated) > 0 || len(result.RulesCreated) > 0 {
+		appLogger.Info("Bootstrapped hierarchy layers and starter rules",
+			"layers", len(result.LayersCreated), "rules", len(result.RulesCreated))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var importCircuitsCmd = &cobra.Command{
+	Use:   "import-circuits <file.csv>",
+	Short: "Import cable and circuit IDs from CSV",
+	Long: `Import circuits from a CSV file with the header
+circuit_id,provider,a_device,a_port,z_device,z_port,description
+(columns in any order; circuit_id, a_device and z_device are required).
+Existing circuits with the same ID are updated. Each circuit is attached to
+the link between its ends; circuits without a matching link are listed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportCircuits,
+}
+
+func init() {
+	importCircuitsCmd.ValidArgsFunction = completeFileArg("csv")
+	rootCmd.AddCommand(importCircuitsCmd)
+}
+
+func runImportCircuits(cmd *cobra.Command, args []string) error {
+	file, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open CSV: %w", err)
+	}
+	defer file.Close()
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	circuitRepo, ok := repo.(topology.CircuitRepository)
+	if !ok {
+		return fmt.Errorf("database type %s does not support circuits", cfg.GetDatabaseConfig().Type)
+	}
+
+	circuitService := service.NewCircuitService(circuitRepo, repo)
+	result, err := circuitService.ImportCircuits(context.Background(), file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d circuits (%d attached to links)\n", result.Imported, result.Attached)
+	for _, id := range result.Unattached {
+		fmt.Printf("  no matching link: %s\n", id)
+	}
+	return nil
+}
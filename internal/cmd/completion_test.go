@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// complete runs the hidden completion command of the CLI and returns the candidates
+func complete(t *testing.T, args ...string) []string {
+	t.Helper()
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Completion of %v failed: %v", args, err)
+	}
+
+	var candidates []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		// 最終行はシェルへの指示（:4 など）
+		if line != "" && !strings.HasPrefix(line, ":") {
+			candidates = append(candidates, line)
+		}
+	}
+	return candidates
+}
+
+func TestCompletion_OutputFlag(t *testing.T) {
+	for _, command := range []string{"fsck", "check-coverage", "lldp-report"} {
+		if got := complete(t, command, "--output", ""); !reflect.DeepEqual(got, outputFormats) {
+			t.Errorf("%s: expected %v, got %v", command, outputFormats, got)
+		}
+	}
+}
+
+func TestCompletion_RepairKinds(t *testing.T) {
+	got, directive := completeConsistencyIssueKinds(fsckCmd, nil, "dangling_link,dup")
+	if len(got) == 0 || got[0] != "dangling_link,dangling_link" {
+		t.Fatalf("Expected the kinds after the comma, got %v", got)
+	}
+	if directive&cobra.ShellCompDirectiveNoSpace == 0 {
+		t.Error("Expected no space after a kind so that more kinds can follow")
+	}
+
+	got = complete(t, "fsck", "--repair", "")
+	if !reflect.DeepEqual(got, []string{"dangling_link", "duplicate_link", "unknown_layer", "orphaned_classification"}) {
+		t.Errorf("Expected every issue kind, got %v", got)
+	}
+}
+
+func TestCompleteFileArg(t *testing.T) {
+	extensions, directive := completeFileArg("csv")(importRacksCmd, nil, "")
+	if !reflect.DeepEqual(extensions, []string{"csv"}) || directive != cobra.ShellCompDirectiveFilterFileExt {
+		t.Errorf("Expected CSV files, got %v (%d)", extensions, directive)
+	}
+
+	// 引数は1つだけ
+	if _, directive := completeFileArg("csv")(importRacksCmd, []string{"racks.csv"}, ""); directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("Expected no completion after the file, got %d", directive)
+	}
+}
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/repository"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -16,25 +19,59 @@ var configCmd = &cobra.Command{
 	Long:  `Configuration management commands for the topology manager`,
 }
 
+var (
+	validateFile    string
+	validateCheckDB bool
+)
+
 // configValidateCmd validates the configuration file
 var configValidateCmd = &cobra.Command{
 	Use:   "validate [config-file]",
 	Short: "Validate configuration file",
-	Long:  `Validate the syntax and content of a configuration file`,
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Validate the syntax and content of a configuration file: unknown keys, metrics
+mapping label references, hierarchy rules and, optionally, database connectivity.
+Every problem is reported with its line number.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		configPath := config.GetDefaultConfigPath()
+		path := validateFile
 		if len(args) > 0 {
-			configPath = args[0]
+			path = args[0]
+		}
+		if path == "" {
+			path = config.GetDefaultConfigPath()
 		}
 
-		fmt.Printf("Validating configuration file: %s\n", configPath)
+		fmt.Printf("Validating configuration file: %s\n", path)
 
-		cfg, err := config.LoadConfig(configPath)
+		cfg, issues, err := config.ValidateFile(path)
 		if err != nil {
 			return fmt.Errorf("configuration validation failed: %w", err)
 		}
 
+		errorCount := 0
+		for _, issue := range issues {
+			if issue.Severity == config.SeverityError {
+				errorCount++
+				fmt.Printf("❌ %s\n", issue)
+			} else {
+				fmt.Printf("⚠️  %s\n", issue)
+			}
+		}
+
+		// データベース接続の確認（任意）
+		if validateCheckDB && cfg != nil && errorCount == 0 {
+			if err := checkDatabaseConnectivity(cfg); err != nil {
+				errorCount++
+				fmt.Printf("❌ database: %v\n", err)
+			} else {
+				fmt.Printf("✅ Connected to %s database\n", cfg.Database.Type)
+			}
+		}
+
+		if errorCount > 0 {
+			return fmt.Errorf("configuration has %d error(s)", errorCount)
+		}
+
 		fmt.Println("✅ Configuration is valid")
 		fmt.Printf("Database type: %s\n", cfg.Database.Type)
 
@@ -42,6 +79,23 @@ var configValidateCmd = &cobra.Command{
 	},
 }
 
+// checkDatabaseConnectivity opens the configured database and runs a health check
+func checkDatabaseConnectivity(cfg *config.Config) error {
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer repo.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := repo.Health(ctx); err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	return nil
+}
+
 // configShowCmd shows the current configuration
 var configShowCmd = &cobra.Command{
 	Use:   "show [config-file]",
@@ -121,6 +175,9 @@ database:
 }
 
 func init() {
+	configValidateCmd.Flags().StringVarP(&validateFile, "file", "f", "", "Configuration file to validate (default: tm.yaml)")
+	configValidateCmd.Flags().BoolVar(&validateCheckDB, "check-db", false, "Also check database connectivity")
+
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configValidateCmd)
 	configCmd.AddCommand(configShowCmd)
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	coverageThreshold   float64
+	coverageDeviceTypes []string
+)
+
+var checkCoverageCmd = &cobra.Command{
+	Use:   "check-coverage",
+	Short: "Check classification coverage against the configured gate",
+	Long: `Evaluate the classification coverage gate and exit with a non-zero status
+when the ratio of classified devices is below the threshold. Intended for use
+in automation pipelines that depend on accurate layering.`,
+	RunE: runCheckCoverage,
+}
+
+func init() {
+	checkCoverageCmd.Flags().Float64Var(&coverageThreshold, "threshold", 0, "Required coverage in percent (overrides classification.coverage.threshold)")
+	checkCoverageCmd.Flags().StringSliceVar(&coverageDeviceTypes, "types", nil, "Device types in scope (overrides classification.coverage.device_types)")
+	addOutputFlag(checkCoverageCmd)
+
+	rootCmd.AddCommand(checkCoverageCmd)
+}
+
+func runCheckCoverage(cmd *cobra.Command, args []string) error {
+	output, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// CLIフラグで設定値を上書き
+	gate := cfg.GetCoverageGate()
+	if cmd.Flags().Changed("threshold") {
+		gate.Threshold = coverageThreshold
+	}
+	if cmd.Flags().Changed("types") {
+		gate.DeviceTypes = coverageDeviceTypes
+	}
+	if gate.Threshold < 0 || gate.Threshold > 100 {
+		return fmt.Errorf("threshold must be between 0 and 100, got %v", gate.Threshold)
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	classificationService := service.NewClassificationService(repo, repo)
+	report, err := classificationService.EvaluateCoverage(context.Background(), gate)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate coverage: %w", err)
+	}
+
+	if output != outputTable {
+		if err := printOutput(output, report); err != nil {
+			return err
+		}
+		if !report.Passed {
+			return fmt.Errorf("classification coverage %.1f%% is below threshold %.1f%%", report.Coverage, report.Threshold)
+		}
+		return nil
+	}
+
+	scope := "all devices"
+	if len(report.DeviceTypes) > 0 {
+		scope = strings.Join(report.DeviceTypes, ", ")
+	}
+	fmt.Printf("Scope: %s\n", scope)
+	fmt.Printf("Classified: %d/%d (%.1f%%, threshold %.1f%%)\n",
+		report.ClassifiedDevices, report.TotalDevices, report.Coverage, report.Threshold)
+
+	if report.Passed {
+		fmt.Println("✅ Coverage gate passed")
+		return nil
+	}
+
+	fmt.Println("❌ Coverage gate failed")
+	fmt.Println("Unclassified devices:")
+	for _, deviceID := range report.UnclassifiedDevices {
+		fmt.Printf("  - %s\n", deviceID)
+	}
+
+	return fmt.Errorf("classification coverage %.1f%% is below threshold %.1f%%", report.Coverage, report.Threshold)
+}
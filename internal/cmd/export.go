@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat          string
+	exportDepth           int
+	exportGroup           bool
+	exportDirection       string
+	exportEdgeLabelFormat string
+	exportOutput          string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <device-id>",
+	Short: "Export the sub-topology around a device",
+	Long: `Export the sub-topology around a device as JSON or as a Mermaid flowchart.
+Mermaid output can be pasted into Markdown docs and wikis that render
+` + "```mermaid" + ` code blocks.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", "json", "Output format (json or mermaid)")
+	exportCmd.Flags().IntVar(&exportDepth, "depth", 3, "Exploration depth")
+	exportCmd.Flags().BoolVar(&exportGroup, "group", false, "Collapse devices into groups by name prefix")
+	exportCmd.Flags().StringVar(&exportDirection, "direction", "TB", "Mermaid flowchart direction (TB, BT, LR, RL)")
+	exportCmd.Flags().StringVar(&exportEdgeLabelFormat, "edge-label-format", visualization.DefaultEdgeLabelFormat, "Edge label template of link metadata keys (empty = no labels)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to a file instead of stdout")
+	_ = exportCmd.RegisterFlagCompletionFunc("format", completeValues("json", "mermaid"))
+	_ = exportCmd.RegisterFlagCompletionFunc("direction", completeValues("TB", "BT", "LR", "RL"))
+
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	if exportFormat != "json" && exportFormat != "mermaid" {
+		return fmt.Errorf("unsupported format '%s' (expected json or mermaid)", exportFormat)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	groupingOpts := visualization.GroupingOptions{
+		Enabled:       exportGroup,
+		MinGroupSize:  3,
+		MaxDepth:      2,
+		GroupByPrefix: true,
+		PrefixMinLen:  3,
+	}
+
+	visualizationService := service.NewVisualizationService(repo)
+	topology, err := visualizationService.GetVisualTopologyWithGrouping(context.Background(), args[0], exportDepth, groupingOpts)
+	if err != nil {
+		return fmt.Errorf("failed to get topology: %w", err)
+	}
+	topology.LabelEdges(exportEdgeLabelFormat)
+
+	var data []byte
+	if exportFormat == "mermaid" {
+		diagram, err := visualization.RenderMermaid(topology, exportDirection)
+		if err != nil {
+			return err
+		}
+		data = []byte(diagram)
+	} else {
+		data, err = json.MarshalIndent(topology, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode topology: %w", err)
+		}
+		data = append(data, '\n')
+	}
+
+	if exportOutput == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(exportOutput, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", exportOutput, err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d nodes and %d edges to %s\n", len(topology.Nodes), len(topology.Edges), exportOutput)
+	return nil
+}
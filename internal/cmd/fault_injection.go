@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/faultinject"
+	"github.com/servak/topology-manager/internal/prometheus"
+)
+
+// withFaultInjection returns the repository and Prometheus client of the synchronization worker.
+// With sync.fault_injection configured (chaos builds only) both fail and delay on purpose.
+func withFaultInjection(cfg *config.Config, repo topology.Repository, promClient *prometheus.Client, logger *log.Logger) (topology.Repository, *prometheus.Client) {
+	faults := cfg.GetFaultInjection()
+	if !faults.Enabled() {
+		return repo, promClient
+	}
+
+	logger.Printf("WARNING: fault injection enabled (write failures %.0f%%, query failures %.0f%%, query delay %s)",
+		faults.WriteFailureRate*100, faults.QueryFailureRate*100, faults.QueryDelay)
+
+	injector := faultinject.New(faults)
+	promConfig := cfg.GetPrometheusConfig()
+	promConfig.Transport = injector.WrapTransport(nil)
+	return injector.WrapRepository(repo), prometheus.NewClient(promConfig)
+}
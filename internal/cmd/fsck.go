@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fsckRepair    []string
+	fsckRepairAll bool
+	fsckDryRun    bool
+)
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Check the stored topology for referential problems",
+	Long: `Scan the database for referential problems and optionally repair them:
+
+  dangling_link            links whose source or target device does not exist (deleted)
+  duplicate_link           links stored again under another ID with the same direction
+                           and ports (the most recently seen copy is kept)
+  unknown_layer            devices whose layer_id references a deleted hierarchy layer
+                           (classification cleared)
+  orphaned_classification  devices classified by a rule that no longer exists
+                           (classification cleared so current rules can apply)
+
+Exits with a non-zero status when unrepaired issues remain.`,
+	RunE: runFsck,
+}
+
+func init() {
+	fsckCmd.Flags().StringSliceVar(&fsckRepair, "repair", nil, "Issue kinds to repair (e.g. --repair dangling_link,duplicate_link)")
+	fsckCmd.Flags().BoolVar(&fsckRepairAll, "repair-all", false, "Repair every kind of issue")
+	fsckCmd.Flags().BoolVar(&fsckDryRun, "dry-run", false, "Report what would be repaired without changing the database")
+	addOutputFlag(fsckCmd)
+	addDeprecatedJSONFlag(fsckCmd)
+	_ = fsckCmd.RegisterFlagCompletionFunc("repair", completeConsistencyIssueKinds)
+
+	rootCmd.AddCommand(fsckCmd)
+}
+
+func runFsck(cmd *cobra.Command, args []string) error {
+	output, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	var repair []topology.ConsistencyIssueKind
+	if fsckRepairAll {
+		repair = topology.ConsistencyIssueKinds
+	} else {
+		for _, value := range fsckRepair {
+			kind, err := topology.ParseConsistencyIssueKind(value)
+			if err != nil {
+				return err
+			}
+			repair = append(repair, kind)
+		}
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	consistencyService := service.NewConsistencyService(repo, repo)
+	report, err := consistencyService.Check(context.Background(), repair, fsckDryRun)
+	if err != nil {
+		return fmt.Errorf("failed to check consistency: %w", err)
+	}
+
+	if output != outputTable {
+		if err := printOutput(output, report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Checked: %d devices, %d links\n", report.Devices, report.Links)
+		for _, kind := range topology.ConsistencyIssueKinds {
+			count := report.Counts[kind]
+			if count == 0 {
+				continue
+			}
+			fmt.Printf("%s: %d\n", kind, count)
+			for _, issue := range report.Issues {
+				if issue.Kind != kind {
+					continue
+				}
+				target := issue.LinkID
+				if target == "" {
+					target = issue.DeviceID
+				}
+				fmt.Printf("  - %s: %s (repair: %s)\n", target, issue.Detail, issue.Repair)
+			}
+			if repaired, ok := report.Repaired[kind]; ok {
+				if report.DryRun {
+					fmt.Printf("  would repair %d\n", repaired)
+				} else {
+					fmt.Printf("  repaired %d\n", repaired)
+				}
+			}
+		}
+	}
+
+	remaining := 0
+	for kind, count := range report.Counts {
+		if _, repaired := report.Repaired[kind]; !repaired || report.DryRun {
+			remaining += count
+		}
+	}
+	if remaining > 0 {
+		return fmt.Errorf("%d issues remain; re-run with --repair <kind> or --repair-all to fix them", remaining)
+	}
+	if output == outputTable {
+		if len(report.Issues) == 0 {
+			fmt.Println("✅ No referential problems found")
+		} else {
+			fmt.Println("✅ All issues repaired")
+		}
+	}
+	return nil
+}
+
+// completeConsistencyIssueKinds completes the issue kinds of --repair, also after a comma
+func completeConsistencyIssueKinds(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix = toComplete[:i+1]
+	}
+	var kinds []string
+	for _, kind := range topology.ConsistencyIssueKinds {
+		kinds = append(kinds, prefix+string(kind))
+	}
+	return kinds, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
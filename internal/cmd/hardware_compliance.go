@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var checkHardwareCmd = &cobra.Command{
+	Use:   "check-hardware",
+	Short: "Check device hardware against the hardware catalog",
+	Long: `List classified devices whose hardware model is not approved for their layer
+in the hardware catalog (e.g. an access-grade switch classified as core) and
+exit with a non-zero status when any are found. Layers without catalog entries
+are not checked.`,
+	RunE: runCheckHardware,
+}
+
+func init() {
+	addOutputFlag(checkHardwareCmd)
+	addDeprecatedJSONFlag(checkHardwareCmd)
+
+	rootCmd.AddCommand(checkHardwareCmd)
+}
+
+func runCheckHardware(cmd *cobra.Command, args []string) error {
+	output, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	classificationService := service.NewClassificationService(repo, repo)
+	report, err := classificationService.EvaluateHardwareCompliance(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to evaluate hardware compliance: %w", err)
+	}
+
+	if output != outputTable {
+		if err := printOutput(output, report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Checked: %d devices, compliant: %d\n", report.CheckedDevices, report.CompliantDevices)
+		for _, violation := range report.Violations {
+			hardware := violation.Hardware
+			if hardware == "" {
+				hardware = "(unknown)"
+			}
+			fmt.Printf("  - %s: %s is not approved for layer %s (approved: %s)\n",
+				violation.DeviceID, hardware, violation.LayerName, strings.Join(violation.ApprovedModels, ", "))
+		}
+	}
+
+	if len(report.Violations) > 0 {
+		return fmt.Errorf("%d devices violate the hardware catalog", len(report.Violations))
+	}
+	if output == outputTable {
+		fmt.Println("✅ All checked devices comply with the hardware catalog")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/prometheus"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var lldpReportPrometheusURL string
+
+var lldpReportCmd = &cobra.Command{
+	Use:   "lldp-report",
+	Short: "Report LLDP data quality issues",
+	Long: `Analyze raw LLDP neighbor metrics against the device inventory and the stored
+topology. Reports neighbors with unresolvable system names, links skipped due to
+missing fields, chassis-ID-only devices and duplicate neighbor entries, which
+helps explain why a device is missing from the graph.`,
+	RunE: runLLDPReport,
+}
+
+func init() {
+	lldpReportCmd.Flags().StringVar(&lldpReportPrometheusURL, "prometheus-url", "", "Prometheus server URL (overrides prometheus.url)")
+	addOutputFlag(lldpReportCmd)
+	addDeprecatedJSONFlag(lldpReportCmd)
+
+	rootCmd.AddCommand(lldpReportCmd)
+}
+
+func runLLDPReport(cmd *cobra.Command, args []string) error {
+	output, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if lldpReportPrometheusURL != "" {
+		cfg.Prometheus.URL = lldpReportPrometheusURL
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+
+	// 保存済みトポロジーのデバイス一覧
+	devices, err := service.ListAllDevices(ctx, repo)
+	if err != nil {
+		return err
+	}
+	topologyDevices := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		topologyDevices[device.ID] = true
+	}
+
+	promClient := prometheus.NewClient(cfg.GetPrometheusConfig())
+	if err := promClient.Health(ctx); err != nil {
+		return fmt.Errorf("prometheus health check failed: %w", err)
+	}
+
+	extractor := prometheus.NewMetricsExtractor(promClient, cfg.GetMetricsConfig())
+	report, err := extractor.AnalyzeLLDPQuality(ctx, topologyDevices)
+	if err != nil {
+		return fmt.Errorf("failed to analyze LLDP data: %w", err)
+	}
+
+	if output != outputTable {
+		return printOutput(output, report)
+	}
+
+	printLLDPReport(report)
+	return nil
+}
+
+func printLLDPReport(report *prometheus.LLDPQualityReport) {
+	for _, warning := range report.Warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+	if report.MetricName == "" {
+		fmt.Println("❌ No LLDP neighbor metrics found")
+		return
+	}
+
+	fmt.Printf("Metric: %s\n", report.MetricName)
+	fmt.Printf("Samples: %d (usable %d), inventory devices: %d\n",
+		report.TotalSamples, report.UsableLinks, report.InventoryDevices)
+
+	sections := []struct {
+		title  string
+		issues []prometheus.LLDPNeighborIssue
+	}{
+		{"Links skipped due to missing fields", report.SkippedLinks},
+		{"Neighbors with unresolvable system names", report.UnresolvableNeighbors},
+		{"Chassis-ID-only devices", report.ChassisIDOnlyDevices},
+		{"Duplicate neighbor entries", report.DuplicateNeighbors},
+		{"Links missing from topology", report.MissingFromTopology},
+	}
+	for _, section := range sections {
+		fmt.Printf("\n%s (%d):\n", section.title, len(section.issues))
+		for _, issue := range section.issues {
+			fmt.Printf("  - %s:%s -> %s:%s  %s\n",
+				issue.SourceDevice, issue.SourcePort, issue.TargetDevice, issue.TargetPort, issue.Reason)
+		}
+	}
+
+	if report.IssueCount() == 0 {
+		fmt.Println("\n✅ No LLDP data quality issues found")
+	}
+}
@@ -1,30 +1,63 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
-	"path/filepath"
-	"sort"
-	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository/postgres"
 	"github.com/spf13/cobra"
 )
 
+var (
+	migrateLockTimeout  time.Duration
+	migrateLockRetries  int
+	migrateClientWindow time.Duration
+	migrateForce        bool
+)
+
 var migrateCmd = &cobra.Command{
-	Use:   "migrate [up|down]",
+	Use:   "migrate [up|contract|status|down]",
 	Short: "Run database migrations",
-	Long:  "Run database migrations to set up or tear down the database schema",
-	Args:  cobra.ExactArgs(1),
-	Run:   runMigrate,
+	Long: `Run database migrations to set up or tear down the database schema.
+
+Migrations are applied online in two phases so a live database keeps serving:
+
+  up        apply pending expand migrations (new tables, columns, indexes). Each one waits
+            at most --lock-timeout for table locks and is retried instead of blocking
+            the devices/links queries behind it. Contract migrations are skipped.
+  contract  apply pending contract migrations (drops, NOT NULL, renames) once every expand
+            migration is applied, the backfills they require have been completed by the
+            worker, and no API/worker instance running an older schema has reported within
+            --client-window.
+  status    show the applied migrations, backfill progress and running instances
+  down      drop all tables`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"up", "contract", "status", "down"},
+	Run:       runMigrate,
+}
+
+func init() {
+	defaults := postgres.DefaultMigratorOptions()
+	migrateCmd.Flags().DurationVar(&migrateLockTimeout, "lock-timeout", defaults.LockTimeout, "Maximum time a migration waits for a table lock (0 = wait indefinitely)")
+	migrateCmd.Flags().IntVar(&migrateLockRetries, "lock-retries", defaults.LockRetries, "Times a migration that timed out on a lock is retried")
+	migrateCmd.Flags().DurationVar(&migrateClientWindow, "client-window", defaults.ClientWindow, "Instances that reported within this window must run the new schema before contract")
+	migrateCmd.Flags().BoolVar(&migrateForce, "force", false, "Apply contract migrations even when blocked")
+	addOutputFlag(migrateCmd)
 }
 
 func runMigrate(cmd *cobra.Command, args []string) {
 	command := args[0]
+	output, err := outputFormat(cmd)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// 設定ファイルを読み込み
 	cfg, err := config.LoadConfig(configPath)
@@ -66,77 +99,82 @@ func runMigrate(cmd *cobra.Command, args []string) {
 		log.Println("Connected to PostgreSQL")
 	}
 
+	migrator := postgres.NewMigrator(db, postgres.MigratorOptions{
+		LockTimeout:  migrateLockTimeout,
+		LockRetries:  migrateLockRetries,
+		ClientWindow: migrateClientWindow,
+		Logf:         log.Printf,
+	})
+	ctx := context.Background()
+
 	switch command {
 	case "up":
-		if err := migrateUp(db); err != nil {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
 			log.Fatalf("Migration up failed: %v", err)
 		}
-		log.Println("Migration up completed successfully")
+		log.Printf("Migration up completed successfully (%d applied)", len(applied))
+	case "contract":
+		applied, err := migrator.Contract(ctx, migrateForce)
+		if err != nil {
+			log.Fatalf("Migration contract failed: %v", err)
+		}
+		log.Printf("Migration contract completed successfully (%d applied)", len(applied))
+	case "status":
+		if err := printMigrationStatus(ctx, migrator, output); err != nil {
+			log.Fatalf("Migration status failed: %v", err)
+		}
 	case "down":
 		if err := migrateDown(db); err != nil {
 			log.Fatalf("Migration down failed: %v", err)
 		}
 		log.Println("Migration down completed successfully")
 	default:
-		log.Fatalf("Unknown command: %s. Use 'up' or 'down'", command)
+		log.Fatalf("Unknown command: %s. Use 'up', 'contract', 'status' or 'down'", command)
 	}
 }
 
-func migrateUp(db *sql.DB) error {
-	// マイグレーションファイルを読み込み
-	migrationDir := "internal/repository/postgres/migrations"
-	files, err := ioutil.ReadDir(migrationDir)
+func printMigrationStatus(ctx context.Context, migrator *postgres.Migrator, output string) error {
+	status, err := migrator.Status(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to read migration directory: %w", err)
-	}
-
-	var migrationFiles []string
-	for _, file := range files {
-		if strings.HasSuffix(file.Name(), ".sql") {
-			migrationFiles = append(migrationFiles, file.Name())
-		}
+		return err
 	}
-
-	// ファイル名でソート
-	sort.Strings(migrationFiles)
-
-	// マイグレーションテーブルを作成
-	if err := createMigrationTable(db); err != nil {
-		return fmt.Errorf("failed to create migration table: %w", err)
+	if output != outputTable {
+		return printOutput(output, status)
 	}
 
-	// 各マイグレーションファイルを実行
-	for _, filename := range migrationFiles {
-		if applied, err := isMigrationApplied(db, filename); err != nil {
-			return fmt.Errorf("failed to check migration status for %s: %w", filename, err)
-		} else if applied {
-			if verbose {
-				log.Printf("Migration %s already applied, skipping", filename)
-			}
-			continue
+	fmt.Println("Migrations:")
+	for _, state := range status.Migrations {
+		mark := "pending"
+		if state.Applied {
+			mark = "applied"
 		}
-
-		log.Printf("Applying migration %s...", filename)
-
-		filePath := filepath.Join(migrationDir, filename)
-		content, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", filename, err)
-		}
-
-		// SQLを実行
-		if _, err := db.Exec(string(content)); err != nil {
-			return fmt.Errorf("failed to execute migration %s: %w", filename, err)
+		fmt.Printf("  %-8s %-9s %s\n", state.Phase, mark, state.Name)
+		if !state.Applied && state.Phase == postgres.PhaseContract {
+			for _, blocker := range status.ContractBlockers(state.Migration) {
+				fmt.Printf("      blocked: %s\n", blocker)
+			}
 		}
+	}
 
-		// マイグレーション履歴に記録
-		if err := recordMigration(db, filename); err != nil {
-			return fmt.Errorf("failed to record migration %s: %w", filename, err)
+	if len(status.Backfills) > 0 {
+		fmt.Println("Backfills:")
+		for _, state := range status.Backfills {
+			progress := "running"
+			if state.Completed {
+				progress = "completed"
+			}
+			fmt.Printf("  %-9s %s (%d rows)\n", progress, state.Name, state.RowsProcessed)
 		}
-
-		log.Printf("Migration %s applied successfully", filename)
 	}
 
+	fmt.Printf("Instances (bundled schema %s):\n", postgres.SchemaVersion())
+	if len(status.Clients) == 0 {
+		fmt.Println("  none reported recently")
+	}
+	for _, client := range status.Clients {
+		fmt.Printf("  %-7s %s schema=%s last_seen=%s\n", client.Role, client.InstanceID, client.SchemaVersion, client.LastSeen.Format(time.RFC3339))
+	}
 	return nil
 }
 
@@ -145,10 +183,33 @@ func migrateDown(db *sql.DB) error {
 	log.Println("Dropping all tables...")
 
 	dropQueries := []string{
+		"DROP TABLE IF EXISTS style_rules",
+		"DROP TABLE IF EXISTS identity_decisions",
+		"DROP TABLE IF EXISTS sync_cycle_stats",
+		"DROP TABLE IF EXISTS maintenance_windows",
+		"DROP TABLE IF EXISTS management_reachability",
+		"DROP TABLE IF EXISTS port_reservations",
+		"DROP TABLE IF EXISTS shadow_classifications",
+		"DROP TABLE IF EXISTS device_workflow_transitions",
+		"DROP TABLE IF EXISTS jobs",
+		"DROP TABLE IF EXISTS icon_mappings",
+		"DROP TABLE IF EXISTS schema_clients",
+		"DROP TABLE IF EXISTS schema_backfills",
+		"DROP TABLE IF EXISTS hardware_lifecycle",
+		"DROP TABLE IF EXISTS hardware_catalog",
+		"DROP TABLE IF EXISTS starting_views",
+		"DROP TABLE IF EXISTS layout_cache",
+		"DROP TABLE IF EXISTS view_requests",
+		"DROP TABLE IF EXISTS display_name_overrides",
+		"DROP TABLE IF EXISTS classification_history",
+		"DROP TABLE IF EXISTS planned_devices",
+		"DROP TABLE IF EXISTS link_history_daily",
+		"DROP TABLE IF EXISTS link_history",
 		"DROP TABLE IF EXISTS links",
 		"DROP TABLE IF EXISTS devices",
 		"DROP TABLE IF EXISTS migrations",
 		"DROP FUNCTION IF EXISTS update_updated_at_column",
+		"DROP FUNCTION IF EXISTS notify_topology_change",
 	}
 
 	for _, query := range dropQueries {
@@ -163,30 +224,47 @@ func migrateDown(db *sql.DB) error {
 	return nil
 }
 
-func createMigrationTable(db *sql.DB) error {
-	query := `
-		CREATE TABLE IF NOT EXISTS migrations (
-			id SERIAL PRIMARY KEY,
-			filename VARCHAR(255) NOT NULL UNIQUE,
-			applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-		)
-	`
-	_, err := db.Exec(query)
-	return err
+// schemaHeartbeatInterval is how often running instances report their schema version;
+// it must stay well below the default --client-window of migrate contract
+const schemaHeartbeatInterval = time.Minute
+
+// localInstanceID identifies this process among the instances sharing a database
+func localInstanceID() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
 }
 
-func isMigrationApplied(db *sql.DB, filename string) (bool, error) {
-	query := "SELECT COUNT(*) FROM migrations WHERE filename = $1"
-	var count int
-	err := db.QueryRow(query, filename).Scan(&count)
-	if err != nil {
-		return false, err
+// startSchemaHeartbeat periodically reports the schema version bundled in this build when
+// the repository supports online migrations, and returns a function that stops it
+func startSchemaHeartbeat(repo interface{}, role string, onError func(error)) func() {
+	schemaRepo, ok := repo.(topology.SchemaMaintenanceRepository)
+	if !ok {
+		return func() {}
 	}
-	return count > 0, nil
-}
 
-func recordMigration(db *sql.DB, filename string) error {
-	query := "INSERT INTO migrations (filename) VALUES ($1)"
-	_, err := db.Exec(query, filename)
-	return err
+	instanceID := localInstanceID()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	report := func() {
+		reportCtx, reportCancel := context.WithTimeout(ctx, 10*time.Second)
+		defer reportCancel()
+		if err := schemaRepo.ReportSchemaVersion(reportCtx, instanceID, role); err != nil && ctx.Err() == nil {
+			onError(err)
+		}
+	}
+
+	report()
+	go func() {
+		ticker := time.NewTicker(schemaHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				report()
+			}
+		}
+	}()
+	return cancel
 }
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var syncNamingRulesDryRun bool
+
+var syncNamingRulesCmd = &cobra.Command{
+	Use:   "sync-naming-rules",
+	Short: "Import hierarchy.naming_rules as classification rules",
+	Long: `Convert hierarchy.naming_rules in the config file into classification rules
+so that a single ruleset classifies devices. Imported rules are marked as
+config-sourced (created_by "config"), cannot be edited through the API and are
+updated or removed on the next sync. They are matched in config order after
+every rule managed through the API, so those always take precedence.`,
+	RunE: runSyncNamingRules,
+}
+
+func init() {
+	syncNamingRulesCmd.Flags().BoolVar(&syncNamingRulesDryRun, "dry-run", false, "Show the changes without writing them")
+	addOutputFlag(syncNamingRulesCmd)
+
+	rootCmd.AddCommand(syncNamingRulesCmd)
+}
+
+func runSyncNamingRules(cmd *cobra.Command, args []string) error {
+	output, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	classificationService := service.NewClassificationService(repo, repo)
+	result, err := classificationService.SyncConfigRules(context.Background(), cfg.GetNamingClassificationRules(), syncNamingRulesDryRun)
+	if err != nil {
+		return err
+	}
+
+	if output != outputTable {
+		return printOutput(output, result)
+	}
+
+	if result.DryRun {
+		fmt.Println("Dry run: no changes written")
+	}
+	fmt.Printf("Created: %d, updated: %d, removed: %d, unchanged: %d\n",
+		len(result.Created), len(result.Updated), len(result.Removed), result.Unchanged)
+	for _, id := range result.Created {
+		fmt.Printf("  + %s\n", id)
+	}
+	for _, id := range result.Updated {
+		fmt.Printf("  ~ %s\n", id)
+	}
+	for _, id := range result.Removed {
+		fmt.Printf("  - %s\n", id)
+	}
+	return nil
+}
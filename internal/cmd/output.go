@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of the query and report commands
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML}
+
+// addOutputFlag adds --output/-o to cmd with completion of the output formats
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", outputTable, "Output format (table, json or yaml)")
+	_ = cmd.RegisterFlagCompletionFunc("output", completeValues(outputFormats...))
+}
+
+// addDeprecatedJSONFlag keeps the --json flag of commands that had it before --output
+func addDeprecatedJSONFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("json", false, "Output the report as JSON")
+	_ = cmd.Flags().MarkDeprecated("json", "use --output json instead")
+}
+
+// addDeprecatedFormatFlag keeps the --format text|json flag of commands that had it before --output
+func addDeprecatedFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().String("format", "text", "Output format (text or json)")
+	_ = cmd.Flags().MarkDeprecated("format", "use --output instead")
+}
+
+// outputFormat returns the --output of cmd. When --output is not given, the deprecated
+// --json or --format flags are honoured so that existing scripts keep working.
+func outputFormat(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString("output")
+	if !cmd.Flags().Changed("output") {
+		if legacy, err := cmd.Flags().GetBool("json"); err == nil && legacy {
+			format = outputJSON
+		}
+		if cmd.Flags().Changed("format") {
+			// --format text は表形式の出力だった
+			format, _ = cmd.Flags().GetString("format")
+			if format == "text" {
+				format = outputTable
+			}
+		}
+	}
+	for _, supported := range outputFormats {
+		if format == supported {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported output format '%s' (expected table, json or yaml)", format)
+}
+
+// printOutput writes value to stdout as indented JSON or as YAML with the field names of its JSON encoding
+func printOutput(format string, value interface{}) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case outputYAML:
+		data, err := marshalYAML(value)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	default:
+		return fmt.Errorf("format '%s' is not printed by printOutput", format)
+	}
+}
+
+// marshalYAML encodes value as YAML through its JSON encoding, so keys, omitempty and
+// custom marshalers match the JSON output and the API
+func marshalYAML(value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	// JSONのフロー形式・ダブルクォートをやめ、ブロック形式で出力する
+	clearYAMLStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
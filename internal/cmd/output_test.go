@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newOutputTestCommand(t *testing.T, legacy func(*cobra.Command), args ...string) *cobra.Command {
+	t.Helper()
+
+	cmd := &cobra.Command{Use: "report"}
+	addOutputFlag(cmd)
+	if legacy != nil {
+		legacy(cmd)
+	}
+	cmd.SetErr(io.Discard)
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("Failed to parse %v: %v", args, err)
+	}
+	return cmd
+}
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		legacy func(*cobra.Command)
+		args   []string
+		want   string
+	}{
+		{"default", nil, nil, outputTable},
+		{"json", nil, []string{"--output", "json"}, outputJSON},
+		{"yaml shorthand", nil, []string{"-o", "yaml"}, outputYAML},
+		// 非推奨のフラグも引き続き使える
+		{"deprecated json", addDeprecatedJSONFlag, []string{"--json"}, outputJSON},
+		{"deprecated format json", addDeprecatedFormatFlag, []string{"--format", "json"}, outputJSON},
+		{"deprecated format text", addDeprecatedFormatFlag, []string{"--format", "text"}, outputTable},
+		// --output が優先される
+		{"output wins over json", addDeprecatedJSONFlag, []string{"--json", "-o", "yaml"}, outputYAML},
+		{"output wins over format", addDeprecatedFormatFlag, []string{"--format", "json", "-o", "table"}, outputTable},
+	}
+	for _, tt := range tests {
+		format, err := outputFormat(newOutputTestCommand(t, tt.legacy, tt.args...))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if format != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, format)
+		}
+	}
+
+	if _, err := outputFormat(newOutputTestCommand(t, nil, "-o", "xml")); err == nil {
+		t.Error("Expected an unsupported output format to be rejected")
+	}
+	if _, err := outputFormat(newOutputTestCommand(t, addDeprecatedFormatFlag, "--format", "csv")); err == nil {
+		t.Error("Expected an unsupported deprecated format to be rejected")
+	}
+}
+
+// captureStdout returns what fn writes to stdout
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	fnErr := fn()
+	os.Stdout = stdout
+	w.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if fnErr != nil {
+		t.Fatalf("Unexpected error: %v", fnErr)
+	}
+	return string(data)
+}
+
+type outputTestReport struct {
+	CheckedDevices int      `json:"checked_devices"`
+	Passed         bool     `json:"passed"`
+	Note           string   `json:"note,omitempty"`
+	Devices        []string `json:"devices"`
+}
+
+func TestPrintOutput(t *testing.T) {
+	report := outputTestReport{CheckedDevices: 2, Passed: true, Devices: []string{"core-01", "core-02"}}
+
+	got := captureStdout(t, func() error { return printOutput(outputJSON, report) })
+	want := "{\n  \"checked_devices\": 2,\n  \"passed\": true,\n  \"devices\": [\n    \"core-01\",\n    \"core-02\"\n  ]\n}\n"
+	if got != want {
+		t.Errorf("Expected indented JSON\n%s\ngot\n%s", want, got)
+	}
+
+	// YAML のキーは JSON と同じ名前で、omitempty も守る
+	got = captureStdout(t, func() error { return printOutput(outputYAML, report) })
+	want = "checked_devices: 2\npassed: true\ndevices:\n  - core-01\n  - core-02\n"
+	if got != want {
+		t.Errorf("Expected block style YAML\n%s\ngot\n%s", want, got)
+	}
+
+	if err := printOutput(outputTable, report); err == nil {
+		t.Error("Expected printOutput to refuse the table format")
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var importRacksCmd = &cobra.Command{
+	Use:   "import-racks <file.csv>",
+	Short: "Import rack placements of devices from CSV",
+	Long: `Import rack placements from a CSV file with the header
+device_id,rack,position,units
+(columns in any order; device_id and rack are required). position is the
+lowest rack unit the device occupies and units its height (default 1).
+Rows with an empty rack remove the device from its rack. Devices that do
+not exist are listed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImportRacks,
+}
+
+func init() {
+	importRacksCmd.ValidArgsFunction = completeFileArg("csv")
+	rootCmd.AddCommand(importRacksCmd)
+}
+
+func runImportRacks(cmd *cobra.Command, args []string) error {
+	file, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open CSV: %w", err)
+	}
+	defer file.Close()
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	rackRepo, ok := repo.(topology.RackRepository)
+	if !ok {
+		return fmt.Errorf("database type %s does not support rack placements", cfg.GetDatabaseConfig().Type)
+	}
+
+	rackService := service.NewRackService(rackRepo, repo)
+	result, err := rackService.ImportPlacements(context.Background(), file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d rack placements\n", result.Imported)
+	for _, id := range result.Unknown {
+		fmt.Printf("  unknown device: %s\n", id)
+	}
+	return nil
+}
@@ -162,6 +162,7 @@ func generateSampleData(count int) ([]topology.Device, []topology.Link) {
 				LayerID:      nil, // will be set by classification
 				DeviceType:   "",  // will be set by classification
 				ClassifiedBy: "",  // empty string will be handled as NULL in database
+				Provenance:   topology.ProvenanceImport,
 				Metadata: map[string]string{
 					"datacenter": "dc1",
 					"rack":       fmt.Sprintf("rack-%d", (i/10)+1),
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"math/rand"
 	"strings"
@@ -26,6 +27,7 @@ var (
 	locationDelimiter          string
 	includeServers             bool
 	enableAutoClassifyEnhanced bool
+	deterministicSeed          bool
 )
 
 var seedDataEnhancedCmd = &cobra.Command{
@@ -35,12 +37,16 @@ var seedDataEnhancedCmd = &cobra.Command{
 - Three-Tier (legacy): Core -> Aggregation -> Access
 - Spine-Leaf (modern): Spine <-> Leaf with ECMP
 - Fat-Tree (latest): Core-Spine -> Agg-Spine -> Edge-Leaf
-- Mixed: Combination of all topologies in a single DC`,
+- Mixed: Combination of all topologies in a single DC
+
+With --deterministic, the same flags always generate the same device and link IDs,
+so re-running updates the previous seed in place instead of adding new devices.`,
 	Run: runSeedDataEnhanced,
 }
 
 func init() {
 	seedDataEnhancedCmd.Flags().StringVarP(&topologyType, "topology", "t", "mixed", "Topology type (three-tier, spine-leaf, fat-tree, mixed)")
+	_ = seedDataEnhancedCmd.RegisterFlagCompletionFunc("topology", completeValues("three-tier", "spine-leaf", "fat-tree", "mixed"))
 	seedDataEnhancedCmd.Flags().Float64Var(&fatTreeScale, "fat-tree-scale", 0.3, "Fat-Tree topology scale factor")
 	seedDataEnhancedCmd.Flags().Float64Var(&spineLeafScale, "spine-leaf-scale", 0.4, "Spine-Leaf topology scale factor")
 	seedDataEnhancedCmd.Flags().Float64Var(&threeTierScale, "three-tier-scale", 0.3, "Three-Tier topology scale factor")
@@ -50,6 +56,7 @@ func init() {
 	seedDataEnhancedCmd.Flags().BoolVar(&includeServers, "include-servers", true, "Include server devices")
 	seedDataEnhancedCmd.Flags().BoolVarP(&clearFirst, "clear", "", false, "Clear existing data before seeding")
 	seedDataEnhancedCmd.Flags().BoolVar(&enableAutoClassifyEnhanced, "enable-auto-classify", true, "Enable automatic device classification for enhanced seed data")
+	seedDataEnhancedCmd.Flags().BoolVar(&deterministicSeed, "deterministic", false, "Generate stable IDs and server counts from the topology type so re-seeding upserts the same devices")
 }
 
 type topologyGenerator struct {
@@ -58,6 +65,8 @@ type topologyGenerator struct {
 	dcSuffix      string
 	delimiter     string
 	now           time.Time
+	idKey         int64 // ID に埋め込む値（通常は実行時刻、決定的モードでは種別のハッシュ）
+	rand          *rand.Rand
 }
 
 func newTopologyGenerator(dcLocation, delimiter string) *topologyGenerator {
@@ -66,19 +75,34 @@ func newTopologyGenerator(dcLocation, delimiter string) *topologyGenerator {
 		suffix = strings.ToUpper(dcLocation)
 	}
 
+	now := time.Now()
 	return &topologyGenerator{
 		deviceCounter: 0,
 		linkCounter:   0,
 		dcSuffix:      suffix,
 		delimiter:     delimiter,
-		now:           time.Now(),
+		now:           now,
+		idKey:         now.Unix(),
+		rand:          rand.New(rand.NewSource(now.UnixNano())),
 	}
 }
 
+// newDeterministicTopologyGenerator creates a generator whose IDs and random choices depend only on
+// the topology type, so the same flags always produce the same topology
+func newDeterministicTopologyGenerator(dcLocation, delimiter, topologyType string) *topologyGenerator {
+	g := newTopologyGenerator(dcLocation, delimiter)
+
+	h := fnv.New32a()
+	h.Write([]byte(topologyType))
+	g.idKey = int64(h.Sum32())
+	g.rand = rand.New(rand.NewSource(g.idKey))
+	return g
+}
+
 func (g *topologyGenerator) generateDeviceID(prefix string) string {
 	g.deviceCounter++
-	// タイムスタンプの下4桁を含めてユニーク性を保証
-	baseID := fmt.Sprintf("%s-%04d-%04d", prefix, int(g.now.Unix()%10000), g.deviceCounter)
+	// タイムスタンプ（決定的モードではハッシュ）の下4桁を含めてユニーク性を保証
+	baseID := fmt.Sprintf("%s-%04d-%04d", prefix, int(g.idKey%10000), g.deviceCounter)
 	if g.dcSuffix != "" {
 		return fmt.Sprintf("%s%s%s", baseID, g.delimiter, g.dcSuffix)
 	}
@@ -87,8 +111,8 @@ func (g *topologyGenerator) generateDeviceID(prefix string) string {
 
 func (g *topologyGenerator) generateLinkID() string {
 	g.linkCounter++
-	// タイムスタンプを含めてユニーク性を保証
-	return fmt.Sprintf("link-%d-%06d", g.now.Unix(), g.linkCounter)
+	// タイムスタンプ（決定的モードではハッシュ）を含めてユニーク性を保証
+	return fmt.Sprintf("link-%d-%06d", g.idKey, g.linkCounter)
 }
 
 func (g *topologyGenerator) createDevice(id, deviceType, hardware string, layer int) topology.Device {
@@ -100,6 +124,7 @@ func (g *topologyGenerator) createDevice(id, deviceType, hardware string, layer
 		LayerID:      &layerID,
 		DeviceType:   "", // will be set by classification
 		ClassifiedBy: "", // will be set by classification
+		Provenance:   topology.ProvenanceImport,
 		Metadata: map[string]string{
 			"datacenter": "dc1",
 			"rack":       fmt.Sprintf("rack-%d", (g.deviceCounter/10)+1),
@@ -196,7 +221,7 @@ func (g *topologyGenerator) generateThreeTierTopology(numCore, numAggPerCore, nu
 	// Servers
 	if includeServers {
 		for _, accessDevice := range accessDevices {
-			serverCount := rand.Intn(16) + 8 // 8-23 servers per access switch
+			serverCount := g.rand.Intn(16) + 8 // 8-23 servers per access switch
 			for i := 0; i < serverCount; i++ {
 				device := g.createDevice(
 					g.generateDeviceID("server"),
@@ -269,7 +294,7 @@ func (g *topologyGenerator) generateSpineLeafTopology(numSpines, numLeavesPerSpi
 	// Servers
 	if includeServers {
 		for _, leafDevice := range leafDevices {
-			serverCount := rand.Intn(28) + 20 // 20-47 servers per leaf
+			serverCount := g.rand.Intn(28) + 20 // 20-47 servers per leaf
 			for i := 0; i < serverCount; i++ {
 				device := g.createDevice(
 					g.generateDeviceID("server"),
@@ -360,7 +385,7 @@ func (g *topologyGenerator) generateFatTreeTopology(coreSpines, aggSpinesPerCore
 	// Servers
 	if includeServers {
 		for _, edgeLeafDevice := range edgeLeafDevices {
-			serverCount := rand.Intn(31) + 30 // 30-60 servers per edge leaf
+			serverCount := g.rand.Intn(31) + 30 // 30-60 servers per edge leaf
 			for i := 0; i < serverCount; i++ {
 				device := g.createDevice(
 					g.generateDeviceID("server"),
@@ -418,7 +443,7 @@ func (g *topologyGenerator) generateMixedTopology(fatTreeScale, spineLeafScale,
 
 		// Border Leaf to Core Interconnect
 		if len(coreInterconnectDevices) > 0 {
-			coreDevice := coreInterconnectDevices[rand.Intn(len(coreInterconnectDevices))]
+			coreDevice := coreInterconnectDevices[g.rand.Intn(len(coreInterconnectDevices))]
 			link := g.createLink(
 				device.ID, coreDevice.ID,
 				"Ethernet49", fmt.Sprintf("Ethernet%d", i+1),
@@ -444,10 +469,10 @@ func (g *topologyGenerator) generateMixedTopology(fatTreeScale, spineLeafScale,
 		// Connect Fat-Tree core spines to DC core
 		for _, device := range ftDevices {
 			if device.Type == "core_spine" && len(coreInterconnectDevices) > 0 {
-				coreDevice := coreInterconnectDevices[rand.Intn(len(coreInterconnectDevices))]
+				coreDevice := coreInterconnectDevices[g.rand.Intn(len(coreInterconnectDevices))]
 				link := g.createLink(
 					device.ID, coreDevice.ID,
-					"Ethernet129", fmt.Sprintf("Ethernet%d", rand.Intn(32)+1),
+					"Ethernet129", fmt.Sprintf("Ethernet%d", g.rand.Intn(32)+1),
 					"L3_routed", "400G", 1.0,
 				)
 				allLinks = append(allLinks, link)
@@ -470,10 +495,10 @@ func (g *topologyGenerator) generateMixedTopology(fatTreeScale, spineLeafScale,
 		// Connect Spine-Leaf spines to DC core
 		for _, device := range slDevices {
 			if device.Type == "spine" && len(coreInterconnectDevices) > 0 {
-				coreDevice := coreInterconnectDevices[rand.Intn(len(coreInterconnectDevices))]
+				coreDevice := coreInterconnectDevices[g.rand.Intn(len(coreInterconnectDevices))]
 				link := g.createLink(
 					device.ID, coreDevice.ID,
-					"swp32", fmt.Sprintf("Ethernet%d", rand.Intn(32)+33),
+					"swp32", fmt.Sprintf("Ethernet%d", g.rand.Intn(32)+33),
 					"L3_routed", "100G", 1.0,
 				)
 				allLinks = append(allLinks, link)
@@ -497,10 +522,10 @@ func (g *topologyGenerator) generateMixedTopology(fatTreeScale, spineLeafScale,
 		// Connect Three-Tier cores to DC core
 		for _, device := range ttDevices {
 			if device.Type == "core" && len(coreInterconnectDevices) > 0 {
-				coreDevice := coreInterconnectDevices[rand.Intn(len(coreInterconnectDevices))]
+				coreDevice := coreInterconnectDevices[g.rand.Intn(len(coreInterconnectDevices))]
 				link := g.createLink(
 					device.ID, coreDevice.ID,
-					"Ethernet49", fmt.Sprintf("Ethernet%d", rand.Intn(32)+65),
+					"Ethernet49", fmt.Sprintf("Ethernet%d", g.rand.Intn(32)+65),
 					"L3_routed_legacy", "100G", 1.0,
 				)
 				allLinks = append(allLinks, link)
@@ -539,6 +564,11 @@ func runSeedDataEnhanced(cmd *cobra.Command, args []string) {
 
 	// Initialize generator
 	generator := newTopologyGenerator(dcLocation, locationDelimiter)
+	if deterministicSeed {
+		// 同じ ID で上書きされるため、再実行してもデバイスは増えない
+		generator = newDeterministicTopologyGenerator(dcLocation, locationDelimiter, topologyType)
+		log.Println("Deterministic mode: existing seed devices and links are updated in place")
+	}
 
 	var devices []topology.Device
 	var links []topology.Link
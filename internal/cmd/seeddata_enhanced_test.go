@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/repository/sqlite"
+)
+
+func seedIDs(devices []topology.Device, links []topology.Link) ([]string, []string) {
+	deviceIDs := make([]string, 0, len(devices))
+	for _, device := range devices {
+		deviceIDs = append(deviceIDs, device.ID)
+	}
+	linkIDs := make([]string, 0, len(links))
+	for _, link := range links {
+		linkIDs = append(linkIDs, link.SourceID+">"+link.TargetID+"#"+link.ID)
+	}
+	return deviceIDs, linkIDs
+}
+
+func TestDeterministicTopologyGenerator_StableIDs(t *testing.T) {
+	first, firstLinks := newDeterministicTopologyGenerator("tokyo", ".", "spine-leaf").generateSpineLeafTopology(2, 3, true)
+	second, secondLinks := newDeterministicTopologyGenerator("tokyo", ".", "spine-leaf").generateSpineLeafTopology(2, 3, true)
+
+	firstDevices, firstLinkIDs := seedIDs(first, firstLinks)
+	secondDevices, secondLinkIDs := seedIDs(second, secondLinks)
+	// サーバー台数も含めて毎回同じトポロジーになる
+	if !reflect.DeepEqual(firstDevices, secondDevices) {
+		t.Errorf("Expected the same device IDs on every run, got %d and %d devices", len(firstDevices), len(secondDevices))
+	}
+	if !reflect.DeepEqual(firstLinkIDs, secondLinkIDs) {
+		t.Errorf("Expected the same links on every run, got %d and %d links", len(firstLinkIDs), len(secondLinkIDs))
+	}
+	if !strings.HasSuffix(firstDevices[0], ".TOKYO") {
+		t.Errorf("Expected the location suffix, got %s", firstDevices[0])
+	}
+
+	other, _ := newDeterministicTopologyGenerator("tokyo", ".", "fat-tree").generateSpineLeafTopology(2, 3, true)
+	if other[0].ID == first[0].ID {
+		t.Errorf("Expected topology types to get distinct IDs, both got %s", first[0].ID)
+	}
+}
+
+func TestDeterministicTopologyGenerator_ReseedUpserts(t *testing.T) {
+	repo, err := repository.NewRepository(repository.Config{
+		Type:   "sqlite",
+		SQLite: sqlite.Config{Path: filepath.Join(t.TempDir(), "seed.db")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if err := repo.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate repository: %v", err)
+	}
+	ctx := context.Background()
+
+	var devices []topology.Device
+	for run := 0; run < 2; run++ {
+		var links []topology.Link
+		devices, links = newDeterministicTopologyGenerator("", "", "three-tier").generateThreeTierTopology(1, 2, 2, true)
+		if err := repo.BulkAddDevices(ctx, devices); err != nil {
+			t.Fatalf("Run %d: failed to add devices: %v", run, err)
+		}
+		if err := repo.BulkAddLinks(ctx, links); err != nil {
+			t.Fatalf("Run %d: failed to add links: %v", run, err)
+		}
+	}
+
+	// 再実行してもデバイスは増えない
+	_, pagination, err := repo.GetDevices(ctx, topology.PaginationOptions{Page: 1, PageSize: 1, Exact: true})
+	if err != nil {
+		t.Fatalf("Failed to count devices: %v", err)
+	}
+	if pagination.TotalCount != len(devices) {
+		t.Errorf("Expected re-seeding to keep %d devices, got %d", len(devices), pagination.TotalCount)
+	}
+}
+
+func TestTopologyGenerator_IDsIncludeRunTime(t *testing.T) {
+	// 通常モードは実行時刻を ID に含め、実行ごとに新しいデバイスを作る
+	g := newTopologyGenerator("", "")
+	expected := fmt.Sprintf("leaf-%04d-0001", g.now.Unix()%10000)
+	if id := g.generateDeviceID("leaf"); id != expected {
+		t.Errorf("Expected %s, got %s", expected, id)
+	}
+	expectedLink := fmt.Sprintf("link-%d-000001", g.now.Unix())
+	if id := g.generateLinkID(); id != expectedLink {
+		t.Errorf("Expected %s, got %s", expectedLink, id)
+	}
+}
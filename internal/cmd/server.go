@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/servak/topology-manager/internal/api"
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/prometheus"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/service"
+	"github.com/servak/topology-manager/internal/worker"
+	"github.com/servak/topology-manager/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serverPort           string
+	serverLogLevel       string
+	serverEnableWorker   bool
+	serverRequestTimeout int
+	serverShareSecret    string
+	serverPrometheusURL  string
+	serverReadOnly       bool
+)
+
+var serverCmd = &cobra.Command{
+	Use:   "server",
+	Short: "Start the API server together with the synchronization worker",
+	Long: `Start the REST API server and the Prometheus synchronization worker in one process,
+sharing the database connection. Database and Prometheus settings are read from the
+config file (tm.yaml); flags only override them when given.`,
+	Example: `  topology-manager server -c tm.yaml
+  topology-manager server --port 9000 --log-level debug
+  topology-manager server --enable-worker=false   # API only, like "api"
+  topology-manager server --read-only             # viewer; only the worker writes`,
+	RunE: runServer,
+}
+
+func init() {
+	serverCmd.Flags().StringVarP(&serverPort, "port", "p", "8080", "API server port")
+	serverCmd.Flags().StringVar(&serverLogLevel, "log-level", "info", "Log level (debug, info, warn, error); --verbose selects debug")
+	serverCmd.Flags().BoolVar(&serverEnableWorker, "enable-worker", true, "Run the Prometheus synchronization worker in the same process")
+	serverCmd.Flags().IntVar(&serverRequestTimeout, "request-timeout", int(api.DefaultRequestTimeout/time.Second), "Time budget of each API request in seconds (0 = no limit)")
+	serverCmd.Flags().BoolVar(&serverReadOnly, "read-only", false, "Reject all changes through the API (default: api.read_only from the config file)")
+	serverCmd.Flags().StringVar(&serverShareSecret, "share-secret", os.Getenv("TM_SHARE_SECRET"), "Key used to sign share links (default $TM_SHARE_SECRET; random if unset)")
+
+	// 設定ファイルの値を使い、明示されたときだけ上書きする
+	serverCmd.Flags().IntVar(&workerInterval, "interval", 300, "LLDP sync interval in seconds")
+	serverCmd.Flags().StringVar(&serverPrometheusURL, "prometheus-url", "", "Prometheus server URL (default: prometheus.url from the config file)")
+	addWorkerFlags(serverCmd)
+
+	rootCmd.AddCommand(serverCmd)
+}
+
+func runServer(cmd *cobra.Command, args []string) error {
+	logLevel := serverLogLevel
+	if verbose {
+		logLevel = "debug"
+	}
+	appLogger := logger.New(logLevel)
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cmd.Flags().Changed("prometheus-url") {
+		cfg.Prometheus.URL = serverPrometheusURL
+	}
+	if cmd.Flags().Changed("prometheus-timeout") {
+		cfg.Prometheus.Timeout = time.Duration(prometheusTimeout) * time.Second
+	}
+	if cmd.Flags().Changed("read-only") {
+		cfg.API.ReadOnly = serverReadOnly
+	}
+
+	// 起動後に設定ミスで落ちないよう、DB接続前に検証する
+	var workerConfig worker.PrometheusSyncConfig
+	if serverEnableWorker {
+		workerConfig, err = buildWorkerConfig(cmd, cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Health(ctx); err != nil {
+		return fmt.Errorf("database health check failed: %w", err)
+	}
+	appLogger.Info("Connected to database", "type", cfg.Database.Type)
+
+	// 実行中のスキーマバージョンを報告し、古いインスタンスが残る間は contract させない
+	role := "api"
+	if serverEnableWorker {
+		role = "server"
+	}
+	stopHeartbeat := startSchemaHeartbeat(repo, role, func(err error) {
+		appLogger.Warn("Failed to report schema version", "error", err)
+	})
+	defer stopHeartbeat()
+
+	if err := bootstrapOnStartup(ctx, repo, cfg, appLogger); err != nil {
+		return fmt.Errorf("failed to bootstrap: %w", err)
+	}
+
+	// API とワーカーで同じリポジトリ（接続プール）を共有する
+	server := api.NewServer(repo, repo, appLogger)
+	server.SetRequestTimeout(time.Duration(serverRequestTimeout) * time.Second)
+	server.SetPlaceholderDefaults(cfg.GetPlaceholderDefaults())
+	server.SetPortNamingLint(cfg.GetPortNamingLint())
+	server.SetMetadataSchema(cfg.GetMetadataSchema())
+	server.SetCoverageGate(cfg.GetCoverageGate())
+	server.SetOrphanedLinkPolicy(cfg.GetOrphanedLinkPolicy())
+	server.SetIdentityOptions(cfg.GetIdentityOptions())
+	if err := configureExports(server, cfg, appLogger); err != nil {
+		return fmt.Errorf("failed to configure exports: %w", err)
+	}
+	server.StartJobRunner(localInstanceID())
+	server.StartChangeListener()
+	server.StartViewRequestFlusher(service.DefaultViewRequestFlushInterval)
+	if serverShareSecret != "" {
+		server.SetShareSecret([]byte(serverShareSecret))
+	} else {
+		appLogger.Warn("No share secret configured; share links will stop working when the server restarts")
+	}
+	if err := configureAuth(server, cfg, appLogger); err != nil {
+		return fmt.Errorf("failed to configure authentication: %w", err)
+	}
+	promClient := prometheus.NewClient(cfg.GetPrometheusConfig())
+	configureMetricsProxy(server, promClient, cfg, appLogger)
+	configureReadOnly(server, cfg, appLogger)
+
+	stopWorker := func() {}
+	if serverEnableWorker {
+		workerLogger := appLogger.WithComponent("worker")
+		// Prometheus が停止中でも API は提供し、同期は次回以降に再試行させる
+		if err := promClient.Health(ctx); err != nil {
+			workerLogger.Warn("Prometheus health check failed; synchronization will retry on schedule", "url", cfg.Prometheus.URL, "error", err)
+		} else {
+			workerLogger.Info("Connected to Prometheus", "url", cfg.Prometheus.URL)
+		}
+
+		workerLog := slog.NewLogLogger(workerLogger.Handler(), slog.LevelInfo)
+		syncRepo, syncClient := withFaultInjection(cfg, repo, promClient, workerLog)
+		syncWorker := worker.NewPrometheusSync(syncClient, cfg.GetMetricsConfig(), syncRepo, repo, workerConfig, workerLog)
+		if err := syncWorker.Start(); err != nil {
+			return fmt.Errorf("failed to start worker: %w", err)
+		}
+		stopWorker = syncWorker.Stop
+		logWorkerConfig(workerLog, workerConfig)
+		server.SetSyncPreviewer(syncWorker)
+	} else {
+		appLogger.Info("Synchronization worker disabled")
+	}
+
+	httpServer := &http.Server{
+		Addr:    ":" + serverPort,
+		Handler: server.Handler(),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		appLogger.Info("Starting API server", "port", serverPort)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case sig := <-sigChan:
+		appLogger.Info("Shutting down server...", "signal", sig.String())
+	case err := <-serveErr:
+		stopWorker()
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// リポジトリは defer で最後に一度だけ閉じる
+	shutdownServer(shutdownCtx, httpServer, server, stopWorker, appLogger)
+
+	appLogger.Info("Server stopped")
+	return nil
+}
+
+// shutdowner is implemented by the HTTP server and the API server
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// shutdownServer stops accepting requests, waits for the requests in flight, then stops the
+// background work of the API server and the synchronization worker, so that nothing uses the
+// repository by the time the caller closes it
+func shutdownServer(ctx context.Context, httpServer, server shutdowner, stopWorker func(), appLogger *logger.Logger) {
+	if err := httpServer.Shutdown(ctx); err != nil {
+		appLogger.Error("Server shutdown error", "error", err)
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		appLogger.Error("Application shutdown error", "error", err)
+	}
+	stopWorker()
+}
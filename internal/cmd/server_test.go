@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/servak/topology-manager/pkg/logger"
+)
+
+// recordingShutdowner appends its name to the shutdown sequence
+type recordingShutdowner struct {
+	name  string
+	steps *[]string
+}
+
+func (r recordingShutdowner) Shutdown(ctx context.Context) error {
+	*r.steps = append(*r.steps, r.name)
+	return nil
+}
+
+func TestShutdownServer_StopsWorkerBeforeRepositoryCloses(t *testing.T) {
+	var steps []string
+	closeRepo := func() { steps = append(steps, "repository") }
+
+	func() {
+		// runServer と同じく、リポジトリは defer で閉じる
+		defer closeRepo()
+		shutdownServer(context.Background(),
+			recordingShutdowner{name: "http", steps: &steps},
+			recordingShutdowner{name: "api", steps: &steps},
+			func() { steps = append(steps, "worker") },
+			logger.New("error"))
+	}()
+
+	expected := []string{"http", "api", "worker", "repository"}
+	if !reflect.DeepEqual(steps, expected) {
+		t.Errorf("Expected shutdown order %v, got %v", expected, steps)
+	}
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/prometheus"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/worker"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bootstrapSnapshotForce         bool
+	bootstrapSnapshotVerifyOnly    bool
+	bootstrapSnapshotSkipVerify    bool
+	bootstrapSnapshotSync          bool
+	bootstrapSnapshotMaxDrift      float64
+	bootstrapSnapshotPrometheusURL string
+)
+
+var bootstrapSnapshotCmd = &cobra.Command{
+	Use:   "bootstrap-snapshot <snapshot.json[.gz]>",
+	Short: "Load a snapshot export into an empty database before the first sync",
+	Long: `Load a previously exported snapshot (format=snapshot, optionally gzip-compressed)
+into an empty database, so that the first sync of a very large fabric only has
+to apply what changed since the snapshot was taken.
+
+Before anything is written the snapshot is verified against Prometheus:
+Prometheus is queried at the snapshot timestamp and the devices and links it
+reported are compared with the snapshot. Devices and links last seen more than
+the Prometheus lookback before the timestamp are not expected to be reported.
+The snapshot is rejected when it disagrees on more than --max-drift of its
+devices and links, when its timestamp is outside the Prometheus retention, or
+when it is older than --max-device-age / --max-link-age (cleanup would expire
+it before a sync refreshes it). --force loads it anyway, also into a database
+that already has devices.
+
+Devices and links keep their provenance and last seen time, so the worker
+takes over with incremental syncs.`,
+	Example: `  topology-manager worker bootstrap-snapshot snapshot-20250301T090000Z.json --verify-only
+  topology-manager worker bootstrap-snapshot snapshot-20250301T090000Z.json.gz --sync`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBootstrapSnapshot,
+}
+
+func init() {
+	bootstrapSnapshotCmd.Flags().BoolVar(&bootstrapSnapshotForce, "force", false, "Load even if verification fails or the database already has devices")
+	bootstrapSnapshotCmd.Flags().BoolVar(&bootstrapSnapshotVerifyOnly, "verify-only", false, "Verify the snapshot against Prometheus without loading it")
+	bootstrapSnapshotCmd.Flags().BoolVar(&bootstrapSnapshotSkipVerify, "skip-verify", false, "Load without verifying the snapshot against Prometheus")
+	bootstrapSnapshotCmd.Flags().BoolVar(&bootstrapSnapshotSync, "sync", false, "Run one incremental sync after loading")
+	bootstrapSnapshotCmd.Flags().Float64Var(&bootstrapSnapshotMaxDrift, "max-drift", topology.DefaultMaxSnapshotDrift, "Share of devices and links the snapshot may disagree with Prometheus on (0-1)")
+	addOutputFlag(bootstrapSnapshotCmd)
+	addDeprecatedFormatFlag(bootstrapSnapshotCmd)
+	bootstrapSnapshotCmd.ValidArgsFunction = completeFileArg("json", "gz")
+	bootstrapSnapshotCmd.Flags().StringVar(&bootstrapSnapshotPrometheusURL, "prometheus-url", "", "Prometheus server URL (default: prometheus.url from the config file)")
+	addWorkerFlags(bootstrapSnapshotCmd)
+
+	workerCmd.AddCommand(bootstrapSnapshotCmd)
+}
+
+func runBootstrapSnapshot(cmd *cobra.Command, args []string) error {
+	output, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+	if bootstrapSnapshotMaxDrift < 0 || bootstrapSnapshotMaxDrift > 1 {
+		return fmt.Errorf("--max-drift must be between 0 and 1")
+	}
+	if bootstrapSnapshotVerifyOnly && bootstrapSnapshotSkipVerify {
+		return fmt.Errorf("--verify-only and --skip-verify cannot be combined")
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", args[0], err)
+	}
+	defer file.Close()
+	snapshot, err := topology.ReadSnapshot(file)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot %s: %w", args[0], err)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if bootstrapSnapshotPrometheusURL != "" {
+		cfg.Prometheus.URL = bootstrapSnapshotPrometheusURL
+	}
+	if cmd.Flags().Changed("prometheus-timeout") {
+		cfg.Prometheus.Timeout = time.Duration(prometheusTimeout) * time.Second
+	}
+
+	workerConfig, err := buildWorkerConfig(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Health(ctx); err != nil {
+		return fmt.Errorf("database health check failed: %w", err)
+	}
+
+	promConfig := cfg.GetPrometheusConfig()
+	promClient := prometheus.NewClient(promConfig)
+	if !bootstrapSnapshotSkipVerify || bootstrapSnapshotSync {
+		if err := promClient.Health(ctx); err != nil {
+			return fmt.Errorf("prometheus health check failed: %w", err)
+		}
+	}
+
+	// 結果の出力を汚さないよう、ログは標準エラーに出す
+	logger := log.New(os.Stderr, "[BOOTSTRAP] ", log.LstdFlags)
+	syncWorker := worker.NewPrometheusSync(promClient, cfg.GetMetricsConfig(), repo, repo, workerConfig, logger)
+
+	result, err := syncWorker.BootstrapFromSnapshot(ctx, snapshot, worker.SnapshotBootstrapOptions{
+		Lookback:   promConfig.Compatibility.LookbackDelta,
+		MaxDrift:   bootstrapSnapshotMaxDrift,
+		SkipVerify: bootstrapSnapshotSkipVerify,
+		Force:      bootstrapSnapshotForce,
+		VerifyOnly: bootstrapSnapshotVerifyOnly,
+		Sync:       bootstrapSnapshotSync,
+	})
+	if result != nil {
+		if printErr := printSnapshotBootstrap(output, result); printErr != nil {
+			return printErr
+		}
+	}
+	return err
+}
+
+func printSnapshotBootstrap(output string, result *topology.SnapshotBootstrapResult) error {
+	if output != outputTable {
+		return printOutput(output, result)
+	}
+
+	if v := result.Verification; v != nil {
+		fmt.Printf("Snapshot taken at %s (%s old): %d devices, %d links\n",
+			v.TakenAt.Format(time.RFC3339), time.Duration(v.AgeSeconds)*time.Second, v.Devices, v.Links)
+		fmt.Printf("Prometheus at snapshot time: %d devices, %d links\n", v.ObservedDevices, v.ObservedLinks)
+		fmt.Printf("Missing from Prometheus: %d devices, %d links\n", len(v.MissingDevices), len(v.MissingLinks))
+		fmt.Printf("Missing from snapshot: %d devices, %d links\n", len(v.UnexpectedDevices), len(v.UnexpectedLinks))
+		fmt.Printf("Drift: %.1f%%\n", v.Drift*100)
+		for _, problem := range v.Problems {
+			fmt.Printf("Problem: %s\n", problem)
+		}
+	}
+	if result.Devices > 0 || result.Links > 0 {
+		fmt.Printf("Loaded %d devices and %d links\n", result.Devices, result.Links)
+	}
+	if result.Synced {
+		fmt.Println("Incremental sync completed")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/prometheus"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/worker"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncDryRun        bool
+	syncPrometheusURL string
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Run one topology synchronization from Prometheus",
+	Long: `Run one synchronization of devices and LLDP links from Prometheus and exit.
+
+With --dry-run nothing is written: devices and links are extracted and compared
+with the database, and the devices and links that would be added, updated or
+no longer reported are printed. Run it before pointing the worker at a new
+Prometheus. Database and Prometheus settings are read from the config file.`,
+	Example: `  topology-manager sync --dry-run
+  topology-manager sync --dry-run -o json --prometheus-url http://prom.example:9090
+  topology-manager sync --enable-auto-classify=false`,
+	RunE: runSync,
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "Print what would change without writing anything")
+	addOutputFlag(syncCmd)
+	addDeprecatedFormatFlag(syncCmd)
+	syncCmd.Flags().StringVar(&syncPrometheusURL, "prometheus-url", "", "Prometheus server URL (default: prometheus.url from the config file)")
+	addWorkerFlags(syncCmd)
+
+	rootCmd.AddCommand(syncCmd)
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	// --output はドライランの差分にのみ適用される
+	output, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cmd.Flags().Changed("prometheus-url") {
+		cfg.Prometheus.URL = syncPrometheusURL
+	}
+	if cmd.Flags().Changed("prometheus-timeout") {
+		cfg.Prometheus.Timeout = time.Duration(prometheusTimeout) * time.Second
+	}
+
+	workerConfig, err := buildWorkerConfig(cmd, cfg)
+	if err != nil {
+		return err
+	}
+
+	repo, err := repository.NewRepository(cfg.GetDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Health(ctx); err != nil {
+		return fmt.Errorf("database health check failed: %w", err)
+	}
+
+	promClient := prometheus.NewClient(cfg.GetPrometheusConfig())
+	if err := promClient.Health(ctx); err != nil {
+		return fmt.Errorf("prometheus health check failed: %w", err)
+	}
+
+	// ドライランの出力を汚さないよう、ログは標準エラーに出す
+	logger := log.New(os.Stderr, "[SYNC] ", log.LstdFlags)
+	syncRepo, syncClient := withFaultInjection(cfg, repo, promClient, logger)
+	syncWorker := worker.NewPrometheusSync(syncClient, cfg.GetMetricsConfig(), syncRepo, repo, workerConfig, logger)
+
+	if !syncDryRun {
+		return syncWorker.RunOnce(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, workerConfig.SyncTimeout)
+	defer cancel()
+	diff, err := syncWorker.DryRun(ctx)
+	if err != nil {
+		return fmt.Errorf("dry run failed: %w", err)
+	}
+
+	if output != outputTable {
+		return printOutput(output, diff)
+	}
+	return diff.WriteText(os.Stdout)
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/servak/topology-manager/internal/config"
 	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
 	"github.com/servak/topology-manager/internal/prometheus"
 	"github.com/servak/topology-manager/internal/repository"
 	"github.com/servak/topology-manager/internal/worker"
@@ -32,6 +35,36 @@ var (
 	maxDeviceAge       int
 	maxLinkAge         int
 	prometheusTimeout  int
+
+	compactionInterval      int
+	enableCompaction        bool
+	historyRawRetention     int
+	historySummaryRetention int
+	syncStatsRetention      int
+
+	layoutPrecomputeInterval int
+	enableLayoutPrecompute   bool
+	layoutPrecomputeViews    int
+
+	schemaBackfillInterval  int
+	enableSchemaBackfill    bool
+	schemaBackfillBatchSize int
+
+	maxDevices int
+	maxLinks   int
+
+	enableMACSync  bool
+	maxMACsPerPort int
+
+	enableMgmtCheck   bool
+	mgmtCheckInterval int
+	mgmtAddressKey    string
+	mgmtTimeout       int
+
+	enableSuggestions          bool
+	suggestionInterval         int
+	suggestionNotifyConfidence float64
+	suggestionWebhookURL       string
 )
 
 var workerCmd = &cobra.Command{
@@ -45,24 +78,51 @@ func init() {
 	// Required flags
 	workerCmd.Flags().IntVarP(&workerInterval, "interval", "i", 300, "LLDP sync interval in seconds")
 	workerCmd.Flags().StringVarP(&prometheusURL, "prometheus-url", "p", "http://localhost:9090", "Prometheus server URL")
+	addWorkerFlags(workerCmd)
+
+	// Add to root command
+	rootCmd.AddCommand(workerCmd)
+}
 
+// addWorkerFlags registers the worker tuning flags and feature toggles shared by worker and server
+func addWorkerFlags(cmd *cobra.Command) {
 	// Optional flags with defaults
-	workerCmd.Flags().IntVar(&deviceInterval, "device-interval", 600, "Device info sync interval in seconds")
-	workerCmd.Flags().IntVar(&cleanupInterval, "cleanup-interval", 3600, "Data cleanup interval in seconds")
-	workerCmd.Flags().IntVar(&batchSize, "batch-size", 100, "Batch size for bulk operations")
-	workerCmd.Flags().IntVar(&syncTimeout, "sync-timeout", 600, "Sync operation timeout in seconds")
-	workerCmd.Flags().IntVar(&prometheusTimeout, "prometheus-timeout", 30, "Prometheus query timeout in seconds")
-	workerCmd.Flags().IntVar(&maxDeviceAge, "max-device-age", 86400, "Maximum device age in seconds before cleanup")
-	workerCmd.Flags().IntVar(&maxLinkAge, "max-link-age", 43200, "Maximum link age in seconds before cleanup")
+	cmd.Flags().IntVar(&deviceInterval, "device-interval", 600, "Device info sync interval in seconds")
+	cmd.Flags().IntVar(&cleanupInterval, "cleanup-interval", 3600, "Data cleanup interval in seconds")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 100, "Batch size for bulk operations")
+	cmd.Flags().IntVar(&syncTimeout, "sync-timeout", 600, "Sync operation timeout in seconds")
+	cmd.Flags().IntVar(&prometheusTimeout, "prometheus-timeout", 30, "Prometheus query timeout in seconds")
+	cmd.Flags().IntVar(&maxDeviceAge, "max-device-age", 86400, "Maximum device age in seconds before cleanup")
+	cmd.Flags().IntVar(&maxLinkAge, "max-link-age", 43200, "Maximum link age in seconds before cleanup")
+	cmd.Flags().IntVar(&compactionInterval, "compaction-interval", 86400, "Link history compaction interval in seconds")
+	cmd.Flags().IntVar(&historyRawRetention, "history-raw-retention", 30, "Days to keep raw link history before compacting into daily summaries (0 = keep forever)")
+	cmd.Flags().IntVar(&historySummaryRetention, "history-summary-retention", 365, "Days to keep daily link history summaries (0 = keep forever)")
+	cmd.Flags().IntVar(&syncStatsRetention, "sync-stats-retention", 30, "Days to keep the per-cycle topology change counts (0 = keep forever)")
+	cmd.Flags().IntVar(&layoutPrecomputeInterval, "layout-precompute-interval", 900, "Layout precomputation interval in seconds")
+	cmd.Flags().IntVar(&layoutPrecomputeViews, "layout-precompute-views", 20, "Number of most requested views whose layouts are precomputed")
+	cmd.Flags().IntVar(&schemaBackfillInterval, "schema-backfill-interval", 60, "Interval in seconds of the backfills required by pending contract migrations")
+	cmd.Flags().IntVar(&schemaBackfillBatchSize, "schema-backfill-batch-size", 1000, "Rows updated per schema backfill transaction")
+	cmd.Flags().IntVar(&maxDevices, "max-devices", 0, fmt.Sprintf("Maximum number of stored devices; new devices over it are skipped (0 = no limit, SQLite default %d)", worker.SQLiteDefaultMaxDevices))
+	cmd.Flags().IntVar(&maxLinks, "max-links", 0, fmt.Sprintf("Maximum number of links ingested per sync (0 = no limit, SQLite default %d)", worker.SQLiteDefaultMaxLinks))
+	cmd.Flags().IntVar(&maxMACsPerPort, "max-macs-per-port", topology.DefaultMaxMACsPerPort, "Switch ports that learned more MAC addresses are treated as uplinks when inferring server ports")
+	cmd.Flags().IntVar(&mgmtCheckInterval, "mgmt-check-interval", 900, "Management reachability check interval in seconds")
+	cmd.Flags().StringVar(&mgmtAddressKey, "mgmt-address-key", topology.DefaultManagementAddressKey, "Device metadata key holding the management address (devices without it are probed at the host of their ID)")
+	cmd.Flags().IntVar(&mgmtTimeout, "mgmt-timeout", int(topology.DefaultManagementTimeout/time.Second), "Timeout in seconds of each SSH/NETCONF connection attempt")
+	cmd.Flags().IntVar(&suggestionInterval, "suggestion-interval", 3600, "Classification rule suggestion generation interval in seconds")
+	cmd.Flags().Float64Var(&suggestionNotifyConfidence, "suggestion-notify-confidence", classification.DefaultSuggestionNotifyConfidence, "Minimum confidence (0-1) of the new suggestions that are notified")
+	cmd.Flags().StringVar(&suggestionWebhookURL, "suggestion-webhook-url", "", "URL receiving a POST of the new high-confidence suggestions (empty = only logged)")
 
 	// Feature toggles
-	workerCmd.Flags().BoolVar(&enableLLDPSync, "enable-lldp", true, "Enable LLDP topology synchronization")
-	workerCmd.Flags().BoolVar(&enableDeviceSync, "enable-device", true, "Enable device info synchronization")
-	workerCmd.Flags().BoolVar(&enableCleanup, "enable-cleanup", true, "Enable old data cleanup")
-	workerCmd.Flags().BoolVar(&enableAutoClassify, "enable-auto-classify", true, "Enable automatic device classification")
-
-	// Add to root command
-	rootCmd.AddCommand(workerCmd)
+	cmd.Flags().BoolVar(&enableLLDPSync, "enable-lldp", true, "Enable LLDP topology synchronization")
+	cmd.Flags().BoolVar(&enableDeviceSync, "enable-device", true, "Enable device info synchronization")
+	cmd.Flags().BoolVar(&enableCleanup, "enable-cleanup", true, "Enable old data cleanup")
+	cmd.Flags().BoolVar(&enableAutoClassify, "enable-auto-classify", true, "Enable automatic device classification")
+	cmd.Flags().BoolVar(&enableCompaction, "enable-compaction", true, "Enable link history retention and compaction")
+	cmd.Flags().BoolVar(&enableLayoutPrecompute, "enable-layout-precompute", true, "Enable layout precomputation for frequently requested views")
+	cmd.Flags().BoolVar(&enableSchemaBackfill, "enable-schema-backfill", true, "Enable schema backfills for online (expand/contract) migrations")
+	cmd.Flags().BoolVar(&enableMACSync, "enable-mac-sync", false, "Enable server-to-port inference from MAC/ARP table metrics (requires prometheus.metrics_mapping.mac_table)")
+	cmd.Flags().BoolVar(&enableMgmtCheck, "enable-mgmt-check", false, "Enable TCP checks of SSH (22) and NETCONF (830) on the management addresses of monitored devices")
+	cmd.Flags().BoolVar(&enableSuggestions, "enable-suggestions", false, "Enable scheduled classification rule suggestions from manual classifications (not suggesting rejected ones again)")
 }
 
 func runWorker(cmd *cobra.Command, args []string) error {
@@ -79,24 +139,9 @@ func runWorker(cmd *cobra.Command, args []string) error {
 	cfg.Prometheus.URL = prometheusURL
 	cfg.Prometheus.Timeout = time.Duration(prometheusTimeout) * time.Second
 
-	// Create worker configuration from CLI flags
-	workerConfig := worker.PrometheusSyncConfig{
-		LLDPSyncInterval:   time.Duration(workerInterval) * time.Second,
-		DeviceSyncInterval: time.Duration(deviceInterval) * time.Second,
-		CleanupInterval:    time.Duration(cleanupInterval) * time.Second,
-		EnableLLDPSync:     enableLLDPSync,
-		EnableDeviceSync:   enableDeviceSync,
-		EnableCleanup:      enableCleanup,
-		EnableAutoClassify: enableAutoClassify,
-		MaxDeviceAge:       time.Duration(maxDeviceAge) * time.Second,
-		MaxLinkAge:         time.Duration(maxLinkAge) * time.Second,
-		BatchSize:          batchSize,
-		SyncTimeout:        time.Duration(syncTimeout) * time.Second,
-	}
-
-	// Validate worker configuration
-	if err := validateWorkerConfig(workerConfig); err != nil {
-		return fmt.Errorf("invalid worker configuration: %w", err)
+	workerConfig, err := buildWorkerConfig(cmd, cfg)
+	if err != nil {
+		return err
 	}
 
 	// Create database repository
@@ -113,6 +158,11 @@ func runWorker(cmd *cobra.Command, args []string) error {
 	}
 	logger.Printf("Connected to %s database", cfg.Database.Type)
 
+	stopHeartbeat := startSchemaHeartbeat(repo, "worker", func(err error) {
+		logger.Printf("Failed to report schema version: %v", err)
+	})
+	defer stopHeartbeat()
+
 	// Create Prometheus client
 	promClient := prometheus.NewClient(cfg.GetPrometheusConfig())
 
@@ -135,7 +185,8 @@ func runWorker(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create and start worker
-	worker := worker.NewPrometheusSync(promClient, appConfig.GetMetricsConfig(), repo, classificationRepo, workerConfig, logger)
+	syncRepo, syncClient := withFaultInjection(cfg, repo, promClient, logger)
+	worker := worker.NewPrometheusSync(syncClient, appConfig.GetMetricsConfig(), syncRepo, classificationRepo, workerConfig, logger)
 
 	if err := worker.Start(); err != nil {
 		return fmt.Errorf("failed to start worker: %w", err)
@@ -158,6 +209,85 @@ func runWorker(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// buildWorkerConfig creates the worker configuration from the worker flags of cmd
+func buildWorkerConfig(cmd *cobra.Command, cfg *config.Config) (worker.PrometheusSyncConfig, error) {
+	workerConfig := worker.PrometheusSyncConfig{
+		LLDPSyncInterval:   time.Duration(workerInterval) * time.Second,
+		DeviceSyncInterval: time.Duration(deviceInterval) * time.Second,
+		CleanupInterval:    time.Duration(cleanupInterval) * time.Second,
+		CompactionInterval: time.Duration(compactionInterval) * time.Second,
+		EnableLLDPSync:     enableLLDPSync,
+		EnableDeviceSync:   enableDeviceSync,
+		EnableCleanup:      enableCleanup,
+		EnableAutoClassify: enableAutoClassify,
+		EnableCompaction:   enableCompaction,
+		MaxDeviceAge:       time.Duration(maxDeviceAge) * time.Second,
+		MaxLinkAge:         time.Duration(maxLinkAge) * time.Second,
+		BatchSize:          batchSize,
+		SyncTimeout:        time.Duration(syncTimeout) * time.Second,
+
+		LinkHistoryRawRetention:     time.Duration(historyRawRetention) * 24 * time.Hour,
+		LinkHistorySummaryRetention: time.Duration(historySummaryRetention) * 24 * time.Hour,
+		SyncStatsRetention:          time.Duration(syncStatsRetention) * 24 * time.Hour,
+
+		LayoutPrecomputeInterval: time.Duration(layoutPrecomputeInterval) * time.Second,
+		EnableLayoutPrecompute:   enableLayoutPrecompute,
+		LayoutPrecomputeViews:    layoutPrecomputeViews,
+
+		SchemaBackfillInterval:  time.Duration(schemaBackfillInterval) * time.Second,
+		EnableSchemaBackfill:    enableSchemaBackfill,
+		SchemaBackfillBatchSize: schemaBackfillBatchSize,
+
+		Quota: topology.IngestQuota{
+			MaxDevices: maxDevices,
+			MaxLinks:   maxLinks,
+		},
+
+		Placeholder:    cfg.GetPlaceholderDefaults(),
+		TagRules:       cfg.GetTagRules(),
+		MetadataSchema: cfg.GetMetadataSchema(),
+		OrphanLinks:    cfg.GetOrphanedLinkPolicy(),
+
+		EnableMACSync: enableMACSync,
+		MACTable:      topology.MACInferenceOptions{MaxMACsPerPort: maxMACsPerPort},
+
+		EnableMgmtCheck:   enableMgmtCheck,
+		MgmtCheckInterval: time.Duration(mgmtCheckInterval) * time.Second,
+		MgmtCheck: topology.ManagementCheckOptions{
+			AddressKey: mgmtAddressKey,
+			Timeout:    time.Duration(mgmtTimeout) * time.Second,
+		},
+
+		EnableSuggestions:          enableSuggestions,
+		SuggestionInterval:         time.Duration(suggestionInterval) * time.Second,
+		SuggestionNotifyConfidence: suggestionNotifyConfidence,
+		SuggestionWebhookURL:       suggestionWebhookURL,
+	}
+
+	if enableMACSync {
+		if _, exists := cfg.Prometheus.MetricsMapping[prometheus.MACTableMappingKey]; !exists {
+			return worker.PrometheusSyncConfig{}, fmt.Errorf("--enable-mac-sync requires prometheus.metrics_mapping.%s in the config file", prometheus.MACTableMappingKey)
+		}
+	}
+
+	// 小規模なSQLite環境を巨大なPrometheusから守るため、明示しない限り上限を設ける
+	if cfg.Database.Type == "sqlite" {
+		if !cmd.Flags().Changed("max-devices") {
+			workerConfig.Quota.MaxDevices = worker.SQLiteDefaultMaxDevices
+		}
+		if !cmd.Flags().Changed("max-links") {
+			workerConfig.Quota.MaxLinks = worker.SQLiteDefaultMaxLinks
+		}
+	}
+
+	// Validate worker configuration
+	if err := validateWorkerConfig(workerConfig); err != nil {
+		return worker.PrometheusSyncConfig{}, fmt.Errorf("invalid worker configuration: %w", err)
+	}
+
+	return workerConfig, nil
+}
+
 func validateWorkerConfig(config worker.PrometheusSyncConfig) error {
 	if config.LLDPSyncInterval <= 0 {
 		return fmt.Errorf("LLDP sync interval must be positive")
@@ -180,6 +310,61 @@ func validateWorkerConfig(config worker.PrometheusSyncConfig) error {
 	if config.MaxLinkAge <= 0 {
 		return fmt.Errorf("max link age must be positive")
 	}
+	if config.EnableCompaction && config.CompactionInterval <= 0 {
+		return fmt.Errorf("compaction interval must be positive")
+	}
+	if config.Quota.MaxDevices < 0 || config.Quota.MaxLinks < 0 {
+		return fmt.Errorf("device and link quotas must not be negative")
+	}
+	if config.LinkHistoryRawRetention < 0 || config.LinkHistorySummaryRetention < 0 {
+		return fmt.Errorf("link history retention must not be negative")
+	}
+	if config.LinkHistorySummaryRetention > 0 && config.LinkHistorySummaryRetention < config.LinkHistoryRawRetention {
+		return fmt.Errorf("link history summary retention must be longer than raw retention")
+	}
+	if config.SyncStatsRetention < 0 {
+		return fmt.Errorf("sync stats retention must not be negative")
+	}
+	if config.EnableLayoutPrecompute {
+		if config.LayoutPrecomputeInterval <= 0 {
+			return fmt.Errorf("layout precompute interval must be positive")
+		}
+		if config.LayoutPrecomputeViews <= 0 {
+			return fmt.Errorf("layout precompute views must be positive")
+		}
+	}
+
+	if config.EnableSchemaBackfill {
+		if config.SchemaBackfillInterval <= 0 {
+			return fmt.Errorf("schema backfill interval must be positive")
+		}
+		if config.SchemaBackfillBatchSize <= 0 {
+			return fmt.Errorf("schema backfill batch size must be positive")
+		}
+	}
+
+	if config.EnableMgmtCheck {
+		if config.MgmtCheckInterval <= 0 {
+			return fmt.Errorf("management reachability check interval must be positive")
+		}
+		if config.MgmtCheck.Timeout <= 0 {
+			return fmt.Errorf("management reachability timeout must be positive")
+		}
+	}
+
+	if config.EnableSuggestions {
+		if config.SuggestionInterval <= 0 {
+			return fmt.Errorf("suggestion interval must be positive")
+		}
+		if config.SuggestionNotifyConfidence < 0 || config.SuggestionNotifyConfidence > 1 {
+			return fmt.Errorf("suggestion notify confidence must be between 0 and 1")
+		}
+		if config.SuggestionWebhookURL != "" {
+			if u, err := url.Parse(config.SuggestionWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("suggestion webhook URL must be an http or https URL")
+			}
+		}
+	}
 
 	// Sanity checks
 	if config.LLDPSyncInterval < 30*time.Second {
@@ -205,4 +390,40 @@ func logWorkerConfig(logger *log.Logger, config worker.PrometheusSyncConfig) {
 	logger.Printf("  Sync Timeout: %s", config.SyncTimeout)
 	logger.Printf("  Max Device Age: %s", config.MaxDeviceAge)
 	logger.Printf("  Max Link Age: %s", config.MaxLinkAge)
+	logger.Printf("  Link History Compaction: %s (enabled: %t)", config.CompactionInterval, config.EnableCompaction)
+	logger.Printf("  Link History Retention: raw %s, summary %s", config.LinkHistoryRawRetention, config.LinkHistorySummaryRetention)
+	logger.Printf("  Sync Stats Retention: %s", config.SyncStatsRetention)
+	logger.Printf("  Layout Precompute: %s, top %d views (enabled: %t)", config.LayoutPrecomputeInterval, config.LayoutPrecomputeViews, config.EnableLayoutPrecompute)
+	logger.Printf("  Schema Backfill: %s, %d rows per batch (enabled: %t)", config.SchemaBackfillInterval, config.SchemaBackfillBatchSize, config.EnableSchemaBackfill)
+	logger.Printf("  Quota: max devices %s, max links %s", formatLimit(config.Quota.MaxDevices), formatLimit(config.Quota.MaxLinks))
+	logger.Printf("  MAC Table Sync: enabled: %t (max %d MACs per server port)", config.EnableMACSync, config.MACTable.MaxMACsPerPort)
+	logger.Printf("  Management Reachability: %s, address from metadata.%s (enabled: %t)", config.MgmtCheckInterval, config.MgmtCheck.AddressKey, config.EnableMgmtCheck)
+	logger.Printf("  Suggestions: %s, notify from confidence %.2f%s (enabled: %t)", config.SuggestionInterval, config.SuggestionNotifyConfidence, formatWebhook(config.SuggestionWebhookURL), config.EnableSuggestions)
+	logger.Printf("  Tag Rules: %d", len(config.TagRules))
+	logger.Printf("  Links of Deleted Devices: dropped for %s (enabled: %t)", config.OrphanLinks.WithDefaults().GracePeriod, !config.OrphanLinks.Disabled)
+	placeholder := config.Placeholder.NewPlaceholder("", time.Time{})
+	logger.Printf("  Placeholders: type %s, hardware %s, layer %s", placeholder.Type, placeholder.Hardware, formatLayer(placeholder.LayerID))
+}
+
+func formatLayer(layerID *int) string {
+	if layerID == nil {
+		return "from classification"
+	}
+	return strconv.Itoa(*layerID)
+}
+
+// formatWebhook shows the host of the webhook only, as webhook URLs often embed a token
+func formatWebhook(webhookURL string) string {
+	u, err := url.Parse(webhookURL)
+	if webhookURL == "" || err != nil {
+		return ""
+	}
+	return " to webhook at " + u.Host
+}
+
+func formatLimit(limit int) string {
+	if limit <= 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(limit)
 }
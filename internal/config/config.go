@@ -7,6 +7,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/servak/topology-manager/internal/auth"
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/faultinject"
 	"github.com/servak/topology-manager/internal/prometheus"
 	"github.com/servak/topology-manager/internal/repository"
 	"github.com/servak/topology-manager/internal/repository/postgres"
@@ -16,9 +20,54 @@ import (
 
 // Config represents the main application configuration
 type Config struct {
-	Hierarchy  HierarchyConfig   `yaml:"hierarchy"`
-	Database   repository.Config `yaml:"database"`
-	Prometheus PrometheusConfig  `yaml:"prometheus"`
+	Hierarchy      HierarchyConfig      `yaml:"hierarchy"`
+	Database       repository.Config    `yaml:"database"`
+	Prometheus     PrometheusConfig     `yaml:"prometheus"`
+	Classification ClassificationConfig `yaml:"classification"`
+	Auth           auth.Config          `yaml:"auth"`
+	Sync           SyncConfig           `yaml:"sync"`
+	API            APIConfig            `yaml:"api"`
+	Lint           LintConfig           `yaml:"lint"`
+}
+
+// APIConfig holds settings of the API server shared by every instance of a deployment
+type APIConfig struct {
+	ReadOnly bool          `yaml:"read_only"` // Reject all changes through the API; synchronization is the only writer
+	Exports  ExportsConfig `yaml:"exports"`
+}
+
+// ExportsConfig holds where export jobs store their files
+type ExportsConfig struct {
+	Dir       string        `yaml:"dir"`       // Directory shared by every API instance (empty = exports disabled)
+	Retention time.Duration `yaml:"retention"` // How long export files are kept (0 = 168h)
+}
+
+// SyncConfig holds settings of the data written by the synchronization worker
+type SyncConfig struct {
+	Placeholder    topology.PlaceholderDefaults `yaml:"placeholder"`     // Attributes of devices only seen as LLDP neighbors
+	Identity       topology.IdentityOptions     `yaml:"identity"`        // Metadata keys matched across sources to find the same device
+	OrphanLinks    topology.OrphanedLinkPolicy  `yaml:"orphan_links"`    // Links still reported for deleted devices
+	FaultInjection faultinject.Config           `yaml:"fault_injection"` // Test only: requires a binary built with -tags chaos
+}
+
+// ClassificationConfig holds device classification settings
+type ClassificationConfig struct {
+	Coverage  CoverageConfig           `yaml:"coverage"`
+	Bootstrap BootstrapConfig          `yaml:"bootstrap"`
+	TagRules  []classification.TagRule `yaml:"tag_rules"` // 同期時にデバイスのメタデータへタグを付けるルール（上から順に評価）
+}
+
+// BootstrapConfig declares the hierarchy layers and starter rules installed by tm bootstrap
+type BootstrapConfig struct {
+	OnStartup bool                            `yaml:"on_startup"` // api / server の起動時にも適用する
+	Layers    []classification.BootstrapLayer `yaml:"layers"`     // 空の場合は既定（border, core, spine, leaf, access, server）
+	Rules     []classification.BootstrapRule  `yaml:"rules"`      // 省略時は既定、[] でルールなし
+}
+
+// CoverageConfig defines the classification coverage gate
+type CoverageConfig struct {
+	Threshold   *float64 `yaml:"threshold"`    // Required coverage in percent (0-100, nil = DefaultCoverageThreshold)
+	DeviceTypes []string `yaml:"device_types"` // Device types in scope (empty = all devices)
 }
 
 // PrometheusConfig holds Prometheus configuration
@@ -27,6 +76,18 @@ type PrometheusConfig struct {
 	Timeout           time.Duration                           `yaml:"timeout"`
 	MetricsMapping    map[string]prometheus.MetricConfigGroup `yaml:"metrics_mapping"`
 	FieldRequirements map[string]prometheus.FieldRequirement  `yaml:"field_requirements"`
+	Compatibility     prometheus.CompatibilityConfig          `yaml:"compatibility"`
+	Filters           []string                                `yaml:"filters"` // PromQL label matchers applied to every metric query
+	Proxy             prometheus.ProxyConfig                  `yaml:"proxy"`   // Metrics the API may query for the frontend
+
+	// Patterns parsing device descriptions into vendor, model and OS version (tried before the built-in ones)
+	DescriptionPatterns []prometheus.DescriptionPattern `yaml:"description_patterns"`
+}
+
+// LintConfig holds the conventions the topology is checked against
+type LintConfig struct {
+	PortNaming topology.PortNamingLint `yaml:"port_naming"` // Per-site port name and description policies (empty = disabled)
+	Metadata   topology.MetadataSchema `yaml:"metadata"`    // Metadata keys required per device type (empty = disabled)
 }
 
 // HierarchyConfig holds device hierarchy configuration
@@ -122,35 +183,15 @@ func (c *Config) setDefaults() {
 
 	// Set default metrics mapping
 	c.setDefaultMetricsMapping()
-
 }
 
-// Validate checks if the configuration is valid
+// Validate checks if the configuration is valid and returns the first error found
 func (c *Config) Validate() error {
-	// Validate hierarchy
-	if err := c.validateHierarchy(); err != nil {
-		return fmt.Errorf("hierarchy configuration error: %w", err)
-	}
-
-	// Validate database
-	switch c.Database.Type {
-	case "postgres":
-		if err := c.Database.Postgres.Validate(); err != nil {
-			return fmt.Errorf("postgres configuration error: %w", err)
-		}
-	case "sqlite":
-		if err := c.Database.SQLite.Validate(); err != nil {
-			return fmt.Errorf("sqlite configuration error: %w", err)
+	for _, issue := range c.validationIssues() {
+		if issue.Severity == SeverityError {
+			return fmt.Errorf("%s: %s", issue.Path, issue.Message)
 		}
-	default:
-		return fmt.Errorf("unsupported database type: %s", c.Database.Type)
-	}
-
-	// Validate Prometheus
-	if err := c.validatePrometheus(); err != nil {
-		return fmt.Errorf("prometheus configuration error: %w", err)
 	}
-
 	return nil
 }
 
@@ -162,8 +203,8 @@ func (c *Config) setDefaultMetricsMapping() {
 				Primary: prometheus.MetricMapping{
 					MetricName: "snmp_device_info",
 					Labels: map[string]string{
-						"device_id": "instance",
-						"hardware":  "sysDescr",
+						"device_id":   "instance",
+						"description": "sysDescr",
 					},
 				},
 				Fallbacks: []prometheus.MetricMapping{
@@ -177,8 +218,8 @@ func (c *Config) setDefaultMetricsMapping() {
 					{
 						MetricName: "lldp_local_info",
 						Labels: map[string]string{
-							"device_id": "chassis_id",
-							"hardware":  "system_description",
+							"device_id":   "chassis_id",
+							"description": "system_description",
 						},
 					},
 				},
@@ -212,7 +253,7 @@ func (c *Config) setDefaultMetricsMapping() {
 		c.Prometheus.FieldRequirements = map[string]prometheus.FieldRequirement{
 			"device_info": {
 				Required: []string{"device_id"},
-				Optional: []string{"hardware"},
+				Optional: []string{"hardware", "description"},
 			},
 			"lldp_neighbors": {
 				Required: []string{"source_device", "target_device"},
@@ -222,48 +263,6 @@ func (c *Config) setDefaultMetricsMapping() {
 	}
 }
 
-// validateHierarchy validates the hierarchy configuration
-func (c *Config) validateHierarchy() error {
-	if len(c.Hierarchy.DeviceTypes) == 0 {
-		return fmt.Errorf("device_types cannot be empty")
-	}
-
-	// Validate naming rules
-	for i, rule := range c.Hierarchy.NamingRules {
-		if rule.Pattern == "" {
-			return fmt.Errorf("naming rule %d: pattern cannot be empty", i)
-		}
-		if rule.Type == "" {
-			return fmt.Errorf("naming rule %d: type cannot be empty", i)
-		}
-
-		// Validate regex pattern
-		if _, err := regexp.Compile(rule.Pattern); err != nil {
-			return fmt.Errorf("naming rule %d: invalid regex pattern '%s': %w", i, rule.Pattern, err)
-		}
-
-		// Check if type exists in device types
-		if _, exists := c.Hierarchy.DeviceTypes[rule.Type]; !exists {
-			return fmt.Errorf("naming rule %d: type '%s' not found in device_types", i, rule.Type)
-		}
-	}
-
-	// Validate manual overrides
-	for device, deviceType := range c.Hierarchy.ManualOverrides {
-		if device == "" {
-			return fmt.Errorf("manual override: device name cannot be empty")
-		}
-		if deviceType == "" {
-			return fmt.Errorf("manual override for device '%s': type cannot be empty", device)
-		}
-		if _, exists := c.Hierarchy.DeviceTypes[deviceType]; !exists {
-			return fmt.Errorf("manual override for device '%s': type '%s' not found in device_types", device, deviceType)
-		}
-	}
-
-	return nil
-}
-
 // expandEnvironmentVariables expands environment variables in configuration values
 func (c *Config) expandEnvironmentVariables() {
 	// Expand database configuration
@@ -273,6 +272,9 @@ func (c *Config) expandEnvironmentVariables() {
 		c.Database.Postgres.Password = expandEnvVar(c.Database.Postgres.Password)
 		c.Database.Postgres.DBName = expandEnvVar(c.Database.Postgres.DBName)
 		c.Database.Postgres.SSLMode = expandEnvVar(c.Database.Postgres.SSLMode)
+		for i, replica := range c.Database.Postgres.Replicas {
+			c.Database.Postgres.Replicas[i] = expandEnvVar(replica)
+		}
 	}
 	if c.Database.Type == "sqlite" {
 		c.Database.SQLite.Path = expandEnvVar(c.Database.SQLite.Path)
@@ -280,6 +282,17 @@ func (c *Config) expandEnvironmentVariables() {
 
 	// Expand Prometheus configuration
 	c.Prometheus.URL = expandEnvVar(c.Prometheus.URL)
+	for key, value := range c.Prometheus.Compatibility.Headers {
+		c.Prometheus.Compatibility.Headers[key] = expandEnvVar(value)
+	}
+	for i, filter := range c.Prometheus.Filters {
+		c.Prometheus.Filters[i] = expandEnvVar(filter)
+	}
+
+	// Expand authentication configuration
+	c.Auth.LDAP.URL = expandEnvVar(c.Auth.LDAP.URL)
+	c.Auth.LDAP.BindDN = expandEnvVar(c.Auth.LDAP.BindDN)
+	c.Auth.LDAP.BindPassword = expandEnvVar(c.Auth.LDAP.BindPassword)
 }
 
 // expandEnvVar expands environment variables in a string
@@ -363,17 +376,6 @@ func GetDefaultConfigPath() string {
 	return "tm.yaml"
 }
 
-// validatePrometheus validates Prometheus configuration
-func (c *Config) validatePrometheus() error {
-	if c.Prometheus.URL == "" {
-		return fmt.Errorf("prometheus URL is required")
-	}
-	if c.Prometheus.Timeout <= 0 {
-		return fmt.Errorf("prometheus timeout must be positive")
-	}
-	return nil
-}
-
 // GetDatabaseConfig returns database repository configuration
 func (c *Config) GetDatabaseConfig() repository.Config {
 	return c.Database
@@ -382,9 +384,107 @@ func (c *Config) GetDatabaseConfig() repository.Config {
 // GetPrometheusConfig returns Prometheus client configuration
 func (c *Config) GetPrometheusConfig() prometheus.Config {
 	return prometheus.Config{
-		URL:     expandEnvVar(c.Prometheus.URL),
-		Timeout: c.Prometheus.Timeout,
+		URL:           expandEnvVar(c.Prometheus.URL),
+		Timeout:       c.Prometheus.Timeout,
+		Compatibility: c.Prometheus.Compatibility,
+	}
+}
+
+// GetProxyConfig returns the settings of the metrics proxy endpoint
+func (c *Config) GetProxyConfig() prometheus.ProxyConfig {
+	return c.Prometheus.Proxy.WithDefaults()
+}
+
+// GetCoverageGate returns the classification coverage gate. An explicit threshold of 0 is kept;
+// only an omitted one falls back to the default.
+func (c *Config) GetCoverageGate() classification.CoverageGate {
+	gate := classification.CoverageGate{
+		Threshold:   classification.DefaultCoverageThreshold,
+		DeviceTypes: c.Classification.Coverage.DeviceTypes,
+	}
+	if c.Classification.Coverage.Threshold != nil {
+		gate.Threshold = *c.Classification.Coverage.Threshold
+	}
+	return gate
+}
+
+// GetBootstrapPack returns the layers and starter rules to bootstrap, the defaults unless declared
+// in classification.bootstrap
+func (c *Config) GetBootstrapPack() classification.BootstrapPack {
+	pack := classification.DefaultBootstrapPack()
+	if len(c.Classification.Bootstrap.Layers) > 0 {
+		pack.Layers = c.Classification.Bootstrap.Layers
+	}
+	if c.Classification.Bootstrap.Rules != nil {
+		pack.Rules = c.Classification.Bootstrap.Rules
+	}
+	return pack
+}
+
+// GetTagRules returns the rules tagging devices during synchronization
+func (c *Config) GetTagRules() []classification.TagRule {
+	return c.Classification.TagRules
+}
+
+// GetPlaceholderDefaults returns the attributes of placeholder devices created for LLDP neighbors
+func (c *Config) GetPlaceholderDefaults() topology.PlaceholderDefaults {
+	return c.Sync.Placeholder
+}
+
+// GetIdentityOptions returns the metadata keys used to resolve device identities
+func (c *Config) GetIdentityOptions() topology.IdentityOptions {
+	return c.Sync.Identity.WithDefaults()
+}
+
+// GetOrphanedLinkPolicy returns how long the worker drops links still reported for deleted devices
+func (c *Config) GetOrphanedLinkPolicy() topology.OrphanedLinkPolicy {
+	return c.Sync.OrphanLinks.WithDefaults()
+}
+
+// GetPortNamingLint returns the port naming conventions checked by /api/v1/analysis/port-naming
+func (c *Config) GetPortNamingLint() topology.PortNamingLint {
+	lint := c.Lint.PortNaming
+	if lint.SiteKey == "" {
+		lint.SiteKey = "site"
 	}
+	return lint
+}
+
+// GetMetadataSchema returns the metadata required per device type, checked by /api/v1/analysis/metadata
+func (c *Config) GetMetadataSchema() topology.MetadataSchema {
+	return c.Lint.Metadata
+}
+
+// GetFaultInjection returns the faults injected into the synchronization worker
+func (c *Config) GetFaultInjection() faultinject.Config {
+	return c.Sync.FaultInjection
+}
+
+// GetNamingClassificationRules converts hierarchy.naming_rules into classification rules.
+// The rules are named by their stable ID, since patterns may contain characters rule names
+// cannot, and get descending priorities in the order of the config file. The classification
+// service matches them after every rule managed in the database.
+func (c *Config) GetNamingClassificationRules() []classification.ClassificationRule {
+	rules := make([]classification.ClassificationRule, 0, len(c.Hierarchy.NamingRules))
+	for i, namingRule := range c.Hierarchy.NamingRules {
+		id := classification.ConfigRuleID(namingRule.Pattern, namingRule.Type)
+		rules = append(rules, classification.ClassificationRule{
+			ID:            id,
+			Name:          id,
+			Description:   fmt.Sprintf("Imported from hierarchy.naming_rules[%d]: %s -> %s", i, namingRule.Pattern, namingRule.Type),
+			LogicOperator: "AND",
+			Conditions: []classification.RuleCondition{
+				{Field: "name", Operator: "regex", Value: namingRule.Pattern},
+			},
+			Layer:      c.Hierarchy.DeviceTypes[namingRule.Type],
+			DeviceType: namingRule.Type,
+			Priority:   len(c.Hierarchy.NamingRules) - i,
+			IsActive:   true,
+			Confidence: 1.0,
+			CreatedBy:  classification.ConfigRuleCreator,
+		})
+	}
+	return rules
 }
 
 // GetMetricsConfig returns metrics configuration for MetricsExtractor
@@ -392,5 +492,8 @@ func (c *Config) GetMetricsConfig() *prometheus.MetricsConfig {
 	return &prometheus.MetricsConfig{
 		MetricsMapping:    c.Prometheus.MetricsMapping,
 		FieldRequirements: c.Prometheus.FieldRequirements,
+		Filters:           c.Prometheus.Filters,
+
+		DescriptionPatterns: c.Prometheus.DescriptionPatterns,
 	}
 }
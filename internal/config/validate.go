@@ -0,0 +1,432 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/prometheus"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity levels of configuration issues
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ValidationIssue describes a configuration problem and where it is located in the file
+type ValidationIssue struct {
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"` // 0 = 不明
+	Message  string `json:"message"`
+
+	segments []string
+}
+
+func (i ValidationIssue) String() string {
+	location := i.Path
+	if i.Line > 0 && location == "" {
+		location = fmt.Sprintf("line %d", i.Line)
+	} else if i.Line > 0 {
+		location = fmt.Sprintf("line %d: %s", i.Line, i.Path)
+	}
+	if location == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", location, i.Message)
+}
+
+// metricFields lists the fields that can be extracted by each metrics mapping
+var metricFields = map[string][]string{
+	"device_info":    {"device_id", "hardware", "description", "location"},
+	"lldp_neighbors": {"source_device", "target_device", "source_port", "target_port"},
+	"mac_table":      {"device_id", "port", "mac", "vlan"},
+	"arp_table":      {"mac", "ip", "hostname"},
+}
+
+// optionalMetricFields lists the fields each optional metrics mapping cannot work without
+var optionalMetricFields = map[string][]string{
+	"mac_table": {"device_id", "port", "mac"},
+	"arp_table": {"mac"},
+}
+
+var yamlErrorLinePattern = regexp.MustCompile(`line (\d+):? ?`)
+
+// ValidateFile loads a configuration file and reports every problem found with its line number.
+// The returned config is nil when the file cannot be parsed.
+func ValidateFile(path string) (*Config, []ValidationIssue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, []ValidationIssue{yamlIssue(err.Error())}, nil
+	}
+
+	var issues []ValidationIssue
+
+	// 未知のキー（タイプミス）と型の不一致を検出
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&Config{}); err != nil && err != io.EOF {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			for _, msg := range typeErr.Errors {
+				issues = append(issues, yamlIssue(msg))
+			}
+		} else {
+			issues = append(issues, yamlIssue(err.Error()))
+		}
+	}
+
+	config := &Config{}
+	config.setDefaults()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		// 型エラーは上で報告済み
+		return nil, issues, nil
+	}
+	config.expandEnvironmentVariables()
+
+	for _, issue := range config.validationIssues() {
+		issue.Line = lookupLine(&root, issue.segments)
+		issues = append(issues, issue)
+	}
+
+	return config, issues, nil
+}
+
+// yamlIssue converts a YAML parser message into an issue
+func yamlIssue(msg string) ValidationIssue {
+	issue := ValidationIssue{Severity: SeverityError, Message: strings.TrimPrefix(msg, "yaml: ")}
+	if m := yamlErrorLinePattern.FindStringSubmatch(issue.Message); m != nil {
+		issue.Line, _ = strconv.Atoi(m[1])
+		// 行番号は Line で表示するため、メッセージ先頭の重複を除く
+		if strings.HasPrefix(issue.Message, m[0]) {
+			issue.Message = strings.TrimPrefix(issue.Message, m[0])
+		}
+	}
+	return issue
+}
+
+// lookupLine returns the line of the deepest node found along segments
+func lookupLine(root *yaml.Node, segments []string) int {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line := node.Line
+
+	for _, segment := range segments {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment {
+					next = node.Content[i+1]
+					line = node.Content[i].Line
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(segment); err == nil && index < len(node.Content) {
+				next = node.Content[index]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	return line
+}
+
+func newIssue(severity string, segments []string, format string, args ...interface{}) ValidationIssue {
+	var path strings.Builder
+	for _, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			fmt.Fprintf(&path, "[%s]", segment)
+			continue
+		}
+		if path.Len() > 0 {
+			path.WriteString(".")
+		}
+		path.WriteString(segment)
+	}
+
+	return ValidationIssue{
+		Severity: severity,
+		Path:     path.String(),
+		Message:  fmt.Sprintf(format, args...),
+		segments: segments,
+	}
+}
+
+// validationIssues runs all semantic checks and collects every problem found
+func (c *Config) validationIssues() []ValidationIssue {
+	var issues []ValidationIssue
+	issues = append(issues, c.hierarchyIssues()...)
+	issues = append(issues, c.databaseIssues()...)
+	issues = append(issues, c.prometheusIssues()...)
+	if err := c.Auth.Validate(); err != nil {
+		issues = append(issues, newIssue(SeverityError, []string{"auth"}, "%v", err))
+	}
+	if err := c.Sync.Placeholder.Validate(); err != nil {
+		issues = append(issues, newIssue(SeverityError, []string{"sync", "placeholder"}, "%v", err))
+	}
+	if err := c.Sync.OrphanLinks.Validate(); err != nil {
+		issues = append(issues, newIssue(SeverityError, []string{"sync", "orphan_links"}, "%v", err))
+	}
+	if err := c.Sync.FaultInjection.Validate(); err != nil {
+		issues = append(issues, newIssue(SeverityError, []string{"sync", "fault_injection"}, "%v", err))
+	}
+	if err := c.Lint.PortNaming.Validate(); err != nil {
+		issues = append(issues, newIssue(SeverityError, []string{"lint", "port_naming"}, "%v", err))
+	}
+	if err := c.Lint.Metadata.Validate(); err != nil {
+		issues = append(issues, newIssue(SeverityError, []string{"lint", "metadata"}, "%v", err))
+	}
+
+	if err := c.GetBootstrapPack().Validate(); err != nil {
+		issues = append(issues, newIssue(SeverityError, []string{"classification", "bootstrap"}, "%v", err))
+	}
+	if err := classification.ValidateTagRules(c.Classification.TagRules); err != nil {
+		issues = append(issues, newIssue(SeverityError, []string{"classification", "tag_rules"}, "%v", err))
+	}
+	if t := c.GetCoverageGate().Threshold; t < 0 || t > 100 {
+		issues = append(issues, newIssue(SeverityError, []string{"classification", "coverage", "threshold"},
+			"coverage threshold must be between 0 and 100, got %v", t))
+	}
+
+	return issues
+}
+
+func (c *Config) hierarchyIssues() []ValidationIssue {
+	var issues []ValidationIssue
+
+	if len(c.Hierarchy.DeviceTypes) == 0 {
+		issues = append(issues, newIssue(SeverityError, []string{"hierarchy", "device_types"}, "device_types cannot be empty"))
+	}
+	deviceTypes := sortedKeys(c.Hierarchy.DeviceTypes)
+
+	for i, rule := range c.Hierarchy.NamingRules {
+		path := []string{"hierarchy", "naming_rules", strconv.Itoa(i)}
+		if rule.Pattern == "" {
+			issues = append(issues, newIssue(SeverityError, append(path, "pattern"), "pattern cannot be empty"))
+		} else if _, err := regexp.Compile(rule.Pattern); err != nil {
+			issues = append(issues, newIssue(SeverityError, append(path, "pattern"), "invalid regex pattern '%s': %v", rule.Pattern, err))
+		}
+		if rule.Type == "" {
+			issues = append(issues, newIssue(SeverityError, append(path, "type"), "type cannot be empty"))
+		} else if _, exists := c.Hierarchy.DeviceTypes[rule.Type]; !exists {
+			issues = append(issues, newIssue(SeverityError, append(path, "type"),
+				"type '%s' not found in hierarchy.device_types (available: %s)", rule.Type, strings.Join(deviceTypes, ", ")))
+		}
+	}
+
+	for _, device := range sortedKeys(c.Hierarchy.ManualOverrides) {
+		deviceType := c.Hierarchy.ManualOverrides[device]
+		path := []string{"hierarchy", "manual_overrides", device}
+		if device == "" {
+			issues = append(issues, newIssue(SeverityError, path, "device name cannot be empty"))
+			continue
+		}
+		if deviceType == "" {
+			issues = append(issues, newIssue(SeverityError, path, "type for device '%s' cannot be empty", device))
+		} else if _, exists := c.Hierarchy.DeviceTypes[deviceType]; !exists {
+			issues = append(issues, newIssue(SeverityError, path,
+				"type '%s' for device '%s' not found in hierarchy.device_types (available: %s)", deviceType, device, strings.Join(deviceTypes, ", ")))
+		}
+	}
+
+	return issues
+}
+
+func (c *Config) databaseIssues() []ValidationIssue {
+	switch c.Database.Type {
+	case "postgres":
+		if err := c.Database.Postgres.Validate(); err != nil {
+			return []ValidationIssue{newIssue(SeverityError, []string{"database", "postgres"}, "%v", err)}
+		}
+	case "sqlite":
+		if err := c.Database.SQLite.Validate(); err != nil {
+			return []ValidationIssue{newIssue(SeverityError, []string{"database", "sqlite"}, "%v", err)}
+		}
+	default:
+		return []ValidationIssue{newIssue(SeverityError, []string{"database", "type"},
+			"unsupported database type '%s' (expected postgres or sqlite)", c.Database.Type)}
+	}
+	return nil
+}
+
+func (c *Config) prometheusIssues() []ValidationIssue {
+	var issues []ValidationIssue
+
+	if c.Prometheus.URL == "" {
+		issues = append(issues, newIssue(SeverityError, []string{"prometheus", "url"}, "prometheus URL is required"))
+	}
+	if c.Prometheus.Timeout <= 0 {
+		issues = append(issues, newIssue(SeverityError, []string{"prometheus", "timeout"}, "prometheus timeout must be positive"))
+	}
+	if err := c.Prometheus.Compatibility.Validate(); err != nil {
+		issues = append(issues, newIssue(SeverityError, []string{"prometheus", "compatibility"}, "%v", err))
+	}
+	issues = append(issues, filterIssues([]string{"prometheus", "filters"}, c.Prometheus.Filters)...)
+	if err := c.Prometheus.Proxy.Validate(); err != nil {
+		issues = append(issues, newIssue(SeverityError, []string{"prometheus", "proxy"}, "%v", err))
+	}
+	for i, pattern := range c.Prometheus.DescriptionPatterns {
+		if err := pattern.Validate(); err != nil {
+			issues = append(issues, newIssue(SeverityError, []string{"prometheus", "description_patterns", strconv.Itoa(i), "pattern"}, "%v", err))
+		}
+	}
+
+	// 必須フィールドの定義（field_requirements）
+	for _, key := range sortedKeys(c.Prometheus.FieldRequirements) {
+		requirement := c.Prometheus.FieldRequirements[key]
+		path := []string{"prometheus", "field_requirements", key}
+		fields, known := metricFields[key]
+		if !known {
+			issues = append(issues, newIssue(SeverityWarning, path, "unknown metric type '%s' is ignored (expected one of: %s)",
+				key, strings.Join(sortedKeys(metricFields), ", ")))
+			continue
+		}
+		for _, field := range requirement.Required {
+			if !containsString(fields, field) {
+				issues = append(issues, newIssue(SeverityError, append(path, "required"),
+					"unknown field '%s' (expected one of: %s)", field, strings.Join(fields, ", ")))
+			} else if field == "location" {
+				issues = append(issues, newIssue(SeverityError, append(path, "required"),
+					"'location' is display-only and cannot be required; every device would be skipped"))
+			} else if field == "description" {
+				issues = append(issues, newIssue(SeverityError, append(path, "required"),
+					"'description' is parsed into hardware, vendor and os_version and cannot be required; require 'hardware' instead"))
+			}
+		}
+		for _, field := range requirement.Optional {
+			if !containsString(fields, field) {
+				issues = append(issues, newIssue(SeverityWarning, append(path, "optional"),
+					"unknown field '%s' (expected one of: %s)", field, strings.Join(fields, ", ")))
+			}
+		}
+	}
+
+	// メトリクスマッピングのラベル参照
+	for _, key := range sortedKeys(metricFields) {
+		if _, optional := optionalMetricFields[key]; optional {
+			continue
+		}
+		if _, exists := c.Prometheus.MetricsMapping[key]; !exists {
+			issues = append(issues, newIssue(SeverityError, []string{"prometheus", "metrics_mapping"},
+				"missing '%s' mapping; add a primary metric for it", key))
+		}
+	}
+	for _, key := range sortedKeys(c.Prometheus.MetricsMapping) {
+		group := c.Prometheus.MetricsMapping[key]
+		path := []string{"prometheus", "metrics_mapping", key}
+		if _, known := metricFields[key]; !known {
+			issues = append(issues, newIssue(SeverityWarning, path, "unknown metric type '%s' is ignored (expected one of: %s)",
+				key, strings.Join(sortedKeys(metricFields), ", ")))
+			continue
+		}
+
+		issues = append(issues, c.metricMappingIssues(key, append(path, "primary"), group.Primary)...)
+		issues = append(issues, filterIssues(append(path, "primary", "filters"), group.Primary.Filters)...)
+		for i, fallback := range group.Fallbacks {
+			fallbackPath := append(append([]string(nil), path...), "fallbacks", strconv.Itoa(i))
+			issues = append(issues, c.metricMappingIssues(key, fallbackPath, fallback)...)
+			issues = append(issues, filterIssues(append(fallbackPath, "filters"), fallback.Filters)...)
+		}
+	}
+
+	return issues
+}
+
+// metricMappingIssues checks that a metric mapping names a metric or a query and maps every required field to a label
+func (c *Config) metricMappingIssues(key string, path []string, mapping prometheus.MetricMapping) []ValidationIssue {
+	var issues []ValidationIssue
+	path = append([]string(nil), path...)
+	labels := mapping.Labels
+	metricName := mapping.Source()
+
+	switch {
+	case mapping.Query != "" && mapping.MetricName != "":
+		issues = append(issues, newIssue(SeverityError, append(path, "query"), "metric_name and query are exclusive; set only one of them"))
+	case mapping.Query != "":
+		// 任意の PromQL にはラベルの絞り込みを差し込めないため、式に含めてもらう
+		if len(mapping.Filters) > 0 {
+			issues = append(issues, newIssue(SeverityError, append(path, "filters"), "filters are not applied to a query; add the matchers to the expression"))
+		}
+		if len(c.Prometheus.Filters) > 0 {
+			issues = append(issues, newIssue(SeverityWarning, append(path, "query"), "prometheus.filters are not applied to a query; add the matchers to the expression"))
+		}
+	case mapping.MetricName == "":
+		issues = append(issues, newIssue(SeverityError, append(path, "metric_name"), "metric_name cannot be empty (or set query)"))
+	}
+
+	fields := metricFields[key]
+	for _, field := range sortedKeys(labels) {
+		if !containsString(fields, field) {
+			issues = append(issues, newIssue(SeverityWarning, append(path, "labels", field),
+				"unknown field '%s' is ignored (expected one of: %s)", field, strings.Join(fields, ", ")))
+		}
+	}
+
+	required := append([]string{}, optionalMetricFields[key]...)
+	if requirement, ok := c.Prometheus.FieldRequirements[key]; ok {
+		for _, field := range requirement.Required {
+			if !containsString(required, field) {
+				required = append(required, field)
+			}
+		}
+	}
+	for _, field := range required {
+		if labels[field] == "" {
+			issues = append(issues, newIssue(SeverityError, append(path, "labels"),
+				"required field '%s' is not mapped to a label; samples of '%s' would be skipped", field, metricName))
+		}
+	}
+
+	return issues
+}
+
+// filterIssues checks that every filter is a valid PromQL label matcher
+func filterIssues(path []string, filters []string) []ValidationIssue {
+	var issues []ValidationIssue
+	for i, filter := range filters {
+		if _, err := prometheus.ParseLabelMatcher(filter); err != nil {
+			issues = append(issues, newIssue(SeverityError, append(append([]string(nil), path...), strconv.Itoa(i)), "%v", err))
+		}
+	}
+	return issues
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issueAt returns the issue reported for path, failing the test when there is none
+func issueAt(t *testing.T, issues []ValidationIssue, path string) ValidationIssue {
+	t.Helper()
+
+	for _, issue := range issues {
+		if issue.Path == path {
+			return issue
+		}
+	}
+	require.Failf(t, "issue not found", "no issue for %s in %v", path, issues)
+	return ValidationIssue{}
+}
+
+func TestValidateFile_ShippedConfig(t *testing.T) {
+	cfg, issues, err := ValidateFile(filepath.Join("..", "..", "tm.yaml"))
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	for _, issue := range issues {
+		assert.NotEqual(t, SeverityError, issue.Severity, issue.String())
+	}
+}
+
+func TestValidateFile_ReportsEveryIssueWithLines(t *testing.T) {
+	cfg, issues, err := ValidateFile(writeConfig(t, `hierarchy:
+  device_types:
+    core: 1
+  naming_rules:
+    - pattern: "^core-("
+      type: core
+    - pattern: "^leaf-"
+      type: leaf
+prometheus:
+  compatibility:
+    flavor: cortex
+  metrics_mapping:
+    device_info:
+      primary:
+        metric_name: snmp_device_info
+        labels:
+          hostname: instance
+    lldp_neighbors:
+      primary:
+        metric_name: lldp_remote_info
+        query: lldp_remote_info{job="snmp"}
+        labels:
+          source_device: instance
+          target_device: remote
+    bogus:
+      primary:
+        metric_name: x
+  typo_key: 1
+`))
+	require.NoError(t, err)
+	require.NotNil(t, cfg, "unknown keys do not prevent the semantic checks")
+
+	tests := []struct {
+		path     string
+		severity string
+		line     int
+		message  string
+	}{
+		{"hierarchy.naming_rules[0].pattern", SeverityError, 5, "invalid regex pattern '^core-('"},
+		{"hierarchy.naming_rules[1].type", SeverityError, 8, "type 'leaf' not found in hierarchy.device_types"},
+		{"prometheus.compatibility", SeverityError, 10, "unsupported prometheus flavor: cortex"},
+		{"prometheus.metrics_mapping.device_info.primary.labels.hostname", SeverityWarning, 17, "unknown field 'hostname' is ignored"},
+		{"prometheus.metrics_mapping.device_info.primary.labels", SeverityError, 16, "required field 'device_id' is not mapped"},
+		{"prometheus.metrics_mapping.lldp_neighbors.primary.query", SeverityError, 21, "metric_name and query are exclusive"},
+		{"prometheus.metrics_mapping.bogus", SeverityWarning, 25, "unknown metric type 'bogus' is ignored"},
+	}
+	for _, tt := range tests {
+		issue := issueAt(t, issues, tt.path)
+		assert.Equal(t, tt.severity, issue.Severity, tt.path)
+		assert.Equal(t, tt.line, issue.Line, tt.path)
+		assert.Contains(t, issue.Message, tt.message, tt.path)
+	}
+
+	// 未知のキーはパスではなく行番号で示す
+	typo := issueAt(t, issues, "")
+	assert.Equal(t, 28, typo.Line)
+	assert.Equal(t, "line 28: field typo_key not found in type config.PrometheusConfig", typo.String())
+}
+
+func TestValidateFile_SyntaxError(t *testing.T) {
+	cfg, issues, err := ValidateFile(writeConfig(t, "hierarchy:\n  device_types: [\n"))
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+	require.Len(t, issues, 1)
+	assert.Equal(t, SeverityError, issues[0].Severity)
+	assert.Positive(t, issues[0].Line)
+	assert.NotContains(t, issues[0].Message, "yaml:")
+}
+
+func TestValidateFile_TypeMismatch(t *testing.T) {
+	cfg, issues, err := ValidateFile(writeConfig(t, "prometheus:\n  timeout: soon\n"))
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+	require.Len(t, issues, 1)
+	assert.Equal(t, 2, issues[0].Line)
+	assert.Contains(t, issues[0].Message, "soon")
+}
+
+func TestValidateFile_MissingFile(t *testing.T) {
+	_, _, err := ValidateFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read config file")
+}
+
+func TestValidationIssue_String(t *testing.T) {
+	tests := []struct {
+		issue ValidationIssue
+		want  string
+	}{
+		{ValidationIssue{Path: "prometheus.url", Line: 3, Message: "prometheus URL is required"}, "line 3: prometheus.url: prometheus URL is required"},
+		{ValidationIssue{Path: "prometheus.url", Message: "prometheus URL is required"}, "prometheus.url: prometheus URL is required"},
+		{ValidationIssue{Line: 7, Message: "mapping values are not allowed"}, "line 7: mapping values are not allowed"},
+		{ValidationIssue{Message: "mapping values are not allowed"}, "mapping values are not allowed"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.issue.String())
+	}
+}
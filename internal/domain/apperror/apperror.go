@@ -0,0 +1,88 @@
+// Package apperror defines typed errors with stable machine-readable codes.
+// Services return them (usually wrapped with fmt.Errorf and %w) and the API maps
+// their kind to an HTTP status and their code to the "code" field of the error body.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifies an error independently of the operation that failed
+type Kind string
+
+const (
+	KindNotFound              Kind = "not_found"
+	KindConflict              Kind = "conflict"
+	KindValidation            Kind = "validation_failed"
+	KindDependencyUnavailable Kind = "dependency_unavailable"
+)
+
+// Sentinels for matching a kind with errors.Is, e.g. errors.Is(err, apperror.ErrNotFound)
+var (
+	ErrNotFound              = &Error{Kind: KindNotFound, Code: string(KindNotFound), Message: "not found"}
+	ErrConflict              = &Error{Kind: KindConflict, Code: string(KindConflict), Message: "conflict"}
+	ErrValidation            = &Error{Kind: KindValidation, Code: string(KindValidation), Message: "validation failed"}
+	ErrDependencyUnavailable = &Error{Kind: KindDependencyUnavailable, Code: string(KindDependencyUnavailable), Message: "dependency unavailable"}
+)
+
+// Error is a typed error. Code is stable across releases so clients can branch on it
+// (e.g. "fabric_not_found"); Message is for humans and may change.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error // underlying cause, if any
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the kind sentinels, so every not-found error is errors.Is(err, ErrNotFound)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	if t == ErrNotFound || t == ErrConflict || t == ErrValidation || t == ErrDependencyUnavailable {
+		return e.Kind == t.Kind
+	}
+	return e == t
+}
+
+// NotFound creates an error for a missing resource
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+// Conflict creates an error for a request that conflicts with the current state
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+// Validation creates an error for invalid input
+func Validation(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+// DependencyUnavailable wraps the failure of a backing service (database, Prometheus, ...)
+func DependencyUnavailable(code string, err error) *Error {
+	return &Error{Kind: KindDependencyUnavailable, Code: code, Message: "dependency unavailable", Err: err}
+}
+
+// From returns the first typed error in the chain of err
+func From(err error) (*Error, bool) {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed, true
+	}
+	return nil, false
+}
@@ -0,0 +1,45 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError_Is(t *testing.T) {
+	errFabricNotFound := NotFound("fabric_not_found", "fabric not found")
+	err := fmt.Errorf("%w: spine-a", errFabricNotFound)
+
+	if !errors.Is(err, errFabricNotFound) {
+		t.Errorf("Expected wrapped error to match its sentinel")
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected wrapped error to match the not-found kind")
+	}
+	if errors.Is(err, ErrConflict) {
+		t.Errorf("Expected wrapped error not to match the conflict kind")
+	}
+	if errors.Is(err, NotFound("group_not_found", "group not found")) {
+		t.Errorf("Expected errors with different codes not to match")
+	}
+}
+
+func TestFrom(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("failed to list devices: %w", DependencyUnavailable("database_unavailable", cause))
+
+	typed, ok := From(err)
+	if !ok {
+		t.Fatalf("Expected typed error in chain")
+	}
+	if typed.Kind != KindDependencyUnavailable || typed.Code != "database_unavailable" {
+		t.Errorf("Unexpected typed error: %+v", typed)
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("Expected cause to stay in the chain")
+	}
+
+	if _, ok := From(errors.New("plain")); ok {
+		t.Errorf("Expected no typed error in a plain error")
+	}
+}
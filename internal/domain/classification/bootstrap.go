@@ -0,0 +1,125 @@
+package classification
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BootstrapRuleCreator marks the starter rules installed by bootstrap. Unlike config-sourced rules
+// they are ordinary rules once installed and may be edited or deleted through the API.
+const BootstrapRuleCreator = "bootstrap"
+
+// BootstrapLayer is a hierarchy layer installed by bootstrap. Layers are matched by name.
+type BootstrapLayer struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description"`
+	Color       string `json:"color,omitempty" yaml:"color"`
+}
+
+// BootstrapRule classifies the devices whose name matches Pattern as DeviceType in the layer named Layer
+type BootstrapRule struct {
+	Pattern    string `json:"pattern" yaml:"pattern"` // デバイス名の正規表現
+	DeviceType string `json:"device_type" yaml:"device_type"`
+	Layer      string `json:"layer" yaml:"layer"` // パック内のレイヤー名
+}
+
+// BootstrapPack is the hierarchy layers, top first, and starter rules installed on first run
+type BootstrapPack struct {
+	Layers []BootstrapLayer `json:"layers" yaml:"layers"`
+	Rules  []BootstrapRule  `json:"rules" yaml:"rules"`
+}
+
+// DefaultBootstrapPack returns the layers of a data center network and rules recognizing the
+// usual device name prefixes followed by a number (core-01, spine01, tor-12, ...)
+func DefaultBootstrapPack() BootstrapPack {
+	return BootstrapPack{
+		Layers: []BootstrapLayer{
+			{Name: "Border", Description: "Border routers and external connectivity", Color: "#e74c3c"},
+			{Name: "Core", Description: "Core routers and switches", Color: "#f39c12"},
+			{Name: "Spine", Description: "Spine switches of the fabric", Color: "#9b59b6"},
+			{Name: "Leaf", Description: "Leaf and top-of-rack switches", Color: "#3498db"},
+			{Name: "Access", Description: "Access switches connecting end devices", Color: "#2ecc71"},
+			{Name: "Server", Description: "Servers and other end devices", Color: "#95a5a6"},
+		},
+		Rules: []BootstrapRule{
+			{Pattern: `(?i)^(border|bdr|edge)[-_.]?[0-9]`, DeviceType: "border", Layer: "Border"},
+			{Pattern: `(?i)^(core|cr)[-_.]?[0-9]`, DeviceType: "core", Layer: "Core"},
+			{Pattern: `(?i)^(spine|sp)[-_.]?[0-9]`, DeviceType: "spine", Layer: "Spine"},
+			{Pattern: `(?i)^(leaf|lf|tor)[-_.]?[0-9]`, DeviceType: "leaf", Layer: "Leaf"},
+			{Pattern: `(?i)^(access|acc|asw)[-_.]?[0-9]`, DeviceType: "access", Layer: "Access"},
+			{Pattern: `(?i)^(server|srv|host)[-_.]?[0-9]`, DeviceType: "server", Layer: "Server"},
+		},
+	}
+}
+
+// Validate checks the layer names are unique and every rule compiles and names a layer of the pack
+func (p BootstrapPack) Validate() error {
+	layers := make(map[string]bool, len(p.Layers))
+	for i, layer := range p.Layers {
+		name := strings.ToLower(strings.TrimSpace(layer.Name))
+		if name == "" {
+			return fmt.Errorf("layer %d has no name", i)
+		}
+		if layers[name] {
+			return fmt.Errorf("layer %s is declared twice", layer.Name)
+		}
+		layers[name] = true
+	}
+
+	for i, rule := range p.Rules {
+		if strings.TrimSpace(rule.DeviceType) == "" {
+			return fmt.Errorf("rule %d has no device_type", i)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil || rule.Pattern == "" {
+			return fmt.Errorf("rule %d (%s) has an invalid pattern '%s'", i, rule.DeviceType, rule.Pattern)
+		}
+		if !layers[strings.ToLower(strings.TrimSpace(rule.Layer))] {
+			return fmt.Errorf("rule %d (%s) refers to layer '%s' which is not in the pack", i, rule.DeviceType, rule.Layer)
+		}
+	}
+	return nil
+}
+
+// BootstrapRuleID returns the stable ID of a starter rule, so that bootstrapping again finds the installed rule
+func BootstrapRuleID(rule BootstrapRule) string {
+	sum := sha256.Sum256([]byte(rule.Pattern + "\x00" + rule.DeviceType))
+	return "bootstrap-" + hex.EncodeToString(sum[:8])
+}
+
+// ClassificationRules converts the starter rules into classification rules; layerIDs maps the
+// lower-cased layer names to the IDs of the installed layers. The rules get priority 0, the lowest
+// every database accepts, so rules created through the API or from suggestions take precedence.
+func (p BootstrapPack) ClassificationRules(layerIDs map[string]int) []ClassificationRule {
+	rules := make([]ClassificationRule, 0, len(p.Rules))
+	for i, rule := range p.Rules {
+		rules = append(rules, ClassificationRule{
+			ID:            BootstrapRuleID(rule),
+			Name:          fmt.Sprintf("Starter rule %d (%s)", i+1, rule.DeviceType),
+			Description:   fmt.Sprintf("Installed by bootstrap: devices named %s", rule.Pattern),
+			LogicOperator: "AND",
+			Conditions: []RuleCondition{
+				{Field: "name", Operator: "regex", Value: rule.Pattern},
+			},
+			Layer:      layerIDs[strings.ToLower(strings.TrimSpace(rule.Layer))],
+			DeviceType: rule.DeviceType,
+			Priority:   0,
+			IsActive:   true,
+			Confidence: 0.8,
+			CreatedBy:  BootstrapRuleCreator,
+		})
+	}
+	return rules
+}
+
+// BootstrapResult reports what bootstrap installed. Layers and rules that already exist are left
+// untouched, so bootstrapping again changes nothing.
+type BootstrapResult struct {
+	DryRun         bool     `json:"dry_run"`
+	LayersCreated  []string `json:"layers_created"`
+	LayersExisting []string `json:"layers_existing"`
+	RulesCreated   []string `json:"rules_created"`
+	RulesExisting  []string `json:"rules_existing"`
+}
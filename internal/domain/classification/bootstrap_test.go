@@ -0,0 +1,69 @@
+package classification
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDefaultBootstrapPack(t *testing.T) {
+	pack := DefaultBootstrapPack()
+	if err := pack.Validate(); err != nil {
+		t.Fatalf("Expected the default pack to be valid, got %v", err)
+	}
+
+	tests := map[string]string{
+		"border-01": "border",
+		"CORE01":    "core",
+		"spine.2":   "spine",
+		"tor-12":    "leaf",
+		"asw_3":     "access",
+		"srv-0042":  "server",
+		"corelab":   "",
+	}
+	for name, want := range tests {
+		got := ""
+		for _, rule := range pack.Rules {
+			if regexp.MustCompile(rule.Pattern).MatchString(name) {
+				got = rule.DeviceType
+				break
+			}
+		}
+		if got != want {
+			t.Errorf("Expected %s to be classified as %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestBootstrapPack_Validate(t *testing.T) {
+	invalid := []BootstrapPack{
+		{Layers: []BootstrapLayer{{Name: " "}}},
+		{Layers: []BootstrapLayer{{Name: "Core"}, {Name: "core"}}},
+		{Layers: []BootstrapLayer{{Name: "Core"}}, Rules: []BootstrapRule{{Pattern: "^core", Layer: "Core"}}},
+		{Layers: []BootstrapLayer{{Name: "Core"}}, Rules: []BootstrapRule{{Pattern: "^(core", DeviceType: "core", Layer: "Core"}}},
+		{Layers: []BootstrapLayer{{Name: "Core"}}, Rules: []BootstrapRule{{Pattern: "^spine", DeviceType: "spine", Layer: "Spine"}}},
+	}
+	for _, pack := range invalid {
+		if err := pack.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", pack)
+		}
+	}
+}
+
+func TestBootstrapPack_ClassificationRules(t *testing.T) {
+	pack := DefaultBootstrapPack()
+	rules := pack.ClassificationRules(map[string]int{"core": 1, "spine": 7})
+
+	if len(rules) != len(pack.Rules) {
+		t.Fatalf("Expected %d rules, got %d", len(pack.Rules), len(rules))
+	}
+	core, spine := rules[1], rules[2]
+	if core.Layer != 1 || spine.Layer != 7 || core.CreatedBy != BootstrapRuleCreator {
+		t.Errorf("Expected rules in the installed layers, got %+v and %+v", core, spine)
+	}
+	if core.Priority != 0 || !core.IsActive {
+		t.Errorf("Expected active rules of the lowest priority, got %+v", core)
+	}
+	if again := pack.ClassificationRules(nil); again[1].ID != core.ID {
+		t.Errorf("Expected stable rule IDs, got %s and %s", core.ID, again[1].ID)
+	}
+}
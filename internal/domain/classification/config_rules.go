@@ -0,0 +1,31 @@
+package classification
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ConfigRuleCreator marks classification rules imported from hierarchy.naming_rules in tm.yaml.
+// Such rules are managed by the config file and are replaced on every sync.
+const ConfigRuleCreator = "config"
+
+// ConfigRuleID returns the stable ID of the rule imported from a naming rule,
+// so that re-importing an unchanged naming rule updates the same rule
+func ConfigRuleID(pattern, deviceType string) string {
+	sum := sha256.Sum256([]byte(pattern + "\x00" + deviceType))
+	return "config-" + hex.EncodeToString(sum[:8])
+}
+
+// IsConfigSourced reports whether the rule was imported from the config file
+func (r ClassificationRule) IsConfigSourced() bool {
+	return r.CreatedBy == ConfigRuleCreator
+}
+
+// ConfigRuleSyncResult reports the changes made by syncing config naming rules
+type ConfigRuleSyncResult struct {
+	DryRun    bool     `json:"dry_run"`
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+}
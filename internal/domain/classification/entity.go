@@ -16,9 +16,9 @@ type DeviceClassification struct {
 
 // RuleCondition represents a single condition in a classification rule
 type RuleCondition struct {
-	Field    string `json:"field"`    // "name", "hardware", "ip_address", "type"
-	Operator string `json:"operator"` // "contains", "starts_with", "ends_with", "equals", "regex"
-	Value    string `json:"value"`
+	Field    string `json:"field" pattern:"^(name|hardware|type|metadata\\..+)$" example:"name" doc:"Device attribute to match: name (the device ID), hardware, type or metadata.<key>"`
+	Operator string `json:"operator" enum:"contains,starts_with,ends_with,equals,regex" example:"starts_with" doc:"Comparison; all but regex are case-insensitive"`
+	Value    string `json:"value" example:"core-" doc:"Value to compare with (a Go regular expression for regex)"`
 }
 
 // ClassificationRule represents a rule for automatic device classification
@@ -32,10 +32,12 @@ type ClassificationRule struct {
 	DeviceType    string          `json:"device_type" db:"device_type"`
 	Priority      int             `json:"priority" db:"priority"` // Higher priority rules are applied first
 	IsActive      bool            `json:"is_active" db:"is_active"`
+	Shadow        bool            `json:"shadow" db:"shadow"`         // 評価して結果を記録するのみで、デバイスは変更しない
 	Confidence    float64         `json:"confidence" db:"confidence"` // 0.0 - 1.0
 	CreatedBy     string          `json:"created_by" db:"created_by"`
 	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+	Quality       *RuleQuality    `json:"quality,omitempty" db:"-" readOnly:"true"` // 一覧取得時のみ（履歴から算出）
 }
 
 // ClassificationSuggestion represents a suggested rule based on manual classifications
@@ -43,7 +45,8 @@ type ClassificationSuggestion struct {
 	ID              string             `json:"id"`
 	RuleID          string             `json:"rule_id"`
 	Rule            ClassificationRule `json:"rule"`
-	AffectedDevices []string           `json:"affected_devices"`
+	AffectedDevices []string           `json:"affected_devices"` // 先頭 MaxAffectedDevices 件まで
+	AffectedCount   int                `json:"affected_device_count"`
 	BasedOnDevices  []string           `json:"based_on_devices"`
 	Confidence      float64            `json:"confidence"`
 	Status          SuggestionStatus   `json:"status"`
@@ -72,6 +75,26 @@ type HierarchyLayer struct {
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// DefaultCoverageThreshold is the required coverage in percent when classification.coverage sets none
+const DefaultCoverageThreshold = 90
+
+// CoverageGate defines the minimum classification coverage required for a set of devices
+type CoverageGate struct {
+	Threshold   float64  `json:"threshold"`    // Required coverage in percent (0-100)
+	DeviceTypes []string `json:"device_types"` // Device types in scope (empty = all devices)
+}
+
+// CoverageReport represents the result of evaluating a CoverageGate
+type CoverageReport struct {
+	Threshold           float64  `json:"threshold"`
+	DeviceTypes         []string `json:"device_types"`
+	TotalDevices        int      `json:"total_devices"`
+	ClassifiedDevices   int      `json:"classified_devices"`
+	Coverage            float64  `json:"coverage"` // Percent (0-100)
+	Passed              bool     `json:"passed"`
+	UnclassifiedDevices []string `json:"unclassified_devices"`
+}
+
 // DefaultHierarchyLayers returns the default network hierarchy layers
 func DefaultHierarchyLayers() []HierarchyLayer {
 	return []HierarchyLayer{
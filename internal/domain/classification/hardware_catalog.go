@@ -0,0 +1,71 @@
+package classification
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// HardwareCatalogEntry approves a hardware model for a hierarchy layer.
+// Model may contain glob wildcards (e.g. "DCS-7280*") and is matched case-insensitively.
+type HardwareCatalogEntry struct {
+	ID          string    `json:"id"`
+	LayerID     int       `json:"layer_id"`
+	Model       string    `json:"model"`
+	Description string    `json:"description,omitempty"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// HardwareCatalog answers which models are approved for each layer
+type HardwareCatalog struct {
+	models map[int][]string
+}
+
+// NewHardwareCatalog builds a catalog from its entries
+func NewHardwareCatalog(entries []HardwareCatalogEntry) *HardwareCatalog {
+	catalog := &HardwareCatalog{models: make(map[int][]string)}
+	for _, entry := range entries {
+		catalog.models[entry.LayerID] = append(catalog.models[entry.LayerID], entry.Model)
+	}
+	return catalog
+}
+
+// ApprovedModels returns the approved models of a layer; nil means the layer is not governed by the catalog
+func (c *HardwareCatalog) ApprovedModels(layerID int) []string {
+	return c.models[layerID]
+}
+
+// IsApproved reports whether hardware is approved for the layer.
+// Layers without catalog entries accept any hardware.
+func (c *HardwareCatalog) IsApproved(layerID int, hardware string) bool {
+	models, governed := c.models[layerID]
+	if !governed {
+		return true
+	}
+
+	hardware = strings.ToLower(strings.TrimSpace(hardware))
+	for _, model := range models {
+		if matched, err := path.Match(strings.ToLower(model), hardware); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// HardwareComplianceReport lists devices whose hardware is not approved for their layer
+type HardwareComplianceReport struct {
+	GeneratedAt      time.Time           `json:"generated_at"`
+	CheckedDevices   int                 `json:"checked_devices"`
+	CompliantDevices int                 `json:"compliant_devices"`
+	Violations       []HardwareViolation `json:"violations"`
+}
+
+// HardwareViolation describes a device whose hardware does not match its layer's approved list
+type HardwareViolation struct {
+	DeviceID       string   `json:"device_id"`
+	Hardware       string   `json:"hardware"`
+	LayerID        int      `json:"layer_id"`
+	LayerName      string   `json:"layer_name"`
+	ApprovedModels []string `json:"approved_models"`
+}
@@ -0,0 +1,36 @@
+package classification
+
+import (
+	"testing"
+)
+
+func TestHardwareCatalog_IsApproved(t *testing.T) {
+	catalog := NewHardwareCatalog([]HardwareCatalogEntry{
+		{LayerID: 1, Model: "DCS-7500*"},
+		{LayerID: 1, Model: "QFX10002"},
+		{LayerID: 4, Model: "DCS-7050*"},
+	})
+
+	tests := []struct {
+		layerID  int
+		hardware string
+		want     bool
+	}{
+		{1, "DCS-7500R3", true},
+		{1, "qfx10002", true},
+		{1, "DCS-7050SX3", false}, // アクセス向け機種がコアに分類されている
+		{4, "DCS-7050SX3", true},
+		{1, "", false},
+		{2, "anything", true}, // カタログ未定義の層は対象外
+	}
+
+	for _, tt := range tests {
+		if got := catalog.IsApproved(tt.layerID, tt.hardware); got != tt.want {
+			t.Errorf("IsApproved(%d, %q) = %v, want %v", tt.layerID, tt.hardware, got, tt.want)
+		}
+	}
+
+	if models := catalog.ApprovedModels(2); models != nil {
+		t.Errorf("Expected no approved models for ungoverned layer, got %v", models)
+	}
+}
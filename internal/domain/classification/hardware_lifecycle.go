@@ -0,0 +1,179 @@
+package classification
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Hardware lifecycle statuses
+const (
+	LifecycleSupported = "supported"
+	LifecycleEndOfSale = "end_of_sale"
+	LifecycleEndOfLife = "end_of_life"
+)
+
+// HardwareLifecycleEntry records the end-of-sale and end-of-life dates of a hardware model.
+// Model is matched fuzzily against device hardware strings (see HardwareLifecycleCatalog).
+type HardwareLifecycleEntry struct {
+	ID          string     `json:"id"`
+	Model       string     `json:"model"`
+	EndOfSale   *time.Time `json:"end_of_sale,omitempty"`
+	EndOfLife   *time.Time `json:"end_of_life,omitempty"`
+	Description string     `json:"description,omitempty"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Status returns the lifecycle status of the model on the given date
+func (e HardwareLifecycleEntry) Status(asOf time.Time) string {
+	if e.EndOfLife != nil && !e.EndOfLife.After(asOf) {
+		return LifecycleEndOfLife
+	}
+	if e.EndOfSale != nil && !e.EndOfSale.After(asOf) {
+		return LifecycleEndOfSale
+	}
+	return LifecycleSupported
+}
+
+// HardwareLifecycleCatalog matches device hardware strings against lifecycle entries
+type HardwareLifecycleCatalog struct {
+	entries []HardwareLifecycleEntry
+	models  []string // 正規化済みのモデル名
+}
+
+// NewHardwareLifecycleCatalog builds a catalog from its entries
+func NewHardwareLifecycleCatalog(entries []HardwareLifecycleEntry) *HardwareLifecycleCatalog {
+	catalog := &HardwareLifecycleCatalog{entries: entries}
+	for _, entry := range entries {
+		catalog.models = append(catalog.models, normalizeModel(entry.Model))
+	}
+	return catalog
+}
+
+// Match returns the entry whose model best matches hardware, or nil. Models and hardware are
+// compared ignoring case, spaces and punctuation, so "DCS-7280SR" matches "Arista DCS7280SR-48C6".
+// A model matches when it equals the hardware, is contained in it or matches it as a glob
+// (e.g. "EX2300*"); an exact match wins, otherwise the longest matching model.
+func (c *HardwareLifecycleCatalog) Match(hardware string) *HardwareLifecycleEntry {
+	normalized := normalizeModel(hardware)
+	if normalized == "" {
+		return nil
+	}
+
+	best, bestLen := -1, 0
+	for i, model := range c.models {
+		if model == "" {
+			continue
+		}
+		if model == normalized {
+			return &c.entries[i]
+		}
+
+		var matched bool
+		if strings.ContainsAny(model, "*?") {
+			matched, _ = path.Match(model, normalized)
+		} else {
+			matched = strings.Contains(normalized, model)
+		}
+		if matched && len(model) > bestLen {
+			best, bestLen = i, len(model)
+		}
+	}
+
+	if best < 0 {
+		return nil
+	}
+	return &c.entries[best]
+}
+
+// normalizeModel lowercases a model or hardware string and drops everything but letters,
+// digits and glob wildcards
+func normalizeModel(model string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(model) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '*' || r == '?' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// HardwareLifecycleReport lists devices running hardware past its end of life (and optionally
+// its end of sale), grouped by layer and site
+type HardwareLifecycleReport struct {
+	GeneratedAt    time.Time                `json:"generated_at"`
+	AsOf           time.Time                `json:"as_of"` // この日付時点の状態で判定
+	CheckedDevices int                      `json:"checked_devices"`
+	MatchedDevices int                      `json:"matched_devices"` // カタログのモデルに一致したデバイス数
+	Groups         []HardwareLifecycleGroup `json:"groups"`
+}
+
+// HardwareLifecycleGroup is the devices of one layer and site in a lifecycle report
+type HardwareLifecycleGroup struct {
+	LayerID   *int                      `json:"layer_id"` // nil = 未分類
+	LayerName string                    `json:"layer_name"`
+	Site      string                    `json:"site"` // 空 = サイト不明
+	Count     int                       `json:"count"`
+	Devices   []HardwareLifecycleDevice `json:"devices"`
+}
+
+// HardwareLifecycleDevice is a device whose hardware matched a lifecycle entry
+type HardwareLifecycleDevice struct {
+	DeviceID  string     `json:"device_id"`
+	Hardware  string     `json:"hardware"`
+	Model     string     `json:"model"` // 一致したカタログのモデル
+	Status    string     `json:"status"`
+	EndOfSale *time.Time `json:"end_of_sale,omitempty"`
+	EndOfLife *time.Time `json:"end_of_life,omitempty"`
+	LayerID   *int       `json:"-"`
+	LayerName string     `json:"-"`
+	Site      string     `json:"-"`
+}
+
+// GroupLifecycleDevices groups devices by layer and site. Groups are ordered by layer ID with
+// unclassified devices last, then by site; devices keep their order.
+func GroupLifecycleDevices(devices []HardwareLifecycleDevice) []HardwareLifecycleGroup {
+	type groupKey struct {
+		layer int
+		site  string
+	}
+	const unclassified = -1
+
+	index := make(map[groupKey]int)
+	groups := []HardwareLifecycleGroup{}
+	for _, device := range devices {
+		key := groupKey{layer: unclassified, site: device.Site}
+		if device.LayerID != nil {
+			key.layer = *device.LayerID
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, HardwareLifecycleGroup{
+				LayerID:   device.LayerID,
+				LayerName: device.LayerName,
+				Site:      device.Site,
+				Devices:   []HardwareLifecycleDevice{},
+			})
+		}
+		groups[i].Devices = append(groups[i].Devices, device)
+		groups[i].Count++
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if (a.LayerID == nil) != (b.LayerID == nil) {
+			return b.LayerID == nil
+		}
+		if a.LayerID != nil && *a.LayerID != *b.LayerID {
+			return *a.LayerID < *b.LayerID
+		}
+		return a.Site < b.Site
+	})
+	return groups
+}
@@ -0,0 +1,91 @@
+package classification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHardwareLifecycleCatalog_Match(t *testing.T) {
+	catalog := NewHardwareLifecycleCatalog([]HardwareLifecycleEntry{
+		{ID: "7280", Model: "DCS-7280"},
+		{ID: "7280sr", Model: "DCS-7280SR"},
+		{ID: "ex2300", Model: "EX2300*"},
+		{ID: "qfx", Model: "QFX5100-48S"},
+	})
+
+	tests := []struct {
+		hardware string
+		want     string
+	}{
+		{"DCS-7280SR-48C6", "7280sr"},      // より長いモデルを優先
+		{"Arista DCS7280CR3-32P4", "7280"}, // ベンダー名や区切り文字の違いは無視
+		{"ex2300-c-12p", "ex2300"},
+		{"qfx5100 48s", "qfx"},
+		{"QFX5100-24Q", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if entry := catalog.Match(tt.hardware); entry != nil {
+			got = entry.ID
+		}
+		if got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.hardware, got, tt.want)
+		}
+	}
+}
+
+func TestHardwareLifecycleEntry_Status(t *testing.T) {
+	endOfSale := time.Date(2023, 6, 30, 0, 0, 0, 0, time.UTC)
+	endOfLife := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	entry := HardwareLifecycleEntry{EndOfSale: &endOfSale, EndOfLife: &endOfLife}
+
+	if status := entry.Status(endOfSale.AddDate(0, 0, -1)); status != LifecycleSupported {
+		t.Errorf("Expected supported before end of sale, got %s", status)
+	}
+	if status := entry.Status(endOfSale); status != LifecycleEndOfSale {
+		t.Errorf("Expected end_of_sale on the end of sale date, got %s", status)
+	}
+	if status := entry.Status(endOfLife.AddDate(0, 1, 0)); status != LifecycleEndOfLife {
+		t.Errorf("Expected end_of_life after end of life, got %s", status)
+	}
+	if status := (HardwareLifecycleEntry{}).Status(endOfLife); status != LifecycleSupported {
+		t.Errorf("Expected supported without dates, got %s", status)
+	}
+}
+
+func TestGroupLifecycleDevices(t *testing.T) {
+	spine, leaf := 30, 40
+	groups := GroupLifecycleDevices([]HardwareLifecycleDevice{
+		{DeviceID: "leaf-b1", LayerID: &leaf, Site: "tyo2"},
+		{DeviceID: "unknown-01"},
+		{DeviceID: "leaf-a1", LayerID: &leaf, Site: "tyo1"},
+		{DeviceID: "spine-01", LayerID: &spine, Site: "tyo1"},
+		{DeviceID: "leaf-a2", LayerID: &leaf, Site: "tyo1"},
+	})
+
+	expected := []struct {
+		layer *int
+		site  string
+		count int
+	}{
+		{&spine, "tyo1", 1},
+		{&leaf, "tyo1", 2},
+		{&leaf, "tyo2", 1},
+		{nil, "", 1},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %+v", len(expected), groups)
+	}
+	for i, want := range expected {
+		group := groups[i]
+		if (group.LayerID == nil) != (want.layer == nil) || (want.layer != nil && *group.LayerID != *want.layer) ||
+			group.Site != want.site || group.Count != want.count {
+			t.Errorf("Unexpected group %d: %+v", i, group)
+		}
+	}
+	if groups[1].Devices[0].DeviceID != "leaf-a1" || groups[1].Devices[1].DeviceID != "leaf-a2" {
+		t.Errorf("Expected devices to keep their order, got %+v", groups[1].Devices)
+	}
+}
@@ -0,0 +1,26 @@
+package classification
+
+import "time"
+
+// ChangeSource identifies what triggered a classification change
+type ChangeSource string
+
+const (
+	ChangeSourceUser ChangeSource = "user"
+	ChangeSourceRule ChangeSource = "rule"
+)
+
+// ClassificationChange records a single change of a device classification
+type ClassificationChange struct {
+	ID                   int64        `json:"id"`
+	DeviceID             string       `json:"device_id"`
+	PreviousLayerID      *int         `json:"previous_layer_id,omitempty"`
+	PreviousDeviceType   string       `json:"previous_device_type"`
+	PreviousClassifiedBy string       `json:"previous_classified_by"`
+	LayerID              *int         `json:"layer_id,omitempty"`
+	DeviceType           string       `json:"device_type"`
+	ClassifiedBy         string       `json:"classified_by"`
+	Source               ChangeSource `json:"source"`
+	Reason               string       `json:"reason"`
+	ChangedAt            time.Time    `json:"changed_at"`
+}
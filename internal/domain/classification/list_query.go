@@ -0,0 +1,145 @@
+package classification
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ListSort is the order of a rule or suggestion list
+type ListSort string
+
+const (
+	// SortByPriority lists the highest priority first (the order rules are applied in)
+	SortByPriority ListSort = "priority"
+	// SortByConfidence lists the most confident first
+	SortByConfidence ListSort = "confidence"
+	// SortByCreatedAt lists the newest first
+	SortByCreatedAt ListSort = "created_at"
+	// SortByQuality lists the rules users override most first (rules only)
+	SortByQuality ListSort = "quality"
+)
+
+// ListQuery selects a page of rules or suggestions. Search matches the rule name and
+// description case-insensitively; Limit 0 returns every match.
+type ListQuery struct {
+	Search  string
+	Sort    ListSort
+	Reverse bool // 既定の並び順（優先度・信頼度・作成日時は降順、品質は悪い順）を反転する
+	Limit   int
+	Offset  int
+}
+
+// Validate checks the sort key and the page bounds
+func (q ListQuery) Validate() error {
+	switch q.Sort {
+	case "", SortByPriority, SortByConfidence, SortByCreatedAt, SortByQuality:
+	default:
+		return fmt.Errorf("unknown sort '%s' (expected priority, confidence, created_at or quality)", q.Sort)
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return fmt.Errorf("limit and offset must not be negative")
+	}
+	return nil
+}
+
+func (q ListQuery) matches(rule ClassificationRule) bool {
+	if q.Search == "" {
+		return true
+	}
+	search := strings.ToLower(q.Search)
+	return strings.Contains(strings.ToLower(rule.Name), search) ||
+		strings.Contains(strings.ToLower(rule.Description), search)
+}
+
+// page returns the bounds of the requested page within total items
+func (q ListQuery) page(total int) (int, int) {
+	start := q.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if q.Limit > 0 && start+q.Limit < total {
+		end = start + q.Limit
+	}
+	return start, end
+}
+
+// PageRules filters, sorts and slices rules according to q and returns the page with the
+// number of rules matching the search. Rules that compare equal keep their order.
+func PageRules(rules []ClassificationRule, q ListQuery) ([]ClassificationRule, int) {
+	matched := make([]ClassificationRule, 0, len(rules))
+	for _, rule := range rules {
+		if q.matches(rule) {
+			matched = append(matched, rule)
+		}
+	}
+
+	if q.Sort == SortByQuality {
+		SortRulesByQuality(matched)
+		if q.Reverse {
+			reverseRules(matched)
+		}
+	} else {
+		sort.SliceStable(matched, func(i, j int) bool {
+			return q.less(matched[i], matched[j])
+		})
+	}
+
+	start, end := q.page(len(matched))
+	return matched[start:end], len(matched)
+}
+
+// PageSuggestions filters suggestions by their rule, sorts and slices them like PageRules
+func PageSuggestions(suggestions []ClassificationSuggestion, q ListQuery) ([]ClassificationSuggestion, int) {
+	matched := make([]ClassificationSuggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		if q.matches(suggestion.Rule) {
+			matched = append(matched, suggestion)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		// 提案の信頼度と作成日時は提案自身のものを使う
+		a, b := matched[i].Rule, matched[j].Rule
+		a.Confidence, a.CreatedAt = matched[i].Confidence, matched[i].CreatedAt
+		b.Confidence, b.CreatedAt = matched[j].Confidence, matched[j].CreatedAt
+		return q.less(a, b)
+	})
+
+	start, end := q.page(len(matched))
+	return matched[start:end], len(matched)
+}
+
+// less compares two rules by the sort key, largest first unless Reverse
+func (q ListQuery) less(a, b ClassificationRule) bool {
+	var cmp int
+	switch q.Sort {
+	case SortByConfidence:
+		cmp = compareFloat(a.Confidence, b.Confidence)
+	case SortByCreatedAt:
+		cmp = a.CreatedAt.Compare(b.CreatedAt)
+	default:
+		cmp = a.Priority - b.Priority
+	}
+	if q.Reverse {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func reverseRules(rules []ClassificationRule) {
+	for i, j := 0, len(rules)-1; i < j; i, j = i+1, j-1 {
+		rules[i], rules[j] = rules[j], rules[i]
+	}
+}
@@ -0,0 +1,74 @@
+package classification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPageRules(t *testing.T) {
+	now := time.Now()
+	rules := []ClassificationRule{
+		{Name: "core-routers", Description: "Core layer", Priority: 100, Confidence: 0.9, CreatedAt: now.Add(-3 * time.Hour)},
+		{Name: "leaf-switches", Description: "Leaf switches in every pod", Priority: 50, Confidence: 0.95, CreatedAt: now.Add(-1 * time.Hour)},
+		{Name: "spine-switches", Description: "Spine layer", Priority: 80, Confidence: 0.7, CreatedAt: now.Add(-2 * time.Hour)},
+	}
+
+	page, total := PageRules(rules, ListQuery{Search: "SWITCH"})
+	if total != 2 || len(page) != 2 || page[0].Name != "spine-switches" {
+		t.Errorf("Expected 2 switch rules by priority, got %d: %+v", total, page)
+	}
+
+	page, total = PageRules(rules, ListQuery{Search: "pod"})
+	if total != 1 || page[0].Name != "leaf-switches" {
+		t.Errorf("Expected description search to find leaf-switches, got %+v", page)
+	}
+
+	page, _ = PageRules(rules, ListQuery{Sort: SortByConfidence})
+	if page[0].Name != "leaf-switches" || page[2].Name != "spine-switches" {
+		t.Errorf("Expected rules by confidence, got %s, %s, %s", page[0].Name, page[1].Name, page[2].Name)
+	}
+
+	page, _ = PageRules(rules, ListQuery{Sort: SortByCreatedAt, Reverse: true})
+	if page[0].Name != "core-routers" {
+		t.Errorf("Expected oldest rule first when reversed, got %s", page[0].Name)
+	}
+
+	page, total = PageRules(rules, ListQuery{Limit: 2, Offset: 1})
+	if total != 3 || len(page) != 2 || page[0].Name != "spine-switches" || page[1].Name != "leaf-switches" {
+		t.Errorf("Expected second and third rule, got %d: %+v", total, page)
+	}
+
+	page, total = PageRules(rules, ListQuery{Offset: 10})
+	if total != 3 || len(page) != 0 {
+		t.Errorf("Expected empty page past the end, got %d rules", len(page))
+	}
+}
+
+func TestPageSuggestions(t *testing.T) {
+	now := time.Now()
+	suggestions := []ClassificationSuggestion{
+		{ID: "a", Rule: ClassificationRule{Name: "tor", Priority: 10}, Confidence: 0.6, CreatedAt: now},
+		{ID: "b", Rule: ClassificationRule{Name: "border", Priority: 20}, Confidence: 0.8, CreatedAt: now.Add(-time.Hour)},
+	}
+
+	page, _ := PageSuggestions(suggestions, ListQuery{Sort: SortByConfidence})
+	if page[0].ID != "b" {
+		t.Errorf("Expected most confident suggestion first, got %s", page[0].ID)
+	}
+	page, _ = PageSuggestions(suggestions, ListQuery{Sort: SortByCreatedAt})
+	if page[0].ID != "a" {
+		t.Errorf("Expected newest suggestion first, got %s", page[0].ID)
+	}
+	page, total := PageSuggestions(suggestions, ListQuery{Search: "bord"})
+	if total != 1 || page[0].ID != "b" {
+		t.Errorf("Expected search to match the suggested rule name, got %+v", page)
+	}
+}
+
+func TestListQuery_Validate(t *testing.T) {
+	for _, query := range []ListQuery{{Sort: "name"}, {Limit: -1}, {Offset: -1}} {
+		if err := query.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", query)
+		}
+	}
+}
@@ -38,3 +38,39 @@ type Repository interface {
 	// Utilities
 	Close() error
 }
+
+// HistoryRepository is implemented by repositories that record classification changes
+type HistoryRepository interface {
+	AddClassificationChange(ctx context.Context, change ClassificationChange) error
+	// ListClassificationHistory returns changes of a device, newest first
+	ListClassificationHistory(ctx context.Context, deviceID string, limit int) ([]ClassificationChange, error)
+}
+
+// HardwareCatalogRepository is implemented by repositories that store the hardware catalog
+type HardwareCatalogRepository interface {
+	ListHardwareCatalog(ctx context.Context) ([]HardwareCatalogEntry, error)
+	SaveHardwareCatalogEntry(ctx context.Context, entry HardwareCatalogEntry) error
+	DeleteHardwareCatalogEntry(ctx context.Context, entryID string) error
+}
+
+// HardwareLifecycleRepository is implemented by repositories that store hardware end-of-sale/end-of-life dates
+type HardwareLifecycleRepository interface {
+	ListHardwareLifecycle(ctx context.Context) ([]HardwareLifecycleEntry, error)
+	SaveHardwareLifecycleEntry(ctx context.Context, entry HardwareLifecycleEntry) error
+	DeleteHardwareLifecycleEntry(ctx context.Context, entryID string) error
+}
+
+// RuleMatchRepository is implemented by repositories that can evaluate rule conditions in a query
+type RuleMatchRepository interface {
+	// FindDevicesMatchingRule returns a page of the IDs of devices matching rule and the total number
+	// of matches. It returns ErrRuleNotQueryable when a condition cannot be expressed in the query.
+	FindDevicesMatchingRule(ctx context.Context, rule ClassificationRule, limit, offset int) ([]string, int, error)
+}
+
+// ShadowClassificationRepository is implemented by repositories that record the classifications of shadow rules
+type ShadowClassificationRepository interface {
+	// ReplaceShadowClassifications replaces the shadow classifications of the evaluated devices;
+	// evaluated devices without a result lose their previous one
+	ReplaceShadowClassifications(ctx context.Context, evaluatedDeviceIDs []string, results []ShadowClassification) error
+	ListShadowClassifications(ctx context.Context) ([]ShadowClassification, error)
+}
@@ -0,0 +1,9 @@
+package classification
+
+import "errors"
+
+// MaxAffectedDevices caps the affected devices embedded in a suggestion; the full list is paginated
+const MaxAffectedDevices = 100
+
+// ErrRuleNotQueryable is returned by RuleMatchRepository when a rule has to be evaluated in memory
+var ErrRuleNotQueryable = errors.New("rule conditions cannot be evaluated by the repository")
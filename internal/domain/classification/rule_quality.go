@@ -0,0 +1,65 @@
+package classification
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// RuleQuality is how well the classifications made by a rule held up against manual review.
+// Counts are numbers of distinct devices taken from the classification history.
+type RuleQuality struct {
+	Applied    int      `json:"applied" doc:"Devices the rule has classified"`
+	Overridden int      `json:"overridden" doc:"Of those, devices later reclassified or unclassified by a user"`
+	Confirmed  int      `json:"confirmed" doc:"Of those, devices a user later classified the same way"`
+	Score      *float64 `json:"score,omitempty" doc:"Share of classifications not overridden (0.0 - 1.0); omitted for rules never applied"`
+}
+
+// NewRuleQuality computes the score of a rule from its outcome counts
+func NewRuleQuality(applied, overridden, confirmed int) RuleQuality {
+	quality := RuleQuality{Applied: applied, Overridden: overridden, Confirmed: confirmed}
+	if applied > 0 {
+		// 履歴の削除などで上書き数が適用数を超えても 0 未満にはしない
+		score := 1 - float64(overridden)/float64(applied)
+		if score < 0 {
+			score = 0
+		}
+		quality.Score = &score
+	}
+	return quality
+}
+
+// SortRulesByQuality orders rules from the lowest score up so misbehaving rules come first.
+// Rules without a score keep their relative order at the end.
+func SortRulesByQuality(rules []ClassificationRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		a, b := rules[i].Quality, rules[j].Quality
+		if a == nil || a.Score == nil {
+			return false
+		}
+		if b == nil || b.Score == nil {
+			return true
+		}
+		if *a.Score != *b.Score {
+			return *a.Score < *b.Score
+		}
+		// 同じスコアなら上書き数が多い方を先に
+		return a.Overridden > b.Overridden
+	})
+}
+
+// RuleNameFromClassifiedBy returns the rule name of a "rule:<name>" classified_by value
+func RuleNameFromClassifiedBy(classifiedBy string) (string, bool) {
+	if !strings.HasPrefix(classifiedBy, "rule:") {
+		return "", false
+	}
+	return strings.TrimPrefix(classifiedBy, "rule:"), true
+}
+
+// RuleQualityRepository is implemented by repositories that can derive rule outcomes from the
+// classification history
+type RuleQualityRepository interface {
+	// ListRuleQuality returns the outcome counts of every rule that appears in the history,
+	// keyed by rule name (devices record the rule that classified them by name)
+	ListRuleQuality(ctx context.Context) (map[string]RuleQuality, error)
+}
@@ -0,0 +1,50 @@
+package classification
+
+import "testing"
+
+func TestNewRuleQuality(t *testing.T) {
+	quality := NewRuleQuality(10, 3, 2)
+	if quality.Score == nil || *quality.Score != 0.7 {
+		t.Errorf("Expected score 0.7, got %v", quality.Score)
+	}
+
+	if quality := NewRuleQuality(0, 0, 0); quality.Score != nil {
+		t.Errorf("Expected no score for a rule never applied, got %v", *quality.Score)
+	}
+
+	if quality := NewRuleQuality(2, 5, 0); quality.Score == nil || *quality.Score != 0 {
+		t.Errorf("Expected score clamped to 0, got %v", quality.Score)
+	}
+}
+
+func TestSortRulesByQuality(t *testing.T) {
+	withQuality := func(name string, applied, overridden int) ClassificationRule {
+		quality := NewRuleQuality(applied, overridden, 0)
+		return ClassificationRule{Name: name, Quality: &quality}
+	}
+	rules := []ClassificationRule{
+		{Name: "unscored"},
+		withQuality("good", 10, 0),
+		withQuality("never-applied", 0, 0),
+		withQuality("bad", 10, 6),
+		withQuality("worse-volume", 20, 12),
+	}
+
+	SortRulesByQuality(rules)
+
+	expected := []string{"worse-volume", "bad", "good", "unscored", "never-applied"}
+	for i, name := range expected {
+		if rules[i].Name != name {
+			t.Errorf("Expected %s at %d, got %s", name, i, rules[i].Name)
+		}
+	}
+}
+
+func TestRuleNameFromClassifiedBy(t *testing.T) {
+	if name, ok := RuleNameFromClassifiedBy("rule:spine by name"); !ok || name != "spine by name" {
+		t.Errorf("Expected rule name, got %q, %v", name, ok)
+	}
+	if _, ok := RuleNameFromClassifiedBy("user:admin"); ok {
+		t.Error("Expected user classification not to be a rule")
+	}
+}
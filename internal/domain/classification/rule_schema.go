@@ -0,0 +1,144 @@
+package classification
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// MetadataFieldPrefix prefixes rule condition fields that match a device metadata value (e.g. metadata.site)
+const MetadataFieldPrefix = "metadata."
+
+// RuleOperators lists the condition operators in the order the rule builder offers them
+var RuleOperators = []RuleOperatorSchema{
+	{Operator: "equals", Description: "Value equals the given text (case-insensitive)"},
+	{Operator: "contains", Description: "Value contains the given text (case-insensitive)"},
+	{Operator: "starts_with", Description: "Value starts with the given text (case-insensitive)"},
+	{Operator: "ends_with", Description: "Value ends with the given text (case-insensitive)"},
+	{Operator: "regex", Description: "Value matches the Go regular expression", CaseSensitive: true},
+}
+
+// RuleSchema describes what the conditions of a classification rule can refer to
+type RuleSchema struct {
+	Fields    []RuleFieldSchema    `json:"fields"`
+	Operators []RuleOperatorSchema `json:"operators"`
+	Logic     []string             `json:"logic" example:"AND"`
+	Devices   int                  `json:"devices"` // 値の収集に使ったデバイス数
+}
+
+// RuleFieldSchema is a condition field with the operators it supports and its most common values
+type RuleFieldSchema struct {
+	Field       string            `json:"field" example:"metadata.site"`
+	Description string            `json:"description"`
+	Metadata    bool              `json:"metadata"` // デバイスのメタデータから見つかったフィールド
+	Operators   []string          `json:"operators"`
+	Devices     int               `json:"devices"` // 値を持つデバイス数
+	Samples     []RuleFieldSample `json:"samples"`
+}
+
+// RuleFieldSample is a value of a field and the number of devices having it
+type RuleFieldSample struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// RuleOperatorSchema describes a condition operator
+type RuleOperatorSchema struct {
+	Operator      string `json:"operator"`
+	Description   string `json:"description"`
+	CaseSensitive bool   `json:"case_sensitive"`
+}
+
+// MetadataKey returns the metadata key of a metadata.<key> condition field
+func MetadataKey(field string) (string, bool) {
+	key, ok := strings.CutPrefix(field, MetadataFieldPrefix)
+	return key, ok && key != ""
+}
+
+// BuildRuleSchema lists the built-in condition fields followed by every metadata key found on
+// devices, each with up to maxSamples of its most frequent values
+func BuildRuleSchema(devices []topology.Device, maxSamples int) RuleSchema {
+	if maxSamples < 0 {
+		maxSamples = 0
+	}
+
+	operators := make([]string, len(RuleOperators))
+	for i, op := range RuleOperators {
+		operators[i] = op.Operator
+	}
+
+	builtins := []struct {
+		field       string
+		description string
+		value       func(topology.Device) string
+	}{
+		{"name", "Device ID", func(d topology.Device) string { return d.ID }},
+		{"hardware", "Hardware model", func(d topology.Device) string { return d.Hardware }},
+		{"type", "Device type", func(d topology.Device) string { return d.Type }},
+	}
+
+	schema := RuleSchema{
+		Operators: RuleOperators,
+		Logic:     []string{"AND", "OR"},
+		Devices:   len(devices),
+	}
+	for _, builtin := range builtins {
+		values := make(map[string]int)
+		for _, device := range devices {
+			if value := builtin.value(device); value != "" {
+				values[value]++
+			}
+		}
+		schema.Fields = append(schema.Fields, newRuleFieldSchema(builtin.field, builtin.description, false, operators, values, maxSamples))
+	}
+
+	metadataValues := make(map[string]map[string]int)
+	for _, device := range devices {
+		for key, value := range device.Metadata {
+			if key == "" || value == "" {
+				continue
+			}
+			if metadataValues[key] == nil {
+				metadataValues[key] = make(map[string]int)
+			}
+			metadataValues[key][value]++
+		}
+	}
+	keys := make([]string, 0, len(metadataValues))
+	for key := range metadataValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		schema.Fields = append(schema.Fields, newRuleFieldSchema(MetadataFieldPrefix+key, "Device metadata "+key, true, operators, metadataValues[key], maxSamples))
+	}
+
+	return schema
+}
+
+func newRuleFieldSchema(field, description string, metadata bool, operators []string, values map[string]int, maxSamples int) RuleFieldSchema {
+	schema := RuleFieldSchema{
+		Field:       field,
+		Description: description,
+		Metadata:    metadata,
+		Operators:   operators,
+		Samples:     make([]RuleFieldSample, 0, len(values)),
+	}
+	for value, count := range values {
+		schema.Devices += count
+		schema.Samples = append(schema.Samples, RuleFieldSample{Value: value, Count: count})
+	}
+
+	// 件数の多い値から。同数なら値の順
+	sort.Slice(schema.Samples, func(i, j int) bool {
+		if schema.Samples[i].Count != schema.Samples[j].Count {
+			return schema.Samples[i].Count > schema.Samples[j].Count
+		}
+		return schema.Samples[i].Value < schema.Samples[j].Value
+	})
+	if len(schema.Samples) > maxSamples {
+		schema.Samples = schema.Samples[:maxSamples]
+	}
+	return schema
+}
@@ -0,0 +1,62 @@
+package classification
+
+import (
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+func TestBuildRuleSchema(t *testing.T) {
+	devices := []topology.Device{
+		{ID: "tyo-core-1", Type: "router", Hardware: "MX480", Metadata: map[string]string{"site": "tyo", "role": "core"}},
+		{ID: "tyo-leaf-1", Type: "switch", Hardware: "QFX5120", Metadata: map[string]string{"site": "tyo"}},
+		{ID: "osa-leaf-1", Type: "switch", Metadata: map[string]string{"site": "osa", "rack": ""}},
+	}
+
+	schema := BuildRuleSchema(devices, 1)
+
+	if schema.Devices != 3 {
+		t.Errorf("Expected 3 devices, got %d", schema.Devices)
+	}
+	if len(schema.Operators) != len(RuleOperators) {
+		t.Errorf("Expected %d operators, got %d", len(RuleOperators), len(schema.Operators))
+	}
+
+	var fields []string
+	for _, field := range schema.Fields {
+		fields = append(fields, field.Field)
+	}
+	want := []string{"name", "hardware", "type", "metadata.role", "metadata.site"}
+	if len(fields) != len(want) {
+		t.Fatalf("Expected fields %v, got %v", want, fields)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Fatalf("Expected fields %v, got %v", want, fields)
+		}
+	}
+
+	hardware := schema.Fields[1]
+	if hardware.Devices != 2 || hardware.Metadata {
+		t.Errorf("Expected hardware on 2 devices, got %+v", hardware)
+	}
+	site := schema.Fields[4]
+	if !site.Metadata || site.Devices != 3 || len(site.Samples) != 1 || site.Samples[0] != (RuleFieldSample{Value: "tyo", Count: 2}) {
+		t.Errorf("Expected the most frequent site sample tyo, got %+v", site)
+	}
+	if len(site.Operators) != len(RuleOperators) {
+		t.Errorf("Expected every operator for metadata fields, got %v", site.Operators)
+	}
+}
+
+func TestMetadataKey(t *testing.T) {
+	if key, ok := MetadataKey("metadata.site"); !ok || key != "site" {
+		t.Errorf("Expected site, got %q %v", key, ok)
+	}
+	if _, ok := MetadataKey("metadata."); ok {
+		t.Error("Expected an empty key to be rejected")
+	}
+	if _, ok := MetadataKey("name"); ok {
+		t.Error("Expected a built-in field not to be a metadata field")
+	}
+}
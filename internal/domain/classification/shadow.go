@@ -0,0 +1,140 @@
+package classification
+
+import (
+	"sort"
+	"time"
+)
+
+// Shadow comparison statuses
+const (
+	ShadowStatusNew       = "new"       // 現在は未分類
+	ShadowStatusChanged   = "changed"   // 現在の分類と異なる
+	ShadowStatusUnchanged = "unchanged" // 現在の分類と同じ
+)
+
+// ShadowClassification is the classification a shadow rule would have given a device.
+// Shadow rules are evaluated in priority order with the live rules, so a shadow rule only
+// records devices it would have won.
+type ShadowClassification struct {
+	DeviceID    string    `json:"device_id"`
+	RuleID      string    `json:"rule_id"`
+	RuleName    string    `json:"rule_name"`
+	Layer       int       `json:"layer"`
+	DeviceType  string    `json:"device_type"`
+	EvaluatedAt time.Time `json:"evaluated_at"`
+}
+
+// ShadowComparison compares the classification of a shadow rule with the current classification of the device
+type ShadowComparison struct {
+	DeviceID            string    `json:"device_id"`
+	RuleID              string    `json:"rule_id"`
+	RuleName            string    `json:"rule_name"`
+	Status              string    `json:"status" enum:"new,changed,unchanged"`
+	CurrentLayer        *int      `json:"current_layer"` // nil = 未分類
+	CurrentDeviceType   string    `json:"current_device_type"`
+	CurrentClassifiedBy string    `json:"current_classified_by"`
+	ShadowLayer         int       `json:"shadow_layer"`
+	ShadowDeviceType    string    `json:"shadow_device_type"`
+	EvaluatedAt         time.Time `json:"evaluated_at"`
+}
+
+// CompareShadow compares a shadow classification with the current classification of its device.
+// A device without a layer or classified_by counts as unclassified.
+func CompareShadow(shadow ShadowClassification, currentLayer *int, currentDeviceType, currentClassifiedBy string) ShadowComparison {
+	comparison := ShadowComparison{
+		DeviceID:            shadow.DeviceID,
+		RuleID:              shadow.RuleID,
+		RuleName:            shadow.RuleName,
+		CurrentLayer:        currentLayer,
+		CurrentDeviceType:   currentDeviceType,
+		CurrentClassifiedBy: currentClassifiedBy,
+		ShadowLayer:         shadow.Layer,
+		ShadowDeviceType:    shadow.DeviceType,
+		EvaluatedAt:         shadow.EvaluatedAt,
+	}
+
+	switch {
+	case currentLayer == nil || currentClassifiedBy == "":
+		comparison.Status = ShadowStatusNew
+	case *currentLayer == shadow.Layer && currentDeviceType == shadow.DeviceType:
+		comparison.Status = ShadowStatusUnchanged
+	default:
+		comparison.Status = ShadowStatusChanged
+	}
+	return comparison
+}
+
+// ShadowRuleSummary counts the outcomes of one shadow rule
+type ShadowRuleSummary struct {
+	RuleID    string `json:"rule_id"`
+	RuleName  string `json:"rule_name"`
+	Matched   int    `json:"matched" doc:"Devices the rule would classify"`
+	New       int    `json:"new" doc:"Of those, devices currently unclassified"`
+	Changed   int    `json:"changed" doc:"Of those, devices currently classified differently"`
+	Unchanged int    `json:"unchanged" doc:"Of those, devices currently classified the same way"`
+}
+
+// ShadowReport compares what the shadow rules would classify with the current classifications
+type ShadowReport struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	Matched     int                 `json:"matched"`
+	New         int                 `json:"new"`
+	Changed     int                 `json:"changed"`
+	Unchanged   int                 `json:"unchanged"`
+	Rules       []ShadowRuleSummary `json:"rules"`
+	Devices     []ShadowComparison  `json:"devices"`
+}
+
+// NewShadowReport summarizes comparisons per rule. Rules are ordered by the number of devices
+// they would change; devices list changes first, then new classifications, then the rest, each
+// by device ID.
+func NewShadowReport(comparisons []ShadowComparison) ShadowReport {
+	report := ShadowReport{
+		GeneratedAt: time.Now(),
+		Rules:       []ShadowRuleSummary{},
+		Devices:     append([]ShadowComparison{}, comparisons...),
+	}
+
+	index := make(map[string]int)
+	for _, comparison := range comparisons {
+		i, ok := index[comparison.RuleID]
+		if !ok {
+			i = len(report.Rules)
+			index[comparison.RuleID] = i
+			report.Rules = append(report.Rules, ShadowRuleSummary{RuleID: comparison.RuleID, RuleName: comparison.RuleName})
+		}
+
+		summary := &report.Rules[i]
+		summary.Matched++
+		report.Matched++
+		switch comparison.Status {
+		case ShadowStatusNew:
+			summary.New++
+			report.New++
+		case ShadowStatusChanged:
+			summary.Changed++
+			report.Changed++
+		default:
+			summary.Unchanged++
+			report.Unchanged++
+		}
+	}
+
+	sort.SliceStable(report.Rules, func(i, j int) bool {
+		a, b := report.Rules[i], report.Rules[j]
+		if a.Changed+a.New != b.Changed+b.New {
+			return a.Changed+a.New > b.Changed+b.New
+		}
+		return a.RuleName < b.RuleName
+	})
+
+	statusOrder := map[string]int{ShadowStatusChanged: 0, ShadowStatusNew: 1, ShadowStatusUnchanged: 2}
+	sort.SliceStable(report.Devices, func(i, j int) bool {
+		a, b := report.Devices[i], report.Devices[j]
+		if statusOrder[a.Status] != statusOrder[b.Status] {
+			return statusOrder[a.Status] < statusOrder[b.Status]
+		}
+		return a.DeviceID < b.DeviceID
+	})
+	return report
+}
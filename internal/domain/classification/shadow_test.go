@@ -0,0 +1,71 @@
+package classification
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareShadow(t *testing.T) {
+	shadow := ShadowClassification{DeviceID: "leaf-01", RuleID: "r1", RuleName: "leaf", Layer: 3, DeviceType: "switch"}
+	layer3, layer2 := 3, 2
+
+	tests := []struct {
+		name         string
+		layer        *int
+		deviceType   string
+		classifiedBy string
+		want         string
+	}{
+		{"unclassified", nil, "", "", ShadowStatusNew},
+		{"layer without classified_by", &layer3, "switch", "", ShadowStatusNew},
+		{"same classification", &layer3, "switch", "rule:old-leaf", ShadowStatusUnchanged},
+		{"different layer", &layer2, "switch", "rule:old-leaf", ShadowStatusChanged},
+		{"different type", &layer3, "router", "rule:old-leaf", ShadowStatusChanged},
+	}
+
+	for _, tt := range tests {
+		got := CompareShadow(shadow, tt.layer, tt.deviceType, tt.classifiedBy)
+		if got.Status != tt.want {
+			t.Errorf("%s: expected status %s, got %s", tt.name, tt.want, got.Status)
+		}
+		if got.ShadowLayer != 3 || got.ShadowDeviceType != "switch" || got.RuleName != "leaf" {
+			t.Errorf("%s: expected shadow classification to be kept, got %+v", tt.name, got)
+		}
+	}
+}
+
+func TestNewShadowReport(t *testing.T) {
+	report := NewShadowReport([]ShadowComparison{
+		{DeviceID: "d", RuleID: "r1", RuleName: "leaf", Status: ShadowStatusUnchanged},
+		{DeviceID: "c", RuleID: "r2", RuleName: "spine", Status: ShadowStatusNew},
+		{DeviceID: "b", RuleID: "r1", RuleName: "leaf", Status: ShadowStatusNew},
+		{DeviceID: "a", RuleID: "r1", RuleName: "leaf", Status: ShadowStatusChanged},
+	})
+
+	if report.Matched != 4 || report.New != 2 || report.Changed != 1 || report.Unchanged != 1 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+
+	if len(report.Rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %d", len(report.Rules))
+	}
+	leaf := report.Rules[0]
+	if leaf.RuleID != "r1" || leaf.Matched != 3 || leaf.New != 1 || leaf.Changed != 1 || leaf.Unchanged != 1 {
+		t.Errorf("Expected the rule with most changes first, got %+v", leaf)
+	}
+
+	var order []string
+	for _, device := range report.Devices {
+		order = append(order, device.DeviceID)
+	}
+	if got := strings.Join(order, ","); got != "a,b,c,d" {
+		t.Errorf("Expected devices ordered changed, new, unchanged, got %s", got)
+	}
+}
+
+func TestNewShadowReport_Empty(t *testing.T) {
+	report := NewShadowReport(nil)
+	if report.Rules == nil || report.Devices == nil {
+		t.Error("Expected empty lists, not nil")
+	}
+}
@@ -0,0 +1,45 @@
+package classification
+
+import "context"
+
+// SuggestionFilter selects the pending suggestions a bulk action applies to. Empty fields match every suggestion.
+type SuggestionFilter struct {
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+	DeviceType    string  `json:"device_type,omitempty"`
+}
+
+// Matches reports whether the suggestion satisfies the filter
+func (f SuggestionFilter) Matches(suggestion ClassificationSuggestion) bool {
+	if suggestion.Confidence < f.MinConfidence {
+		return false
+	}
+	if f.DeviceType != "" && suggestion.Rule.DeviceType != f.DeviceType {
+		return false
+	}
+	return true
+}
+
+// SuggestionFailure is a suggestion a bulk action could not resolve
+type SuggestionFailure struct {
+	SuggestionID string `json:"suggestion_id"`
+	Error        string `json:"error"`
+}
+
+// BulkSuggestionResult summarizes a bulk accept or reject
+type BulkSuggestionResult struct {
+	Action        string              `json:"action"`
+	Filter        SuggestionFilter    `json:"filter"`
+	Matched       int                 `json:"matched"`
+	Accepted      int                 `json:"accepted"`
+	Rejected      int                 `json:"rejected"`
+	SuggestionIDs []string            `json:"suggestion_ids"` // 解決できた提案
+	Failed        []SuggestionFailure `json:"failed"`
+}
+
+// SuggestionBulkRepository is implemented by repositories that can resolve many suggestions in one transaction
+type SuggestionBulkRepository interface {
+	// ResolveSuggestions sets the status of pending suggestions in one transaction; accepting a
+	// suggestion also activates its rule. A suggestion that cannot be resolved (e.g. no longer pending)
+	// is rolled back alone and returned in failed, the others are committed together.
+	ResolveSuggestions(ctx context.Context, suggestionIDs []string, status SuggestionStatus) (resolved []string, failed []SuggestionFailure, err error)
+}
@@ -0,0 +1,24 @@
+package classification
+
+import "testing"
+
+func TestSuggestionFilter_Matches(t *testing.T) {
+	suggestion := ClassificationSuggestion{Confidence: 0.8, Rule: ClassificationRule{DeviceType: "switch"}}
+
+	tests := []struct {
+		filter SuggestionFilter
+		want   bool
+	}{
+		{SuggestionFilter{}, true},
+		{SuggestionFilter{MinConfidence: 0.8}, true},
+		{SuggestionFilter{MinConfidence: 0.9}, false},
+		{SuggestionFilter{DeviceType: "switch"}, true},
+		{SuggestionFilter{DeviceType: "router"}, false},
+		{SuggestionFilter{MinConfidence: 0.5, DeviceType: "router"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Matches(suggestion); got != tt.want {
+			t.Errorf("Matches(%+v) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package classification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SuggestionsEvent is the name of the notification sent when scheduled generation creates high-confidence suggestions
+const SuggestionsEvent = "classification.suggestions"
+
+// DefaultSuggestionNotifyConfidence is the confidence from which a new suggestion is notified
+const DefaultSuggestionNotifyConfidence = 0.9
+
+// SuggestionHistoryRepository is implemented by repositories that keep resolved suggestions, so that
+// scheduled generation does not suggest a rejected rule again
+type SuggestionHistoryRepository interface {
+	// ListClassificationSuggestionsByStatus returns the suggestions in any of statuses, by descending confidence
+	ListClassificationSuggestionsByStatus(ctx context.Context, statuses ...SuggestionStatus) ([]ClassificationSuggestion, error)
+}
+
+// SuggestionFingerprint identifies what the rule of a suggestion classifies, regardless of its ID and name,
+// so that a suggestion generated again is recognized
+func SuggestionFingerprint(rule ClassificationRule) string {
+	conditions := make([]string, 0, len(rule.Conditions))
+	for _, c := range rule.Conditions {
+		value := c.Value
+		if c.Operator != "regex" {
+			value = strings.ToLower(value) // regex 以外は大文字小文字を区別しない
+		}
+		conditions = append(conditions, c.Field+"\x00"+c.Operator+"\x00"+value)
+	}
+	sort.Strings(conditions)
+
+	parts := append([]string{
+		strings.ToUpper(rule.LogicOperator),
+		strconv.Itoa(rule.Layer),
+		strings.ToLower(rule.DeviceType),
+	}, conditions...)
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x01")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// SuggestionRun reports one scheduled suggestion generation
+type SuggestionRun struct {
+	Generated       int                        `json:"generated"`
+	Created         []ClassificationSuggestion `json:"created"`          // 保存した新しい提案（信頼度の高い順）
+	SkippedRejected int                        `json:"skipped_rejected"` // 却下済みの提案と同じ
+	SkippedExisting int                        `json:"skipped_existing"` // 保留中・採用済みの提案や既存のルールと同じ
+}
+
+// HighConfidence returns the created suggestions whose confidence is at least minConfidence
+func (r SuggestionRun) HighConfidence(minConfidence float64) []ClassificationSuggestion {
+	var suggestions []ClassificationSuggestion
+	for _, suggestion := range r.Created {
+		if suggestion.Confidence >= minConfidence {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
+
+// SuggestionNotification is sent when scheduled generation creates suggestions of at least MinConfidence
+type SuggestionNotification struct {
+	Event         string                     `json:"event" enum:"classification.suggestions"`
+	CreatedAt     time.Time                  `json:"created_at"`
+	MinConfidence float64                    `json:"min_confidence"`
+	Suggestions   []ClassificationSuggestion `json:"suggestions"`
+}
@@ -0,0 +1,60 @@
+package classification
+
+import "testing"
+
+func TestSuggestionFingerprint(t *testing.T) {
+	rule := ClassificationRule{
+		ID:            "a",
+		Name:          "Auto: Names starting with 'core'",
+		LogicOperator: "AND",
+		Conditions: []RuleCondition{
+			{Field: "name", Operator: "starts_with", Value: "core"},
+			{Field: "hardware", Operator: "equals", Value: "QFX5100"},
+		},
+		Layer:      2,
+		DeviceType: "core",
+	}
+	same := ClassificationRule{
+		ID:            "b",
+		Name:          "renamed",
+		LogicOperator: "and",
+		Conditions: []RuleCondition{
+			{Field: "hardware", Operator: "equals", Value: "qfx5100"},
+			{Field: "name", Operator: "starts_with", Value: "CORE"},
+		},
+		Layer:      2,
+		DeviceType: "Core",
+		Confidence: 0.5,
+	}
+	if SuggestionFingerprint(rule) != SuggestionFingerprint(same) {
+		t.Errorf("Expected the same fingerprint regardless of ID, name, order and case")
+	}
+
+	otherLayer := same
+	otherLayer.Layer = 3
+	regex := ClassificationRule{LogicOperator: "AND", Layer: 2, DeviceType: "core", Conditions: []RuleCondition{{Field: "name", Operator: "regex", Value: "^Core"}}}
+	regexLower := regex
+	regexLower.Conditions = []RuleCondition{{Field: "name", Operator: "regex", Value: "^core"}}
+	if SuggestionFingerprint(rule) == SuggestionFingerprint(otherLayer) {
+		t.Errorf("Expected rules of different layers to differ")
+	}
+	if SuggestionFingerprint(regex) == SuggestionFingerprint(regexLower) {
+		t.Errorf("Expected regular expressions to be case-sensitive")
+	}
+}
+
+func TestSuggestionRun_HighConfidence(t *testing.T) {
+	run := SuggestionRun{Created: []ClassificationSuggestion{
+		{ID: "s1", Confidence: 0.95},
+		{ID: "s2", Confidence: 0.9},
+		{ID: "s3", Confidence: 0.7},
+	}}
+
+	high := run.HighConfidence(0.9)
+	if len(high) != 2 || high[0].ID != "s1" || high[1].ID != "s2" {
+		t.Errorf("Expected s1 and s2, got %+v", high)
+	}
+	if high := run.HighConfidence(1); len(high) != 0 {
+		t.Errorf("Expected no suggestion, got %+v", high)
+	}
+}
@@ -0,0 +1,122 @@
+package classification
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ruleConditionFields are the condition fields of classification and tag rules besides metadata.<key>
+var ruleConditionFields = map[string]bool{"name": true, "hardware": true, "type": true}
+
+// TagRule sets metadata tags on the devices matching its conditions during synchronization, e.g.
+// env=prod and pod=pod7 for devices named prd-pod7-*. The conditions are those of classification rules.
+type TagRule struct {
+	Name          string            `json:"name" yaml:"name"`
+	LogicOperator string            `json:"logic" yaml:"logic"` // "AND"（既定）または "OR"
+	Conditions    []RuleCondition   `json:"conditions" yaml:"conditions"`
+	Tags          map[string]string `json:"tags" yaml:"tags"` // メタデータのキー → 値
+}
+
+// Validate checks the rule has conditions the classification engine understands and at least one tag
+func (r TagRule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.LogicOperator != "" && r.LogicOperator != "AND" && r.LogicOperator != "OR" {
+		return fmt.Errorf("logic must be AND or OR")
+	}
+	if len(r.Conditions) == 0 {
+		return fmt.Errorf("at least one condition is required")
+	}
+	for i, condition := range r.Conditions {
+		if err := validateRuleCondition(condition); err != nil {
+			return fmt.Errorf("condition %d: %w", i+1, err)
+		}
+	}
+	if len(r.Tags) == 0 {
+		return fmt.Errorf("at least one tag is required")
+	}
+	for key := range r.Tags {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("tag keys cannot be empty")
+		}
+	}
+	return nil
+}
+
+// ValidateTagRules checks every rule and that rule names are unique
+func ValidateTagRules(rules []TagRule) error {
+	names := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %d (%s): %w", i, rule.Name, err)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("rule %s is declared twice", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return nil
+}
+
+func validateRuleCondition(condition RuleCondition) error {
+	if _, ok := MetadataKey(condition.Field); !ok && !ruleConditionFields[condition.Field] {
+		return fmt.Errorf("unknown field '%s' (expected name, hardware, type or metadata.<key>)", condition.Field)
+	}
+	switch condition.Operator {
+	case "contains", "starts_with", "ends_with", "equals":
+	case "regex":
+		if _, err := regexp.Compile(condition.Value); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown operator '%s'", condition.Operator)
+	}
+	return nil
+}
+
+// MatchedTags returns the tags of the rules for which matches reports true. Rules are taken in
+// order, so the first matching rule setting a key decides its value.
+func MatchedTags(rules []TagRule, matches func(TagRule) bool) map[string]string {
+	var tags map[string]string
+	for _, rule := range rules {
+		if !matches(rule) {
+			continue
+		}
+		for key, value := range rule.Tags {
+			if _, set := tags[key]; set {
+				continue
+			}
+			if tags == nil {
+				tags = make(map[string]string, len(rule.Tags))
+			}
+			tags[key] = value
+		}
+	}
+	return tags
+}
+
+// ApplyTags returns metadata with tags set, replacing the values of existing keys, and whether
+// anything changed. metadata is not modified, so maps shared between devices stay intact.
+func ApplyTags(metadata, tags map[string]string) (map[string]string, bool) {
+	changed := false
+	for key, value := range tags {
+		if current, ok := metadata[key]; !ok || current != value {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return metadata, false
+	}
+
+	tagged := make(map[string]string, len(metadata)+len(tags))
+	for key, value := range metadata {
+		tagged[key] = value
+	}
+	for key, value := range tags {
+		tagged[key] = value
+	}
+	return tagged, true
+}
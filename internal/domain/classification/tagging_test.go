@@ -0,0 +1,70 @@
+package classification
+
+import "testing"
+
+func TestTagRule_Validate(t *testing.T) {
+	valid := TagRule{
+		Name:       "prod",
+		Conditions: []RuleCondition{{Field: "metadata.site", Operator: "regex", Value: "^prd-"}},
+		Tags:       map[string]string{"env": "prod"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Expected a valid rule, got %v", err)
+	}
+
+	invalid := map[string]TagRule{
+		"no name":       {Conditions: valid.Conditions, Tags: valid.Tags},
+		"logic":         {Name: "x", LogicOperator: "XOR", Conditions: valid.Conditions, Tags: valid.Tags},
+		"no conditions": {Name: "x", Tags: valid.Tags},
+		"field":         {Name: "x", Conditions: []RuleCondition{{Field: "metadata.", Operator: "equals"}}, Tags: valid.Tags},
+		"operator":      {Name: "x", Conditions: []RuleCondition{{Field: "name", Operator: "like"}}, Tags: valid.Tags},
+		"regex":         {Name: "x", Conditions: []RuleCondition{{Field: "name", Operator: "regex", Value: "("}}, Tags: valid.Tags},
+		"no tags":       {Name: "x", Conditions: valid.Conditions},
+		"empty key":     {Name: "x", Conditions: valid.Conditions, Tags: map[string]string{" ": "v"}},
+	}
+	for name, rule := range invalid {
+		if err := rule.Validate(); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+
+	if err := ValidateTagRules([]TagRule{valid, valid}); err == nil {
+		t.Error("Expected duplicate rule names to be rejected")
+	}
+}
+
+func TestMatchedTags(t *testing.T) {
+	rules := []TagRule{
+		{Name: "pod7", Tags: map[string]string{"pod": "pod7"}},
+		{Name: "prod", Tags: map[string]string{"env": "prod", "pod": "other"}},
+		{Name: "lab", Tags: map[string]string{"env": "lab"}},
+	}
+	matching := map[string]bool{"pod7": true, "prod": true}
+
+	tags := MatchedTags(rules, func(rule TagRule) bool { return matching[rule.Name] })
+	if len(tags) != 2 || tags["pod"] != "pod7" || tags["env"] != "prod" {
+		t.Errorf("Expected the first matching rule to win per key, got %v", tags)
+	}
+	if tags := MatchedTags(rules, func(TagRule) bool { return false }); tags != nil {
+		t.Errorf("Expected no tags, got %v", tags)
+	}
+}
+
+func TestApplyTags(t *testing.T) {
+	shared := map[string]string{"site": "tyo", "env": "lab"}
+
+	tagged, changed := ApplyTags(shared, map[string]string{"env": "prod"})
+	if !changed || tagged["env"] != "prod" || tagged["site"] != "tyo" {
+		t.Errorf("Expected env to be replaced, got %v (changed %t)", tagged, changed)
+	}
+	if shared["env"] != "lab" {
+		t.Errorf("Expected the original metadata to be kept, got %v", shared)
+	}
+
+	if _, changed := ApplyTags(tagged, map[string]string{"env": "prod"}); changed {
+		t.Error("Expected no change when the tags are already set")
+	}
+	if tagged, changed := ApplyTags(nil, map[string]string{"pod": "pod7"}); !changed || tagged["pod"] != "pod7" {
+		t.Errorf("Expected tags on a device without metadata, got %v", tagged)
+	}
+}
@@ -0,0 +1,95 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"    // 実行待ち（visible_at 以降に取得可能）
+	StatusRunning   = "running"   // ワーカーがリース中（visible_at はリースの期限）
+	StatusSucceeded = "succeeded" // 完了
+	StatusFailed    = "failed"    // 再試行回数を使い切って失敗
+)
+
+// Statuses lists the valid job statuses
+var Statuses = []string{StatusQueued, StatusRunning, StatusSucceeded, StatusFailed}
+
+// DefaultMaxAttempts is the number of attempts of a job unless the submitter sets one
+const DefaultMaxAttempts = 3
+
+// MaxAttemptsLimit bounds the number of attempts a submitter may request
+const MaxAttemptsLimit = 10
+
+// Retry backoff bounds
+const (
+	BaseRetryDelay = 30 * time.Second
+	MaxRetryDelay  = 30 * time.Minute
+)
+
+var kindPattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]*$`)
+
+// Job is a long-running task stored in the job queue so that it survives process restarts.
+// A worker claims a job by leasing it for a visibility timeout; when the worker dies the lease
+// expires and the job becomes visible to other workers again.
+type Job struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"` // 直近の失敗理由
+	LockedBy    string          `json:"locked_by,omitempty"`
+	VisibleAt   time.Time       `json:"visible_at"`
+	CreatedBy   string          `json:"created_by"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Validate checks the job can be enqueued
+func (j Job) Validate() error {
+	if !kindPattern.MatchString(j.Kind) {
+		return fmt.Errorf("kind must consist of lowercase letters, digits, '.', '-' and '_'")
+	}
+	if j.MaxAttempts < 1 || j.MaxAttempts > MaxAttemptsLimit {
+		return fmt.Errorf("max_attempts must be between 1 and %d, got %d", MaxAttemptsLimit, j.MaxAttempts)
+	}
+	if len(j.Payload) > 0 && !json.Valid(j.Payload) {
+		return fmt.Errorf("payload must be valid JSON")
+	}
+	return nil
+}
+
+// IsFinished reports whether the job will not run again
+func (j Job) IsFinished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// IsValidStatus reports whether s is a known job status
+func IsValidStatus(s string) bool {
+	for _, status := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryDelay returns how long a job waits before its next attempt after attempt failed.
+// The delay doubles with every attempt, starting at BaseRetryDelay and capped at MaxRetryDelay.
+func RetryDelay(attempt int) time.Duration {
+	delay := BaseRetryDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= MaxRetryDelay {
+			return MaxRetryDelay
+		}
+	}
+	return delay
+}
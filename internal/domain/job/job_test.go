@@ -0,0 +1,57 @@
+package job
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJob_Validate(t *testing.T) {
+	valid := Job{Kind: "report.hardware_compliance", MaxAttempts: DefaultMaxAttempts, Payload: json.RawMessage(`{"a":1}`)}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected job to be valid, got %v", err)
+	}
+
+	invalid := []Job{
+		{Kind: "Report", MaxAttempts: 1},
+		{Kind: "", MaxAttempts: 1},
+		{Kind: "snapshot", MaxAttempts: 0},
+		{Kind: "snapshot", MaxAttempts: MaxAttemptsLimit + 1},
+		{Kind: "snapshot", MaxAttempts: 1, Payload: json.RawMessage(`{`)},
+	}
+	for _, j := range invalid {
+		if err := j.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", j)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, MaxRetryDelay},
+		{100, MaxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := RetryDelay(tt.attempt); got != tt.expected {
+			t.Errorf("Expected delay %s after attempt %d, got %s", tt.expected, tt.attempt, got)
+		}
+	}
+}
+
+func TestJob_IsFinished(t *testing.T) {
+	for _, status := range Statuses {
+		finished := Job{Status: status}.IsFinished()
+		if expected := status == StatusSucceeded || status == StatusFailed; finished != expected {
+			t.Errorf("Expected IsFinished of %s to be %t", status, expected)
+		}
+	}
+	if IsValidStatus("done") {
+		t.Error("Expected unknown status to be invalid")
+	}
+}
@@ -0,0 +1,28 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Repository is implemented by repositories that store a durable job queue.
+// Lease times are computed with the database clock so that workers on several hosts agree.
+type Repository interface {
+	EnqueueJob(ctx context.Context, job Job) error
+	// ClaimJob leases the oldest visible job of one of kinds to worker for visibility and
+	// counts an attempt. Running jobs whose lease expired are visible again; those that used
+	// up their attempts are marked failed instead. It returns nil when no job is visible.
+	ClaimJob(ctx context.Context, worker string, kinds []string, visibility time.Duration) (*Job, error)
+	// ExtendJobLease returns false when worker no longer holds the lease
+	ExtendJobLease(ctx context.Context, id, worker string, visibility time.Duration) (bool, error)
+	// CompleteJob returns false when worker no longer holds the lease
+	CompleteJob(ctx context.Context, id, worker string, result json.RawMessage) (bool, error)
+	// FailJob records a failed attempt. The job is queued again after retryDelay, or marked
+	// failed when it used up its attempts. It returns false when worker no longer holds the lease.
+	FailJob(ctx context.Context, id, worker, message string, retryDelay time.Duration) (bool, error)
+	// GetJob returns nil when the job does not exist
+	GetJob(ctx context.Context, id string) (*Job, error)
+	// ListJobs returns the newest jobs first, optionally restricted to a status
+	ListJobs(ctx context.Context, status string, limit int) ([]Job, error)
+}
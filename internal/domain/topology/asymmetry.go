@@ -0,0 +1,67 @@
+package topology
+
+import "sort"
+
+// AsymmetricLink is a link observed from only one of its endpoints
+// (A reports B as a neighbor, but B never reports A). This usually means
+// LLDP is disabled or filtered on the silent side.
+type AsymmetricLink struct {
+	Link         Link   `json:"link"`
+	ReportedBy   string `json:"reported_by"`
+	SilentDevice string `json:"silent_device"`
+}
+
+// FindAsymmetricLinks returns the links in links that have no reverse observation.
+// Ports are compared when both sides know them; an unknown port matches any port.
+func FindAsymmetricLinks(links []Link) []AsymmetricLink {
+	observed := reverseObserved(links)
+
+	var asymmetric []AsymmetricLink
+	for i, link := range links {
+		if link.SourceID == link.TargetID || observed[i] {
+			continue
+		}
+		asymmetric = append(asymmetric, AsymmetricLink{
+			Link:         link,
+			ReportedBy:   link.SourceID,
+			SilentDevice: link.TargetID,
+		})
+	}
+
+	sort.Slice(asymmetric, func(i, j int) bool {
+		if asymmetric[i].ReportedBy != asymmetric[j].ReportedBy {
+			return asymmetric[i].ReportedBy < asymmetric[j].ReportedBy
+		}
+		return asymmetric[i].Link.SourcePort < asymmetric[j].Link.SourcePort
+	})
+
+	return asymmetric
+}
+
+// reverseObserved reports for each link whether the target side also reported it
+func reverseObserved(links []Link) []bool {
+	byPair := make(map[string][]Link, len(links))
+	for _, link := range links {
+		key := link.SourceID + "|" + link.TargetID
+		byPair[key] = append(byPair[key], link)
+	}
+
+	observed := make([]bool, len(links))
+	for i, link := range links {
+		for _, reverse := range byPair[link.TargetID+"|"+link.SourceID] {
+			if portsMatch(link.SourcePort, reverse.TargetPort) && portsMatch(link.TargetPort, reverse.SourcePort) {
+				observed[i] = true
+				break
+			}
+		}
+	}
+	return observed
+}
+
+func portsMatch(a, b string) bool {
+	// ポート名が取得できていない場合（同期時に UnknownPort で補完）は比較しない
+	if a == "" || b == "" || a == UnknownPort || b == UnknownPort {
+		return true
+	}
+	return a == b
+}
@@ -0,0 +1,61 @@
+package topology
+
+import (
+	"testing"
+)
+
+func TestFindAsymmetricLinks_SymmetricPair(t *testing.T) {
+	links := []Link{
+		{ID: "l1", SourceID: "core-01", TargetID: "dist-01", SourcePort: "Ethernet1", TargetPort: "Ethernet49"},
+		{ID: "l2", SourceID: "dist-01", TargetID: "core-01", SourcePort: "Ethernet49", TargetPort: "Ethernet1"},
+	}
+
+	asymmetric := FindAsymmetricLinks(links)
+	if len(asymmetric) != 0 {
+		t.Errorf("Expected no asymmetric links, got %v", asymmetric)
+	}
+}
+
+func TestFindAsymmetricLinks_OneSided(t *testing.T) {
+	links := []Link{
+		{ID: "l1", SourceID: "core-01", TargetID: "dist-01", SourcePort: "Ethernet1", TargetPort: "Ethernet49"},
+		{ID: "l2", SourceID: "dist-01", TargetID: "core-01", SourcePort: "Ethernet49", TargetPort: "Ethernet1"},
+		{ID: "l3", SourceID: "dist-01", TargetID: "access-01", SourcePort: "Ethernet1", TargetPort: "Ethernet48"},
+	}
+
+	asymmetric := FindAsymmetricLinks(links)
+	if len(asymmetric) != 1 {
+		t.Fatalf("Expected 1 asymmetric link, got %d", len(asymmetric))
+	}
+	if asymmetric[0].Link.ID != "l3" {
+		t.Errorf("Expected link 'l3', got '%s'", asymmetric[0].Link.ID)
+	}
+	if asymmetric[0].ReportedBy != "dist-01" || asymmetric[0].SilentDevice != "access-01" {
+		t.Errorf("Expected dist-01 -> access-01, got %s -> %s", asymmetric[0].ReportedBy, asymmetric[0].SilentDevice)
+	}
+}
+
+func TestFindAsymmetricLinks_PortMismatch(t *testing.T) {
+	// 逆方向の観測はあるが別ポート同士のリンク
+	links := []Link{
+		{ID: "l1", SourceID: "core-01", TargetID: "dist-01", SourcePort: "Ethernet1", TargetPort: "Ethernet49"},
+		{ID: "l2", SourceID: "dist-01", TargetID: "core-01", SourcePort: "Ethernet50", TargetPort: "Ethernet2"},
+	}
+
+	asymmetric := FindAsymmetricLinks(links)
+	if len(asymmetric) != 2 {
+		t.Errorf("Expected 2 asymmetric links, got %d", len(asymmetric))
+	}
+}
+
+func TestFindAsymmetricLinks_UnknownPort(t *testing.T) {
+	links := []Link{
+		{ID: "l1", SourceID: "core-01", TargetID: "dist-01", SourcePort: "Ethernet1", TargetPort: "unknown"},
+		{ID: "l2", SourceID: "dist-01", TargetID: "core-01", SourcePort: "Ethernet49", TargetPort: "Ethernet1"},
+	}
+
+	asymmetric := FindAsymmetricLinks(links)
+	if len(asymmetric) != 0 {
+		t.Errorf("Expected unknown ports to match, got %v", asymmetric)
+	}
+}
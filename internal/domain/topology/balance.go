@@ -0,0 +1,247 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BalanceTiers selects the hierarchy layers that make up each tier of a spine/leaf fabric
+type BalanceTiers struct {
+	SpineLayers  []int `json:"spine_layers"`
+	LeafLayers   []int `json:"leaf_layers"`
+	ServerLayers []int `json:"server_layers"`
+}
+
+// DefaultBalanceTiers are the spine, leaf and server layers of the default hierarchy
+var DefaultBalanceTiers = BalanceTiers{
+	SpineLayers:  []int{30, 31, 32},
+	LeafLayers:   []int{40, 41},
+	ServerLayers: []int{50, 51},
+}
+
+// DefaultImbalanceRatio flags devices carrying 1.5x more (or less) than the median of their tier
+const DefaultImbalanceRatio = 1.5
+
+const (
+	ImbalanceOver  = "over"
+	ImbalanceUnder = "under"
+)
+
+// SpineBalance is the load of a spine
+type SpineBalance struct {
+	DeviceID  string `json:"device_id"`
+	Leaves    int    `json:"leaves"`
+	Imbalance string `json:"imbalance,omitempty"` // "over" or "under" the median of the spines
+}
+
+// LeafBalance is the load and the uplinks of a leaf.
+// Link weights are the path costs of the links, so a high uplink weight means slow uplinks.
+type LeafBalance struct {
+	DeviceID              string  `json:"device_id"`
+	Servers               int     `json:"servers"`
+	Spines                int     `json:"spines"`
+	AvgUplinkWeight       float64 `json:"avg_uplink_weight"`
+	ServerImbalance       string  `json:"server_imbalance,omitempty"`
+	SpineImbalance        string  `json:"spine_imbalance,omitempty"`
+	UplinkWeightImbalance string  `json:"uplink_weight_imbalance,omitempty"`
+}
+
+// BalanceReport describes how evenly servers spread over leaves and leaves over spines
+type BalanceReport struct {
+	Tiers                BalanceTiers   `json:"tiers"`
+	Ratio                float64        `json:"ratio"`
+	ServerCount          int            `json:"server_count"`
+	MedianLeavesPerSpine float64        `json:"median_leaves_per_spine"`
+	MedianServersPerLeaf float64        `json:"median_servers_per_leaf"`
+	MedianSpinesPerLeaf  float64        `json:"median_spines_per_leaf"`
+	MedianUplinkWeight   float64        `json:"median_uplink_weight"`
+	Spines               []SpineBalance `json:"spines"`
+	Leaves               []LeafBalance  `json:"leaves"`
+	// Findings describes every imbalanced device, e.g. "spine-03 carries 24 leaves, 2.0x the median of 12"
+	Findings []string `json:"findings"`
+}
+
+// AnalyzeBalance computes the spine/leaf balance of devices. Devices are placed in tiers by
+// their layer; a device is imbalanced when its count is more than ratio times the median of
+// its tier, or less than the median divided by ratio.
+func AnalyzeBalance(devices []Device, links []Link, tiers BalanceTiers, ratio float64) *BalanceReport {
+	if ratio <= 1 {
+		ratio = DefaultImbalanceRatio
+	}
+
+	tierOf := make(map[string]string, len(devices))
+	inLayers := func(layerID *int, layers []int) bool {
+		if layerID == nil {
+			return false
+		}
+		for _, layer := range layers {
+			if *layerID == layer {
+				return true
+			}
+		}
+		return false
+	}
+	for _, device := range devices {
+		switch {
+		case inLayers(device.LayerID, tiers.SpineLayers):
+			tierOf[device.ID] = "spine"
+		case inLayers(device.LayerID, tiers.LeafLayers):
+			tierOf[device.ID] = "leaf"
+		case inLayers(device.LayerID, tiers.ServerLayers):
+			tierOf[device.ID] = "server"
+		}
+	}
+
+	// 複数リンクで接続されていても対向機器は一度だけ数える
+	spineLeaves := make(map[string]map[string]bool)
+	leafSpines := make(map[string]map[string]bool)
+	leafServers := make(map[string]map[string]bool)
+	uplinkWeights := make(map[string][]float64)
+	servers := make(map[string]bool)
+	addPeer := func(set map[string]map[string]bool, device, peer string) {
+		if set[device] == nil {
+			set[device] = make(map[string]bool)
+		}
+		set[device][peer] = true
+	}
+
+	for _, link := range links {
+		source, target := tierOf[link.SourceID], tierOf[link.TargetID]
+		spine, leaf, server := "", "", ""
+		switch {
+		case source == "spine" && target == "leaf":
+			spine, leaf = link.SourceID, link.TargetID
+		case source == "leaf" && target == "spine":
+			spine, leaf = link.TargetID, link.SourceID
+		case source == "leaf" && target == "server":
+			leaf, server = link.SourceID, link.TargetID
+		case source == "server" && target == "leaf":
+			leaf, server = link.TargetID, link.SourceID
+		default:
+			continue
+		}
+
+		if spine != "" {
+			addPeer(spineLeaves, spine, leaf)
+			addPeer(leafSpines, leaf, spine)
+			uplinkWeights[leaf] = append(uplinkWeights[leaf], link.Weight)
+		} else {
+			addPeer(leafServers, leaf, server)
+			servers[server] = true
+		}
+	}
+
+	report := &BalanceReport{
+		Tiers:       tiers,
+		Ratio:       ratio,
+		ServerCount: len(servers),
+		Spines:      []SpineBalance{},
+		Leaves:      []LeafBalance{},
+		Findings:    []string{},
+	}
+
+	for _, device := range devices {
+		switch tierOf[device.ID] {
+		case "spine":
+			report.Spines = append(report.Spines, SpineBalance{DeviceID: device.ID, Leaves: len(spineLeaves[device.ID])})
+		case "leaf":
+			leaf := LeafBalance{
+				DeviceID: device.ID,
+				Servers:  len(leafServers[device.ID]),
+				Spines:   len(leafSpines[device.ID]),
+			}
+			if weights := uplinkWeights[device.ID]; len(weights) > 0 {
+				sum := 0.0
+				for _, weight := range weights {
+					sum += weight
+				}
+				leaf.AvgUplinkWeight = sum / float64(len(weights))
+			}
+			report.Leaves = append(report.Leaves, leaf)
+		}
+	}
+	sort.Slice(report.Spines, func(i, j int) bool { return report.Spines[i].DeviceID < report.Spines[j].DeviceID })
+	sort.Slice(report.Leaves, func(i, j int) bool { return report.Leaves[i].DeviceID < report.Leaves[j].DeviceID })
+
+	leavesPerSpine := make([]float64, len(report.Spines))
+	for i, spine := range report.Spines {
+		leavesPerSpine[i] = float64(spine.Leaves)
+	}
+	serversPerLeaf := make([]float64, len(report.Leaves))
+	spinesPerLeaf := make([]float64, len(report.Leaves))
+	var leafUplinkWeights []float64
+	for i, leaf := range report.Leaves {
+		serversPerLeaf[i] = float64(leaf.Servers)
+		spinesPerLeaf[i] = float64(leaf.Spines)
+		if leaf.Spines > 0 {
+			leafUplinkWeights = append(leafUplinkWeights, leaf.AvgUplinkWeight)
+		}
+	}
+	report.MedianLeavesPerSpine = median(leavesPerSpine)
+	report.MedianServersPerLeaf = median(serversPerLeaf)
+	report.MedianSpinesPerLeaf = median(spinesPerLeaf)
+	report.MedianUplinkWeight = median(leafUplinkWeights)
+
+	for i := range report.Spines {
+		spine := &report.Spines[i]
+		spine.Imbalance = imbalance(float64(spine.Leaves), report.MedianLeavesPerSpine, ratio)
+		if spine.Imbalance != "" {
+			report.Findings = append(report.Findings, describeImbalance(spine.DeviceID, "carries", float64(spine.Leaves), "leaves", report.MedianLeavesPerSpine))
+		}
+	}
+	for i := range report.Leaves {
+		leaf := &report.Leaves[i]
+		leaf.ServerImbalance = imbalance(float64(leaf.Servers), report.MedianServersPerLeaf, ratio)
+		if leaf.ServerImbalance != "" {
+			report.Findings = append(report.Findings, describeImbalance(leaf.DeviceID, "carries", float64(leaf.Servers), "servers", report.MedianServersPerLeaf))
+		}
+		// スパインへのアップリンクは少ない側のみ問題にする
+		if imbalance(float64(leaf.Spines), report.MedianSpinesPerLeaf, ratio) == ImbalanceUnder {
+			leaf.SpineImbalance = ImbalanceUnder
+			report.Findings = append(report.Findings, describeImbalance(leaf.DeviceID, "connects to", float64(leaf.Spines), "spines", report.MedianSpinesPerLeaf))
+		}
+		// アップリンクの重み（遅延・コスト）は大きい側のみ問題にする
+		if leaf.Spines > 0 && imbalance(leaf.AvgUplinkWeight, report.MedianUplinkWeight, ratio) == ImbalanceOver {
+			leaf.UplinkWeightImbalance = ImbalanceOver
+			report.Findings = append(report.Findings, describeImbalance(leaf.DeviceID, "has an average uplink weight of", leaf.AvgUplinkWeight, "", report.MedianUplinkWeight))
+		}
+	}
+
+	return report
+}
+
+// imbalance compares value with the median of its tier
+func imbalance(value, median, ratio float64) string {
+	if median <= 0 {
+		return ""
+	}
+	if value > median*ratio {
+		return ImbalanceOver
+	}
+	if value < median/ratio {
+		return ImbalanceUnder
+	}
+	return ""
+}
+
+func describeImbalance(deviceID, verb string, value float64, unit string, median float64) string {
+	if unit != "" {
+		unit = " " + unit
+	}
+	return fmt.Sprintf("%s %s %g%s, %.1fx the median of %g", deviceID, verb, value, unit, value/median, median)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
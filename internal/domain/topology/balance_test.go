@@ -0,0 +1,138 @@
+package topology
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAnalyzeBalance(t *testing.T) {
+	spineLayer, leafLayer, serverLayer := 32, 41, 50
+	tiers := BalanceTiers{SpineLayers: []int{spineLayer}, LeafLayers: []int{leafLayer}, ServerLayers: []int{serverLayer}}
+
+	var devices []Device
+	var links []Link
+	addDevice := func(id string, layer int) {
+		devices = append(devices, Device{ID: id, LayerID: &layer})
+	}
+	addLink := func(source, target string, weight float64) {
+		links = append(links, Link{ID: fmt.Sprintf("link-%d", len(links)), SourceID: source, TargetID: target, Weight: weight})
+	}
+
+	for _, spine := range []string{"spine-01", "spine-02", "spine-03"} {
+		addDevice(spine, spineLayer)
+	}
+	// leaf-01..06 は spine-01/02、leaf-07/08 は spine-03 のみに接続
+	for i := 1; i <= 8; i++ {
+		leaf := fmt.Sprintf("leaf-%02d", i)
+		addDevice(leaf, leafLayer)
+		if i <= 6 {
+			addLink("spine-01", leaf, 1)
+			addLink(leaf, "spine-02", 1)
+		} else {
+			addLink("spine-03", leaf, 1)
+			addLink("spine-03", leaf, 1) // 並列リンクは1台として数える
+		}
+	}
+	// leaf-01 は8台、それ以外のリーフは2台のサーバーを収容
+	servers := 0
+	for i := 1; i <= 8; i++ {
+		count := 2
+		if i == 1 {
+			count = 8
+		}
+		for j := 0; j < count; j++ {
+			servers++
+			server := fmt.Sprintf("server-%02d", servers)
+			addDevice(server, serverLayer)
+			addLink(fmt.Sprintf("leaf-%02d", i), server, 1)
+		}
+	}
+
+	report := AnalyzeBalance(devices, links, tiers, 0)
+
+	if report.Ratio != DefaultImbalanceRatio {
+		t.Errorf("Expected default ratio, got %v", report.Ratio)
+	}
+	if len(report.Spines) != 3 || len(report.Leaves) != 8 {
+		t.Fatalf("Expected 3 spines and 8 leaves, got %d and %d", len(report.Spines), len(report.Leaves))
+	}
+	if report.ServerCount != 22 {
+		t.Errorf("Expected 22 servers, got %d", report.ServerCount)
+	}
+	if report.MedianLeavesPerSpine != 6 {
+		t.Errorf("Expected median of 6 leaves per spine, got %v", report.MedianLeavesPerSpine)
+	}
+
+	spines := make(map[string]SpineBalance)
+	for _, spine := range report.Spines {
+		spines[spine.DeviceID] = spine
+	}
+	if spines["spine-03"].Leaves != 2 || spines["spine-03"].Imbalance != ImbalanceUnder {
+		t.Errorf("Expected spine-03 to be under with 2 leaves, got %+v", spines["spine-03"])
+	}
+	if spines["spine-01"].Imbalance != "" {
+		t.Errorf("Expected spine-01 to be balanced, got %+v", spines["spine-01"])
+	}
+
+	leaves := make(map[string]LeafBalance)
+	for _, leaf := range report.Leaves {
+		leaves[leaf.DeviceID] = leaf
+	}
+	if leaves["leaf-01"].Servers != 8 || leaves["leaf-01"].ServerImbalance != ImbalanceOver {
+		t.Errorf("Expected leaf-01 to be over on servers, got %+v", leaves["leaf-01"])
+	}
+	if leaves["leaf-02"].ServerImbalance != "" {
+		t.Errorf("Expected leaf-02 to be balanced on servers, got %+v", leaves["leaf-02"])
+	}
+	if leaves["leaf-07"].Spines != 1 || leaves["leaf-07"].SpineImbalance != ImbalanceUnder {
+		t.Errorf("Expected leaf-07 to be under on spines, got %+v", leaves["leaf-07"])
+	}
+	if len(report.Findings) == 0 {
+		t.Errorf("Expected findings for the imbalanced devices")
+	}
+}
+
+func TestAnalyzeBalance_SpineOverloaded(t *testing.T) {
+	spineLayer, leafLayer := 1, 2
+	tiers := BalanceTiers{SpineLayers: []int{spineLayer}, LeafLayers: []int{leafLayer}}
+
+	var devices []Device
+	var links []Link
+	for _, spine := range []string{"spine-01", "spine-02", "spine-03"} {
+		devices = append(devices, Device{ID: spine, LayerID: &spineLayer})
+	}
+	// spine-03 は他のスパインの2倍のリーフを収容
+	leavesPerSpine := map[string]int{"spine-01": 2, "spine-02": 2, "spine-03": 4}
+	n := 0
+	for _, spine := range []string{"spine-01", "spine-02", "spine-03"} {
+		for i := 0; i < leavesPerSpine[spine]; i++ {
+			n++
+			leaf := fmt.Sprintf("leaf-%02d", n)
+			devices = append(devices, Device{ID: leaf, LayerID: &leafLayer})
+			links = append(links, Link{ID: leaf, SourceID: spine, TargetID: leaf, Weight: 1})
+		}
+	}
+	// leaf-01 のアップリンクは他より遅い
+	links[0].Weight = 10
+
+	report := AnalyzeBalance(devices, links, tiers, 1.5)
+
+	for _, spine := range report.Spines {
+		want := ""
+		if spine.DeviceID == "spine-03" {
+			want = ImbalanceOver
+		}
+		if spine.Imbalance != want {
+			t.Errorf("Expected imbalance %q for %s, got %q", want, spine.DeviceID, spine.Imbalance)
+		}
+	}
+	for _, leaf := range report.Leaves {
+		want := ""
+		if leaf.DeviceID == "leaf-01" {
+			want = ImbalanceOver
+		}
+		if leaf.UplinkWeightImbalance != want {
+			t.Errorf("Expected uplink weight imbalance %q for %s, got %q", want, leaf.DeviceID, leaf.UplinkWeightImbalance)
+		}
+	}
+}
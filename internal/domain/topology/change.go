@@ -0,0 +1,51 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Tables whose changes are reported by a ChangeListener
+const (
+	ChangeTableDevices = "devices"
+	ChangeTableLinks   = "links"
+)
+
+// Change operations
+const (
+	ChangeOpInsert = "INSERT"
+	ChangeOpUpdate = "UPDATE"
+	ChangeOpDelete = "DELETE"
+	// ChangeOpResync means changes may have been missed; consumers reload what they hold
+	ChangeOpResync = "RESYNC"
+)
+
+// Change is a device or link written by any writer
+type Change struct {
+	Table      string    `json:"table,omitempty" enum:"devices,links"`
+	Op         string    `json:"op" enum:"INSERT,UPDATE,DELETE,RESYNC"`
+	ID         string    `json:"id,omitempty"` // デバイスIDまたはリンクID
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// ParseChange decodes the payload of a change notification ({"table","op","id"})
+func ParseChange(payload string, receivedAt time.Time) (Change, error) {
+	var change Change
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		return Change{}, fmt.Errorf("invalid change payload: %w", err)
+	}
+	if change.Table != ChangeTableDevices && change.Table != ChangeTableLinks {
+		return Change{}, fmt.Errorf("unknown table '%s' in change payload", change.Table)
+	}
+	switch change.Op {
+	case ChangeOpInsert, ChangeOpUpdate, ChangeOpDelete:
+	default:
+		return Change{}, fmt.Errorf("unknown operation '%s' in change payload", change.Op)
+	}
+	if change.ID == "" {
+		return Change{}, fmt.Errorf("change payload has no id")
+	}
+	change.ReceivedAt = receivedAt
+	return change, nil
+}
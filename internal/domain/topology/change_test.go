@@ -0,0 +1,29 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseChange(t *testing.T) {
+	now := time.Now()
+	change, err := ParseChange(`{"table":"links","op":"DELETE","id":"leaf-01:et1-spine-01:et3"}`, now)
+	if err != nil {
+		t.Fatalf("Expected a valid payload, got %v", err)
+	}
+	if change.Table != ChangeTableLinks || change.Op != ChangeOpDelete || change.ID != "leaf-01:et1-spine-01:et3" || !change.ReceivedAt.Equal(now) {
+		t.Errorf("Unexpected change %+v", change)
+	}
+
+	for _, payload := range []string{
+		`not json`,
+		`{"table":"hardware_catalog","op":"INSERT","id":"x"}`,
+		`{"table":"devices","op":"TRUNCATE","id":"x"}`,
+		`{"table":"devices","op":"INSERT"}`,
+		`{"table":"devices","op":"RESYNC","id":"x"}`, // 再同期は通知ではなくリスナーが発行する
+	} {
+		if _, err := ParseChange(payload, now); err == nil {
+			t.Errorf("Expected payload %s to be rejected", payload)
+		}
+	}
+}
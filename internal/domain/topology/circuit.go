@@ -0,0 +1,135 @@
+package topology
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Circuit is a physical cable or carrier circuit identified by the ID the facilities team or provider uses.
+// It is attached to the link whose endpoints match its A and Z ends.
+type Circuit struct {
+	ID          string    `json:"id"` // 回線ID・ケーブルID（例: XC-102938）
+	Provider    string    `json:"provider,omitempty"`
+	ADevice     string    `json:"a_device"`
+	APort       string    `json:"a_port,omitempty"`
+	ZDevice     string    `json:"z_device"`
+	ZPort       string    `json:"z_port,omitempty"`
+	LinkID      string    `json:"link_id,omitempty"` // 両端に一致するリンク（見つからなければ空）
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Validate checks that the circuit has an ID and both ends
+func (c Circuit) Validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("circuit id is required")
+	}
+	if c.ADevice == "" || c.ZDevice == "" {
+		return fmt.Errorf("circuit %s: a_device and z_device are required", c.ID)
+	}
+	return nil
+}
+
+// MatchesLink reports whether the ends of the circuit are the ends of link, in either direction.
+// An empty port on the circuit matches any port of the device.
+func (c Circuit) MatchesLink(link Link) bool {
+	matchEnd := func(device, port, linkDevice, linkPort string) bool {
+		return device == linkDevice && (port == "" || port == linkPort)
+	}
+	if matchEnd(c.ADevice, c.APort, link.SourceID, link.SourcePort) && matchEnd(c.ZDevice, c.ZPort, link.TargetID, link.TargetPort) {
+		return true
+	}
+	return matchEnd(c.ADevice, c.APort, link.TargetID, link.TargetPort) && matchEnd(c.ZDevice, c.ZPort, link.SourceID, link.SourcePort)
+}
+
+// circuitCSVColumns are the recognized CSV header names
+var circuitCSVColumns = []string{"circuit_id", "provider", "a_device", "a_port", "z_device", "z_port", "description"}
+
+// ParseCircuitsCSV reads circuits from CSV with a header row. Columns may appear in any order;
+// circuit_id, a_device and z_device are required and unknown columns are ignored.
+func ParseCircuitsCSV(r io.Reader) ([]Circuit, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("CSV is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"circuit_id", "a_device", "z_device"} {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing column '%s' (expected %s)", required, strings.Join(circuitCSVColumns, ","))
+		}
+	}
+
+	var circuits []Circuit
+	seen := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			i, ok := index[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		circuit := Circuit{
+			ID:          field("circuit_id"),
+			Provider:    field("provider"),
+			ADevice:     field("a_device"),
+			APort:       field("a_port"),
+			ZDevice:     field("z_device"),
+			ZPort:       field("z_port"),
+			Description: field("description"),
+		}
+		if circuit.ID == "" && circuit.ADevice == "" && circuit.ZDevice == "" {
+			continue // 空行
+		}
+		if err := circuit.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if previous, ok := seen[circuit.ID]; ok {
+			return nil, fmt.Errorf("line %d: circuit %s is already defined on line %d", line, circuit.ID, previous)
+		}
+		seen[circuit.ID] = line
+
+		circuits = append(circuits, circuit)
+	}
+
+	return circuits, nil
+}
+
+// CircuitImportResult reports the outcome of a circuit import
+type CircuitImportResult struct {
+	Imported int `json:"imported"`
+	Attached int `json:"attached"`
+	// Unattached lists the circuits whose ends match no known link
+	Unattached []string `json:"unattached"`
+}
+
+// LinkDetail is a link with the circuits attached to it
+type LinkDetail struct {
+	Link
+	Circuits []Circuit `json:"circuits"`
+}
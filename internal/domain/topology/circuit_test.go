@@ -0,0 +1,60 @@
+package topology
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCircuitsCSV(t *testing.T) {
+	input := `provider, circuit_id, a_device, a_port, z_device, z_port, rack
+NTT, XC-1001, core-01, et-0/0/1, dist-01, Ethernet1, R12
+,XC-1002,dist-01,,access-01,,
+
+`
+	circuits, err := ParseCircuitsCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(circuits) != 2 {
+		t.Fatalf("Expected 2 circuits, got %d", len(circuits))
+	}
+	if circuits[0].ID != "XC-1001" || circuits[0].Provider != "NTT" || circuits[0].APort != "et-0/0/1" || circuits[0].ZPort != "Ethernet1" {
+		t.Errorf("Unexpected first circuit: %+v", circuits[0])
+	}
+	if circuits[1].ID != "XC-1002" || circuits[1].APort != "" {
+		t.Errorf("Unexpected second circuit: %+v", circuits[1])
+	}
+}
+
+func TestParseCircuitsCSV_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"circuit_id,a_device\nXC-1,core-01\n",
+		"circuit_id,a_device,z_device\nXC-1,core-01,\n",
+		"circuit_id,a_device,z_device\nXC-1,core-01,dist-01\nXC-1,core-02,dist-02\n",
+	} {
+		if _, err := ParseCircuitsCSV(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected error for CSV %q", input)
+		}
+	}
+}
+
+func TestCircuit_MatchesLink(t *testing.T) {
+	link := Link{SourceID: "core-01", SourcePort: "et-0/0/1", TargetID: "dist-01", TargetPort: "Ethernet1"}
+
+	tests := []struct {
+		circuit Circuit
+		want    bool
+	}{
+		{Circuit{ADevice: "core-01", APort: "et-0/0/1", ZDevice: "dist-01", ZPort: "Ethernet1"}, true},
+		{Circuit{ADevice: "dist-01", APort: "Ethernet1", ZDevice: "core-01", ZPort: "et-0/0/1"}, true},
+		{Circuit{ADevice: "core-01", ZDevice: "dist-01"}, true},
+		{Circuit{ADevice: "core-01", APort: "et-0/0/2", ZDevice: "dist-01"}, false},
+		{Circuit{ADevice: "core-01", ZDevice: "dist-02"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.circuit.MatchesLink(link); got != tt.want {
+			t.Errorf("MatchesLink(%+v) = %v, want %v", tt.circuit, got, tt.want)
+		}
+	}
+}
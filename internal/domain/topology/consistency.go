@@ -0,0 +1,177 @@
+package topology
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConsistencyIssueKind is a class of referential problem found by CheckConsistency
+type ConsistencyIssueKind string
+
+const (
+	// IssueDanglingLink is a link whose source or target device does not exist
+	IssueDanglingLink ConsistencyIssueKind = "dangling_link"
+	// IssueDuplicateLink is a link stored again under another ID (same direction and ports)
+	IssueDuplicateLink ConsistencyIssueKind = "duplicate_link"
+	// IssueUnknownLayer is a device whose layer_id references a hierarchy layer that does not exist
+	IssueUnknownLayer ConsistencyIssueKind = "unknown_layer"
+	// IssueOrphanedClassification is a device classified by a rule that no longer exists
+	IssueOrphanedClassification ConsistencyIssueKind = "orphaned_classification"
+)
+
+// ConsistencyIssueKinds lists every issue kind in the order they are reported
+var ConsistencyIssueKinds = []ConsistencyIssueKind{
+	IssueDanglingLink,
+	IssueDuplicateLink,
+	IssueUnknownLayer,
+	IssueOrphanedClassification,
+}
+
+// ParseConsistencyIssueKind validates an issue kind given on the command line
+func ParseConsistencyIssueKind(value string) (ConsistencyIssueKind, error) {
+	for _, kind := range ConsistencyIssueKinds {
+		if string(kind) == value {
+			return kind, nil
+		}
+	}
+	names := make([]string, len(ConsistencyIssueKinds))
+	for i, kind := range ConsistencyIssueKinds {
+		names[i] = string(kind)
+	}
+	return "", fmt.Errorf("unknown issue kind '%s' (expected one of: %s)", value, strings.Join(names, ", "))
+}
+
+// ConsistencyIssue is one problem found by CheckConsistency. LinkID or DeviceID names the
+// row the repair touches.
+type ConsistencyIssue struct {
+	Kind     ConsistencyIssueKind `json:"kind"`
+	LinkID   string               `json:"link_id,omitempty"`
+	DeviceID string               `json:"device_id,omitempty"`
+	Detail   string               `json:"detail"`
+	Repair   string               `json:"repair"`
+}
+
+// ConsistencyReport is the result of a consistency check and the repairs made
+type ConsistencyReport struct {
+	Devices  int                          `json:"devices"`
+	Links    int                          `json:"links"`
+	Issues   []ConsistencyIssue           `json:"issues"`
+	Counts   map[ConsistencyIssueKind]int `json:"counts"`
+	Repaired map[ConsistencyIssueKind]int `json:"repaired,omitempty"`
+	DryRun   bool                         `json:"dry_run,omitempty"`
+}
+
+// ConsistencyRepository is implemented by repositories that can read and repair rows that
+// the regular accessors skip, such as links whose devices are gone
+type ConsistencyRepository interface {
+	// ListAllLinks returns every stored link, including links whose devices no longer exist
+	ListAllLinks(ctx context.Context) ([]Link, error)
+	// RemoveLinks deletes the links with the given IDs and returns how many were deleted
+	RemoveLinks(ctx context.Context, linkIDs []string) (int, error)
+	// ClearDeviceClassifications resets the layer and device type of the devices, records them
+	// as classified by "system:auto" like other unclassified devices, and returns how many were changed
+	ClearDeviceClassifications(ctx context.Context, deviceIDs []string) (int, error)
+}
+
+// CheckConsistency scans devices and links for referential problems. layerIDs holds the
+// existing hierarchy layers and ruleNames the existing classification rules.
+func CheckConsistency(devices []Device, links []Link, layerIDs map[int]bool, ruleNames map[string]bool) []ConsistencyIssue {
+	var issues []ConsistencyIssue
+
+	deviceIDs := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		deviceIDs[device.ID] = true
+	}
+
+	// リンクは ID 順に並べ、重複のうち残すものを決定的にする
+	sorted := make([]Link, len(links))
+	copy(sorted, links)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	kept := make(map[string]Link)
+	for _, link := range sorted {
+		var missing []string
+		for _, id := range []string{link.SourceID, link.TargetID} {
+			if !deviceIDs[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			issues = append(issues, ConsistencyIssue{
+				Kind:   IssueDanglingLink,
+				LinkID: link.ID,
+				Detail: fmt.Sprintf("%s -> %s references missing device %s", link.SourceID, link.TargetID, strings.Join(missing, ", ")),
+				Repair: "delete link",
+			})
+			continue
+		}
+
+		key := duplicateLinkKey(link)
+		original, seen := kept[key]
+		if !seen {
+			kept[key] = link
+			continue
+		}
+		// 最後に観測された方を残す
+		duplicate := link
+		if link.LastSeen.After(original.LastSeen) {
+			kept[key] = link
+			duplicate = original
+		}
+		issues = append(issues, ConsistencyIssue{
+			Kind:   IssueDuplicateLink,
+			LinkID: duplicate.ID,
+			Detail: fmt.Sprintf("%s:%s -> %s:%s is also stored as %s", link.SourceID, link.SourcePort, link.TargetID, link.TargetPort, kept[key].ID),
+			Repair: "delete link",
+		})
+	}
+
+	sortedDevices := make([]Device, len(devices))
+	copy(sortedDevices, devices)
+	sort.Slice(sortedDevices, func(i, j int) bool { return sortedDevices[i].ID < sortedDevices[j].ID })
+
+	for _, device := range sortedDevices {
+		if device.LayerID != nil && !layerIDs[*device.LayerID] {
+			issues = append(issues, ConsistencyIssue{
+				Kind:     IssueUnknownLayer,
+				DeviceID: device.ID,
+				Detail:   fmt.Sprintf("layer_id %d does not exist", *device.LayerID),
+				Repair:   "clear classification",
+			})
+			continue
+		}
+		if strings.HasPrefix(device.ClassifiedBy, "rule:") && !ruleNames[strings.TrimPrefix(device.ClassifiedBy, "rule:")] {
+			issues = append(issues, ConsistencyIssue{
+				Kind:     IssueOrphanedClassification,
+				DeviceID: device.ID,
+				Detail:   fmt.Sprintf("classified by deleted %s", device.ClassifiedBy),
+				Repair:   "clear classification",
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issueKindOrder(issues[i].Kind) < issueKindOrder(issues[j].Kind)
+	})
+	return issues
+}
+
+// duplicateLinkKey identifies a link by direction and ports; port names are compared
+// case-insensitively and a missing port equals an empty one
+func duplicateLinkKey(link Link) string {
+	return strings.Join([]string{
+		link.SourceID, strings.ToLower(link.SourcePort),
+		link.TargetID, strings.ToLower(link.TargetPort),
+	}, "\x00")
+}
+
+func issueKindOrder(kind ConsistencyIssueKind) int {
+	for i, k := range ConsistencyIssueKinds {
+		if k == kind {
+			return i
+		}
+	}
+	return len(ConsistencyIssueKinds)
+}
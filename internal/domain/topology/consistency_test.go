@@ -0,0 +1,53 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	layer, deleted := 1, 9
+	now := time.Now()
+	devices := []Device{
+		{ID: "core-01", LayerID: &layer, ClassifiedBy: "user:admin"},
+		{ID: "leaf-01", LayerID: &deleted, ClassifiedBy: "rule:leaf"},
+		{ID: "leaf-02", LayerID: &layer, ClassifiedBy: "rule:removed"},
+		{ID: "leaf-03", LayerID: &layer, ClassifiedBy: "rule:leaf"},
+	}
+	links := []Link{
+		{ID: "l1", SourceID: "core-01", TargetID: "leaf-01", SourcePort: "Eth1", TargetPort: "Eth49", LastSeen: now.Add(-time.Hour)},
+		{ID: "l2", SourceID: "core-01", TargetID: "leaf-01", SourcePort: "eth1", TargetPort: "ETH49", LastSeen: now},
+		{ID: "l3", SourceID: "leaf-01", TargetID: "core-01", SourcePort: "Eth49", TargetPort: "Eth1", LastSeen: now},
+		{ID: "l4", SourceID: "core-01", TargetID: "gone-01", SourcePort: "Eth2", TargetPort: "Eth1"},
+	}
+
+	issues := CheckConsistency(devices, links, map[int]bool{layer: true}, map[string]bool{"leaf": true})
+
+	expected := []struct {
+		kind   ConsistencyIssueKind
+		target string
+	}{
+		{IssueDanglingLink, "l4"},
+		{IssueDuplicateLink, "l1"}, // 新しく観測された l2 を残す
+		{IssueUnknownLayer, "leaf-01"},
+		{IssueOrphanedClassification, "leaf-02"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %d: %+v", len(expected), len(issues), issues)
+	}
+	for i, want := range expected {
+		target := issues[i].LinkID + issues[i].DeviceID
+		if issues[i].Kind != want.kind || target != want.target {
+			t.Errorf("Expected %s on %s at %d, got %s on %s", want.kind, want.target, i, issues[i].Kind, target)
+		}
+	}
+}
+
+func TestParseConsistencyIssueKind(t *testing.T) {
+	if kind, err := ParseConsistencyIssueKind("dangling_link"); err != nil || kind != IssueDanglingLink {
+		t.Errorf("Expected dangling_link, got %q, %v", kind, err)
+	}
+	if _, err := ParseConsistencyIssueKind("bogus"); err == nil {
+		t.Error("Expected an error for an unknown kind")
+	}
+}
@@ -0,0 +1,149 @@
+package topology
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CrossLink is a link with one end in set A and the other in set B, oriented from A to B
+type CrossLink struct {
+	LinkID       string  `json:"link_id"`
+	DeviceA      string  `json:"device_a"`
+	PortA        string  `json:"port_a"`
+	DeviceB      string  `json:"device_b"`
+	PortB        string  `json:"port_b"`
+	Speed        string  `json:"speed,omitempty"`         // リンクメタデータの speed そのまま
+	BandwidthBps float64 `json:"bandwidth_bps,omitempty"` // speed を解釈できない場合は 0
+}
+
+// CrossLinkPair aggregates the cross links between two devices
+type CrossLinkPair struct {
+	DeviceA      string  `json:"device_a"`
+	DeviceB      string  `json:"device_b"`
+	Links        int     `json:"links"`
+	BandwidthBps float64 `json:"bandwidth_bps"`
+}
+
+// CrossLinkReport lists the links crossing between two device sets with their aggregate bandwidth
+type CrossLinkReport struct {
+	FilterA           string          `json:"filter_a"`
+	FilterB           string          `json:"filter_b"`
+	DevicesA          int             `json:"devices_a"`
+	DevicesB          int             `json:"devices_b"`
+	TotalLinks        int             `json:"total_links"`
+	BandwidthBps      float64         `json:"bandwidth_bps"`
+	UnknownSpeedLinks int             `json:"unknown_speed_links"` // 帯域に含まれていないリンク
+	Pairs             []CrossLinkPair `json:"pairs"`
+	Links             []CrossLink     `json:"links"`
+}
+
+// FindCrossLinks returns the links with one end in setA and the other in setB. Each link is counted
+// once even if it appears more than once in links. The sets must not overlap.
+func FindCrossLinks(setA, setB map[string]bool, links []Link) CrossLinkReport {
+	report := CrossLinkReport{
+		DevicesA: len(setA),
+		DevicesB: len(setB),
+		Pairs:    []CrossLinkPair{},
+		Links:    []CrossLink{},
+	}
+
+	seen := make(map[string]bool)
+	pairs := make(map[[2]string]*CrossLinkPair)
+	for _, link := range links {
+		if seen[link.ID] {
+			continue
+		}
+
+		crossLink := CrossLink{LinkID: link.ID, Speed: link.Metadata["speed"]}
+		switch {
+		case setA[link.SourceID] && setB[link.TargetID]:
+			crossLink.DeviceA, crossLink.PortA = link.SourceID, link.SourcePort
+			crossLink.DeviceB, crossLink.PortB = link.TargetID, link.TargetPort
+		case setB[link.SourceID] && setA[link.TargetID]:
+			crossLink.DeviceA, crossLink.PortA = link.TargetID, link.TargetPort
+			crossLink.DeviceB, crossLink.PortB = link.SourceID, link.SourcePort
+		default:
+			continue
+		}
+		seen[link.ID] = true
+
+		if bps, ok := ParseLinkSpeed(crossLink.Speed); ok {
+			crossLink.BandwidthBps = bps
+			report.BandwidthBps += bps
+		} else {
+			report.UnknownSpeedLinks++
+		}
+		report.Links = append(report.Links, crossLink)
+
+		key := [2]string{crossLink.DeviceA, crossLink.DeviceB}
+		pair, ok := pairs[key]
+		if !ok {
+			pair = &CrossLinkPair{DeviceA: crossLink.DeviceA, DeviceB: crossLink.DeviceB}
+			pairs[key] = pair
+		}
+		pair.Links++
+		pair.BandwidthBps += crossLink.BandwidthBps
+	}
+
+	report.TotalLinks = len(report.Links)
+	sort.Slice(report.Links, func(i, j int) bool {
+		a, b := report.Links[i], report.Links[j]
+		if a.DeviceA != b.DeviceA {
+			return a.DeviceA < b.DeviceA
+		}
+		if a.DeviceB != b.DeviceB {
+			return a.DeviceB < b.DeviceB
+		}
+		return a.LinkID < b.LinkID
+	})
+	for _, pair := range pairs {
+		report.Pairs = append(report.Pairs, *pair)
+	}
+	sort.Slice(report.Pairs, func(i, j int) bool {
+		if report.Pairs[i].DeviceA != report.Pairs[j].DeviceA {
+			return report.Pairs[i].DeviceA < report.Pairs[j].DeviceA
+		}
+		return report.Pairs[i].DeviceB < report.Pairs[j].DeviceB
+	})
+
+	return report
+}
+
+// linkSpeedUnits maps speed suffixes to bits per second
+var linkSpeedUnits = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"t", 1e12},
+	{"g", 1e9},
+	{"m", 1e6},
+	{"k", 1e3},
+}
+
+// ParseLinkSpeed parses link speed metadata such as "100G", "400Gbps", "25 Gb/s" or "10000M" into
+// bits per second. A bare number is taken as Mbps, as reported by ifHighSpeed.
+func ParseLinkSpeed(speed string) (float64, bool) {
+	value := strings.ToLower(strings.TrimSpace(speed))
+	value = strings.TrimSuffix(value, "bps")
+	value = strings.TrimSuffix(value, "b/s")
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	multiplier := 1e6
+	for _, unit := range linkSpeedUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value = strings.TrimSpace(number)
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 {
+		return 0, false
+	}
+	return number * multiplier, true
+}
@@ -0,0 +1,69 @@
+package topology
+
+import "testing"
+
+func TestParseLinkSpeed(t *testing.T) {
+	tests := []struct {
+		speed    string
+		expected float64
+		ok       bool
+	}{
+		{"100G", 100e9, true},
+		{"400Gbps", 400e9, true},
+		{"25 Gb/s", 25e9, true},
+		{"1.6T", 1.6e12, true},
+		{"10000M", 10e9, true},
+		{"100000", 100e9, true}, // ifHighSpeed (Mbps)
+		{"", 0, false},
+		{"fast", 0, false},
+		{"-1G", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseLinkSpeed(tt.speed)
+		if ok != tt.ok || got != tt.expected {
+			t.Errorf("ParseLinkSpeed(%q): expected %v (%v), got %v (%v)", tt.speed, tt.expected, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestFindCrossLinks(t *testing.T) {
+	setA := map[string]bool{"spine-a1": true, "spine-a2": true}
+	setB := map[string]bool{"spine-b1": true}
+	links := []Link{
+		{ID: "l1", SourceID: "spine-a1", SourcePort: "et1", TargetID: "spine-b1", TargetPort: "et9", Metadata: map[string]string{"speed": "100G"}},
+		{ID: "l2", SourceID: "spine-b1", SourcePort: "et10", TargetID: "spine-a1", TargetPort: "et2", Metadata: map[string]string{"speed": "100G"}},
+		{ID: "l3", SourceID: "spine-a2", SourcePort: "et1", TargetID: "spine-b1", TargetPort: "et11"},
+		{ID: "l4", SourceID: "spine-a1", TargetID: "spine-a2", Metadata: map[string]string{"speed": "400G"}}, // A 内部
+		{ID: "l5", SourceID: "spine-b1", TargetID: "leaf-b1", Metadata: map[string]string{"speed": "400G"}},  // 集合外
+		{ID: "l1", SourceID: "spine-a1", SourcePort: "et1", TargetID: "spine-b1", TargetPort: "et9", Metadata: map[string]string{"speed": "100G"}},
+	}
+
+	report := FindCrossLinks(setA, setB, links)
+
+	if report.DevicesA != 2 || report.DevicesB != 1 {
+		t.Errorf("Expected 2 and 1 devices, got %d and %d", report.DevicesA, report.DevicesB)
+	}
+	if report.TotalLinks != 3 {
+		t.Fatalf("Expected 3 cross links, got %d", report.TotalLinks)
+	}
+	if report.BandwidthBps != 200e9 {
+		t.Errorf("Expected 200G aggregate bandwidth, got %v", report.BandwidthBps)
+	}
+	if report.UnknownSpeedLinks != 1 {
+		t.Errorf("Expected 1 link without speed, got %d", report.UnknownSpeedLinks)
+	}
+
+	// B→A で登録されたリンクも A→B の向きにそろえる
+	reversed := report.Links[1]
+	if reversed.LinkID != "l2" || reversed.DeviceA != "spine-a1" || reversed.PortA != "et2" || reversed.DeviceB != "spine-b1" || reversed.PortB != "et10" {
+		t.Errorf("Expected l2 oriented from A to B, got %+v", reversed)
+	}
+
+	if len(report.Pairs) != 2 {
+		t.Fatalf("Expected 2 device pairs, got %d", len(report.Pairs))
+	}
+	if pair := report.Pairs[0]; pair.DeviceA != "spine-a1" || pair.Links != 2 || pair.BandwidthBps != 200e9 {
+		t.Errorf("Unexpected pair: %+v", pair)
+	}
+}
@@ -0,0 +1,49 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Device event kinds
+const (
+	DeviceEventClassification = "classification"
+	DeviceEventWorkflow       = "workflow"
+)
+
+// DeviceEvent is one entry of the timeline of changes made to a device
+type DeviceEvent struct {
+	Kind      string    `json:"kind"`    // classification, workflow
+	Summary   string    `json:"summary"` // "onboarding → active" など
+	Reason    string    `json:"reason,omitempty"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// Event returns the transition as a device event
+func (t WorkflowTransition) Event() DeviceEvent {
+	return DeviceEvent{
+		Kind:      DeviceEventWorkflow,
+		Summary:   fmt.Sprintf("%s → %s", t.From, t.To),
+		Reason:    t.Reason,
+		ChangedBy: t.ChangedBy,
+		ChangedAt: t.ChangedAt,
+	}
+}
+
+// MergeDeviceEvents merges event lists into one timeline, newest first, keeping at most limit
+// events (limit <= 0 keeps all). Events at the same time keep the order of the lists.
+func MergeDeviceEvents(limit int, lists ...[]DeviceEvent) []DeviceEvent {
+	events := []DeviceEvent{}
+	for _, list := range lists {
+		events = append(events, list...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].ChangedAt.After(events[j].ChangedAt)
+	})
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
@@ -0,0 +1,41 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeDeviceEvents(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	classifications := []DeviceEvent{
+		{Kind: DeviceEventClassification, Summary: "c2", ChangedAt: base.Add(3 * time.Hour)},
+		{Kind: DeviceEventClassification, Summary: "c1", ChangedAt: base.Add(time.Hour)},
+	}
+	workflow := []DeviceEvent{
+		WorkflowTransition{From: WorkflowOnboarding, To: WorkflowActive, ChangedBy: "alice", ChangedAt: base.Add(2 * time.Hour)}.Event(),
+		WorkflowTransition{From: WorkflowDiscovered, To: WorkflowOnboarding, ChangedBy: "alice", ChangedAt: base.Add(time.Hour)}.Event(),
+	}
+
+	events := MergeDeviceEvents(0, classifications, workflow)
+	expected := []string{"c2", "onboarding → active", "c1", "discovered → onboarding"}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %+v", len(expected), events)
+	}
+	for i, summary := range expected {
+		if events[i].Summary != summary {
+			t.Errorf("Expected event %d to be %q, got %q", i, summary, events[i].Summary)
+		}
+	}
+	if events[1].Kind != DeviceEventWorkflow || events[1].ChangedBy != "alice" {
+		t.Errorf("Unexpected workflow event: %+v", events[1])
+	}
+
+	limited := MergeDeviceEvents(2, classifications, workflow)
+	if len(limited) != 2 || limited[1].Summary != "onboarding → active" {
+		t.Errorf("Expected the 2 newest events, got %+v", limited)
+	}
+
+	if empty := MergeDeviceEvents(10); empty == nil || len(empty) != 0 {
+		t.Errorf("Expected an empty timeline, got %+v", empty)
+	}
+}
@@ -0,0 +1,149 @@
+package topology
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeviceFilter selects devices by attributes.
+// Filters are written as comma-separated terms, e.g. "type=server,metadata.site=tyo1,last_seen<30d".
+type DeviceFilter struct {
+	Type           string            `json:"type,omitempty"`
+	Hardware       string            `json:"hardware,omitempty"`
+	DeviceType     string            `json:"device_type,omitempty"`
+	LayerID        *int              `json:"layer,omitempty"`
+	Provenance     string            `json:"provenance,omitempty"`
+	WorkflowState  string            `json:"workflow_state,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	LastSeenBefore time.Time         `json:"last_seen_before,omitempty"`
+}
+
+// ParseDeviceFilter parses a filter expression. last_seen terms are resolved relative to now.
+// Supported terms: type=X, hardware=X, device_type=X, layer=N, provenance=X, workflow_state=X,
+// metadata.KEY=X and last_seen<DURATION (e.g. 12h, 30d).
+func ParseDeviceFilter(expr string, now time.Time) (DeviceFilter, error) {
+	filter := DeviceFilter{Metadata: make(map[string]string)}
+
+	terms := 0
+	for _, term := range strings.Split(expr, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		terms++
+
+		if value, ok := strings.CutPrefix(term, "last_seen<"); ok {
+			age, err := parseAge(strings.TrimSpace(value))
+			if err != nil {
+				return DeviceFilter{}, fmt.Errorf("invalid last_seen term '%s': %w", term, err)
+			}
+			filter.LastSeenBefore = now.Add(-age)
+			continue
+		}
+
+		key, value, ok := strings.Cut(term, "=")
+		if !ok {
+			return DeviceFilter{}, fmt.Errorf("invalid term '%s' (expected key=value or last_seen<duration)", term)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case key == "type":
+			filter.Type = value
+		case key == "hardware":
+			filter.Hardware = value
+		case key == "device_type":
+			filter.DeviceType = value
+		case key == "layer":
+			layer, err := strconv.Atoi(value)
+			if err != nil {
+				return DeviceFilter{}, fmt.Errorf("invalid layer term '%s': layer must be a number", term)
+			}
+			filter.LayerID = &layer
+		case key == "provenance":
+			if !IsValidProvenance(value) {
+				return DeviceFilter{}, fmt.Errorf("unknown provenance '%s' (expected one of %s)", value, strings.Join(Provenances, ", "))
+			}
+			filter.Provenance = value
+		case key == "workflow_state":
+			if !IsValidWorkflowState(value) {
+				return DeviceFilter{}, fmt.Errorf("unknown workflow state '%s' (expected one of %s)", value, strings.Join(WorkflowStates, ", "))
+			}
+			filter.WorkflowState = value
+		case strings.HasPrefix(key, "metadata.") && len(key) > len("metadata."):
+			filter.Metadata[strings.TrimPrefix(key, "metadata.")] = value
+		default:
+			return DeviceFilter{}, fmt.Errorf("unknown filter key '%s' (expected type, hardware, device_type, layer, provenance, workflow_state, metadata.<key> or last_seen)", key)
+		}
+	}
+
+	if terms == 0 {
+		return DeviceFilter{}, fmt.Errorf("filter cannot be empty")
+	}
+
+	return filter, nil
+}
+
+// parseAge parses a duration that additionally accepts a day suffix (e.g. 30d)
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid number of days '%s'", days)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return age, nil
+}
+
+// Matches reports whether device satisfies every term of the filter
+func (f DeviceFilter) Matches(device Device) bool {
+	if f.Type != "" && device.Type != f.Type {
+		return false
+	}
+	if f.Hardware != "" && device.Hardware != f.Hardware {
+		return false
+	}
+	if f.DeviceType != "" && device.DeviceType != f.DeviceType {
+		return false
+	}
+	if f.LayerID != nil && (device.LayerID == nil || *device.LayerID != *f.LayerID) {
+		return false
+	}
+	if f.Provenance != "" && device.Provenance != f.Provenance {
+		return false
+	}
+	if f.WorkflowState != "" && EffectiveWorkflowState(device) != f.WorkflowState {
+		return false
+	}
+	for key, value := range f.Metadata {
+		if device.Metadata[key] != value {
+			return false
+		}
+	}
+	if !f.LastSeenBefore.IsZero() && !device.LastSeen.Before(f.LastSeenBefore) {
+		return false
+	}
+	return true
+}
+
+// DeviceDeletionResult reports the devices and links removed (or that would be removed) by a bulk deletion
+type DeviceDeletionResult struct {
+	DryRun               bool     `json:"dry_run"`
+	Devices              int      `json:"devices"`
+	Links                int      `json:"links"`
+	DeviceIDs            []string `json:"device_ids,omitempty"`
+	ConfirmationRequired bool     `json:"confirmation_required"`
+	ConfirmationToken    string   `json:"confirmation_token,omitempty"`
+}
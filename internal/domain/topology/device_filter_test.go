@@ -0,0 +1,104 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDeviceFilter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	filter, err := ParseDeviceFilter("type=server, metadata.site=tyo1, last_seen<30d", now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if filter.Type != "server" {
+		t.Errorf("Expected type 'server', got '%s'", filter.Type)
+	}
+	if filter.Metadata["site"] != "tyo1" {
+		t.Errorf("Expected metadata site 'tyo1', got '%s'", filter.Metadata["site"])
+	}
+	if want := now.Add(-30 * 24 * time.Hour); !filter.LastSeenBefore.Equal(want) {
+		t.Errorf("Expected last_seen before %s, got %s", want, filter.LastSeenBefore)
+	}
+}
+
+func TestParseDeviceFilter_Invalid(t *testing.T) {
+	for _, expr := range []string{"", " , ", "name=core-01", "type", "last_seen<soon", "last_seen<-1h", "metadata.=x", "provenance=guess", "workflow_state=retired", "layer=spine"} {
+		if _, err := ParseDeviceFilter(expr, time.Now()); err == nil {
+			t.Errorf("Expected error for filter %q", expr)
+		}
+	}
+}
+
+func TestDeviceFilter_Matches(t *testing.T) {
+	now := time.Now()
+	filter := DeviceFilter{
+		Type:           "server",
+		Metadata:       map[string]string{"site": "tyo1"},
+		LastSeenBefore: now.Add(-time.Hour),
+	}
+
+	stale := Device{ID: "srv-01", Type: "server", Metadata: map[string]string{"site": "tyo1"}, LastSeen: now.Add(-2 * time.Hour)}
+	if !filter.Matches(stale) {
+		t.Errorf("Expected stale server to match")
+	}
+
+	fresh := stale
+	fresh.LastSeen = now
+	if filter.Matches(fresh) {
+		t.Errorf("Expected recently seen server not to match")
+	}
+
+	otherSite := stale
+	otherSite.Metadata = map[string]string{"site": "osa1"}
+	if filter.Matches(otherSite) {
+		t.Errorf("Expected server at another site not to match")
+	}
+}
+
+func TestDeviceFilter_Provenance(t *testing.T) {
+	filter, err := ParseDeviceFilter("provenance=lldp-placeholder", time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !filter.Matches(Device{ID: "sw-01", Provenance: ProvenanceLLDPPlaceholder}) {
+		t.Errorf("Expected placeholder to match")
+	}
+	if filter.Matches(Device{ID: "sw-02", Provenance: ProvenancePrometheus}) {
+		t.Errorf("Expected monitored device not to match")
+	}
+}
+
+func TestDeviceFilter_WorkflowState(t *testing.T) {
+	filter, err := ParseDeviceFilter("workflow_state=discovered", time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !filter.Matches(Device{ID: "sw-01"}) {
+		t.Errorf("Expected device without a workflow state to count as discovered")
+	}
+	if filter.Matches(Device{ID: "sw-02", WorkflowState: WorkflowActive}) {
+		t.Errorf("Expected active device not to match")
+	}
+}
+
+func TestDeviceFilter_Layer(t *testing.T) {
+	filter, err := ParseDeviceFilter("layer=30,device_type=spine", time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	layer, otherLayer := 30, 40
+	if !filter.Matches(Device{ID: "spine-01", DeviceType: "spine", LayerID: &layer}) {
+		t.Errorf("Expected spine in layer 30 to match")
+	}
+	if filter.Matches(Device{ID: "leaf-01", DeviceType: "spine", LayerID: &otherLayer}) {
+		t.Errorf("Expected device in layer 40 not to match")
+	}
+	if filter.Matches(Device{ID: "spine-02", DeviceType: "spine"}) {
+		t.Errorf("Expected unclassified device not to match")
+	}
+}
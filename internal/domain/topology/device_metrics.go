@@ -0,0 +1,39 @@
+package topology
+
+import "time"
+
+// MetricDefinition describes a metric that can be fetched for a device through the API
+type MetricDefinition struct {
+	Name         string `json:"name"`
+	Unit         string `json:"unit,omitempty"`
+	Description  string `json:"description,omitempty"`
+	PerInterface bool   `json:"per_interface"` // interface の指定が必要
+}
+
+// MetricPoint is one sample of a metric series
+type MetricPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// MetricSeries is one time series returned for a metric
+type MetricSeries struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	Points []MetricPoint     `json:"points"`
+}
+
+// DeviceMetric is the result of a metric query for a device or one of its interfaces
+type DeviceMetric struct {
+	Metric    string         `json:"metric"`
+	DeviceID  string         `json:"device_id"`
+	Interface string         `json:"interface,omitempty"`
+	Unit      string         `json:"unit,omitempty"`
+	Start     *time.Time     `json:"start,omitempty"` // 範囲クエリのみ
+	End       time.Time      `json:"end"`
+	Step      string         `json:"step,omitempty"`
+	Series    []MetricSeries `json:"series"`
+	Truncated bool           `json:"truncated,omitempty"` // 系列数の上限で切り詰めた
+	Partial   bool           `json:"partial,omitempty"`   // バックエンドの一部が応答せず系列が欠けている
+	Cached    bool           `json:"cached"`
+	FetchedAt time.Time      `json:"fetched_at"`
+}
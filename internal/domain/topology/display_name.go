@@ -0,0 +1,75 @@
+package topology
+
+import (
+	"regexp"
+	"sort"
+	"time"
+)
+
+// DisplayNameOverride maps a device ID, or every device ID matching a pattern,
+// to a human-friendly name shown in visualizations. The canonical ID is unchanged.
+type DisplayNameOverride struct {
+	ID        string    `json:"id"`
+	DeviceID  string    `json:"device_id,omitempty"` // 完全一致（Pattern と排他）
+	Pattern   string    `json:"pattern,omitempty"`   // 正規表現（Name で $1 などのキャプチャを参照可能）
+	Name      string    `json:"name"`
+	Priority  int       `json:"priority"` // パターン同士では大きいほど優先
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DisplayNameResolver resolves display names from a set of overrides
+type DisplayNameResolver struct {
+	exact    map[string]string
+	patterns []compiledDisplayName
+}
+
+type compiledDisplayName struct {
+	re   *regexp.Regexp
+	name string
+}
+
+// NewDisplayNameResolver builds a resolver. Per-device overrides take precedence over
+// patterns; patterns are tried by priority. Overrides with invalid patterns are ignored.
+func NewDisplayNameResolver(overrides []DisplayNameOverride) *DisplayNameResolver {
+	resolver := &DisplayNameResolver{exact: make(map[string]string)}
+
+	var patterns []DisplayNameOverride
+	for _, override := range overrides {
+		if override.DeviceID != "" {
+			resolver.exact[override.DeviceID] = override.Name
+		} else if override.Pattern != "" {
+			patterns = append(patterns, override)
+		}
+	}
+
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return patterns[i].Priority > patterns[j].Priority
+	})
+	for _, override := range patterns {
+		re, err := regexp.Compile(override.Pattern)
+		if err != nil {
+			continue
+		}
+		resolver.patterns = append(resolver.patterns, compiledDisplayName{re: re, name: override.Name})
+	}
+
+	return resolver
+}
+
+// Resolve returns the display name of a device, or the device ID when no override matches
+func (r *DisplayNameResolver) Resolve(deviceID string) string {
+	if r == nil {
+		return deviceID
+	}
+	if name, ok := r.exact[deviceID]; ok {
+		return name
+	}
+	for _, pattern := range r.patterns {
+		if match := pattern.re.FindStringSubmatchIndex(deviceID); match != nil {
+			return string(pattern.re.ExpandString(nil, pattern.name, deviceID, match))
+		}
+	}
+	return deviceID
+}
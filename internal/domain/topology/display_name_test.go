@@ -0,0 +1,40 @@
+package topology
+
+import (
+	"testing"
+)
+
+func TestDisplayNameResolver_ExactOverridesPattern(t *testing.T) {
+	resolver := NewDisplayNameResolver([]DisplayNameOverride{
+		{Pattern: `^tyo-core-(\d+)$`, Name: "Tokyo Core $1"},
+		{DeviceID: "tyo-core-01", Name: "東京コア1"},
+	})
+
+	if name := resolver.Resolve("tyo-core-01"); name != "東京コア1" {
+		t.Errorf("Expected per-device override, got '%s'", name)
+	}
+	if name := resolver.Resolve("tyo-core-02"); name != "Tokyo Core 02" {
+		t.Errorf("Expected pattern override, got '%s'", name)
+	}
+	if name := resolver.Resolve("osa-core-01"); name != "osa-core-01" {
+		t.Errorf("Expected device ID fallback, got '%s'", name)
+	}
+}
+
+func TestDisplayNameResolver_PatternPriority(t *testing.T) {
+	resolver := NewDisplayNameResolver([]DisplayNameOverride{
+		{Pattern: `^tyo-`, Name: "Tokyo device", Priority: 0},
+		{Pattern: `^tyo-leaf-(\d+)$`, Name: "Tokyo Leaf $1", Priority: 10},
+	})
+
+	if name := resolver.Resolve("tyo-leaf-3"); name != "Tokyo Leaf 3" {
+		t.Errorf("Expected higher priority pattern, got '%s'", name)
+	}
+}
+
+func TestDisplayNameResolver_Nil(t *testing.T) {
+	var resolver *DisplayNameResolver
+	if name := resolver.Resolve("core-01"); name != "core-01" {
+		t.Errorf("Expected device ID from nil resolver, got '%s'", name)
+	}
+}
@@ -5,16 +5,21 @@ import (
 )
 
 type Device struct {
-	ID           string            `json:"id" db:"id"`
-	Type         string            `json:"type" db:"type"`
-	Hardware     string            `json:"hardware" db:"hardware"`
-	LayerID      *int              `json:"layer_id" db:"layer_id"` // NULL許可
-	DeviceType   string            `json:"device_type" db:"device_type"`
-	ClassifiedBy string            `json:"classified_by" db:"classified_by"`
-	Metadata     map[string]string `json:"metadata" db:"metadata"`
-	LastSeen     time.Time         `json:"last_seen" db:"last_seen"`
-	CreatedAt    time.Time         `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time         `json:"updated_at" db:"updated_at"`
+	ID            string            `json:"id" db:"id"`
+	Type          string            `json:"type" db:"type"`
+	Hardware      string            `json:"hardware" db:"hardware"`
+	LayerID       *int              `json:"layer_id" db:"layer_id"` // NULL許可
+	DeviceType    string            `json:"device_type" db:"device_type"`
+	ClassifiedBy  string            `json:"classified_by" db:"classified_by"`
+	Provenance    string            `json:"provenance" db:"provenance"`                 // 最初に登録した経路（更新しても変わらない）
+	WorkflowState string            `json:"workflow_state" db:"workflow_state"`         // 構築ワークフローの状態（同期では変わらない）
+	Rack          string            `json:"rack,omitempty" db:"rack"`                   // 設置ラック（同期では変わらない）
+	RackPosition  *int              `json:"rack_position,omitempty" db:"rack_position"` // 最下段のラックユニット（1始まり）
+	RackUnits     int               `json:"rack_units,omitempty" db:"rack_units"`       // 高さ（U、0 は 1U とみなす）
+	Metadata      map[string]string `json:"metadata" db:"metadata"`
+	LastSeen      time.Time         `json:"last_seen" db:"last_seen"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at" db:"updated_at"`
 }
 
 type Link struct {
@@ -57,7 +62,8 @@ type ReachabilityOptions struct {
 }
 
 type SubTopologyOptions struct {
-	Radius int `json:"radius"`
+	Radius int               `json:"radius"`
+	Filter SubTopologyFilter `json:"filter"`
 }
 
 type PathOptions struct {
@@ -71,13 +77,39 @@ type PaginationOptions struct {
 	SortDir  string `json:"sort_dir"`
 	Type     string `json:"type,omitempty"`
 	Hardware string `json:"hardware,omitempty"`
+	Exact    bool   `json:"exact,omitempty"`  // false = 大きなテーブルでは統計情報による推定件数を許容する
+	Keyset   bool   `json:"keyset,omitempty"` // true = ID 順のカーソル方式（Page は使わない）
+	After    string `json:"after,omitempty"`  // Keyset: この ID より後のデバイスから返す（空 = 先頭）
 }
 
 type PaginationResult struct {
-	Page       int  `json:"page"`
-	PageSize   int  `json:"page_size"`
-	TotalCount int  `json:"total_count"`
-	TotalPages int  `json:"total_pages"`
-	HasNext    bool `json:"has_next"`
-	HasPrev    bool `json:"has_prev"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	TotalCount int    `json:"total_count"`
+	TotalPages int    `json:"total_pages"`
+	HasNext    bool   `json:"has_next"`
+	HasPrev    bool   `json:"has_prev"`
+	Estimated  bool   `json:"estimated"`             // true = TotalCount と TotalPages は推定値
+	NextCursor string `json:"next_cursor,omitempty"` // Keyset: 次のページのカーソル（最後のページでは空）
+}
+
+// RowCount is the number of rows of a table, estimated from the table statistics when Estimated is set
+type RowCount struct {
+	Count     int64 `json:"count"`
+	Estimated bool  `json:"estimated"`
+}
+
+// LinkHistoryRetention defines retention windows for archived link observations
+type LinkHistoryRetention struct {
+	RawRetention     time.Duration `json:"raw_retention"`     // 生データを保持する期間（以降は日次サマリーへ圧縮）
+	SummaryRetention time.Duration `json:"summary_retention"` // 日次サマリーを保持する期間
+}
+
+// LinkHistoryCompactionResult summarizes a link history compaction run
+type LinkHistoryCompactionResult struct {
+	CompactedRows     int64    `json:"compacted_rows"`
+	DeletedRawRows    int64    `json:"deleted_raw_rows"`
+	DeletedSummaries  int64    `json:"deleted_summaries"`
+	DroppedPartitions []string `json:"dropped_partitions"`
+	CreatedPartitions []string `json:"created_partitions"`
 }
@@ -0,0 +1,272 @@
+package topology
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Export formats produced by export jobs
+const (
+	ExportFormatGraphML    = "graphml"
+	ExportFormatDevicesCSV = "devices_csv"
+	ExportFormatLinksCSV   = "links_csv"
+	ExportFormatSnapshot   = "snapshot"
+)
+
+type exportFormat struct {
+	extension   string
+	contentType string
+	write       func(w io.Writer, snapshot *Snapshot, annotations *ExportAnnotations) error
+}
+
+var exportFormats = map[string]exportFormat{
+	ExportFormatGraphML: {"graphml", "application/graphml+xml", WriteGraphML},
+	ExportFormatDevicesCSV: {"csv", "text/csv; charset=utf-8", func(w io.Writer, s *Snapshot, a *ExportAnnotations) error {
+		return WriteDevicesCSV(w, s.Devices, a)
+	}},
+	ExportFormatLinksCSV: {"csv", "text/csv; charset=utf-8", func(w io.Writer, s *Snapshot, a *ExportAnnotations) error {
+		return WriteLinksCSV(w, s.Links, a)
+	}},
+	// スナップショットはメタデータを含むデバイスとリンクそのものなので注記は加えない
+	ExportFormatSnapshot: {"json", "application/json", func(w io.Writer, s *Snapshot, _ *ExportAnnotations) error {
+		return json.NewEncoder(w).Encode(s)
+	}},
+}
+
+// ExportFormats lists the supported export formats
+var ExportFormats = []string{ExportFormatGraphML, ExportFormatDevicesCSV, ExportFormatLinksCSV, ExportFormatSnapshot}
+
+// IsValidExportFormat reports whether format is one of ExportFormats
+func IsValidExportFormat(format string) bool {
+	_, ok := exportFormats[format]
+	return ok
+}
+
+// ExportRequest is the payload of an export job
+type ExportRequest struct {
+	Format  string   `json:"format" enum:"graphml,devices_csv,links_csv,snapshot" doc:"File format"`
+	Include []string `json:"include,omitempty" enum:"classification,notes,maintenance,ownership" doc:"Operational context added as attributes of devices and links (graphml and CSV): layer name and classified_by, metadata.notes, current and scheduled maintenance, metadata.owner"`
+}
+
+// ExportFile is the result of an export job: a file in object storage
+type ExportFile struct {
+	Format      string    `json:"format"`
+	Include     []string  `json:"include,omitempty"`
+	Key         string    `json:"key"` // オブジェクトストレージ上のキー
+	Filename    string    `json:"filename" example:"topology-20250101T120000Z.graphml"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Devices     int       `json:"devices"`
+	Links       int       `json:"links"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ExportFilename returns the download file name of an export of snapshot, e.g. links-20250101T120000Z.csv
+func ExportFilename(format string, snapshot *Snapshot) string {
+	name := "topology"
+	switch format {
+	case ExportFormatDevicesCSV:
+		name = "devices"
+	case ExportFormatLinksCSV:
+		name = "links"
+	case ExportFormatSnapshot:
+		name = "snapshot"
+	}
+	return fmt.Sprintf("%s-%s.%s", name, snapshot.TakenAt.UTC().Format("20060102T150405Z"), exportFormats[format].extension)
+}
+
+// ExportContentType returns the MIME type of files in format
+func ExportContentType(format string) string {
+	return exportFormats[format].contentType
+}
+
+// WriteExport writes snapshot to w in format, with the attributes of annotations (nil = none)
+func WriteExport(w io.Writer, format string, snapshot *Snapshot, annotations *ExportAnnotations) error {
+	exporter, ok := exportFormats[format]
+	if !ok {
+		return fmt.Errorf("unknown export format '%s' (expected one of %v)", format, ExportFormats)
+	}
+	return exporter.write(w, snapshot, annotations)
+}
+
+// WriteDevicesCSV writes one row per device with a header row; the columns of annotations follow the standard ones
+func WriteDevicesCSV(w io.Writer, devices []Device, annotations *ExportAnnotations) error {
+	writer := csv.NewWriter(w)
+	header := []string{"id", "type", "hardware", "device_type", "layer_id", "provenance", "workflow_state", "last_seen"}
+	if err := writer.Write(append(header, annotations.DeviceColumns()...)); err != nil {
+		return err
+	}
+	for _, device := range devices {
+		layer := ""
+		if device.LayerID != nil {
+			layer = strconv.Itoa(*device.LayerID)
+		}
+		row := []string{
+			device.ID, device.Type, device.Hardware, device.DeviceType, layer,
+			device.Provenance, device.WorkflowState, formatExportTime(device.LastSeen),
+		}
+		if err := writer.Write(append(row, annotations.DeviceValues(device)...)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteLinksCSV writes one row per link with a header row; the columns of annotations follow the standard ones
+func WriteLinksCSV(w io.Writer, links []Link, annotations *ExportAnnotations) error {
+	writer := csv.NewWriter(w)
+	header := []string{"id", "source_id", "source_port", "target_id", "target_port", "weight", "last_seen"}
+	if err := writer.Write(append(header, annotations.LinkColumns()...)); err != nil {
+		return err
+	}
+	for _, link := range links {
+		row := []string{
+			link.ID, link.SourceID, link.SourcePort, link.TargetID, link.TargetPort,
+			strconv.FormatFloat(link.Weight, 'f', -1, 64), formatExportTime(link.LastSeen),
+		}
+		if err := writer.Write(append(row, annotations.LinkValues(link)...)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+type graphMLKey struct {
+	XMLName  xml.Name `xml:"key"`
+	ID       string   `xml:"id,attr"`
+	For      string   `xml:"for,attr"`
+	AttrName string   `xml:"attr.name,attr"`
+	AttrType string   `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	XMLName xml.Name      `xml:"node"`
+	ID      string        `xml:"id,attr"`
+	Data    []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	XMLName xml.Name      `xml:"edge"`
+	ID      string        `xml:"id,attr"`
+	Source  string        `xml:"source,attr"`
+	Target  string        `xml:"target,attr"`
+	Data    []graphMLData `xml:"data"`
+}
+
+// WriteGraphML writes the devices and links of snapshot as an undirected GraphML graph, readable by
+// tools such as yEd, Gephi and NetworkX, with the attributes of annotations as string data. Nodes
+// and edges are written one at a time, so the whole document is never held in memory.
+func WriteGraphML(w io.Writer, snapshot *Snapshot, annotations *ExportAnnotations) error {
+	if _, err := io.WriteString(w, xml.Header+`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`+"\n"); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("  ", "  ")
+	keys := []graphMLKey{
+		{ID: "type", For: "node", AttrName: "type", AttrType: "string"},
+		{ID: "hardware", For: "node", AttrName: "hardware", AttrType: "string"},
+		{ID: "device_type", For: "node", AttrName: "device_type", AttrType: "string"},
+		{ID: "layer_id", For: "node", AttrName: "layer_id", AttrType: "int"},
+		{ID: "source_port", For: "edge", AttrName: "source_port", AttrType: "string"},
+		{ID: "target_port", For: "edge", AttrName: "target_port", AttrType: "string"},
+		{ID: "weight", For: "edge", AttrName: "weight", AttrType: "double"},
+	}
+	keys = append(keys, annotationKeys(annotations.DeviceColumns(), annotations.LinkColumns())...)
+	for _, key := range keys {
+		if err := encoder.Encode(key); err != nil {
+			return err
+		}
+	}
+
+	graph := xml.StartElement{
+		Name: xml.Name{Local: "graph"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: "topology"}, {Name: xml.Name{Local: "edgedefault"}, Value: "undirected"}},
+	}
+	if err := encoder.EncodeToken(graph); err != nil {
+		return err
+	}
+	for _, device := range snapshot.Devices {
+		node := graphMLNode{ID: device.ID}
+		node.Data = appendGraphMLData(node.Data, "type", device.Type)
+		node.Data = appendGraphMLData(node.Data, "hardware", device.Hardware)
+		node.Data = appendGraphMLData(node.Data, "device_type", device.DeviceType)
+		if device.LayerID != nil {
+			node.Data = appendGraphMLData(node.Data, "layer_id", strconv.Itoa(*device.LayerID))
+		}
+		node.Data = appendGraphMLAnnotations(node.Data, annotations.DeviceColumns(), annotations.DeviceValues(device))
+		if err := encoder.Encode(node); err != nil {
+			return err
+		}
+	}
+	for _, link := range snapshot.Links {
+		edge := graphMLEdge{ID: link.ID, Source: link.SourceID, Target: link.TargetID}
+		edge.Data = appendGraphMLData(edge.Data, "source_port", link.SourcePort)
+		edge.Data = appendGraphMLData(edge.Data, "target_port", link.TargetPort)
+		edge.Data = appendGraphMLData(edge.Data, "weight", strconv.FormatFloat(link.Weight, 'f', -1, 64))
+		edge.Data = appendGraphMLAnnotations(edge.Data, annotations.LinkColumns(), annotations.LinkValues(link))
+		if err := encoder.Encode(edge); err != nil {
+			return err
+		}
+	}
+	if err := encoder.EncodeToken(graph.End()); err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n</graphml>\n")
+	return err
+}
+
+func appendGraphMLData(data []graphMLData, key, value string) []graphMLData {
+	if value == "" {
+		return data
+	}
+	return append(data, graphMLData{Key: key, Value: value})
+}
+
+func appendGraphMLAnnotations(data []graphMLData, columns, values []string) []graphMLData {
+	for i, column := range columns {
+		data = appendGraphMLData(data, column, values[i])
+	}
+	return data
+}
+
+// annotationKeys declares the annotation attributes; those of both devices and links are declared once for all elements
+func annotationKeys(deviceColumns, linkColumns []string) []graphMLKey {
+	var keys []graphMLKey
+	for _, column := range deviceColumns {
+		key := graphMLKey{ID: column, For: "node", AttrName: column, AttrType: "string"}
+		if containsString(linkColumns, column) {
+			key.For = "all"
+		}
+		keys = append(keys, key)
+	}
+	for _, column := range linkColumns {
+		if !containsString(deviceColumns, column) {
+			keys = append(keys, graphMLKey{ID: column, For: "edge", AttrName: column, AttrType: "string"})
+		}
+	}
+	return keys
+}
@@ -0,0 +1,168 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Operational context an export may include besides connectivity
+const (
+	ExportIncludeClassification = "classification" // レイヤー名と分類した人・ルール
+	ExportIncludeNotes          = "notes"          // metadata.notes
+	ExportIncludeMaintenance    = "maintenance"    // 実施中・予定のメンテナンス
+	ExportIncludeOwnership      = "ownership"      // metadata.owner
+)
+
+// ExportIncludes lists the annotations of ExportRequest.Include in the order their attributes are written
+var ExportIncludes = []string{ExportIncludeClassification, ExportIncludeNotes, ExportIncludeMaintenance, ExportIncludeOwnership}
+
+// Metadata keys holding the notes and the owner of devices and links
+const (
+	NotesMetadataKey = "notes"
+	OwnerMetadataKey = "owner"
+)
+
+// Maintenance states of the devices and links of an export
+const (
+	MaintenanceStateActive    = "in_maintenance"
+	MaintenanceStateScheduled = "scheduled"
+)
+
+// ValidateExportIncludes checks every include is one of ExportIncludes
+func ValidateExportIncludes(include []string) error {
+	for _, name := range include {
+		if !containsString(ExportIncludes, name) {
+			return fmt.Errorf("unknown include '%s' (expected some of %v)", name, ExportIncludes)
+		}
+	}
+	return nil
+}
+
+// MaintenanceState is the maintenance a device or link is in or scheduled for
+type MaintenanceState struct {
+	State   string   // MaintenanceStateActive or MaintenanceStateScheduled
+	Tickets []string // 実施中・予定の作業の変更チケット
+}
+
+// ExportAnnotations is the operational context written as extra attributes of the devices and
+// links of an export. A nil *ExportAnnotations writes connectivity only.
+type ExportAnnotations struct {
+	Include           []string
+	LayerNames        map[int]string              // レイヤーID → 名前
+	DeviceMaintenance map[string]MaintenanceState // デバイスID → 状態
+	LinkMaintenance   map[string]MaintenanceState // リンクID → 状態
+}
+
+// SetMaintenance records the state of the devices and links of windows at now: in maintenance
+// while a window is active, scheduled when every window starts later
+func (a *ExportAnnotations) SetMaintenance(windows []MaintenanceWindow, now time.Time) {
+	a.DeviceMaintenance = make(map[string]MaintenanceState)
+	a.LinkMaintenance = make(map[string]MaintenanceState)
+
+	sorted := append([]MaintenanceWindow(nil), windows...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StartsAt.Before(sorted[j].StartsAt) })
+	for _, window := range sorted {
+		if !window.EndsAt.After(now) {
+			continue
+		}
+		state := MaintenanceStateScheduled
+		if !window.StartsAt.After(now) {
+			state = MaintenanceStateActive
+		}
+		for _, id := range window.Devices {
+			a.DeviceMaintenance[id] = addMaintenance(a.DeviceMaintenance[id], state, window.Ticket)
+		}
+		for _, id := range window.Links {
+			a.LinkMaintenance[id] = addMaintenance(a.LinkMaintenance[id], state, window.Ticket)
+		}
+	}
+}
+
+func addMaintenance(current MaintenanceState, state, ticket string) MaintenanceState {
+	if current.State != MaintenanceStateActive {
+		current.State = state
+	}
+	if !containsString(current.Tickets, ticket) {
+		current.Tickets = append(current.Tickets, ticket)
+	}
+	return current
+}
+
+// includes reports whether the annotation named name is included
+func (a *ExportAnnotations) includes(name string) bool {
+	return a != nil && containsString(a.Include, name)
+}
+
+// DeviceColumns returns the names of the attributes written for every device
+func (a *ExportAnnotations) DeviceColumns() []string {
+	var columns []string
+	if a.includes(ExportIncludeClassification) {
+		columns = append(columns, "layer", "classified_by")
+	}
+	if a.includes(ExportIncludeNotes) {
+		columns = append(columns, "notes")
+	}
+	if a.includes(ExportIncludeMaintenance) {
+		columns = append(columns, "maintenance_state", "maintenance_tickets")
+	}
+	if a.includes(ExportIncludeOwnership) {
+		columns = append(columns, "owner")
+	}
+	return columns
+}
+
+// DeviceValues returns the values of DeviceColumns for device
+func (a *ExportAnnotations) DeviceValues(device Device) []string {
+	var values []string
+	if a.includes(ExportIncludeClassification) {
+		layer := ""
+		if device.LayerID != nil {
+			layer = a.LayerNames[*device.LayerID]
+		}
+		values = append(values, layer, device.ClassifiedBy)
+	}
+	if a.includes(ExportIncludeNotes) {
+		values = append(values, device.Metadata[NotesMetadataKey])
+	}
+	if a.includes(ExportIncludeMaintenance) {
+		maintenance := a.DeviceMaintenance[device.ID]
+		values = append(values, maintenance.State, strings.Join(maintenance.Tickets, ","))
+	}
+	if a.includes(ExportIncludeOwnership) {
+		values = append(values, device.Metadata[OwnerMetadataKey])
+	}
+	return values
+}
+
+// LinkColumns returns the names of the attributes written for every link. Links are not classified.
+func (a *ExportAnnotations) LinkColumns() []string {
+	var columns []string
+	if a.includes(ExportIncludeNotes) {
+		columns = append(columns, "notes")
+	}
+	if a.includes(ExportIncludeMaintenance) {
+		columns = append(columns, "maintenance_state", "maintenance_tickets")
+	}
+	if a.includes(ExportIncludeOwnership) {
+		columns = append(columns, "owner")
+	}
+	return columns
+}
+
+// LinkValues returns the values of LinkColumns for link
+func (a *ExportAnnotations) LinkValues(link Link) []string {
+	var values []string
+	if a.includes(ExportIncludeNotes) {
+		values = append(values, link.Metadata[NotesMetadataKey])
+	}
+	if a.includes(ExportIncludeMaintenance) {
+		maintenance := a.LinkMaintenance[link.ID]
+		values = append(values, maintenance.State, strings.Join(maintenance.Tickets, ","))
+	}
+	if a.includes(ExportIncludeOwnership) {
+		values = append(values, link.Metadata[OwnerMetadataKey])
+	}
+	return values
+}
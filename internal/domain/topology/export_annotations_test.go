@@ -0,0 +1,125 @@
+package topology
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func annotatedSnapshot() (*Snapshot, *ExportAnnotations) {
+	snapshot := exportSnapshot()
+	snapshot.Devices[0].ClassifiedBy = "user:alice"
+	snapshot.Devices[0].Metadata = map[string]string{"owner": "netops", "notes": "replaced PSU"}
+	snapshot.Links[0].Metadata = map[string]string{"owner": "dc-team"}
+
+	now := snapshot.TakenAt
+	annotations := &ExportAnnotations{
+		Include:    []string{ExportIncludeOwnership, ExportIncludeClassification, ExportIncludeMaintenance, ExportIncludeNotes},
+		LayerNames: map[int]string{2: "Leaf"},
+	}
+	annotations.SetMaintenance([]MaintenanceWindow{
+		{Ticket: "CHG-2", StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour), Devices: []string{"leaf-01", "spine-01"}},
+		{Ticket: "CHG-1", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Devices: []string{"leaf-01"}, Links: []string{"l1"}},
+		{Ticket: "CHG-0", StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour), Devices: []string{"spine-01"}},
+	}, now)
+	return snapshot, annotations
+}
+
+func TestExportAnnotations_SetMaintenance(t *testing.T) {
+	_, annotations := annotatedSnapshot()
+
+	leaf := annotations.DeviceMaintenance["leaf-01"]
+	if leaf.State != MaintenanceStateActive || strings.Join(leaf.Tickets, ",") != "CHG-1,CHG-2" {
+		t.Errorf("Expected leaf-01 in maintenance with CHG-1 and CHG-2, got %+v", leaf)
+	}
+	spine := annotations.DeviceMaintenance["spine-01"]
+	if spine.State != MaintenanceStateScheduled || strings.Join(spine.Tickets, ",") != "CHG-2" {
+		t.Errorf("Expected spine-01 scheduled for CHG-2 only, got %+v", spine)
+	}
+	if link := annotations.LinkMaintenance["l1"]; link.State != MaintenanceStateActive {
+		t.Errorf("Expected l1 in maintenance, got %+v", link)
+	}
+}
+
+func TestWriteCSV_Annotations(t *testing.T) {
+	snapshot, annotations := annotatedSnapshot()
+
+	var devices bytes.Buffer
+	if err := WriteDevicesCSV(&devices, snapshot.Devices, annotations); err != nil {
+		t.Fatalf("Failed to write devices: %v", err)
+	}
+	rows, err := csv.NewReader(&devices).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got %v", err)
+	}
+	header := strings.Join(rows[0][8:], ",")
+	if header != "layer,classified_by,notes,maintenance_state,maintenance_tickets,owner" {
+		t.Errorf("Expected the annotation columns in a fixed order, got %s", header)
+	}
+	if leaf := strings.Join(rows[1][8:], "|"); leaf != "Leaf|user:alice|replaced PSU|in_maintenance|CHG-1,CHG-2|netops" {
+		t.Errorf("Unexpected annotations of leaf-01: %s", leaf)
+	}
+
+	var links bytes.Buffer
+	if err := WriteLinksCSV(&links, snapshot.Links, annotations); err != nil {
+		t.Fatalf("Failed to write links: %v", err)
+	}
+	rows, _ = csv.NewReader(&links).ReadAll()
+	if got := strings.Join(rows[0][7:], ","); got != "notes,maintenance_state,maintenance_tickets,owner" {
+		t.Errorf("Expected links without classification columns, got %s", got)
+	}
+	if got := strings.Join(rows[1][7:], "|"); got != "|in_maintenance|CHG-1|dc-team" {
+		t.Errorf("Unexpected annotations of l1: %s", got)
+	}
+}
+
+func TestWriteGraphML_Annotations(t *testing.T) {
+	snapshot, annotations := annotatedSnapshot()
+	annotations.Include = []string{ExportIncludeClassification, ExportIncludeOwnership}
+
+	var buf bytes.Buffer
+	if err := WriteGraphML(&buf, snapshot, annotations); err != nil {
+		t.Fatalf("Failed to write GraphML: %v", err)
+	}
+
+	var doc struct {
+		Keys  []graphMLKey `xml:"key"`
+		Graph struct {
+			Nodes []graphMLNode `xml:"node"`
+			Edges []graphMLEdge `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected well-formed GraphML, got %v", err)
+	}
+	scopes := map[string]string{}
+	for _, key := range doc.Keys {
+		scopes[key.ID] = key.For
+	}
+	if scopes["layer"] != "node" || scopes["owner"] != "all" || scopes["notes"] != "" {
+		t.Errorf("Expected layer for nodes and owner for all elements, got %v", scopes)
+	}
+
+	data := map[string]string{}
+	for _, d := range doc.Graph.Nodes[0].Data {
+		data[d.Key] = d.Value
+	}
+	if data["layer"] != "Leaf" || data["owner"] != "netops" || data["classified_by"] != "user:alice" {
+		t.Errorf("Unexpected data of leaf-01: %v", data)
+	}
+	if edge := doc.Graph.Edges[0].Data; edge[len(edge)-1].Key != "owner" || edge[len(edge)-1].Value != "dc-team" {
+		t.Errorf("Expected the owner of l1, got %+v", edge)
+	}
+}
+
+func TestValidateExportIncludes(t *testing.T) {
+	if err := ValidateExportIncludes([]string{"notes", "ownership"}); err != nil {
+		t.Errorf("Expected valid includes, got %v", err)
+	}
+	if err := ValidateExportIncludes([]string{"owner"}); err == nil {
+		t.Error("Expected owner to be rejected")
+	}
+}
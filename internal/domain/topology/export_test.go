@@ -0,0 +1,87 @@
+package topology
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportSnapshot() *Snapshot {
+	layer := 2
+	return &Snapshot{
+		TakenAt: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		Devices: []Device{
+			{ID: "leaf-01", Type: "switch", Hardware: "QFX5120", LayerID: &layer},
+			{ID: "spine-01", Type: "switch", Hardware: "a&b"},
+		},
+		Links: []Link{
+			{ID: "l1", SourceID: "leaf-01", SourcePort: "et-0/0/48", TargetID: "spine-01", TargetPort: "et-0/0/1", Weight: 1},
+		},
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGraphML(&buf, exportSnapshot(), nil); err != nil {
+		t.Fatalf("Failed to write GraphML: %v", err)
+	}
+
+	var doc struct {
+		Graph struct {
+			EdgeDefault string        `xml:"edgedefault,attr"`
+			Nodes       []graphMLNode `xml:"node"`
+			Edges       []graphMLEdge `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expected well-formed GraphML, got %v\n%s", err, buf.String())
+	}
+	if doc.Graph.EdgeDefault != "undirected" {
+		t.Errorf("Expected an undirected graph, got %q", doc.Graph.EdgeDefault)
+	}
+	if len(doc.Graph.Nodes) != 2 || len(doc.Graph.Edges) != 1 {
+		t.Fatalf("Expected 2 nodes and 1 edge, got %d and %d", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+	if edge := doc.Graph.Edges[0]; edge.Source != "leaf-01" || edge.Target != "spine-01" {
+		t.Errorf("Expected an edge from leaf-01 to spine-01, got %+v", edge)
+	}
+	if !strings.Contains(buf.String(), "a&amp;b") {
+		t.Error("Expected attribute values to be escaped")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	snapshot := exportSnapshot()
+
+	var devices bytes.Buffer
+	if err := WriteDevicesCSV(&devices, snapshot.Devices, nil); err != nil {
+		t.Fatalf("Failed to write devices: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(devices.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "leaf-01,switch,QFX5120,,2,") {
+		t.Errorf("Expected a header and two devices, got %q", devices.String())
+	}
+
+	var links bytes.Buffer
+	if err := WriteLinksCSV(&links, snapshot.Links, nil); err != nil {
+		t.Fatalf("Failed to write links: %v", err)
+	}
+	if !strings.Contains(links.String(), "l1,leaf-01,et-0/0/48,spine-01,et-0/0/1,1,") {
+		t.Errorf("Expected the link row, got %q", links.String())
+	}
+}
+
+func TestExportFilename(t *testing.T) {
+	snapshot := exportSnapshot()
+	if got := ExportFilename(ExportFormatLinksCSV, snapshot); got != "links-20250101T120000Z.csv" {
+		t.Errorf("Expected links-20250101T120000Z.csv, got %s", got)
+	}
+	if got := ExportFilename(ExportFormatGraphML, snapshot); got != "topology-20250101T120000Z.graphml" {
+		t.Errorf("Expected topology-20250101T120000Z.graphml, got %s", got)
+	}
+	if IsValidExportFormat("pdf") {
+		t.Error("Expected pdf to be rejected")
+	}
+}
@@ -0,0 +1,164 @@
+package topology
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fabricNamePattern restricts fabric names to characters that are safe in URLs
+var fabricNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// FabricCondition selects fabric members by a device attribute.
+// Fields and operators follow classification rule conditions; metadata.<key> is also accepted as field.
+type FabricCondition struct {
+	Field    string `json:"field"`    // "name", "hardware", "type", "metadata.<key>"
+	Operator string `json:"operator"` // "contains", "starts_with", "ends_with", "equals", "regex"
+	Value    string `json:"value"`
+}
+
+// Fabric is a named collection of devices (e.g. "prod-fabric-a") whose members are selected by conditions
+type Fabric struct {
+	Name          string            `json:"name"`
+	Description   string            `json:"description,omitempty"`
+	LogicOperator string            `json:"logic"` // "AND" (default) or "OR"
+	Conditions    []FabricCondition `json:"conditions"`
+	Priority      int               `json:"priority"` // 複数のファブリックに一致する場合は高い方に所属
+	MemberCount   int               `json:"member_count"`
+	CreatedBy     string            `json:"created_by"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// Validate checks the name and the member selection of the fabric
+func (f Fabric) Validate() error {
+	if !fabricNamePattern.MatchString(f.Name) {
+		return fmt.Errorf("name must start with a letter or digit and contain only letters, digits, '.', '_' and '-'")
+	}
+	if f.LogicOperator != "" && f.LogicOperator != "AND" && f.LogicOperator != "OR" {
+		return fmt.Errorf("logic must be AND or OR")
+	}
+	if len(f.Conditions) == 0 {
+		return fmt.Errorf("at least one condition is required")
+	}
+
+	for i, condition := range f.Conditions {
+		switch {
+		case condition.Field == "name", condition.Field == "hardware", condition.Field == "type":
+		case strings.HasPrefix(condition.Field, "metadata.") && len(condition.Field) > len("metadata."):
+		default:
+			return fmt.Errorf("condition %d: unknown field '%s' (expected name, hardware, type or metadata.<key>)", i+1, condition.Field)
+		}
+
+		switch condition.Operator {
+		case "contains", "starts_with", "ends_with", "equals":
+		case "regex":
+			if _, err := regexp.Compile(condition.Value); err != nil {
+				return fmt.Errorf("condition %d: invalid regex: %w", i+1, err)
+			}
+		default:
+			return fmt.Errorf("condition %d: unknown operator '%s'", i+1, condition.Operator)
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether device is selected by the conditions of the fabric
+func (f Fabric) Matches(device Device) bool {
+	if len(f.Conditions) == 0 {
+		return false
+	}
+
+	for _, condition := range f.Conditions {
+		matched := condition.Matches(device)
+		if f.LogicOperator == "OR" && matched {
+			return true
+		}
+		if f.LogicOperator != "OR" && !matched {
+			return false
+		}
+	}
+	return f.LogicOperator != "OR"
+}
+
+// Matches reports whether device satisfies the condition. Text comparisons are case-insensitive.
+func (c FabricCondition) Matches(device Device) bool {
+	var fieldValue string
+	switch {
+	case c.Field == "name":
+		fieldValue = device.ID // DeviceにNameがないため、IDを使用
+	case c.Field == "hardware":
+		fieldValue = device.Hardware
+	case c.Field == "type":
+		fieldValue = device.Type
+	case strings.HasPrefix(c.Field, "metadata."):
+		value, ok := device.Metadata[strings.TrimPrefix(c.Field, "metadata.")]
+		if !ok {
+			return false
+		}
+		fieldValue = value
+	default:
+		return false
+	}
+
+	switch c.Operator {
+	case "contains":
+		return strings.Contains(strings.ToLower(fieldValue), strings.ToLower(c.Value))
+	case "starts_with":
+		return strings.HasPrefix(strings.ToLower(fieldValue), strings.ToLower(c.Value))
+	case "ends_with":
+		return strings.HasSuffix(strings.ToLower(fieldValue), strings.ToLower(c.Value))
+	case "equals":
+		return strings.EqualFold(fieldValue, c.Value)
+	case "regex":
+		if re, err := regexp.Compile(c.Value); err == nil {
+			return re.MatchString(fieldValue)
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// AssignFabrics assigns each device to the first matching fabric in priority order
+// (higher priority first, then by name) and returns the fabric name by device ID.
+// Devices matching no fabric are absent from the result.
+func AssignFabrics(fabrics []Fabric, devices []Device) map[string]string {
+	ordered := make([]Fabric, len(fabrics))
+	copy(ordered, fabrics)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+
+	assignments := make(map[string]string)
+	for _, device := range devices {
+		for _, fabric := range ordered {
+			if fabric.Matches(device) {
+				assignments[device.ID] = fabric.Name
+				break
+			}
+		}
+	}
+	return assignments
+}
+
+// FabricReport summarizes the members of a fabric and how the fabric connects to the rest of the network
+type FabricReport struct {
+	Name           string         `json:"name"`
+	DeviceCount    int            `json:"device_count"`
+	DevicesByType  map[string]int `json:"devices_by_type"`
+	DevicesByLayer map[string]int `json:"devices_by_layer"` // レイヤーID（未分類は "unclassified"）ごとの台数
+	InternalLinks  int            `json:"internal_links"`
+	ExternalLinks  int            `json:"external_links"`
+	// ExternalFabrics counts the external links by the fabric of the peer ("unassigned" for devices in no fabric)
+	ExternalFabrics map[string]int `json:"external_fabrics"`
+}
+
+// UnassignedFabric is the ExternalFabrics key of peers that belong to no fabric
+const UnassignedFabric = "unassigned"
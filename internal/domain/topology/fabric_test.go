@@ -0,0 +1,75 @@
+package topology
+
+import "testing"
+
+func TestFabric_Matches(t *testing.T) {
+	fabric := Fabric{
+		Name:          "prod-fabric-a",
+		LogicOperator: "AND",
+		Conditions: []FabricCondition{
+			{Field: "name", Operator: "starts_with", Value: "FA-"},
+			{Field: "metadata.site", Operator: "equals", Value: "tyo1"},
+		},
+	}
+
+	tests := []struct {
+		device Device
+		want   bool
+	}{
+		{Device{ID: "fa-leaf-01", Metadata: map[string]string{"site": "TYO1"}}, true},
+		{Device{ID: "fa-leaf-02", Metadata: map[string]string{"site": "osk1"}}, false},
+		{Device{ID: "fa-leaf-03"}, false},
+		{Device{ID: "fb-leaf-01", Metadata: map[string]string{"site": "tyo1"}}, false},
+	}
+	for _, tt := range tests {
+		if got := fabric.Matches(tt.device); got != tt.want {
+			t.Errorf("Matches(%s) = %v, want %v", tt.device.ID, got, tt.want)
+		}
+	}
+
+	fabric.LogicOperator = "OR"
+	if !fabric.Matches(Device{ID: "fa-leaf-02"}) {
+		t.Errorf("Expected OR fabric to match on a single condition")
+	}
+}
+
+func TestFabric_Validate(t *testing.T) {
+	valid := Fabric{Name: "prod-fabric-a", Conditions: []FabricCondition{{Field: "type", Operator: "equals", Value: "switch"}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	invalid := []Fabric{
+		{Name: "", Conditions: valid.Conditions},
+		{Name: "prod/a", Conditions: valid.Conditions},
+		{Name: "prod-a"},
+		{Name: "prod-a", LogicOperator: "XOR", Conditions: valid.Conditions},
+		{Name: "prod-a", Conditions: []FabricCondition{{Field: "ip", Operator: "equals", Value: "x"}}},
+		{Name: "prod-a", Conditions: []FabricCondition{{Field: "name", Operator: "like", Value: "x"}}},
+		{Name: "prod-a", Conditions: []FabricCondition{{Field: "name", Operator: "regex", Value: "("}}},
+	}
+	for _, fabric := range invalid {
+		if err := fabric.Validate(); err == nil {
+			t.Errorf("Expected error for fabric %+v", fabric)
+		}
+	}
+}
+
+func TestAssignFabrics(t *testing.T) {
+	fabrics := []Fabric{
+		{Name: "all-leaves", Priority: 0, Conditions: []FabricCondition{{Field: "name", Operator: "contains", Value: "leaf"}}},
+		{Name: "fabric-a", Priority: 10, Conditions: []FabricCondition{{Field: "name", Operator: "starts_with", Value: "fa-"}}},
+	}
+	devices := []Device{{ID: "fa-leaf-01"}, {ID: "fb-leaf-01"}, {ID: "core-01"}}
+
+	assignments := AssignFabrics(fabrics, devices)
+	if assignments["fa-leaf-01"] != "fabric-a" {
+		t.Errorf("Expected fa-leaf-01 in the higher-priority fabric-a, got '%s'", assignments["fa-leaf-01"])
+	}
+	if assignments["fb-leaf-01"] != "all-leaves" {
+		t.Errorf("Expected fb-leaf-01 in all-leaves, got '%s'", assignments["fb-leaf-01"])
+	}
+	if _, ok := assignments["core-01"]; ok {
+		t.Errorf("Expected core-01 to be unassigned")
+	}
+}
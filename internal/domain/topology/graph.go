@@ -0,0 +1,127 @@
+package topology
+
+// GraphIndex is an in-memory adjacency index of a topology, built once for analyses that
+// walk many paths. Links are undirected; parallel links are kept as separate edges.
+// For weighted paths, a link's weight is the cost of leaving its source side; a link
+// reported from both sides therefore carries one weight per direction.
+type GraphIndex struct {
+	adjacency map[string][]graphEdge
+	links     int
+}
+
+type graphEdge struct {
+	to      string
+	link    int  // リンクの通し番号（フロー計算用）
+	forward bool // source -> target の向きか
+	cost    float64
+	// 逆側からの観測（別リンク）があるため、重み付き経路ではこの向きに使わない
+	shadowed bool
+}
+
+// NewGraphIndex indexes links by their endpoints
+func NewGraphIndex(links []Link) *GraphIndex {
+	g := &GraphIndex{adjacency: make(map[string][]graphEdge)}
+	observed := reverseObserved(links)
+	for i, link := range links {
+		if link.SourceID == link.TargetID {
+			continue
+		}
+		cost := link.Weight
+		if cost <= 0 {
+			cost = 1
+		}
+		g.adjacency[link.SourceID] = append(g.adjacency[link.SourceID], graphEdge{to: link.TargetID, link: g.links, forward: true, cost: cost})
+		g.adjacency[link.TargetID] = append(g.adjacency[link.TargetID], graphEdge{to: link.SourceID, link: g.links, cost: cost, shadowed: observed[i]})
+		g.links++
+	}
+	return g
+}
+
+// Degree returns the number of links of a device
+func (g *GraphIndex) Degree(deviceID string) int {
+	return len(g.adjacency[deviceID])
+}
+
+// HopCounts returns the minimum hop count from deviceID to every device reachable within maxHops
+func (g *GraphIndex) HopCounts(deviceID string, maxHops int) map[string]int {
+	hops := map[string]int{deviceID: 0}
+	queue := []string{deviceID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if hops[current] >= maxHops {
+			continue
+		}
+		for _, edge := range g.adjacency[current] {
+			if _, seen := hops[edge.to]; seen {
+				continue
+			}
+			hops[edge.to] = hops[current] + 1
+			queue = append(queue, edge.to)
+		}
+	}
+	return hops
+}
+
+// DisjointPaths counts the link-disjoint paths between two devices, stopping at limit.
+// It is a unit-capacity max flow, so the cost is at most limit breadth-first searches.
+func (g *GraphIndex) DisjointPaths(fromID, toID string, limit int) int {
+	if fromID == toID {
+		return 0
+	}
+	// 端点の次数を超える本数の経路は存在しない
+	if degree := g.Degree(fromID); degree < limit {
+		limit = degree
+	}
+	if degree := g.Degree(toID); degree < limit {
+		limit = degree
+	}
+
+	// flow[link] は source -> target 向きを正とするリンク上の流量（-1, 0, 1）
+	flow := make([]int8, g.links)
+	residual := func(edge graphEdge) bool {
+		if edge.forward {
+			return flow[edge.link] < 1
+		}
+		return flow[edge.link] > -1
+	}
+
+	type step struct {
+		prev string
+		edge graphEdge
+	}
+	paths := 0
+	for paths < limit {
+		visited := map[string]step{fromID: {}}
+		queue := []string{fromID}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			if current == toID {
+				break
+			}
+			for _, edge := range g.adjacency[current] {
+				if _, seen := visited[edge.to]; seen || !residual(edge) {
+					continue
+				}
+				visited[edge.to] = step{prev: current, edge: edge}
+				queue = append(queue, edge.to)
+			}
+		}
+		if _, ok := visited[toID]; !ok {
+			break
+		}
+
+		// 見つかった増加路に沿って流量を流す
+		for id := toID; id != fromID; id = visited[id].prev {
+			edge := visited[id].edge
+			if edge.forward {
+				flow[edge.link]++
+			} else {
+				flow[edge.link]--
+			}
+		}
+		paths++
+	}
+	return paths
+}
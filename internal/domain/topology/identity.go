@@ -0,0 +1,523 @@
+package topology
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Evidence matching devices described by different sources
+const (
+	IdentityMatchSerial   = "serial"   // シリアル番号が一致
+	IdentityMatchMAC      = "mac"      // シャーシMACが一致
+	IdentityMatchMgmtIP   = "mgmt_ip"  // 管理IPが一致
+	IdentityMatchHostname = "hostname" // 正規化したホスト名のみ一致（自動では統合しない）
+	IdentityMatchManual   = "manual"   // 手動で統合
+)
+
+// Reasons a group of devices needs a manual decision
+const (
+	IdentityConflictHostname       = "hostname_only"   // ホスト名しか一致しない
+	IdentityConflictSerialMismatch = "serial_mismatch" // MACや管理IPは一致するがシリアル番号が異なる
+)
+
+// Default device metadata keys holding identifiers
+const (
+	DefaultIdentitySerialKey = "serial"
+	DefaultIdentityMACKey    = "mac"
+)
+
+// IdentityOptions names the device metadata holding the identifiers matched across sources
+type IdentityOptions struct {
+	SerialKey      string `json:"serial_key" yaml:"serial_key"`
+	MACKey         string `json:"mac_key" yaml:"mac_key"`
+	MgmtAddressKey string `json:"mgmt_address_key" yaml:"mgmt_address_key"`
+}
+
+// WithDefaults returns the options with unset keys replaced by the defaults
+func (o IdentityOptions) WithDefaults() IdentityOptions {
+	if o.SerialKey == "" {
+		o.SerialKey = DefaultIdentitySerialKey
+	}
+	if o.MACKey == "" {
+		o.MACKey = DefaultIdentityMACKey
+	}
+	if o.MgmtAddressKey == "" {
+		o.MgmtAddressKey = DefaultManagementAddressKey
+	}
+	return o
+}
+
+// identityProvenanceOrder ranks the sources whose ID becomes the canonical one
+var identityProvenanceOrder = []string{ProvenanceNetBox, ProvenanceManual, ProvenanceImport, ProvenancePrometheus, ProvenanceMACTable, ProvenanceLLDPPlaceholder}
+
+// IdentityDecision is a manual resolution of the devices of a conflict: the devices are the same
+// box known as CanonicalID, or distinct boxes when CanonicalID is empty
+type IdentityDecision struct {
+	Key         string    `json:"key"` // IdentityKey(Devices)
+	Devices     []string  `json:"devices"`
+	CanonicalID string    `json:"canonical_id,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	DecidedBy   string    `json:"decided_by"`
+	DecidedAt   time.Time `json:"decided_at"`
+}
+
+// IdentityKey identifies a set of devices independent of their order
+func IdentityKey(devices []string) string {
+	sorted := append([]string(nil), devices...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// IdentityMap lists the devices known under several IDs and the groups left for manual resolution
+type IdentityMap struct {
+	Devices    int                `json:"devices"`
+	Identities []DeviceIdentity   `json:"identities"` // 複数のIDを持つ機器のみ
+	Conflicts  []IdentityConflict `json:"conflicts"`
+	// StaleDecisions are decisions whose devices no longer form a conflict
+	StaleDecisions []string `json:"stale_decisions"`
+}
+
+// DeviceIdentity is one box and the IDs the sources know it by
+type DeviceIdentity struct {
+	CanonicalID string          `json:"canonical_id"`
+	Aliases     []IdentityAlias `json:"aliases"` // 正規ID以外のID
+}
+
+// IdentityAlias is another ID of a box and the evidence tying it to the others
+type IdentityAlias struct {
+	DeviceID   string `json:"device_id"`
+	Provenance string `json:"provenance"`
+	Method     string `json:"method" enum:"serial,mac,mgmt_ip,manual"`
+	Evidence   string `json:"evidence,omitempty"` // 一致した値
+}
+
+// IdentityConflict is a group of devices that may be the same box but is not merged automatically
+type IdentityConflict struct {
+	Key      string             `json:"key"` // 手動で解決するときに指定する
+	Reason   string             `json:"reason" enum:"hostname_only,serial_mismatch"`
+	Devices  []string           `json:"devices"`
+	Evidence []IdentityEvidence `json:"evidence"`
+}
+
+// IdentityEvidence is an identifier shared by several devices
+type IdentityEvidence struct {
+	Method  string   `json:"method" enum:"serial,mac,mgmt_ip,hostname"`
+	Value   string   `json:"value"`
+	Devices []string `json:"devices"`
+}
+
+// Lookup returns the canonical ID of deviceID, which is deviceID itself unless it is an alias
+func (m *IdentityMap) Lookup(deviceID string) (string, *DeviceIdentity) {
+	for i, identity := range m.Identities {
+		if identity.CanonicalID == deviceID {
+			return deviceID, &m.Identities[i]
+		}
+		for _, alias := range identity.Aliases {
+			if alias.DeviceID == deviceID {
+				return identity.CanonicalID, &m.Identities[i]
+			}
+		}
+	}
+	return deviceID, nil
+}
+
+// identifiers are the normalized identifiers of a device
+type identifiers map[string]string
+
+// ResolveIdentities groups the devices describing the same box. Devices sharing a serial number,
+// chassis MAC or management IP are merged unless their serial numbers differ; devices sharing only
+// a fuzzy hostname are never merged automatically. Both are reported as conflicts until decided.
+// LLDP placeholders take their MAC from the remote_chassis_id of the links pointing at them.
+func ResolveIdentities(devices []Device, links []Link, options IdentityOptions, decisions []IdentityDecision) *IdentityMap {
+	options = options.WithDefaults()
+
+	byID := make(map[string]Device, len(devices))
+	ids := make([]string, 0, len(devices))
+	for _, device := range devices {
+		byID[device.ID] = device
+		ids = append(ids, device.ID)
+	}
+	sort.Strings(ids)
+
+	chassis := make(map[string]string)
+	for _, link := range links {
+		if mac := normalizeMAC(link.Metadata["remote_chassis_id"]); mac != "" {
+			chassis[link.TargetID] = mac
+		}
+	}
+
+	attrs := make(map[string]identifiers, len(ids))
+	for _, id := range ids {
+		attrs[id] = deviceIdentifiers(byID[id], options, chassis[id])
+	}
+
+	// 強い識別子ごとに共有するデバイスを集める
+	strong := sharedIdentifiers(ids, attrs, IdentityMatchSerial, IdentityMatchMAC, IdentityMatchMgmtIP)
+	groups := newUnionFind()
+	for _, evidence := range strong {
+		for _, id := range evidence.Devices[1:] {
+			groups.union(evidence.Devices[0], id)
+		}
+	}
+
+	decided := make(map[string]IdentityDecision, len(decisions))
+	for _, decision := range decisions {
+		decided[decision.Key] = decision
+	}
+	used := make(map[string]bool)
+	canonicalOf := make(map[string]string) // グループの代表 → 手動で決めた正規ID
+
+	merged := newUnionFind()
+	result := &IdentityMap{Devices: len(ids), Identities: []DeviceIdentity{}, Conflicts: []IdentityConflict{}, StaleDecisions: []string{}}
+
+	// シリアル番号の食い違うグループは判断があるまで統合しない
+	for _, members := range groups.sets(ids) {
+		if len(members) < 2 {
+			continue
+		}
+		key := IdentityKey(members)
+		serials := make(map[string]bool)
+		for _, id := range members {
+			if serial := attrs[id][IdentityMatchSerial]; serial != "" {
+				serials[serial] = true
+			}
+		}
+		if len(serials) > 1 {
+			decision, ok := decided[key]
+			if !ok {
+				result.Conflicts = append(result.Conflicts, IdentityConflict{
+					Key: key, Reason: IdentityConflictSerialMismatch, Devices: members, Evidence: evidenceAmong(strong, members),
+				})
+				continue
+			}
+			used[key] = true
+			if decision.CanonicalID == "" {
+				continue
+			}
+			canonicalOf[members[0]] = decision.CanonicalID
+		}
+		for _, id := range members[1:] {
+			merged.union(members[0], id)
+		}
+	}
+
+	// ホスト名のみ一致するデバイスは候補として報告する
+	for _, evidence := range sharedIdentifiers(ids, attrs, IdentityMatchHostname) {
+		roots := make(map[string]bool)
+		for _, id := range evidence.Devices {
+			roots[merged.find(id)] = true
+		}
+		if len(roots) < 2 {
+			continue
+		}
+		key := IdentityKey(evidence.Devices)
+		decision, ok := decided[key]
+		if !ok {
+			result.Conflicts = append(result.Conflicts, IdentityConflict{
+				Key: key, Reason: IdentityConflictHostname, Devices: evidence.Devices, Evidence: []IdentityEvidence{evidence},
+			})
+			continue
+		}
+		used[key] = true
+		if decision.CanonicalID != "" {
+			for _, id := range evidence.Devices[1:] {
+				merged.union(evidence.Devices[0], id)
+			}
+			canonicalOf[evidence.Devices[0]] = decision.CanonicalID
+		}
+	}
+
+	// 衝突から生まれたものではない手動の統合
+	for _, decision := range decisions {
+		if used[decision.Key] {
+			continue
+		}
+		var known []string
+		for _, id := range decision.Devices {
+			if _, ok := byID[id]; ok {
+				known = append(known, id)
+			}
+		}
+		if decision.CanonicalID == "" || len(known) < 2 {
+			result.StaleDecisions = append(result.StaleDecisions, decision.Key)
+			continue
+		}
+		for _, id := range known[1:] {
+			merged.union(known[0], id)
+		}
+		canonicalOf[known[0]] = decision.CanonicalID
+	}
+
+	canonicalByRoot := make(map[string]string)
+	for member, canonical := range canonicalOf {
+		canonicalByRoot[merged.find(member)] = canonical
+	}
+	for _, members := range merged.sets(ids) {
+		if len(members) < 2 {
+			continue
+		}
+		canonical, ok := canonicalByRoot[merged.find(members[0])]
+		if !ok || !containsString(members, canonical) {
+			canonical = preferredCanonical(members, byID)
+		}
+
+		identity := DeviceIdentity{CanonicalID: canonical, Aliases: []IdentityAlias{}}
+		for _, id := range members {
+			if id == canonical {
+				continue
+			}
+			alias := IdentityAlias{DeviceID: id, Provenance: byID[id].Provenance, Method: IdentityMatchManual}
+			for _, method := range []string{IdentityMatchSerial, IdentityMatchMAC, IdentityMatchMgmtIP} {
+				if value := attrs[id][method]; value != "" && sharesIdentifier(members, attrs, id, method, value) {
+					alias.Method, alias.Evidence = method, value
+					break
+				}
+			}
+			identity.Aliases = append(identity.Aliases, alias)
+		}
+		result.Identities = append(result.Identities, identity)
+	}
+
+	sort.Slice(result.Identities, func(i, j int) bool { return result.Identities[i].CanonicalID < result.Identities[j].CanonicalID })
+	sort.Slice(result.Conflicts, func(i, j int) bool { return result.Conflicts[i].Key < result.Conflicts[j].Key })
+	sort.Strings(result.StaleDecisions)
+	return result
+}
+
+// deviceIdentifiers normalizes the identifiers of a device; chassisMAC comes from LLDP
+func deviceIdentifiers(device Device, options IdentityOptions, chassisMAC string) identifiers {
+	attrs := identifiers{}
+	if serial := strings.ToUpper(strings.TrimSpace(device.Metadata[options.SerialKey])); serial != "" {
+		attrs[IdentityMatchSerial] = serial
+	}
+	if mac := normalizeMAC(device.Metadata[options.MACKey]); mac != "" {
+		attrs[IdentityMatchMAC] = mac
+	} else if chassisMAC != "" {
+		attrs[IdentityMatchMAC] = chassisMAC
+	}
+
+	// instance ラベル由来の ID（host:port）が IP の場合は管理IPとみなす
+	host := device.ID
+	if h, _, err := net.SplitHostPort(device.ID); err == nil {
+		host = h
+	}
+	address := strings.TrimSpace(device.Metadata[options.MgmtAddressKey])
+	if address == "" {
+		address = host
+	}
+	if ip := normalizeIP(address); ip != "" {
+		attrs[IdentityMatchMgmtIP] = ip
+	}
+	if net.ParseIP(host) == nil {
+		if hostname := normalizeHostname(host); hostname != "" {
+			attrs[IdentityMatchHostname] = hostname
+		}
+	}
+	return attrs
+}
+
+// normalizeMAC returns a MAC address as lower case colon separated hex, or "" when value is not one
+func normalizeMAC(value string) string {
+	var hex []rune
+	for _, r := range strings.ToLower(strings.TrimSpace(value)) {
+		switch {
+		case (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f'):
+			hex = append(hex, r)
+		case r == ':' || r == '-' || r == '.' || r == ' ':
+		default:
+			return ""
+		}
+	}
+	if len(hex) != 12 || strings.Trim(string(hex), "0") == "" {
+		return ""
+	}
+	parts := make([]string, 6)
+	for i := range parts {
+		parts[i] = string(hex[i*2 : i*2+2])
+	}
+	return strings.Join(parts, ":")
+}
+
+// normalizeIP returns the IP of an address, also given as host:port or CIDR, or ""
+func normalizeIP(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	if ip, _, err := net.ParseCIDR(address); err == nil {
+		return ip.String()
+	}
+	if ip := net.ParseIP(address); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// normalizeHostname reduces a hostname to its first label without separators, case and leading
+// zeros of numbers, so that "LEAF-01.dc1.example.com" and "leaf1" compare equal
+func normalizeHostname(host string) string {
+	label := strings.ToLower(strings.SplitN(host, ".", 2)[0])
+	var b strings.Builder
+	var digits strings.Builder
+	flush := func() {
+		if digits.Len() > 0 {
+			n, err := strconv.ParseUint(digits.String(), 10, 64)
+			if err == nil {
+				b.WriteString(strconv.FormatUint(n, 10))
+			} else {
+				b.WriteString(digits.String())
+			}
+			digits.Reset()
+		}
+	}
+	for _, r := range label {
+		switch {
+		case unicode.IsDigit(r):
+			digits.WriteRune(r)
+		case unicode.IsLetter(r):
+			flush()
+			b.WriteRune(r)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// sharedIdentifiers lists the identifier values of methods held by more than one device
+func sharedIdentifiers(ids []string, attrs map[string]identifiers, methods ...string) []IdentityEvidence {
+	var shared []IdentityEvidence
+	for _, method := range methods {
+		holders := make(map[string][]string)
+		var values []string
+		for _, id := range ids {
+			value := attrs[id][method]
+			if value == "" {
+				continue
+			}
+			if _, ok := holders[value]; !ok {
+				values = append(values, value)
+			}
+			holders[value] = append(holders[value], id)
+		}
+		sort.Strings(values)
+		for _, value := range values {
+			if len(holders[value]) > 1 {
+				shared = append(shared, IdentityEvidence{Method: method, Value: value, Devices: holders[value]})
+			}
+		}
+	}
+	return shared
+}
+
+// evidenceAmong returns the evidence whose devices all belong to members
+func evidenceAmong(evidence []IdentityEvidence, members []string) []IdentityEvidence {
+	set := toSet(members)
+	var among []IdentityEvidence
+	for _, e := range evidence {
+		if set[e.Devices[0]] {
+			among = append(among, e)
+		}
+	}
+	return among
+}
+
+func sharesIdentifier(members []string, attrs map[string]identifiers, self, method, value string) bool {
+	for _, id := range members {
+		if id != self && attrs[id][method] == value {
+			return true
+		}
+	}
+	return false
+}
+
+// preferredCanonical picks the ID of the most authoritative source, then the smallest ID
+func preferredCanonical(members []string, byID map[string]Device) string {
+	rank := func(id string) int {
+		for i, provenance := range identityProvenanceOrder {
+			if byID[id].Provenance == provenance {
+				return i
+			}
+		}
+		return len(identityProvenanceOrder)
+	}
+	best := members[0]
+	for _, id := range members[1:] {
+		if rank(id) < rank(best) || (rank(id) == rank(best) && id < best) {
+			best = id
+		}
+	}
+	return best
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// unionFind groups device IDs
+type unionFind struct {
+	parent map[string]string
+}
+
+func newUnionFind() *unionFind {
+	return &unionFind{parent: make(map[string]string)}
+}
+
+func (u *unionFind) find(id string) string {
+	parent, ok := u.parent[id]
+	if !ok || parent == id {
+		return id
+	}
+	root := u.find(parent)
+	u.parent[id] = root
+	return root
+}
+
+func (u *unionFind) union(a, b string) {
+	ra, rb := u.find(a), u.find(b)
+	if ra == rb {
+		return
+	}
+	// 小さいIDを代表にして結果を安定させる
+	if rb < ra {
+		ra, rb = rb, ra
+	}
+	u.parent[rb] = ra
+}
+
+// sets returns the groups of ids, each sorted, in the order of their smallest ID
+func (u *unionFind) sets(ids []string) [][]string {
+	byRoot := make(map[string][]string)
+	var roots []string
+	for _, id := range ids {
+		root := u.find(id)
+		if _, ok := byRoot[root]; !ok {
+			roots = append(roots, root)
+		}
+		byRoot[root] = append(byRoot[root], id)
+	}
+	sets := make([][]string, 0, len(roots))
+	for _, root := range roots {
+		sets = append(sets, byRoot[root])
+	}
+	return sets
+}
+
+// Validate checks a decision before it is stored
+func (d IdentityDecision) Validate() error {
+	if len(d.Devices) < 2 {
+		return fmt.Errorf("at least two devices are required")
+	}
+	if d.CanonicalID != "" && !containsString(d.Devices, d.CanonicalID) {
+		return fmt.Errorf("canonical_id %s is not one of the devices", d.CanonicalID)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package topology
+
+import "testing"
+
+func TestResolveIdentities(t *testing.T) {
+	devices := []Device{
+		// NetBox, Prometheus（instance ラベル）と LLDP がそれぞれ別の ID で同じ機器を記述する
+		{ID: "leaf-01", Provenance: ProvenanceNetBox, Metadata: map[string]string{"serial": "sn100", "mgmt_address": "10.0.0.1", "mac": "AA:BB:CC:DD:EE:FF"}},
+		{ID: "10.0.0.1:9116", Provenance: ProvenancePrometheus, Metadata: map[string]string{"serial": "SN100"}},
+		{ID: "LEAF-01.dc1.example.com", Provenance: ProvenanceLLDPPlaceholder},
+		{ID: "spine-01", Provenance: ProvenancePrometheus, Metadata: map[string]string{"mac": "00-11-22-33-44-55"}},
+		// 管理IPは同じだがシリアル番号が異なる（RMA 後の古いレコードなど）
+		{ID: "leaf-02", Provenance: ProvenanceNetBox, Metadata: map[string]string{"serial": "SN200", "mgmt_address": "10.0.0.2"}},
+		{ID: "10.0.0.2:9116", Provenance: ProvenancePrometheus, Metadata: map[string]string{"serial": "SN201"}},
+		// ホスト名のみ一致
+		{ID: "spine01", Provenance: ProvenanceImport},
+	}
+	links := []Link{
+		{ID: "l1", SourceID: "spine-01", TargetID: "LEAF-01.dc1.example.com", Metadata: map[string]string{"remote_chassis_id": "aa:bb:cc:dd:ee:ff"}},
+		{ID: "l2", SourceID: "10.0.0.1:9116", TargetID: "spine-01", Metadata: map[string]string{"remote_chassis_id": "0011.2233.4455"}},
+	}
+
+	identities := ResolveIdentities(devices, links, IdentityOptions{}, nil)
+
+	if len(identities.Identities) != 1 {
+		t.Fatalf("Expected 1 identity, got %+v", identities.Identities)
+	}
+	identity := identities.Identities[0]
+	if identity.CanonicalID != "leaf-01" || len(identity.Aliases) != 2 {
+		t.Fatalf("Expected leaf-01 with 2 aliases, got %+v", identity)
+	}
+	methods := map[string]string{}
+	for _, alias := range identity.Aliases {
+		methods[alias.DeviceID] = alias.Method
+	}
+	if methods["10.0.0.1:9116"] != IdentityMatchSerial || methods["LEAF-01.dc1.example.com"] != IdentityMatchMAC {
+		t.Errorf("Expected matches by serial and LLDP chassis MAC, got %v", methods)
+	}
+
+	reasons := map[string]string{}
+	for _, conflict := range identities.Conflicts {
+		reasons[conflict.Key] = conflict.Reason
+	}
+	if len(reasons) != 2 || reasons["10.0.0.2:9116,leaf-02"] != IdentityConflictSerialMismatch || reasons["spine-01,spine01"] != IdentityConflictHostname {
+		t.Errorf("Expected a serial mismatch and a hostname conflict, got %+v", identities.Conflicts)
+	}
+
+	// 手動の判断で衝突を解決する
+	decisions := []IdentityDecision{
+		{Key: "10.0.0.2:9116,leaf-02", Devices: []string{"leaf-02", "10.0.0.2:9116"}},
+		{Key: "spine-01,spine01", Devices: []string{"spine-01", "spine01"}, CanonicalID: "spine01"},
+		{Key: "gone,leaf-01", Devices: []string{"gone", "leaf-01"}, CanonicalID: "leaf-01"},
+	}
+	identities = ResolveIdentities(devices, links, IdentityOptions{}, decisions)
+	if len(identities.Conflicts) != 0 {
+		t.Errorf("Expected every conflict to be resolved, got %+v", identities.Conflicts)
+	}
+	if canonical, _ := identities.Lookup("spine-01"); canonical != "spine01" {
+		t.Errorf("Expected spine-01 to resolve to spine01, got %s", canonical)
+	}
+	if canonical, _ := identities.Lookup("10.0.0.2:9116"); canonical != "10.0.0.2:9116" {
+		t.Errorf("Expected devices decided distinct to stay separate, got %s", canonical)
+	}
+	if len(identities.StaleDecisions) != 1 || identities.StaleDecisions[0] != "gone,leaf-01" {
+		t.Errorf("Expected the decision about a removed device to be stale, got %v", identities.StaleDecisions)
+	}
+}
+
+func TestNormalizeIdentifiers(t *testing.T) {
+	if got := normalizeMAC("0011.2233.4455"); got != "00:11:22:33:44:55" {
+		t.Errorf("Expected a Cisco style MAC to be normalized, got %q", got)
+	}
+	if got := normalizeMAC("leaf-01"); got != "" {
+		t.Errorf("Expected a non-MAC chassis ID to be ignored, got %q", got)
+	}
+	if got := normalizeHostname("LEAF-01.dc1.example.com"); got != normalizeHostname("leaf1") {
+		t.Errorf("Expected hostnames to compare equal, got %q", got)
+	}
+	if got := normalizeIP("10.0.0.1/24"); got != "10.0.0.1" {
+		t.Errorf("Expected the IP of a CIDR, got %q", got)
+	}
+}
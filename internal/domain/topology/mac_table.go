@@ -0,0 +1,216 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultMaxMACsPerPort is the number of MAC addresses above which a switch port is treated as
+// an uplink or trunk rather than a server port
+const DefaultMaxMACsPerPort = 8
+
+// MACEntry is one MAC address learned on a switch port, optionally enriched from the ARP table
+type MACEntry struct {
+	DeviceID string `json:"device_id"` // MACを学習したスイッチ
+	Port     string `json:"port"`
+	MAC      string `json:"mac"`
+	VLAN     string `json:"vlan,omitempty"`
+	IP       string `json:"ip,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// MACInferenceOptions tune which switch ports are considered server ports
+type MACInferenceOptions struct {
+	// MaxMACsPerPort skips ports that learned more MAC addresses (0 = DefaultMaxMACsPerPort)
+	MaxMACsPerPort int `json:"max_macs_per_port" yaml:"max_macs_per_port"`
+}
+
+func (o MACInferenceOptions) maxMACsPerPort() int {
+	if o.MaxMACsPerPort <= 0 {
+		return DefaultMaxMACsPerPort
+	}
+	return o.MaxMACsPerPort
+}
+
+// ServerAttachment is the switch port a server MAC address was inferred to hang off
+type ServerAttachment struct {
+	ServerID string `json:"server_id"`
+	MAC      string `json:"mac"`
+	IP       string `json:"ip,omitempty"`
+	SwitchID string `json:"switch_id"`
+	Port     string `json:"port"`
+}
+
+// MACInferenceResult holds the servers and links inferred from MAC tables
+type MACInferenceResult struct {
+	// Devices are the servers not stored yet
+	Devices     []Device           `json:"devices"`
+	Links       []Link             `json:"links"`
+	Attachments []ServerAttachment `json:"attachments"`
+	// Ambiguous are MAC addresses learned on several server ports of the same size
+	Ambiguous []string `json:"ambiguous"`
+	// SkippedPorts are ports ignored as uplinks, as "switch:port"
+	SkippedPorts []string `json:"skipped_ports"`
+}
+
+// NormalizeMAC returns mac as lowercase colon-separated octets. It accepts colons, dashes,
+// Cisco-style dots or no separators.
+func NormalizeMAC(mac string) (string, bool) {
+	hex := strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(strings.TrimSpace(mac)))
+	if len(hex) != 12 {
+		return "", false
+	}
+	for _, c := range hex {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return "", false
+		}
+	}
+
+	octets := make([]string, 6)
+	for i := range octets {
+		octets[i] = hex[i*2 : i*2+2]
+	}
+	return strings.Join(octets, ":"), true
+}
+
+// InferServerAttachments infers which switch port each MAC address hangs off. Ports with LLDP
+// links and ports that learned more than MaxMACsPerPort addresses carry other switches' traffic
+// and are ignored; a MAC still learned on several ports is attached to the port with the fewest
+// addresses. Servers are named after their hostname, or "server-" and the MAC address, and are
+// created with the mac-table provenance unless already stored. Links run from the switch port
+// to the server, with the MAC address as the server port.
+func InferServerAttachments(entries []MACEntry, devices []Device, links []Link, opts MACInferenceOptions, now time.Time) MACInferenceResult {
+	result := MACInferenceResult{
+		Devices:      []Device{},
+		Links:        []Link{},
+		Attachments:  []ServerAttachment{},
+		Ambiguous:    []string{},
+		SkippedPorts: []string{},
+	}
+
+	known := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		known[device.ID] = true
+	}
+
+	// LLDP で対向が分かっているポートはスイッチ間リンク
+	linked := make(map[string]bool)
+	for _, link := range links {
+		linked[portKey(link.SourceID, link.SourcePort)] = true
+		linked[portKey(link.TargetID, link.TargetPort)] = true
+	}
+
+	type port struct{ switchID, name string }
+	macsByPort := make(map[port]map[string]bool)
+	info := make(map[string]MACEntry)
+	for _, entry := range entries {
+		mac, ok := NormalizeMAC(entry.MAC)
+		if !ok || entry.DeviceID == "" || entry.Port == "" {
+			continue
+		}
+		p := port{entry.DeviceID, entry.Port}
+		if macsByPort[p] == nil {
+			macsByPort[p] = make(map[string]bool)
+		}
+		macsByPort[p][mac] = true
+
+		merged := info[mac]
+		if merged.IP == "" {
+			merged.IP = entry.IP
+		}
+		if merged.Hostname == "" {
+			merged.Hostname = entry.Hostname
+		}
+		info[mac] = merged
+	}
+
+	maxMACs := opts.maxMACsPerPort()
+	candidates := make(map[string][]port)
+	for p, macs := range macsByPort {
+		if linked[portKey(p.switchID, p.name)] || len(macs) > maxMACs {
+			result.SkippedPorts = append(result.SkippedPorts, portKey(p.switchID, p.name))
+			continue
+		}
+		for mac := range macs {
+			candidates[mac] = append(candidates[mac], p)
+		}
+	}
+
+	macs := make([]string, 0, len(candidates))
+	for mac := range candidates {
+		macs = append(macs, mac)
+	}
+	sort.Strings(macs)
+
+	created := make(map[string]bool)
+	for _, mac := range macs {
+		ports := candidates[mac]
+		sort.Slice(ports, func(i, j int) bool {
+			if ni, nj := len(macsByPort[ports[i]]), len(macsByPort[ports[j]]); ni != nj {
+				return ni < nj
+			}
+			return portKey(ports[i].switchID, ports[i].name) < portKey(ports[j].switchID, ports[j].name)
+		})
+		if len(ports) > 1 && len(macsByPort[ports[0]]) == len(macsByPort[ports[1]]) {
+			result.Ambiguous = append(result.Ambiguous, mac)
+			continue
+		}
+		attached := ports[0]
+
+		serverID := info[mac].Hostname
+		if serverID == "" {
+			serverID = "server-" + strings.ReplaceAll(mac, ":", "")
+		}
+		// スイッチ自身のMAC（管理インターフェースなど）はサーバーとして扱わない
+		if serverID == attached.switchID {
+			continue
+		}
+
+		if !known[serverID] && !created[serverID] {
+			created[serverID] = true
+			metadata := map[string]string{"mac": mac}
+			if ip := info[mac].IP; ip != "" {
+				metadata["ip"] = ip
+			}
+			result.Devices = append(result.Devices, Device{
+				ID:         serverID,
+				Type:       "server",
+				Hardware:   UnknownPlaceholderValue,
+				Provenance: ProvenanceMACTable,
+				Metadata:   metadata,
+				LastSeen:   now,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			})
+		}
+
+		result.Links = append(result.Links, Link{
+			ID:         fmt.Sprintf("mac-link-%s-%s-%s", attached.switchID, attached.name, mac),
+			SourceID:   attached.switchID,
+			SourcePort: attached.name,
+			TargetID:   serverID,
+			TargetPort: mac,
+			Weight:     1.0,
+			Metadata:   map[string]string{"mac": mac},
+			LastSeen:   now,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+		result.Attachments = append(result.Attachments, ServerAttachment{
+			ServerID: serverID,
+			MAC:      mac,
+			IP:       info[mac].IP,
+			SwitchID: attached.switchID,
+			Port:     attached.name,
+		})
+	}
+
+	sort.Strings(result.SkippedPorts)
+	return result
+}
+
+func portKey(deviceID, port string) string {
+	return deviceID + ":" + port
+}
@@ -0,0 +1,104 @@
+package topology
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNormalizeMAC(t *testing.T) {
+	for _, input := range []string{"AA:BB:CC:00:11:22", "aa-bb-cc-00-11-22", "aabb.cc00.1122", "aabbcc001122"} {
+		if mac, ok := NormalizeMAC(input); !ok || mac != "aa:bb:cc:00:11:22" {
+			t.Errorf("Expected %s to normalize to aa:bb:cc:00:11:22, got %s", input, mac)
+		}
+	}
+	for _, input := range []string{"", "aa:bb:cc", "zz:bb:cc:00:11:22"} {
+		if _, ok := NormalizeMAC(input); ok {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestInferServerAttachments(t *testing.T) {
+	devices := []Device{{ID: "leaf-01"}, {ID: "leaf-02"}, {ID: "spine-01"}, {ID: "db-01"}}
+	links := []Link{{SourceID: "leaf-01", SourcePort: "Eth49", TargetID: "spine-01", TargetPort: "Eth1"}}
+
+	entries := []MACEntry{
+		// サーバーポート
+		{DeviceID: "leaf-01", Port: "Eth1", MAC: "00:00:00:00:00:01", Hostname: "web-01", IP: "10.0.0.1"},
+		{DeviceID: "leaf-01", Port: "Eth2", MAC: "00:00:00:00:00:02"},
+		{DeviceID: "leaf-02", Port: "Eth1", MAC: "00:00:00:00:00:03", Hostname: "db-01"},
+		// アップリンク（LLDPリンクあり）で学習した同じMACは無視する
+		{DeviceID: "leaf-01", Port: "Eth49", MAC: "00:00:00:00:00:03"},
+		{DeviceID: "spine-01", Port: "Eth1", MAC: "00:00:00:00:00:01"},
+		// 同じ数のMACを学習した2つのポート
+		{DeviceID: "leaf-01", Port: "Eth3", MAC: "00:00:00:00:00:04"},
+		{DeviceID: "leaf-02", Port: "Eth3", MAC: "00:00:00:00:00:04"},
+	}
+	// LLDPのないトランクポート
+	for i := 0; i < 3; i++ {
+		entries = append(entries, MACEntry{DeviceID: "leaf-02", Port: "Po1", MAC: fmt.Sprintf("00:00:00:00:01:%02x", i)})
+	}
+
+	result := InferServerAttachments(entries, devices, links, MACInferenceOptions{MaxMACsPerPort: 2}, time.Now())
+
+	attached := make(map[string]string)
+	for _, a := range result.Attachments {
+		attached[a.ServerID] = a.SwitchID + ":" + a.Port
+	}
+	expected := map[string]string{
+		"web-01":              "leaf-01:Eth1",
+		"server-000000000002": "leaf-01:Eth2",
+		"db-01":               "leaf-02:Eth1",
+	}
+	if len(attached) != len(expected) {
+		t.Errorf("Expected %d attachments, got %v", len(expected), attached)
+	}
+	for server, port := range expected {
+		if attached[server] != port {
+			t.Errorf("Expected %s on %s, got %s", server, port, attached[server])
+		}
+	}
+
+	if fmt.Sprint(result.Ambiguous) != "[00:00:00:00:00:04]" {
+		t.Errorf("Expected MAC on two equal ports to be ambiguous, got %v", result.Ambiguous)
+	}
+	if fmt.Sprint(result.SkippedPorts) != "[leaf-01:Eth49 leaf-02:Po1 spine-01:Eth1]" {
+		t.Errorf("Expected linked and trunk ports to be skipped, got %v", result.SkippedPorts)
+	}
+
+	// 既存のデバイスは作成しない
+	if len(result.Devices) != 2 {
+		t.Fatalf("Expected 2 new servers, got %+v", result.Devices)
+	}
+	for _, device := range result.Devices {
+		if device.Provenance != ProvenanceMACTable || device.Type != "server" {
+			t.Errorf("Expected mac-table server, got %+v", device)
+		}
+		if device.ID == "web-01" && device.Metadata["ip"] != "10.0.0.1" {
+			t.Errorf("Expected IP from the ARP table, got %v", device.Metadata)
+		}
+	}
+	if len(result.Links) != 3 || result.Links[0].TargetPort == "" {
+		t.Errorf("Expected one link per attachment with the MAC as server port, got %+v", result.Links)
+	}
+}
+
+func TestInferServerAttachments_PrefersPortWithFewestMACs(t *testing.T) {
+	entries := []MACEntry{
+		{DeviceID: "leaf-01", Port: "Eth1", MAC: "00:00:00:00:00:01"},
+		{DeviceID: "leaf-01", Port: "Eth9", MAC: "00:00:00:00:00:01"},
+		{DeviceID: "leaf-01", Port: "Eth9", MAC: "00:00:00:00:00:02"},
+	}
+
+	result := InferServerAttachments(entries, nil, nil, MACInferenceOptions{}, time.Now())
+
+	for _, a := range result.Attachments {
+		if a.MAC == "00:00:00:00:00:01" && a.Port != "Eth1" {
+			t.Errorf("Expected MAC to be attached to the port with fewer MACs, got %s", a.Port)
+		}
+	}
+	if len(result.Attachments) != 2 {
+		t.Errorf("Expected 2 attachments, got %+v", result.Attachments)
+	}
+}
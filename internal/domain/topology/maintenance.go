@@ -0,0 +1,174 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Causes of a maintenance impact
+const (
+	ImpactCauseDown    = "down"    // 対象デバイス自体が停止する
+	ImpactCausePlanned = "planned" // 計画した作業だけで全経路を失う
+	ImpactCauseOverlap = "overlap" // 重なる既存の作業と合わせると全経路を失う
+)
+
+// MaintenanceWindow is a scheduled period during which devices and links are taken down
+type MaintenanceWindow struct {
+	ID          string    `json:"id"`
+	Ticket      string    `json:"ticket" example:"CHG-1234"` // 変更チケット
+	Description string    `json:"description,omitempty"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	Devices     []string  `json:"devices"`
+	Links       []string  `json:"links"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Validate checks the window before it is stored or evaluated
+func (w MaintenanceWindow) Validate() error {
+	if w.StartsAt.IsZero() || w.EndsAt.IsZero() {
+		return fmt.Errorf("starts_at and ends_at are required")
+	}
+	if !w.EndsAt.After(w.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	if len(w.Devices) == 0 && len(w.Links) == 0 {
+		return fmt.Errorf("at least one device or link is required")
+	}
+	return nil
+}
+
+// Overlaps reports whether the window is active at some time between start and end
+func (w MaintenanceWindow) Overlaps(start, end time.Time) bool {
+	return w.StartsAt.Before(end) && start.Before(w.EndsAt)
+}
+
+// MaintenanceImpact lists the devices that lose every path to the uplinks while a planned
+// window is active, taking the windows overlapping it into account
+type MaintenanceImpact struct {
+	Window      MaintenanceWindow   `json:"window"`
+	Overlapping []MaintenanceWindow `json:"overlapping"` // 期間が重なる既存の作業
+	Uplinks     []string            `json:"uplinks"`
+	Checked     int                 `json:"checked"` // 評価したデバイス数
+	Impacted    []ImpactedDevice    `json:"impacted"`
+	// AlreadyIsolated counts checked devices that reach no uplink even without the planned window
+	AlreadyIsolated int `json:"already_isolated"`
+}
+
+// ImpactedDevice is a device that cannot reach any uplink during the window
+type ImpactedDevice struct {
+	DeviceID string `json:"device_id"`
+	Cause    string `json:"cause" enum:"down,planned,overlap"`
+	// Windows are the overlapping windows that together with the planned one cut the device off
+	Windows []string `json:"windows,omitempty"`
+}
+
+// EvaluateMaintenanceImpact finds the devices that reach an uplink today but reach none while
+// planned and the overlapping windows are active. A device reaching any uplink over any remaining
+// path is not impacted; redundancy is therefore taken into account by construction.
+func EvaluateMaintenanceImpact(links []Link, devices, uplinks []string, planned MaintenanceWindow, overlapping []MaintenanceWindow) *MaintenanceImpact {
+	impact := &MaintenanceImpact{
+		Window:      planned,
+		Overlapping: overlapping,
+		Uplinks:     uplinks,
+		Checked:     len(devices),
+		Impacted:    []ImpactedDevice{},
+	}
+	if impact.Overlapping == nil {
+		impact.Overlapping = []MaintenanceWindow{}
+	}
+
+	existing := reachableDuring(links, uplinks, overlapping...)
+	plannedOnly := reachableDuring(links, uplinks, planned)
+	combined := reachableDuring(links, uplinks, append([]MaintenanceWindow{planned}, overlapping...)...)
+	plannedDevices := toSet(planned.Devices)
+
+	for _, deviceID := range devices {
+		// 既存の作業だけで既に到達できないデバイスは今回の作業の影響ではない
+		if !existing[deviceID] {
+			impact.AlreadyIsolated++
+			continue
+		}
+		if combined[deviceID] {
+			continue
+		}
+
+		impacted := ImpactedDevice{DeviceID: deviceID}
+		switch {
+		case plannedDevices[deviceID]:
+			impacted.Cause = ImpactCauseDown
+		case !plannedOnly[deviceID]:
+			impacted.Cause = ImpactCausePlanned
+		default:
+			impacted.Cause = ImpactCauseOverlap
+			// 単独で計画と組み合わせて経路を断つ既存の作業（複数の組み合わせでのみ断つ場合は全件）
+			for _, window := range overlapping {
+				if !reachableDuring(links, uplinks, planned, window)[deviceID] {
+					impacted.Windows = append(impacted.Windows, window.ID)
+				}
+			}
+			if len(impacted.Windows) == 0 {
+				for _, window := range overlapping {
+					impacted.Windows = append(impacted.Windows, window.ID)
+				}
+			}
+		}
+		impact.Impacted = append(impact.Impacted, impacted)
+	}
+
+	sort.Slice(impact.Impacted, func(i, j int) bool { return impact.Impacted[i].DeviceID < impact.Impacted[j].DeviceID })
+	return impact
+}
+
+// reachableDuring returns the devices connected to any uplink once the devices and links of windows are down
+func reachableDuring(links []Link, uplinks []string, windows ...MaintenanceWindow) map[string]bool {
+	downDevices := make(map[string]bool)
+	downLinks := make(map[string]bool)
+	for _, window := range windows {
+		for _, id := range window.Devices {
+			downDevices[id] = true
+		}
+		for _, id := range window.Links {
+			downLinks[id] = true
+		}
+	}
+
+	remaining := make([]Link, 0, len(links))
+	for _, link := range links {
+		if downLinks[link.ID] || downDevices[link.SourceID] || downDevices[link.TargetID] {
+			continue
+		}
+		remaining = append(remaining, link)
+	}
+	g := NewGraphIndex(remaining)
+
+	reached := make(map[string]bool)
+	var queue []string
+	for _, uplink := range uplinks {
+		if !downDevices[uplink] && !reached[uplink] {
+			reached[uplink] = true
+			queue = append(queue, uplink)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range g.adjacency[current] {
+			if !reached[edge.to] {
+				reached[edge.to] = true
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+	return reached
+}
+
+func toSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,68 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateMaintenanceImpact(t *testing.T) {
+	// server-1 は leaf-1 と leaf-2 に冗長接続、server-2 は leaf-2 のみ、server-3 は leaf-3 のみ
+	links := []Link{
+		{ID: "l1", SourceID: "leaf-1", TargetID: "spine-1"},
+		{ID: "l2", SourceID: "leaf-2", TargetID: "spine-1"},
+		{ID: "l3", SourceID: "leaf-3", TargetID: "spine-1"},
+		{ID: "s1a", SourceID: "server-1", TargetID: "leaf-1"},
+		{ID: "s1b", SourceID: "server-1", TargetID: "leaf-2"},
+		{ID: "s2", SourceID: "server-2", TargetID: "leaf-2"},
+		{ID: "s3", SourceID: "server-3", TargetID: "leaf-3"},
+		{ID: "s4", SourceID: "server-4", TargetID: "leaf-3"},
+	}
+	servers := []string{"server-1", "server-2", "server-3", "server-4", "server-5"}
+	start := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+
+	planned := MaintenanceWindow{ID: "planned", StartsAt: start, EndsAt: start.Add(2 * time.Hour), Devices: []string{"leaf-2", "server-4"}}
+	overlapping := []MaintenanceWindow{
+		{ID: "chg-1", StartsAt: start.Add(time.Hour), EndsAt: start.Add(3 * time.Hour), Links: []string{"s1a"}},
+	}
+
+	impact := EvaluateMaintenanceImpact(links, servers, []string{"spine-1"}, planned, overlapping)
+
+	expected := map[string]string{
+		"server-1": ImpactCauseOverlap, // leaf-2 の停止だけなら leaf-1 経由で残る
+		"server-2": ImpactCausePlanned,
+		"server-4": ImpactCauseDown,
+	}
+	if len(impact.Impacted) != len(expected) {
+		t.Fatalf("Expected %d impacted devices, got %+v", len(expected), impact.Impacted)
+	}
+	for _, impacted := range impact.Impacted {
+		if expected[impacted.DeviceID] != impacted.Cause {
+			t.Errorf("Expected %s to be impacted with cause %q, got %q", impacted.DeviceID, expected[impacted.DeviceID], impacted.Cause)
+		}
+		if impacted.Cause == ImpactCauseOverlap && (len(impacted.Windows) != 1 || impacted.Windows[0] != "chg-1") {
+			t.Errorf("Expected %s to be cut off together with chg-1, got %v", impacted.DeviceID, impacted.Windows)
+		}
+	}
+	// server-5 はリンクがなく元から到達できない
+	if impact.AlreadyIsolated != 1 || impact.Checked != 5 {
+		t.Errorf("Expected 5 checked and 1 already isolated, got %d and %d", impact.Checked, impact.AlreadyIsolated)
+	}
+}
+
+func TestMaintenanceWindow_Validate(t *testing.T) {
+	start := time.Now()
+	if err := (MaintenanceWindow{StartsAt: start, EndsAt: start.Add(time.Hour), Links: []string{"l1"}}).Validate(); err != nil {
+		t.Errorf("Expected a valid window, got %v", err)
+	}
+	if err := (MaintenanceWindow{StartsAt: start, EndsAt: start, Links: []string{"l1"}}).Validate(); err == nil {
+		t.Error("Expected an empty period to be rejected")
+	}
+	if err := (MaintenanceWindow{StartsAt: start, EndsAt: start.Add(time.Hour)}).Validate(); err == nil {
+		t.Error("Expected a window without devices or links to be rejected")
+	}
+
+	window := MaintenanceWindow{StartsAt: start, EndsAt: start.Add(time.Hour)}
+	if !window.Overlaps(start.Add(30*time.Minute), start.Add(2*time.Hour)) || window.Overlaps(start.Add(time.Hour), start.Add(2*time.Hour)) {
+		t.Error("Expected windows to overlap only when their periods intersect")
+	}
+}
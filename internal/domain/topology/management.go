@@ -0,0 +1,132 @@
+package topology
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default settings of the management-plane reachability check
+const (
+	DefaultManagementAddressKey = "mgmt_address"
+	DefaultSSHPort              = 22
+	DefaultNETCONFPort          = 830
+	DefaultManagementTimeout    = 3 * time.Second
+	DefaultManagementWorkers    = 16
+)
+
+// ManagementCheckOptions controls how the management addresses of devices are probed
+type ManagementCheckOptions struct {
+	// AddressKey is the device metadata key holding the management address. Devices without it
+	// are probed at the host part of their ID (e.g. the Prometheus instance label).
+	AddressKey  string        `json:"address_key" yaml:"address_key"`
+	SSHPort     int           `json:"ssh_port" yaml:"ssh_port"`
+	NETCONFPort int           `json:"netconf_port" yaml:"netconf_port"` // 0 = DefaultNETCONFPort, -1 = NETCONFは確認しない
+	Timeout     time.Duration `json:"timeout" yaml:"timeout"`           // TCP接続1回あたり
+	Workers     int           `json:"workers" yaml:"workers"`           // 同時に確認するデバイス数
+}
+
+// WithDefaults returns the options with unset fields replaced by the defaults
+func (o ManagementCheckOptions) WithDefaults() ManagementCheckOptions {
+	if o.AddressKey == "" {
+		o.AddressKey = DefaultManagementAddressKey
+	}
+	if o.SSHPort <= 0 {
+		o.SSHPort = DefaultSSHPort
+	}
+	if o.NETCONFPort == 0 {
+		o.NETCONFPort = DefaultNETCONFPort
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultManagementTimeout
+	}
+	if o.Workers <= 0 {
+		o.Workers = DefaultManagementWorkers
+	}
+	return o
+}
+
+// ManagementAddress returns the host the management plane of device is probed at
+func (o ManagementCheckOptions) ManagementAddress(device Device) string {
+	if address := strings.TrimSpace(device.Metadata[o.AddressKey]); address != "" {
+		return address
+	}
+	// instance ラベル由来の ID は host:port 形式のことが多い
+	if host, port, err := net.SplitHostPort(device.ID); err == nil {
+		if _, err := strconv.Atoi(port); err == nil {
+			return host
+		}
+	}
+	return device.ID
+}
+
+// ManagementReachability is the result of the latest management-plane check of a device.
+// It is kept apart from the data-plane status (last_seen from Prometheus).
+type ManagementReachability struct {
+	DeviceID        string     `json:"device_id"`
+	Address         string     `json:"address"`
+	SSH             bool       `json:"ssh"`
+	NETCONF         bool       `json:"netconf"`
+	Error           string     `json:"error,omitempty"` // 到達できなかった理由（最後のエラー）
+	CheckedAt       time.Time  `json:"checked_at"`
+	LastReachableAt *time.Time `json:"last_reachable_at,omitempty"`
+}
+
+// Manageable reports whether SSH or NETCONF accepted a connection at the last check
+func (r ManagementReachability) Manageable() bool {
+	return r.SSH || r.NETCONF
+}
+
+// UnmanageableDevice is a device that is monitored but whose management plane is unreachable
+type UnmanageableDevice struct {
+	ManagementReachability
+	LastSeen time.Time `json:"last_seen"` // データプレーン（監視）で最後に確認された時刻
+}
+
+// ManagementReachabilityReport summarizes the management-plane checks of the monitored devices
+type ManagementReachabilityReport struct {
+	MonitoredSince   time.Time            `json:"monitored_since"`
+	MonitoredDevices int                  `json:"monitored_devices"`
+	Manageable       int                  `json:"manageable"`
+	Unchecked        int                  `json:"unchecked"` // まだ確認していないデバイス
+	Unmanageable     []UnmanageableDevice `json:"unmanageable"`
+}
+
+// BuildManagementReachabilityReport lists the devices seen by monitoring since monitoredSince
+// whose last management-plane check failed. Devices not seen since then are out of scope:
+// their management plane being down is expected.
+func BuildManagementReachabilityReport(devices []Device, results []ManagementReachability, monitoredSince time.Time) ManagementReachabilityReport {
+	report := ManagementReachabilityReport{
+		MonitoredSince: monitoredSince,
+		Unmanageable:   []UnmanageableDevice{},
+	}
+
+	byDevice := make(map[string]ManagementReachability, len(results))
+	for _, result := range results {
+		byDevice[result.DeviceID] = result
+	}
+
+	for _, device := range devices {
+		if device.LastSeen.Before(monitoredSince) {
+			continue
+		}
+		report.MonitoredDevices++
+
+		result, ok := byDevice[device.ID]
+		switch {
+		case !ok:
+			report.Unchecked++
+		case result.Manageable():
+			report.Manageable++
+		default:
+			report.Unmanageable = append(report.Unmanageable, UnmanageableDevice{ManagementReachability: result, LastSeen: device.LastSeen})
+		}
+	}
+
+	sort.Slice(report.Unmanageable, func(i, j int) bool {
+		return report.Unmanageable[i].DeviceID < report.Unmanageable[j].DeviceID
+	})
+	return report
+}
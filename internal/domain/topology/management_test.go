@@ -0,0 +1,52 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManagementCheckOptions_ManagementAddress(t *testing.T) {
+	opts := ManagementCheckOptions{}.WithDefaults()
+
+	tests := []struct {
+		device   Device
+		expected string
+	}{
+		{Device{ID: "spine-01", Metadata: map[string]string{"mgmt_address": "10.0.0.1"}}, "10.0.0.1"},
+		{Device{ID: "10.0.0.2:9116"}, "10.0.0.2"},
+		{Device{ID: "[2001:db8::1]:161"}, "2001:db8::1"},
+		{Device{ID: "leaf-01.example.com"}, "leaf-01.example.com"},
+	}
+	for _, tt := range tests {
+		if got := opts.ManagementAddress(tt.device); got != tt.expected {
+			t.Errorf("Expected %s for %s, got %s", tt.expected, tt.device.ID, got)
+		}
+	}
+}
+
+func TestBuildManagementReachabilityReport(t *testing.T) {
+	now := time.Now()
+	devices := []Device{
+		{ID: "spine-01", LastSeen: now},
+		{ID: "leaf-01", LastSeen: now},
+		{ID: "leaf-02", LastSeen: now},
+		{ID: "old-01", LastSeen: now.Add(-48 * time.Hour)},
+	}
+	results := []ManagementReachability{
+		{DeviceID: "spine-01", SSH: true, CheckedAt: now},
+		{DeviceID: "leaf-01", Error: "connection refused", CheckedAt: now},
+		{DeviceID: "old-01", CheckedAt: now},
+	}
+
+	report := BuildManagementReachabilityReport(devices, results, now.Add(-24*time.Hour))
+
+	if report.MonitoredDevices != 3 || report.Manageable != 1 || report.Unchecked != 1 {
+		t.Errorf("Expected 3 monitored, 1 manageable and 1 unchecked device, got %+v", report)
+	}
+	if len(report.Unmanageable) != 1 || report.Unmanageable[0].DeviceID != "leaf-01" {
+		t.Fatalf("Expected only leaf-01 to be unmanageable (old-01 is no longer monitored), got %+v", report.Unmanageable)
+	}
+	if report.Unmanageable[0].LastSeen != now {
+		t.Errorf("Expected the data-plane last_seen to be reported, got %v", report.Unmanageable[0].LastSeen)
+	}
+}
@@ -0,0 +1,137 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetadataRequirement declares the metadata keys every device of a device type must carry
+type MetadataRequirement struct {
+	DeviceType string   `json:"device_type" yaml:"device_type" example:"server"`
+	Keys       []string `json:"keys" yaml:"keys"`
+}
+
+// MetadataSchema holds the metadata required per device type. A device is of the device type it
+// was classified as, or of its discovered type while unclassified; types are compared
+// case-insensitively.
+type MetadataSchema struct {
+	Required []MetadataRequirement `json:"required" yaml:"required"`
+	// Enforce rejects classifying a device as a type whose required metadata it lacks
+	Enforce bool `json:"enforce" yaml:"enforce"`
+}
+
+// Validate checks every requirement names a device type and keys, once per device type
+func (s MetadataSchema) Validate() error {
+	seen := make(map[string]int)
+	for i, requirement := range s.Required {
+		deviceType := strings.ToLower(strings.TrimSpace(requirement.DeviceType))
+		if deviceType == "" {
+			return fmt.Errorf("requirement %d has no device_type", i)
+		}
+		if previous, ok := seen[deviceType]; ok {
+			return fmt.Errorf("requirement %d repeats device_type %s of requirement %d", i, requirement.DeviceType, previous)
+		}
+		seen[deviceType] = i
+		if len(requirement.Keys) == 0 {
+			return fmt.Errorf("requirement %d (%s) has no keys", i, requirement.DeviceType)
+		}
+		for _, key := range requirement.Keys {
+			if strings.TrimSpace(key) == "" {
+				return fmt.Errorf("requirement %d (%s) has an empty key", i, requirement.DeviceType)
+			}
+		}
+	}
+	return nil
+}
+
+// RequiredKeys returns the keys required for deviceType; nil means the type is not governed
+func (s MetadataSchema) RequiredKeys(deviceType string) []string {
+	for _, requirement := range s.Required {
+		if strings.EqualFold(strings.TrimSpace(requirement.DeviceType), strings.TrimSpace(deviceType)) {
+			return requirement.Keys
+		}
+	}
+	return nil
+}
+
+// MissingKeys returns the required keys device lacks or leaves blank, in schema order. The key
+// "rack" is also satisfied by the rack placement of the device.
+func (s MetadataSchema) MissingKeys(device Device) []string {
+	var missing []string
+	for _, key := range s.RequiredKeys(MetadataDeviceType(device)) {
+		if key == "rack" && device.Rack != "" {
+			continue
+		}
+		if strings.TrimSpace(device.Metadata[key]) == "" {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// MetadataDeviceType returns the device type a device is checked as: its classified device type,
+// or its discovered type while unclassified
+func MetadataDeviceType(device Device) string {
+	if device.DeviceType != "" {
+		return device.DeviceType
+	}
+	return device.Type
+}
+
+// MetadataViolation is a device lacking metadata required for its device type
+type MetadataViolation struct {
+	DeviceID   string   `json:"device_id"`
+	DeviceType string   `json:"device_type"`
+	Missing    []string `json:"missing"`
+}
+
+// MetadataComplianceReport lists the devices lacking the metadata required for their device type
+type MetadataComplianceReport struct {
+	GeneratedAt      time.Time           `json:"generated_at"`
+	CheckedDevices   int                 `json:"checked_devices"` // 要件のあるデバイスタイプのデバイス数
+	CompliantDevices int                 `json:"compliant_devices"`
+	Violations       []MetadataViolation `json:"violations"`
+	ByDeviceType     map[string]int      `json:"by_device_type"` // デバイスタイプごとの違反数
+	MissingKeys      map[string]int      `json:"missing_keys"`   // キーごとの欠落数
+}
+
+// CheckMetadataCompliance checks devices against schema. Devices of types without requirements
+// are not checked.
+func CheckMetadataCompliance(devices []Device, schema MetadataSchema, now time.Time) *MetadataComplianceReport {
+	report := &MetadataComplianceReport{
+		GeneratedAt:  now,
+		Violations:   []MetadataViolation{},
+		ByDeviceType: map[string]int{},
+		MissingKeys:  map[string]int{},
+	}
+
+	for _, device := range devices {
+		deviceType := MetadataDeviceType(device)
+		if schema.RequiredKeys(deviceType) == nil {
+			continue
+		}
+		report.CheckedDevices++
+
+		missing := schema.MissingKeys(device)
+		if len(missing) == 0 {
+			report.CompliantDevices++
+			continue
+		}
+		report.Violations = append(report.Violations, MetadataViolation{
+			DeviceID:   device.ID,
+			DeviceType: deviceType,
+			Missing:    missing,
+		})
+		report.ByDeviceType[strings.ToLower(deviceType)]++
+		for _, key := range missing {
+			report.MissingKeys[key]++
+		}
+	}
+
+	sort.Slice(report.Violations, func(i, j int) bool {
+		return report.Violations[i].DeviceID < report.Violations[j].DeviceID
+	})
+	return report
+}
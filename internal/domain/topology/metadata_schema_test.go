@@ -0,0 +1,57 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetadataSchema_Validate(t *testing.T) {
+	valid := MetadataSchema{Required: []MetadataRequirement{
+		{DeviceType: "server", Keys: []string{"owner", "rack"}},
+		{DeviceType: "switch", Keys: []string{"site"}},
+	}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Expected schema to be valid, got %v", err)
+	}
+
+	invalid := []MetadataSchema{
+		{Required: []MetadataRequirement{{Keys: []string{"owner"}}}},
+		{Required: []MetadataRequirement{{DeviceType: "server"}}},
+		{Required: []MetadataRequirement{{DeviceType: "server", Keys: []string{" "}}}},
+		{Required: []MetadataRequirement{{DeviceType: "server", Keys: []string{"owner"}}, {DeviceType: "Server", Keys: []string{"rack"}}}},
+	}
+	for _, schema := range invalid {
+		if err := schema.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", schema)
+		}
+	}
+}
+
+func TestCheckMetadataCompliance(t *testing.T) {
+	schema := MetadataSchema{Required: []MetadataRequirement{
+		{DeviceType: "server", Keys: []string{"owner", "rack"}},
+	}}
+	devices := []Device{
+		{ID: "srv-02", DeviceType: "server", Metadata: map[string]string{"owner": "db-team"}},
+		{ID: "srv-01", DeviceType: "Server", Metadata: map[string]string{"owner": "web-team", "rack": "r12"}},
+		// ラックに設置済みなら rack キーは不要
+		{ID: "srv-04", DeviceType: "server", Rack: "r12", Metadata: map[string]string{"owner": "web-team"}},
+		// 未分類のデバイスは検出されたタイプで判定する
+		{ID: "srv-03", Type: "server", Metadata: map[string]string{"owner": " "}},
+		{ID: "leaf-01", DeviceType: "leaf"},
+	}
+
+	report := CheckMetadataCompliance(devices, schema, time.Now())
+	if report.CheckedDevices != 4 || report.CompliantDevices != 2 {
+		t.Errorf("Expected 4 checked and 2 compliant devices, got %+v", report)
+	}
+	if len(report.Violations) != 2 || report.Violations[0].DeviceID != "srv-02" || report.Violations[1].DeviceID != "srv-03" {
+		t.Fatalf("Expected srv-02 and srv-03 to violate the schema, got %+v", report.Violations)
+	}
+	if missing := report.Violations[1].Missing; len(missing) != 2 || missing[0] != "owner" || missing[1] != "rack" {
+		t.Errorf("Expected a blank owner to count as missing, got %v", missing)
+	}
+	if report.ByDeviceType["server"] != 2 || report.MissingKeys["rack"] != 2 || report.MissingKeys["owner"] != 1 {
+		t.Errorf("Expected violations to be counted per device type and key, got %+v", report)
+	}
+}
@@ -0,0 +1,86 @@
+package topology
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultOrphanGracePeriod is how long after a device was deleted the links still reported for it are dropped
+const DefaultOrphanGracePeriod = 24 * time.Hour
+
+// OrphanedLinkPolicy decides how the sync treats links that monitoring still reports for a deleted
+// device. Stored links are deleted together with their devices, but neighbors keep reporting them
+// until their LLDP entries age out, and the sync would recreate the device as a placeholder. Until
+// the grace period since the deletion has passed those links are dropped instead; a device still
+// reported after that is recreated.
+type OrphanedLinkPolicy struct {
+	Disabled    bool          `yaml:"disabled"`     // 削除済みデバイスもすぐにプレースホルダーとして再作成する
+	GracePeriod time.Duration `yaml:"grace_period"` // 0 = DefaultOrphanGracePeriod
+}
+
+// WithDefaults returns the policy with the default grace period when none is set
+func (p OrphanedLinkPolicy) WithDefaults() OrphanedLinkPolicy {
+	if p.GracePeriod == 0 {
+		p.GracePeriod = DefaultOrphanGracePeriod
+	}
+	return p
+}
+
+// Validate checks the grace period is not negative
+func (p OrphanedLinkPolicy) Validate() error {
+	if p.GracePeriod < 0 {
+		return fmt.Errorf("grace_period must not be negative")
+	}
+	return nil
+}
+
+// DeletedSince returns the deletion time from which links to deleted devices are still dropped at now
+func (p OrphanedLinkPolicy) DeletedSince(now time.Time) time.Time {
+	return now.Add(-p.WithDefaults().GracePeriod)
+}
+
+// DeletedDevice is a device deleted through the API and the links monitoring still reported for it
+type DeletedDevice struct {
+	DeviceID       string     `json:"device_id"`
+	DeletedAt      time.Time  `json:"deleted_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`                 // これ以降も報告されるリンクは取り込む
+	OrphanedLinks  []string   `json:"orphaned_links"`             // 直近の同期で除外したリンク
+	LastReportedAt *time.Time `json:"last_reported_at,omitempty"` // nil = 削除後に報告されていない
+}
+
+// OrphanedLinkReport lists the devices whose links are dropped by the sync under the policy
+type OrphanedLinkReport struct {
+	GracePeriodSeconds int64           `json:"grace_period_seconds"`
+	Dropping           bool            `json:"dropping"` // false = 削除済みデバイスも再作成する
+	Devices            []DeletedDevice `json:"devices"`
+}
+
+// NewOrphanedLinkReport sets when each deletion expires under policy
+func NewOrphanedLinkReport(devices []DeletedDevice, policy OrphanedLinkPolicy) OrphanedLinkReport {
+	policy = policy.WithDefaults()
+	report := OrphanedLinkReport{
+		GracePeriodSeconds: int64(policy.GracePeriod / time.Second),
+		Dropping:           !policy.Disabled,
+		Devices:            make([]DeletedDevice, 0, len(devices)),
+	}
+	for _, device := range devices {
+		device.ExpiresAt = device.DeletedAt.Add(policy.GracePeriod)
+		if device.OrphanedLinks == nil {
+			device.OrphanedLinks = []string{}
+		}
+		report.Devices = append(report.Devices, device)
+	}
+	return report
+}
+
+// OrphanedLinkRepository is implemented by repositories that remember the devices deleted through
+// DeleteDevices, so the sync does not recreate them from links their neighbors still report
+type OrphanedLinkRepository interface {
+	// ListDeletedDevices returns the devices deleted at or after since, oldest deletion first
+	ListDeletedDevices(ctx context.Context, since time.Time) ([]DeletedDevice, error)
+	// RecordOrphanedLinks stores the links reported at for a deleted device by the latest sync
+	RecordOrphanedLinks(ctx context.Context, deviceID string, linkIDs []string, at time.Time) error
+	// PurgeDeletedDevices forgets the devices deleted before deletedBefore and returns how many
+	PurgeDeletedDevices(ctx context.Context, deletedBefore time.Time) (int, error)
+}
@@ -0,0 +1,45 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrphanedLinkPolicy(t *testing.T) {
+	if got := (OrphanedLinkPolicy{}).WithDefaults().GracePeriod; got != DefaultOrphanGracePeriod {
+		t.Errorf("Expected the default grace period, got %v", got)
+	}
+	if err := (OrphanedLinkPolicy{GracePeriod: -time.Hour}).Validate(); err == nil {
+		t.Error("Expected a negative grace period to be rejected")
+	}
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	if got := (OrphanedLinkPolicy{GracePeriod: time.Hour}).DeletedSince(now); !got.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Expected devices deleted since 11:00 to be dropped, got %v", got)
+	}
+}
+
+func TestNewOrphanedLinkReport(t *testing.T) {
+	deletedAt := time.Date(2026, 10, 15, 11, 30, 0, 0, time.UTC)
+	devices := []DeletedDevice{
+		{DeviceID: "leaf-01", DeletedAt: deletedAt, OrphanedLinks: []string{"l1"}},
+		{DeviceID: "leaf-02", DeletedAt: deletedAt},
+	}
+	policy := OrphanedLinkPolicy{GracePeriod: time.Hour}
+
+	report := NewOrphanedLinkReport(devices, policy)
+	if report.GracePeriodSeconds != 3600 || !report.Dropping || len(report.Devices) != 2 {
+		t.Fatalf("Expected two devices with a one hour grace period, got %+v", report)
+	}
+	if want := deletedAt.Add(time.Hour); !report.Devices[0].ExpiresAt.Equal(want) {
+		t.Errorf("Expected the deletion to expire at %v, got %v", want, report.Devices[0].ExpiresAt)
+	}
+	if report.Devices[1].OrphanedLinks == nil {
+		t.Error("Expected an empty list of links rather than null")
+	}
+
+	policy.Disabled = true
+	if report := NewOrphanedLinkReport(devices, policy); report.Dropping {
+		t.Errorf("Expected links not to be dropped when disabled, got %+v", report)
+	}
+}
@@ -0,0 +1,116 @@
+package topology
+
+import "sort"
+
+// OverviewUnassigned is the ID of the overview node holding the devices that belong to no group
+const OverviewUnassigned = "(unassigned)"
+
+// OverviewNode is one site or fabric collapsed into a single node
+type OverviewNode struct {
+	ID          string         `json:"id"`
+	Devices     int            `json:"devices"`
+	DeviceTypes map[string]int `json:"device_types"`         // デバイスタイプ別の台数
+	Unassigned  bool           `json:"unassigned,omitempty"` // どのグループにも属さないデバイス
+}
+
+// OverviewLink aggregates the links between two groups
+type OverviewLink struct {
+	Source            string  `json:"source"`
+	Target            string  `json:"target"`
+	Links             int     `json:"links"`
+	BandwidthBps      float64 `json:"bandwidth_bps"`
+	UnknownSpeedLinks int     `json:"unknown_speed_links"` // 帯域に含まれていないリンク
+}
+
+// Overview is the topology with every group collapsed into one node and the links between
+// groups aggregated, small enough to render as a landing page before drilling into a group
+type Overview struct {
+	GroupBy         string         `json:"group_by"`
+	Nodes           []OverviewNode `json:"nodes"`
+	Links           []OverviewLink `json:"links"`
+	IntraGroupLinks int            `json:"intra_group_links"` // グループ内のリンク（集約対象外）
+}
+
+// BuildOverview collapses devices into the groups of groupOf (group by device ID). Devices absent
+// from groupOf are collected in the OverviewUnassigned node. Links between different groups are
+// aggregated per group pair regardless of direction; each link ID is counted once, and links to
+// unknown devices are ignored.
+func BuildOverview(groupOf map[string]string, devices []Device, links []Link) Overview {
+	overview := Overview{Nodes: []OverviewNode{}, Links: []OverviewLink{}}
+
+	nodes := make(map[string]*OverviewNode)
+	deviceGroup := make(map[string]string, len(devices))
+	for _, device := range devices {
+		group, ok := groupOf[device.ID]
+		if !ok || group == "" {
+			group = OverviewUnassigned
+		}
+		deviceGroup[device.ID] = group
+
+		node, ok := nodes[group]
+		if !ok {
+			node = &OverviewNode{ID: group, DeviceTypes: make(map[string]int), Unassigned: group == OverviewUnassigned}
+			nodes[group] = node
+		}
+		node.Devices++
+		node.DeviceTypes[device.Type]++
+	}
+
+	seen := make(map[string]bool)
+	pairs := make(map[[2]string]*OverviewLink)
+	for _, link := range links {
+		if seen[link.ID] {
+			continue
+		}
+		source, sourceOK := deviceGroup[link.SourceID]
+		target, targetOK := deviceGroup[link.TargetID]
+		if !sourceOK || !targetOK {
+			continue
+		}
+		seen[link.ID] = true
+
+		if source == target {
+			overview.IntraGroupLinks++
+			continue
+		}
+		// 向きに関係なく同じグループの組に集約する
+		if source > target {
+			source, target = target, source
+		}
+		key := [2]string{source, target}
+		pair, ok := pairs[key]
+		if !ok {
+			pair = &OverviewLink{Source: source, Target: target}
+			pairs[key] = pair
+		}
+		pair.Links++
+		if bps, ok := ParseLinkSpeed(link.Metadata["speed"]); ok {
+			pair.BandwidthBps += bps
+		} else {
+			pair.UnknownSpeedLinks++
+		}
+	}
+
+	for _, node := range nodes {
+		overview.Nodes = append(overview.Nodes, *node)
+	}
+	// 未所属のノードは最後に並べる
+	sort.Slice(overview.Nodes, func(i, j int) bool {
+		a, b := overview.Nodes[i], overview.Nodes[j]
+		if a.Unassigned != b.Unassigned {
+			return b.Unassigned
+		}
+		return a.ID < b.ID
+	})
+	for _, pair := range pairs {
+		overview.Links = append(overview.Links, *pair)
+	}
+	sort.Slice(overview.Links, func(i, j int) bool {
+		if overview.Links[i].Source != overview.Links[j].Source {
+			return overview.Links[i].Source < overview.Links[j].Source
+		}
+		return overview.Links[i].Target < overview.Links[j].Target
+	})
+
+	return overview
+}
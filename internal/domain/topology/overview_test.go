@@ -0,0 +1,52 @@
+package topology
+
+import "testing"
+
+func TestBuildOverview(t *testing.T) {
+	devices := []Device{
+		{ID: "tyo-spine-1", Type: "spine"},
+		{ID: "tyo-leaf-1", Type: "leaf"},
+		{ID: "osa-spine-1", Type: "spine"},
+		{ID: "lab-sw-1", Type: "switch"},
+	}
+	groupOf := map[string]string{
+		"tyo-spine-1": "tyo",
+		"tyo-leaf-1":  "tyo",
+		"osa-spine-1": "osa",
+	}
+	links := []Link{
+		{ID: "l1", SourceID: "tyo-spine-1", TargetID: "osa-spine-1", Metadata: map[string]string{"speed": "100G"}},
+		{ID: "l2", SourceID: "osa-spine-1", TargetID: "tyo-spine-1", Metadata: map[string]string{"speed": "100G"}},
+		{ID: "l3", SourceID: "tyo-spine-1", TargetID: "osa-spine-1"},
+		{ID: "l1", SourceID: "tyo-spine-1", TargetID: "osa-spine-1", Metadata: map[string]string{"speed": "100G"}}, // 重複
+		{ID: "l4", SourceID: "tyo-spine-1", TargetID: "tyo-leaf-1"},                                                // サイト内
+		{ID: "l5", SourceID: "lab-sw-1", TargetID: "tyo-leaf-1", Metadata: map[string]string{"speed": "10G"}},
+		{ID: "l6", SourceID: "tyo-leaf-1", TargetID: "gone-1"}, // 存在しないデバイス
+	}
+
+	overview := BuildOverview(groupOf, devices, links)
+
+	if len(overview.Nodes) != 3 {
+		t.Fatalf("Expected 3 nodes, got %+v", overview.Nodes)
+	}
+	if overview.Nodes[0].ID != "osa" || overview.Nodes[1].ID != "tyo" || !overview.Nodes[2].Unassigned {
+		t.Errorf("Expected nodes ordered by ID with the unassigned node last, got %+v", overview.Nodes)
+	}
+	if tyo := overview.Nodes[1]; tyo.Devices != 2 || tyo.DeviceTypes["spine"] != 1 || tyo.DeviceTypes["leaf"] != 1 {
+		t.Errorf("Expected tyo to hold one spine and one leaf, got %+v", tyo)
+	}
+	if overview.IntraGroupLinks != 1 {
+		t.Errorf("Expected 1 intra-group link, got %d", overview.IntraGroupLinks)
+	}
+
+	if len(overview.Links) != 2 {
+		t.Fatalf("Expected 2 aggregated links, got %+v", overview.Links)
+	}
+	dci := overview.Links[1]
+	if dci.Source != "osa" || dci.Target != "tyo" || dci.Links != 3 || dci.BandwidthBps != 200e9 || dci.UnknownSpeedLinks != 1 {
+		t.Errorf("Expected osa-tyo to aggregate 3 links with 200G, got %+v", dci)
+	}
+	if lab := overview.Links[0]; lab.Source != OverviewUnassigned || lab.Target != "tyo" || lab.BandwidthBps != 10e9 {
+		t.Errorf("Expected the unassigned link to tyo, got %+v", lab)
+	}
+}
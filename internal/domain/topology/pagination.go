@@ -0,0 +1,39 @@
+package topology
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// EncodeCursor returns the opaque next_cursor token continuing after the device deviceID
+func EncodeCursor(deviceID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(deviceID))
+}
+
+// DecodeCursor returns the device ID a next_cursor token continues after
+func DecodeCursor(cursor string) (string, error) {
+	deviceID, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(deviceID) == 0 {
+		return "", fmt.Errorf("malformed cursor '%s'", cursor)
+	}
+	return string(deviceID), nil
+}
+
+// KeysetPage turns the devices read for a keyset page into the page and its pagination. Repositories
+// read opts.PageSize+1 devices ordered by ID after opts.After, so that the extra device tells whether
+// another page follows without counting the remaining rows.
+func KeysetPage(devices []Device, opts PaginationOptions, total RowCount) ([]Device, *PaginationResult) {
+	result := &PaginationResult{
+		PageSize:   opts.PageSize,
+		TotalCount: int(total.Count),
+		TotalPages: (int(total.Count) + opts.PageSize - 1) / opts.PageSize,
+		HasPrev:    opts.After != "",
+		Estimated:  total.Estimated,
+	}
+	if len(devices) > opts.PageSize {
+		devices = devices[:opts.PageSize]
+		result.HasNext = true
+		result.NextCursor = EncodeCursor(devices[len(devices)-1].ID)
+	}
+	return devices, result
+}
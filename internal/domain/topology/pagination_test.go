@@ -0,0 +1,37 @@
+package topology
+
+import "testing"
+
+func TestCursor(t *testing.T) {
+	for _, id := range []string{"leaf-01", "srv 01/eth0+a", "東京-core"} {
+		cursor := EncodeCursor(id)
+		decoded, err := DecodeCursor(cursor)
+		if err != nil || decoded != id {
+			t.Errorf("Expected %s back from %s, got %s (%v)", id, cursor, decoded, err)
+		}
+	}
+	for _, cursor := range []string{"", "not base64!"} {
+		if _, err := DecodeCursor(cursor); err == nil {
+			t.Errorf("Expected cursor '%s' to be rejected", cursor)
+		}
+	}
+}
+
+func TestKeysetPage(t *testing.T) {
+	read := []Device{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	opts := PaginationOptions{PageSize: 2, Keyset: true}
+
+	devices, result := KeysetPage(read, opts, RowCount{Count: 3})
+	if len(devices) != 2 || !result.HasNext || result.HasPrev || result.TotalPages != 2 {
+		t.Fatalf("Expected the first of two pages, got %d devices and %+v", len(devices), result)
+	}
+	if after, _ := DecodeCursor(result.NextCursor); after != "b" {
+		t.Errorf("Expected the next page to start after b, got %s", after)
+	}
+
+	opts.After = "b"
+	devices, result = KeysetPage(read[2:], opts, RowCount{Count: 3})
+	if len(devices) != 1 || result.HasNext || !result.HasPrev || result.NextCursor != "" {
+		t.Errorf("Expected the last page without a cursor, got %d devices and %+v", len(devices), result)
+	}
+}
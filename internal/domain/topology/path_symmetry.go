@@ -0,0 +1,234 @@
+package topology
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// costTolerance absorbs float rounding when comparing path costs
+const costTolerance = 1e-9
+
+// CostMismatch is a hop whose two directions carry different weights
+type CostMismatch struct {
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	ForwardWeight float64 `json:"forward_weight"`
+	ReverseWeight float64 `json:"reverse_weight"`
+}
+
+// PathAsymmetry is a pair whose return traffic takes a path that the forward traffic would not.
+// ReversePath is listed from Target back to Source.
+type PathAsymmetry struct {
+	Source      string   `json:"source"`
+	Target      string   `json:"target"`
+	ForwardPath []string `json:"forward_path"`
+	ReversePath []string `json:"reverse_path"`
+	ForwardCost float64  `json:"forward_cost"`
+	ReverseCost float64  `json:"reverse_cost"`
+	// Mismatches lists the hops of either path whose weight differs by direction,
+	// the usual cause of asymmetric routing
+	Mismatches []CostMismatch `json:"mismatches"`
+}
+
+// PathSymmetryReport compares the weighted shortest paths in both directions between two device sets
+type PathSymmetryReport struct {
+	Sources     []string        `json:"sources"`
+	Targets     []string        `json:"targets"`
+	Pairs       int             `json:"pairs"`
+	Unreachable int             `json:"unreachable"`
+	Asymmetric  []PathAsymmetry `json:"asymmetric"`
+}
+
+// AnalyzePathSymmetry computes the weighted shortest path A->B and B->A for every source/target pair
+// and flags the pairs whose reverse path, walked forward, costs more than the forward shortest path
+// (or vice versa). Equal-cost alternatives are not flagged. Each unordered pair is checked once.
+func AnalyzePathSymmetry(g *GraphIndex, sources, targets []string) *PathSymmetryReport {
+	report := &PathSymmetryReport{
+		Sources:    sortedUnique(sources),
+		Targets:    sortedUnique(targets),
+		Asymmetric: []PathAsymmetry{},
+	}
+
+	// デバイスごとに一度だけ最短経路木を求める
+	trees := make(map[string]shortestPathTree)
+	tree := func(id string) shortestPathTree {
+		t, ok := trees[id]
+		if !ok {
+			t = g.shortestPathTree(id)
+			trees[id] = t
+		}
+		return t
+	}
+
+	checked := make(map[string]bool)
+	for _, source := range report.Sources {
+		for _, target := range report.Targets {
+			if source == target || checked[target+"|"+source] {
+				continue
+			}
+			checked[source+"|"+target] = true
+			report.Pairs++
+
+			forward := tree(source).pathTo(target)
+			reverse := tree(target).pathTo(source)
+			if forward == nil || reverse == nil {
+				report.Unreachable++
+				continue
+			}
+
+			forwardCost := tree(source).dist[target]
+			reverseCost := tree(target).dist[source]
+			if g.pathCost(reversed(reverse)) <= forwardCost+costTolerance && g.pathCost(reversed(forward)) <= reverseCost+costTolerance {
+				continue
+			}
+
+			report.Asymmetric = append(report.Asymmetric, PathAsymmetry{
+				Source:      source,
+				Target:      target,
+				ForwardPath: forward,
+				ReversePath: reverse,
+				ForwardCost: forwardCost,
+				ReverseCost: reverseCost,
+				Mismatches:  g.costMismatches(forward, reverse),
+			})
+		}
+	}
+
+	return report
+}
+
+type shortestPathTree struct {
+	dist map[string]float64
+	prev map[string]string
+}
+
+// pathTo returns the path from the root of the tree to id, or nil when id is unreachable
+func (t shortestPathTree) pathTo(id string) []string {
+	if _, ok := t.dist[id]; !ok {
+		return nil
+	}
+	path := []string{id}
+	for {
+		prev, ok := t.prev[id]
+		if !ok {
+			break
+		}
+		path = append(path, prev)
+		id = prev
+	}
+	return reversed(path)
+}
+
+// shortestPathTree runs Dijkstra from deviceID over the directed link weights
+func (g *GraphIndex) shortestPathTree(deviceID string) shortestPathTree {
+	t := shortestPathTree{
+		dist: map[string]float64{deviceID: 0},
+		prev: make(map[string]string),
+	}
+	done := make(map[string]bool)
+	queue := &costQueue{{id: deviceID}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(costItem)
+		if done[current.id] {
+			continue
+		}
+		done[current.id] = true
+
+		for _, edge := range g.adjacency[current.id] {
+			if edge.shadowed || done[edge.to] {
+				continue
+			}
+			cost := current.cost + edge.cost
+			if known, ok := t.dist[edge.to]; ok && known <= cost {
+				continue
+			}
+			t.dist[edge.to] = cost
+			t.prev[edge.to] = current.id
+			heap.Push(queue, costItem{id: edge.to, cost: cost})
+		}
+	}
+	return t
+}
+
+// hopCost returns the cheapest usable edge from one device to a neighbor, or +Inf
+func (g *GraphIndex) hopCost(from, to string) float64 {
+	cost := math.Inf(1)
+	for _, edge := range g.adjacency[from] {
+		if edge.to == to && !edge.shadowed && edge.cost < cost {
+			cost = edge.cost
+		}
+	}
+	return cost
+}
+
+// pathCost returns the cost of walking path in its order
+func (g *GraphIndex) pathCost(path []string) float64 {
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		total += g.hopCost(path[i-1], path[i])
+	}
+	return total
+}
+
+// costMismatches returns the hops of the paths whose cost differs by direction
+func (g *GraphIndex) costMismatches(paths ...[]string) []CostMismatch {
+	seen := make(map[string]bool)
+	mismatches := []CostMismatch{}
+	for _, path := range paths {
+		for i := 1; i < len(path); i++ {
+			from, to := path[i-1], path[i]
+			if from > to {
+				from, to = to, from
+			}
+			if seen[from+"|"+to] {
+				continue
+			}
+			seen[from+"|"+to] = true
+
+			forward, reverse := g.hopCost(from, to), g.hopCost(to, from)
+			if math.Abs(forward-reverse) > costTolerance {
+				mismatches = append(mismatches, CostMismatch{From: from, To: to, ForwardWeight: forward, ReverseWeight: reverse})
+			}
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		if mismatches[i].From != mismatches[j].From {
+			return mismatches[i].From < mismatches[j].From
+		}
+		return mismatches[i].To < mismatches[j].To
+	})
+	return mismatches
+}
+
+func reversed(path []string) []string {
+	result := make([]string, len(path))
+	for i, id := range path {
+		result[len(path)-1-i] = id
+	}
+	return result
+}
+
+type costItem struct {
+	id   string
+	cost float64
+}
+
+// costQueue is a min-heap of devices by path cost; ties are broken by device ID for stable paths
+type costQueue []costItem
+
+func (q costQueue) Len() int { return len(q) }
+func (q costQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].id < q[j].id
+}
+func (q costQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *costQueue) Push(x interface{}) { *q = append(*q, x.(costItem)) }
+func (q *costQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,58 @@
+package topology
+
+import "testing"
+
+func TestAnalyzePathSymmetry(t *testing.T) {
+	link := func(id, source, target string, weight float64) Link {
+		return Link{ID: id, SourceID: source, TargetID: target, SourcePort: target, TargetPort: source, Weight: weight}
+	}
+	// a-b-d と a-c-d の2経路。d->b だけ重みが 10 に設定されている
+	links := []Link{
+		link("ab", "a", "b", 1), link("ba", "b", "a", 1),
+		link("bd", "b", "d", 1), link("db", "d", "b", 10),
+		link("ac", "a", "c", 2), link("ca", "c", "a", 2),
+		link("cd", "c", "d", 2), link("dc", "d", "c", 2),
+		// e-f は片側からのみ観測（両方向に同じ重みを使う）
+		link("ef", "e", "f", 3),
+	}
+	g := NewGraphIndex(links)
+
+	report := AnalyzePathSymmetry(g, []string{"a", "e", "c"}, []string{"d", "f", "a"})
+
+	// a>d, a>f, a>c, e>d, e>f, e>a, c>d, c>f（c>a は a>c と同じ組）
+	if report.Pairs != 8 {
+		t.Errorf("Expected 8 unordered pairs, got %d", report.Pairs)
+	}
+	if report.Unreachable != 4 {
+		t.Errorf("Expected 4 unreachable pairs, got %d", report.Unreachable)
+	}
+	if len(report.Asymmetric) != 1 {
+		t.Fatalf("Expected 1 asymmetric pair, got %+v", report.Asymmetric)
+	}
+
+	got := report.Asymmetric[0]
+	if got.Source != "a" || got.Target != "d" {
+		t.Errorf("Expected a>d to be asymmetric, got %s>%s", got.Source, got.Target)
+	}
+	if len(got.ForwardPath) != 3 || got.ForwardPath[1] != "b" || got.ForwardCost != 2 {
+		t.Errorf("Expected forward path via b with cost 2, got %v (%v)", got.ForwardPath, got.ForwardCost)
+	}
+	if len(got.ReversePath) != 3 || got.ReversePath[0] != "d" || got.ReversePath[1] != "c" || got.ReverseCost != 4 {
+		t.Errorf("Expected reverse path d>c>a with cost 4, got %v (%v)", got.ReversePath, got.ReverseCost)
+	}
+	if len(got.Mismatches) != 1 || got.Mismatches[0].From != "b" || got.Mismatches[0].ReverseWeight != 10 {
+		t.Errorf("Expected the b-d hop as mismatch, got %+v", got.Mismatches)
+	}
+}
+
+func TestAnalyzePathSymmetry_EqualCostPaths(t *testing.T) {
+	link := func(source, target string) Link {
+		return Link{ID: source + target, SourceID: source, TargetID: target, Weight: 1}
+	}
+	// 等コストの2経路はどちらを選んでも非対称とはみなさない
+	g := NewGraphIndex([]Link{link("a", "b"), link("b", "d"), link("a", "c"), link("c", "d")})
+
+	if report := AnalyzePathSymmetry(g, []string{"a"}, []string{"d"}); len(report.Asymmetric) != 0 {
+		t.Errorf("Expected equal-cost paths not to be flagged, got %+v", report.Asymmetric)
+	}
+}
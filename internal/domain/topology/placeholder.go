@@ -0,0 +1,108 @@
+package topology
+
+import (
+	"fmt"
+	"time"
+)
+
+// UnknownPlaceholderValue is the type and hardware of placeholders when no default is configured
+const UnknownPlaceholderValue = "unknown"
+
+// UnknownPort is the port name the sync stores for a link end whose port monitoring does not report
+const UnknownPort = UnknownPlaceholderValue
+
+// PlaceholderDefaults are the attributes given to devices only seen as LLDP neighbors.
+// The zero value keeps type and hardware "unknown" and leaves the layer to classification.
+type PlaceholderDefaults struct {
+	LayerID    *int              `json:"layer,omitempty" yaml:"layer"`
+	Type       string            `json:"type,omitempty" yaml:"type"`
+	Hardware   string            `json:"hardware,omitempty" yaml:"hardware"`
+	DeviceType string            `json:"device_type,omitempty" yaml:"device_type"`
+	Metadata   map[string]string `json:"metadata,omitempty" yaml:"metadata"` // e.g. discovered_via: lldp
+}
+
+// Validate checks the defaults can be stored on a device
+func (d PlaceholderDefaults) Validate() error {
+	if d.LayerID != nil && *d.LayerID < 0 {
+		return fmt.Errorf("layer must not be negative, got %d", *d.LayerID)
+	}
+	for key := range d.Metadata {
+		if key == "" {
+			return fmt.Errorf("metadata keys cannot be empty")
+		}
+	}
+	return nil
+}
+
+func (d PlaceholderDefaults) placeholderType() string {
+	if d.Type == "" {
+		return UnknownPlaceholderValue
+	}
+	return d.Type
+}
+
+func (d PlaceholderDefaults) placeholderHardware() string {
+	if d.Hardware == "" {
+		return UnknownPlaceholderValue
+	}
+	return d.Hardware
+}
+
+// NewPlaceholder builds the placeholder device for id, first seen at now
+func (d PlaceholderDefaults) NewPlaceholder(id string, now time.Time) Device {
+	var layerID *int
+	if d.LayerID != nil {
+		layer := *d.LayerID
+		layerID = &layer
+	}
+
+	metadata := make(map[string]string, len(d.Metadata))
+	for key, value := range d.Metadata {
+		metadata[key] = value
+	}
+
+	return Device{
+		ID:         id,
+		Type:       d.placeholderType(),
+		Hardware:   d.placeholderHardware(),
+		LayerID:    layerID, // 未設定なら分類で決まる
+		DeviceType: d.DeviceType,
+		Provenance: ProvenanceLLDPPlaceholder,
+		Metadata:   metadata,
+		LastSeen:   now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// AwaitingEnrichment reports whether device is a placeholder that monitoring has not described yet,
+// i.e. it still has the placeholder type and hardware. Metadata is not compared since it is not
+// stored by every repository.
+func (d PlaceholderDefaults) AwaitingEnrichment(device Device) bool {
+	return device.Provenance == ProvenanceLLDPPlaceholder &&
+		device.Type == d.placeholderType() &&
+		device.Hardware == d.placeholderHardware()
+}
+
+// EnrichedPlaceholders returns the IDs of the incoming devices that describe stored placeholders
+// still awaiting enrichment: monitoring now reports a type or hardware other than the placeholder's
+func (d PlaceholderDefaults) EnrichedPlaceholders(stored, incoming []Device) []string {
+	awaiting := make(map[string]bool)
+	for _, device := range stored {
+		if d.AwaitingEnrichment(device) {
+			awaiting[device.ID] = true
+		}
+	}
+
+	var enriched []string
+	for _, device := range incoming {
+		if !awaiting[device.ID] {
+			continue
+		}
+		if device.Type != d.placeholderType() || device.Hardware != d.placeholderHardware() {
+			enriched = append(enriched, device.ID)
+			delete(awaiting, device.ID) // 同じIDが重複して届いても1回だけ
+		}
+	}
+	return enriched
+}
@@ -0,0 +1,98 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlaceholderDefaults_NewPlaceholder(t *testing.T) {
+	now := time.Now()
+
+	device := PlaceholderDefaults{}.NewPlaceholder("peer-01", now)
+	if device.Type != "unknown" || device.Hardware != "unknown" || device.LayerID != nil {
+		t.Errorf("Expected zero defaults to keep unknown type/hardware and no layer, got %+v", device)
+	}
+	if device.Provenance != ProvenanceLLDPPlaceholder {
+		t.Errorf("Expected lldp-placeholder provenance, got %s", device.Provenance)
+	}
+
+	layer := 99
+	defaults := PlaceholderDefaults{
+		LayerID:  &layer,
+		Type:     "external",
+		Metadata: map[string]string{"discovered_via": "lldp"},
+	}
+	device = defaults.NewPlaceholder("peer-02", now)
+	if device.Type != "external" || device.Hardware != "unknown" {
+		t.Errorf("Expected configured type and unknown hardware, got %s/%s", device.Type, device.Hardware)
+	}
+	if device.LayerID == nil || *device.LayerID != 99 {
+		t.Errorf("Expected layer 99, got %v", device.LayerID)
+	}
+	if device.Metadata["discovered_via"] != "lldp" {
+		t.Errorf("Expected discovered_via metadata, got %v", device.Metadata)
+	}
+
+	// 各プレースホルダーは設定を共有しない
+	device.Metadata["site"] = "tyo1"
+	*device.LayerID = 1
+	if _, ok := defaults.Metadata["site"]; ok || *defaults.LayerID != 99 {
+		t.Error("Expected placeholders not to share metadata or layer with the defaults")
+	}
+}
+
+func TestPlaceholderDefaults_AwaitingEnrichment(t *testing.T) {
+	defaults := PlaceholderDefaults{Type: "external"}
+	placeholder := defaults.NewPlaceholder("peer-01", time.Now())
+
+	if !defaults.AwaitingEnrichment(placeholder) {
+		t.Error("Expected a new placeholder to await enrichment")
+	}
+
+	enriched := placeholder
+	enriched.Hardware = "Cisco C9300"
+	if defaults.AwaitingEnrichment(enriched) {
+		t.Error("Expected a placeholder with hardware from monitoring not to await enrichment")
+	}
+
+	discovered := placeholder
+	discovered.Provenance = ProvenancePrometheus
+	if defaults.AwaitingEnrichment(discovered) {
+		t.Error("Expected only placeholders to await enrichment")
+	}
+}
+
+func TestPlaceholderDefaults_EnrichedPlaceholders(t *testing.T) {
+	defaults := PlaceholderDefaults{}
+	now := time.Now()
+	stored := []Device{
+		defaults.NewPlaceholder("peer-01", now),
+		defaults.NewPlaceholder("peer-02", now),
+		{ID: "leaf-01", Type: "switch", Hardware: "QFX5120", Provenance: ProvenancePrometheus},
+	}
+	incoming := []Device{
+		{ID: "peer-01", Type: "switch", Hardware: "Cisco C9300"},
+		{ID: "peer-01", Type: "switch", Hardware: "Cisco C9300"},
+		{ID: "peer-02", Type: UnknownPlaceholderValue, Hardware: UnknownPlaceholderValue}, // まだ情報なし
+		{ID: "leaf-01", Type: "switch", Hardware: "QFX5120"},
+		{ID: "new-01", Type: "switch", Hardware: "EX2300"},
+	}
+
+	enriched := defaults.EnrichedPlaceholders(stored, incoming)
+	if len(enriched) != 1 || enriched[0] != "peer-01" {
+		t.Errorf("Expected only peer-01 to be enriched, got %v", enriched)
+	}
+}
+
+func TestPlaceholderDefaults_Validate(t *testing.T) {
+	layer := -1
+	if err := (PlaceholderDefaults{LayerID: &layer}).Validate(); err == nil {
+		t.Error("Expected negative layer to be rejected")
+	}
+	if err := (PlaceholderDefaults{Metadata: map[string]string{"": "x"}}).Validate(); err == nil {
+		t.Error("Expected empty metadata key to be rejected")
+	}
+	if err := (PlaceholderDefaults{}).Validate(); err != nil {
+		t.Errorf("Expected zero defaults to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package topology
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rules broken by a port
+const (
+	PortRuleName               = "port_name"           // ポート名が命名規則に合わない
+	PortRuleDescriptionMissing = "description_missing" // ポートの説明がない
+	PortRuleDescriptionFormat  = "description_format"  // ポートの説明が規約に合わない
+)
+
+// Placeholders of a description pattern, replaced with the neighbor of the checked port
+const (
+	PortPeerPlaceholder     = "{peer}"
+	PortPeerPortPlaceholder = "{peer_port}"
+)
+
+// PortNamingPolicy is a convention for the ports of the devices of a site. Patterns are regular
+// expressions matched against the whole name or description.
+type PortNamingPolicy struct {
+	Site        string   `json:"site,omitempty" yaml:"site"`                 // empty = every site
+	DeviceTypes []string `json:"device_types,omitempty" yaml:"device_types"` // empty = every device type
+	PortPattern string   `json:"port_pattern,omitempty" yaml:"port_pattern" example:"^(Ethernet|et-)[0-9/:-]+$"`
+	// DescriptionPattern may contain {peer} and {peer_port}, e.g. "^to {peer} {peer_port}$"
+	DescriptionPattern string `json:"description_pattern,omitempty" yaml:"description_pattern"`
+	RequireDescription bool   `json:"require_description,omitempty" yaml:"require_description"`
+}
+
+// PortNamingLint holds the policies checked against the ports of discovered links. The first
+// policy matching the site and type of a device applies, so site specific policies go first.
+type PortNamingLint struct {
+	SiteKey  string             `json:"site_key" yaml:"site_key"` // device metadata holding the site (empty = "site")
+	Policies []PortNamingPolicy `json:"policies" yaml:"policies"`
+}
+
+// Validate checks every policy can be compiled
+func (l PortNamingLint) Validate() error {
+	for i, policy := range l.Policies {
+		if policy.PortPattern == "" && policy.DescriptionPattern == "" && !policy.RequireDescription {
+			return fmt.Errorf("policy %d checks nothing; set port_pattern, description_pattern or require_description", i)
+		}
+		if _, err := compilePortPolicy(policy); err != nil {
+			return fmt.Errorf("policy %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// PortNamingViolation is a port breaking the policy that applies to its device
+type PortNamingViolation struct {
+	DeviceID string `json:"device_id"`
+	Site     string `json:"site,omitempty"`
+	Port     string `json:"port"`
+	LinkID   string `json:"link_id"`
+	Peer     string `json:"peer"`
+	PeerPort string `json:"peer_port"`
+	Rule     string `json:"rule" enum:"port_name,description_missing,description_format"`
+	Value    string `json:"value"`              // 規約に合わない名前または説明
+	Expected string `json:"expected,omitempty"` // 適用したパターン（{peer} は置換済み）
+	Policy   int    `json:"policy"`             // 適用したポリシーの番号
+}
+
+// PortNamingReport lists the ports of the discovered links that break the naming conventions
+type PortNamingReport struct {
+	SiteKey      string `json:"site_key"`
+	CheckedPorts int    `json:"checked_ports"`
+	// UncoveredPorts counts the ports of devices no policy applies to
+	UncoveredPorts int                   `json:"uncovered_ports"`
+	Violations     []PortNamingViolation `json:"violations"`
+	BySite         map[string]int        `json:"by_site"` // 拠点ごとの違反数（拠点なし = ""）
+}
+
+// portEnd is one end of a discovered link
+type portEnd struct {
+	deviceID    string
+	port        string
+	description string
+	described   bool // LLDP は相手側ポートの説明のみを報告する
+	linkID      string
+	peer        string
+	peerPort    string
+}
+
+type compiledPortPolicy struct {
+	port        *regexp.Regexp
+	description string
+}
+
+// LintPortNaming checks every port of links against lint. LLDP only reports the description of the
+// remote port, so descriptions are checked on the target end of a link (metadata remote_port_desc)
+// and the source end is checked for its name only. Ports without a name are skipped.
+func LintPortNaming(devices []Device, links []Link, lint PortNamingLint) (*PortNamingReport, error) {
+	siteKey := lint.SiteKey
+	if siteKey == "" {
+		siteKey = "site"
+	}
+	compiled := make([]compiledPortPolicy, len(lint.Policies))
+	for i, policy := range lint.Policies {
+		c, err := compilePortPolicy(policy)
+		if err != nil {
+			return nil, fmt.Errorf("policy %d: %w", i, err)
+		}
+		compiled[i] = *c
+	}
+
+	deviceByID := make(map[string]Device, len(devices))
+	for _, device := range devices {
+		deviceByID[device.ID] = device
+	}
+
+	// 同じポートが複数のリンクに現れても一度だけ検査する
+	ends := make(map[string]*portEnd)
+	var order []string
+	addEnd := func(end portEnd) {
+		if end.port == "" {
+			return
+		}
+		key := end.deviceID + "\x00" + end.port
+		if existing, ok := ends[key]; ok {
+			if end.described && !existing.described {
+				*existing = end
+			}
+			return
+		}
+		ends[key] = &end
+		order = append(order, key)
+	}
+	for _, link := range links {
+		addEnd(portEnd{deviceID: link.SourceID, port: link.SourcePort, linkID: link.ID, peer: link.TargetID, peerPort: link.TargetPort})
+		description, described := link.Metadata["remote_port_desc"]
+		addEnd(portEnd{deviceID: link.TargetID, port: link.TargetPort, description: description, described: described,
+			linkID: link.ID, peer: link.SourceID, peerPort: link.SourcePort})
+	}
+
+	report := &PortNamingReport{
+		SiteKey:    siteKey,
+		Violations: []PortNamingViolation{},
+		BySite:     make(map[string]int),
+	}
+	for _, key := range order {
+		end := ends[key]
+		device := deviceByID[end.deviceID]
+		site := device.Metadata[siteKey]
+		index := matchPortPolicy(lint.Policies, site, device.Type)
+		if index < 0 {
+			report.UncoveredPorts++
+			continue
+		}
+		report.CheckedPorts++
+
+		policy := lint.Policies[index]
+		violation := PortNamingViolation{
+			DeviceID: end.deviceID,
+			Site:     site,
+			Port:     end.port,
+			LinkID:   end.linkID,
+			Peer:     end.peer,
+			PeerPort: end.peerPort,
+			Policy:   index,
+		}
+		add := func(rule, value, expected string) {
+			v := violation
+			v.Rule, v.Value, v.Expected = rule, value, expected
+			report.Violations = append(report.Violations, v)
+			report.BySite[site]++
+		}
+
+		if c := compiled[index]; c.port != nil && !c.port.MatchString(end.port) {
+			add(PortRuleName, end.port, policy.PortPattern)
+		}
+		if !end.described {
+			continue
+		}
+		description := strings.TrimSpace(end.description)
+		if description == "" {
+			if policy.RequireDescription || policy.DescriptionPattern != "" {
+				add(PortRuleDescriptionMissing, "", policy.DescriptionPattern)
+			}
+			continue
+		}
+		if pattern := compiled[index].description; pattern != "" {
+			expanded := strings.NewReplacer(
+				PortPeerPlaceholder, regexp.QuoteMeta(end.peer),
+				PortPeerPortPlaceholder, regexp.QuoteMeta(end.peerPort),
+			).Replace(pattern)
+			// パターンは検証済みで、置換するのはエスケープした文字列のみ
+			if !regexp.MustCompile(expanded).MatchString(description) {
+				add(PortRuleDescriptionFormat, description, expanded)
+			}
+		}
+	}
+
+	sort.SliceStable(report.Violations, func(i, j int) bool {
+		a, b := report.Violations[i], report.Violations[j]
+		if a.DeviceID != b.DeviceID {
+			return a.DeviceID < b.DeviceID
+		}
+		return a.Port < b.Port
+	})
+	return report, nil
+}
+
+// matchPortPolicy returns the index of the first policy applying to a device, or -1
+func matchPortPolicy(policies []PortNamingPolicy, site, deviceType string) int {
+	for i, policy := range policies {
+		if policy.Site != "" && policy.Site != site {
+			continue
+		}
+		if len(policy.DeviceTypes) > 0 && !containsPortPolicyType(policy.DeviceTypes, deviceType) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+func containsPortPolicyType(types []string, deviceType string) bool {
+	for _, t := range types {
+		if t == deviceType {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePortPolicy anchors the patterns of a policy. The description pattern is only checked here
+// and compiled per port once the placeholders are replaced.
+func compilePortPolicy(policy PortNamingPolicy) (*compiledPortPolicy, error) {
+	compiled := &compiledPortPolicy{}
+	if policy.PortPattern != "" {
+		re, err := regexp.Compile(anchorPattern(policy.PortPattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid port_pattern %q: %w", policy.PortPattern, err)
+		}
+		compiled.port = re
+	}
+	if policy.DescriptionPattern != "" {
+		compiled.description = anchorPattern(policy.DescriptionPattern)
+		probe := strings.NewReplacer(PortPeerPlaceholder, "peer", PortPeerPortPlaceholder, "port").Replace(compiled.description)
+		if _, err := regexp.Compile(probe); err != nil {
+			return nil, fmt.Errorf("invalid description_pattern %q: %w", policy.DescriptionPattern, err)
+		}
+	}
+	return compiled, nil
+}
+
+// anchorPattern makes pattern match the whole string
+func anchorPattern(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
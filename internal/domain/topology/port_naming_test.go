@@ -0,0 +1,65 @@
+package topology
+
+import "testing"
+
+func TestLintPortNaming(t *testing.T) {
+	devices := []Device{
+		{ID: "spine-1", Type: "switch", Metadata: map[string]string{"site": "tokyo"}},
+		{ID: "leaf-1", Type: "switch", Metadata: map[string]string{"site": "tokyo"}},
+		{ID: "leaf-2", Type: "switch", Metadata: map[string]string{"site": "osaka"}},
+		{ID: "server-1", Type: "server", Metadata: map[string]string{"site": "tokyo"}},
+	}
+	links := []Link{
+		{ID: "l1", SourceID: "leaf-1", SourcePort: "Ethernet1", TargetID: "spine-1", TargetPort: "Ethernet1",
+			Metadata: map[string]string{"remote_port_desc": "to leaf-1 Ethernet1"}},
+		{ID: "l2", SourceID: "leaf-2", SourcePort: "eth0", TargetID: "spine-1", TargetPort: "Ethernet2",
+			Metadata: map[string]string{"remote_port_desc": "uplink"}},
+		{ID: "l3", SourceID: "server-1", SourcePort: "eno1", TargetID: "leaf-1", TargetPort: "Ethernet10",
+			Metadata: map[string]string{"remote_port_desc": ""}},
+		// 説明が報告されないリンクは名前のみ検査する
+		{ID: "l4", SourceID: "leaf-1", SourcePort: "Ethernet1", TargetID: "leaf-2", TargetPort: "Ethernet3"},
+	}
+	lint := PortNamingLint{Policies: []PortNamingPolicy{
+		{Site: "osaka", PortPattern: "eth[0-9]+"},
+		{Site: "tokyo", DeviceTypes: []string{"switch"}, PortPattern: "Ethernet[0-9]+", DescriptionPattern: "to {peer} {peer_port}"},
+	}}
+
+	report, err := LintPortNaming(devices, links, lint)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]string{
+		"spine-1/Ethernet2": PortRuleDescriptionFormat,
+		"leaf-1/Ethernet10": PortRuleDescriptionMissing,
+		"leaf-2/Ethernet3":  PortRuleName,
+	}
+	if len(report.Violations) != len(expected) {
+		t.Fatalf("Expected %d violations, got %+v", len(expected), report.Violations)
+	}
+	for _, violation := range report.Violations {
+		key := violation.DeviceID + "/" + violation.Port
+		if expected[key] != violation.Rule {
+			t.Errorf("Expected %s to break %q, got %q", key, expected[key], violation.Rule)
+		}
+	}
+	// server-1 にはポリシーがない
+	if report.CheckedPorts != 6 || report.UncoveredPorts != 1 {
+		t.Errorf("Expected 6 checked and 1 uncovered port, got %d and %d", report.CheckedPorts, report.UncoveredPorts)
+	}
+	if report.BySite["tokyo"] != 2 || report.BySite["osaka"] != 1 {
+		t.Errorf("Expected 2 violations in tokyo and 1 in osaka, got %v", report.BySite)
+	}
+}
+
+func TestPortNamingLint_Validate(t *testing.T) {
+	if err := (PortNamingLint{Policies: []PortNamingPolicy{{DescriptionPattern: "to {peer}"}}}).Validate(); err != nil {
+		t.Errorf("Expected a valid policy, got %v", err)
+	}
+	if err := (PortNamingLint{Policies: []PortNamingPolicy{{Site: "tokyo"}}}).Validate(); err == nil {
+		t.Error("Expected a policy checking nothing to be rejected")
+	}
+	if err := (PortNamingLint{Policies: []PortNamingPolicy{{PortPattern: "Ethernet("}}}).Validate(); err == nil {
+		t.Error("Expected an invalid pattern to be rejected")
+	}
+}
@@ -0,0 +1,156 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Port reservation statuses
+const (
+	ReservationStatusReserved  = "reserved"  // 予約中でポートは空き
+	ReservationStatusFulfilled = "fulfilled" // 予定どおりの隣接機器が検出された
+	ReservationStatusConflict  = "conflict"  // 予定と異なる隣接機器が検出された
+	ReservationStatusExpired   = "expired"
+)
+
+// PortStatusInUse is the interface inventory status of a port with a neighbor and no reservation
+const PortStatusInUse = "in_use"
+
+// PortReservation holds a device port for future cabling. PeerDevice and PeerPort name the
+// planned neighbor; without them any neighbor discovered on the port is a conflict.
+type PortReservation struct {
+	ID          string    `json:"id"`
+	DeviceID    string    `json:"device_id"`
+	Port        string    `json:"port"`
+	PeerDevice  string    `json:"peer_device,omitempty"`
+	PeerPort    string    `json:"peer_port,omitempty"`
+	Ticket      string    `json:"ticket"` // 変更チケット（例: CHG-1234）
+	Description string    `json:"description,omitempty"`
+	ReservedBy  string    `json:"reserved_by"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	Status      string    `json:"status,omitempty" enum:"reserved,fulfilled,conflict,expired" readOnly:"true"`
+	Neighbor    *PortPeer `json:"neighbor,omitempty" readOnly:"true"` // ポートで検出された隣接機器
+}
+
+// PortPeer is the neighbor discovered on a port
+type PortPeer struct {
+	DeviceID string    `json:"device_id"`
+	Port     string    `json:"port"`
+	LinkID   string    `json:"link_id"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Validate checks the reservation before it is stored
+func (r PortReservation) Validate(now time.Time) error {
+	if r.DeviceID == "" || r.Port == "" {
+		return fmt.Errorf("device_id and port are required")
+	}
+	if r.Ticket == "" {
+		return fmt.Errorf("ticket is required")
+	}
+	if r.PeerPort != "" && r.PeerDevice == "" {
+		return fmt.Errorf("peer_port requires peer_device")
+	}
+	if !r.ExpiresAt.After(now) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	return nil
+}
+
+// Expired reports whether the reservation has lapsed on now
+func (r PortReservation) Expired(now time.Time) bool {
+	return !r.ExpiresAt.After(now)
+}
+
+// MatchesPeer reports whether peer is the planned neighbor; an empty PeerPort matches any port of PeerDevice
+func (r PortReservation) MatchesPeer(peer PortPeer) bool {
+	return r.PeerDevice != "" && r.PeerDevice == peer.DeviceID && (r.PeerPort == "" || r.PeerPort == peer.Port)
+}
+
+// Evaluate sets the status and the discovered neighbor of the reservation from the links of its device
+func (r *PortReservation) Evaluate(links []Link, now time.Time) {
+	r.Neighbor = PortNeighbors(r.DeviceID, links)[r.Port]
+	switch {
+	case r.Expired(now):
+		r.Status = ReservationStatusExpired
+	case r.Neighbor == nil:
+		r.Status = ReservationStatusReserved
+	case r.MatchesPeer(*r.Neighbor):
+		r.Status = ReservationStatusFulfilled
+	default:
+		r.Status = ReservationStatusConflict
+	}
+}
+
+// PortNeighbors returns the neighbor on each port of deviceID. When links disagree on a port,
+// the most recently seen one wins.
+func PortNeighbors(deviceID string, links []Link) map[string]*PortPeer {
+	neighbors := make(map[string]*PortPeer)
+	for _, link := range links {
+		var port string
+		var peer PortPeer
+		switch deviceID {
+		case link.SourceID:
+			port, peer = link.SourcePort, PortPeer{DeviceID: link.TargetID, Port: link.TargetPort}
+		case link.TargetID:
+			port, peer = link.TargetPort, PortPeer{DeviceID: link.SourceID, Port: link.SourcePort}
+		default:
+			continue
+		}
+		if port == "" {
+			continue
+		}
+		peer.LinkID, peer.LastSeen = link.ID, link.LastSeen
+		if existing, ok := neighbors[port]; ok && existing.LastSeen.After(peer.LastSeen) {
+			continue
+		}
+		neighbors[port] = &peer
+	}
+	return neighbors
+}
+
+// DevicePort is one port of the interface inventory of a device
+type DevicePort struct {
+	Port        string           `json:"port"`
+	Status      string           `json:"status" enum:"in_use,reserved,fulfilled,conflict"`
+	Neighbor    *PortPeer        `json:"neighbor,omitempty"`
+	Reservation *PortReservation `json:"reservation,omitempty"`
+}
+
+// BuildInterfaceInventory lists the ports of a device known from its links and its active
+// reservations, ordered by port name. Expired reservations are left out.
+func BuildInterfaceInventory(deviceID string, links []Link, reservations []PortReservation, now time.Time) []DevicePort {
+	neighbors := PortNeighbors(deviceID, links)
+	ports := make(map[string]*DevicePort, len(neighbors))
+	for port, neighbor := range neighbors {
+		ports[port] = &DevicePort{Port: port, Status: PortStatusInUse, Neighbor: neighbor}
+	}
+
+	for _, reservation := range reservations {
+		if reservation.DeviceID != deviceID {
+			continue
+		}
+		reservation.Evaluate(links, now)
+		if reservation.Status == ReservationStatusExpired {
+			continue
+		}
+		port, ok := ports[reservation.Port]
+		if !ok {
+			port = &DevicePort{Port: reservation.Port}
+			ports[reservation.Port] = port
+		}
+		port.Status = reservation.Status
+		port.Reservation = &reservation
+	}
+
+	inventory := make([]DevicePort, 0, len(ports))
+	for _, port := range ports {
+		inventory = append(inventory, *port)
+	}
+	sort.Slice(inventory, func(i, j int) bool {
+		return inventory[i].Port < inventory[j].Port
+	})
+	return inventory
+}
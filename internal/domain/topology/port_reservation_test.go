@@ -0,0 +1,89 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPortReservation_Evaluate(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	links := []Link{
+		{ID: "l1", SourceID: "leaf-01", SourcePort: "Ethernet1", TargetID: "server-01", TargetPort: "eth0"},
+		{ID: "l2", SourceID: "spine-01", SourcePort: "Ethernet1", TargetID: "leaf-01", TargetPort: "Ethernet49"},
+	}
+
+	tests := []struct {
+		name        string
+		reservation PortReservation
+		want        string
+	}{
+		{"free port", PortReservation{DeviceID: "leaf-01", Port: "Ethernet2", ExpiresAt: now.Add(time.Hour)}, ReservationStatusReserved},
+		{"planned neighbor", PortReservation{DeviceID: "leaf-01", Port: "Ethernet1", PeerDevice: "server-01", PeerPort: "eth0", ExpiresAt: now.Add(time.Hour)}, ReservationStatusFulfilled},
+		{"planned device, any port", PortReservation{DeviceID: "leaf-01", Port: "Ethernet49", PeerDevice: "spine-01", ExpiresAt: now.Add(time.Hour)}, ReservationStatusFulfilled},
+		{"different neighbor", PortReservation{DeviceID: "leaf-01", Port: "Ethernet1", PeerDevice: "server-02", ExpiresAt: now.Add(time.Hour)}, ReservationStatusConflict},
+		{"no planned neighbor", PortReservation{DeviceID: "leaf-01", Port: "Ethernet49", ExpiresAt: now.Add(time.Hour)}, ReservationStatusConflict},
+		{"expired", PortReservation{DeviceID: "leaf-01", Port: "Ethernet1", ExpiresAt: now}, ReservationStatusExpired},
+	}
+
+	for _, tt := range tests {
+		reservation := tt.reservation
+		reservation.Evaluate(links, now)
+		if reservation.Status != tt.want {
+			t.Errorf("%s: expected status %s, got %s", tt.name, tt.want, reservation.Status)
+		}
+	}
+}
+
+func TestPortReservation_Validate(t *testing.T) {
+	now := time.Now()
+	valid := PortReservation{DeviceID: "leaf-01", Port: "Ethernet2", Ticket: "CHG-1", ExpiresAt: now.Add(time.Hour)}
+	if err := valid.Validate(now); err != nil {
+		t.Fatalf("Expected valid reservation, got %v", err)
+	}
+
+	invalid := map[string]func(r *PortReservation){
+		"missing port":           func(r *PortReservation) { r.Port = "" },
+		"missing ticket":         func(r *PortReservation) { r.Ticket = "" },
+		"peer port without peer": func(r *PortReservation) { r.PeerPort = "eth0" },
+		"expiry in the past":     func(r *PortReservation) { r.ExpiresAt = now.Add(-time.Hour) },
+	}
+	for name, tamper := range invalid {
+		reservation := valid
+		tamper(&reservation)
+		if err := reservation.Validate(now); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestBuildInterfaceInventory(t *testing.T) {
+	now := time.Now()
+	links := []Link{
+		{ID: "l1", SourceID: "leaf-01", SourcePort: "Ethernet1", TargetID: "server-01", TargetPort: "eth0"},
+		{ID: "l2", SourceID: "spine-01", SourcePort: "Ethernet1", TargetID: "leaf-01", TargetPort: "Ethernet49"},
+	}
+	reservations := []PortReservation{
+		{ID: "r1", DeviceID: "leaf-01", Port: "Ethernet2", Ticket: "CHG-1", ExpiresAt: now.Add(time.Hour)},
+		{ID: "r2", DeviceID: "leaf-01", Port: "Ethernet1", Ticket: "CHG-2", PeerDevice: "server-02", ExpiresAt: now.Add(time.Hour)},
+		{ID: "r3", DeviceID: "leaf-01", Port: "Ethernet3", Ticket: "CHG-3", ExpiresAt: now.Add(-time.Hour)},
+	}
+
+	inventory := BuildInterfaceInventory("leaf-01", links, reservations, now)
+
+	want := []struct{ port, status string }{
+		{"Ethernet1", ReservationStatusConflict},
+		{"Ethernet2", ReservationStatusReserved},
+		{"Ethernet49", PortStatusInUse},
+	}
+	if len(inventory) != len(want) {
+		t.Fatalf("Expected %d ports, got %+v", len(want), inventory)
+	}
+	for i, w := range want {
+		if inventory[i].Port != w.port || inventory[i].Status != w.status {
+			t.Errorf("Port %d: expected %s %s, got %s %s", i, w.port, w.status, inventory[i].Port, inventory[i].Status)
+		}
+	}
+	if inventory[0].Neighbor == nil || inventory[0].Neighbor.DeviceID != "server-01" || inventory[0].Reservation.ID != "r2" {
+		t.Errorf("Expected conflicting port to show neighbor and reservation, got %+v", inventory[0])
+	}
+}
@@ -0,0 +1,26 @@
+package topology
+
+// Provenance values record how a device first entered the topology. The provenance is set when the
+// device is first stored and kept across later updates, so a placeholder enriched by monitoring
+// stays distinguishable from a device discovered by monitoring.
+const (
+	ProvenancePrometheus      = "prometheus"       // device_info メトリクスで発見
+	ProvenanceLLDPPlaceholder = "lldp-placeholder" // LLDP の対向としてのみ登場したプレースホルダー
+	ProvenanceImport          = "import"           // シードデータやファイルからの取り込み
+	ProvenanceManual          = "manual"           // 手動登録
+	ProvenanceNetBox          = "netbox"           // NetBox からの同期
+	ProvenanceMACTable        = "mac-table"        // MAC/ARPテーブルから推定したサーバー
+)
+
+// Provenances lists the valid provenance values
+var Provenances = []string{ProvenancePrometheus, ProvenanceLLDPPlaceholder, ProvenanceImport, ProvenanceManual, ProvenanceNetBox, ProvenanceMACTable}
+
+// IsValidProvenance reports whether p is a known provenance
+func IsValidProvenance(p string) bool {
+	for _, provenance := range Provenances {
+		if p == provenance {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package topology
+
+import (
+	"time"
+)
+
+// PlannedDeviceStatus represents the lifecycle state of a planned device
+type PlannedDeviceStatus string
+
+const (
+	PlannedDeviceStatusPlanned    PlannedDeviceStatus = "planned"    // 監視にまだ現れていない
+	PlannedDeviceStatusDiscovered PlannedDeviceStatus = "discovered" // 検出済み、テンプレートと一致
+	PlannedDeviceStatusMismatch   PlannedDeviceStatus = "mismatch"   // 検出済み、テンプレートと不一致
+)
+
+// DeviceTemplate describes the expected shape of a device before it is discovered
+type DeviceTemplate struct {
+	Type            string   `json:"type,omitempty"`
+	Hardware        string   `json:"hardware,omitempty"`
+	LayerID         *int     `json:"layer_id,omitempty"`
+	DeviceType      string   `json:"device_type,omitempty"`
+	ExpectedUplinks []string `json:"expected_uplinks,omitempty"` // 接続されるべき上位デバイスID
+}
+
+// TemplateMismatch describes a difference between a discovered device and its template
+type TemplateMismatch struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// PlannedDevice is a device registered ahead of its appearance in monitoring
+type PlannedDevice struct {
+	ID           string              `json:"id"`
+	Template     DeviceTemplate      `json:"template"`
+	Status       PlannedDeviceStatus `json:"status"`
+	Mismatches   []TemplateMismatch  `json:"mismatches"`
+	CreatedBy    string              `json:"created_by"`
+	DiscoveredAt *time.Time          `json:"discovered_at,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
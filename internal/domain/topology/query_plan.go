@@ -0,0 +1,35 @@
+package topology
+
+import "time"
+
+// QueryPlan is the plan the database chose for one statement of a request, as reported by
+// EXPLAIN ANALYZE with the actual row counts and timings
+type QueryPlan struct {
+	Name        string                 `json:"name"`
+	SQL         string                 `json:"sql"`
+	Args        []string               `json:"args"`
+	Executions  int                    `json:"executions"` // リクエスト1回あたりの実行回数
+	Rows        int64                  `json:"rows"`       // 最上位ノードの実際の行数
+	PlanningMs  float64                `json:"planning_ms"`
+	ExecutionMs float64                `json:"execution_ms"`
+	Plan        map[string]interface{} `json:"plan"`
+}
+
+// SubTopologyExplanation is the plans of the statements extracting the sub-topology around a
+// device. Statements run once per neighbor are explained for the first neighbor only.
+type SubTopologyExplanation struct {
+	DeviceID    string      `json:"device_id"`
+	ExplainedAt time.Time   `json:"explained_at"`
+	Statements  []QueryPlan `json:"statements"`
+	TotalMs     float64     `json:"total_ms"` // EstimateTotalMs の結果
+}
+
+// EstimateTotalMs estimates the database time of the request: the planning and execution time of
+// each statement multiplied by how often the request runs it
+func (e SubTopologyExplanation) EstimateTotalMs() float64 {
+	total := 0.0
+	for _, statement := range e.Statements {
+		total += (statement.PlanningMs + statement.ExecutionMs) * float64(statement.Executions)
+	}
+	return total
+}
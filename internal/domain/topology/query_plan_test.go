@@ -0,0 +1,18 @@
+package topology
+
+import "testing"
+
+func TestSubTopologyExplanation_EstimateTotalMs(t *testing.T) {
+	explanation := SubTopologyExplanation{Statements: []QueryPlan{
+		{Name: "root_device", Executions: 1, PlanningMs: 0.5, ExecutionMs: 1},
+		{Name: "links", Executions: 1, PlanningMs: 1, ExecutionMs: 4},
+		{Name: "neighbor_device", Executions: 10, PlanningMs: 0.1, ExecutionMs: 0.4},
+	}}
+
+	if got := explanation.EstimateTotalMs(); got != 11.5 {
+		t.Errorf("Expected 11.5ms, got %v", got)
+	}
+	if got := (SubTopologyExplanation{}).EstimateTotalMs(); got != 0 {
+		t.Errorf("Expected 0ms without statements, got %v", got)
+	}
+}
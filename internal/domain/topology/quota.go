@@ -0,0 +1,52 @@
+package topology
+
+import "sort"
+
+// IngestQuota limits how much topology the sync worker stores. Zero means no limit.
+type IngestQuota struct {
+	MaxDevices int `json:"max_devices" yaml:"max_devices"`
+	MaxLinks   int `json:"max_links" yaml:"max_links"`
+}
+
+// AdmitDevices splits incoming devices into the devices that fit the device quota and the
+// IDs of the rejected ones. Devices already stored always pass since they are only updated;
+// new devices are admitted in ID order while there is room, so repeated syncs keep the same set.
+func (q IngestQuota) AdmitDevices(incoming []Device, stored map[string]bool) ([]Device, []string) {
+	if q.MaxDevices <= 0 {
+		return incoming, nil
+	}
+
+	admitted := make([]Device, 0, len(incoming))
+	var candidates []Device
+	seen := make(map[string]bool, len(incoming))
+	for _, device := range incoming {
+		if stored[device.ID] {
+			admitted = append(admitted, device)
+		} else if !seen[device.ID] {
+			seen[device.ID] = true
+			candidates = append(candidates, device)
+		}
+	}
+
+	room := q.MaxDevices - len(stored)
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+
+	var rejected []string
+	for _, device := range candidates {
+		if room > 0 {
+			admitted = append(admitted, device)
+			room--
+		} else {
+			rejected = append(rejected, device.ID)
+		}
+	}
+	return admitted, rejected
+}
+
+// AdmitLinks caps links at the link quota and returns the admitted links and the number dropped
+func (q IngestQuota) AdmitLinks(links []Link) ([]Link, int) {
+	if q.MaxLinks <= 0 || len(links) <= q.MaxLinks {
+		return links, 0
+	}
+	return links[:q.MaxLinks], len(links) - q.MaxLinks
+}
@@ -0,0 +1,54 @@
+package topology
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIngestQuota_AdmitDevices(t *testing.T) {
+	quota := IngestQuota{MaxDevices: 4}
+	stored := map[string]bool{"core-01": true, "core-02": true}
+	incoming := []Device{{ID: "leaf-03"}, {ID: "core-01"}, {ID: "leaf-01"}, {ID: "leaf-02"}, {ID: "leaf-01"}}
+
+	admitted, rejected := quota.AdmitDevices(incoming, stored)
+
+	var ids []string
+	for _, device := range admitted {
+		ids = append(ids, device.ID)
+	}
+	if fmt.Sprint(ids) != "[core-01 leaf-01 leaf-02]" {
+		t.Errorf("Expected stored device and the first new devices by ID, got %v", ids)
+	}
+	if fmt.Sprint(rejected) != "[leaf-03]" {
+		t.Errorf("Expected leaf-03 to be rejected, got %v", rejected)
+	}
+}
+
+func TestIngestQuota_AdmitDevices_Full(t *testing.T) {
+	quota := IngestQuota{MaxDevices: 1}
+	stored := map[string]bool{"core-01": true, "core-02": true}
+
+	// 上限を既に超えていても保存済みデバイスの更新は続ける
+	admitted, rejected := quota.AdmitDevices([]Device{{ID: "core-02"}, {ID: "leaf-01"}}, stored)
+	if len(admitted) != 1 || admitted[0].ID != "core-02" {
+		t.Errorf("Expected only the stored device to be admitted, got %v", admitted)
+	}
+	if len(rejected) != 1 {
+		t.Errorf("Expected 1 rejected device, got %v", rejected)
+	}
+}
+
+func TestIngestQuota_Unlimited(t *testing.T) {
+	devices := []Device{{ID: "a"}, {ID: "b"}}
+	if admitted, rejected := (IngestQuota{}).AdmitDevices(devices, nil); len(admitted) != 2 || rejected != nil {
+		t.Errorf("Expected every device without a quota, got %v and %v", admitted, rejected)
+	}
+
+	links := []Link{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	if admitted, dropped := (IngestQuota{MaxLinks: 2}).AdmitLinks(links); len(admitted) != 2 || dropped != 1 {
+		t.Errorf("Expected 2 links admitted and 1 dropped, got %d and %d", len(admitted), dropped)
+	}
+	if admitted, dropped := (IngestQuota{}).AdmitLinks(links); len(admitted) != 3 || dropped != 0 {
+		t.Errorf("Expected every link without a quota, got %d and %d", len(admitted), dropped)
+	}
+}
@@ -0,0 +1,273 @@
+package topology
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxRackUnit is the highest rack unit a device may occupy
+const MaxRackUnit = 60
+
+// RackPlacement is the position of a device in a rack. An empty Rack removes the device from its rack.
+type RackPlacement struct {
+	DeviceID string `json:"device_id"`
+	Rack     string `json:"rack"`
+	Position *int   `json:"position,omitempty"` // 最下段のユニット（1始まり、位置が未定なら nil）
+	Units    int    `json:"units,omitempty"`    // 高さ（U、0 は 1U とみなす）
+}
+
+// Validate checks that the placement fits in a rack of MaxRackUnit units
+func (p RackPlacement) Validate() error {
+	if p.DeviceID == "" {
+		return fmt.Errorf("device_id is required")
+	}
+	if p.Rack == "" {
+		if p.Position != nil || p.Units != 0 {
+			return fmt.Errorf("device %s: position and units require a rack", p.DeviceID)
+		}
+		return nil
+	}
+	if p.Units < 0 || p.Units > MaxRackUnit {
+		return fmt.Errorf("device %s: units must be between 1 and %d", p.DeviceID, MaxRackUnit)
+	}
+	if p.Position != nil && (*p.Position < 1 || *p.Position+rackHeight(p.Units)-1 > MaxRackUnit) {
+		return fmt.Errorf("device %s: position must be between 1 and %d and leave room for %dU", p.DeviceID, MaxRackUnit, rackHeight(p.Units))
+	}
+	return nil
+}
+
+// RackConnection is a link from a port of a racked device
+type RackConnection struct {
+	LocalPort string `json:"local_port"`
+	Device    string `json:"device"`
+	Port      string `json:"port"`
+	Rack      string `json:"rack,omitempty"` // 接続先のラック（同じラックなら配線がラック内で完結する）
+	SameRack  bool   `json:"same_rack"`
+}
+
+// RackedDevice is a device drawn in a rack elevation
+type RackedDevice struct {
+	ID          string           `json:"id"`
+	Type        string           `json:"type"`
+	Hardware    string           `json:"hardware"`
+	LayerID     *int             `json:"layer_id"`
+	Position    *int             `json:"position,omitempty"`
+	Units       int              `json:"units"`
+	Overlaps    []string         `json:"overlaps,omitempty"`    // 同じユニットを占める他のデバイス
+	Connections []RackConnection `json:"connections,omitempty"` // ポート順
+}
+
+// RackElevation lists the devices of a rack from the lowest unit up
+type RackElevation struct {
+	Rack   string `json:"rack"`
+	Height int    `json:"height"` // 使われている最上段のユニット
+	// Devices are sorted by position; devices whose position is unknown come last
+	Devices  []RackedDevice `json:"devices"`
+	Overlaps int            `json:"overlaps"` // 他のデバイスとユニットが重なっているデバイスの数
+}
+
+// BuildRackElevations groups the devices with a rack by rack, sorted by rack name. Connections
+// are listed for the racked devices that are an end of one of links, which may be nil.
+func BuildRackElevations(devices []Device, links []Link) []RackElevation {
+	rackOf := make(map[string]string)
+	byRack := make(map[string][]RackedDevice)
+	for _, device := range devices {
+		if device.Rack == "" {
+			continue
+		}
+		rackOf[device.ID] = device.Rack
+		byRack[device.Rack] = append(byRack[device.Rack], RackedDevice{
+			ID:       device.ID,
+			Type:     device.Type,
+			Hardware: device.Hardware,
+			LayerID:  device.LayerID,
+			Position: device.RackPosition,
+			Units:    rackHeight(device.RackUnits),
+		})
+	}
+
+	connections := rackConnections(links, rackOf)
+
+	racks := make([]string, 0, len(byRack))
+	for rack := range byRack {
+		racks = append(racks, rack)
+	}
+	sort.Strings(racks)
+
+	elevations := make([]RackElevation, 0, len(racks))
+	for _, rack := range racks {
+		racked := byRack[rack]
+		sort.SliceStable(racked, func(i, j int) bool {
+			a, b := racked[i].Position, racked[j].Position
+			if (a == nil) != (b == nil) {
+				return b == nil
+			}
+			if a != nil && *a != *b {
+				return *a < *b
+			}
+			return racked[i].ID < racked[j].ID
+		})
+
+		elevation := RackElevation{Rack: rack, Devices: racked}
+		for i := range racked {
+			racked[i].Connections = connections[racked[i].ID]
+			if racked[i].Position == nil {
+				continue
+			}
+			top := *racked[i].Position + racked[i].Units - 1
+			if top > elevation.Height {
+				elevation.Height = top
+			}
+			for j := range racked {
+				if i != j && racked[j].Position != nil && unitsOverlap(racked[i], racked[j]) {
+					racked[i].Overlaps = append(racked[i].Overlaps, racked[j].ID)
+				}
+			}
+			if len(racked[i].Overlaps) > 0 {
+				elevation.Overlaps++
+			}
+		}
+		elevations = append(elevations, elevation)
+	}
+	return elevations
+}
+
+// rackConnections lists the connections of each racked device by port. Links reported from both
+// ends are listed once.
+func rackConnections(links []Link, rackOf map[string]string) map[string][]RackConnection {
+	connections := make(map[string][]RackConnection)
+	seen := make(map[string]bool)
+	add := func(device, port, remote, remotePort string) {
+		if _, ok := rackOf[device]; !ok {
+			return
+		}
+		key := device + "\x00" + port + "\x00" + remote + "\x00" + remotePort
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		connections[device] = append(connections[device], RackConnection{
+			LocalPort: port,
+			Device:    remote,
+			Port:      remotePort,
+			Rack:      rackOf[remote],
+			SameRack:  rackOf[remote] == rackOf[device],
+		})
+	}
+	for _, link := range links {
+		add(link.SourceID, link.SourcePort, link.TargetID, link.TargetPort)
+		add(link.TargetID, link.TargetPort, link.SourceID, link.SourcePort)
+	}
+
+	for device := range connections {
+		sort.SliceStable(connections[device], func(i, j int) bool {
+			return connections[device][i].LocalPort < connections[device][j].LocalPort
+		})
+	}
+	return connections
+}
+
+func unitsOverlap(a, b RackedDevice) bool {
+	return *a.Position < *b.Position+b.Units && *b.Position < *a.Position+a.Units
+}
+
+// rackHeight returns the height of a device in units, counting an unknown height as 1U
+func rackHeight(units int) int {
+	if units < 1 {
+		return 1
+	}
+	return units
+}
+
+// rackCSVColumns are the recognized CSV header names
+var rackCSVColumns = []string{"device_id", "rack", "position", "units"}
+
+// ParseRackPlacementsCSV reads rack placements from CSV with a header row. Columns may appear in
+// any order; device_id and rack are required and unknown columns are ignored. An empty rack
+// removes the device from its rack.
+func ParseRackPlacementsCSV(r io.Reader) ([]RackPlacement, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("CSV is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"device_id", "rack"} {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing column '%s' (expected %s)", required, strings.Join(rackCSVColumns, ","))
+		}
+	}
+
+	var placements []RackPlacement
+	seen := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			i, ok := index[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		placement := RackPlacement{DeviceID: field("device_id"), Rack: field("rack")}
+		if placement.DeviceID == "" && placement.Rack == "" {
+			continue // 空行
+		}
+		if value := field("position"); value != "" {
+			position, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: position '%s' is not a number", line, value)
+			}
+			placement.Position = &position
+		}
+		if value := field("units"); value != "" {
+			units, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: units '%s' is not a number", line, value)
+			}
+			placement.Units = units
+		}
+		if err := placement.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if previous, ok := seen[placement.DeviceID]; ok {
+			return nil, fmt.Errorf("line %d: device %s is already placed on line %d", line, placement.DeviceID, previous)
+		}
+		seen[placement.DeviceID] = line
+
+		placements = append(placements, placement)
+	}
+
+	return placements, nil
+}
+
+// RackImportResult reports the outcome of a rack placement import
+type RackImportResult struct {
+	Imported int `json:"imported"`
+	// Unknown lists the devices of the file that do not exist
+	Unknown []string `json:"unknown"`
+}
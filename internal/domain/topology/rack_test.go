@@ -0,0 +1,100 @@
+package topology
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildRackElevations(t *testing.T) {
+	unit := func(u int) *int { return &u }
+
+	devices := []Device{
+		{ID: "srv-02", Rack: "r12", RackPosition: unit(2), RackUnits: 2},
+		{ID: "leaf-01", Rack: "r12", RackPosition: unit(40)},
+		{ID: "srv-01", Rack: "r12", RackPosition: unit(1)},
+		{ID: "pdu-01", Rack: "r12"},
+		{ID: "spine-01", Rack: "r01", RackPosition: unit(20)},
+		{ID: "unracked"},
+	}
+	links := []Link{
+		{ID: "l1", SourceID: "leaf-01", TargetID: "srv-01", SourcePort: "Ethernet1", TargetPort: "eth0"},
+		// 両端から報告されたリンクは1本に数える
+		{ID: "l1r", SourceID: "srv-01", TargetID: "leaf-01", SourcePort: "eth0", TargetPort: "Ethernet1"},
+		{ID: "l2", SourceID: "leaf-01", TargetID: "spine-01", SourcePort: "Ethernet49", TargetPort: "Ethernet1"},
+	}
+
+	elevations := BuildRackElevations(devices, links)
+	if len(elevations) != 2 || elevations[0].Rack != "r01" || elevations[1].Rack != "r12" {
+		t.Fatalf("Expected racks r01 and r12, got %+v", elevations)
+	}
+
+	r12 := elevations[1]
+	var order []string
+	for _, device := range r12.Devices {
+		order = append(order, device.ID)
+	}
+	if strings.Join(order, ",") != "srv-01,srv-02,leaf-01,pdu-01" {
+		t.Errorf("Expected devices sorted by rack unit with unpositioned devices last, got %v", order)
+	}
+	if r12.Height != 40 {
+		t.Errorf("Expected height 40, got %d", r12.Height)
+	}
+	if r12.Overlaps != 0 {
+		t.Errorf("Expected no overlaps, got %d", r12.Overlaps)
+	}
+
+	leaf := r12.Devices[2]
+	if len(leaf.Connections) != 2 {
+		t.Fatalf("Expected 2 connections of leaf-01, got %+v", leaf.Connections)
+	}
+	if c := leaf.Connections[0]; c.LocalPort != "Ethernet1" || c.Device != "srv-01" || !c.SameRack {
+		t.Errorf("Expected a connection to srv-01 within the rack, got %+v", c)
+	}
+	if c := leaf.Connections[1]; c.Device != "spine-01" || c.Rack != "r01" || c.SameRack {
+		t.Errorf("Expected a connection to spine-01 in r01, got %+v", c)
+	}
+
+	devices = append(devices, Device{ID: "srv-03", Rack: "r12", RackPosition: unit(3)})
+	r12 = BuildRackElevations(devices, nil)[1]
+	if r12.Overlaps != 2 || len(r12.Devices[1].Overlaps) != 1 || r12.Devices[1].Overlaps[0] != "srv-03" {
+		t.Errorf("Expected srv-02 and srv-03 to overlap in unit 3, got %+v", r12)
+	}
+}
+
+func TestParseRackPlacementsCSV(t *testing.T) {
+	placements, err := ParseRackPlacementsCSV(strings.NewReader(
+		"rack,device_id,position,units\n" +
+			"r12,leaf-01,40,1\n" +
+			"r12,srv-01,,\n" +
+			"\n" +
+			",old-01,,\n"))
+	if err != nil {
+		t.Fatalf("Expected CSV to parse, got %v", err)
+	}
+	if len(placements) != 3 {
+		t.Fatalf("Expected 3 placements, got %+v", placements)
+	}
+	if placements[0].Position == nil || *placements[0].Position != 40 || placements[0].Units != 1 {
+		t.Errorf("Expected leaf-01 at unit 40, got %+v", placements[0])
+	}
+	if placements[1].Position != nil {
+		t.Errorf("Expected srv-01 without position, got %+v", placements[1])
+	}
+	if placements[2].Rack != "" {
+		t.Errorf("Expected old-01 to be removed from its rack, got %+v", placements[2])
+	}
+
+	invalid := []string{
+		"device_id,position\nleaf-01,1\n",
+		"device_id,rack,position\nleaf-01,r12,top\n",
+		"device_id,rack,position\nleaf-01,r12,0\n",
+		"device_id,rack,position,units\nleaf-01,r12,60,2\n",
+		"device_id,rack,position\nleaf-01,,3\n",
+		"device_id,rack\nleaf-01,r12\nleaf-01,r13\n",
+	}
+	for _, csv := range invalid {
+		if _, err := ParseRackPlacementsCSV(strings.NewReader(csv)); err == nil {
+			t.Errorf("Expected %q to be rejected", csv)
+		}
+	}
+}
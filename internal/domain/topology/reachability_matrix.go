@@ -0,0 +1,149 @@
+package topology
+
+import "sort"
+
+const (
+	// DefaultMatrixMaxHops is the hop limit of a reachability matrix unless requested otherwise
+	DefaultMatrixMaxHops = 10
+	// MaxMatrixHops caps the hop limit of a reachability matrix
+	MaxMatrixHops = 20
+	// DefaultMatrixMaxPaths is how many disjoint paths are counted per pair unless requested otherwise
+	DefaultMatrixMaxPaths = 4
+	// MaxMatrixPaths caps the disjoint paths counted per pair
+	MaxMatrixPaths = 16
+	// MaxMatrixPairs caps the number of source/target pairs of one matrix
+	MaxMatrixPairs = 2500
+)
+
+// DeviceSet selects the devices on one side of a reachability matrix by ID or by layer
+type DeviceSet struct {
+	DeviceIDs []string `json:"device_ids,omitempty"`
+	Layers    []int    `json:"layers,omitempty"`
+}
+
+// Resolve returns the IDs of the devices in the set, and the explicitly listed IDs that are not in devices
+func (s DeviceSet) Resolve(devices []Device) (ids []string, unknown []string) {
+	known := make(map[string]bool, len(devices))
+	layers := make(map[int]bool, len(s.Layers))
+	for _, layer := range s.Layers {
+		layers[layer] = true
+	}
+	for _, device := range devices {
+		known[device.ID] = true
+		if device.LayerID != nil && layers[*device.LayerID] {
+			ids = append(ids, device.ID)
+		}
+	}
+	for _, id := range s.DeviceIDs {
+		if known[id] {
+			ids = append(ids, id)
+		} else {
+			unknown = append(unknown, id)
+		}
+	}
+	return sortedUnique(ids), unknown
+}
+
+// ReachabilityPair is the reachability of one target from one source
+type ReachabilityPair struct {
+	Source    string `json:"source"`
+	Target    string `json:"target"`
+	Reachable bool   `json:"reachable"`
+	HopCount  int    `json:"hop_count,omitempty"`
+	// DisjointPaths is the number of link-disjoint paths, counted up to the matrix max_paths
+	DisjointPaths int `json:"disjoint_paths"`
+}
+
+// ReachabilityMatrix summarizes reachability between two sets of devices
+type ReachabilityMatrix struct {
+	Sources  []string           `json:"sources"`
+	Targets  []string           `json:"targets"`
+	MaxHops  int                `json:"max_hops"`
+	MaxPaths int                `json:"max_paths"`
+	Pairs    []ReachabilityPair `json:"pairs"`
+	// Reachable, Unreachable and SinglePath count the pairs; SinglePath pairs are
+	// reachable through only one disjoint path and lose connectivity on any link failure.
+	Reachable   int `json:"reachable"`
+	Unreachable int `json:"unreachable"`
+	SinglePath  int `json:"single_path"`
+}
+
+// BuildReachabilityMatrix computes the reachability of every target from every source.
+// Pairs of a device with itself are skipped. Disjoint paths are only counted for pairs
+// reachable within maxHops, and never more than maxPaths per pair.
+func BuildReachabilityMatrix(g *GraphIndex, sources, targets []string, maxHops, maxPaths int) *ReachabilityMatrix {
+	if maxHops <= 0 {
+		maxHops = DefaultMatrixMaxHops
+	}
+	if maxPaths <= 0 {
+		maxPaths = DefaultMatrixMaxPaths
+	}
+
+	matrix := &ReachabilityMatrix{
+		Sources:  sortedUnique(sources),
+		Targets:  sortedUnique(targets),
+		MaxHops:  maxHops,
+		MaxPaths: maxPaths,
+		Pairs:    []ReachabilityPair{},
+	}
+
+	for _, source := range matrix.Sources {
+		// 送信元ごとに一度だけ BFS してホップ数を求める
+		hops := g.HopCounts(source, maxHops)
+		for _, target := range matrix.Targets {
+			if source == target {
+				continue
+			}
+
+			pair := ReachabilityPair{Source: source, Target: target}
+			if hopCount, ok := hops[target]; ok {
+				pair.Reachable = true
+				pair.HopCount = hopCount
+				pair.DisjointPaths = g.DisjointPaths(source, target, maxPaths)
+			}
+
+			switch {
+			case !pair.Reachable:
+				matrix.Unreachable++
+			case pair.DisjointPaths == 1:
+				matrix.Reachable++
+				matrix.SinglePath++
+			default:
+				matrix.Reachable++
+			}
+			matrix.Pairs = append(matrix.Pairs, pair)
+		}
+	}
+
+	return matrix
+}
+
+// CountMatrixPairs returns the number of pairs a matrix of sources and targets would contain
+func CountMatrixPairs(sources, targets []string) int {
+	sources, targets = sortedUnique(sources), sortedUnique(targets)
+	inTargets := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		inTargets[target] = true
+	}
+
+	pairs := len(sources) * len(targets)
+	for _, source := range sources {
+		if inTargets[source] {
+			pairs--
+		}
+	}
+	return pairs
+}
+
+func sortedUnique(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,82 @@
+package topology
+
+import "testing"
+
+func TestBuildReachabilityMatrix(t *testing.T) {
+	link := func(id, source, target string) Link {
+		return Link{ID: id, SourceID: source, TargetID: target, Weight: 1}
+	}
+	// leaf-01/02 は両スパインに接続、leaf-03 は spine-01 のみ、leaf-04 は孤立
+	links := []Link{
+		link("l1", "leaf-01", "spine-01"),
+		link("l2", "leaf-01", "spine-02"),
+		link("l3", "spine-02", "leaf-02"),
+		link("l4", "leaf-02", "spine-01"),
+		link("l5", "leaf-03", "spine-01"),
+		link("l6", "spine-01", "border-01"),
+		link("l7", "spine-02", "border-01"),
+	}
+	g := NewGraphIndex(links)
+
+	matrix := BuildReachabilityMatrix(g, []string{"leaf-02", "leaf-01", "leaf-03", "leaf-04", "leaf-01"}, []string{"border-01", "leaf-01"}, 0, 0)
+
+	if matrix.MaxHops != DefaultMatrixMaxHops || matrix.MaxPaths != DefaultMatrixMaxPaths {
+		t.Errorf("Expected default limits, got %d hops and %d paths", matrix.MaxHops, matrix.MaxPaths)
+	}
+	if len(matrix.Sources) != 4 || matrix.Sources[0] != "leaf-01" {
+		t.Errorf("Expected sorted unique sources, got %v", matrix.Sources)
+	}
+	// leaf-01 -> leaf-01 は除外
+	if len(matrix.Pairs) != 7 {
+		t.Fatalf("Expected 7 pairs, got %d", len(matrix.Pairs))
+	}
+
+	pairs := make(map[string]ReachabilityPair)
+	for _, pair := range matrix.Pairs {
+		pairs[pair.Source+">"+pair.Target] = pair
+	}
+	tests := []struct {
+		key       string
+		reachable bool
+		hops      int
+		paths     int
+	}{
+		{"leaf-01>border-01", true, 2, 2},
+		{"leaf-02>leaf-01", true, 2, 2},
+		{"leaf-03>border-01", true, 2, 1},
+		{"leaf-04>border-01", false, 0, 0},
+	}
+	for _, tt := range tests {
+		pair := pairs[tt.key]
+		if pair.Reachable != tt.reachable || pair.HopCount != tt.hops || pair.DisjointPaths != tt.paths {
+			t.Errorf("%s: expected reachable=%v hops=%d paths=%d, got %+v", tt.key, tt.reachable, tt.hops, tt.paths, pair)
+		}
+	}
+
+	if matrix.Reachable != 5 || matrix.Unreachable != 2 || matrix.SinglePath != 2 {
+		t.Errorf("Expected 5 reachable, 2 unreachable and 2 single-path pairs, got %+v", matrix)
+	}
+}
+
+func TestGraphIndex_DisjointPaths(t *testing.T) {
+	// a-b-d と a-c-d の2経路に加え、b-c の横断リンクがあっても本数は2
+	links := []Link{
+		{ID: "1", SourceID: "a", TargetID: "b"},
+		{ID: "2", SourceID: "b", TargetID: "d"},
+		{ID: "3", SourceID: "a", TargetID: "c"},
+		{ID: "4", SourceID: "c", TargetID: "d"},
+		{ID: "5", SourceID: "b", TargetID: "c"},
+		{ID: "6", SourceID: "d", TargetID: "a"},
+	}
+	g := NewGraphIndex(links)
+
+	if got := g.DisjointPaths("a", "d", 10); got != 3 {
+		t.Errorf("Expected 3 disjoint paths, got %d", got)
+	}
+	if got := g.DisjointPaths("a", "d", 2); got != 2 {
+		t.Errorf("Expected the count to stop at the limit, got %d", got)
+	}
+	if got := g.HopCounts("b", 1); len(got) != 4 {
+		t.Errorf("Expected b and its 3 neighbors within 1 hop, got %v", got)
+	}
+}
@@ -2,6 +2,7 @@ package topology
 
 import (
 	"context"
+	"time"
 )
 
 type Repository interface {
@@ -23,6 +24,7 @@ type Repository interface {
 	ExtractSubTopology(ctx context.Context, deviceID string, opts SubTopologyOptions) ([]Device, []Link, error)
 
 	// リンク検索（可視化API使用中）
+	GetLink(ctx context.Context, linkID string) (*Link, error)
 	GetDeviceLinks(ctx context.Context, deviceID string) ([]Link, error)
 
 	// バルク操作（seedDataコマンド使用中）
@@ -33,3 +35,149 @@ type Repository interface {
 	Close() error
 	Health(ctx context.Context) error
 }
+
+// LinkHistoryRepository is implemented by repositories that archive link observations
+type LinkHistoryRepository interface {
+	CompactLinkHistory(ctx context.Context, retention LinkHistoryRetention) (*LinkHistoryCompactionResult, error)
+}
+
+// ProvisioningRepository is implemented by repositories that store planned devices
+type ProvisioningRepository interface {
+	GetPlannedDevice(ctx context.Context, deviceID string) (*PlannedDevice, error)
+	ListPlannedDevices(ctx context.Context) ([]PlannedDevice, error)
+	SavePlannedDevice(ctx context.Context, device PlannedDevice) error
+	DeletePlannedDevice(ctx context.Context, deviceID string) error
+}
+
+// DisplayNameRepository is implemented by repositories that store display-name overrides
+type DisplayNameRepository interface {
+	ListDisplayNameOverrides(ctx context.Context) ([]DisplayNameOverride, error)
+	SaveDisplayNameOverride(ctx context.Context, override DisplayNameOverride) error
+	DeleteDisplayNameOverride(ctx context.Context, overrideID string) error
+}
+
+// DeviceDeletionRepository is implemented by repositories that can delete devices together with their links
+type DeviceDeletionRepository interface {
+	// DeleteDevices removes the devices and every link touching them in one transaction.
+	// With dryRun the transaction is rolled back and only the counts are returned.
+	// Repositories implementing OrphanedLinkRepository also record the deletions.
+	DeleteDevices(ctx context.Context, deviceIDs []string, dryRun bool) (*DeviceDeletionResult, error)
+}
+
+// RackRepository is implemented by repositories that store the rack placement of devices
+type RackRepository interface {
+	// SetDeviceRack replaces the rack placement of a device. It returns false when the device does not exist.
+	SetDeviceRack(ctx context.Context, placement RackPlacement) (bool, error)
+}
+
+// LinkListRepository is implemented by repositories that read every link in one query
+type LinkListRepository interface {
+	// ListLinks returns every link ordered by ID
+	ListLinks(ctx context.Context) ([]Link, error)
+}
+
+// RowCounter is implemented by repositories that can count devices and links cheaply
+type RowCounter interface {
+	// CountDevices and CountLinks return an estimate for large tables unless exact is set
+	CountDevices(ctx context.Context, exact bool) (RowCount, error)
+	CountLinks(ctx context.Context, exact bool) (RowCount, error)
+}
+
+// WorkflowRepository is implemented by repositories that store device workflow states and their history
+type WorkflowRepository interface {
+	// TransitionDeviceWorkflow moves the device from transition.From to transition.To and records the
+	// transition. It returns false without changes when the device is missing or no longer in From.
+	TransitionDeviceWorkflow(ctx context.Context, transition WorkflowTransition) (bool, error)
+	// ListWorkflowTransitions returns the transitions of a device, newest first
+	ListWorkflowTransitions(ctx context.Context, deviceID string, limit int) ([]WorkflowTransition, error)
+}
+
+// FabricRepository is implemented by repositories that store fabric definitions
+type FabricRepository interface {
+	ListFabrics(ctx context.Context) ([]Fabric, error)
+	GetFabric(ctx context.Context, name string) (*Fabric, error)
+	SaveFabric(ctx context.Context, fabric Fabric) error
+	DeleteFabric(ctx context.Context, name string) error
+}
+
+// CircuitRepository is implemented by repositories that store cable and circuit IDs
+type CircuitRepository interface {
+	// SearchCircuits returns circuits whose ID, provider, end devices or description contain query
+	SearchCircuits(ctx context.Context, query string, limit int) ([]Circuit, error)
+	GetCircuit(ctx context.Context, circuitID string) (*Circuit, error)
+	ListLinkCircuits(ctx context.Context, linkID string) ([]Circuit, error)
+	SaveCircuit(ctx context.Context, circuit Circuit) error
+	DeleteCircuit(ctx context.Context, circuitID string) error
+}
+
+// ReplicaStatus counts the read replicas a repository routes read-only queries to
+type ReplicaStatus struct {
+	Configured int `json:"configured"`
+	Healthy    int `json:"healthy"`
+}
+
+// ReplicaStatusRepository is implemented by repositories that can serve reads from replicas
+type ReplicaStatusRepository interface {
+	ReplicaStatus() ReplicaStatus
+}
+
+// PortReservationRepository is implemented by repositories that store port reservations
+type PortReservationRepository interface {
+	// ListPortReservations returns the reservations of a device, or of all devices when deviceID is empty
+	ListPortReservations(ctx context.Context, deviceID string) ([]PortReservation, error)
+	GetPortReservation(ctx context.Context, reservationID string) (*PortReservation, error)
+	SavePortReservation(ctx context.Context, reservation PortReservation) error
+	DeletePortReservation(ctx context.Context, reservationID string) error
+}
+
+// ManagementReachabilityRepository is implemented by repositories that store the results of
+// management-plane (SSH/NETCONF) checks
+type ManagementReachabilityRepository interface {
+	// SaveManagementReachability replaces the latest result of each device. LastReachableAt is
+	// set to CheckedAt for manageable results and kept from earlier checks otherwise.
+	SaveManagementReachability(ctx context.Context, results []ManagementReachability) error
+	ListManagementReachability(ctx context.Context) ([]ManagementReachability, error)
+}
+
+// MaintenanceRepository is implemented by repositories that store maintenance windows
+type MaintenanceRepository interface {
+	// ListMaintenanceWindows returns the windows active at some time between from and to,
+	// ordered by start; zero times leave that side open
+	ListMaintenanceWindows(ctx context.Context, from, to time.Time) ([]MaintenanceWindow, error)
+	GetMaintenanceWindow(ctx context.Context, windowID string) (*MaintenanceWindow, error)
+	SaveMaintenanceWindow(ctx context.Context, window MaintenanceWindow) error
+	DeleteMaintenanceWindow(ctx context.Context, windowID string) error
+}
+
+// IdentityRepository is implemented by repositories that store manual device identity decisions
+type IdentityRepository interface {
+	ListIdentityDecisions(ctx context.Context) ([]IdentityDecision, error)
+	// SaveIdentityDecision creates or replaces the decision with the same key
+	SaveIdentityDecision(ctx context.Context, decision IdentityDecision) error
+	DeleteIdentityDecision(ctx context.Context, key string) error
+}
+
+// SyncStatsRepository is implemented by repositories that keep a history of synchronization cycles
+type SyncStatsRepository interface {
+	SaveSyncCycleStats(ctx context.Context, stats SyncCycleStats) error
+	// ListSyncCycleStats returns the cycles finished after since, oldest first, at most the last limit (0 = all)
+	ListSyncCycleStats(ctx context.Context, since time.Time, limit int) ([]SyncCycleStats, error)
+	// DeleteSyncCycleStats removes the cycles finished before cutoff
+	DeleteSyncCycleStats(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// QueryExplainRepository is implemented by repositories that can report the plans of the
+// queries behind API requests
+type QueryExplainRepository interface {
+	// ExplainSubTopology runs the statements of ExtractSubTopology with EXPLAIN ANALYZE. It
+	// returns nil when the device does not exist.
+	ExplainSubTopology(ctx context.Context, deviceID string, opts SubTopologyOptions) (*SubTopologyExplanation, error)
+}
+
+// ChangeListener is implemented by repositories that report device and link changes made by any
+// writer, including other instances and manual SQL
+type ChangeListener interface {
+	// ListenChanges calls handle for every change until ctx is cancelled. After a lost connection
+	// it reports a ChangeOpResync change since changes made in the meantime are unknown.
+	ListenChanges(ctx context.Context, handle func(Change)) error
+}
@@ -0,0 +1,14 @@
+package topology
+
+import "context"
+
+// SchemaMaintenanceRepository is implemented by repositories whose schema is migrated online
+// in expand/contract steps
+type SchemaMaintenanceRepository interface {
+	// ReportSchemaVersion records that the instance is running with the schema version bundled
+	// in this build, so contract migrations wait until no older instance remains
+	ReportSchemaVersion(ctx context.Context, instanceID, role string) error
+	// RunSchemaBackfills advances the pending backfills of applied expand migrations in small
+	// batches and returns the number of rows updated
+	RunSchemaBackfills(ctx context.Context, batchSize int) (int, error)
+}
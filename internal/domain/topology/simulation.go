@@ -0,0 +1,65 @@
+package topology
+
+// SimulationRequest describes hypothetical changes applied on top of the current topology
+type SimulationRequest struct {
+	AddDevices    []Device `json:"add_devices,omitempty"`
+	RemoveDevices []string `json:"remove_devices,omitempty"`
+	AddLinks      []Link   `json:"add_links,omitempty"`
+	RemoveLinks   []string `json:"remove_links,omitempty"`
+
+	// 変更前後で比較する経路と到達性
+	Paths        []PathQuery         `json:"paths,omitempty"`
+	Reachability []ReachabilityQuery `json:"reachability,omitempty"`
+}
+
+// PathQuery is a source/destination pair to evaluate in a simulation
+type PathQuery struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ReachabilityQuery is a reachability check from a device in a simulation
+type ReachabilityQuery struct {
+	DeviceID string `json:"device_id"`
+	MaxHops  int    `json:"max_hops"`
+}
+
+// SimulationResult holds baseline vs simulated metrics
+type SimulationResult struct {
+	Paths            []PathComparison         `json:"paths"`
+	Reachability     []ReachabilityComparison `json:"reachability"`
+	Oversubscription []OversubscriptionChange `json:"oversubscription"`
+}
+
+// PathComparison compares the shortest path before and after the simulated changes
+type PathComparison struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Baseline  *Path  `json:"baseline"`  // nil = 到達不可
+	Simulated *Path  `json:"simulated"` // nil = 到達不可
+}
+
+// ReachabilityComparison compares devices reachable from a source before and after the simulated changes
+type ReachabilityComparison struct {
+	DeviceID       string   `json:"device_id"`
+	MaxHops        int      `json:"max_hops"`
+	BaselineCount  int      `json:"baseline_count"`
+	SimulatedCount int      `json:"simulated_count"`
+	Lost           []string `json:"lost"`
+	Gained         []string `json:"gained"`
+}
+
+// Oversubscription describes the downlink/uplink capacity ratio of a device
+type Oversubscription struct {
+	UplinkCapacity   float64 `json:"uplink_capacity"`
+	DownlinkCapacity float64 `json:"downlink_capacity"`
+	Ratio            float64 `json:"ratio"` // downlink / uplink（アップリンクが無い場合は0）
+	HasUplink        bool    `json:"has_uplink"`
+}
+
+// OversubscriptionChange compares oversubscription of an affected device
+type OversubscriptionChange struct {
+	DeviceID  string            `json:"device_id"`
+	Baseline  *Oversubscription `json:"baseline"`  // nil = 変更前には存在しない
+	Simulated *Oversubscription `json:"simulated"` // nil = 変更後には存在しない
+}
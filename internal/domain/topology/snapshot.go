@@ -0,0 +1,58 @@
+package topology
+
+import (
+	"fmt"
+	"time"
+)
+
+// SnapshotSourceBackfill marks snapshots reconstructed from historical Prometheus data
+const SnapshotSourceBackfill = "prometheus_backfill"
+
+// SnapshotSourceStored marks snapshots of the stored topology
+const SnapshotSourceStored = "stored_topology"
+
+// MaxBackfillPoints bounds the number of snapshots a single backfill may produce
+const MaxBackfillPoints = 1000
+
+// Snapshot is the topology as it was at a point in time
+type Snapshot struct {
+	TakenAt time.Time `json:"taken_at"`
+	Source  string    `json:"source"`
+	Devices []Device  `json:"devices"`
+	Links   []Link    `json:"links"`
+}
+
+// FileName returns the file name of the snapshot, e.g. snapshot-20250101T120000Z.json
+func (s *Snapshot) FileName() string {
+	return fmt.Sprintf("snapshot-%s.json", s.TakenAt.UTC().Format("20060102T150405Z"))
+}
+
+// BackfillTimes returns the timestamps from from to to at the given step, including both ends.
+// When from equals to a single timestamp is returned and step is ignored.
+func BackfillTimes(from, to time.Time, step time.Duration) ([]time.Time, error) {
+	if from.IsZero() {
+		return nil, fmt.Errorf("start time is required")
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("end time %s is before start time %s", to.Format(time.RFC3339), from.Format(time.RFC3339))
+	}
+	if to.Equal(from) {
+		return []time.Time{from}, nil
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("step must be positive")
+	}
+	if points := int64(to.Sub(from)/step) + 1; points > MaxBackfillPoints {
+		return nil, fmt.Errorf("time range produces %d snapshots (maximum %d), use a larger step", points, MaxBackfillPoints)
+	}
+
+	var times []time.Time
+	for t := from; !t.After(to); t = t.Add(step) {
+		times = append(times, t)
+	}
+	// 終端がステップに揃わない場合も終端時刻のスナップショットを含める
+	if last := times[len(times)-1]; last.Before(to) {
+		times = append(times, to)
+	}
+	return times, nil
+}
@@ -0,0 +1,223 @@
+package topology
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// DefaultPrometheusLookback is the instant query lookback of Prometheus when none is configured
+const DefaultPrometheusLookback = 5 * time.Minute
+
+// DefaultMaxSnapshotDrift is the share of devices and links a snapshot may disagree with
+// Prometheus on before it is rejected
+const DefaultMaxSnapshotDrift = 0.05
+
+// ReadSnapshot reads a snapshot export (JSON), optionally gzip-compressed, and validates it
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	reader := bufio.NewReader(r)
+	// gzip のマジックナンバーで圧縮の有無を判定する
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip archive: %w", err)
+		}
+		defer gz.Close()
+		return decodeSnapshot(gz)
+	}
+	return decodeSnapshot(reader)
+}
+
+func decodeSnapshot(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if err := snapshot.Validate(); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// Validate checks that the snapshot can be loaded: it has a timestamp, device and link IDs are
+// unique and every link ends at a device of the snapshot
+func (s *Snapshot) Validate() error {
+	if s.TakenAt.IsZero() {
+		return fmt.Errorf("snapshot has no taken_at timestamp")
+	}
+
+	devices := make(map[string]bool, len(s.Devices))
+	for i, device := range s.Devices {
+		if device.ID == "" {
+			return fmt.Errorf("device %d has no id", i)
+		}
+		if devices[device.ID] {
+			return fmt.Errorf("device %s appears more than once", device.ID)
+		}
+		devices[device.ID] = true
+	}
+
+	links := make(map[string]bool, len(s.Links))
+	for i, link := range s.Links {
+		if link.ID == "" {
+			return fmt.Errorf("link %d has no id", i)
+		}
+		if links[link.ID] {
+			return fmt.Errorf("link %s appears more than once", link.ID)
+		}
+		links[link.ID] = true
+		for _, end := range []string{link.SourceID, link.TargetID} {
+			if !devices[end] {
+				return fmt.Errorf("link %s references device %s which is not in the snapshot", link.ID, end)
+			}
+		}
+	}
+	return nil
+}
+
+// SnapshotVerifyOptions controls how a snapshot is checked against Prometheus before it is loaded
+type SnapshotVerifyOptions struct {
+	Now time.Time
+	// MaxAge is the age after which cleanup would expire the loaded data before a sync refreshes it
+	MaxAge time.Duration
+	// Lookback is the instant query lookback of Prometheus (0 = DefaultPrometheusLookback)
+	Lookback time.Duration
+	// MaxDrift is the share of disagreeing devices and links that is tolerated
+	MaxDrift float64
+}
+
+// SnapshotVerification compares a snapshot with what Prometheus reported at its timestamp
+type SnapshotVerification struct {
+	TakenAt         time.Time `json:"taken_at"`
+	Source          string    `json:"source"`
+	AgeSeconds      int64     `json:"age_seconds"`
+	Devices         int       `json:"devices"`
+	Links           int       `json:"links"`
+	ObservedDevices int       `json:"observed_devices"` // Prometheus が taken_at 時点で報告したデバイス（LLDP の対向を含む）
+	ObservedLinks   int       `json:"observed_links"`
+	// Missing entries are in the snapshot and were seen within the lookback of taken_at, but
+	// Prometheus did not report them; entries last seen earlier had already gone and are not counted.
+	// Links are listed as "source:port -- target:port".
+	MissingDevices []string `json:"missing_devices"`
+	MissingLinks   []string `json:"missing_links"`
+	// Unexpected entries were reported by Prometheus but are absent from the snapshot
+	UnexpectedDevices []string `json:"unexpected_devices"`
+	UnexpectedLinks   []string `json:"unexpected_links"`
+	Drift             float64  `json:"drift"`    // 食い違ったデバイス・リンクの割合
+	Problems          []string `json:"problems"` // 空なら取り込んでよい
+}
+
+// Consistent reports whether the snapshot may be loaded
+func (v *SnapshotVerification) Consistent() bool {
+	return len(v.Problems) == 0
+}
+
+// VerifySnapshot checks that snapshot is young enough to be taken over by incremental sync and
+// agrees with observed, the topology Prometheus reported at snapshot.TakenAt. A snapshot whose
+// timestamp is outside the Prometheus retention cannot be confirmed and is reported as such.
+func VerifySnapshot(snapshot, observed *Snapshot, opts SnapshotVerifyOptions) SnapshotVerification {
+	lookback := opts.Lookback
+	if lookback <= 0 {
+		lookback = DefaultPrometheusLookback
+	}
+
+	v := SnapshotVerification{
+		TakenAt:           snapshot.TakenAt,
+		Source:            snapshot.Source,
+		AgeSeconds:        int64(opts.Now.Sub(snapshot.TakenAt) / time.Second),
+		Devices:           len(snapshot.Devices),
+		Links:             len(snapshot.Links),
+		MissingDevices:    []string{},
+		MissingLinks:      []string{},
+		UnexpectedDevices: []string{},
+		UnexpectedLinks:   []string{},
+		Problems:          []string{},
+	}
+
+	age := opts.Now.Sub(snapshot.TakenAt)
+	if age < 0 {
+		v.Problems = append(v.Problems, fmt.Sprintf("snapshot was taken in the future (%s)", snapshot.TakenAt.Format(time.RFC3339)))
+	}
+	if opts.MaxAge > 0 && age > opts.MaxAge {
+		v.Problems = append(v.Problems, fmt.Sprintf("snapshot is %s old, older than the maximum age %s; cleanup would expire it before incremental sync refreshes it",
+			age.Truncate(time.Second), opts.MaxAge))
+	}
+
+	if observed == nil || len(observed.Devices)+len(observed.Links) == 0 {
+		v.Problems = append(v.Problems, fmt.Sprintf("Prometheus has no topology data at %s; the snapshot is outside the retention or lookback (%s) and cannot be verified",
+			snapshot.TakenAt.Format(time.RFC3339), lookback))
+		return v
+	}
+	v.ObservedDevices = len(observed.Devices)
+	v.ObservedLinks = len(observed.Links)
+
+	// taken_at からルックバック以上前に最後に見えたものは、その時点で既に Prometheus から消えている
+	since := snapshot.TakenAt.Add(-lookback)
+	recent := func(lastSeen time.Time) bool {
+		return lastSeen.IsZero() || !lastSeen.Before(since)
+	}
+
+	observedDevices := make(map[string]bool, len(observed.Devices))
+	for _, device := range observed.Devices {
+		observedDevices[device.ID] = true
+	}
+	snapshotDevices := make(map[string]bool, len(snapshot.Devices))
+	for _, device := range snapshot.Devices {
+		snapshotDevices[device.ID] = true
+		if !observedDevices[device.ID] && recent(device.LastSeen) {
+			v.MissingDevices = append(v.MissingDevices, device.ID)
+		}
+	}
+	for id := range observedDevices {
+		if !snapshotDevices[id] {
+			v.UnexpectedDevices = append(v.UnexpectedDevices, id)
+		}
+	}
+
+	// リンク ID は抽出順の連番のため、端点とポートで照合する
+	observedLinks := make(map[string]bool, len(observed.Links))
+	for _, link := range observed.Links {
+		observedLinks[duplicateLinkKey(link)] = true
+	}
+	snapshotLinks := make(map[string]bool, len(snapshot.Links))
+	for _, link := range snapshot.Links {
+		snapshotLinks[duplicateLinkKey(link)] = true
+		if !observedLinks[duplicateLinkKey(link)] && recent(link.LastSeen) {
+			v.MissingLinks = append(v.MissingLinks, describeLink(link))
+		}
+	}
+	for _, link := range observed.Links {
+		if key := duplicateLinkKey(link); !snapshotLinks[key] {
+			snapshotLinks[key] = true // 重複して報告されたリンクは1本に数える
+			v.UnexpectedLinks = append(v.UnexpectedLinks, describeLink(link))
+		}
+	}
+
+	for _, ids := range [][]string{v.MissingDevices, v.MissingLinks, v.UnexpectedDevices, v.UnexpectedLinks} {
+		sort.Strings(ids)
+	}
+
+	disagreeing := len(v.MissingDevices) + len(v.MissingLinks) + len(v.UnexpectedDevices) + len(v.UnexpectedLinks)
+	total := v.Devices + v.Links + len(v.UnexpectedDevices) + len(v.UnexpectedLinks)
+	if total > 0 {
+		v.Drift = float64(disagreeing) / float64(total)
+	}
+	if v.Drift > opts.MaxDrift {
+		v.Problems = append(v.Problems, fmt.Sprintf("snapshot disagrees with Prometheus at %s on %.1f%% of devices and links (maximum %.1f%%); is it from this Prometheus and is taken_at correct?",
+			snapshot.TakenAt.Format(time.RFC3339), v.Drift*100, opts.MaxDrift*100))
+	}
+	return v
+}
+
+// SnapshotBootstrapResult reports the outcome of loading a snapshot into the repository
+type SnapshotBootstrapResult struct {
+	Verification *SnapshotVerification `json:"verification,omitempty"` // nil = 検証を省略した
+	Devices      int                   `json:"devices"`
+	Links        int                   `json:"links"`
+	// Synced is set when an incremental sync ran right after the snapshot was loaded
+	Synced bool `json:"synced"`
+}
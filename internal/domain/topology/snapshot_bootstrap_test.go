@@ -0,0 +1,104 @@
+package topology
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadSnapshot(t *testing.T) {
+	takenAt := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	snapshot := Snapshot{
+		TakenAt: takenAt,
+		Source:  SnapshotSourceStored,
+		Devices: []Device{{ID: "leaf-01"}, {ID: "spine-01"}},
+		Links:   []Link{{ID: "l1", SourceID: "leaf-01", TargetID: "spine-01"}},
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(data)
+	gz.Close()
+
+	for name, input := range map[string][]byte{"json": data, "gzip": compressed.Bytes()} {
+		read, err := ReadSnapshot(bytes.NewReader(input))
+		if err != nil {
+			t.Fatalf("Expected %s snapshot to be read, got %v", name, err)
+		}
+		if !read.TakenAt.Equal(takenAt) || len(read.Devices) != 2 || len(read.Links) != 1 {
+			t.Errorf("Expected %s snapshot to round-trip, got %+v", name, read)
+		}
+	}
+
+	invalid := []string{
+		`{"devices":[{"id":"a"}]}`,
+		`{"taken_at":"2025-03-01T09:00:00Z","devices":[{"id":"a"},{"id":"a"}]}`,
+		`{"taken_at":"2025-03-01T09:00:00Z","devices":[{"id":"a"}],"links":[{"id":"l1","source_id":"a","target_id":"b"}]}`,
+		`not json`,
+	}
+	for _, input := range invalid {
+		if _, err := ReadSnapshot(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected %q to be rejected", input)
+		}
+	}
+}
+
+func TestVerifySnapshot(t *testing.T) {
+	takenAt := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	opts := SnapshotVerifyOptions{Now: takenAt.Add(time.Hour), MaxAge: 12 * time.Hour, MaxDrift: 0.2}
+
+	snapshot := &Snapshot{
+		TakenAt: takenAt,
+		Devices: []Device{
+			{ID: "leaf-01", LastSeen: takenAt},
+			{ID: "leaf-02", LastSeen: takenAt.Add(-time.Minute)},
+			{ID: "spine-01", LastSeen: takenAt},
+			// ルックバックより前に消えていたデバイスは報告されなくてよい
+			{ID: "old-01", LastSeen: takenAt.Add(-time.Hour)},
+		},
+		Links: []Link{
+			{ID: "l1", SourceID: "leaf-01", SourcePort: "Ethernet49", TargetID: "spine-01", TargetPort: "Ethernet1", LastSeen: takenAt},
+			{ID: "l2", SourceID: "leaf-02", SourcePort: "Ethernet49", TargetID: "spine-01", TargetPort: "Ethernet2", LastSeen: takenAt},
+		},
+	}
+	observed := &Snapshot{
+		TakenAt: takenAt,
+		Devices: []Device{{ID: "leaf-01"}, {ID: "leaf-02"}, {ID: "spine-01"}},
+		// リンク ID は抽出順に振られるため一致しなくてよい
+		Links: []Link{
+			{ID: "lldp-link-0", SourceID: "leaf-02", SourcePort: "Ethernet49", TargetID: "spine-01", TargetPort: "Ethernet2"},
+			{ID: "lldp-link-1", SourceID: "leaf-01", SourcePort: "Ethernet49", TargetID: "spine-01", TargetPort: "Ethernet1"},
+		},
+	}
+
+	v := VerifySnapshot(snapshot, observed, opts)
+	if !v.Consistent() || v.Drift != 0 || len(v.MissingDevices) != 0 {
+		t.Errorf("Expected the snapshot to agree with Prometheus, got %+v", v)
+	}
+	if v.AgeSeconds != 3600 || v.ObservedDevices != 3 || v.ObservedLinks != 2 {
+		t.Errorf("Expected age and observed counts, got %+v", v)
+	}
+
+	observed.Devices = append(observed.Devices, Device{ID: "leaf-03"})
+	observed.Links = observed.Links[1:]
+	v = VerifySnapshot(snapshot, observed, opts)
+	if len(v.UnexpectedDevices) != 1 || v.UnexpectedDevices[0] != "leaf-03" || len(v.MissingLinks) != 1 || v.MissingLinks[0] != "leaf-02:Ethernet49 -- spine-01:Ethernet2" {
+		t.Errorf("Expected leaf-03 to be unexpected and l2 to be missing, got %+v", v)
+	}
+	if v.Consistent() || v.Drift < 0.2 {
+		t.Errorf("Expected 2 of 7 entries to exceed the drift, got %+v", v)
+	}
+
+	opts.Now = takenAt.Add(13 * time.Hour)
+	v = VerifySnapshot(snapshot, nil, opts)
+	if len(v.Problems) != 2 {
+		t.Errorf("Expected the snapshot to be too old and unverifiable, got %v", v.Problems)
+	}
+}
@@ -0,0 +1,50 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackfillTimes(t *testing.T) {
+	from := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	times, err := BackfillTimes(from, from.Add(2*time.Hour+30*time.Minute), time.Hour)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []time.Time{from, from.Add(time.Hour), from.Add(2 * time.Hour), from.Add(2*time.Hour + 30*time.Minute)}
+	if len(times) != len(want) {
+		t.Fatalf("Expected %d timestamps, got %v", len(want), times)
+	}
+	for i := range want {
+		if !times[i].Equal(want[i]) {
+			t.Errorf("Expected timestamp %d to be %s, got %s", i, want[i], times[i])
+		}
+	}
+
+	single, err := BackfillTimes(from, from, 0)
+	if err != nil || len(single) != 1 {
+		t.Errorf("Expected a single timestamp, got %v (err: %v)", single, err)
+	}
+}
+
+func TestBackfillTimes_Invalid(t *testing.T) {
+	from := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := BackfillTimes(from, from.Add(-time.Hour), time.Minute); err == nil {
+		t.Errorf("Expected error when the end is before the start")
+	}
+	if _, err := BackfillTimes(from, from.Add(time.Hour), 0); err == nil {
+		t.Errorf("Expected error for a zero step")
+	}
+	if _, err := BackfillTimes(from, from.Add(30*24*time.Hour), time.Minute); err == nil {
+		t.Errorf("Expected error when the range produces too many snapshots")
+	}
+}
+
+func TestSnapshot_FileName(t *testing.T) {
+	snapshot := Snapshot{TakenAt: time.Date(2025, 3, 1, 21, 4, 5, 0, time.FixedZone("JST", 9*60*60))}
+	if got := snapshot.FileName(); got != "snapshot-20250301T120405Z.json" {
+		t.Errorf("Expected UTC file name, got %s", got)
+	}
+}
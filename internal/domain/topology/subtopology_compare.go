@@ -0,0 +1,221 @@
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Aspects in which two subtopologies differ
+const (
+	CompareAspectLinks    = "links"
+	CompareAspectLayer    = "layer"
+	CompareAspectType     = "type"
+	CompareAspectHardware = "hardware"
+	CompareAspectUplinks  = "uplink_pattern"
+)
+
+// unclassifiedLayerKey is the layer key of devices without a layer
+const unclassifiedLayerKey = "unclassified"
+
+// SubTopologyProfile summarizes the structure below a root device
+type SubTopologyProfile struct {
+	Root              string         `json:"root"`
+	Devices           int            `json:"devices"`
+	Links             int            `json:"links"`
+	DevicesByLayer    map[string]int `json:"devices_by_layer"` // レイヤーID（未分類は "unclassified"）ごとの台数
+	DevicesByType     map[string]int `json:"devices_by_type"`
+	DevicesByHardware map[string]int `json:"devices_by_hardware"`
+	// UplinkPatterns counts the devices by layer and number of uplinks per upper layer,
+	// e.g. "41->32 x2" for devices of layer 41 with two links to layer 32
+	UplinkPatterns map[string]int `json:"uplink_patterns"`
+}
+
+// SubTopologyDifference is one count that differs between the two subtopologies
+type SubTopologyDifference struct {
+	Aspect string `json:"aspect" enum:"links,layer,type,hardware,uplink_pattern"`
+	Key    string `json:"key"`
+	A      int    `json:"a"`
+	B      int    `json:"b"`
+}
+
+// SubTopologyComparison is the structural diff of the subtopologies under two roots
+type SubTopologyComparison struct {
+	A           SubTopologyProfile      `json:"a"`
+	B           SubTopologyProfile      `json:"b"`
+	Identical   bool                    `json:"identical"`
+	Differences []SubTopologyDifference `json:"differences"`
+}
+
+// BelowRoot keeps the devices reachable from root without passing through a device of a higher
+// layer than root, and the links between them. Higher layers have smaller layer IDs; unclassified
+// devices are kept. It drops the aggregation tier a pod hangs off so pods can be compared.
+func BelowRoot(root string, devices []Device, links []Link) ([]Device, []Link) {
+	byID := make(map[string]Device, len(devices))
+	for _, device := range devices {
+		byID[device.ID] = device
+	}
+	rootDevice, ok := byID[root]
+	if !ok {
+		return nil, nil
+	}
+	below := func(device Device) bool {
+		return rootDevice.LayerID == nil || device.LayerID == nil || *device.LayerID >= *rootDevice.LayerID
+	}
+
+	adjacency := make(map[string][]string)
+	for _, link := range links {
+		adjacency[link.SourceID] = append(adjacency[link.SourceID], link.TargetID)
+		adjacency[link.TargetID] = append(adjacency[link.TargetID], link.SourceID)
+	}
+
+	kept := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, neighbor := range adjacency[current] {
+			device, ok := byID[neighbor]
+			if !ok || kept[neighbor] || !below(device) {
+				continue
+			}
+			kept[neighbor] = true
+			queue = append(queue, neighbor)
+		}
+	}
+
+	keptDevices := make([]Device, 0, len(kept))
+	for _, device := range devices {
+		if kept[device.ID] {
+			keptDevices = append(keptDevices, device)
+		}
+	}
+	keptLinks := make([]Link, 0, len(links))
+	for _, link := range links {
+		if kept[link.SourceID] && kept[link.TargetID] {
+			keptLinks = append(keptLinks, link)
+		}
+	}
+	return keptDevices, keptLinks
+}
+
+// ProfileSubTopology counts the devices, links and uplink patterns of a subtopology. Links
+// reported from both sides are counted once.
+func ProfileSubTopology(root string, devices []Device, links []Link) SubTopologyProfile {
+	profile := SubTopologyProfile{
+		Root:              root,
+		Devices:           len(devices),
+		DevicesByLayer:    make(map[string]int),
+		DevicesByType:     make(map[string]int),
+		DevicesByHardware: make(map[string]int),
+		UplinkPatterns:    make(map[string]int),
+	}
+
+	layerOf := make(map[string]*int, len(devices))
+	for _, device := range devices {
+		layerOf[device.ID] = device.LayerID
+		profile.DevicesByLayer[layerKey(device.LayerID)]++
+		profile.DevicesByType[valueOrUnknown(device.Type)]++
+		profile.DevicesByHardware[valueOrUnknown(device.Hardware)]++
+	}
+
+	// 両側から報告されたリンクは端点とポートの組で1本に数える
+	seen := make(map[string]bool, len(links))
+	uplinks := make(map[string]map[string]int) // デバイス → 上位レイヤー → 本数
+	for _, link := range links {
+		sourceLayer, sourceOK := layerOf[link.SourceID]
+		targetLayer, targetOK := layerOf[link.TargetID]
+		if !sourceOK || !targetOK {
+			continue
+		}
+		key := duplicateLinkKey(link)
+		reverse := duplicateLinkKey(Link{SourceID: link.TargetID, SourcePort: link.TargetPort, TargetID: link.SourceID, TargetPort: link.SourcePort})
+		if seen[key] || seen[reverse] {
+			continue
+		}
+		seen[key] = true
+		profile.Links++
+
+		if sourceLayer == nil || targetLayer == nil || *sourceLayer == *targetLayer {
+			continue
+		}
+		lower, upper := link.SourceID, targetLayer
+		if *sourceLayer < *targetLayer {
+			lower, upper = link.TargetID, sourceLayer
+		}
+		if uplinks[lower] == nil {
+			uplinks[lower] = make(map[string]int)
+		}
+		uplinks[lower][strconv.Itoa(*upper)]++
+	}
+
+	for _, device := range devices {
+		if device.ID == root {
+			continue
+		}
+		layer := layerKey(device.LayerID)
+		if len(uplinks[device.ID]) == 0 {
+			profile.UplinkPatterns[layer+"->none"]++
+			continue
+		}
+		for upper, count := range uplinks[device.ID] {
+			profile.UplinkPatterns[fmt.Sprintf("%s->%s x%d", layer, upper, count)]++
+		}
+	}
+
+	return profile
+}
+
+// CompareSubTopologies lists every count that differs between the two profiles, sorted by aspect and key
+func CompareSubTopologies(a, b SubTopologyProfile) SubTopologyComparison {
+	comparison := SubTopologyComparison{A: a, B: b, Differences: []SubTopologyDifference{}}
+
+	if a.Links != b.Links {
+		comparison.Differences = append(comparison.Differences, SubTopologyDifference{Aspect: CompareAspectLinks, Key: "total", A: a.Links, B: b.Links})
+	}
+	for _, aspect := range []struct {
+		name string
+		a, b map[string]int
+	}{
+		{CompareAspectLayer, a.DevicesByLayer, b.DevicesByLayer},
+		{CompareAspectType, a.DevicesByType, b.DevicesByType},
+		{CompareAspectHardware, a.DevicesByHardware, b.DevicesByHardware},
+		{CompareAspectUplinks, a.UplinkPatterns, b.UplinkPatterns},
+	} {
+		keys := make([]string, 0, len(aspect.a)+len(aspect.b))
+		for key := range aspect.a {
+			keys = append(keys, key)
+		}
+		for key := range aspect.b {
+			if _, ok := aspect.a[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if aspect.a[key] != aspect.b[key] {
+				comparison.Differences = append(comparison.Differences, SubTopologyDifference{
+					Aspect: aspect.name, Key: key, A: aspect.a[key], B: aspect.b[key],
+				})
+			}
+		}
+	}
+
+	comparison.Identical = len(comparison.Differences) == 0
+	return comparison
+}
+
+func layerKey(layerID *int) string {
+	if layerID == nil {
+		return unclassifiedLayerKey
+	}
+	return strconv.Itoa(*layerID)
+}
+
+func valueOrUnknown(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "unknown"
+	}
+	return value
+}
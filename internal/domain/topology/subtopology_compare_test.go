@@ -0,0 +1,67 @@
+package topology
+
+import "testing"
+
+func TestCompareSubTopologies(t *testing.T) {
+	superSpine, spine, leaf := 10, 32, 41
+	layer := func(id int) *int { return &id }
+
+	// pod1 は spine 1台・leaf 2台（各2本のアップリンク）、pod2 は leaf-b2 のアップリンクが1本で機種も異なる
+	devices := []Device{
+		{ID: "super-01", LayerID: layer(superSpine), Type: "switch", Hardware: "7800R3"},
+		{ID: "spine-a1", LayerID: layer(spine), Type: "switch", Hardware: "7280R3"},
+		{ID: "leaf-a1", LayerID: layer(leaf), Type: "switch", Hardware: "7050X3"},
+		{ID: "leaf-a2", LayerID: layer(leaf), Type: "switch", Hardware: "7050X3"},
+		{ID: "spine-b1", LayerID: layer(spine), Type: "switch", Hardware: "7280R3"},
+		{ID: "leaf-b1", LayerID: layer(leaf), Type: "switch", Hardware: "7050X3"},
+		{ID: "leaf-b2", LayerID: layer(leaf), Type: "switch", Hardware: "7050X4"},
+	}
+	links := []Link{
+		{ID: "u1", SourceID: "spine-a1", TargetID: "super-01", SourcePort: "Et1", TargetPort: "Et1"},
+		{ID: "u2", SourceID: "spine-b1", TargetID: "super-01", SourcePort: "Et1", TargetPort: "Et2"},
+		{ID: "a1", SourceID: "leaf-a1", TargetID: "spine-a1", SourcePort: "Et49", TargetPort: "Et1"},
+		{ID: "a2", SourceID: "leaf-a1", TargetID: "spine-a1", SourcePort: "Et50", TargetPort: "Et2"},
+		{ID: "a3", SourceID: "leaf-a2", TargetID: "spine-a1", SourcePort: "Et49", TargetPort: "Et3"},
+		{ID: "a4", SourceID: "leaf-a2", TargetID: "spine-a1", SourcePort: "Et50", TargetPort: "Et4"},
+		// 両側から報告されたリンクは1本に数える
+		{ID: "a4r", SourceID: "spine-a1", TargetID: "leaf-a2", SourcePort: "Et4", TargetPort: "Et50"},
+		{ID: "b1", SourceID: "leaf-b1", TargetID: "spine-b1", SourcePort: "Et49", TargetPort: "Et1"},
+		{ID: "b2", SourceID: "leaf-b1", TargetID: "spine-b1", SourcePort: "Et50", TargetPort: "Et2"},
+		{ID: "b3", SourceID: "leaf-b2", TargetID: "spine-b1", SourcePort: "Et49", TargetPort: "Et3"},
+	}
+
+	devicesA, linksA := BelowRoot("spine-a1", devices, links)
+	if len(devicesA) != 3 {
+		t.Fatalf("Expected the super spine and the other pod to be left out, got %+v", devicesA)
+	}
+	profileA := ProfileSubTopology("spine-a1", devicesA, linksA)
+	if profileA.Links != 4 || profileA.UplinkPatterns["41->32 x2"] != 2 {
+		t.Errorf("Expected 4 links and two leaves with 2 uplinks, got %+v", profileA)
+	}
+
+	comparison := CompareSubTopologies(profileA, ProfileSubTopology("spine-a1", devicesA, linksA))
+	if !comparison.Identical || len(comparison.Differences) != 0 {
+		t.Errorf("Expected a pod to mirror itself, got %+v", comparison.Differences)
+	}
+
+	devicesB, linksB := BelowRoot("spine-b1", devices, links)
+	comparison = CompareSubTopologies(profileA, ProfileSubTopology("spine-b1", devicesB, linksB))
+	if comparison.Identical {
+		t.Fatal("Expected the pods to differ")
+	}
+	expected := []SubTopologyDifference{
+		{Aspect: CompareAspectLinks, Key: "total", A: 4, B: 3},
+		{Aspect: CompareAspectHardware, Key: "7050X3", A: 2, B: 1},
+		{Aspect: CompareAspectHardware, Key: "7050X4", A: 0, B: 1},
+		{Aspect: CompareAspectUplinks, Key: "41->32 x1", A: 0, B: 1},
+		{Aspect: CompareAspectUplinks, Key: "41->32 x2", A: 2, B: 1},
+	}
+	if len(comparison.Differences) != len(expected) {
+		t.Fatalf("Expected %d differences, got %+v", len(expected), comparison.Differences)
+	}
+	for i, difference := range comparison.Differences {
+		if difference != expected[i] {
+			t.Errorf("Expected difference %d to be %+v, got %+v", i, expected[i], difference)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package topology
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DeviceStatus is the liveness of a device derived from when it was last seen
+type DeviceStatus string
+
+const (
+	// DeviceStatusActive devices were seen within DeviceStaleAfter
+	DeviceStatusActive DeviceStatus = "active"
+	// DeviceStatusStale devices have not been seen for DeviceStaleAfter
+	DeviceStatusStale DeviceStatus = "stale"
+)
+
+// DeviceStaleAfter is how long after last_seen a device counts as stale
+// (the worker's default max device age)
+const DeviceStaleAfter = 24 * time.Hour
+
+// SubTopologyFilter includes or excludes the devices of a sub-topology by attribute. The
+// repository applies it while extracting, so filtered devices never leave the database.
+// Empty fields do not filter and the root device is always kept.
+type SubTopologyFilter struct {
+	IncludeTypes          []string          `json:"include_types,omitempty"`
+	ExcludeTypes          []string          `json:"exclude_types,omitempty"`
+	HardwareRegex         string            `json:"hardware_regex,omitempty"`
+	ExcludeHardware       string            `json:"exclude_hardware_regex,omitempty"`
+	IncludeMetadata       map[string]string `json:"include_metadata,omitempty"` // すべて一致
+	ExcludeMetadata       map[string]string `json:"exclude_metadata,omitempty"` // いずれか一致で除外
+	Status                DeviceStatus      `json:"status,omitempty"`
+	IncludeWorkflowStates []string          `json:"include_workflow_states,omitempty"`
+	ExcludeWorkflowStates []string          `json:"exclude_workflow_states,omitempty"`
+}
+
+// IsEmpty reports whether the filter keeps every device
+func (f SubTopologyFilter) IsEmpty() bool {
+	return len(f.IncludeTypes) == 0 && len(f.ExcludeTypes) == 0 &&
+		f.HardwareRegex == "" && f.ExcludeHardware == "" &&
+		len(f.IncludeMetadata) == 0 && len(f.ExcludeMetadata) == 0 &&
+		f.Status == "" && len(f.IncludeWorkflowStates) == 0 && len(f.ExcludeWorkflowStates) == 0
+}
+
+// Validate checks the hardware patterns, the status and the workflow states. The database evaluates the
+// patterns, so only syntax shared by RE2 and POSIX regular expressions is portable.
+func (f SubTopologyFilter) Validate() error {
+	for _, pattern := range []string{f.HardwareRegex, f.ExcludeHardware} {
+		if pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid hardware pattern '%s': %v", pattern, err)
+		}
+	}
+	switch f.Status {
+	case "", DeviceStatusActive, DeviceStatusStale:
+	default:
+		return fmt.Errorf("unknown device status '%s' (expected active or stale)", f.Status)
+	}
+	for _, state := range append(append([]string{}, f.IncludeWorkflowStates...), f.ExcludeWorkflowStates...) {
+		if !IsValidWorkflowState(state) {
+			return fmt.Errorf("unknown workflow state '%s' (expected one of %s)", state, strings.Join(WorkflowStates, ", "))
+		}
+	}
+	return nil
+}
+
+// Matches reports whether device passes the filter at now. Repositories that cannot evaluate the
+// filter in SQL apply it with Matches; the filter must be valid.
+func (f SubTopologyFilter) Matches(device Device, now time.Time) bool {
+	if len(f.IncludeTypes) > 0 && !containsString(f.IncludeTypes, device.Type) {
+		return false
+	}
+	if containsString(f.ExcludeTypes, device.Type) {
+		return false
+	}
+	if f.HardwareRegex != "" {
+		if matched, _ := regexp.MatchString(f.HardwareRegex, device.Hardware); !matched {
+			return false
+		}
+	}
+	if f.ExcludeHardware != "" {
+		if matched, _ := regexp.MatchString(f.ExcludeHardware, device.Hardware); matched {
+			return false
+		}
+	}
+	for key, value := range f.IncludeMetadata {
+		if actual, ok := device.Metadata[key]; !ok || actual != value {
+			return false
+		}
+	}
+	for key, value := range f.ExcludeMetadata {
+		if actual, ok := device.Metadata[key]; ok && actual == value {
+			return false
+		}
+	}
+	if len(f.IncludeWorkflowStates) > 0 && !containsString(f.IncludeWorkflowStates, device.WorkflowState) {
+		return false
+	}
+	if containsString(f.ExcludeWorkflowStates, device.WorkflowState) {
+		return false
+	}
+
+	switch f.Status {
+	case DeviceStatusActive:
+		return !device.LastSeen.Before(now.Add(-DeviceStaleAfter))
+	case DeviceStatusStale:
+		return device.LastSeen.Before(now.Add(-DeviceStaleAfter))
+	}
+	return true
+}
+
+// ParseMetadataTerms parses "key=value" terms of a metadata filter
+func ParseMetadataTerms(terms []string) (map[string]string, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	metadata := make(map[string]string, len(terms))
+	for _, term := range terms {
+		key, value, ok := strings.Cut(term, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid metadata term '%s' (expected key=value)", term)
+		}
+		metadata[key] = strings.TrimSpace(value)
+	}
+	return metadata, nil
+}
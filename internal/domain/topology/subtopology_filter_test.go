@@ -0,0 +1,91 @@
+package topology
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubTopologyFilter_Validate(t *testing.T) {
+	if !(SubTopologyFilter{}).IsEmpty() {
+		t.Error("Expected zero filter to be empty")
+	}
+
+	valid := SubTopologyFilter{HardwareRegex: `^DCS-7050(SX|TX)`, Status: DeviceStatusStale}
+	if valid.IsEmpty() {
+		t.Error("Expected filter with a hardware pattern not to be empty")
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected filter to be valid, got %v", err)
+	}
+
+	if (SubTopologyFilter{IncludeWorkflowStates: []string{WorkflowOnboarding}}).IsEmpty() {
+		t.Error("Expected filter with a workflow state not to be empty")
+	}
+
+	invalid := []SubTopologyFilter{
+		{HardwareRegex: "(unclosed"},
+		{ExcludeHardware: "[z-a]"},
+		{Status: "down"},
+		{ExcludeWorkflowStates: []string{"retired"}},
+	}
+	for _, filter := range invalid {
+		if err := filter.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", filter)
+		}
+	}
+}
+
+func TestParseMetadataTerms(t *testing.T) {
+	metadata, err := ParseMetadataTerms([]string{"site=tyo1", " rack = A-01 ", "note="})
+	if err != nil {
+		t.Fatalf("Expected terms to parse, got %v", err)
+	}
+	if metadata["site"] != "tyo1" || metadata["rack"] != "A-01" {
+		t.Errorf("Unexpected metadata %v", metadata)
+	}
+	if value, ok := metadata["note"]; !ok || value != "" {
+		t.Errorf("Expected empty value for note, got %q", value)
+	}
+
+	for _, term := range []string{"site", "=tyo1"} {
+		if _, err := ParseMetadataTerms([]string{term}); err == nil {
+			t.Errorf("Expected %q to be rejected", term)
+		}
+	}
+}
+
+func TestSubTopologyFilter_Matches(t *testing.T) {
+	now := time.Now()
+	device := Device{
+		ID:            "leaf-01",
+		Type:          "switch",
+		Hardware:      "DCS-7050SX",
+		WorkflowState: WorkflowActive,
+		Metadata:      map[string]string{"site": "tyo1"},
+		LastSeen:      now,
+	}
+
+	tests := []struct {
+		name   string
+		filter SubTopologyFilter
+		want   bool
+	}{
+		{"empty", SubTopologyFilter{}, true},
+		{"include type", SubTopologyFilter{IncludeTypes: []string{"router"}}, false},
+		{"exclude type", SubTopologyFilter{ExcludeTypes: []string{"switch"}}, false},
+		{"hardware", SubTopologyFilter{HardwareRegex: `^DCS-7050(SX|TX)`}, true},
+		{"exclude hardware", SubTopologyFilter{ExcludeHardware: `7050`}, false},
+		{"include metadata", SubTopologyFilter{IncludeMetadata: map[string]string{"site": "tyo1"}}, true},
+		{"include missing metadata", SubTopologyFilter{IncludeMetadata: map[string]string{"rack": "a1"}}, false},
+		{"exclude metadata", SubTopologyFilter{ExcludeMetadata: map[string]string{"site": "tyo1"}}, false},
+		{"exclude other metadata", SubTopologyFilter{ExcludeMetadata: map[string]string{"site": "osa1"}}, true},
+		{"workflow state", SubTopologyFilter{ExcludeWorkflowStates: []string{WorkflowActive}}, false},
+		{"active", SubTopologyFilter{Status: DeviceStatusActive}, true},
+		{"stale", SubTopologyFilter{Status: DeviceStatusStale}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Matches(device, now); got != tt.want {
+			t.Errorf("%s: Expected Matches to return %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
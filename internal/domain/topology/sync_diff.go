@@ -0,0 +1,218 @@
+package topology
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FieldChange is one field a sync would overwrite
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// SyncChange is a device or link a sync would add, update or no longer refresh
+type SyncChange struct {
+	ID          string        `json:"id"`
+	Description string        `json:"description"`
+	Changes     []FieldChange `json:"changes,omitempty"`
+}
+
+// SyncChangeSet groups the changes of one kind of entity
+type SyncChangeSet struct {
+	Added     []SyncChange `json:"added"`
+	Updated   []SyncChange `json:"updated"`
+	Removed   []SyncChange `json:"removed"`
+	Unchanged int          `json:"unchanged"`
+}
+
+// SyncDiff previews what a sync would write without writing it. Removed entries are no longer
+// reported by Prometheus: the sync does not delete them, but stops refreshing them so they age out.
+// Timestamps and metadata are not compared.
+type SyncDiff struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Devices     SyncChangeSet `json:"devices"`
+	Links       SyncChangeSet `json:"links"`
+	// Placeholders are link endpoints not known as devices, created as lldp-placeholder devices
+	Placeholders []string `json:"placeholders"`
+	// SkippedDevices and SkippedLinks were rejected by the ingestion quota
+	SkippedDevices []string `json:"skipped_devices"`
+	SkippedLinks   int      `json:"skipped_links"`
+	Warnings       []string `json:"warnings"`
+}
+
+// IsEmpty reports whether the sync would change nothing
+func (d *SyncDiff) IsEmpty() bool {
+	return len(d.Devices.Added)+len(d.Devices.Updated)+len(d.Devices.Removed)+
+		len(d.Links.Added)+len(d.Links.Updated)+len(d.Links.Removed) == 0
+}
+
+// DiffDevices compares the devices a sync would write with the stored ones. Only devices that
+// entered the topology through the sync and are neither written nor in reported (e.g. LLDP
+// neighbors) are reported as removed.
+func DiffDevices(stored, incoming []Device, reported map[string]bool) SyncChangeSet {
+	current := make(map[string]Device, len(stored))
+	for _, device := range stored {
+		current[device.ID] = device
+	}
+
+	set := SyncChangeSet{Added: []SyncChange{}, Updated: []SyncChange{}, Removed: []SyncChange{}}
+	written := make(map[string]bool, len(incoming))
+	for _, device := range incoming {
+		if written[device.ID] {
+			continue
+		}
+		written[device.ID] = true
+
+		old, ok := current[device.ID]
+		if !ok {
+			set.Added = append(set.Added, SyncChange{ID: device.ID, Description: describeDevice(device)})
+			continue
+		}
+		changes := fieldChanges(
+			FieldChange{"type", old.Type, device.Type},
+			FieldChange{"hardware", old.Hardware, device.Hardware},
+		)
+		if len(changes) == 0 {
+			set.Unchanged++
+			continue
+		}
+		set.Updated = append(set.Updated, SyncChange{ID: device.ID, Description: describeDevice(device), Changes: changes})
+	}
+
+	for _, device := range stored {
+		if written[device.ID] || reported[device.ID] {
+			continue
+		}
+		// 手動登録・インポートしたデバイスは同期の対象外
+		switch device.Provenance {
+		case "", ProvenancePrometheus, ProvenanceLLDPPlaceholder:
+			set.Removed = append(set.Removed, SyncChange{ID: device.ID, Description: describeDevice(device)})
+		}
+	}
+
+	set.sort()
+	return set
+}
+
+// DiffLinks compares the links a sync would write with the stored ones. Links are matched by ID,
+// as the upsert does.
+func DiffLinks(stored, incoming []Link) SyncChangeSet {
+	current := make(map[string]Link, len(stored))
+	for _, link := range stored {
+		current[link.ID] = link
+	}
+
+	set := SyncChangeSet{Added: []SyncChange{}, Updated: []SyncChange{}, Removed: []SyncChange{}}
+	written := make(map[string]bool, len(incoming))
+	for _, link := range incoming {
+		if written[link.ID] {
+			continue
+		}
+		written[link.ID] = true
+
+		old, ok := current[link.ID]
+		if !ok {
+			set.Added = append(set.Added, SyncChange{ID: link.ID, Description: describeLink(link)})
+			continue
+		}
+		changes := fieldChanges(
+			FieldChange{"source", old.SourceID, link.SourceID},
+			FieldChange{"source_port", old.SourcePort, link.SourcePort},
+			FieldChange{"target", old.TargetID, link.TargetID},
+			FieldChange{"target_port", old.TargetPort, link.TargetPort},
+			FieldChange{"weight", formatWeight(old.Weight), formatWeight(link.Weight)},
+		)
+		if len(changes) == 0 {
+			set.Unchanged++
+			continue
+		}
+		set.Updated = append(set.Updated, SyncChange{ID: link.ID, Description: describeLink(link), Changes: changes})
+	}
+
+	for _, link := range stored {
+		if !written[link.ID] {
+			set.Removed = append(set.Removed, SyncChange{ID: link.ID, Description: describeLink(link)})
+		}
+	}
+
+	set.sort()
+	return set
+}
+
+func fieldChanges(fields ...FieldChange) []FieldChange {
+	var changes []FieldChange
+	for _, field := range fields {
+		if field.Old != field.New {
+			changes = append(changes, field)
+		}
+	}
+	return changes
+}
+
+func (s *SyncChangeSet) sort() {
+	for _, changes := range [][]SyncChange{s.Added, s.Updated, s.Removed} {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+	}
+}
+
+func describeDevice(device Device) string {
+	return fmt.Sprintf("%s (%s, %s)", device.ID, device.Type, device.Hardware)
+}
+
+func describeLink(link Link) string {
+	return fmt.Sprintf("%s:%s -- %s:%s", link.SourceID, link.SourcePort, link.TargetID, link.TargetPort)
+}
+
+func formatWeight(weight float64) string {
+	return fmt.Sprintf("%g", weight)
+}
+
+// WriteText writes the diff in a human-readable form: a summary followed by one line per
+// added (+), updated (~) and removed (-) entry
+func (d *SyncDiff) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Devices: +%d ~%d -%d (%d unchanged)\n", len(d.Devices.Added), len(d.Devices.Updated), len(d.Devices.Removed), d.Devices.Unchanged)
+	fmt.Fprintf(&b, "Links:   +%d ~%d -%d (%d unchanged)\n", len(d.Links.Added), len(d.Links.Updated), len(d.Links.Removed), d.Links.Unchanged)
+	if len(d.Placeholders) > 0 {
+		fmt.Fprintf(&b, "Placeholders: %d devices only seen as LLDP neighbors\n", len(d.Placeholders))
+	}
+	if len(d.SkippedDevices) > 0 || d.SkippedLinks > 0 {
+		fmt.Fprintf(&b, "Skipped by quota: %d devices, %d links\n", len(d.SkippedDevices), d.SkippedLinks)
+	}
+	for _, warning := range d.Warnings {
+		fmt.Fprintf(&b, "Warning: %s\n", warning)
+	}
+
+	for _, section := range []struct {
+		kind string
+		set  SyncChangeSet
+	}{
+		{"device", d.Devices},
+		{"link", d.Links},
+	} {
+		for _, change := range section.set.Added {
+			fmt.Fprintf(&b, "+ %s %s\n", section.kind, change.Description)
+		}
+		for _, change := range section.set.Updated {
+			fmt.Fprintf(&b, "~ %s %s\n", section.kind, change.Description)
+			for _, field := range change.Changes {
+				fmt.Fprintf(&b, "    %s: %q -> %q\n", field.Field, field.Old, field.New)
+			}
+		}
+		for _, change := range section.set.Removed {
+			fmt.Fprintf(&b, "- %s %s (no longer reported)\n", section.kind, change.Description)
+		}
+	}
+
+	if d.IsEmpty() {
+		b.WriteString("No changes\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,97 @@
+package topology
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffDevices(t *testing.T) {
+	stored := []Device{
+		{ID: "core-01", Type: "switch", Hardware: "DCS-7500", Provenance: ProvenancePrometheus},
+		{ID: "core-02", Type: "switch", Hardware: "DCS-7500", Provenance: ProvenancePrometheus},
+		{ID: "old-01", Type: "switch", Hardware: "EX4300", Provenance: ProvenancePrometheus},
+		{ID: "neighbor-01", Type: "unknown", Hardware: "unknown", Provenance: ProvenanceLLDPPlaceholder},
+		{ID: "manual-01", Type: "server", Provenance: ProvenanceManual},
+	}
+	incoming := []Device{
+		{ID: "core-01", Type: "switch", Hardware: "DCS-7500"},
+		{ID: "core-02", Type: "switch", Hardware: "DCS-7800"},
+		{ID: "leaf-01", Type: "switch", Hardware: "DCS-7050"},
+	}
+
+	set := DiffDevices(stored, incoming, map[string]bool{"neighbor-01": true})
+
+	if len(set.Added) != 1 || set.Added[0].ID != "leaf-01" {
+		t.Errorf("Expected leaf-01 to be added, got %+v", set.Added)
+	}
+	if len(set.Updated) != 1 || set.Updated[0].ID != "core-02" {
+		t.Fatalf("Expected core-02 to be updated, got %+v", set.Updated)
+	}
+	if change := set.Updated[0].Changes; len(change) != 1 || change[0].Field != "hardware" || change[0].New != "DCS-7800" {
+		t.Errorf("Expected hardware change, got %+v", change)
+	}
+	if set.Unchanged != 1 {
+		t.Errorf("Expected 1 unchanged device, got %d", set.Unchanged)
+	}
+	// 手動登録と、LLDP の対向として報告され続けるプレースホルダーは対象外
+	if len(set.Removed) != 1 || set.Removed[0].ID != "old-01" {
+		t.Errorf("Expected only old-01 to be removed, got %+v", set.Removed)
+	}
+}
+
+func TestDiffLinks(t *testing.T) {
+	stored := []Link{
+		{ID: "lldp-link-0", SourceID: "a", SourcePort: "Eth1", TargetID: "b", TargetPort: "Eth1", Weight: 1},
+		{ID: "lldp-link-1", SourceID: "a", SourcePort: "Eth2", TargetID: "c", TargetPort: "Eth1", Weight: 1},
+		{ID: "lldp-link-2", SourceID: "a", SourcePort: "Eth3", TargetID: "d", TargetPort: "Eth1", Weight: 1},
+	}
+	incoming := []Link{
+		{ID: "lldp-link-0", SourceID: "a", SourcePort: "Eth1", TargetID: "b", TargetPort: "Eth1", Weight: 1},
+		{ID: "lldp-link-1", SourceID: "a", SourcePort: "Eth2", TargetID: "e", TargetPort: "Eth1", Weight: 1},
+	}
+
+	set := DiffLinks(stored, incoming)
+
+	if len(set.Added) != 0 || set.Unchanged != 1 {
+		t.Errorf("Expected no additions and 1 unchanged link, got %+v", set)
+	}
+	if len(set.Updated) != 1 || set.Updated[0].Changes[0].Field != "target" {
+		t.Errorf("Expected lldp-link-1 target change, got %+v", set.Updated)
+	}
+	if len(set.Removed) != 1 || set.Removed[0].ID != "lldp-link-2" {
+		t.Errorf("Expected lldp-link-2 to be removed, got %+v", set.Removed)
+	}
+}
+
+func TestSyncDiff_WriteText(t *testing.T) {
+	diff := &SyncDiff{
+		Devices: DiffDevices(nil, []Device{{ID: "leaf-01", Type: "switch", Hardware: "DCS-7050"}}, nil),
+		Links: DiffLinks(
+			[]Link{{ID: "l1", SourceID: "a", SourcePort: "Eth1", TargetID: "b", TargetPort: "Eth2", Weight: 1}},
+			[]Link{{ID: "l1", SourceID: "a", SourcePort: "Eth1", TargetID: "b", TargetPort: "Eth2", Weight: 10}},
+		),
+	}
+
+	var b strings.Builder
+	if err := diff.WriteText(&b); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	text := b.String()
+	for _, expected := range []string{
+		"Devices: +1 ~0 -0 (0 unchanged)",
+		"+ device leaf-01 (switch, DCS-7050)",
+		"~ link a:Eth1 -- b:Eth2",
+		`    weight: "1" -> "10"`,
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected %q in:\n%s", expected, text)
+		}
+	}
+
+	empty := &SyncDiff{}
+	b.Reset()
+	empty.WriteText(&b)
+	if !strings.Contains(b.String(), "No changes") {
+		t.Errorf("Expected empty diff to say so, got %s", b.String())
+	}
+}
@@ -0,0 +1,101 @@
+package topology
+
+import "time"
+
+// SyncCycleStats counts the changes between what two consecutive synchronization cycles observed
+// in monitoring. A spike points to a misbehaving exporter or a network event.
+type SyncCycleStats struct {
+	StartedAt          time.Time `json:"started_at"`
+	FinishedAt         time.Time `json:"finished_at"`
+	Devices            int       `json:"devices"` // 今回観測したデバイス数（LLDPの対向を含む）
+	Links              int       `json:"links"`
+	DevicesAppeared    int       `json:"devices_appeared"`
+	DevicesDisappeared int       `json:"devices_disappeared"`
+	LinksAdded         int       `json:"links_added"`
+	LinksRemoved       int       `json:"links_removed"`
+	Errors             int       `json:"errors"` // 失敗したフェーズ数（失敗したフェーズの変化は数えない）
+	// Baseline is true for the first cycle of a worker, which has nothing to compare with
+	Baseline bool `json:"baseline"`
+}
+
+// SyncObservation is the set of devices and links seen by a synchronization cycle
+type SyncObservation struct {
+	devices map[string]bool
+	links   map[string]bool
+}
+
+// NewSyncObservation creates an empty observation
+func NewSyncObservation() *SyncObservation {
+	return &SyncObservation{
+		devices: make(map[string]bool),
+		links:   make(map[string]bool),
+	}
+}
+
+// AddDevices records devices as observed
+func (o *SyncObservation) AddDevices(devices []Device) {
+	for _, device := range devices {
+		o.devices[device.ID] = true
+	}
+}
+
+// AddLinks records links and the devices at their ends as observed. Links are identified by
+// their ends and ports, as link IDs are assigned anew by every extraction.
+func (o *SyncObservation) AddLinks(links []Link) {
+	for _, link := range links {
+		o.links[duplicateLinkKey(link)] = true
+		o.devices[link.SourceID] = true
+		o.devices[link.TargetID] = true
+	}
+}
+
+// KeepDevices carries the devices of previous over, so a failed device phase does not count as a change
+func (o *SyncObservation) KeepDevices(previous *SyncObservation) {
+	if previous == nil {
+		return
+	}
+	for id := range previous.devices {
+		o.devices[id] = true
+	}
+}
+
+// KeepLinks carries the links of previous over, so a failed link phase does not count as a change
+func (o *SyncObservation) KeepLinks(previous *SyncObservation) {
+	if previous == nil {
+		return
+	}
+	for key := range previous.links {
+		o.links[key] = true
+	}
+}
+
+// Compare counts the changes since previous (nil = first cycle, reported as a baseline)
+func (o *SyncObservation) Compare(previous *SyncObservation) SyncCycleStats {
+	stats := SyncCycleStats{
+		Devices:  len(o.devices),
+		Links:    len(o.links),
+		Baseline: previous == nil,
+	}
+	if previous == nil {
+		return stats
+	}
+
+	stats.DevicesAppeared, stats.DevicesDisappeared = diffSets(previous.devices, o.devices)
+	stats.LinksAdded, stats.LinksRemoved = diffSets(previous.links, o.links)
+	return stats
+}
+
+// diffSets counts the keys only in current and only in previous
+func diffSets(previous, current map[string]bool) (added, removed int) {
+	for key := range current {
+		if !previous[key] {
+			added++
+		}
+	}
+	for key := range previous {
+		if !current[key] {
+			removed++
+		}
+	}
+	return added, removed
+}
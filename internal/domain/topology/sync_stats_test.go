@@ -0,0 +1,38 @@
+package topology
+
+import "testing"
+
+func TestSyncObservation_Compare(t *testing.T) {
+	first := NewSyncObservation()
+	first.AddDevices([]Device{{ID: "leaf-1"}, {ID: "leaf-2"}})
+	first.AddLinks([]Link{
+		{ID: "lldp-link-0", SourceID: "leaf-1", SourcePort: "Ethernet1", TargetID: "spine-1", TargetPort: "Ethernet1"},
+		{ID: "lldp-link-1", SourceID: "leaf-2", SourcePort: "Ethernet1", TargetID: "spine-1", TargetPort: "Ethernet2"},
+	})
+
+	baseline := first.Compare(nil)
+	if !baseline.Baseline || baseline.Devices != 3 || baseline.Links != 2 || baseline.DevicesAppeared != 0 {
+		t.Errorf("Expected a baseline of 3 devices and 2 links, got %+v", baseline)
+	}
+
+	// リンクIDは抽出ごとに振り直されるため、同じ端点とポートなら同じリンクとみなす
+	second := NewSyncObservation()
+	second.AddDevices([]Device{{ID: "leaf-1"}, {ID: "leaf-3"}})
+	second.AddLinks([]Link{
+		{ID: "lldp-link-1", SourceID: "leaf-1", SourcePort: "Ethernet1", TargetID: "spine-1", TargetPort: "Ethernet1"},
+		{ID: "lldp-link-0", SourceID: "leaf-3", SourcePort: "Ethernet1", TargetID: "spine-1", TargetPort: "Ethernet3"},
+	})
+
+	stats := second.Compare(first)
+	if stats.Baseline || stats.DevicesAppeared != 1 || stats.DevicesDisappeared != 1 || stats.LinksAdded != 1 || stats.LinksRemoved != 1 {
+		t.Errorf("Expected one device and link each appearing and disappearing, got %+v", stats)
+	}
+
+	// 失敗したフェーズは前回の観測を引き継ぐ
+	failed := NewSyncObservation()
+	failed.KeepDevices(second)
+	failed.KeepLinks(second)
+	if stats := failed.Compare(second); stats.DevicesAppeared+stats.DevicesDisappeared+stats.LinksAdded+stats.LinksRemoved != 0 {
+		t.Errorf("Expected no changes when every phase failed, got %+v", stats)
+	}
+}
@@ -0,0 +1,84 @@
+package topology
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Workflow states track a device through turn-up. Devices enter as discovered and only move
+// along the allowed transitions; syncs never change the state of a stored device.
+const (
+	WorkflowDiscovered     = "discovered"     // 監視やLLDPで見つかったばかり
+	WorkflowOnboarding     = "onboarding"     // 構築・設定中
+	WorkflowActive         = "active"         // 本番稼働中
+	WorkflowQuarantined    = "quarantined"    // 障害や調査のため切り離し中
+	WorkflowDecommissioned = "decommissioned" // 撤去済み
+)
+
+// WorkflowStates lists the workflow states in turn-up order
+var WorkflowStates = []string{WorkflowDiscovered, WorkflowOnboarding, WorkflowActive, WorkflowQuarantined, WorkflowDecommissioned}
+
+// workflowTransitions maps each state to the states a device may move to next
+var workflowTransitions = map[string][]string{
+	WorkflowDiscovered:     {WorkflowOnboarding, WorkflowDecommissioned},
+	WorkflowOnboarding:     {WorkflowActive, WorkflowQuarantined, WorkflowDecommissioned},
+	WorkflowActive:         {WorkflowQuarantined, WorkflowDecommissioned},
+	WorkflowQuarantined:    {WorkflowOnboarding, WorkflowActive, WorkflowDecommissioned},
+	WorkflowDecommissioned: {WorkflowOnboarding}, // 再利用する機器は構築からやり直す
+}
+
+// IsValidWorkflowState reports whether state is a known workflow state
+func IsValidWorkflowState(state string) bool {
+	_, ok := workflowTransitions[state]
+	return ok
+}
+
+// EffectiveWorkflowState returns the workflow state of device, treating an unset state as discovered
+func EffectiveWorkflowState(device Device) string {
+	if device.WorkflowState == "" {
+		return WorkflowDiscovered
+	}
+	return device.WorkflowState
+}
+
+// AllowedWorkflowTransitions returns the states a device in state may move to
+func AllowedWorkflowTransitions(state string) []string {
+	return append([]string{}, workflowTransitions[state]...)
+}
+
+// ValidateWorkflowTransition checks that a device may move from one state to another
+func ValidateWorkflowTransition(from, to string) error {
+	if !IsValidWorkflowState(to) {
+		return fmt.Errorf("unknown workflow state '%s' (expected one of %s)", to, strings.Join(WorkflowStates, ", "))
+	}
+	for _, next := range workflowTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	allowed := workflowTransitions[from]
+	if len(allowed) == 0 {
+		return fmt.Errorf("unknown workflow state '%s'", from)
+	}
+	return fmt.Errorf("cannot move from %s to %s (allowed: %s)", from, to, strings.Join(allowed, ", "))
+}
+
+// WorkflowTransition records one change of a device's workflow state
+type WorkflowTransition struct {
+	ID        int64     `json:"id"`
+	DeviceID  string    `json:"device_id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// DeviceWorkflow is the workflow state of a device with the states it may move to and its history
+type DeviceWorkflow struct {
+	DeviceID string               `json:"device_id"`
+	State    string               `json:"state"`
+	Allowed  []string             `json:"allowed_transitions"`
+	History  []WorkflowTransition `json:"history"`
+}
@@ -0,0 +1,49 @@
+package topology
+
+import "testing"
+
+func TestValidateWorkflowTransition(t *testing.T) {
+	allowed := [][2]string{
+		{WorkflowDiscovered, WorkflowOnboarding},
+		{WorkflowOnboarding, WorkflowActive},
+		{WorkflowActive, WorkflowQuarantined},
+		{WorkflowQuarantined, WorkflowActive},
+		{WorkflowActive, WorkflowDecommissioned},
+		{WorkflowDecommissioned, WorkflowOnboarding},
+	}
+	for _, transition := range allowed {
+		if err := ValidateWorkflowTransition(transition[0], transition[1]); err != nil {
+			t.Errorf("Expected %s → %s to be allowed, got %v", transition[0], transition[1], err)
+		}
+	}
+
+	rejected := [][2]string{
+		{WorkflowDiscovered, WorkflowActive},     // 構築を経ずに稼働させない
+		{WorkflowActive, WorkflowActive},         // 同じ状態への遷移
+		{WorkflowDecommissioned, WorkflowActive}, // 撤去済みは構築からやり直す
+		{WorkflowOnboarding, "retired"},          // 未知の状態
+		{"retired", WorkflowActive},
+	}
+	for _, transition := range rejected {
+		if err := ValidateWorkflowTransition(transition[0], transition[1]); err == nil {
+			t.Errorf("Expected %s → %s to be rejected", transition[0], transition[1])
+		}
+	}
+}
+
+func TestEffectiveWorkflowState(t *testing.T) {
+	if state := EffectiveWorkflowState(Device{}); state != WorkflowDiscovered {
+		t.Errorf("Expected unset state to be discovered, got %s", state)
+	}
+	if state := EffectiveWorkflowState(Device{WorkflowState: WorkflowQuarantined}); state != WorkflowQuarantined {
+		t.Errorf("Expected quarantined, got %s", state)
+	}
+}
+
+func TestAllowedWorkflowTransitions_ReturnsCopy(t *testing.T) {
+	allowed := AllowedWorkflowTransitions(WorkflowActive)
+	allowed[0] = "mutated"
+	if AllowedWorkflowTransitions(WorkflowActive)[0] == "mutated" {
+		t.Errorf("Expected the allowed transitions not to be shared")
+	}
+}
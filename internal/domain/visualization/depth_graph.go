@@ -0,0 +1,65 @@
+package visualization
+
+// DepthGraph is the undirected adjacency of a topology being built for one request. Hop depths
+// are memoized per root, so building nodes, grouping and hiding nodes behind groups share one
+// breadth-first search instead of rebuilding the adjacency at each step.
+type DepthGraph struct {
+	adjacency map[string][]string
+	depths    map[string]map[string]int // ルートごとの深度（メモ化）
+}
+
+// NewDepthGraph returns an empty graph
+func NewDepthGraph() *DepthGraph {
+	return &DepthGraph{
+		adjacency: make(map[string][]string),
+		depths:    make(map[string]map[string]int),
+	}
+}
+
+// NewDepthGraphFromEdges indexes the edges of a visual topology
+func NewDepthGraphFromEdges(edges []VisualEdge) *DepthGraph {
+	g := NewDepthGraph()
+	for _, edge := range edges {
+		g.AddEdge(edge.Source, edge.Target)
+	}
+	return g
+}
+
+// AddEdge connects two nodes. Memoized depths are discarded.
+func (g *DepthGraph) AddEdge(sourceID, targetID string) {
+	g.adjacency[sourceID] = append(g.adjacency[sourceID], targetID)
+	g.adjacency[targetID] = append(g.adjacency[targetID], sourceID)
+	if len(g.depths) > 0 {
+		g.depths = make(map[string]map[string]int)
+	}
+}
+
+// Neighbors returns the nodes directly connected to nodeID, once per edge
+func (g *DepthGraph) Neighbors(nodeID string) []string {
+	return g.adjacency[nodeID]
+}
+
+// Depths returns the hop count from rootID to every node reachable from it. The map is shared
+// between callers and must not be modified.
+func (g *DepthGraph) Depths(rootID string) map[string]int {
+	if depths, ok := g.depths[rootID]; ok {
+		return depths
+	}
+
+	depths := map[string]int{rootID: 0}
+	queue := []string{rootID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, neighborID := range g.adjacency[current] {
+			if _, seen := depths[neighborID]; seen {
+				continue
+			}
+			depths[neighborID] = depths[current] + 1
+			queue = append(queue, neighborID)
+		}
+	}
+
+	g.depths[rootID] = depths
+	return depths
+}
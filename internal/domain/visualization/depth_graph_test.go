@@ -0,0 +1,118 @@
+package visualization
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDepthGraph_Depths(t *testing.T) {
+	graph := NewDepthGraphFromEdges([]VisualEdge{
+		{Source: "core-001", Target: "dist-100"},
+		{Source: "core-001", Target: "dist-101"},
+		{Source: "dist-100", Target: "access-001"},
+		{Source: "access-001", Target: "dist-101"},
+		{Source: "isolated-a", Target: "isolated-b"},
+	})
+
+	depths := graph.Depths("core-001")
+	expected := map[string]int{"core-001": 0, "dist-100": 1, "dist-101": 1, "access-001": 2}
+	if len(depths) != len(expected) {
+		t.Errorf("Expected %d reachable nodes, got %+v", len(expected), depths)
+	}
+	for nodeID, want := range expected {
+		if got, ok := depths[nodeID]; !ok || got != want {
+			t.Errorf("Expected depth %d for %s, got %d (found=%v)", want, nodeID, got, ok)
+		}
+	}
+
+	if len(graph.Neighbors("access-001")) != 2 || len(graph.Neighbors("unknown")) != 0 {
+		t.Errorf("Unexpected neighbors: %v", graph.Neighbors("access-001"))
+	}
+}
+
+func TestDepthGraph_Memoization(t *testing.T) {
+	graph := NewDepthGraphFromEdges([]VisualEdge{{Source: "a", Target: "b"}})
+
+	first := graph.Depths("a")
+	first["marker"] = 99 // 同じマップが返されることの確認用
+	if graph.Depths("a")["marker"] != 99 {
+		t.Errorf("Expected depths to be memoized per root")
+	}
+
+	graph.AddEdge("b", "c")
+	if depths := graph.Depths("a"); depths["c"] != 2 || depths["marker"] != 0 {
+		t.Errorf("Expected AddEdge to discard memoized depths, got %+v", depths)
+	}
+}
+
+// benchmarkEdges builds a fabric-like topology of n nodes: each node hangs off the node n/4
+// positions before it and every tenth node has a cross link to its neighbour
+func benchmarkEdges(n int) []VisualEdge {
+	edges := make([]VisualEdge, 0, n+n/10)
+	for i := 1; i < n; i++ {
+		edges = append(edges, VisualEdge{Source: fmt.Sprintf("node-%d", (i-1)/4), Target: fmt.Sprintf("node-%d", i)})
+		if i%10 == 0 {
+			edges = append(edges, VisualEdge{Source: fmt.Sprintf("node-%d", i), Target: fmt.Sprintf("node-%d", i-1)})
+		}
+	}
+	return edges
+}
+
+// グルーピングありの1リクエストでは、トポロジー構築・グループ判定・非表示ノード判定の3回深度を参照する
+const depthLookupsPerRequest = 3
+
+func BenchmarkDepthGraph_RebuildPerLookup(b *testing.B) {
+	edges := benchmarkEdges(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < depthLookupsPerRequest; j++ {
+			NewDepthGraphFromEdges(edges).Depths("node-0")
+		}
+	}
+}
+
+func BenchmarkDepthGraph_SharedPerRequest(b *testing.B) {
+	edges := benchmarkEdges(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		graph := NewDepthGraphFromEdges(edges)
+		for j := 0; j < depthLookupsPerRequest; j++ {
+			graph.Depths("node-0")
+		}
+	}
+}
+
+// 非表示ノード判定の近傍探索：全エッジの走査と隣接リストの比較
+func BenchmarkNeighbors_EdgeScan(b *testing.B) {
+	edges := benchmarkEdges(10000)
+	nodeIDs := make([]string, 0, 100)
+	for i := 0; i < 10000; i += 100 {
+		nodeIDs = append(nodeIDs, fmt.Sprintf("node-%d", i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, nodeID := range nodeIDs {
+			neighbors := 0
+			for _, edge := range edges {
+				if edge.Source == nodeID || edge.Target == nodeID {
+					neighbors++
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkNeighbors_Adjacency(b *testing.B) {
+	edges := benchmarkEdges(10000)
+	nodeIDs := make([]string, 0, 100)
+	for i := 0; i < 10000; i += 100 {
+		nodeIDs = append(nodeIDs, fmt.Sprintf("node-%d", i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		graph := NewDepthGraphFromEdges(edges)
+		for _, nodeID := range nodeIDs {
+			_ = len(graph.Neighbors(nodeID))
+		}
+	}
+}
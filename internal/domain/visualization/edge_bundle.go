@@ -0,0 +1,75 @@
+package visualization
+
+import "sort"
+
+// DefaultEdgeBundleMinEdges is the number of edges between two layers from which they are bundled
+const DefaultEdgeBundleMinEdges = 8
+
+// EdgeBundle groups the edges between two hierarchy layers so frontends can draw them as
+// one bundled curve instead of many overlapping lines
+type EdgeBundle struct {
+	ID          string `json:"id"`           // "bundle-30-40" など
+	SourceLayer string `json:"source_layer"` // 上位の階層（グループノードは "group"）
+	TargetLayer string `json:"target_layer"`
+	Count       int    `json:"count"`
+}
+
+// BundleEdges assigns every edge between a layer pair with at least minEdges edges to the
+// bundle of that pair and lists the bundles, ordered by layer. Layer pairs are keyed like
+// TopologyStats.LayerEdges. Existing bundles are replaced; minEdges <= 0 removes them.
+func (t *VisualTopology) BundleEdges(minEdges int) {
+	t.Bundles = nil
+	for i := range t.Edges {
+		t.Edges[i].Bundle = ""
+	}
+	if minEdges <= 0 {
+		return
+	}
+
+	nodeLayers := make(map[string]string, len(t.Nodes))
+	for _, node := range t.Nodes {
+		nodeLayers[node.ID] = nodeLayerKey(node)
+	}
+
+	type layerPair struct{ source, target string }
+	pairs := make(map[int]layerPair)
+	counts := make(map[layerPair]int)
+	for i, edge := range t.Edges {
+		source, okSource := nodeLayers[edge.Source]
+		target, okTarget := nodeLayers[edge.Target]
+		if !okSource || !okTarget {
+			continue
+		}
+		if layerKeyLess(target, source) {
+			source, target = target, source
+		}
+		pair := layerPair{source, target}
+		pairs[i] = pair
+		counts[pair]++
+	}
+
+	for pair, count := range counts {
+		if count < minEdges {
+			continue
+		}
+		t.Bundles = append(t.Bundles, EdgeBundle{
+			ID:          "bundle-" + pair.source + "-" + pair.target,
+			SourceLayer: pair.source,
+			TargetLayer: pair.target,
+			Count:       count,
+		})
+	}
+	sort.Slice(t.Bundles, func(i, j int) bool {
+		a, b := t.Bundles[i], t.Bundles[j]
+		if a.SourceLayer != b.SourceLayer {
+			return layerKeyLess(a.SourceLayer, b.SourceLayer)
+		}
+		return layerKeyLess(a.TargetLayer, b.TargetLayer)
+	})
+
+	for i, pair := range pairs {
+		if counts[pair] >= minEdges {
+			t.Edges[i].Bundle = "bundle-" + pair.source + "-" + pair.target
+		}
+	}
+}
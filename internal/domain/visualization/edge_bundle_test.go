@@ -0,0 +1,78 @@
+package visualization
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBundleEdges(t *testing.T) {
+	topology := &VisualTopology{
+		Nodes: []VisualNode{
+			{ID: "spine-01", Layer: 30},
+			{ID: "border-01", Layer: 20},
+			{ID: "group_prefix_srv", Type: "group"},
+		},
+		Edges: []VisualEdge{
+			{ID: "uplink", Source: "spine-01", Target: "border-01"},
+			{ID: "servers", Source: "spine-01", Target: "group_prefix_srv"},
+		},
+	}
+	// スパインから64台のリーフへのファンアウト（向きは混在）
+	for i := 0; i < 64; i++ {
+		leaf := fmt.Sprintf("leaf-%02d", i)
+		topology.Nodes = append(topology.Nodes, VisualNode{ID: leaf, Layer: 40})
+		edge := VisualEdge{ID: "e-" + leaf, Source: "spine-01", Target: leaf}
+		if i%2 == 1 {
+			edge.Source, edge.Target = leaf, "spine-01"
+		}
+		topology.Edges = append(topology.Edges, edge)
+	}
+
+	topology.BundleEdges(DefaultEdgeBundleMinEdges)
+
+	if len(topology.Bundles) != 1 {
+		t.Fatalf("Expected 1 bundle, got %+v", topology.Bundles)
+	}
+	bundle := topology.Bundles[0]
+	if bundle.ID != "bundle-30-40" || bundle.SourceLayer != "30" || bundle.TargetLayer != "40" || bundle.Count != 64 {
+		t.Errorf("Unexpected bundle: %+v", bundle)
+	}
+	for _, edge := range topology.Edges {
+		expected := "bundle-30-40"
+		if edge.ID == "uplink" || edge.ID == "servers" {
+			expected = ""
+		}
+		if edge.Bundle != expected {
+			t.Errorf("Expected edge %s in bundle %q, got %q", edge.ID, expected, edge.Bundle)
+		}
+	}
+
+	topology.BundleEdges(0)
+	if topology.Bundles != nil || topology.Edges[2].Bundle != "" {
+		t.Errorf("Expected bundles to be removed, got %+v", topology.Bundles)
+	}
+}
+
+func TestBundleEdges_GroupLayerSortsLast(t *testing.T) {
+	topology := &VisualTopology{
+		Nodes: []VisualNode{
+			{ID: "leaf-01", Layer: 40},
+			{ID: "leaf-02", Layer: 40},
+			{ID: "group_prefix_srv", Type: "group"},
+		},
+		Edges: []VisualEdge{
+			{ID: "a", Source: "group_prefix_srv", Target: "leaf-01"},
+			{ID: "b", Source: "leaf-02", Target: "group_prefix_srv"},
+			{ID: "c", Source: "leaf-01", Target: "leaf-02"},
+		},
+	}
+
+	topology.BundleEdges(1)
+
+	if len(topology.Bundles) != 2 || topology.Bundles[0].ID != "bundle-40-40" || topology.Bundles[1].ID != "bundle-40-group" {
+		t.Fatalf("Expected 40-40 and 40-group bundles, got %+v", topology.Bundles)
+	}
+	if topology.Bundles[1].Count != 2 || topology.Edges[0].Bundle != "bundle-40-group" {
+		t.Errorf("Expected both group edges in one bundle, got %+v", topology.Edges)
+	}
+}
@@ -0,0 +1,40 @@
+package visualization
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultEdgeLabelFormat composes edge labels such as "100G core-distribution"
+const DefaultEdgeLabelFormat = "{speed} {link_type}"
+
+var edgeLabelPlaceholder = regexp.MustCompile(`\{([a-zA-Z0-9_.-]+)\}`)
+
+// FormatEdgeLabel renders format for edge. Placeholders refer to link metadata keys
+// ({speed}, {link_type}, ...) or to {local_port} / {remote_port}; unknown keys render empty.
+func FormatEdgeLabel(format string, edge VisualEdge) string {
+	label := edgeLabelPlaceholder.ReplaceAllStringFunc(format, func(placeholder string) string {
+		key := placeholder[1 : len(placeholder)-1]
+		switch key {
+		case "local_port":
+			return edge.LocalPort
+		case "remote_port":
+			return edge.RemotePort
+		}
+		return edge.Metadata[key]
+	})
+
+	// 欠けた値で生じた余分な空白を詰める
+	return strings.Join(strings.Fields(label), " ")
+}
+
+// LabelEdges sets the label of every edge from format; an empty format removes all labels
+func (t *VisualTopology) LabelEdges(format string) {
+	for i := range t.Edges {
+		if format == "" {
+			t.Edges[i].Label = ""
+			continue
+		}
+		t.Edges[i].Label = FormatEdgeLabel(format, t.Edges[i])
+	}
+}
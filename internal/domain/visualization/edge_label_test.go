@@ -0,0 +1,62 @@
+package visualization
+
+import "testing"
+
+func TestFormatEdgeLabel(t *testing.T) {
+	edge := VisualEdge{
+		LocalPort:  "Ethernet1",
+		RemotePort: "Ethernet49",
+		Metadata:   map[string]string{"speed": "100G", "link_type": "L3", "circuit.id": "CKT-1"},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{"default", DefaultEdgeLabelFormat, "100G L3"},
+		{"ports", "{local_port} - {remote_port}", "Ethernet1 - Ethernet49"},
+		{"dotted key", "{circuit.id}", "CKT-1"},
+		{"literal text", "speed: {speed}", "speed: 100G"},
+		// 欠けた値の前後の空白は詰める
+		{"missing key", "{speed}  {vlan} {link_type}", "100G L3"},
+		{"only missing keys", "{vlan} {mtu}", ""},
+		{"unclosed placeholder", "{speed", "{speed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatEdgeLabel(tt.format, edge); got != tt.want {
+				t.Errorf("FormatEdgeLabel(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+
+	// メタデータの無いエッジでもポートは使える
+	if got := FormatEdgeLabel(DefaultEdgeLabelFormat+" {local_port}", VisualEdge{LocalPort: "eth0"}); got != "eth0" {
+		t.Errorf("Expected only the port for an edge without metadata, got %q", got)
+	}
+}
+
+func TestVisualTopology_LabelEdges(t *testing.T) {
+	topology := &VisualTopology{Edges: []VisualEdge{
+		{ID: "l1", Metadata: map[string]string{"speed": "100G", "link_type": "L3"}},
+		{ID: "l2", Metadata: map[string]string{"speed": "25G"}},
+		{ID: "l3", Label: "stale"},
+	}}
+
+	topology.LabelEdges(DefaultEdgeLabelFormat)
+	for i, want := range []string{"100G L3", "25G", ""} {
+		if topology.Edges[i].Label != want {
+			t.Errorf("Expected edge %s to be labeled %q, got %q", topology.Edges[i].ID, want, topology.Edges[i].Label)
+		}
+	}
+
+	// 空のフォーマットはラベルをすべて外す
+	topology.LabelEdges("")
+	for _, edge := range topology.Edges {
+		if edge.Label != "" {
+			t.Errorf("Expected no label on edge %s, got %q", edge.ID, edge.Label)
+		}
+	}
+}
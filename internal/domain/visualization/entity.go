@@ -5,27 +5,35 @@ import (
 )
 
 type VisualTopology struct {
-	RootDevice string              `json:"root_device"`
-	Depth      int                 `json:"depth"`
-	Timestamp  int64               `json:"timestamp"`
-	Nodes      []VisualNode        `json:"nodes"`
-	Edges      []VisualEdge        `json:"edges"`
-	Groups     []GroupedVisualNode `json:"groups,omitempty"`
-	Layout     Layout              `json:"layout"`
-	Stats      TopologyStats       `json:"stats"`
+	SchemaVersion int                 `json:"schema_version"` // ペイロードのスキーマバージョン（CurrentSchemaVersion）
+	RootDevice    string              `json:"root_device"`
+	Depth         int                 `json:"depth"`
+	Timestamp     int64               `json:"timestamp"`
+	Nodes         []VisualNode        `json:"nodes"`
+	Edges         []VisualEdge        `json:"edges"`
+	Groups        []GroupedVisualNode `json:"groups,omitempty"`
+	Bundles       []EdgeBundle        `json:"bundles,omitempty"` // 密な階層ペアのエッジバンドル（edge_bundles 指定時のみ）
+	Layout        Layout              `json:"layout"`
+	LayoutPatch   *LayoutPatch        `json:"layout_patch,omitempty"` // 差分レイアウト（グループ展開時のみ）
+	Stats         TopologyStats       `json:"stats"`
 }
 
 type VisualNode struct {
-	ID          string                     `json:"id"`
-	Name        string                     `json:"name"`
-	Type        string                     `json:"type"`
-	Hardware    string                     `json:"hardware"`
-	Status      string                     `json:"status"`
-	Layer       int                        `json:"layer"`
-	IsRoot      bool                       `json:"is_root"`
-	Position    Position                   `json:"position"`
-	Style       NodeStyle                  `json:"style"`
-	Connections *ConnectionClassification `json:"connections,omitempty"`
+	ID            string                    `json:"id"`
+	Name          string                    `json:"name"`
+	Type          string                    `json:"type"`
+	Hardware      string                    `json:"hardware"`
+	Status        string                    `json:"status"`
+	Layer         int                       `json:"layer"`
+	WorkflowState string                    `json:"workflow_state,omitempty"` // デバイスの構築ワークフロー状態
+	IsRoot        bool                      `json:"is_root"`
+	Position      Position                  `json:"position"`
+	Style         NodeStyle                 `json:"style"`
+	Connections   *ConnectionClassification `json:"connections,omitempty"`
+	Parent        string                    `json:"parent,omitempty"`      // ポートグラフでポートが属するデバイス
+	StyleRules    []string                  `json:"style_rules,omitempty"` // スタイルを上書きしたルールのID（適用順）
+
+	Metadata map[string]string `json:"-"` // スタイルルール評価用のデバイスメタデータ
 }
 
 type VisualEdge struct {
@@ -37,7 +45,20 @@ type VisualEdge struct {
 	Status         string    `json:"status"`
 	Weight         float64   `json:"weight"`
 	Style          EdgeStyle `json:"style"`
-	ConnectionType string    `json:"connection_type"` // "uplink", "downlink", "peer"
+	ConnectionType string    `json:"connection_type"`       // "uplink", "downlink", "peer"
+	Asymmetric     bool      `json:"asymmetric,omitempty"`  // 片側からのみ LLDP で観測されたリンク
+	Label          string    `json:"label,omitempty"`       // リンクメタデータから組み立てた表示ラベル（例: "100G L3"）
+	Bundle         string    `json:"bundle,omitempty"`      // 所属するエッジバンドルのID
+	StyleRules     []string  `json:"style_rules,omitempty"` // スタイルを上書きしたルールのID（適用順）
+
+	Utilization       *float64 `json:"utilization,omitempty"`        // 直近の利用率（%、color_by=utilization 指定時のみ）
+	UtilizationBucket string   `json:"utilization_bucket,omitempty"` // "low", "medium", "high", "unknown"
+
+	AggregatedCount int      `json:"aggregated_count,omitempty"` // グループエッジにまとめた並行リンク数
+	TotalWeight     float64  `json:"total_weight,omitempty"`     // まとめたリンクの重みの合計
+	MemberLinkIDs   []string `json:"member_link_ids,omitempty"`  // まとめたリンクのID（ドリルダウン用）
+
+	Metadata map[string]string `json:"-"` // ラベル組み立て・スタイルルール評価用のリンクメタデータ
 }
 
 type Position struct {
@@ -51,6 +72,7 @@ type NodeStyle struct {
 	Size        float64 `json:"size"`
 	BorderColor string  `json:"border_color"`
 	BorderWidth float64 `json:"border_width"`
+	BorderStyle string  `json:"border_style,omitempty"` // "dashed" など（省略時は実線）
 }
 
 type EdgeStyle struct {
@@ -63,14 +85,27 @@ type Layout struct {
 	Type      string                 `json:"type"`
 	Options   map[string]interface{} `json:"options"`
 	Positions map[string]Position    `json:"positions"`
+	Bands     []LayerBand            `json:"bands,omitempty"` // 階層ごとの帯（layer_bands 指定時のみ）
+}
+
+// LayoutPatch describes an incremental layout change so the frontend can animate
+// new nodes into place instead of re-laying out the whole graph
+type LayoutPatch struct {
+	AnchorID string              `json:"anchor_id"` // 展開されたグループ
+	Anchor   Position            `json:"anchor"`    // 新規ノードの配置基準位置
+	Added    map[string]Position `json:"added"`     // 新規ノードの位置のみ
+	Removed  []string            `json:"removed"`   // 表示から外れたノード
 }
 
 type TopologyStats struct {
-	TotalNodes  int            `json:"total_nodes"`
-	TotalEdges  int            `json:"total_edges"`
-	TotalGroups int            `json:"total_groups"`
-	Layers      map[string]int `json:"layers"`
-	Generated   time.Time      `json:"generated"`
+	TotalNodes  int                       `json:"total_nodes"`
+	TotalEdges  int                       `json:"total_edges"`
+	TotalGroups int                       `json:"total_groups"`
+	Layers      map[string]int            `json:"layers"`
+	LayerEdges  map[string]int            `json:"layer_edges"`           // 階層ペアごとのエッジ数（"1-2" など）
+	GroupSizes  map[string]GroupSizeStats `json:"group_sizes,omitempty"` // グループ種別ごとのメンバー数
+	Degree      DegreeStats               `json:"degree"`
+	Generated   time.Time                 `json:"generated"`
 }
 
 // GroupedVisualNode represents a group of nodes that are visually collapsed
@@ -122,13 +157,30 @@ type ConnectionInfo struct {
 	IsSameGroup     bool    `json:"is_same_group,omitempty"` // peers の場合、同じグループ（同じuplinkに接続）かどうか
 }
 
+// GroupingPreview lists the groups that would be formed for given grouping options
+type GroupingPreview struct {
+	RootDevice     string         `json:"root_device"`
+	Depth          int            `json:"depth"`
+	TotalDevices   int            `json:"total_devices"`
+	GroupedDevices int            `json:"grouped_devices"`
+	Groups         []GroupPreview `json:"groups"`
+}
+
+// GroupPreview summarizes a single group without nodes, edges or layout
+type GroupPreview struct {
+	GroupType string   `json:"group_type"` // "prefix", "type"
+	Prefix    string   `json:"prefix"`
+	Count     int      `json:"count"`
+	DeviceIDs []string `json:"device_ids"`
+}
+
 // GroupingOptions specifies how nodes should be grouped
 type GroupingOptions struct {
-	Enabled       bool `json:"enabled"`
-	MinGroupSize  int  `json:"min_group_size"`  // 最小グループサイズ
-	MaxDepth      int  `json:"max_depth"`       // この深度より深いノードをグループ化
-	GroupByPrefix bool `json:"group_by_prefix"` // 共通プレフィックスでグループ化
-	GroupByType   bool `json:"group_by_type"`   // デバイスタイプでグループ化
-	GroupByDepth  bool `json:"group_by_depth"`  // 深度でグループ化
-	PrefixMinLen  int  `json:"prefix_min_len"`  // 最小プレフィックス長
+	Enabled       bool `json:"enabled" doc:"Collapse similar devices into group nodes"`
+	MinGroupSize  int  `json:"min_group_size" example:"3" doc:"Minimum number of devices that form a group"`                 // 最小グループサイズ
+	MaxDepth      int  `json:"max_depth" example:"2" doc:"Only nodes deeper than this depth are grouped"`                    // この深度より深いノードをグループ化
+	GroupByPrefix bool `json:"group_by_prefix" doc:"Group devices sharing a common name prefix (e.g. access-01..access-48)"` // 共通プレフィックスでグループ化
+	GroupByType   bool `json:"group_by_type" doc:"Group devices of the same type"`                                           // デバイスタイプでグループ化
+	GroupByDepth  bool `json:"group_by_depth" doc:"Group devices at the same depth"`                                         // 深度でグループ化
+	PrefixMinLen  int  `json:"prefix_min_len" example:"3" doc:"Minimum length of a common name prefix"`                      // 最小プレフィックス長
 }
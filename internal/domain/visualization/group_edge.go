@@ -0,0 +1,32 @@
+package visualization
+
+// NewGroupEdge creates the edge replacing member, a link between a device outside a group and a
+// device collapsed into it. Source or target is the group node.
+func NewGroupEdge(id, source, target string, member VisualEdge) VisualEdge {
+	edge := VisualEdge{
+		ID:         id,
+		Source:     source,
+		Target:     target,
+		LocalPort:  member.LocalPort,
+		RemotePort: member.RemotePort,
+		Status:     member.Status,
+		Weight:     member.Weight,
+		Style:      member.Style,
+	}
+	if source != member.Source {
+		edge.LocalPort = "group"
+	} else {
+		edge.RemotePort = "group"
+	}
+	edge.AddGroupMember(member)
+	return edge
+}
+
+// AddGroupMember adds member to the parallel links aggregated by a group edge. The ports are
+// those of the first member; Weight stays the weight of the first member for styling, while
+// TotalWeight sums all members.
+func (e *VisualEdge) AddGroupMember(member VisualEdge) {
+	e.AggregatedCount++
+	e.TotalWeight += member.Weight
+	e.MemberLinkIDs = append(e.MemberLinkIDs, member.ID)
+}
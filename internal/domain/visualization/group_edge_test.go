@@ -0,0 +1,26 @@
+package visualization
+
+import "testing"
+
+func TestNewGroupEdge(t *testing.T) {
+	first := VisualEdge{ID: "l1", Source: "spine-01", Target: "leaf-01", LocalPort: "Ethernet1", RemotePort: "Ethernet49", Weight: 1}
+	second := VisualEdge{ID: "l2", Source: "spine-01", Target: "leaf-01", LocalPort: "Ethernet2", RemotePort: "Ethernet50", Weight: 2}
+
+	edge := NewGroupEdge("spine-01-group-leaf", "spine-01", "group-leaf", first)
+	edge.AddGroupMember(second)
+
+	if edge.LocalPort != "Ethernet1" || edge.RemotePort != "group" {
+		t.Errorf("Expected the ports of the first member toward the group, got %s and %s", edge.LocalPort, edge.RemotePort)
+	}
+	if edge.AggregatedCount != 2 || edge.TotalWeight != 3 || edge.Weight != 1 {
+		t.Errorf("Expected 2 links of total weight 3, got %+v", edge)
+	}
+	if len(edge.MemberLinkIDs) != 2 || edge.MemberLinkIDs[0] != "l1" || edge.MemberLinkIDs[1] != "l2" {
+		t.Errorf("Expected member links l1 and l2, got %v", edge.MemberLinkIDs)
+	}
+
+	reverse := NewGroupEdge("group-leaf-spine-01", "group-leaf", "spine-01", VisualEdge{ID: "l3", Source: "leaf-02", Target: "spine-01", RemotePort: "Ethernet3"})
+	if reverse.LocalPort != "group" || reverse.RemotePort != "Ethernet3" || reverse.AggregatedCount != 1 {
+		t.Errorf("Expected a single link from the group, got %+v", reverse)
+	}
+}
@@ -0,0 +1,36 @@
+package visualization
+
+import "time"
+
+// GroupMembers describes the devices collapsed into a group node without expanding it
+type GroupMembers struct {
+	GroupID       string              `json:"group_id"`
+	Name          string              `json:"name"`
+	GroupType     string              `json:"group_type"`
+	Count         int                 `json:"count"`
+	Members       []GroupMember       `json:"members"`
+	InternalLinks int                 `json:"internal_links"` // グループ内で完結するリンク数
+	ExternalPeers []GroupExternalPeer `json:"external_peers"`
+}
+
+// GroupMember is a device of a group with its classification
+type GroupMember struct {
+	DeviceID      string    `json:"device_id"`
+	Name          string    `json:"name"`
+	Type          string    `json:"type"`
+	Hardware      string    `json:"hardware"`
+	Status        string    `json:"status"`
+	LayerID       *int      `json:"layer_id"`
+	DeviceType    string    `json:"device_type"`
+	ClassifiedBy  string    `json:"classified_by"`
+	LastSeen      time.Time `json:"last_seen"`
+	ExternalLinks int       `json:"external_links"` // グループ外のデバイスへのリンク数
+}
+
+// GroupExternalPeer summarizes the links between a group and a device outside of it
+type GroupExternalPeer struct {
+	PeerID    string   `json:"peer_id"`
+	PeerName  string   `json:"peer_name"`
+	LinkCount int      `json:"link_count"`
+	Members   []string `json:"members"` // ピアと接続しているグループ内デバイス
+}
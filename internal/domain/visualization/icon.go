@@ -0,0 +1,149 @@
+package visualization
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultIcon is the icon of devices that no mapping matches
+const DefaultIcon = "generic"
+
+// MaxIconSVGSize bounds the embedded SVG of an icon mapping
+const MaxIconSVGSize = 64 * 1024
+
+var iconIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// IconMapping maps a device type and/or vendor to an icon identifier shared by all frontends.
+// Vendor matches the beginning of the device hardware, case-insensitively.
+type IconMapping struct {
+	ID         string    `json:"id"`
+	DeviceType string    `json:"device_type,omitempty"`
+	Vendor     string    `json:"vendor,omitempty"`
+	Icon       string    `json:"icon"`
+	SVG        string    `json:"svg,omitempty"`
+	UpdatedBy  string    `json:"updated_by"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Validate checks the mapping has a selector and well-formed identifiers
+func (m IconMapping) Validate() error {
+	if !iconIDPattern.MatchString(m.ID) {
+		return fmt.Errorf("id must consist of lowercase letters, digits, '-' and '_'")
+	}
+	if !iconIDPattern.MatchString(m.Icon) {
+		return fmt.Errorf("icon must consist of lowercase letters, digits, '-' and '_'")
+	}
+	if m.DeviceType == "" && m.Vendor == "" {
+		return fmt.Errorf("device_type or vendor is required")
+	}
+	if m.SVG != "" {
+		if len(m.SVG) > MaxIconSVGSize {
+			return fmt.Errorf("svg must not exceed %d bytes", MaxIconSVGSize)
+		}
+		svg := strings.ToLower(strings.TrimSpace(m.SVG))
+		if !strings.HasPrefix(svg, "<svg") {
+			return fmt.Errorf("svg must be a single <svg> element")
+		}
+		// スプライトとしてそのまま埋め込むため、スクリプトは受け付けない
+		if strings.Contains(svg, "<script") {
+			return fmt.Errorf("svg must not contain scripts")
+		}
+	}
+	return nil
+}
+
+// specificity ranks mappings: device type and vendor > device type > vendor
+func (m IconMapping) specificity() int {
+	score := 0
+	if m.DeviceType != "" {
+		score += 2
+	}
+	if m.Vendor != "" {
+		score++
+	}
+	return score
+}
+
+func (m IconMapping) matches(deviceType, hardware string) bool {
+	if m.DeviceType != "" && !strings.EqualFold(m.DeviceType, deviceType) {
+		return false
+	}
+	if m.Vendor != "" && !strings.HasPrefix(strings.ToLower(hardware), strings.ToLower(m.Vendor)) {
+		return false
+	}
+	return true
+}
+
+// IconManifest is the versioned set of icon mappings served to frontends.
+// Version changes whenever a mapping or an embedded SVG changes.
+type IconManifest struct {
+	Version     string        `json:"version"`
+	DefaultIcon string        `json:"default_icon"`
+	Mappings    []IconMapping `json:"mappings"`
+}
+
+// BuildIconManifest sorts the mappings by ID and derives the manifest version from their content
+func BuildIconManifest(mappings []IconMapping) IconManifest {
+	sorted := make([]IconMapping, len(mappings))
+	copy(sorted, mappings)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	// 更新者・更新日時はバージョンに含めない（同じ内容の再保存でキャッシュを無効にしない）
+	hash := sha256.New()
+	for _, m := range sorted {
+		data, _ := json.Marshal([]string{m.ID, m.DeviceType, m.Vendor, m.Icon, m.SVG})
+		hash.Write(data)
+	}
+
+	return IconManifest{
+		Version:     hex.EncodeToString(hash.Sum(nil))[:16],
+		DefaultIcon: DefaultIcon,
+		Mappings:    sorted,
+	}
+}
+
+// Resolve returns the icon of the most specific mapping matching the device, or DefaultIcon.
+// Ties are broken by mapping ID.
+func (m IconManifest) Resolve(deviceType, hardware string) string {
+	icon, best := m.DefaultIcon, 0
+	for _, mapping := range m.Mappings {
+		if score := mapping.specificity(); score > best && mapping.matches(deviceType, hardware) {
+			icon, best = mapping.Icon, score
+		}
+	}
+	return icon
+}
+
+// WithoutSVG returns a copy of the manifest with the embedded SVGs removed
+func (m IconManifest) WithoutSVG() IconManifest {
+	mappings := make([]IconMapping, len(m.Mappings))
+	for i, mapping := range m.Mappings {
+		mapping.SVG = ""
+		mappings[i] = mapping
+	}
+	m.Mappings = mappings
+	return m
+}
+
+// Sprite renders the embedded SVGs as one SVG sprite with a <symbol id="ICON"> per icon.
+// When several mappings share an icon, the SVG of the first mapping by ID is used.
+func (m IconManifest) Sprite() string {
+	var b strings.Builder
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" style="display:none">`)
+	seen := make(map[string]bool)
+	for _, mapping := range m.Mappings {
+		if mapping.SVG == "" || seen[mapping.Icon] {
+			continue
+		}
+		seen[mapping.Icon] = true
+		fmt.Fprintf(&b, "\n<symbol id=\"%s\">%s</symbol>", mapping.Icon, strings.TrimSpace(mapping.SVG))
+	}
+	b.WriteString("\n</svg>\n")
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package visualization
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIconMapping_Validate(t *testing.T) {
+	valid := IconMapping{ID: "cisco-switch", DeviceType: "switch", Vendor: "Cisco", Icon: "switch-cisco", SVG: `<svg viewBox="0 0 24 24"></svg>`}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected mapping to be valid, got %v", err)
+	}
+
+	invalid := []IconMapping{
+		{ID: "Bad ID", DeviceType: "switch", Icon: "switch"},
+		{ID: "switch", DeviceType: "switch", Icon: ""},
+		{ID: "switch", Icon: "switch"},
+		{ID: "switch", DeviceType: "switch", Icon: "switch", SVG: "<png/>"},
+		{ID: "switch", DeviceType: "switch", Icon: "switch", SVG: "<svg><script>alert(1)</script></svg>"},
+	}
+	for _, m := range invalid {
+		if err := m.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", m)
+		}
+	}
+}
+
+func TestIconManifest_Resolve(t *testing.T) {
+	manifest := BuildIconManifest([]IconMapping{
+		{ID: "juniper", Vendor: "Juniper", Icon: "juniper"},
+		{ID: "switch", DeviceType: "switch", Icon: "switch"},
+		{ID: "switch-cisco", DeviceType: "switch", Vendor: "cisco", Icon: "switch-cisco"},
+	})
+
+	tests := []struct {
+		deviceType, hardware, expected string
+	}{
+		{"switch", "Cisco Catalyst 9300", "switch-cisco"},
+		{"switch", "Juniper QFX5120", "switch"},
+		{"router", "Juniper MX204", "juniper"},
+		{"server", "Dell R650", DefaultIcon},
+	}
+	for _, tt := range tests {
+		if got := manifest.Resolve(tt.deviceType, tt.hardware); got != tt.expected {
+			t.Errorf("Expected %s/%s to resolve to %s, got %s", tt.deviceType, tt.hardware, tt.expected, got)
+		}
+	}
+}
+
+func TestBuildIconManifest_Version(t *testing.T) {
+	a := IconMapping{ID: "a", DeviceType: "switch", Icon: "switch"}
+	b := IconMapping{ID: "b", DeviceType: "router", Icon: "router"}
+
+	first := BuildIconManifest([]IconMapping{a, b})
+	second := BuildIconManifest([]IconMapping{b, a})
+	if first.Version != second.Version {
+		t.Error("Expected version to be independent of mapping order")
+	}
+	if first.Mappings[0].ID != "a" {
+		t.Errorf("Expected mappings sorted by ID, got %s first", first.Mappings[0].ID)
+	}
+
+	b.UpdatedBy = "alice"
+	if BuildIconManifest([]IconMapping{a, b}).Version != first.Version {
+		t.Error("Expected version to ignore updated_by")
+	}
+
+	b.SVG = "<svg></svg>"
+	if BuildIconManifest([]IconMapping{a, b}).Version == first.Version {
+		t.Error("Expected version to change with the embedded SVG")
+	}
+}
+
+func TestIconManifest_Sprite(t *testing.T) {
+	manifest := BuildIconManifest([]IconMapping{
+		{ID: "a", DeviceType: "switch", Icon: "switch", SVG: "<svg>a</svg>"},
+		{ID: "b", Vendor: "Arista", Icon: "switch", SVG: "<svg>b</svg>"},
+		{ID: "c", DeviceType: "router", Icon: "router"},
+	})
+
+	sprite := manifest.Sprite()
+	if strings.Count(sprite, "<symbol") != 1 {
+		t.Errorf("Expected one symbol per icon with an SVG, got %s", sprite)
+	}
+	if !strings.Contains(sprite, `<symbol id="switch"><svg>a</svg></symbol>`) {
+		t.Errorf("Expected the SVG of the first mapping, got %s", sprite)
+	}
+
+	if stripped := manifest.WithoutSVG(); stripped.Mappings[0].SVG != "" || manifest.Mappings[0].SVG == "" {
+		t.Error("Expected WithoutSVG to strip a copy only")
+	}
+}
@@ -0,0 +1,70 @@
+package visualization
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LayerBandPadding is the vertical margin added above and below the nodes of a band
+const LayerBandPadding = 50.0
+
+// LayerBand is a horizontal swimlane behind the nodes of one hierarchy layer
+type LayerBand struct {
+	Layer int     `json:"layer"`
+	Label string  `json:"label"`
+	Color string  `json:"color,omitempty"`
+	YMin  float64 `json:"y_min"`
+	YMax  float64 `json:"y_max"`
+	Nodes int     `json:"nodes"`
+}
+
+// LayerStyle is the label and color of a hierarchy layer
+type LayerStyle struct {
+	Name  string
+	Color string
+}
+
+// NewLayerBands returns one band per layer of the positioned nodes, ordered top to bottom.
+// Group nodes may contain devices of several layers and are not assigned to a band.
+// Layers missing from styles are labeled "Layer N".
+func NewLayerBands(nodes []VisualNode, positions map[string]Position, styles map[int]LayerStyle) []LayerBand {
+	bands := make(map[int]*LayerBand)
+	for _, node := range nodes {
+		if node.Type == "group" {
+			continue
+		}
+		position, ok := positions[node.ID]
+		if !ok {
+			continue
+		}
+
+		band, ok := bands[node.Layer]
+		if !ok {
+			band = &LayerBand{Layer: node.Layer, YMin: position.Y, YMax: position.Y}
+			if style, ok := styles[node.Layer]; ok && style.Name != "" {
+				band.Label = style.Name
+				band.Color = style.Color
+			} else {
+				band.Label = fmt.Sprintf("Layer %d", node.Layer)
+			}
+			bands[node.Layer] = band
+		}
+		band.YMin = min(band.YMin, position.Y)
+		band.YMax = max(band.YMax, position.Y)
+		band.Nodes++
+	}
+
+	result := make([]LayerBand, 0, len(bands))
+	for _, band := range bands {
+		band.YMin -= LayerBandPadding
+		band.YMax += LayerBandPadding
+		result = append(result, *band)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].YMin != result[j].YMin {
+			return result[i].YMin < result[j].YMin
+		}
+		return result[i].Layer < result[j].Layer
+	})
+	return result
+}
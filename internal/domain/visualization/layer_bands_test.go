@@ -0,0 +1,44 @@
+package visualization
+
+import "testing"
+
+func TestNewLayerBands(t *testing.T) {
+	nodes := []VisualNode{
+		{ID: "spine-01", Layer: 30},
+		{ID: "spine-02", Layer: 30},
+		{ID: "leaf-01", Layer: 40},
+		{ID: "srv-01", Layer: 50},
+		{ID: "group_prefix_srv", Type: "group"},
+		{ID: "unplaced", Layer: 60},
+	}
+	positions := map[string]Position{
+		"spine-01":         {X: -100, Y: 150},
+		"spine-02":         {X: 100, Y: 150},
+		"leaf-01":          {X: 0, Y: 300},
+		"srv-01":           {X: 0, Y: 450},
+		"group_prefix_srv": {X: 0, Y: 0},
+	}
+	styles := map[int]LayerStyle{
+		30: {Name: "Spine", Color: "#45b7d1"},
+		40: {Name: "Leaf", Color: "#4ecdc4"},
+	}
+
+	bands := NewLayerBands(nodes, positions, styles)
+
+	if len(bands) != 3 {
+		t.Fatalf("Expected 3 bands, got %d: %+v", len(bands), bands)
+	}
+	spine := bands[0]
+	if spine.Layer != 30 || spine.Label != "Spine" || spine.Color != "#45b7d1" || spine.Nodes != 2 {
+		t.Errorf("Unexpected spine band: %+v", spine)
+	}
+	if spine.YMin != 150-LayerBandPadding || spine.YMax != 150+LayerBandPadding {
+		t.Errorf("Expected spine band around y=150, got %v..%v", spine.YMin, spine.YMax)
+	}
+	if bands[1].Layer != 40 {
+		t.Errorf("Expected leaf band second, got layer %d", bands[1].Layer)
+	}
+	if server := bands[2]; server.Label != "Layer 50" || server.Color != "" {
+		t.Errorf("Expected fallback label for a layer without style, got %+v", server)
+	}
+}
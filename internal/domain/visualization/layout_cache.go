@@ -0,0 +1,46 @@
+package visualization
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ViewKey identifies a visualization request whose layout can be cached
+type ViewKey struct {
+	RootDevice string `json:"root_device"`
+	Depth      int    `json:"depth"`
+	Options    string `json:"options"` // GroupingOptions の JSON
+}
+
+// NewViewKey builds the cache key of a grouped topology request
+func NewViewKey(rootDevice string, depth int, opts GroupingOptions) ViewKey {
+	options, _ := json.Marshal(opts)
+	return ViewKey{
+		RootDevice: rootDevice,
+		Depth:      depth,
+		Options:    string(options),
+	}
+}
+
+// GroupingOptions decodes the grouping options stored in the key
+func (k ViewKey) GroupingOptions() (GroupingOptions, error) {
+	var opts GroupingOptions
+	err := json.Unmarshal([]byte(k.Options), &opts)
+	return opts, err
+}
+
+// ViewPopularity tracks how often a view is requested
+type ViewPopularity struct {
+	ViewKey
+	RequestCount  int64     `json:"request_count"`
+	LastRequested time.Time `json:"last_requested"`
+}
+
+// CachedLayout is a precomputed layout for a view.
+// It is valid only while the nodes, their layers and the edges of the view are unchanged.
+type CachedLayout struct {
+	ViewKey
+	NodeSetHash string    `json:"node_set_hash"` // ノード・階層・エッジのハッシュ
+	Layout      Layout    `json:"layout"`
+	ComputedAt  time.Time `json:"computed_at"`
+}
@@ -0,0 +1,93 @@
+package visualization
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Mermaid flowchart directions accepted by RenderMermaid
+var mermaidDirections = map[string]bool{"TB": true, "TD": true, "BT": true, "LR": true, "RL": true}
+
+// RenderMermaid renders the topology as a Mermaid flowchart that can be pasted into Markdown.
+// Nodes and edges are sorted so the output stays stable between exports; group nodes are
+// drawn as subroutine boxes with their member count and the root device is highlighted.
+func RenderMermaid(t *VisualTopology, direction string) (string, error) {
+	direction = strings.ToUpper(direction)
+	if direction == "" {
+		direction = "TB"
+	}
+	if !mermaidDirections[direction] {
+		return "", fmt.Errorf("unsupported mermaid direction '%s' (expected TB, BT, LR or RL)", direction)
+	}
+
+	nodes := append([]VisualNode(nil), t.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	groupSizes := make(map[string]int, len(t.Groups))
+	for _, group := range t.Groups {
+		groupSizes[group.ID] = group.Count
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "flowchart %s\n", direction)
+
+	// デバイスIDは Mermaid の識別子に使えない文字を含むため連番に置き換える
+	ids := make(map[string]string, len(nodes))
+	var roots []string
+	for i, node := range nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.ID] = id
+
+		name := node.Name
+		if name == "" {
+			name = node.ID
+		}
+		if node.Type == "group" {
+			if count, ok := groupSizes[node.ID]; ok {
+				name = fmt.Sprintf("%s (%d devices)", name, count)
+			}
+			fmt.Fprintf(&b, "    %s[[\"%s\"]]\n", id, mermaidText(name))
+			continue
+		}
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", id, mermaidText(name))
+		if node.IsRoot {
+			roots = append(roots, id)
+		}
+	}
+
+	edges := append([]VisualEdge(nil), t.Edges...)
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		if edges[i].Target != edges[j].Target {
+			return edges[i].Target < edges[j].Target
+		}
+		return edges[i].ID < edges[j].ID
+	})
+	for _, edge := range edges {
+		source, sourceOK := ids[edge.Source]
+		target, targetOK := ids[edge.Target]
+		if !sourceOK || !targetOK {
+			continue
+		}
+		if edge.Label != "" {
+			fmt.Fprintf(&b, "    %s ---|\"%s\"| %s\n", source, mermaidText(edge.Label), target)
+			continue
+		}
+		fmt.Fprintf(&b, "    %s --- %s\n", source, target)
+	}
+
+	if len(roots) > 0 {
+		b.WriteString("    classDef root stroke-width:3px\n")
+		fmt.Fprintf(&b, "    class %s root\n", strings.Join(roots, ","))
+	}
+
+	return b.String(), nil
+}
+
+// mermaidText escapes characters that end a quoted Mermaid label
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
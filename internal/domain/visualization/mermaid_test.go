@@ -0,0 +1,47 @@
+package visualization
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMermaid(t *testing.T) {
+	topology := &VisualTopology{
+		Nodes: []VisualNode{
+			{ID: "dist-01", Name: "dist-01", Type: "switch"},
+			{ID: "core-01", Name: `Core "A"`, Type: "router", IsRoot: true},
+			{ID: "group_prefix_access", Name: "access-*", Type: "group"},
+		},
+		Edges: []VisualEdge{
+			{ID: "l2", Source: "dist-01", Target: "group_prefix_access"},
+			{ID: "l1", Source: "core-01", Target: "dist-01", Label: "100G"},
+			{ID: "l3", Source: "dist-01", Target: "missing"},
+		},
+		Groups: []GroupedVisualNode{{ID: "group_prefix_access", Count: 4}},
+	}
+
+	out, err := RenderMermaid(topology, "lr")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"flowchart LR",
+		`    n0["Core #quot;A#quot;"]`,
+		`    n1["dist-01"]`,
+		`    n2[["access-* (4 devices)"]]`,
+		`    n0 ---|"100G"| n1`,
+		`    n1 --- n2`,
+		"    classDef root stroke-width:3px",
+		"    class n0 root",
+	}, "\n") + "\n"
+	if out != expected {
+		t.Errorf("Unexpected mermaid output:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestRenderMermaid_InvalidDirection(t *testing.T) {
+	if _, err := RenderMermaid(&VisualTopology{}, "up"); err == nil {
+		t.Error("Expected error for unsupported direction")
+	}
+}
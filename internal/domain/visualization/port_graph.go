@@ -0,0 +1,7 @@
+package visualization
+
+// PortNodeID returns the node ID of a device interface in the port graph.
+// Interface names contain slashes (e.g. Ethernet1/1), so "|" separates the device ID.
+func PortNodeID(deviceID, port string) string {
+	return deviceID + "|" + port
+}
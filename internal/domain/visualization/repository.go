@@ -0,0 +1,40 @@
+package visualization
+
+import (
+	"context"
+	"time"
+)
+
+// LayoutCacheRepository is implemented by repositories that store precomputed layouts
+type LayoutCacheRepository interface {
+	// RecordViewRequests adds count requests of a view, the last one made at
+	RecordViewRequests(ctx context.Context, key ViewKey, count int, at time.Time) error
+	// ListPopularViews returns views requested since the given time, most requested first
+	ListPopularViews(ctx context.Context, since time.Time, limit int) ([]ViewPopularity, error)
+	GetCachedLayout(ctx context.Context, key ViewKey) (*CachedLayout, error)
+	SaveCachedLayout(ctx context.Context, layout CachedLayout) error
+}
+
+// StartingViewRepository is implemented by repositories that store per-role starting views
+type StartingViewRepository interface {
+	ListStartingViews(ctx context.Context) ([]StartingView, error)
+	GetStartingView(ctx context.Context, role string) (*StartingView, error)
+	SaveStartingView(ctx context.Context, view StartingView) error
+	DeleteStartingView(ctx context.Context, role string) error
+}
+
+// IconRepository is implemented by repositories that store device icon mappings
+type IconRepository interface {
+	ListIconMappings(ctx context.Context) ([]IconMapping, error)
+	SaveIconMapping(ctx context.Context, mapping IconMapping) error
+	// DeleteIconMapping returns false when the mapping does not exist
+	DeleteIconMapping(ctx context.Context, id string) (bool, error)
+}
+
+// StyleRuleRepository is implemented by repositories that store visualization style rules
+type StyleRuleRepository interface {
+	ListStyleRules(ctx context.Context) ([]StyleRule, error)
+	SaveStyleRule(ctx context.Context, rule StyleRule) error
+	// DeleteStyleRule returns false when the rule does not exist
+	DeleteStyleRule(ctx context.Context, id string) (bool, error)
+}
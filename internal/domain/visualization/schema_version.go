@@ -0,0 +1,139 @@
+package visualization
+
+import "time"
+
+// Visual topology payload schema versions. Version 1 is the payload before workflow states,
+// edge labels, bundles, layer bands, layout patches and degree statistics were added; version 2
+// is the current payload. Consumers that cache payloads pin a version with schema_version.
+const (
+	SchemaVersion1       = 1
+	SchemaVersion2       = 2
+	CurrentSchemaVersion = SchemaVersion2
+)
+
+// VisualTopologyV1 is a visual topology in schema version 1
+type VisualTopologyV1 struct {
+	SchemaVersion int                 `json:"schema_version"`
+	RootDevice    string              `json:"root_device"`
+	Depth         int                 `json:"depth"`
+	Timestamp     int64               `json:"timestamp"`
+	Nodes         []VisualNodeV1      `json:"nodes"`
+	Edges         []VisualEdgeV1      `json:"edges"`
+	Groups        []GroupedVisualNode `json:"groups,omitempty"`
+	Layout        LayoutV1            `json:"layout"`
+	Stats         TopologyStatsV1     `json:"stats"`
+}
+
+// VisualNodeV1 is a node in schema version 1
+type VisualNodeV1 struct {
+	ID          string                    `json:"id"`
+	Name        string                    `json:"name"`
+	Type        string                    `json:"type"`
+	Hardware    string                    `json:"hardware"`
+	Status      string                    `json:"status"`
+	Layer       int                       `json:"layer"`
+	IsRoot      bool                      `json:"is_root"`
+	Position    Position                  `json:"position"`
+	Style       NodeStyleV1               `json:"style"`
+	Connections *ConnectionClassification `json:"connections,omitempty"`
+}
+
+// NodeStyleV1 is a node style in schema version 1
+type NodeStyleV1 struct {
+	Color       string  `json:"color"`
+	Shape       string  `json:"shape"`
+	Size        float64 `json:"size"`
+	BorderColor string  `json:"border_color"`
+	BorderWidth float64 `json:"border_width"`
+}
+
+// VisualEdgeV1 is an edge in schema version 1
+type VisualEdgeV1 struct {
+	ID             string    `json:"id"`
+	Source         string    `json:"source"`
+	Target         string    `json:"target"`
+	LocalPort      string    `json:"local_port"`
+	RemotePort     string    `json:"remote_port"`
+	Status         string    `json:"status"`
+	Weight         float64   `json:"weight"`
+	Style          EdgeStyle `json:"style"`
+	ConnectionType string    `json:"connection_type"`
+}
+
+// LayoutV1 is a layout in schema version 1
+type LayoutV1 struct {
+	Type      string                 `json:"type"`
+	Options   map[string]interface{} `json:"options"`
+	Positions map[string]Position    `json:"positions"`
+}
+
+// TopologyStatsV1 are the statistics in schema version 1
+type TopologyStatsV1 struct {
+	TotalNodes  int            `json:"total_nodes"`
+	TotalEdges  int            `json:"total_edges"`
+	TotalGroups int            `json:"total_groups"`
+	Layers      map[string]int `json:"layers"`
+	Generated   time.Time      `json:"generated"`
+}
+
+// V1 returns the topology in schema version 1. Fields added in later versions are dropped.
+func (t *VisualTopology) V1() *VisualTopologyV1 {
+	nodes := make([]VisualNodeV1, 0, len(t.Nodes))
+	for _, node := range t.Nodes {
+		nodes = append(nodes, VisualNodeV1{
+			ID:       node.ID,
+			Name:     node.Name,
+			Type:     node.Type,
+			Hardware: node.Hardware,
+			Status:   node.Status,
+			Layer:    node.Layer,
+			IsRoot:   node.IsRoot,
+			Position: node.Position,
+			Style: NodeStyleV1{
+				Color:       node.Style.Color,
+				Shape:       node.Style.Shape,
+				Size:        node.Style.Size,
+				BorderColor: node.Style.BorderColor,
+				BorderWidth: node.Style.BorderWidth,
+			},
+			Connections: node.Connections,
+		})
+	}
+
+	edges := make([]VisualEdgeV1, 0, len(t.Edges))
+	for _, edge := range t.Edges {
+		edges = append(edges, VisualEdgeV1{
+			ID:             edge.ID,
+			Source:         edge.Source,
+			Target:         edge.Target,
+			LocalPort:      edge.LocalPort,
+			RemotePort:     edge.RemotePort,
+			Status:         edge.Status,
+			Weight:         edge.Weight,
+			Style:          edge.Style,
+			ConnectionType: edge.ConnectionType,
+		})
+	}
+
+	return &VisualTopologyV1{
+		SchemaVersion: SchemaVersion1,
+		RootDevice:    t.RootDevice,
+		Depth:         t.Depth,
+		Timestamp:     t.Timestamp,
+		Nodes:         nodes,
+		Edges:         edges,
+		Groups:        t.Groups,
+		Layout: LayoutV1{
+			Type:      t.Layout.Type,
+			Options:   t.Layout.Options,
+			Positions: t.Layout.Positions,
+		},
+		Stats: TopologyStatsV1{
+			TotalNodes:  t.Stats.TotalNodes,
+			TotalEdges:  t.Stats.TotalEdges,
+			TotalGroups: t.Stats.TotalGroups,
+			Layers:      t.Stats.Layers,
+			Generated:   t.Stats.Generated,
+		},
+	}
+}
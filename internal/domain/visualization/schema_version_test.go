@@ -0,0 +1,45 @@
+package visualization
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestVisualTopologyV1(t *testing.T) {
+	topology := &VisualTopology{
+		SchemaVersion: CurrentSchemaVersion,
+		RootDevice:    "spine-01",
+		Depth:         2,
+		Nodes: []VisualNode{
+			{ID: "spine-01", Layer: 30, IsRoot: true, WorkflowState: "active", Style: NodeStyle{Color: "#fff", BorderStyle: "dashed"}},
+		},
+		Edges: []VisualEdge{
+			{ID: "e1", Source: "spine-01", Target: "leaf-01", Label: "100G", Bundle: "bundle-30-40", Asymmetric: true},
+		},
+		Bundles: []EdgeBundle{{ID: "bundle-30-40"}},
+		Layout:  Layout{Type: "hierarchical", Bands: []LayerBand{{}}},
+		Stats:   TopologyStats{TotalNodes: 1, LayerEdges: map[string]int{"30-40": 1}},
+	}
+
+	v1 := topology.V1()
+	if v1.SchemaVersion != SchemaVersion1 || v1.RootDevice != "spine-01" || v1.Depth != 2 {
+		t.Errorf("Unexpected v1 topology: %+v", v1)
+	}
+	if len(v1.Nodes) != 1 || !v1.Nodes[0].IsRoot || v1.Nodes[0].Style.Color != "#fff" {
+		t.Errorf("Expected the node to be kept, got %+v", v1.Nodes)
+	}
+	if len(v1.Edges) != 1 || v1.Edges[0].Target != "leaf-01" || v1.Stats.TotalNodes != 1 {
+		t.Errorf("Expected the edge and stats to be kept, got %+v %+v", v1.Edges, v1.Stats)
+	}
+
+	data, err := json.Marshal(v1)
+	if err != nil {
+		t.Fatalf("Failed to marshal v1 topology: %v", err)
+	}
+	for _, field := range []string{"workflow_state", "border_style", "label", "bundle", "asymmetric", "bundles", "bands", "layer_edges", "degree", "layout_patch"} {
+		if strings.Contains(string(data), `"`+field+`"`) {
+			t.Errorf("Expected %s to be dropped in schema version 1, got %s", field, data)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package visualization
+
+import (
+	"errors"
+	"time"
+
+	"github.com/servak/topology-manager/pkg/signedtoken"
+)
+
+const (
+	// DefaultShareTTL is how long a share link stays valid unless requested otherwise
+	DefaultShareTTL = 24 * time.Hour
+	// MaxShareTTL is the longest a share link may stay valid
+	MaxShareTTL = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidShareToken is returned for malformed or tampered share tokens
+	ErrInvalidShareToken = errors.New("invalid share token")
+	// ErrShareTokenExpired is returned for share tokens past their expiry
+	ErrShareTokenExpired = errors.New("share token expired")
+)
+
+// ShareClaims is the content of a share token. The view is copied into the token when the
+// link is created, so later changes to the starting view do not change what the link shows.
+type ShareClaims struct {
+	Role       string           `json:"role"`
+	RootDevice string           `json:"root_device"`
+	Depth      int              `json:"depth"`
+	Grouping   *GroupingOptions `json:"grouping,omitempty"`
+	CreatedBy  string           `json:"created_by"`
+	IssuedAt   int64            `json:"iat"`
+	ExpiresAt  int64            `json:"exp"`
+}
+
+// ShareLink is a read-only link to a saved view
+type ShareLink struct {
+	Token     string    `json:"token"`
+	Path      string    `json:"path"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// sharePurpose separates the signing key of share tokens from other tokens signed with the same secret
+const sharePurpose = "share"
+
+// SignShareToken encodes claims into a signed share token
+func SignShareToken(secret []byte, claims ShareClaims) (string, error) {
+	return signedtoken.Sign(secret, sharePurpose, claims)
+}
+
+// VerifyShareToken checks the signature and expiry of a token and returns its claims
+func VerifyShareToken(secret []byte, token string, now time.Time) (*ShareClaims, error) {
+	var claims ShareClaims
+	if err := signedtoken.Verify(secret, sharePurpose, token, &claims); err != nil {
+		return nil, ErrInvalidShareToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrShareTokenExpired
+	}
+
+	return &claims, nil
+}
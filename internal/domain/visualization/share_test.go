@@ -0,0 +1,67 @@
+package visualization
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShareToken_RoundTrip(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1700000000, 0)
+	claims := ShareClaims{
+		Role:       "noc",
+		RootDevice: "core-01",
+		Depth:      3,
+		Grouping:   &GroupingOptions{Enabled: true, MinGroupSize: 3},
+		IssuedAt:   now.Unix(),
+		ExpiresAt:  now.Add(time.Hour).Unix(),
+	}
+
+	token, err := SignShareToken(secret, claims)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got, err := VerifyShareToken(secret, token, now.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.RootDevice != "core-01" || got.Depth != 3 || got.Grouping == nil || !got.Grouping.Enabled {
+		t.Errorf("Unexpected claims: %+v", got)
+	}
+
+	if _, err := VerifyShareToken(secret, token, now.Add(time.Hour)); !errors.Is(err, ErrShareTokenExpired) {
+		t.Errorf("Expected expired token error, got %v", err)
+	}
+}
+
+func TestShareToken_Invalid(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1700000000, 0)
+	token, err := SignShareToken(secret, ShareClaims{RootDevice: "core-01", ExpiresAt: now.Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 別のビューを指すように書き換えたペイロード
+	forged, _ := SignShareToken([]byte("other"), ShareClaims{RootDevice: "core-02", ExpiresAt: now.Add(time.Hour).Unix()})
+	payload, _, _ := strings.Cut(forged, ".")
+	_, signature, _ := strings.Cut(token, ".")
+
+	for _, tc := range []struct {
+		name   string
+		secret []byte
+		token  string
+	}{
+		{"wrong secret", []byte("other"), token},
+		{"tampered payload", secret, payload + "." + signature},
+		{"no signature", secret, payload},
+		{"garbage", secret, "not-a-token"},
+	} {
+		if _, err := VerifyShareToken(tc.secret, tc.token, now); !errors.Is(err, ErrInvalidShareToken) {
+			t.Errorf("%s: expected invalid token error, got %v", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package visualization
+
+import "time"
+
+// DefaultStartingViewRole is used for users whose role has no starting view of its own
+const DefaultStartingViewRole = "default"
+
+// StartingView is the topology a role or team lands on after login
+type StartingView struct {
+	Role        string           `json:"role"`
+	RootDevice  string           `json:"root_device"`
+	Depth       int              `json:"depth"`
+	Grouping    *GroupingOptions `json:"grouping,omitempty"`
+	Description string           `json:"description,omitempty"`
+	UpdatedBy   string           `json:"updated_by"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
@@ -0,0 +1,142 @@
+package visualization
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// groupLayerKey is used instead of a layer number for group nodes, whose members may span layers
+const groupLayerKey = "group"
+
+// GroupSizeStats summarizes the groups of one group type
+type GroupSizeStats struct {
+	Groups  int `json:"groups"`
+	Devices int `json:"devices"` // グループに含まれるデバイスの合計
+	Min     int `json:"min"`
+	Max     int `json:"max"`
+}
+
+// DegreeStats summarizes how many edges touch each node
+type DegreeStats struct {
+	Min  int     `json:"min"`
+	Max  int     `json:"max"`
+	Mean float64 `json:"mean"`
+	P50  int     `json:"p50"`
+	P90  int     `json:"p90"`
+	P99  int     `json:"p99"`
+}
+
+// NewTopologyStats computes the stats of a rendered topology. Layer pairs are keyed "low-high"
+// (e.g. "1-2"); edges touching a group node use "group" for that end.
+func NewTopologyStats(nodes []VisualNode, edges []VisualEdge, groups []GroupedVisualNode, generated time.Time) TopologyStats {
+	layers := make(map[string]int)
+	nodeLayers := make(map[string]string, len(nodes))
+	degrees := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		layers[fmt.Sprintf("%d", node.Layer)]++
+		nodeLayers[node.ID] = nodeLayerKey(node)
+		degrees[node.ID] = 0
+	}
+
+	layerEdges := make(map[string]int)
+	for _, edge := range edges {
+		source, okSource := nodeLayers[edge.Source]
+		target, okTarget := nodeLayers[edge.Target]
+		if !okSource || !okTarget {
+			continue
+		}
+		layerEdges[layerPairKey(source, target)]++
+		degrees[edge.Source]++
+		degrees[edge.Target]++
+	}
+
+	groupSizes := make(map[string]GroupSizeStats)
+	for _, group := range groups {
+		stats := groupSizes[group.GroupType]
+		if stats.Groups == 0 || group.Count < stats.Min {
+			stats.Min = group.Count
+		}
+		if group.Count > stats.Max {
+			stats.Max = group.Count
+		}
+		stats.Groups++
+		stats.Devices += group.Count
+		groupSizes[group.GroupType] = stats
+	}
+
+	values := make([]int, 0, len(degrees))
+	for _, degree := range degrees {
+		values = append(values, degree)
+	}
+
+	return TopologyStats{
+		TotalNodes:  len(nodes),
+		TotalEdges:  len(edges),
+		TotalGroups: len(groups),
+		Layers:      layers,
+		LayerEdges:  layerEdges,
+		GroupSizes:  groupSizes,
+		Degree:      NewDegreeStats(values),
+		Generated:   generated,
+	}
+}
+
+// NewDegreeStats computes degree statistics with nearest-rank percentiles
+func NewDegreeStats(degrees []int) DegreeStats {
+	if len(degrees) == 0 {
+		return DegreeStats{}
+	}
+
+	sorted := append([]int(nil), degrees...)
+	sort.Ints(sorted)
+
+	total := 0
+	for _, degree := range sorted {
+		total += degree
+	}
+
+	return DegreeStats{
+		Min:  sorted[0],
+		Max:  sorted[len(sorted)-1],
+		Mean: math.Round(float64(total)/float64(len(sorted))*100) / 100,
+		P50:  percentile(sorted, 50),
+		P90:  percentile(sorted, 90),
+		P99:  percentile(sorted, 99),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted values
+func percentile(sorted []int, p int) int {
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func nodeLayerKey(node VisualNode) string {
+	if node.Type == "group" {
+		return groupLayerKey
+	}
+	return fmt.Sprintf("%d", node.Layer)
+}
+
+// layerPairKey orders the two layer keys so each pair is counted once; group sorts last
+func layerPairKey(a, b string) string {
+	if layerKeyLess(b, a) {
+		a, b = b, a
+	}
+	return a + "-" + b
+}
+
+func layerKeyLess(a, b string) bool {
+	if a == groupLayerKey || b == groupLayerKey {
+		return b == groupLayerKey && a != groupLayerKey
+	}
+	var x, y int
+	fmt.Sscanf(a, "%d", &x)
+	fmt.Sscanf(b, "%d", &y)
+	return x < y
+}
@@ -0,0 +1,72 @@
+package visualization
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTopologyStats(t *testing.T) {
+	nodes := []VisualNode{
+		{ID: "core-01", Layer: 1},
+		{ID: "dist-01", Layer: 2},
+		{ID: "dist-02", Layer: 2},
+		{ID: "access-01", Layer: 10},
+		{ID: "group_prefix_srv", Type: "group"},
+	}
+	edges := []VisualEdge{
+		{ID: "l1", Source: "dist-01", Target: "core-01"},
+		{ID: "l2", Source: "core-01", Target: "dist-02"},
+		{ID: "l3", Source: "access-01", Target: "dist-01"},
+		{ID: "l4", Source: "group_prefix_srv", Target: "access-01"},
+		{ID: "l5", Source: "dist-01", Target: "dist-02"},
+		{ID: "l6", Source: "dist-01", Target: "missing"},
+	}
+	groups := []GroupedVisualNode{
+		{ID: "group_prefix_srv", GroupType: "prefix", Count: 12},
+		{ID: "group_prefix_web", GroupType: "prefix", Count: 3},
+		{ID: "group_depth_3", GroupType: "depth", Count: 5},
+	}
+
+	stats := NewTopologyStats(nodes, edges, groups, time.Unix(0, 0))
+
+	if stats.TotalNodes != 5 || stats.TotalEdges != 6 || stats.TotalGroups != 3 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	expectedEdges := map[string]int{"1-2": 2, "2-10": 1, "10-group": 1, "2-2": 1}
+	if len(stats.LayerEdges) != len(expectedEdges) {
+		t.Errorf("Expected layer edges %v, got %v", expectedEdges, stats.LayerEdges)
+	}
+	for key, count := range expectedEdges {
+		if stats.LayerEdges[key] != count {
+			t.Errorf("Expected %d edges for %s, got %d", count, key, stats.LayerEdges[key])
+		}
+	}
+
+	prefix := stats.GroupSizes["prefix"]
+	if prefix.Groups != 2 || prefix.Devices != 15 || prefix.Min != 3 || prefix.Max != 12 {
+		t.Errorf("Unexpected prefix group sizes: %+v", prefix)
+	}
+	if depth := stats.GroupSizes["depth"]; depth.Groups != 1 || depth.Min != 5 || depth.Max != 5 {
+		t.Errorf("Unexpected depth group sizes: %+v", depth)
+	}
+
+	// 次数: core-01=2, dist-01=3, dist-02=2, access-01=2, group=1（存在しないノードへのエッジは除外）
+	if stats.Degree.Min != 1 || stats.Degree.Max != 3 || stats.Degree.Mean != 2 || stats.Degree.P50 != 2 || stats.Degree.P90 != 3 {
+		t.Errorf("Unexpected degree stats: %+v", stats.Degree)
+	}
+}
+
+func TestNewDegreeStats(t *testing.T) {
+	if stats := NewDegreeStats(nil); stats != (DegreeStats{}) {
+		t.Errorf("Expected zero stats for no nodes, got %+v", stats)
+	}
+
+	degrees := make([]int, 100)
+	for i := range degrees {
+		degrees[i] = 100 - i
+	}
+	stats := NewDegreeStats(degrees)
+	if stats.P50 != 50 || stats.P90 != 90 || stats.P99 != 99 || stats.Min != 1 || stats.Max != 100 || stats.Mean != 50.5 {
+		t.Errorf("Unexpected degree stats: %+v", stats)
+	}
+}
@@ -0,0 +1,304 @@
+package visualization
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Elements a style rule applies to
+const (
+	StyleTargetNode = "node"
+	StyleTargetEdge = "edge"
+)
+
+// nodeStyleFields and edgeStyleFields are the attributes style conditions can match besides metadata.<key>
+var (
+	nodeStyleFields = []string{"name", "type", "hardware", "layer", "workflow_state"}
+	edgeStyleFields = []string{"name", "source", "target", "local_port", "remote_port", "connection_type"}
+)
+
+// styleLineStyles are the accepted border and line styles
+var styleLineStyles = []string{"solid", "dashed", "dotted"}
+
+// StyleCondition selects nodes or edges by an attribute. Operators follow classification rule conditions.
+type StyleCondition struct {
+	Field    string `json:"field" example:"metadata.env" doc:"Node: name (the device ID), type, hardware, layer, workflow_state or metadata.<key>. Edge: name (the link ID), source, target, local_port, remote_port, connection_type or metadata.<key>"`
+	Operator string `json:"operator" enum:"contains,starts_with,ends_with,equals,regex" example:"equals" doc:"Comparison; all but regex are case-insensitive"`
+	Value    string `json:"value" example:"staging" doc:"Value to compare with (a Go regular expression for regex)"`
+}
+
+// NodeStyleOverride replaces the set properties of a node style; empty and zero properties are kept
+type NodeStyleOverride struct {
+	Color       string  `json:"color,omitempty" example:"#bdc3c7"`
+	Shape       string  `json:"shape,omitempty"`
+	Size        float64 `json:"size,omitempty" minimum:"0"`
+	BorderColor string  `json:"border_color,omitempty" example:"#7f8c8d"`
+	BorderWidth float64 `json:"border_width,omitempty" minimum:"0"`
+	BorderStyle string  `json:"border_style,omitempty" enum:"solid,dashed,dotted"`
+}
+
+// EdgeStyleOverride replaces the set properties of an edge style; empty and zero properties are kept
+type EdgeStyleOverride struct {
+	Color     string  `json:"color,omitempty"`
+	Width     float64 `json:"width,omitempty" minimum:"0"`
+	LineStyle string  `json:"line_style,omitempty" enum:"solid,dashed,dotted"`
+}
+
+// StyleRule overrides the style of the nodes or edges matching its conditions, e.g. a dashed
+// gray border for devices with metadata.env=staging
+type StyleRule struct {
+	ID            string             `json:"id"`
+	Description   string             `json:"description,omitempty"`
+	Target        string             `json:"target" enum:"node,edge"`
+	LogicOperator string             `json:"logic"` // "AND"（既定）または "OR"
+	Conditions    []StyleCondition   `json:"conditions"`
+	Priority      int                `json:"priority"` // 高いほど後に適用され、同じ項目を上書きする
+	NodeStyle     *NodeStyleOverride `json:"node_style,omitempty"`
+	EdgeStyle     *EdgeStyleOverride `json:"edge_style,omitempty"`
+	UpdatedBy     string             `json:"updated_by"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// Validate checks the conditions and the style of the rule fit its target
+func (r StyleRule) Validate() error {
+	if !iconIDPattern.MatchString(r.ID) {
+		return fmt.Errorf("id must consist of lowercase letters, digits, '-' and '_'")
+	}
+	if r.LogicOperator != "" && r.LogicOperator != "AND" && r.LogicOperator != "OR" {
+		return fmt.Errorf("logic must be AND or OR")
+	}
+	if len(r.Conditions) == 0 {
+		return fmt.Errorf("at least one condition is required")
+	}
+
+	var fields []string
+	switch r.Target {
+	case StyleTargetNode:
+		if r.NodeStyle == nil || *r.NodeStyle == (NodeStyleOverride{}) || r.EdgeStyle != nil {
+			return fmt.Errorf("node rules require node_style only")
+		}
+		if r.NodeStyle.Size < 0 || r.NodeStyle.BorderWidth < 0 {
+			return fmt.Errorf("size and border_width must not be negative")
+		}
+		if r.NodeStyle.BorderStyle != "" && !containsStyle(styleLineStyles, r.NodeStyle.BorderStyle) {
+			return fmt.Errorf("border_style must be one of %s", strings.Join(styleLineStyles, ", "))
+		}
+		fields = nodeStyleFields
+	case StyleTargetEdge:
+		if r.EdgeStyle == nil || *r.EdgeStyle == (EdgeStyleOverride{}) || r.NodeStyle != nil {
+			return fmt.Errorf("edge rules require edge_style only")
+		}
+		if r.EdgeStyle.Width < 0 {
+			return fmt.Errorf("width must not be negative")
+		}
+		if r.EdgeStyle.LineStyle != "" && !containsStyle(styleLineStyles, r.EdgeStyle.LineStyle) {
+			return fmt.Errorf("line_style must be one of %s", strings.Join(styleLineStyles, ", "))
+		}
+		fields = edgeStyleFields
+	default:
+		return fmt.Errorf("target must be node or edge")
+	}
+
+	for i, condition := range r.Conditions {
+		if !containsStyle(fields, condition.Field) &&
+			!(strings.HasPrefix(condition.Field, "metadata.") && len(condition.Field) > len("metadata.")) {
+			return fmt.Errorf("condition %d: unknown %s field '%s' (expected %s or metadata.<key>)",
+				i+1, r.Target, condition.Field, strings.Join(fields, ", "))
+		}
+		switch condition.Operator {
+		case "contains", "starts_with", "ends_with", "equals":
+		case "regex":
+			if _, err := regexp.Compile(condition.Value); err != nil {
+				return fmt.Errorf("condition %d: invalid regex: %w", i+1, err)
+			}
+		default:
+			return fmt.Errorf("condition %d: unknown operator '%s'", i+1, condition.Operator)
+		}
+	}
+	return nil
+}
+
+// ApplyStyleRules overrides the styles of the matching nodes and edges in ascending priority,
+// so the rule with the highest priority wins on a property set by several rules, and lists the
+// applied rules in StyleRules. Node metadata conditions need the device metadata in Metadata.
+func ApplyStyleRules(nodes []VisualNode, edges []VisualEdge, rules []StyleRule) {
+	if len(rules) == 0 {
+		return
+	}
+
+	ordered := make([]compiledStyleRule, 0, len(rules))
+	for _, rule := range rules {
+		ordered = append(ordered, compileStyleRule(rule))
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].rule.Priority != ordered[j].rule.Priority {
+			return ordered[i].rule.Priority < ordered[j].rule.Priority
+		}
+		return ordered[i].rule.ID < ordered[j].rule.ID
+	})
+
+	for i := range nodes {
+		node := &nodes[i]
+		for _, compiled := range ordered {
+			if compiled.rule.Target != StyleTargetNode || !compiled.matches(func(field string) (string, bool) { return nodeField(*node, field) }) {
+				continue
+			}
+			compiled.rule.NodeStyle.apply(&node.Style)
+			node.StyleRules = append(node.StyleRules, compiled.rule.ID)
+		}
+	}
+
+	for i := range edges {
+		edge := &edges[i]
+		for _, compiled := range ordered {
+			if compiled.rule.Target != StyleTargetEdge || !compiled.matches(func(field string) (string, bool) { return edgeField(*edge, field) }) {
+				continue
+			}
+			compiled.rule.EdgeStyle.apply(&edge.Style)
+			edge.StyleRules = append(edge.StyleRules, compiled.rule.ID)
+		}
+	}
+}
+
+// compiledStyleRule holds the regular expressions of a rule so they are compiled once per view
+type compiledStyleRule struct {
+	rule    StyleRule
+	regexes map[int]*regexp.Regexp
+}
+
+func compileStyleRule(rule StyleRule) compiledStyleRule {
+	compiled := compiledStyleRule{rule: rule, regexes: make(map[int]*regexp.Regexp)}
+	for i, condition := range rule.Conditions {
+		if condition.Operator == "regex" {
+			// 不正な正規表現は保存時に拒否されるため、ここでは一致しない扱いにする
+			compiled.regexes[i], _ = regexp.Compile(condition.Value)
+		}
+	}
+	return compiled
+}
+
+func (c compiledStyleRule) matches(lookup func(field string) (string, bool)) bool {
+	if len(c.rule.Conditions) == 0 {
+		return false
+	}
+
+	for i, condition := range c.rule.Conditions {
+		value, ok := lookup(condition.Field)
+		matched := ok && c.matchCondition(i, condition, value)
+		if c.rule.LogicOperator == "OR" && matched {
+			return true
+		}
+		if c.rule.LogicOperator != "OR" && !matched {
+			return false
+		}
+	}
+	return c.rule.LogicOperator != "OR"
+}
+
+func (c compiledStyleRule) matchCondition(i int, condition StyleCondition, value string) bool {
+	switch condition.Operator {
+	case "contains":
+		return strings.Contains(strings.ToLower(value), strings.ToLower(condition.Value))
+	case "starts_with":
+		return strings.HasPrefix(strings.ToLower(value), strings.ToLower(condition.Value))
+	case "ends_with":
+		return strings.HasSuffix(strings.ToLower(value), strings.ToLower(condition.Value))
+	case "equals":
+		return strings.EqualFold(value, condition.Value)
+	case "regex":
+		re := c.regexes[i]
+		return re != nil && re.MatchString(value)
+	default:
+		return false
+	}
+}
+
+// nodeField returns the attribute of a node matched by style conditions
+func nodeField(node VisualNode, field string) (string, bool) {
+	switch field {
+	case "name":
+		return node.ID, true
+	case "type":
+		return node.Type, true
+	case "hardware":
+		return node.Hardware, true
+	case "layer":
+		return strconv.Itoa(node.Layer), true
+	case "workflow_state":
+		return node.WorkflowState, true
+	}
+	if key, ok := strings.CutPrefix(field, "metadata."); ok {
+		value, ok := node.Metadata[key]
+		return value, ok
+	}
+	return "", false
+}
+
+// edgeField returns the attribute of an edge matched by style conditions
+func edgeField(edge VisualEdge, field string) (string, bool) {
+	switch field {
+	case "name":
+		return edge.ID, true
+	case "source":
+		return edge.Source, true
+	case "target":
+		return edge.Target, true
+	case "local_port":
+		return edge.LocalPort, true
+	case "remote_port":
+		return edge.RemotePort, true
+	case "connection_type":
+		return edge.ConnectionType, true
+	}
+	if key, ok := strings.CutPrefix(field, "metadata."); ok {
+		value, ok := edge.Metadata[key]
+		return value, ok
+	}
+	return "", false
+}
+
+func (o *NodeStyleOverride) apply(style *NodeStyle) {
+	if o.Color != "" {
+		style.Color = o.Color
+	}
+	if o.Shape != "" {
+		style.Shape = o.Shape
+	}
+	if o.Size > 0 {
+		style.Size = o.Size
+	}
+	if o.BorderColor != "" {
+		style.BorderColor = o.BorderColor
+	}
+	if o.BorderWidth > 0 {
+		style.BorderWidth = o.BorderWidth
+	}
+	if o.BorderStyle != "" {
+		style.BorderStyle = o.BorderStyle
+	}
+}
+
+func (o *EdgeStyleOverride) apply(style *EdgeStyle) {
+	if o.Color != "" {
+		style.Color = o.Color
+	}
+	if o.Width > 0 {
+		style.Width = o.Width
+	}
+	if o.LineStyle != "" {
+		style.LineStyle = o.LineStyle
+	}
+}
+
+func containsStyle(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package visualization
+
+import "testing"
+
+func TestStyleRule_Validate(t *testing.T) {
+	valid := StyleRule{
+		ID: "staging", Target: StyleTargetNode,
+		Conditions: []StyleCondition{{Field: "metadata.env", Operator: "equals", Value: "staging"}},
+		NodeStyle:  &NodeStyleOverride{BorderColor: "#95a5a6", BorderStyle: "dashed"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected rule to be valid, got %v", err)
+	}
+
+	condition := []StyleCondition{{Field: "type", Operator: "equals", Value: "switch"}}
+	invalid := []StyleRule{
+		{ID: "Bad ID", Target: StyleTargetNode, Conditions: condition, NodeStyle: &NodeStyleOverride{Color: "#fff"}},
+		{ID: "r", Target: "group", Conditions: condition, NodeStyle: &NodeStyleOverride{Color: "#fff"}},
+		{ID: "r", Target: StyleTargetNode, NodeStyle: &NodeStyleOverride{Color: "#fff"}},
+		{ID: "r", Target: StyleTargetNode, Conditions: condition},
+		{ID: "r", Target: StyleTargetNode, Conditions: condition, NodeStyle: &NodeStyleOverride{}},
+		{ID: "r", Target: StyleTargetNode, Conditions: condition, EdgeStyle: &EdgeStyleOverride{Color: "#fff"}},
+		{ID: "r", Target: StyleTargetNode, Conditions: condition, NodeStyle: &NodeStyleOverride{BorderStyle: "wavy"}},
+		// ノードのフィールドはエッジルールでは使えない
+		{ID: "r", Target: StyleTargetEdge, Conditions: condition, EdgeStyle: &EdgeStyleOverride{Width: 3}},
+		{ID: "r", Target: StyleTargetEdge, Conditions: []StyleCondition{{Field: "local_port", Operator: "regex", Value: "("}}, EdgeStyle: &EdgeStyleOverride{Width: 3}},
+	}
+	for _, rule := range invalid {
+		if err := rule.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", rule)
+		}
+	}
+}
+
+func TestApplyStyleRules(t *testing.T) {
+	nodes := []VisualNode{
+		{ID: "leaf-01", Type: "switch", Style: NodeStyle{Color: "#4ecdc4", BorderColor: "#26d0ce", BorderWidth: 2}, Metadata: map[string]string{"env": "Staging"}},
+		{ID: "leaf-02", Type: "switch", Style: NodeStyle{Color: "#4ecdc4", BorderColor: "#26d0ce", BorderWidth: 2}, Metadata: map[string]string{"env": "prod"}},
+	}
+	edges := []VisualEdge{
+		{ID: "l1", Source: "leaf-01", Target: "spine-01", LocalPort: "Ethernet49", Style: EdgeStyle{Color: "#2ecc71", Width: 2, LineStyle: "solid"}},
+		{ID: "l2", Source: "leaf-02", Target: "spine-01", LocalPort: "Ethernet1", Style: EdgeStyle{Color: "#2ecc71", Width: 2, LineStyle: "solid"}, Metadata: map[string]string{"speed": "100G"}},
+	}
+	rules := []StyleRule{
+		{ID: "staging-red", Target: StyleTargetNode, Priority: 10,
+			Conditions: []StyleCondition{{Field: "metadata.env", Operator: "equals", Value: "staging"}},
+			NodeStyle:  &NodeStyleOverride{BorderColor: "#e74c3c"}},
+		{ID: "staging", Target: StyleTargetNode,
+			Conditions: []StyleCondition{{Field: "metadata.env", Operator: "equals", Value: "staging"}},
+			NodeStyle:  &NodeStyleOverride{BorderColor: "#95a5a6", BorderStyle: "dashed"}},
+		{ID: "uplinks", Target: StyleTargetEdge, LogicOperator: "OR",
+			Conditions: []StyleCondition{
+				{Field: "local_port", Operator: "regex", Value: `^Ethernet(49|5[0-6])$`},
+				{Field: "metadata.speed", Operator: "equals", Value: "400G"},
+			},
+			EdgeStyle: &EdgeStyleOverride{Width: 4}},
+	}
+
+	ApplyStyleRules(nodes, edges, rules)
+
+	// 優先度の高いルールが後に適用され、同じ項目を上書きする
+	staging := nodes[0]
+	if staging.Style.BorderColor != "#e74c3c" || staging.Style.BorderStyle != "dashed" || staging.Style.Color != "#4ecdc4" {
+		t.Errorf("Expected overrides merged in priority order, got %+v", staging.Style)
+	}
+	if len(staging.StyleRules) != 2 || staging.StyleRules[0] != "staging" || staging.StyleRules[1] != "staging-red" {
+		t.Errorf("Expected applied rules in order, got %v", staging.StyleRules)
+	}
+	if nodes[1].Style.BorderColor != "#26d0ce" || len(nodes[1].StyleRules) != 0 {
+		t.Errorf("Expected a non-matching node to keep its style, got %+v", nodes[1])
+	}
+
+	if edges[0].Style.Width != 4 || edges[0].Style.Color != "#2ecc71" {
+		t.Errorf("Expected the uplink to be widened, got %+v", edges[0].Style)
+	}
+	if edges[1].Style.Width != 2 {
+		t.Errorf("Expected the other edge to keep its width, got %+v", edges[1].Style)
+	}
+}
@@ -0,0 +1,58 @@
+package visualization
+
+// Edge coloring modes selected with color_by
+const (
+	EdgeColorByStatus      = "status"
+	EdgeColorByUtilization = "utilization"
+)
+
+// Utilization buckets of edges colored by utilization
+const (
+	UtilizationLow     = "low"     // 40% 未満
+	UtilizationMedium  = "medium"  // 80% 未満
+	UtilizationHigh    = "high"    // 80% 以上
+	UtilizationUnknown = "unknown" // 速度かトラフィックが不明
+)
+
+// Bucket boundaries in percent
+const (
+	UtilizationMediumPercent = 40.0
+	UtilizationHighPercent   = 80.0
+)
+
+// UtilizationColors are the edge colors of the utilization buckets
+var UtilizationColors = map[string]string{
+	UtilizationLow:     "#2ecc71",
+	UtilizationMedium:  "#f1c40f",
+	UtilizationHigh:    "#e74c3c",
+	UtilizationUnknown: "#95a5a6",
+}
+
+// UtilizationBucket returns the bucket of a utilization in percent
+func UtilizationBucket(percent float64) string {
+	switch {
+	case percent >= UtilizationHighPercent:
+		return UtilizationHigh
+	case percent >= UtilizationMediumPercent:
+		return UtilizationMedium
+	default:
+		return UtilizationLow
+	}
+}
+
+// ColorEdgesByUtilization colors every edge by the bucket of its utilization in percent, keyed by
+// edge ID. Edges without a utilization are colored as unknown.
+func (t *VisualTopology) ColorEdgesByUtilization(utilization map[string]float64) {
+	for i := range t.Edges {
+		edge := &t.Edges[i]
+		percent, ok := utilization[edge.ID]
+		if !ok {
+			edge.Utilization = nil
+			edge.UtilizationBucket = UtilizationUnknown
+		} else {
+			edge.Utilization = &percent
+			edge.UtilizationBucket = UtilizationBucket(percent)
+		}
+		edge.Style.Color = UtilizationColors[edge.UtilizationBucket]
+	}
+}
@@ -0,0 +1,43 @@
+package visualization
+
+import "testing"
+
+func TestUtilizationBucket(t *testing.T) {
+	tests := []struct {
+		percent float64
+		want    string
+	}{
+		{0, UtilizationLow},
+		{39.9, UtilizationLow},
+		{40, UtilizationMedium},
+		{79.9, UtilizationMedium},
+		{80, UtilizationHigh},
+		{120, UtilizationHigh},
+	}
+	for _, tt := range tests {
+		if got := UtilizationBucket(tt.percent); got != tt.want {
+			t.Errorf("Expected %v%% to be %s, got %s", tt.percent, tt.want, got)
+		}
+	}
+}
+
+func TestColorEdgesByUtilization(t *testing.T) {
+	topology := &VisualTopology{Edges: []VisualEdge{
+		{ID: "l1", Style: EdgeStyle{Color: "#2ecc71"}},
+		{ID: "l2", Style: EdgeStyle{Color: "#2ecc71"}},
+		{ID: "l3", Style: EdgeStyle{Color: "#2ecc71"}},
+	}}
+
+	topology.ColorEdgesByUtilization(map[string]float64{"l1": 12.5, "l2": 85})
+
+	l1, l2, l3 := topology.Edges[0], topology.Edges[1], topology.Edges[2]
+	if l1.Utilization == nil || *l1.Utilization != 12.5 || l1.UtilizationBucket != UtilizationLow || l1.Style.Color != UtilizationColors[UtilizationLow] {
+		t.Errorf("Expected l1 to be low, got %+v", l1)
+	}
+	if l2.UtilizationBucket != UtilizationHigh || l2.Style.Color != "#e74c3c" {
+		t.Errorf("Expected l2 to be high, got %+v", l2)
+	}
+	if l3.Utilization != nil || l3.UtilizationBucket != UtilizationUnknown || l3.Style.Color != UtilizationColors[UtilizationUnknown] {
+		t.Errorf("Expected l3 without traffic to be unknown, got %+v", l3)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build chaos
+
+package faultinject
+
+// Available reports whether the binary was built with -tags chaos
+const Available = true
@@ -0,0 +1,100 @@
+// Package faultinject injects failures and delays into the repository and the Prometheus
+// client of the synchronization worker, to test how the sync pipeline behaves under
+// partial failures. It only takes effect in binaries built with -tags chaos.
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrInjected is returned by operations failed on purpose
+var ErrInjected = errors.New("injected fault")
+
+// Config defines the faults to inject. The zero value injects nothing.
+type Config struct {
+	WriteFailureRate float64       `yaml:"write_failure_rate"` // Share of repository writes that fail (0-1)
+	QueryFailureRate float64       `yaml:"query_failure_rate"` // Share of repository reads and Prometheus queries that fail (0-1)
+	QueryDelay       time.Duration `yaml:"query_delay"`        // Added to every repository read and Prometheus query
+	Seed             int64         `yaml:"seed"`               // Random seed (0 = seeded from the clock)
+}
+
+// Enabled reports whether any fault is configured
+func (c Config) Enabled() bool {
+	return c.WriteFailureRate > 0 || c.QueryFailureRate > 0 || c.QueryDelay > 0
+}
+
+// Validate checks the rates and rejects fault injection in binaries built without the chaos tag
+func (c Config) Validate() error {
+	if c.WriteFailureRate < 0 || c.WriteFailureRate > 1 {
+		return fmt.Errorf("write_failure_rate must be between 0 and 1, got %v", c.WriteFailureRate)
+	}
+	if c.QueryFailureRate < 0 || c.QueryFailureRate > 1 {
+		return fmt.Errorf("query_failure_rate must be between 0 and 1, got %v", c.QueryFailureRate)
+	}
+	if c.QueryDelay < 0 {
+		return fmt.Errorf("query_delay must not be negative")
+	}
+	if c.Enabled() && !Available {
+		return fmt.Errorf("fault injection requires a binary built with -tags chaos")
+	}
+	return nil
+}
+
+// Injector decides which operations fail. It is safe for concurrent use.
+type Injector struct {
+	config Config
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// New creates an injector for config
+func New(config Config) *Injector {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{
+		config: config,
+		rand:   rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Write returns ErrInjected for the configured share of writes
+func (i *Injector) Write(operation string) error {
+	if i.roll(i.config.WriteFailureRate) {
+		return fmt.Errorf("%w: %s", ErrInjected, operation)
+	}
+	return nil
+}
+
+// Query waits for the configured delay and returns ErrInjected for the configured share of queries
+func (i *Injector) Query(ctx context.Context, operation string) error {
+	if i.config.QueryDelay > 0 {
+		timer := time.NewTimer(i.config.QueryDelay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if i.roll(i.config.QueryFailureRate) {
+		return fmt.Errorf("%w: %s", ErrInjected, operation)
+	}
+	return nil
+}
+
+func (i *Injector) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rand.Float64() < rate
+}
@@ -0,0 +1,72 @@
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInjector_Rates(t *testing.T) {
+	never := New(Config{Seed: 1})
+	always := New(Config{WriteFailureRate: 1, QueryFailureRate: 1, Seed: 1})
+
+	for i := 0; i < 100; i++ {
+		if err := never.Write("BulkAddLinks"); err != nil {
+			t.Fatalf("Expected no write failure without faults, got %v", err)
+		}
+		if err := always.Write("BulkAddLinks"); !errors.Is(err, ErrInjected) {
+			t.Fatalf("Expected injected write failure, got %v", err)
+		}
+		if err := always.Query(context.Background(), "GetDevice"); !errors.Is(err, ErrInjected) {
+			t.Fatalf("Expected injected query failure, got %v", err)
+		}
+	}
+
+	half := New(Config{WriteFailureRate: 0.5, Seed: 1})
+	failed := 0
+	for i := 0; i < 1000; i++ {
+		if half.Write("BulkAddDevices") != nil {
+			failed++
+		}
+	}
+	if failed < 400 || failed > 600 {
+		t.Errorf("Expected about half of the writes to fail, got %d/1000", failed)
+	}
+}
+
+func TestInjector_QueryDelayHonorsContext(t *testing.T) {
+	injector := New(Config{QueryDelay: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := injector.Query(ctx, "GetDevices"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the delay to end with the context, got %v", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("Expected zero config to be valid, got %v", err)
+	}
+
+	invalid := []Config{
+		{WriteFailureRate: 1.5},
+		{QueryFailureRate: -0.1},
+		{QueryDelay: -time.Second},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", config)
+		}
+	}
+
+	// 通常のビルドでは障害注入を有効にできない
+	err := Config{WriteFailureRate: 0.1}.Validate()
+	if Available && err != nil {
+		t.Errorf("Expected fault injection to be allowed in chaos builds, got %v", err)
+	}
+	if !Available && err == nil {
+		t.Error("Expected fault injection to be rejected without the chaos build tag")
+	}
+}
@@ -0,0 +1,105 @@
+package faultinject
+
+import (
+	"context"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Repository wraps a topology repository. Failed writes are not applied, so a failed bulk
+// write leaves the repository as a rolled back transaction would. Optional capabilities of
+// the wrapped repository (link history, port reservations, ...) are not exposed.
+type Repository struct {
+	repo     topology.Repository
+	injector *Injector
+}
+
+// WrapRepository returns repo with the faults of the injector
+func (i *Injector) WrapRepository(repo topology.Repository) *Repository {
+	return &Repository{repo: repo, injector: i}
+}
+
+func (r *Repository) GetDevice(ctx context.Context, deviceID string) (*topology.Device, error) {
+	if err := r.injector.Query(ctx, "GetDevice"); err != nil {
+		return nil, err
+	}
+	return r.repo.GetDevice(ctx, deviceID)
+}
+
+func (r *Repository) SearchDevices(ctx context.Context, query string, limit int) ([]topology.Device, error) {
+	if err := r.injector.Query(ctx, "SearchDevices"); err != nil {
+		return nil, err
+	}
+	return r.repo.SearchDevices(ctx, query, limit)
+}
+
+func (r *Repository) GetDevices(ctx context.Context, opts topology.PaginationOptions) ([]topology.Device, *topology.PaginationResult, error) {
+	if err := r.injector.Query(ctx, "GetDevices"); err != nil {
+		return nil, nil, err
+	}
+	return r.repo.GetDevices(ctx, opts)
+}
+
+func (r *Repository) UpdateDevice(ctx context.Context, device topology.Device) error {
+	if err := r.injector.Write("UpdateDevice"); err != nil {
+		return err
+	}
+	return r.repo.UpdateDevice(ctx, device)
+}
+
+func (r *Repository) FindReachableDevices(ctx context.Context, deviceID string, opts topology.ReachabilityOptions) ([]topology.Device, error) {
+	if err := r.injector.Query(ctx, "FindReachableDevices"); err != nil {
+		return nil, err
+	}
+	return r.repo.FindReachableDevices(ctx, deviceID, opts)
+}
+
+func (r *Repository) FindShortestPath(ctx context.Context, fromID, toID string, opts topology.PathOptions) (*topology.Path, error) {
+	if err := r.injector.Query(ctx, "FindShortestPath"); err != nil {
+		return nil, err
+	}
+	return r.repo.FindShortestPath(ctx, fromID, toID, opts)
+}
+
+func (r *Repository) ExtractSubTopology(ctx context.Context, deviceID string, opts topology.SubTopologyOptions) ([]topology.Device, []topology.Link, error) {
+	if err := r.injector.Query(ctx, "ExtractSubTopology"); err != nil {
+		return nil, nil, err
+	}
+	return r.repo.ExtractSubTopology(ctx, deviceID, opts)
+}
+
+func (r *Repository) GetLink(ctx context.Context, linkID string) (*topology.Link, error) {
+	if err := r.injector.Query(ctx, "GetLink"); err != nil {
+		return nil, err
+	}
+	return r.repo.GetLink(ctx, linkID)
+}
+
+func (r *Repository) GetDeviceLinks(ctx context.Context, deviceID string) ([]topology.Link, error) {
+	if err := r.injector.Query(ctx, "GetDeviceLinks"); err != nil {
+		return nil, err
+	}
+	return r.repo.GetDeviceLinks(ctx, deviceID)
+}
+
+func (r *Repository) BulkAddDevices(ctx context.Context, devices []topology.Device) error {
+	if err := r.injector.Write("BulkAddDevices"); err != nil {
+		return err
+	}
+	return r.repo.BulkAddDevices(ctx, devices)
+}
+
+func (r *Repository) BulkAddLinks(ctx context.Context, links []topology.Link) error {
+	if err := r.injector.Write("BulkAddLinks"); err != nil {
+		return err
+	}
+	return r.repo.BulkAddLinks(ctx, links)
+}
+
+func (r *Repository) Close() error {
+	return r.repo.Close()
+}
+
+func (r *Repository) Health(ctx context.Context) error {
+	return r.repo.Health(ctx)
+}
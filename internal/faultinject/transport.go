@@ -0,0 +1,27 @@
+package faultinject
+
+import (
+	"net/http"
+)
+
+// Transport delays and fails HTTP requests, e.g. the queries of the Prometheus client
+type Transport struct {
+	base     http.RoundTripper
+	injector *Injector
+}
+
+// WrapTransport returns base with the query faults of the injector. A nil base uses http.DefaultTransport.
+func (i *Injector) WrapTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, injector: i}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.injector.Query(req.Context(), req.Method+" "+req.URL.Path); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
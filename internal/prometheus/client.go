@@ -3,27 +3,78 @@ package prometheus
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// Query front-end flavors supported by the client
+const (
+	FlavorPrometheus      = "prometheus"
+	FlavorVictoriaMetrics = "victoriametrics"
+	FlavorThanos          = "thanos"
+)
+
 // Client represents a Prometheus client for querying metrics
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	timeout    time.Duration
+	compat     CompatibilityConfig
 }
 
 // Config holds Prometheus client configuration
 type Config struct {
-	URL     string        `yaml:"url"`
-	Timeout time.Duration `yaml:"timeout"`
+	URL           string              `yaml:"url"`
+	Timeout       time.Duration       `yaml:"timeout"`
+	Compatibility CompatibilityConfig `yaml:"compatibility"`
+
+	// Transport replaces the HTTP transport (nil = http.DefaultTransport)
+	Transport http.RoundTripper `yaml:"-"`
+}
+
+// CompatibilityConfig adjusts the client for Prometheus-compatible query front-ends
+// such as VictoriaMetrics and Thanos Query
+type CompatibilityConfig struct {
+	Flavor          string            `yaml:"flavor"`           // prometheus (default), victoriametrics, thanos
+	QueryPath       string            `yaml:"query_path"`       // API prefix, e.g. /select/0/prometheus for vmselect
+	HealthPath      string            `yaml:"health_path"`      // empty = flavor default
+	Headers         map[string]string `yaml:"headers"`          // tenant headers, e.g. THANOS-TENANT or X-Scope-OrgID
+	LookbackDelta   time.Duration     `yaml:"lookback_delta"`   // instant query lookback (0 = server default)
+	Dedup           *bool             `yaml:"dedup"`            // Thanos only
+	PartialResponse *bool             `yaml:"partial_response"` // Thanos only
+	ReplicaLabels   []string          `yaml:"replica_labels"`   // Thanos only
 }
 
+// Validate checks the compatibility settings
+func (c CompatibilityConfig) Validate() error {
+	switch c.Flavor {
+	case "", FlavorPrometheus, FlavorVictoriaMetrics, FlavorThanos:
+	default:
+		return fmt.Errorf("unsupported prometheus flavor: %s", c.Flavor)
+	}
+	if c.QueryPath != "" && !strings.HasPrefix(c.QueryPath, "/") {
+		return fmt.Errorf("query_path must start with '/': %s", c.QueryPath)
+	}
+	if c.LookbackDelta < 0 {
+		return fmt.Errorf("lookback_delta must not be negative")
+	}
+	if c.Flavor != FlavorThanos && (c.Dedup != nil || c.PartialResponse != nil || len(c.ReplicaLabels) > 0) {
+		return fmt.Errorf("dedup, partial_response and replica_labels are only supported by thanos")
+	}
+	return nil
+}
+
+// ErrPartialResponse is returned along with the result of a query that the server could only
+// partly answer, e.g. because a Thanos store or a vmstorage node was unreachable. The result
+// lacks series, so it must not be taken as the complete state of the network.
+var ErrPartialResponse = errors.New("partial response")
+
 // QueryResult represents the result of a Prometheus query
 type QueryResult struct {
 	Status string `json:"status"`
@@ -33,6 +84,10 @@ type QueryResult struct {
 	} `json:"data"`
 	Error     string `json:"error,omitempty"`
 	ErrorType string `json:"errorType,omitempty"`
+
+	// Thanos は部分応答時に warnings を、VictoriaMetrics は isPartial を返す
+	Warnings  []string `json:"warnings,omitempty"`
+	IsPartial bool     `json:"isPartial,omitempty"`
 }
 
 // Result represents a single result from Prometheus
@@ -56,64 +111,87 @@ func NewClient(config Config) *Client {
 		timeout = 30 * time.Second
 	}
 
+	compat := config.Compatibility
+	if compat.Flavor == "" {
+		compat.Flavor = FlavorPrometheus
+	}
+
 	return &Client{
-		baseURL: config.URL,
+		baseURL: strings.TrimSuffix(config.URL, "/"),
 		httpClient: &http.Client{
-			Timeout: timeout,
+			Timeout:   timeout,
+			Transport: config.Transport,
 		},
 		timeout: timeout,
+		compat:  compat,
 	}
 }
 
-// Query executes a PromQL query and returns the results
+// Query executes a PromQL query and returns the results. A partial response is returned with an
+// error wrapping ErrPartialResponse.
 func (c *Client) Query(ctx context.Context, query string, timestamp time.Time) (*QueryResult, error) {
 	params := url.Values{}
 	params.Set("query", query)
 	if !timestamp.IsZero() {
 		params.Set("time", strconv.FormatInt(timestamp.Unix(), 10))
 	}
-
-	url := fmt.Sprintf("%s/api/v1/query?%s", c.baseURL, params.Encode())
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if c.compat.LookbackDelta > 0 {
+		// VictoriaMetrics はインスタントクエリの step をルックバック期間として扱う
+		if c.compat.Flavor == FlavorVictoriaMetrics {
+			params.Set("step", fmt.Sprintf("%.0fs", c.compat.LookbackDelta.Seconds()))
+		} else {
+			params.Set("lookback_delta", fmt.Sprintf("%.0fs", c.compat.LookbackDelta.Seconds()))
+		}
 	}
+	c.setQueryHints(params)
 
-	resp, err := c.httpClient.Do(req)
+	result, err := c.doQuery(ctx, "/api/v1/query", params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("prometheus query failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var result QueryResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if result.Status != "success" {
-		return nil, fmt.Errorf("prometheus query failed: %s (%s)", result.Error, result.ErrorType)
+		return result, fmt.Errorf("prometheus query failed: %w", err)
 	}
 
-	return &result, nil
+	return result, nil
 }
 
-// QueryRange executes a range query and returns the results
+// QueryRange executes a range query and returns the results. A partial response is returned with
+// an error wrapping ErrPartialResponse.
 func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (*QueryResult, error) {
 	params := url.Values{}
 	params.Set("query", query)
 	params.Set("start", strconv.FormatInt(start.Unix(), 10))
 	params.Set("end", strconv.FormatInt(end.Unix(), 10))
 	params.Set("step", fmt.Sprintf("%.0fs", step.Seconds()))
+	c.setQueryHints(params)
 
-	url := fmt.Sprintf("%s/api/v1/query_range?%s", c.baseURL, params.Encode())
+	result, err := c.doQuery(ctx, "/api/v1/query_range", params)
+	if err != nil {
+		return result, fmt.Errorf("prometheus range query failed: %w", err)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	return result, nil
+}
+
+// setQueryHints adds flavor-specific query parameters
+func (c *Client) setQueryHints(params url.Values) {
+	if c.compat.Flavor != FlavorThanos {
+		return
+	}
+	if c.compat.Dedup != nil {
+		params.Set("dedup", strconv.FormatBool(*c.compat.Dedup))
+	}
+	if c.compat.PartialResponse != nil {
+		params.Set("partial_response", strconv.FormatBool(*c.compat.PartialResponse))
+	}
+	for _, label := range c.compat.ReplicaLabels {
+		params.Add("replicaLabels[]", label)
+	}
+}
+
+// doQuery sends a query API request and decodes the response
+func (c *Client) doQuery(ctx context.Context, endpoint string, params url.Values) (*QueryResult, error) {
+	url := fmt.Sprintf("%s%s%s?%s", c.baseURL, c.compat.QueryPath, endpoint, params.Encode())
+
+	req, err := c.newRequest(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -124,23 +202,62 @@ func (c *Client) QueryRange(ctx context.Context, query string, start, end time.T
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("prometheus range query failed with status %d: %s", resp.StatusCode, string(body))
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	var result QueryResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if resp.StatusCode != http.StatusOK {
+		// エラー応答もJSON形式であればメッセージを取り出す（VictoriaMetrics は 422 等を返す）
+		if json.Unmarshal(body, &result) == nil && result.Error != "" {
+			return nil, fmt.Errorf("status %d: %s (%s)", resp.StatusCode, result.Error, result.ErrorType)
+		}
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	if result.Status != "success" {
-		return nil, fmt.Errorf("prometheus range query failed: %s (%s)", result.Error, result.ErrorType)
+		return nil, fmt.Errorf("%s (%s)", result.Error, result.ErrorType)
+	}
+
+	if result.IsPartial || len(result.Warnings) > 0 {
+		reason := strings.Join(result.Warnings, "; ")
+		if reason == "" {
+			reason = "isPartial"
+		}
+		return &result, fmt.Errorf("%w: %s", ErrPartialResponse, reason)
 	}
 
 	return &result, nil
 }
 
+// newRequest creates a GET request with the configured tenant headers
+func (c *Client) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range c.compat.Headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+// healthPath returns the health check endpoint for the configured flavor
+func (c *Client) healthPath() string {
+	if c.compat.HealthPath != "" {
+		return c.compat.HealthPath
+	}
+	if c.compat.Flavor == FlavorVictoriaMetrics {
+		return "/health"
+	}
+	return "/-/healthy"
+}
+
 // GetLLDPNeighbors retrieves LLDP neighbor information from Prometheus
 func (c *Client) GetLLDPNeighbors(ctx context.Context) (*QueryResult, error) {
 	// Query for LLDP neighbor information
@@ -170,9 +287,9 @@ func (c *Client) GetInterfaceInfo(ctx context.Context) (*QueryResult, error) {
 
 // Health checks if Prometheus is healthy and reachable
 func (c *Client) Health(ctx context.Context) error {
-	url := fmt.Sprintf("%s/-/healthy", c.baseURL)
+	url := fmt.Sprintf("%s%s", c.baseURL, c.healthPath())
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := c.newRequest(ctx, url)
 	if err != nil {
 		return fmt.Errorf("failed to create health check request: %w", err)
 	}
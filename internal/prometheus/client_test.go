@@ -0,0 +1,340 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Responses of Prometheus-compatible front-ends to an instant query
+const (
+	completeResponse = `{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"instance":"leaf-01","sysDescr":"Arista 7050"},"value":[1760000000,"1"]}]}}`
+	// Thanos Query with partial_response enabled and a store down
+	thanosPartialResponse = `{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"instance":"leaf-01","sysDescr":"Arista 7050"},"value":[1760000000,"1"]}]},
+		"warnings":["receive 10.0.0.12:10901: rpc error: code = Unavailable"]}`
+	// vmselect with a vmstorage node down (-search.denyPartialResponse=false)
+	victoriaMetricsPartialResponse = `{"status":"success","isPartial":true,"data":{"resultType":"vector","result":[
+		{"metric":{"instance":"leaf-01","sysDescr":"Arista 7050"},"value":[1760000000,"1"]}]}}`
+)
+
+// newFixtureClient returns a client of a server answering every query with body, counting the queries
+func newFixtureClient(t *testing.T, body string, queries *int) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if queries != nil {
+			*queries++
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(Config{URL: server.URL})
+}
+
+func TestClient_Query_PartialResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		partial bool
+		reason  string
+	}{
+		{name: "complete", body: completeResponse},
+		{name: "thanos warnings", body: thanosPartialResponse, partial: true, reason: "rpc error"},
+		{name: "victoriametrics isPartial", body: victoriaMetricsPartialResponse, partial: true, reason: "isPartial"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFixtureClient(t, tt.body, nil)
+
+			for _, query := range []func() (*QueryResult, error){
+				func() (*QueryResult, error) { return client.Query(context.Background(), "up", time.Time{}) },
+				func() (*QueryResult, error) {
+					return client.QueryRange(context.Background(), "up", time.Now().Add(-time.Hour), time.Now(), time.Minute)
+				},
+			} {
+				result, err := query()
+				if got := errors.Is(err, ErrPartialResponse); got != tt.partial {
+					t.Fatalf("Expected partial=%v, got error %v", tt.partial, err)
+				}
+				if !tt.partial && err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if tt.partial && !strings.Contains(err.Error(), tt.reason) {
+					t.Errorf("Expected the error to name %q, got %v", tt.reason, err)
+				}
+				// 部分応答でも得られた系列は返す
+				if result == nil || len(result.Data.Result) != 1 {
+					t.Errorf("Expected the series to be returned, got %+v", result)
+				}
+			}
+		})
+	}
+}
+
+func TestMetricsExtractor_PartialResponseSkipsFallbacks(t *testing.T) {
+	queries := 0
+	client := newFixtureClient(t, thanosPartialResponse, &queries)
+	mapping := func(metric string) MetricMapping {
+		return MetricMapping{MetricName: metric, Labels: map[string]string{"device_id": "instance", "hardware": "sysDescr"}}
+	}
+	extractor := NewMetricsExtractor(client, &MetricsConfig{
+		MetricsMapping: map[string]MetricConfigGroup{
+			"device_info": {Primary: mapping("snmp_device_info"), Fallbacks: []MetricMapping{mapping("device_info")}},
+		},
+	})
+
+	devices, warnings := extractor.ExtractDevices(context.Background())
+	if len(devices) != 0 {
+		t.Errorf("Expected no devices from a partial response, got %d", len(devices))
+	}
+	if len(warnings) != 1 || !errors.Is(warnings[0], ErrPartialResponse) {
+		t.Errorf("Expected one partial response warning, got %v", warnings)
+	}
+	if queries != 1 {
+		t.Errorf("Expected the fallbacks to be skipped, got %d queries", queries)
+	}
+}
+
+func TestCompatibilityConfig_Validate(t *testing.T) {
+	enabled := true
+	tests := []struct {
+		name    string
+		config  CompatibilityConfig
+		wantErr string
+	}{
+		{name: "defaults"},
+		{name: "victoriametrics path", config: CompatibilityConfig{Flavor: FlavorVictoriaMetrics, QueryPath: "/select/0/prometheus"}},
+		{name: "thanos hints", config: CompatibilityConfig{Flavor: FlavorThanos, Dedup: &enabled, ReplicaLabels: []string{"replica"}}},
+		{name: "unknown flavor", config: CompatibilityConfig{Flavor: "cortex"}, wantErr: "unsupported prometheus flavor"},
+		{name: "relative query path", config: CompatibilityConfig{QueryPath: "select/0"}, wantErr: "must start with '/'"},
+		{name: "negative lookback", config: CompatibilityConfig{LookbackDelta: -time.Minute}, wantErr: "must not be negative"},
+		{name: "dedup without thanos", config: CompatibilityConfig{Flavor: FlavorVictoriaMetrics, Dedup: &enabled}, wantErr: "only supported by thanos"},
+		{name: "replica labels without flavor", config: CompatibilityConfig{ReplicaLabels: []string{"replica"}}, wantErr: "only supported by thanos"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// newRecordingClient returns a client of a server answering every request with a complete
+// response, and the last request it received
+func newRecordingClient(t *testing.T, compat CompatibilityConfig) (*Client, func() *http.Request) {
+	var last *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(completeResponse))
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(Config{URL: server.URL + "/", Compatibility: compat}), func() *http.Request { return last }
+}
+
+func TestClient_Query_CompatibilityParameters(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name   string
+		compat CompatibilityConfig
+		path   string
+		params map[string][]string
+		absent []string
+	}{
+		{
+			name:   "prometheus lookback",
+			compat: CompatibilityConfig{LookbackDelta: 2 * time.Minute},
+			path:   "/api/v1/query",
+			params: map[string][]string{"lookback_delta": {"120s"}},
+			absent: []string{"step", "dedup"},
+		},
+		{
+			name:   "victoriametrics lookback as step",
+			compat: CompatibilityConfig{Flavor: FlavorVictoriaMetrics, QueryPath: "/select/0/prometheus", LookbackDelta: time.Minute},
+			path:   "/select/0/prometheus/api/v1/query",
+			params: map[string][]string{"step": {"60s"}},
+			absent: []string{"lookback_delta"},
+		},
+		{
+			name:   "thanos hints",
+			compat: CompatibilityConfig{Flavor: FlavorThanos, Dedup: &enabled, PartialResponse: &disabled, ReplicaLabels: []string{"replica", "rule_replica"}},
+			path:   "/api/v1/query",
+			params: map[string][]string{"dedup": {"true"}, "partial_response": {"false"}, "replicaLabels[]": {"replica", "rule_replica"}},
+			absent: []string{"lookback_delta"},
+		},
+		{
+			// 検証を経ない設定でも Thanos 以外にはヒントを送らない
+			name:   "hints ignored outside thanos",
+			compat: CompatibilityConfig{Dedup: &enabled},
+			path:   "/api/v1/query",
+			absent: []string{"dedup", "lookback_delta"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, last := newRecordingClient(t, tt.compat)
+			if _, err := client.Query(context.Background(), "up", time.Time{}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			req := last()
+			if req.URL.Path != tt.path {
+				t.Errorf("Expected path %s, got %s", tt.path, req.URL.Path)
+			}
+			query := req.URL.Query()
+			if query.Has("time") {
+				t.Error("Expected no time parameter for a zero timestamp")
+			}
+			for key, want := range tt.params {
+				if got := query[key]; strings.Join(got, ",") != strings.Join(want, ",") {
+					t.Errorf("Expected %s=%v, got %v", key, want, got)
+				}
+			}
+			for _, key := range tt.absent {
+				if query.Has(key) {
+					t.Errorf("Expected no %s parameter, got %v", key, query[key])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_TenantHeaders(t *testing.T) {
+	client, last := newRecordingClient(t, CompatibilityConfig{Headers: map[string]string{"X-Scope-OrgID": "tenant-a"}})
+
+	if _, err := client.QueryRange(context.Background(), "up", time.Unix(1760000000, 0), time.Unix(1760003600, 0), time.Minute); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := last().Header.Get("X-Scope-OrgID"); got != "tenant-a" {
+		t.Errorf("Expected the tenant header on queries, got %q", got)
+	}
+	if query := last().URL.Query(); query.Get("start") != "1760000000" || query.Get("end") != "1760003600" || query.Get("step") != "60s" {
+		t.Errorf("Expected the range parameters, got %v", query)
+	}
+
+	if err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Unexpected health error: %v", err)
+	}
+	if got := last().Header.Get("X-Scope-OrgID"); got != "tenant-a" {
+		t.Errorf("Expected the tenant header on health checks, got %q", got)
+	}
+}
+
+func TestClient_Health(t *testing.T) {
+	tests := []struct {
+		name   string
+		compat CompatibilityConfig
+		path   string
+	}{
+		{name: "prometheus", path: "/-/healthy"},
+		{name: "thanos", compat: CompatibilityConfig{Flavor: FlavorThanos}, path: "/-/healthy"},
+		{name: "victoriametrics", compat: CompatibilityConfig{Flavor: FlavorVictoriaMetrics}, path: "/health"},
+		{name: "custom", compat: CompatibilityConfig{Flavor: FlavorVictoriaMetrics, HealthPath: "/ready"}, path: "/ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			client := NewClient(Config{URL: server.URL, Compatibility: tt.compat})
+			if err := client.Health(context.Background()); err != nil {
+				t.Errorf("Expected %s to be checked, got %v", tt.path, err)
+			}
+		})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	err := NewClient(Config{URL: server.URL}).Health(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "status 503") {
+		t.Errorf("Expected an unhealthy server to fail the check, got %v", err)
+	}
+}
+
+func TestClient_Query_ErrorResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{
+			name:    "victoriametrics json error",
+			status:  http.StatusUnprocessableEntity,
+			body:    `{"status":"error","errorType":"422","error":"cannot parse query"}`,
+			wantErr: "status 422: cannot parse query (422)",
+		},
+		{
+			name:    "plain text error",
+			status:  http.StatusBadGateway,
+			body:    "upstream unavailable",
+			wantErr: "status 502: upstream unavailable",
+		},
+		{
+			name:    "error status with 200",
+			status:  http.StatusOK,
+			body:    `{"status":"error","errorType":"bad_data","error":"invalid parameter"}`,
+			wantErr: "invalid parameter (bad_data)",
+		},
+		{
+			name:    "malformed json",
+			status:  http.StatusOK,
+			body:    `{"status":"success","data":`,
+			wantErr: "failed to decode response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(server.Close)
+
+			result, err := NewClient(Config{URL: server.URL}).Query(context.Background(), "up", time.Now())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			if errors.Is(err, ErrPartialResponse) {
+				t.Errorf("Expected a failed query not to be reported as partial, got %v", err)
+			}
+			if result != nil {
+				t.Errorf("Expected no result for a failed query, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestClient_Query_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	_, err := NewClient(Config{URL: server.URL, Timeout: time.Second}).Query(context.Background(), "up", time.Time{})
+	if err == nil || !strings.Contains(err.Error(), "failed to execute request") {
+		t.Errorf("Expected an unreachable server to fail the query, got %v", err)
+	}
+}
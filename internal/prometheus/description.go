@@ -0,0 +1,182 @@
+package prometheus
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Device metadata keys filled from parsed system descriptions
+const (
+	MetadataVendor    = "vendor"
+	MetadataOSVersion = "os_version"
+)
+
+// Named capture groups of description patterns
+var descriptionGroups = []string{"vendor", "model", "os_version"}
+
+// DescriptionPattern parses system descriptions (sysDescr, LLDP system description) with a regular
+// expression. The named groups vendor, model and os_version capture the fields.
+type DescriptionPattern struct {
+	Pattern string `yaml:"pattern"`
+	Vendor  string `yaml:"vendor"` // Vendor of matching descriptions when the pattern has no vendor group
+}
+
+// DeviceDescription holds the fields parsed from a system description
+type DeviceDescription struct {
+	Vendor    string
+	Model     string
+	OSVersion string
+}
+
+// Validate checks that the pattern compiles and captures at least one known group
+func (p DescriptionPattern) Validate() error {
+	_, err := p.compile()
+	return err
+}
+
+func (p DescriptionPattern) compile() (*regexp.Regexp, error) {
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid description pattern '%s': %w", p.Pattern, err)
+	}
+
+	captures := false
+	for _, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if !containsGroup(name) {
+			return nil, fmt.Errorf("description pattern '%s' has unknown group '%s' (expected %s)", p.Pattern, name, strings.Join(descriptionGroups, ", "))
+		}
+		captures = true
+	}
+	if !captures {
+		return nil, fmt.Errorf("description pattern '%s' captures none of %s", p.Pattern, strings.Join(descriptionGroups, ", "))
+	}
+	return re, nil
+}
+
+func containsGroup(name string) bool {
+	for _, group := range descriptionGroups {
+		if group == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultDescriptionPatterns returns the built-in patterns. They are tried after the configured ones.
+func DefaultDescriptionPatterns() []DescriptionPattern {
+	return []DescriptionPattern{
+		{Pattern: `Arista Networks EOS version (?P<os_version>\S+) running on an Arista Networks (?P<model>\S+)`, Vendor: "Arista"},
+		{Pattern: `Cisco IOS Software,? (?:\[\w+\],? )?(?P<model>\S+) Software .*?Version (?P<os_version>[^\s,]+)`, Vendor: "Cisco"},
+		{Pattern: `Cisco Nexus Operating System \(NX-OS\) Software.*?Version (?P<os_version>[^\s,]+)`, Vendor: "Cisco"},
+		{Pattern: `Juniper Networks, Inc\. (?P<model>\S+) .*?JUNOS (?P<os_version>[^\s,]+)`, Vendor: "Juniper"},
+		{Pattern: `(?s)Huawei Versatile Routing Platform.*?Version (?P<os_version>\S+) \((?P<model>\S+)`, Vendor: "Huawei"},
+		{Pattern: `IX Series (?P<model>IX\d+\w*) .*?Version (?P<os_version>[^\s,]+)`, Vendor: "NEC"},
+		{Pattern: `^(?P<model>RTX\d+\w*|NVR\d+\w*) Rev\.(?P<os_version>\S+)`, Vendor: "Yamaha"},
+		// 以前からの英語表記のパターン（OSバージョンなし）
+		{Pattern: `(?P<vendor>Cisco)\s+(?P<model>\w+\s*\d+\w*)`},
+		{Pattern: `(?P<vendor>Arista)\s+DCS-(?P<model>\d+\w*)`},
+		{Pattern: `(?P<vendor>Juniper)\s+(?P<model>\w+\s*\d+\w*)`},
+		{Pattern: `(?P<vendor>HP)\s+(?P<model>\w+\s*\d+\w*)`},
+		{Pattern: `(?P<vendor>Dell)\s+(?P<model>\w+\s*\d+\w*)`},
+	}
+}
+
+type compiledDescriptionPattern struct {
+	re     *regexp.Regexp
+	vendor string
+}
+
+// DescriptionParser parses system descriptions with the configured patterns followed by the built-in ones
+type DescriptionParser struct {
+	patterns []compiledDescriptionPattern
+}
+
+// NewDescriptionParser compiles the configured patterns; the built-in patterns are appended
+func NewDescriptionParser(patterns []DescriptionPattern) (*DescriptionParser, error) {
+	parser := &DescriptionParser{}
+	for _, pattern := range append(append([]DescriptionPattern(nil), patterns...), DefaultDescriptionPatterns()...) {
+		re, err := pattern.compile()
+		if err != nil {
+			return nil, err
+		}
+		parser.patterns = append(parser.patterns, compiledDescriptionPattern{re: re, vendor: pattern.Vendor})
+	}
+	return parser, nil
+}
+
+// Parse returns the fields of the first matching pattern. ok is false when no pattern matches.
+func (p *DescriptionParser) Parse(description string) (parsed DeviceDescription, ok bool) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return DeviceDescription{}, false
+	}
+
+	for _, pattern := range p.patterns {
+		matches := pattern.re.FindStringSubmatch(description)
+		if matches == nil {
+			continue
+		}
+		parsed.Vendor = pattern.vendor
+		for i, name := range pattern.re.SubexpNames() {
+			value := strings.TrimSpace(matches[i])
+			switch name {
+			case "vendor":
+				if value != "" {
+					parsed.Vendor = value
+				}
+			case "model":
+				parsed.Model = value
+			case "os_version":
+				parsed.OSVersion = value
+			}
+		}
+		return parsed, true
+	}
+	return DeviceDescription{}, false
+}
+
+// Apply fills the device from a system description: the model becomes the hardware unless the
+// device already has one, and the vendor and OS version are stored in the metadata.
+// Unmatched descriptions leave the device unchanged.
+func (p *DescriptionParser) Apply(device *topology.Device, description string) bool {
+	parsed, ok := p.Parse(description)
+	if !ok {
+		return false
+	}
+
+	if device.Hardware == "" && parsed.Model != "" {
+		device.Hardware = parsed.Model
+	}
+	if device.Metadata == nil {
+		device.Metadata = make(map[string]string)
+	}
+	if parsed.Vendor != "" {
+		device.Metadata[MetadataVendor] = parsed.Vendor
+	}
+	if parsed.OSVersion != "" {
+		device.Metadata[MetadataOSVersion] = parsed.OSVersion
+	}
+	return true
+}
+
+// DescriptionParser returns the parser for the configured description patterns. Invalid patterns
+// are rejected by config validation; should one get here, only the built-in patterns are used.
+func (c *MetricsConfig) DescriptionParser() *DescriptionParser {
+	var patterns []DescriptionPattern
+	if c != nil {
+		patterns = c.DescriptionPatterns
+	}
+	parser, err := NewDescriptionParser(patterns)
+	if err != nil {
+		log.Printf("Ignoring description patterns: %v", err)
+		parser, _ = NewDescriptionParser(nil)
+	}
+	return parser
+}
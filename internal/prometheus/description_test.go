@@ -0,0 +1,98 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+func TestDescriptionParser_BuiltinPatterns(t *testing.T) {
+	parser, err := NewDescriptionParser(nil)
+	if err != nil {
+		t.Fatalf("Expected built-in patterns to compile, got %v", err)
+	}
+
+	tests := []struct {
+		description string
+		want        DeviceDescription
+	}{
+		{"Arista Networks EOS version 4.28.3M running on an Arista Networks DCS-7050SX3-48YC8", DeviceDescription{"Arista", "DCS-7050SX3-48YC8", "4.28.3M"}},
+		{"Cisco IOS Software, C3750E Software (C3750E-UNIVERSALK9-M), Version 15.0(2)SE11, RELEASE SOFTWARE (fc3)", DeviceDescription{"Cisco", "C3750E", "15.0(2)SE11"}},
+		{"Juniper Networks, Inc. qfx5100-48s-6q Ethernet Switch, kernel JUNOS 18.4R2-S4.1, Build date: 2020-01-01", DeviceDescription{"Juniper", "qfx5100-48s-6q", "18.4R2-S4.1"}},
+		{"Huawei Versatile Routing Platform Software\r\nVRP (R) software, Version 8.180 (CE6850 V200R005C10SPC800)", DeviceDescription{"Huawei", "CE6850", "8.180"}},
+		{"RTX1210 Rev.14.01.38 (Fri Jul 10 09:48:51 2020)", DeviceDescription{"Yamaha", "RTX1210", "14.01.38"}},
+		{"Dell S5248F", DeviceDescription{"Dell", "S5248F", ""}},
+	}
+
+	for _, tt := range tests {
+		got, ok := parser.Parse(tt.description)
+		if !ok {
+			t.Errorf("Expected %q to match a built-in pattern", tt.description)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Expected %+v for %q, got %+v", tt.want, tt.description, got)
+		}
+	}
+
+	if _, ok := parser.Parse("Linux server-01 5.15.0-91-generic #101-Ubuntu SMP x86_64"); ok {
+		t.Error("Expected a Linux description to match no pattern")
+	}
+}
+
+func TestDescriptionParser_ConfiguredPatternsFirst(t *testing.T) {
+	parser, err := NewDescriptionParser([]DescriptionPattern{
+		{Pattern: `^(?P<model>FX\d+\S*) ファームウェア バージョン (?P<os_version>\S+)`, Vendor: "Example Networks"},
+		{Pattern: `(?P<vendor>Arista) lab image (?P<os_version>\S+)`},
+	})
+	if err != nil {
+		t.Fatalf("Expected patterns to compile, got %v", err)
+	}
+
+	got, ok := parser.Parse("FX2400-R ファームウェア バージョン 3.2.1")
+	if !ok || got != (DeviceDescription{"Example Networks", "FX2400-R", "3.2.1"}) {
+		t.Errorf("Expected the configured Japanese pattern to match, got %+v (%v)", got, ok)
+	}
+
+	got, _ = parser.Parse("Arista lab image 4.30.0F-dev")
+	if got.OSVersion != "4.30.0F-dev" || got.Vendor != "Arista" {
+		t.Errorf("Expected the configured pattern to win over the built-in ones, got %+v", got)
+	}
+}
+
+func TestDescriptionPattern_Validate(t *testing.T) {
+	invalid := []DescriptionPattern{
+		{Pattern: `(?P<model>[`},
+		{Pattern: `Cisco (\S+)`},
+		{Pattern: `(?P<hardware>\S+)`},
+	}
+	for _, pattern := range invalid {
+		if err := pattern.Validate(); err == nil {
+			t.Errorf("Expected %q to be invalid", pattern.Pattern)
+		}
+	}
+}
+
+func TestDescriptionParser_Apply(t *testing.T) {
+	parser, _ := NewDescriptionParser(nil)
+
+	device := topology.Device{ID: "spine-01"}
+	if !parser.Apply(&device, "Arista Networks EOS version 4.28.3M running on an Arista Networks DCS-7280SR3-48YC8") {
+		t.Fatal("Expected the description to be parsed")
+	}
+	if device.Hardware != "DCS-7280SR3-48YC8" || device.Metadata[MetadataOSVersion] != "4.28.3M" || device.Metadata[MetadataVendor] != "Arista" {
+		t.Errorf("Expected hardware, vendor and OS version to be filled, got %+v", device)
+	}
+
+	// 既にハードウェアがあれば上書きしない
+	device = topology.Device{ID: "leaf-01", Hardware: "7050SX3"}
+	parser.Apply(&device, "Arista Networks EOS version 4.28.3M running on an Arista Networks DCS-7050SX3-48YC8")
+	if device.Hardware != "7050SX3" {
+		t.Errorf("Expected existing hardware to be kept, got %s", device.Hardware)
+	}
+
+	device = topology.Device{ID: "server-01"}
+	if parser.Apply(&device, "Linux server-01 5.15.0") || device.Hardware != "" || device.Metadata != nil {
+		t.Errorf("Expected an unmatched description to leave the device unchanged, got %+v", device)
+	}
+}
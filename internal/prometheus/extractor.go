@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -17,8 +18,28 @@ type MetricConfigGroup struct {
 
 // MetricMapping defines how to extract data from a specific metric
 type MetricMapping struct {
-	MetricName string            `yaml:"metric_name"`
-	Labels     map[string]string `yaml:"labels"`
+	MetricName string `yaml:"metric_name"`
+	// Query is a PromQL expression queried instead of MetricName, e.g. a join or label_replace
+	// building the labels of a record. The filters are not applied to it.
+	Query   string            `yaml:"query"`
+	Labels  map[string]string `yaml:"labels"`
+	Filters []string          `yaml:"filters"` // PromQL label matchers added to the global filters
+}
+
+// Source names the metric or the query of the mapping in messages
+func (m MetricMapping) Source() string {
+	if m.Query != "" {
+		return m.Query
+	}
+	return m.MetricName
+}
+
+// mappingRequiredFields lists the fields without which the samples of each mapping are skipped
+var mappingRequiredFields = map[string][]string{
+	"device_info":      {"device_id"},
+	"lldp_neighbors":   {"source_device", "target_device"},
+	MACTableMappingKey: {"device_id", "port", "mac"},
+	ARPTableMappingKey: {"mac"},
 }
 
 // FieldRequirement defines required and optional fields for validation
@@ -31,24 +52,37 @@ type FieldRequirement struct {
 type MetricsConfig struct {
 	MetricsMapping    map[string]MetricConfigGroup `yaml:"metrics_mapping"`
 	FieldRequirements map[string]FieldRequirement  `yaml:"field_requirements"`
+	// Filters are PromQL label matchers (e.g. env="prod") applied to every metric query,
+	// so one Prometheus serving several environments yields the topology of one of them
+	Filters []string `yaml:"filters"`
+	// DescriptionPatterns parse the description field of devices into vendor, model and OS version
+	DescriptionPatterns []DescriptionPattern `yaml:"description_patterns"`
 }
 
 // MetricsExtractor extracts network topology data from Prometheus metrics
 type MetricsExtractor struct {
-	client *Client
-	config *MetricsConfig
+	client       *Client
+	config       *MetricsConfig
+	descriptions *DescriptionParser
 }
 
 // NewMetricsExtractor creates a new MetricsExtractor instance
 func NewMetricsExtractor(client *Client, config *MetricsConfig) *MetricsExtractor {
 	return &MetricsExtractor{
-		client: client,
-		config: config,
+		client:       client,
+		config:       config,
+		descriptions: config.DescriptionParser(),
 	}
 }
 
 // ExtractDevices extracts device information from Prometheus metrics
 func (e *MetricsExtractor) ExtractDevices(ctx context.Context) ([]topology.Device, []error) {
+	return e.ExtractDevicesAt(ctx, time.Time{})
+}
+
+// ExtractDevicesAt extracts device information as Prometheus saw it at the given time.
+// A zero time queries the latest samples.
+func (e *MetricsExtractor) ExtractDevicesAt(ctx context.Context, at time.Time) ([]topology.Device, []error) {
 	var warnings []error
 
 	deviceConfig, exists := e.config.MetricsMapping["device_info"]
@@ -57,21 +91,28 @@ func (e *MetricsExtractor) ExtractDevices(ctx context.Context) ([]topology.Devic
 	}
 
 	// Try primary metric first
-	devices, err := e.tryExtractDevices(ctx, deviceConfig.Primary, "device_info")
+	devices, err := e.tryExtractDevices(ctx, deviceConfig.Primary, at, "device_info")
 	if err == nil && len(devices) > 0 {
-		log.Printf("Successfully extracted %d devices using primary metric '%s'", len(devices), deviceConfig.Primary.MetricName)
+		log.Printf("Successfully extracted %d devices using primary metric '%s'", len(devices), deviceConfig.Primary.Source())
 		return e.validateAndCleanDevices(devices, "device_info"), warnings
 	}
-	warnings = append(warnings, fmt.Errorf("primary metric '%s' failed: %w", deviceConfig.Primary.MetricName, err))
+	warnings = append(warnings, fmt.Errorf("primary metric '%s' failed: %w", deviceConfig.Primary.Source(), err))
+	// 部分応答はバックエンドの障害なので、フォールバックも同じく欠けている
+	if errors.Is(err, ErrPartialResponse) {
+		return nil, warnings
+	}
 
 	// Try fallback metrics
 	for i, fallback := range deviceConfig.Fallbacks {
-		devices, err := e.tryExtractDevices(ctx, fallback, "device_info")
+		devices, err := e.tryExtractDevices(ctx, fallback, at, "device_info")
 		if err == nil && len(devices) > 0 {
-			log.Printf("Successfully extracted %d devices using fallback %d metric '%s'", len(devices), i+1, fallback.MetricName)
+			log.Printf("Successfully extracted %d devices using fallback %d metric '%s'", len(devices), i+1, fallback.Source())
 			return e.validateAndCleanDevices(devices, "device_info"), warnings
 		}
-		warnings = append(warnings, fmt.Errorf("fallback %d metric '%s' failed: %w", i+1, fallback.MetricName, err))
+		warnings = append(warnings, fmt.Errorf("fallback %d metric '%s' failed: %w", i+1, fallback.Source(), err))
+		if errors.Is(err, ErrPartialResponse) {
+			break
+		}
 	}
 
 	return nil, warnings
@@ -79,6 +120,12 @@ func (e *MetricsExtractor) ExtractDevices(ctx context.Context) ([]topology.Devic
 
 // ExtractLinks extracts link information from Prometheus metrics
 func (e *MetricsExtractor) ExtractLinks(ctx context.Context) ([]topology.Link, []error) {
+	return e.ExtractLinksAt(ctx, time.Time{})
+}
+
+// ExtractLinksAt extracts link information as Prometheus saw it at the given time.
+// A zero time queries the latest samples.
+func (e *MetricsExtractor) ExtractLinksAt(ctx context.Context, at time.Time) ([]topology.Link, []error) {
 	var warnings []error
 
 	linkConfig, exists := e.config.MetricsMapping["lldp_neighbors"]
@@ -87,44 +134,58 @@ func (e *MetricsExtractor) ExtractLinks(ctx context.Context) ([]topology.Link, [
 	}
 
 	// Try primary metric first
-	links, err := e.tryExtractLinks(ctx, linkConfig.Primary, "lldp_neighbors")
+	links, err := e.tryExtractLinks(ctx, linkConfig.Primary, at, "lldp_neighbors")
 	if err == nil && len(links) > 0 {
-		log.Printf("Successfully extracted %d links using primary metric '%s'", len(links), linkConfig.Primary.MetricName)
+		log.Printf("Successfully extracted %d links using primary metric '%s'", len(links), linkConfig.Primary.Source())
 		return e.validateAndCleanLinks(links, "lldp_neighbors"), warnings
 	}
-	warnings = append(warnings, fmt.Errorf("primary metric '%s' failed: %w", linkConfig.Primary.MetricName, err))
+	warnings = append(warnings, fmt.Errorf("primary metric '%s' failed: %w", linkConfig.Primary.Source(), err))
+	// 部分応答はバックエンドの障害なので、フォールバックも同じく欠けている
+	if errors.Is(err, ErrPartialResponse) {
+		return nil, warnings
+	}
 
 	// Try fallback metrics
 	for i, fallback := range linkConfig.Fallbacks {
-		links, err := e.tryExtractLinks(ctx, fallback, "lldp_neighbors")
+		links, err := e.tryExtractLinks(ctx, fallback, at, "lldp_neighbors")
 		if err == nil && len(links) > 0 {
-			log.Printf("Successfully extracted %d links using fallback %d metric '%s'", len(links), i+1, fallback.MetricName)
+			log.Printf("Successfully extracted %d links using fallback %d metric '%s'", len(links), i+1, fallback.Source())
 			return e.validateAndCleanLinks(links, "lldp_neighbors"), warnings
 		}
-		warnings = append(warnings, fmt.Errorf("fallback %d metric '%s' failed: %w", i+1, fallback.MetricName, err))
+		warnings = append(warnings, fmt.Errorf("fallback %d metric '%s' failed: %w", i+1, fallback.Source(), err))
+		if errors.Is(err, ErrPartialResponse) {
+			break
+		}
 	}
 
 	return nil, warnings
 }
 
 // tryExtractDevices attempts to extract devices from a specific metric configuration
-func (e *MetricsExtractor) tryExtractDevices(ctx context.Context, mapping MetricMapping, configKey string) ([]topology.Device, error) {
-	query := fmt.Sprintf(`{__name__="%s"}`, mapping.MetricName)
+func (e *MetricsExtractor) tryExtractDevices(ctx context.Context, mapping MetricMapping, at time.Time, configKey string) ([]topology.Device, error) {
+	query, err := e.selector(mapping)
+	if err != nil {
+		return nil, err
+	}
 
-	result, err := e.client.Query(ctx, query, time.Time{})
+	result, err := e.client.Query(ctx, query, at)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query metric '%s': %w", mapping.MetricName, err)
+		return nil, fmt.Errorf("failed to query metric '%s': %w", mapping.Source(), err)
+	}
+	if err := e.checkQueryResult(result, mapping, configKey); err != nil {
+		return nil, err
 	}
 
 	var devices []topology.Device
-	now := time.Now()
+	now := observedAt(at)
 
 	for _, sample := range result.Data.Result {
 		device := topology.Device{
-			LastSeen:  now,
-			CreatedAt: now,
-			UpdatedAt: now,
-			Metadata:  make(map[string]string),
+			Provenance: topology.ProvenancePrometheus,
+			LastSeen:   now,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+			Metadata:   make(map[string]string),
 		}
 
 		// Extract fields based on label mapping
@@ -145,27 +206,42 @@ func (e *MetricsExtractor) tryExtractDevices(ctx context.Context, mapping Metric
 			device.Metadata["location"] = location // store as display info only
 		}
 
+		// 説明文（sysDescr など）からベンダー・機種・OSバージョンを取り出す。
+		// 機種を取り出せなければ説明文をそのままハードウェアとする
+		if description, exists := e.extractLabelValue(sample.Metric, mapping.Labels, "description"); exists {
+			e.descriptions.Apply(&device, description)
+			if device.Hardware == "" {
+				device.Hardware = description
+			}
+		}
+
 		devices = append(devices, device)
 	}
 
 	if len(devices) == 0 {
-		return nil, fmt.Errorf("no devices found in metric '%s'", mapping.MetricName)
+		return nil, fmt.Errorf("no devices found in metric '%s'", mapping.Source())
 	}
 
 	return devices, nil
 }
 
 // tryExtractLinks attempts to extract links from a specific metric configuration
-func (e *MetricsExtractor) tryExtractLinks(ctx context.Context, mapping MetricMapping, configKey string) ([]topology.Link, error) {
-	query := fmt.Sprintf(`{__name__="%s"}`, mapping.MetricName)
+func (e *MetricsExtractor) tryExtractLinks(ctx context.Context, mapping MetricMapping, at time.Time, configKey string) ([]topology.Link, error) {
+	query, err := e.selector(mapping)
+	if err != nil {
+		return nil, err
+	}
 
-	result, err := e.client.Query(ctx, query, time.Time{})
+	result, err := e.client.Query(ctx, query, at)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query metric '%s': %w", mapping.MetricName, err)
+		return nil, fmt.Errorf("failed to query metric '%s': %w", mapping.Source(), err)
+	}
+	if err := e.checkQueryResult(result, mapping, configKey); err != nil {
+		return nil, err
 	}
 
 	var links []topology.Link
-	now := time.Now()
+	now := observedAt(at)
 
 	for i, sample := range result.Data.Result {
 		link := topology.Link{
@@ -210,12 +286,65 @@ func (e *MetricsExtractor) tryExtractLinks(ctx context.Context, mapping MetricMa
 	}
 
 	if len(links) == 0 {
-		return nil, fmt.Errorf("no links found in metric '%s'", mapping.MetricName)
+		return nil, fmt.Errorf("no links found in metric '%s'", mapping.Source())
 	}
 
 	return links, nil
 }
 
+// selector returns the series selector of a metric mapping with the configured label filters,
+// or the query of the mapping as is
+func (e *MetricsExtractor) selector(mapping MetricMapping) (string, error) {
+	if mapping.Query != "" {
+		return mapping.Query, nil
+	}
+	filters := append(append([]string(nil), e.config.Filters...), mapping.Filters...)
+	return BuildSelector(mapping.Source(), filters)
+}
+
+// checkQueryResult verifies that the result of a custom query is an instant vector carrying the
+// labels of the required fields of configKey. A label missing from every sample means the
+// expression lost it (e.g. in an aggregation or a join), so the mapping fails and the next
+// fallback is tried instead of silently skipping every sample.
+func (e *MetricsExtractor) checkQueryResult(result *QueryResult, mapping MetricMapping, configKey string) error {
+	if mapping.Query == "" || len(result.Data.Result) == 0 {
+		return nil
+	}
+	if result.Data.ResultType != "" && result.Data.ResultType != "vector" {
+		return fmt.Errorf("query '%s' returned a %s; an instant vector is required", mapping.Query, result.Data.ResultType)
+	}
+
+	required := append([]string(nil), mappingRequiredFields[configKey]...)
+	if requirements, ok := e.config.FieldRequirements[configKey]; ok {
+		required = append(required, requirements.Required...)
+	}
+	for _, field := range required {
+		label := mapping.Labels[field]
+		if label == "" {
+			return fmt.Errorf("required field '%s' is not mapped to a label of query '%s'", field, mapping.Query)
+		}
+		found := false
+		for _, sample := range result.Data.Result {
+			if sample.Metric[label] != "" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("query '%s' returned no label '%s' for required field '%s'", mapping.Query, label, field)
+		}
+	}
+	return nil
+}
+
+// observedAt is the time extracted devices and links were last seen
+func observedAt(at time.Time) time.Time {
+	if at.IsZero() {
+		return time.Now()
+	}
+	return at
+}
+
 // extractLabelValue extracts a label value based on mapping configuration
 func (e *MetricsExtractor) extractLabelValue(labels map[string]string, mapping map[string]string, field string) (string, bool) {
 	prometheusLabel, exists := mapping[field]
@@ -343,10 +472,10 @@ func (e *MetricsExtractor) fillMissingDeviceFields(device topology.Device) topol
 // fillMissingLinkFields fills missing optional fields with default values
 func (e *MetricsExtractor) fillMissingLinkFields(link topology.Link) topology.Link {
 	if link.SourcePort == "" {
-		link.SourcePort = "unknown"
+		link.SourcePort = topology.UnknownPort
 	}
 	if link.TargetPort == "" {
-		link.TargetPort = "unknown"
+		link.TargetPort = topology.UnknownPort
 	}
 	// status field removed from Link
 	if link.Weight == 0 {
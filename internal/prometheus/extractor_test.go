@@ -0,0 +1,53 @@
+package prometheus
+
+import "testing"
+
+func TestMetricsExtractor_CustomQuery(t *testing.T) {
+	extractor := NewMetricsExtractor(nil, &MetricsConfig{
+		Filters: []string{`env="prod"`},
+		FieldRequirements: map[string]FieldRequirement{
+			"lldp_neighbors": {Required: []string{"source_port"}},
+		},
+	})
+	mapping := MetricMapping{
+		Query: `label_replace(lldp_remote_info, "peer", "$1", "remote_name", "(.*)")`,
+		Labels: map[string]string{
+			"source_device": "instance",
+			"target_device": "peer",
+			"source_port":   "ifName",
+		},
+	}
+
+	query, err := extractor.selector(mapping)
+	if err != nil || query != mapping.Query {
+		t.Errorf("Expected the query unchanged without filters, got %s (err: %v)", query, err)
+	}
+
+	result := &QueryResult{}
+	result.Data.ResultType = "vector"
+	result.Data.Result = []Result{
+		{Metric: map[string]string{"instance": "leaf-01", "peer": "spine-01", "ifName": "Ethernet49"}},
+		{Metric: map[string]string{"instance": "leaf-02", "ifName": "Ethernet49"}},
+	}
+	if err := extractor.checkQueryResult(result, mapping, "lldp_neighbors"); err != nil {
+		t.Errorf("Expected labels present in some samples to pass, got %v", err)
+	}
+
+	// 結合で失われたラベル（全サンプルにない）はマッピングの失敗とする
+	for _, sample := range result.Data.Result {
+		delete(sample.Metric, "ifName")
+	}
+	if err := extractor.checkQueryResult(result, mapping, "lldp_neighbors"); err == nil {
+		t.Error("Expected a required label missing from every sample to fail")
+	}
+
+	result.Data.ResultType = "matrix"
+	if err := extractor.checkQueryResult(result, mapping, "device_info"); err == nil {
+		t.Error("Expected a range vector to be rejected")
+	}
+
+	// メトリクス名のマッピングは従来どおりサンプル単位でスキップする
+	if err := extractor.checkQueryResult(result, MetricMapping{MetricName: "lldp_remote_info"}, "lldp_neighbors"); err != nil {
+		t.Errorf("Expected metric name mappings not to be checked, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package prometheus
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// labelMatcherPattern matches a single PromQL label matcher such as env="prod" or site!~"lab-.*"
+var labelMatcherPattern = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*("(?:[^"\\]|\\.)*")\s*$`)
+
+// LabelMatcher is a PromQL label matcher used to restrict the series the extractor reads
+type LabelMatcher struct {
+	Label    string
+	Operator string
+	Value    string
+}
+
+// String renders the matcher in PromQL syntax
+func (m LabelMatcher) String() string {
+	return fmt.Sprintf("%s%s%s", m.Label, m.Operator, strconv.Quote(m.Value))
+}
+
+// ParseLabelMatcher parses a PromQL label matcher, e.g. env="prod" or tenant=~"team-(a|b)"
+func ParseLabelMatcher(expr string) (LabelMatcher, error) {
+	match := labelMatcherPattern.FindStringSubmatch(expr)
+	if match == nil {
+		return LabelMatcher{}, fmt.Errorf("invalid label matcher '%s' (expected e.g. env=\"prod\")", expr)
+	}
+
+	value, err := strconv.Unquote(match[3])
+	if err != nil {
+		return LabelMatcher{}, fmt.Errorf("invalid value in label matcher '%s': %w", expr, err)
+	}
+	if match[1] == "__name__" {
+		return LabelMatcher{}, fmt.Errorf("label matcher '%s' cannot select the metric name; use metric_name instead", expr)
+	}
+	if match[2] == "=~" || match[2] == "!~" {
+		if _, err := regexp.Compile(value); err != nil {
+			return LabelMatcher{}, fmt.Errorf("invalid regex in label matcher '%s': %w", expr, err)
+		}
+	}
+
+	return LabelMatcher{Label: match[1], Operator: match[2], Value: value}, nil
+}
+
+// BuildSelector builds the series selector of a metric restricted by label matchers
+func BuildSelector(metricName string, filters []string) (string, error) {
+	matchers := []string{fmt.Sprintf(`__name__=%s`, strconv.Quote(metricName))}
+	for _, filter := range filters {
+		matcher, err := ParseLabelMatcher(filter)
+		if err != nil {
+			return "", err
+		}
+		matchers = append(matchers, matcher.String())
+	}
+	return "{" + strings.Join(matchers, ",") + "}", nil
+}
@@ -0,0 +1,34 @@
+package prometheus
+
+import "testing"
+
+func TestBuildSelector(t *testing.T) {
+	got, err := BuildSelector("snmp_device_info", []string{`env="prod"`, ` site !~ "lab-.*" `})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := `{__name__="snmp_device_info",env="prod",site!~"lab-.*"}`
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	got, err = BuildSelector("lldp_remote_info", nil)
+	if err != nil || got != `{__name__="lldp_remote_info"}` {
+		t.Errorf("Expected selector without filters, got %s (err: %v)", got, err)
+	}
+}
+
+func TestParseLabelMatcher_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		`env`,
+		`env=prod`,
+		`env=="prod"`,
+		`1env="prod"`,
+		`__name__="up"`,
+		`env=~"(prod"`,
+	} {
+		if _, err := ParseLabelMatcher(expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
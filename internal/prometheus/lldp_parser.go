@@ -13,13 +13,15 @@ import (
 
 // LLDPParser parses LLDP information from Prometheus metrics
 type LLDPParser struct {
-	client *Client
+	client       *Client
+	descriptions *DescriptionParser
 }
 
-// NewLLDPParser creates a new LLDP parser
-func NewLLDPParser(client *Client) *LLDPParser {
+// NewLLDPParser creates a new LLDP parser. System descriptions are parsed with descriptions.
+func NewLLDPParser(client *Client, descriptions *DescriptionParser) *LLDPParser {
 	return &LLDPParser{
-		client: client,
+		client:       client,
+		descriptions: descriptions,
 	}
 }
 
@@ -157,8 +159,13 @@ func (p *LLDPParser) BuildTopologyFromLLDP(ctx context.Context) ([]topology.Devi
 		localDeviceID := p.resolveDeviceID(neighbor.LocalDevice, deviceMap)
 		if localDevice, exists := uniqueDevices[localDeviceID]; !exists {
 			device := p.createDeviceFromInfo(localDeviceID, neighbor.LocalDevice, deviceMap, now)
+			// LLDP を報告しているデバイスは監視対象
+			device.Provenance = topology.ProvenancePrometheus
 			uniqueDevices[localDeviceID] = device
 		} else {
+			// 先に隣接先として見つかっていても監視対象として扱う
+			localDevice.Provenance = topology.ProvenancePrometheus
+			uniqueDevices[localDeviceID] = localDevice
 			// Update last seen time
 			if neighbor.LastSeen.After(localDevice.LastSeen) {
 				localDevice.LastSeen = neighbor.LastSeen
@@ -178,7 +185,7 @@ func (p *LLDPParser) BuildTopologyFromLLDP(ctx context.Context) ([]topology.Devi
 			device := p.createDeviceFromInfo(remoteDeviceID, neighbor.RemoteSystemName, deviceMap, now)
 			// Fill in additional info from LLDP if device info is not available
 			if device.Hardware == "" && neighbor.RemoteSystemDesc != "" {
-				device.Hardware = p.extractHardwareFromDesc(neighbor.RemoteSystemDesc)
+				p.applySystemDesc(&device, neighbor.RemoteSystemDesc)
 			}
 			uniqueDevices[remoteDeviceID] = device
 		} else {
@@ -260,19 +267,21 @@ func (p *LLDPParser) resolveDeviceID(identifier string, deviceMap map[string]Dev
 
 func (p *LLDPParser) createDeviceFromInfo(deviceID, identifier string, deviceMap map[string]DeviceInfo, now time.Time) topology.Device {
 	device := topology.Device{
-		ID:        deviceID,
-		Type:      "unknown",
-		LayerID:   nil, // will be set by classification
-		Metadata:  make(map[string]string),
-		LastSeen:  now,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:         deviceID,
+		Type:       "unknown",
+		LayerID:    nil, // will be set by classification
+		Provenance: topology.ProvenanceLLDPPlaceholder,
+		Metadata:   make(map[string]string),
+		LastSeen:   now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
 
 	// Fill in additional info if available
 	if deviceInfo, exists := deviceMap[identifier]; exists {
+		device.Provenance = topology.ProvenancePrometheus
 		if deviceInfo.SystemDesc != "" {
-			device.Hardware = p.extractHardwareFromDesc(deviceInfo.SystemDesc)
+			p.applySystemDesc(&device, deviceInfo.SystemDesc)
 		}
 		if deviceInfo.Location != "" {
 			device.Metadata["location"] = deviceInfo.Location
@@ -337,28 +346,24 @@ func (p *LLDPParser) normalizePortName(portName string) string {
 	return portName
 }
 
+// applySystemDesc fills the hardware, vendor and OS version of a device from its system description
+func (p *LLDPParser) applySystemDesc(device *topology.Device, systemDesc string) {
+	p.descriptions.Apply(device, systemDesc)
+	if device.Hardware == "" {
+		device.Hardware = p.extractHardwareFromDesc(systemDesc)
+	}
+}
+
 func (p *LLDPParser) extractHardwareFromDesc(systemDesc string) string {
 	if systemDesc == "" {
 		return ""
 	}
 
-	// Common hardware patterns
-	patterns := []string{
-		`Cisco\s+(\w+\s*\d+\w*)`,
-		`Arista\s+DCS-(\d+\w*)`,
-		`Juniper\s+(\w+\s*\d+\w*)`,
-		`HP\s+(\w+\s*\d+\w*)`,
-		`Dell\s+(\w+\s*\d+\w*)`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		if matches := re.FindStringSubmatch(systemDesc); len(matches) > 1 {
-			return strings.TrimSpace(matches[1])
-		}
+	if parsed, ok := p.descriptions.Parse(systemDesc); ok && parsed.Model != "" {
+		return parsed.Model
 	}
 
-	// Extract first meaningful part of description
+	// 一致するパターンがなければ説明文の先頭の語を使う
 	parts := strings.Fields(systemDesc)
 	if len(parts) > 0 {
 		return parts[0]
@@ -0,0 +1,104 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// MAC table metrics mapping keys. Neither is configured by default.
+const (
+	MACTableMappingKey = "mac_table" // fields: device_id, port, mac, vlan
+	ARPTableMappingKey = "arp_table" // fields: mac, ip, hostname
+)
+
+// ExtractMACEntries extracts the MAC addresses learned on switch ports. When an arp_table
+// mapping is configured, entries are enriched with the IP address and hostname of the MAC.
+func (e *MetricsExtractor) ExtractMACEntries(ctx context.Context) ([]topology.MACEntry, []error) {
+	macConfig, exists := e.config.MetricsMapping[MACTableMappingKey]
+	if !exists {
+		return nil, []error{fmt.Errorf("%s mapping not found in configuration", MACTableMappingKey)}
+	}
+
+	results, mapping, warnings := e.queryWithFallbacks(ctx, macConfig, MACTableMappingKey)
+	if len(results) == 0 {
+		return nil, warnings
+	}
+
+	var entries []topology.MACEntry
+	for _, sample := range results {
+		entry := topology.MACEntry{}
+		entry.DeviceID, _ = e.extractLabelValue(sample.Metric, mapping.Labels, "device_id")
+		entry.Port, _ = e.extractLabelValue(sample.Metric, mapping.Labels, "port")
+		entry.MAC, _ = e.extractLabelValue(sample.Metric, mapping.Labels, "mac")
+		entry.VLAN, _ = e.extractLabelValue(sample.Metric, mapping.Labels, "vlan")
+		if entry.DeviceID == "" || entry.Port == "" || entry.MAC == "" {
+			continue // Skip incomplete entries
+		}
+		entries = append(entries, entry)
+	}
+	log.Printf("Extracted %d MAC table entries using metric '%s'", len(entries), mapping.Source())
+
+	// ARPテーブルは任意（IPアドレスとホスト名の補完のみ）
+	arpConfig, exists := e.config.MetricsMapping[ARPTableMappingKey]
+	if !exists {
+		return entries, warnings
+	}
+	arpResults, arpMapping, arpWarnings := e.queryWithFallbacks(ctx, arpConfig, ARPTableMappingKey)
+	warnings = append(warnings, arpWarnings...)
+
+	type arpEntry struct{ ip, hostname string }
+	arp := make(map[string]arpEntry)
+	for _, sample := range arpResults {
+		mac, _ := e.extractLabelValue(sample.Metric, arpMapping.Labels, "mac")
+		normalized, ok := topology.NormalizeMAC(mac)
+		if !ok {
+			continue
+		}
+		ip, _ := e.extractLabelValue(sample.Metric, arpMapping.Labels, "ip")
+		hostname, _ := e.extractLabelValue(sample.Metric, arpMapping.Labels, "hostname")
+		arp[normalized] = arpEntry{ip: ip, hostname: hostname}
+	}
+
+	for i := range entries {
+		if normalized, ok := topology.NormalizeMAC(entries[i].MAC); ok {
+			entries[i].IP = arp[normalized].ip
+			entries[i].Hostname = arp[normalized].hostname
+		}
+	}
+
+	return entries, warnings
+}
+
+// queryWithFallbacks returns the series of the first metric of the group that has any
+func (e *MetricsExtractor) queryWithFallbacks(ctx context.Context, group MetricConfigGroup, configKey string) ([]Result, MetricMapping, []error) {
+	var warnings []error
+	for i, mapping := range append([]MetricMapping{group.Primary}, group.Fallbacks...) {
+		query, err := e.selector(mapping)
+		if err == nil {
+			var result *QueryResult
+			if result, err = e.client.Query(ctx, query, time.Time{}); err == nil {
+				if err = e.checkQueryResult(result, mapping, configKey); err == nil {
+					if len(result.Data.Result) > 0 {
+						return result.Data.Result, mapping, warnings
+					}
+					err = fmt.Errorf("no series found")
+				}
+			}
+		}
+
+		if i == 0 {
+			warnings = append(warnings, fmt.Errorf("primary metric '%s' failed: %w", mapping.Source(), err))
+		} else {
+			warnings = append(warnings, fmt.Errorf("fallback %d metric '%s' failed: %w", i, mapping.Source(), err))
+		}
+		if errors.Is(err, ErrPartialResponse) {
+			break
+		}
+	}
+	return nil, MetricMapping{}, warnings
+}
@@ -0,0 +1,184 @@
+package prometheus
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Placeholders substituted in the query of a proxy metric
+const (
+	ProxyDevicePlaceholder    = "{device}"
+	ProxyInterfacePlaceholder = "{interface}"
+)
+
+// Defaults of ProxyConfig
+const (
+	DefaultProxyCacheTTL  = 30 * time.Second
+	DefaultProxyRateLimit = 5.0
+	DefaultProxyBurst     = 20
+	DefaultProxyMaxRange  = 24 * time.Hour
+	DefaultProxyMaxPoints = 500
+	DefaultProxyMaxSeries = 20
+)
+
+// ProxyMetric is a metric the API may query from Prometheus on behalf of the frontend.
+// Only the device and interface are filled in by the caller, so browsers never send PromQL.
+type ProxyMetric struct {
+	Query       string `yaml:"query"` // {device} と {interface} を置換（ダブルクォート内で使う）
+	Unit        string `yaml:"unit"`
+	Description string `yaml:"description"`
+}
+
+// PerInterface reports whether the metric needs an interface
+func (m ProxyMetric) PerInterface() bool {
+	return strings.Contains(m.Query, ProxyInterfacePlaceholder)
+}
+
+// Render substitutes the device and interface into the query. The values are escaped for a
+// double-quoted PromQL string so they cannot change the query.
+func (m ProxyMetric) Render(device, iface string) string {
+	return strings.NewReplacer(
+		ProxyDevicePlaceholder, escapePromQLString(device),
+		ProxyInterfacePlaceholder, escapePromQLString(iface),
+	).Replace(m.Query)
+}
+
+// escapePromQLString escapes value for use inside a double-quoted PromQL string
+func escapePromQLString(value string) string {
+	quoted := strconv.Quote(value)
+	return quoted[1 : len(quoted)-1]
+}
+
+// ProxyConfig configures the metrics proxy endpoint
+type ProxyConfig struct {
+	Disabled  bool                   `yaml:"disabled"`
+	Metrics   map[string]ProxyMetric `yaml:"metrics"`    // 空の場合は DefaultProxyMetrics
+	CacheTTL  time.Duration          `yaml:"cache_ttl"`  // 同じ問い合わせの応答を再利用する期間
+	RateLimit float64                `yaml:"rate_limit"` // Prometheus へ送るクエリの上限（毎秒）
+	Burst     int                    `yaml:"burst"`
+	MaxRange  time.Duration          `yaml:"max_range"`  // 範囲クエリの最大期間
+	MaxPoints int                    `yaml:"max_points"` // 1系列あたりの最大点数
+	MaxSeries int                    `yaml:"max_series"` // 返す系列数の上限
+
+	Utilization UtilizationQueries `yaml:"utilization"` // color_by=utilization のトラフィック
+}
+
+// UtilizationQueries select the interface traffic edges are colored by with color_by=utilization.
+// Each query returns the traffic in bps of every interface, labeled with the device and the
+// interface; the results are cached for cache_ttl like other metrics.
+type UtilizationQueries struct {
+	In             string `yaml:"in"`
+	Out            string `yaml:"out"`
+	DeviceLabel    string `yaml:"device_label"`
+	InterfaceLabel string `yaml:"interface_label"`
+}
+
+// DefaultUtilizationQueries assume the SNMP exporter (IF-MIB) like DefaultProxyMetrics
+var DefaultUtilizationQueries = UtilizationQueries{
+	In:             `rate(ifHCInOctets[5m]) * 8`,
+	Out:            `rate(ifHCOutOctets[5m]) * 8`,
+	DeviceLabel:    "instance",
+	InterfaceLabel: "ifName",
+}
+
+// DefaultProxyMetrics are the metrics offered when none are configured. They assume
+// node_exporter for CPU and memory and the SNMP exporter (IF-MIB) for interfaces.
+var DefaultProxyMetrics = map[string]ProxyMetric{
+	"cpu": {
+		Query:       `100 - avg(rate(node_cpu_seconds_total{instance="{device}",mode="idle"}[5m])) * 100`,
+		Unit:        "percent",
+		Description: "CPU utilization",
+	},
+	"memory": {
+		Query:       `100 * (1 - node_memory_MemAvailable_bytes{instance="{device}"} / node_memory_MemTotal_bytes{instance="{device}"})`,
+		Unit:        "percent",
+		Description: "Memory utilization",
+	},
+	"traffic_in": {
+		Query:       `rate(ifHCInOctets{instance="{device}",ifName="{interface}"}[5m]) * 8`,
+		Unit:        "bps",
+		Description: "Inbound traffic of an interface",
+	},
+	"traffic_out": {
+		Query:       `rate(ifHCOutOctets{instance="{device}",ifName="{interface}"}[5m]) * 8`,
+		Unit:        "bps",
+		Description: "Outbound traffic of an interface",
+	},
+	"errors": {
+		Query:       `rate(ifInErrors{instance="{device}",ifName="{interface}"}[5m]) + rate(ifOutErrors{instance="{device}",ifName="{interface}"}[5m])`,
+		Unit:        "errors/s",
+		Description: "Input and output errors of an interface",
+	},
+	"oper_status": {
+		Query:       `ifOperStatus{instance="{device}",ifName="{interface}"}`,
+		Description: "Operational status of an interface (1 = up, 2 = down)",
+	},
+}
+
+// WithDefaults returns the config with unset values replaced by their defaults
+func (c ProxyConfig) WithDefaults() ProxyConfig {
+	if len(c.Metrics) == 0 {
+		c.Metrics = DefaultProxyMetrics
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = DefaultProxyCacheTTL
+	}
+	if c.RateLimit == 0 {
+		c.RateLimit = DefaultProxyRateLimit
+	}
+	if c.Burst == 0 {
+		c.Burst = DefaultProxyBurst
+	}
+	if c.MaxRange == 0 {
+		c.MaxRange = DefaultProxyMaxRange
+	}
+	if c.MaxPoints == 0 {
+		c.MaxPoints = DefaultProxyMaxPoints
+	}
+	if c.MaxSeries == 0 {
+		c.MaxSeries = DefaultProxyMaxSeries
+	}
+	if c.Utilization.In == "" {
+		c.Utilization.In = DefaultUtilizationQueries.In
+	}
+	if c.Utilization.Out == "" {
+		c.Utilization.Out = DefaultUtilizationQueries.Out
+	}
+	if c.Utilization.DeviceLabel == "" {
+		c.Utilization.DeviceLabel = DefaultUtilizationQueries.DeviceLabel
+	}
+	if c.Utilization.InterfaceLabel == "" {
+		c.Utilization.InterfaceLabel = DefaultUtilizationQueries.InterfaceLabel
+	}
+	return c
+}
+
+// Validate checks the proxy settings
+func (c ProxyConfig) Validate() error {
+	names := make([]string, 0, len(c.Metrics))
+	for name := range c.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		metric := c.Metrics[name]
+		if strings.TrimSpace(metric.Query) == "" {
+			return fmt.Errorf("metric '%s': query cannot be empty", name)
+		}
+		if !strings.Contains(metric.Query, ProxyDevicePlaceholder) {
+			// デバイスで絞り込まないクエリは任意の系列を返せてしまう
+			return fmt.Errorf("metric '%s': query must select the device with %s", name, ProxyDevicePlaceholder)
+		}
+	}
+	if strings.ContainsAny(c.Utilization.DeviceLabel+c.Utilization.InterfaceLabel, " \t\"{}") {
+		return fmt.Errorf("utilization: device_label and interface_label must be label names")
+	}
+	if c.CacheTTL < 0 || c.RateLimit < 0 || c.Burst < 0 || c.MaxRange < 0 || c.MaxPoints < 0 || c.MaxSeries < 0 {
+		return fmt.Errorf("cache_ttl, rate_limit, burst, max_range, max_points and max_series must not be negative")
+	}
+	return nil
+}
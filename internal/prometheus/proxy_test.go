@@ -0,0 +1,41 @@
+package prometheus
+
+import "testing"
+
+func TestProxyMetric_Render(t *testing.T) {
+	metric := ProxyMetric{Query: `rate(ifHCInOctets{instance="{device}",ifName="{interface}"}[5m]) * 8`}
+	if !metric.PerInterface() {
+		t.Error("Expected metric with {interface} to be per-interface")
+	}
+
+	got := metric.Render("leaf-01", "Ethernet1/1")
+	want := `rate(ifHCInOctets{instance="leaf-01",ifName="Ethernet1/1"}[5m]) * 8`
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// 値に含まれる引用符でクエリを書き換えられないこと
+	got = metric.Render(`x"} or vector(1) or {a="`, `\`)
+	want = `rate(ifHCInOctets{instance="x\"} or vector(1) or {a=\"",ifName="\\"}[5m]) * 8`
+	if got != want {
+		t.Errorf("Expected escaped query %q, got %q", want, got)
+	}
+}
+
+func TestProxyConfig_Validate(t *testing.T) {
+	if err := (ProxyConfig{}).WithDefaults().Validate(); err != nil {
+		t.Errorf("Expected default proxy config to be valid, got %v", err)
+	}
+
+	invalid := []ProxyConfig{
+		{Metrics: map[string]ProxyMetric{"cpu": {Query: " "}}},
+		{Metrics: map[string]ProxyMetric{"all": {Query: `up`}}},
+		{RateLimit: -1},
+		{Utilization: UtilizationQueries{DeviceLabel: `instance"}`}},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", config)
+		}
+	}
+}
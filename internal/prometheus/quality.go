@@ -0,0 +1,219 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// chassisIDPattern matches neighbors identified only by a MAC-style chassis ID
+// (aa:bb:cc:dd:ee:ff, aa-bb-cc-dd-ee-ff, aabb.ccdd.eeff, aabbccddeeff)
+var chassisIDPattern = regexp.MustCompile(`(?i)^(?:[0-9a-f]{2}[:\-]){5}[0-9a-f]{2}$|^(?:[0-9a-f]{4}\.){2}[0-9a-f]{4}$|^[0-9a-f]{12}$`)
+
+// LLDPQualityReport summarizes problems found in raw LLDP metrics
+type LLDPQualityReport struct {
+	MetricName            string              `json:"metric_name"`
+	GeneratedAt           time.Time           `json:"generated_at"`
+	TotalSamples          int                 `json:"total_samples"`
+	UsableLinks           int                 `json:"usable_links"`
+	InventoryDevices      int                 `json:"inventory_devices"`
+	UnresolvableNeighbors []LLDPNeighborIssue `json:"unresolvable_neighbors"`
+	SkippedLinks          []LLDPNeighborIssue `json:"skipped_links"`
+	ChassisIDOnlyDevices  []LLDPNeighborIssue `json:"chassis_id_only_devices"`
+	DuplicateNeighbors    []LLDPNeighborIssue `json:"duplicate_neighbors"`
+	MissingFromTopology   []LLDPNeighborIssue `json:"missing_from_topology"`
+	Warnings              []string            `json:"warnings,omitempty"`
+}
+
+// LLDPNeighborIssue describes a single problematic LLDP neighbor entry
+type LLDPNeighborIssue struct {
+	SourceDevice string `json:"source_device"`
+	SourcePort   string `json:"source_port"`
+	TargetDevice string `json:"target_device"`
+	TargetPort   string `json:"target_port"`
+	Reason       string `json:"reason"`
+}
+
+// IssueCount returns the total number of issues in the report
+func (r *LLDPQualityReport) IssueCount() int {
+	return len(r.UnresolvableNeighbors) + len(r.SkippedLinks) + len(r.ChassisIDOnlyDevices) +
+		len(r.DuplicateNeighbors) + len(r.MissingFromTopology)
+}
+
+// AnalyzeLLDPQuality compares raw LLDP neighbor metrics against the device inventory and the
+// resulting topology, reporting why neighbors may be missing from the graph.
+// topologyDevices holds the device IDs currently stored in the topology database.
+func (e *MetricsExtractor) AnalyzeLLDPQuality(ctx context.Context, topologyDevices map[string]bool) (*LLDPQualityReport, error) {
+	linkConfig, exists := e.config.MetricsMapping["lldp_neighbors"]
+	if !exists {
+		return nil, fmt.Errorf("lldp_neighbors mapping not found in configuration")
+	}
+
+	report := &LLDPQualityReport{
+		GeneratedAt:           time.Now(),
+		UnresolvableNeighbors: []LLDPNeighborIssue{},
+		SkippedLinks:          []LLDPNeighborIssue{},
+		ChassisIDOnlyDevices:  []LLDPNeighborIssue{},
+		DuplicateNeighbors:    []LLDPNeighborIssue{},
+		MissingFromTopology:   []LLDPNeighborIssue{},
+	}
+
+	// 同期処理と同じ順序（プライマリ→フォールバック）で最初にデータを返したメトリクスを使用
+	var mapping MetricMapping
+	var samples []Result
+	for _, candidate := range append([]MetricMapping{linkConfig.Primary}, linkConfig.Fallbacks...) {
+		query, err := e.selector(candidate)
+		if err != nil {
+			return nil, err
+		}
+		result, err := e.client.Query(ctx, query, time.Time{})
+		if err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("metric '%s' failed: %v", candidate.Source(), err))
+			// 部分応答から欠落を報告すると誤検知になる
+			if errors.Is(err, ErrPartialResponse) {
+				break
+			}
+			continue
+		}
+		if len(result.Data.Result) == 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("metric '%s' returned no samples", candidate.Source()))
+			continue
+		}
+		if err := e.checkQueryResult(result, candidate, "lldp_neighbors"); err != nil {
+			report.Warnings = append(report.Warnings, err.Error())
+			continue
+		}
+		mapping = candidate
+		samples = result.Data.Result
+		break
+	}
+	if samples == nil {
+		return report, nil
+	}
+	report.MetricName = mapping.Source()
+	report.TotalSamples = len(samples)
+
+	// device_info 由来の監視対象デバイス
+	inventory := make(map[string]bool)
+	devices, warnings := e.ExtractDevices(ctx)
+	for _, warning := range warnings {
+		report.Warnings = append(report.Warnings, warning.Error())
+	}
+	for _, device := range devices {
+		inventory[device.ID] = true
+	}
+	report.InventoryDevices = len(inventory)
+
+	var required []string
+	if requirements, ok := e.config.FieldRequirements["lldp_neighbors"]; ok {
+		required = requirements.Required
+	}
+
+	neighborsByPort := make(map[string][]LLDPNeighborIssue)
+	var portOrder []string
+	unresolvedSeen := make(map[string]bool)
+	chassisSeen := make(map[string]bool)
+
+	for _, sample := range samples {
+		entry := LLDPNeighborIssue{}
+		entry.SourceDevice, _ = e.extractLabelValue(sample.Metric, mapping.Labels, "source_device")
+		entry.SourcePort, _ = e.extractLabelValue(sample.Metric, mapping.Labels, "source_port")
+		entry.TargetDevice, _ = e.extractLabelValue(sample.Metric, mapping.Labels, "target_device")
+		entry.TargetPort, _ = e.extractLabelValue(sample.Metric, mapping.Labels, "target_port")
+
+		// 必須フィールド不足でスキップされるリンク
+		if missing := e.missingLinkFields(entry, required); len(missing) > 0 {
+			entry.Reason = fmt.Sprintf("missing %s", strings.Join(missing, ", "))
+			report.SkippedLinks = append(report.SkippedLinks, entry)
+			continue
+		}
+		report.UsableLinks++
+
+		portKey := entry.SourceDevice + "|" + entry.SourcePort
+		if _, seen := neighborsByPort[portKey]; !seen {
+			portOrder = append(portOrder, portKey)
+		}
+		neighborsByPort[portKey] = append(neighborsByPort[portKey], entry)
+
+		for _, id := range []string{entry.SourceDevice, entry.TargetDevice} {
+			switch {
+			case chassisIDPattern.MatchString(id):
+				if !chassisSeen[id] {
+					chassisSeen[id] = true
+					issue := entry
+					issue.Reason = fmt.Sprintf("%s is identified only by chassis ID", id)
+					report.ChassisIDOnlyDevices = append(report.ChassisIDOnlyDevices, issue)
+				}
+			case !inventory[id]:
+				if !unresolvedSeen[id] {
+					unresolvedSeen[id] = true
+					issue := entry
+					issue.Reason = fmt.Sprintf("%s not found in device_info metrics (stored as placeholder)", id)
+					report.UnresolvableNeighbors = append(report.UnresolvableNeighbors, issue)
+				}
+			}
+		}
+
+		if topologyDevices != nil {
+			for _, id := range []string{entry.SourceDevice, entry.TargetDevice} {
+				if !topologyDevices[id] {
+					issue := entry
+					issue.Reason = fmt.Sprintf("%s not present in topology database", id)
+					report.MissingFromTopology = append(report.MissingFromTopology, issue)
+					break
+				}
+			}
+		}
+	}
+
+	// 同一ローカルポートに複数の隣接エントリがある場合は重複として報告
+	for _, portKey := range portOrder {
+		entries := neighborsByPort[portKey]
+		if len(entries) < 2 {
+			continue
+		}
+		targets := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			targets = append(targets, entry.TargetDevice+":"+entry.TargetPort)
+		}
+		sort.Strings(targets)
+
+		issue := entries[0]
+		issue.Reason = fmt.Sprintf("%d neighbor entries on the same local port: %s", len(entries), strings.Join(targets, ", "))
+		report.DuplicateNeighbors = append(report.DuplicateNeighbors, issue)
+	}
+
+	return report, nil
+}
+
+// missingLinkFields returns the required link fields that are empty in entry
+func (e *MetricsExtractor) missingLinkFields(entry LLDPNeighborIssue, required []string) []string {
+	values := map[string]string{
+		"source_device": entry.SourceDevice,
+		"target_device": entry.TargetDevice,
+		"source_port":   entry.SourcePort,
+		"target_port":   entry.TargetPort,
+	}
+
+	// source/target は同期処理で常に必須
+	var missing []string
+	for _, field := range []string{"source_device", "target_device"} {
+		if values[field] == "" {
+			missing = append(missing, field)
+		}
+	}
+	for _, field := range required {
+		if field == "source_device" || field == "target_device" {
+			continue
+		}
+		if value, ok := values[field]; ok && value == "" {
+			missing = append(missing, field)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,166 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newQualityFixtureClient returns a client of a server answering queries for each metric with its body
+func newQualityFixtureClient(t *testing.T, bodies map[string]string) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		query := r.URL.Query().Get("query")
+		for metric, body := range bodies {
+			if strings.HasPrefix(query, `{__name__="`+metric+`"`) {
+				w.Write([]byte(body))
+				return
+			}
+		}
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(Config{URL: server.URL})
+}
+
+func newQualityExtractor(t *testing.T, lldpBody string) *MetricsExtractor {
+	client := newQualityFixtureClient(t, map[string]string{
+		"snmp_device_info": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"instance":"leaf-01"},"value":[1760000000,"1"]},
+			{"metric":{"instance":"spine-01"},"value":[1760000000,"1"]}]}}`,
+		"lldp_remote_info": lldpBody,
+	})
+	lldpMapping := func(metric string) MetricMapping {
+		return MetricMapping{MetricName: metric, Labels: map[string]string{
+			"source_device": "instance",
+			"source_port":   "ifName",
+			"target_device": "remote_name",
+			"target_port":   "remote_port",
+		}}
+	}
+	return NewMetricsExtractor(client, &MetricsConfig{
+		MetricsMapping: map[string]MetricConfigGroup{
+			"device_info": {Primary: MetricMapping{MetricName: "snmp_device_info", Labels: map[string]string{"device_id": "instance"}}},
+			"lldp_neighbors": {
+				Primary:   lldpMapping("lldp_remote_info_v2"),
+				Fallbacks: []MetricMapping{lldpMapping("lldp_remote_info")},
+			},
+		},
+		FieldRequirements: map[string]FieldRequirement{
+			"lldp_neighbors": {Required: []string{"target_port"}},
+		},
+	})
+}
+
+func TestAnalyzeLLDPQuality(t *testing.T) {
+	extractor := newQualityExtractor(t, `{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"instance":"leaf-01","ifName":"Eth1","remote_name":"spine-01","remote_port":"Eth1"},"value":[1760000000,"1"]},
+		{"metric":{"instance":"leaf-01","ifName":"Eth2","remote_name":"leaf-02","remote_port":"Eth1"},"value":[1760000000,"1"]},
+		{"metric":{"instance":"leaf-01","ifName":"Eth3","remote_name":"aa:bb:cc:dd:ee:ff","remote_port":"Eth1"},"value":[1760000000,"1"]},
+		{"metric":{"instance":"leaf-01","ifName":"Eth1","remote_name":"spine-02","remote_port":"Eth1"},"value":[1760000000,"1"]},
+		{"metric":{"instance":"leaf-01","ifName":"Eth5","remote_port":"Eth1"},"value":[1760000000,"1"]},
+		{"metric":{"instance":"leaf-01","ifName":"Eth4","remote_name":"spine-01"},"value":[1760000000,"1"]}]}}`)
+
+	report, err := extractor.AnalyzeLLDPQuality(context.Background(), map[string]bool{"leaf-01": true, "spine-01": true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// プライマリが空ならフォールバックのメトリクスを分析する
+	if report.MetricName != "lldp_remote_info" {
+		t.Errorf("Expected the fallback metric to be analyzed, got %q", report.MetricName)
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "returned no samples") {
+		t.Errorf("Expected a warning for the empty primary metric, got %v", report.Warnings)
+	}
+	if report.TotalSamples != 6 || report.UsableLinks != 4 || report.InventoryDevices != 2 {
+		t.Errorf("Expected 6 samples, 4 usable links and 2 inventory devices, got %d, %d and %d",
+			report.TotalSamples, report.UsableLinks, report.InventoryDevices)
+	}
+
+	skipped := make(map[string]string)
+	for _, issue := range report.SkippedLinks {
+		skipped[issue.SourcePort] = issue.Reason
+	}
+	if len(skipped) != 2 || skipped["Eth5"] != "missing target_device" || skipped["Eth4"] != "missing target_port" {
+		t.Errorf("Expected the links missing required fields to be skipped, got %v", skipped)
+	}
+
+	var unresolvable []string
+	for _, issue := range report.UnresolvableNeighbors {
+		unresolvable = append(unresolvable, issue.TargetDevice)
+	}
+	if strings.Join(unresolvable, ",") != "leaf-02,spine-02" {
+		t.Errorf("Expected neighbors missing from device_info to be unresolvable, got %v", unresolvable)
+	}
+
+	// シャーシIDのみの隣接はインベントリ外でも未解決としては数えない
+	if len(report.ChassisIDOnlyDevices) != 1 || report.ChassisIDOnlyDevices[0].TargetDevice != "aa:bb:cc:dd:ee:ff" {
+		t.Errorf("Expected one chassis ID only neighbor, got %+v", report.ChassisIDOnlyDevices)
+	}
+
+	if len(report.DuplicateNeighbors) != 1 {
+		t.Fatalf("Expected one duplicate local port, got %+v", report.DuplicateNeighbors)
+	}
+	duplicate := report.DuplicateNeighbors[0]
+	if duplicate.SourcePort != "Eth1" || !strings.Contains(duplicate.Reason, "spine-01:Eth1, spine-02:Eth1") {
+		t.Errorf("Expected both neighbors on leaf-01 Eth1 to be listed, got %+v", duplicate)
+	}
+
+	if len(report.MissingFromTopology) != 3 {
+		t.Errorf("Expected the links to devices outside the topology to be reported, got %+v", report.MissingFromTopology)
+	}
+	if report.IssueCount() != 2+2+1+1+3 {
+		t.Errorf("Expected every issue to be counted, got %d", report.IssueCount())
+	}
+}
+
+func TestAnalyzeLLDPQuality_WithoutTopology(t *testing.T) {
+	extractor := newQualityExtractor(t, `{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"instance":"leaf-01","ifName":"Eth1","remote_name":"leaf-02","remote_port":"Eth1"},"value":[1760000000,"1"]}]}}`)
+
+	// nil のトポロジーでは欠落を報告しない
+	report, err := extractor.AnalyzeLLDPQuality(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(report.MissingFromTopology) != 0 {
+		t.Errorf("Expected no topology comparison without topology devices, got %+v", report.MissingFromTopology)
+	}
+	if len(report.UnresolvableNeighbors) != 1 {
+		t.Errorf("Expected leaf-02 to be unresolvable, got %+v", report.UnresolvableNeighbors)
+	}
+}
+
+func TestAnalyzeLLDPQuality_PartialResponse(t *testing.T) {
+	client := newQualityFixtureClient(t, map[string]string{"lldp_remote_info_v2": thanosPartialResponse})
+	extractor := NewMetricsExtractor(client, &MetricsConfig{
+		MetricsMapping: map[string]MetricConfigGroup{
+			"lldp_neighbors": {
+				Primary:   MetricMapping{MetricName: "lldp_remote_info_v2"},
+				Fallbacks: []MetricMapping{{MetricName: "lldp_remote_info"}},
+			},
+		},
+	})
+
+	// 部分応答ではフォールバックを試さず、何も分析しない
+	report, err := extractor.AnalyzeLLDPQuality(context.Background(), map[string]bool{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.TotalSamples != 0 || report.IssueCount() != 0 {
+		t.Errorf("Expected nothing to be reported from a partial response, got %+v", report)
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "partial") {
+		t.Errorf("Expected one partial response warning, got %v", report.Warnings)
+	}
+}
+
+func TestAnalyzeLLDPQuality_MissingMapping(t *testing.T) {
+	extractor := NewMetricsExtractor(nil, &MetricsConfig{})
+	if _, err := extractor.AnalyzeLLDPQuality(context.Background(), nil); err == nil {
+		t.Error("Expected an error without an lldp_neighbors mapping")
+	}
+}
@@ -0,0 +1,21 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.RunWithPostgres(m))
+}
+
+func TestClearDeviceClassifications_Postgres(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	setup.SeedTestData(t)
+
+	testClearDeviceClassifications(t, setup.Repo)
+}
@@ -0,0 +1,46 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClearDeviceClassifications checks that a backend resets classifications to the same
+// value as every other backend. repo must hold the test seed data.
+func testClearDeviceClassifications(t *testing.T, repo repository.Repository) {
+	t.Helper()
+
+	consistencyRepo, ok := repo.(topology.ConsistencyRepository)
+	require.True(t, ok, "repository should support consistency repairs")
+	ctx := context.Background()
+
+	cleared, err := consistencyRepo.ClearDeviceClassifications(ctx, []string{"device-001", "device-002", "no-such-device"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, cleared)
+
+	for _, id := range []string{"device-001", "device-002"} {
+		device, err := repo.GetDevice(ctx, id)
+		require.NoError(t, err)
+		assert.Nil(t, device.LayerID, id)
+		assert.Empty(t, device.DeviceType, id)
+		assert.Equal(t, "system:auto", device.ClassifiedBy, id)
+	}
+
+	untouched, err := repo.GetDevice(ctx, "device-003")
+	require.NoError(t, err)
+	assert.Equal(t, "rule:Test Rule", untouched.ClassifiedBy)
+}
+
+func TestClearDeviceClassifications_SQLite(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+
+	testClearDeviceClassifications(t, setup.Repo)
+}
@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository/postgres"
+	"github.com/servak/topology-manager/internal/repository/sqlite"
+)
+
+// ParseDatabaseURL parses "sqlite:<path>" or "postgres://..." into a database configuration
+func ParseDatabaseURL(value string) (Config, error) {
+	switch {
+	case strings.HasPrefix(value, "sqlite:"):
+		path := strings.TrimPrefix(value, "sqlite:")
+		if path == "" {
+			return Config{}, fmt.Errorf("sqlite path is required")
+		}
+		return Config{Type: "sqlite", SQLite: sqlite.Config{Path: path}}, nil
+	case strings.HasPrefix(value, "postgres:"), strings.HasPrefix(value, "postgresql:"):
+		return Config{Type: "postgres", Postgres: postgres.Config{DSN: value}}, nil
+	default:
+		return Config{}, fmt.Errorf("unsupported database URL '%s' (expected sqlite:<path> or postgres://...)", value)
+	}
+}
+
+// CopyOptions controls CopyData
+type CopyOptions struct {
+	BatchSize int
+	// Progress is called after each batch with the entity name and the number of rows copied so far
+	Progress func(entity string, copied int)
+}
+
+// CopyResult reports the number of rows of an entity in the source and the target after copying.
+// Mismatched lists the IDs of rows found in the target whose content differs from the source.
+type CopyResult struct {
+	Entity     string   `json:"entity"`
+	Source     int      `json:"source"`
+	Target     int      `json:"target"`
+	Mismatched []string `json:"mismatched,omitempty"`
+	Verified   bool     `json:"verified"`
+}
+
+func newCopyResult(entity string, source, target int, mismatched []string) CopyResult {
+	return CopyResult{
+		Entity:     entity,
+		Source:     source,
+		Target:     target,
+		Mismatched: mismatched,
+		Verified:   target == source && len(mismatched) == 0,
+	}
+}
+
+// CopyData copies hierarchy layers, devices, links, classification rules and pending suggestions
+// from src to dst in batches, then verifies that every source row exists in the target. Layers,
+// devices and links are also compared field by field (metadata included, timestamps excluded), so
+// a backend that silently drops a column fails the verification.
+// The target schema must already be migrated. Existing rows with the same ID are overwritten
+// (rules and suggestions are kept), so the copy can be re-run after a failure.
+func CopyData(ctx context.Context, src, dst Repository, opts CopyOptions) ([]CopyResult, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(string, int) {}
+	}
+
+	var results []CopyResult
+
+	// 1. 階層レイヤー（デバイスが layer_id で参照するため最初にIDを保ったままコピー）
+	layers, err := src.ListHierarchyLayers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source layers: %w", err)
+	}
+	for _, layer := range layers {
+		if err := dst.SaveHierarchyLayer(ctx, layer); err != nil {
+			return nil, fmt.Errorf("failed to copy layer %d: %w", layer.ID, err)
+		}
+	}
+	progress("layers", len(layers))
+
+	targetLayers, err := dst.ListHierarchyLayers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list target layers: %w", err)
+	}
+	targetLayerByID := make(map[int]classification.HierarchyLayer, len(targetLayers))
+	for _, layer := range targetLayers {
+		targetLayerByID[layer.ID] = layer
+	}
+	copiedLayers := 0
+	var mismatchedLayers []string
+	for _, layer := range layers {
+		target, ok := targetLayerByID[layer.ID]
+		if !ok {
+			continue
+		}
+		copiedLayers++
+		if !sameLayer(layer, target) {
+			mismatchedLayers = append(mismatchedLayers, strconv.Itoa(layer.ID))
+		}
+	}
+	results = append(results, newCopyResult("layers", len(layers), copiedLayers, mismatchedLayers))
+
+	// 2. デバイス（ID 順のカーソルでページ単位にバッチコピー）
+	var deviceIDs []string
+	var mismatchedDevices []string
+	targetDevices := 0
+	pageOpts := topology.PaginationOptions{PageSize: opts.BatchSize, Keyset: true}
+	for {
+		devices, pagination, err := src.GetDevices(ctx, pageOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source devices (after %q): %w", pageOpts.After, err)
+		}
+		if len(devices) == 0 {
+			break
+		}
+		if err := dst.BulkAddDevices(ctx, devices); err != nil {
+			return nil, fmt.Errorf("failed to copy devices (after %q): %w", pageOpts.After, err)
+		}
+		for _, device := range devices {
+			deviceIDs = append(deviceIDs, device.ID)
+		}
+		progress("devices", len(deviceIDs))
+
+		// ページを保持せずに済むよう、書き込んだページごとに照合する
+		for _, device := range devices {
+			target, err := dst.GetDevice(ctx, device.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to verify device %s: %w", device.ID, err)
+			}
+			if target == nil {
+				continue
+			}
+			targetDevices++
+			if !sameDevice(device, *target) {
+				mismatchedDevices = append(mismatchedDevices, device.ID)
+			}
+		}
+
+		if pagination == nil || !pagination.HasNext {
+			break
+		}
+		pageOpts.After = devices[len(devices)-1].ID
+	}
+
+	results = append(results, newCopyResult("devices", len(deviceIDs), targetDevices, mismatchedDevices))
+
+	// 3. リンク（両端のデバイスがコピーされた後に投入）
+	sourceLinks, err := collectLinks(ctx, src, deviceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source links: %w", err)
+	}
+	for start := 0; start < len(sourceLinks); start += opts.BatchSize {
+		end := start + opts.BatchSize
+		if end > len(sourceLinks) {
+			end = len(sourceLinks)
+		}
+		if err := dst.BulkAddLinks(ctx, sourceLinks[start:end]); err != nil {
+			return nil, fmt.Errorf("failed to copy links: %w", err)
+		}
+		progress("links", end)
+	}
+
+	targetLinks, err := collectLinks(ctx, dst, deviceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify links: %w", err)
+	}
+	targetLinkByID := make(map[string]topology.Link, len(targetLinks))
+	for _, link := range targetLinks {
+		targetLinkByID[link.ID] = link
+	}
+	copiedLinks := 0
+	var mismatchedLinks []string
+	for _, link := range sourceLinks {
+		target, ok := targetLinkByID[link.ID]
+		if !ok {
+			continue
+		}
+		copiedLinks++
+		if !sameLink(link, target) {
+			mismatchedLinks = append(mismatchedLinks, link.ID)
+		}
+	}
+	results = append(results, newCopyResult("links", len(sourceLinks), copiedLinks, mismatchedLinks))
+
+	// 4. 分類ルール（既存のルールは上書きしない）
+	rules, err := src.ListClassificationRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source rules: %w", err)
+	}
+	copiedRules := 0
+	for _, rule := range rules {
+		existing, err := dst.GetClassificationRule(ctx, rule.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check rule %s: %w", rule.ID, err)
+		}
+		if existing == nil {
+			if err := dst.SaveClassificationRule(ctx, rule); err != nil {
+				return nil, fmt.Errorf("failed to copy rule %s: %w", rule.ID, err)
+			}
+		}
+		copiedRules++
+	}
+	progress("rules", copiedRules)
+	results = append(results, newCopyResult("rules", len(rules), copiedRules, nil))
+
+	// 5. 保留中のルール提案
+	suggestions, err := src.ListPendingClassificationSuggestions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source suggestions: %w", err)
+	}
+	copiedSuggestions := 0
+	for _, suggestion := range suggestions {
+		existing, err := dst.GetClassificationSuggestion(ctx, suggestion.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check suggestion %s: %w", suggestion.ID, err)
+		}
+		if existing == nil {
+			if err := dst.SaveClassificationSuggestion(ctx, suggestion); err != nil {
+				return nil, fmt.Errorf("failed to copy suggestion %s: %w", suggestion.ID, err)
+			}
+		}
+		copiedSuggestions++
+	}
+	progress("suggestions", copiedSuggestions)
+	results = append(results, newCopyResult("suggestions", len(suggestions), copiedSuggestions, nil))
+
+	return results, nil
+}
+
+// collectLinks returns the links touching deviceIDs, each link once
+func collectLinks(ctx context.Context, repo Repository, deviceIDs []string) ([]topology.Link, error) {
+	seen := make(map[string]bool)
+	var links []topology.Link
+	for _, deviceID := range deviceIDs {
+		deviceLinks, err := repo.GetDeviceLinks(ctx, deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links of device %s: %w", deviceID, err)
+		}
+		for _, link := range deviceLinks {
+			if !seen[link.ID] {
+				seen[link.ID] = true
+				links = append(links, link)
+			}
+		}
+	}
+	return links, nil
+}
+
+// sameLayer reports whether the copied layer kept every field but the timestamps
+func sameLayer(source, target classification.HierarchyLayer) bool {
+	return source.Name == target.Name && source.Description == target.Description &&
+		source.Order == target.Order && source.Color == target.Color
+}
+
+// sameDevice reports whether the copied device kept every field but the timestamps, which the
+// backends store with different precision
+func sameDevice(source, target topology.Device) bool {
+	return source.Type == target.Type && source.Hardware == target.Hardware &&
+		equalIntPtr(source.LayerID, target.LayerID) && source.DeviceType == target.DeviceType &&
+		source.ClassifiedBy == target.ClassifiedBy && source.Provenance == target.Provenance &&
+		source.WorkflowState == target.WorkflowState && source.Rack == target.Rack &&
+		equalIntPtr(source.RackPosition, target.RackPosition) && source.RackUnits == target.RackUnits &&
+		equalMetadata(source.Metadata, target.Metadata)
+}
+
+// sameLink reports whether the copied link kept every field but the timestamps
+func sameLink(source, target topology.Link) bool {
+	return source.SourceID == target.SourceID && source.TargetID == target.TargetID &&
+		source.SourcePort == target.SourcePort && source.TargetPort == target.TargetPort &&
+		source.Weight == target.Weight && equalMetadata(source.Metadata, target.Metadata)
+}
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// equalMetadata compares metadata treating nil and empty maps as equal
+func equalMetadata(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
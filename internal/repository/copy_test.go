@@ -0,0 +1,114 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metadataDroppingRepository stores links without their metadata, like the Postgres backend did
+type metadataDroppingRepository struct {
+	repository.Repository
+}
+
+func (r metadataDroppingRepository) BulkAddLinks(ctx context.Context, links []topology.Link) error {
+	stripped := make([]topology.Link, len(links))
+	for i, link := range links {
+		link.Metadata = nil
+		stripped[i] = link
+	}
+	return r.Repository.BulkAddLinks(ctx, stripped)
+}
+
+func newCopySource(t *testing.T) repository.Repository {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+
+	ctx := context.Background()
+	device, err := setup.Repo.GetDevice(ctx, "device-001")
+	require.NoError(t, err)
+	device.Metadata = map[string]string{"site": "tokyo"}
+	require.NoError(t, setup.Repo.UpdateDevice(ctx, *device))
+
+	link, err := setup.Repo.GetLink(ctx, "link-001")
+	require.NoError(t, err)
+	link.Metadata = map[string]string{"speed": "100"}
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, []topology.Link{*link}))
+	return setup.Repo
+}
+
+func newCopyTarget(t *testing.T) repository.Repository {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	return setup.Repo
+}
+
+func copyResultsByEntity(results []repository.CopyResult) map[string]repository.CopyResult {
+	byEntity := make(map[string]repository.CopyResult, len(results))
+	for _, result := range results {
+		byEntity[result.Entity] = result
+	}
+	return byEntity
+}
+
+func TestCopyData(t *testing.T) {
+	src, dst := newCopySource(t), newCopyTarget(t)
+	ctx := context.Background()
+
+	var progress []string
+	results, err := repository.CopyData(ctx, src, dst, repository.CopyOptions{
+		BatchSize: 2,
+		Progress:  func(entity string, copied int) { progress = append(progress, entity) },
+	})
+	require.NoError(t, err)
+
+	byEntity := copyResultsByEntity(results)
+	for _, entity := range []string{"layers", "devices", "links", "rules", "suggestions"} {
+		require.Contains(t, byEntity, entity)
+		assert.True(t, byEntity[entity].Verified, "%s: %+v", entity, byEntity[entity])
+		assert.Empty(t, byEntity[entity].Mismatched, entity)
+	}
+	assert.Equal(t, 3, byEntity["devices"].Target)
+	assert.Equal(t, 2, byEntity["links"].Target)
+	// 3台を2台ずつのバッチでコピーする
+	assert.Equal(t, []string{"layers", "devices", "devices", "links", "rules", "suggestions"}, progress)
+
+	device, err := dst.GetDevice(ctx, "device-001")
+	require.NoError(t, err)
+	require.NotNil(t, device)
+	assert.Equal(t, map[string]string{"site": "tokyo"}, device.Metadata)
+	link, err := dst.GetLink(ctx, "link-001")
+	require.NoError(t, err)
+	require.NotNil(t, link)
+	assert.Equal(t, map[string]string{"speed": "100"}, link.Metadata)
+
+	// 再実行しても同じ結果になる
+	results, err = repository.CopyData(ctx, src, dst, repository.CopyOptions{})
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.True(t, result.Verified, "%+v", result)
+	}
+}
+
+func TestCopyData_DetectsLostMetadata(t *testing.T) {
+	src := newCopySource(t)
+	dst := metadataDroppingRepository{Repository: newCopyTarget(t)}
+
+	results, err := repository.CopyData(context.Background(), src, dst, repository.CopyOptions{})
+	require.NoError(t, err)
+
+	byEntity := copyResultsByEntity(results)
+	links := byEntity["links"]
+	assert.Equal(t, 2, links.Source)
+	assert.Equal(t, 2, links.Target, "every link exists in the target")
+	assert.Contains(t, links.Mismatched, "link-001")
+	assert.False(t, links.Verified, "matching counts must not pass when metadata was lost")
+
+	assert.True(t, byEntity["devices"].Verified)
+}
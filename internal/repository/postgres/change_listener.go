@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// changeChannel is the channel the notify_topology_change trigger publishes to (033_notify_topology_changes.sql)
+const changeChannel = "topology_changes"
+
+const (
+	listenerMinReconnect = time.Second
+	listenerMaxReconnect = time.Minute
+	// listenerPingInterval detects a silently dropped connection while no notification arrives
+	listenerPingInterval = 90 * time.Second
+)
+
+// ListenChanges listens for the device and link change notifications on a dedicated connection,
+// reconnecting when it is lost
+func (r *postgresRepository) ListenChanges(ctx context.Context, handle func(topology.Change)) error {
+	listener := pq.NewListener(r.dsn, listenerMinReconnect, listenerMaxReconnect, nil)
+	defer listener.Close()
+
+	if err := listener.Listen(changeChannel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", changeChannel, err)
+	}
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			if notification == nil {
+				// 再接続した。切断中の変更は届かないため再読み込みを促す
+				handle(topology.Change{Op: topology.ChangeOpResync, ReceivedAt: time.Now()})
+				continue
+			}
+			change, err := topology.ParseChange(notification.Extra, time.Now())
+			if err != nil {
+				continue // 他の用途で同じチャンネルに送られた通知は無視する
+			}
+			handle(change)
+		case <-ticker.C:
+			// 失敗すると pq が再接続し、nil の通知が届く
+			go listener.Ping()
+		}
+	}
+}
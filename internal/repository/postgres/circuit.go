@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Circuit repository methods
+
+const circuitColumns = `id, provider, a_device, a_port, z_device, z_port, link_id, description, updated_at`
+
+// SearchCircuits retrieves circuits whose ID, provider, end devices or description contain query
+func (r *postgresRepository) SearchCircuits(ctx context.Context, query string, limit int) ([]topology.Circuit, error) {
+	searchQuery := `
+		SELECT ` + circuitColumns + ` FROM circuits
+		WHERE id ILIKE $1 OR provider ILIKE $1 OR a_device ILIKE $1 OR z_device ILIKE $1 OR description ILIKE $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	return r.queryCircuits(ctx, searchQuery, "%"+escapeLike(query)+"%", limit)
+}
+
+// GetCircuit retrieves a circuit by ID
+func (r *postgresRepository) GetCircuit(ctx context.Context, circuitID string) (*topology.Circuit, error) {
+	query := `SELECT ` + circuitColumns + ` FROM circuits WHERE id = $1`
+
+	circuit, err := scanCircuit(r.db.QueryRowContext(ctx, query, circuitID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return circuit, nil
+}
+
+// ListLinkCircuits retrieves the circuits attached to a link
+func (r *postgresRepository) ListLinkCircuits(ctx context.Context, linkID string) ([]topology.Circuit, error) {
+	query := `SELECT ` + circuitColumns + ` FROM circuits WHERE link_id = $1 ORDER BY id`
+
+	return r.queryCircuits(ctx, query, linkID)
+}
+
+// SaveCircuit creates or replaces a circuit
+func (r *postgresRepository) SaveCircuit(ctx context.Context, circuit topology.Circuit) error {
+	if circuit.UpdatedAt.IsZero() {
+		circuit.UpdatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO circuits (` + circuitColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			provider = EXCLUDED.provider,
+			a_device = EXCLUDED.a_device,
+			a_port = EXCLUDED.a_port,
+			z_device = EXCLUDED.z_device,
+			z_port = EXCLUDED.z_port,
+			link_id = EXCLUDED.link_id,
+			description = EXCLUDED.description,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		circuit.ID, circuit.Provider, circuit.ADevice, circuit.APort, circuit.ZDevice, circuit.ZPort,
+		sql.NullString{String: circuit.LinkID, Valid: circuit.LinkID != ""}, circuit.Description, circuit.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save circuit: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCircuit removes a circuit
+func (r *postgresRepository) DeleteCircuit(ctx context.Context, circuitID string) error {
+	query := `DELETE FROM circuits WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, circuitID)
+	if err != nil {
+		return fmt.Errorf("failed to delete circuit: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRepository) queryCircuits(ctx context.Context, query string, args ...interface{}) ([]topology.Circuit, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query circuits: %w", err)
+	}
+	defer rows.Close()
+
+	circuits := []topology.Circuit{}
+	for rows.Next() {
+		circuit, err := scanCircuit(rows)
+		if err != nil {
+			return nil, err
+		}
+		circuits = append(circuits, *circuit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate circuits: %w", err)
+	}
+
+	return circuits, nil
+}
+
+type circuitScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCircuit(row circuitScanner) (*topology.Circuit, error) {
+	var circuit topology.Circuit
+	var linkID sql.NullString
+
+	err := row.Scan(&circuit.ID, &circuit.Provider, &circuit.ADevice, &circuit.APort, &circuit.ZDevice, &circuit.ZPort,
+		&linkID, &circuit.Description, &circuit.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan circuit: %w", err)
+	}
+	circuit.LinkID = linkID.String
+
+	return &circuit, nil
+}
@@ -64,6 +64,10 @@ func (r *postgresRepository) ListDeviceClassifications(ctx context.Context) ([]c
 		classifications = append(classifications, c)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate device classifications: %w", err)
+	}
+
 	return classifications, nil
 }
 
@@ -91,6 +95,10 @@ func (r *postgresRepository) ListUnclassifiedDevices(ctx context.Context) ([]str
 		deviceIDs = append(deviceIDs, deviceID)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate unclassified devices: %w", err)
+	}
+
 	return deviceIDs, nil
 }
 
@@ -119,6 +127,10 @@ func (r *postgresRepository) ListUnclassifiedDevicesWithPagination(ctx context.C
 		deviceIDs = append(deviceIDs, deviceID)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate unclassified devices: %w", err)
+	}
+
 	return deviceIDs, nil
 }
 
@@ -188,7 +200,7 @@ func (r *postgresRepository) DeleteDeviceClassification(ctx context.Context, dev
 // Classification Rules
 func (r *postgresRepository) GetClassificationRule(ctx context.Context, ruleID string) (*classification.ClassificationRule, error) {
 	query := `
-		SELECT id, name, description, logic_operator, conditions, layer, device_type, priority, is_active, created_by, created_at, updated_at
+		SELECT id, name, description, logic_operator, conditions, layer, device_type, priority, is_active, shadow, created_by, created_at, updated_at
 		FROM classification_rules 
 		WHERE id = $1
 	`
@@ -197,7 +209,7 @@ func (r *postgresRepository) GetClassificationRule(ctx context.Context, ruleID s
 	var conditionsJSON []byte
 	err := r.db.QueryRowContext(ctx, query, ruleID).Scan(
 		&rule.ID, &rule.Name, &rule.Description, &rule.LogicOperator, &conditionsJSON,
-		&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+		&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Shadow, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -217,7 +229,7 @@ func (r *postgresRepository) GetClassificationRule(ctx context.Context, ruleID s
 
 func (r *postgresRepository) ListClassificationRules(ctx context.Context) ([]classification.ClassificationRule, error) {
 	query := `
-		SELECT id, name, description, logic_operator, conditions, layer, device_type, priority, is_active, created_by, created_at, updated_at
+		SELECT id, name, description, logic_operator, conditions, layer, device_type, priority, is_active, shadow, created_by, created_at, updated_at
 		FROM classification_rules 
 		ORDER BY priority DESC, created_at DESC
 	`
@@ -234,7 +246,7 @@ func (r *postgresRepository) ListClassificationRules(ctx context.Context) ([]cla
 		var conditionsJSON []byte
 		err := rows.Scan(
 			&rule.ID, &rule.Name, &rule.Description, &rule.LogicOperator, &conditionsJSON,
-			&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+			&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Shadow, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan classification rule: %w", err)
@@ -248,12 +260,16 @@ func (r *postgresRepository) ListClassificationRules(ctx context.Context) ([]cla
 		rules = append(rules, rule)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate classification rules: %w", err)
+	}
+
 	return rules, nil
 }
 
 func (r *postgresRepository) ListActiveClassificationRules(ctx context.Context) ([]classification.ClassificationRule, error) {
 	query := `
-		SELECT id, name, description, logic_operator, conditions, layer, device_type, priority, is_active, created_by, created_at, updated_at
+		SELECT id, name, description, logic_operator, conditions, layer, device_type, priority, is_active, shadow, created_by, created_at, updated_at
 		FROM classification_rules 
 		WHERE is_active = true
 		ORDER BY priority DESC, created_at DESC
@@ -271,7 +287,7 @@ func (r *postgresRepository) ListActiveClassificationRules(ctx context.Context)
 		var conditionsJSON []byte
 		err := rows.Scan(
 			&rule.ID, &rule.Name, &rule.Description, &rule.LogicOperator, &conditionsJSON,
-			&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+			&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Shadow, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan classification rule: %w", err)
@@ -285,6 +301,10 @@ func (r *postgresRepository) ListActiveClassificationRules(ctx context.Context)
 		rules = append(rules, rule)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate classification rules: %w", err)
+	}
+
 	return rules, nil
 }
 
@@ -307,13 +327,13 @@ func (r *postgresRepository) SaveClassificationRule(ctx context.Context, rule cl
 	}
 
 	query := `
-		INSERT INTO classification_rules (id, name, description, logic_operator, conditions, layer, device_type, priority, is_active, created_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO classification_rules (id, name, description, logic_operator, conditions, layer, device_type, priority, is_active, shadow, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
 	_, err = r.db.ExecContext(ctx, query,
 		rule.ID, rule.Name, rule.Description, rule.LogicOperator, conditionsJSON,
-		rule.Layer, rule.DeviceType, rule.Priority, rule.IsActive, rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt,
+		rule.Layer, rule.DeviceType, rule.Priority, rule.IsActive, rule.Shadow, rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt,
 	)
 
 	if err != nil {
@@ -335,13 +355,13 @@ func (r *postgresRepository) UpdateClassificationRule(ctx context.Context, rule
 	query := `
 		UPDATE classification_rules 
 		SET name = $2, description = $3, logic_operator = $4, conditions = $5, 
-		    layer = $6, device_type = $7, priority = $8, is_active = $9, updated_at = $10
+		    layer = $6, device_type = $7, priority = $8, is_active = $9, shadow = $10, updated_at = $11
 		WHERE id = $1
 	`
 
 	_, err = r.db.ExecContext(ctx, query,
 		rule.ID, rule.Name, rule.Description, rule.LogicOperator, conditionsJSON,
-		rule.Layer, rule.DeviceType, rule.Priority, rule.IsActive, rule.UpdatedAt,
+		rule.Layer, rule.DeviceType, rule.Priority, rule.IsActive, rule.Shadow, rule.UpdatedAt,
 	)
 
 	if err != nil {
@@ -366,7 +386,7 @@ func (r *postgresRepository) DeleteClassificationRule(ctx context.Context, ruleI
 func (r *postgresRepository) GetClassificationSuggestion(ctx context.Context, suggestionID string) (*classification.ClassificationSuggestion, error) {
 	query := `
 		SELECT s.id, s.rule_id, s.confidence, s.status, s.affected_devices, s.based_on_devices, s.created_at, s.updated_at,
-		       r.id, r.name, r.description, r.logic_operator, r.conditions, r.layer, r.device_type, r.priority, r.is_active, r.created_by, r.created_at, r.updated_at
+		       r.id, r.name, r.description, r.logic_operator, r.conditions, r.layer, r.device_type, r.priority, r.is_active, r.shadow, r.created_by, r.created_at, r.updated_at
 		FROM classification_suggestions s
 		JOIN classification_rules r ON s.rule_id = r.id
 		WHERE s.id = $1
@@ -381,7 +401,7 @@ func (r *postgresRepository) GetClassificationSuggestion(ctx context.Context, su
 		&suggestion.ID, &suggestion.RuleID, &suggestion.Confidence, &suggestion.Status,
 		&affectedDevicesJSON, &basedOnDevicesJSON, &suggestion.CreatedAt, &suggestion.UpdatedAt,
 		&rule.ID, &rule.Name, &rule.Description, &rule.LogicOperator, &conditionsJSON,
-		&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+		&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Shadow, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -408,18 +428,28 @@ func (r *postgresRepository) GetClassificationSuggestion(ctx context.Context, su
 }
 
 func (r *postgresRepository) ListPendingClassificationSuggestions(ctx context.Context) ([]classification.ClassificationSuggestion, error) {
+	return r.ListClassificationSuggestionsByStatus(ctx, classification.SuggestionStatusPending)
+}
+
+// ListClassificationSuggestionsByStatus returns the suggestions in any of statuses, by descending confidence
+func (r *postgresRepository) ListClassificationSuggestionsByStatus(ctx context.Context, statuses ...classification.SuggestionStatus) ([]classification.ClassificationSuggestion, error) {
 	query := `
 		SELECT s.id, s.rule_id, s.confidence, s.status, s.affected_devices, s.based_on_devices, s.created_at, s.updated_at,
-		       r.id, r.name, r.description, r.logic_operator, r.conditions, r.layer, r.device_type, r.priority, r.is_active, r.created_by, r.created_at, r.updated_at
+		       r.id, r.name, r.description, r.logic_operator, r.conditions, r.layer, r.device_type, r.priority, r.is_active, r.shadow, r.created_by, r.created_at, r.updated_at
 		FROM classification_suggestions s
 		JOIN classification_rules r ON s.rule_id = r.id
-		WHERE s.status = 'pending'
+		WHERE s.status = ANY($1)
 		ORDER BY s.confidence DESC, s.created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	statusNames := make([]string, len(statuses))
+	for i, status := range statuses {
+		statusNames[i] = string(status)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(statusNames))
 	if err != nil {
-		return nil, fmt.Errorf("failed to list pending classification suggestions: %w", err)
+		return nil, fmt.Errorf("failed to list classification suggestions: %w", err)
 	}
 	defer rows.Close()
 
@@ -434,7 +464,7 @@ func (r *postgresRepository) ListPendingClassificationSuggestions(ctx context.Co
 			&suggestion.ID, &suggestion.RuleID, &suggestion.Confidence, &suggestion.Status,
 			&affectedDevices, &basedOnDevices, &suggestion.CreatedAt, &suggestion.UpdatedAt,
 			&rule.ID, &rule.Name, &rule.Description, &rule.LogicOperator, &conditionsJSON,
-			&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
+			&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Shadow, &rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan classification suggestion: %w", err)
@@ -452,6 +482,10 @@ func (r *postgresRepository) ListPendingClassificationSuggestions(ctx context.Co
 		suggestions = append(suggestions, suggestion)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate classification suggestions: %w", err)
+	}
+
 	return suggestions, nil
 }
 
@@ -555,6 +589,10 @@ func (r *postgresRepository) ListHierarchyLayers(ctx context.Context) ([]classif
 		layers = append(layers, layer)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate hierarchy layers: %w", err)
+	}
+
 	return layers, nil
 }
 
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// Classification history repository methods
+
+// AddClassificationChange records a change of a device classification
+func (r *postgresRepository) AddClassificationChange(ctx context.Context, change classification.ClassificationChange) error {
+	if change.ChangedAt.IsZero() {
+		change.ChangedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO classification_history (
+			device_id, previous_layer_id, previous_device_type, previous_classified_by,
+			layer_id, device_type, classified_by, source, reason, changed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		change.DeviceID, change.PreviousLayerID, change.PreviousDeviceType, change.PreviousClassifiedBy,
+		change.LayerID, change.DeviceType, change.ClassifiedBy, change.Source, change.Reason, change.ChangedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add classification change: %w", err)
+	}
+
+	return nil
+}
+
+// ListClassificationHistory retrieves classification changes of a device, newest first
+func (r *postgresRepository) ListClassificationHistory(ctx context.Context, deviceID string, limit int) ([]classification.ClassificationChange, error) {
+	query := `
+		SELECT id, device_id, previous_layer_id, previous_device_type, previous_classified_by,
+		       layer_id, device_type, classified_by, source, reason, changed_at
+		FROM classification_history
+		WHERE device_id = $1
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, deviceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classification history: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []classification.ClassificationChange
+	for rows.Next() {
+		var change classification.ClassificationChange
+		var previousLayerID, layerID sql.NullInt64
+		var previousDeviceType, previousClassifiedBy, deviceType, classifiedBy, reason sql.NullString
+
+		err := rows.Scan(
+			&change.ID, &change.DeviceID, &previousLayerID, &previousDeviceType, &previousClassifiedBy,
+			&layerID, &deviceType, &classifiedBy, &change.Source, &reason, &change.ChangedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan classification change: %w", err)
+		}
+
+		if previousLayerID.Valid {
+			layer := int(previousLayerID.Int64)
+			change.PreviousLayerID = &layer
+		}
+		if layerID.Valid {
+			layer := int(layerID.Int64)
+			change.LayerID = &layer
+		}
+		change.PreviousDeviceType = previousDeviceType.String
+		change.PreviousClassifiedBy = previousClassifiedBy.String
+		change.DeviceType = deviceType.String
+		change.ClassifiedBy = classifiedBy.String
+		change.Reason = reason.String
+
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate classification history: %w", err)
+	}
+
+	return changes, nil
+}
+
+// ListRuleQuality counts, per rule, the devices it classified and how many of them a user later
+// reclassified (overridden) or classified the same way (confirmed)
+func (r *postgresRepository) ListRuleQuality(ctx context.Context) (map[string]classification.RuleQuality, error) {
+	quality := make(map[string]classification.RuleQuality)
+
+	appliedQuery := `
+		SELECT classified_by, COUNT(DISTINCT device_id)
+		FROM classification_history
+		WHERE source = $1 AND classified_by LIKE 'rule:%'
+		GROUP BY classified_by
+	`
+	rows, err := r.db.QueryContext(ctx, appliedQuery, classification.ChangeSourceRule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count rule classifications: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var classifiedBy string
+		var applied int
+		if err := rows.Scan(&classifiedBy, &applied); err != nil {
+			return nil, fmt.Errorf("failed to scan rule classifications: %w", err)
+		}
+		if name, ok := classification.RuleNameFromClassifiedBy(classifiedBy); ok {
+			q := quality[name]
+			q.Applied = applied
+			quality[name] = q
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count rule classifications: %w", err)
+	}
+
+	// 手動分類で階層かデバイスタイプが変わったものを上書き、同じものを確定として数える
+	reviewQuery := `
+		SELECT previous_classified_by,
+		       COUNT(DISTINCT CASE WHEN changed THEN device_id END),
+		       COUNT(DISTINCT CASE WHEN NOT changed THEN device_id END)
+		FROM (
+			SELECT device_id, previous_classified_by,
+			       (COALESCE(layer_id, -1) <> COALESCE(previous_layer_id, -1)
+			        OR COALESCE(device_type, '') <> COALESCE(previous_device_type, '')) AS changed
+			FROM classification_history
+			WHERE source = $1 AND previous_classified_by LIKE 'rule:%'
+		) reviews
+		GROUP BY previous_classified_by
+	`
+	reviewRows, err := r.db.QueryContext(ctx, reviewQuery, classification.ChangeSourceUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count rule overrides: %w", err)
+	}
+	defer reviewRows.Close()
+	for reviewRows.Next() {
+		var classifiedBy string
+		var overridden, confirmed int
+		if err := reviewRows.Scan(&classifiedBy, &overridden, &confirmed); err != nil {
+			return nil, fmt.Errorf("failed to scan rule overrides: %w", err)
+		}
+		if name, ok := classification.RuleNameFromClassifiedBy(classifiedBy); ok {
+			q := quality[name]
+			q.Overridden, q.Confirmed = overridden, confirmed
+			quality[name] = q
+		}
+	}
+	if err := reviewRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count rule overrides: %w", err)
+	}
+
+	for name, q := range quality {
+		quality[name] = classification.NewRuleQuality(q.Applied, q.Overridden, q.Confirmed)
+	}
+	return quality, nil
+}
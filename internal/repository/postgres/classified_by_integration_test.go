@@ -0,0 +1,47 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifiedBy_AcceptsDirectoryUsernamesAndRuleNames(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	setup.SeedTestData(t)
+	ctx := context.Background()
+
+	// LDAP/OIDC のユーザー名や空白を含むルール名も記録できる
+	for _, classifiedBy := range []string{
+		"user:taro.yamada",
+		"user:taro.yamada@example.com",
+		"user:taro_yamada",
+		"rule:Leaf switches",
+		"system:auto",
+	} {
+		device, err := setup.Repo.GetDevice(ctx, "device-001")
+		require.NoError(t, err)
+		device.ClassifiedBy = classifiedBy
+		device.UpdatedAt = time.Now()
+		require.NoError(t, setup.Repo.UpdateDevice(ctx, *device), classifiedBy)
+
+		stored, err := setup.Repo.GetDevice(ctx, "device-001")
+		require.NoError(t, err)
+		assert.Equal(t, classifiedBy, stored.ClassifiedBy)
+	}
+
+	// 接頭辞のない値や空の主体は引き続き拒否する
+	for _, classifiedBy := range []string{"taro.yamada", "user:"} {
+		device, err := setup.Repo.GetDevice(ctx, "device-002")
+		require.NoError(t, err)
+		device.ClassifiedBy = classifiedBy
+		device.UpdatedAt = time.Now()
+		assert.Error(t, setup.Repo.UpdateDevice(ctx, *device), classifiedBy)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Config represents PostgreSQL database configuration
@@ -16,6 +17,11 @@ type Config struct {
 	DBName   string `yaml:"dbname"`
 	SSLMode  string `yaml:"sslmode"`
 	DSN      string `yaml:"dsn"` // Direct DSN string (takes precedence)
+
+	// Read replicas serving read-only queries (topology extraction and listings). Writes and
+	// single-device lookups stay on the primary; reads fall back to it while no replica is healthy.
+	Replicas             []string      `yaml:"replicas"`               // レプリカのDSN
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval"` // デフォルト10s
 }
 
 // BuildDSN returns the PostgreSQL connection string
@@ -73,6 +79,16 @@ func (c *Config) ParseDSN(dsn string) error {
 
 // Validate checks if the PostgreSQL configuration is valid
 func (c *Config) Validate() error {
+	for i, replica := range c.Replicas {
+		u, err := url.Parse(replica)
+		if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			return fmt.Errorf("invalid replica DSN %d: expected a postgres:// URL", i+1)
+		}
+	}
+	if c.ReplicaCheckInterval < 0 {
+		return fmt.Errorf("replica check interval must not be negative")
+	}
+
 	// If DSN is provided, try to parse it
 	if c.DSN != "" {
 		return c.ParseDSN(c.DSN)
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ListAllLinks returns every stored link without checking that its devices exist
+func (r *postgresRepository) ListAllLinks(ctx context.Context) ([]topology.Link, error) {
+	query := `
+		SELECT id, source_id, target_id, source_port, target_port, weight, last_seen
+		FROM links
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []topology.Link
+	for rows.Next() {
+		var link topology.Link
+		// 壊れたデータも読めるよう NULL を許容する
+		var sourcePort, targetPort sql.NullString
+		var weight sql.NullFloat64
+		var lastSeen sql.NullTime
+		if err := rows.Scan(&link.ID, &link.SourceID, &link.TargetID, &sourcePort, &targetPort, &weight, &lastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		link.SourcePort = sourcePort.String
+		link.TargetPort = targetPort.String
+		link.Weight = weight.Float64
+		link.LastSeen = lastSeen.Time
+		link.Metadata = make(map[string]string)
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate links: %w", err)
+	}
+
+	return links, nil
+}
+
+// RemoveLinks deletes links by ID in one transaction
+func (r *postgresRepository) RemoveLinks(ctx context.Context, linkIDs []string) (int, error) {
+	return r.execEach(ctx, `DELETE FROM links WHERE id = $1`, linkIDs)
+}
+
+// ClearDeviceClassifications resets the classification of devices in one transaction
+func (r *postgresRepository) ClearDeviceClassifications(ctx context.Context, deviceIDs []string) (int, error) {
+	// SQLite と同じく system:auto にする（layer_id が NULL なので未分類として扱われる）
+	query := `
+		UPDATE devices SET
+			layer_id = NULL,
+			device_type = '',
+			classified_by = 'system:auto',
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`
+	return r.execEach(ctx, query, deviceIDs)
+}
+
+// execEach runs a single-parameter statement for every ID and returns the affected row count
+func (r *postgresRepository) execEach(ctx context.Context, query string, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	affected := 0
+	for _, id := range ids {
+		res, err := stmt.ExecContext(ctx, id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update %s: %w", id, err)
+		}
+		n, _ := res.RowsAffected()
+		affected += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %w", err)
+	}
+	return affected, nil
+}
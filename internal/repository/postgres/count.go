@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// exactCountThreshold is the estimated row count below which a table is counted exactly.
+// COUNT(*) of a small table is cheap, and its statistics may be stale or missing.
+const exactCountThreshold = 100000
+
+func (r *postgresRepository) CountDevices(ctx context.Context, exact bool) (topology.RowCount, error) {
+	return r.countRows(ctx, "devices", exact)
+}
+
+func (r *postgresRepository) CountLinks(ctx context.Context, exact bool) (topology.RowCount, error) {
+	return r.countRows(ctx, "links", exact)
+}
+
+// countRows returns pg_class.reltuples for tables of at least exactCountThreshold rows, and
+// COUNT(*) for smaller tables or when exact is set
+func (r *postgresRepository) countRows(ctx context.Context, table string, exact bool) (topology.RowCount, error) {
+	if !exact {
+		// reltuples は VACUUM / ANALYZE 時点の推定値で、一度も解析されていないテーブルでは -1 または 0 になる
+		var estimate float64
+		if err := r.readScalar(ctx, &estimate, "SELECT reltuples FROM pg_class WHERE oid = $1::regclass", table); err != nil {
+			return topology.RowCount{}, fmt.Errorf("failed to estimate %s count: %w", table, err)
+		}
+		if estimate >= exactCountThreshold {
+			return topology.RowCount{Count: int64(estimate), Estimated: true}, nil
+		}
+	}
+
+	var count int64
+	if err := r.readScalar(ctx, &count, "SELECT COUNT(*) FROM "+table); err != nil {
+		return topology.RowCount{}, fmt.Errorf("failed to count %s: %w", table, err)
+	}
+	return topology.RowCount{Count: count}, nil
+}
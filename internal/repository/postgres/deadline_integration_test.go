@@ -0,0 +1,77 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository/postgres"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// isCancellation reports whether err is how a query stopped by its context is reported:
+// the context error or the server's query_canceled (SQLSTATE 57014)
+func isCancellation(err error) bool {
+	var stateErr interface{ SQLState() string }
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &stateErr) && stateErr.SQLState() == "57014")
+}
+
+// waitForNoActiveQuery waits until no backend is running a query containing text
+func waitForNoActiveQuery(t *testing.T, setup *testutil.TestSetup, text string) {
+	t.Helper()
+
+	db := postgres.DBForTest(setup.Repo)
+	assert.Eventually(t, func() bool {
+		var running int
+		err := db.QueryRow(`SELECT COUNT(*) FROM pg_stat_activity
+			WHERE state = 'active' AND pid <> pg_backend_pid() AND query LIKE '%' || $1 || '%'`, text).Scan(&running)
+		return err == nil && running == 0
+	}, 5*time.Second, 50*time.Millisecond, "query %q still running on the server", text)
+}
+
+func TestQueryCancelledAtDeadline(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	db := postgres.DBForTest(setup.Repo)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := db.ExecContext(ctx, "SELECT pg_sleep(30)")
+	require.Error(t, err)
+	assert.True(t, isCancellation(err), "unexpected error: %v", err)
+	assert.Less(t, time.Since(started), 5*time.Second)
+
+	// クライアント側だけでなくサーバー側のクエリも止まっている
+	waitForNoActiveQuery(t, setup, "pg_sleep(30)")
+}
+
+func TestRepositoryQueryCancelledAtDeadline(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	setup.SeedTestData(t)
+	db := postgres.DBForTest(setup.Repo)
+
+	// テーブルをロックして、デバイス一覧のクエリを待たせる
+	lock, err := db.Begin()
+	require.NoError(t, err)
+	defer lock.Rollback()
+	_, err = lock.Exec("LOCK TABLE devices IN ACCESS EXCLUSIVE MODE")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, _, err = setup.Repo.GetDevices(ctx, topology.PaginationOptions{Page: 1, PageSize: 10, Exact: true})
+	require.Error(t, err)
+	assert.True(t, isCancellation(err), "unexpected error: %v", err)
+	assert.Less(t, time.Since(started), 5*time.Second)
+
+	waitForNoActiveQuery(t, setup, "FROM devices")
+}
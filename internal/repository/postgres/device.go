@@ -3,38 +3,54 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/servak/topology-manager/internal/domain/topology"
 )
 
 // Device-related repository methods
 
+// deviceUpsertQuery inserts a device or merges it into the stored row. Each column keeps the
+// value of the write with the newest updated_at (last-write-wins), so concurrent seed and sync
+// runs converge regardless of commit order. last_seen only moves forward and created_at backward,
+// and provenance keeps the value of the first write. The workflow state is only set on insert
+// (discovered unless given) and otherwise changed by TransitionDeviceWorkflow. The rack placement
+// is only replaced by writes carrying a rack, so syncs keep it; SetDeviceRack clears it.
+const deviceUpsertQuery = `
+	INSERT INTO devices (id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at, provenance, workflow_state, rack, rack_position, rack_units)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE(NULLIF($12, ''), 'discovered'), $13, $14, $15)
+	ON CONFLICT (id) DO UPDATE SET
+		type = CASE WHEN EXCLUDED.updated_at >= devices.updated_at THEN EXCLUDED.type ELSE devices.type END,
+		hardware = CASE WHEN EXCLUDED.updated_at >= devices.updated_at THEN EXCLUDED.hardware ELSE devices.hardware END,
+		layer_id = CASE WHEN EXCLUDED.updated_at >= devices.updated_at THEN EXCLUDED.layer_id ELSE devices.layer_id END,
+		device_type = CASE WHEN EXCLUDED.updated_at >= devices.updated_at THEN EXCLUDED.device_type ELSE devices.device_type END,
+		classified_by = CASE WHEN EXCLUDED.updated_at >= devices.updated_at THEN EXCLUDED.classified_by ELSE devices.classified_by END,
+		metadata = CASE WHEN EXCLUDED.updated_at >= devices.updated_at THEN EXCLUDED.metadata ELSE devices.metadata END,
+		last_seen = GREATEST(devices.last_seen, EXCLUDED.last_seen),
+		created_at = LEAST(devices.created_at, EXCLUDED.created_at),
+		updated_at = GREATEST(devices.updated_at, EXCLUDED.updated_at),
+		provenance = CASE WHEN devices.provenance = '' THEN EXCLUDED.provenance ELSE devices.provenance END,
+		rack = CASE WHEN EXCLUDED.rack <> '' THEN EXCLUDED.rack ELSE devices.rack END,
+		rack_position = CASE WHEN EXCLUDED.rack <> '' THEN EXCLUDED.rack_position ELSE devices.rack_position END,
+		rack_units = CASE WHEN EXCLUDED.rack <> '' THEN EXCLUDED.rack_units ELSE devices.rack_units END
+`
+
 func (r *postgresRepository) AddDevice(ctx context.Context, device topology.Device) error {
-	query := `
-		INSERT INTO devices (id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT (id) DO UPDATE SET
-			type = EXCLUDED.type,
-			hardware = EXCLUDED.hardware,
-			layer_id = EXCLUDED.layer_id,
-			device_type = EXCLUDED.device_type,
-			classified_by = EXCLUDED.classified_by,
-			metadata = EXCLUDED.metadata,
-			last_seen = EXCLUDED.last_seen,
-			updated_at = EXCLUDED.updated_at
-	`
+	device = withUpsertTimestamps(device)
 
-	metadataJSON := "{}"
-	if len(device.Metadata) > 0 {
-		// TODO: Proper JSON serialization for metadata
-		metadataJSON = "{}"
+	metadataJSON, err := marshalMetadata(device.Metadata)
+	if err != nil {
+		return err
 	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, deviceUpsertQuery,
 		device.ID, device.Type, device.Hardware, device.LayerID,
 		device.DeviceType, device.ClassifiedBy, metadataJSON, device.LastSeen,
-		device.CreatedAt, device.UpdatedAt,
+		device.CreatedAt, device.UpdatedAt, device.Provenance, device.WorkflowState,
+		device.Rack, device.RackPosition, device.RackUnits,
 	)
 
 	if err != nil {
@@ -44,23 +60,27 @@ func (r *postgresRepository) AddDevice(ctx context.Context, device topology.Devi
 	return nil
 }
 
+// UpdateDevice upserts device. Columns are only replaced when device.UpdatedAt is not older than the
+// stored row, so callers stamp UpdatedAt with the time of their change.
 func (r *postgresRepository) UpdateDevice(ctx context.Context, device topology.Device) error {
 	return r.AddDevice(ctx, device) // Use upsert logic
 }
 
-func (r *postgresRepository) GetDevice(ctx context.Context, deviceID string) (*topology.Device, error) {
-	query := `
-		SELECT id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at
+// getDeviceQuery selects the device $1; ExplainSubTopology explains the same statement
+const getDeviceQuery = `
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
 		FROM devices 
 		WHERE id = $1
 	`
 
+func (r *postgresRepository) GetDevice(ctx context.Context, deviceID string) (*topology.Device, error) {
 	var device topology.Device
 	var metadataJSON string
 
-	err := r.db.QueryRowContext(ctx, query, deviceID).Scan(
+	err := r.db.QueryRowContext(ctx, getDeviceQuery, deviceID).Scan(
 		&device.ID, &device.Type, &device.Hardware, &device.LayerID,
-		&device.DeviceType, &device.ClassifiedBy, &metadataJSON, &device.LastSeen,
+		&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+		&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
 		&device.CreatedAt, &device.UpdatedAt,
 	)
 
@@ -71,21 +91,21 @@ func (r *postgresRepository) GetDevice(ctx context.Context, deviceID string) (*t
 		return nil, fmt.Errorf("failed to get device: %w", err)
 	}
 
-	// Initialize metadata map
-	device.Metadata = make(map[string]string)
-	// TODO: Parse JSON metadata
+	device.Metadata = unmarshalMetadata(metadataJSON)
 
 	return &device, nil
 }
 
 func (r *postgresRepository) GetDevices(ctx context.Context, opts topology.PaginationOptions) ([]topology.Device, *topology.PaginationResult, error) {
-	// Count total devices
-	var totalCount int
-	countQuery := "SELECT COUNT(*) FROM devices"
-	err := r.db.QueryRowContext(ctx, countQuery).Scan(&totalCount)
+	// Count total devices（大きなテーブルでは統計情報から推定する）
+	total, err := r.countRows(ctx, "devices", opts.Exact)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to count devices: %w", err)
+		return nil, nil, err
+	}
+	if opts.Keyset {
+		return r.getDevicesAfter(ctx, opts, total)
 	}
+	totalCount := int(total.Count)
 
 	// Calculate pagination
 	offset := (opts.Page - 1) * opts.PageSize
@@ -93,13 +113,13 @@ func (r *postgresRepository) GetDevices(ctx context.Context, opts topology.Pagin
 
 	// Get devices with pagination
 	query := `
-		SELECT id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
 		FROM devices 
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, opts.PageSize, offset)
+	rows, err := r.readQuery(ctx, query, opts.PageSize, offset)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get devices: %w", err)
 	}
@@ -112,17 +132,22 @@ func (r *postgresRepository) GetDevices(ctx context.Context, opts topology.Pagin
 
 		err := rows.Scan(
 			&device.ID, &device.Type, &device.Hardware, &device.LayerID,
-			&device.DeviceType, &device.ClassifiedBy, &metadataJSON, &device.LastSeen,
+			&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+			&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
 			&device.CreatedAt, &device.UpdatedAt,
 		)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to scan device: %w", err)
 		}
 
-		device.Metadata = make(map[string]string)
+		device.Metadata = unmarshalMetadata(metadataJSON)
 		devices = append(devices, device)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate devices: %w", err)
+	}
+
 	result := &topology.PaginationResult{
 		TotalCount: totalCount,
 		TotalPages: totalPages,
@@ -130,14 +155,59 @@ func (r *postgresRepository) GetDevices(ctx context.Context, opts topology.Pagin
 		PageSize:   opts.PageSize,
 		HasNext:    opts.Page < totalPages,
 		HasPrev:    opts.Page > 1,
+		Estimated:  total.Estimated,
 	}
 
 	return devices, result, nil
 }
 
+// getDevicesAfter returns the page of devices following opts.After in ID order. Unlike OFFSET it
+// seeks the primary key, so late pages cost as much as the first.
+func (r *postgresRepository) getDevicesAfter(ctx context.Context, opts topology.PaginationOptions, total topology.RowCount) ([]topology.Device, *topology.PaginationResult, error) {
+	query := `
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
+		FROM devices
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	// 1件多く読んで次のページの有無を判定する
+	rows, err := r.readQuery(ctx, query, opts.After, opts.PageSize+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []topology.Device
+	for rows.Next() {
+		var device topology.Device
+		var metadataJSON string
+
+		err := rows.Scan(
+			&device.ID, &device.Type, &device.Hardware, &device.LayerID,
+			&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+			&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
+			&device.CreatedAt, &device.UpdatedAt,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+
+		device.Metadata = unmarshalMetadata(metadataJSON)
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate devices: %w", err)
+	}
+
+	devices, result := topology.KeysetPage(devices, opts, total)
+	return devices, result, nil
+}
+
 func (r *postgresRepository) SearchDevices(ctx context.Context, query string, limit int) ([]topology.Device, error) {
 	searchQuery := `
-		SELECT id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
 		FROM devices 
 		WHERE id ILIKE $1 OR type ILIKE $1 OR hardware ILIKE $1 OR device_type ILIKE $1
 		ORDER BY id
@@ -145,7 +215,7 @@ func (r *postgresRepository) SearchDevices(ctx context.Context, query string, li
 	`
 
 	searchPattern := "%" + query + "%"
-	rows, err := r.db.QueryContext(ctx, searchQuery, searchPattern, limit)
+	rows, err := r.readQuery(ctx, searchQuery, searchPattern, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search devices: %w", err)
 	}
@@ -158,17 +228,22 @@ func (r *postgresRepository) SearchDevices(ctx context.Context, query string, li
 
 		err := rows.Scan(
 			&device.ID, &device.Type, &device.Hardware, &device.LayerID,
-			&device.DeviceType, &device.ClassifiedBy, &metadataJSON, &device.LastSeen,
+			&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+			&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
 			&device.CreatedAt, &device.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan device: %w", err)
 		}
 
-		device.Metadata = make(map[string]string)
+		device.Metadata = unmarshalMetadata(metadataJSON)
 		devices = append(devices, device)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate devices: %w", err)
+	}
+
 	return devices, nil
 }
 
@@ -183,13 +258,13 @@ func (r *postgresRepository) RemoveDevice(ctx context.Context, deviceID string)
 
 func (r *postgresRepository) FindDevicesByType(ctx context.Context, deviceType string) ([]topology.Device, error) {
 	query := `
-		SELECT id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
 		FROM devices 
 		WHERE device_type = $1
 		ORDER BY id
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, deviceType)
+	rows, err := r.readQuery(ctx, query, deviceType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find devices by type: %w", err)
 	}
@@ -202,29 +277,34 @@ func (r *postgresRepository) FindDevicesByType(ctx context.Context, deviceType s
 
 		err := rows.Scan(
 			&device.ID, &device.Type, &device.Hardware, &device.LayerID,
-			&device.DeviceType, &device.ClassifiedBy, &metadataJSON, &device.LastSeen,
+			&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+			&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
 			&device.CreatedAt, &device.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan device: %w", err)
 		}
 
-		device.Metadata = make(map[string]string)
+		device.Metadata = unmarshalMetadata(metadataJSON)
 		devices = append(devices, device)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate devices: %w", err)
+	}
+
 	return devices, nil
 }
 
 func (r *postgresRepository) FindDevicesByHardware(ctx context.Context, hardware string) ([]topology.Device, error) {
 	query := `
-		SELECT id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
 		FROM devices 
 		WHERE hardware = $1
 		ORDER BY id
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, hardware)
+	rows, err := r.readQuery(ctx, query, hardware)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find devices by hardware: %w", err)
 	}
@@ -237,17 +317,22 @@ func (r *postgresRepository) FindDevicesByHardware(ctx context.Context, hardware
 
 		err := rows.Scan(
 			&device.ID, &device.Type, &device.Hardware, &device.LayerID,
-			&device.DeviceType, &device.ClassifiedBy, &metadataJSON, &device.LastSeen,
+			&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+			&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
 			&device.CreatedAt, &device.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan device: %w", err)
 		}
 
-		device.Metadata = make(map[string]string)
+		device.Metadata = unmarshalMetadata(metadataJSON)
 		devices = append(devices, device)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate devices: %w", err)
+	}
+
 	return devices, nil
 }
 
@@ -262,30 +347,23 @@ func (r *postgresRepository) BulkAddDevices(ctx context.Context, devices []topol
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO devices (id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT (id) DO UPDATE SET
-			type = EXCLUDED.type,
-			hardware = EXCLUDED.hardware,
-			layer_id = EXCLUDED.layer_id,
-			device_type = EXCLUDED.device_type,
-			classified_by = EXCLUDED.classified_by,
-			metadata = EXCLUDED.metadata,
-			last_seen = EXCLUDED.last_seen,
-			updated_at = EXCLUDED.updated_at
-	`)
+	stmt, err := tx.PrepareContext(ctx, deviceUpsertQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	for _, device := range devices {
-		metadataJSON := "{}"
+	// 並行するバッチが同じ順序で行ロックを取るようID順に書き込み、デッドロックを防ぐ
+	for _, device := range devicesForUpsert(devices) {
+		metadataJSON, err := marshalMetadata(device.Metadata)
+		if err != nil {
+			return err
+		}
 		_, err = stmt.ExecContext(ctx,
 			device.ID, device.Type, device.Hardware, device.LayerID,
 			device.DeviceType, device.ClassifiedBy, metadataJSON, device.LastSeen,
-			device.CreatedAt, device.UpdatedAt,
+			device.CreatedAt, device.UpdatedAt, device.Provenance, device.WorkflowState,
+			device.Rack, device.RackPosition, device.RackUnits,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert device %s: %w", device.ID, err)
@@ -293,4 +371,69 @@ func (r *postgresRepository) BulkAddDevices(ctx context.Context, devices []topol
 	}
 
 	return tx.Commit()
-}
\ No newline at end of file
+}
+
+// marshalMetadata encodes device or link metadata for the JSONB column; nil maps are stored as {}
+func marshalMetadata(metadata map[string]string) (string, error) {
+	if len(metadata) == 0 {
+		return "{}", nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return string(data), nil
+}
+
+// unmarshalMetadata decodes the JSONB column. Values that are not strings (written
+// outside the application) are dropped rather than failing the whole read.
+func unmarshalMetadata(data string) map[string]string {
+	metadata := make(map[string]string)
+	if data == "" {
+		return metadata
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return metadata
+	}
+	for key, value := range raw {
+		if text, ok := value.(string); ok {
+			metadata[key] = text
+		}
+	}
+	return metadata
+}
+
+// devicesForUpsert returns the devices sorted by ID with one entry per ID (the newest by
+// updated_at, later entries winning ties) and with missing timestamps set to now
+func devicesForUpsert(devices []topology.Device) []topology.Device {
+	latest := make(map[string]topology.Device, len(devices))
+	for _, device := range devices {
+		device = withUpsertTimestamps(device)
+		if current, ok := latest[device.ID]; !ok || !device.UpdatedAt.Before(current.UpdatedAt) {
+			latest[device.ID] = device
+		}
+	}
+
+	result := make([]topology.Device, 0, len(latest))
+	for _, device := range latest {
+		result = append(result, device)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// withUpsertTimestamps fills zero timestamps, which would otherwise always lose last-write-wins
+func withUpsertTimestamps(device topology.Device) topology.Device {
+	now := time.Now()
+	if device.UpdatedAt.IsZero() {
+		device.UpdatedAt = now
+	}
+	if device.CreatedAt.IsZero() {
+		device.CreatedAt = device.UpdatedAt
+	}
+	if device.LastSeen.IsZero() {
+		device.LastSeen = device.UpdatedAt
+	}
+	return device
+}
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"testing"
+)
+
+func TestDeviceMetadataRoundTrip(t *testing.T) {
+	data, err := marshalMetadata(nil)
+	if err != nil || data != "{}" {
+		t.Fatalf("nil metadata: got %q, %v", data, err)
+	}
+
+	data, err = marshalMetadata(map[string]string{"site": "tokyo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := unmarshalMetadata(data)
+	if len(metadata) != 1 || metadata["site"] != "tokyo" {
+		t.Errorf("round trip: got %v", metadata)
+	}
+
+	// 文字列以外の値は読み捨てる
+	metadata = unmarshalMetadata(`{"site": "tokyo", "rack": 12, "tags": ["a"]}`)
+	if len(metadata) != 1 || metadata["site"] != "tokyo" {
+		t.Errorf("non-string values: got %v", metadata)
+	}
+
+	if metadata := unmarshalMetadata("not json"); metadata == nil || len(metadata) != 0 {
+		t.Errorf("invalid JSON: got %v", metadata)
+	}
+}
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// explainPrefix makes PostgreSQL execute the statement and report the plan with the actual rows,
+// timings and buffer usage. Only read-only statements are explained.
+const explainPrefix = "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "
+
+// ExplainSubTopology runs the statements of ExtractSubTopology with EXPLAIN ANALYZE, on the same
+// connections (primary or replica) the extraction uses
+func (r *postgresRepository) ExplainSubTopology(ctx context.Context, deviceID string, opts topology.SubTopologyOptions) (*topology.SubTopologyExplanation, error) {
+	centerDevice, err := r.GetDevice(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get center device: %w", err)
+	}
+	if centerDevice == nil {
+		return nil, nil
+	}
+
+	explanation := &topology.SubTopologyExplanation{
+		DeviceID:    deviceID,
+		ExplainedAt: time.Now(),
+	}
+
+	root, err := r.explain(ctx, r.db.QueryContext, "root_device", getDeviceQuery, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	explanation.Statements = append(explanation.Statements, root)
+
+	linksQuery, args := subTopologyLinksQuery(opts.Filter, deviceID, time.Now())
+	links, err := r.explain(ctx, r.readQuery, "links", linksQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	explanation.Statements = append(explanation.Statements, links)
+
+	// 対向デバイスの取得は対向ごとに同じ文を実行するため、最初の対向で代表させる
+	neighbors, err := r.subTopologyNeighbors(ctx, deviceID, linksQuery, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(neighbors) > 0 {
+		neighbor, err := r.explain(ctx, r.db.QueryContext, "neighbor_device", getDeviceQuery, neighbors[0])
+		if err != nil {
+			return nil, err
+		}
+		neighbor.Executions = len(neighbors)
+		explanation.Statements = append(explanation.Statements, neighbor)
+	}
+
+	return explanation, nil
+}
+
+// subTopologyNeighbors returns the sorted IDs of the peers of deviceID that ExtractSubTopology looks up
+func (r *postgresRepository) subTopologyNeighbors(ctx context.Context, deviceID, linksQuery string, args []interface{}) ([]string, error) {
+	rows, err := r.readQuery(ctx, linksQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var skip interface{}
+		var sourceID, targetID string
+		if err := rows.Scan(&skip, &sourceID, &targetID, &skip, &skip, &skip, &skip, &skip, &skip, &skip); err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		for _, id := range []string{sourceID, targetID} {
+			if id != deviceID {
+				seen[id] = true
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate links: %w", err)
+	}
+
+	neighbors := make([]string, 0, len(seen))
+	for id := range seen {
+		neighbors = append(neighbors, id)
+	}
+	sort.Strings(neighbors)
+	return neighbors, nil
+}
+
+// explain runs statement with EXPLAIN ANALYZE through query
+func (r *postgresRepository) explain(ctx context.Context, query func(context.Context, string, ...interface{}) (*sql.Rows, error), name, statement string, args ...interface{}) (topology.QueryPlan, error) {
+	rows, err := query(ctx, explainPrefix+statement, args...)
+	if err != nil {
+		return topology.QueryPlan{}, fmt.Errorf("failed to explain %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var raw []byte
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return topology.QueryPlan{}, fmt.Errorf("failed to explain %s: %w", name, err)
+		}
+		return topology.QueryPlan{}, fmt.Errorf("failed to explain %s: no plan returned", name)
+	}
+	if err := rows.Scan(&raw); err != nil {
+		return topology.QueryPlan{}, fmt.Errorf("failed to scan plan of %s: %w", name, err)
+	}
+
+	plan, err := parseExplainJSON(raw)
+	if err != nil {
+		return topology.QueryPlan{}, fmt.Errorf("failed to parse plan of %s: %w", name, err)
+	}
+	plan.Name = name
+	plan.SQL = strings.TrimSpace(statement)
+	plan.Args = explainArgs(args)
+	plan.Executions = 1
+	return plan, nil
+}
+
+// parseExplainJSON reads the output of EXPLAIN (ANALYZE, FORMAT JSON)
+func parseExplainJSON(raw []byte) (topology.QueryPlan, error) {
+	var explained []struct {
+		Plan          map[string]interface{} `json:"Plan"`
+		PlanningTime  float64                `json:"Planning Time"`
+		ExecutionTime float64                `json:"Execution Time"`
+	}
+	if err := json.Unmarshal(raw, &explained); err != nil {
+		return topology.QueryPlan{}, err
+	}
+	if len(explained) == 0 || explained[0].Plan == nil {
+		return topology.QueryPlan{}, fmt.Errorf("plan is empty")
+	}
+
+	plan := topology.QueryPlan{
+		PlanningMs:  explained[0].PlanningTime,
+		ExecutionMs: explained[0].ExecutionTime,
+		Plan:        explained[0].Plan,
+	}
+	// 最上位ノードは1回だけ実行されるので実際の行数がそのまま結果の行数になる
+	if rows, ok := explained[0].Plan["Actual Rows"].(float64); ok {
+		plan.Rows = int64(rows)
+	}
+	return plan, nil
+}
+
+// explainArgs renders the bound arguments as PostgreSQL receives them
+func explainArgs(args []interface{}) []string {
+	rendered := make([]string, 0, len(args))
+	for _, arg := range args {
+		if valuer, ok := arg.(driver.Valuer); ok {
+			if value, err := valuer.Value(); err == nil {
+				arg = value
+			}
+		}
+		switch v := arg.(type) {
+		case time.Time:
+			rendered = append(rendered, v.Format(time.RFC3339Nano))
+		case []byte:
+			rendered = append(rendered, string(v))
+		default:
+			rendered = append(rendered, fmt.Sprint(v))
+		}
+	}
+	return rendered
+}
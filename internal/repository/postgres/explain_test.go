@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestParseExplainJSON(t *testing.T) {
+	raw := []byte(`[{"Plan": {"Node Type": "Index Scan", "Index Name": "devices_pkey", "Actual Rows": 1, "Actual Loops": 1},
+		"Planning Time": 0.125, "Execution Time": 0.042}]`)
+
+	plan, err := parseExplainJSON(raw)
+	if err != nil {
+		t.Fatalf("Expected the plan to parse, got %v", err)
+	}
+	if plan.Rows != 1 || plan.PlanningMs != 0.125 || plan.ExecutionMs != 0.042 {
+		t.Errorf("Expected 1 row in 0.125ms + 0.042ms, got %+v", plan)
+	}
+	if plan.Plan["Index Name"] != "devices_pkey" {
+		t.Errorf("Expected the plan tree to be kept, got %v", plan.Plan)
+	}
+
+	for _, invalid := range []string{`[]`, `[{}]`, `not json`} {
+		if _, err := parseExplainJSON([]byte(invalid)); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestExplainArgs(t *testing.T) {
+	cutoff := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	args := explainArgs([]interface{}{"core-01", pq.Array([]string{"switch", "router"}), cutoff})
+
+	want := []string{"core-01", "{\"switch\",\"router\"}", "2024-05-01T12:00:00Z"}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Errorf("Expected argument %d to be %s, got %s", i, want[i], args[i])
+		}
+	}
+}
@@ -0,0 +1,56 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// AddTestMigrations adds migrations (keyed by file name) and the backfills they require to the
+// bundled ones until the test ends
+func AddTestMigrations(t *testing.T, scripts map[string]string, fills map[string]func(ctx context.Context, tx *sql.Tx, limit int) (int, error)) {
+	t.Helper()
+
+	files := fstest.MapFS{}
+	bundled, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range bundled {
+		data, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[name] = &fstest.MapFile{Data: data}
+	}
+	for name, script := range scripts {
+		files["migrations/"+name] = &fstest.MapFile{Data: []byte(script)}
+	}
+
+	previousSource, previousFills := migrationSource, backfills
+	migrationSource = files
+	backfills = make(map[string]backfill, len(previousFills)+len(fills))
+	for name, fill := range previousFills {
+		backfills[name] = fill
+	}
+	for name, fill := range fills {
+		backfills[name] = fill
+	}
+	t.Cleanup(func() {
+		migrationSource, backfills = previousSource, previousFills
+	})
+}
+
+// NewTestMigrator returns a migrator for the database of a repository created by NewPostgresRepository
+func NewTestMigrator(repo interface{}) *Migrator {
+	return NewMigrator(DBForTest(repo), DefaultMigratorOptions())
+}
+
+// DBForTest returns the database of a repository created by NewPostgresRepository
+func DBForTest(repo interface{}) *sql.DB {
+	return repo.(*postgresRepository).db
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Fabric repository methods
+
+const fabricColumns = `name, description, logic_operator, conditions, priority, created_by, created_at, updated_at`
+
+// ListFabrics retrieves all fabric definitions
+func (r *postgresRepository) ListFabrics(ctx context.Context) ([]topology.Fabric, error) {
+	query := `SELECT ` + fabricColumns + ` FROM fabrics ORDER BY priority DESC, name`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fabrics: %w", err)
+	}
+	defer rows.Close()
+
+	var fabrics []topology.Fabric
+	for rows.Next() {
+		fabric, err := scanFabric(rows)
+		if err != nil {
+			return nil, err
+		}
+		fabrics = append(fabrics, *fabric)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate fabrics: %w", err)
+	}
+
+	return fabrics, nil
+}
+
+// GetFabric retrieves a fabric by name
+func (r *postgresRepository) GetFabric(ctx context.Context, name string) (*topology.Fabric, error) {
+	query := `SELECT ` + fabricColumns + ` FROM fabrics WHERE name = $1`
+
+	fabric, err := scanFabric(r.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return fabric, nil
+}
+
+// SaveFabric creates or replaces a fabric definition
+func (r *postgresRepository) SaveFabric(ctx context.Context, fabric topology.Fabric) error {
+	now := time.Now()
+	if fabric.CreatedAt.IsZero() {
+		fabric.CreatedAt = now
+	}
+	if fabric.UpdatedAt.IsZero() {
+		fabric.UpdatedAt = now
+	}
+
+	conditionsJSON, err := json.Marshal(fabric.Conditions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fabric conditions: %w", err)
+	}
+
+	query := `
+		INSERT INTO fabrics (` + fabricColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (name) DO UPDATE SET
+			description = EXCLUDED.description,
+			logic_operator = EXCLUDED.logic_operator,
+			conditions = EXCLUDED.conditions,
+			priority = EXCLUDED.priority,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		fabric.Name, fabric.Description, fabric.LogicOperator, conditionsJSON, fabric.Priority,
+		fabric.CreatedBy, fabric.CreatedAt, fabric.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save fabric: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteFabric removes a fabric definition
+func (r *postgresRepository) DeleteFabric(ctx context.Context, name string) error {
+	query := `DELETE FROM fabrics WHERE name = $1`
+
+	_, err := r.db.ExecContext(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete fabric: %w", err)
+	}
+
+	return nil
+}
+
+type fabricScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFabric(row fabricScanner) (*topology.Fabric, error) {
+	var fabric topology.Fabric
+	var conditionsJSON []byte
+
+	err := row.Scan(&fabric.Name, &fabric.Description, &fabric.LogicOperator, &conditionsJSON, &fabric.Priority,
+		&fabric.CreatedBy, &fabric.CreatedAt, &fabric.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan fabric: %w", err)
+	}
+
+	if err := json.Unmarshal(conditionsJSON, &fabric.Conditions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fabric conditions: %w", err)
+	}
+
+	return &fabric, nil
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// Hardware catalog repository methods
+
+// ListHardwareCatalog retrieves all approved hardware models
+func (r *postgresRepository) ListHardwareCatalog(ctx context.Context) ([]classification.HardwareCatalogEntry, error) {
+	query := `
+		SELECT id, layer_id, model, description, created_by, created_at
+		FROM hardware_catalog
+		ORDER BY layer_id, model
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hardware catalog: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []classification.HardwareCatalogEntry
+	for rows.Next() {
+		var entry classification.HardwareCatalogEntry
+		if err := rows.Scan(&entry.ID, &entry.LayerID, &entry.Model, &entry.Description, &entry.CreatedBy, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan hardware catalog entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate hardware catalog: %w", err)
+	}
+
+	return entries, nil
+}
+
+// SaveHardwareCatalogEntry creates or updates an approved hardware model
+func (r *postgresRepository) SaveHardwareCatalogEntry(ctx context.Context, entry classification.HardwareCatalogEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO hardware_catalog (id, layer_id, model, description, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			layer_id = EXCLUDED.layer_id,
+			model = EXCLUDED.model,
+			description = EXCLUDED.description
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		entry.ID, entry.LayerID, entry.Model, entry.Description, entry.CreatedBy, entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save hardware catalog entry: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteHardwareCatalogEntry removes an approved hardware model
+func (r *postgresRepository) DeleteHardwareCatalogEntry(ctx context.Context, entryID string) error {
+	query := `DELETE FROM hardware_catalog WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, entryID)
+	if err != nil {
+		return fmt.Errorf("failed to delete hardware catalog entry: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// Hardware lifecycle repository methods
+
+// ListHardwareLifecycle retrieves the end-of-sale/end-of-life dates of all hardware models
+func (r *postgresRepository) ListHardwareLifecycle(ctx context.Context) ([]classification.HardwareLifecycleEntry, error) {
+	query := `
+		SELECT id, model, end_of_sale, end_of_life, description, created_by, created_at
+		FROM hardware_lifecycle
+		ORDER BY model
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hardware lifecycle: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []classification.HardwareLifecycleEntry
+	for rows.Next() {
+		var entry classification.HardwareLifecycleEntry
+		var endOfSale, endOfLife sql.NullTime
+		if err := rows.Scan(&entry.ID, &entry.Model, &endOfSale, &endOfLife, &entry.Description, &entry.CreatedBy, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan hardware lifecycle entry: %w", err)
+		}
+		if endOfSale.Valid {
+			entry.EndOfSale = &endOfSale.Time
+		}
+		if endOfLife.Valid {
+			entry.EndOfLife = &endOfLife.Time
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate hardware lifecycle: %w", err)
+	}
+
+	return entries, nil
+}
+
+// SaveHardwareLifecycleEntry creates or updates the lifecycle dates of a hardware model
+func (r *postgresRepository) SaveHardwareLifecycleEntry(ctx context.Context, entry classification.HardwareLifecycleEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO hardware_lifecycle (id, model, end_of_sale, end_of_life, description, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			model = EXCLUDED.model,
+			end_of_sale = EXCLUDED.end_of_sale,
+			end_of_life = EXCLUDED.end_of_life,
+			description = EXCLUDED.description
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		entry.ID, entry.Model, entry.EndOfSale, entry.EndOfLife, entry.Description, entry.CreatedBy, entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save hardware lifecycle entry: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteHardwareLifecycleEntry removes the lifecycle dates of a hardware model
+func (r *postgresRepository) DeleteHardwareLifecycleEntry(ctx context.Context, entryID string) error {
+	query := `DELETE FROM hardware_lifecycle WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, entryID)
+	if err != nil {
+		return fmt.Errorf("failed to delete hardware lifecycle entry: %w", err)
+	}
+
+	return nil
+}
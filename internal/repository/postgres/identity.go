@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Identity decision repository methods
+
+// ListIdentityDecisions retrieves every manual identity decision
+func (r *postgresRepository) ListIdentityDecisions(ctx context.Context) ([]topology.IdentityDecision, error) {
+	query := `SELECT key, devices, canonical_id, note, decided_by, decided_at FROM identity_decisions ORDER BY key`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identity decisions: %w", err)
+	}
+	defer rows.Close()
+
+	decisions := []topology.IdentityDecision{}
+	for rows.Next() {
+		var decision topology.IdentityDecision
+		var devicesJSON string
+		if err := rows.Scan(&decision.Key, &devicesJSON, &decision.CanonicalID, &decision.Note, &decision.DecidedBy, &decision.DecidedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan identity decision: %w", err)
+		}
+		if err := json.Unmarshal([]byte(devicesJSON), &decision.Devices); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal identity decision devices: %w", err)
+		}
+		decisions = append(decisions, decision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate identity decisions: %w", err)
+	}
+
+	return decisions, nil
+}
+
+// SaveIdentityDecision creates or replaces the decision with the same key
+func (r *postgresRepository) SaveIdentityDecision(ctx context.Context, decision topology.IdentityDecision) error {
+	if decision.DecidedAt.IsZero() {
+		decision.DecidedAt = time.Now()
+	}
+	devicesJSON, err := json.Marshal(decision.Devices)
+	if err != nil {
+		return fmt.Errorf("failed to marshal identity decision devices: %w", err)
+	}
+
+	query := `
+		INSERT INTO identity_decisions (key, devices, canonical_id, note, decided_by, decided_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (key) DO UPDATE SET
+			devices = EXCLUDED.devices,
+			canonical_id = EXCLUDED.canonical_id,
+			note = EXCLUDED.note,
+			decided_by = EXCLUDED.decided_by,
+			decided_at = EXCLUDED.decided_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		decision.Key, string(devicesJSON), decision.CanonicalID, decision.Note, decision.DecidedBy, decision.DecidedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save identity decision: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteIdentityDecision removes a decision
+func (r *postgresRepository) DeleteIdentityDecision(ctx context.Context, key string) error {
+	query := `DELETE FROM identity_decisions WHERE key = $1`
+
+	_, err := r.db.ExecContext(ctx, query, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete identity decision: %w", err)
+	}
+
+	return nil
+}
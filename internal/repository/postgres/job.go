@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/servak/topology-manager/internal/domain/job"
+)
+
+// Job queue repository methods
+
+const jobColumns = `id, kind, payload, status, attempts, max_attempts, result, error, locked_by, visible_at, created_by, created_at, updated_at, finished_at`
+
+type jobScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row jobScanner) (*job.Job, error) {
+	var j job.Job
+	var payload, result []byte
+	if err := row.Scan(&j.ID, &j.Kind, &payload, &j.Status, &j.Attempts, &j.MaxAttempts, &result,
+		&j.Error, &j.LockedBy, &j.VisibleAt, &j.CreatedBy, &j.CreatedAt, &j.UpdatedAt, &j.FinishedAt); err != nil {
+		return nil, err
+	}
+	if len(payload) > 0 {
+		j.Payload = json.RawMessage(payload)
+	}
+	if len(result) > 0 {
+		j.Result = json.RawMessage(result)
+	}
+	return &j, nil
+}
+
+// nullableJSON passes raw JSON as text so that empty values are stored as NULL
+func nullableJSON(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// EnqueueJob stores a new job, visible immediately
+func (r *postgresRepository) EnqueueJob(ctx context.Context, j job.Job) error {
+	query := `
+		INSERT INTO jobs (id, kind, payload, status, max_attempts, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, j.ID, j.Kind, nullableJSON(j.Payload), job.StatusQueued, j.MaxAttempts, j.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimJob leases the oldest visible job of kinds to worker
+func (r *postgresRepository) ClaimJob(ctx context.Context, worker string, kinds []string, visibility time.Duration) (*job.Job, error) {
+	// リースが切れたまま再試行回数を使い切ったジョブは、再取得せずに失敗として終える
+	expireQuery := `
+		UPDATE jobs SET
+			status = 'failed',
+			error = 'lease expired after the last attempt',
+			locked_by = '',
+			updated_at = NOW(),
+			finished_at = NOW()
+		WHERE status = 'running' AND visible_at <= NOW() AND attempts >= max_attempts
+	`
+	if _, err := r.db.ExecContext(ctx, expireQuery); err != nil {
+		return nil, fmt.Errorf("failed to expire abandoned jobs: %w", err)
+	}
+
+	// SKIP LOCKED で複数のワーカーが同じジョブを取得しないようにする
+	query := `
+		UPDATE jobs SET
+			status = 'running',
+			locked_by = $1,
+			attempts = attempts + 1,
+			visible_at = NOW() + $2 * INTERVAL '1 second',
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status IN ('queued', 'running') AND visible_at <= NOW() AND kind = ANY($3)
+			ORDER BY visible_at, created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+
+	claimed, err := scanJob(r.db.QueryRowContext(ctx, query, worker, visibility.Seconds(), pq.Array(kinds)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return claimed, nil
+}
+
+// ExtendJobLease pushes the lease of a running job held by worker
+func (r *postgresRepository) ExtendJobLease(ctx context.Context, id, worker string, visibility time.Duration) (bool, error) {
+	query := `
+		UPDATE jobs SET visible_at = NOW() + $3 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id = $1 AND locked_by = $2 AND status = 'running'
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, worker, visibility.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to extend job lease: %w", err)
+	}
+
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// CompleteJob stores the result of a running job held by worker
+func (r *postgresRepository) CompleteJob(ctx context.Context, id, worker string, result json.RawMessage) (bool, error) {
+	query := `
+		UPDATE jobs SET
+			status = 'succeeded',
+			result = $3,
+			error = '',
+			locked_by = '',
+			updated_at = NOW(),
+			finished_at = NOW()
+		WHERE id = $1 AND locked_by = $2 AND status = 'running'
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, worker, nullableJSON(result))
+	if err != nil {
+		return false, fmt.Errorf("failed to complete job: %w", err)
+	}
+
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// FailJob records a failed attempt of a running job held by worker
+func (r *postgresRepository) FailJob(ctx context.Context, id, worker, message string, retryDelay time.Duration) (bool, error) {
+	query := `
+		UPDATE jobs SET
+			status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'queued' END,
+			error = $3,
+			locked_by = '',
+			visible_at = NOW() + $4 * INTERVAL '1 second',
+			updated_at = NOW(),
+			finished_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END
+		WHERE id = $1 AND locked_by = $2 AND status = 'running'
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, worker, message, retryDelay.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to record job failure: %w", err)
+	}
+
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// GetJob retrieves a job by ID
+func (r *postgresRepository) GetJob(ctx context.Context, id string) (*job.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+
+	j, err := scanJob(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return j, nil
+}
+
+// ListJobs retrieves the newest jobs, optionally restricted to a status
+func (r *postgresRepository) ListJobs(ctx context.Context, status string, limit int) ([]job.Job, error) {
+	query := `
+		SELECT ` + jobColumns + ` FROM jobs
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC, id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []job.Job{}
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, *j)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate jobs: %w", err)
+	}
+
+	return jobs, nil
+}
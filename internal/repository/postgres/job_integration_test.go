@@ -0,0 +1,88 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/job"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimJob_ConcurrentWorkersClaimEachJobOnce(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	repo := setup.Repo.(job.Repository)
+	ctx := context.Background()
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, repo.EnqueueJob(ctx, job.Job{ID: fmt.Sprintf("job-%02d", i), Kind: "snapshot", MaxAttempts: 1, CreatedBy: "test"}))
+	}
+
+	var mu sync.Mutex
+	claimed := make(map[string]string)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		worker := fmt.Sprintf("worker-%d", w)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j, err := repo.ClaimJob(ctx, worker, []string{"snapshot"}, time.Minute)
+				if !assert.NoError(t, err) || j == nil {
+					return
+				}
+				mu.Lock()
+				assert.Empty(t, claimed[j.ID], "job %s claimed twice", j.ID)
+				claimed[j.ID] = worker
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, 20)
+}
+
+func TestFailJob_RetriesUntilAttemptsAreUsedUp(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	repo := setup.Repo.(job.Repository)
+	ctx := context.Background()
+
+	require.NoError(t, repo.EnqueueJob(ctx, job.Job{ID: "job-1", Kind: "snapshot", MaxAttempts: 2, CreatedBy: "test"}))
+
+	first, err := repo.ClaimJob(ctx, "a", []string{"snapshot"}, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	held, err := repo.FailJob(ctx, first.ID, "a", "boom", 0)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	// 他のワーカーのリースは完了にできない
+	second, err := repo.ClaimJob(ctx, "b", []string{"snapshot"}, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, second)
+	assert.Equal(t, 2, second.Attempts)
+	held, err = repo.CompleteJob(ctx, second.ID, "a", nil)
+	require.NoError(t, err)
+	assert.False(t, held)
+
+	held, err = repo.FailJob(ctx, second.ID, "b", "boom again", 0)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	stored, err := repo.GetJob(ctx, "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, job.StatusFailed, stored.Status)
+	assert.Equal(t, "boom again", stored.Error)
+	assert.NotNil(t, stored.FinishedAt)
+
+	none, err := repo.ClaimJob(ctx, "c", []string{"snapshot"}, time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+}
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+// Layout cache repository methods
+
+// RecordViewRequests adds count requests of a view, the last one made at
+func (r *postgresRepository) RecordViewRequests(ctx context.Context, key visualization.ViewKey, count int, at time.Time) error {
+	query := `
+		INSERT INTO view_requests (root_device, depth, options, request_count, last_requested)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (root_device, depth, options) DO UPDATE SET
+			request_count = view_requests.request_count + EXCLUDED.request_count,
+			last_requested = EXCLUDED.last_requested
+	`
+
+	_, err := r.db.ExecContext(ctx, query, key.RootDevice, key.Depth, key.Options, count, at)
+	if err != nil {
+		return fmt.Errorf("failed to record view requests: %w", err)
+	}
+
+	return nil
+}
+
+// ListPopularViews retrieves views requested since the given time, most requested first
+func (r *postgresRepository) ListPopularViews(ctx context.Context, since time.Time, limit int) ([]visualization.ViewPopularity, error) {
+	query := `
+		SELECT root_device, depth, options, request_count, last_requested
+		FROM view_requests
+		WHERE last_requested >= $1
+		ORDER BY request_count DESC, last_requested DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list popular views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []visualization.ViewPopularity
+	for rows.Next() {
+		var view visualization.ViewPopularity
+		if err := rows.Scan(&view.RootDevice, &view.Depth, &view.Options, &view.RequestCount, &view.LastRequested); err != nil {
+			return nil, fmt.Errorf("failed to scan view popularity: %w", err)
+		}
+		views = append(views, view)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate popular views: %w", err)
+	}
+
+	return views, nil
+}
+
+// GetCachedLayout retrieves the cached layout of a view
+func (r *postgresRepository) GetCachedLayout(ctx context.Context, key visualization.ViewKey) (*visualization.CachedLayout, error) {
+	query := `
+		SELECT node_set_hash, layout, computed_at
+		FROM layout_cache
+		WHERE root_device = $1 AND depth = $2 AND options = $3
+	`
+
+	cached := visualization.CachedLayout{ViewKey: key}
+	var layoutJSON []byte
+	err := r.db.QueryRowContext(ctx, query, key.RootDevice, key.Depth, key.Options).Scan(&cached.NodeSetHash, &layoutJSON, &cached.ComputedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached layout: %w", err)
+	}
+
+	if err := json.Unmarshal(layoutJSON, &cached.Layout); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cached layout: %w", err)
+	}
+
+	return &cached, nil
+}
+
+// SaveCachedLayout creates or replaces the cached layout of a view
+func (r *postgresRepository) SaveCachedLayout(ctx context.Context, cached visualization.CachedLayout) error {
+	if cached.ComputedAt.IsZero() {
+		cached.ComputedAt = time.Now()
+	}
+
+	layoutJSON, err := json.Marshal(cached.Layout)
+	if err != nil {
+		return fmt.Errorf("failed to marshal layout: %w", err)
+	}
+
+	query := `
+		INSERT INTO layout_cache (root_device, depth, options, node_set_hash, layout, computed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (root_device, depth, options) DO UPDATE SET
+			node_set_hash = EXCLUDED.node_set_hash,
+			layout = EXCLUDED.layout,
+			computed_at = EXCLUDED.computed_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		cached.RootDevice, cached.Depth, cached.Options, cached.NodeSetHash, layoutJSON, cached.ComputedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save cached layout: %w", err)
+	}
+
+	return nil
+}
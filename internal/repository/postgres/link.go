@@ -2,35 +2,42 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/servak/topology-manager/internal/domain/topology"
 )
 
 // Link-related repository methods
 
+// linkUpsertQuery inserts a link or merges it into the stored row with the same
+// column-wise last-write-wins on updated_at as deviceUpsertQuery
+const linkUpsertQuery = `
+	INSERT INTO links (id, source_id, target_id, source_port, target_port, weight, metadata, last_seen, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (id) DO UPDATE SET
+		source_id = CASE WHEN EXCLUDED.updated_at >= links.updated_at THEN EXCLUDED.source_id ELSE links.source_id END,
+		target_id = CASE WHEN EXCLUDED.updated_at >= links.updated_at THEN EXCLUDED.target_id ELSE links.target_id END,
+		source_port = CASE WHEN EXCLUDED.updated_at >= links.updated_at THEN EXCLUDED.source_port ELSE links.source_port END,
+		target_port = CASE WHEN EXCLUDED.updated_at >= links.updated_at THEN EXCLUDED.target_port ELSE links.target_port END,
+		weight = CASE WHEN EXCLUDED.updated_at >= links.updated_at THEN EXCLUDED.weight ELSE links.weight END,
+		metadata = CASE WHEN EXCLUDED.updated_at >= links.updated_at THEN EXCLUDED.metadata ELSE links.metadata END,
+		last_seen = GREATEST(links.last_seen, EXCLUDED.last_seen),
+		created_at = LEAST(links.created_at, EXCLUDED.created_at),
+		updated_at = GREATEST(links.updated_at, EXCLUDED.updated_at)
+`
+
 func (r *postgresRepository) AddLink(ctx context.Context, link topology.Link) error {
-	query := `
-		INSERT INTO links (id, source_id, target_id, source_port, target_port, weight, metadata, last_seen, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT (id) DO UPDATE SET
-			source_id = EXCLUDED.source_id,
-			target_id = EXCLUDED.target_id,
-			source_port = EXCLUDED.source_port,
-			target_port = EXCLUDED.target_port,
-			weight = EXCLUDED.weight,
-			metadata = EXCLUDED.metadata,
-			last_seen = EXCLUDED.last_seen,
-			updated_at = EXCLUDED.updated_at
-	`
+	link = withLinkUpsertTimestamps(link)
 
-	metadataJSON := "{}"
-	if len(link.Metadata) > 0 {
-		// TODO: Proper JSON serialization for metadata
-		metadataJSON = "{}"
+	metadataJSON, err := marshalMetadata(link.Metadata)
+	if err != nil {
+		return err
 	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, linkUpsertQuery,
 		link.ID, link.SourceID, link.TargetID, link.SourcePort, link.TargetPort,
 		link.Weight, metadataJSON, link.LastSeen, link.CreatedAt, link.UpdatedAt,
 	)
@@ -61,13 +68,14 @@ func (r *postgresRepository) GetLink(ctx context.Context, linkID string) (*topol
 		&link.Weight, &metadataJSON, &link.LastSeen, &link.CreatedAt, &link.UpdatedAt,
 	)
 
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get link: %w", err)
 	}
 
-	// Initialize metadata map
-	link.Metadata = make(map[string]string)
-	// TODO: Parse JSON metadata
+	link.Metadata = unmarshalMetadata(metadataJSON)
 
 	return &link, nil
 }
@@ -89,7 +97,7 @@ func (r *postgresRepository) GetDeviceLinks(ctx context.Context, deviceID string
 		ORDER BY id
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, deviceID)
+	rows, err := r.readQuery(ctx, query, deviceID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get device links: %w", err)
 	}
@@ -108,10 +116,52 @@ func (r *postgresRepository) GetDeviceLinks(ctx context.Context, deviceID string
 			return nil, fmt.Errorf("failed to scan link: %w", err)
 		}
 
-		link.Metadata = make(map[string]string)
+		link.Metadata = unmarshalMetadata(metadataJSON)
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate links: %w", err)
+	}
+
+	return links, nil
+}
+
+// ListLinks returns every link ordered by ID
+func (r *postgresRepository) ListLinks(ctx context.Context) ([]topology.Link, error) {
+	query := `
+		SELECT id, source_id, target_id, source_port, target_port, weight, metadata, last_seen, created_at, updated_at
+		FROM links
+		ORDER BY id
+	`
+
+	rows, err := r.readQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []topology.Link{}
+	for rows.Next() {
+		var link topology.Link
+		var metadataJSON string
+
+		err := rows.Scan(
+			&link.ID, &link.SourceID, &link.TargetID, &link.SourcePort, &link.TargetPort,
+			&link.Weight, &metadataJSON, &link.LastSeen, &link.CreatedAt, &link.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+
+		link.Metadata = unmarshalMetadata(metadataJSON)
 		links = append(links, link)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate links: %w", err)
+	}
+
 	return links, nil
 }
 
@@ -142,10 +192,14 @@ func (r *postgresRepository) FindLinksByPort(ctx context.Context, deviceID, port
 			return nil, fmt.Errorf("failed to scan link: %w", err)
 		}
 
-		link.Metadata = make(map[string]string)
+		link.Metadata = unmarshalMetadata(metadataJSON)
 		links = append(links, link)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate links: %w", err)
+	}
+
 	return links, nil
 }
 
@@ -154,32 +208,31 @@ func (r *postgresRepository) BulkAddLinks(ctx context.Context, links []topology.
 		return nil
 	}
 
+	// デバイスと同様にID順で書き込み、並行するバッチ間のデッドロックを防ぐ
+	links = linksForUpsert(links)
+
+	// 観測がデフォルトパーティションに入らないよう、該当月のパーティションを先に作成する
+	if err := r.ensureLinkHistoryPartitions(ctx, links); err != nil {
+		return err
+	}
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO links (id, source_id, target_id, source_port, target_port, weight, metadata, last_seen, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT (id) DO UPDATE SET
-			source_id = EXCLUDED.source_id,
-			target_id = EXCLUDED.target_id,
-			source_port = EXCLUDED.source_port,
-			target_port = EXCLUDED.target_port,
-			weight = EXCLUDED.weight,
-			metadata = EXCLUDED.metadata,
-			last_seen = EXCLUDED.last_seen,
-			updated_at = EXCLUDED.updated_at
-	`)
+	stmt, err := tx.PrepareContext(ctx, linkUpsertQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
 	for _, link := range links {
-		metadataJSON := "{}"
+		metadataJSON, err := marshalMetadata(link.Metadata)
+		if err != nil {
+			return err
+		}
 		_, err = stmt.ExecContext(ctx,
 			link.ID, link.SourceID, link.TargetID, link.SourcePort, link.TargetPort,
 			link.Weight, metadataJSON, link.LastSeen, link.CreatedAt, link.UpdatedAt,
@@ -189,5 +242,43 @@ func (r *postgresRepository) BulkAddLinks(ctx context.Context, links []topology.
 		}
 	}
 
+	// 観測履歴を記録（保持期間・圧縮はコンパクションジョブで管理）
+	if err := recordLinkHistory(ctx, tx, links); err != nil {
+		return err
+	}
+
 	return tx.Commit()
-}
\ No newline at end of file
+}
+
+// linksForUpsert returns the links sorted by ID with one entry per ID, like devicesForUpsert
+func linksForUpsert(links []topology.Link) []topology.Link {
+	latest := make(map[string]topology.Link, len(links))
+	for _, link := range links {
+		link = withLinkUpsertTimestamps(link)
+		if current, ok := latest[link.ID]; !ok || !link.UpdatedAt.Before(current.UpdatedAt) {
+			latest[link.ID] = link
+		}
+	}
+
+	result := make([]topology.Link, 0, len(latest))
+	for _, link := range latest {
+		result = append(result, link)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// withLinkUpsertTimestamps fills zero timestamps like withUpsertTimestamps
+func withLinkUpsertTimestamps(link topology.Link) topology.Link {
+	now := time.Now()
+	if link.UpdatedAt.IsZero() {
+		link.UpdatedAt = now
+	}
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = link.UpdatedAt
+	}
+	if link.LastSeen.IsZero() {
+		link.LastSeen = link.UpdatedAt
+	}
+	return link
+}
@@ -0,0 +1,353 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Link history repository methods
+// link_history / link_history_daily は observed_at / day による月次パーティション構成
+
+const partitionSuffixLayout = "2006_01"
+
+// linkHistoryBatchSize is how many observations one INSERT into link_history writes
+const linkHistoryBatchSize = 1000
+
+// partitionColumns is the range partition key of each partitioned table
+var partitionColumns = map[string]string{
+	"link_history":       "observed_at",
+	"link_history_daily": "day",
+}
+
+// recordLinkHistory appends link observations to link_history within the given transaction,
+// in batches of linkHistoryBatchSize rows
+func recordLinkHistory(ctx context.Context, tx *sql.Tx, links []topology.Link) error {
+	for start := 0; start < len(links); start += linkHistoryBatchSize {
+		batch := links[start:min(start+linkHistoryBatchSize, len(links))]
+
+		columns := make([]pq.StringArray, 5)
+		weights := make(pq.Float64Array, 0, len(batch))
+		observedAt := make(pq.StringArray, 0, len(batch))
+		for _, link := range batch {
+			for i, value := range []string{link.ID, link.SourceID, link.TargetID, link.SourcePort, link.TargetPort} {
+				columns[i] = append(columns[i], value)
+			}
+			weights = append(weights, link.Weight)
+			observed := link.LastSeen
+			if observed.IsZero() {
+				observed = time.Now()
+			}
+			observedAt = append(observedAt, observed.Format(time.RFC3339Nano))
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO link_history (link_id, source_id, target_id, source_port, target_port, weight, observed_at)
+			SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::numeric[], $7::timestamptz[])
+		`, columns[0], columns[1], columns[2], columns[3], columns[4], weights, observedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record link history: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ensureLinkHistoryPartitions creates the monthly link_history partitions the observations of links fall into
+func (r *postgresRepository) ensureLinkHistoryPartitions(ctx context.Context, links []topology.Link) error {
+	months := make(map[time.Time]bool)
+	for _, link := range links {
+		observed := link.LastSeen
+		if observed.IsZero() {
+			observed = time.Now()
+		}
+		months[monthStart(observed)] = true
+	}
+
+	for month := range months {
+		if _, _, err := r.ensureMonthlyPartition(ctx, "link_history", month); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// monthStart returns the first day of the UTC month of t, the range of its partition
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// CompactLinkHistory rolls raw observations up into daily summaries and drops expired monthly partitions
+func (r *postgresRepository) CompactLinkHistory(ctx context.Context, retention topology.LinkHistoryRetention) (*topology.LinkHistoryCompactionResult, error) {
+	now := time.Now().UTC()
+	result := &topology.LinkHistoryCompactionResult{
+		DroppedPartitions: []string{},
+		CreatedPartitions: []string{},
+	}
+
+	// 当月と翌月のパーティションを先行作成し、デフォルトパーティションに入った行の月も作成して移す
+	currentMonth := monthStart(now)
+	for _, table := range []string{"link_history", "link_history_daily"} {
+		months, err := r.defaultPartitionMonths(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		months = append(months, currentMonth, currentMonth.AddDate(0, 1, 0))
+		if err := r.ensureMonthlyPartitions(ctx, table, months, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if retention.RawRetention > 0 {
+		cutoff := now.Add(-retention.RawRetention)
+		// 日次サマリーがデフォルトパーティションに入らないよう、圧縮する月のパーティションを先に作成する
+		months, err := r.linkHistoryMonthsBefore(ctx, cutoff)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.ensureMonthlyPartitions(ctx, "link_history_daily", months, result); err != nil {
+			return nil, err
+		}
+		if err := r.compactRawLinkHistory(ctx, cutoff, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if retention.SummaryRetention > 0 {
+		if err := r.expireLinkHistorySummaries(ctx, now.Add(-retention.SummaryRetention), result); err != nil {
+			return nil, err
+		}
+	}
+
+	// DROP したパーティションは次に必要になったときに作り直す
+	for _, name := range result.DroppedPartitions {
+		r.partitions.Delete(name)
+	}
+
+	// 削除したタプルの領域を回収（VACUUM はトランザクション外で実行する必要がある）
+	if result.DeletedRawRows > 0 || result.DeletedSummaries > 0 || len(result.DroppedPartitions) > 0 {
+		if _, err := r.db.ExecContext(ctx, "VACUUM (ANALYZE) link_history, link_history_daily"); err != nil {
+			return nil, fmt.Errorf("failed to vacuum link history: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// compactRawLinkHistory rolls up raw rows older than cutoff and removes them
+func (r *postgresRepository) compactRawLinkHistory(ctx context.Context, cutoff time.Time, result *topology.LinkHistoryCompactionResult) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO link_history_daily (link_id, day, source_id, target_id, source_port, target_port, observations, first_seen, last_seen)
+		SELECT link_id, (observed_at AT TIME ZONE 'UTC')::date,
+			MAX(source_id), MAX(target_id), MAX(source_port), MAX(target_port),
+			COUNT(*), MIN(observed_at), MAX(observed_at)
+		FROM link_history
+		WHERE observed_at < $1
+		GROUP BY link_id, (observed_at AT TIME ZONE 'UTC')::date
+		ON CONFLICT (link_id, day) DO UPDATE SET
+			observations = link_history_daily.observations + EXCLUDED.observations,
+			first_seen = LEAST(link_history_daily.first_seen, EXCLUDED.first_seen),
+			last_seen = GREATEST(link_history_daily.last_seen, EXCLUDED.last_seen)
+	`, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to roll up link history: %w", err)
+	}
+	result.CompactedRows, _ = res.RowsAffected()
+
+	// 全期間が保持期間外の月次パーティションは DELETE せずに DROP する
+	dropped, err := dropExpiredPartitions(ctx, tx, "link_history", cutoff)
+	if err != nil {
+		return err
+	}
+	result.DroppedPartitions = append(result.DroppedPartitions, dropped...)
+
+	// 境界パーティションとデフォルトパーティションの残りを削除
+	res, err = tx.ExecContext(ctx, `DELETE FROM link_history WHERE observed_at < $1`, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to delete compacted link history: %w", err)
+	}
+	result.DeletedRawRows, _ = res.RowsAffected()
+
+	return tx.Commit()
+}
+
+// expireLinkHistorySummaries removes daily summaries older than cutoff
+func (r *postgresRepository) expireLinkHistorySummaries(ctx context.Context, cutoff time.Time, result *topology.LinkHistoryCompactionResult) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	dropped, err := dropExpiredPartitions(ctx, tx, "link_history_daily", cutoff)
+	if err != nil {
+		return err
+	}
+	result.DroppedPartitions = append(result.DroppedPartitions, dropped...)
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM link_history_daily WHERE day < $1::date`, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired link history summaries: %w", err)
+	}
+	result.DeletedSummaries, _ = res.RowsAffected()
+
+	return tx.Commit()
+}
+
+// ensureMonthlyPartitions creates the missing monthly partitions of table and records them in result
+func (r *postgresRepository) ensureMonthlyPartitions(ctx context.Context, table string, months []time.Time, result *topology.LinkHistoryCompactionResult) error {
+	seen := make(map[time.Time]bool, len(months))
+	for _, month := range months {
+		if seen[month] {
+			continue
+		}
+		seen[month] = true
+
+		name, created, err := r.ensureMonthlyPartition(ctx, table, month)
+		if err != nil {
+			return err
+		}
+		if created {
+			result.CreatedPartitions = append(result.CreatedPartitions, name)
+		}
+	}
+	return nil
+}
+
+// defaultPartitionMonths returns the months of the rows in the default partition of table
+func (r *postgresRepository) defaultPartitionMonths(ctx context.Context, table string) ([]time.Time, error) {
+	column := partitionColumns[table]
+	if column == "observed_at" {
+		column = "observed_at AT TIME ZONE 'UTC'"
+	}
+	query := fmt.Sprintf(`SELECT DISTINCT date_trunc('month', %s)::date FROM %s_default`, column, table)
+	return r.queryMonths(ctx, query)
+}
+
+// linkHistoryMonthsBefore returns the months of the raw observations before cutoff
+func (r *postgresRepository) linkHistoryMonthsBefore(ctx context.Context, cutoff time.Time) ([]time.Time, error) {
+	return r.queryMonths(ctx, `
+		SELECT DISTINCT date_trunc('month', observed_at AT TIME ZONE 'UTC')::date
+		FROM link_history
+		WHERE observed_at < $1
+	`, cutoff)
+}
+
+func (r *postgresRepository) queryMonths(ctx context.Context, query string, args ...interface{}) ([]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link history months: %w", err)
+	}
+	defer rows.Close()
+
+	var months []time.Time
+	for rows.Next() {
+		var month time.Time
+		if err := rows.Scan(&month); err != nil {
+			return nil, fmt.Errorf("failed to scan link history month: %w", err)
+		}
+		months = append(months, monthStart(month))
+	}
+	return months, rows.Err()
+}
+
+// ensureMonthlyPartition creates the monthly partition of table starting at month if missing.
+// Rows of that month already in the default partition would block the new partition, so they
+// are moved into it in the same transaction.
+func (r *postgresRepository) ensureMonthlyPartition(ctx context.Context, table string, month time.Time) (string, bool, error) {
+	name := fmt.Sprintf("%s_%s", table, month.Format(partitionSuffixLayout))
+	if _, ok := r.partitions.Load(name); ok {
+		return name, false, nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, name).Scan(&exists); err != nil {
+		return "", false, fmt.Errorf("failed to check partition %s: %w", name, err)
+	}
+	if exists {
+		r.partitions.Store(name, true)
+		return name, false, nil
+	}
+
+	from, to := month.Format("2006-01-02"), month.AddDate(0, 1, 0).Format("2006-01-02")
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		fmt.Sprintf(`CREATE TEMP TABLE partition_rows (LIKE %s) ON COMMIT DROP`, table),
+		fmt.Sprintf(`WITH moved AS (DELETE FROM %s_default WHERE %s >= '%s' AND %s < '%s' RETURNING *) INSERT INTO partition_rows SELECT * FROM moved`,
+			table, partitionColumns[table], from, partitionColumns[table], to),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')`, name, table, from, to),
+		fmt.Sprintf(`INSERT INTO %s SELECT * FROM partition_rows`, table),
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return "", false, fmt.Errorf("failed to create partition %s: %w", name, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", false, fmt.Errorf("failed to create partition %s: %w", name, err)
+	}
+
+	r.partitions.Store(name, true)
+	return name, true, nil
+}
+
+// dropExpiredPartitions drops monthly partitions of table whose whole range is before cutoff
+func dropExpiredPartitions(ctx context.Context, tx *sql.Tx, table string, cutoff time.Time) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_class p ON p.oid = i.inhparent
+		WHERE p.relname = $1
+	`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", table, err)
+	}
+
+	var expired []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan partition name: %w", err)
+		}
+
+		// デフォルトパーティションなど命名規則外のものは対象外
+		month, err := time.Parse(partitionSuffixLayout, strings.TrimPrefix(name, table+"_"))
+		if err != nil {
+			continue
+		}
+		if !month.AddDate(0, 1, 0).After(cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate partitions of %s: %w", table, err)
+	}
+
+	for _, name := range expired {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", name)); err != nil {
+			return nil, fmt.Errorf("failed to drop partition %s: %w", name, err)
+		}
+	}
+
+	return expired, nil
+}
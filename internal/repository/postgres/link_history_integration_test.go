@@ -0,0 +1,120 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository/postgres"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countRows(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&count))
+	return count
+}
+
+func partitionExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+
+	var exists bool
+	require.NoError(t, db.QueryRow("SELECT to_regclass($1) IS NOT NULL", name).Scan(&exists))
+	return exists
+}
+
+func TestBulkAddLinks_CreatesHistoryPartitions(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	setup.SeedTestData(t)
+	db := postgres.DBForTest(setup.Repo)
+	ctx := context.Background()
+
+	// マイグレーションが作成するのは当月と翌月のみ
+	future := time.Now().UTC().AddDate(0, 3, 0)
+	name := "link_history_" + future.Format("2006_01")
+	require.False(t, partitionExists(t, db, name))
+
+	links := make([]topology.Link, 2500)
+	for i := range links {
+		links[i] = topology.Link{
+			ID:         fmt.Sprintf("history-%04d", i),
+			SourceID:   "device-001",
+			TargetID:   "device-002",
+			SourcePort: fmt.Sprintf("Eth%d", i),
+			TargetPort: fmt.Sprintf("Eth%d", i),
+			Weight:     1,
+			LastSeen:   future,
+		}
+	}
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, links))
+
+	assert.True(t, partitionExists(t, db, name))
+	assert.Equal(t, len(links), countRows(t, db, name), "every observation should be recorded across batches")
+	assert.Zero(t, countRows(t, db, "link_history_default"))
+}
+
+func TestCompactLinkHistory_MovesDefaultPartitionRows(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	db := postgres.DBForTest(setup.Repo)
+	ctx := context.Background()
+
+	// パーティション作成前に記録された観測とサマリー
+	_, err := db.Exec(`
+		INSERT INTO link_history (link_id, source_id, target_id, source_port, target_port, observed_at)
+		VALUES ('old', 'device-001', 'device-002', 'Eth1', 'Eth1', '2020-01-15T12:00:00Z')
+	`)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		INSERT INTO link_history_daily (link_id, day, source_id, target_id, observations, first_seen, last_seen)
+		VALUES ('old', '2020-02-10', 'device-001', 'device-002', 3, '2020-02-10T00:00:00Z', '2020-02-10T12:00:00Z')
+	`)
+	require.NoError(t, err)
+	require.Equal(t, 1, countRows(t, db, "link_history_default"))
+	require.Equal(t, 1, countRows(t, db, "link_history_daily_default"))
+
+	historyRepo, ok := setup.Repo.(topology.LinkHistoryRepository)
+	require.True(t, ok)
+	result, err := historyRepo.CompactLinkHistory(ctx, topology.LinkHistoryRetention{})
+	require.NoError(t, err)
+
+	assert.Contains(t, result.CreatedPartitions, "link_history_2020_01")
+	assert.Contains(t, result.CreatedPartitions, "link_history_daily_2020_02")
+	assert.Zero(t, countRows(t, db, "link_history_default"))
+	assert.Zero(t, countRows(t, db, "link_history_daily_default"))
+	assert.Equal(t, 1, countRows(t, db, "link_history_2020_01"))
+	assert.Equal(t, 1, countRows(t, db, "link_history_daily_2020_02"))
+}
+
+func TestCompactLinkHistory_RollsUpIntoMonthlyPartitions(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	db := postgres.DBForTest(setup.Repo)
+	ctx := context.Background()
+
+	observedAt := time.Now().UTC().AddDate(0, -4, 0)
+	_, err := db.Exec(`
+		INSERT INTO link_history (link_id, source_id, target_id, source_port, target_port, observed_at)
+		VALUES ('old', 'device-001', 'device-002', 'Eth1', 'Eth1', $1), ('old', 'device-001', 'device-002', 'Eth1', 'Eth1', $1)
+	`, observedAt)
+	require.NoError(t, err)
+
+	historyRepo := setup.Repo.(topology.LinkHistoryRepository)
+	result, err := historyRepo.CompactLinkHistory(ctx, topology.LinkHistoryRetention{RawRetention: 30 * 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.CompactedRows)
+
+	// 日次サマリーはデフォルトパーティションではなく該当月のパーティションに入る
+	summaries := "link_history_daily_" + observedAt.Format("2006_01")
+	assert.Contains(t, result.CreatedPartitions, summaries)
+	assert.Equal(t, 1, countRows(t, db, summaries))
+	assert.Zero(t, countRows(t, db, "link_history_daily_default"))
+	assert.Zero(t, countRows(t, db, "link_history"))
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Maintenance window repository methods
+
+const maintenanceWindowColumns = `id, ticket, description, starts_at, ends_at, devices, links, created_by, created_at`
+
+// ListMaintenanceWindows retrieves the windows active between from and to (zero = open)
+func (r *postgresRepository) ListMaintenanceWindows(ctx context.Context, from, to time.Time) ([]topology.MaintenanceWindow, error) {
+	var fromArg, toArg interface{}
+	if !from.IsZero() {
+		fromArg = from
+	}
+	if !to.IsZero() {
+		toArg = to
+	}
+
+	query := `
+		SELECT ` + maintenanceWindowColumns + ` FROM maintenance_windows
+		WHERE ($1::timestamptz IS NULL OR ends_at > $1)
+		  AND ($2::timestamptz IS NULL OR starts_at < $2)
+		ORDER BY starts_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, fromArg, toArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	windows := []topology.MaintenanceWindow{}
+	for rows.Next() {
+		window, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, *window)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate maintenance windows: %w", err)
+	}
+
+	return windows, nil
+}
+
+// GetMaintenanceWindow retrieves a window by ID
+func (r *postgresRepository) GetMaintenanceWindow(ctx context.Context, windowID string) (*topology.MaintenanceWindow, error) {
+	query := `SELECT ` + maintenanceWindowColumns + ` FROM maintenance_windows WHERE id = $1`
+
+	window, err := scanMaintenanceWindow(r.db.QueryRowContext(ctx, query, windowID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return window, nil
+}
+
+// SaveMaintenanceWindow creates or updates a window
+func (r *postgresRepository) SaveMaintenanceWindow(ctx context.Context, window topology.MaintenanceWindow) error {
+	if window.CreatedAt.IsZero() {
+		window.CreatedAt = time.Now()
+	}
+	// NULL ではなく空の配列として保存する
+	if window.Devices == nil {
+		window.Devices = []string{}
+	}
+	if window.Links == nil {
+		window.Links = []string{}
+	}
+
+	devicesJSON, err := json.Marshal(window.Devices)
+	if err != nil {
+		return fmt.Errorf("failed to marshal maintenance devices: %w", err)
+	}
+	linksJSON, err := json.Marshal(window.Links)
+	if err != nil {
+		return fmt.Errorf("failed to marshal maintenance links: %w", err)
+	}
+
+	query := `
+		INSERT INTO maintenance_windows (` + maintenanceWindowColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			ticket = EXCLUDED.ticket,
+			description = EXCLUDED.description,
+			starts_at = EXCLUDED.starts_at,
+			ends_at = EXCLUDED.ends_at,
+			devices = EXCLUDED.devices,
+			links = EXCLUDED.links
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		window.ID, window.Ticket, window.Description, window.StartsAt, window.EndsAt,
+		devicesJSON, linksJSON, window.CreatedBy, window.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save maintenance window: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMaintenanceWindow removes a window
+func (r *postgresRepository) DeleteMaintenanceWindow(ctx context.Context, windowID string) error {
+	query := `DELETE FROM maintenance_windows WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, windowID)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", err)
+	}
+
+	return nil
+}
+
+type maintenanceWindowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMaintenanceWindow(row maintenanceWindowScanner) (*topology.MaintenanceWindow, error) {
+	var window topology.MaintenanceWindow
+	var devicesJSON, linksJSON []byte
+
+	err := row.Scan(&window.ID, &window.Ticket, &window.Description, &window.StartsAt, &window.EndsAt,
+		&devicesJSON, &linksJSON, &window.CreatedBy, &window.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+	}
+
+	if err := json.Unmarshal(devicesJSON, &window.Devices); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal maintenance devices: %w", err)
+	}
+	if err := json.Unmarshal(linksJSON, &window.Links); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal maintenance links: %w", err)
+	}
+
+	return &window, nil
+}
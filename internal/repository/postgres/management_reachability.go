@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Management reachability repository methods
+
+// SaveManagementReachability replaces the latest management-plane check of each device
+func (r *postgresRepository) SaveManagementReachability(ctx context.Context, results []topology.ManagementReachability) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 確認中に削除されたデバイスは記録しない。到達できなかった場合は前回到達できた時刻を残す
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO management_reachability (device_id, address, ssh, netconf, error, checked_at, last_reachable_at)
+		SELECT $1::text, $2::text, $3::boolean, $4::boolean, $5::text, $6::timestamptz,
+			CASE WHEN $3::boolean OR $4::boolean THEN $6::timestamptz END
+		WHERE EXISTS (SELECT 1 FROM devices WHERE id = $1::text)
+		ON CONFLICT (device_id) DO UPDATE SET
+			address = EXCLUDED.address,
+			ssh = EXCLUDED.ssh,
+			netconf = EXCLUDED.netconf,
+			error = EXCLUDED.error,
+			checked_at = EXCLUDED.checked_at,
+			last_reachable_at = COALESCE(EXCLUDED.last_reachable_at, management_reachability.last_reachable_at)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, result := range results {
+		if _, err := stmt.ExecContext(ctx, result.DeviceID, result.Address, result.SSH, result.NETCONF, result.Error, result.CheckedAt); err != nil {
+			return fmt.Errorf("failed to save management reachability of %s: %w", result.DeviceID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListManagementReachability retrieves the latest management-plane check of every checked device
+func (r *postgresRepository) ListManagementReachability(ctx context.Context) ([]topology.ManagementReachability, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT device_id, address, ssh, netconf, error, checked_at, last_reachable_at
+		FROM management_reachability
+		ORDER BY device_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list management reachability: %w", err)
+	}
+	defer rows.Close()
+
+	results := []topology.ManagementReachability{}
+	for rows.Next() {
+		var result topology.ManagementReachability
+		if err := rows.Scan(&result.DeviceID, &result.Address, &result.SSH, &result.NETCONF, &result.Error, &result.CheckedAt, &result.LastReachableAt); err != nil {
+			return nil, fmt.Errorf("failed to scan management reachability: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate management reachability: %w", err)
+	}
+
+	return results, nil
+}
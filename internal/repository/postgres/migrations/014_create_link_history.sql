@@ -0,0 +1,52 @@
+-- 014_create_link_history.sql
+-- リンク観測履歴テーブル（月次パーティション）
+-- 古い生データは日次サマリーへ圧縮され、保持期間を過ぎたパーティションは DROP される
+
+CREATE TABLE IF NOT EXISTS link_history (
+    link_id VARCHAR(255) NOT NULL,
+    source_id VARCHAR(255) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    source_port VARCHAR(100),
+    target_port VARCHAR(100),
+    weight DECIMAL(10,2) DEFAULT 1.0,
+    observed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+) PARTITION BY RANGE (observed_at);
+
+CREATE INDEX IF NOT EXISTS idx_link_history_link_observed ON link_history(link_id, observed_at);
+
+-- 日次サマリーテーブル（月次パーティション）
+CREATE TABLE IF NOT EXISTS link_history_daily (
+    link_id VARCHAR(255) NOT NULL,
+    day DATE NOT NULL,
+    source_id VARCHAR(255) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    source_port VARCHAR(100),
+    target_port VARCHAR(100),
+    observations INTEGER NOT NULL DEFAULT 0,
+    first_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    PRIMARY KEY (link_id, day)
+) PARTITION BY RANGE (day);
+
+-- 該当する月次パーティションが無い場合の受け皿
+CREATE TABLE IF NOT EXISTS link_history_default PARTITION OF link_history DEFAULT;
+CREATE TABLE IF NOT EXISTS link_history_daily_default PARTITION OF link_history_daily DEFAULT;
+
+-- 当月と翌月のパーティションを作成（以降はコンパクションジョブが先行作成する）
+DO $$
+DECLARE
+    month_start DATE;
+BEGIN
+    FOR i IN 0..1 LOOP
+        month_start := (date_trunc('month', CURRENT_DATE) + make_interval(months => i))::DATE;
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF link_history FOR VALUES FROM (%L) TO (%L)',
+            'link_history_' || to_char(month_start, 'YYYY_MM'), month_start, (month_start + INTERVAL '1 month')::DATE
+        );
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF link_history_daily FOR VALUES FROM (%L) TO (%L)',
+            'link_history_daily_' || to_char(month_start, 'YYYY_MM'), month_start, (month_start + INTERVAL '1 month')::DATE
+        );
+    END LOOP;
+END $$;
@@ -0,0 +1,21 @@
+-- 015_create_planned_devices.sql
+-- 監視に現れる前に登録される計画デバイス（テンプレート付き）
+
+CREATE TABLE IF NOT EXISTS planned_devices (
+    id VARCHAR(255) PRIMARY KEY,
+    type VARCHAR(100),
+    hardware VARCHAR(255),
+    layer_id INTEGER REFERENCES hierarchy_layers(id),
+    device_type VARCHAR(100),
+    expected_uplinks JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL DEFAULT 'planned',
+    mismatches JSONB NOT NULL DEFAULT '[]',
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    discovered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT valid_planned_status CHECK (status IN ('planned', 'discovered', 'mismatch'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_planned_devices_status ON planned_devices(status);
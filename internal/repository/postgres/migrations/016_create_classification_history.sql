@@ -0,0 +1,20 @@
+-- 016_create_classification_history.sql
+-- デバイス分類の変更履歴（ユーザー上書き・ルール適用）
+
+CREATE TABLE IF NOT EXISTS classification_history (
+    id BIGSERIAL PRIMARY KEY,
+    device_id VARCHAR(255) NOT NULL,
+    previous_layer_id INTEGER,
+    previous_device_type VARCHAR(100),
+    previous_classified_by VARCHAR(255),
+    layer_id INTEGER,
+    device_type VARCHAR(100),
+    classified_by VARCHAR(255),
+    source VARCHAR(20) NOT NULL,
+    reason TEXT,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT valid_change_source CHECK (source IN ('user', 'rule'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_classification_history_device ON classification_history(device_id, changed_at DESC);
@@ -0,0 +1,15 @@
+-- 017_create_display_name_overrides.sql
+-- 可視化で表示する名前の上書き（デバイス単位またはパターン）
+
+CREATE TABLE IF NOT EXISTS display_name_overrides (
+    id VARCHAR(255) PRIMARY KEY,
+    device_id VARCHAR(255) UNIQUE,
+    pattern TEXT,
+    name VARCHAR(255) NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT device_or_pattern CHECK ((device_id IS NULL) <> (pattern IS NULL))
+);
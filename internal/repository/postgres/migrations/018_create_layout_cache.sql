@@ -0,0 +1,25 @@
+-- 018_create_layout_cache.sql
+-- 可視化ビューのリクエスト頻度と事前計算済みレイアウト
+
+CREATE TABLE IF NOT EXISTS view_requests (
+    root_device VARCHAR(255) NOT NULL,
+    depth INTEGER NOT NULL,
+    options TEXT NOT NULL,
+    request_count BIGINT NOT NULL DEFAULT 0,
+    last_requested TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    PRIMARY KEY (root_device, depth, options)
+);
+
+CREATE INDEX IF NOT EXISTS idx_view_requests_last_requested ON view_requests(last_requested);
+
+CREATE TABLE IF NOT EXISTS layout_cache (
+    root_device VARCHAR(255) NOT NULL,
+    depth INTEGER NOT NULL,
+    options TEXT NOT NULL,
+    node_set_hash VARCHAR(64) NOT NULL,
+    layout JSONB NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    PRIMARY KEY (root_device, depth, options)
+);
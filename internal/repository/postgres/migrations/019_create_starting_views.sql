@@ -0,0 +1,12 @@
+-- 019_create_starting_views.sql
+-- ロール・チームごとのログイン直後に表示するトポロジー
+
+CREATE TABLE IF NOT EXISTS starting_views (
+    role VARCHAR(255) PRIMARY KEY,
+    root_device VARCHAR(255) NOT NULL,
+    depth INTEGER NOT NULL DEFAULT 3,
+    grouping JSONB,
+    description TEXT NOT NULL DEFAULT '',
+    updated_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
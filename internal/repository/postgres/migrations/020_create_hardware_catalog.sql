@@ -0,0 +1,13 @@
+-- 020_create_hardware_catalog.sql
+-- 層ごとの承認済みハードウェアモデル（コンプライアンスチェック用）
+
+CREATE TABLE IF NOT EXISTS hardware_catalog (
+    id VARCHAR(255) PRIMARY KEY,
+    layer_id INTEGER NOT NULL REFERENCES hierarchy_layers(id) ON DELETE CASCADE,
+    model VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (layer_id, model)
+);
@@ -0,0 +1,13 @@
+-- 021_create_fabrics.sql
+-- ファブリック（"prod-fabric-a" などの名前付きデバイス集合）の定義
+
+CREATE TABLE IF NOT EXISTS fabrics (
+    name VARCHAR(255) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    logic_operator VARCHAR(10) NOT NULL DEFAULT 'AND',
+    conditions JSONB NOT NULL DEFAULT '[]',
+    priority INTEGER NOT NULL DEFAULT 0,
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
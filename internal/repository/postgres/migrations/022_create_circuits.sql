@@ -0,0 +1,16 @@
+-- 022_create_circuits.sql
+-- ケーブル・回線IDの管理（DCファシリティチームとの物理配線作業の調整用）
+
+CREATE TABLE IF NOT EXISTS circuits (
+    id VARCHAR(255) PRIMARY KEY,
+    provider VARCHAR(255) NOT NULL DEFAULT '',
+    a_device VARCHAR(255) NOT NULL,
+    a_port VARCHAR(255) NOT NULL DEFAULT '',
+    z_device VARCHAR(255) NOT NULL,
+    z_port VARCHAR(255) NOT NULL DEFAULT '',
+    link_id VARCHAR(255), -- リンクは再収集で作り直されるため外部キーにしない
+    description TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_circuits_link_id ON circuits(link_id);
@@ -0,0 +1,6 @@
+-- 023_drop_device_link_updated_at_triggers.sql
+-- devices / links の upsert は書き込み側の updated_at で last-write-wins を判定するため、
+-- updated_at を更新時刻で上書きするトリガーを削除する（並行する seed と同期の競合解決用）
+
+DROP TRIGGER IF EXISTS update_devices_updated_at ON devices;
+DROP TRIGGER IF EXISTS update_links_updated_at ON links;
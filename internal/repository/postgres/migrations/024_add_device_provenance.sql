@@ -0,0 +1,7 @@
+-- 024_add_device_provenance.sql
+-- デバイスを最初に登録した経路（prometheus, lldp-placeholder, import, manual, netbox）。
+-- upsert では空の場合のみ設定し、以降の更新では変更しない
+
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS provenance VARCHAR(32) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_devices_provenance ON devices(provenance);
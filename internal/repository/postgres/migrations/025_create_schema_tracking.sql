@@ -0,0 +1,19 @@
+-- 025_create_schema_tracking.sql
+-- migrate:phase expand
+-- オンラインマイグレーション（expand/contract）用の管理テーブル。
+-- schema_backfills: expand 後にワーカーが少しずつ進めるバックフィルの進捗
+-- schema_clients: 稼働中の API / ワーカーが同梱しているスキーマバージョン（contract の前提確認用）
+
+CREATE TABLE IF NOT EXISTS schema_backfills (
+    name VARCHAR(255) PRIMARY KEY,
+    rows_processed BIGINT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS schema_clients (
+    instance_id VARCHAR(255) PRIMARY KEY,
+    role VARCHAR(32) NOT NULL,
+    schema_version VARCHAR(255) NOT NULL,
+    last_seen TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,13 @@
+-- 026_create_icon_mappings.sql
+-- migrate:phase expand
+-- デバイス種別・ベンダーごとのアイコン（フロントエンド間で表示を揃えるためのマニフェスト）
+
+CREATE TABLE IF NOT EXISTS icon_mappings (
+    id VARCHAR(255) PRIMARY KEY,
+    device_type VARCHAR(255) NOT NULL DEFAULT '',
+    vendor VARCHAR(255) NOT NULL DEFAULT '',
+    icon VARCHAR(255) NOT NULL,
+    svg TEXT NOT NULL DEFAULT '',
+    updated_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
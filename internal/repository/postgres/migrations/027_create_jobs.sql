@@ -0,0 +1,25 @@
+-- 027_create_jobs.sql
+-- migrate:phase expand
+-- 長時間かかる処理のジョブキュー（プロセスの再起動をまたいで実行し、複数インスタンスで分担する）
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id VARCHAR(64) PRIMARY KEY,
+    kind VARCHAR(255) NOT NULL,
+    payload JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 3,
+    result JSONB,
+    error TEXT NOT NULL DEFAULT '',
+    locked_by VARCHAR(255) NOT NULL DEFAULT '',
+    visible_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+-- 取得待ちのジョブ（実行待ち・リース切れ）を見える順に探す
+CREATE INDEX IF NOT EXISTS idx_jobs_visible ON jobs(visible_at) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_jobs_created_at ON jobs(created_at);
@@ -0,0 +1,22 @@
+-- 028_add_device_workflow_state.sql
+-- migrate:phase expand
+-- デバイスの構築ワークフロー状態（discovered → onboarding → active → quarantined → decommissioned）と遷移履歴。
+-- 同期の upsert では変更せず、ワークフローAPIからのみ遷移する
+
+-- 既存のデバイスは稼働中とみなし、以降に見つかったデバイスは discovered から始める
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS workflow_state VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE devices ALTER COLUMN workflow_state SET DEFAULT 'discovered';
+
+-- devices(workflow_state) のインデックスは書き込みを止めないよう 039 で CONCURRENTLY に作成する
+
+CREATE TABLE IF NOT EXISTS device_workflow_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    device_id VARCHAR(255) NOT NULL,
+    from_state VARCHAR(20) NOT NULL,
+    to_state VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    changed_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_workflow_transitions_device ON device_workflow_transitions(device_id, changed_at DESC);
@@ -0,0 +1,13 @@
+-- 029_create_hardware_lifecycle.sql
+-- migrate:phase expand
+-- ハードウェアモデルの販売終了（EoS）・サポート終了（EoL）日。デバイスの hardware とあいまい一致で照合する
+
+CREATE TABLE IF NOT EXISTS hardware_lifecycle (
+    id VARCHAR(255) PRIMARY KEY,
+    model VARCHAR(255) NOT NULL UNIQUE,
+    end_of_sale DATE,
+    end_of_life DATE,
+    description TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
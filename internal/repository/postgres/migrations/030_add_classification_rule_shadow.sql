@@ -0,0 +1,17 @@
+-- 030_add_classification_rule_shadow.sql
+-- migrate:phase expand
+-- シャドウルール: 評価はするがデバイスは変更せず、適用されるはずだった分類を shadow_classifications に記録する。
+-- デバイスごとに最新の評価結果のみ保持する
+
+ALTER TABLE classification_rules ADD COLUMN IF NOT EXISTS shadow BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS shadow_classifications (
+    device_id VARCHAR(255) PRIMARY KEY,
+    rule_id VARCHAR(255) NOT NULL,
+    rule_name VARCHAR(255) NOT NULL,
+    layer INTEGER NOT NULL,
+    device_type VARCHAR(255) NOT NULL DEFAULT '',
+    evaluated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_shadow_classifications_rule_id ON shadow_classifications(rule_id);
@@ -0,0 +1,19 @@
+-- 031_create_port_reservations.sql
+-- migrate:phase expand
+-- 将来の配線のためのポート予約（変更チケットと有効期限付き）。
+-- 予約ポートで予定と異なる隣接機器が検出されると競合として報告する
+
+CREATE TABLE IF NOT EXISTS port_reservations (
+    id VARCHAR(255) PRIMARY KEY,
+    device_id VARCHAR(255) NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+    port VARCHAR(255) NOT NULL,
+    peer_device VARCHAR(255) NOT NULL DEFAULT '',
+    peer_port VARCHAR(255) NOT NULL DEFAULT '',
+    ticket VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    reserved_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (device_id, port)
+);
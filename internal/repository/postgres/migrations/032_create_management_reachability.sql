@@ -0,0 +1,14 @@
+-- 032_create_management_reachability.sql
+-- migrate:phase expand
+-- 管理プレーン（SSH/NETCONF）への到達性の最新の確認結果。
+-- 監視（last_seen）とは別に記録し、監視できているが管理できない機器を報告する
+
+CREATE TABLE IF NOT EXISTS management_reachability (
+    device_id VARCHAR(255) PRIMARY KEY REFERENCES devices(id) ON DELETE CASCADE,
+    address VARCHAR(255) NOT NULL,
+    ssh BOOLEAN NOT NULL DEFAULT FALSE,
+    netconf BOOLEAN NOT NULL DEFAULT FALSE,
+    error TEXT NOT NULL DEFAULT '',
+    checked_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_reachable_at TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,34 @@
+-- 033_notify_topology_changes.sql
+-- migrate:phase expand
+-- devices / links の変更を NOTIFY で通知する。
+-- 別インスタンスや手動SQLによる変更も、APIプロセスが再同期なしで受け取れる
+
+CREATE OR REPLACE FUNCTION notify_topology_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    changed_id TEXT;
+BEGIN
+    -- 同期のたびに更新される last_seen / updated_at だけの変更は通知しない
+    IF TG_OP = 'UPDATE' AND
+       (to_jsonb(NEW) - 'last_seen' - 'updated_at') = (to_jsonb(OLD) - 'last_seen' - 'updated_at') THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        changed_id := OLD.id;
+    ELSE
+        changed_id := NEW.id;
+    END IF;
+    -- 同じトランザクション内の同一ペイロードはPostgreSQLが1件にまとめる
+    PERFORM pg_notify('topology_changes', json_build_object('table', TG_TABLE_NAME, 'op', TG_OP, 'id', changed_id)::text);
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS notify_devices_change ON devices;
+CREATE TRIGGER notify_devices_change AFTER INSERT OR UPDATE OR DELETE ON devices
+    FOR EACH ROW EXECUTE FUNCTION notify_topology_change();
+
+DROP TRIGGER IF EXISTS notify_links_change ON links;
+CREATE TRIGGER notify_links_change AFTER INSERT OR UPDATE OR DELETE ON links
+    FOR EACH ROW EXECUTE FUNCTION notify_topology_change();
@@ -0,0 +1,20 @@
+-- 034_create_maintenance_windows.sql
+-- migrate:phase expand
+-- 予定された保守作業（期間中に停止するデバイスとリンク）。
+-- 新しい作業の影響を評価するとき、期間が重なる作業も停止しているものとして扱う
+
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id VARCHAR(255) PRIMARY KEY,
+    ticket VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    devices JSONB NOT NULL DEFAULT '[]', -- リンクやデバイスは再収集で作り直されるため外部キーにしない
+    links JSONB NOT NULL DEFAULT '[]',
+    created_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_period ON maintenance_windows (starts_at, ends_at);
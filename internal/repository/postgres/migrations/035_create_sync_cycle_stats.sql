@@ -0,0 +1,20 @@
+-- 035_create_sync_cycle_stats.sql
+-- migrate:phase expand
+-- 同期サイクルごとの変化量（前回サイクルとの差分）の履歴。
+-- エクスポーターの異常やネットワークイベントによる急増を時系列で確認する
+
+CREATE TABLE IF NOT EXISTS sync_cycle_stats (
+    id BIGSERIAL PRIMARY KEY,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    devices INTEGER NOT NULL DEFAULT 0,
+    links INTEGER NOT NULL DEFAULT 0,
+    devices_appeared INTEGER NOT NULL DEFAULT 0,
+    devices_disappeared INTEGER NOT NULL DEFAULT 0,
+    links_added INTEGER NOT NULL DEFAULT 0,
+    links_removed INTEGER NOT NULL DEFAULT 0,
+    errors INTEGER NOT NULL DEFAULT 0,
+    baseline BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_cycle_stats_finished_at ON sync_cycle_stats (finished_at);
@@ -0,0 +1,13 @@
+-- 036_create_identity_decisions.sql
+-- migrate:phase expand
+-- 複数のソースが別IDで記述する機器の同一性に関する手動の判断。
+-- key は対象デバイスIDを整列して連結したもの。canonical_id が空の場合は別の機器と判断した
+
+CREATE TABLE IF NOT EXISTS identity_decisions (
+    key TEXT PRIMARY KEY,
+    devices JSONB NOT NULL DEFAULT '[]', -- デバイスは再収集で作り直されるため外部キーにしない
+    canonical_id VARCHAR(255) NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    decided_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    decided_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,15 @@
+-- 037_create_style_rules.sql
+-- migrate:phase expand
+-- 条件に一致するノード・エッジの表示スタイルを上書きするルール
+
+CREATE TABLE IF NOT EXISTS style_rules (
+    id VARCHAR(255) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    target VARCHAR(16) NOT NULL, -- node / edge
+    logic_operator VARCHAR(8) NOT NULL DEFAULT 'AND',
+    conditions JSONB NOT NULL DEFAULT '[]',
+    priority INTEGER NOT NULL DEFAULT 0,
+    style JSONB NOT NULL DEFAULT '{}', -- target に応じた node_style / edge_style
+    updated_by VARCHAR(255) NOT NULL DEFAULT 'system',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
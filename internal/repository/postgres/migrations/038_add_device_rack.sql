@@ -0,0 +1,13 @@
+-- 038_add_device_rack.sql
+-- migrate:phase expand
+-- デバイスの設置ラックとラックユニット位置（ラック図の表示用）。
+-- ラックを持たない upsert（同期）では変更せず、ラック配置のAPI・CSV取り込みで設定する
+
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS rack VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS rack_position INTEGER;
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS rack_units INTEGER NOT NULL DEFAULT 0;
+
+-- 既存行の検査で devices をロックし続けないよう NOT VALID で追加し、041 で検証する
+ALTER TABLE devices ADD CONSTRAINT devices_rack_position_check CHECK (rack_position IS NULL OR rack_position >= 1) NOT VALID;
+
+-- devices(rack) のインデックスは 040 で CONCURRENTLY に作成する
@@ -0,0 +1,7 @@
+-- 039_index_device_workflow_state.sql
+-- migrate:phase expand
+-- migrate:no-transaction
+-- ワークフロー状態での絞り込み用インデックス。CONCURRENTLY で作成し、大きな devices テーブルへの
+-- 同期の書き込みを止めない（028 を適用済みのデータベースでは既に存在するため何もしない）
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_devices_workflow_state ON devices(workflow_state);
@@ -0,0 +1,7 @@
+-- 040_index_device_rack.sql
+-- migrate:phase expand
+-- migrate:no-transaction
+-- ラック図用の部分インデックス。CONCURRENTLY で作成し、同期の書き込みを止めない
+-- （038 を適用済みのデータベースでは既に存在するため何もしない）
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_devices_rack ON devices(rack) WHERE rack <> '';
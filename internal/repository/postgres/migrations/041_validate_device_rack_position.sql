@@ -0,0 +1,7 @@
+-- 041_validate_device_rack_position.sql
+-- migrate:phase expand
+-- 038 で NOT VALID として追加したラック位置の制約を検証する。VALIDATE CONSTRAINT は
+-- SHARE UPDATE EXCLUSIVE ロックしか取らないため、検査中も読み書きを止めない
+-- （038 を適用済みのデータベースでは検証済みの制約なので何もしない）
+
+ALTER TABLE devices VALIDATE CONSTRAINT devices_rack_position_check;
@@ -0,0 +1,13 @@
+-- 042_create_device_deletions.sql
+-- migrate:phase expand
+-- API で削除したデバイス。近隣が LLDP で報告し続けるリンクから同期がプレースホルダーとして
+-- 再作成しないよう、猶予期間のあいだ記録し、その間に除外したリンクを残す
+
+CREATE TABLE IF NOT EXISTS device_deletions (
+    device_id VARCHAR(255) PRIMARY KEY, -- 削除済みのため外部キーにしない
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    orphaned_links JSONB NOT NULL DEFAULT '[]',
+    last_reported_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_deletions_deleted_at ON device_deletions (deleted_at);
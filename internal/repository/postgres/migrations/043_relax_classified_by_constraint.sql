@@ -0,0 +1,14 @@
+-- 043_relax_classified_by_constraint.sql
+-- migrate:phase expand
+-- 013 の制約は接頭辞の後に英数字とハイフンしか許さず、LDAP/OIDC のユーザー名
+-- （taro.yamada、taro@example.com、taro_yamada）や空白を含むルール名で分類すると
+-- 更新が失敗していた。接頭辞の後は空でない任意の文字列を許可する。
+-- 既存の値はすべて新しい制約を満たすが、devices をロックし続けないよう NOT VALID で追加し、044 で検証する
+
+ALTER TABLE devices DROP CONSTRAINT IF EXISTS valid_classified_by;
+
+ALTER TABLE devices ADD CONSTRAINT valid_classified_by CHECK (
+    classified_by = '' OR
+    classified_by IS NULL OR
+    classified_by ~ '^(rule|user|system):.+$'
+) NOT VALID;
@@ -0,0 +1,6 @@
+-- 044_validate_classified_by_constraint.sql
+-- migrate:phase expand
+-- 043 で NOT VALID として追加した classified_by の制約を検証する。VALIDATE CONSTRAINT は
+-- SHARE UPDATE EXCLUSIVE ロックしか取らないため、検査中も読み書きを止めない
+
+ALTER TABLE devices VALIDATE CONSTRAINT valid_classified_by;
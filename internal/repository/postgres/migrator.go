@@ -0,0 +1,507 @@
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MigrationPhase is the step of an online schema change a migration belongs to
+type MigrationPhase string
+
+const (
+	// PhaseExpand migrations only add schema (columns, tables, indexes) that old code ignores,
+	// so they can be applied while every instance keeps running
+	PhaseExpand MigrationPhase = "expand"
+	// PhaseContract migrations remove or tighten schema the previous release still used.
+	// They run only once backfills are done and no older instance is running.
+	PhaseContract MigrationPhase = "contract"
+)
+
+// Migration is a bundled migration file with the directives read from its header comments:
+//
+//	-- migrate:phase contract            (default expand)
+//	-- migrate:no-transaction            (e.g. CREATE INDEX CONCURRENTLY)
+//	-- migrate:requires-backfill <name>  (contract only after the backfill completed)
+type Migration struct {
+	Name             string         `json:"name"`
+	Phase            MigrationPhase `json:"phase"`
+	NoTransaction    bool           `json:"no_transaction,omitempty"`
+	RequiresBackfill []string       `json:"requires_backfill,omitempty"`
+	Script           string         `json:"-"`
+}
+
+// backfill updates at most limit rows of a pending data migration and returns how many it
+// changed; a backfill is complete once a batch changes nothing
+type backfill func(ctx context.Context, tx *sql.Tx, limit int) (int, error)
+
+// backfills are the data migrations run by the worker after an expand migration added the
+// columns they fill, keyed by the name used in requires-backfill. No bundled contract
+// migration needs one yet; the integration tests register their own.
+var backfills = map[string]backfill{}
+
+// parseMigration reads the directives from the leading comment lines of a migration
+func parseMigration(name, script string) (Migration, error) {
+	migration := Migration{Name: name, Phase: PhaseExpand, Script: script}
+
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			// ディレクティブは先頭のコメントにのみ書ける
+			break
+		}
+		comment := strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		if !strings.HasPrefix(comment, "migrate:") {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(comment, "migrate:"))
+		if len(fields) == 0 {
+			return Migration{}, fmt.Errorf("%s: empty migrate directive", name)
+		}
+		switch fields[0] {
+		case "phase":
+			if len(fields) != 2 || (fields[1] != string(PhaseExpand) && fields[1] != string(PhaseContract)) {
+				return Migration{}, fmt.Errorf("%s: phase must be 'expand' or 'contract'", name)
+			}
+			migration.Phase = MigrationPhase(fields[1])
+		case "no-transaction":
+			migration.NoTransaction = true
+		case "requires-backfill":
+			if len(fields) != 2 {
+				return Migration{}, fmt.Errorf("%s: requires-backfill takes one backfill name", name)
+			}
+			migration.RequiresBackfill = append(migration.RequiresBackfill, fields[1])
+		default:
+			return Migration{}, fmt.Errorf("%s: unknown migrate directive '%s'", name, fields[0])
+		}
+	}
+
+	if len(migration.RequiresBackfill) > 0 && migration.Phase != PhaseContract {
+		return Migration{}, fmt.Errorf("%s: only contract migrations can require a backfill", name)
+	}
+	return migration, nil
+}
+
+// LoadMigrations returns the bundled migrations in the order they apply
+func LoadMigrations() ([]Migration, error) {
+	names, err := MigrationNames()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		script, err := fs.ReadFile(migrationSource, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		migration, err := parseMigration(path.Base(name), string(script))
+		if err != nil {
+			return nil, err
+		}
+		for _, required := range migration.RequiresBackfill {
+			if _, ok := backfills[required]; !ok {
+				return nil, fmt.Errorf("%s: unknown backfill '%s'", migration.Name, required)
+			}
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// SchemaVersion is the name of the newest bundled migration. Instances report it so a
+// contract migration waits until every running instance bundles it.
+func SchemaVersion() string {
+	names, err := MigrationNames()
+	if err != nil || len(names) == 0 {
+		return ""
+	}
+	return path.Base(names[len(names)-1])
+}
+
+// splitStatements splits a no-transaction migration at semicolons that end a line, since
+// statements like CREATE INDEX CONCURRENTLY must not share a query with others
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if current.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// SchemaClient is an API or worker instance that recently reported its schema version
+type SchemaClient struct {
+	InstanceID    string    `json:"instance_id"`
+	Role          string    `json:"role"`
+	SchemaVersion string    `json:"schema_version"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// BackfillState is the progress of a registered backfill
+type BackfillState struct {
+	Name          string `json:"name"`
+	RowsProcessed int64  `json:"rows_processed"`
+	Completed     bool   `json:"completed"`
+}
+
+// MigrationState is a bundled migration and whether it has been applied
+type MigrationState struct {
+	Migration
+	Applied bool `json:"applied"`
+}
+
+// MigrationStatus is the state of the online migration of a database
+type MigrationStatus struct {
+	Migrations []MigrationState `json:"migrations"`
+	Backfills  []BackfillState  `json:"backfills"`
+	Clients    []SchemaClient   `json:"clients"`
+}
+
+// ContractBlockers lists why the contract migration cannot be applied yet; it is safe to
+// apply when the list is empty
+func (s *MigrationStatus) ContractBlockers(migration Migration) []string {
+	var blockers []string
+
+	for _, state := range s.Migrations {
+		if state.Name >= migration.Name {
+			break
+		}
+		if !state.Applied && state.Phase == PhaseExpand {
+			blockers = append(blockers, fmt.Sprintf("expand migration %s is not applied", state.Name))
+		}
+	}
+
+	completed := make(map[string]bool, len(s.Backfills))
+	for _, state := range s.Backfills {
+		completed[state.Name] = state.Completed
+	}
+	for _, name := range migration.RequiresBackfill {
+		if !completed[name] {
+			blockers = append(blockers, fmt.Sprintf("backfill %s has not completed", name))
+		}
+	}
+
+	// 名前は連番付きなので文字列比較で新旧を判定できる
+	for _, client := range s.Clients {
+		if client.SchemaVersion < migration.Name {
+			blockers = append(blockers, fmt.Sprintf("%s %s is running schema %s", client.Role, client.InstanceID, client.SchemaVersion))
+		}
+	}
+
+	return blockers
+}
+
+// MigratorOptions tunes how migrations are applied to a live database
+type MigratorOptions struct {
+	// LockTimeout bounds how long a migration waits for a table lock so it never queues
+	// up the API and worker queries behind it (0 = wait indefinitely)
+	LockTimeout time.Duration
+	// LockRetries is how many times a migration that timed out on a lock is retried
+	LockRetries int
+	// ClientWindow is how recently an instance must have reported to block a contract
+	ClientWindow time.Duration
+	// Logf receives progress messages (nil = silent)
+	Logf func(format string, args ...interface{})
+}
+
+// DefaultMigratorOptions returns options suitable for a production database
+func DefaultMigratorOptions() MigratorOptions {
+	return MigratorOptions{
+		LockTimeout:  5 * time.Second,
+		LockRetries:  5,
+		ClientWindow: 10 * time.Minute,
+	}
+}
+
+// Migrator applies the bundled migrations in expand/contract phases
+type Migrator struct {
+	db      *sql.DB
+	options MigratorOptions
+}
+
+// NewMigrator creates a migrator for db
+func NewMigrator(db *sql.DB, options MigratorOptions) *Migrator {
+	return &Migrator{db: db, options: options}
+}
+
+func (m *Migrator) logf(format string, args ...interface{}) {
+	if m.options.Logf != nil {
+		m.options.Logf(format, args...)
+	}
+}
+
+func (m *Migrator) ensureMigrationTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS migrations (
+			id SERIAL PRIMARY KEY,
+			filename VARCHAR(255) NOT NULL UNIQUE,
+			applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create migration table: %w", err)
+	}
+	return nil
+}
+
+// Status reports the applied migrations, backfill progress and running instances
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	if err := m.ensureMigrationTable(ctx); err != nil {
+		return nil, err
+	}
+	return m.status(ctx)
+}
+
+func (m *Migrator) status(ctx context.Context) (*MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string]bool)
+	rows, err := m.db.QueryContext(ctx, "SELECT filename FROM migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		applied[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{}
+	for _, migration := range migrations {
+		status.Migrations = append(status.Migrations, MigrationState{Migration: migration, Applied: applied[migration.Name]})
+	}
+
+	// 管理テーブルは 025 で作成されるため、それ以前のデータベースでは空とみなす
+	var tracked bool
+	if err := m.db.QueryRowContext(ctx, "SELECT to_regclass('schema_clients') IS NOT NULL").Scan(&tracked); err != nil {
+		return nil, fmt.Errorf("failed to check schema tracking tables: %w", err)
+	}
+	if !tracked {
+		return status, nil
+	}
+
+	status.Backfills, err = listBackfillStates(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	clientRows, err := m.db.QueryContext(ctx, `
+		SELECT instance_id, role, schema_version, last_seen
+		FROM schema_clients
+		WHERE last_seen >= $1
+		ORDER BY instance_id
+	`, time.Now().Add(-m.options.ClientWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schema clients: %w", err)
+	}
+	defer clientRows.Close()
+	for clientRows.Next() {
+		var client SchemaClient
+		if err := clientRows.Scan(&client.InstanceID, &client.Role, &client.SchemaVersion, &client.LastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan schema client: %w", err)
+		}
+		status.Clients = append(status.Clients, client)
+	}
+	return status, clientRows.Err()
+}
+
+// Up applies the pending expand migrations in order and returns their names. Contract
+// migrations are skipped; apply them with Contract once the rollout has finished.
+func (m *Migrator) Up(ctx context.Context) ([]string, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	for _, state := range status.Migrations {
+		if state.Applied {
+			continue
+		}
+		if state.Phase == PhaseContract {
+			m.logf("Skipping contract migration %s (run 'migrate contract' after the rollout)", state.Name)
+			continue
+		}
+		if err := m.apply(ctx, state.Migration); err != nil {
+			return applied, err
+		}
+		applied = append(applied, state.Name)
+	}
+	return applied, nil
+}
+
+// Contract applies the pending contract migrations whose blockers are cleared and returns
+// their names. It stops at the first blocked migration unless force is set.
+func (m *Migrator) Contract(ctx context.Context, force bool) ([]string, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	for _, state := range status.Migrations {
+		if state.Applied || state.Phase != PhaseContract {
+			continue
+		}
+		if blockers := status.ContractBlockers(state.Migration); len(blockers) > 0 {
+			if !force {
+				return applied, fmt.Errorf("contract migration %s is blocked: %s", state.Name, strings.Join(blockers, "; "))
+			}
+			m.logf("Forcing contract migration %s despite: %s", state.Name, strings.Join(blockers, "; "))
+		}
+		if err := m.apply(ctx, state.Migration); err != nil {
+			return applied, err
+		}
+		applied = append(applied, state.Name)
+	}
+	return applied, nil
+}
+
+// apply runs a migration, retrying when it gave up waiting for a lock
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	for attempt := 0; ; attempt++ {
+		m.logf("Applying %s migration %s...", migration.Phase, migration.Name)
+
+		var err error
+		if migration.NoTransaction {
+			err = m.applyWithoutTransaction(ctx, migration)
+		} else {
+			err = m.applyInTransaction(ctx, migration)
+		}
+		if err == nil {
+			m.logf("Migration %s applied successfully", migration.Name)
+			return nil
+		}
+		if !isLockTimeout(err) || attempt >= m.options.LockRetries {
+			return fmt.Errorf("failed to apply migration %s: %w", migration.Name, err)
+		}
+
+		// ロック待ちで諦めた場合は、他のクエリを先に通してから再試行する
+		wait := time.Duration(attempt+1) * time.Second
+		m.logf("Migration %s timed out waiting for a lock; retrying in %s (%d/%d)", migration.Name, wait, attempt+1, m.options.LockRetries)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (m *Migrator) applyInTransaction(ctx context.Context, migration Migration) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL lock_timeout = %d", m.options.LockTimeout.Milliseconds())); err != nil {
+		return fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+	// 引数なしのExecは単純クエリプロトコルで複数ステートメントを実行できる
+	if _, err := tx.ExecContext(ctx, migration.Script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO migrations (filename) VALUES ($1)", migration.Name); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+	return tx.Commit()
+}
+
+// applyWithoutTransaction runs each statement on its own. Statements must be idempotent
+// (IF NOT EXISTS) because a retry starts again from the first one.
+func (m *Migrator) applyWithoutTransaction(ctx context.Context, migration Migration) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET lock_timeout = %d", m.options.LockTimeout.Milliseconds())); err != nil {
+		return fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+	// プールに戻す前に既定値へ戻す
+	defer conn.ExecContext(context.Background(), "RESET lock_timeout")
+
+	for _, statement := range splitStatements(migration.Script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if _, err := conn.ExecContext(ctx, "INSERT INTO migrations (filename) VALUES ($1)", migration.Name); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+	return nil
+}
+
+// isLockTimeout reports whether err is PostgreSQL's lock_not_available (lock_timeout expired)
+func isLockTimeout(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "55P03"
+}
+
+// listBackfillStates returns the progress of every registered backfill ordered by name
+func listBackfillStates(ctx context.Context, db *sql.DB) ([]BackfillState, error) {
+	progress := make(map[string]BackfillState)
+	rows, err := db.QueryContext(ctx, "SELECT name, rows_processed, completed_at IS NOT NULL FROM schema_backfills")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backfills: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var state BackfillState
+		if err := rows.Scan(&state.Name, &state.RowsProcessed, &state.Completed); err != nil {
+			return nil, fmt.Errorf("failed to scan backfill: %w", err)
+		}
+		progress[state.Name] = state
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	states := make([]BackfillState, 0, len(backfills))
+	for name := range backfills {
+		state, ok := progress[name]
+		if !ok {
+			state = BackfillState{Name: name}
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states, nil
+}
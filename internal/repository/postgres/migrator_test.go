@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMigration(t *testing.T) {
+	migration, err := parseMigration("030_drop_devices_legacy.sql", `-- 030_drop_devices_legacy.sql
+-- migrate:phase contract
+-- migrate:requires-backfill devices_hardware
+-- 旧カラムを削除
+
+ALTER TABLE devices DROP COLUMN legacy;
+-- migrate:no-transaction
+`)
+	if err != nil {
+		t.Fatalf("Expected migration to parse, got %v", err)
+	}
+	if migration.Phase != PhaseContract {
+		t.Errorf("Expected contract phase, got %s", migration.Phase)
+	}
+	if len(migration.RequiresBackfill) != 1 || migration.RequiresBackfill[0] != "devices_hardware" {
+		t.Errorf("Expected backfill devices_hardware, got %v", migration.RequiresBackfill)
+	}
+	// SQL の後のコメントはディレクティブとして扱わない
+	if migration.NoTransaction {
+		t.Error("Expected directives after the first statement to be ignored")
+	}
+
+	migration, err = parseMigration("024_add.sql", "-- 024_add.sql\nALTER TABLE devices ADD COLUMN x TEXT;\n")
+	if err != nil || migration.Phase != PhaseExpand {
+		t.Errorf("Expected migration without directives to be expand, got %s (%v)", migration.Phase, err)
+	}
+
+	invalid := []string{
+		"-- migrate:phase shrink\n",
+		"-- migrate:requires-backfill x\n",
+		"-- migrate:lock-free\n",
+	}
+	for _, script := range invalid {
+		if _, err := parseMigration("bad.sql", script); err == nil {
+			t.Errorf("Expected %q to be rejected", script)
+		}
+	}
+}
+
+func TestMigrationStatus_ContractBlockers(t *testing.T) {
+	contract := Migration{Name: "031_drop_legacy.sql", Phase: PhaseContract, RequiresBackfill: []string{"fill"}}
+	status := &MigrationStatus{
+		Migrations: []MigrationState{
+			{Migration: Migration{Name: "030_add.sql", Phase: PhaseExpand}},
+			{Migration: contract},
+			{Migration: Migration{Name: "032_add.sql", Phase: PhaseExpand}},
+		},
+		Backfills: []BackfillState{{Name: "fill"}},
+		Clients: []SchemaClient{
+			{InstanceID: "a", Role: "api", SchemaVersion: "031_drop_legacy.sql", LastSeen: time.Now()},
+			{InstanceID: "b", Role: "worker", SchemaVersion: "030_add.sql", LastSeen: time.Now()},
+		},
+	}
+
+	if blockers := status.ContractBlockers(contract); len(blockers) != 3 {
+		t.Errorf("Expected 3 blockers (expand, backfill, old worker), got %v", blockers)
+	}
+
+	// 後続の expand マイグレーションや新しいインスタンスは妨げにならない
+	status.Migrations[0].Applied = true
+	status.Backfills[0].Completed = true
+	status.Clients[1].SchemaVersion = "032_add.sql"
+	if blockers := status.ContractBlockers(contract); len(blockers) != 0 {
+		t.Errorf("Expected no blockers, got %v", blockers)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := splitStatements(`-- migrate:no-transaction
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_a
+    ON devices(a);
+
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_b ON devices(b);
+`)
+	if len(statements) != 2 {
+		t.Fatalf("Expected 2 statements, got %d: %q", len(statements), statements)
+	}
+	if statements[0] != "CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_a\n    ON devices(a);" {
+		t.Errorf("Unexpected first statement %q", statements[0])
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatalf("Expected bundled migrations to load, got %v", err)
+	}
+	if len(migrations) == 0 || SchemaVersion() != migrations[len(migrations)-1].Name {
+		t.Errorf("Expected schema version to be the newest migration, got %s", SchemaVersion())
+	}
+}
+
+var (
+	createTablePattern = regexp.MustCompile(`(?i)CREATE TABLE (?:IF NOT EXISTS )?(\w+)`)
+	createIndexPattern = regexp.MustCompile(`(?i)CREATE (?:UNIQUE )?INDEX (CONCURRENTLY )?(?:IF NOT EXISTS )?\w+\s+ON (\w+)`)
+	alterTablePattern  = regexp.MustCompile(`(?i)^ALTER TABLE (\w+)`)
+	checkPattern       = regexp.MustCompile(`(?i)\bCHECK\s*\(`)
+)
+
+// TestLoadMigrations_OnlineSafe checks the migrations since the expand/contract tooling (025) do not
+// block writes to existing tables: their indexes are built concurrently outside a transaction and
+// their CHECK constraints are added NOT VALID and validated separately.
+func TestLoadMigrations_OnlineSafe(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatalf("Expected bundled migrations to load, got %v", err)
+	}
+
+	for _, migration := range migrations {
+		if migration.Name < "025" {
+			continue
+		}
+		created := make(map[string]bool)
+		for _, match := range createTablePattern.FindAllStringSubmatch(migration.Script, -1) {
+			created[strings.ToLower(match[1])] = true
+		}
+
+		for _, statement := range splitStatements(migration.Script) {
+			if match := createIndexPattern.FindStringSubmatch(statement); match != nil {
+				concurrent, table := match[1] != "", strings.ToLower(match[2])
+				if concurrent && !migration.NoTransaction {
+					t.Errorf("%s: CREATE INDEX CONCURRENTLY requires -- migrate:no-transaction", migration.Name)
+				}
+				if !concurrent && !created[table] {
+					t.Errorf("%s: index on existing table %s must be built CONCURRENTLY", migration.Name, table)
+				}
+			}
+			if match := alterTablePattern.FindStringSubmatch(statement); match != nil && !created[strings.ToLower(match[1])] {
+				upper := strings.ToUpper(statement)
+				if checkPattern.MatchString(statement) && (strings.Contains(upper, "ADD COLUMN") || !strings.Contains(upper, "NOT VALID")) {
+					t.Errorf("%s: CHECK constraints on existing table %s must be added NOT VALID", migration.Name, match[1])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ListDeletedDevices returns the devices deleted at or after since, oldest deletion first
+func (r *postgresRepository) ListDeletedDevices(ctx context.Context, since time.Time) ([]topology.DeletedDevice, error) {
+	query := `
+		SELECT device_id, deleted_at, orphaned_links, last_reported_at
+		FROM device_deletions
+		WHERE deleted_at >= $1
+		ORDER BY deleted_at, device_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted devices: %w", err)
+	}
+	defer rows.Close()
+
+	devices := []topology.DeletedDevice{}
+	for rows.Next() {
+		var device topology.DeletedDevice
+		var linksJSON []byte
+		var lastReportedAt sql.NullTime
+		if err := rows.Scan(&device.DeviceID, &device.DeletedAt, &linksJSON, &lastReportedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted device: %w", err)
+		}
+		if err := json.Unmarshal(linksJSON, &device.OrphanedLinks); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal orphaned links: %w", err)
+		}
+		if lastReportedAt.Valid {
+			device.LastReportedAt = &lastReportedAt.Time
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate deleted devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+// RecordOrphanedLinks stores the links reported at for a deleted device by the latest sync
+func (r *postgresRepository) RecordOrphanedLinks(ctx context.Context, deviceID string, linkIDs []string, at time.Time) error {
+	if linkIDs == nil {
+		linkIDs = []string{}
+	}
+	linksJSON, err := json.Marshal(linkIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal orphaned links: %w", err)
+	}
+
+	query := `UPDATE device_deletions SET orphaned_links = $1, last_reported_at = $2 WHERE device_id = $3`
+	if _, err := r.db.ExecContext(ctx, query, string(linksJSON), at, deviceID); err != nil {
+		return fmt.Errorf("failed to record orphaned links: %w", err)
+	}
+	return nil
+}
+
+// PurgeDeletedDevices forgets the devices deleted before deletedBefore
+func (r *postgresRepository) PurgeDeletedDevices(ctx context.Context, deletedBefore time.Time) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM device_deletions WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted devices: %w", err)
+	}
+	purged, _ := res.RowsAffected()
+	return int(purged), nil
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 
 	_ "github.com/lib/pq"
 )
 
 // postgresRepository implements both topology and classification repository interfaces
 type postgresRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	replicas *replicaPool // nil = レプリカなし（読み取りもプライマリ）
+	dsn      string       // LISTEN はプールとは別の専用接続を使う
+
+	partitions sync.Map // 存在を確認済みの月次パーティション名
 }
 
 // NewPostgresRepository creates a new PostgreSQL repository
@@ -29,11 +34,27 @@ func NewPostgresRepository(config Config) (*postgresRepository, error) {
 		return nil, fmt.Errorf("failed to ping PostgreSQL database: %w", err)
 	}
 
-	return &postgresRepository{db: db}, nil
+	repo := &postgresRepository{db: db, dsn: config.BuildDSN()}
+	if len(config.Replicas) > 0 {
+		replicas, err := openReplicaPool(config.Replicas, config.ReplicaCheckInterval)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		repo.replicas = replicas
+	}
+
+	return repo, nil
 }
 
 // Close closes the database connection
 func (r *postgresRepository) Close() error {
+	if r.replicas != nil {
+		if err := r.replicas.Close(); err != nil {
+			r.db.Close()
+			return fmt.Errorf("failed to close replicas: %w", err)
+		}
+	}
 	return r.db.Close()
 }
 
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Planned device repository methods
+
+const plannedDeviceColumns = `id, type, hardware, layer_id, device_type, expected_uplinks, status, mismatches, created_by, discovered_at, created_at, updated_at`
+
+type plannedDeviceScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPlannedDevice(scanner plannedDeviceScanner) (*topology.PlannedDevice, error) {
+	var device topology.PlannedDevice
+	var deviceType, hardware, classifiedType sql.NullString
+	var uplinksJSON, mismatchesJSON []byte
+	var discoveredAt sql.NullTime
+
+	err := scanner.Scan(
+		&device.ID, &deviceType, &hardware, &device.Template.LayerID, &classifiedType,
+		&uplinksJSON, &device.Status, &mismatchesJSON, &device.CreatedBy, &discoveredAt,
+		&device.CreatedAt, &device.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	device.Template.Type = deviceType.String
+	device.Template.Hardware = hardware.String
+	device.Template.DeviceType = classifiedType.String
+	if discoveredAt.Valid {
+		device.DiscoveredAt = &discoveredAt.Time
+	}
+
+	// JSONBからデシリアライズ
+	if err := json.Unmarshal(uplinksJSON, &device.Template.ExpectedUplinks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal expected uplinks: %w", err)
+	}
+	if err := json.Unmarshal(mismatchesJSON, &device.Mismatches); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mismatches: %w", err)
+	}
+
+	return &device, nil
+}
+
+func (r *postgresRepository) GetPlannedDevice(ctx context.Context, deviceID string) (*topology.PlannedDevice, error) {
+	query := `SELECT ` + plannedDeviceColumns + ` FROM planned_devices WHERE id = $1`
+
+	device, err := scanPlannedDevice(r.db.QueryRowContext(ctx, query, deviceID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planned device: %w", err)
+	}
+
+	return device, nil
+}
+
+func (r *postgresRepository) ListPlannedDevices(ctx context.Context) ([]topology.PlannedDevice, error) {
+	query := `SELECT ` + plannedDeviceColumns + ` FROM planned_devices ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list planned devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []topology.PlannedDevice
+	for rows.Next() {
+		device, err := scanPlannedDevice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan planned device: %w", err)
+		}
+		devices = append(devices, *device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate planned devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+func (r *postgresRepository) SavePlannedDevice(ctx context.Context, device topology.PlannedDevice) error {
+	// 作成日時が設定されていない場合は現在時刻を設定
+	if device.CreatedAt.IsZero() {
+		device.CreatedAt = time.Now()
+	}
+	device.UpdatedAt = time.Now()
+
+	uplinks := device.Template.ExpectedUplinks
+	if uplinks == nil {
+		uplinks = []string{}
+	}
+	uplinksJSON, err := json.Marshal(uplinks)
+	if err != nil {
+		return fmt.Errorf("failed to marshal expected uplinks: %w", err)
+	}
+
+	mismatches := device.Mismatches
+	if mismatches == nil {
+		mismatches = []topology.TemplateMismatch{}
+	}
+	mismatchesJSON, err := json.Marshal(mismatches)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mismatches: %w", err)
+	}
+
+	query := `
+		INSERT INTO planned_devices (` + plannedDeviceColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			type = EXCLUDED.type,
+			hardware = EXCLUDED.hardware,
+			layer_id = EXCLUDED.layer_id,
+			device_type = EXCLUDED.device_type,
+			expected_uplinks = EXCLUDED.expected_uplinks,
+			status = EXCLUDED.status,
+			mismatches = EXCLUDED.mismatches,
+			discovered_at = EXCLUDED.discovered_at,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		device.ID, device.Template.Type, device.Template.Hardware, device.Template.LayerID, device.Template.DeviceType,
+		uplinksJSON, device.Status, mismatchesJSON, device.CreatedBy, device.DiscoveredAt,
+		device.CreatedAt, device.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save planned device: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresRepository) DeletePlannedDevice(ctx context.Context, deviceID string) error {
+	query := `DELETE FROM planned_devices WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query, deviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete planned device: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Rack placement repository methods
+
+// SetDeviceRack replaces the rack, position and height of a device
+func (r *postgresRepository) SetDeviceRack(ctx context.Context, placement topology.RackPlacement) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE devices SET rack = $1, rack_position = $2, rack_units = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`, placement.Rack, placement.Position, placement.Units, placement.DeviceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to set device rack: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to set device rack: %w", err)
+	}
+	return updated > 0, nil
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+const (
+	// DefaultReplicaCheckInterval is how often replicas are pinged to detect failures and recoveries
+	DefaultReplicaCheckInterval = 10 * time.Second
+
+	replicaPingTimeout = 3 * time.Second
+)
+
+// replica is a read-only connection whose health is updated by the pool's checks
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaPool routes read-only queries to healthy replicas in turn. Replicas failing a ping are
+// skipped until a later check succeeds, so reads fall back to the primary and return automatically.
+type replicaPool struct {
+	replicas []*replica
+	next     atomic.Uint64
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// openReplicaPool connects to the replica DSNs and starts the health checks. An unreachable replica
+// does not fail startup; it is used once a check succeeds.
+func openReplicaPool(dsns []string, interval time.Duration) (*replicaPool, error) {
+	if interval <= 0 {
+		interval = DefaultReplicaCheckInterval
+	}
+
+	p := &replicaPool{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for i, dsn := range dsns {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			p.closeReplicas()
+			return nil, fmt.Errorf("failed to open replica %d: %w", i+1, err)
+		}
+		p.replicas = append(p.replicas, &replica{db: db})
+	}
+
+	p.check()
+	go p.run(interval)
+	return p, nil
+}
+
+// run re-checks the replicas until the pool is closed
+func (p *replicaPool) run(interval time.Duration) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.check()
+		}
+	}
+}
+
+// check pings every replica and records whether it can serve reads
+func (p *replicaPool) check() {
+	for _, rep := range p.replicas {
+		rep.healthy.Store(rep.ping() == nil)
+	}
+}
+
+func (rep *replica) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), replicaPingTimeout)
+	defer cancel()
+	return rep.db.PingContext(ctx)
+}
+
+// pick returns the next healthy replica, or nil when none can serve reads
+func (p *replicaPool) pick() *replica {
+	n := uint64(len(p.replicas))
+	start := p.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		rep := p.replicas[(start+i)%n]
+		if rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+// status reports how many replicas are configured and currently healthy
+func (p *replicaPool) status() topology.ReplicaStatus {
+	status := topology.ReplicaStatus{Configured: len(p.replicas)}
+	for _, rep := range p.replicas {
+		if rep.healthy.Load() {
+			status.Healthy++
+		}
+	}
+	return status
+}
+
+// Close stops the health checks and closes the replica connections
+func (p *replicaPool) Close() error {
+	close(p.stop)
+	<-p.done
+	return p.closeReplicas()
+}
+
+func (p *replicaPool) closeReplicas() error {
+	var errs []error
+	for _, rep := range p.replicas {
+		if err := rep.db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// readQuery runs a read-only query on a replica when one is healthy. When the replica turns out to
+// be unreachable it is marked unhealthy and the query is retried on the primary.
+func (r *postgresRepository) readQuery(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if r.replicas == nil {
+		return r.db.QueryContext(ctx, query, args...)
+	}
+
+	rep := r.replicas.pick()
+	if rep == nil {
+		return r.db.QueryContext(ctx, query, args...)
+	}
+
+	rows, err := rep.db.QueryContext(ctx, query, args...)
+	if err == nil || ctx.Err() != nil {
+		return rows, err
+	}
+	// クエリ自体の誤りはプライマリでも失敗するため、接続できない場合のみ切り替える
+	if pingErr := rep.ping(); pingErr == nil {
+		return nil, err
+	}
+	rep.healthy.Store(false)
+	return r.db.QueryContext(ctx, query, args...)
+}
+
+// readScalar runs a read-only query returning a single row on a replica, like readQuery
+func (r *postgresRepository) readScalar(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	rows, err := r.readQuery(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := rows.Scan(dest); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// ReplicaStatus reports the read replicas; both counts are zero when none are configured
+func (r *postgresRepository) ReplicaStatus() topology.ReplicaStatus {
+	if r.replicas == nil {
+		return topology.ReplicaStatus{}
+	}
+	return r.replicas.status()
+}
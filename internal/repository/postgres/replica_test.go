@@ -0,0 +1,282 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeServer is a database reached through the replicatest driver. A down server fails pings and
+// queries like an unreachable host; a query of "bad" fails on a reachable one like a SQL error.
+type fakeServer struct {
+	name    string
+	down    atomic.Bool
+	queries atomic.Int64
+}
+
+var (
+	errServerDown = errors.New("connection refused")
+	errBadQuery   = errors.New("syntax error")
+
+	fakeServersMu sync.Mutex
+	fakeServers   = map[string]*fakeServer{}
+)
+
+func init() {
+	sql.Register("replicatest", fakeDriver{})
+}
+
+// newFakeServer registers a server and returns it with a database handle connected to it
+func newFakeServer(t *testing.T, name string) (*fakeServer, *sql.DB) {
+	t.Helper()
+
+	server := &fakeServer{name: name}
+	dsn := t.Name() + "/" + name
+	fakeServersMu.Lock()
+	fakeServers[dsn] = server
+	fakeServersMu.Unlock()
+
+	db, err := sql.Open("replicatest", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", name, err)
+	}
+	// 停止中のサーバーへの接続を使い回さないよう、プールしない
+	db.SetMaxIdleConns(0)
+	t.Cleanup(func() { db.Close() })
+	return server, db
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	fakeServersMu.Lock()
+	server := fakeServers[dsn]
+	fakeServersMu.Unlock()
+	if server == nil || server.down.Load() {
+		return nil, errServerDown
+	}
+	return &fakeConn{server: server}, nil
+}
+
+type fakeConn struct {
+	server *fakeServer
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	if c.server.down.Load() {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+// QueryContext answers every query with the name of the server
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.server.down.Load() {
+		return nil, errServerDown
+	}
+	c.server.queries.Add(1)
+	if query == "bad" {
+		return nil, errBadQuery
+	}
+	return &fakeRows{value: c.server.name}, nil
+}
+
+type fakeRows struct {
+	value string
+	read  bool
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"server"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	dest[0] = r.value
+	return nil
+}
+
+// newTestReplicaPool starts a pool over the given handles the way openReplicaPool does
+func newTestReplicaPool(t *testing.T, interval time.Duration, dbs ...*sql.DB) *replicaPool {
+	t.Helper()
+
+	p := &replicaPool{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, db := range dbs {
+		p.replicas = append(p.replicas, &replica{db: db})
+	}
+	p.check()
+	go p.run(interval)
+	return p
+}
+
+func readFrom(t *testing.T, repo *postgresRepository) string {
+	t.Helper()
+
+	var server string
+	if err := repo.readScalar(context.Background(), &server, "SELECT server"); err != nil {
+		t.Fatalf("Unexpected read error: %v", err)
+	}
+	return server
+}
+
+func waitForHealthy(t *testing.T, p *replicaPool, healthy int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for p.status().Healthy != healthy {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d healthy replicas, got %+v", healthy, p.status())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReadQuery_RoundRobinsHealthyReplicas(t *testing.T) {
+	primary, primaryDB := newFakeServer(t, "primary")
+	_, replicaA := newFakeServer(t, "replica-a")
+	_, replicaB := newFakeServer(t, "replica-b")
+	pool := newTestReplicaPool(t, time.Hour, replicaA, replicaB)
+	repo := &postgresRepository{db: primaryDB, replicas: pool}
+
+	served := map[string]int{}
+	for i := 0; i < 4; i++ {
+		served[readFrom(t, repo)]++
+	}
+	if served["replica-a"] != 2 || served["replica-b"] != 2 {
+		t.Errorf("Expected reads to alternate between the replicas, got %v", served)
+	}
+	if primary.queries.Load() != 0 {
+		t.Errorf("Expected no reads on the primary, got %d", primary.queries.Load())
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Errorf("Unexpected close error: %v", err)
+	}
+	select {
+	case <-pool.done:
+	default:
+		t.Error("Expected Close to stop the health checks")
+	}
+}
+
+func TestReadQuery_FailsOverAndBack(t *testing.T) {
+	_, primaryDB := newFakeServer(t, "primary")
+	serverA, replicaA := newFakeServer(t, "replica-a")
+	_, replicaB := newFakeServer(t, "replica-b")
+	pool := newTestReplicaPool(t, 10*time.Millisecond, replicaA, replicaB)
+	t.Cleanup(func() { pool.Close() })
+	repo := &postgresRepository{db: primaryDB, replicas: pool}
+
+	if status := repo.ReplicaStatus(); status.Configured != 2 || status.Healthy != 2 {
+		t.Fatalf("Expected 2 of 2 replicas to be healthy, got %+v", status)
+	}
+
+	// 次のチェックを待たずに、読み取りで停止を検出したレプリカを外す
+	serverA.down.Store(true)
+	served := map[string]int{}
+	for i := 0; i < 4; i++ {
+		served[readFrom(t, repo)]++
+	}
+	if served["replica-a"] != 0 || served["replica-b"] < 3 {
+		t.Errorf("Expected reads to move off the unreachable replica, got %v", served)
+	}
+	if served["primary"] > 1 {
+		t.Errorf("Expected at most the failed read to be retried on the primary, got %v", served)
+	}
+	waitForHealthy(t, pool, 1)
+
+	// 復旧したレプリカはヘルスチェックで再び使われる
+	serverA.down.Store(false)
+	waitForHealthy(t, pool, 2)
+	served = map[string]int{}
+	for i := 0; i < 4; i++ {
+		served[readFrom(t, repo)]++
+	}
+	if served["replica-a"] != 2 || served["replica-b"] != 2 {
+		t.Errorf("Expected the recovered replica to serve reads again, got %v", served)
+	}
+}
+
+func TestReadQuery_FallsBackToPrimary(t *testing.T) {
+	_, primaryDB := newFakeServer(t, "primary")
+	serverA, replicaA := newFakeServer(t, "replica-a")
+
+	// 起動時に接続できないレプリカはエラーにせず、プライマリで読む
+	serverA.down.Store(true)
+	pool := newTestReplicaPool(t, 10*time.Millisecond, replicaA)
+	t.Cleanup(func() { pool.Close() })
+	repo := &postgresRepository{db: primaryDB, replicas: pool}
+
+	if status := repo.ReplicaStatus(); status.Configured != 1 || status.Healthy != 0 {
+		t.Fatalf("Expected the unreachable replica to be unhealthy, got %+v", status)
+	}
+	if server := readFrom(t, repo); server != "primary" {
+		t.Errorf("Expected the primary to serve reads without a healthy replica, got %s", server)
+	}
+
+	serverA.down.Store(false)
+	waitForHealthy(t, pool, 1)
+	if server := readFrom(t, repo); server != "replica-a" {
+		t.Errorf("Expected the replica to serve reads once reachable, got %s", server)
+	}
+}
+
+func TestReadQuery_QueryErrorDoesNotFailOver(t *testing.T) {
+	primary, primaryDB := newFakeServer(t, "primary")
+	_, replicaA := newFakeServer(t, "replica-a")
+	pool := newTestReplicaPool(t, time.Hour, replicaA)
+	t.Cleanup(func() { pool.Close() })
+	repo := &postgresRepository{db: primaryDB, replicas: pool}
+
+	// 接続できるレプリカでのクエリの誤りはプライマリで再試行しない
+	_, err := repo.readQuery(context.Background(), "bad")
+	if !errors.Is(err, errBadQuery) {
+		t.Errorf("Expected the query error to be returned, got %v", err)
+	}
+	if primary.queries.Load() != 0 {
+		t.Errorf("Expected no retry on the primary, got %d queries", primary.queries.Load())
+	}
+	if pool.status().Healthy != 1 {
+		t.Errorf("Expected the replica to stay healthy, got %+v", pool.status())
+	}
+}
+
+func TestReplicaStatus_WithoutReplicas(t *testing.T) {
+	_, primaryDB := newFakeServer(t, "primary")
+	repo := &postgresRepository{db: primaryDB}
+
+	if status := repo.ReplicaStatus(); status.Configured != 0 || status.Healthy != 0 {
+		t.Errorf("Expected empty status without replicas, got %+v", status)
+	}
+	if server := readFrom(t, repo); server != "primary" {
+		t.Errorf("Expected the primary to serve reads without replicas, got %s", server)
+	}
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// ruleConditionColumns maps rule condition fields to device columns
+var ruleConditionColumns = map[string]string{
+	"name":     "id", // DeviceにNameがないため、IDを使用
+	"hardware": "hardware",
+	"type":     "type",
+}
+
+// FindDevicesMatchingRule evaluates the rule conditions in SQL and returns a page of matching device IDs
+func (r *postgresRepository) FindDevicesMatchingRule(ctx context.Context, rule classification.ClassificationRule, limit, offset int) ([]string, int, error) {
+	where, args := ruleWhereClause(rule)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM devices WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count matching devices: %w", err)
+	}
+	if limit <= 0 || total == 0 {
+		return []string{}, total, nil
+	}
+
+	query := fmt.Sprintf("SELECT id FROM devices WHERE %s ORDER BY id LIMIT $%d OFFSET $%d", where, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query matching devices: %w", err)
+	}
+	defer rows.Close()
+
+	deviceIDs := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan device id: %w", err)
+		}
+		deviceIDs = append(deviceIDs, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate matching devices: %w", err)
+	}
+
+	return deviceIDs, total, nil
+}
+
+// ruleWhereClause builds the condition of a rule with the same semantics as the in-memory
+// matcher: text operators are case-insensitive and unknown fields or operators never match
+func ruleWhereClause(rule classification.ClassificationRule) (string, []interface{}) {
+	if len(rule.Conditions) == 0 {
+		return "FALSE", nil
+	}
+
+	var clauses []string
+	var args []interface{}
+	for _, condition := range rule.Conditions {
+		column, ok := ruleConditionColumns[condition.Field]
+		if !ok {
+			key, isMetadata := classification.MetadataKey(condition.Field)
+			if !isMetadata {
+				clauses = append(clauses, "FALSE")
+				continue
+			}
+			// キーがないデバイスは空文字として比較する（インメモリ評価と同じ）
+			args = append(args, key)
+			column = fmt.Sprintf("COALESCE(metadata->>$%d, '')", len(args))
+		}
+
+		placeholder := fmt.Sprintf("$%d", len(args)+1)
+		switch condition.Operator {
+		case "contains":
+			clauses = append(clauses, fmt.Sprintf("%s ILIKE '%%' || %s || '%%'", column, placeholder))
+			args = append(args, escapeLike(condition.Value))
+		case "starts_with":
+			clauses = append(clauses, fmt.Sprintf("%s ILIKE %s || '%%'", column, placeholder))
+			args = append(args, escapeLike(condition.Value))
+		case "ends_with":
+			clauses = append(clauses, fmt.Sprintf("%s ILIKE '%%' || %s", column, placeholder))
+			args = append(args, escapeLike(condition.Value))
+		case "equals":
+			clauses = append(clauses, fmt.Sprintf("LOWER(%s) = LOWER(%s)", column, placeholder))
+			args = append(args, condition.Value)
+		case "regex":
+			// 不正な正規表現はインメモリ評価と同じく一致しない扱い
+			if _, err := regexp.Compile(condition.Value); err != nil {
+				clauses = append(clauses, "FALSE")
+				continue
+			}
+			clauses = append(clauses, fmt.Sprintf("%s ~ %s", column, placeholder))
+			args = append(args, condition.Value)
+		default:
+			clauses = append(clauses, "FALSE")
+		}
+	}
+
+	separator := " AND "
+	if rule.LogicOperator == "OR" {
+		separator = " OR "
+	}
+	return "(" + strings.Join(clauses, separator) + ")", args
+}
+
+// escapeLike escapes the LIKE wildcards in a literal value (backslash is the default escape character)
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationSource is where migrations are loaded from (tests add their own to the bundled ones)
+var migrationSource fs.FS = migrationFiles
+
+// MigrationNames returns the file names of the bundled migrations in the order they apply
+func MigrationNames() ([]string, error) {
+	names, err := fs.Glob(migrationSource, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ApplyMigrations runs every bundled migration in order, each in its own transaction, and
+// records them as applied. The migrations are not idempotent, so this is only meant for an
+// empty database such as the ephemeral one created by the integration test harness.
+func (r *postgresRepository) ApplyMigrations(ctx context.Context) error {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+
+	migrator := NewMigrator(r.db, DefaultMigratorOptions())
+	if err := migrator.ensureMigrationTable(ctx); err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		if err := r.applyMigration(ctx, migration); err != nil {
+			return err
+		}
+		// Migrator が後から適用済みと判断できるよう記録する
+		if _, err := r.db.ExecContext(ctx, "INSERT INTO migrations (filename) VALUES ($1)", migration.Name); err != nil {
+			return fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// applyMigration runs one migration without lock timeouts or bookkeeping
+func (r *postgresRepository) applyMigration(ctx context.Context, migration Migration) error {
+	if migration.NoTransaction {
+		for _, statement := range splitStatements(migration.Script) {
+			if _, err := r.db.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", migration.Name, err)
+			}
+		}
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// 引数なしのExecは単純クエリプロトコルで複数ステートメントを実行できる
+	if _, err := tx.ExecContext(ctx, migration.Script); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to apply migration %s: %w", migration.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", migration.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/repository/postgres"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaBackfill_ExpandBackfillContract(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	setup.SeedTestData(t)
+	ctx := context.Background()
+
+	postgres.AddTestMigrations(t, map[string]string{
+		"900_add_devices_label.sql": `-- migrate:phase expand
+ALTER TABLE devices ADD COLUMN label TEXT;
+`,
+		"901_require_devices_label.sql": `-- migrate:phase contract
+-- migrate:requires-backfill devices_label
+ALTER TABLE devices ALTER COLUMN label SET NOT NULL;
+`,
+	}, map[string]func(ctx context.Context, tx *sql.Tx, limit int) (int, error){
+		"devices_label": func(ctx context.Context, tx *sql.Tx, limit int) (int, error) {
+			result, err := tx.ExecContext(ctx, `
+				UPDATE devices SET label = 'label-' || id
+				WHERE id IN (SELECT id FROM devices WHERE label IS NULL ORDER BY id LIMIT $1)
+			`, limit)
+			if err != nil {
+				return 0, err
+			}
+			rows, err := result.RowsAffected()
+			return int(rows), err
+		},
+	})
+
+	migrator := postgres.NewTestMigrator(setup.Repo)
+	maintenance, ok := setup.Repo.(topology.SchemaMaintenanceRepository)
+	require.True(t, ok)
+
+	// expand だけが適用され、contract はバックフィル完了まで待たされる
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"900_add_devices_label.sql"}, applied)
+
+	_, err = migrator.Contract(ctx, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "backfill devices_label has not completed")
+
+	// 1バッチ2行ずつ、シード済みの3デバイスを埋める
+	rows, err := maintenance.RunSchemaBackfills(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rows)
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	var state postgres.BackfillState
+	for _, backfill := range status.Backfills {
+		if backfill.Name == "devices_label" {
+			state = backfill
+		}
+	}
+	assert.True(t, state.Completed)
+	assert.Equal(t, int64(3), state.RowsProcessed)
+
+	// 完了後の実行は何もしない
+	rows, err = maintenance.RunSchemaBackfills(ctx, 2)
+	require.NoError(t, err)
+	assert.Zero(t, rows)
+
+	// NOT NULL の付与は未処理の行が残っていれば失敗する
+	applied, err = migrator.Contract(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"901_require_devices_label.sql"}, applied)
+}
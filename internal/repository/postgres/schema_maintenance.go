@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReportSchemaVersion records the schema version bundled in this build for the instance
+func (r *postgresRepository) ReportSchemaVersion(ctx context.Context, instanceID, role string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO schema_clients (instance_id, role, schema_version, last_seen)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (instance_id) DO UPDATE SET
+			role = EXCLUDED.role,
+			schema_version = EXCLUDED.schema_version,
+			last_seen = EXCLUDED.last_seen
+	`, instanceID, role, SchemaVersion())
+	if err != nil {
+		return fmt.Errorf("failed to report schema version: %w", err)
+	}
+	return nil
+}
+
+// RunSchemaBackfills advances the backfills required by pending contract migrations whose
+// expand migrations are applied. Each batch commits on its own, so an interrupted run
+// resumes where it stopped.
+func (r *postgresRepository) RunSchemaBackfills(ctx context.Context, batchSize int) (int, error) {
+	if len(backfills) == 0 {
+		return 0, nil
+	}
+
+	migrator := NewMigrator(r.db, DefaultMigratorOptions())
+	status, err := migrator.status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	completed := make(map[string]bool, len(status.Backfills))
+	for _, state := range status.Backfills {
+		completed[state.Name] = state.Completed
+	}
+
+	total := 0
+	expandPending := false
+	for _, state := range status.Migrations {
+		if !state.Applied && state.Phase == PhaseExpand {
+			// 以降のバックフィルは埋める先の列がまだないかもしれない
+			expandPending = true
+		}
+		if state.Applied || state.Phase != PhaseContract || expandPending {
+			continue
+		}
+		for _, name := range state.RequiresBackfill {
+			if completed[name] {
+				continue
+			}
+			rows, done, err := r.runBackfill(ctx, name, backfills[name], batchSize, migrator.options)
+			total += rows
+			if err != nil {
+				return total, err
+			}
+			completed[name] = done
+		}
+	}
+	return total, nil
+}
+
+// runBackfill runs batches of one backfill until it completes or ctx is done
+func (r *postgresRepository) runBackfill(ctx context.Context, name string, fn backfill, batchSize int, options MigratorOptions) (int, bool, error) {
+	total := 0
+	for ctx.Err() == nil {
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return total, false, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		// 本番のクエリを待たせないよう、ロックが取れないバッチは次回に回す
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL lock_timeout = %d", options.LockTimeout.Milliseconds())); err != nil {
+			tx.Rollback()
+			return total, false, fmt.Errorf("failed to set lock timeout: %w", err)
+		}
+
+		rows, err := fn(ctx, tx, batchSize)
+		if err != nil {
+			tx.Rollback()
+			if isLockTimeout(err) {
+				return total, false, nil
+			}
+			return total, false, fmt.Errorf("backfill %s failed: %w", name, err)
+		}
+
+		done := rows == 0
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO schema_backfills (name, rows_processed, completed_at, updated_at)
+			VALUES ($1, $2, CASE WHEN $3::boolean THEN CURRENT_TIMESTAMP END, CURRENT_TIMESTAMP)
+			ON CONFLICT (name) DO UPDATE SET
+				rows_processed = schema_backfills.rows_processed + EXCLUDED.rows_processed,
+				completed_at = EXCLUDED.completed_at,
+				updated_at = EXCLUDED.updated_at
+		`, name, rows, done)
+		if err != nil {
+			tx.Rollback()
+			return total, false, fmt.Errorf("failed to record backfill %s: %w", name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return total, false, fmt.Errorf("failed to commit backfill %s: %w", name, err)
+		}
+
+		total += rows
+		if done {
+			return total, true, nil
+		}
+	}
+	return total, false, nil
+}
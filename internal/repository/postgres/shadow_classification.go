@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// Shadow classification repository methods
+
+// ReplaceShadowClassifications replaces the shadow classifications of the evaluated devices in one transaction
+func (r *postgresRepository) ReplaceShadowClassifications(ctx context.Context, evaluatedDeviceIDs []string, results []classification.ShadowClassification) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	deleteStmt, err := tx.PrepareContext(ctx, `DELETE FROM shadow_classifications WHERE device_id = $1`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare shadow classification deletion: %w", err)
+	}
+	defer deleteStmt.Close()
+
+	for _, deviceID := range evaluatedDeviceIDs {
+		if _, err := deleteStmt.ExecContext(ctx, deviceID); err != nil {
+			return fmt.Errorf("failed to delete shadow classification of %s: %w", deviceID, err)
+		}
+	}
+
+	insertStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO shadow_classifications (device_id, rule_id, rule_name, layer, device_type, evaluated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (device_id) DO UPDATE SET
+			rule_id = EXCLUDED.rule_id,
+			rule_name = EXCLUDED.rule_name,
+			layer = EXCLUDED.layer,
+			device_type = EXCLUDED.device_type,
+			evaluated_at = EXCLUDED.evaluated_at
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare shadow classification insert: %w", err)
+	}
+	defer insertStmt.Close()
+
+	for _, result := range results {
+		if _, err := insertStmt.ExecContext(ctx, result.DeviceID, result.RuleID, result.RuleName, result.Layer, result.DeviceType, result.EvaluatedAt); err != nil {
+			return fmt.Errorf("failed to save shadow classification of %s: %w", result.DeviceID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit shadow classifications: %w", err)
+	}
+	return nil
+}
+
+// ListShadowClassifications retrieves all recorded shadow classifications
+func (r *postgresRepository) ListShadowClassifications(ctx context.Context) ([]classification.ShadowClassification, error) {
+	query := `
+		SELECT device_id, rule_id, rule_name, layer, device_type, evaluated_at
+		FROM shadow_classifications
+		ORDER BY device_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow classifications: %w", err)
+	}
+	defer rows.Close()
+
+	var results []classification.ShadowClassification
+	for rows.Next() {
+		var result classification.ShadowClassification
+		if err := rows.Scan(&result.DeviceID, &result.RuleID, &result.RuleName, &result.Layer, &result.DeviceType, &result.EvaluatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shadow classification: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate shadow classifications: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+// Style rule repository methods
+
+const styleRuleColumns = `id, description, target, logic_operator, conditions, priority, style, updated_by, updated_at`
+
+// ListStyleRules retrieves all style rules
+func (r *postgresRepository) ListStyleRules(ctx context.Context) ([]visualization.StyleRule, error) {
+	query := `SELECT ` + styleRuleColumns + ` FROM style_rules ORDER BY priority, id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list style rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []visualization.StyleRule{}
+	for rows.Next() {
+		var rule visualization.StyleRule
+		var conditionsJSON, styleJSON string
+		if err := rows.Scan(&rule.ID, &rule.Description, &rule.Target, &rule.LogicOperator, &conditionsJSON,
+			&rule.Priority, &styleJSON, &rule.UpdatedBy, &rule.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan style rule: %w", err)
+		}
+		if err := json.Unmarshal([]byte(conditionsJSON), &rule.Conditions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal style rule conditions: %w", err)
+		}
+		switch rule.Target {
+		case visualization.StyleTargetNode:
+			rule.NodeStyle = &visualization.NodeStyleOverride{}
+			err = json.Unmarshal([]byte(styleJSON), rule.NodeStyle)
+		case visualization.StyleTargetEdge:
+			rule.EdgeStyle = &visualization.EdgeStyleOverride{}
+			err = json.Unmarshal([]byte(styleJSON), rule.EdgeStyle)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal style rule style: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate style rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// SaveStyleRule creates or replaces a style rule
+func (r *postgresRepository) SaveStyleRule(ctx context.Context, rule visualization.StyleRule) error {
+	if rule.UpdatedAt.IsZero() {
+		rule.UpdatedAt = time.Now()
+	}
+	if rule.LogicOperator == "" {
+		rule.LogicOperator = "AND"
+	}
+
+	conditionsJSON, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal style rule conditions: %w", err)
+	}
+	var style interface{} = rule.NodeStyle
+	if rule.Target == visualization.StyleTargetEdge {
+		style = rule.EdgeStyle
+	}
+	styleJSON, err := json.Marshal(style)
+	if err != nil {
+		return fmt.Errorf("failed to marshal style rule style: %w", err)
+	}
+
+	query := `
+		INSERT INTO style_rules (` + styleRuleColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			description = EXCLUDED.description,
+			target = EXCLUDED.target,
+			logic_operator = EXCLUDED.logic_operator,
+			conditions = EXCLUDED.conditions,
+			priority = EXCLUDED.priority,
+			style = EXCLUDED.style,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		rule.ID, rule.Description, rule.Target, rule.LogicOperator, string(conditionsJSON),
+		rule.Priority, string(styleJSON), rule.UpdatedBy, rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save style rule: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteStyleRule removes a style rule
+func (r *postgresRepository) DeleteStyleRule(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM style_rules WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete style rule: %w", err)
+	}
+
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
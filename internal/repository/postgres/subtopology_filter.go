@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// subTopologyFilterConditions translates filter into conditions on the devices table aliased
+// as alias. Placeholders are numbered after the len(args) arguments already bound.
+func subTopologyFilterConditions(alias string, filter topology.SubTopologyFilter, args []interface{}, now time.Time) ([]string, []interface{}) {
+	var conditions []string
+	bind := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(filter.IncludeTypes) > 0 {
+		conditions = append(conditions, fmt.Sprintf("%s.type = ANY(%s)", alias, bind(pq.Array(filter.IncludeTypes))))
+	}
+	if len(filter.ExcludeTypes) > 0 {
+		conditions = append(conditions, fmt.Sprintf("NOT (%s.type = ANY(%s))", alias, bind(pq.Array(filter.ExcludeTypes))))
+	}
+	if filter.HardwareRegex != "" {
+		conditions = append(conditions, fmt.Sprintf("%s.hardware ~ %s", alias, bind(filter.HardwareRegex)))
+	}
+	if filter.ExcludeHardware != "" {
+		conditions = append(conditions, fmt.Sprintf("%s.hardware !~ %s", alias, bind(filter.ExcludeHardware)))
+	}
+
+	// プレースホルダの番号を安定させるためキー順に並べる
+	for _, key := range sortedKeys(filter.IncludeMetadata) {
+		conditions = append(conditions, fmt.Sprintf("%s.metadata ->> %s = %s", alias, bind(key), bind(filter.IncludeMetadata[key])))
+	}
+	for _, key := range sortedKeys(filter.ExcludeMetadata) {
+		// メタデータがない（NULL）デバイスは除外しない
+		conditions = append(conditions, fmt.Sprintf("COALESCE(%s.metadata ->> %s, '') <> %s", alias, bind(key), bind(filter.ExcludeMetadata[key])))
+	}
+
+	if len(filter.IncludeWorkflowStates) > 0 {
+		conditions = append(conditions, fmt.Sprintf("%s.workflow_state = ANY(%s)", alias, bind(pq.Array(filter.IncludeWorkflowStates))))
+	}
+	if len(filter.ExcludeWorkflowStates) > 0 {
+		conditions = append(conditions, fmt.Sprintf("NOT (%s.workflow_state = ANY(%s))", alias, bind(pq.Array(filter.ExcludeWorkflowStates))))
+	}
+
+	switch filter.Status {
+	case topology.DeviceStatusActive:
+		conditions = append(conditions, fmt.Sprintf("%s.last_seen >= %s", alias, bind(now.Add(-topology.DeviceStaleAfter))))
+	case topology.DeviceStatusStale:
+		conditions = append(conditions, fmt.Sprintf("%s.last_seen < %s", alias, bind(now.Add(-topology.DeviceStaleAfter))))
+	}
+
+	return conditions, args
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// subTopologyLinksQuery selects the links of deviceID ($1) whose peer device passes filter
+func subTopologyLinksQuery(filter topology.SubTopologyFilter, deviceID string, now time.Time) (string, []interface{}) {
+	conditions, args := subTopologyFilterConditions("d", filter, []interface{}{deviceID}, now)
+	if len(conditions) == 0 {
+		return `
+		SELECT id, source_id, target_id, source_port, target_port, weight, metadata, last_seen, created_at, updated_at
+		FROM links 
+		WHERE source_id = $1 OR target_id = $1
+		LIMIT 100
+	`, args
+	}
+
+	// 対向デバイスの属性で絞り込む（自己ループの対向はルート自身なので常に残す）
+	query := fmt.Sprintf(`
+		SELECT l.id, l.source_id, l.target_id, l.source_port, l.target_port, l.weight, l.metadata, l.last_seen, l.created_at, l.updated_at
+		FROM links l
+		JOIN devices d ON d.id = CASE WHEN l.source_id = $1 THEN l.target_id ELSE l.source_id END
+		WHERE (l.source_id = $1 OR l.target_id = $1)
+			AND (d.id = $1 OR (%s))
+		LIMIT 100
+	`, strings.Join(conditions, " AND "))
+	return query, args
+}
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+func TestSubTopologyLinksQuery(t *testing.T) {
+	now := time.Now()
+
+	query, args := subTopologyLinksQuery(topology.SubTopologyFilter{}, "core-01", now)
+	if strings.Contains(query, "JOIN devices") || len(args) != 1 {
+		t.Errorf("Expected unfiltered query without join, got %d args:\n%s", len(args), query)
+	}
+
+	filter := topology.SubTopologyFilter{
+		IncludeTypes:    []string{"switch"},
+		ExcludeHardware: "^EX",
+		IncludeMetadata: map[string]string{"site": "tyo1", "rack": "A"},
+		Status:          topology.DeviceStatusActive,
+	}
+	query, args = subTopologyLinksQuery(filter, "core-01", now)
+	if len(args) != 8 {
+		t.Fatalf("Expected 8 bound arguments, got %d", len(args))
+	}
+	for _, want := range []string{
+		"d.type = ANY($2)",
+		"d.hardware !~ $3",
+		"d.metadata ->> $4 = $5", // rack（キー順）
+		"d.metadata ->> $6 = $7", // site
+		"d.last_seen >= $8",
+		"d.id = $1 OR",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("Expected query to contain %q:\n%s", want, query)
+		}
+	}
+	if args[3] != "rack" || args[5] != "site" {
+		t.Errorf("Expected metadata keys bound in order, got %v and %v", args[3], args[5])
+	}
+	if cutoff, ok := args[7].(time.Time); !ok || !cutoff.Equal(now.Add(-topology.DeviceStaleAfter)) {
+		t.Errorf("Expected stale cutoff %v, got %v", now.Add(-topology.DeviceStaleAfter), args[7])
+	}
+}
@@ -0,0 +1,47 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func extractedDeviceIDs(t *testing.T, setup *testutil.TestSetup, filter topology.SubTopologyFilter) []string {
+	t.Helper()
+
+	devices, _, err := setup.Repo.ExtractSubTopology(context.Background(), "device-002", topology.SubTopologyOptions{Radius: 1, Filter: filter})
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(devices))
+	for _, device := range devices {
+		ids = append(ids, device.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestExtractSubTopology_MetadataFilter(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	setup.SeedTestData(t)
+	setup.SeedSiteMetadata(t)
+
+	assert.Equal(t, []string{"device-001", "device-002", "device-003"}, extractedDeviceIDs(t, setup, topology.SubTopologyFilter{}))
+
+	// ルート（device-002）はメタデータを持たないが常に残る
+	assert.Equal(t, []string{"device-001", "device-002"}, extractedDeviceIDs(t, setup, topology.SubTopologyFilter{
+		IncludeMetadata: map[string]string{"site": "tokyo"},
+	}))
+	assert.Equal(t, []string{"device-002", "device-003"}, extractedDeviceIDs(t, setup, topology.SubTopologyFilter{
+		ExcludeMetadata: map[string]string{"site": "tokyo"},
+	}))
+	assert.Equal(t, []string{"device-002"}, extractedDeviceIDs(t, setup, topology.SubTopologyFilter{
+		IncludeMetadata: map[string]string{"site": "nagoya"},
+	}))
+}
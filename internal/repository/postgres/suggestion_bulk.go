@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// ResolveSuggestions sets the status of pending suggestions in one transaction. Each suggestion runs in
+// its own savepoint so a failure only rolls back that suggestion.
+func (r *postgresRepository) ResolveSuggestions(ctx context.Context, suggestionIDs []string, status classification.SuggestionStatus) ([]string, []classification.SuggestionFailure, error) {
+	resolved := []string{}
+	failed := []classification.SuggestionFailure{}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, suggestionID := range suggestionIDs {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT resolve_suggestion`); err != nil {
+			return nil, nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		if err := resolveSuggestion(ctx, tx, suggestionID, status, now); err != nil {
+			// 失敗した提案のみ巻き戻して残りは続行する
+			if _, rbErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT resolve_suggestion`); rbErr != nil {
+				return nil, nil, fmt.Errorf("failed to roll back suggestion %s: %w", suggestionID, rbErr)
+			}
+			failed = append(failed, classification.SuggestionFailure{SuggestionID: suggestionID, Error: err.Error()})
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT resolve_suggestion`); err != nil {
+			return nil, nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		resolved = append(resolved, suggestionID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit suggestions: %w", err)
+	}
+
+	return resolved, failed, nil
+}
+
+// resolveSuggestion updates one suggestion, and activates its rule when it is accepted
+func resolveSuggestion(ctx context.Context, tx *sql.Tx, suggestionID string, status classification.SuggestionStatus, now time.Time) error {
+	// 別の操作で処理済みの提案は上書きしない
+	var ruleID string
+	err := tx.QueryRowContext(ctx, `
+		UPDATE classification_suggestions SET status = $2, updated_at = $3
+		WHERE id = $1 AND status = 'pending'
+		RETURNING rule_id`,
+		suggestionID, string(status), now,
+	).Scan(&ruleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("suggestion is no longer pending")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update suggestion status: %w", err)
+	}
+
+	if status != classification.SuggestionStatusAccepted {
+		return nil
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE classification_rules SET is_active = TRUE, updated_at = $2 WHERE id = $1`, ruleID, now)
+	if err != nil {
+		return fmt.Errorf("failed to activate rule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("rule %s not found", ruleID)
+	}
+	return nil
+}
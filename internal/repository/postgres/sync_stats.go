@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Sync cycle stats repository methods
+
+const syncCycleStatsColumns = `started_at, finished_at, devices, links, devices_appeared, devices_disappeared,
+	links_added, links_removed, errors, baseline`
+
+// SaveSyncCycleStats appends the stats of a synchronization cycle
+func (r *postgresRepository) SaveSyncCycleStats(ctx context.Context, stats topology.SyncCycleStats) error {
+	query := `
+		INSERT INTO sync_cycle_stats (` + syncCycleStatsColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		stats.StartedAt, stats.FinishedAt, stats.Devices, stats.Links, stats.DevicesAppeared, stats.DevicesDisappeared,
+		stats.LinksAdded, stats.LinksRemoved, stats.Errors, stats.Baseline,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save sync cycle stats: %w", err)
+	}
+
+	return nil
+}
+
+// ListSyncCycleStats retrieves the cycles finished after since, oldest first, at most the last limit (0 = all)
+func (r *postgresRepository) ListSyncCycleStats(ctx context.Context, since time.Time, limit int) ([]topology.SyncCycleStats, error) {
+	var limitArg interface{}
+	if limit > 0 {
+		limitArg = limit
+	}
+
+	// 最新の limit 件を取得してから古い順に並べ替える
+	query := `
+		SELECT ` + syncCycleStatsColumns + ` FROM (
+			SELECT * FROM sync_cycle_stats
+			WHERE finished_at > $1
+			ORDER BY finished_at DESC, id DESC
+			LIMIT $2
+		) latest
+		ORDER BY finished_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since, limitArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync cycle stats: %w", err)
+	}
+	defer rows.Close()
+
+	history := []topology.SyncCycleStats{}
+	for rows.Next() {
+		var stats topology.SyncCycleStats
+		if err := rows.Scan(&stats.StartedAt, &stats.FinishedAt, &stats.Devices, &stats.Links, &stats.DevicesAppeared,
+			&stats.DevicesDisappeared, &stats.LinksAdded, &stats.LinksRemoved, &stats.Errors, &stats.Baseline); err != nil {
+			return nil, fmt.Errorf("failed to scan sync cycle stats: %w", err)
+		}
+		history = append(history, stats)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sync cycle stats: %w", err)
+	}
+
+	return history, nil
+}
+
+// DeleteSyncCycleStats removes the cycles finished before cutoff
+func (r *postgresRepository) DeleteSyncCycleStats(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sync_cycle_stats WHERE finished_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sync cycle stats: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/servak/topology-manager/internal/domain/topology"
 )
@@ -34,15 +35,10 @@ func (r *postgresRepository) ExtractSubTopology(ctx context.Context, deviceID st
 	// Add the center device
 	devices = append(devices, *centerDevice)
 	
-	// Get all links connected to this device
-	linksQuery := `
-		SELECT id, source_id, target_id, source_port, target_port, weight, metadata, last_seen, created_at, updated_at
-		FROM links 
-		WHERE source_id = $1 OR target_id = $1
-		LIMIT 100
-	`
+	// Get all links connected to this device (filtered in SQL by the peer's attributes)
+	linksQuery, args := subTopologyLinksQuery(opts.Filter, deviceID, time.Now())
 	
-	rows, err := r.db.QueryContext(ctx, linksQuery, deviceID)
+	rows, err := r.readQuery(ctx, linksQuery, args...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to query links: %w", err)
 	}
@@ -63,7 +59,7 @@ func (r *postgresRepository) ExtractSubTopology(ctx context.Context, deviceID st
 			return nil, nil, fmt.Errorf("failed to scan link: %w", err)
 		}
 		
-		link.Metadata = make(map[string]string)
+		link.Metadata = unmarshalMetadata(metadataJSON)
 		links = append(links, link)
 		
 		// Track connected devices
@@ -74,6 +70,10 @@ func (r *postgresRepository) ExtractSubTopology(ctx context.Context, deviceID st
 			connectedDeviceIDs[link.TargetID] = true
 		}
 	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate links: %w", err)
+	}
 	
 	// Get all connected devices
 	for connectedID := range connectedDeviceIDs {
@@ -83,6 +83,10 @@ func (r *postgresRepository) ExtractSubTopology(ctx context.Context, deviceID st
 		
 		device, err := r.GetDevice(ctx, connectedID)
 		if err != nil {
+			// キャンセル時はスキップせずに中断
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
 			continue // Skip on error
 		}
 		if device != nil {
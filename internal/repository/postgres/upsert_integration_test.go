@@ -0,0 +1,195 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.RunWithPostgres(m))
+}
+
+func upsertTestDevices(n int, hardware string, updatedAt time.Time) []topology.Device {
+	devices := make([]topology.Device, n)
+	for i := range devices {
+		devices[i] = topology.Device{
+			ID:        fmt.Sprintf("device-%03d", i),
+			Type:      "switch",
+			Hardware:  hardware,
+			LastSeen:  updatedAt,
+			CreatedAt: updatedAt,
+			UpdatedAt: updatedAt,
+		}
+	}
+	return devices
+}
+
+func TestBulkAddDevices_ConcurrentLastWriteWins(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	ctx := context.Background()
+
+	older := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	newer := older.Add(time.Minute)
+
+	// seed と同期を模して、同じデバイスを異なる順序・時刻で並行に書き込む
+	seed := upsertTestDevices(200, "seed", older)
+	synced := upsertTestDevices(200, "sync", newer)
+	for i, j := 0, len(synced)-1; i < j; i, j = i+1, j-1 {
+		synced[i], synced[j] = synced[j], synced[i]
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		batch := seed
+		if i%2 == 1 {
+			batch = synced
+		}
+		wg.Add(1)
+		go func(batch []topology.Device) {
+			defer wg.Done()
+			errs <- setup.Repo.BulkAddDevices(ctx, batch)
+		}(batch)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	devices, result, err := setup.Repo.GetDevices(ctx, topology.PaginationOptions{Page: 1, PageSize: 1000, OrderBy: "id", SortDir: "ASC"})
+	require.NoError(t, err)
+	assert.Equal(t, 200, result.TotalCount)
+	for _, device := range devices {
+		assert.Equal(t, "sync", device.Hardware, "newest write should win for %s", device.ID)
+		assert.True(t, device.UpdatedAt.Equal(newer), "updated_at of %s should be the newest", device.ID)
+		assert.True(t, device.CreatedAt.Equal(older), "created_at of %s should be the oldest", device.ID)
+	}
+}
+
+func TestBulkAddDevices_OlderWriteDoesNotOverwrite(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	ctx := context.Background()
+
+	newer := time.Now().Truncate(time.Microsecond)
+	older := newer.Add(-time.Hour)
+
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, upsertTestDevices(1, "sync", newer)))
+	// 古い書き込みは後からコミットされても反映されない
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, upsertTestDevices(1, "seed", older)))
+
+	device, err := setup.Repo.GetDevice(ctx, "device-000")
+	require.NoError(t, err)
+	require.NotNil(t, device)
+	assert.Equal(t, "sync", device.Hardware)
+	assert.True(t, device.LastSeen.Equal(newer))
+}
+
+func TestBulkAddDevices_DuplicateIDsInBatch(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	batch := append(upsertTestDevices(1, "first", now), upsertTestDevices(1, "second", now)...)
+
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, batch))
+
+	device, err := setup.Repo.GetDevice(ctx, "device-000")
+	require.NoError(t, err)
+	require.NotNil(t, device)
+	assert.Equal(t, "second", device.Hardware, "later entry should win a tie")
+}
+
+func TestAddDevice_PersistsMetadata(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Microsecond)
+	devices := upsertTestDevices(2, "hw", now)
+	devices[0].Metadata = map[string]string{"site": "tokyo", "role": "spine"}
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, devices))
+
+	device, err := setup.Repo.GetDevice(ctx, "device-000")
+	require.NoError(t, err)
+	require.NotNil(t, device)
+	assert.Equal(t, map[string]string{"site": "tokyo", "role": "spine"}, device.Metadata)
+
+	// 単体の更新でもメタデータが置き換わる
+	device.Metadata = map[string]string{"site": "osaka"}
+	device.UpdatedAt = now.Add(time.Minute)
+	require.NoError(t, setup.Repo.UpdateDevice(ctx, *device))
+
+	found, err := setup.Repo.SearchDevices(ctx, "device-00", 10)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, map[string]string{"site": "osaka"}, found[0].Metadata)
+	assert.Empty(t, found[1].Metadata)
+}
+
+func TestAddLink_PersistsMetadata(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	setup.SeedTestData(t)
+	ctx := context.Background()
+
+	link := testutil.CreateTestLink("link-speed", "device-001", "device-003")
+	link.Metadata = map[string]string{"speed": "100", "lag": "po1"}
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, []topology.Link{link}))
+
+	stored, err := setup.Repo.GetLink(ctx, "link-speed")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, link.Metadata, stored.Metadata)
+
+	// 更新と一覧の読み込みでも保たれる
+	stored.Metadata = map[string]string{"speed": "400"}
+	stored.UpdatedAt = stored.UpdatedAt.Add(time.Minute)
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, []topology.Link{*stored}))
+
+	links, err := setup.Repo.GetDeviceLinks(ctx, "device-003")
+	require.NoError(t, err)
+	for _, deviceLink := range links {
+		if deviceLink.ID == "link-speed" {
+			assert.Equal(t, map[string]string{"speed": "400"}, deviceLink.Metadata)
+		}
+	}
+}
+
+func TestDirectUpdates_StampUpdatedAt(t *testing.T) {
+	setup := testutil.NewPostgresTestSetup(t)
+	ctx := context.Background()
+
+	stale := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, upsertTestDevices(2, "hw", stale)))
+
+	rackRepo := setup.Repo.(topology.RackRepository)
+	ok, err := rackRepo.SetDeviceRack(ctx, topology.RackPlacement{DeviceID: "device-000", Rack: "r12", Units: 1})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	workflowRepo := setup.Repo.(topology.WorkflowRepository)
+	ok, err = workflowRepo.TransitionDeviceWorkflow(ctx, topology.WorkflowTransition{
+		DeviceID: "device-001", From: topology.WorkflowDiscovered, To: topology.WorkflowOnboarding, ChangedBy: "test",
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// 後から届いた古い同期の書き込みで変更が巻き戻らない
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, upsertTestDevices(2, "late", stale.Add(time.Minute))))
+	for _, id := range []string{"device-000", "device-001"} {
+		device, err := setup.Repo.GetDevice(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, device.UpdatedAt.After(stale.Add(time.Minute)), "%s updated_at should be stamped", id)
+		assert.Equal(t, "hw", device.Hardware)
+	}
+}
@@ -138,7 +138,7 @@ func (r *sqliteRepository) DeleteDeviceClassification(ctx context.Context, devic
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?`
 
-	result, err := r.db.ExecContext(ctx, query, deviceID)
+	result, err := r.writer.ExecContext(ctx, query, deviceID)
 	if err != nil {
 		return err
 	}
@@ -229,8 +229,8 @@ func (r *sqliteRepository) SaveClassificationRule(ctx context.Context, rule clas
 	}
 
 	query := `
-		INSERT INTO classification_rules (id, name, description, conditions, logic_operator, layer, device_type, priority, is_active, confidence, created_by, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO classification_rules (id, name, description, conditions, logic_operator, layer, device_type, priority, is_active, shadow, confidence, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT (id) DO UPDATE SET
 			name = EXCLUDED.name,
 			description = EXCLUDED.description,
@@ -240,12 +240,13 @@ func (r *sqliteRepository) SaveClassificationRule(ctx context.Context, rule clas
 			device_type = EXCLUDED.device_type,
 			priority = EXCLUDED.priority,
 			is_active = EXCLUDED.is_active,
+			shadow = EXCLUDED.shadow,
 			confidence = EXCLUDED.confidence,
 			updated_at = CURRENT_TIMESTAMP`
 
-	_, err = r.db.ExecContext(ctx, query,
+	_, err = r.writer.ExecContext(ctx, query,
 		rule.ID, rule.Name, rule.Description, string(conditionsJSON), rule.LogicOperator,
-		rule.Layer, rule.DeviceType, rule.Priority, rule.IsActive, rule.Confidence,
+		rule.Layer, rule.DeviceType, rule.Priority, rule.IsActive, rule.Shadow, rule.Confidence,
 		rule.CreatedBy, rule.CreatedAt, rule.UpdatedAt)
 
 	return err
@@ -257,13 +258,13 @@ func (r *sqliteRepository) GetClassificationRule(ctx context.Context, ruleID str
 	var conditionsJSON string
 
 	query := `
-		SELECT id, name, description, conditions, logic_operator, layer, device_type, priority, is_active, confidence, created_by, created_at, updated_at
+		SELECT id, name, description, conditions, logic_operator, layer, device_type, priority, is_active, shadow, confidence, created_by, created_at, updated_at
 		FROM classification_rules
 		WHERE id = ?`
 
 	err := r.db.QueryRowContext(ctx, query, ruleID).Scan(
 		&rule.ID, &rule.Name, &rule.Description, &conditionsJSON, &rule.LogicOperator,
-		&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Confidence,
+		&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Shadow, &rule.Confidence,
 		&rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt)
 
 	if err != nil {
@@ -298,13 +299,14 @@ func (r *sqliteRepository) UpdateClassificationRule(ctx context.Context, rule cl
 			device_type = ?,
 			priority = ?,
 			is_active = ?,
+			shadow = ?,
 			confidence = ?,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.writer.ExecContext(ctx, query,
 		rule.Name, rule.Description, string(conditionsJSON), rule.LogicOperator,
-		rule.Layer, rule.DeviceType, rule.Priority, rule.IsActive, rule.Confidence,
+		rule.Layer, rule.DeviceType, rule.Priority, rule.IsActive, rule.Shadow, rule.Confidence,
 		rule.ID)
 	if err != nil {
 		return err
@@ -324,7 +326,7 @@ func (r *sqliteRepository) UpdateClassificationRule(ctx context.Context, rule cl
 // DeleteClassificationRule deletes a classification rule
 func (r *sqliteRepository) DeleteClassificationRule(ctx context.Context, ruleID string) error {
 	query := "DELETE FROM classification_rules WHERE id = ?"
-	result, err := r.db.ExecContext(ctx, query, ruleID)
+	result, err := r.writer.ExecContext(ctx, query, ruleID)
 	if err != nil {
 		return err
 	}
@@ -343,7 +345,7 @@ func (r *sqliteRepository) DeleteClassificationRule(ctx context.Context, ruleID
 // ListClassificationRules lists all classification rules
 func (r *sqliteRepository) ListClassificationRules(ctx context.Context) ([]classification.ClassificationRule, error) {
 	query := `
-		SELECT id, name, description, conditions, logic_operator, layer, device_type, priority, is_active, confidence, created_by, created_at, updated_at
+		SELECT id, name, description, conditions, logic_operator, layer, device_type, priority, is_active, shadow, confidence, created_by, created_at, updated_at
 		FROM classification_rules
 		ORDER BY priority DESC, name`
 
@@ -360,7 +362,7 @@ func (r *sqliteRepository) ListClassificationRules(ctx context.Context) ([]class
 
 		err := rows.Scan(
 			&rule.ID, &rule.Name, &rule.Description, &conditionsJSON, &rule.LogicOperator,
-			&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Confidence,
+			&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Shadow, &rule.Confidence,
 			&rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt)
 		if err != nil {
 			return nil, err
@@ -380,7 +382,7 @@ func (r *sqliteRepository) ListClassificationRules(ctx context.Context) ([]class
 // ListActiveClassificationRules lists all active classification rules
 func (r *sqliteRepository) ListActiveClassificationRules(ctx context.Context) ([]classification.ClassificationRule, error) {
 	query := `
-		SELECT id, name, description, conditions, logic_operator, layer, device_type, priority, is_active, confidence, created_by, created_at, updated_at
+		SELECT id, name, description, conditions, logic_operator, layer, device_type, priority, is_active, shadow, confidence, created_by, created_at, updated_at
 		FROM classification_rules
 		WHERE is_active = true
 		ORDER BY priority DESC, name`
@@ -398,7 +400,7 @@ func (r *sqliteRepository) ListActiveClassificationRules(ctx context.Context) ([
 
 		err := rows.Scan(
 			&rule.ID, &rule.Name, &rule.Description, &conditionsJSON, &rule.LogicOperator,
-			&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Confidence,
+			&rule.Layer, &rule.DeviceType, &rule.Priority, &rule.IsActive, &rule.Shadow, &rule.Confidence,
 			&rule.CreatedBy, &rule.CreatedAt, &rule.UpdatedAt)
 		if err != nil {
 			return nil, err
@@ -478,7 +480,7 @@ func (r *sqliteRepository) SaveHierarchyLayer(ctx context.Context, layer classif
 			description = EXCLUDED.description,
 			updated_at = CURRENT_TIMESTAMP`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.writer.ExecContext(ctx, query,
 		layer.ID, layer.Name, layer.Description,
 		layer.CreatedAt, layer.UpdatedAt)
 
@@ -494,7 +496,7 @@ func (r *sqliteRepository) UpdateHierarchyLayer(ctx context.Context, layer class
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?`
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.writer.ExecContext(ctx, query,
 		layer.Name, layer.Description, layer.ID)
 	if err != nil {
 		return err
@@ -514,7 +516,7 @@ func (r *sqliteRepository) UpdateHierarchyLayer(ctx context.Context, layer class
 // DeleteHierarchyLayer deletes a hierarchy layer
 func (r *sqliteRepository) DeleteHierarchyLayer(ctx context.Context, layerID int) error {
 	query := "DELETE FROM hierarchy_layers WHERE id = ?"
-	result, err := r.db.ExecContext(ctx, query, layerID)
+	result, err := r.writer.ExecContext(ctx, query, layerID)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteRepository_ConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topology.db")
+
+	// API とワーカーの2プロセスが同じファイルに書き込む状況を2つのリポジトリで再現する
+	api, err := NewSQliteRepository(Config{Path: path})
+	require.NoError(t, err)
+	defer api.Close()
+	require.NoError(t, api.Migrate())
+
+	worker, err := NewSQliteRepository(Config{Path: path})
+	require.NoError(t, err)
+	defer worker.Close()
+
+	var mode string
+	require.NoError(t, api.db.Get(&mode, "PRAGMA journal_mode"))
+	assert.Equal(t, "wal", mode)
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, 80)
+	for i := 0; i < 40; i++ {
+		for j, repo := range []*sqliteRepository{api, worker} {
+			wg.Add(1)
+			go func(repo *sqliteRepository, id string) {
+				defer wg.Done()
+				devices := make([]topology.Device, 0, 50)
+				for k := 0; k < 50; k++ {
+					devices = append(devices, topology.Device{ID: fmt.Sprintf("%s-%d", id, k), Type: "switch", LastSeen: time.Now()})
+				}
+				if err := repo.BulkAddDevices(ctx, devices); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := repo.GetDevice(ctx, id+"-0"); err != nil {
+					errs <- err
+				}
+			}(repo, fmt.Sprintf("dev-%d-%d", j, i))
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Expected concurrent writes to succeed, got %v", err)
+	}
+	_, page, err := api.GetDevices(ctx, topology.PaginationOptions{Page: 1, PageSize: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 80*50, page.TotalCount)
+}
+
+func TestSQLiteRepository_ReadModifyWriteTransactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topology.db")
+	first, err := NewSQliteRepository(Config{Path: path})
+	require.NoError(t, err)
+	defer first.Close()
+	require.NoError(t, first.Migrate())
+	second, err := NewSQliteRepository(Config{Path: path})
+	require.NoError(t, err)
+	defer second.Close()
+
+	ctx := context.Background()
+	require.NoError(t, first.AddDevice(ctx, topology.Device{ID: "counter", Type: "switch", Hardware: "0", LastSeen: time.Now()}))
+
+	// 読んでから書くトランザクションは開始時に書き込みロックを取るため、別接続の書き込みで失敗しない
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 20; i++ {
+		for _, repo := range []*sqliteRepository{first, second} {
+			wg.Add(1)
+			go func(repo *sqliteRepository) {
+				defer wg.Done()
+				tx, err := repo.writer.BeginTxx(ctx, nil)
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer tx.Rollback()
+				var count int
+				if err := tx.GetContext(ctx, &count, "SELECT CAST(hardware AS INTEGER) FROM devices WHERE id = 'counter'"); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := tx.ExecContext(ctx, "UPDATE devices SET hardware = ? WHERE id = 'counter'", fmt.Sprint(count+1)); err != nil {
+					errs <- err
+					return
+				}
+				if err := tx.Commit(); err != nil {
+					errs <- err
+				}
+			}(repo)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Expected read-modify-write transactions to succeed, got %v", err)
+	}
+	device, err := first.GetDevice(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, "40", device.Hardware)
+}
+
+func TestSQLiteRepository_ReadPoolIsQueryOnly(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: filepath.Join(t.TempDir(), "topology.db")})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	_, err = repo.db.Exec("DELETE FROM devices")
+	assert.Error(t, err, "Expected writes through the read pool to be rejected")
+}
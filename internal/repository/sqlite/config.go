@@ -2,12 +2,33 @@ package sqlite
 
 import (
 	"fmt"
+	"net/url"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const (
+	// DefaultJournalMode lets readers proceed while a write is in progress
+	DefaultJournalMode = "wal"
+	// DefaultBusyTimeout is how long a connection waits for another writer, possibly another
+	// process such as the worker, before failing with "database is locked"
+	DefaultBusyTimeout = 5 * time.Second
+)
+
+// JournalModes lists the supported values of Config.JournalMode
+var JournalModes = []string{"wal", "delete", "truncate", "persist", "memory", "off"}
+
 // Config represents SQLite database configuration
 type Config struct {
 	Path string `yaml:"path"`
+
+	// Writes go through a single connection, so writers of this process queue instead of failing
+	// with "database is locked"; other processes are waited for up to BusyTimeout.
+	JournalMode  string        `yaml:"journal_mode"`   // デフォルト wal（:memory: では無効）
+	BusyTimeout  time.Duration `yaml:"busy_timeout"`   // デフォルト5s
+	MaxReadConns int           `yaml:"max_read_conns"` // 読み取り用の接続数の上限（0 = 無制限）
 }
 
 // Validate checks if the SQLite configuration is valid
@@ -15,6 +36,15 @@ func (c *Config) Validate() error {
 	if c.Path == "" {
 		return fmt.Errorf("sqlite path is required")
 	}
+	if c.JournalMode != "" && !isJournalMode(c.JournalMode) {
+		return fmt.Errorf("unknown journal_mode '%s' (expected one of %s)", c.JournalMode, strings.Join(JournalModes, ", "))
+	}
+	if c.BusyTimeout < 0 {
+		return fmt.Errorf("busy_timeout must not be negative")
+	}
+	if c.MaxReadConns < 0 {
+		return fmt.Errorf("max_read_conns must not be negative")
+	}
 
 	// Special case for in-memory database
 	if c.Path == ":memory:" {
@@ -34,6 +64,15 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func isJournalMode(mode string) bool {
+	for _, m := range JournalModes {
+		if strings.EqualFold(m, mode) {
+			return true
+		}
+	}
+	return false
+}
+
 // DSN returns the SQLite connection string
 func (c *Config) DSN() string {
 	if c.Path == ":memory:" {
@@ -41,3 +80,32 @@ func (c *Config) DSN() string {
 	}
 	return c.Path
 }
+
+// connectionDSN returns the connection string with the pragmas every connection of the pool
+// applies when opened. Write connections start transactions with BEGIN IMMEDIATE, so a
+// transaction waits for the write lock up front instead of failing when it upgrades a read.
+func (c *Config) connectionDSN(write bool) string {
+	params := url.Values{}
+	params.Set("_foreign_keys", "1")
+
+	busyTimeout := c.BusyTimeout
+	if busyTimeout == 0 {
+		busyTimeout = DefaultBusyTimeout
+	}
+	params.Set("_busy_timeout", strconv.FormatInt(busyTimeout.Milliseconds(), 10))
+
+	// ジャーナルモードは書き込み接続が設定する（WAL はファイルに記録され読み取り接続にも効く）
+	if write {
+		if c.Path != ":memory:" {
+			journalMode := c.JournalMode
+			if journalMode == "" {
+				journalMode = DefaultJournalMode
+			}
+			params.Set("_journal_mode", strings.ToUpper(journalMode))
+		}
+		params.Set("_txlock", "immediate")
+	} else {
+		params.Set("_query_only", "1")
+	}
+	return c.DSN() + "?" + params.Encode()
+}
@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// SQLite keeps no row estimates, so the counts are always exact
+
+func (r *sqliteRepository) CountDevices(ctx context.Context, exact bool) (topology.RowCount, error) {
+	return r.countRows(ctx, "devices")
+}
+
+func (r *sqliteRepository) CountLinks(ctx context.Context, exact bool) (topology.RowCount, error) {
+	return r.countRows(ctx, "links")
+}
+
+func (r *sqliteRepository) countRows(ctx context.Context, table string) (topology.RowCount, error) {
+	var count int64
+	if err := r.db.QueryRowxContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil {
+		return topology.RowCount{}, fmt.Errorf("failed to count %s: %w", table, err)
+	}
+	return topology.RowCount{Count: count}, nil
+}
@@ -11,20 +11,40 @@ import (
 
 // Device-related repository methods
 
+// upsertDeviceQuery updates existing devices in place. INSERT OR REPLACE would delete the row
+// first and cascade the delete to the links of the device.
+const upsertDeviceQuery = `
+	INSERT INTO devices (id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), 'discovered'), ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (id) DO UPDATE SET
+		type = excluded.type,
+		hardware = excluded.hardware,
+		layer_id = excluded.layer_id,
+		device_type = excluded.device_type,
+		classified_by = excluded.classified_by,
+		provenance = CASE WHEN devices.provenance = '' THEN excluded.provenance ELSE devices.provenance END,
+		rack = CASE WHEN excluded.rack <> '' THEN excluded.rack ELSE devices.rack END,
+		rack_position = CASE WHEN excluded.rack <> '' THEN excluded.rack_position ELSE devices.rack_position END,
+		rack_units = CASE WHEN excluded.rack <> '' THEN excluded.rack_units ELSE devices.rack_units END,
+		metadata = excluded.metadata,
+		last_seen = excluded.last_seen,
+		created_at = excluded.created_at,
+		updated_at = excluded.updated_at
+`
+
+// AddDevice upserts a device. The provenance of an existing device is kept so it records how the device was first seen,
+// and so is its workflow state, which only TransitionDeviceWorkflow changes. The rack placement is kept unless the
+// device carries a rack, so syncs do not clear it; SetDeviceRack removes a device from its rack.
 func (r *sqliteRepository) AddDevice(ctx context.Context, device topology.Device) error {
-	query := `
-		INSERT OR REPLACE INTO devices (id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
 	metadataJSON, err := json.Marshal(device.Metadata)
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	_, err = r.db.ExecContext(ctx, query,
+	_, err = r.writer.ExecContext(ctx, upsertDeviceQuery,
 		device.ID, device.Type, device.Hardware, device.LayerID,
-		device.DeviceType, device.ClassifiedBy, string(metadataJSON), device.LastSeen,
+		device.DeviceType, device.ClassifiedBy, device.Provenance, device.WorkflowState,
+		device.Rack, device.RackPosition, device.RackUnits, string(metadataJSON), device.LastSeen,
 		device.CreatedAt, device.UpdatedAt,
 	)
 
@@ -41,7 +61,7 @@ func (r *sqliteRepository) UpdateDevice(ctx context.Context, device topology.Dev
 
 func (r *sqliteRepository) GetDevice(ctx context.Context, deviceID string) (*topology.Device, error) {
 	query := `
-		SELECT id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
 		FROM devices 
 		WHERE id = ?
 	`
@@ -51,7 +71,8 @@ func (r *sqliteRepository) GetDevice(ctx context.Context, deviceID string) (*top
 
 	err := r.db.QueryRowxContext(ctx, query, deviceID).Scan(
 		&device.ID, &device.Type, &device.Hardware, &device.LayerID,
-		&device.DeviceType, &device.ClassifiedBy, &metadataJSON, &device.LastSeen,
+		&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+		&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
 		&device.CreatedAt, &device.UpdatedAt,
 	)
 
@@ -78,6 +99,9 @@ func (r *sqliteRepository) GetDevices(ctx context.Context, opts topology.Paginat
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to count devices: %w", err)
 	}
+	if opts.Keyset {
+		return r.getDevicesAfter(ctx, opts, topology.RowCount{Count: int64(totalCount)})
+	}
 
 	// Calculate pagination
 	offset := (opts.Page - 1) * opts.PageSize
@@ -85,7 +109,7 @@ func (r *sqliteRepository) GetDevices(ctx context.Context, opts topology.Paginat
 
 	// Get devices with pagination
 	query := `
-		SELECT id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
 		FROM devices 
 		ORDER BY created_at DESC
 		LIMIT ? OFFSET ?
@@ -104,7 +128,8 @@ func (r *sqliteRepository) GetDevices(ctx context.Context, opts topology.Paginat
 
 		err := rows.Scan(
 			&device.ID, &device.Type, &device.Hardware, &device.LayerID,
-			&device.DeviceType, &device.ClassifiedBy, &metadataJSON, &device.LastSeen,
+			&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+			&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
 			&device.CreatedAt, &device.UpdatedAt,
 		)
 		if err != nil {
@@ -130,9 +155,55 @@ func (r *sqliteRepository) GetDevices(ctx context.Context, opts topology.Paginat
 	return devices, result, nil
 }
 
+// getDevicesAfter returns the page of devices following opts.After in ID order. Unlike OFFSET it
+// seeks the primary key, so late pages cost as much as the first.
+func (r *sqliteRepository) getDevicesAfter(ctx context.Context, opts topology.PaginationOptions, total topology.RowCount) ([]topology.Device, *topology.PaginationResult, error) {
+	query := `
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
+		FROM devices
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?
+	`
+
+	// 1件多く読んで次のページの有無を判定する
+	rows, err := r.db.QueryxContext(ctx, query, opts.After, opts.PageSize+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []topology.Device
+	for rows.Next() {
+		var device topology.Device
+		var metadataJSON string
+
+		err := rows.Scan(
+			&device.ID, &device.Type, &device.Hardware, &device.LayerID,
+			&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+			&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
+			&device.CreatedAt, &device.UpdatedAt,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(metadataJSON), &device.Metadata); err != nil {
+			device.Metadata = make(map[string]string)
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate devices: %w", err)
+	}
+
+	devices, result := topology.KeysetPage(devices, opts, total)
+	return devices, result, nil
+}
+
 func (r *sqliteRepository) SearchDevices(ctx context.Context, query string, limit int) ([]topology.Device, error) {
 	searchQuery := `
-		SELECT id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
 		FROM devices 
 		WHERE id LIKE ? OR type LIKE ? OR hardware LIKE ? OR device_type LIKE ?
 		ORDER BY id
@@ -153,7 +224,8 @@ func (r *sqliteRepository) SearchDevices(ctx context.Context, query string, limi
 
 		err := rows.Scan(
 			&device.ID, &device.Type, &device.Hardware, &device.LayerID,
-			&device.DeviceType, &device.ClassifiedBy, &metadataJSON, &device.LastSeen,
+			&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+			&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
 			&device.CreatedAt, &device.UpdatedAt,
 		)
 		if err != nil {
@@ -172,7 +244,7 @@ func (r *sqliteRepository) SearchDevices(ctx context.Context, query string, limi
 
 func (r *sqliteRepository) RemoveDevice(ctx context.Context, deviceID string) error {
 	query := `DELETE FROM devices WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, query, deviceID)
+	_, err := r.writer.ExecContext(ctx, query, deviceID)
 	if err != nil {
 		return fmt.Errorf("failed to remove device: %w", err)
 	}
@@ -181,7 +253,7 @@ func (r *sqliteRepository) RemoveDevice(ctx context.Context, deviceID string) er
 
 func (r *sqliteRepository) FindDevicesByType(ctx context.Context, deviceType string) ([]topology.Device, error) {
 	query := `
-		SELECT id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
 		FROM devices 
 		WHERE device_type = ?
 		ORDER BY id
@@ -200,7 +272,8 @@ func (r *sqliteRepository) FindDevicesByType(ctx context.Context, deviceType str
 
 		err := rows.Scan(
 			&device.ID, &device.Type, &device.Hardware, &device.LayerID,
-			&device.DeviceType, &device.ClassifiedBy, &metadataJSON, &device.LastSeen,
+			&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+			&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
 			&device.CreatedAt, &device.UpdatedAt,
 		)
 		if err != nil {
@@ -219,7 +292,7 @@ func (r *sqliteRepository) FindDevicesByType(ctx context.Context, deviceType str
 
 func (r *sqliteRepository) FindDevicesByHardware(ctx context.Context, hardware string) ([]topology.Device, error) {
 	query := `
-		SELECT id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at
+		SELECT id, type, hardware, layer_id, device_type, classified_by, provenance, workflow_state, rack, rack_position, rack_units, metadata, last_seen, created_at, updated_at
 		FROM devices 
 		WHERE hardware = ?
 		ORDER BY id
@@ -238,7 +311,8 @@ func (r *sqliteRepository) FindDevicesByHardware(ctx context.Context, hardware s
 
 		err := rows.Scan(
 			&device.ID, &device.Type, &device.Hardware, &device.LayerID,
-			&device.DeviceType, &device.ClassifiedBy, &metadataJSON, &device.LastSeen,
+			&device.DeviceType, &device.ClassifiedBy, &device.Provenance, &device.WorkflowState,
+			&device.Rack, &device.RackPosition, &device.RackUnits, &metadataJSON, &device.LastSeen,
 			&device.CreatedAt, &device.UpdatedAt,
 		)
 		if err != nil {
@@ -260,16 +334,13 @@ func (r *sqliteRepository) BulkAddDevices(ctx context.Context, devices []topolog
 		return nil
 	}
 
-	tx, err := r.db.BeginTxx(ctx, nil)
+	tx, err := r.writer.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PreparexContext(ctx, `
-		INSERT OR REPLACE INTO devices (id, type, hardware, layer_id, device_type, classified_by, metadata, last_seen, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`)
+	stmt, err := tx.PreparexContext(ctx, upsertDeviceQuery)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -283,7 +354,8 @@ func (r *sqliteRepository) BulkAddDevices(ctx context.Context, devices []topolog
 
 		_, err = stmt.ExecContext(ctx,
 			device.ID, device.Type, device.Hardware, device.LayerID,
-			device.DeviceType, device.ClassifiedBy, string(metadataJSON), device.LastSeen,
+			device.DeviceType, device.ClassifiedBy, device.Provenance, device.WorkflowState,
+			device.Rack, device.RackPosition, device.RackUnits, string(metadataJSON), device.LastSeen,
 			device.CreatedAt, device.UpdatedAt,
 		)
 		if err != nil {
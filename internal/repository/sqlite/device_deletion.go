@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// DeleteDevices removes devices and their links in one transaction; dryRun rolls it back
+func (r *sqliteRepository) DeleteDevices(ctx context.Context, deviceIDs []string, dryRun bool) (*topology.DeviceDeletionResult, error) {
+	result := &topology.DeviceDeletionResult{DryRun: dryRun}
+	if len(deviceIDs) == 0 {
+		return result, nil
+	}
+
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 外部キーの CASCADE に頼らず明示的にリンクを削除して件数を数える
+	linkStmt, err := tx.PreparexContext(ctx, `DELETE FROM links WHERE source_id = ? OR target_id = ?`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare link deletion: %w", err)
+	}
+	defer linkStmt.Close()
+
+	deviceStmt, err := tx.PreparexContext(ctx, `DELETE FROM devices WHERE id = ?`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare device deletion: %w", err)
+	}
+	defer deviceStmt.Close()
+
+	// 近隣がまだ報告するリンクから同期がデバイスを再作成しないよう、削除を記録する
+	deletionStmt, err := tx.PreparexContext(ctx, `
+		INSERT INTO device_deletions (device_id, deleted_at) VALUES (?, ?)
+		ON CONFLICT (device_id) DO UPDATE SET
+			deleted_at = EXCLUDED.deleted_at,
+			orphaned_links = '[]',
+			last_reported_at = NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare deletion record: %w", err)
+	}
+	defer deletionStmt.Close()
+	now := time.Now()
+
+	for _, deviceID := range deviceIDs {
+		res, err := linkStmt.ExecContext(ctx, deviceID, deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete links of device %s: %w", deviceID, err)
+		}
+		links, _ := res.RowsAffected()
+		result.Links += int(links)
+
+		res, err = deviceStmt.ExecContext(ctx, deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete device %s: %w", deviceID, err)
+		}
+		devices, _ := res.RowsAffected()
+		result.Devices += int(devices)
+
+		if devices > 0 {
+			if _, err := deletionStmt.ExecContext(ctx, deviceID, now); err != nil {
+				return nil, fmt.Errorf("failed to record deletion of device %s: %w", deviceID, err)
+			}
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit device deletion: %w", err)
+	}
+
+	return result, nil
+}
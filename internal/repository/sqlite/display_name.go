@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Display name override repository methods
+
+// ListDisplayNameOverrides retrieves all display-name overrides
+func (r *sqliteRepository) ListDisplayNameOverrides(ctx context.Context) ([]topology.DisplayNameOverride, error) {
+	query := `
+		SELECT id, device_id, pattern, name, priority, created_by, created_at, updated_at
+		FROM display_name_overrides
+		ORDER BY priority DESC, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list display name overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []topology.DisplayNameOverride
+	for rows.Next() {
+		var override topology.DisplayNameOverride
+		var deviceID, pattern sql.NullString
+
+		err := rows.Scan(
+			&override.ID, &deviceID, &pattern, &override.Name, &override.Priority,
+			&override.CreatedBy, &override.CreatedAt, &override.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan display name override: %w", err)
+		}
+		override.DeviceID = deviceID.String
+		override.Pattern = pattern.String
+
+		overrides = append(overrides, override)
+	}
+
+	return overrides, nil
+}
+
+// SaveDisplayNameOverride creates or updates a display-name override
+func (r *sqliteRepository) SaveDisplayNameOverride(ctx context.Context, override topology.DisplayNameOverride) error {
+	// 作成日時が設定されていない場合は現在時刻を設定
+	if override.CreatedAt.IsZero() {
+		override.CreatedAt = time.Now()
+	}
+	override.UpdatedAt = time.Now()
+
+	// 空文字は NULL として保存（device_id と pattern は排他）
+	var deviceID, pattern interface{}
+	if override.DeviceID != "" {
+		deviceID = override.DeviceID
+	}
+	if override.Pattern != "" {
+		pattern = override.Pattern
+	}
+
+	query := `
+		INSERT INTO display_name_overrides (id, device_id, pattern, name, priority, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			device_id = EXCLUDED.device_id,
+			pattern = EXCLUDED.pattern,
+			name = EXCLUDED.name,
+			priority = EXCLUDED.priority,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.writer.ExecContext(ctx, query,
+		override.ID, deviceID, pattern, override.Name, override.Priority,
+		override.CreatedBy, override.CreatedAt, override.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save display name override: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDisplayNameOverride removes a display-name override
+func (r *sqliteRepository) DeleteDisplayNameOverride(ctx context.Context, overrideID string) error {
+	query := `DELETE FROM display_name_overrides WHERE id = ?`
+
+	_, err := r.writer.ExecContext(ctx, query, overrideID)
+	if err != nil {
+		return fmt.Errorf("failed to delete display name override: %w", err)
+	}
+
+	return nil
+}
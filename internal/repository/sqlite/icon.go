@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+// Icon mapping repository methods
+
+const iconMappingColumns = `id, device_type, vendor, icon, svg, updated_by, updated_at`
+
+// ListIconMappings retrieves all icon mappings
+func (r *sqliteRepository) ListIconMappings(ctx context.Context) ([]visualization.IconMapping, error) {
+	query := `SELECT ` + iconMappingColumns + ` FROM icon_mappings ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list icon mappings: %w", err)
+	}
+	defer rows.Close()
+
+	var mappings []visualization.IconMapping
+	for rows.Next() {
+		var m visualization.IconMapping
+		if err := rows.Scan(&m.ID, &m.DeviceType, &m.Vendor, &m.Icon, &m.SVG, &m.UpdatedBy, &m.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan icon mapping: %w", err)
+		}
+		mappings = append(mappings, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate icon mappings: %w", err)
+	}
+
+	return mappings, nil
+}
+
+// SaveIconMapping creates or replaces an icon mapping
+func (r *sqliteRepository) SaveIconMapping(ctx context.Context, mapping visualization.IconMapping) error {
+	if mapping.UpdatedAt.IsZero() {
+		mapping.UpdatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO icon_mappings (` + iconMappingColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			device_type = EXCLUDED.device_type,
+			vendor = EXCLUDED.vendor,
+			icon = EXCLUDED.icon,
+			svg = EXCLUDED.svg,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.writer.ExecContext(ctx, query,
+		mapping.ID, mapping.DeviceType, mapping.Vendor, mapping.Icon, mapping.SVG, mapping.UpdatedBy, mapping.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save icon mapping: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteIconMapping removes an icon mapping
+func (r *sqliteRepository) DeleteIconMapping(ctx context.Context, id string) (bool, error) {
+	res, err := r.writer.ExecContext(ctx, `DELETE FROM icon_mappings WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete icon mapping: %w", err)
+	}
+
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -22,7 +22,7 @@ func (r *sqliteRepository) AddLink(ctx context.Context, link topology.Link) erro
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	_, err = r.db.ExecContext(ctx, query,
+	_, err = r.writer.ExecContext(ctx, query,
 		link.ID, link.SourceID, link.TargetID, link.SourcePort, link.TargetPort,
 		link.Weight, string(metadataJSON), link.LastSeen, link.CreatedAt, link.UpdatedAt,
 	)
@@ -70,7 +70,7 @@ func (r *sqliteRepository) GetLink(ctx context.Context, linkID string) (*topolog
 
 func (r *sqliteRepository) RemoveLink(ctx context.Context, linkID string) error {
 	query := `DELETE FROM links WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, query, linkID)
+	_, err := r.writer.ExecContext(ctx, query, linkID)
 	if err != nil {
 		return fmt.Errorf("failed to remove link: %w", err)
 	}
@@ -114,6 +114,45 @@ func (r *sqliteRepository) GetDeviceLinks(ctx context.Context, deviceID string)
 	return links, nil
 }
 
+// ListLinks returns every link ordered by ID
+func (r *sqliteRepository) ListLinks(ctx context.Context) ([]topology.Link, error) {
+	query := `
+		SELECT id, source_id, target_id, source_port, target_port, weight, metadata, last_seen, created_at, updated_at
+		FROM links
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryxContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []topology.Link{}
+	for rows.Next() {
+		var link topology.Link
+		var metadataJSON string
+
+		err := rows.Scan(
+			&link.ID, &link.SourceID, &link.TargetID, &link.SourcePort, &link.TargetPort,
+			&link.Weight, &metadataJSON, &link.LastSeen, &link.CreatedAt, &link.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(metadataJSON), &link.Metadata); err != nil {
+			link.Metadata = make(map[string]string)
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate links: %w", err)
+	}
+
+	return links, nil
+}
+
 func (r *sqliteRepository) FindLinksByPort(ctx context.Context, deviceID, port string) ([]topology.Link, error) {
 	query := `
 		SELECT id, source_id, target_id, source_port, target_port, weight, metadata, last_seen, created_at, updated_at
@@ -156,7 +195,7 @@ func (r *sqliteRepository) BulkAddLinks(ctx context.Context, links []topology.Li
 		return nil
 	}
 
-	tx, err := r.db.BeginTxx(ctx, nil)
+	tx, err := r.writer.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -186,5 +225,10 @@ func (r *sqliteRepository) BulkAddLinks(ctx context.Context, links []topology.Li
 		}
 	}
 
+	// 観測履歴を記録（保持期間・圧縮はコンパクションジョブで管理）
+	if err := recordLinkHistory(ctx, tx, links); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
\ No newline at end of file
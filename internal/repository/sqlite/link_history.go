@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Link history repository methods
+// SQLite はパーティションをサポートしないため、単一テーブルに対して圧縮と削除を行う
+
+// recordLinkHistory appends link observations to link_history within the given transaction
+func recordLinkHistory(ctx context.Context, tx *sqlx.Tx, links []topology.Link) error {
+	stmt, err := tx.PreparexContext(ctx, `
+		INSERT INTO link_history (link_id, source_id, target_id, source_port, target_port, weight, observed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare link history statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, link := range links {
+		observedAt := link.LastSeen
+		if observedAt.IsZero() {
+			observedAt = time.Now()
+		}
+		if _, err := stmt.ExecContext(ctx,
+			link.ID, link.SourceID, link.TargetID, link.SourcePort, link.TargetPort, link.Weight, observedAt.UTC(),
+		); err != nil {
+			return fmt.Errorf("failed to record history for link %s: %w", link.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// CompactLinkHistory rolls raw observations up into daily summaries and removes expired rows
+func (r *sqliteRepository) CompactLinkHistory(ctx context.Context, retention topology.LinkHistoryRetention) (*topology.LinkHistoryCompactionResult, error) {
+	now := time.Now().UTC()
+	result := &topology.LinkHistoryCompactionResult{
+		DroppedPartitions: []string{},
+		CreatedPartitions: []string{},
+	}
+
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if retention.RawRetention > 0 {
+		cutoff := now.Add(-retention.RawRetention)
+
+		// INSERT ... SELECT で ON CONFLICT を使う場合、構文の曖昧さ回避のため WHERE 句が必須
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO link_history_daily (link_id, day, source_id, target_id, source_port, target_port, observations, first_seen, last_seen)
+			SELECT link_id, date(observed_at),
+				MAX(source_id), MAX(target_id), MAX(source_port), MAX(target_port),
+				COUNT(*), MIN(observed_at), MAX(observed_at)
+			FROM link_history
+			WHERE observed_at < ?
+			GROUP BY link_id, date(observed_at)
+			ON CONFLICT (link_id, day) DO UPDATE SET
+				observations = link_history_daily.observations + excluded.observations,
+				first_seen = MIN(link_history_daily.first_seen, excluded.first_seen),
+				last_seen = MAX(link_history_daily.last_seen, excluded.last_seen)
+		`, cutoff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to roll up link history: %w", err)
+		}
+		result.CompactedRows, _ = res.RowsAffected()
+
+		res, err = tx.ExecContext(ctx, `DELETE FROM link_history WHERE observed_at < ?`, cutoff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete compacted link history: %w", err)
+		}
+		result.DeletedRawRows, _ = res.RowsAffected()
+	}
+
+	if retention.SummaryRetention > 0 {
+		cutoff := now.Add(-retention.SummaryRetention)
+		res, err := tx.ExecContext(ctx, `DELETE FROM link_history_daily WHERE day < ?`, cutoff.Format("2006-01-02"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete expired link history summaries: %w", err)
+		}
+		result.DeletedSummaries, _ = res.RowsAffected()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit link history compaction: %w", err)
+	}
+
+	// VACUUM はデータベース全体を書き直し、その間の同期と API の書き込みを止めるため実行しない。
+	// 削除した領域は以降の書き込みで再利用される
+	return result, nil
+}
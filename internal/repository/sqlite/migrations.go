@@ -20,6 +20,11 @@ CREATE TABLE IF NOT EXISTS devices (
     layer_id INTEGER,
     device_type TEXT,
     classified_by TEXT, -- "user:username", "rule:ruleName", "system:auto"
+    provenance TEXT NOT NULL DEFAULT '', -- how the device was first seen
+    workflow_state TEXT NOT NULL DEFAULT 'discovered', -- turn-up workflow state
+    rack TEXT NOT NULL DEFAULT '', -- rack the device is mounted in
+    rack_position INTEGER, -- lowest rack unit occupied (1-based)
+    rack_units INTEGER NOT NULL DEFAULT 0, -- height in rack units (0 = 1U)
     
     -- Metadata and timestamps
     metadata TEXT, -- JSON data stored as TEXT in SQLite
@@ -29,6 +34,7 @@ CREATE TABLE IF NOT EXISTS devices (
     
     -- Constraints
     CHECK (classified_by IS NULL OR 
+           classified_by = '' OR 
            classified_by LIKE 'user:%' OR 
            classified_by LIKE 'rule:%' OR 
            classified_by = 'system:auto')
@@ -78,6 +84,7 @@ CREATE TABLE IF NOT EXISTS classification_rules (
     device_type TEXT NOT NULL,
     priority INTEGER DEFAULT 100,
     is_active BOOLEAN DEFAULT true,
+    shadow BOOLEAN NOT NULL DEFAULT false,
     confidence REAL,
     created_by TEXT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -110,6 +117,283 @@ CREATE TABLE IF NOT EXISTS classification_suggestions (
     FOREIGN KEY (rule_id) REFERENCES classification_rules(id) ON DELETE CASCADE
 );`
 
+const createLinkHistoryTables = `
+CREATE TABLE IF NOT EXISTS link_history (
+    link_id TEXT NOT NULL,
+    source_id TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    source_port TEXT,
+    target_port TEXT,
+    weight REAL DEFAULT 1.0,
+    observed_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS link_history_daily (
+    link_id TEXT NOT NULL,
+    day TEXT NOT NULL, -- YYYY-MM-DD
+    source_id TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    source_port TEXT,
+    target_port TEXT,
+    observations INTEGER NOT NULL DEFAULT 0,
+    first_seen TIMESTAMP NOT NULL,
+    last_seen TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (link_id, day)
+);`
+
+const createPlannedDevicesTable = `
+CREATE TABLE IF NOT EXISTS planned_devices (
+    id TEXT PRIMARY KEY,
+    type TEXT,
+    hardware TEXT,
+    layer_id INTEGER,
+    device_type TEXT,
+    expected_uplinks TEXT NOT NULL DEFAULT '[]', -- JSON array stored as TEXT
+    status TEXT NOT NULL DEFAULT 'planned',
+    mismatches TEXT NOT NULL DEFAULT '[]', -- JSON array stored as TEXT
+    created_by TEXT NOT NULL DEFAULT 'system',
+    discovered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (status IN ('planned', 'discovered', 'mismatch'))
+);`
+
+const createClassificationHistoryTable = `
+CREATE TABLE IF NOT EXISTS classification_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    device_id TEXT NOT NULL,
+    previous_layer_id INTEGER,
+    previous_device_type TEXT,
+    previous_classified_by TEXT,
+    layer_id INTEGER,
+    device_type TEXT,
+    classified_by TEXT,
+    source TEXT NOT NULL, -- 'user', 'rule'
+    reason TEXT,
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (source IN ('user', 'rule'))
+);`
+
+const createDisplayNameOverridesTable = `
+CREATE TABLE IF NOT EXISTS display_name_overrides (
+    id TEXT PRIMARY KEY,
+    device_id TEXT UNIQUE,
+    pattern TEXT,
+    name TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK ((device_id IS NULL) <> (pattern IS NULL))
+);`
+
+const createLayoutCacheTables = `
+CREATE TABLE IF NOT EXISTS view_requests (
+    root_device TEXT NOT NULL,
+    depth INTEGER NOT NULL,
+    options TEXT NOT NULL, -- GroupingOptions JSON
+    request_count INTEGER NOT NULL DEFAULT 0,
+    last_requested TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (root_device, depth, options)
+);
+
+CREATE TABLE IF NOT EXISTS layout_cache (
+    root_device TEXT NOT NULL,
+    depth INTEGER NOT NULL,
+    options TEXT NOT NULL, -- GroupingOptions JSON
+    node_set_hash TEXT NOT NULL,
+    layout TEXT NOT NULL, -- JSON stored as TEXT
+    computed_at TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (root_device, depth, options)
+);`
+
+const createStartingViewsTable = `
+CREATE TABLE IF NOT EXISTS starting_views (
+    role TEXT PRIMARY KEY,
+    root_device TEXT NOT NULL,
+    depth INTEGER NOT NULL DEFAULT 3,
+    grouping TEXT, -- GroupingOptions JSON
+    description TEXT NOT NULL DEFAULT '',
+    updated_by TEXT NOT NULL DEFAULT 'system',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createHardwareCatalogTable = `
+CREATE TABLE IF NOT EXISTS hardware_catalog (
+    id TEXT PRIMARY KEY,
+    layer_id INTEGER NOT NULL,
+    model TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (layer_id) REFERENCES hierarchy_layers(id) ON DELETE CASCADE,
+    UNIQUE (layer_id, model)
+);`
+
+const createHardwareLifecycleTable = `
+CREATE TABLE IF NOT EXISTS hardware_lifecycle (
+    id TEXT PRIMARY KEY,
+    model TEXT NOT NULL UNIQUE,
+    end_of_sale DATE,
+    end_of_life DATE,
+    description TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createShadowClassificationsTable = `
+CREATE TABLE IF NOT EXISTS shadow_classifications (
+    device_id TEXT PRIMARY KEY,
+    rule_id TEXT NOT NULL,
+    rule_name TEXT NOT NULL,
+    layer INTEGER NOT NULL,
+    device_type TEXT NOT NULL DEFAULT '',
+    evaluated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createFabricsTable = `
+CREATE TABLE IF NOT EXISTS fabrics (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    logic_operator TEXT NOT NULL DEFAULT 'AND',
+    conditions TEXT NOT NULL DEFAULT '[]', -- FabricCondition JSON
+    priority INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createCircuitsTable = `
+CREATE TABLE IF NOT EXISTS circuits (
+    id TEXT PRIMARY KEY,
+    provider TEXT NOT NULL DEFAULT '',
+    a_device TEXT NOT NULL,
+    a_port TEXT NOT NULL DEFAULT '',
+    z_device TEXT NOT NULL,
+    z_port TEXT NOT NULL DEFAULT '',
+    link_id TEXT, -- リンクは再収集で作り直されるため外部キーにしない
+    description TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createPortReservationsTable = `
+CREATE TABLE IF NOT EXISTS port_reservations (
+    id TEXT PRIMARY KEY,
+    device_id TEXT NOT NULL,
+    port TEXT NOT NULL,
+    peer_device TEXT NOT NULL DEFAULT '',
+    peer_port TEXT NOT NULL DEFAULT '',
+    ticket TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    reserved_by TEXT NOT NULL DEFAULT 'system',
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (device_id) REFERENCES devices(id) ON DELETE CASCADE,
+    UNIQUE (device_id, port)
+);`
+
+const createManagementReachabilityTable = `
+CREATE TABLE IF NOT EXISTS management_reachability (
+    device_id TEXT PRIMARY KEY,
+    address TEXT NOT NULL,
+    ssh BOOLEAN NOT NULL DEFAULT FALSE,
+    netconf BOOLEAN NOT NULL DEFAULT FALSE,
+    error TEXT NOT NULL DEFAULT '',
+    checked_at TIMESTAMP NOT NULL,
+    last_reachable_at TIMESTAMP,
+
+    FOREIGN KEY (device_id) REFERENCES devices(id) ON DELETE CASCADE
+);`
+
+const createMaintenanceWindowsTable = `
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id TEXT PRIMARY KEY,
+    ticket TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    devices TEXT NOT NULL DEFAULT '[]', -- デバイスID JSON
+    links TEXT NOT NULL DEFAULT '[]',   -- リンクID JSON
+    created_by TEXT NOT NULL DEFAULT 'system',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createSyncCycleStatsTable = `
+CREATE TABLE IF NOT EXISTS sync_cycle_stats (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP NOT NULL,
+    devices INTEGER NOT NULL DEFAULT 0,
+    links INTEGER NOT NULL DEFAULT 0,
+    devices_appeared INTEGER NOT NULL DEFAULT 0,
+    devices_disappeared INTEGER NOT NULL DEFAULT 0,
+    links_added INTEGER NOT NULL DEFAULT 0,
+    links_removed INTEGER NOT NULL DEFAULT 0,
+    errors INTEGER NOT NULL DEFAULT 0,
+    baseline BOOLEAN NOT NULL DEFAULT 0
+);`
+
+const createDeviceDeletionsTable = `
+CREATE TABLE IF NOT EXISTS device_deletions (
+    device_id TEXT PRIMARY KEY, -- 削除済みのため外部キーにしない
+    deleted_at TIMESTAMP NOT NULL,
+    orphaned_links TEXT NOT NULL DEFAULT '[]', -- リンクID JSON
+    last_reported_at TIMESTAMP
+);`
+
+const createIdentityDecisionsTable = `
+CREATE TABLE IF NOT EXISTS identity_decisions (
+    key TEXT PRIMARY KEY,
+    devices TEXT NOT NULL DEFAULT '[]', -- デバイスID JSON
+    canonical_id TEXT NOT NULL DEFAULT '',
+    note TEXT NOT NULL DEFAULT '',
+    decided_by TEXT NOT NULL DEFAULT 'system',
+    decided_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createStyleRulesTable = `
+CREATE TABLE IF NOT EXISTS style_rules (
+    id TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    target TEXT NOT NULL, -- node / edge
+    logic_operator TEXT NOT NULL DEFAULT 'AND',
+    conditions TEXT NOT NULL DEFAULT '[]', -- JSON
+    priority INTEGER NOT NULL DEFAULT 0,
+    style TEXT NOT NULL DEFAULT '{}', -- target に応じた node_style / edge_style の JSON
+    updated_by TEXT NOT NULL DEFAULT 'system',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createIconMappingsTable = `
+CREATE TABLE IF NOT EXISTS icon_mappings (
+    id TEXT PRIMARY KEY,
+    device_type TEXT NOT NULL DEFAULT '',
+    vendor TEXT NOT NULL DEFAULT '',
+    icon TEXT NOT NULL,
+    svg TEXT NOT NULL DEFAULT '',
+    updated_by TEXT NOT NULL DEFAULT 'system',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
+const createDeviceWorkflowTransitionsTable = `
+CREATE TABLE IF NOT EXISTS device_workflow_transitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    device_id TEXT NOT NULL,
+    from_state TEXT NOT NULL,
+    to_state TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    changed_by TEXT NOT NULL DEFAULT 'system',
+    changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);`
+
 const createIndexes = `
 -- Device indexes for better performance
 CREATE INDEX IF NOT EXISTS idx_devices_type ON devices(type);
@@ -118,6 +402,9 @@ CREATE INDEX IF NOT EXISTS idx_devices_layer_id ON devices(layer_id);
 CREATE INDEX IF NOT EXISTS idx_devices_device_type ON devices(device_type);
 CREATE INDEX IF NOT EXISTS idx_devices_classified_by ON devices(classified_by);
 CREATE INDEX IF NOT EXISTS idx_devices_last_seen ON devices(last_seen);
+CREATE INDEX IF NOT EXISTS idx_devices_provenance ON devices(provenance);
+CREATE INDEX IF NOT EXISTS idx_devices_workflow_state ON devices(workflow_state);
+CREATE INDEX IF NOT EXISTS idx_devices_rack ON devices(rack);
 
 -- Link indexes
 CREATE INDEX IF NOT EXISTS idx_links_source_id ON links(source_id);
@@ -126,6 +413,23 @@ CREATE INDEX IF NOT EXISTS idx_links_source_port ON links(source_id, source_port
 CREATE INDEX IF NOT EXISTS idx_links_target_port ON links(target_id, target_port);
 CREATE INDEX IF NOT EXISTS idx_links_last_seen ON links(last_seen);
 
+-- Link history indexes
+CREATE INDEX IF NOT EXISTS idx_link_history_link_observed ON link_history(link_id, observed_at);
+CREATE INDEX IF NOT EXISTS idx_link_history_observed_at ON link_history(observed_at);
+CREATE INDEX IF NOT EXISTS idx_link_history_daily_day ON link_history_daily(day);
+
+-- Planned device indexes
+CREATE INDEX IF NOT EXISTS idx_planned_devices_status ON planned_devices(status);
+
+-- View popularity indexes
+CREATE INDEX IF NOT EXISTS idx_view_requests_last_requested ON view_requests(last_requested);
+
+-- Classification history indexes
+CREATE INDEX IF NOT EXISTS idx_classification_history_device ON classification_history(device_id, changed_at);
+
+-- Device workflow indexes
+CREATE INDEX IF NOT EXISTS idx_device_workflow_transitions_device ON device_workflow_transitions(device_id, changed_at);
+
 -- Classification rule indexes
 CREATE INDEX IF NOT EXISTS idx_classification_rules_active ON classification_rules(is_active);
 CREATE INDEX IF NOT EXISTS idx_classification_rules_priority ON classification_rules(priority);
@@ -138,7 +442,13 @@ CREATE INDEX IF NOT EXISTS idx_classification_suggestions_rule_id ON classificat
 CREATE INDEX IF NOT EXISTS idx_classification_suggestions_confidence ON classification_suggestions(confidence);
 
 -- Hierarchy layer indexes
-CREATE INDEX IF NOT EXISTS idx_hierarchy_layers_order_index ON hierarchy_layers(order_index);`
+CREATE INDEX IF NOT EXISTS idx_hierarchy_layers_order_index ON hierarchy_layers(order_index);
+
+-- Shadow classification indexes
+CREATE INDEX IF NOT EXISTS idx_shadow_classifications_rule_id ON shadow_classifications(rule_id);
+
+-- Circuit indexes
+CREATE INDEX IF NOT EXISTS idx_circuits_link_id ON circuits(link_id);`
 
 // insertDefaultHierarchyLayers inserts default hierarchy layers
 const insertDefaultHierarchyLayers = `
@@ -157,11 +467,37 @@ func RunMigrations(db *sqlx.DB) error {
 		createHierarchyLayersTable,
 		createClassificationRulesTable,
 		createClassificationSuggestionsTable,
+		createLinkHistoryTables,
+		createPlannedDevicesTable,
+		createClassificationHistoryTable,
+		createDisplayNameOverridesTable,
+		createLayoutCacheTables,
+		createStartingViewsTable,
+		createHardwareCatalogTable,
+		createHardwareLifecycleTable,
+		createShadowClassificationsTable,
+		createFabricsTable,
+		createCircuitsTable,
+		createPortReservationsTable,
+		createManagementReachabilityTable,
+		createMaintenanceWindowsTable,
+		createSyncCycleStatsTable,
+		createIdentityDecisionsTable,
+		createDeviceDeletionsTable,
+		createStyleRulesTable,
+		createIconMappingsTable,
+		createDeviceWorkflowTransitionsTable,
 		createIndexes,
 		insertDefaultHierarchyLayers,
 	}
 
 	for i, migration := range migrations {
+		// 既存DBのテーブルには後から追加した列がないため、インデックス作成前に補う
+		if migration == createIndexes {
+			if err := addMissingColumns(db); err != nil {
+				return err
+			}
+		}
 		if _, err := db.Exec(migration); err != nil {
 			return fmt.Errorf("failed to execute migration %d: %w", i+1, err)
 		}
@@ -169,3 +505,32 @@ func RunMigrations(db *sqlx.DB) error {
 
 	return nil
 }
+
+// addedColumns lists columns added after a table was first created, with their definitions
+var addedColumns = []struct {
+	table, column, definition string
+}{
+	{"devices", "provenance", "TEXT NOT NULL DEFAULT ''"},
+	{"devices", "workflow_state", "TEXT NOT NULL DEFAULT 'active'"}, // 既存のデバイスは稼働中とみなす（新規は upsert で discovered）
+	{"devices", "rack", "TEXT NOT NULL DEFAULT ''"},
+	{"devices", "rack_position", "INTEGER"},
+	{"devices", "rack_units", "INTEGER NOT NULL DEFAULT 0"},
+	{"classification_rules", "shadow", "BOOLEAN NOT NULL DEFAULT false"},
+}
+
+// addMissingColumns adds columns that CREATE TABLE IF NOT EXISTS does not add to existing tables
+func addMissingColumns(db *sqlx.DB) error {
+	for _, c := range addedColumns {
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, c.table, c.column).Scan(&count); err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", c.table, err)
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
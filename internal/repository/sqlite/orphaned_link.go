@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ListDeletedDevices returns the devices deleted at or after since, oldest deletion first
+func (r *sqliteRepository) ListDeletedDevices(ctx context.Context, since time.Time) ([]topology.DeletedDevice, error) {
+	// 時刻は文字列として保存されタイムゾーンが混在しうるため、読み込んでから絞り込む
+	query := `SELECT device_id, deleted_at, orphaned_links, last_reported_at FROM device_deletions`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted devices: %w", err)
+	}
+	defer rows.Close()
+
+	devices := []topology.DeletedDevice{}
+	for rows.Next() {
+		var device topology.DeletedDevice
+		var linksJSON string
+		var lastReportedAt sql.NullTime
+		if err := rows.Scan(&device.DeviceID, &device.DeletedAt, &linksJSON, &lastReportedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted device: %w", err)
+		}
+		if device.DeletedAt.Before(since) {
+			continue
+		}
+		if err := json.Unmarshal([]byte(linksJSON), &device.OrphanedLinks); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal orphaned links: %w", err)
+		}
+		if lastReportedAt.Valid {
+			device.LastReportedAt = &lastReportedAt.Time
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate deleted devices: %w", err)
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		if !devices[i].DeletedAt.Equal(devices[j].DeletedAt) {
+			return devices[i].DeletedAt.Before(devices[j].DeletedAt)
+		}
+		return devices[i].DeviceID < devices[j].DeviceID
+	})
+	return devices, nil
+}
+
+// RecordOrphanedLinks stores the links reported at for a deleted device by the latest sync
+func (r *sqliteRepository) RecordOrphanedLinks(ctx context.Context, deviceID string, linkIDs []string, at time.Time) error {
+	if linkIDs == nil {
+		linkIDs = []string{}
+	}
+	linksJSON, err := json.Marshal(linkIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal orphaned links: %w", err)
+	}
+
+	query := `UPDATE device_deletions SET orphaned_links = ?, last_reported_at = ? WHERE device_id = ?`
+	if _, err := r.writer.ExecContext(ctx, query, string(linksJSON), at, deviceID); err != nil {
+		return fmt.Errorf("failed to record orphaned links: %w", err)
+	}
+	return nil
+}
+
+// PurgeDeletedDevices forgets the devices deleted before deletedBefore
+func (r *sqliteRepository) PurgeDeletedDevices(ctx context.Context, deletedBefore time.Time) (int, error) {
+	devices, err := r.ListDeletedDevices(ctx, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	var expired []string
+	for _, device := range devices {
+		if device.DeletedAt.Before(deletedBefore) {
+			expired = append(expired, device.DeviceID)
+		}
+	}
+
+	return r.execEach(ctx, `DELETE FROM device_deletions WHERE device_id = ?`, expired)
+}
@@ -0,0 +1,118 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Port reservation repository methods
+
+const portReservationColumns = `id, device_id, port, peer_device, peer_port, ticket, description, reserved_by, expires_at, created_at`
+
+// ListPortReservations retrieves the reservations of a device, or of all devices when deviceID is empty
+func (r *sqliteRepository) ListPortReservations(ctx context.Context, deviceID string) ([]topology.PortReservation, error) {
+	query := `
+		SELECT ` + portReservationColumns + ` FROM port_reservations
+		WHERE ?1 = '' OR device_id = ?1
+		ORDER BY device_id, port
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list port reservations: %w", err)
+	}
+	defer rows.Close()
+
+	reservations := []topology.PortReservation{}
+	for rows.Next() {
+		reservation, err := scanPortReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, *reservation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate port reservations: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// GetPortReservation retrieves a reservation by ID
+func (r *sqliteRepository) GetPortReservation(ctx context.Context, reservationID string) (*topology.PortReservation, error) {
+	query := `SELECT ` + portReservationColumns + ` FROM port_reservations WHERE id = ?`
+
+	reservation, err := scanPortReservation(r.db.QueryRowContext(ctx, query, reservationID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// SavePortReservation creates or updates a reservation
+func (r *sqliteRepository) SavePortReservation(ctx context.Context, reservation topology.PortReservation) error {
+	if reservation.CreatedAt.IsZero() {
+		reservation.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO port_reservations (` + portReservationColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			peer_device = EXCLUDED.peer_device,
+			peer_port = EXCLUDED.peer_port,
+			ticket = EXCLUDED.ticket,
+			description = EXCLUDED.description,
+			expires_at = EXCLUDED.expires_at
+	`
+
+	_, err := r.writer.ExecContext(ctx, query,
+		reservation.ID, reservation.DeviceID, reservation.Port, reservation.PeerDevice, reservation.PeerPort,
+		reservation.Ticket, reservation.Description, reservation.ReservedBy, reservation.ExpiresAt, reservation.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save port reservation: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePortReservation removes a reservation
+func (r *sqliteRepository) DeletePortReservation(ctx context.Context, reservationID string) error {
+	query := `DELETE FROM port_reservations WHERE id = ?`
+
+	_, err := r.writer.ExecContext(ctx, query, reservationID)
+	if err != nil {
+		return fmt.Errorf("failed to delete port reservation: %w", err)
+	}
+
+	return nil
+}
+
+type portReservationScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPortReservation(row portReservationScanner) (*topology.PortReservation, error) {
+	var reservation topology.PortReservation
+
+	err := row.Scan(&reservation.ID, &reservation.DeviceID, &reservation.Port, &reservation.PeerDevice, &reservation.PeerPort,
+		&reservation.Ticket, &reservation.Description, &reservation.ReservedBy, &reservation.ExpiresAt, &reservation.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan port reservation: %w", err)
+	}
+
+	return &reservation, nil
+}
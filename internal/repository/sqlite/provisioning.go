@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Planned device repository methods
+
+const plannedDeviceColumns = `id, type, hardware, layer_id, device_type, expected_uplinks, status, mismatches, created_by, discovered_at, created_at, updated_at`
+
+type plannedDeviceScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPlannedDevice(scanner plannedDeviceScanner) (*topology.PlannedDevice, error) {
+	var device topology.PlannedDevice
+	var deviceType, hardware, classifiedType sql.NullString
+	var uplinksJSON, mismatchesJSON string
+	var discoveredAt sql.NullTime
+
+	err := scanner.Scan(
+		&device.ID, &deviceType, &hardware, &device.Template.LayerID, &classifiedType,
+		&uplinksJSON, &device.Status, &mismatchesJSON, &device.CreatedBy, &discoveredAt,
+		&device.CreatedAt, &device.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	device.Template.Type = deviceType.String
+	device.Template.Hardware = hardware.String
+	device.Template.DeviceType = classifiedType.String
+	if discoveredAt.Valid {
+		device.DiscoveredAt = &discoveredAt.Time
+	}
+
+	if err := json.Unmarshal([]byte(uplinksJSON), &device.Template.ExpectedUplinks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal expected uplinks: %w", err)
+	}
+	if err := json.Unmarshal([]byte(mismatchesJSON), &device.Mismatches); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mismatches: %w", err)
+	}
+
+	return &device, nil
+}
+
+// GetPlannedDevice retrieves a planned device by ID
+func (r *sqliteRepository) GetPlannedDevice(ctx context.Context, deviceID string) (*topology.PlannedDevice, error) {
+	query := `SELECT ` + plannedDeviceColumns + ` FROM planned_devices WHERE id = ?`
+
+	device, err := scanPlannedDevice(r.db.QueryRowContext(ctx, query, deviceID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get planned device: %w", err)
+	}
+
+	return device, nil
+}
+
+// ListPlannedDevices retrieves all planned devices
+func (r *sqliteRepository) ListPlannedDevices(ctx context.Context) ([]topology.PlannedDevice, error) {
+	query := `SELECT ` + plannedDeviceColumns + ` FROM planned_devices ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list planned devices: %w", err)
+	}
+	defer rows.Close()
+
+	var devices []topology.PlannedDevice
+	for rows.Next() {
+		device, err := scanPlannedDevice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan planned device: %w", err)
+		}
+		devices = append(devices, *device)
+	}
+
+	return devices, nil
+}
+
+// SavePlannedDevice creates or updates a planned device
+func (r *sqliteRepository) SavePlannedDevice(ctx context.Context, device topology.PlannedDevice) error {
+	if device.CreatedAt.IsZero() {
+		device.CreatedAt = time.Now()
+	}
+	device.UpdatedAt = time.Now()
+
+	uplinks := device.Template.ExpectedUplinks
+	if uplinks == nil {
+		uplinks = []string{}
+	}
+	uplinksJSON, err := json.Marshal(uplinks)
+	if err != nil {
+		return fmt.Errorf("failed to marshal expected uplinks: %w", err)
+	}
+
+	mismatches := device.Mismatches
+	if mismatches == nil {
+		mismatches = []topology.TemplateMismatch{}
+	}
+	mismatchesJSON, err := json.Marshal(mismatches)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mismatches: %w", err)
+	}
+
+	query := `
+		INSERT INTO planned_devices (` + plannedDeviceColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			type = excluded.type,
+			hardware = excluded.hardware,
+			layer_id = excluded.layer_id,
+			device_type = excluded.device_type,
+			expected_uplinks = excluded.expected_uplinks,
+			status = excluded.status,
+			mismatches = excluded.mismatches,
+			discovered_at = excluded.discovered_at,
+			updated_at = excluded.updated_at`
+
+	_, err = r.writer.ExecContext(ctx, query,
+		device.ID, device.Template.Type, device.Template.Hardware, device.Template.LayerID, device.Template.DeviceType,
+		string(uplinksJSON), device.Status, string(mismatchesJSON), device.CreatedBy, device.DiscoveredAt,
+		device.CreatedAt, device.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save planned device: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePlannedDevice removes a planned device
+func (r *sqliteRepository) DeletePlannedDevice(ctx context.Context, deviceID string) error {
+	_, err := r.writer.ExecContext(ctx, `DELETE FROM planned_devices WHERE id = ?`, deviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete planned device: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Rack placement repository methods
+
+// SetDeviceRack replaces the rack, position and height of a device
+func (r *sqliteRepository) SetDeviceRack(ctx context.Context, placement topology.RackPlacement) (bool, error) {
+	result, err := r.writer.ExecContext(ctx, `
+		UPDATE devices SET rack = ?, rack_position = ?, rack_units = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, placement.Rack, placement.Position, placement.Units, placement.DeviceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to set device rack: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to set device rack: %w", err)
+	}
+	return updated > 0, nil
+}
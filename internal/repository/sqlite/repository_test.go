@@ -3,10 +3,13 @@ package sqlite
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/servak/topology-manager/internal/domain/classification"
 	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -99,9 +102,16 @@ func TestSQLiteRepository(t *testing.T) {
 		}
 
 		// Search for Arista devices
+		// test-device-01 も Arista なので、このサブテストで追加したデバイスだけを数える
 		results, err := repo.SearchDevices(ctx, "Arista", 10)
 		require.NoError(t, err)
-		assert.Len(t, results, 2)
+		var found []string
+		for _, result := range results {
+			if strings.HasPrefix(result.ID, "search-test-") {
+				found = append(found, result.ID)
+			}
+		}
+		assert.ElementsMatch(t, []string{"search-test-01", "search-test-03"}, found)
 
 		// Search for specific device ID
 		results, err = repo.SearchDevices(ctx, "search-test-02", 10)
@@ -303,6 +313,402 @@ func TestSQLiteRepository(t *testing.T) {
 		assert.Equal(t, 2, pagination.Page)
 		assert.True(t, pagination.HasPrev)
 	})
+
+	t.Run("Cursor Pagination", func(t *testing.T) {
+		opts := topology.PaginationOptions{PageSize: 4, Keyset: true}
+
+		var seen []string
+		for {
+			devices, pagination, err := repo.GetDevices(ctx, opts)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(devices), 4)
+			assert.Equal(t, opts.After != "", pagination.HasPrev)
+			for _, device := range devices {
+				seen = append(seen, device.ID)
+			}
+			if !pagination.HasNext {
+				assert.Empty(t, pagination.NextCursor)
+				assert.Equal(t, pagination.TotalCount, len(seen))
+				break
+			}
+			opts.After, err = topology.DecodeCursor(pagination.NextCursor)
+			require.NoError(t, err)
+		}
+		assert.IsIncreasing(t, seen)
+	})
+}
+
+func TestFindDevicesMatchingRule(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: ":memory:"})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	ctx := context.Background()
+	for _, id := range []string{"core-01", "core-02", "CORE_x", "dist-01", "access-01"} {
+		require.NoError(t, repo.AddDevice(ctx, topology.Device{ID: id, Type: "switch", Hardware: "Arista 7280", LastSeen: time.Now()}))
+	}
+
+	rule := classification.ClassificationRule{
+		LogicOperator: "AND",
+		Conditions:    []classification.RuleCondition{{Field: "name", Operator: "starts_with", Value: "core-"}},
+	}
+
+	t.Run("Count and Page", func(t *testing.T) {
+		ids, total, err := repo.FindDevicesMatchingRule(ctx, rule, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, []string{"core-02"}, ids)
+	})
+
+	t.Run("Wildcards Are Literal", func(t *testing.T) {
+		rule := classification.ClassificationRule{
+			Conditions: []classification.RuleCondition{{Field: "name", Operator: "contains", Value: "_"}},
+		}
+		ids, total, err := repo.FindDevicesMatchingRule(ctx, rule, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []string{"CORE_x"}, ids)
+	})
+
+	t.Run("OR Logic", func(t *testing.T) {
+		rule := classification.ClassificationRule{
+			LogicOperator: "OR",
+			Conditions: []classification.RuleCondition{
+				{Field: "name", Operator: "equals", Value: "DIST-01"},
+				{Field: "name", Operator: "ends_with", Value: "access-01"},
+			},
+		}
+		_, total, err := repo.FindDevicesMatchingRule(ctx, rule, 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+	})
+
+	t.Run("Regex Not Queryable", func(t *testing.T) {
+		rule := classification.ClassificationRule{
+			Conditions: []classification.RuleCondition{{Field: "name", Operator: "regex", Value: "^core-"}},
+		}
+		_, _, err := repo.FindDevicesMatchingRule(ctx, rule, 10, 0)
+		assert.ErrorIs(t, err, classification.ErrRuleNotQueryable)
+	})
+
+	t.Run("Metadata Not Queryable", func(t *testing.T) {
+		rule := classification.ClassificationRule{
+			Conditions: []classification.RuleCondition{{Field: "metadata.site", Operator: "equals", Value: "tyo"}},
+		}
+		_, _, err := repo.FindDevicesMatchingRule(ctx, rule, 10, 0)
+		assert.ErrorIs(t, err, classification.ErrRuleNotQueryable)
+	})
+}
+
+func TestListRuleQuality(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: ":memory:"})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	ctx := context.Background()
+	leaf, spine := 3, 2
+	for _, id := range []string{"leaf-01", "leaf-02", "leaf-03"} {
+		require.NoError(t, repo.AddClassificationChange(ctx, classification.ClassificationChange{
+			DeviceID: id, LayerID: &leaf, DeviceType: "leaf", ClassifiedBy: "rule:leaf by name", Source: classification.ChangeSourceRule,
+		}))
+	}
+	changes := []classification.ClassificationChange{
+		// 別の分類への変更と分類の削除は上書き、同じ分類は確定
+		{DeviceID: "leaf-01", PreviousLayerID: &leaf, PreviousDeviceType: "leaf", PreviousClassifiedBy: "rule:leaf by name",
+			LayerID: &spine, DeviceType: "spine", ClassifiedBy: "user:admin", Source: classification.ChangeSourceUser},
+		{DeviceID: "leaf-02", PreviousLayerID: &leaf, PreviousDeviceType: "leaf", PreviousClassifiedBy: "rule:leaf by name",
+			Source: classification.ChangeSourceUser},
+		{DeviceID: "leaf-03", PreviousLayerID: &leaf, PreviousDeviceType: "leaf", PreviousClassifiedBy: "rule:leaf by name",
+			LayerID: &leaf, DeviceType: "leaf", ClassifiedBy: "user:admin", Source: classification.ChangeSourceUser},
+	}
+	for _, change := range changes {
+		require.NoError(t, repo.AddClassificationChange(ctx, change))
+	}
+
+	quality, err := repo.ListRuleQuality(ctx)
+	require.NoError(t, err)
+	require.Contains(t, quality, "leaf by name")
+	q := quality["leaf by name"]
+	assert.Equal(t, 3, q.Applied)
+	assert.Equal(t, 2, q.Overridden)
+	assert.Equal(t, 1, q.Confirmed)
+	require.NotNil(t, q.Score)
+	assert.InDelta(t, 1.0/3, *q.Score, 1e-9)
+}
+
+func TestManagementReachability(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: ":memory:"})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repo.AddDevice(ctx, topology.Device{ID: "spine-01", Type: "switch", LastSeen: now}))
+
+	require.NoError(t, repo.SaveManagementReachability(ctx, []topology.ManagementReachability{
+		{DeviceID: "spine-01", Address: "10.0.0.1", SSH: true, CheckedAt: now.Add(-time.Hour)},
+		{DeviceID: "deleted-01", Address: "10.0.0.9", CheckedAt: now}, // 確認中に削除されたデバイス
+	}))
+	// 到達できなくなっても前回到達できた時刻は残る
+	require.NoError(t, repo.SaveManagementReachability(ctx, []topology.ManagementReachability{
+		{DeviceID: "spine-01", Address: "10.0.0.1", Error: "i/o timeout", CheckedAt: now},
+	}))
+
+	results, err := repo.ListManagementReachability(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Manageable())
+	assert.Equal(t, "i/o timeout", results[0].Error)
+	assert.True(t, results[0].CheckedAt.Equal(now))
+	require.NotNil(t, results[0].LastReachableAt)
+	assert.True(t, results[0].LastReachableAt.Equal(now.Add(-time.Hour)))
+}
+
+func TestDeviceDeletions(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: ":memory:"})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	ctx := context.Background()
+	now := time.Now()
+	for _, id := range []string{"spine-01", "leaf-01", "leaf-02"} {
+		require.NoError(t, repo.AddDevice(ctx, topology.Device{ID: id, Type: "switch", LastSeen: now}))
+	}
+	require.NoError(t, repo.BulkAddLinks(ctx, []topology.Link{
+		{ID: "l1", SourceID: "spine-01", TargetID: "leaf-01", SourcePort: "Eth1", TargetPort: "Eth49", LastSeen: now},
+	}))
+
+	// ドライランと存在しないデバイスは記録しない
+	_, err = repo.DeleteDevices(ctx, []string{"leaf-01"}, true)
+	require.NoError(t, err)
+	result, err := repo.DeleteDevices(ctx, []string{"leaf-01", "leaf-02", "gone-01"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Devices)
+	assert.Equal(t, 1, result.Links)
+
+	deleted, err := repo.ListDeletedDevices(ctx, now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Len(t, deleted, 2)
+	assert.Equal(t, "leaf-01", deleted[0].DeviceID)
+	assert.Empty(t, deleted[0].OrphanedLinks)
+	assert.Nil(t, deleted[0].LastReportedAt)
+
+	require.NoError(t, repo.RecordOrphanedLinks(ctx, "leaf-01", []string{"l1"}, now))
+	deleted, err = repo.ListDeletedDevices(ctx, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"l1"}, deleted[0].OrphanedLinks)
+	require.NotNil(t, deleted[0].LastReportedAt)
+
+	// 猶予期間の起点は削除時刻
+	deleted, err = repo.ListDeletedDevices(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	purged, err := repo.PurgeDeletedDevices(ctx, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	purged, err = repo.PurgeDeletedDevices(ctx, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+}
+
+func TestMaintenanceWindows(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: ":memory:"})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.SaveMaintenanceWindow(ctx, topology.MaintenanceWindow{
+		ID: "late", Ticket: "CHG-2", StartsAt: start.Add(4 * time.Hour), EndsAt: start.Add(5 * time.Hour), Links: []string{"l1"},
+	}))
+	require.NoError(t, repo.SaveMaintenanceWindow(ctx, topology.MaintenanceWindow{
+		ID: "early", Ticket: "CHG-1", StartsAt: start, EndsAt: start.Add(2 * time.Hour), Devices: []string{"leaf-01"},
+	}))
+
+	windows, err := repo.ListMaintenanceWindows(ctx, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+	assert.Equal(t, "early", windows[0].ID)
+	assert.Equal(t, []string{"leaf-01"}, windows[0].Devices)
+	assert.Equal(t, []string{}, windows[0].Links)
+
+	// 終了時刻ちょうどから始まる期間とは重ならない
+	windows, err = repo.ListMaintenanceWindows(ctx, start.Add(2*time.Hour), start.Add(4*time.Hour+time.Minute))
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.Equal(t, "late", windows[0].ID)
+
+	require.NoError(t, repo.DeleteMaintenanceWindow(ctx, "late"))
+	window, err := repo.GetMaintenanceWindow(ctx, "late")
+	require.NoError(t, err)
+	assert.Nil(t, window)
+}
+
+func TestSyncCycleStats(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: ":memory:"})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		startedAt := start.Add(time.Duration(i) * 5 * time.Minute)
+		require.NoError(t, repo.SaveSyncCycleStats(ctx, topology.SyncCycleStats{
+			StartedAt: startedAt, FinishedAt: startedAt.Add(time.Minute), Links: 10, LinksAdded: i, Baseline: i == 0,
+		}))
+	}
+
+	history, err := repo.ListSyncCycleStats(ctx, time.Time{}, 2)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 1, history[0].LinksAdded)
+	assert.Equal(t, 2, history[1].LinksAdded)
+
+	history, err = repo.ListSyncCycleStats(ctx, start.Add(2*time.Minute), 0)
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	deleted, err := repo.DeleteSyncCycleStats(ctx, start.Add(10*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	history, err = repo.ListSyncCycleStats(ctx, time.Time{}, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.False(t, history[0].Baseline)
+}
+
+func TestIdentityDecisions(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: ":memory:"})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	ctx := context.Background()
+	decision := topology.IdentityDecision{
+		Key: "leaf-01,leaf01", Devices: []string{"leaf-01", "leaf01"}, CanonicalID: "leaf-01", DecidedBy: "alice",
+	}
+	require.NoError(t, repo.SaveIdentityDecision(ctx, decision))
+
+	// 同じキーの判断は置き換える
+	decision.CanonicalID = ""
+	decision.Note = "different chassis"
+	require.NoError(t, repo.SaveIdentityDecision(ctx, decision))
+
+	decisions, err := repo.ListIdentityDecisions(ctx)
+	require.NoError(t, err)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, []string{"leaf-01", "leaf01"}, decisions[0].Devices)
+	assert.Empty(t, decisions[0].CanonicalID)
+	assert.Equal(t, "different chassis", decisions[0].Note)
+	assert.False(t, decisions[0].DecidedAt.IsZero())
+
+	require.NoError(t, repo.DeleteIdentityDecision(ctx, decision.Key))
+	decisions, err = repo.ListIdentityDecisions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, decisions)
+}
+
+func TestStyleRules(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: ":memory:"})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	ctx := context.Background()
+	require.NoError(t, repo.SaveStyleRule(ctx, visualization.StyleRule{
+		ID: "staging", Target: visualization.StyleTargetNode, Priority: 5,
+		Conditions: []visualization.StyleCondition{{Field: "metadata.env", Operator: "equals", Value: "staging"}},
+		NodeStyle:  &visualization.NodeStyleOverride{BorderColor: "#95a5a6", BorderStyle: "dashed"},
+	}))
+	require.NoError(t, repo.SaveStyleRule(ctx, visualization.StyleRule{
+		ID: "uplinks", Target: visualization.StyleTargetEdge,
+		Conditions: []visualization.StyleCondition{{Field: "local_port", Operator: "starts_with", Value: "Ethernet49"}},
+		EdgeStyle:  &visualization.EdgeStyleOverride{Width: 4},
+	}))
+
+	rules, err := repo.ListStyleRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "uplinks", rules[0].ID)
+	assert.Equal(t, "AND", rules[0].LogicOperator)
+	assert.Equal(t, 4.0, rules[0].EdgeStyle.Width)
+	assert.Nil(t, rules[0].NodeStyle)
+	assert.Equal(t, "dashed", rules[1].NodeStyle.BorderStyle)
+	assert.Equal(t, "metadata.env", rules[1].Conditions[0].Field)
+
+	deleted, err := repo.DeleteStyleRule(ctx, "uplinks")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = repo.DeleteStyleRule(ctx, "uplinks")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestRowCounts(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: ":memory:"})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	ctx := context.Background()
+	require.NoError(t, repo.BulkAddDevices(ctx, []topology.Device{
+		{ID: "leaf-01", Type: "switch", LastSeen: time.Now()},
+		{ID: "spine-01", Type: "switch", LastSeen: time.Now()},
+	}))
+	require.NoError(t, repo.AddLink(ctx, topology.Link{
+		ID: "l1", SourceID: "leaf-01", TargetID: "spine-01", SourcePort: "Ethernet49", TargetPort: "Ethernet1", LastSeen: time.Now(),
+	}))
+
+	devices, err := repo.CountDevices(ctx, false)
+	require.NoError(t, err)
+	assert.Equal(t, topology.RowCount{Count: 2}, devices)
+
+	links, err := repo.CountLinks(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, topology.RowCount{Count: 1}, links)
+}
+
+func TestDeviceRack(t *testing.T) {
+	repo, err := NewSQliteRepository(Config{Path: ":memory:"})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, repo.Migrate())
+
+	ctx := context.Background()
+	require.NoError(t, repo.AddDevice(ctx, topology.Device{ID: "leaf-01", Type: "switch", LastSeen: time.Now()}))
+
+	position := 40
+	ok, err := repo.SetDeviceRack(ctx, topology.RackPlacement{DeviceID: "leaf-01", Rack: "r12", Position: &position, Units: 1})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// 同期の upsert はラックを持たないため配置を変えない
+	require.NoError(t, repo.AddDevice(ctx, topology.Device{ID: "leaf-01", Type: "switch", Hardware: "7050X3", LastSeen: time.Now()}))
+	device, err := repo.GetDevice(ctx, "leaf-01")
+	require.NoError(t, err)
+	assert.Equal(t, "r12", device.Rack)
+	require.NotNil(t, device.RackPosition)
+	assert.Equal(t, 40, *device.RackPosition)
+	assert.Equal(t, "7050X3", device.Hardware)
+
+	ok, err = repo.SetDeviceRack(ctx, topology.RackPlacement{DeviceID: "leaf-01"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	device, err = repo.GetDevice(ctx, "leaf-01")
+	require.NoError(t, err)
+	assert.Empty(t, device.Rack)
+	assert.Nil(t, device.RackPosition)
+
+	ok, err = repo.SetDeviceRack(ctx, topology.RackPlacement{DeviceID: "missing", Rack: "r12"})
+	require.NoError(t, err)
+	assert.False(t, ok)
 }
 
 func TestSQLiteConfig(t *testing.T) {
@@ -329,4 +735,17 @@ func TestSQLiteConfig(t *testing.T) {
 		config := Config{Path: "/tmp/test.db"}
 		assert.Equal(t, "/tmp/test.db", config.DSN())
 	})
+
+	t.Run("Connection Settings", func(t *testing.T) {
+		config := Config{Path: ":memory:", JournalMode: "DELETE", BusyTimeout: time.Second}
+		assert.NoError(t, config.Validate())
+
+		for _, invalid := range []Config{
+			{Path: ":memory:", JournalMode: "wall"},
+			{Path: ":memory:", BusyTimeout: -time.Second},
+			{Path: ":memory:", MaxReadConns: -1},
+		} {
+			assert.Error(t, invalid.Validate(), "Expected %+v to be rejected", invalid)
+		}
+	})
 }
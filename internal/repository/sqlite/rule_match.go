@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// ruleConditionColumns maps rule condition fields to device columns
+var ruleConditionColumns = map[string]string{
+	"name":     "id", // DeviceにNameがないため、IDを使用
+	"hardware": "hardware",
+	"type":     "type",
+}
+
+// FindDevicesMatchingRule evaluates the rule conditions in SQL and returns a page of matching device IDs.
+// SQLite has no regular expression operator, so rules with regex or metadata conditions return ErrRuleNotQueryable.
+func (r *sqliteRepository) FindDevicesMatchingRule(ctx context.Context, rule classification.ClassificationRule, limit, offset int) ([]string, int, error) {
+	where, args, err := ruleWhereClause(rule)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowxContext(ctx, "SELECT COUNT(*) FROM devices WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count matching devices: %w", err)
+	}
+	if limit <= 0 || total == 0 {
+		return []string{}, total, nil
+	}
+
+	deviceIDs := []string{}
+	query := "SELECT id FROM devices WHERE " + where + " ORDER BY id LIMIT ? OFFSET ?"
+	if err := r.db.SelectContext(ctx, &deviceIDs, query, append(args, limit, offset)...); err != nil {
+		return nil, 0, fmt.Errorf("failed to query matching devices: %w", err)
+	}
+
+	return deviceIDs, total, nil
+}
+
+// ruleWhereClause builds the condition of a rule with the same semantics as the in-memory
+// matcher: text operators are case-insensitive and unknown fields or operators never match
+func ruleWhereClause(rule classification.ClassificationRule) (string, []interface{}, error) {
+	if len(rule.Conditions) == 0 {
+		return "0", nil, nil
+	}
+
+	var clauses []string
+	var args []interface{}
+	for _, condition := range rule.Conditions {
+		column, ok := ruleConditionColumns[condition.Field]
+		if !ok {
+			// メタデータは JSON 文字列で保存しているためインメモリで評価する
+			if _, isMetadata := classification.MetadataKey(condition.Field); isMetadata {
+				return "", nil, classification.ErrRuleNotQueryable
+			}
+			clauses = append(clauses, "0")
+			continue
+		}
+
+		// SQLite の LIKE は ASCII の大文字小文字を区別しない
+		switch condition.Operator {
+		case "contains":
+			clauses = append(clauses, column+` LIKE '%' || ? || '%' ESCAPE '\'`)
+			args = append(args, escapeLike(condition.Value))
+		case "starts_with":
+			clauses = append(clauses, column+` LIKE ? || '%' ESCAPE '\'`)
+			args = append(args, escapeLike(condition.Value))
+		case "ends_with":
+			clauses = append(clauses, column+` LIKE '%' || ? ESCAPE '\'`)
+			args = append(args, escapeLike(condition.Value))
+		case "equals":
+			clauses = append(clauses, "LOWER("+column+") = LOWER(?)")
+			args = append(args, condition.Value)
+		case "regex":
+			return "", nil, classification.ErrRuleNotQueryable
+		default:
+			clauses = append(clauses, "0")
+		}
+	}
+
+	separator := " AND "
+	if rule.LogicOperator == "OR" {
+		separator = " OR "
+	}
+	return "(" + strings.Join(clauses, separator) + ")", args, nil
+}
+
+// escapeLike escapes the LIKE wildcards in a literal value
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
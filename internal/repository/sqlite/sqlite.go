@@ -10,45 +10,48 @@ import (
 
 // sqliteRepository implements both topology and classification repository interfaces
 type sqliteRepository struct {
-	db *sqlx.DB
+	db     *sqlx.DB // 読み取り用
+	writer *sqlx.DB // 書き込み用（1接続のみ。書き込みはこの接続を待つ列で直列化される）
 }
 
-// NewSQliteRepository creates a new SQLite repository
+// NewSQliteRepository creates a new SQLite repository. Reads and writes use separate connection
+// pools: the write pool has a single connection, so concurrent writers of the API and the worker
+// queue for it instead of failing with "database is locked".
 func NewSQliteRepository(config Config) (*sqliteRepository, error) {
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 
-	db, err := sqlx.Connect("sqlite3", config.DSN())
+	writer, err := sqlx.Connect("sqlite3", config.connectionDSN(true))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to SQLite: %w", err)
 	}
+	writer.SetMaxOpenConns(1)
 
-	// Enable foreign key constraints
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+	// :memory: は接続ごとに別のデータベースになるため、読み書きで同じ接続を使う
+	if config.Path == ":memory:" {
+		return &sqliteRepository{db: writer, writer: writer}, nil
 	}
 
-	// Enable WAL mode for better concurrency (except for :memory:)
-	if config.Path != ":memory:" {
-		if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
-			db.Close()
-			return nil, fmt.Errorf("failed to enable WAL mode: %w", err)
-		}
-	}
-
-	if err := db.Ping(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to ping SQLite database: %w", err)
+	db, err := sqlx.Connect("sqlite3", config.connectionDSN(false))
+	if err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("failed to connect to SQLite: %w", err)
 	}
+	db.SetMaxOpenConns(config.MaxReadConns)
 
-	return &sqliteRepository{db: db}, nil
+	return &sqliteRepository{db: db, writer: writer}, nil
 }
 
-// Close closes the database connection
+// Close closes the database connections
 func (r *sqliteRepository) Close() error {
-	return r.db.Close()
+	if r.writer != r.db {
+		if err := r.db.Close(); err != nil {
+			r.writer.Close()
+			return err
+		}
+	}
+	return r.writer.Close()
 }
 
 // Health checks database connectivity
@@ -58,28 +61,28 @@ func (r *sqliteRepository) Health(ctx context.Context) error {
 
 // Migrate runs database migrations
 func (r *sqliteRepository) Migrate() error {
-	return RunMigrations(r.db)
+	return RunMigrations(r.writer)
 }
 
 // Clear clears the database
 func (r *sqliteRepository) Clear() error {
-	_, err := r.db.Exec("DELETE FROM links")
+	_, err := r.writer.Exec("DELETE FROM links")
 	if err != nil {
 		return fmt.Errorf("failed to clear links: %w", err)
 	}
-	_, err = r.db.Exec("DELETE FROM devices")
+	_, err = r.writer.Exec("DELETE FROM devices")
 	if err != nil {
 		return fmt.Errorf("failed to clear devices: %w", err)
 	}
-	_, err = r.db.Exec("DELETE FROM device_classifications")
+	_, err = r.writer.Exec("DELETE FROM device_classifications")
 	if err != nil {
 		return fmt.Errorf("failed to clear device classifications: %w", err)
 	}
-	_, err = r.db.Exec("DELETE FROM classification_rules")
+	_, err = r.writer.Exec("DELETE FROM classification_rules")
 	if err != nil {
 		return fmt.Errorf("failed to clear classification rules: %w", err)
 	}
-	_, err = r.db.Exec("DELETE FROM hierarchy_layers")
+	_, err = r.writer.Exec("DELETE FROM hierarchy_layers")
 	if err != nil {
 		return fmt.Errorf("failed to clear hierarchy layers: %w", err)
 	}
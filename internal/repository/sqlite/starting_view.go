@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+// Starting view repository methods
+
+const startingViewColumns = `role, root_device, depth, grouping, description, updated_by, updated_at`
+
+// ListStartingViews retrieves the starting views of all roles
+func (r *sqliteRepository) ListStartingViews(ctx context.Context) ([]visualization.StartingView, error) {
+	query := `SELECT ` + startingViewColumns + ` FROM starting_views ORDER BY role`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list starting views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []visualization.StartingView
+	for rows.Next() {
+		view, err := scanStartingView(rows)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *view)
+	}
+
+	return views, nil
+}
+
+// GetStartingView retrieves the starting view of a role
+func (r *sqliteRepository) GetStartingView(ctx context.Context, role string) (*visualization.StartingView, error) {
+	query := `SELECT ` + startingViewColumns + ` FROM starting_views WHERE role = ?`
+
+	view, err := scanStartingView(r.db.QueryRowContext(ctx, query, role))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return view, nil
+}
+
+// SaveStartingView creates or replaces the starting view of a role
+func (r *sqliteRepository) SaveStartingView(ctx context.Context, view visualization.StartingView) error {
+	if view.UpdatedAt.IsZero() {
+		view.UpdatedAt = time.Now()
+	}
+
+	var groupingJSON interface{}
+	if view.Grouping != nil {
+		data, err := json.Marshal(view.Grouping)
+		if err != nil {
+			return fmt.Errorf("failed to marshal grouping options: %w", err)
+		}
+		groupingJSON = string(data)
+	}
+
+	query := `
+		INSERT INTO starting_views (` + startingViewColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (role) DO UPDATE SET
+			root_device = EXCLUDED.root_device,
+			depth = EXCLUDED.depth,
+			grouping = EXCLUDED.grouping,
+			description = EXCLUDED.description,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.writer.ExecContext(ctx, query,
+		view.Role, view.RootDevice, view.Depth, groupingJSON, view.Description, view.UpdatedBy, view.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save starting view: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteStartingView removes the starting view of a role
+func (r *sqliteRepository) DeleteStartingView(ctx context.Context, role string) error {
+	query := `DELETE FROM starting_views WHERE role = ?`
+
+	_, err := r.writer.ExecContext(ctx, query, role)
+	if err != nil {
+		return fmt.Errorf("failed to delete starting view: %w", err)
+	}
+
+	return nil
+}
+
+type startingViewScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStartingView(row startingViewScanner) (*visualization.StartingView, error) {
+	var view visualization.StartingView
+	var groupingJSON sql.NullString
+
+	err := row.Scan(&view.Role, &view.RootDevice, &view.Depth, &groupingJSON, &view.Description, &view.UpdatedBy, &view.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan starting view: %w", err)
+	}
+
+	if groupingJSON.Valid {
+		var grouping visualization.GroupingOptions
+		if err := json.Unmarshal([]byte(groupingJSON.String), &grouping); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal grouping options: %w", err)
+		}
+		view.Grouping = &grouping
+	}
+
+	return &view, nil
+}
@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Sync cycle stats repository methods
+
+const syncCycleStatsColumns = `started_at, finished_at, devices, links, devices_appeared, devices_disappeared,
+	links_added, links_removed, errors, baseline`
+
+// SaveSyncCycleStats appends the stats of a synchronization cycle
+func (r *sqliteRepository) SaveSyncCycleStats(ctx context.Context, stats topology.SyncCycleStats) error {
+	query := `
+		INSERT INTO sync_cycle_stats (` + syncCycleStatsColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.writer.ExecContext(ctx, query,
+		stats.StartedAt, stats.FinishedAt, stats.Devices, stats.Links, stats.DevicesAppeared, stats.DevicesDisappeared,
+		stats.LinksAdded, stats.LinksRemoved, stats.Errors, stats.Baseline,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save sync cycle stats: %w", err)
+	}
+
+	return nil
+}
+
+// ListSyncCycleStats retrieves the cycles finished after since, oldest first, at most the last limit (0 = all)
+func (r *sqliteRepository) ListSyncCycleStats(ctx context.Context, since time.Time, limit int) ([]topology.SyncCycleStats, error) {
+	// 時刻は文字列として保存されタイムゾーンが混在しうるため、記録順に読み込んでから絞り込む
+	query := `SELECT ` + syncCycleStatsColumns + ` FROM sync_cycle_stats ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync cycle stats: %w", err)
+	}
+	defer rows.Close()
+
+	history := []topology.SyncCycleStats{}
+	for rows.Next() {
+		var stats topology.SyncCycleStats
+		if err := rows.Scan(&stats.StartedAt, &stats.FinishedAt, &stats.Devices, &stats.Links, &stats.DevicesAppeared,
+			&stats.DevicesDisappeared, &stats.LinksAdded, &stats.LinksRemoved, &stats.Errors, &stats.Baseline); err != nil {
+			return nil, fmt.Errorf("failed to scan sync cycle stats: %w", err)
+		}
+		if !stats.FinishedAt.After(since) {
+			continue
+		}
+		history = append(history, stats)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sync cycle stats: %w", err)
+	}
+
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history, nil
+}
+
+// DeleteSyncCycleStats removes the cycles finished before cutoff
+func (r *sqliteRepository) DeleteSyncCycleStats(ctx context.Context, cutoff time.Time) (int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, finished_at FROM sync_cycle_stats`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sync cycle stats: %w", err)
+	}
+	var expired []int64
+	for rows.Next() {
+		var id int64
+		var finishedAt time.Time
+		if err := rows.Scan(&id, &finishedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan sync cycle stats: %w", err)
+		}
+		if finishedAt.Before(cutoff) {
+			expired = append(expired, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate sync cycle stats: %w", err)
+	}
+
+	for _, id := range expired {
+		if _, err := r.writer.ExecContext(ctx, `DELETE FROM sync_cycle_stats WHERE id = ?`, id); err != nil {
+			return 0, fmt.Errorf("failed to delete sync cycle stats: %w", err)
+		}
+	}
+	return int64(len(expired)), nil
+}
@@ -3,6 +3,7 @@ package sqlite
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/servak/topology-manager/internal/domain/topology"
 )
@@ -15,14 +16,55 @@ func (r *sqliteRepository) FindReachableDevices(ctx context.Context, deviceID st
 	return nil, fmt.Errorf("FindReachableDevices not implemented for SQLite")
 }
 
+// ExtractSubTopology returns the device and its direct neighbors that pass opts.Filter, with the
+// links between them. SQLite has no regular expressions, so the filter is applied in Go.
 func (r *sqliteRepository) ExtractSubTopology(ctx context.Context, deviceID string, opts topology.SubTopologyOptions) ([]topology.Device, []topology.Link, error) {
-	// TODO: Implement sub-topology extraction using SQLite
-	// For now, return placeholder  
-	return nil, nil, fmt.Errorf("ExtractSubTopology not implemented for SQLite")
+	centerDevice, err := r.GetDevice(ctx, deviceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get center device: %w", err)
+	}
+	if centerDevice == nil {
+		return nil, nil, fmt.Errorf("device not found: %s", deviceID)
+	}
+
+	deviceLinks, err := r.GetDeviceLinks(ctx, deviceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	devices := []topology.Device{*centerDevice}
+	links := []topology.Link{}
+	// 対向デバイスごとに判定結果を覚えておく（自己ループの対向はルート自身なので常に残す）
+	included := map[string]bool{deviceID: true}
+	for _, link := range deviceLinks {
+		peerID := link.TargetID
+		if peerID == deviceID {
+			peerID = link.SourceID
+		}
+
+		keep, seen := included[peerID]
+		if !seen {
+			peer, err := r.GetDevice(ctx, peerID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get device %s: %w", peerID, err)
+			}
+			keep = peer != nil && opts.Filter.Matches(*peer, now)
+			included[peerID] = keep
+			if keep {
+				devices = append(devices, *peer)
+			}
+		}
+		if keep {
+			links = append(links, link)
+		}
+	}
+
+	return devices, links, nil
 }
 
 func (r *sqliteRepository) FindShortestPath(ctx context.Context, fromID, toID string, opts topology.PathOptions) (*topology.Path, error) {
 	// TODO: Implement shortest path algorithm (Dijkstra, etc.) using SQLite
 	// For now, return placeholder
 	return nil, fmt.Errorf("FindShortestPath not implemented for SQLite")
-}
\ No newline at end of file
+}
@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Device workflow repository methods
+
+// TransitionDeviceWorkflow moves a device to a new workflow state and records the transition.
+// The state is compared and set in one statement, so concurrent transitions cannot both succeed.
+func (r *sqliteRepository) TransitionDeviceWorkflow(ctx context.Context, transition topology.WorkflowTransition) (bool, error) {
+	if transition.ChangedAt.IsZero() {
+		transition.ChangedAt = time.Now()
+	}
+
+	tx, err := r.writer.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE devices SET workflow_state = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND workflow_state = ?
+	`, transition.To, transition.DeviceID, transition.From)
+	if err != nil {
+		return false, fmt.Errorf("failed to update workflow state: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update workflow state: %w", err)
+	}
+	if updated == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO device_workflow_transitions (device_id, from_state, to_state, reason, changed_by, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, transition.DeviceID, transition.From, transition.To, transition.Reason, transition.ChangedBy, transition.ChangedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record workflow transition: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit workflow transition: %w", err)
+	}
+	return true, nil
+}
+
+// ListWorkflowTransitions retrieves the workflow transitions of a device, newest first
+func (r *sqliteRepository) ListWorkflowTransitions(ctx context.Context, deviceID string, limit int) ([]topology.WorkflowTransition, error) {
+	query := `
+		SELECT id, device_id, from_state, to_state, reason, changed_by, changed_at
+		FROM device_workflow_transitions
+		WHERE device_id = ?
+		ORDER BY changed_at DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, deviceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow transitions: %w", err)
+	}
+	defer rows.Close()
+
+	transitions := []topology.WorkflowTransition{}
+	for rows.Next() {
+		var transition topology.WorkflowTransition
+		err := rows.Scan(
+			&transition.ID, &transition.DeviceID, &transition.From, &transition.To,
+			&transition.Reason, &transition.ChangedBy, &transition.ChangedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workflow transition: %w", err)
+		}
+		transitions = append(transitions, transition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate workflow transitions: %w", err)
+	}
+
+	return transitions, nil
+}
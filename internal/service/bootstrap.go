@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// Bootstrap installs the layers and starter rules of pack that are missing. Layers are matched
+// by name case-insensitively and rules by their stable ID; existing ones are never modified, so
+// running it again, or after the starter rules were edited, is safe.
+func (s *ClassificationService) Bootstrap(ctx context.Context, pack classification.BootstrapPack, dryRun bool) (*classification.BootstrapResult, error) {
+	if err := pack.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid bootstrap pack: %w", err)
+	}
+
+	result := &classification.BootstrapResult{
+		DryRun:         dryRun,
+		LayersCreated:  []string{},
+		LayersExisting: []string{},
+		RulesCreated:   []string{},
+		RulesExisting:  []string{},
+	}
+
+	layers, err := s.classificationRepo.ListHierarchyLayers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hierarchy layers: %w", err)
+	}
+	layerIDs := make(map[string]int, len(layers))
+	maxID := -1
+	for _, layer := range layers {
+		layerIDs[strings.ToLower(strings.TrimSpace(layer.Name))] = layer.ID
+		if layer.ID > maxID {
+			maxID = layer.ID
+		}
+	}
+
+	now := time.Now()
+	for i, layer := range pack.Layers {
+		name := strings.ToLower(strings.TrimSpace(layer.Name))
+		if _, ok := layerIDs[name]; ok {
+			result.LayersExisting = append(result.LayersExisting, layer.Name)
+			continue
+		}
+
+		// 新しいレイヤーは既存の末尾のIDに続ける（ドライランでもルールの層を決めるため割り当てる）
+		maxID++
+		layerIDs[name] = maxID
+		result.LayersCreated = append(result.LayersCreated, layer.Name)
+		if dryRun {
+			continue
+		}
+		if err := s.classificationRepo.SaveHierarchyLayer(ctx, classification.HierarchyLayer{
+			ID:          maxID,
+			Name:        layer.Name,
+			Description: layer.Description,
+			Order:       i,
+			Color:       layer.Color,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to save layer %s: %w", layer.Name, err)
+		}
+	}
+
+	existing, err := s.classificationRepo.ListClassificationRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classification rules: %w", err)
+	}
+	installed := make(map[string]bool, len(existing))
+	for _, rule := range existing {
+		installed[rule.ID] = true
+	}
+
+	for _, rule := range pack.ClassificationRules(layerIDs) {
+		if installed[rule.ID] {
+			result.RulesExisting = append(result.RulesExisting, rule.ID)
+			continue
+		}
+		result.RulesCreated = append(result.RulesCreated, rule.ID)
+		if dryRun {
+			continue
+		}
+		rule.CreatedAt = now
+		rule.UpdatedAt = now
+		if err := s.classificationRepo.SaveClassificationRule(ctx, rule); err != nil {
+			return nil, fmt.Errorf("failed to save rule %s: %w", rule.ID, err)
+		}
+	}
+
+	return result, nil
+}
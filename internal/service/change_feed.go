@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+const (
+	// DefaultChangeListenRetry is how long the feed waits before listening again after the listener failed
+	DefaultChangeListenRetry = 10 * time.Second
+	// changeSubscriberBuffer is the number of changes a subscriber may fall behind before missing some
+	changeSubscriberBuffer = 256
+)
+
+// ChangeFeed fans the device and link changes reported by the repository out to subscribers,
+// such as caches and event streams, so writes by other instances or manual SQL reach them
+// without a full resync
+type ChangeFeed struct {
+	listener topology.ChangeListener
+
+	mu          sync.Mutex
+	subscribers map[chan topology.Change]*changeSubscriber
+}
+
+type changeSubscriber struct {
+	missed bool // 取りこぼしがあり、次に再同期を送る
+}
+
+func NewChangeFeed(listener topology.ChangeListener) *ChangeFeed {
+	return &ChangeFeed{
+		listener:    listener,
+		subscribers: make(map[chan topology.Change]*changeSubscriber),
+	}
+}
+
+// Run listens for changes until ctx is cancelled, listening again after retry when the listener fails
+func (f *ChangeFeed) Run(ctx context.Context, retry time.Duration, onError func(error)) {
+	for {
+		err := f.listener.ListenChanges(ctx, f.Publish)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			onError(err)
+		}
+		// 停止中の変更は届かないため購読者に再読み込みを促す
+		f.Publish(topology.Change{Op: topology.ChangeOpResync, ReceivedAt: time.Now()})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// Subscribe returns a channel receiving every change until unsubscribe is called. A subscriber
+// that falls behind misses changes instead of blocking the feed and then receives a ChangeOpResync change.
+func (f *ChangeFeed) Subscribe() (<-chan topology.Change, func()) {
+	ch := make(chan topology.Change, changeSubscriberBuffer)
+
+	f.mu.Lock()
+	f.subscribers[ch] = &changeSubscriber{}
+	f.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			f.mu.Lock()
+			delete(f.subscribers, ch)
+			f.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish delivers change to the subscribers without waiting for them
+func (f *ChangeFeed) Publish(change topology.Change) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch, subscriber := range f.subscribers {
+		if subscriber.missed {
+			select {
+			case ch <- topology.Change{Op: topology.ChangeOpResync, ReceivedAt: change.ReceivedAt}:
+				subscriber.missed = false
+			default:
+				continue
+			}
+		}
+		select {
+		case ch <- change:
+		default:
+			subscriber.missed = true
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrInvalidCircuit is returned when a circuit is malformed or attached to an unknown link
+var ErrInvalidCircuit = apperror.Validation("invalid_circuit", "invalid circuit")
+
+// CircuitService tracks cable and circuit IDs and attaches them to the links they carry
+type CircuitService struct {
+	circuitRepo  topology.CircuitRepository
+	topologyRepo topology.Repository
+}
+
+func NewCircuitService(circuitRepo topology.CircuitRepository, topologyRepo topology.Repository) *CircuitService {
+	return &CircuitService{
+		circuitRepo:  circuitRepo,
+		topologyRepo: topologyRepo,
+	}
+}
+
+// SearchCircuits returns circuits whose ID, provider, end devices or description contain query
+func (s *CircuitService) SearchCircuits(ctx context.Context, query string, limit int) ([]topology.Circuit, error) {
+	return s.circuitRepo.SearchCircuits(ctx, strings.TrimSpace(query), limit)
+}
+
+// GetCircuit returns a circuit, or nil when it does not exist
+func (s *CircuitService) GetCircuit(ctx context.Context, circuitID string) (*topology.Circuit, error) {
+	return s.circuitRepo.GetCircuit(ctx, circuitID)
+}
+
+// SaveCircuit validates and stores a circuit. Without an explicit link ID the circuit is
+// attached to the link whose endpoints match its ends.
+func (s *CircuitService) SaveCircuit(ctx context.Context, circuit topology.Circuit) (*topology.Circuit, error) {
+	circuit.ID = strings.TrimSpace(circuit.ID)
+	if err := circuit.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCircuit, err)
+	}
+
+	if circuit.LinkID != "" {
+		link, err := s.topologyRepo.GetLink(ctx, circuit.LinkID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get link: %w", err)
+		}
+		if link == nil {
+			return nil, fmt.Errorf("%w: link %s not found", ErrInvalidCircuit, circuit.LinkID)
+		}
+	} else {
+		linkID, err := s.resolveLink(ctx, circuit)
+		if err != nil {
+			return nil, err
+		}
+		circuit.LinkID = linkID
+	}
+
+	circuit.UpdatedAt = time.Now()
+	if err := s.circuitRepo.SaveCircuit(ctx, circuit); err != nil {
+		return nil, fmt.Errorf("failed to save circuit: %w", err)
+	}
+
+	return &circuit, nil
+}
+
+// ImportCircuits stores the circuits of a CSV export and attaches them to links.
+// The whole file is validated before anything is written.
+func (s *CircuitService) ImportCircuits(ctx context.Context, r io.Reader) (*topology.CircuitImportResult, error) {
+	circuits, err := topology.ParseCircuitsCSV(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCircuit, err)
+	}
+
+	result := &topology.CircuitImportResult{Unattached: []string{}}
+	for _, circuit := range circuits {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		saved, err := s.SaveCircuit(ctx, circuit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import circuit %s: %w", circuit.ID, err)
+		}
+
+		result.Imported++
+		if saved.LinkID != "" {
+			result.Attached++
+		} else {
+			result.Unattached = append(result.Unattached, saved.ID)
+		}
+	}
+
+	return result, nil
+}
+
+// DeleteCircuit removes a circuit
+func (s *CircuitService) DeleteCircuit(ctx context.Context, circuitID string) error {
+	return s.circuitRepo.DeleteCircuit(ctx, circuitID)
+}
+
+// GetLinkDetail returns a link with its circuits, or nil when the link does not exist
+func (s *CircuitService) GetLinkDetail(ctx context.Context, linkID string) (*topology.LinkDetail, error) {
+	link, err := s.topologyRepo.GetLink(ctx, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link: %w", err)
+	}
+	if link == nil {
+		return nil, nil
+	}
+
+	circuits, err := s.circuitRepo.ListLinkCircuits(ctx, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link circuits: %w", err)
+	}
+
+	return &topology.LinkDetail{Link: *link, Circuits: circuits}, nil
+}
+
+// resolveLink returns the ID of the link between the ends of the circuit, or "" when there is none
+func (s *CircuitService) resolveLink(ctx context.Context, circuit topology.Circuit) (string, error) {
+	links, err := s.topologyRepo.GetDeviceLinks(ctx, circuit.ADevice)
+	if err != nil {
+		return "", fmt.Errorf("failed to get links for device %s: %w", circuit.ADevice, err)
+	}
+
+	for _, link := range links {
+		if circuit.MatchesLink(link) {
+			return link.ID, nil
+		}
+	}
+	return "", nil
+}
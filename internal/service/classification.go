@@ -2,30 +2,72 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/servak/topology-manager/internal/domain/apperror"
 	"github.com/servak/topology-manager/internal/domain/classification"
 	"github.com/servak/topology-manager/internal/domain/topology"
 )
 
+// ErrInvalidListQuery is returned when a rule or suggestion list query is invalid
+var ErrInvalidListQuery = apperror.Validation("invalid_list_query", "invalid list query")
+
 type ClassificationService struct {
 	classificationRepo classification.Repository
 	topologyRepo       topology.Repository
+	historyRepo        classification.HistoryRepository              // nil = 履歴を記録しない
+	catalogRepo        classification.HardwareCatalogRepository      // nil = ハードウェアカタログなし
+	lifecycleRepo      classification.HardwareLifecycleRepository    // nil = EoS/EoL日なし
+	qualityRepo        classification.RuleQualityRepository          // nil = ルールの品質を算出しない
+	shadowRepo         classification.ShadowClassificationRepository // nil = シャドウルールの結果を記録しない
+	metadataSchema     topology.MetadataSchema                       // デバイスタイプごとの必須メタデータ
+	coverageGate       classification.CoverageGate                   // API が閾値・対象を省略した場合のゲート
 }
 
 func NewClassificationService(classificationRepo classification.Repository, topologyRepo topology.Repository) *ClassificationService {
+	historyRepo, _ := classificationRepo.(classification.HistoryRepository)
+	catalogRepo, _ := classificationRepo.(classification.HardwareCatalogRepository)
+	lifecycleRepo, _ := classificationRepo.(classification.HardwareLifecycleRepository)
+	qualityRepo, _ := classificationRepo.(classification.RuleQualityRepository)
+	shadowRepo, _ := classificationRepo.(classification.ShadowClassificationRepository)
+
 	return &ClassificationService{
 		classificationRepo: classificationRepo,
 		topologyRepo:       topologyRepo,
+		historyRepo:        historyRepo,
+		catalogRepo:        catalogRepo,
+		lifecycleRepo:      lifecycleRepo,
+		qualityRepo:        qualityRepo,
+		shadowRepo:         shadowRepo,
+		coverageGate:       classification.CoverageGate{Threshold: classification.DefaultCoverageThreshold},
 	}
 }
 
-// ClassifyDevice manually classifies a device
-func (s *ClassificationService) ClassifyDevice(ctx context.Context, deviceID string, layer int, deviceType string, userID string) error {
+// SetMetadataSchema sets the metadata required per device type. When it is enforced, manual
+// classifications into a type whose required metadata the device lacks are rejected and rules
+// leave such devices as they are.
+func (s *ClassificationService) SetMetadataSchema(schema topology.MetadataSchema) {
+	s.metadataSchema = schema
+}
+
+// SetCoverageGate sets the configured coverage gate, used where a request leaves it out
+func (s *ClassificationService) SetCoverageGate(gate classification.CoverageGate) {
+	s.coverageGate = gate
+}
+
+// CoverageGate returns the configured coverage gate
+func (s *ClassificationService) CoverageGate() classification.CoverageGate {
+	return s.coverageGate
+}
+
+// ClassifyDevice manually classifies a device. reason is recorded in the classification history.
+func (s *ClassificationService) ClassifyDevice(ctx context.Context, deviceID string, layer int, deviceType string, userID string, reason string) error {
 	// Verify device exists
 	device, err := s.topologyRepo.GetDevice(ctx, deviceID)
 	if err != nil {
@@ -35,13 +77,83 @@ func (s *ClassificationService) ClassifyDevice(ctx context.Context, deviceID str
 		return fmt.Errorf("device not found: %s", deviceID)
 	}
 
+	previous := *device
+
 	// Update device with classification information in new schema
 	device.LayerID = &layer
 	device.DeviceType = deviceType
 	device.ClassifiedBy = fmt.Sprintf("user:%s", userID) // user:username format
+	// 読み込んだ時刻のままでは並行する同期の upsert に負ける
+	device.UpdatedAt = time.Now()
+
+	if err := checkRequiredMetadata(s.metadataSchema, *device); err != nil {
+		return err
+	}
 
 	// Update the device in the topology repository
-	return s.topologyRepo.UpdateDevice(ctx, *device)
+	if err := s.topologyRepo.UpdateDevice(ctx, *device); err != nil {
+		return err
+	}
+
+	if reason == "" {
+		reason = "manual classification"
+		if strings.HasPrefix(previous.ClassifiedBy, "rule:") {
+			reason = fmt.Sprintf("manual override of %s", previous.ClassifiedBy)
+		}
+	}
+	return s.recordChange(ctx, previous, *device, classification.ChangeSourceUser, reason)
+}
+
+// GetClassificationHistory returns the classification changes of a device, newest first.
+// Returns nil when the repository does not record history.
+func (s *ClassificationService) GetClassificationHistory(ctx context.Context, deviceID string, limit int) ([]classification.ClassificationChange, error) {
+	if s.historyRepo == nil {
+		return nil, nil
+	}
+
+	changes, err := s.historyRepo.ListClassificationHistory(ctx, deviceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get classification history: %w", err)
+	}
+	return changes, nil
+}
+
+// recordChange stores the transition from previous to current in the classification history
+func (s *ClassificationService) recordChange(ctx context.Context, previous, current topology.Device, source classification.ChangeSource, reason string) error {
+	if s.historyRepo == nil {
+		return nil
+	}
+
+	// 変更がない場合は記録しない
+	if equalLayerID(previous.LayerID, current.LayerID) &&
+		previous.DeviceType == current.DeviceType &&
+		previous.ClassifiedBy == current.ClassifiedBy {
+		return nil
+	}
+
+	change := classification.ClassificationChange{
+		DeviceID:             current.ID,
+		PreviousLayerID:      previous.LayerID,
+		PreviousDeviceType:   previous.DeviceType,
+		PreviousClassifiedBy: previous.ClassifiedBy,
+		LayerID:              current.LayerID,
+		DeviceType:           current.DeviceType,
+		ClassifiedBy:         current.ClassifiedBy,
+		Source:               source,
+		Reason:               reason,
+		ChangedAt:            time.Now(),
+	}
+	if err := s.historyRepo.AddClassificationChange(ctx, change); err != nil {
+		return fmt.Errorf("failed to record classification change: %w", err)
+	}
+	return nil
+}
+
+func equalLayerID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
 }
 
 // GetDeviceClassification retrieves classification for a specific device
@@ -88,16 +200,9 @@ func (s *ClassificationService) GetDeviceClassification(ctx context.Context, dev
 // ListDeviceClassifications retrieves all device classifications from the new schema
 func (s *ClassificationService) ListDeviceClassifications(ctx context.Context) ([]classification.DeviceClassification, error) {
 	// Get all devices with classification information
-	paginationOpts := topology.PaginationOptions{
-		Page:     1,
-		PageSize: 10000, // 大きめに取得
-		OrderBy:  "id",
-		SortDir:  "ASC",
-	}
-
-	allDevices, _, err := s.topologyRepo.GetDevices(ctx, paginationOpts)
+	allDevices, err := ListAllDevices(ctx, s.topologyRepo)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get devices: %w", err)
+		return nil, err
 	}
 
 	var classifications []classification.DeviceClassification
@@ -145,36 +250,33 @@ func (s *ClassificationService) DeleteDeviceClassification(ctx context.Context,
 		return fmt.Errorf("device not found: %s", deviceID)
 	}
 
+	previous := *device
+
 	// Clear classification fields
 	device.LayerID = nil
 	device.DeviceType = ""
 	device.ClassifiedBy = ""
+	device.UpdatedAt = time.Now()
 
 	// Update the device in the topology repository
-	return s.topologyRepo.UpdateDevice(ctx, *device)
+	if err := s.topologyRepo.UpdateDevice(ctx, *device); err != nil {
+		return err
+	}
+
+	return s.recordChange(ctx, previous, *device, classification.ChangeSourceUser, "classification removed")
 }
 
 // ListUnclassifiedDevices returns devices that haven't been classified
 func (s *ClassificationService) ListUnclassifiedDevices(ctx context.Context) ([]topology.Device, error) {
-	// Get all devices from topology repository
-	paginationOpts := topology.PaginationOptions{
-		Page:     1,
-		PageSize: 10000, // 大きめに取得
-		OrderBy:  "id",
-		SortDir:  "ASC",
-	}
-
-	allDevices, _, err := s.topologyRepo.GetDevices(ctx, paginationOpts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get devices: %w", err)
-	}
-
-	// Filter unclassified devices
 	var unclassifiedDevices []topology.Device
-	for _, device := range allDevices {
+	err := walkDevices(ctx, s.topologyRepo, "", func(device topology.Device) bool {
 		if s.isUnclassified(device) {
 			unclassifiedDevices = append(unclassifiedDevices, device)
 		}
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return unclassifiedDevices, nil
@@ -183,25 +285,9 @@ func (s *ClassificationService) ListUnclassifiedDevices(ctx context.Context) ([]
 // ListUnclassifiedDevicesWithPagination returns devices that haven't been classified with pagination
 func (s *ClassificationService) ListUnclassifiedDevicesWithPagination(ctx context.Context, limit, offset int) ([]topology.Device, int, error) {
 	// 新しいスキーマでは、layer_idがNULLまたはclassified_byがNULL/空のデバイスが未分類
-	// 簡易実装: topologyリポジトリから全デバイスを取得してフィルタリング
-	paginationOpts := topology.PaginationOptions{
-		Page:     1,
-		PageSize: 1000, // 大きめに取得してフィルタリング
-		OrderBy:  "id",
-		SortDir:  "ASC",
-	}
-
-	allDevices, _, err := s.topologyRepo.GetDevices(ctx, paginationOpts)
+	unclassifiedDevices, err := s.ListUnclassifiedDevices(ctx)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get devices: %w", err)
-	}
-
-	// 未分類デバイスをフィルタリング
-	var unclassifiedDevices []topology.Device
-	for _, device := range allDevices {
-		if s.isUnclassified(device) {
-			unclassifiedDevices = append(unclassifiedDevices, device)
-		}
+		return nil, 0, err
 	}
 
 	// ページネーション適用
@@ -219,27 +305,124 @@ func (s *ClassificationService) ListUnclassifiedDevicesWithPagination(ctx contex
 	return unclassifiedDevices[start:end], totalCount, nil
 }
 
+// ListUnclassifiedDevicesByCursor returns up to limit unclassified devices after cursor in ID order
+// (empty cursor = first page) and the cursor of the following page, empty on the last page. Unlike
+// offsets it reads only the devices up to the end of the page.
+func (s *ClassificationService) ListUnclassifiedDevicesByCursor(ctx context.Context, cursor string, limit int) ([]topology.Device, string, error) {
+	after := ""
+	if cursor != "" {
+		decoded, err := topology.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		after = decoded
+	}
+
+	// 次のページの有無を判定するため1台多く集める
+	devices := []topology.Device{}
+	err := walkDevices(ctx, s.topologyRepo, after, func(device topology.Device) bool {
+		if s.isUnclassified(device) {
+			devices = append(devices, device)
+		}
+		return len(devices) <= limit
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(devices) <= limit {
+		return devices, "", nil
+	}
+	devices = devices[:limit]
+	return devices, topology.EncodeCursor(devices[len(devices)-1].ID), nil
+}
+
 // isUnclassified checks if a device is unclassified in the new schema
 func (s *ClassificationService) isUnclassified(device topology.Device) bool {
 	// layer_idがNULLまたはclassified_byがNULL/空の場合は未分類
 	return device.LayerID == nil || device.ClassifiedBy == ""
 }
 
-// ApplyClassificationRules applies all active rules to classify devices
+// EvaluateCoverage checks classification coverage of the devices in scope against a gate
+func (s *ClassificationService) EvaluateCoverage(ctx context.Context, gate classification.CoverageGate) (*classification.CoverageReport, error) {
+	// 対象デバイスタイプの絞り込み（空の場合は全デバイス）
+	inScope := make(map[string]bool, len(gate.DeviceTypes))
+	for _, t := range gate.DeviceTypes {
+		inScope[strings.ToLower(t)] = true
+	}
+
+	report := &classification.CoverageReport{
+		Threshold:           gate.Threshold,
+		DeviceTypes:         gate.DeviceTypes,
+		UnclassifiedDevices: []string{},
+	}
+
+	err := walkDevices(ctx, s.topologyRepo, "", func(device topology.Device) bool {
+		if len(inScope) > 0 && !inScope[strings.ToLower(device.Type)] {
+			return true
+		}
+		report.TotalDevices++
+		if s.isUnclassified(device) {
+			report.UnclassifiedDevices = append(report.UnclassifiedDevices, device.ID)
+		} else {
+			report.ClassifiedDevices++
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 対象デバイスが0台の場合はカバレッジ100%とみなす
+	report.Coverage = 100
+	if report.TotalDevices > 0 {
+		report.Coverage = float64(report.ClassifiedDevices) / float64(report.TotalDevices) * 100
+	}
+	report.Passed = report.Coverage >= gate.Threshold
+
+	return report, nil
+}
+
+// ApplyClassificationRules applies all active rules to classify devices. Shadow rules do not
+// change devices; the classifications they would have made are recorded instead.
 func (s *ClassificationService) ApplyClassificationRules(ctx context.Context, deviceIDs []string) ([]classification.DeviceClassification, error) {
-	rules, err := s.classificationRepo.ListActiveClassificationRules(ctx)
+	return s.applyClassificationRules(ctx, deviceIDs, "")
+}
+
+// EnrichedPlaceholderReason prefixes the history reason of classifications made by ReclassifyEnrichedPlaceholders
+const EnrichedPlaceholderReason = "placeholder enriched by monitoring"
+
+// ReclassifyEnrichedPlaceholders re-runs the active rules for placeholder devices that monitoring has
+// described since they were created from LLDP neighbors. The rules can now match the hardware and
+// metadata the placeholders lacked; the resulting changes are recorded with the enrichment as reason.
+func (s *ClassificationService) ReclassifyEnrichedPlaceholders(ctx context.Context, deviceIDs []string) ([]classification.DeviceClassification, error) {
+	return s.applyClassificationRules(ctx, deviceIDs, EnrichedPlaceholderReason)
+}
+
+// applyClassificationRules applies the active rules to the devices; trigger, when set, is prepended
+// to the reason recorded in the classification history
+func (s *ClassificationService) applyClassificationRules(ctx context.Context, deviceIDs []string, trigger string) ([]classification.DeviceClassification, error) {
+	rules, err := s.activeRules(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list active rules: %w", err)
+		return nil, err
 	}
 
 	var results []classification.DeviceClassification
+	var evaluated []string
+	var shadowResults []classification.ShadowClassification
 
 	for _, deviceID := range deviceIDs {
+		// Stop when the request has been cancelled; errors below are skipped per device
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		// Get device details
 		device, err := s.topologyRepo.GetDevice(ctx, deviceID)
 		if err != nil || device == nil {
 			continue
 		}
+		evaluated = append(evaluated, deviceID)
 
 		// Skip if device is already manually classified (user: prefix)
 		if strings.HasPrefix(device.ClassifiedBy, "user:") {
@@ -247,49 +430,96 @@ func (s *ClassificationService) ApplyClassificationRules(ctx context.Context, de
 		}
 
 		// Apply rules in priority order
-		for _, rule := range rules {
-			if s.deviceMatchesRule(*device, rule) {
-				// Update device with classification information
-				device.LayerID = &rule.Layer
-				device.DeviceType = rule.DeviceType
-				device.ClassifiedBy = fmt.Sprintf("rule:%s", rule.Name)
-
-				// Update device in topology repository
-				if err := s.topologyRepo.UpdateDevice(ctx, *device); err == nil {
-					// Create result object for return
-					classification := classification.DeviceClassification{
-						ID:         device.ID,
-						DeviceID:   deviceID,
-						Layer:      rule.Layer,
-						DeviceType: rule.DeviceType,
-						IsManual:   false,
-						CreatedBy:  "system",
-						CreatedAt:  time.Now(),
-						UpdatedAt:  time.Now(),
-					}
-					results = append(results, classification)
-				}
-				break // Apply only the first matching rule
+		rule, shadow := s.matchRules(*device, rules)
+		if shadow != nil {
+			shadowResults = append(shadowResults, newShadowClassification(deviceID, *shadow))
+		}
+		if rule == nil {
+			continue
+		}
+
+		previous := *device
+
+		// Update device with classification information
+		device.LayerID = &rule.Layer
+		device.DeviceType = rule.DeviceType
+		device.ClassifiedBy = fmt.Sprintf("rule:%s", rule.Name)
+		device.UpdatedAt = time.Now()
+
+		// 必須メタデータを欠くタイプにはルールでも分類しない（メタデータが揃った後の同期で分類される）
+		if err := checkRequiredMetadata(s.metadataSchema, *device); err != nil {
+			continue
+		}
+
+		// Update device in topology repository
+		if err := s.topologyRepo.UpdateDevice(ctx, *device); err == nil {
+			reason := fmt.Sprintf("matched rule %s", rule.Name)
+			if trigger != "" {
+				reason = trigger + ": " + reason
+			}
+			if err := s.recordChange(ctx, previous, *device, classification.ChangeSourceRule, reason); err != nil {
+				return nil, err
+			}
+
+			// Create result object for return
+			classification := classification.DeviceClassification{
+				ID:         device.ID,
+				DeviceID:   deviceID,
+				Layer:      rule.Layer,
+				DeviceType: rule.DeviceType,
+				IsManual:   false,
+				CreatedBy:  "system",
+				CreatedAt:  time.Now(),
+				UpdatedAt:  time.Now(),
 			}
+			results = append(results, classification)
 		}
 	}
 
+	if err := s.recordShadowClassifications(ctx, evaluated, shadowResults); err != nil {
+		return nil, err
+	}
+
 	return results, nil
 }
 
+// matchRules returns the first matching live rule and the first matching shadow rule ranked
+// above it; either is nil when there is none. rules must be in priority order.
+func (s *ClassificationService) matchRules(device topology.Device, rules []classification.ClassificationRule) (live, shadow *classification.ClassificationRule) {
+	for i := range rules {
+		if !s.deviceMatchesRule(device, rules[i]) {
+			continue
+		}
+		if !rules[i].Shadow {
+			return &rules[i], shadow // Apply only the first matching rule
+		}
+		// シャドウルールは最初に一致したものだけ記録し、適用するルールを探し続ける
+		if shadow == nil {
+			shadow = &rules[i]
+		}
+	}
+	return nil, shadow
+}
+
 // deviceMatchesRule checks if a device matches a classification rule
 func (s *ClassificationService) deviceMatchesRule(device topology.Device, rule classification.ClassificationRule) bool {
-	if len(rule.Conditions) == 0 {
+	return s.deviceMatchesConditions(device, rule.LogicOperator, rule.Conditions)
+}
+
+// deviceMatchesConditions checks if a device matches conditions combined with logic (AND or OR);
+// shared by classification and tag rules
+func (s *ClassificationService) deviceMatchesConditions(device topology.Device, logic string, conditions []classification.RuleCondition) bool {
+	if len(conditions) == 0 {
 		return false
 	}
 
 	var results []bool
-	for _, condition := range rule.Conditions {
+	for _, condition := range conditions {
 		results = append(results, s.deviceMatchesCondition(device, condition))
 	}
 
 	// Apply logic operator
-	if rule.LogicOperator == "OR" {
+	if logic == "OR" {
 		// OR: at least one condition must be true
 		for _, result := range results {
 			if result {
@@ -320,7 +550,11 @@ func (s *ClassificationService) deviceMatchesCondition(device topology.Device, c
 	case "type":
 		fieldValue = device.Type
 	default:
-		return false
+		key, ok := classification.MetadataKey(condition.Field)
+		if !ok {
+			return false
+		}
+		fieldValue = device.Metadata[key]
 	}
 
 	switch condition.Operator {
@@ -378,16 +612,9 @@ func (s *ClassificationService) GenerateRuleSuggestions(ctx context.Context) ([]
 // getManualClassifications retrieves all manual device classifications with device details
 func (s *ClassificationService) getManualClassifications(ctx context.Context) ([]classificationWithDevice, error) {
 	// Get all devices from topology repository
-	paginationOpts := topology.PaginationOptions{
-		Page:     1,
-		PageSize: 10000, // 大きめに取得
-		OrderBy:  "id",
-		SortDir:  "ASC",
-	}
-
-	allDevices, _, err := s.topologyRepo.GetDevices(ctx, paginationOpts)
+	allDevices, err := ListAllDevices(ctx, s.topologyRepo)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get devices: %w", err)
+		return nil, err
 	}
 
 	var result []classificationWithDevice
@@ -485,15 +712,15 @@ func (s *ClassificationService) analyzeNamePatterns(ctx context.Context, group [
 				}
 
 				suggestion := classification.ClassificationSuggestion{
-					ID:              uuid.New().String(),
-					Rule:            rule,
-					AffectedDevices: s.findAffectedDevicesByRule(ctx, rule),
-					BasedOnDevices:  deviceIDs,
-					Confidence:      confidence,
-					Status:          classification.SuggestionStatusPending,
-					CreatedAt:       time.Now(),
+					ID:             uuid.New().String(),
+					Rule:           rule,
+					BasedOnDevices: deviceIDs,
+					Confidence:     confidence,
+					Status:         classification.SuggestionStatusPending,
+					CreatedAt:      time.Now(),
 				}
 
+				suggestion.AffectedDevices, suggestion.AffectedCount = s.findAffectedDevicesByRule(ctx, rule)
 				suggestions = append(suggestions, suggestion)
 			}
 		}
@@ -528,15 +755,15 @@ func (s *ClassificationService) analyzeNamePatterns(ctx context.Context, group [
 				}
 
 				suggestion := classification.ClassificationSuggestion{
-					ID:              uuid.New().String(),
-					Rule:            rule,
-					AffectedDevices: s.findAffectedDevicesByRule(ctx, rule),
-					BasedOnDevices:  deviceIDs,
-					Confidence:      confidence,
-					Status:          classification.SuggestionStatusPending,
-					CreatedAt:       time.Now(),
+					ID:             uuid.New().String(),
+					Rule:           rule,
+					BasedOnDevices: deviceIDs,
+					Confidence:     confidence,
+					Status:         classification.SuggestionStatusPending,
+					CreatedAt:      time.Now(),
 				}
 
+				suggestion.AffectedDevices, suggestion.AffectedCount = s.findAffectedDevicesByRule(ctx, rule)
 				suggestions = append(suggestions, suggestion)
 			}
 		}
@@ -581,15 +808,15 @@ func (s *ClassificationService) analyzeHardwarePatterns(ctx context.Context, gro
 				}
 
 				suggestion := classification.ClassificationSuggestion{
-					ID:              uuid.New().String(),
-					Rule:            rule,
-					AffectedDevices: s.findAffectedDevicesByRule(ctx, rule),
-					BasedOnDevices:  deviceIDs,
-					Confidence:      confidence,
-					Status:          classification.SuggestionStatusPending,
-					CreatedAt:       time.Now(),
+					ID:             uuid.New().String(),
+					Rule:           rule,
+					BasedOnDevices: deviceIDs,
+					Confidence:     confidence,
+					Status:         classification.SuggestionStatusPending,
+					CreatedAt:      time.Now(),
 				}
 
+				suggestion.AffectedDevices, suggestion.AffectedCount = s.findAffectedDevicesByRule(ctx, rule)
 				suggestions = append(suggestions, suggestion)
 			}
 		}
@@ -702,10 +929,53 @@ func (s *ClassificationService) calculateConfidence(pattern string, names []stri
 	return float64(matches) / float64(len(names))
 }
 
-func (s *ClassificationService) findAffectedDevicesByRule(ctx context.Context, rule classification.ClassificationRule) []string {
-	// This would need to query all devices and apply the rule
-	// For now, return empty slice - implement when needed
-	return []string{}
+// findAffectedDevicesByRule returns up to MaxAffectedDevices devices matching rule and the total number of matches
+func (s *ClassificationService) findAffectedDevicesByRule(ctx context.Context, rule classification.ClassificationRule) ([]string, int) {
+	deviceIDs, total, err := s.ListAffectedDevices(ctx, rule, classification.MaxAffectedDevices, 0)
+	if err != nil {
+		return []string{}, 0
+	}
+	return deviceIDs, total
+}
+
+// ListAffectedDevices returns a page of the devices matching rule, sorted by ID, and the total number of matches.
+// Conditions are evaluated by the repository when possible, otherwise against all devices in memory.
+func (s *ClassificationService) ListAffectedDevices(ctx context.Context, rule classification.ClassificationRule, limit, offset int) ([]string, int, error) {
+	if matchRepo, ok := s.topologyRepo.(classification.RuleMatchRepository); ok {
+		deviceIDs, total, err := matchRepo.FindDevicesMatchingRule(ctx, rule, limit, offset)
+		if err == nil {
+			return deviceIDs, total, nil
+		}
+		if !errors.Is(err, classification.ErrRuleNotQueryable) {
+			return nil, 0, fmt.Errorf("failed to find matching devices: %w", err)
+		}
+	}
+
+	// クエリで評価できない条件（SQLiteの正規表現など）はメモリ上で評価
+	var matched []string
+	err := walkDevices(ctx, s.topologyRepo, "", func(device topology.Device) bool {
+		if s.deviceMatchesRule(device, rule) {
+			matched = append(matched, device.ID)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Strings(matched)
+
+	total := len(matched)
+	if offset >= total {
+		return []string{}, total, nil
+	}
+	end := offset + limit
+	if limit <= 0 {
+		end = offset
+	}
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
 }
 
 // SaveClassificationRule saves a new or updated classification rule
@@ -725,18 +995,64 @@ func (s *ClassificationService) GetClassificationRule(ctx context.Context, ruleI
 
 // UpdateClassificationRule updates an existing classification rule
 func (s *ClassificationService) UpdateClassificationRule(ctx context.Context, rule classification.ClassificationRule) error {
+	if err := s.checkRuleEditable(ctx, rule.ID); err != nil {
+		return err
+	}
 	rule.UpdatedAt = time.Now()
 	return s.classificationRepo.UpdateClassificationRule(ctx, rule)
 }
 
 // DeleteClassificationRule deletes a classification rule
 func (s *ClassificationService) DeleteClassificationRule(ctx context.Context, ruleID string) error {
+	if err := s.checkRuleEditable(ctx, ruleID); err != nil {
+		return err
+	}
 	return s.classificationRepo.DeleteClassificationRule(ctx, ruleID)
 }
 
-// ListClassificationRules lists all classification rules
-func (s *ClassificationService) ListClassificationRules(ctx context.Context) ([]classification.ClassificationRule, error) {
-	return s.classificationRepo.ListClassificationRules(ctx)
+// ListClassificationRules returns a page of the classification rules with their quality, derived
+// from how often users overrode their classifications, and the number of rules matching the search
+func (s *ClassificationService) ListClassificationRules(ctx context.Context, query classification.ListQuery) ([]classification.ClassificationRule, int, error) {
+	if err := query.Validate(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidListQuery, err)
+	}
+
+	rules, err := s.classificationRepo.ListClassificationRules(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.qualityRepo == nil {
+		page, total := classification.PageRules(rules, query)
+		return page, total, nil
+	}
+
+	// 品質順の並べ替えに必要なため、ページングの前に全ルールへ付与する
+	quality, err := s.qualityRepo.ListRuleQuality(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get rule quality: %w", err)
+	}
+	for i := range rules {
+		// デバイスにはルール名で記録されているため名前で対応付ける
+		q, ok := quality[rules[i].Name]
+		if !ok {
+			q = classification.NewRuleQuality(0, 0, 0)
+		}
+		rules[i].Quality = &q
+	}
+	page, total := classification.PageRules(rules, query)
+	return page, total, nil
+}
+
+// GetRuleSchema describes the fields, including the metadata keys found on devices, and the
+// operators rule conditions can use, with up to maxSamples common values per field
+func (s *ClassificationService) GetRuleSchema(ctx context.Context, maxSamples int) (*classification.RuleSchema, error) {
+	devices, err := ListAllDevices(ctx, s.topologyRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := classification.BuildRuleSchema(devices, maxSamples)
+	return &schema, nil
 }
 
 // AcceptSuggestion accepts a classification suggestion and creates an active rule
@@ -769,9 +1085,40 @@ func (s *ClassificationService) RejectSuggestion(ctx context.Context, suggestion
 	return s.classificationRepo.UpdateClassificationSuggestionStatus(ctx, suggestionID, classification.SuggestionStatusRejected)
 }
 
-// ListPendingSuggestions lists all pending classification suggestions
-func (s *ClassificationService) ListPendingSuggestions(ctx context.Context) ([]classification.ClassificationSuggestion, error) {
-	return s.classificationRepo.ListPendingClassificationSuggestions(ctx)
+// ListPendingSuggestions returns a page of the pending classification suggestions and the number
+// of suggestions matching the search
+func (s *ClassificationService) ListPendingSuggestions(ctx context.Context, query classification.ListQuery) ([]classification.ClassificationSuggestion, int, error) {
+	if err := query.Validate(); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidListQuery, err)
+	}
+	if query.Sort == classification.SortByQuality {
+		return nil, 0, fmt.Errorf("%w: suggestions cannot be sorted by quality", ErrInvalidListQuery)
+	}
+
+	suggestions, err := s.classificationRepo.ListPendingClassificationSuggestions(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := classification.PageSuggestions(suggestions, query)
+
+	// 影響デバイス数は現在のデバイスで数え直す（返すページの分だけ）
+	for i := range page {
+		page[i].AffectedDevices, page[i].AffectedCount = s.findAffectedDevicesByRule(ctx, page[i].Rule)
+	}
+	return page, total, nil
+}
+
+// ListSuggestionAffectedDevices returns a page of the devices the rule of a suggestion would classify.
+// It returns nil when the suggestion does not exist.
+func (s *ClassificationService) ListSuggestionAffectedDevices(ctx context.Context, suggestionID string, limit, offset int) ([]string, int, error) {
+	suggestion, err := s.classificationRepo.GetClassificationSuggestion(ctx, suggestionID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get suggestion: %w", err)
+	}
+	if suggestion == nil {
+		return nil, 0, nil
+	}
+	return s.ListAffectedDevices(ctx, suggestion.Rule, limit, offset)
 }
 
 // Hierarchy Layer management
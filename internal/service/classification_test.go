@@ -3,208 +3,365 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
 	"github.com/servak/topology-manager/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestClassificationService_ListHierarchyLayers(t *testing.T) {
+func newTestClassificationService(t *testing.T) (*ClassificationService, *testutil.TestSetup) {
 	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
+	t.Cleanup(setup.Cleanup)
+	return NewClassificationService(setup.Repo, setup.Repo), setup
+}
 
-	ctx := context.Background()
+// seedUnclassifiedDevices adds devices that no rule or user has classified yet
+func seedUnclassifiedDevices(t *testing.T, setup *testutil.TestSetup, ids ...string) {
+	devices := make([]topology.Device, 0, len(ids))
+	for _, id := range ids {
+		device := testutil.CreateTestDevice(id)
+		device.LayerID = nil
+		device.DeviceType = ""
+		device.ClassifiedBy = "system:auto"
+		devices = append(devices, device)
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(context.Background(), devices))
+}
 
-	// Create classification service
-	classificationService := NewClassificationService(setup.Repo, setup.Logger)
+func TestClassificationService_ListHierarchyLayers(t *testing.T) {
+	classificationService, _ := newTestClassificationService(t)
 
 	// Test listing hierarchy layers (default ones should be present from migration)
-	layers, err := classificationService.ListHierarchyLayers(ctx)
-	require.NoError(t, err)
-	
-	// Should have default layers from migration
-	assert.GreaterOrEqual(t, len(layers), 1)
-	
-	// Find the "Core" layer that should exist
-	var coreLayer *string
+	layers, err := classificationService.ListHierarchyLayers(context.Background())
+	require.NoError(t, err)
+
+	var names []string
 	for _, layer := range layers {
-		if layer.Name == "Core" {
-			name := layer.Name
-			coreLayer = &name
-			break
-		}
+		names = append(names, layer.Name)
 	}
-	assert.NotNil(t, coreLayer, "Should have a Core layer from default data")
+	assert.Contains(t, names, "Core", "Should have a Core layer from default data")
 }
 
 func TestClassificationService_SaveAndGetHierarchyLayer(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-
+	classificationService, _ := newTestClassificationService(t)
 	ctx := context.Background()
 
-	// Create a test layer
 	testLayer := testutil.CreateTestHierarchyLayer(100, "Test Layer")
-	
-	// Save the layer
-	err := setup.ClassificationService.SaveHierarchyLayer(ctx, testLayer)
-	require.NoError(t, err)
+	require.NoError(t, classificationService.SaveHierarchyLayer(ctx, testLayer))
 
-	// Retrieve the layer
-	retrievedLayer, err := setup.ClassificationService.GetHierarchyLayer(ctx, testLayer.ID)
+	retrievedLayer, err := classificationService.GetHierarchyLayer(ctx, testLayer.ID)
 	require.NoError(t, err)
 	require.NotNil(t, retrievedLayer)
 
-	// Verify the retrieved layer
 	assert.Equal(t, testLayer.ID, retrievedLayer.ID)
 	assert.Equal(t, testLayer.Name, retrievedLayer.Name)
 	assert.Equal(t, testLayer.Description, retrievedLayer.Description)
-	assert.Equal(t, testLayer.Order, retrievedLayer.Order)
 }
 
 func TestClassificationService_ListClassificationRules(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-
+	classificationService, _ := newTestClassificationService(t)
 	ctx := context.Background()
 
-	// Create test rules
 	rule1 := testutil.CreateTestClassificationRule("rule-001", "Switch Rule")
 	rule2 := testutil.CreateTestClassificationRule("rule-002", "Router Rule")
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, rule1))
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, rule2))
 
-	// Save rules
-	err := setup.ClassificationService.SaveClassificationRule(ctx, rule1)
-	require.NoError(t, err)
-	
-	err = setup.ClassificationService.SaveClassificationRule(ctx, rule2)
+	rules, total, err := classificationService.ListClassificationRules(ctx, classification.ListQuery{})
 	require.NoError(t, err)
+	assert.Equal(t, len(rules), total)
 
-	// List all rules
-	rules, err := setup.ClassificationService.ListClassificationRules(ctx)
-	require.NoError(t, err)
-	
-	// Should have at least our test rules (plus any default ones)
-	assert.GreaterOrEqual(t, len(rules), 2)
-	
-	// Find our test rules
-	foundRule1 := false
-	foundRule2 := false
+	names := make(map[string]string)
 	for _, rule := range rules {
-		if rule.ID == "rule-001" {
-			foundRule1 = true
-			assert.Equal(t, "Switch Rule", rule.Name)
-		}
-		if rule.ID == "rule-002" {
-			foundRule2 = true
-			assert.Equal(t, "Router Rule", rule.Name)
-		}
+		names[rule.ID] = rule.Name
 	}
-	assert.True(t, foundRule1, "Should find rule-001")
-	assert.True(t, foundRule2, "Should find rule-002")
-}
+	assert.Equal(t, "Switch Rule", names["rule-001"])
+	assert.Equal(t, "Router Rule", names["rule-002"])
 
-func TestClassificationService_ListActiveClassificationRules(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
+	// 検索とページングは件数に反映される
+	rules, total, err = classificationService.ListClassificationRules(ctx, classification.ListQuery{Search: "router", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "rule-002", rules[0].ID)
 
+	_, _, err = classificationService.ListClassificationRules(ctx, classification.ListQuery{Sort: "name"})
+	assert.ErrorIs(t, err, ErrInvalidListQuery)
+}
+
+func TestClassificationService_ApplyOnlyActiveRules(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
 	ctx := context.Background()
+	seedUnclassifiedDevices(t, setup, "device-001", "device-002")
 
-	// Create active and inactive rules
-	activeRule := testutil.CreateTestClassificationRule("active-rule", "Active Rule")
-	activeRule.IsActive = true
-	
 	inactiveRule := testutil.CreateTestClassificationRule("inactive-rule", "Inactive Rule")
 	inactiveRule.IsActive = false
+	inactiveRule.Priority = 200
+	inactiveRule.DeviceType = "inactive-type"
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, inactiveRule))
 
-	// Save rules
-	err := setup.ClassificationService.SaveClassificationRule(ctx, activeRule)
+	activeRule := testutil.CreateTestClassificationRule("active-rule", "Active Rule")
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, activeRule))
+
+	results, err := classificationService.ApplyClassificationRules(ctx, []string{"device-001", "device-002"})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	device, err := setup.Repo.GetDevice(ctx, "device-001")
 	require.NoError(t, err)
-	
-	err = setup.ClassificationService.SaveClassificationRule(ctx, inactiveRule)
+	require.NotNil(t, device)
+	assert.Equal(t, "network-switch", device.DeviceType)
+	assert.Equal(t, "rule:Active Rule", device.ClassifiedBy)
+}
+
+func TestClassificationService_ManualClassificationIsKept(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
+	ctx := context.Background()
+	seedUnclassifiedDevices(t, setup, "device-001")
+
+	require.NoError(t, classificationService.ClassifyDevice(ctx, "device-001", 2, "router", "admin", ""))
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, testutil.CreateTestClassificationRule("rule-001", "Switch Rule")))
+
+	results, err := classificationService.ApplyClassificationRules(ctx, []string{"device-001"})
 	require.NoError(t, err)
+	assert.Empty(t, results, "Rules should not override manual classifications")
 
-	// List only active rules
-	activeRules, err := setup.ClassificationService.ListActiveClassificationRules(ctx)
+	device, err := setup.Repo.GetDevice(ctx, "device-001")
 	require.NoError(t, err)
+	assert.Equal(t, "router", device.DeviceType)
+	assert.Equal(t, "user:admin", device.ClassifiedBy)
 
-	// Find our active rule (should be present)
-	foundActive := false
-	foundInactive := false
-	for _, rule := range activeRules {
-		if rule.ID == "active-rule" {
-			foundActive = true
-			assert.True(t, rule.IsActive)
-		}
-		if rule.ID == "inactive-rule" {
-			foundInactive = true
-		}
+	err = classificationService.ClassifyDevice(ctx, "no-such-device", 1, "router", "admin", "")
+	assert.Error(t, err)
+}
+
+func TestClassificationService_DirectoryUsernames(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
+	ctx := context.Background()
+	seedUnclassifiedDevices(t, setup, "device-001")
+
+	// LDAP/OIDC のユーザー名はそのまま記録する
+	require.NoError(t, classificationService.ClassifyDevice(ctx, "device-001", 2, "router", "taro.yamada@example.com", ""))
+
+	device, err := setup.Repo.GetDevice(ctx, "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, "user:taro.yamada@example.com", device.ClassifiedBy)
+}
+
+func TestClassificationService_ChangesStampUpdatedAt(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
+	ctx := context.Background()
+	seedUnclassifiedDevices(t, setup, "device-001", "device-002")
+
+	stale := time.Now().Add(-time.Hour)
+	for _, id := range []string{"device-001", "device-002"} {
+		device, err := setup.Repo.GetDevice(ctx, id)
+		require.NoError(t, err)
+		device.UpdatedAt = stale
+		require.NoError(t, setup.Repo.UpdateDevice(ctx, *device))
+	}
+
+	assertStamped := func(id string) {
+		t.Helper()
+		device, err := setup.Repo.GetDevice(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, device.UpdatedAt.After(stale.Add(time.Minute)), "%s updated_at should move forward, got %s", id, device.UpdatedAt)
 	}
-	assert.True(t, foundActive, "Should find active rule")
-	assert.False(t, foundInactive, "Should not find inactive rule in active list")
+
+	require.NoError(t, classificationService.ClassifyDevice(ctx, "device-001", 2, "router", "admin", ""))
+	assertStamped("device-001")
+
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, testutil.CreateTestClassificationRule("rule-001", "Switch Rule")))
+	_, err := classificationService.ApplyClassificationRules(ctx, []string{"device-002"})
+	require.NoError(t, err)
+	assertStamped("device-002")
+
+	device, err := setup.Repo.GetDevice(ctx, "device-001")
+	require.NoError(t, err)
+	device.UpdatedAt = stale
+	require.NoError(t, setup.Repo.UpdateDevice(ctx, *device))
+	require.NoError(t, classificationService.DeleteDeviceClassification(ctx, "device-001"))
+	assertStamped("device-001")
 }
 
-func TestClassificationService_ListUnclassifiedDevices(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-	setup.SeedTestData(t)
+func TestClassificationService_RecordsHistory(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
+	ctx := context.Background()
+	seedUnclassifiedDevices(t, setup, "device-001", "device-002")
+
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, testutil.CreateTestClassificationRule("rule-001", "Switch Rule")))
+	_, err := classificationService.ApplyClassificationRules(ctx, []string{"device-001"})
+	require.NoError(t, err)
+	require.NoError(t, classificationService.ClassifyDevice(ctx, "device-001", 2, "router", "admin", ""))
+	// 同じ分類のやり直しは記録しない
+	require.NoError(t, classificationService.ClassifyDevice(ctx, "device-001", 2, "router", "admin", "again"))
+	require.NoError(t, classificationService.ClassifyDevice(ctx, "device-001", 3, "server", "admin", "moved to the access layer"))
+	require.NoError(t, classificationService.DeleteDeviceClassification(ctx, "device-001"))
+
+	changes, err := classificationService.GetClassificationHistory(ctx, "device-001", 100)
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+
+	// 新しい順
+	removed, reasoned, override, rule := changes[0], changes[1], changes[2], changes[3]
+
+	assert.Equal(t, classification.ChangeSourceRule, rule.Source)
+	assert.Equal(t, "matched rule Switch Rule", rule.Reason)
+	assert.Nil(t, rule.PreviousLayerID)
+	assert.Equal(t, "system:auto", rule.PreviousClassifiedBy)
+	require.NotNil(t, rule.LayerID)
+	assert.Equal(t, 1, *rule.LayerID)
+	assert.Equal(t, "network-switch", rule.DeviceType)
+	assert.Equal(t, "rule:Switch Rule", rule.ClassifiedBy)
+
+	assert.Equal(t, classification.ChangeSourceUser, override.Source)
+	assert.Equal(t, "manual override of rule:Switch Rule", override.Reason)
+	assert.Equal(t, "network-switch", override.PreviousDeviceType)
+	assert.Equal(t, "router", override.DeviceType)
+	assert.Equal(t, "user:admin", override.ClassifiedBy)
+
+	assert.Equal(t, "moved to the access layer", reasoned.Reason)
+	require.NotNil(t, reasoned.PreviousLayerID)
+	assert.Equal(t, 2, *reasoned.PreviousLayerID)
+	assert.Equal(t, "server", reasoned.DeviceType)
+
+	assert.Equal(t, classification.ChangeSourceUser, removed.Source)
+	assert.Equal(t, "classification removed", removed.Reason)
+	assert.Equal(t, "server", removed.PreviousDeviceType)
+	assert.Nil(t, removed.LayerID)
+	assert.Empty(t, removed.ClassifiedBy)
+
+	limited, err := classificationService.GetClassificationHistory(ctx, "device-001", 1)
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+	assert.Equal(t, removed.ID, limited[0].ID)
 
+	// 他のデバイスの履歴は混ざらない
+	untouched, err := classificationService.GetClassificationHistory(ctx, "device-002", 100)
+	require.NoError(t, err)
+	assert.Empty(t, untouched)
+}
+
+func TestClassificationService_EnforcedMetadataSchema(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
 	ctx := context.Background()
+	seedUnclassifiedDevices(t, setup, "device-001", "device-002")
+
+	device, err := setup.Repo.GetDevice(ctx, "device-002")
+	require.NoError(t, err)
+	device.Metadata = map[string]string{"site": "tokyo"}
+	require.NoError(t, setup.Repo.UpdateDevice(ctx, *device))
+
+	classificationService.SetMetadataSchema(topology.MetadataSchema{
+		Required: []topology.MetadataRequirement{{DeviceType: "network-switch", Keys: []string{"site"}}},
+		Enforce:  true,
+	})
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, testutil.CreateTestClassificationRule("rule-001", "Switch Rule")))
+
+	// ルールも必須メタデータを欠くデバイスは分類しない
+	results, err := classificationService.ApplyClassificationRules(ctx, []string{"device-001", "device-002"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "device-002", results[0].DeviceID)
+
+	device, err = setup.Repo.GetDevice(ctx, "device-001")
+	require.NoError(t, err)
+	assert.Empty(t, device.DeviceType)
+
+	err = classificationService.ClassifyDevice(ctx, "device-001", 1, "network-switch", "admin", "")
+	assert.ErrorIs(t, err, ErrMissingRequiredMetadata)
+}
 
-	// All devices should be unclassified initially
-	devices, err := setup.ClassificationService.ListUnclassifiedDevices(ctx)
+func TestClassificationService_ListUnclassifiedDevices(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
+	setup.SeedTestData(t)
+	seedUnclassifiedDevices(t, setup, "new-001", "new-002", "new-003")
+
+	devices, err := classificationService.ListUnclassifiedDevices(context.Background())
 	require.NoError(t, err)
-	
-	// Should have our test devices
-	assert.GreaterOrEqual(t, len(devices), 3)
-	
-	// Check that we have our test device IDs
+
 	deviceIDs := make(map[string]bool)
 	for _, device := range devices {
 		deviceIDs[device.ID] = true
 	}
-	assert.True(t, deviceIDs["device-001"], "Should find device-001")
-	assert.True(t, deviceIDs["device-002"], "Should find device-002")
-	assert.True(t, deviceIDs["device-003"], "Should find device-003")
+	assert.Len(t, deviceIDs, 3)
+	assert.True(t, deviceIDs["new-001"], "Should find new-001")
+	assert.False(t, deviceIDs["device-001"], "Classified devices should not be listed")
 }
 
 func TestClassificationService_ListUnclassifiedDevicesWithPagination(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-	setup.SeedTestData(t)
-
+	classificationService, setup := newTestClassificationService(t)
+	seedUnclassifiedDevices(t, setup, "new-001", "new-002", "new-003")
 	ctx := context.Background()
 
-	// Test pagination with limit 2, offset 0
-	devices, err := setup.ClassificationService.ListUnclassifiedDevicesWithPagination(ctx, 2, 0)
+	devices, total, err := classificationService.ListUnclassifiedDevicesWithPagination(ctx, 2, 0)
 	require.NoError(t, err)
-	
-	// Should get exactly 2 devices
-	assert.Equal(t, 2, len(devices))
+	assert.Equal(t, 3, total)
+	assert.Len(t, devices, 2)
 
-	// Test pagination with limit 2, offset 1
-	devicesOffset, err := setup.ClassificationService.ListUnclassifiedDevicesWithPagination(ctx, 2, 1)
+	devicesOffset, total, err := classificationService.ListUnclassifiedDevicesWithPagination(ctx, 2, 2)
 	require.NoError(t, err)
-	
-	// Should get at least 1 device (remaining ones)
-	assert.GreaterOrEqual(t, len(devicesOffset), 1)
-	
-	// Devices should be different from first page
-	if len(devices) > 0 && len(devicesOffset) > 0 {
-		assert.NotEqual(t, devices[0].ID, devicesOffset[0].ID)
+	assert.Equal(t, 3, total)
+	require.Len(t, devicesOffset, 1)
+	assert.NotEqual(t, devices[0].ID, devicesOffset[0].ID)
+	assert.NotEqual(t, devices[1].ID, devicesOffset[0].ID)
+}
+
+func TestClassificationService_ListUnclassifiedDevicesByCursor(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
+	setup.SeedTestData(t)
+	seedUnclassifiedDevices(t, setup, "new-001", "new-002", "new-003", "new-004", "new-005")
+	ctx := context.Background()
+
+	// 分類済みデバイスを挟んでもページをまたいで未分類デバイスだけを返す
+	setDevicePageSize(t, 2)
+
+	var ids []string
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		devices, next, err := classificationService.ListUnclassifiedDevicesByCursor(ctx, cursor, 2)
+		require.NoError(t, err)
+		for _, device := range devices {
+			ids = append(ids, device.ID)
+		}
+		if next == "" {
+			break
+		}
+		require.Len(t, devices, 2)
+		cursor = next
 	}
+	assert.Equal(t, []string{"new-001", "new-002", "new-003", "new-004", "new-005"}, ids)
+
+	_, _, err := classificationService.ListUnclassifiedDevicesByCursor(ctx, "!!", 2)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
 }
 
-func TestClassificationService_CountUnclassifiedDevices(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
+func TestClassificationService_EvaluateCoverageReadsEveryPage(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
 	setup.SeedTestData(t)
+	seedUnclassifiedDevices(t, setup, "new-001", "new-002", "new-003")
 
-	ctx := context.Background()
+	// 1ページに収まらない台数でも全デバイスを数える
+	setDevicePageSize(t, 2)
 
-	// Count unclassified devices
-	count, err := setup.ClassificationService.CountUnclassifiedDevices(ctx)
+	report, err := classificationService.EvaluateCoverage(context.Background(), classification.CoverageGate{Threshold: 50})
 	require.NoError(t, err)
-	
-	// Should have at least our 3 test devices
-	assert.GreaterOrEqual(t, count, 3)
-}
\ No newline at end of file
+	assert.Equal(t, 6, report.TotalDevices)
+	assert.Equal(t, 3, report.ClassifiedDevices)
+	assert.Equal(t, []string{"new-001", "new-002", "new-003"}, report.UnclassifiedDevices)
+	assert.InDelta(t, 50, report.Coverage, 0.001)
+	assert.True(t, report.Passed)
+}
+
+func TestClassificationService_CoverageGate(t *testing.T) {
+	classificationService, _ := newTestClassificationService(t)
+
+	assert.Equal(t, float64(classification.DefaultCoverageThreshold), classificationService.CoverageGate().Threshold)
+
+	gate := classification.CoverageGate{Threshold: 0, DeviceTypes: []string{"router"}}
+	classificationService.SetCoverageGate(gate)
+	assert.Equal(t, gate, classificationService.CoverageGate())
+}
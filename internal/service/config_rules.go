@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// ErrConfigManagedRule is returned when a rule imported from the config file is edited through the API
+var ErrConfigManagedRule = apperror.Conflict("config_managed_rule", "rule is managed by hierarchy.naming_rules in the config file")
+
+// SyncConfigRules makes the config-sourced classification rules match rules, which are
+// converted from hierarchy.naming_rules. Rules no longer present in the config are removed;
+// rules created through the API or accepted suggestions are never touched.
+func (s *ClassificationService) SyncConfigRules(ctx context.Context, rules []classification.ClassificationRule, dryRun bool) (*classification.ConfigRuleSyncResult, error) {
+	existing, err := s.classificationRepo.ListClassificationRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classification rules: %w", err)
+	}
+
+	current := make(map[string]classification.ClassificationRule)
+	for _, rule := range existing {
+		if rule.IsConfigSourced() {
+			current[rule.ID] = rule
+		}
+	}
+
+	result := &classification.ConfigRuleSyncResult{
+		DryRun:  dryRun,
+		Created: []string{},
+		Updated: []string{},
+		Removed: []string{},
+	}
+	now := time.Now()
+	wanted := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		wanted[rule.ID] = true
+		previous, exists := current[rule.ID]
+
+		if exists && configRuleUnchanged(previous, rule) {
+			result.Unchanged++
+			continue
+		}
+
+		rule.UpdatedAt = now
+		if exists {
+			result.Updated = append(result.Updated, rule.ID)
+			if dryRun {
+				continue
+			}
+			rule.CreatedAt = previous.CreatedAt
+			if err := s.classificationRepo.UpdateClassificationRule(ctx, rule); err != nil {
+				return nil, fmt.Errorf("failed to update rule %s: %w", rule.ID, err)
+			}
+			continue
+		}
+
+		result.Created = append(result.Created, rule.ID)
+		if dryRun {
+			continue
+		}
+		rule.CreatedAt = now
+		if err := s.classificationRepo.SaveClassificationRule(ctx, rule); err != nil {
+			return nil, fmt.Errorf("failed to save rule %s: %w", rule.ID, err)
+		}
+	}
+
+	// 設定ファイルから削除された命名規則
+	for _, rule := range existing {
+		if !rule.IsConfigSourced() || wanted[rule.ID] {
+			continue
+		}
+		result.Removed = append(result.Removed, rule.ID)
+		if dryRun {
+			continue
+		}
+		if err := s.classificationRepo.DeleteClassificationRule(ctx, rule.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete rule %s: %w", rule.ID, err)
+		}
+	}
+
+	return result, nil
+}
+
+// configRuleUnchanged compares the fields derived from the config file
+func configRuleUnchanged(previous, rule classification.ClassificationRule) bool {
+	return previous.Name == rule.Name &&
+		previous.Description == rule.Description &&
+		previous.Layer == rule.Layer &&
+		previous.DeviceType == rule.DeviceType &&
+		previous.Priority == rule.Priority &&
+		previous.IsActive == rule.IsActive &&
+		reflect.DeepEqual(previous.Conditions, rule.Conditions)
+}
+
+// activeRules returns the active rules in the order they are matched: by priority, except that
+// the rules imported from the config file come after every other rule
+func (s *ClassificationService) activeRules(ctx context.Context) ([]classification.ClassificationRule, error) {
+	rules, err := s.classificationRepo.ListActiveClassificationRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active rules: %w", err)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return !rules[i].IsConfigSourced() && rules[j].IsConfigSourced()
+	})
+	return rules, nil
+}
+
+// checkRuleEditable rejects changes to rules managed by the config file
+func (s *ClassificationService) checkRuleEditable(ctx context.Context, ruleID string) error {
+	rule, err := s.classificationRepo.GetClassificationRule(ctx, ruleID)
+	if err != nil {
+		return fmt.Errorf("failed to get rule: %w", err)
+	}
+	if rule != nil && rule.IsConfigSourced() {
+		return fmt.Errorf("%w: %s", ErrConfigManagedRule, ruleID)
+	}
+	return nil
+}
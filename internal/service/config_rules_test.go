@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/config"
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func namingRulesConfig(rules ...config.NamingRule) *config.Config {
+	cfg := &config.Config{}
+	cfg.Hierarchy.DeviceTypes = map[string]int{"core": 1, "distribution": 2, "access": 3}
+	cfg.Hierarchy.NamingRules = rules
+	return cfg
+}
+
+func TestClassificationService_SyncConfigRules(t *testing.T) {
+	classificationService, _ := newTestClassificationService(t)
+	ctx := context.Background()
+
+	apiRule := testutil.CreateTestClassificationRule("api-rule", "API Rule")
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, apiRule))
+
+	cfg := namingRulesConfig(
+		config.NamingRule{Pattern: "^core-.*", Type: "core"},
+		config.NamingRule{Pattern: "^dist-.*", Type: "distribution"},
+	)
+	rules := cfg.GetNamingClassificationRules()
+	coreID := classification.ConfigRuleID("^core-.*", "core")
+	distID := classification.ConfigRuleID("^dist-.*", "distribution")
+
+	// ドライランは何も書き込まない
+	result, err := classificationService.SyncConfigRules(ctx, rules, true)
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.ElementsMatch(t, []string{coreID, distID}, result.Created)
+	stored, err := classificationService.GetClassificationRule(ctx, coreID)
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	result, err = classificationService.SyncConfigRules(ctx, rules, false)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{coreID, distID}, result.Created)
+
+	stored, err = classificationService.GetClassificationRule(ctx, distID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.True(t, stored.IsConfigSourced())
+	assert.Equal(t, 2, stored.Layer)
+	assert.Equal(t, "distribution", stored.DeviceType)
+	assert.Equal(t, 1, stored.Priority, "config rules are ranked in config order")
+	require.Len(t, stored.Conditions, 1)
+	assert.Equal(t, "regex", stored.Conditions[0].Operator)
+
+	// 変更がなければ何もしない
+	result, err = classificationService.SyncConfigRules(ctx, rules, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Unchanged)
+	assert.Empty(t, result.Created)
+	assert.Empty(t, result.Updated)
+	assert.Empty(t, result.Removed)
+
+	// 階層の変更は更新、設定から消えた規則は削除
+	cfg = namingRulesConfig(config.NamingRule{Pattern: "^core-.*", Type: "core"})
+	cfg.Hierarchy.DeviceTypes["core"] = 0
+	result, err = classificationService.SyncConfigRules(ctx, cfg.GetNamingClassificationRules(), false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{coreID}, result.Updated)
+	assert.Equal(t, []string{distID}, result.Removed)
+
+	stored, err = classificationService.GetClassificationRule(ctx, coreID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, 0, stored.Layer)
+	stored, err = classificationService.GetClassificationRule(ctx, distID)
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+
+	// API で作成したルールは同期の対象外
+	stored, err = classificationService.GetClassificationRule(ctx, "api-rule")
+	require.NoError(t, err)
+	assert.NotNil(t, stored)
+
+	result, err = classificationService.SyncConfigRules(ctx, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{coreID}, result.Removed)
+	stored, err = classificationService.GetClassificationRule(ctx, "api-rule")
+	require.NoError(t, err)
+	assert.NotNil(t, stored)
+}
+
+func TestClassificationService_ConfigRulesAreReadOnly(t *testing.T) {
+	classificationService, _ := newTestClassificationService(t)
+	ctx := context.Background()
+
+	rules := namingRulesConfig(config.NamingRule{Pattern: "^core-.*", Type: "core"}).GetNamingClassificationRules()
+	_, err := classificationService.SyncConfigRules(ctx, rules, false)
+	require.NoError(t, err)
+
+	rule := rules[0]
+	rule.Layer = 3
+	assert.ErrorIs(t, classificationService.UpdateClassificationRule(ctx, rule), ErrConfigManagedRule)
+	assert.ErrorIs(t, classificationService.DeleteClassificationRule(ctx, rule.ID), ErrConfigManagedRule)
+
+	stored, err := classificationService.GetClassificationRule(ctx, rule.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, 1, stored.Layer)
+
+	apiRule := testutil.CreateTestClassificationRule("api-rule", "API Rule")
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, apiRule))
+	apiRule.Layer = 3
+	assert.NoError(t, classificationService.UpdateClassificationRule(ctx, apiRule))
+	assert.NoError(t, classificationService.DeleteClassificationRule(ctx, apiRule.ID))
+}
+
+func TestClassificationService_ConfigRulesClassifyBelowAPIRules(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
+	ctx := context.Background()
+	seedUnclassifiedDevices(t, setup, "core-01", "Test-core-02")
+
+	rules := namingRulesConfig(config.NamingRule{Pattern: "core-", Type: "core"}).GetNamingClassificationRules()
+	_, err := classificationService.SyncConfigRules(ctx, rules, false)
+	require.NoError(t, err)
+
+	// Test-core-02 は API のルールにも一致する（優先度が低くても API のルールが先）
+	apiRule := testutil.CreateTestClassificationRule("api-rule", "API Rule")
+	apiRule.Priority = 0
+	apiRule.Conditions = []classification.RuleCondition{{Field: "name", Operator: "starts_with", Value: "Test-"}}
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, apiRule))
+
+	_, err = classificationService.ApplyClassificationRules(ctx, []string{"core-01", "Test-core-02"})
+	require.NoError(t, err)
+
+	device, err := setup.Repo.GetDevice(ctx, "core-01")
+	require.NoError(t, err)
+	assert.Equal(t, "core", device.DeviceType)
+	assert.Equal(t, "rule:"+rules[0].Name, device.ClassifiedBy)
+
+	device, err = setup.Repo.GetDevice(ctx, "Test-core-02")
+	require.NoError(t, err)
+	assert.Equal(t, "network-switch", device.DeviceType)
+}
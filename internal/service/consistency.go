@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrConsistencyCheckUnsupported is returned when the repository cannot list raw links
+var ErrConsistencyCheckUnsupported = errors.New("consistency check is not supported by this repository")
+
+// ConsistencyService finds and repairs referential problems in the stored topology
+type ConsistencyService struct {
+	topologyRepo       topology.Repository
+	classificationRepo classification.Repository
+	consistencyRepo    topology.ConsistencyRepository // nil = 未対応
+}
+
+func NewConsistencyService(topologyRepo topology.Repository, classificationRepo classification.Repository) *ConsistencyService {
+	consistencyRepo, _ := topologyRepo.(topology.ConsistencyRepository)
+
+	return &ConsistencyService{
+		topologyRepo:       topologyRepo,
+		classificationRepo: classificationRepo,
+		consistencyRepo:    consistencyRepo,
+	}
+}
+
+// Check scans for referential problems and repairs the issues of the kinds in repair.
+// With dryRun the repairs are only counted.
+func (s *ConsistencyService) Check(ctx context.Context, repair []topology.ConsistencyIssueKind, dryRun bool) (*topology.ConsistencyReport, error) {
+	if s.consistencyRepo == nil {
+		return nil, ErrConsistencyCheckUnsupported
+	}
+
+	devices, err := ListAllDevices(ctx, s.topologyRepo)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.consistencyRepo.ListAllLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := s.classificationRepo.ListHierarchyLayers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hierarchy layers: %w", err)
+	}
+	layerIDs := make(map[int]bool, len(layers))
+	for _, layer := range layers {
+		layerIDs[layer.ID] = true
+	}
+
+	rules, err := s.classificationRepo.ListClassificationRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classification rules: %w", err)
+	}
+	// デバイスにはルール名で記録されている
+	ruleNames := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		ruleNames[rule.Name] = true
+	}
+
+	report := &topology.ConsistencyReport{
+		Devices: len(devices),
+		Links:   len(links),
+		Issues:  topology.CheckConsistency(devices, links, layerIDs, ruleNames),
+		Counts:  make(map[topology.ConsistencyIssueKind]int),
+		DryRun:  dryRun,
+	}
+	for _, issue := range report.Issues {
+		report.Counts[issue.Kind]++
+	}
+
+	if len(repair) == 0 {
+		return report, nil
+	}
+	report.Repaired = make(map[topology.ConsistencyIssueKind]int)
+	for _, kind := range repair {
+		var linkIDs, deviceIDs []string
+		for _, issue := range report.Issues {
+			if issue.Kind != kind {
+				continue
+			}
+			if issue.LinkID != "" {
+				linkIDs = append(linkIDs, issue.LinkID)
+			} else {
+				deviceIDs = append(deviceIDs, issue.DeviceID)
+			}
+		}
+		if dryRun {
+			report.Repaired[kind] = len(linkIDs) + len(deviceIDs)
+			continue
+		}
+
+		removed, err := s.consistencyRepo.RemoveLinks(ctx, linkIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to repair %s: %w", kind, err)
+		}
+		cleared, err := s.consistencyRepo.ClearDeviceClassifications(ctx, deviceIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to repair %s: %w", kind, err)
+		}
+		report.Repaired[kind] = removed + cleared
+	}
+
+	return report, nil
+}
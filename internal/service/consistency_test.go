@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsistencyService_CheckReadsEveryPage(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+	ctx := context.Background()
+
+	// device-003 の先に 1ページに収まらないデバイスをつなぐ
+	var devices []topology.Device
+	var links []topology.Link
+	previous := "device-003"
+	for i := 1; i <= 6; i++ {
+		id := fmt.Sprintf("edge-%02d", i)
+		devices = append(devices, testutil.CreateTestDevice(id))
+		links = append(links, testutil.CreateTestLink("link-"+id, previous, id))
+		previous = id
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, devices))
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, links))
+
+	setDevicePageSize(t, 2)
+	consistencyService := NewConsistencyService(setup.Repo, setup.Repo)
+	report, err := consistencyService.Check(ctx, []topology.ConsistencyIssueKind{topology.IssueDanglingLink, topology.IssueOrphanedClassification}, false)
+	require.NoError(t, err)
+
+	// 後ろのページのデバイスへのリンクを宙に浮いたリンクとして削除しない
+	assert.Equal(t, 9, report.Devices)
+	assert.Equal(t, 8, report.Links)
+	assert.Zero(t, report.Counts[topology.IssueDanglingLink])
+	assert.Zero(t, report.Repaired[topology.IssueDanglingLink])
+	link, err := setup.Repo.GetLink(ctx, "link-edge-06")
+	require.NoError(t, err)
+	assert.NotNil(t, link)
+
+	// 存在しないルールによる分類は未分類に戻す
+	assert.Equal(t, 9, report.Counts[topology.IssueOrphanedClassification])
+	assert.Equal(t, 9, report.Repaired[topology.IssueOrphanedClassification])
+	device, err := setup.Repo.GetDevice(ctx, "edge-06")
+	require.NoError(t, err)
+	assert.Nil(t, device.LayerID)
+	assert.Equal(t, "system:auto", device.ClassifiedBy)
+}
@@ -0,0 +1,37 @@
+// Package contract defines the service interfaces consumed by API handlers, so cached, mocked
+// or remote implementations can be injected in place of the services of package service.
+package contract
+
+import (
+	"context"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+// Visualization builds visual topologies for the frontend
+type Visualization interface {
+	GetSimpleVisualTopology(ctx context.Context, rootDeviceID string, depth int, filter topology.SubTopologyFilter) (*visualization.VisualTopology, error)
+	GetVisualTopologyWithGrouping(ctx context.Context, rootDeviceID string, depth int, groupingOpts visualization.GroupingOptions) (*visualization.VisualTopology, error)
+	GetFilteredVisualTopology(ctx context.Context, rootDeviceID string, depth int, groupingOpts visualization.GroupingOptions, filter topology.SubTopologyFilter) (*visualization.VisualTopology, error)
+	GetDeviceSetTopology(ctx context.Context, devices []topology.Device) (*visualization.VisualTopology, error)
+	GetPortTopology(ctx context.Context, rootDeviceID string, depth int, peerID string) (*visualization.VisualTopology, error)
+	PreviewGroups(ctx context.Context, rootDeviceID string, depth int, groupingOpts visualization.GroupingOptions) (*visualization.GroupingPreview, error)
+	GetGroupMembers(ctx context.Context, rootDeviceID, groupID string, depth int, groupingOpts visualization.GroupingOptions) (*visualization.GroupMembers, error)
+	ExpandGroup(ctx context.Context, rootDeviceID, groupID string, depth, expandDepth int, groupingOpts visualization.GroupingOptions, positions map[string]visualization.Position) (*visualization.VisualTopology, error)
+	// AddLayerBands sets the layer bands of a hierarchical layout in place
+	AddLayerBands(ctx context.Context, visualTopology *visualization.VisualTopology) error
+}
+
+// ChangeObserver is implemented by injected services that cache topology data. The server forwards
+// the device and link changes made by any writer so stale entries can be dropped; a ChangeOpResync
+// change means changes may have been missed and everything cached is stale.
+type ChangeObserver interface {
+	ObserveChange(change topology.Change)
+}
+
+// ViewRequestFlusher is implemented by services that buffer the view request counts used to
+// precompute popular layouts. The server flushes them periodically and on Shutdown.
+type ViewRequestFlusher interface {
+	FlushViewRequests(ctx context.Context) (int, error)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// maxDevicePageSize is the largest page of the device list
+const maxDevicePageSize = 1000
+
+// ErrCountsUnsupported is returned when the repository cannot count devices and links
+var ErrCountsUnsupported = errors.New("counting devices and links is not supported by this repository")
+
+// ErrInvalidCursor is returned when a pagination cursor is not a next_cursor of the device list
+var ErrInvalidCursor = apperror.Validation("invalid_cursor", "invalid pagination cursor")
+
+// TopologyCounts is the number of devices and links
+type TopologyCounts struct {
+	Devices topology.RowCount `json:"devices"`
+	Links   topology.RowCount `json:"links"`
+}
+
+// ListDevices returns a page of devices, newest first. Unless exact is set, the total of a large
+// device table is estimated from the table statistics.
+func (s *TopologyService) ListDevices(ctx context.Context, page, pageSize int, exact bool) ([]topology.Device, *topology.PaginationResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > maxDevicePageSize {
+		pageSize = maxDevicePageSize
+	}
+
+	devices, pagination, err := s.repo.GetDevices(ctx, topology.PaginationOptions{
+		Page:     page,
+		PageSize: pageSize,
+		Exact:    exact,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	if devices == nil {
+		devices = []topology.Device{}
+	}
+	return devices, pagination, nil
+}
+
+// ListDevicesByCursor returns the page of devices after cursor in ID order (empty cursor = first
+// page). Its pagination carries the next_cursor of the following page, and pages stay as fast as the
+// first however deep the client goes, unlike the page numbers of ListDevices.
+func (s *TopologyService) ListDevicesByCursor(ctx context.Context, cursor string, pageSize int, exact bool) ([]topology.Device, *topology.PaginationResult, error) {
+	if pageSize < 1 || pageSize > maxDevicePageSize {
+		pageSize = maxDevicePageSize
+	}
+
+	opts := topology.PaginationOptions{
+		PageSize: pageSize,
+		Exact:    exact,
+		Keyset:   true,
+	}
+	if cursor != "" {
+		after, err := topology.DecodeCursor(cursor)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		opts.After = after
+	}
+
+	devices, pagination, err := s.repo.GetDevices(ctx, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	if devices == nil {
+		devices = []topology.Device{}
+	}
+	return devices, pagination, nil
+}
+
+// CountTopology counts the devices and links, estimating large tables unless exact is set
+func (s *TopologyService) CountTopology(ctx context.Context, exact bool) (*TopologyCounts, error) {
+	if s.counter == nil {
+		return nil, ErrCountsUnsupported
+	}
+
+	devices, err := s.counter.CountDevices(ctx, exact)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.counter.CountLinks(ctx, exact)
+	if err != nil {
+		return nil, err
+	}
+	return &TopologyCounts{Devices: devices, Links: links}, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrInvalidCrossLinkQuery is returned when a cross-link filter cannot be parsed or the two
+// filters select overlapping device sets
+var ErrInvalidCrossLinkQuery = apperror.Validation("invalid_cross_link_query", "invalid cross-link query")
+
+// FindCrossLinks reports the links between the devices matching filterA and those matching
+// filterB, with their aggregate bandwidth. Filters use the DeviceFilter syntax.
+func (s *TopologyService) FindCrossLinks(ctx context.Context, filterA, filterB string) (*topology.CrossLinkReport, error) {
+	now := time.Now()
+	parsedA, err := topology.ParseDeviceFilter(filterA, now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: filter a: %v", ErrInvalidCrossLinkQuery, err)
+	}
+	parsedB, err := topology.ParseDeviceFilter(filterB, now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: filter b: %v", ErrInvalidCrossLinkQuery, err)
+	}
+
+	setA := make(map[string]bool)
+	setB := make(map[string]bool)
+	var idsA, overlap []string
+	err = walkDevices(ctx, s.repo, "", func(device topology.Device) bool {
+		inA, inB := parsedA.Matches(device), parsedB.Matches(device)
+		if inA && inB {
+			overlap = append(overlap, device.ID)
+		}
+		if inA {
+			setA[device.ID] = true
+			idsA = append(idsA, device.ID)
+		}
+		if inB {
+			setB[device.ID] = true
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(overlap) > 0 {
+		sort.Strings(overlap)
+		return nil, fmt.Errorf("%w: devices match both filters: %s", ErrInvalidCrossLinkQuery, strings.Join(overlap, ", "))
+	}
+
+	// 交差リンクは必ず A 側の端点を持つため、A のデバイスのリンクだけ取得すればよい
+	var links []topology.Link
+	for _, deviceID := range idsA {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		deviceLinks, err := s.repo.GetDeviceLinks(ctx, deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", deviceID, err)
+		}
+		links = append(links, deviceLinks...)
+	}
+
+	report := topology.FindCrossLinks(setA, setB, links)
+	report.FilterA = filterA
+	report.FilterB = filterB
+	return &report, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopologyService_FindCrossLinksOverEveryPage(t *testing.T) {
+	topologyService, setup := newTestTopologyService(t)
+	ctx := context.Background()
+
+	var devices []topology.Device
+	var links []topology.Link
+	for i := 1; i <= 5; i++ {
+		spine := testutil.CreateTestDevice(fmt.Sprintf("spine-%02d", i))
+		spine.Type = "spine"
+		leaf := testutil.CreateTestDevice(fmt.Sprintf("leaf-%02d", i))
+		leaf.Type = "leaf"
+		devices = append(devices, spine, leaf)
+		links = append(links, testutil.CreateTestLink(fmt.Sprintf("uplink-%02d", i), leaf.ID, spine.ID))
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, devices))
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, links))
+
+	// 1ページに収まらなくても両側の全デバイスを対象にする
+	setDevicePageSize(t, 3)
+	report, err := topologyService.FindCrossLinks(ctx, "type=spine", "type=leaf")
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.DevicesA)
+	assert.Equal(t, 5, report.DevicesB)
+	assert.Equal(t, 5, report.TotalLinks)
+	assert.Equal(t, "type=spine", report.FilterA)
+}
+
+func TestTopologyService_FindCrossLinksValidation(t *testing.T) {
+	topologyService, _ := newTestTopologyService(t)
+	ctx := context.Background()
+
+	_, err := topologyService.FindCrossLinks(ctx, "bogus", "type=switch")
+	assert.ErrorIs(t, err, ErrInvalidCrossLinkQuery)
+
+	// 両方に一致するデバイスは列挙して拒否する
+	_, err = topologyService.FindCrossLinks(ctx, "type=switch", "layer=1")
+	assert.ErrorIs(t, err, ErrInvalidCrossLinkQuery)
+	assert.Contains(t, err.Error(), "device-001, device-002, device-003")
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// bulkDeleteConfirmThreshold is the number of devices above which a deletion must be confirmed
+const bulkDeleteConfirmThreshold = 50
+
+var (
+	// ErrInvalidDeviceFilter is returned when a bulk deletion filter cannot be parsed
+	ErrInvalidDeviceFilter = apperror.Validation("invalid_device_filter", "invalid device filter")
+	// ErrDeletionConfirmationRequired is returned when a large deletion lacks a valid confirmation token
+	ErrDeletionConfirmationRequired = apperror.Conflict("deletion_confirmation_required", "deletion confirmation required")
+	// ErrBulkDeleteUnsupported is returned when the repository cannot delete devices
+	ErrBulkDeleteUnsupported = errors.New("bulk deletion is not supported by this repository")
+)
+
+// DeleteDevices deletes every device matching filterExpr together with its links.
+// With dryRun nothing is deleted and the counts plus a confirmation token are returned.
+// Deleting more than bulkDeleteConfirmThreshold devices requires the token of a preceding dry run,
+// which is only valid while the matching device set is unchanged.
+func (s *TopologyService) DeleteDevices(ctx context.Context, filterExpr string, dryRun bool, confirmationToken string) (*topology.DeviceDeletionResult, error) {
+	if s.deletionRepo == nil {
+		return nil, ErrBulkDeleteUnsupported
+	}
+
+	filter, err := topology.ParseDeviceFilter(filterExpr, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDeviceFilter, err)
+	}
+
+	var deviceIDs []string
+	err = walkDevices(ctx, s.repo, "", func(device topology.Device) bool {
+		if filter.Matches(device) {
+			deviceIDs = append(deviceIDs, device.ID)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(deviceIDs)
+
+	token := deletionToken(deviceIDs)
+	confirmationRequired := len(deviceIDs) > bulkDeleteConfirmThreshold
+	if !dryRun && confirmationRequired && confirmationToken != token {
+		return nil, fmt.Errorf("%w: %d devices match; run a dry run and pass its confirmation_token", ErrDeletionConfirmationRequired, len(deviceIDs))
+	}
+
+	result, err := s.deletionRepo.DeleteDevices(ctx, deviceIDs, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete devices: %w", err)
+	}
+	result.DeviceIDs = deviceIDs
+	result.ConfirmationRequired = confirmationRequired
+	if dryRun {
+		result.ConfirmationToken = token
+	}
+
+	return result, nil
+}
+
+// deletionToken identifies the exact set of devices a deletion applies to
+func deletionToken(deviceIDs []string) string {
+	h := sha256.New()
+	for _, id := range deviceIDs {
+		h.Write([]byte(id))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
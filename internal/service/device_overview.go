@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+// DefaultDeviceOverviewEventLimit is the number of recent events returned with a device overview
+const DefaultDeviceOverviewEventLimit = 20
+
+// ErrOverviewDeviceNotFound is returned when the device of an overview does not exist
+var ErrOverviewDeviceNotFound = apperror.NotFound("device_not_found", "device not found")
+
+// DeviceOverview is everything the device detail view shows, read in one request
+type DeviceOverview struct {
+	Device         topology.Device                         `json:"device"`
+	DisplayName    string                                  `json:"display_name"`
+	WorkflowState  string                                  `json:"workflow_state"`
+	Classification *classification.DeviceClassification    `json:"classification"` // nil = 未分類
+	Layer          *classification.HierarchyLayer          `json:"layer,omitempty"`
+	Connections    *visualization.ConnectionClassification `json:"connections"`
+	RecentEvents   []topology.DeviceEvent                  `json:"recent_events"` // 分類とワークフローの変更（新しい順）
+}
+
+// DeviceOverviewService aggregates the data of the device detail view so the UI does not
+// need a separate request for the device, its classification, connections and history.
+type DeviceOverviewService struct {
+	topologyRepo          topology.Repository
+	workflowRepo          topology.WorkflowRepository // nil = ワークフロー履歴なし
+	visualizationService  *VisualizationService
+	classificationService *ClassificationService
+}
+
+func NewDeviceOverviewService(topologyRepo topology.Repository, visualizationService *VisualizationService, classificationService *ClassificationService) *DeviceOverviewService {
+	workflowRepo, _ := topologyRepo.(topology.WorkflowRepository)
+	return &DeviceOverviewService{
+		topologyRepo:          topologyRepo,
+		workflowRepo:          workflowRepo,
+		visualizationService:  visualizationService,
+		classificationService: classificationService,
+	}
+}
+
+// GetDeviceOverview returns the overview of a device with at most eventLimit recent events
+func (s *DeviceOverviewService) GetDeviceOverview(ctx context.Context, deviceID string, eventLimit int) (*DeviceOverview, error) {
+	device, err := s.topologyRepo.GetDevice(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if device == nil {
+		return nil, fmt.Errorf("%w: %s", ErrOverviewDeviceNotFound, deviceID)
+	}
+	if eventLimit <= 0 {
+		eventLimit = DefaultDeviceOverviewEventLimit
+	}
+
+	overview := &DeviceOverview{
+		Device:        *device,
+		WorkflowState: topology.EffectiveWorkflowState(*device),
+	}
+
+	displayNames, err := s.visualizationService.displayNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	overview.DisplayName = displayNames.Resolve(device.ID)
+
+	overview.Classification, err = s.classificationService.GetDeviceClassification(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.LayerID != nil {
+		overview.Layer, err = s.classificationService.GetHierarchyLayer(ctx, *device.LayerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hierarchy layer: %w", err)
+		}
+	}
+
+	overview.Connections, err = s.visualizationService.GetDeviceConnections(ctx, *device, displayNames)
+	if err != nil {
+		return nil, err
+	}
+
+	overview.RecentEvents, err = s.recentEvents(ctx, deviceID, eventLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return overview, nil
+}
+
+// recentEvents merges the classification history and the workflow transitions of a device
+func (s *DeviceOverviewService) recentEvents(ctx context.Context, deviceID string, limit int) ([]topology.DeviceEvent, error) {
+	changes, err := s.classificationService.GetClassificationHistory(ctx, deviceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	classificationEvents := make([]topology.DeviceEvent, 0, len(changes))
+	for _, change := range changes {
+		classificationEvents = append(classificationEvents, classificationChangeEvent(change))
+	}
+
+	var workflowEvents []topology.DeviceEvent
+	if s.workflowRepo != nil {
+		transitions, err := s.workflowRepo.ListWorkflowTransitions(ctx, deviceID, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, transition := range transitions {
+			workflowEvents = append(workflowEvents, transition.Event())
+		}
+	}
+
+	return topology.MergeDeviceEvents(limit, classificationEvents, workflowEvents), nil
+}
+
+// classificationChangeEvent returns a classification change as a device event
+func classificationChangeEvent(change classification.ClassificationChange) topology.DeviceEvent {
+	return topology.DeviceEvent{
+		Kind:      topology.DeviceEventClassification,
+		Summary:   fmt.Sprintf("%s → %s", classificationLabel(change.PreviousLayerID, change.PreviousDeviceType), classificationLabel(change.LayerID, change.DeviceType)),
+		Reason:    change.Reason,
+		ChangedBy: change.ClassifiedBy,
+		ChangedAt: change.ChangedAt,
+	}
+}
+
+// classificationLabel formats a layer and device type like "layer 40 (leaf)"
+func classificationLabel(layerID *int, deviceType string) string {
+	label := "unclassified"
+	if layerID != nil {
+		label = fmt.Sprintf("layer %d", *layerID)
+	}
+	if deviceType != "" {
+		label += " (" + deviceType + ")"
+	}
+	return label
+}
+
+// GetDeviceConnections classifies the connections of a single device into uplinks, downlinks
+// and peers without exploring the topology. Only the device's neighbors and the uplinks of its
+// peers are loaded, which is what classifyConnections needs for IsSameGroup.
+func (s *VisualizationService) GetDeviceConnections(ctx context.Context, device topology.Device, displayNames *topology.DisplayNameResolver) (*visualization.ConnectionClassification, error) {
+	deviceMap := map[string]topology.Device{device.ID: device}
+	links, err := s.topologyRepo.GetDeviceLinks(ctx, device.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links for device %s: %w", device.ID, err)
+	}
+	if err := s.loadLinkPeers(ctx, device.ID, links, deviceMap); err != nil {
+		return nil, err
+	}
+
+	// 同一階層のピアは上位デバイスを共有しているか判定するため、ピアのリンクも読み込む
+	deviceLayer := s.getDeviceLayer(device.LayerID)
+	allLinks := append([]topology.Link{}, links...)
+	seenLinks := make(map[string]bool, len(links))
+	for _, link := range links {
+		seenLinks[link.ID] = true
+	}
+	for _, link := range links {
+		peerID := link.TargetID
+		if peerID == device.ID {
+			peerID = link.SourceID
+		}
+		peer, ok := deviceMap[peerID]
+		if !ok || s.getDeviceLayer(peer.LayerID) != deviceLayer {
+			continue
+		}
+
+		peerLinks, err := s.topologyRepo.GetDeviceLinks(ctx, peerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", peerID, err)
+		}
+		if err := s.loadLinkPeers(ctx, peerID, peerLinks, deviceMap); err != nil {
+			return nil, err
+		}
+		for _, peerLink := range peerLinks {
+			if !seenLinks[peerLink.ID] {
+				seenLinks[peerLink.ID] = true
+				allLinks = append(allLinks, peerLink)
+			}
+		}
+	}
+
+	return s.classifyConnections(ctx, device.ID, deviceMap, allLinks, displayNames), nil
+}
+
+// loadLinkPeers adds the devices at the far end of deviceID's links to deviceMap
+func (s *VisualizationService) loadLinkPeers(ctx context.Context, deviceID string, links []topology.Link, deviceMap map[string]topology.Device) error {
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		peerID := link.TargetID
+		if peerID == deviceID {
+			peerID = link.SourceID
+		}
+		if _, ok := deviceMap[peerID]; ok {
+			continue
+		}
+
+		peer, err := s.topologyRepo.GetDevice(ctx, peerID)
+		if err != nil {
+			return fmt.Errorf("failed to get device %s: %w", peerID, err)
+		}
+		if peer != nil {
+			deviceMap[peerID] = *peer
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// devicePageSize is how many devices are read per page when walking every device
+var devicePageSize = 1000
+
+// walkDevices calls fn for every device after the device ID after (empty = from the first) in ID
+// order, reading the devices page by page, until fn returns false
+func walkDevices(ctx context.Context, repo topology.Repository, after string, fn func(topology.Device) bool) error {
+	opts := topology.PaginationOptions{PageSize: devicePageSize, Keyset: true, After: after}
+	for {
+		devices, pagination, err := repo.GetDevices(ctx, opts)
+		if err != nil {
+			return fmt.Errorf("failed to get devices: %w", err)
+		}
+		for _, device := range devices {
+			if !fn(device) {
+				return nil
+			}
+		}
+		if pagination == nil || !pagination.HasNext || len(devices) == 0 {
+			return nil
+		}
+		opts.After = devices[len(devices)-1].ID
+	}
+}
+
+// ListAllDevices returns every device in ID order. Unlike a single large page it does not stop at
+// a fixed number of devices.
+func ListAllDevices(ctx context.Context, repo topology.Repository) ([]topology.Device, error) {
+	devices := []topology.Device{}
+	err := walkDevices(ctx, repo, "", func(device topology.Device) bool {
+		devices = append(devices, device)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setDevicePageSize makes services read every device in pages of size for the rest of the test
+func setDevicePageSize(t *testing.T, size int) {
+	pageSize := devicePageSize
+	devicePageSize = size
+	t.Cleanup(func() { devicePageSize = pageSize })
+}
+
+func TestListAllDevices(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+	seedUnclassifiedDevices(t, setup, "new-001", "new-002")
+
+	// 1ページに収まらない台数でも全デバイスを ID 順に返す
+	setDevicePageSize(t, 2)
+	devices, err := ListAllDevices(context.Background(), setup.Repo)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, device := range devices {
+		ids = append(ids, device.ID)
+	}
+	assert.Equal(t, []string{"device-001", "device-002", "device-003", "new-001", "new-002"}, ids)
+}
+
+func TestListAllDevices_Empty(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+
+	devices, err := ListAllDevices(context.Background(), setup.Repo)
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+	assert.NotNil(t, devices)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+// GetDeviceSetTopology returns the visual topology of the given devices and the links between them.
+// Links to devices outside the set are left out. Unlike the explored topologies there is no root device.
+func (s *VisualizationService) GetDeviceSetTopology(ctx context.Context, devices []topology.Device) (*visualization.VisualTopology, error) {
+	displayNames, err := s.displayNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceMap := make(map[string]topology.Device, len(devices))
+	for _, device := range devices {
+		deviceMap[device.ID] = device
+	}
+
+	// 集合内のリンクのみを重複なく集める
+	var links []topology.Link
+	seenLinks := make(map[string]bool)
+	for _, device := range devices {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		deviceLinks, err := s.topologyRepo.GetDeviceLinks(ctx, device.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", device.ID, err)
+		}
+		for _, link := range deviceLinks {
+			if seenLinks[link.ID] {
+				continue
+			}
+			if _, ok := deviceMap[link.SourceID]; !ok {
+				continue
+			}
+			if _, ok := deviceMap[link.TargetID]; !ok {
+				continue
+			}
+			seenLinks[link.ID] = true
+			links = append(links, link)
+		}
+	}
+
+	visualNodes := make([]visualization.VisualNode, 0, len(devices))
+	for _, device := range devices {
+		visualNodes = append(visualNodes, visualization.VisualNode{
+			ID:            device.ID,
+			Name:          displayNames.Resolve(device.ID),
+			Type:          device.Type,
+			Hardware:      device.Hardware,
+			Status:        "active", // default status since status field removed
+			Layer:         s.getDeviceLayer(device.LayerID),
+			WorkflowState: topology.EffectiveWorkflowState(device),
+			Style:         s.deviceNodeStyle(device, false),
+			Connections:   s.classifyConnections(ctx, device.ID, deviceMap, links, displayNames),
+			Metadata:      device.Metadata,
+		})
+	}
+
+	asymmetricLinks := asymmetricLinkIDs(links)
+	visualEdges := make([]visualization.VisualEdge, 0, len(links))
+	for _, link := range links {
+		visualEdge := visualization.VisualEdge{
+			ID:             link.ID,
+			Source:         link.SourceID,
+			Target:         link.TargetID,
+			LocalPort:      link.SourcePort,
+			RemotePort:     link.TargetPort,
+			Status:         "active", // default status since status field removed
+			Weight:         link.Weight,
+			Style:          s.getEdgeStyle("active", link.Weight),
+			ConnectionType: s.determineConnectionType(link, deviceMap),
+			Metadata:       link.Metadata,
+		}
+		s.markAsymmetric(&visualEdge, asymmetricLinks)
+		visualEdges = append(visualEdges, visualEdge)
+	}
+
+	if err := s.applyStyleRules(ctx, visualNodes, visualEdges); err != nil {
+		return nil, err
+	}
+
+	s.calculateHierarchicalLayout(visualNodes, visualEdges, "")
+
+	return &visualization.VisualTopology{
+		Nodes:     visualNodes,
+		Edges:     visualEdges,
+		Timestamp: time.Now().Unix(),
+		Stats:     visualization.NewTopologyStats(visualNodes, visualEdges, nil, time.Now()),
+	}, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrInvalidDisplayName is returned when a display-name override is malformed
+var ErrInvalidDisplayName = apperror.Validation("invalid_display_name", "invalid display name override")
+
+type DisplayNameService struct {
+	displayNameRepo topology.DisplayNameRepository
+}
+
+func NewDisplayNameService(displayNameRepo topology.DisplayNameRepository) *DisplayNameService {
+	return &DisplayNameService{
+		displayNameRepo: displayNameRepo,
+	}
+}
+
+// ListOverrides returns all display-name overrides
+func (s *DisplayNameService) ListOverrides(ctx context.Context) ([]topology.DisplayNameOverride, error) {
+	return s.displayNameRepo.ListDisplayNameOverrides(ctx)
+}
+
+// SaveOverride validates and stores a display-name override.
+// An override for a device that already has one replaces it.
+// Returns nil when override.ID is set but no such override exists.
+func (s *DisplayNameService) SaveOverride(ctx context.Context, override topology.DisplayNameOverride, userID string) (*topology.DisplayNameOverride, error) {
+	override.DeviceID = strings.TrimSpace(override.DeviceID)
+	override.Name = strings.TrimSpace(override.Name)
+
+	if override.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidDisplayName)
+	}
+	if (override.DeviceID == "") == (override.Pattern == "") {
+		return nil, fmt.Errorf("%w: exactly one of device_id or pattern is required", ErrInvalidDisplayName)
+	}
+	if override.Pattern != "" {
+		if _, err := regexp.Compile(override.Pattern); err != nil {
+			return nil, fmt.Errorf("%w: invalid pattern '%s': %v", ErrInvalidDisplayName, override.Pattern, err)
+		}
+	}
+
+	existing, err := s.displayNameRepo.ListDisplayNameOverrides(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list display name overrides: %w", err)
+	}
+	found := false
+	for _, e := range existing {
+		// 更新時、または同じデバイスへの再登録時は作成情報を保持する
+		if (override.ID != "" && e.ID == override.ID) || (override.ID == "" && override.DeviceID != "" && e.DeviceID == override.DeviceID) {
+			override.ID = e.ID
+			override.CreatedBy = e.CreatedBy
+			override.CreatedAt = e.CreatedAt
+			found = true
+			break
+		}
+	}
+	if override.ID != "" && !found {
+		return nil, nil
+	}
+	if override.ID == "" {
+		override.ID = uuid.New().String()
+	}
+	if override.CreatedBy == "" {
+		override.CreatedBy = userID
+	}
+
+	if err := s.displayNameRepo.SaveDisplayNameOverride(ctx, override); err != nil {
+		return nil, fmt.Errorf("failed to save display name override: %w", err)
+	}
+
+	return &override, nil
+}
+
+// DeleteOverride removes a display-name override
+func (s *DisplayNameService) DeleteOverride(ctx context.Context, overrideID string) error {
+	return s.displayNameRepo.DeleteDisplayNameOverride(ctx, overrideID)
+}
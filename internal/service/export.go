@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/job"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/storage"
+)
+
+// JobKindExport is the job kind writing a topology export to object storage
+const JobKindExport = "export"
+
+// ExportDownloadPathPrefix is the path under which signed export downloads are served
+const ExportDownloadPathPrefix = "/api/v1/exports/download/"
+
+const (
+	// DefaultExportLinkTTL is how long a download link stays valid unless requested otherwise
+	DefaultExportLinkTTL = 15 * time.Minute
+	// MaxExportLinkTTL is the longest a download link may stay valid
+	MaxExportLinkTTL = 7 * 24 * time.Hour
+	// DefaultExportRetention is how long export files are kept in object storage
+	DefaultExportRetention = 7 * 24 * time.Hour
+	// exportKeyPrefix is the object storage prefix of export files
+	exportKeyPrefix = "exports/"
+)
+
+var (
+	// ErrInvalidExport is returned when an export or its download link is requested with invalid parameters
+	ErrInvalidExport = apperror.Validation("invalid_export", "invalid export")
+	// ErrExportNotFound is returned when the export job or its file does not exist
+	ErrExportNotFound = apperror.NotFound("export_not_found", "export not found")
+	// ErrExportNotReady is returned when a download link is requested before the export job succeeded
+	ErrExportNotReady = apperror.Conflict("export_not_ready", "export not ready")
+	// ErrInvalidDownloadLink is returned for tampered or expired download links
+	ErrInvalidDownloadLink = apperror.Validation("invalid_download_link", "invalid download link")
+)
+
+// ExportLink is a time-limited link to download an export file
+type ExportLink struct {
+	Token     string              `json:"token"`
+	Path      string              `json:"path"`
+	ExpiresAt time.Time           `json:"expires_at"`
+	File      topology.ExportFile `json:"file"`
+}
+
+// ExportService produces exports of the whole topology with export jobs, keeps them in object
+// storage and hands them out through signed download links, so large topologies never have to
+// fit into a synchronous response
+type ExportService struct {
+	secret          []byte
+	retention       time.Duration
+	store           storage.ObjectStore
+	jobService      *JobService
+	topologyService *TopologyService
+
+	// 注記の取得元（nil = レイヤー名・メンテナンスを書き出さない）
+	classificationService *ClassificationService
+	maintenanceService    *MaintenanceService
+}
+
+// NewExportService creates an export service signing download links with a random secret until
+// SetSecret is called. It registers the export job kind with jobService.
+func NewExportService(store storage.ObjectStore, jobService *JobService, topologyService *TopologyService) *ExportService {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to generate export secret: %v", err))
+	}
+
+	s := &ExportService{
+		secret:          secret,
+		retention:       DefaultExportRetention,
+		store:           store,
+		jobService:      jobService,
+		topologyService: topologyService,
+	}
+	jobService.Register(JobKindExport, func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		var req topology.ExportRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return nil, fmt.Errorf("invalid export payload: %w", err)
+		}
+		return s.Export(ctx, req)
+	})
+	return s
+}
+
+// SetSecret changes the key download links are signed with. Links signed with the previous key stop working.
+func (s *ExportService) SetSecret(secret []byte) {
+	s.secret = secret
+}
+
+// SetAnnotationSources sets where the layer names and the maintenance windows of annotated exports
+// are read from. Without a maintenance service, exports including maintenance are rejected.
+func (s *ExportService) SetAnnotationSources(classificationService *ClassificationService, maintenanceService *MaintenanceService) {
+	s.classificationService = classificationService
+	s.maintenanceService = maintenanceService
+}
+
+// SetRetention changes how long export files are kept (0 = DefaultExportRetention)
+func (s *ExportService) SetRetention(retention time.Duration) {
+	if retention <= 0 {
+		retention = DefaultExportRetention
+	}
+	s.retention = retention
+}
+
+// SubmitExport enqueues an export job
+func (s *ExportService) SubmitExport(ctx context.Context, req topology.ExportRequest, userID string) (*job.Job, error) {
+	if err := s.validateRequest(req); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.jobService.Submit(ctx, JobKindExport, payload, 0, userID)
+}
+
+// Export writes the current topology to object storage and removes exports past their retention
+func (s *ExportService) Export(ctx context.Context, req topology.ExportRequest) (*topology.ExportFile, error) {
+	if err := s.validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.topologyService.TakeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := s.annotations(ctx, req.Include, snapshot.TakenAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// 全体をメモリに載せずにストレージへ書き出す
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(topology.WriteExport(writer, req.Format, snapshot, annotations))
+	}()
+	filename := topology.ExportFilename(req.Format, snapshot)
+	object, err := s.store.Put(ctx, exportKeyPrefix+uuid.New().String()+"/"+filename, reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to store export: %w", err)
+	}
+
+	s.pruneExports(ctx, time.Now().Add(-s.retention))
+
+	return &topology.ExportFile{
+		Format:      req.Format,
+		Include:     req.Include,
+		Key:         object.Key,
+		Filename:    filename,
+		ContentType: topology.ExportContentType(req.Format),
+		Size:        object.Size,
+		Devices:     len(snapshot.Devices),
+		Links:       len(snapshot.Links),
+		CreatedAt:   object.ModifiedAt,
+	}, nil
+}
+
+func (s *ExportService) validateRequest(req topology.ExportRequest) error {
+	if !topology.IsValidExportFormat(req.Format) {
+		return fmt.Errorf("%w: unknown format '%s' (expected one of %v)", ErrInvalidExport, req.Format, topology.ExportFormats)
+	}
+	if err := topology.ValidateExportIncludes(req.Include); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	for _, name := range req.Include {
+		if name == topology.ExportIncludeMaintenance && s.maintenanceService == nil {
+			return fmt.Errorf("%w: maintenance windows are not supported by this repository", ErrInvalidExport)
+		}
+	}
+	return nil
+}
+
+// annotations reads the operational context of include at the time of the snapshot (nil when nothing is included)
+func (s *ExportService) annotations(ctx context.Context, include []string, now time.Time) (*topology.ExportAnnotations, error) {
+	if len(include) == 0 {
+		return nil, nil
+	}
+
+	annotations := &topology.ExportAnnotations{Include: include, LayerNames: map[int]string{}}
+	for _, name := range include {
+		switch name {
+		case topology.ExportIncludeClassification:
+			if s.classificationService == nil {
+				continue
+			}
+			layers, err := s.classificationService.ListHierarchyLayers(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list hierarchy layers: %w", err)
+			}
+			for _, layer := range layers {
+				annotations.LayerNames[layer.ID] = layer.Name
+			}
+		case topology.ExportIncludeMaintenance:
+			// 終了していない作業（実施中と予定）
+			windows, err := s.maintenanceService.ListWindows(ctx, now, time.Time{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+			}
+			annotations.SetMaintenance(windows, now)
+		}
+	}
+	return annotations, nil
+}
+
+// pruneExports deletes export files older than cutoff. Failures only delay the cleanup until the next export.
+func (s *ExportService) pruneExports(ctx context.Context, cutoff time.Time) {
+	objects, err := s.store.List(ctx, exportKeyPrefix)
+	if err != nil {
+		return
+	}
+	for _, object := range objects {
+		if object.ModifiedAt.Before(cutoff) {
+			s.store.Delete(ctx, object.Key)
+		}
+	}
+}
+
+// CreateDownloadLink issues a link to the file of a succeeded export job, valid for ttl (0 = DefaultExportLinkTTL)
+func (s *ExportService) CreateDownloadLink(ctx context.Context, jobID string, ttl time.Duration) (*ExportLink, error) {
+	if ttl == 0 {
+		ttl = DefaultExportLinkTTL
+	}
+	if ttl < time.Minute || ttl > MaxExportLinkTTL {
+		return nil, fmt.Errorf("%w: expiry must be between 1m and %s", ErrInvalidExport, MaxExportLinkTTL)
+	}
+
+	found, err := s.jobService.GetJob(ctx, jobID)
+	if errors.Is(err, ErrJobNotFound) || (err == nil && found.Kind != JobKindExport) {
+		return nil, fmt.Errorf("%w: %s", ErrExportNotFound, jobID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if found.Status != job.StatusSucceeded {
+		return nil, fmt.Errorf("%w: job %s is %s", ErrExportNotReady, jobID, found.Status)
+	}
+
+	var file topology.ExportFile
+	if err := json.Unmarshal(found.Result, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode export result: %w", err)
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	token, err := storage.SignGrant(s.secret, storage.Grant{
+		Key:         file.Key,
+		Filename:    file.Filename,
+		ContentType: file.ContentType,
+		ExpiresAt:   expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign download link: %w", err)
+	}
+
+	return &ExportLink{
+		Token:     token,
+		Path:      ExportDownloadPathPrefix + token,
+		ExpiresAt: time.Unix(expiresAt.Unix(), 0),
+		File:      file,
+	}, nil
+}
+
+// OpenDownload verifies a download token and opens the export file it grants; the caller closes it
+func (s *ExportService) OpenDownload(ctx context.Context, token string) (io.ReadCloser, *storage.Grant, *storage.Object, error) {
+	grant, err := storage.VerifyGrant(s.secret, token, time.Now())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %v", ErrInvalidDownloadLink, err)
+	}
+
+	content, object, err := s.store.Open(ctx, grant.Key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, nil, nil, fmt.Errorf("%w: the file was removed after %s", ErrExportNotFound, s.retention)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return content, grant, object, nil
+}
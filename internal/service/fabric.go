@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/service/contract"
+)
+
+var (
+	// ErrInvalidFabric is returned when a fabric definition is malformed
+	ErrInvalidFabric = apperror.Validation("invalid_fabric", "invalid fabric")
+	// ErrFabricNotFound is returned when the requested fabric does not exist
+	ErrFabricNotFound = apperror.NotFound("fabric_not_found", "fabric not found")
+)
+
+// FabricService manages fabrics and assigns devices to them by their member conditions.
+// Membership is evaluated on every request, so devices join or leave fabrics as they change.
+type FabricService struct {
+	fabricRepo           topology.FabricRepository
+	topologyRepo         topology.Repository
+	visualizationService contract.Visualization
+}
+
+func NewFabricService(fabricRepo topology.FabricRepository, topologyRepo topology.Repository, visualizationService contract.Visualization) *FabricService {
+	return &FabricService{
+		fabricRepo:           fabricRepo,
+		topologyRepo:         topologyRepo,
+		visualizationService: visualizationService,
+	}
+}
+
+// ListFabrics returns all fabrics with their current member counts
+func (s *FabricService) ListFabrics(ctx context.Context) ([]topology.Fabric, error) {
+	fabrics, assignments, _, err := s.assign(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, name := range assignments {
+		counts[name]++
+	}
+	for i := range fabrics {
+		fabrics[i].MemberCount = counts[fabrics[i].Name]
+	}
+
+	return fabrics, nil
+}
+
+// GetFabric returns a fabric with its current member count, or nil when it does not exist
+func (s *FabricService) GetFabric(ctx context.Context, name string) (*topology.Fabric, error) {
+	fabric, members, err := s.fabricMembers(ctx, name)
+	if errors.Is(err, ErrFabricNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fabric.MemberCount = len(members)
+	return fabric, nil
+}
+
+// SaveFabric validates and stores a fabric definition
+func (s *FabricService) SaveFabric(ctx context.Context, fabric topology.Fabric, userID string) (*topology.Fabric, error) {
+	fabric.Name = strings.TrimSpace(fabric.Name)
+	if fabric.LogicOperator == "" {
+		fabric.LogicOperator = "AND"
+	}
+	if err := fabric.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFabric, err)
+	}
+
+	existing, err := s.fabricRepo.GetFabric(ctx, fabric.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fabric: %w", err)
+	}
+
+	now := time.Now()
+	if existing != nil {
+		fabric.CreatedBy = existing.CreatedBy
+		fabric.CreatedAt = existing.CreatedAt
+	} else {
+		fabric.CreatedBy = userID
+		fabric.CreatedAt = now
+	}
+	fabric.UpdatedAt = now
+
+	if err := s.fabricRepo.SaveFabric(ctx, fabric); err != nil {
+		return nil, fmt.Errorf("failed to save fabric: %w", err)
+	}
+
+	return s.GetFabric(ctx, fabric.Name)
+}
+
+// DeleteFabric removes a fabric definition. Its devices are reassigned to the next matching fabric.
+func (s *FabricService) DeleteFabric(ctx context.Context, name string) error {
+	return s.fabricRepo.DeleteFabric(ctx, name)
+}
+
+// ListFabricMembers returns the devices assigned to a fabric ordered by ID
+func (s *FabricService) ListFabricMembers(ctx context.Context, name string) ([]topology.Device, error) {
+	_, members, err := s.fabricMembers(ctx, name)
+	return members, err
+}
+
+// GetFabricTopology returns the visual topology of the members of a fabric and the links between them
+func (s *FabricService) GetFabricTopology(ctx context.Context, name string) (*visualization.VisualTopology, error) {
+	_, members, err := s.fabricMembers(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.visualizationService.GetDeviceSetTopology(ctx, members)
+}
+
+// GetFabricReport summarizes the members of a fabric and its links to other fabrics
+func (s *FabricService) GetFabricReport(ctx context.Context, name string) (*topology.FabricReport, error) {
+	fabrics, assignments, devices, err := s.assign(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !containsFabric(fabrics, name) {
+		return nil, fmt.Errorf("%w: %s", ErrFabricNotFound, name)
+	}
+
+	report := &topology.FabricReport{
+		Name:            name,
+		DevicesByType:   make(map[string]int),
+		DevicesByLayer:  make(map[string]int),
+		ExternalFabrics: make(map[string]int),
+	}
+
+	countedLinks := make(map[string]bool)
+	for _, device := range devices {
+		if assignments[device.ID] != name {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		report.DeviceCount++
+		report.DevicesByType[device.Type]++
+		if device.LayerID != nil {
+			report.DevicesByLayer[strconv.Itoa(*device.LayerID)]++
+		} else {
+			report.DevicesByLayer["unclassified"]++
+		}
+
+		links, err := s.topologyRepo.GetDeviceLinks(ctx, device.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", device.ID, err)
+		}
+		for _, link := range links {
+			// ファブリック内リンクは両端から見えるので一度だけ数える
+			if countedLinks[link.ID] {
+				continue
+			}
+			countedLinks[link.ID] = true
+
+			peerID := link.TargetID
+			if peerID == device.ID {
+				peerID = link.SourceID
+			}
+
+			peerFabric, assigned := assignments[peerID]
+			switch {
+			case peerFabric == name:
+				report.InternalLinks++
+			case assigned:
+				report.ExternalLinks++
+				report.ExternalFabrics[peerFabric]++
+			default:
+				report.ExternalLinks++
+				report.ExternalFabrics[topology.UnassignedFabric]++
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// fabricMembers returns the fabric and its members, or ErrFabricNotFound
+func (s *FabricService) fabricMembers(ctx context.Context, name string) (*topology.Fabric, []topology.Device, error) {
+	fabrics, assignments, devices, err := s.assign(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range fabrics {
+		if fabrics[i].Name != name {
+			continue
+		}
+
+		members := []topology.Device{}
+		for _, device := range devices {
+			if assignments[device.ID] == name {
+				members = append(members, device)
+			}
+		}
+		return &fabrics[i], members, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %s", ErrFabricNotFound, name)
+}
+
+// assign loads the fabrics and all devices and assigns every device to its fabric
+func (s *FabricService) assign(ctx context.Context) ([]topology.Fabric, map[string]string, []topology.Device, error) {
+	fabrics, err := s.fabricRepo.ListFabrics(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to list fabrics: %w", err)
+	}
+	if fabrics == nil {
+		fabrics = []topology.Fabric{}
+	}
+
+	devices, err := ListAllDevices(ctx, s.topologyRepo)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return fabrics, topology.AssignFabrics(fabrics, devices), devices, nil
+}
+
+func containsFabric(fabrics []topology.Fabric, name string) bool {
+	for _, fabric := range fabrics {
+		if fabric.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFabricService_AssignsEveryDevicePage(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	ctx := context.Background()
+
+	devices := make([]topology.Device, 0, 20)
+	for i := 0; i < 10; i++ {
+		devices = append(devices, testutil.CreateTestDevice(fmt.Sprintf("fab-a-%02d", i)), testutil.CreateTestDevice(fmt.Sprintf("other-%02d", i)))
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, devices))
+
+	fabricRepo, ok := setup.Repo.(topology.FabricRepository)
+	require.True(t, ok, "SQLite repository should store fabrics")
+	fabricService := NewFabricService(fabricRepo, setup.Repo, NewVisualizationService(setup.Repo))
+	_, err := fabricService.SaveFabric(ctx, topology.Fabric{
+		Name:       "fabric-a",
+		Conditions: []topology.FabricCondition{{Field: "name", Operator: "starts_with", Value: "fab-a-"}},
+	}, "user:admin")
+	require.NoError(t, err)
+
+	// 1ページに収まらなくても全デバイスを割り当てる
+	setDevicePageSize(t, 3)
+	fabric, err := fabricService.GetFabric(ctx, "fabric-a")
+	require.NoError(t, err)
+	require.NotNil(t, fabric)
+	assert.Equal(t, 10, fabric.MemberCount)
+
+	members, err := fabricService.ListFabricMembers(ctx, "fabric-a")
+	require.NoError(t, err)
+	require.Len(t, members, 10)
+	assert.Equal(t, "fab-a-00", members[0].ID)
+	assert.Equal(t, "fab-a-09", members[9].ID)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+// GetGroupMembers returns the member devices of a group of the grouped topology rooted at
+// rootDeviceID, with their classification and a summary of the links leaving the group.
+// The group is addressed by the same parameters the topology was requested with.
+func (s *VisualizationService) GetGroupMembers(ctx context.Context, rootDeviceID, groupID string, depth int, groupingOpts visualization.GroupingOptions) (*visualization.GroupMembers, error) {
+	groupingOpts.Enabled = true
+	currentTopology, err := s.GetVisualTopologyWithGrouping(ctx, rootDeviceID, depth, groupingOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var group *visualization.GroupedVisualNode
+	for i := range currentTopology.Groups {
+		if currentTopology.Groups[i].ID == groupID {
+			group = &currentTopology.Groups[i]
+			break
+		}
+	}
+	if group == nil {
+		return nil, fmt.Errorf("%w: %s", ErrGroupNotFound, groupID)
+	}
+
+	displayNames, err := s.displayNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	memberSet := make(map[string]bool, len(group.DeviceIDs))
+	for _, id := range group.DeviceIDs {
+		memberSet[id] = true
+	}
+
+	result := &visualization.GroupMembers{
+		GroupID:       group.ID,
+		Name:          group.Name,
+		GroupType:     group.GroupType,
+		Count:         group.Count,
+		Members:       make([]visualization.GroupMember, 0, len(group.DeviceIDs)),
+		ExternalPeers: []visualization.GroupExternalPeer{},
+	}
+
+	peers := make(map[string]*visualization.GroupExternalPeer)
+	countedLinks := make(map[string]bool)
+	for _, deviceID := range group.DeviceIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		device, err := s.topologyRepo.GetDevice(ctx, deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get device %s: %w", deviceID, err)
+		}
+		if device == nil {
+			continue
+		}
+
+		links, err := s.topologyRepo.GetDeviceLinks(ctx, deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", deviceID, err)
+		}
+
+		member := visualization.GroupMember{
+			DeviceID:     device.ID,
+			Name:         displayNames.Resolve(device.ID),
+			Type:         device.Type,
+			Hardware:     device.Hardware,
+			Status:       "active", // default status since status field removed
+			LayerID:      device.LayerID,
+			DeviceType:   device.DeviceType,
+			ClassifiedBy: device.ClassifiedBy,
+			LastSeen:     device.LastSeen,
+		}
+
+		for _, link := range links {
+			peerID := link.TargetID
+			if peerID == deviceID {
+				peerID = link.SourceID
+			}
+
+			// グループ内リンクは両端から見えるので一度だけ数える
+			if memberSet[peerID] {
+				if !countedLinks[link.ID] {
+					countedLinks[link.ID] = true
+					result.InternalLinks++
+				}
+				continue
+			}
+
+			member.ExternalLinks++
+			peer, exists := peers[peerID]
+			if !exists {
+				peer = &visualization.GroupExternalPeer{PeerID: peerID, PeerName: displayNames.Resolve(peerID)}
+				peers[peerID] = peer
+			}
+			peer.LinkCount++
+			if len(peer.Members) == 0 || peer.Members[len(peer.Members)-1] != deviceID {
+				peer.Members = append(peer.Members, deviceID)
+			}
+		}
+
+		result.Members = append(result.Members, member)
+	}
+
+	sort.Slice(result.Members, func(i, j int) bool { return result.Members[i].DeviceID < result.Members[j].DeviceID })
+	for _, peer := range peers {
+		result.ExternalPeers = append(result.ExternalPeers, *peer)
+	}
+	sort.Slice(result.ExternalPeers, func(i, j int) bool {
+		if result.ExternalPeers[i].LinkCount != result.ExternalPeers[j].LinkCount {
+			return result.ExternalPeers[i].LinkCount > result.ExternalPeers[j].LinkCount
+		}
+		return result.ExternalPeers[i].PeerID < result.ExternalPeers[j].PeerID
+	})
+
+	return result, nil
+}
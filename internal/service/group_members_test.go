@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+func TestGetGroupMembers(t *testing.T) {
+	repo := createTestTopology()
+	// グループ内のリンクは外部ピアに数えない
+	repo.AddLink(topology.Link{ID: "dist-100_dist-101", SourceID: "dist-100", TargetID: "dist-101", SourcePort: "et-0/0/2", TargetPort: "et-0/0/2", Weight: 1.0})
+	service := NewVisualizationService(repo)
+	ctx := context.Background()
+	groupingOpts := visualization.GroupingOptions{MinGroupSize: 3, MaxDepth: 1, GroupByPrefix: true, PrefixMinLen: 3}
+
+	current, err := service.GetVisualTopologyWithGrouping(ctx, "core-001", 1, visualization.GroupingOptions{
+		Enabled: true, MinGroupSize: 3, MaxDepth: 1, GroupByPrefix: true, PrefixMinLen: 3,
+	})
+	if err != nil {
+		t.Fatalf("GetVisualTopologyWithGrouping failed: %v", err)
+	}
+	var groupID string
+	for _, group := range current.Groups {
+		if group.Prefix == "dist-" {
+			groupID = group.ID
+		}
+	}
+	if groupID == "" {
+		t.Fatalf("Expected the dist devices to be grouped, got %+v", current.Groups)
+	}
+
+	// Enabled を指定しなくてもグループ化した状態で探す
+	members, err := service.GetGroupMembers(ctx, "core-001", groupID, 1, groupingOpts)
+	if err != nil {
+		t.Fatalf("GetGroupMembers failed: %v", err)
+	}
+
+	if members.GroupID != groupID || members.Count != 3 {
+		t.Errorf("Expected group %s of 3 devices, got %s of %d", groupID, members.GroupID, members.Count)
+	}
+	var ids []string
+	for _, member := range members.Members {
+		ids = append(ids, member.DeviceID)
+	}
+	if !reflect.DeepEqual(ids, []string{"dist-100", "dist-101", "dist-102"}) {
+		t.Fatalf("Expected the dist devices sorted by ID, got %v", ids)
+	}
+	first := members.Members[0]
+	if first.Hardware != "Juniper QFX5100" || first.LayerID == nil || *first.LayerID != 4 {
+		t.Errorf("Expected the member's classification, got %+v", first)
+	}
+	// dist-100: core-001 と access-001
+	if first.ExternalLinks != 2 {
+		t.Errorf("Expected 2 external links on dist-100, got %d", first.ExternalLinks)
+	}
+	if members.InternalLinks != 1 {
+		t.Errorf("Expected the link inside the group to be counted once, got %d", members.InternalLinks)
+	}
+
+	if len(members.ExternalPeers) != 4 {
+		t.Fatalf("Expected core-001 and three access peers, got %+v", members.ExternalPeers)
+	}
+	// リンク数の多いピアが先
+	core := members.ExternalPeers[0]
+	if core.PeerID != "core-001" || core.LinkCount != 3 || !reflect.DeepEqual(core.Members, []string{"dist-100", "dist-101", "dist-102"}) {
+		t.Errorf("Expected core-001 linked to every member, got %+v", core)
+	}
+	access := members.ExternalPeers[1]
+	if access.PeerID != "access-001" || access.LinkCount != 1 || !reflect.DeepEqual(access.Members, []string{"dist-100"}) {
+		t.Errorf("Expected access-001 linked to dist-100, got %+v", access)
+	}
+}
+
+func TestGetGroupMembers_GroupNotFound(t *testing.T) {
+	service := NewVisualizationService(createTestTopology())
+	groupingOpts := visualization.GroupingOptions{MinGroupSize: 3, MaxDepth: 1, GroupByPrefix: true, PrefixMinLen: 3}
+
+	_, err := service.GetGroupMembers(context.Background(), "core-001", "no-such-group", 1, groupingOpts)
+	if !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, got %v", err)
+	}
+
+	_, err = service.GetGroupMembers(context.Background(), "no-such-root", "no-such-group", 1, groupingOpts)
+	if err == nil {
+		t.Error("Expected an error for an unknown root device")
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+var (
+	// ErrInvalidCatalogEntry is returned when a hardware catalog entry is malformed
+	ErrInvalidCatalogEntry = apperror.Validation("invalid_catalog_entry", "invalid hardware catalog entry")
+	// ErrHardwareCatalogUnsupported is returned when the repository cannot store a hardware catalog
+	ErrHardwareCatalogUnsupported = errors.New("hardware catalog is not supported by this repository")
+)
+
+// ListHardwareCatalog returns all approved hardware models
+func (s *ClassificationService) ListHardwareCatalog(ctx context.Context) ([]classification.HardwareCatalogEntry, error) {
+	if s.catalogRepo == nil {
+		return nil, ErrHardwareCatalogUnsupported
+	}
+	return s.catalogRepo.ListHardwareCatalog(ctx)
+}
+
+// AddHardwareCatalogEntry approves a hardware model for a layer
+func (s *ClassificationService) AddHardwareCatalogEntry(ctx context.Context, entry classification.HardwareCatalogEntry, userID string) (*classification.HardwareCatalogEntry, error) {
+	if s.catalogRepo == nil {
+		return nil, ErrHardwareCatalogUnsupported
+	}
+
+	entry.Model = strings.TrimSpace(entry.Model)
+	if entry.Model == "" {
+		return nil, fmt.Errorf("%w: model is required", ErrInvalidCatalogEntry)
+	}
+
+	layer, err := s.classificationRepo.GetHierarchyLayer(ctx, entry.LayerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hierarchy layer: %w", err)
+	}
+	if layer == nil {
+		return nil, fmt.Errorf("%w: layer %d not found", ErrInvalidCatalogEntry, entry.LayerID)
+	}
+
+	entry.ID = uuid.New().String()
+	entry.CreatedBy = userID
+	entry.CreatedAt = time.Now()
+	if err := s.catalogRepo.SaveHardwareCatalogEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to save hardware catalog entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// DeleteHardwareCatalogEntry removes an approved hardware model
+func (s *ClassificationService) DeleteHardwareCatalogEntry(ctx context.Context, entryID string) error {
+	if s.catalogRepo == nil {
+		return ErrHardwareCatalogUnsupported
+	}
+	return s.catalogRepo.DeleteHardwareCatalogEntry(ctx, entryID)
+}
+
+// EvaluateHardwareCompliance lists classified devices whose hardware is not approved for their layer.
+// Unclassified devices and layers without catalog entries are not checked.
+func (s *ClassificationService) EvaluateHardwareCompliance(ctx context.Context) (*classification.HardwareComplianceReport, error) {
+	if s.catalogRepo == nil {
+		return nil, ErrHardwareCatalogUnsupported
+	}
+
+	entries, err := s.catalogRepo.ListHardwareCatalog(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hardware catalog: %w", err)
+	}
+	catalog := classification.NewHardwareCatalog(entries)
+
+	layers, err := s.classificationRepo.ListHierarchyLayers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hierarchy layers: %w", err)
+	}
+	layerNames := make(map[int]string, len(layers))
+	for _, layer := range layers {
+		layerNames[layer.ID] = layer.Name
+	}
+
+	report := &classification.HardwareComplianceReport{
+		GeneratedAt: time.Now(),
+		Violations:  []classification.HardwareViolation{},
+	}
+	err = walkDevices(ctx, s.topologyRepo, "", func(device topology.Device) bool {
+		if device.LayerID == nil || catalog.ApprovedModels(*device.LayerID) == nil {
+			return true
+		}
+		report.CheckedDevices++
+
+		if catalog.IsApproved(*device.LayerID, device.Hardware) {
+			report.CompliantDevices++
+			return true
+		}
+		report.Violations = append(report.Violations, classification.HardwareViolation{
+			DeviceID:       device.ID,
+			Hardware:       device.Hardware,
+			LayerID:        *device.LayerID,
+			LayerName:      layerNames[*device.LayerID],
+			ApprovedModels: catalog.ApprovedModels(*device.LayerID),
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassificationService_EvaluateHardwareCompliance(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
+	ctx := context.Background()
+
+	_, err := classificationService.AddHardwareCatalogEntry(ctx, classification.HardwareCatalogEntry{LayerID: 1, Model: " Arista 7280* "}, "admin")
+	require.NoError(t, err)
+
+	device := func(id, hardware string, layer *int) topology.Device {
+		device := testutil.CreateTestDevice(id)
+		device.Hardware = hardware
+		device.LayerID = layer
+		return device
+	}
+	core, access := 1, 3
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, []topology.Device{
+		device("core-01", "arista 7280R3", &core),
+		device("core-02", "Arista 7280SR", &core),
+		device("core-03", "Arista 7050", &core),
+		device("core-04", "Arista 7280CR", &core),
+		device("core-05", "Cisco C9300", &core),
+		device("access-01", "Cisco C9300", &access),
+		device("new-01", "Cisco C9300", nil),
+	}))
+
+	// 1ページに収まらない台数でも全デバイスを検査する
+	setDevicePageSize(t, 2)
+	report, err := classificationService.EvaluateHardwareCompliance(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.CheckedDevices, "only devices of layers governed by the catalog are checked")
+	assert.Equal(t, 3, report.CompliantDevices)
+	require.Len(t, report.Violations, 2)
+	assert.Equal(t, "core-03", report.Violations[0].DeviceID)
+	assert.Equal(t, "core-05", report.Violations[1].DeviceID)
+	assert.Equal(t, 1, report.Violations[1].LayerID)
+	assert.NotEmpty(t, report.Violations[1].LayerName)
+	assert.Equal(t, []string{"Arista 7280*"}, report.Violations[1].ApprovedModels)
+}
+
+func TestClassificationService_AddHardwareCatalogEntryValidation(t *testing.T) {
+	classificationService, _ := newTestClassificationService(t)
+	ctx := context.Background()
+
+	_, err := classificationService.AddHardwareCatalogEntry(ctx, classification.HardwareCatalogEntry{LayerID: 1, Model: " "}, "admin")
+	assert.ErrorIs(t, err, ErrInvalidCatalogEntry)
+	_, err = classificationService.AddHardwareCatalogEntry(ctx, classification.HardwareCatalogEntry{LayerID: 999, Model: "Arista 7280*"}, "admin")
+	assert.ErrorIs(t, err, ErrInvalidCatalogEntry)
+
+	entries, err := classificationService.ListHardwareCatalog(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// カタログが空ならすべてのデバイスが対象外
+	report, err := classificationService.EvaluateHardwareCompliance(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, report.CheckedDevices)
+	assert.Empty(t, report.Violations)
+}
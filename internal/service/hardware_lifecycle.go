@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// DefaultSiteMetadataKey is the device metadata key lifecycle reports group sites by
+const DefaultSiteMetadataKey = "site"
+
+// ErrInvalidLifecycleEntry is returned when a hardware lifecycle entry is malformed
+var ErrInvalidLifecycleEntry = apperror.Validation("invalid_lifecycle_entry", "invalid hardware lifecycle entry")
+
+// ListHardwareLifecycle returns the end-of-sale/end-of-life dates of all hardware models
+func (s *ClassificationService) ListHardwareLifecycle(ctx context.Context) ([]classification.HardwareLifecycleEntry, error) {
+	if s.lifecycleRepo == nil {
+		return nil, ErrHardwareCatalogUnsupported
+	}
+	return s.lifecycleRepo.ListHardwareLifecycle(ctx)
+}
+
+// SetHardwareLifecycle records the lifecycle dates of a hardware model. An existing entry of the
+// same model (ignoring case) is updated in place.
+func (s *ClassificationService) SetHardwareLifecycle(ctx context.Context, entry classification.HardwareLifecycleEntry, userID string) (*classification.HardwareLifecycleEntry, error) {
+	if s.lifecycleRepo == nil {
+		return nil, ErrHardwareCatalogUnsupported
+	}
+
+	entry.Model = strings.TrimSpace(entry.Model)
+	if entry.Model == "" {
+		return nil, fmt.Errorf("%w: model is required", ErrInvalidLifecycleEntry)
+	}
+	if entry.EndOfSale == nil && entry.EndOfLife == nil {
+		return nil, fmt.Errorf("%w: end_of_sale or end_of_life is required", ErrInvalidLifecycleEntry)
+	}
+	if entry.EndOfSale != nil && entry.EndOfLife != nil && entry.EndOfLife.Before(*entry.EndOfSale) {
+		return nil, fmt.Errorf("%w: end_of_life is before end_of_sale", ErrInvalidLifecycleEntry)
+	}
+
+	entries, err := s.lifecycleRepo.ListHardwareLifecycle(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hardware lifecycle: %w", err)
+	}
+	entry.ID = uuid.New().String()
+	entry.CreatedBy = userID
+	entry.CreatedAt = time.Now()
+	for _, existing := range entries {
+		if strings.EqualFold(existing.Model, entry.Model) {
+			entry.ID = existing.ID
+			entry.CreatedBy = existing.CreatedBy
+			entry.CreatedAt = existing.CreatedAt
+			break
+		}
+	}
+
+	if err := s.lifecycleRepo.SaveHardwareLifecycleEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to save hardware lifecycle entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// DeleteHardwareLifecycleEntry removes the lifecycle dates of a hardware model
+func (s *ClassificationService) DeleteHardwareLifecycleEntry(ctx context.Context, entryID string) error {
+	if s.lifecycleRepo == nil {
+		return ErrHardwareCatalogUnsupported
+	}
+	return s.lifecycleRepo.DeleteHardwareLifecycleEntry(ctx, entryID)
+}
+
+// EvaluateHardwareLifecycle lists devices whose hardware is past its end of life on asOf, grouped by
+// layer and by the device metadata value of siteKey. With includeEndOfSale, devices past their end
+// of sale are listed too.
+func (s *ClassificationService) EvaluateHardwareLifecycle(ctx context.Context, asOf time.Time, siteKey string, includeEndOfSale bool) (*classification.HardwareLifecycleReport, error) {
+	if s.lifecycleRepo == nil {
+		return nil, ErrHardwareCatalogUnsupported
+	}
+	if siteKey == "" {
+		siteKey = DefaultSiteMetadataKey
+	}
+
+	entries, err := s.lifecycleRepo.ListHardwareLifecycle(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hardware lifecycle: %w", err)
+	}
+	catalog := classification.NewHardwareLifecycleCatalog(entries)
+
+	layers, err := s.classificationRepo.ListHierarchyLayers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hierarchy layers: %w", err)
+	}
+	layerNames := make(map[int]string, len(layers))
+	for _, layer := range layers {
+		layerNames[layer.ID] = layer.Name
+	}
+
+	report := &classification.HardwareLifecycleReport{
+		GeneratedAt: time.Now(),
+		AsOf:        asOf,
+	}
+	var affected []classification.HardwareLifecycleDevice
+	err = walkDevices(ctx, s.topologyRepo, "", func(device topology.Device) bool {
+		report.CheckedDevices++
+
+		entry := catalog.Match(device.Hardware)
+		if entry == nil {
+			return true
+		}
+		report.MatchedDevices++
+
+		status := entry.Status(asOf)
+		if status == classification.LifecycleSupported || (status == classification.LifecycleEndOfSale && !includeEndOfSale) {
+			return true
+		}
+
+		lifecycleDevice := classification.HardwareLifecycleDevice{
+			DeviceID:  device.ID,
+			Hardware:  device.Hardware,
+			Model:     entry.Model,
+			Status:    status,
+			EndOfSale: entry.EndOfSale,
+			EndOfLife: entry.EndOfLife,
+			LayerID:   device.LayerID,
+			Site:      device.Metadata[siteKey],
+		}
+		if device.LayerID != nil {
+			lifecycleDevice.LayerName = layerNames[*device.LayerID]
+		}
+		affected = append(affected, lifecycleDevice)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Groups = classification.GroupLifecycleDevices(affected)
+
+	return report, nil
+}
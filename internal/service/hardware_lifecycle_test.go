@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassificationService_EvaluateHardwareLifecycle(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
+	ctx := context.Background()
+
+	asOf := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	past, future := asOf.AddDate(-1, 0, 0), asOf.AddDate(1, 0, 0)
+	_, err := classificationService.SetHardwareLifecycle(ctx, classification.HardwareLifecycleEntry{Model: "EX2300*", EndOfSale: &past, EndOfLife: &past}, "admin")
+	require.NoError(t, err)
+	_, err = classificationService.SetHardwareLifecycle(ctx, classification.HardwareLifecycleEntry{Model: "QFX5100", EndOfSale: &past, EndOfLife: &future}, "admin")
+	require.NoError(t, err)
+
+	device := func(id, hardware, site string) topology.Device {
+		device := testutil.CreateTestDevice(id)
+		device.Hardware = hardware
+		device.Metadata = map[string]string{"site": site}
+		return device
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, []topology.Device{
+		device("access-01", "EX2300-48P", "tokyo"),
+		device("access-02", "EX2300-24T", "osaka"),
+		device("access-03", "EX2300-24T", "tokyo"),
+		device("dist-01", "QFX5100", "tokyo"),
+		device("dist-02", "DCS-7280SR", "tokyo"),
+	}))
+
+	// 1ページに収まらない台数でも全デバイスを検査する
+	setDevicePageSize(t, 2)
+	report, err := classificationService.EvaluateHardwareLifecycle(ctx, asOf, "", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, report.CheckedDevices)
+	assert.Equal(t, 4, report.MatchedDevices)
+	affected := map[string][]string{}
+	for _, group := range report.Groups {
+		for _, device := range group.Devices {
+			assert.Equal(t, classification.LifecycleEndOfLife, device.Status)
+			affected[group.Site] = append(affected[group.Site], device.DeviceID)
+		}
+	}
+	assert.Equal(t, map[string][]string{"tokyo": {"access-01", "access-03"}, "osaka": {"access-02"}}, affected)
+
+	// 販売終了のみのモデルも含める
+	report, err = classificationService.EvaluateHardwareLifecycle(ctx, asOf, "", true)
+	require.NoError(t, err)
+	var count int
+	for _, group := range report.Groups {
+		count += group.Count
+	}
+	assert.Equal(t, 4, count)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+var (
+	// ErrInvalidIconMapping is returned when an icon mapping is malformed
+	ErrInvalidIconMapping = apperror.Validation("invalid_icon_mapping", "invalid icon mapping")
+	// ErrIconMappingNotFound is returned when the requested icon mapping does not exist
+	ErrIconMappingNotFound = apperror.NotFound("icon_mapping_not_found", "icon mapping not found")
+)
+
+// IconService manages the icon mappings and serves them as a versioned manifest
+type IconService struct {
+	iconRepo visualization.IconRepository
+}
+
+func NewIconService(iconRepo visualization.IconRepository) *IconService {
+	return &IconService{iconRepo: iconRepo}
+}
+
+// GetManifest returns the current icon manifest
+func (s *IconService) GetManifest(ctx context.Context) (visualization.IconManifest, error) {
+	mappings, err := s.iconRepo.ListIconMappings(ctx)
+	if err != nil {
+		return visualization.IconManifest{}, fmt.Errorf("failed to list icon mappings: %w", err)
+	}
+	return visualization.BuildIconManifest(mappings), nil
+}
+
+// SaveIconMapping validates and stores an icon mapping
+func (s *IconService) SaveIconMapping(ctx context.Context, mapping visualization.IconMapping, userID string) (*visualization.IconMapping, error) {
+	mapping.ID = strings.TrimSpace(mapping.ID)
+	mapping.DeviceType = strings.TrimSpace(mapping.DeviceType)
+	mapping.Vendor = strings.TrimSpace(mapping.Vendor)
+	mapping.Icon = strings.TrimSpace(mapping.Icon)
+
+	if err := mapping.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIconMapping, err)
+	}
+
+	mapping.UpdatedBy = userID
+	mapping.UpdatedAt = time.Now()
+	if err := s.iconRepo.SaveIconMapping(ctx, mapping); err != nil {
+		return nil, fmt.Errorf("failed to save icon mapping: %w", err)
+	}
+
+	return &mapping, nil
+}
+
+// DeleteIconMapping removes an icon mapping
+func (s *IconService) DeleteIconMapping(ctx context.Context, id string) error {
+	deleted, err := s.iconRepo.DeleteIconMapping(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %s", ErrIconMappingNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+var (
+	// ErrInvalidIdentityDecision is returned when an identity decision is malformed or names unknown devices
+	ErrInvalidIdentityDecision = apperror.Validation("invalid_identity_decision", "invalid identity decision")
+	// ErrIdentityDecisionNotFound is returned when no decision is stored under the key
+	ErrIdentityDecisionNotFound = apperror.NotFound("identity_decision_not_found", "identity decision not found")
+)
+
+// IdentityService resolves the devices that several sources describe under different IDs into
+// one canonical identity and stores the manual decisions on the conflicts left over
+type IdentityService struct {
+	identityRepo    topology.IdentityRepository
+	topologyService *TopologyService
+	options         topology.IdentityOptions
+}
+
+func NewIdentityService(identityRepo topology.IdentityRepository, topologyService *TopologyService) *IdentityService {
+	return &IdentityService{
+		identityRepo:    identityRepo,
+		topologyService: topologyService,
+	}
+}
+
+// SetOptions changes the device metadata keys holding the identifiers
+func (s *IdentityService) SetOptions(options topology.IdentityOptions) {
+	s.options = options
+}
+
+// Map resolves the identities of the current topology applying the stored decisions
+func (s *IdentityService) Map(ctx context.Context) (*topology.IdentityMap, error) {
+	snapshot, err := s.topologyService.TakeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	decisions, err := s.identityRepo.ListIdentityDecisions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return topology.ResolveIdentities(snapshot.Devices, snapshot.Links, s.options, decisions), nil
+}
+
+// Decisions returns the stored decisions
+func (s *IdentityService) Decisions(ctx context.Context) ([]topology.IdentityDecision, error) {
+	return s.identityRepo.ListIdentityDecisions(ctx)
+}
+
+// Decide stores a decision on existing devices, replacing an earlier decision on the same devices
+func (s *IdentityService) Decide(ctx context.Context, decision topology.IdentityDecision, userID string) (*topology.IdentityDecision, error) {
+	decision.Devices = trimIDs(decision.Devices)
+	decision.CanonicalID = strings.TrimSpace(decision.CanonicalID)
+	if err := decision.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIdentityDecision, err)
+	}
+
+	snapshot, err := s.topologyService.TakeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(snapshot.Devices))
+	for _, device := range snapshot.Devices {
+		known[device.ID] = true
+	}
+	var unknown []string
+	for _, deviceID := range decision.Devices {
+		if !known[deviceID] {
+			unknown = append(unknown, deviceID)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: unknown devices %s", ErrInvalidIdentityDecision, strings.Join(unknown, ", "))
+	}
+
+	decision.Key = topology.IdentityKey(decision.Devices)
+	decision.DecidedBy = userID
+	decision.DecidedAt = time.Now()
+	if err := s.identityRepo.SaveIdentityDecision(ctx, decision); err != nil {
+		return nil, err
+	}
+	return &decision, nil
+}
+
+// DeleteDecision removes a decision so its devices are resolved automatically again
+func (s *IdentityService) DeleteDecision(ctx context.Context, key string) error {
+	decisions, err := s.identityRepo.ListIdentityDecisions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, decision := range decisions {
+		if decision.Key == key {
+			return s.identityRepo.DeleteIdentityDecision(ctx, key)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrIdentityDecisionNotFound, key)
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/job"
+)
+
+var (
+	// ErrInvalidJob is returned when a job cannot be submitted
+	ErrInvalidJob = apperror.Validation("invalid_job", "invalid job")
+	// ErrJobNotFound is returned when the requested job does not exist
+	ErrJobNotFound = apperror.NotFound("job_not_found", "job not found")
+)
+
+// Job kinds run by the API server
+const (
+	JobKindSnapshot                 = "snapshot"
+	JobKindConsistencyReport        = "report.consistency"
+	JobKindHardwareComplianceReport = "report.hardware_compliance"
+	JobKindMetadataComplianceReport = "report.metadata_compliance"
+)
+
+// Job runner defaults
+const (
+	DefaultJobVisibility   = 5 * time.Minute
+	DefaultJobPollInterval = 5 * time.Second
+	MaxJobListLimit        = 500
+)
+
+// JobHandler runs one job and returns its result, which is stored as JSON
+type JobHandler func(ctx context.Context, payload json.RawMessage) (interface{}, error)
+
+// JobService submits jobs to the durable job queue and runs the kinds registered with it
+type JobService struct {
+	jobRepo    job.Repository
+	handlers   map[string]JobHandler
+	visibility time.Duration
+}
+
+func NewJobService(jobRepo job.Repository) *JobService {
+	return &JobService{
+		jobRepo:    jobRepo,
+		handlers:   make(map[string]JobHandler),
+		visibility: DefaultJobVisibility,
+	}
+}
+
+// Register runs jobs of kind with handler. It must be called before jobs are run.
+func (s *JobService) Register(kind string, handler JobHandler) {
+	s.handlers[kind] = handler
+}
+
+// Kinds returns the registered job kinds
+func (s *JobService) Kinds() []string {
+	kinds := make([]string, 0, len(s.handlers))
+	for kind := range s.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Submit enqueues a job of a registered kind. maxAttempts 0 uses job.DefaultMaxAttempts.
+func (s *JobService) Submit(ctx context.Context, kind string, payload json.RawMessage, maxAttempts int, userID string) (*job.Job, error) {
+	if _, ok := s.handlers[kind]; !ok {
+		return nil, fmt.Errorf("%w: unknown kind '%s' (expected one of %v)", ErrInvalidJob, kind, s.Kinds())
+	}
+	if maxAttempts == 0 {
+		maxAttempts = job.DefaultMaxAttempts
+	}
+
+	submitted := job.Job{
+		ID:          uuid.New().String(),
+		Kind:        kind,
+		Payload:     payload,
+		Status:      job.StatusQueued,
+		MaxAttempts: maxAttempts,
+		CreatedBy:   userID,
+	}
+	if err := submitted.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+
+	if err := s.jobRepo.EnqueueJob(ctx, submitted); err != nil {
+		return nil, err
+	}
+
+	// 時刻などはデータベースで決まるため、保存された内容を返す
+	return s.GetJob(ctx, submitted.ID)
+}
+
+// GetJob returns a job by ID
+func (s *JobService) GetJob(ctx context.Context, id string) (*job.Job, error) {
+	found, err := s.jobRepo.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return found, nil
+}
+
+// ListJobs returns the newest jobs, optionally restricted to a status
+func (s *JobService) ListJobs(ctx context.Context, status string, limit int) ([]job.Job, error) {
+	if status != "" && !job.IsValidStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status '%s'", ErrInvalidJob, status)
+	}
+	if limit <= 0 || limit > MaxJobListLimit {
+		limit = MaxJobListLimit
+	}
+	return s.jobRepo.ListJobs(ctx, status, limit)
+}
+
+// RunJobs claims and runs jobs as workerID until ctx is canceled, waiting pollInterval
+// whenever the queue is empty. Errors of the queue itself are passed to onError.
+func (s *JobService) RunJobs(ctx context.Context, workerID string, pollInterval time.Duration, onError func(error)) {
+	for {
+		ran, err := s.RunNext(ctx, workerID)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			onError(err)
+		}
+		if ran && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// RunNext claims one visible job and runs it. It returns false when no job was visible.
+// A failed job is retried with job.RetryDelay until it uses up its attempts. When ctx is
+// canceled while the job runs, nothing is recorded and the job runs again once its lease expires.
+func (s *JobService) RunNext(ctx context.Context, workerID string) (bool, error) {
+	kinds := s.Kinds()
+	if len(kinds) == 0 {
+		return false, nil
+	}
+
+	claimed, err := s.jobRepo.ClaimJob(ctx, workerID, kinds, s.visibility)
+	if err != nil || claimed == nil {
+		return false, err
+	}
+
+	result, runErr := s.execute(ctx, claimed, workerID)
+	if ctx.Err() != nil {
+		return true, nil
+	}
+
+	var held bool
+	if runErr != nil {
+		held, err = s.jobRepo.FailJob(ctx, claimed.ID, workerID, runErr.Error(), job.RetryDelay(claimed.Attempts))
+	} else {
+		held, err = s.jobRepo.CompleteJob(ctx, claimed.ID, workerID, result)
+	}
+	if err != nil {
+		return true, err
+	}
+	if !held {
+		return true, fmt.Errorf("lease of job %s expired before attempt %d finished", claimed.ID, claimed.Attempts)
+	}
+	return true, nil
+}
+
+// execute runs the handler of a claimed job while renewing its lease
+func (s *JobService) execute(ctx context.Context, claimed *job.Job, workerID string) (result json.RawMessage, err error) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// リースを定期的に延長し、他のワーカーに奪われた場合は実行を打ち切る
+	go func() {
+		ticker := time.NewTicker(s.visibility / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				if held, err := s.jobRepo.ExtendJobLease(jobCtx, claimed.ID, workerID, s.visibility); err == nil && !held {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	handler, ok := s.handlers[claimed.Kind]
+	if !ok {
+		return nil, fmt.Errorf("no handler for kind '%s'", claimed.Kind)
+	}
+
+	output, err := handler(jobCtx, claimed.Payload)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job result: %w", err)
+	}
+	return data, nil
+}
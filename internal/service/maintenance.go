@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+var (
+	// ErrInvalidMaintenanceWindow is returned when a maintenance window or impact preview is malformed
+	ErrInvalidMaintenanceWindow = apperror.Validation("invalid_maintenance_window", "invalid maintenance window")
+	// ErrMaintenanceWindowNotFound is returned when the maintenance window does not exist
+	ErrMaintenanceWindowNotFound = apperror.NotFound("maintenance_window_not_found", "maintenance window not found")
+)
+
+// MaintenanceService schedules maintenance windows and previews which devices would lose every
+// path to the uplinks during a window, counting the windows that overlap it as down as well
+type MaintenanceService struct {
+	maintenanceRepo topology.MaintenanceRepository
+	topologyService *TopologyService
+}
+
+func NewMaintenanceService(maintenanceRepo topology.MaintenanceRepository, topologyService *TopologyService) *MaintenanceService {
+	return &MaintenanceService{
+		maintenanceRepo: maintenanceRepo,
+		topologyService: topologyService,
+	}
+}
+
+// ListWindows returns the windows active between from and to (zero = open)
+func (s *MaintenanceService) ListWindows(ctx context.Context, from, to time.Time) ([]topology.MaintenanceWindow, error) {
+	return s.maintenanceRepo.ListMaintenanceWindows(ctx, from, to)
+}
+
+// ScheduleWindow stores a window taking down existing devices and links
+func (s *MaintenanceService) ScheduleWindow(ctx context.Context, window topology.MaintenanceWindow, userID string) (*topology.MaintenanceWindow, error) {
+	window.Ticket = strings.TrimSpace(window.Ticket)
+	if window.Ticket == "" {
+		return nil, fmt.Errorf("%w: ticket is required", ErrInvalidMaintenanceWindow)
+	}
+	if _, err := s.resolveWindow(ctx, &window); err != nil {
+		return nil, err
+	}
+
+	window.ID = uuid.New().String()
+	window.CreatedBy = userID
+	window.CreatedAt = time.Now()
+	if err := s.maintenanceRepo.SaveMaintenanceWindow(ctx, window); err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// CancelWindow removes a window
+func (s *MaintenanceService) CancelWindow(ctx context.Context, windowID string) error {
+	window, err := s.maintenanceRepo.GetMaintenanceWindow(ctx, windowID)
+	if err != nil {
+		return err
+	}
+	if window == nil {
+		return fmt.Errorf("%w: %s", ErrMaintenanceWindowNotFound, windowID)
+	}
+	return s.maintenanceRepo.DeleteMaintenanceWindow(ctx, windowID)
+}
+
+// PreviewImpact reports the devices of targets that reach one of uplinks today but would reach
+// none while window and the stored windows overlapping it are active. A stored window with the
+// same ID as window is not counted twice, so a scheduled window can be re-evaluated.
+func (s *MaintenanceService) PreviewImpact(ctx context.Context, window topology.MaintenanceWindow, targets, uplinks topology.DeviceSet) (*topology.MaintenanceImpact, error) {
+	snapshot, err := s.resolveWindow(ctx, &window)
+	if err != nil {
+		return nil, err
+	}
+
+	targetIDs, unknownTargets := targets.Resolve(snapshot.Devices)
+	uplinkIDs, unknownUplinks := uplinks.Resolve(snapshot.Devices)
+	if unknown := append(unknownTargets, unknownUplinks...); len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: unknown devices %s", ErrInvalidMaintenanceWindow, strings.Join(unknown, ", "))
+	}
+	if len(targetIDs) == 0 || len(uplinkIDs) == 0 {
+		return nil, fmt.Errorf("%w: targets and uplinks must each match at least one device", ErrInvalidMaintenanceWindow)
+	}
+
+	stored, err := s.maintenanceRepo.ListMaintenanceWindows(ctx, window.StartsAt, window.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	overlapping := make([]topology.MaintenanceWindow, 0, len(stored))
+	for _, other := range stored {
+		if other.ID != window.ID {
+			overlapping = append(overlapping, other)
+		}
+	}
+
+	return topology.EvaluateMaintenanceImpact(snapshot.Links, targetIDs, uplinkIDs, window, overlapping), nil
+}
+
+// resolveWindow validates window against the current topology and returns the topology
+func (s *MaintenanceService) resolveWindow(ctx context.Context, window *topology.MaintenanceWindow) (*topology.Snapshot, error) {
+	window.Devices = trimIDs(window.Devices)
+	window.Links = trimIDs(window.Links)
+	if err := window.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMaintenanceWindow, err)
+	}
+
+	snapshot, err := s.topologyService.TakeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(snapshot.Devices)+len(snapshot.Links))
+	for _, device := range snapshot.Devices {
+		known[device.ID] = true
+	}
+	for _, link := range snapshot.Links {
+		known[link.ID] = true
+	}
+	var unknown []string
+	for _, id := range append(append([]string{}, window.Devices...), window.Links...) {
+		if !known[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: unknown devices or links %s", ErrInvalidMaintenanceWindow, strings.Join(unknown, ", "))
+	}
+
+	return snapshot, nil
+}
+
+// trimIDs drops blank and duplicate IDs, keeping the order
+func trimIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	trimmed := []string{}
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		trimmed = append(trimmed, id)
+	}
+	return trimmed
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// DefaultMonitoredWithin is how recently a device must have been seen by monitoring to be
+// included in the management reachability report
+const DefaultMonitoredWithin = 24 * time.Hour
+
+// ErrInvalidMonitoredWithin is returned when the monitoring window of the report is not positive
+var ErrInvalidMonitoredWithin = apperror.Validation("invalid_monitored_within", "monitored_within must be positive")
+
+// ManagementReachabilityService reports devices that are monitored but whose management plane
+// (SSH/NETCONF) the worker could not reach
+type ManagementReachabilityService struct {
+	managementRepo topology.ManagementReachabilityRepository
+	topologyRepo   topology.Repository
+}
+
+func NewManagementReachabilityService(managementRepo topology.ManagementReachabilityRepository, topologyRepo topology.Repository) *ManagementReachabilityService {
+	return &ManagementReachabilityService{
+		managementRepo: managementRepo,
+		topologyRepo:   topologyRepo,
+	}
+}
+
+// Report lists the devices seen by monitoring within monitoredWithin whose last management-plane
+// check failed (0 = DefaultMonitoredWithin)
+func (s *ManagementReachabilityService) Report(ctx context.Context, monitoredWithin time.Duration) (*topology.ManagementReachabilityReport, error) {
+	if monitoredWithin < 0 {
+		return nil, ErrInvalidMonitoredWithin
+	}
+	if monitoredWithin == 0 {
+		monitoredWithin = DefaultMonitoredWithin
+	}
+
+	results, err := s.managementRepo.ListManagementReachability(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := ListAllDevices(ctx, s.topologyRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	report := topology.BuildManagementReachabilityReport(devices, results, time.Now().Add(-monitoredWithin))
+	return &report, nil
+}
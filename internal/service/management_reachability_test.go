@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagementReachabilityService_ReportOverEveryPage(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	ctx := context.Background()
+
+	stale := testutil.CreateTestDevice("device-005")
+	stale.LastSeen = time.Now().Add(-48 * time.Hour)
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, []topology.Device{
+		testutil.CreateTestDevice("device-001"),
+		testutil.CreateTestDevice("device-002"),
+		testutil.CreateTestDevice("device-003"),
+		testutil.CreateTestDevice("device-004"),
+		stale,
+	}))
+
+	managementRepo, ok := setup.Repo.(topology.ManagementReachabilityRepository)
+	require.True(t, ok, "SQLite repository should store management reachability")
+	now := time.Now()
+	require.NoError(t, managementRepo.SaveManagementReachability(ctx, []topology.ManagementReachability{
+		{DeviceID: "device-001", SSH: true, CheckedAt: now},
+		{DeviceID: "device-003", Error: "connection refused", CheckedAt: now},
+		{DeviceID: "device-004", Error: "timeout", CheckedAt: now},
+		{DeviceID: "device-005", Error: "timeout", CheckedAt: now},
+	}))
+
+	// 1ページに収まらない台数でも全デバイスを集計する
+	setDevicePageSize(t, 2)
+	report, err := NewManagementReachabilityService(managementRepo, setup.Repo).Report(ctx, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.MonitoredDevices, "devices not seen within the window are out of scope")
+	assert.Equal(t, 1, report.Manageable)
+	assert.Equal(t, 1, report.Unchecked)
+	require.Len(t, report.Unmanageable, 2)
+	assert.Equal(t, "device-003", report.Unmanageable[0].DeviceID)
+	assert.Equal(t, "device-004", report.Unmanageable[1].DeviceID)
+
+	_, err = NewManagementReachabilityService(managementRepo, setup.Repo).Report(ctx, -time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidMonitoredWithin)
+}
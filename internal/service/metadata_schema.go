@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrMissingRequiredMetadata is returned when a device is classified as a device type whose
+// required metadata it lacks and the metadata schema is enforced
+var ErrMissingRequiredMetadata = apperror.Validation("missing_required_metadata", "device lacks required metadata")
+
+// MetadataComplianceService checks the devices against the metadata required per device type
+type MetadataComplianceService struct {
+	topologyRepo topology.Repository
+	schema       topology.MetadataSchema
+}
+
+func NewMetadataComplianceService(topologyRepo topology.Repository, schema topology.MetadataSchema) *MetadataComplianceService {
+	return &MetadataComplianceService{
+		topologyRepo: topologyRepo,
+		schema:       schema,
+	}
+}
+
+// Schema returns the configured requirements
+func (s *MetadataComplianceService) Schema() topology.MetadataSchema {
+	return s.schema
+}
+
+// Report lists the devices lacking required metadata, only those of deviceType unless empty
+func (s *MetadataComplianceService) Report(ctx context.Context, deviceType string) (*topology.MetadataComplianceReport, error) {
+	devices := []topology.Device{}
+	err := walkDevices(ctx, s.topologyRepo, "", func(device topology.Device) bool {
+		if deviceType == "" || strings.EqualFold(topology.MetadataDeviceType(device), deviceType) {
+			devices = append(devices, device)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return topology.CheckMetadataCompliance(devices, s.schema, time.Now()), nil
+}
+
+// checkRequiredMetadata returns ErrMissingRequiredMetadata when schema is enforced and device
+// lacks metadata required for its device type
+func checkRequiredMetadata(schema topology.MetadataSchema, device topology.Device) error {
+	if !schema.Enforce {
+		return nil
+	}
+	if missing := schema.MissingKeys(device); len(missing) > 0 {
+		return fmt.Errorf("%w: device %s lacks %s required for device type %s",
+			ErrMissingRequiredMetadata, device.ID, strings.Join(missing, ", "), topology.MetadataDeviceType(device))
+	}
+	return nil
+}
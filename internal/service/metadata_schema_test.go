@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataComplianceService_ReportOverEveryPage(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	ctx := context.Background()
+
+	device := func(id, deviceType string, metadata map[string]string) topology.Device {
+		device := testutil.CreateTestDevice(id)
+		device.DeviceType = deviceType
+		device.Metadata = metadata
+		return device
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, []topology.Device{
+		device("server-01", "server", map[string]string{"owner": "infra"}),
+		device("server-02", "server", nil),
+		device("server-03", "server", map[string]string{"owner": "infra"}),
+		device("server-04", "server", nil),
+		device("switch-01", "network-switch", nil),
+	}))
+
+	schema := topology.MetadataSchema{Required: []topology.MetadataRequirement{
+		{DeviceType: "server", Keys: []string{"owner"}},
+		{DeviceType: "network-switch", Keys: []string{"rack"}},
+	}}
+	metadataService := NewMetadataComplianceService(setup.Repo, schema)
+
+	// 1ページに収まらない台数でも全デバイスを検査する
+	setDevicePageSize(t, 2)
+	report, err := metadataService.Report(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.CheckedDevices)
+	assert.Equal(t, 2, report.CompliantDevices)
+	assert.Equal(t, map[string]int{"owner": 2, "rack": 1}, report.MissingKeys)
+
+	// デバイスタイプは大文字小文字を区別しない
+	report, err = metadataService.Report(ctx, "Server")
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.CheckedDevices)
+	require.Len(t, report.Violations, 2)
+	assert.Equal(t, "server-02", report.Violations[0].DeviceID)
+	assert.Equal(t, "server-04", report.Violations[1].DeviceID)
+}
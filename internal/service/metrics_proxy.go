@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/prometheus"
+)
+
+// minMetricStep is the smallest step of a range query; finer steps only repeat scrape samples
+const minMetricStep = 15 * time.Second
+
+var (
+	// ErrUnknownMetric is returned for metrics that are not in the proxy whitelist
+	ErrUnknownMetric = apperror.NotFound("unknown_metric", "unknown metric")
+	// ErrInvalidMetricQuery is returned for a missing interface or an invalid range or step
+	ErrInvalidMetricQuery = apperror.Validation("invalid_metric_query", "invalid metric query")
+	// ErrMetricDeviceNotFound is returned when metrics are requested for an unknown device
+	ErrMetricDeviceNotFound = apperror.NotFound("device_not_found", "device not found")
+	// ErrMetricsRateLimited is returned when the query budget towards Prometheus is exhausted
+	ErrMetricsRateLimited = errors.New("metrics query rate limit exceeded")
+)
+
+// MetricsQuerier runs PromQL queries; it is implemented by *prometheus.Client
+type MetricsQuerier interface {
+	Query(ctx context.Context, query string, timestamp time.Time) (*prometheus.QueryResult, error)
+	QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (*prometheus.QueryResult, error)
+}
+
+// MetricQuery selects a whitelisted metric of a device. Range 0 asks for the current value.
+type MetricQuery struct {
+	DeviceID  string
+	Metric    string
+	Interface string
+	Range     time.Duration
+	Step      time.Duration // 0 = 範囲と max_points から決める
+}
+
+// MetricsProxyService runs whitelisted metric queries against Prometheus for the frontend.
+// Results are cached and queries that miss the cache are rate limited, so browsers can
+// show device metrics without reaching Prometheus or sending arbitrary PromQL.
+type MetricsProxyService struct {
+	querier MetricsQuerier
+	repo    topology.Repository
+	config  prometheus.ProxyConfig
+
+	mu      sync.Mutex
+	cache   map[string]cachedMetric
+	traffic *cachedTraffic // 利用率の色分け用（全インターフェース）
+	tokens  float64
+	refill  time.Time
+}
+
+type cachedMetric struct {
+	metric  topology.DeviceMetric
+	expires time.Time
+}
+
+// cachedTraffic is the traffic in bps of every interface, keyed by device and interface
+type cachedTraffic struct {
+	bps     map[[2]string]float64
+	expires time.Time
+}
+
+// NewMetricsProxyService creates a proxy for the metrics in config
+func NewMetricsProxyService(querier MetricsQuerier, repo topology.Repository, config prometheus.ProxyConfig) *MetricsProxyService {
+	config = config.WithDefaults()
+	return &MetricsProxyService{
+		querier: querier,
+		repo:    repo,
+		config:  config,
+		cache:   make(map[string]cachedMetric),
+		tokens:  float64(config.Burst),
+		refill:  time.Now(),
+	}
+}
+
+// ListMetrics returns the metrics that can be queried, sorted by name
+func (s *MetricsProxyService) ListMetrics() []topology.MetricDefinition {
+	definitions := make([]topology.MetricDefinition, 0, len(s.config.Metrics))
+	for name, metric := range s.config.Metrics {
+		definitions = append(definitions, topology.MetricDefinition{
+			Name:         name,
+			Unit:         metric.Unit,
+			Description:  metric.Description,
+			PerInterface: metric.PerInterface(),
+		})
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions
+}
+
+// GetDeviceMetric runs the query of a whitelisted metric for a device
+func (s *MetricsProxyService) GetDeviceMetric(ctx context.Context, query MetricQuery) (*topology.DeviceMetric, error) {
+	query.Interface = strings.TrimSpace(query.Interface)
+	metric, ok := s.config.Metrics[query.Metric]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMetric, query.Metric)
+	}
+	if metric.PerInterface() && query.Interface == "" {
+		return nil, fmt.Errorf("%w: metric %s requires an interface", ErrInvalidMetricQuery, query.Metric)
+	}
+	if !metric.PerInterface() {
+		query.Interface = ""
+	}
+	if query.Range < 0 || query.Range > s.config.MaxRange {
+		return nil, fmt.Errorf("%w: range must be between 0 and %s", ErrInvalidMetricQuery, s.config.MaxRange)
+	}
+	if query.Step < 0 {
+		return nil, fmt.Errorf("%w: step must not be negative", ErrInvalidMetricQuery)
+	}
+
+	now := time.Now()
+	var start time.Time
+	end := now
+	if query.Range > 0 {
+		// 1系列の点数が max_points を超えない最小のステップ（秒単位に切り上げ）
+		minStep := (query.Range/time.Duration(s.config.MaxPoints) + time.Second - 1).Truncate(time.Second)
+		if minStep < minMetricStep {
+			minStep = minMetricStep
+		}
+		query.Step = query.Step.Truncate(time.Second)
+		if query.Step == 0 {
+			query.Step = minStep
+		}
+		if query.Step < minStep {
+			return nil, fmt.Errorf("%w: step must be at least %s for range %s", ErrInvalidMetricQuery, minStep, query.Range)
+		}
+		// 終端をステップに揃えて、同じ期間の問い合わせがキャッシュを共有できるようにする
+		end = now.Truncate(query.Step)
+		start = end.Add(-query.Range)
+	} else {
+		query.Step = 0
+		end = now.Truncate(time.Second)
+	}
+
+	device, err := s.repo.GetDevice(ctx, query.DeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if device == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMetricDeviceNotFound, query.DeviceID)
+	}
+
+	key := strings.Join([]string{query.Metric, query.DeviceID, query.Interface, query.Range.String(), query.Step.String()}, "\x00")
+	if cached, ok := s.lookup(key, now); ok {
+		cached.Cached = true
+		return &cached, nil
+	}
+	if !s.allow(now) {
+		return nil, ErrMetricsRateLimited
+	}
+
+	promQL := metric.Render(query.DeviceID, query.Interface)
+	var result *prometheus.QueryResult
+	if query.Range > 0 {
+		result, err = s.querier.QueryRange(ctx, promQL, start, end, query.Step)
+	} else {
+		result, err = s.querier.Query(ctx, promQL, end)
+	}
+	// 部分応答は欠けていることを示して返す
+	partial := errors.Is(err, prometheus.ErrPartialResponse)
+	if err != nil && !partial {
+		return nil, apperror.DependencyUnavailable("prometheus_unavailable", err)
+	}
+
+	deviceMetric := topology.DeviceMetric{
+		Metric:    query.Metric,
+		DeviceID:  query.DeviceID,
+		Interface: query.Interface,
+		Unit:      metric.Unit,
+		End:       end,
+		FetchedAt: now,
+	}
+	if query.Range > 0 {
+		deviceMetric.Start = &start
+		deviceMetric.Step = query.Step.String()
+	}
+	deviceMetric.Series, deviceMetric.Truncated = s.toSeries(result)
+	deviceMetric.Partial = partial
+
+	// 部分応答はキャッシュせず、次の要求で取り直す
+	if !partial {
+		s.store(key, deviceMetric, now)
+	}
+	return &deviceMetric, nil
+}
+
+// EdgeUtilization returns the utilization in percent of the edges whose speed and traffic are known,
+// keyed by edge ID. An edge is as utilized as the busier direction of either end. The traffic of all
+// interfaces is fetched with the utilization queries and cached; while the query budget is exhausted
+// an expired result is used if there is one.
+func (s *MetricsProxyService) EdgeUtilization(ctx context.Context, edges []visualization.VisualEdge) (map[string]float64, error) {
+	traffic, err := s.interfaceTraffic(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	utilization := make(map[string]float64)
+	for _, edge := range edges {
+		speed, ok := topology.ParseLinkSpeed(edge.Metadata["speed"])
+		if !ok || speed <= 0 {
+			continue
+		}
+		var bps float64
+		found := false
+		for _, end := range [][2]string{{edge.Source, edge.LocalPort}, {edge.Target, edge.RemotePort}} {
+			if value, ok := traffic[end]; ok {
+				bps = math.Max(bps, value)
+				found = true
+			}
+		}
+		if found {
+			utilization[edge.ID] = bps / speed * 100
+		}
+	}
+	return utilization, nil
+}
+
+// interfaceTraffic returns the traffic of every interface, the larger of both directions
+func (s *MetricsProxyService) interfaceTraffic(ctx context.Context) (map[[2]string]float64, error) {
+	now := time.Now()
+	s.mu.Lock()
+	cached := s.traffic
+	s.mu.Unlock()
+	if cached != nil && now.Before(cached.expires) {
+		return cached.bps, nil
+	}
+	if !s.allow(now) {
+		if cached != nil {
+			return cached.bps, nil
+		}
+		return nil, ErrMetricsRateLimited
+	}
+
+	queries := s.config.Utilization
+	bps := make(map[[2]string]float64)
+	partial := false
+	for _, query := range []string{queries.In, queries.Out} {
+		result, err := s.querier.Query(ctx, query, now.Truncate(time.Second))
+		if errors.Is(err, prometheus.ErrPartialResponse) {
+			partial = true // 欠けたインターフェースは利用率なしとする
+		} else if err != nil {
+			return nil, apperror.DependencyUnavailable("prometheus_unavailable", err)
+		}
+		for _, r := range result.Data.Result {
+			point, ok := toMetricPoint(r.Value)
+			if !ok {
+				continue
+			}
+			key := [2]string{r.Metric[queries.DeviceLabel], r.Metric[queries.InterfaceLabel]}
+			bps[key] = math.Max(bps[key], point.Value)
+		}
+	}
+
+	if !partial {
+		s.mu.Lock()
+		s.traffic = &cachedTraffic{bps: bps, expires: now.Add(s.config.CacheTTL)}
+		s.mu.Unlock()
+	}
+	return bps, nil
+}
+
+// toSeries converts a query result, keeping at most MaxSeries series and MaxPoints points each
+func (s *MetricsProxyService) toSeries(result *prometheus.QueryResult) ([]topology.MetricSeries, bool) {
+	series := []topology.MetricSeries{}
+	truncated := false
+	for _, r := range result.Data.Result {
+		if len(series) >= s.config.MaxSeries {
+			truncated = true
+			break
+		}
+
+		points := []topology.MetricPoint{}
+		if len(r.Value) == 2 {
+			if point, ok := toMetricPoint(r.Value); ok {
+				points = append(points, point)
+			}
+		}
+		for _, value := range r.Values {
+			if point, ok := toMetricPoint(value); ok {
+				points = append(points, point)
+			}
+		}
+		if len(points) > s.config.MaxPoints {
+			points = points[len(points)-s.config.MaxPoints:]
+			truncated = true
+		}
+		series = append(series, topology.MetricSeries{Labels: r.Metric, Points: points})
+	}
+	return series, truncated
+}
+
+// toMetricPoint converts a [timestamp, "value"] pair of the Prometheus API
+func toMetricPoint(pair []interface{}) (topology.MetricPoint, bool) {
+	if len(pair) != 2 {
+		return topology.MetricPoint{}, false
+	}
+	timestamp, ok := pair[0].(float64)
+	if !ok {
+		return topology.MetricPoint{}, false
+	}
+	raw, ok := pair[1].(string)
+	if !ok {
+		return topology.MetricPoint{}, false
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return topology.MetricPoint{}, false
+	}
+	// NaN や Inf は JSON にできないため除外する
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return topology.MetricPoint{}, false
+	}
+	sec, frac := math.Modf(timestamp)
+	return topology.MetricPoint{Time: time.Unix(int64(sec), int64(frac*1e9)).UTC(), Value: value}, true
+}
+
+func (s *MetricsProxyService) lookup(key string, now time.Time) (topology.DeviceMetric, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.cache[key]
+	if !ok || !now.Before(entry.expires) {
+		return topology.DeviceMetric{}, false
+	}
+	return entry.metric, true
+}
+
+func (s *MetricsProxyService) store(key string, metric topology.DeviceMetric, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, entry := range s.cache {
+		if !now.Before(entry.expires) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = cachedMetric{metric: metric, expires: now.Add(s.config.CacheTTL)}
+}
+
+// allow takes a token from the bucket limiting the queries sent to Prometheus
+func (s *MetricsProxyService) allow(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elapsed := now.Sub(s.refill).Seconds(); elapsed > 0 {
+		s.tokens = math.Min(float64(s.config.Burst), s.tokens+elapsed*s.config.RateLimit)
+		s.refill = now
+	}
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// OrphanedLinkService keeps the sync from recreating deleted devices out of the links their
+// neighbors still report, and lists those links
+type OrphanedLinkService struct {
+	repo       topology.OrphanedLinkRepository
+	deviceRepo topology.Repository
+	policy     topology.OrphanedLinkPolicy
+}
+
+func NewOrphanedLinkService(repo topology.OrphanedLinkRepository, deviceRepo topology.Repository, policy topology.OrphanedLinkPolicy) *OrphanedLinkService {
+	return &OrphanedLinkService{
+		repo:       repo,
+		deviceRepo: deviceRepo,
+		policy:     policy.WithDefaults(),
+	}
+}
+
+// Report lists the devices deleted within the grace period at now and the links dropped for them
+func (s *OrphanedLinkService) Report(ctx context.Context, now time.Time) (*topology.OrphanedLinkReport, error) {
+	devices, err := s.repo.ListDeletedDevices(ctx, s.policy.DeletedSince(now))
+	if err != nil {
+		return nil, err
+	}
+	report := topology.NewOrphanedLinkReport(devices, s.policy)
+	return &report, nil
+}
+
+// DropOrphanedLinks returns links without those reported for devices deleted within the grace
+// period at now, and records the dropped links on the deletions. Devices that were added again
+// since their deletion keep their links.
+func (s *OrphanedLinkService) DropOrphanedLinks(ctx context.Context, links []topology.Link, now time.Time) ([]topology.Link, int, error) {
+	if s.policy.Disabled || len(links) == 0 {
+		return links, 0, nil
+	}
+
+	deleted, err := s.repo.ListDeletedDevices(ctx, s.policy.DeletedSince(now))
+	if err != nil {
+		return nil, 0, err
+	}
+	gone := make(map[string]bool, len(deleted))
+	for _, deletion := range deleted {
+		device, err := s.deviceRepo.GetDevice(ctx, deletion.DeviceID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to check deleted device %s: %w", deletion.DeviceID, err)
+		}
+		if device == nil {
+			gone[deletion.DeviceID] = true
+		}
+	}
+	if len(gone) == 0 {
+		return links, 0, nil
+	}
+
+	kept := make([]topology.Link, 0, len(links))
+	orphaned := make(map[string][]string)
+	for _, link := range links {
+		if !gone[link.SourceID] && !gone[link.TargetID] {
+			kept = append(kept, link)
+			continue
+		}
+		for _, deviceID := range []string{link.SourceID, link.TargetID} {
+			if gone[deviceID] {
+				orphaned[deviceID] = append(orphaned[deviceID], link.ID)
+			}
+		}
+	}
+
+	deviceIDs := make([]string, 0, len(orphaned))
+	for deviceID := range orphaned {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	sort.Strings(deviceIDs)
+	for _, deviceID := range deviceIDs {
+		if err := s.repo.RecordOrphanedLinks(ctx, deviceID, orphaned[deviceID], now); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return kept, len(links) - len(kept), nil
+}
+
+// Purge forgets the deletions whose grace period has passed at now, so the devices are recreated
+// if their neighbors still report them
+func (s *OrphanedLinkService) Purge(ctx context.Context, now time.Time) (int, error) {
+	return s.repo.PurgeDeletedDevices(ctx, s.policy.DeletedSince(now))
+}
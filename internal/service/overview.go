@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// GetSiteOverview collapses the devices sharing the metadata value siteKey (default "site") into
+// one node per site and aggregates the links between sites
+func (s *TopologyService) GetSiteOverview(ctx context.Context, siteKey string) (*topology.Overview, error) {
+	if siteKey == "" {
+		siteKey = DefaultSiteMetadataKey
+	}
+
+	devices, err := ListAllDevices(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	groupOf := make(map[string]string, len(devices))
+	for _, device := range devices {
+		if site := device.Metadata[siteKey]; site != "" {
+			groupOf[device.ID] = site
+		}
+	}
+	return buildOverview(ctx, s.repo, "metadata."+siteKey, groupOf, devices)
+}
+
+// GetFabricOverview collapses the members of each fabric into one node and aggregates the links
+// between fabrics
+func (s *FabricService) GetFabricOverview(ctx context.Context) (*topology.Overview, error) {
+	_, assignments, devices, err := s.assign(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return buildOverview(ctx, s.topologyRepo, "fabric", assignments, devices)
+}
+
+// buildOverview loads the links of devices and collapses them into the groups of groupOf
+func buildOverview(ctx context.Context, repo topology.Repository, groupBy string, groupOf map[string]string, devices []topology.Device) (*topology.Overview, error) {
+	var links []topology.Link
+	for _, device := range devices {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		deviceLinks, err := repo.GetDeviceLinks(ctx, device.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", device.ID, err)
+		}
+		links = append(links, deviceLinks...)
+	}
+
+	overview := topology.BuildOverview(groupOf, devices, links)
+	overview.GroupBy = groupBy
+	return &overview, nil
+}
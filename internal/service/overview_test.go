@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopologyService_GetSiteOverviewOverEveryPage(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	ctx := context.Background()
+
+	device := func(id, site string) topology.Device {
+		device := testutil.CreateTestDevice(id)
+		if site != "" {
+			device.Metadata = map[string]string{"site": site}
+		}
+		return device
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, []topology.Device{
+		device("osaka-01", "osaka"),
+		device("osaka-02", "osaka"),
+		device("spare-01", ""),
+		device("tokyo-01", "tokyo"),
+		device("tokyo-02", "tokyo"),
+		device("tokyo-03", "tokyo"),
+	}))
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, []topology.Link{
+		testutil.CreateTestLink("link-001", "tokyo-01", "tokyo-02"),
+		testutil.CreateTestLink("link-002", "tokyo-03", "osaka-01"),
+		testutil.CreateTestLink("link-003", "osaka-02", "tokyo-01"),
+	}))
+
+	// 1ページに収まらない台数でも全デバイスを集約する
+	setDevicePageSize(t, 2)
+	overview, err := NewTopologyService(setup.Repo).GetSiteOverview(ctx, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "metadata.site", overview.GroupBy)
+	devices := map[string]int{}
+	for _, node := range overview.Nodes {
+		devices[node.ID] = node.Devices
+	}
+	assert.Equal(t, map[string]int{"tokyo": 3, "osaka": 2, topology.OverviewUnassigned: 1}, devices)
+	require.Len(t, overview.Links, 1)
+	assert.Equal(t, 2, overview.Links[0].Links)
+	assert.Equal(t, 1, overview.IntraGroupLinks)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// PortNamingLintService checks the ports of the discovered links against the configured
+// per-site naming and description conventions
+type PortNamingLintService struct {
+	topologyService *TopologyService
+	lint            topology.PortNamingLint
+}
+
+func NewPortNamingLintService(topologyService *TopologyService, lint topology.PortNamingLint) *PortNamingLintService {
+	return &PortNamingLintService{
+		topologyService: topologyService,
+		lint:            lint,
+	}
+}
+
+// Policies returns the configured conventions
+func (s *PortNamingLintService) Policies() topology.PortNamingLint {
+	return s.lint
+}
+
+// Report lists the violations of the current topology, only those of site unless empty
+func (s *PortNamingLintService) Report(ctx context.Context, site string) (*topology.PortNamingReport, error) {
+	snapshot, err := s.topologyService.TakeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := topology.LintPortNaming(snapshot.Devices, snapshot.Links, s.lint)
+	if err != nil {
+		return nil, err
+	}
+	if site == "" {
+		return report, nil
+	}
+
+	violations := report.Violations[:0]
+	for _, violation := range report.Violations {
+		if violation.Site == site {
+			violations = append(violations, violation)
+		}
+	}
+	report.Violations = violations
+	report.BySite = map[string]int{site: report.BySite[site]}
+	return report, nil
+}
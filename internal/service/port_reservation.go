@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+var (
+	// ErrInvalidPortReservation is returned when a port reservation is malformed
+	ErrInvalidPortReservation = apperror.Validation("invalid_port_reservation", "invalid port reservation")
+	// ErrPortAlreadyReserved is returned when the port already has an active reservation
+	ErrPortAlreadyReserved = apperror.Conflict("port_already_reserved", "port is already reserved")
+	// ErrPortReservationNotFound is returned when the reservation does not exist
+	ErrPortReservationNotFound = apperror.NotFound("port_reservation_not_found", "port reservation not found")
+	// ErrReservationDeviceNotFound is returned when the device of a reservation or inventory does not exist
+	ErrReservationDeviceNotFound = apperror.NotFound("device_not_found", "device not found")
+)
+
+// PortReservationService lets planners reserve device ports for future cabling and reports
+// reserved ports on which a different neighbor was discovered
+type PortReservationService struct {
+	reservationRepo topology.PortReservationRepository
+	topologyRepo    topology.Repository
+}
+
+func NewPortReservationService(reservationRepo topology.PortReservationRepository, topologyRepo topology.Repository) *PortReservationService {
+	return &PortReservationService{
+		reservationRepo: reservationRepo,
+		topologyRepo:    topologyRepo,
+	}
+}
+
+// ListReservations returns the reservations of a device (all devices when deviceID is empty) with
+// their status. A non-empty status keeps only reservations in that status.
+func (s *PortReservationService) ListReservations(ctx context.Context, deviceID, status string) ([]topology.PortReservation, error) {
+	reservations, err := s.reservationRepo.ListPortReservations(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make(map[string][]topology.Link)
+	now := time.Now()
+	filtered := []topology.PortReservation{}
+	for _, reservation := range reservations {
+		deviceLinks, ok := links[reservation.DeviceID]
+		if !ok {
+			deviceLinks, err = s.topologyRepo.GetDeviceLinks(ctx, reservation.DeviceID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get links for device %s: %w", reservation.DeviceID, err)
+			}
+			links[reservation.DeviceID] = deviceLinks
+		}
+
+		reservation.Evaluate(deviceLinks, now)
+		if status != "" && reservation.Status != status {
+			continue
+		}
+		filtered = append(filtered, reservation)
+	}
+
+	return filtered, nil
+}
+
+// ReservePort stores a reservation of a port. A port may hold one reservation at a time;
+// an expired reservation of the port is replaced.
+func (s *PortReservationService) ReservePort(ctx context.Context, reservation topology.PortReservation, userID string) (*topology.PortReservation, error) {
+	reservation.DeviceID = strings.TrimSpace(reservation.DeviceID)
+	reservation.Port = strings.TrimSpace(reservation.Port)
+	reservation.Ticket = strings.TrimSpace(reservation.Ticket)
+	now := time.Now()
+	if err := reservation.Validate(now); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPortReservation, err)
+	}
+
+	device, err := s.topologyRepo.GetDevice(ctx, reservation.DeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if device == nil {
+		return nil, fmt.Errorf("%w: %s", ErrReservationDeviceNotFound, reservation.DeviceID)
+	}
+
+	existing, err := s.reservationRepo.ListPortReservations(ctx, reservation.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range existing {
+		if other.Port != reservation.Port {
+			continue
+		}
+		if !other.Expired(now) {
+			return nil, fmt.Errorf("%w: %s %s (ticket %s)", ErrPortAlreadyReserved, other.DeviceID, other.Port, other.Ticket)
+		}
+		if err := s.reservationRepo.DeletePortReservation(ctx, other.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	reservation.ID = uuid.New().String()
+	reservation.ReservedBy = userID
+	reservation.CreatedAt = now
+	if err := s.reservationRepo.SavePortReservation(ctx, reservation); err != nil {
+		return nil, err
+	}
+
+	links, err := s.topologyRepo.GetDeviceLinks(ctx, reservation.DeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links for device %s: %w", reservation.DeviceID, err)
+	}
+	reservation.Evaluate(links, now)
+	return &reservation, nil
+}
+
+// ReleaseReservation removes a reservation
+func (s *PortReservationService) ReleaseReservation(ctx context.Context, reservationID string) error {
+	reservation, err := s.reservationRepo.GetPortReservation(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+	if reservation == nil {
+		return fmt.Errorf("%w: %s", ErrPortReservationNotFound, reservationID)
+	}
+	return s.reservationRepo.DeletePortReservation(ctx, reservationID)
+}
+
+// GetInterfaceInventory lists the ports of a device with their neighbors and reservations
+func (s *PortReservationService) GetInterfaceInventory(ctx context.Context, deviceID string) ([]topology.DevicePort, error) {
+	device, err := s.topologyRepo.GetDevice(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if device == nil {
+		return nil, fmt.Errorf("%w: %s", ErrReservationDeviceNotFound, deviceID)
+	}
+
+	links, err := s.topologyRepo.GetDeviceLinks(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links for device %s: %w", deviceID, err)
+	}
+	reservations, err := s.reservationRepo.ListPortReservations(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return topology.BuildInterfaceInventory(deviceID, links, reservations, time.Now()), nil
+}
+
+// FindConflicts returns the active reservations on which links show a neighbor other than the
+// planned one. The synchronization calls it with the links it has just written.
+func (s *PortReservationService) FindConflicts(ctx context.Context, links []topology.Link) ([]topology.PortReservation, error) {
+	reservations, err := s.reservationRepo.ListPortReservations(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var conflicts []topology.PortReservation
+	for _, reservation := range reservations {
+		reservation.Evaluate(links, now)
+		if reservation.Status == topology.ReservationStatusConflict {
+			conflicts = append(conflicts, reservation)
+		}
+	}
+	return conflicts, nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+// portNodeSpacing is the horizontal distance between port nodes of the same device
+const portNodeSpacing = 40.0
+
+// GetPortTopology returns the sub-topology around rootDeviceID as a port graph: every
+// interface with a link becomes a node whose parent is its device, and edges connect
+// ports instead of devices. When peerID is set only links between the root and the peer
+// are kept, which shows exactly which ports interconnect two chassis.
+func (s *VisualizationService) GetPortTopology(ctx context.Context, rootDeviceID string, depth int, peerID string) (*visualization.VisualTopology, error) {
+	if depth <= 0 {
+		depth = 1
+	}
+
+	rootDevice, err := s.topologyRepo.GetDevice(ctx, rootDeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get root device: %w", err)
+	}
+	if rootDevice == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRootDeviceNotFound, rootDeviceID)
+	}
+
+	devices, links, err := s.topologyRepo.ExtractSubTopology(ctx, rootDeviceID, topology.SubTopologyOptions{
+		Radius: depth,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract sub-topology: %w", err)
+	}
+
+	// 対向デバイス指定時は2台間のリンクのみに絞る
+	if peerID != "" {
+		var pairLinks []topology.Link
+		for _, link := range links {
+			if (link.SourceID == rootDeviceID && link.TargetID == peerID) || (link.SourceID == peerID && link.TargetID == rootDeviceID) {
+				pairLinks = append(pairLinks, link)
+			}
+		}
+		links = pairLinks
+
+		var pairDevices []topology.Device
+		for _, device := range devices {
+			if device.ID == rootDeviceID || device.ID == peerID {
+				pairDevices = append(pairDevices, device)
+			}
+		}
+		devices = pairDevices
+	}
+
+	displayNames, err := s.displayNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// デバイスはポートを囲む親ノードになる
+	deviceNodes := make([]visualization.VisualNode, 0, len(devices))
+	deviceMap := make(map[string]topology.Device, len(devices))
+	for _, device := range devices {
+		deviceMap[device.ID] = device
+		deviceNodes = append(deviceNodes, visualization.VisualNode{
+			ID:            device.ID,
+			Name:          displayNames.Resolve(device.ID),
+			Type:          device.Type,
+			Hardware:      device.Hardware,
+			Status:        "active",
+			Layer:         s.getDeviceLayer(device.LayerID),
+			WorkflowState: topology.EffectiveWorkflowState(device),
+			IsRoot:        device.ID == rootDeviceID,
+			Style:         s.deviceNodeStyle(device, device.ID == rootDeviceID),
+		})
+	}
+
+	ports := make(map[string][]string) // デバイスID -> ポートノードID
+	portNodes := make(map[string]visualization.VisualNode)
+	addPort := func(device topology.Device, port string) string {
+		id := visualization.PortNodeID(device.ID, port)
+		if _, exists := portNodes[id]; !exists {
+			portNodes[id] = visualization.VisualNode{
+				ID:     id,
+				Name:   port,
+				Type:   "port",
+				Status: "active",
+				Layer:  s.getDeviceLayer(device.LayerID),
+				Parent: device.ID,
+				Style: visualization.NodeStyle{
+					Color:       "#bdc3c7",
+					Shape:       "round-rectangle",
+					Size:        16,
+					BorderColor: "#7f8c8d",
+					BorderWidth: 1,
+				},
+			}
+			ports[device.ID] = append(ports[device.ID], id)
+		}
+		return id
+	}
+
+	asymmetricLinks := asymmetricLinkIDs(links)
+	edges := make([]visualization.VisualEdge, 0, len(links))
+	for _, link := range links {
+		source, sourceOK := deviceMap[link.SourceID]
+		target, targetOK := deviceMap[link.TargetID]
+		if !sourceOK || !targetOK {
+			continue
+		}
+
+		// ポート名が不明なリンクも並行リンクとして区別できるようリンクIDで識別
+		sourcePort, targetPort := link.SourcePort, link.TargetPort
+		if sourcePort == "" {
+			sourcePort = "unknown-" + link.ID
+		}
+		if targetPort == "" {
+			targetPort = "unknown-" + link.ID
+		}
+
+		edge := visualization.VisualEdge{
+			ID:         link.ID,
+			Source:     addPort(source, sourcePort),
+			Target:     addPort(target, targetPort),
+			LocalPort:  link.SourcePort,
+			RemotePort: link.TargetPort,
+			Status:     "active",
+			Weight:     link.Weight,
+			Style:      s.getEdgeStyle("active", link.Weight),
+			Metadata:   link.Metadata,
+		}
+		s.markAsymmetric(&edge, asymmetricLinks)
+		edges = append(edges, edge)
+	}
+
+	// デバイスを階層レイアウトで配置し、ポートはデバイスの下に横並び
+	layout := s.calculateLayout(deviceNodes, nil, rootDeviceID)
+	layout.Type = "port-graph"
+	nodes := make([]visualization.VisualNode, 0, len(deviceNodes)+len(portNodes))
+	for _, deviceNode := range deviceNodes {
+		deviceNode.Position = layout.Positions[deviceNode.ID]
+		nodes = append(nodes, deviceNode)
+
+		portIDs := ports[deviceNode.ID]
+		sort.Strings(portIDs)
+		startX := deviceNode.Position.X - float64(len(portIDs)-1)*portNodeSpacing/2
+		for i, portID := range portIDs {
+			portNode := portNodes[portID]
+			portNode.Position = visualization.Position{
+				X: startX + float64(i)*portNodeSpacing,
+				Y: deviceNode.Position.Y + 30,
+			}
+			layout.Positions[portID] = portNode.Position
+			nodes = append(nodes, portNode)
+		}
+	}
+
+	// 階層・次数の統計はポートではなくデバイス単位で集計する
+	deviceEdges := make([]visualization.VisualEdge, len(edges))
+	for i, edge := range edges {
+		deviceEdges[i] = edge
+		deviceEdges[i].Source = portNodes[edge.Source].Parent
+		deviceEdges[i].Target = portNodes[edge.Target].Parent
+	}
+	stats := visualization.NewTopologyStats(deviceNodes, deviceEdges, nil, time.Now())
+	stats.TotalNodes = len(nodes)
+
+	return &visualization.VisualTopology{
+		RootDevice: rootDeviceID,
+		Depth:      depth,
+		Timestamp:  time.Now().Unix(),
+		Nodes:      nodes,
+		Edges:      edges,
+		Layout:     layout,
+		Stats:      stats,
+	}, nil
+}
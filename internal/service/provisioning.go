@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrInvalidPlannedDevice is returned when a planned device registration is malformed
+var ErrInvalidPlannedDevice = apperror.Validation("invalid_planned_device", "invalid planned device")
+
+type ProvisioningService struct {
+	provisioningRepo topology.ProvisioningRepository
+	topologyRepo     topology.Repository
+	metadataSchema   topology.MetadataSchema // デバイスタイプごとの必須メタデータ
+	placeholder      topology.PlaceholderDefaults
+}
+
+func NewProvisioningService(provisioningRepo topology.ProvisioningRepository, topologyRepo topology.Repository) *ProvisioningService {
+	return &ProvisioningService{
+		provisioningRepo: provisioningRepo,
+		topologyRepo:     topologyRepo,
+	}
+}
+
+// SetPlaceholderDefaults sets the placeholder attributes the sync worker uses, so that templates
+// complete the placeholders still awaiting enrichment
+func (s *ProvisioningService) SetPlaceholderDefaults(defaults topology.PlaceholderDefaults) {
+	s.placeholder = defaults
+}
+
+// SetMetadataSchema sets the metadata required per device type. When it is enforced, templates do
+// not classify discovered devices as a type whose required metadata they lack; the missing keys
+// are reported as a mismatch instead.
+func (s *ProvisioningService) SetMetadataSchema(schema topology.MetadataSchema) {
+	s.metadataSchema = schema
+}
+
+// PlanDevice registers a device from a template before it appears in monitoring.
+// If the device is already known it is reconciled immediately.
+func (s *ProvisioningService) PlanDevice(ctx context.Context, planned topology.PlannedDevice, userID string) (*topology.PlannedDevice, error) {
+	planned.ID = strings.TrimSpace(planned.ID)
+	if planned.ID == "" {
+		return nil, fmt.Errorf("%w: id is required", ErrInvalidPlannedDevice)
+	}
+	for _, uplink := range planned.Template.ExpectedUplinks {
+		if uplink == planned.ID {
+			return nil, fmt.Errorf("%w: device %s cannot be its own uplink", ErrInvalidPlannedDevice, planned.ID)
+		}
+	}
+
+	existing, err := s.provisioningRepo.GetPlannedDevice(ctx, planned.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planned device: %w", err)
+	}
+	if existing != nil {
+		// テンプレートの更新時も作成情報は保持する
+		planned.CreatedBy = existing.CreatedBy
+		planned.CreatedAt = existing.CreatedAt
+	} else {
+		planned.CreatedBy = userID
+	}
+	planned.Status = topology.PlannedDeviceStatusPlanned
+	planned.Mismatches = []topology.TemplateMismatch{}
+	planned.DiscoveredAt = nil
+
+	device, err := s.topologyRepo.GetDevice(ctx, planned.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if device != nil {
+		if err := s.reconcile(ctx, &planned, device); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.provisioningRepo.SavePlannedDevice(ctx, planned); err != nil {
+		return nil, fmt.Errorf("failed to save planned device: %w", err)
+	}
+
+	return &planned, nil
+}
+
+// GetPlannedDevice returns a planned device, or nil if it is not registered
+func (s *ProvisioningService) GetPlannedDevice(ctx context.Context, deviceID string) (*topology.PlannedDevice, error) {
+	return s.provisioningRepo.GetPlannedDevice(ctx, deviceID)
+}
+
+// ListPlannedDevices returns planned devices, optionally filtered by status
+func (s *ProvisioningService) ListPlannedDevices(ctx context.Context, status topology.PlannedDeviceStatus) ([]topology.PlannedDevice, error) {
+	devices, err := s.provisioningRepo.ListPlannedDevices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list planned devices: %w", err)
+	}
+
+	if status == "" {
+		return devices, nil
+	}
+
+	var filtered []topology.PlannedDevice
+	for _, device := range devices {
+		if device.Status == status {
+			filtered = append(filtered, device)
+		}
+	}
+	return filtered, nil
+}
+
+// DeletePlannedDevice removes a planned device record; the discovered device itself is kept
+func (s *ProvisioningService) DeletePlannedDevice(ctx context.Context, deviceID string) error {
+	return s.provisioningRepo.DeletePlannedDevice(ctx, deviceID)
+}
+
+// ReconcilePlannedDevices merges planned records with devices discovered by sync and
+// re-evaluates template mismatches. It returns the records that are discovered.
+func (s *ProvisioningService) ReconcilePlannedDevices(ctx context.Context) ([]topology.PlannedDevice, error) {
+	plannedDevices, err := s.provisioningRepo.ListPlannedDevices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list planned devices: %w", err)
+	}
+
+	var reconciled []topology.PlannedDevice
+	for _, planned := range plannedDevices {
+		device, err := s.topologyRepo.GetDevice(ctx, planned.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get device %s: %w", planned.ID, err)
+		}
+		if device == nil {
+			continue // まだ監視に現れていない
+		}
+
+		if err := s.reconcile(ctx, &planned, device); err != nil {
+			return nil, err
+		}
+		if err := s.provisioningRepo.SavePlannedDevice(ctx, planned); err != nil {
+			return nil, fmt.Errorf("failed to save planned device %s: %w", planned.ID, err)
+		}
+		reconciled = append(reconciled, planned)
+	}
+
+	return reconciled, nil
+}
+
+// reconcile merges the template into the discovered device and records mismatches on the planned record
+func (s *ProvisioningService) reconcile(ctx context.Context, planned *topology.PlannedDevice, device *topology.Device) error {
+	template := planned.Template
+	updated := false
+
+	// LLDPのみで検出され、監視からまだ情報を得ていないプレースホルダーはテンプレートの値で補完する
+	awaiting := s.placeholder.AwaitingEnrichment(*device)
+	if awaiting || device.Type == "" {
+		if template.Type != "" {
+			device.Type = template.Type
+			updated = true
+		}
+		if template.Hardware != "" && (awaiting || device.Hardware == "") {
+			device.Hardware = template.Hardware
+			updated = true
+		}
+	}
+
+	// 未分類であればテンプレートの階層を適用（手動分類として扱う）
+	var missingMetadata []string
+	if device.LayerID == nil && template.LayerID != nil {
+		classified := *device
+		layerID := *template.LayerID
+		classified.LayerID = &layerID
+		classified.DeviceType = template.DeviceType
+		classified.ClassifiedBy = fmt.Sprintf("user:%s", planned.CreatedBy)
+		if s.metadataSchema.Enforce {
+			missingMetadata = s.metadataSchema.MissingKeys(classified)
+		}
+		if len(missingMetadata) == 0 {
+			*device = classified
+			updated = true
+		}
+	}
+
+	if updated {
+		device.UpdatedAt = time.Now()
+		if err := s.topologyRepo.UpdateDevice(ctx, *device); err != nil {
+			return fmt.Errorf("failed to merge planned device %s: %w", planned.ID, err)
+		}
+	}
+
+	mismatches, err := s.compareWithTemplate(ctx, template, device)
+	if err != nil {
+		return err
+	}
+
+	if len(missingMetadata) > 0 {
+		mismatches = append(mismatches, topology.TemplateMismatch{Field: "metadata", Expected: strings.Join(missingMetadata, ", "), Actual: ""})
+	}
+
+	planned.Mismatches = mismatches
+	if len(mismatches) > 0 {
+		planned.Status = topology.PlannedDeviceStatusMismatch
+	} else {
+		planned.Status = topology.PlannedDeviceStatusDiscovered
+	}
+	if planned.DiscoveredAt == nil {
+		now := time.Now()
+		planned.DiscoveredAt = &now
+	}
+
+	return nil
+}
+
+// compareWithTemplate lists the differences between a discovered device and its template
+func (s *ProvisioningService) compareWithTemplate(ctx context.Context, template topology.DeviceTemplate, device *topology.Device) ([]topology.TemplateMismatch, error) {
+	mismatches := []topology.TemplateMismatch{}
+
+	if template.Type != "" && !strings.EqualFold(template.Type, device.Type) {
+		mismatches = append(mismatches, topology.TemplateMismatch{Field: "type", Expected: template.Type, Actual: device.Type})
+	}
+	if template.Hardware != "" && !strings.EqualFold(template.Hardware, device.Hardware) {
+		mismatches = append(mismatches, topology.TemplateMismatch{Field: "hardware", Expected: template.Hardware, Actual: device.Hardware})
+	}
+	if template.LayerID != nil && device.LayerID != nil && *template.LayerID != *device.LayerID {
+		mismatches = append(mismatches, topology.TemplateMismatch{
+			Field:    "layer_id",
+			Expected: strconv.Itoa(*template.LayerID),
+			Actual:   strconv.Itoa(*device.LayerID),
+		})
+	}
+	if template.DeviceType != "" && device.DeviceType != "" && template.DeviceType != device.DeviceType {
+		mismatches = append(mismatches, topology.TemplateMismatch{Field: "device_type", Expected: template.DeviceType, Actual: device.DeviceType})
+	}
+
+	if len(template.ExpectedUplinks) == 0 {
+		return mismatches, nil
+	}
+
+	links, err := s.topologyRepo.GetDeviceLinks(ctx, device.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links for %s: %w", device.ID, err)
+	}
+
+	neighbors := make(map[string]bool)
+	for _, link := range links {
+		if link.SourceID == device.ID {
+			neighbors[link.TargetID] = true
+		} else {
+			neighbors[link.SourceID] = true
+		}
+	}
+
+	for _, uplink := range template.ExpectedUplinks {
+		if !neighbors[uplink] {
+			mismatches = append(mismatches, topology.TemplateMismatch{Field: "uplink", Expected: uplink, Actual: ""})
+		}
+	}
+
+	return mismatches, nil
+}
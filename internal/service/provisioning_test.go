@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProvisioningService(t *testing.T) (*ProvisioningService, *testutil.TestSetup) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	provisioningRepo, ok := setup.Repo.(topology.ProvisioningRepository)
+	require.True(t, ok, "SQLite repository should support planned devices")
+	return NewProvisioningService(provisioningRepo, setup.Repo), setup
+}
+
+func TestProvisioningService_TemplateClassifiesDiscoveredDevice(t *testing.T) {
+	provisioningService, setup := newTestProvisioningService(t)
+	ctx := context.Background()
+	seedUnclassifiedDevices(t, setup, "device-001")
+
+	layerID := 2
+	planned, err := provisioningService.PlanDevice(ctx, topology.PlannedDevice{
+		ID:       "device-001",
+		Template: topology.DeviceTemplate{LayerID: &layerID, DeviceType: "server"},
+	}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, topology.PlannedDeviceStatusDiscovered, planned.Status)
+
+	device, err := setup.Repo.GetDevice(ctx, "device-001")
+	require.NoError(t, err)
+	assert.Equal(t, "server", device.DeviceType)
+	assert.Equal(t, "user:admin", device.ClassifiedBy)
+}
+
+func TestProvisioningService_EnforcedMetadataSchema(t *testing.T) {
+	provisioningService, setup := newTestProvisioningService(t)
+	ctx := context.Background()
+	seedUnclassifiedDevices(t, setup, "device-001")
+
+	provisioningService.SetMetadataSchema(topology.MetadataSchema{
+		Required: []topology.MetadataRequirement{{DeviceType: "server", Keys: []string{"site", "owner"}}},
+		Enforce:  true,
+	})
+
+	layerID := 2
+	planned, err := provisioningService.PlanDevice(ctx, topology.PlannedDevice{
+		ID:       "device-001",
+		Template: topology.DeviceTemplate{LayerID: &layerID, DeviceType: "server"},
+	}, "admin")
+	require.NoError(t, err)
+
+	// 必須メタデータを欠くため分類せず、欠落を不一致として報告する
+	assert.Equal(t, topology.PlannedDeviceStatusMismatch, planned.Status)
+	assert.Contains(t, planned.Mismatches, topology.TemplateMismatch{Field: "metadata", Expected: "site, owner"})
+
+	device, err := setup.Repo.GetDevice(ctx, "device-001")
+	require.NoError(t, err)
+	assert.Nil(t, device.LayerID)
+	assert.Empty(t, device.DeviceType)
+}
+
+func TestProvisioningService_TemplateCompletesPlaceholders(t *testing.T) {
+	provisioningService, setup := newTestProvisioningService(t)
+	ctx := context.Background()
+
+	defaults := topology.PlaceholderDefaults{Type: "lldp-neighbor", Hardware: "n/a"}
+	provisioningService.SetPlaceholderDefaults(defaults)
+
+	// 監視が type=unknown と報告したデバイスはプレースホルダーではない
+	monitored := testutil.CreateTestDevice("monitored-001")
+	monitored.Type = topology.UnknownPlaceholderValue
+	monitored.Hardware = "Arista 7050"
+	monitored.Provenance = topology.ProvenancePrometheus
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, []topology.Device{
+		defaults.NewPlaceholder("placeholder-001", time.Now()),
+		monitored,
+	}))
+
+	template := topology.DeviceTemplate{Type: "switch", Hardware: "Arista 7280"}
+	for _, id := range []string{"placeholder-001", "monitored-001"} {
+		_, err := provisioningService.PlanDevice(ctx, topology.PlannedDevice{ID: id, Template: template}, "admin")
+		require.NoError(t, err)
+	}
+
+	device, err := setup.Repo.GetDevice(ctx, "placeholder-001")
+	require.NoError(t, err)
+	assert.Equal(t, "switch", device.Type)
+	assert.Equal(t, "Arista 7280", device.Hardware)
+
+	device, err = setup.Repo.GetDevice(ctx, "monitored-001")
+	require.NoError(t, err)
+	assert.Equal(t, topology.UnknownPlaceholderValue, device.Type)
+	assert.Equal(t, "Arista 7050", device.Hardware)
+}
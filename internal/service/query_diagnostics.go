@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrExplainDeviceNotFound is returned when the root of the explained request does not exist
+var ErrExplainDeviceNotFound = apperror.NotFound("device_not_found", "device not found")
+
+// QueryDiagnosticsService explains the database queries behind API requests, so operators can
+// tune indexes for their data without access to the database
+type QueryDiagnosticsService struct {
+	explainRepo topology.QueryExplainRepository
+}
+
+func NewQueryDiagnosticsService(explainRepo topology.QueryExplainRepository) *QueryDiagnosticsService {
+	return &QueryDiagnosticsService{explainRepo: explainRepo}
+}
+
+// ExplainSubTopology explains the statements extracting the topology around deviceID, as the
+// topology and visualization requests with the same depth and filter run them
+func (s *QueryDiagnosticsService) ExplainSubTopology(ctx context.Context, deviceID string, depth int, filter topology.SubTopologyFilter) (*topology.SubTopologyExplanation, error) {
+	explanation, err := s.explainRepo.ExplainSubTopology(ctx, deviceID, topology.SubTopologyOptions{
+		Radius: depth,
+		Filter: filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain sub-topology: %w", err)
+	}
+	if explanation == nil {
+		return nil, fmt.Errorf("%w: %s", ErrExplainDeviceNotFound, deviceID)
+	}
+	explanation.TotalMs = explanation.EstimateTotalMs()
+	return explanation, nil
+}
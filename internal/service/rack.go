@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+var (
+	// ErrInvalidRackPlacement is returned when a rack placement or a placement CSV is malformed
+	ErrInvalidRackPlacement = apperror.Validation("invalid_rack_placement", "invalid rack placement")
+	// ErrRackNotFound is returned when no device is mounted in the rack
+	ErrRackNotFound = apperror.NotFound("rack_not_found", "rack not found")
+	// ErrRackDeviceNotFound is returned when the placed device does not exist
+	ErrRackDeviceNotFound = apperror.NotFound("device_not_found", "device not found")
+)
+
+// RackService places devices in racks and builds rack elevations
+type RackService struct {
+	rackRepo     topology.RackRepository
+	topologyRepo topology.Repository
+}
+
+func NewRackService(rackRepo topology.RackRepository, topologyRepo topology.Repository) *RackService {
+	return &RackService{
+		rackRepo:     rackRepo,
+		topologyRepo: topologyRepo,
+	}
+}
+
+// Elevations returns the devices of every rack sorted by rack unit, without connections
+func (s *RackService) Elevations(ctx context.Context) ([]topology.RackElevation, error) {
+	devices, err := ListAllDevices(ctx, s.topologyRepo)
+	if err != nil {
+		return nil, err
+	}
+	return topology.BuildRackElevations(devices, nil), nil
+}
+
+// Elevation returns the devices of a rack sorted by rack unit, with the connections of each port
+func (s *RackService) Elevation(ctx context.Context, rack string) (*topology.RackElevation, error) {
+	devices, err := ListAllDevices(ctx, s.topologyRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []topology.Link
+	for _, device := range devices {
+		if device.Rack != rack {
+			continue
+		}
+		deviceLinks, err := s.topologyRepo.GetDeviceLinks(ctx, device.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", device.ID, err)
+		}
+		links = append(links, deviceLinks...)
+	}
+
+	for _, elevation := range topology.BuildRackElevations(devices, links) {
+		if elevation.Rack == rack {
+			return &elevation, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrRackNotFound, rack)
+}
+
+// SetPlacement places a device in a rack, or removes it from its rack when the rack is empty
+func (s *RackService) SetPlacement(ctx context.Context, placement topology.RackPlacement) error {
+	placement.DeviceID = strings.TrimSpace(placement.DeviceID)
+	placement.Rack = strings.TrimSpace(placement.Rack)
+	if err := placement.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRackPlacement, err)
+	}
+
+	ok, err := s.rackRepo.SetDeviceRack(ctx, placement)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrRackDeviceNotFound, placement.DeviceID)
+	}
+	return nil
+}
+
+// ImportPlacements stores the rack placements of a CSV export. The whole file is validated before
+// anything is written; devices that do not exist are skipped and listed.
+func (s *RackService) ImportPlacements(ctx context.Context, r io.Reader) (*topology.RackImportResult, error) {
+	placements, err := topology.ParseRackPlacementsCSV(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRackPlacement, err)
+	}
+
+	result := &topology.RackImportResult{Unknown: []string{}}
+	for _, placement := range placements {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		ok, err := s.rackRepo.SetDeviceRack(ctx, placement)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import rack placement of %s: %w", placement.DeviceID, err)
+		}
+		if ok {
+			result.Imported++
+		} else {
+			result.Unknown = append(result.Unknown, placement.DeviceID)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRackService_ElevationsOverEveryPage(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+	ctx := context.Background()
+
+	rackRepo, ok := setup.Repo.(topology.RackRepository)
+	require.True(t, ok, "SQLite repository should store rack placements")
+	rackService := NewRackService(rackRepo, setup.Repo)
+
+	position := func(unit int) *int { return &unit }
+	for _, placement := range []topology.RackPlacement{
+		{DeviceID: "device-001", Rack: "rack-a", Position: position(10)},
+		{DeviceID: "device-002", Rack: "rack-b", Position: position(1)},
+		{DeviceID: "device-003", Rack: "rack-b", Position: position(20), Units: 2},
+	} {
+		require.NoError(t, rackService.SetPlacement(ctx, placement))
+	}
+	assert.ErrorIs(t, rackService.SetPlacement(ctx, topology.RackPlacement{DeviceID: "no-such-device", Rack: "rack-a"}), ErrRackDeviceNotFound)
+
+	// 1ページに収まらない台数でも全デバイスを並べる
+	setDevicePageSize(t, 2)
+	elevations, err := rackService.Elevations(ctx)
+	require.NoError(t, err)
+	require.Len(t, elevations, 2)
+	assert.Equal(t, "rack-a", elevations[0].Rack)
+	require.Len(t, elevations[1].Devices, 2)
+	assert.Equal(t, "device-002", elevations[1].Devices[0].ID)
+	assert.Equal(t, "device-003", elevations[1].Devices[1].ID)
+	assert.Equal(t, 21, elevations[1].Height)
+
+	elevation, err := rackService.Elevation(ctx, "rack-b")
+	require.NoError(t, err)
+	require.Len(t, elevation.Devices, 2)
+	var sameRack int
+	for _, connection := range elevation.Devices[1].Connections {
+		if connection.SameRack {
+			sameRack++
+		}
+	}
+	assert.Equal(t, 1, sameRack, "link-002 connects device-002 and device-003 inside rack-b")
+
+	_, err = rackService.Elevation(ctx, "rack-z")
+	assert.ErrorIs(t, err, ErrRackNotFound)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrInvalidReachabilityQuery is returned when a reachability matrix is requested with empty,
+// unknown or too large device sets, or with out-of-range limits
+var ErrInvalidReachabilityQuery = apperror.Validation("invalid_reachability_query", "invalid reachability query")
+
+// BuildReachabilityMatrix reports reachability, hop count and link-disjoint path count between
+// every source and target. The whole topology is loaded once into an in-memory graph index;
+// the cost is bounded by MaxMatrixPairs, maxHops and maxPaths.
+func (s *TopologyService) BuildReachabilityMatrix(ctx context.Context, sources, targets topology.DeviceSet, maxHops, maxPaths int) (*topology.ReachabilityMatrix, error) {
+	if maxHops < 0 || maxHops > topology.MaxMatrixHops {
+		return nil, fmt.Errorf("%w: max_hops must be between 1 and %d", ErrInvalidReachabilityQuery, topology.MaxMatrixHops)
+	}
+	if maxPaths < 0 || maxPaths > topology.MaxMatrixPaths {
+		return nil, fmt.Errorf("%w: max_paths must be between 1 and %d", ErrInvalidReachabilityQuery, topology.MaxMatrixPaths)
+	}
+
+	sourceIDs, targetIDs, links, err := s.loadDeviceSetGraph(ctx, sources, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	return topology.BuildReachabilityMatrix(topology.NewGraphIndex(links), sourceIDs, targetIDs, maxHops, maxPaths), nil
+}
+
+// AnalyzePathSymmetry compares the weighted shortest paths in both directions between every source
+// and target, flagging pairs whose return traffic would take a different path (usually a cost
+// configured differently on the two ends of a link)
+func (s *TopologyService) AnalyzePathSymmetry(ctx context.Context, sources, targets topology.DeviceSet) (*topology.PathSymmetryReport, error) {
+	sourceIDs, targetIDs, links, err := s.loadDeviceSetGraph(ctx, sources, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	return topology.AnalyzePathSymmetry(topology.NewGraphIndex(links), sourceIDs, targetIDs), nil
+}
+
+// loadDeviceSetGraph resolves the source and target sets, bounded by MaxMatrixPairs,
+// and loads every link of the topology
+func (s *TopologyService) loadDeviceSetGraph(ctx context.Context, sources, targets topology.DeviceSet) ([]string, []string, []topology.Link, error) {
+	devices, err := ListAllDevices(ctx, s.repo)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sourceIDs, unknownSources := sources.Resolve(devices)
+	targetIDs, unknownTargets := targets.Resolve(devices)
+	if unknown := append(unknownSources, unknownTargets...); len(unknown) > 0 {
+		return nil, nil, nil, fmt.Errorf("%w: unknown devices %s", ErrInvalidReachabilityQuery, strings.Join(unknown, ", "))
+	}
+	if len(sourceIDs) == 0 || len(targetIDs) == 0 {
+		return nil, nil, nil, fmt.Errorf("%w: source and target sets must each match at least one device", ErrInvalidReachabilityQuery)
+	}
+	if pairs := topology.CountMatrixPairs(sourceIDs, targetIDs); pairs > topology.MaxMatrixPairs {
+		return nil, nil, nil, fmt.Errorf("%w: %d pairs requested, at most %d allowed", ErrInvalidReachabilityQuery, pairs, topology.MaxMatrixPairs)
+	}
+
+	links, err := s.loadLinks(ctx, devices)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return sourceIDs, targetIDs, links, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopologyService_BuildReachabilityMatrixOverEveryPage(t *testing.T) {
+	topologyService, setup := newTestTopologyService(t)
+	ctx := context.Background()
+
+	// device-003 の先に chain-01 〜 chain-04 を直列につなぐ
+	devices := make([]topology.Device, 0, 4)
+	links := make([]topology.Link, 0, 4)
+	previous := "device-003"
+	for i := 1; i <= 4; i++ {
+		id := fmt.Sprintf("chain-%02d", i)
+		devices = append(devices, testutil.CreateTestDevice(id))
+		links = append(links, testutil.CreateTestLink("link-"+id, previous, id))
+		previous = id
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, devices))
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, links))
+
+	// 1ページに収まらないデバイスも解決し、そのリンクも辿る
+	setDevicePageSize(t, 2)
+	matrix, err := topologyService.BuildReachabilityMatrix(ctx,
+		topology.DeviceSet{DeviceIDs: []string{"device-001"}},
+		topology.DeviceSet{DeviceIDs: []string{"chain-04", "device-002"}}, 8, 2)
+	require.NoError(t, err)
+
+	require.Len(t, matrix.Pairs, 2)
+	hops := make(map[string]int)
+	for _, pair := range matrix.Pairs {
+		assert.True(t, pair.Reachable, "%s should be reachable", pair.Target)
+		hops[pair.Target] = pair.HopCount
+	}
+	assert.Equal(t, map[string]int{"chain-04": 6, "device-002": 1}, hops)
+	assert.Equal(t, 2, matrix.Reachable)
+	assert.Equal(t, 2, matrix.SinglePath)
+}
+
+func TestTopologyService_BuildReachabilityMatrixValidation(t *testing.T) {
+	topologyService, _ := newTestTopologyService(t)
+	ctx := context.Background()
+	sources := topology.DeviceSet{DeviceIDs: []string{"device-001"}}
+
+	_, err := topologyService.BuildReachabilityMatrix(ctx, sources, topology.DeviceSet{DeviceIDs: []string{"no-such-device"}}, 3, 1)
+	assert.ErrorIs(t, err, ErrInvalidReachabilityQuery)
+	assert.Contains(t, err.Error(), "no-such-device")
+
+	_, err = topologyService.BuildReachabilityMatrix(ctx, sources, topology.DeviceSet{Layers: []int{99}}, 3, 1)
+	assert.ErrorIs(t, err, ErrInvalidReachabilityQuery)
+
+	_, err = topologyService.BuildReachabilityMatrix(ctx, sources, sources, topology.MaxMatrixHops+1, 1)
+	assert.ErrorIs(t, err, ErrInvalidReachabilityQuery)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrShadowClassificationUnsupported is returned when the repository cannot record shadow classifications
+var ErrShadowClassificationUnsupported = errors.New("shadow classification is not supported by this repository")
+
+func newShadowClassification(deviceID string, rule classification.ClassificationRule) classification.ShadowClassification {
+	return classification.ShadowClassification{
+		DeviceID:    deviceID,
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		Layer:       rule.Layer,
+		DeviceType:  rule.DeviceType,
+		EvaluatedAt: time.Now(),
+	}
+}
+
+// recordShadowClassifications replaces the shadow classifications of the evaluated devices
+func (s *ClassificationService) recordShadowClassifications(ctx context.Context, evaluated []string, results []classification.ShadowClassification) error {
+	if s.shadowRepo == nil || len(evaluated) == 0 {
+		return nil
+	}
+	if err := s.shadowRepo.ReplaceShadowClassifications(ctx, evaluated, results); err != nil {
+		return fmt.Errorf("failed to record shadow classifications: %w", err)
+	}
+	return nil
+}
+
+// EvaluateShadowRules evaluates the active rules against every device without changing any and
+// records what the shadow rules would classify, then compares it with the current classifications.
+// Manually classified devices are skipped as rules never change them.
+func (s *ClassificationService) EvaluateShadowRules(ctx context.Context) (*classification.ShadowReport, error) {
+	if s.shadowRepo == nil {
+		return nil, ErrShadowClassificationUnsupported
+	}
+
+	rules, err := s.activeRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := ListAllDevices(ctx, s.topologyRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluated := make([]string, 0, len(devices))
+	var results []classification.ShadowClassification
+	for _, device := range devices {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		evaluated = append(evaluated, device.ID)
+		if strings.HasPrefix(device.ClassifiedBy, "user:") {
+			continue
+		}
+		if _, shadow := s.matchRules(device, rules); shadow != nil {
+			results = append(results, newShadowClassification(device.ID, *shadow))
+		}
+	}
+
+	if err := s.recordShadowClassifications(ctx, evaluated, results); err != nil {
+		return nil, err
+	}
+	return s.shadowReport(devices, rules, results), nil
+}
+
+// GetShadowReport compares the recorded shadow classifications with the current classifications.
+// Results of rules that are no longer active shadow rules and of removed devices are left out.
+func (s *ClassificationService) GetShadowReport(ctx context.Context) (*classification.ShadowReport, error) {
+	if s.shadowRepo == nil {
+		return nil, ErrShadowClassificationUnsupported
+	}
+
+	results, err := s.shadowRepo.ListShadowClassifications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow classifications: %w", err)
+	}
+	rules, err := s.activeRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := ListAllDevices(ctx, s.topologyRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.shadowReport(devices, rules, results), nil
+}
+
+func (s *ClassificationService) shadowReport(devices []topology.Device, rules []classification.ClassificationRule, results []classification.ShadowClassification) *classification.ShadowReport {
+	shadowRules := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.Shadow {
+			shadowRules[rule.ID] = true
+		}
+	}
+	byID := make(map[string]topology.Device, len(devices))
+	for _, device := range devices {
+		byID[device.ID] = device
+	}
+
+	var comparisons []classification.ShadowComparison
+	for _, result := range results {
+		device, ok := byID[result.DeviceID]
+		if !ok || !shadowRules[result.RuleID] {
+			continue
+		}
+		comparisons = append(comparisons, classification.CompareShadow(result, device.LayerID, device.DeviceType, device.ClassifiedBy))
+	}
+
+	report := classification.NewShadowReport(comparisons)
+	return &report
+}
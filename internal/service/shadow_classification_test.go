@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassificationService_ShadowRulesOverEveryPage(t *testing.T) {
+	classificationService, setup := newTestClassificationService(t)
+	ctx := context.Background()
+	seedUnclassifiedDevices(t, setup, "device-001", "device-002", "device-003", "device-004", "device-005")
+
+	rule := testutil.CreateTestClassificationRule("shadow-rule", "Shadow Rule")
+	rule.Shadow = true
+	require.NoError(t, classificationService.SaveClassificationRule(ctx, rule))
+
+	// 1ページに収まらない台数でも全デバイスを評価する
+	setDevicePageSize(t, 2)
+	report, err := classificationService.EvaluateShadowRules(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Matched)
+	assert.Equal(t, 5, report.New)
+	require.Len(t, report.Devices, 5)
+	assert.Equal(t, "device-005", report.Devices[4].DeviceID)
+
+	// シャドウルールはデバイスを変更しない
+	device, err := setup.Repo.GetDevice(ctx, "device-005")
+	require.NoError(t, err)
+	assert.Nil(t, device.LayerID)
+
+	report, err = classificationService.GetShadowReport(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Matched)
+	for _, comparison := range report.Devices {
+		assert.Equal(t, classification.ShadowStatusNew, comparison.Status)
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/service/contract"
+)
+
+// SharedPathPrefix is the path under which share links are served
+const SharedPathPrefix = "/api/v1/shared/"
+
+var (
+	// ErrInvalidShareLink is returned when a share link is requested with an invalid expiry
+	ErrInvalidShareLink = apperror.Validation("invalid_share_link", "invalid share link")
+	// ErrStartingViewNotFound is returned when sharing a role that has no starting view
+	ErrStartingViewNotFound = apperror.NotFound("starting_view_not_found", "starting view not found")
+)
+
+// ShareService issues and verifies signed, read-only links to saved starting views.
+// Links are stateless: the view and expiry are carried in the token itself.
+type ShareService struct {
+	secret               []byte
+	startingViewRepo     visualization.StartingViewRepository
+	visualizationService contract.Visualization
+}
+
+// NewShareService creates a share service signing with a random secret until SetSecret is called
+func NewShareService(startingViewRepo visualization.StartingViewRepository, visualizationService contract.Visualization) *ShareService {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to generate share secret: %v", err))
+	}
+
+	return &ShareService{
+		secret:               secret,
+		startingViewRepo:     startingViewRepo,
+		visualizationService: visualizationService,
+	}
+}
+
+// SetSecret changes the key share tokens are signed with. Links signed with the previous key stop working.
+func (s *ShareService) SetSecret(secret []byte) {
+	s.secret = secret
+}
+
+// CreateShareLink issues a link to the saved starting view of role, valid for ttl (0 = DefaultShareTTL)
+func (s *ShareService) CreateShareLink(ctx context.Context, role string, ttl time.Duration, userID string) (*visualization.ShareLink, error) {
+	role = strings.TrimSpace(role)
+	if ttl == 0 {
+		ttl = visualization.DefaultShareTTL
+	}
+	if ttl < time.Minute || ttl > visualization.MaxShareTTL {
+		return nil, fmt.Errorf("%w: expiry must be between 1m and %s", ErrInvalidShareLink, visualization.MaxShareTTL)
+	}
+
+	view, err := s.startingViewRepo.GetStartingView(ctx, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get starting view: %w", err)
+	}
+	if view == nil {
+		return nil, fmt.Errorf("%w: role %s has no starting view", ErrStartingViewNotFound, role)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	token, err := visualization.SignShareToken(s.secret, visualization.ShareClaims{
+		Role:       view.Role,
+		RootDevice: view.RootDevice,
+		Depth:      view.Depth,
+		Grouping:   view.Grouping,
+		CreatedBy:  userID,
+		IssuedAt:   now.Unix(),
+		ExpiresAt:  expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign share token: %w", err)
+	}
+
+	return &visualization.ShareLink{
+		Token:     token,
+		Path:      SharedPathPrefix + token,
+		Role:      view.Role,
+		ExpiresAt: time.Unix(expiresAt.Unix(), 0),
+	}, nil
+}
+
+// VerifyToken returns the claims of a valid, unexpired share token
+func (s *ShareService) VerifyToken(token string) (*visualization.ShareClaims, error) {
+	return visualization.VerifyShareToken(s.secret, token, time.Now())
+}
+
+// GetSharedTopology returns the topology of the view captured in claims
+func (s *ShareService) GetSharedTopology(ctx context.Context, claims *visualization.ShareClaims) (*visualization.VisualTopology, error) {
+	var grouping visualization.GroupingOptions
+	if claims.Grouping != nil {
+		grouping = *claims.Grouping
+	}
+	return s.visualizationService.GetVisualTopologyWithGrouping(ctx, claims.RootDevice, claims.Depth, grouping)
+}
@@ -0,0 +1,476 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrInvalidSimulation is returned when a simulation request references unknown or conflicting entities
+var ErrInvalidSimulation = apperror.Validation("invalid_simulation", "invalid simulation request")
+
+const (
+	defaultSimulationMaxHops = 10
+	maxSimulationHops        = 20
+)
+
+type SimulationService struct {
+	topologyRepo topology.Repository
+}
+
+func NewSimulationService(topologyRepo topology.Repository) *SimulationService {
+	return &SimulationService{
+		topologyRepo: topologyRepo,
+	}
+}
+
+// Simulate applies hypothetical changes on top of the current topology and compares paths,
+// reachability and oversubscription before and after the change
+func (s *SimulationService) Simulate(ctx context.Context, req topology.SimulationRequest) (*topology.SimulationResult, error) {
+	baseline := newSimulationGraph(ctx, s.topologyRepo)
+	simulated := baseline.withChanges()
+
+	if err := simulated.apply(req); err != nil {
+		return nil, err
+	}
+
+	result := &topology.SimulationResult{
+		Paths:            []topology.PathComparison{},
+		Reachability:     []topology.ReachabilityComparison{},
+		Oversubscription: []topology.OversubscriptionChange{},
+	}
+
+	// 経路の比較
+	for _, q := range req.Paths {
+		before, err := baseline.shortestPath(q.From, q.To, maxSimulationHops)
+		if err != nil {
+			return nil, err
+		}
+		after, err := simulated.shortestPath(q.From, q.To, maxSimulationHops)
+		if err != nil {
+			return nil, err
+		}
+		result.Paths = append(result.Paths, topology.PathComparison{
+			From:      q.From,
+			To:        q.To,
+			Baseline:  before,
+			Simulated: after,
+		})
+	}
+
+	// 到達性の比較
+	for _, q := range req.Reachability {
+		maxHops := q.MaxHops
+		if maxHops <= 0 {
+			maxHops = defaultSimulationMaxHops
+		} else if maxHops > maxSimulationHops {
+			maxHops = maxSimulationHops
+		}
+
+		before, err := baseline.reachable(q.DeviceID, maxHops)
+		if err != nil {
+			return nil, err
+		}
+		after, err := simulated.reachable(q.DeviceID, maxHops)
+		if err != nil {
+			return nil, err
+		}
+
+		comparison := topology.ReachabilityComparison{
+			DeviceID:       q.DeviceID,
+			MaxHops:        maxHops,
+			BaselineCount:  len(before),
+			SimulatedCount: len(after),
+			Lost:           []string{},
+			Gained:         []string{},
+		}
+		for id := range before {
+			if !after[id] {
+				comparison.Lost = append(comparison.Lost, id)
+			}
+		}
+		for id := range after {
+			if !before[id] {
+				comparison.Gained = append(comparison.Gained, id)
+			}
+		}
+		sort.Strings(comparison.Lost)
+		sort.Strings(comparison.Gained)
+		result.Reachability = append(result.Reachability, comparison)
+	}
+
+	// 変更の影響を受けるデバイスのオーバーサブスクリプション比較
+	affected, err := simulated.affectedDevices(baseline)
+	if err != nil {
+		return nil, err
+	}
+	for _, deviceID := range affected {
+		before, err := baseline.oversubscription(deviceID)
+		if err != nil {
+			return nil, err
+		}
+		after, err := simulated.oversubscription(deviceID)
+		if err != nil {
+			return nil, err
+		}
+		result.Oversubscription = append(result.Oversubscription, topology.OversubscriptionChange{
+			DeviceID:  deviceID,
+			Baseline:  before,
+			Simulated: after,
+		})
+	}
+
+	return result, nil
+}
+
+// simulationCache caches repository lookups shared by the baseline and simulated graphs
+type simulationCache struct {
+	devices map[string]*topology.Device
+	links   map[string][]topology.Link
+}
+
+// simulationGraph lazily loads the topology from the repository and overlays simulated changes
+type simulationGraph struct {
+	ctx   context.Context
+	repo  topology.Repository
+	cache *simulationCache
+
+	addedDevices   map[string]topology.Device
+	removedDevices map[string]bool
+	addedLinks     map[string][]topology.Link // device ID -> links
+	removedLinks   map[string]bool
+
+	removedLinkEnds []string // 削除リンクの端点（影響デバイスの特定用）
+}
+
+func newSimulationGraph(ctx context.Context, repo topology.Repository) *simulationGraph {
+	return &simulationGraph{
+		ctx:  ctx,
+		repo: repo,
+		cache: &simulationCache{
+			devices: make(map[string]*topology.Device),
+			links:   make(map[string][]topology.Link),
+		},
+		addedDevices:   make(map[string]topology.Device),
+		removedDevices: make(map[string]bool),
+		addedLinks:     make(map[string][]topology.Link),
+		removedLinks:   make(map[string]bool),
+	}
+}
+
+// withChanges returns an empty overlay sharing the repository cache
+func (g *simulationGraph) withChanges() *simulationGraph {
+	overlay := newSimulationGraph(g.ctx, g.repo)
+	overlay.cache = g.cache
+	return overlay
+}
+
+// apply validates and records the requested changes
+func (g *simulationGraph) apply(req topology.SimulationRequest) error {
+	for _, device := range req.AddDevices {
+		if device.ID == "" {
+			return fmt.Errorf("%w: added device requires an id", ErrInvalidSimulation)
+		}
+		existing, err := g.baseDevice(device.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("%w: device %s already exists", ErrInvalidSimulation, device.ID)
+		}
+		g.addedDevices[device.ID] = device
+	}
+
+	for _, deviceID := range req.RemoveDevices {
+		existing, err := g.baseDevice(deviceID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return fmt.Errorf("%w: device %s not found", ErrInvalidSimulation, deviceID)
+		}
+		g.removedDevices[deviceID] = true
+	}
+
+	for _, linkID := range req.RemoveLinks {
+		link, err := g.repo.GetLink(g.ctx, linkID)
+		if err != nil {
+			return fmt.Errorf("failed to get link %s: %w", linkID, err)
+		}
+		if link == nil {
+			return fmt.Errorf("%w: link %s not found", ErrInvalidSimulation, linkID)
+		}
+		g.removedLinks[linkID] = true
+		g.removedLinkEnds = append(g.removedLinkEnds, link.SourceID, link.TargetID)
+	}
+
+	for i, link := range req.AddLinks {
+		if link.ID == "" {
+			link.ID = fmt.Sprintf("sim-link-%d", i+1)
+		}
+		if link.Weight == 0 {
+			link.Weight = 1.0
+		}
+		for _, endpoint := range []string{link.SourceID, link.TargetID} {
+			device, err := g.device(endpoint)
+			if err != nil {
+				return err
+			}
+			if device == nil {
+				return fmt.Errorf("%w: link %s references unknown device %s", ErrInvalidSimulation, link.ID, endpoint)
+			}
+		}
+		g.addedLinks[link.SourceID] = append(g.addedLinks[link.SourceID], link)
+		g.addedLinks[link.TargetID] = append(g.addedLinks[link.TargetID], link)
+	}
+
+	return nil
+}
+
+// baseDevice returns a device from the repository (without overlay)
+func (g *simulationGraph) baseDevice(deviceID string) (*topology.Device, error) {
+	if device, ok := g.cache.devices[deviceID]; ok {
+		return device, nil
+	}
+	device, err := g.repo.GetDevice(g.ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device %s: %w", deviceID, err)
+	}
+	g.cache.devices[deviceID] = device
+	return device, nil
+}
+
+// device returns a device with simulated changes applied (nil if absent)
+func (g *simulationGraph) device(deviceID string) (*topology.Device, error) {
+	if device, ok := g.addedDevices[deviceID]; ok {
+		return &device, nil
+	}
+	if g.removedDevices[deviceID] {
+		return nil, nil
+	}
+	return g.baseDevice(deviceID)
+}
+
+// links returns links of a device with simulated changes applied
+func (g *simulationGraph) links(deviceID string) ([]topology.Link, error) {
+	if g.removedDevices[deviceID] {
+		return nil, nil
+	}
+
+	base, ok := g.cache.links[deviceID]
+	if !ok {
+		var err error
+		base, err = g.repo.GetDeviceLinks(g.ctx, deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", deviceID, err)
+		}
+		g.cache.links[deviceID] = base
+	}
+
+	result := make([]topology.Link, 0, len(base)+len(g.addedLinks[deviceID]))
+	for _, link := range base {
+		if g.removedLinks[link.ID] || g.removedDevices[link.SourceID] || g.removedDevices[link.TargetID] {
+			continue
+		}
+		result = append(result, link)
+	}
+	result = append(result, g.addedLinks[deviceID]...)
+	return result, nil
+}
+
+// shortestPath finds the minimum-hop path between two devices (nil if unreachable)
+func (g *simulationGraph) shortestPath(fromID, toID string, maxHops int) (*topology.Path, error) {
+	from, err := g.device(fromID)
+	if err != nil || from == nil {
+		return nil, err
+	}
+
+	type visit struct {
+		prev string
+		link topology.Link
+		hops int
+	}
+	visited := map[string]visit{fromID: {}}
+	queue := []string{fromID}
+
+	for len(queue) > 0 && queue[0] != toID {
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current].hops >= maxHops {
+			continue
+		}
+
+		links, err := g.links(current)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			neighborID := link.TargetID
+			if neighborID == current {
+				neighborID = link.SourceID
+			}
+			if _, seen := visited[neighborID]; seen {
+				continue
+			}
+			visited[neighborID] = visit{prev: current, link: link, hops: visited[current].hops + 1}
+			queue = append(queue, neighborID)
+		}
+	}
+
+	if _, ok := visited[toID]; !ok {
+		return nil, nil
+	}
+
+	// 経路を逆順にたどって組み立てる
+	path := &topology.Path{}
+	for id := toID; ; id = visited[id].prev {
+		device, err := g.device(id)
+		if err != nil {
+			return nil, err
+		}
+		if device != nil {
+			path.Devices = append([]topology.Device{*device}, path.Devices...)
+		}
+		if id == fromID {
+			break
+		}
+		path.Links = append([]topology.Link{visited[id].link}, path.Links...)
+		path.TotalCost += visited[id].link.Weight
+	}
+	path.HopCount = len(path.Links)
+
+	return path, nil
+}
+
+// reachable returns the set of devices reachable from deviceID within maxHops
+func (g *simulationGraph) reachable(deviceID string, maxHops int) (map[string]bool, error) {
+	result := make(map[string]bool)
+	device, err := g.device(deviceID)
+	if err != nil || device == nil {
+		return result, err
+	}
+
+	depth := map[string]int{deviceID: 0}
+	queue := []string{deviceID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if depth[current] >= maxHops {
+			continue
+		}
+
+		links, err := g.links(current)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			neighborID := link.TargetID
+			if neighborID == current {
+				neighborID = link.SourceID
+			}
+			if _, seen := depth[neighborID]; seen {
+				continue
+			}
+			depth[neighborID] = depth[current] + 1
+			result[neighborID] = true
+			queue = append(queue, neighborID)
+		}
+	}
+
+	return result, nil
+}
+
+// affectedDevices lists devices whose links change between baseline and this overlay
+func (g *simulationGraph) affectedDevices(baseline *simulationGraph) ([]string, error) {
+	affected := make(map[string]bool)
+
+	for deviceID := range g.addedDevices {
+		affected[deviceID] = true
+	}
+	for deviceID, links := range g.addedLinks {
+		affected[deviceID] = true
+		for _, link := range links {
+			affected[link.SourceID] = true
+			affected[link.TargetID] = true
+		}
+	}
+	for deviceID := range g.removedDevices {
+		affected[deviceID] = true
+		links, err := baseline.links(deviceID)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			affected[link.SourceID] = true
+			affected[link.TargetID] = true
+		}
+	}
+	for _, deviceID := range g.removedLinkEnds {
+		affected[deviceID] = true
+	}
+
+	deviceIDs := make([]string, 0, len(affected))
+	for deviceID := range affected {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	sort.Strings(deviceIDs)
+	return deviceIDs, nil
+}
+
+// oversubscription calculates the downlink/uplink capacity ratio of a device (nil if absent)
+func (g *simulationGraph) oversubscription(deviceID string) (*topology.Oversubscription, error) {
+	device, err := g.device(deviceID)
+	if err != nil || device == nil || device.LayerID == nil {
+		return nil, err
+	}
+
+	links, err := g.links(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &topology.Oversubscription{}
+	for _, link := range links {
+		neighborID := link.TargetID
+		if neighborID == deviceID {
+			neighborID = link.SourceID
+		}
+		neighbor, err := g.device(neighborID)
+		if err != nil {
+			return nil, err
+		}
+		if neighbor == nil || neighbor.LayerID == nil {
+			continue
+		}
+
+		// レイヤー値が小さいほど上位階層
+		switch {
+		case *neighbor.LayerID < *device.LayerID:
+			result.UplinkCapacity += linkCapacity(link)
+		case *neighbor.LayerID > *device.LayerID:
+			result.DownlinkCapacity += linkCapacity(link)
+		}
+	}
+
+	if result.UplinkCapacity > 0 {
+		result.HasUplink = true
+		result.Ratio = result.DownlinkCapacity / result.UplinkCapacity
+	}
+
+	return result, nil
+}
+
+// linkCapacity returns the capacity of a link from its speed metadata, falling back to weight
+func linkCapacity(link topology.Link) float64 {
+	if speed, err := strconv.ParseFloat(link.Metadata["speed"], 64); err == nil && speed > 0 {
+		return speed
+	}
+	if link.Weight > 0 {
+		return link.Weight
+	}
+	return 1.0
+}
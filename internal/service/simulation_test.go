@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedFabric adds spine-01 (layer 1), leaf-01 (layer 2) and server-01/02 (layer 3) with 100G
+// uplinks and 25G server links
+func seedFabric(t *testing.T, setup *testutil.TestSetup) {
+	ctx := context.Background()
+
+	var devices []topology.Device
+	for id, layer := range map[string]int{"spine-01": 1, "spine-02": 1, "leaf-01": 2, "server-01": 3, "server-02": 3} {
+		device := testutil.CreateTestDevice(id)
+		layerID := layer
+		device.LayerID = &layerID
+		devices = append(devices, device)
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, devices))
+
+	link := func(id, source, target, speed string) topology.Link {
+		link := testutil.CreateTestLink(id, source, target)
+		link.Metadata = map[string]string{"speed": speed}
+		return link
+	}
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, []topology.Link{
+		link("uplink-1", "leaf-01", "spine-01", "100"),
+		link("server-1", "leaf-01", "server-01", "25"),
+		link("server-2", "leaf-01", "server-02", "25"),
+	}))
+}
+
+func newTestSimulationService(t *testing.T) (*SimulationService, *testutil.TestSetup) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	seedFabric(t, setup)
+	return NewSimulationService(setup.Repo), setup
+}
+
+func TestSimulationService_RemoveLink(t *testing.T) {
+	simulationService, setup := newTestSimulationService(t)
+	ctx := context.Background()
+
+	result, err := simulationService.Simulate(ctx, topology.SimulationRequest{
+		RemoveLinks:  []string{"uplink-1"},
+		Paths:        []topology.PathQuery{{From: "server-01", To: "spine-01"}},
+		Reachability: []topology.ReachabilityQuery{{DeviceID: "server-01"}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Paths, 1)
+	require.NotNil(t, result.Paths[0].Baseline)
+	assert.Equal(t, 2, result.Paths[0].Baseline.HopCount)
+	assert.Nil(t, result.Paths[0].Simulated, "spine-01 should be unreachable without the uplink")
+
+	require.Len(t, result.Reachability, 1)
+	reachability := result.Reachability[0]
+	assert.Equal(t, defaultSimulationMaxHops, reachability.MaxHops)
+	assert.Equal(t, 3, reachability.BaselineCount)
+	assert.Equal(t, 2, reachability.SimulatedCount)
+	assert.Equal(t, []string{"spine-01"}, reachability.Lost)
+	assert.Empty(t, reachability.Gained)
+
+	// 端点のアップリンクが無くなる
+	changes := make(map[string]topology.OversubscriptionChange)
+	for _, change := range result.Oversubscription {
+		changes[change.DeviceID] = change
+	}
+	require.Contains(t, changes, "leaf-01")
+	assert.True(t, changes["leaf-01"].Baseline.HasUplink)
+	assert.InDelta(t, 0.5, changes["leaf-01"].Baseline.Ratio, 0.001)
+	assert.False(t, changes["leaf-01"].Simulated.HasUplink)
+	assert.Contains(t, changes, "spine-01")
+
+	// シミュレーションは保存済みのトポロジーを変更しない
+	link, err := setup.Repo.GetLink(ctx, "uplink-1")
+	require.NoError(t, err)
+	assert.NotNil(t, link)
+}
+
+func TestSimulationService_AddUplink(t *testing.T) {
+	simulationService, _ := newTestSimulationService(t)
+
+	uplink := testutil.CreateTestLink("", "leaf-01", "spine-02")
+	uplink.Metadata = map[string]string{"speed": "100"}
+	result, err := simulationService.Simulate(context.Background(), topology.SimulationRequest{
+		AddLinks:     []topology.Link{uplink},
+		Paths:        []topology.PathQuery{{From: "server-01", To: "spine-02"}},
+		Reachability: []topology.ReachabilityQuery{{DeviceID: "spine-02", MaxHops: 1}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Paths, 1)
+	assert.Nil(t, result.Paths[0].Baseline)
+	require.NotNil(t, result.Paths[0].Simulated)
+	assert.Equal(t, 2, result.Paths[0].Simulated.HopCount)
+	assert.Equal(t, "sim-link-1", result.Paths[0].Simulated.Links[1].ID, "added links without an id should get one")
+
+	assert.Equal(t, []string{"leaf-01"}, result.Reachability[0].Gained)
+
+	// 2本目のアップリンクでオーバーサブスクリプションが半減する
+	var leaf *topology.OversubscriptionChange
+	for i := range result.Oversubscription {
+		if result.Oversubscription[i].DeviceID == "leaf-01" {
+			leaf = &result.Oversubscription[i]
+		}
+	}
+	require.NotNil(t, leaf)
+	assert.InDelta(t, 0.5, leaf.Baseline.Ratio, 0.001)
+	assert.InDelta(t, 0.25, leaf.Simulated.Ratio, 0.001)
+	assert.InDelta(t, 200, leaf.Simulated.UplinkCapacity, 0.001)
+}
+
+func TestSimulationService_AddAndRemoveDevices(t *testing.T) {
+	simulationService, _ := newTestSimulationService(t)
+
+	// leaf-01 を新しい leaf-02 に置き換える
+	layer := 2
+	replacement := testutil.CreateTestDevice("leaf-02")
+	replacement.LayerID = &layer
+	result, err := simulationService.Simulate(context.Background(), topology.SimulationRequest{
+		AddDevices:    []topology.Device{replacement},
+		RemoveDevices: []string{"leaf-01"},
+		AddLinks: []topology.Link{
+			{ID: "new-uplink", SourceID: "leaf-02", TargetID: "spine-01"},
+			{ID: "new-server", SourceID: "leaf-02", TargetID: "server-01"},
+		},
+		Paths: []topology.PathQuery{{From: "server-01", To: "spine-01"}, {From: "server-02", To: "spine-01"}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Paths, 2)
+	require.NotNil(t, result.Paths[0].Simulated)
+	assert.Equal(t, "leaf-02", result.Paths[0].Simulated.Devices[1].ID)
+	assert.Nil(t, result.Paths[1].Simulated, "server-02 was only connected through the removed leaf")
+
+	changes := make(map[string]topology.OversubscriptionChange)
+	for _, change := range result.Oversubscription {
+		changes[change.DeviceID] = change
+	}
+	require.Contains(t, changes, "leaf-01")
+	assert.NotNil(t, changes["leaf-01"].Baseline)
+	assert.Nil(t, changes["leaf-01"].Simulated, "removed devices have no simulated oversubscription")
+	require.Contains(t, changes, "leaf-02")
+	assert.Nil(t, changes["leaf-02"].Baseline, "added devices have no baseline oversubscription")
+	assert.InDelta(t, 1, changes["leaf-02"].Simulated.UplinkCapacity, 0.001, "links without speed count their weight")
+	assert.Contains(t, changes, "server-02", "neighbors of removed devices are affected")
+}
+
+func TestSimulationService_InvalidRequests(t *testing.T) {
+	simulationService, _ := newTestSimulationService(t)
+
+	tests := []struct {
+		name string
+		req  topology.SimulationRequest
+	}{
+		{"added device without id", topology.SimulationRequest{AddDevices: []topology.Device{{}}}},
+		{"added device already exists", topology.SimulationRequest{AddDevices: []topology.Device{{ID: "leaf-01"}}}},
+		{"removed device not found", topology.SimulationRequest{RemoveDevices: []string{"missing"}}},
+		{"removed link not found", topology.SimulationRequest{RemoveLinks: []string{"missing"}}},
+		{"link to unknown device", topology.SimulationRequest{AddLinks: []topology.Link{{SourceID: "leaf-01", TargetID: "missing"}}}},
+		{"link to removed device", topology.SimulationRequest{
+			RemoveDevices: []string{"spine-01"},
+			AddLinks:      []topology.Link{{SourceID: "leaf-01", TargetID: "spine-01"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := simulationService.Simulate(context.Background(), tt.req)
+			assert.ErrorIs(t, err, ErrInvalidSimulation)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+// ErrInvalidStartingView is returned when a starting view is malformed or points to an unknown device
+var ErrInvalidStartingView = apperror.Validation("invalid_starting_view", "invalid starting view")
+
+type StartingViewService struct {
+	startingViewRepo visualization.StartingViewRepository
+	topologyRepo     topology.Repository
+}
+
+func NewStartingViewService(startingViewRepo visualization.StartingViewRepository, topologyRepo topology.Repository) *StartingViewService {
+	return &StartingViewService{
+		startingViewRepo: startingViewRepo,
+		topologyRepo:     topologyRepo,
+	}
+}
+
+// ListStartingViews returns the starting views of all roles
+func (s *StartingViewService) ListStartingViews(ctx context.Context) ([]visualization.StartingView, error) {
+	return s.startingViewRepo.ListStartingViews(ctx)
+}
+
+// ResolveStartingView returns the starting view of role, falling back to the default view.
+// Returns nil when neither is configured.
+func (s *StartingViewService) ResolveStartingView(ctx context.Context, role string) (*visualization.StartingView, error) {
+	role = strings.TrimSpace(role)
+	if role != "" {
+		view, err := s.startingViewRepo.GetStartingView(ctx, role)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get starting view: %w", err)
+		}
+		if view != nil {
+			return view, nil
+		}
+	}
+
+	view, err := s.startingViewRepo.GetStartingView(ctx, visualization.DefaultStartingViewRole)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default starting view: %w", err)
+	}
+	return view, nil
+}
+
+// SaveStartingView validates and stores the starting view of a role
+func (s *StartingViewService) SaveStartingView(ctx context.Context, view visualization.StartingView, userID string) (*visualization.StartingView, error) {
+	view.Role = strings.TrimSpace(view.Role)
+	view.RootDevice = strings.TrimSpace(view.RootDevice)
+
+	if view.Role == "" {
+		return nil, fmt.Errorf("%w: role is required", ErrInvalidStartingView)
+	}
+	if view.RootDevice == "" {
+		return nil, fmt.Errorf("%w: root_device is required", ErrInvalidStartingView)
+	}
+	if view.Depth <= 0 {
+		view.Depth = 3
+	}
+	if view.Depth > 10 {
+		return nil, fmt.Errorf("%w: depth must be between 1 and 10", ErrInvalidStartingView)
+	}
+
+	// 存在しないデバイスを開始地点にしない
+	device, err := s.topologyRepo.GetDevice(ctx, view.RootDevice)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get root device: %w", err)
+	}
+	if device == nil {
+		return nil, fmt.Errorf("%w: root device %s not found", ErrInvalidStartingView, view.RootDevice)
+	}
+
+	view.UpdatedBy = userID
+	view.UpdatedAt = time.Now()
+	if err := s.startingViewRepo.SaveStartingView(ctx, view); err != nil {
+		return nil, fmt.Errorf("failed to save starting view: %w", err)
+	}
+
+	return &view, nil
+}
+
+// DeleteStartingView removes the starting view of a role
+func (s *StartingViewService) DeleteStartingView(ctx context.Context, role string) error {
+	return s.startingViewRepo.DeleteStartingView(ctx, role)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStartingViewService(t *testing.T) *StartingViewService {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+
+	startingViewRepo, ok := setup.Repo.(visualization.StartingViewRepository)
+	require.True(t, ok)
+	return NewStartingViewService(startingViewRepo, setup.Repo)
+}
+
+func TestStartingViewService_ResolveFallsBackToDefault(t *testing.T) {
+	startingViewService := newTestStartingViewService(t)
+	ctx := context.Background()
+
+	view, err := startingViewService.ResolveStartingView(ctx, "dc-ops")
+	require.NoError(t, err)
+	assert.Nil(t, view, "no view is configured yet")
+
+	_, err = startingViewService.SaveStartingView(ctx, visualization.StartingView{Role: "default", RootDevice: "device-001"}, "admin")
+	require.NoError(t, err)
+	saved, err := startingViewService.SaveStartingView(ctx, visualization.StartingView{
+		Role:       " network-core ",
+		RootDevice: " device-002 ",
+		Depth:      2,
+		Grouping:   &visualization.GroupingOptions{Enabled: true, MinGroupSize: 3},
+	}, "jane")
+	require.NoError(t, err)
+	assert.Equal(t, "network-core", saved.Role)
+	assert.Equal(t, "device-002", saved.RootDevice)
+	assert.Equal(t, "jane", saved.UpdatedBy)
+	assert.False(t, saved.UpdatedAt.IsZero())
+
+	view, err = startingViewService.ResolveStartingView(ctx, "network-core")
+	require.NoError(t, err)
+	require.NotNil(t, view)
+	assert.Equal(t, "device-002", view.RootDevice)
+	assert.Equal(t, 2, view.Depth)
+	require.NotNil(t, view.Grouping)
+	assert.Equal(t, 3, view.Grouping.MinGroupSize)
+
+	// ビューの無いロールと空のロールは default を使う
+	for _, role := range []string{"dc-ops", "", "  "} {
+		view, err = startingViewService.ResolveStartingView(ctx, role)
+		require.NoError(t, err)
+		require.NotNil(t, view, role)
+		assert.Equal(t, "default", view.Role, role)
+		assert.Equal(t, 3, view.Depth, "omitted depth defaults to 3")
+	}
+
+	views, err := startingViewService.ListStartingViews(ctx)
+	require.NoError(t, err)
+	assert.Len(t, views, 2)
+
+	require.NoError(t, startingViewService.DeleteStartingView(ctx, "network-core"))
+	view, err = startingViewService.ResolveStartingView(ctx, "network-core")
+	require.NoError(t, err)
+	assert.Equal(t, "default", view.Role)
+}
+
+func TestStartingViewService_SaveReplacesView(t *testing.T) {
+	startingViewService := newTestStartingViewService(t)
+	ctx := context.Background()
+
+	_, err := startingViewService.SaveStartingView(ctx, visualization.StartingView{Role: "dc-ops", RootDevice: "device-001", Depth: 4}, "admin")
+	require.NoError(t, err)
+	_, err = startingViewService.SaveStartingView(ctx, visualization.StartingView{Role: "dc-ops", RootDevice: "device-003", Description: "border leaf"}, "jane")
+	require.NoError(t, err)
+
+	view, err := startingViewService.ResolveStartingView(ctx, "dc-ops")
+	require.NoError(t, err)
+	assert.Equal(t, "device-003", view.RootDevice)
+	assert.Equal(t, 3, view.Depth)
+	assert.Equal(t, "border leaf", view.Description)
+	assert.Equal(t, "jane", view.UpdatedBy)
+
+	views, err := startingViewService.ListStartingViews(ctx)
+	require.NoError(t, err)
+	assert.Len(t, views, 1)
+}
+
+func TestStartingViewService_InvalidViews(t *testing.T) {
+	startingViewService := newTestStartingViewService(t)
+
+	tests := []struct {
+		name string
+		view visualization.StartingView
+	}{
+		{"missing role", visualization.StartingView{Role: " ", RootDevice: "device-001"}},
+		{"missing root device", visualization.StartingView{Role: "dc-ops"}},
+		{"depth too large", visualization.StartingView{Role: "dc-ops", RootDevice: "device-001", Depth: 11}},
+		{"unknown root device", visualization.StartingView{Role: "dc-ops", RootDevice: "missing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := startingViewService.SaveStartingView(context.Background(), tt.view, "admin")
+			assert.ErrorIs(t, err, ErrInvalidStartingView)
+		})
+	}
+
+	views, err := startingViewService.ListStartingViews(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, views)
+}
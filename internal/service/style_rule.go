@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+var (
+	// ErrInvalidStyleRule is returned when a style rule is malformed
+	ErrInvalidStyleRule = apperror.Validation("invalid_style_rule", "invalid style rule")
+	// ErrStyleRuleNotFound is returned when the requested style rule does not exist
+	ErrStyleRuleNotFound = apperror.NotFound("style_rule_not_found", "style rule not found")
+)
+
+// StyleRuleService manages the rules overriding the styles of visualized nodes and edges
+type StyleRuleService struct {
+	styleRepo visualization.StyleRuleRepository
+}
+
+func NewStyleRuleService(styleRepo visualization.StyleRuleRepository) *StyleRuleService {
+	return &StyleRuleService{styleRepo: styleRepo}
+}
+
+// ListStyleRules returns the rules in the order they are applied
+func (s *StyleRuleService) ListStyleRules(ctx context.Context) ([]visualization.StyleRule, error) {
+	return s.styleRepo.ListStyleRules(ctx)
+}
+
+// SaveStyleRule validates and stores a style rule
+func (s *StyleRuleService) SaveStyleRule(ctx context.Context, rule visualization.StyleRule, userID string) (*visualization.StyleRule, error) {
+	rule.ID = strings.TrimSpace(rule.ID)
+	rule.LogicOperator = strings.ToUpper(strings.TrimSpace(rule.LogicOperator))
+	if rule.LogicOperator == "" {
+		rule.LogicOperator = "AND"
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStyleRule, err)
+	}
+
+	rule.UpdatedBy = userID
+	rule.UpdatedAt = time.Now()
+	if err := s.styleRepo.SaveStyleRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to save style rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// DeleteStyleRule removes a style rule
+func (s *StyleRuleService) DeleteStyleRule(ctx context.Context, id string) error {
+	deleted, err := s.styleRepo.DeleteStyleRule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %s", ErrStyleRuleNotFound, id)
+	}
+	return nil
+}
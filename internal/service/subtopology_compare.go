@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+var (
+	// ErrInvalidSubTopologyComparison is returned when the two roots of a comparison are the same device
+	ErrInvalidSubTopologyComparison = apperror.Validation("invalid_subtopology_comparison", "invalid subtopology comparison")
+	// ErrComparisonRootNotFound is returned when a root of a comparison does not exist
+	ErrComparisonRootNotFound = apperror.NotFound("device_not_found", "device not found")
+)
+
+// CompareSubTopologies extracts the subtopologies within depth hops below rootA and rootB and
+// diffs their structure, e.g. to validate that a newly built pod mirrors a reference pod
+func (s *TopologyService) CompareSubTopologies(ctx context.Context, rootA, rootB string, depth int) (*topology.SubTopologyComparison, error) {
+	if rootA == rootB {
+		return nil, fmt.Errorf("%w: a and b must be different devices", ErrInvalidSubTopologyComparison)
+	}
+	for _, root := range []string{rootA, rootB} {
+		device, err := s.repo.GetDevice(ctx, root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get device %s: %w", root, err)
+		}
+		if device == nil {
+			return nil, fmt.Errorf("%w: %s", ErrComparisonRootNotFound, root)
+		}
+	}
+
+	profileA, err := s.profileBelow(ctx, rootA, depth)
+	if err != nil {
+		return nil, err
+	}
+	profileB, err := s.profileBelow(ctx, rootB, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := topology.CompareSubTopologies(*profileA, *profileB)
+	return &comparison, nil
+}
+
+// profileBelow profiles the subtopology below root
+func (s *TopologyService) profileBelow(ctx context.Context, root string, depth int) (*topology.SubTopologyProfile, error) {
+	devices, links, err := s.repo.ExtractSubTopology(ctx, root, topology.SubTopologyOptions{Radius: depth})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract sub-topology of %s: %w", root, err)
+	}
+
+	devices, links = topology.BelowRoot(root, devices, links)
+	profile := topology.ProfileSubTopology(root, devices, links)
+	return &profile, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+var (
+	// ErrInvalidSuggestionAction is returned when a bulk action is not accept or reject, or has an invalid filter
+	ErrInvalidSuggestionAction = apperror.Validation("invalid_suggestion_action", "invalid suggestion action")
+	// ErrBulkSuggestionsUnsupported is returned when the repository cannot resolve suggestions in bulk
+	ErrBulkSuggestionsUnsupported = errors.New("bulk suggestion actions are not supported by this repository")
+)
+
+// HandleSuggestionsBulk accepts or rejects every pending suggestion matching filter in one transaction.
+// Suggestions that fail are reported in the result without undoing the others.
+func (s *ClassificationService) HandleSuggestionsBulk(ctx context.Context, action string, filter classification.SuggestionFilter) (*classification.BulkSuggestionResult, error) {
+	bulkRepo, ok := s.classificationRepo.(classification.SuggestionBulkRepository)
+	if !ok {
+		return nil, ErrBulkSuggestionsUnsupported
+	}
+
+	var status classification.SuggestionStatus
+	switch action {
+	case "accept":
+		status = classification.SuggestionStatusAccepted
+	case "reject":
+		status = classification.SuggestionStatusRejected
+	default:
+		return nil, fmt.Errorf("%w: action must be accept or reject", ErrInvalidSuggestionAction)
+	}
+	if filter.MinConfidence < 0 || filter.MinConfidence > 1 {
+		return nil, fmt.Errorf("%w: min_confidence must be between 0 and 1", ErrInvalidSuggestionAction)
+	}
+
+	suggestions, err := s.classificationRepo.ListPendingClassificationSuggestions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending suggestions: %w", err)
+	}
+
+	var suggestionIDs []string
+	for _, suggestion := range suggestions {
+		if filter.Matches(suggestion) {
+			suggestionIDs = append(suggestionIDs, suggestion.ID)
+		}
+	}
+
+	result := &classification.BulkSuggestionResult{
+		Action:        action,
+		Filter:        filter,
+		Matched:       len(suggestionIDs),
+		SuggestionIDs: []string{},
+		Failed:        []classification.SuggestionFailure{},
+	}
+	if len(suggestionIDs) == 0 {
+		return result, nil
+	}
+
+	resolved, failed, err := bulkRepo.ResolveSuggestions(ctx, suggestionIDs, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve suggestions: %w", err)
+	}
+
+	result.SuggestionIDs = append(result.SuggestionIDs, resolved...)
+	result.Failed = append(result.Failed, failed...)
+	if status == classification.SuggestionStatusAccepted {
+		result.Accepted = len(resolved)
+	} else {
+		result.Rejected = len(resolved)
+	}
+
+	return result, nil
+}
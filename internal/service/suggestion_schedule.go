@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// ErrSuggestionHistoryUnsupported is returned when the repository does not keep resolved suggestions
+var ErrSuggestionHistoryUnsupported = errors.New("suggestion history is not supported by this repository")
+
+// GenerateNewSuggestions generates rule suggestions and saves those that are new, with their rule
+// inactive until accepted. A suggestion is skipped when a rejected suggestion, a pending or accepted
+// suggestion or an existing rule classifies the same devices the same way.
+func (s *ClassificationService) GenerateNewSuggestions(ctx context.Context) (*classification.SuggestionRun, error) {
+	historyRepo, ok := s.classificationRepo.(classification.SuggestionHistoryRepository)
+	if !ok {
+		return nil, ErrSuggestionHistoryUnsupported
+	}
+
+	generated, err := s.GenerateRuleSuggestions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	run := &classification.SuggestionRun{
+		Generated: len(generated),
+		Created:   []classification.ClassificationSuggestion{},
+	}
+	if len(generated) == 0 {
+		return run, nil
+	}
+
+	known, err := historyRepo.ListClassificationSuggestionsByStatus(ctx,
+		classification.SuggestionStatusPending, classification.SuggestionStatusAccepted, classification.SuggestionStatusRejected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suggestions: %w", err)
+	}
+	rules, err := s.classificationRepo.ListClassificationRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list classification rules: %w", err)
+	}
+
+	// 却下された提案を優先して記録し、同じルールの再提案を却下済みとして数える
+	seen := make(map[string]classification.SuggestionStatus, len(known)+len(rules))
+	for _, rule := range rules {
+		seen[classification.SuggestionFingerprint(rule)] = classification.SuggestionStatusAccepted
+	}
+	for _, suggestion := range known {
+		fingerprint := classification.SuggestionFingerprint(suggestion.Rule)
+		if seen[fingerprint] != classification.SuggestionStatusRejected {
+			seen[fingerprint] = suggestion.Status
+		}
+	}
+
+	sort.SliceStable(generated, func(i, j int) bool {
+		return generated[i].Confidence > generated[j].Confidence
+	})
+
+	now := time.Now()
+	for _, suggestion := range generated {
+		fingerprint := classification.SuggestionFingerprint(suggestion.Rule)
+		if status, ok := seen[fingerprint]; ok {
+			if status == classification.SuggestionStatusRejected {
+				run.SkippedRejected++
+			} else {
+				run.SkippedExisting++
+			}
+			continue
+		}
+		seen[fingerprint] = classification.SuggestionStatusPending
+
+		// 提案はルールを参照するため、無効なルールとして先に保存する
+		rule := suggestion.Rule
+		if rule.ID == "" {
+			rule.ID = uuid.New().String()
+		}
+		rule.IsActive = false
+		if err := s.classificationRepo.SaveClassificationRule(ctx, rule); err != nil {
+			return nil, fmt.Errorf("failed to save suggested rule: %w", err)
+		}
+
+		suggestion.Rule = rule
+		suggestion.RuleID = rule.ID
+		suggestion.Status = classification.SuggestionStatusPending
+		suggestion.CreatedAt = now
+		suggestion.UpdatedAt = now
+		if err := s.classificationRepo.SaveClassificationSuggestion(ctx, suggestion); err != nil {
+			return nil, fmt.Errorf("failed to save suggestion: %w", err)
+		}
+		run.Created = append(run.Created, suggestion)
+	}
+
+	return run, nil
+}
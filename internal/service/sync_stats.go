@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ErrInvalidSyncStatsRange is returned when the period of the sync stats history is not positive
+var ErrInvalidSyncStatsRange = apperror.Validation("invalid_sync_stats_range", "since must be positive")
+
+// SyncStatsService serves the topology changes counted by each synchronization cycle of the worker
+type SyncStatsService struct {
+	statsRepo topology.SyncStatsRepository
+}
+
+func NewSyncStatsService(statsRepo topology.SyncStatsRepository) *SyncStatsService {
+	return &SyncStatsService{statsRepo: statsRepo}
+}
+
+// History returns the cycles finished within since, oldest first, at most the last limit (0 = all)
+func (s *SyncStatsService) History(ctx context.Context, since time.Duration, limit int) ([]topology.SyncCycleStats, error) {
+	if since <= 0 {
+		return nil, ErrInvalidSyncStatsRange
+	}
+	return s.statsRepo.ListSyncCycleStats(ctx, time.Now().Add(-since), limit)
+}
+
+// syncMetrics are the gauges of the last cycle in the Prometheus exposition format
+var syncMetrics = []struct {
+	name  string
+	help  string
+	value func(stats topology.SyncCycleStats) float64
+}{
+	{"tm_sync_cycle_devices", "Devices observed by the last synchronization cycle",
+		func(s topology.SyncCycleStats) float64 { return float64(s.Devices) }},
+	{"tm_sync_cycle_links", "Links observed by the last synchronization cycle",
+		func(s topology.SyncCycleStats) float64 { return float64(s.Links) }},
+	{"tm_sync_cycle_devices_appeared", "Devices observed by the last synchronization cycle but not by the one before",
+		func(s topology.SyncCycleStats) float64 { return float64(s.DevicesAppeared) }},
+	{"tm_sync_cycle_devices_disappeared", "Devices observed by the cycle before the last but not by the last",
+		func(s topology.SyncCycleStats) float64 { return float64(s.DevicesDisappeared) }},
+	{"tm_sync_cycle_links_added", "Links observed by the last synchronization cycle but not by the one before",
+		func(s topology.SyncCycleStats) float64 { return float64(s.LinksAdded) }},
+	{"tm_sync_cycle_links_removed", "Links observed by the cycle before the last but not by the last",
+		func(s topology.SyncCycleStats) float64 { return float64(s.LinksRemoved) }},
+	{"tm_sync_cycle_errors", "Failed phases of the last synchronization cycle",
+		func(s topology.SyncCycleStats) float64 { return float64(s.Errors) }},
+	{"tm_sync_cycle_duration_seconds", "Duration of the last synchronization cycle",
+		func(s topology.SyncCycleStats) float64 { return s.FinishedAt.Sub(s.StartedAt).Seconds() }},
+	{"tm_sync_cycle_last_finished_timestamp_seconds", "Unix time the last synchronization cycle finished",
+		func(s topology.SyncCycleStats) float64 { return float64(s.FinishedAt.UnixMilli()) / 1000 }},
+}
+
+// WriteMetrics writes the counts of the last cycle as Prometheus gauges. Nothing but the metric
+// descriptions is written until the worker has recorded a cycle.
+func (s *SyncStatsService) WriteMetrics(ctx context.Context, w io.Writer) error {
+	history, err := s.statsRepo.ListSyncCycleStats(ctx, time.Time{}, 1)
+	if err != nil {
+		return err
+	}
+
+	for _, metric := range syncMetrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", metric.name, metric.help, metric.name)
+		if len(history) > 0 {
+			fmt.Fprintf(w, "%s %s\n", metric.name, strconv.FormatFloat(metric.value(history[len(history)-1]), 'f', -1, 64))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"github.com/servak/topology-manager/internal/domain/classification"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// ApplyTagRules sets the tags of the matching tag rules on the metadata of devices before they are
+// stored and returns how many devices changed. Rules are evaluated against the devices as given, so
+// the tags of one rule never make another match; for each key the first matching rule wins.
+func (s *ClassificationService) ApplyTagRules(devices []topology.Device, rules []classification.TagRule) int {
+	if len(rules) == 0 {
+		return 0
+	}
+
+	tagged := 0
+	for i := range devices {
+		device := &devices[i]
+		tags := classification.MatchedTags(rules, func(rule classification.TagRule) bool {
+			return s.deviceMatchesConditions(*device, rule.LogicOperator, rule.Conditions)
+		})
+		if len(tags) == 0 {
+			continue
+		}
+
+		metadata, changed := classification.ApplyTags(device.Metadata, tags)
+		if changed {
+			device.Metadata = metadata
+			tagged++
+		}
+	}
+	return tagged
+}
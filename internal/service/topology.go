@@ -2,20 +2,39 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/servak/topology-manager/internal/domain/topology"
 )
 
 type TopologyService struct {
-	repo topology.Repository
+	repo         topology.Repository
+	deletionRepo topology.DeviceDeletionRepository // nil = 一括削除なし
+	counter      topology.RowCounter               // nil = 件数APIなし
+	linkLister   topology.LinkListRepository       // nil = デバイスごとにリンクを取得
+	placeholder  topology.PlaceholderDefaults
 }
 
 func NewTopologyService(repo topology.Repository) *TopologyService {
+	deletionRepo, _ := repo.(topology.DeviceDeletionRepository)
+	counter, _ := repo.(topology.RowCounter)
+	linkLister, _ := repo.(topology.LinkListRepository)
+
 	return &TopologyService{
-		repo: repo,
+		repo:         repo,
+		deletionRepo: deletionRepo,
+		counter:      counter,
+		linkLister:   linkLister,
 	}
 }
 
+// SetPlaceholderDefaults sets the placeholder attributes the sync worker uses, so that
+// placeholders awaiting enrichment can be recognized
+func (s *TopologyService) SetPlaceholderDefaults(defaults topology.PlaceholderDefaults) {
+	s.placeholder = defaults
+}
+
 // トポロジー検索メソッド（フロントエンドで使用中）
 func (s *TopologyService) FindReachableDevices(ctx context.Context, deviceID string, opts topology.ReachabilityOptions) ([]topology.Device, error) {
 	return s.repo.FindReachableDevices(ctx, deviceID, opts)
@@ -32,3 +51,170 @@ func (s *TopologyService) SearchDevices(ctx context.Context, query string, limit
 	}
 	return s.repo.SearchDevices(ctx, query, limit)
 }
+
+// SearchDevicesByFilter searches for devices matching filter, e.g. by provenance or workflow
+// state. An empty query lists every matching device, up to limit.
+func (s *TopologyService) SearchDevicesByFilter(ctx context.Context, query string, filter topology.DeviceFilter, limit int) ([]topology.Device, error) {
+	if filter.Provenance != "" && !topology.IsValidProvenance(filter.Provenance) {
+		return nil, fmt.Errorf("%w: unknown provenance '%s'", ErrInvalidDeviceFilter, filter.Provenance)
+	}
+	if filter.WorkflowState != "" && !topology.IsValidWorkflowState(filter.WorkflowState) {
+		return nil, fmt.Errorf("%w: unknown workflow state '%s'", ErrInvalidDeviceFilter, filter.WorkflowState)
+	}
+	return s.searchMatchingDevices(ctx, query, limit, filter.Matches)
+}
+
+// SearchPlaceholdersAwaitingEnrichment searches for placeholder devices that still have the
+// placeholder type and hardware, i.e. LLDP neighbors monitoring has not described yet.
+// An empty query lists every such placeholder, up to limit.
+func (s *TopologyService) SearchPlaceholdersAwaitingEnrichment(ctx context.Context, query string, limit int) ([]topology.Device, error) {
+	return s.searchMatchingDevices(ctx, query, limit, s.placeholder.AwaitingEnrichment)
+}
+
+func (s *TopologyService) searchMatchingDevices(ctx context.Context, query string, limit int, match func(topology.Device) bool) ([]topology.Device, error) {
+	// 条件で絞り込むため、検索結果は上限を付けずに取得する
+	var devices []topology.Device
+	var err error
+	if query != "" {
+		devices, err = s.repo.SearchDevices(ctx, query, 10000)
+		if err != nil {
+			err = fmt.Errorf("failed to get devices: %w", err)
+		}
+	} else {
+		devices, err = ListAllDevices(ctx, s.repo)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []topology.Device{}
+	for _, device := range devices {
+		if !match(device) {
+			continue
+		}
+		matched = append(matched, device)
+		if limit > 0 && len(matched) >= limit {
+			break
+		}
+	}
+	return matched, nil
+}
+
+// loadLinks returns every link of devices
+func (s *TopologyService) loadLinks(ctx context.Context, devices []topology.Device) ([]topology.Link, error) {
+	// 両端のデバイスから同じリンクが返るため ID で重複除去
+	seen := make(map[string]bool)
+	links := []topology.Link{}
+	for _, device := range devices {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		deviceLinks, err := s.repo.GetDeviceLinks(ctx, device.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", device.ID, err)
+		}
+		for _, link := range deviceLinks {
+			if !seen[link.ID] {
+				seen[link.ID] = true
+				links = append(links, link)
+			}
+		}
+	}
+	return links, nil
+}
+
+// TakeSnapshot returns the stored topology as a snapshot
+func (s *TopologyService) TakeSnapshot(ctx context.Context) (*topology.Snapshot, error) {
+	devices, err := ListAllDevices(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.loadLinks(ctx, devices)
+	if err != nil {
+		return nil, err
+	}
+
+	return &topology.Snapshot{
+		TakenAt: time.Now(),
+		Source:  topology.SnapshotSourceStored,
+		Devices: devices,
+		Links:   links,
+	}, nil
+}
+
+// FindAsymmetricLinks returns links reported by only one endpoint.
+// When deviceID is empty, all links in the topology are analyzed.
+func (s *TopologyService) FindAsymmetricLinks(ctx context.Context, deviceID string) ([]topology.AsymmetricLink, error) {
+	if deviceID != "" {
+		links, err := s.repo.GetDeviceLinks(ctx, deviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get device links: %w", err)
+		}
+		return topology.FindAsymmetricLinks(links), nil
+	}
+
+	links, err := s.allLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return topology.FindAsymmetricLinks(links), nil
+}
+
+// allLinks returns every link, in one query when the repository supports it
+func (s *TopologyService) allLinks(ctx context.Context) ([]topology.Link, error) {
+	if s.linkLister != nil {
+		links, err := s.linkLister.ListLinks(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list links: %w", err)
+		}
+		return links, nil
+	}
+
+	devices, err := ListAllDevices(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadLinks(ctx, devices)
+}
+
+// AnalyzeSpineLeafBalance reports how evenly servers spread over leaves and leaves over spines.
+// Devices are placed in tiers by their classification layer.
+func (s *TopologyService) AnalyzeSpineLeafBalance(ctx context.Context, tiers topology.BalanceTiers, ratio float64) (*topology.BalanceReport, error) {
+	devices, err := ListAllDevices(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	fabricLayers := make(map[int]bool)
+	for _, layer := range append(tiers.SpineLayers, tiers.LeafLayers...) {
+		fabricLayers[layer] = true
+	}
+
+	// 対象のリンクは必ずスパインかリーフに接続するため、その2層のリンクのみ取得
+	seen := make(map[string]bool)
+	var links []topology.Link
+	for _, device := range devices {
+		if device.LayerID == nil || !fabricLayers[*device.LayerID] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		deviceLinks, err := s.repo.GetDeviceLinks(ctx, device.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", device.ID, err)
+		}
+		for _, link := range deviceLinks {
+			if !seen[link.ID] {
+				seen[link.ID] = true
+				links = append(links, link)
+			}
+		}
+	}
+
+	return topology.AnalyzeBalance(devices, links, tiers, ratio), nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/servak/topology-manager/internal/domain/topology"
@@ -10,79 +11,57 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestTopologyService_AddAndGetDevice(t *testing.T) {
+func newTestTopologyService(t *testing.T) (*TopologyService, *testutil.TestSetup) {
 	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-
-	topologyService := NewTopologyService(setup.Repo)
-
-	ctx := context.Background()
-
-	// Create a test device
-	testDevice := testutil.CreateTestDevice("test-device-001")
+	t.Cleanup(setup.Cleanup)
+	setup.SeedTestData(t)
+	return NewTopologyService(setup.Repo), setup
+}
 
-	// Add device through service
-	err := topologyService.AddDevice(ctx, testDevice)
-	require.NoError(t, err)
+func TestTopologyService_ListDevices(t *testing.T) {
+	topologyService, _ := newTestTopologyService(t)
 
-	// Retrieve device
-	retrievedDevice, err := topologyService.GetDevice(ctx, testDevice.ID)
+	devices, paginationResult, err := topologyService.ListDevices(context.Background(), 1, 2, true)
 	require.NoError(t, err)
-	require.NotNil(t, retrievedDevice)
+	require.NotNil(t, paginationResult)
 
-	// Verify device properties
-	assert.Equal(t, testDevice.ID, retrievedDevice.ID)
-	assert.Equal(t, testDevice.Type, retrievedDevice.Type)
-	assert.Equal(t, testDevice.Hardware, retrievedDevice.Hardware)
-	assert.Equal(t, testDevice.DeviceType, retrievedDevice.DeviceType)
+	assert.Len(t, devices, 2)
+	assert.Equal(t, 1, paginationResult.Page)
+	assert.Equal(t, 2, paginationResult.PageSize)
+	assert.Equal(t, 3, paginationResult.TotalCount)
+	assert.True(t, paginationResult.HasNext)
 }
 
-func TestTopologyService_GetDevices(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-	setup.SeedTestData(t)
-
-	topologyService := NewTopologyService(setup.Repo)
-
+func TestTopologyService_ListDevicesByCursor(t *testing.T) {
+	topologyService, _ := newTestTopologyService(t)
 	ctx := context.Background()
 
-	// Test getting devices with pagination
-	paginationOpts := topology.PaginationOptions{
-		Page:     1,
-		PageSize: 2,
+	var ids []string
+	cursor := ""
+	for i := 0; i < 3; i++ {
+		devices, paginationResult, err := topologyService.ListDevicesByCursor(ctx, cursor, 2, true)
+		require.NoError(t, err)
+		for _, device := range devices {
+			ids = append(ids, device.ID)
+		}
+		cursor = paginationResult.NextCursor
+		if cursor == "" {
+			break
+		}
 	}
+	assert.Equal(t, []string{"device-001", "device-002", "device-003"}, ids)
 
-	devices, paginationResult, err := topologyService.GetDevices(ctx, paginationOpts)
-	require.NoError(t, err)
-	require.NotNil(t, paginationResult)
-
-	// Should get up to 2 devices
-	assert.LessOrEqual(t, len(devices), 2)
-	assert.GreaterOrEqual(t, len(devices), 1)
-
-	// Check pagination result
-	assert.Equal(t, 1, paginationResult.Page)
-	assert.Equal(t, 2, paginationResult.PageSize)
-	assert.GreaterOrEqual(t, paginationResult.TotalCount, 3) // At least our test data
+	_, _, err := topologyService.ListDevicesByCursor(ctx, "not a cursor", 2, true)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
 }
 
 func TestTopologyService_SearchDevices(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-	setup.SeedTestData(t)
-
-	topologyService := NewTopologyService(setup.Repo)
-
+	topologyService, _ := newTestTopologyService(t)
 	ctx := context.Background()
 
-	// Search for devices by ID pattern
 	devices, err := topologyService.SearchDevices(ctx, "device-00", 10)
 	require.NoError(t, err)
 
-	// Should find our test devices
-	assert.GreaterOrEqual(t, len(devices), 3)
-
-	// Verify we found the right devices
 	foundIDs := make(map[string]bool)
 	for _, device := range devices {
 		foundIDs[device.ID] = true
@@ -90,174 +69,168 @@ func TestTopologyService_SearchDevices(t *testing.T) {
 	assert.True(t, foundIDs["device-001"])
 	assert.True(t, foundIDs["device-002"])
 	assert.True(t, foundIDs["device-003"])
+
+	// 空のクエリは何も返さない
+	devices, err = topologyService.SearchDevices(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, devices)
 }
 
 func TestTopologyService_SearchDevicesByHardware(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-	setup.SeedTestData(t)
-
-	topologyService := NewTopologyService(setup.Repo)
-
-	ctx := context.Background()
+	topologyService, _ := newTestTopologyService(t)
 
-	// Search for devices by hardware (all test devices have "Test Hardware" in their hardware field)
-	devices, err := topologyService.SearchDevices(ctx, "Test Hardware", 10)
+	devices, err := topologyService.SearchDevices(context.Background(), "Test Hardware", 10)
 	require.NoError(t, err)
-
-	// Should find our test devices
-	assert.GreaterOrEqual(t, len(devices), 3)
-
-	// Verify all found devices have the expected hardware pattern
+	assert.Len(t, devices, 3)
 	for _, device := range devices {
 		assert.Contains(t, device.Hardware, "Test Hardware")
 	}
 }
 
-func TestTopologyService_AddAndGetLink(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-
-	topologyService := NewTopologyService(setup.Repo)
-
+func TestTopologyService_SearchDevicesByFilter(t *testing.T) {
+	topologyService, setup := newTestTopologyService(t)
 	ctx := context.Background()
 
-	// First add devices
-	device1 := testutil.CreateTestDevice("device-link-001")
-	device2 := testutil.CreateTestDevice("device-link-002")
-	
-	err := topologyService.AddDevice(ctx, device1)
-	require.NoError(t, err)
-	err = topologyService.AddDevice(ctx, device2)
-	require.NoError(t, err)
+	router := testutil.CreateTestDevice("router-001")
+	router.Provenance = topology.ProvenanceManual
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, []topology.Device{router}))
 
-	// Create and add link
-	testLink := testutil.CreateTestLink("test-link-001", device1.ID, device2.ID)
-	err = topologyService.AddLink(ctx, testLink)
+	devices, err := topologyService.SearchDevicesByFilter(ctx, "", topology.DeviceFilter{Provenance: topology.ProvenanceManual}, 10)
 	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "router-001", devices[0].ID)
 
-	// Retrieve link
-	retrievedLink, err := topologyService.GetLink(ctx, testLink.ID)
-	require.NoError(t, err)
-	require.NotNil(t, retrievedLink)
-
-	// Verify link properties
-	assert.Equal(t, testLink.ID, retrievedLink.ID)
-	assert.Equal(t, testLink.SourceID, retrievedLink.SourceID)
-	assert.Equal(t, testLink.TargetID, retrievedLink.TargetID)
-	assert.Equal(t, testLink.SourcePort, retrievedLink.SourcePort)
-	assert.Equal(t, testLink.TargetPort, retrievedLink.TargetPort)
+	_, err = topologyService.SearchDevicesByFilter(ctx, "", topology.DeviceFilter{Provenance: "nowhere"}, 10)
+	assert.ErrorIs(t, err, ErrInvalidDeviceFilter)
 }
 
-func TestTopologyService_GetDeviceLinks(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-	setup.SeedTestData(t)
-
-	topologyService := NewTopologyService(setup.Repo)
+func TestTopologyService_TakeSnapshot(t *testing.T) {
+	topologyService, _ := newTestTopologyService(t)
 
-	ctx := context.Background()
-
-	// Get links for device-001 (should have link to device-002)
-	links, err := topologyService.GetDeviceLinks(ctx, "device-001")
+	snapshot, err := topologyService.TakeSnapshot(context.Background())
 	require.NoError(t, err)
 
-	// Should have at least one link
-	assert.GreaterOrEqual(t, len(links), 1)
+	assert.Equal(t, topology.SnapshotSourceStored, snapshot.Source)
+	assert.Len(t, snapshot.Devices, 3)
 
-	// Find the link between device-001 and device-002
-	foundLink := false
-	for _, link := range links {
-		if (link.SourceID == "device-001" && link.TargetID == "device-002") ||
-			(link.SourceID == "device-002" && link.TargetID == "device-001") {
-			foundLink = true
-			break
-		}
+	// 両端から返るリンクは1本として数える
+	linkIDs := make(map[string]bool)
+	for _, link := range snapshot.Links {
+		assert.False(t, linkIDs[link.ID], "Link %s should appear once", link.ID)
+		linkIDs[link.ID] = true
 	}
-	assert.True(t, foundLink, "Should find link between device-001 and device-002")
+	assert.True(t, linkIDs["link-001"])
+	assert.True(t, linkIDs["link-002"])
 }
 
-func TestTopologyService_RemoveDevice(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
+// deviceLinkCounter counts the per-device link queries made through the repository
+type deviceLinkCounter struct {
+	topology.Repository
+	calls int
+}
+
+func (c *deviceLinkCounter) GetDeviceLinks(ctx context.Context, deviceID string) ([]topology.Link, error) {
+	c.calls++
+	return c.Repository.GetDeviceLinks(ctx, deviceID)
+}
 
+func TestTopologyService_FindAsymmetricLinks(t *testing.T) {
+	_, setup := newTestTopologyService(t)
 	ctx := context.Background()
 
-	// Add a device
-	testDevice := testutil.CreateTestDevice("device-to-remove")
-	err := setup.TopologyService.AddDevice(ctx, testDevice)
-	require.NoError(t, err)
+	// link-001 は両端から観測され、link-002 は device-002 からのみ
+	reverse := testutil.CreateTestLink("link-003", "device-002", "device-001")
+	reverse.SourcePort, reverse.TargetPort = "eth1", "eth0"
+	require.NoError(t, setup.Repo.BulkAddLinks(ctx, []topology.Link{reverse}))
+
+	lister, ok := setup.Repo.(topology.LinkListRepository)
+	require.True(t, ok)
+	counter := &deviceLinkCounter{Repository: setup.Repo}
+	withLister := NewTopologyService(struct {
+		*deviceLinkCounter
+		topology.LinkListRepository
+	}{counter, lister})
+	asymmetric, err := withLister.FindAsymmetricLinks(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, asymmetric, 1)
+	assert.Equal(t, "link-002", asymmetric[0].Link.ID)
+	assert.Equal(t, "device-002", asymmetric[0].ReportedBy)
+	assert.Equal(t, "device-003", asymmetric[0].SilentDevice)
+	assert.Zero(t, counter.calls, "every link should be read in one query")
+
+	// 一括取得できないリポジトリではデバイスごとに読んで同じ結果になる
+	fallback := NewTopologyService(counter)
+	fallbackLinks, err := fallback.FindAsymmetricLinks(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, asymmetric, fallbackLinks)
+	assert.Equal(t, 3, counter.calls)
+}
 
-	// Verify it exists
-	retrievedDevice, err := setup.TopologyService.GetDevice(ctx, testDevice.ID)
-	require.NoError(t, err)
-	require.NotNil(t, retrievedDevice)
+func TestTopologyService_DeleteDevices(t *testing.T) {
+	topologyService, setup := newTestTopologyService(t)
+	ctx := context.Background()
 
-	// Remove the device
-	err = setup.TopologyService.RemoveDevice(ctx, testDevice.ID)
+	dryRun, err := topologyService.DeleteDevices(ctx, "hardware=Test Hardware device-001", true, "")
 	require.NoError(t, err)
+	assert.Equal(t, []string{"device-001"}, dryRun.DeviceIDs)
+	assert.NotEmpty(t, dryRun.ConfirmationToken)
 
-	// Verify it's gone
-	removedDevice, err := setup.TopologyService.GetDevice(ctx, testDevice.ID)
+	device, err := setup.Repo.GetDevice(ctx, "device-001")
 	require.NoError(t, err)
-	assert.Nil(t, removedDevice)
-}
-
-func TestTopologyService_BulkAddDevices(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
-
-	ctx := context.Background()
+	assert.NotNil(t, device, "Dry run should not delete the device")
 
-	// Create multiple test devices
-	devices := []topology.Device{
-		testutil.CreateTestDevice("bulk-device-001"),
-		testutil.CreateTestDevice("bulk-device-002"),
-		testutil.CreateTestDevice("bulk-device-003"),
-	}
+	_, err = topologyService.DeleteDevices(ctx, "hardware=Test Hardware device-001", false, "")
+	require.NoError(t, err)
 
-	// Bulk add devices
-	err := setup.TopologyService.BulkAddDevices(ctx, devices)
+	device, err = setup.Repo.GetDevice(ctx, "device-001")
 	require.NoError(t, err)
+	assert.Nil(t, device)
 
-	// Verify all devices were added
-	for _, device := range devices {
-		retrievedDevice, err := setup.TopologyService.GetDevice(ctx, device.ID)
-		require.NoError(t, err)
-		require.NotNil(t, retrievedDevice)
-		assert.Equal(t, device.ID, retrievedDevice.ID)
+	links, err := setup.Repo.GetDeviceLinks(ctx, "device-002")
+	require.NoError(t, err)
+	for _, link := range links {
+		assert.NotEqual(t, "device-001", link.SourceID, "Links of the deleted device should be gone")
+		assert.NotEqual(t, "device-001", link.TargetID, "Links of the deleted device should be gone")
 	}
-}
 
-func TestTopologyService_FindDevicesByType(t *testing.T) {
-	setup := testutil.NewTestSetup(t)
-	defer setup.Cleanup()
+	_, err = topologyService.DeleteDevices(ctx, "colour=blue", true, "")
+	assert.ErrorIs(t, err, ErrInvalidDeviceFilter)
+}
 
+func TestTopologyService_DeleteDevicesMatchesEveryPage(t *testing.T) {
+	topologyService, setup := newTestTopologyService(t)
 	ctx := context.Background()
 
-	// Add devices with different types
-	switchDevice := testutil.CreateTestDevice("switch-001")
-	switchDevice.DeviceType = "network-switch"
-	
-	routerDevice := testutil.CreateTestDevice("router-001")
-	routerDevice.DeviceType = "network-router"
+	// 1ページに収まらない台数の一致するデバイスを、一致しないデバイスと交互に並べる
+	var devices []topology.Device
+	var matching []string
+	for i := 0; i < bulkDeleteConfirmThreshold+5; i++ {
+		retired := testutil.CreateTestDevice(fmt.Sprintf("rack-%03d-a", i))
+		retired.Metadata = map[string]string{"site": "tyo1"}
+		kept := testutil.CreateTestDevice(fmt.Sprintf("rack-%03d-b", i))
+		kept.Metadata = map[string]string{"site": "osa1"}
+		devices = append(devices, retired, kept)
+		matching = append(matching, retired.ID)
+	}
+	require.NoError(t, setup.Repo.BulkAddDevices(ctx, devices))
+	setDevicePageSize(t, 7)
 
-	err := setup.TopologyService.AddDevice(ctx, switchDevice)
-	require.NoError(t, err)
-	err = setup.TopologyService.AddDevice(ctx, routerDevice)
+	dryRun, err := topologyService.DeleteDevices(ctx, "metadata.site=tyo1", true, "")
 	require.NoError(t, err)
+	assert.Equal(t, matching, dryRun.DeviceIDs)
+	assert.True(t, dryRun.ConfirmationRequired)
+
+	_, err = topologyService.DeleteDevices(ctx, "metadata.site=tyo1", false, "")
+	assert.ErrorIs(t, err, ErrDeletionConfirmationRequired)
 
-	// Find devices by type
-	switches, err := setup.TopologyService.FindDevicesByType(ctx, "network-switch")
+	result, err := topologyService.DeleteDevices(ctx, "metadata.site=tyo1", false, dryRun.ConfirmationToken)
 	require.NoError(t, err)
+	assert.Equal(t, matching, result.DeviceIDs)
 
-	// Should find at least our switch device (plus any from seed data)
-	foundSwitch := false
-	for _, device := range switches {
-		if device.ID == "switch-001" {
-			foundSwitch = true
-			assert.Equal(t, "network-switch", device.DeviceType)
-		}
+	remaining, err := ListAllDevices(ctx, setup.Repo)
+	require.NoError(t, err)
+	assert.Len(t, remaining, 3+len(matching))
+	for _, device := range remaining {
+		assert.NotEqual(t, "tyo1", device.Metadata["site"], device.ID)
 	}
-	assert.True(t, foundSwitch, "Should find the switch device")
-}
\ No newline at end of file
+}
@@ -2,22 +2,99 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/classification"
 	"github.com/servak/topology-manager/internal/domain/topology"
 	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/service/contract"
 	"github.com/servak/topology-manager/pkg/grouping"
 )
 
+var (
+	// ErrGroupNotFound is returned when a group to expand is not part of the topology
+	ErrGroupNotFound = apperror.NotFound("group_not_found", "group not found")
+	// ErrRootDeviceNotFound is returned when the root device of a topology does not exist
+	ErrRootDeviceNotFound = apperror.NotFound("device_not_found", "root device not found")
+)
+
+var (
+	_ contract.Visualization      = (*VisualizationService)(nil)
+	_ contract.ViewRequestFlusher = (*VisualizationService)(nil)
+)
+
 type VisualizationService struct {
-	topologyRepo topology.Repository
+	topologyRepo    topology.Repository
+	displayNameRepo topology.DisplayNameRepository      // nil = 表示名の上書きなし
+	layoutCache     visualization.LayoutCacheRepository // nil = レイアウトキャッシュなし
+	hierarchyRepo   classification.Repository           // nil = 階層帯は "Layer N" 表記
+	styleRepo       visualization.StyleRuleRepository   // nil = スタイルルールなし
+
+	// FlushViewRequests まで溜めるビューごとのリクエスト数
+	viewRequestsMu sync.Mutex
+	viewRequests   map[visualization.ViewKey]viewRequestCount
+}
+
+// DefaultViewRequestFlushInterval is how often the API writes the buffered view request counts
+const DefaultViewRequestFlushInterval = time.Minute
+
+// viewRequestCount is the buffered number of requests of a view and when the last one was made
+type viewRequestCount struct {
+	count int
+	last  time.Time
 }
 
 func NewVisualizationService(topologyRepo topology.Repository) *VisualizationService {
+	displayNameRepo, _ := topologyRepo.(topology.DisplayNameRepository)
+	layoutCache, _ := topologyRepo.(visualization.LayoutCacheRepository)
+	hierarchyRepo, _ := topologyRepo.(classification.Repository)
+	styleRepo, _ := topologyRepo.(visualization.StyleRuleRepository)
+
 	return &VisualizationService{
-		topologyRepo: topologyRepo,
+		topologyRepo:    topologyRepo,
+		displayNameRepo: displayNameRepo,
+		layoutCache:     layoutCache,
+		hierarchyRepo:   hierarchyRepo,
+		styleRepo:       styleRepo,
+	}
+}
+
+// AddLayerBands adds one labeled band per hierarchy layer to the layout of visualTopology,
+// using the names and colors of the hierarchy layers
+func (s *VisualizationService) AddLayerBands(ctx context.Context, visualTopology *visualization.VisualTopology) error {
+	styles := make(map[int]visualization.LayerStyle)
+	if s.hierarchyRepo != nil {
+		layers, err := s.hierarchyRepo.ListHierarchyLayers(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list hierarchy layers: %w", err)
+		}
+		for _, layer := range layers {
+			styles[layer.ID] = visualization.LayerStyle{Name: layer.Name, Color: layer.Color}
+		}
 	}
+
+	visualTopology.Layout.Bands = visualization.NewLayerBands(visualTopology.Nodes, visualTopology.Layout.Positions, styles)
+	return nil
+}
+
+// displayNames loads the display-name overrides used for VisualNode.Name
+func (s *VisualizationService) displayNames(ctx context.Context) (*topology.DisplayNameResolver, error) {
+	if s.displayNameRepo == nil {
+		return nil, nil
+	}
+
+	overrides, err := s.displayNameRepo.ListDisplayNameOverrides(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list display name overrides: %w", err)
+	}
+	return topology.NewDisplayNameResolver(overrides), nil
 }
 
 func (s *VisualizationService) GetVisualTopology(ctx context.Context, rootDeviceID string, depth int) (*visualization.VisualTopology, error) {
@@ -26,8 +103,9 @@ func (s *VisualizationService) GetVisualTopology(ctx context.Context, rootDevice
 	})
 }
 
-// GetSimpleVisualTopology returns a simplified visual topology without grouping for hierarchical display
-func (s *VisualizationService) GetSimpleVisualTopology(ctx context.Context, rootDeviceID string, depth int) (*visualization.VisualTopology, error) {
+// GetSimpleVisualTopology returns a simplified visual topology without grouping for hierarchical display.
+// filter restricts the devices around the root.
+func (s *VisualizationService) GetSimpleVisualTopology(ctx context.Context, rootDeviceID string, depth int, filter topology.SubTopologyFilter) (*visualization.VisualTopology, error) {
 	if depth <= 0 {
 		depth = 3
 	}
@@ -38,17 +116,23 @@ func (s *VisualizationService) GetSimpleVisualTopology(ctx context.Context, root
 		return nil, fmt.Errorf("failed to get root device: %w", err)
 	}
 	if rootDevice == nil {
-		return nil, fmt.Errorf("root device %s not found", rootDeviceID)
+		return nil, fmt.Errorf("%w: %s", ErrRootDeviceNotFound, rootDeviceID)
 	}
 
 	// サブトポロジー抽出
 	devices, links, err := s.topologyRepo.ExtractSubTopology(ctx, rootDeviceID, topology.SubTopologyOptions{
 		Radius: depth,
+		Filter: filter,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract sub-topology: %w", err)
 	}
 
+	displayNames, err := s.displayNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// デバイスマップ作成（レイヤー情報の参照用）
 	deviceMap := make(map[string]topology.Device)
 	for _, device := range devices {
@@ -61,25 +145,28 @@ func (s *VisualizationService) GetSimpleVisualTopology(ctx context.Context, root
 
 	for _, device := range devices {
 		// 接続分類を追加
-		connections := s.classifyConnections(ctx, device.ID, deviceMap, links)
+		connections := s.classifyConnections(ctx, device.ID, deviceMap, links, displayNames)
 		
 		visualNode := visualization.VisualNode{
-			ID:          device.ID,
-			Name:        device.ID,
-			Type:        device.Type,
-			Hardware:    device.Hardware,
-			Status:      "active", // default status since status field removed
-			Layer:       s.getDeviceLayer(device.LayerID),
-			IsRoot:      device.ID == rootDeviceID,
-			Position:    visualization.Position{X: 0, Y: 0}, // レイアウト計算で後から設定
-			Style:       s.getNodeStyle(device.Type, "active", device.ID == rootDeviceID),
-			Connections: connections, // 新しい接続分類情報
+			ID:            device.ID,
+			Name:          displayNames.Resolve(device.ID),
+			Type:          device.Type,
+			Hardware:      device.Hardware,
+			Status:        "active", // default status since status field removed
+			Layer:         s.getDeviceLayer(device.LayerID),
+			WorkflowState: topology.EffectiveWorkflowState(device),
+			IsRoot:        device.ID == rootDeviceID,
+			Position:      visualization.Position{X: 0, Y: 0}, // レイアウト計算で後から設定
+			Style:         s.deviceNodeStyle(device, device.ID == rootDeviceID),
+			Connections:   connections, // 新しい接続分類情報
+			Metadata:      device.Metadata,
 		}
 		visualNodes = append(visualNodes, visualNode)
 		nodeMap[device.ID] = &visualNode
 	}
 
 	// シンプルなビジュアルエッジ作成
+	asymmetricLinks := asymmetricLinkIDs(links)
 	visualEdges := make([]visualization.VisualEdge, 0, len(links))
 	for _, link := range links {
 		// 両方のノードが存在することを確認
@@ -97,11 +184,17 @@ func (s *VisualizationService) GetSimpleVisualTopology(ctx context.Context, root
 				Weight:         link.Weight,
 				Style:          s.getEdgeStyle("active", link.Weight),
 				ConnectionType: connectionType, // 新しい接続タイプ情報
+				Metadata:       link.Metadata,
 			}
+			s.markAsymmetric(&visualEdge, asymmetricLinks)
 			visualEdges = append(visualEdges, visualEdge)
 		}
 	}
 
+	if err := s.applyStyleRules(ctx, visualNodes, visualEdges); err != nil {
+		return nil, err
+	}
+
 	// シンプルなレイアウト計算（階層ベース）
 	s.calculateHierarchicalLayout(visualNodes, visualEdges, rootDeviceID)
 
@@ -116,6 +209,17 @@ func (s *VisualizationService) GetSimpleVisualTopology(ctx context.Context, root
 }
 
 func (s *VisualizationService) GetVisualTopologyWithGrouping(ctx context.Context, rootDeviceID string, depth int, groupingOpts visualization.GroupingOptions) (*visualization.VisualTopology, error) {
+	return s.buildVisualTopology(ctx, rootDeviceID, depth, groupingOpts, topology.SubTopologyFilter{}, true)
+}
+
+// GetFilteredVisualTopology is GetVisualTopologyWithGrouping restricted to the devices that pass filter.
+// Filtered views bypass the layout cache.
+func (s *VisualizationService) GetFilteredVisualTopology(ctx context.Context, rootDeviceID string, depth int, groupingOpts visualization.GroupingOptions, filter topology.SubTopologyFilter) (*visualization.VisualTopology, error) {
+	return s.buildVisualTopology(ctx, rootDeviceID, depth, groupingOpts, filter, true)
+}
+
+// buildVisualTopology builds a grouped topology; useCache=false always recomputes the layout
+func (s *VisualizationService) buildVisualTopology(ctx context.Context, rootDeviceID string, depth int, groupingOpts visualization.GroupingOptions, filter topology.SubTopologyFilter, useCache bool) (*visualization.VisualTopology, error) {
 	if depth <= 0 {
 		depth = 3
 	}
@@ -126,41 +230,46 @@ func (s *VisualizationService) GetVisualTopologyWithGrouping(ctx context.Context
 		return nil, fmt.Errorf("failed to get root device: %w", err)
 	}
 	if rootDevice == nil {
-		return nil, fmt.Errorf("root device %s not found", rootDeviceID)
+		return nil, fmt.Errorf("%w: %s", ErrRootDeviceNotFound, rootDeviceID)
 	}
 
-	// 最適化されたサブトポロジー抽出を使用
+	// 最適化されたサブトポロジー抽出を使用（絞り込みもリポジトリ側で行う）
 	devices, links, err := s.topologyRepo.ExtractSubTopology(ctx, rootDeviceID, topology.SubTopologyOptions{
 		Radius: depth,
+		Filter: filter,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract sub-topology: %w", err)
 	}
 
+	displayNames, err := s.displayNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// 可視化用のノードとエッジに変換
 	visualNodes := make([]visualization.VisualNode, 0, len(devices))
 	nodeMap := make(map[string]*visualization.VisualNode)
-	deviceDepthMap := make(map[string]int)
-
-	// ルートからの距離を計算
-	deviceDepthMap = s.calculateDeviceDepths(devices, links, rootDeviceID)
 
 	for _, device := range devices {
 		visualNode := visualization.VisualNode{
-			ID:       device.ID,
-			Name:     device.ID, // IDをNameとして使用
-			Type:     device.Type,
-			Hardware: device.Hardware,
-			Status:   "active", // default status since status field removed
-			Layer:    s.getDeviceLayer(device.LayerID),
-			IsRoot:   device.ID == rootDeviceID,
-			Position: visualization.Position{X: 0, Y: 0}, // レイアウト計算で後から設定
-			Style:    s.getNodeStyle(device.Type, "active", device.ID == rootDeviceID),
+			ID:            device.ID,
+			Name:          displayNames.Resolve(device.ID),
+			Type:          device.Type,
+			Hardware:      device.Hardware,
+			Status:        "active", // default status since status field removed
+			Layer:         s.getDeviceLayer(device.LayerID),
+			WorkflowState: topology.EffectiveWorkflowState(device),
+			IsRoot:        device.ID == rootDeviceID,
+			Position:      visualization.Position{X: 0, Y: 0}, // レイアウト計算で後から設定
+			Style:         s.deviceNodeStyle(device, device.ID == rootDeviceID),
+			Metadata:      device.Metadata,
 		}
 		visualNodes = append(visualNodes, visualNode)
 		nodeMap[device.ID] = &visualNode
 	}
 
+	asymmetricLinks := asymmetricLinkIDs(links)
 	visualEdges := make([]visualization.VisualEdge, 0, len(links))
 	for _, link := range links {
 		// 両方のノードが存在することを確認
@@ -174,37 +283,45 @@ func (s *VisualizationService) GetVisualTopologyWithGrouping(ctx context.Context
 				Status:     "active", // default status since status field removed
 				Weight:     link.Weight,
 				Style:      s.getEdgeStyle("active", link.Weight),
+				Metadata:   link.Metadata,
 			}
+			s.markAsymmetric(&visualEdge, asymmetricLinks)
 			visualEdges = append(visualEdges, visualEdge)
 		}
 	}
 
+	// グループ化の前に適用し、グループへ集約したエッジにもスタイルを引き継ぐ
+	if err := s.applyStyleRules(ctx, visualNodes, visualEdges); err != nil {
+		return nil, err
+	}
+
 	// グルーピング処理
 	var groups []visualization.GroupedVisualNode
 	if groupingOpts.Enabled {
-		groups = s.createGroups(visualNodes, visualEdges, deviceDepthMap, groupingOpts)
+		// ルートからの距離はグループ判定と非表示ノードの判定で共有する
+		graph := visualization.NewDepthGraphFromEdges(visualEdges)
+		groups = s.createGroups(visualNodes, visualEdges, graph.Depths(rootDeviceID), groupingOpts)
 		// グループ化されたノードを除外し、グループノードを追加
-		visualNodes, visualEdges = s.applyGrouping(visualNodes, visualEdges, groups, rootDeviceID)
+		visualNodes, visualEdges = s.applyGrouping(visualNodes, visualEdges, graph, groups, rootDeviceID)
 	}
 
-	// レイアウト計算
-	layout := s.calculateLayout(visualNodes, visualEdges, rootDeviceID)
-
-	// 統計情報の計算
-	layerStats := make(map[string]int)
-	for _, node := range visualNodes {
-		layerKey := fmt.Sprintf("%d", node.Layer)
-		layerStats[layerKey]++
+	// 重いレイアウト計算の前にキャンセル済みでないか確認
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	stats := visualization.TopologyStats{
-		TotalNodes:  len(visualNodes),
-		TotalEdges:  len(visualEdges),
-		TotalGroups: len(groups),
-		Layers:      layerStats,
-		Generated:   time.Now(),
+	// レイアウト計算（キャッシュ済みならそれを使用）
+	var layout visualization.Layout
+	if filter.IsEmpty() {
+		layout = s.cachedLayout(ctx, visualization.NewViewKey(rootDeviceID, depth, groupingOpts), visualNodes, visualEdges, useCache)
+	} else {
+		// ビューキーにフィルタを含まないため、絞り込んだビューはキャッシュしない
+		layout = s.calculateLayout(visualNodes, visualEdges, rootDeviceID)
 	}
 
+	// 統計情報の計算
+	stats := visualization.NewTopologyStats(visualNodes, visualEdges, groups, time.Now())
+
 	return &visualization.VisualTopology{
 		RootDevice: rootDeviceID,
 		Depth:      depth,
@@ -217,6 +334,65 @@ func (s *VisualizationService) GetVisualTopologyWithGrouping(ctx context.Context
 	}, nil
 }
 
+// PreviewGroups returns only the groups that would be formed for groupingOpts,
+// skipping node/edge construction and layout so grouping options can be tuned cheaply
+func (s *VisualizationService) PreviewGroups(ctx context.Context, rootDeviceID string, depth int, groupingOpts visualization.GroupingOptions) (*visualization.GroupingPreview, error) {
+	if depth <= 0 {
+		depth = 3
+	}
+
+	rootDevice, err := s.topologyRepo.GetDevice(ctx, rootDeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get root device: %w", err)
+	}
+	if rootDevice == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRootDeviceNotFound, rootDeviceID)
+	}
+
+	devices, links, err := s.topologyRepo.ExtractSubTopology(ctx, rootDeviceID, topology.SubTopologyOptions{
+		Radius: depth,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract sub-topology: %w", err)
+	}
+
+	// グルーピング判定に必要な項目のみ設定
+	nodes := make([]visualization.VisualNode, 0, len(devices))
+	for _, device := range devices {
+		nodes = append(nodes, visualization.VisualNode{
+			ID:     device.ID,
+			Name:   device.ID,
+			Type:   device.Type,
+			IsRoot: device.ID == rootDeviceID,
+		})
+	}
+
+	deviceDepthMap := s.calculateDeviceDepths(devices, links, rootDeviceID)
+	groups := s.createGroups(nodes, nil, deviceDepthMap, groupingOpts)
+
+	preview := &visualization.GroupingPreview{
+		RootDevice:   rootDeviceID,
+		Depth:        depth,
+		TotalDevices: len(devices),
+		Groups:       make([]visualization.GroupPreview, 0, len(groups)),
+	}
+	grouped := make(map[string]bool)
+	for _, group := range groups {
+		preview.Groups = append(preview.Groups, visualization.GroupPreview{
+			GroupType: group.GroupType,
+			Prefix:    group.Prefix,
+			Count:     group.Count,
+			DeviceIDs: group.DeviceIDs,
+		})
+		for _, id := range group.DeviceIDs {
+			grouped[id] = true
+		}
+	}
+	preview.GroupedDevices = len(grouped)
+
+	return preview, nil
+}
+
 func (s *VisualizationService) exploreTopology(ctx context.Context, rootDeviceID string, rootLayer, depth int) ([]topology.Device, []topology.Link, error) {
 	visited := make(map[string]bool)
 	deviceMap := make(map[string]topology.Device)
@@ -237,6 +413,11 @@ func (s *VisualizationService) exploreTopology(ctx context.Context, rootDeviceID
 
 		visited[current.deviceID] = true
 
+		// キャンセルされたリクエストのために探索を続けない
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
 		// デバイス情報を取得
 		device, err := s.topologyRepo.GetDevice(ctx, current.deviceID)
 		if err != nil {
@@ -265,6 +446,9 @@ func (s *VisualizationService) exploreTopology(ctx context.Context, rootDeviceID
 			// 隣接デバイス情報を取得
 			neighbor, err := s.topologyRepo.GetDevice(ctx, neighborID)
 			if err != nil {
+				if ctx.Err() != nil {
+					return nil, nil, ctx.Err()
+				}
 				continue
 			}
 			if neighbor == nil {
@@ -367,6 +551,29 @@ func (s *VisualizationService) getNodeStyle(deviceType, status string, isRoot bo
 	return style
 }
 
+// deviceNodeStyle returns the node style of device, marking devices by their workflow state: gear
+// being turned up gets a dashed border, quarantined devices a thick red border and decommissioned
+// devices are greyed out
+func (s *VisualizationService) deviceNodeStyle(device topology.Device, isRoot bool) visualization.NodeStyle {
+	style := s.getNodeStyle(device.Type, "active", isRoot)
+
+	switch topology.EffectiveWorkflowState(device) {
+	case topology.WorkflowOnboarding:
+		style.BorderStyle = "dashed"
+		style.BorderColor = "#f39c12"
+		style.BorderWidth = 3
+	case topology.WorkflowQuarantined:
+		style.BorderColor = "#e74c3c"
+		style.BorderWidth = 4
+	case topology.WorkflowDecommissioned:
+		style.Color = "#dfe6e9"
+		style.BorderColor = "#b2bec3"
+		style.BorderStyle = "dashed"
+	}
+
+	return style
+}
+
 func (s *VisualizationService) getEdgeStyle(status string, weight float64) visualization.EdgeStyle {
 	style := visualization.EdgeStyle{
 		Width:     2,
@@ -393,6 +600,38 @@ func (s *VisualizationService) getEdgeStyle(status string, weight float64) visua
 	return style
 }
 
+// applyStyleRules overrides the styles of the nodes and edges matching the stored style rules
+func (s *VisualizationService) applyStyleRules(ctx context.Context, nodes []visualization.VisualNode, edges []visualization.VisualEdge) error {
+	if s.styleRepo == nil {
+		return nil
+	}
+	rules, err := s.styleRepo.ListStyleRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list style rules: %w", err)
+	}
+	visualization.ApplyStyleRules(nodes, edges, rules)
+	return nil
+}
+
+// asymmetricLinkIDs returns the IDs of links observed from only one side
+func asymmetricLinkIDs(links []topology.Link) map[string]bool {
+	ids := make(map[string]bool)
+	for _, asymmetric := range topology.FindAsymmetricLinks(links) {
+		ids[asymmetric.Link.ID] = true
+	}
+	return ids
+}
+
+// markAsymmetric flags an edge seen from only one side and draws it dashed
+func (s *VisualizationService) markAsymmetric(edge *visualization.VisualEdge, asymmetricLinks map[string]bool) {
+	if !asymmetricLinks[edge.ID] {
+		return
+	}
+	edge.Asymmetric = true
+	edge.Style.Color = "#f39c12"
+	edge.Style.LineStyle = "dashed"
+}
+
 func (s *VisualizationService) calculateLayout(nodes []visualization.VisualNode, edges []visualization.VisualEdge, rootDeviceID string) visualization.Layout {
 	// 基本的な階層レイアウトを実装
 	positions := make(map[string]visualization.Position)
@@ -407,21 +646,27 @@ func (s *VisualizationService) calculateLayout(nodes []visualization.VisualNode,
 	layerY := 0.0
 	layerSpacing := 150.0
 
-	for layer := 0; layer <= 10; layer++ { // 最大10階層まで
-		if nodesInLayer, exists := layerNodes[layer]; exists {
-			nodeSpacing := 200.0
-			totalWidth := float64(len(nodesInLayer)-1) * nodeSpacing
-			startX := -totalWidth / 2
-
-			for i, node := range nodesInLayer {
-				x := startX + float64(i)*nodeSpacing
-				positions[node.ID] = visualization.Position{
-					X: x,
-					Y: layerY,
-				}
+	// 階層IDは 10, 20, ... のように飛び番のため、存在する階層を昇順に配置する
+	layers := make([]int, 0, len(layerNodes))
+	for layer := range layerNodes {
+		layers = append(layers, layer)
+	}
+	sort.Ints(layers)
+
+	for _, layer := range layers {
+		nodesInLayer := layerNodes[layer]
+		nodeSpacing := 200.0
+		totalWidth := float64(len(nodesInLayer)-1) * nodeSpacing
+		startX := -totalWidth / 2
+
+		for i, node := range nodesInLayer {
+			x := startX + float64(i)*nodeSpacing
+			positions[node.ID] = visualization.Position{
+				X: x,
+				Y: layerY,
 			}
-			layerY += layerSpacing
 		}
+		layerY += layerSpacing
 	}
 
 	return visualization.Layout{
@@ -434,44 +679,170 @@ func (s *VisualizationService) calculateLayout(nodes []visualization.VisualNode,
 	}
 }
 
-// calculateDeviceDepths calculates the depth of each device from the root
-func (s *VisualizationService) calculateDeviceDepths(devices []topology.Device, links []topology.Link, rootDeviceID string) map[string]int {
-	depthMap := make(map[string]int)
-	visited := make(map[string]bool)
+// cachedLayout returns the cached layout of the view when its node set is unchanged,
+// otherwise computes the layout and stores it. Cache failures never fail the request.
+func (s *VisualizationService) cachedLayout(ctx context.Context, key visualization.ViewKey, nodes []visualization.VisualNode, edges []visualization.VisualEdge, useCache bool) visualization.Layout {
+	if s.layoutCache == nil {
+		return s.calculateLayout(nodes, edges, key.RootDevice)
+	}
 
-	// Build adjacency list
-	adjList := make(map[string][]string)
-	for _, link := range links {
-		adjList[link.SourceID] = append(adjList[link.SourceID], link.TargetID)
-		adjList[link.TargetID] = append(adjList[link.TargetID], link.SourceID)
+	hash := layoutInputHash(nodes, edges)
+	if useCache {
+		// 人気ビューの集計はメモリに溜め、FlushViewRequests でまとめて書き込む
+		s.countViewRequest(key, time.Now())
+
+		if cached, err := s.layoutCache.GetCachedLayout(ctx, key); err == nil && cached != nil && cached.NodeSetHash == hash {
+			layout := cached.Layout
+			if layout.Options == nil {
+				layout.Options = make(map[string]interface{})
+			}
+			layout.Options["cached"] = true
+			layout.Options["computed_at"] = cached.ComputedAt
+			return layout
+		}
 	}
 
-	// BFS to calculate depths
-	queue := []struct {
-		deviceID string
-		depth    int
-	}{{rootDeviceID, 0}}
+	layout := s.calculateLayout(nodes, edges, key.RootDevice)
+	_ = s.layoutCache.SaveCachedLayout(ctx, visualization.CachedLayout{
+		ViewKey:     key,
+		NodeSetHash: hash,
+		Layout:      layout,
+		ComputedAt:  time.Now(),
+	})
+	return layout
+}
+
+// layoutInputHash identifies what a layout was computed from: the nodes with their layers and the
+// edges between them. Links added or removed within the same node set invalidate the layout too.
+func layoutInputHash(nodes []visualization.VisualNode, edges []visualization.VisualEdge) string {
+	nodeKeys := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		nodeKeys = append(nodeKeys, fmt.Sprintf("%s\x00%d", node.ID, node.Layer))
+	}
+	sort.Strings(nodeKeys)
 
-	depthMap[rootDeviceID] = 0
-	visited[rootDeviceID] = true
+	edgeKeys := make([]string, 0, len(edges))
+	for _, edge := range edges {
+		edgeKeys = append(edgeKeys, edge.ID+"\x00"+edge.Source+"\x00"+edge.Target)
+	}
+	sort.Strings(edgeKeys)
 
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
+	h := sha256.New()
+	for _, key := range nodeKeys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+	}
+	// ノードとエッジの区切り
+	h.Write([]byte{1})
+	for _, key := range edgeKeys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
 
-		for _, neighborID := range adjList[current.deviceID] {
-			if !visited[neighborID] {
-				visited[neighborID] = true
-				depthMap[neighborID] = current.depth + 1
-				queue = append(queue, struct {
-					deviceID string
-					depth    int
-				}{neighborID, current.depth + 1})
+// countViewRequest buffers a request of the view made at
+func (s *VisualizationService) countViewRequest(key visualization.ViewKey, at time.Time) {
+	s.viewRequestsMu.Lock()
+	defer s.viewRequestsMu.Unlock()
+
+	if s.viewRequests == nil {
+		s.viewRequests = make(map[visualization.ViewKey]viewRequestCount)
+	}
+	counted := s.viewRequests[key]
+	counted.count++
+	counted.last = at
+	s.viewRequests[key] = counted
+}
+
+// FlushViewRequests writes the view requests buffered since the last flush, one write per view,
+// and returns how many views were written. Counts that fail to be written are kept for the next flush.
+func (s *VisualizationService) FlushViewRequests(ctx context.Context) (int, error) {
+	if s.layoutCache == nil {
+		return 0, nil
+	}
+
+	s.viewRequestsMu.Lock()
+	pending := s.viewRequests
+	s.viewRequests = nil
+	s.viewRequestsMu.Unlock()
+
+	written := 0
+	var firstErr error
+	for key, counted := range pending {
+		if firstErr == nil {
+			if err := s.layoutCache.RecordViewRequests(ctx, key, counted.count, counted.last); err != nil {
+				firstErr = err
+			} else {
+				written++
+				continue
 			}
 		}
+		s.restoreViewRequests(key, counted)
+	}
+	return written, firstErr
+}
+
+// restoreViewRequests puts counts that could not be written back into the buffer
+func (s *VisualizationService) restoreViewRequests(key visualization.ViewKey, counted viewRequestCount) {
+	s.viewRequestsMu.Lock()
+	defer s.viewRequestsMu.Unlock()
+
+	if s.viewRequests == nil {
+		s.viewRequests = make(map[visualization.ViewKey]viewRequestCount)
+	}
+	current := s.viewRequests[key]
+	current.count += counted.count
+	if counted.last.After(current.last) {
+		current.last = counted.last
+	}
+	s.viewRequests[key] = current
+}
+
+// PrecomputePopularLayouts recomputes and stores the layouts of the views requested most since the given time.
+// It returns the number of layouts stored.
+func (s *VisualizationService) PrecomputePopularLayouts(ctx context.Context, since time.Time, limit int) (int, error) {
+	if s.layoutCache == nil {
+		return 0, nil
+	}
+
+	views, err := s.layoutCache.ListPopularViews(ctx, since, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list popular views: %w", err)
+	}
+
+	computed := 0
+	for _, view := range views {
+		if err := ctx.Err(); err != nil {
+			return computed, err
+		}
+
+		opts, err := view.GroupingOptions()
+		if err != nil {
+			continue // 古い形式のオプションは無視
+		}
+		if _, err := s.buildVisualTopology(ctx, view.RootDevice, view.Depth, opts, topology.SubTopologyFilter{}, false); err != nil {
+			// 削除されたルートデバイスなどはスキップ
+			continue
+		}
+		computed++
 	}
 
-	return depthMap
+	return computed, nil
+}
+
+// calculateDeviceDepths calculates the depth of each device from the root
+func (s *VisualizationService) calculateDeviceDepths(devices []topology.Device, links []topology.Link, rootDeviceID string) map[string]int {
+	return linkDepthGraph(links).Depths(rootDeviceID)
+}
+
+// linkDepthGraph indexes links for depth calculation
+func linkDepthGraph(links []topology.Link) *visualization.DepthGraph {
+	graph := visualization.NewDepthGraph()
+	for _, link := range links {
+		graph.AddEdge(link.SourceID, link.TargetID)
+	}
+	return graph
 }
 
 // createGroups creates groups based on grouping options
@@ -499,8 +870,9 @@ func (s *VisualizationService) createGroups(nodes []visualization.VisualNode, ed
 		deviceNames := make([]string, len(candidateNodes))
 		deviceNodeMap := make(map[string]visualization.VisualNode)
 		for i, node := range candidateNodes {
-			deviceNames[i] = node.Name
-			deviceNodeMap[node.Name] = node
+			// 表示名ではなく安定したIDのプレフィックスでグループ化
+			deviceNames[i] = node.ID
+			deviceNodeMap[node.ID] = node
 		}
 
 		prefixGroups := grouping.GroupByLongestCommonPrefix(deviceNames, opts.MinGroupSize)
@@ -575,8 +947,9 @@ func (s *VisualizationService) createGroups(nodes []visualization.VisualNode, ed
 	return groups
 }
 
-// applyGrouping applies grouping by removing grouped nodes and adding group nodes
-func (s *VisualizationService) applyGrouping(nodes []visualization.VisualNode, edges []visualization.VisualEdge, groups []visualization.GroupedVisualNode, rootDeviceID string) ([]visualization.VisualNode, []visualization.VisualEdge) {
+// applyGrouping applies grouping by removing grouped nodes and adding group nodes.
+// graph is the depth graph of edges.
+func (s *VisualizationService) applyGrouping(nodes []visualization.VisualNode, edges []visualization.VisualEdge, graph *visualization.DepthGraph, groups []visualization.GroupedVisualNode, rootDeviceID string) ([]visualization.VisualNode, []visualization.VisualEdge) {
 	if len(groups) == 0 {
 		return nodes, edges
 	}
@@ -591,7 +964,7 @@ func (s *VisualizationService) applyGrouping(nodes []visualization.VisualNode, e
 
 	// グループ化されたノードの先のノードも特定
 	nodesAfterGroups := make(map[string]bool)
-	s.findNodesAfterGroups(nodes, edges, groupedDeviceIDs, nodesAfterGroups, rootDeviceID)
+	s.findNodesAfterGroups(nodes, graph, groupedDeviceIDs, nodesAfterGroups, rootDeviceID)
 
 	// グループ化されないノードを保持（グループの先のノードも除外、ただしルートノードは除外しない）
 	filteredNodes := make([]visualization.VisualNode, 0)
@@ -625,7 +998,7 @@ func (s *VisualizationService) applyGrouping(nodes []visualization.VisualNode, e
 
 	// シンプルなエッジ変換アプローチ
 	filteredEdges := make([]visualization.VisualEdge, 0)
-	edgeIDMap := make(map[string]bool) // 重複エッジを防ぐ
+	edgeIndex := make(map[string]int) // 並行リンクは同じグループエッジにまとめる
 
 	fmt.Printf("Processing %d edges for grouping\n", len(edges))
 
@@ -648,19 +1021,11 @@ func (s *VisualizationService) applyGrouping(nodes []visualization.VisualNode, e
 			groupID := s.findGroupIDForDevice(edge.Source, groups)
 			if groupID != "" {
 				newEdgeID := fmt.Sprintf("%s-%s", groupID, edge.Target)
-				if !edgeIDMap[newEdgeID] {
-					newEdge := visualization.VisualEdge{
-						ID:         newEdgeID,
-						Source:     groupID,
-						Target:     edge.Target,
-						LocalPort:  "group",
-						RemotePort: edge.RemotePort,
-						Status:     edge.Status,
-						Weight:     edge.Weight,
-						Style:      edge.Style,
-					}
-					filteredEdges = append(filteredEdges, newEdge)
-					edgeIDMap[newEdgeID] = true
+				if i, ok := edgeIndex[newEdgeID]; ok {
+					filteredEdges[i].AddGroupMember(edge)
+				} else {
+					edgeIndex[newEdgeID] = len(filteredEdges)
+					filteredEdges = append(filteredEdges, visualization.NewGroupEdge(newEdgeID, groupID, edge.Target, edge))
 					fmt.Printf("  Created group edge: %s->%s\n", groupID, edge.Target)
 				}
 			}
@@ -672,26 +1037,31 @@ func (s *VisualizationService) applyGrouping(nodes []visualization.VisualNode, e
 			groupID := s.findGroupIDForDevice(edge.Target, groups)
 			if groupID != "" {
 				newEdgeID := fmt.Sprintf("%s-%s", edge.Source, groupID)
-				if !edgeIDMap[newEdgeID] {
-					newEdge := visualization.VisualEdge{
-						ID:         newEdgeID,
-						Source:     edge.Source,
-						Target:     groupID,
-						LocalPort:  edge.LocalPort,
-						RemotePort: "group",
-						Status:     edge.Status,
-						Weight:     edge.Weight,
-						Style:      edge.Style,
-					}
-					filteredEdges = append(filteredEdges, newEdge)
-					edgeIDMap[newEdgeID] = true
+				if i, ok := edgeIndex[newEdgeID]; ok {
+					filteredEdges[i].AddGroupMember(edge)
+				} else {
+					edgeIndex[newEdgeID] = len(filteredEdges)
+					filteredEdges = append(filteredEdges, visualization.NewGroupEdge(newEdgeID, edge.Source, groupID, edge))
 					fmt.Printf("  Created group edge: %s->%s\n", edge.Source, groupID)
 				}
 			}
 			continue
 		}
 
-		// Case 4: 両方がグループ化 → 内部エッジなので除外
+		// Case 4: 両方がグループ化 → 別グループ間ならグループ->グループのエッジ作成、同じグループなら内部エッジなので除外
+		sourceGroupID := s.findGroupIDForDevice(edge.Source, groups)
+		targetGroupID := s.findGroupIDForDevice(edge.Target, groups)
+		if sourceGroupID != "" && targetGroupID != "" && sourceGroupID != targetGroupID {
+			newEdgeID := fmt.Sprintf("%s-%s", sourceGroupID, targetGroupID)
+			if i, ok := edgeIndex[newEdgeID]; ok {
+				filteredEdges[i].AddGroupMember(edge)
+			} else {
+				edgeIndex[newEdgeID] = len(filteredEdges)
+				filteredEdges = append(filteredEdges, visualization.NewGroupEdge(newEdgeID, sourceGroupID, targetGroupID, edge))
+				fmt.Printf("  Created group edge: %s->%s\n", sourceGroupID, targetGroupID)
+			}
+			continue
+		}
 		fmt.Printf("  Skipped internal edge\n")
 	}
 
@@ -749,26 +1119,9 @@ func (s *VisualizationService) findGroupIDForDevice(deviceID string, groups []vi
 }
 
 // findNodesAfterGroups identifies nodes that are only reachable through grouped nodes
-func (s *VisualizationService) findNodesAfterGroups(nodes []visualization.VisualNode, edges []visualization.VisualEdge, groupedDeviceIDs map[string]bool, nodesAfterGroups map[string]bool, rootDeviceID string) {
-	// Convert VisualEdge to Link for calculateDeviceDepths
-	links := make([]topology.Link, len(edges))
-	for i, edge := range edges {
-		links[i] = topology.Link{
-			SourceID: edge.Source,
-			TargetID: edge.Target,
-		}
-	}
-
-	// Convert VisualNode to Device for calculateDeviceDepths
-	devices := make([]topology.Device, len(nodes))
-	for i, node := range nodes {
-		devices[i] = topology.Device{
-			ID: node.ID,
-		}
-	}
-
-	// Create a map of node depths from root
-	deviceDepthMap := s.calculateDeviceDepths(devices, links, rootDeviceID)
+func (s *VisualizationService) findNodesAfterGroups(nodes []visualization.VisualNode, graph *visualization.DepthGraph, groupedDeviceIDs map[string]bool, nodesAfterGroups map[string]bool, rootDeviceID string) {
+	// Depths from root (memoized when the topology was built)
+	deviceDepthMap := graph.Depths(rootDeviceID)
 
 	// Find the maximum depth of grouped devices
 	maxGroupDepth := 0
@@ -784,7 +1137,7 @@ func (s *VisualizationService) findNodesAfterGroups(nodes []visualization.Visual
 		if !groupedDeviceIDs[node.ID] && !node.IsRoot {
 			if nodeDepth, exists := deviceDepthMap[node.ID]; exists && nodeDepth > maxGroupDepth {
 				// Check if this node is only reachable through grouped nodes
-				if s.isOnlyReachableThroughGroups(node.ID, edges, groupedDeviceIDs) {
+				if s.isOnlyReachableThroughGroups(node.ID, graph, groupedDeviceIDs) {
 					nodesAfterGroups[node.ID] = true
 				}
 			}
@@ -793,16 +1146,8 @@ func (s *VisualizationService) findNodesAfterGroups(nodes []visualization.Visual
 }
 
 // isOnlyReachableThroughGroups checks if a node can only be reached through grouped nodes
-func (s *VisualizationService) isOnlyReachableThroughGroups(nodeID string, edges []visualization.VisualEdge, groupedDeviceIDs map[string]bool) bool {
-	// Find all direct neighbors of this node
-	neighbors := make([]string, 0)
-	for _, edge := range edges {
-		if edge.Source == nodeID {
-			neighbors = append(neighbors, edge.Target)
-		} else if edge.Target == nodeID {
-			neighbors = append(neighbors, edge.Source)
-		}
-	}
+func (s *VisualizationService) isOnlyReachableThroughGroups(nodeID string, graph *visualization.DepthGraph, groupedDeviceIDs map[string]bool) bool {
+	neighbors := graph.Neighbors(nodeID)
 
 	// If all neighbors are grouped devices, then this node is only reachable through groups
 	for _, neighbor := range neighbors {
@@ -834,6 +1179,10 @@ func (s *VisualizationService) ExpandGroupInTopology(
 
 	// グループ内のデバイスを出発点として探索
 	for _, deviceID := range groupDeviceIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, err
+		}
+
 		// デバイス自体を追加
 		device, err := s.topologyRepo.GetDevice(ctx, deviceID)
 		if err != nil || device == nil {
@@ -872,6 +1221,11 @@ func (s *VisualizationService) ExpandGroupInTopology(
 	}
 	deviceDepthMap = s.calculateDeviceDepths(allDevices, allLinks, rootDeviceID)
 
+	displayNames, err := s.displayNames(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	// 新しいノードを作成
 	fmt.Printf("ExpandGroupInTopology: Found %d expanded devices\n", len(expandedDevices))
 	for _, device := range expandedDevices {
@@ -880,15 +1234,17 @@ func (s *VisualizationService) ExpandGroupInTopology(
 		// 既存のトポロジーに含まれていないノードのみ追加
 		if !exists {
 			visualNode := visualization.VisualNode{
-				ID:       device.ID,
-				Name:     device.ID,
-				Type:     device.Type,
-				Hardware: device.Hardware,
-				Status:   "active", // default status since status field removed
-				Layer:    s.getDeviceLayer(device.LayerID),
-				IsRoot:   device.ID == rootDeviceID,
-				Position: visualization.Position{X: 0, Y: 0},
-				Style:    s.getNodeStyle(device.Type, "active", device.ID == rootDeviceID),
+				ID:            device.ID,
+				Name:          displayNames.Resolve(device.ID),
+				Type:          device.Type,
+				Hardware:      device.Hardware,
+				Status:        "active", // default status since status field removed
+				Layer:         s.getDeviceLayer(device.LayerID),
+				WorkflowState: topology.EffectiveWorkflowState(device),
+				IsRoot:        device.ID == rootDeviceID,
+				Position:      visualization.Position{X: 0, Y: 0},
+				Style:         s.deviceNodeStyle(device, device.ID == rootDeviceID),
+				Metadata:      device.Metadata,
 			}
 			newVisualNodes = append(newVisualNodes, visualNode)
 			fmt.Printf("Added new visual node: %s\n", device.ID)
@@ -910,11 +1266,16 @@ func (s *VisualizationService) ExpandGroupInTopology(
 				Status:     "active", // default status since status field removed
 				Weight:     link.Weight,
 				Style:      s.getEdgeStyle("active", link.Weight),
+				Metadata:   link.Metadata,
 			}
 			newVisualEdges = append(newVisualEdges, visualEdge)
 		}
 	}
 
+	if err := s.applyStyleRules(ctx, newVisualNodes, newVisualEdges); err != nil {
+		return nil, nil, nil, err
+	}
+
 	// 現在のトポロジーを更新
 	updatedTopology := currentTopology
 
@@ -969,7 +1330,8 @@ func (s *VisualizationService) ExpandGroupInTopology(
 			if len(newGroups) > 0 {
 				// 新しいグループを適用
 				fmt.Printf("Before recursive grouping: %d nodes\n", len(updatedTopology.Nodes))
-				groupedNodes, groupedEdges := s.applyGrouping(updatedTopology.Nodes, updatedTopology.Edges, newGroups, rootDeviceID)
+				graph := visualization.NewDepthGraphFromEdges(updatedTopology.Edges)
+				groupedNodes, groupedEdges := s.applyGrouping(updatedTopology.Nodes, updatedTopology.Edges, graph, newGroups, rootDeviceID)
 				updatedTopology.Nodes = groupedNodes
 				updatedTopology.Edges = groupedEdges
 				updatedTopology.Groups = append(updatedTopology.Groups, newGroups...)
@@ -979,19 +1341,110 @@ func (s *VisualizationService) ExpandGroupInTopology(
 	}
 
 	// 統計情報を更新
-	updatedTopology.Stats = visualization.TopologyStats{
-		TotalNodes:  len(updatedTopology.Nodes),
-		TotalEdges:  len(updatedTopology.Edges),
-		TotalGroups: len(updatedTopology.Groups),
-		Generated:   time.Now(),
-	}
+	updatedTopology.Stats = visualization.NewTopologyStats(updatedTopology.Nodes, updatedTopology.Edges, updatedTopology.Groups, time.Now())
 
-	// レイアウトを再計算
-	updatedTopology.Layout = s.calculateLayout(updatedTopology.Nodes, updatedTopology.Edges, rootDeviceID)
+	// 既存ノードの位置は維持し、新規ノードのみ展開したグループの位置周辺に配置
+	if len(currentTopology.Layout.Positions) > 0 {
+		updatedTopology.Layout, updatedTopology.LayoutPatch = s.patchLayout(currentTopology.Layout, updatedTopology.Nodes, groupID)
+	} else {
+		updatedTopology.Layout = s.calculateLayout(updatedTopology.Nodes, updatedTopology.Edges, rootDeviceID)
+	}
 
 	return &updatedTopology, newVisualNodes, newVisualEdges, nil
 }
 
+// ExpandGroup expands a group of the grouped topology rooted at rootDeviceID.
+// positions holds the node positions currently shown by the client; they take
+// precedence over the computed layout so that existing nodes do not move.
+func (s *VisualizationService) ExpandGroup(ctx context.Context, rootDeviceID, groupID string, depth, expandDepth int, groupingOpts visualization.GroupingOptions, positions map[string]visualization.Position) (*visualization.VisualTopology, error) {
+	currentTopology, err := s.GetVisualTopologyWithGrouping(ctx, rootDeviceID, depth, groupingOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupDeviceIDs []string
+	for _, group := range currentTopology.Groups {
+		if group.ID == groupID {
+			groupDeviceIDs = group.DeviceIDs
+			break
+		}
+	}
+	if groupDeviceIDs == nil {
+		return nil, fmt.Errorf("%w: %s", ErrGroupNotFound, groupID)
+	}
+
+	for id, position := range positions {
+		currentTopology.Layout.Positions[id] = position
+	}
+
+	updatedTopology, _, _, err := s.ExpandGroupInTopology(ctx, groupID, rootDeviceID, groupDeviceIDs, *currentTopology, groupingOpts, expandDepth)
+	if err != nil {
+		return nil, err
+	}
+	return updatedTopology, nil
+}
+
+// patchLayout keeps the positions of nodes already laid out and places new nodes
+// in a grid centered on the expanded group's position
+func (s *VisualizationService) patchLayout(previous visualization.Layout, nodes []visualization.VisualNode, anchorID string) (visualization.Layout, *visualization.LayoutPatch) {
+	const nodeSpacing = 120.0
+	const rowSpacing = 100.0
+
+	patch := &visualization.LayoutPatch{
+		AnchorID: anchorID,
+		Anchor:   previous.Positions[anchorID],
+		Added:    make(map[string]visualization.Position),
+		Removed:  []string{},
+	}
+
+	positions := make(map[string]visualization.Position, len(nodes))
+	present := make(map[string]bool, len(nodes))
+	var added []visualization.VisualNode
+	for _, node := range nodes {
+		present[node.ID] = true
+		if position, ok := previous.Positions[node.ID]; ok {
+			positions[node.ID] = position
+		} else {
+			added = append(added, node)
+		}
+	}
+
+	// 階層順に並べ、グループがあった位置を中心に行ごとに配置
+	sort.Slice(added, func(i, j int) bool {
+		if added[i].Layer != added[j].Layer {
+			return added[i].Layer < added[j].Layer
+		}
+		return added[i].ID < added[j].ID
+	})
+	perRow := int(math.Ceil(math.Sqrt(float64(len(added)))))
+	for i, node := range added {
+		row, col := i/perRow, i%perRow
+		rowCount := perRow
+		if remaining := len(added) - row*perRow; remaining < perRow {
+			rowCount = remaining
+		}
+		position := visualization.Position{
+			X: patch.Anchor.X + (float64(col)-float64(rowCount-1)/2)*nodeSpacing,
+			Y: patch.Anchor.Y + float64(row)*rowSpacing,
+		}
+		positions[node.ID] = position
+		patch.Added[node.ID] = position
+	}
+
+	for id := range previous.Positions {
+		if !present[id] {
+			patch.Removed = append(patch.Removed, id)
+		}
+	}
+	sort.Strings(patch.Removed)
+
+	return visualization.Layout{
+		Type:      previous.Type,
+		Options:   previous.Options,
+		Positions: positions,
+	}, patch
+}
+
 // exploreFromDevice explores topology from a specific device up to a given depth
 func (s *VisualizationService) exploreFromDevice(ctx context.Context, deviceID string, depth int) ([]topology.Device, []topology.Link, error) {
 	visited := make(map[string]bool)
@@ -1013,6 +1466,11 @@ func (s *VisualizationService) exploreFromDevice(ctx context.Context, deviceID s
 
 		visited[current.deviceID] = true
 
+		// エラーをスキップする探索なので、キャンセル時は明示的に中断
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
 		// デバイス情報を取得
 		device, err := s.topologyRepo.GetDevice(ctx, current.deviceID)
 		if err != nil || device == nil {
@@ -1096,7 +1554,7 @@ func (s *VisualizationService) getDeviceLayer(layerID *int) int {
 }
 
 // classifyConnections classifies device connections into uplinks, downlinks, and peers
-func (s *VisualizationService) classifyConnections(ctx context.Context, deviceID string, deviceMap map[string]topology.Device, links []topology.Link) *visualization.ConnectionClassification {
+func (s *VisualizationService) classifyConnections(ctx context.Context, deviceID string, deviceMap map[string]topology.Device, links []topology.Link, displayNames *topology.DisplayNameResolver) *visualization.ConnectionClassification {
 	device, exists := deviceMap[deviceID]
 	if !exists {
 		return &visualization.ConnectionClassification{}
@@ -1135,7 +1593,7 @@ func (s *VisualizationService) classifyConnections(ctx context.Context, deviceID
 		// 接続情報の構築
 		connInfo := visualization.ConnectionInfo{
 			DeviceID:        connectedDeviceID,
-			DeviceName:      connectedDevice.ID,
+			DeviceName:      displayNames.Resolve(connectedDevice.ID),
 			DeviceType:      connectedDevice.Type,
 			DeviceHardware:  connectedDevice.Hardware,
 			Layer:           connectedLayer,
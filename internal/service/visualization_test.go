@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/servak/topology-manager/internal/domain/topology"
 	"github.com/servak/topology-manager/internal/domain/visualization"
+	"github.com/servak/topology-manager/internal/testutil"
 )
 
 // MockTopologyRepository はテスト用のモックリポジトリ
@@ -46,6 +49,23 @@ func (m *MockTopologyRepository) ExtractSubTopology(ctx context.Context, rootDev
 	return devices, m.links, nil
 }
 
+func (m *MockTopologyRepository) GetDevices(ctx context.Context, opts topology.PaginationOptions) ([]topology.Device, *topology.PaginationResult, error) {
+	devices := make([]topology.Device, 0, len(m.devices))
+	for _, device := range m.devices {
+		devices = append(devices, *device)
+	}
+	return devices, &topology.PaginationResult{TotalCount: len(devices), TotalPages: 1, Page: 1, PageSize: len(devices)}, nil
+}
+
+func (m *MockTopologyRepository) GetLink(ctx context.Context, linkID string) (*topology.Link, error) {
+	for _, link := range m.links {
+		if link.ID == linkID {
+			return &link, nil
+		}
+	}
+	return nil, nil
+}
+
 func (m *MockTopologyRepository) GetDeviceLinks(ctx context.Context, deviceID string) ([]topology.Link, error) {
 	links := make([]topology.Link, 0)
 	for _, link := range m.links {
@@ -87,16 +107,14 @@ func createTestTopology() *MockTopologyRepository {
 		ID:       "core-001",
 		Type:     "core",
 		Hardware: "Arista DCS-7280SR-48C6",
-		Status:   "up",
-		Layer:    3,
+		LayerID:  intPtr(3),
 	})
 
 	repo.AddDevice(&topology.Device{
 		ID:       "core-002",
 		Type:     "core",
 		Hardware: "Arista DCS-7280SR-48C6",
-		Status:   "up",
-		Layer:    3,
+		LayerID:  intPtr(3),
 	})
 
 	// 同じプレフィックスを持つdist デバイス群（グループ化されるはず）
@@ -104,24 +122,21 @@ func createTestTopology() *MockTopologyRepository {
 		ID:       "dist-100",
 		Type:     "distribution",
 		Hardware: "Juniper QFX5100",
-		Status:   "up",
-		Layer:    4,
+		LayerID:  intPtr(4),
 	})
 
 	repo.AddDevice(&topology.Device{
 		ID:       "dist-101",
 		Type:     "distribution",
 		Hardware: "Juniper QFX5100",
-		Status:   "up",
-		Layer:    4,
+		LayerID:  intPtr(4),
 	})
 
 	repo.AddDevice(&topology.Device{
 		ID:       "dist-102",
 		Type:     "distribution",
 		Hardware: "Juniper QFX5100",
-		Status:   "up",
-		Layer:    4,
+		LayerID:  intPtr(4),
 	})
 
 	// 同じプレフィックスを持つaccess デバイス群（グループ化されるはず）
@@ -129,24 +144,21 @@ func createTestTopology() *MockTopologyRepository {
 		ID:       "access-001",
 		Type:     "access",
 		Hardware: "Cisco 2960X",
-		Status:   "up",
-		Layer:    5,
+		LayerID:  intPtr(5),
 	})
 
 	repo.AddDevice(&topology.Device{
 		ID:       "access-002",
 		Type:     "access",
 		Hardware: "Cisco 2960X",
-		Status:   "up",
-		Layer:    5,
+		LayerID:  intPtr(5),
 	})
 
 	repo.AddDevice(&topology.Device{
 		ID:       "access-003",
 		Type:     "access",
 		Hardware: "Cisco 2960X",
-		Status:   "up",
-		Layer:    5,
+		LayerID:  intPtr(5),
 	})
 
 	// リンクを追加
@@ -156,7 +168,6 @@ func createTestTopology() *MockTopologyRepository {
 		TargetID:   "core-002",
 		SourcePort: "Ethernet1/1",
 		TargetPort: "Ethernet1/1",
-		Status:     "up",
 		Weight:     1.0,
 	})
 
@@ -166,7 +177,6 @@ func createTestTopology() *MockTopologyRepository {
 		TargetID:   "dist-100",
 		SourcePort: "Ethernet1/2",
 		TargetPort: "et-0/0/0",
-		Status:     "up",
 		Weight:     1.0,
 	})
 
@@ -176,7 +186,6 @@ func createTestTopology() *MockTopologyRepository {
 		TargetID:   "dist-101",
 		SourcePort: "Ethernet1/3",
 		TargetPort: "et-0/0/0",
-		Status:     "up",
 		Weight:     1.0,
 	})
 
@@ -186,7 +195,6 @@ func createTestTopology() *MockTopologyRepository {
 		TargetID:   "dist-102",
 		SourcePort: "Ethernet1/4",
 		TargetPort: "et-0/0/0",
-		Status:     "up",
 		Weight:     1.0,
 	})
 
@@ -196,7 +204,6 @@ func createTestTopology() *MockTopologyRepository {
 		TargetID:   "access-001",
 		SourcePort: "et-0/0/1",
 		TargetPort: "GigabitEthernet0/1",
-		Status:     "up",
 		Weight:     1.0,
 	})
 
@@ -206,7 +213,6 @@ func createTestTopology() *MockTopologyRepository {
 		TargetID:   "access-002",
 		SourcePort: "et-0/0/1",
 		TargetPort: "GigabitEthernet0/1",
-		Status:     "up",
 		Weight:     1.0,
 	})
 
@@ -216,7 +222,6 @@ func createTestTopology() *MockTopologyRepository {
 		TargetID:   "access-003",
 		SourcePort: "et-0/0/1",
 		TargetPort: "GigabitEthernet0/1",
-		Status:     "up",
 		Weight:     1.0,
 	})
 
@@ -450,3 +455,357 @@ func TestGroupEdgeCreation(t *testing.T) {
 		t.Error("Expected at least some edges in the result")
 	}
 }
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestLayoutInputHash(t *testing.T) {
+	nodes := []visualization.VisualNode{{ID: "a", Layer: 1}, {ID: "b", Layer: 2}, {ID: "c", Layer: 2}}
+	edges := []visualization.VisualEdge{{ID: "a-b", Source: "a", Target: "b"}, {ID: "a-c", Source: "a", Target: "c"}}
+	hash := layoutInputHash(nodes, edges)
+
+	// 順序には依存しない
+	reordered := layoutInputHash(
+		[]visualization.VisualNode{nodes[2], nodes[0], nodes[1]},
+		[]visualization.VisualEdge{edges[1], edges[0]},
+	)
+	if reordered != hash {
+		t.Error("hash should not depend on the order of nodes and edges")
+	}
+
+	// 同じノードでもリンクの増減や階層の変更でレイアウトは変わる
+	rewired := []visualization.VisualEdge{edges[0], {ID: "b-c", Source: "b", Target: "c"}}
+	if layoutInputHash(nodes, rewired) == hash {
+		t.Error("hash should change when the edges change")
+	}
+	if layoutInputHash(nodes, edges[:1]) == hash {
+		t.Error("hash should change when an edge is removed")
+	}
+	relayered := []visualization.VisualNode{nodes[0], nodes[1], {ID: "c", Layer: 3}}
+	if layoutInputHash(relayered, edges) == hash {
+		t.Error("hash should change when a node changes layer")
+	}
+}
+
+func TestCachedLayout_EdgeChangeInvalidates(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	defer setup.Cleanup()
+	s := NewVisualizationService(setup.Repo)
+	ctx := context.Background()
+
+	key := visualization.NewViewKey("a", 2, visualization.GroupingOptions{})
+	nodes := []visualization.VisualNode{{ID: "a", Layer: 1}, {ID: "b", Layer: 2}, {ID: "c", Layer: 2}}
+	edges := []visualization.VisualEdge{{ID: "a-b", Source: "a", Target: "b"}}
+
+	s.cachedLayout(ctx, key, nodes, edges, true)
+	if layout := s.cachedLayout(ctx, key, nodes, edges, true); layout.Options["cached"] != true {
+		t.Fatal("an unchanged view should be served from the cache")
+	}
+
+	edges = append(edges, visualization.VisualEdge{ID: "a-c", Source: "a", Target: "c"})
+	if layout := s.cachedLayout(ctx, key, nodes, edges, true); layout.Options["cached"] == true {
+		t.Error("a view whose edges changed should not be served from the cache")
+	}
+}
+
+func TestFlushViewRequests(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	defer setup.Cleanup()
+	s := NewVisualizationService(setup.Repo)
+	ctx := context.Background()
+	since := time.Now().Add(-time.Hour)
+
+	key := visualization.NewViewKey("a", 2, visualization.GroupingOptions{})
+	other := visualization.NewViewKey("b", 1, visualization.GroupingOptions{})
+	nodes := []visualization.VisualNode{{ID: "a", Layer: 1}}
+	for i := 0; i < 3; i++ {
+		s.cachedLayout(ctx, key, nodes, nil, true)
+	}
+	s.cachedLayout(ctx, other, nodes, nil, true)
+	// 再計算の要求は人気ビューの集計に含めない
+	s.cachedLayout(ctx, other, nodes, nil, false)
+
+	// リクエストのたびには書き込まない
+	views, err := s.layoutCache.ListPopularViews(ctx, since, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 0 {
+		t.Fatalf("expected no view requests before the flush, got %d", len(views))
+	}
+
+	written, err := s.FlushViewRequests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 2 {
+		t.Errorf("expected 2 views written, got %d", written)
+	}
+
+	// 次のフラッシュまでに増えた分は加算される
+	s.cachedLayout(ctx, key, nodes, nil, true)
+	if _, err := s.FlushViewRequests(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if written, _ := s.FlushViewRequests(ctx); written != 0 {
+		t.Errorf("expected an empty buffer after the flush, wrote %d views", written)
+	}
+
+	views, err = s.layoutCache.ListPopularViews(ctx, since, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 2 {
+		t.Fatalf("expected 2 popular views, got %d", len(views))
+	}
+	if views[0].ViewKey != key || views[0].RequestCount != 4 {
+		t.Errorf("expected %s requested 4 times first, got %s requested %d times", key.RootDevice, views[0].RootDevice, views[0].RequestCount)
+	}
+	if views[1].ViewKey != other || views[1].RequestCount != 1 {
+		t.Errorf("expected %s requested once, got %d", other.RootDevice, views[1].RequestCount)
+	}
+}
+
+func TestPatchLayout(t *testing.T) {
+	service := NewVisualizationService(NewMockTopologyRepository())
+	previous := visualization.Layout{
+		Type:    "hierarchical",
+		Options: map[string]interface{}{"direction": "TB"},
+		Positions: map[string]visualization.Position{
+			"core-001":  {X: 0, Y: 0},
+			"dist-100":  {X: -200, Y: 150},
+			"group-001": {X: 300, Y: 300},
+		},
+	}
+	nodes := []visualization.VisualNode{
+		{ID: "core-001", Layer: 3},
+		{ID: "dist-100", Layer: 4},
+		{ID: "access-003", Layer: 5},
+		{ID: "access-001", Layer: 5},
+		{ID: "dist-101", Layer: 4},
+	}
+
+	layout, patch := service.patchLayout(previous, nodes, "group-001")
+
+	// 既存ノードは動かさない
+	for _, id := range []string{"core-001", "dist-100"} {
+		if layout.Positions[id] != previous.Positions[id] {
+			t.Errorf("Expected %s to keep %+v, got %+v", id, previous.Positions[id], layout.Positions[id])
+		}
+		if _, ok := patch.Added[id]; ok {
+			t.Errorf("Expected %s not to be in the patch", id)
+		}
+	}
+	if layout.Type != previous.Type || layout.Options["direction"] != "TB" {
+		t.Errorf("Expected the layout type and options to be kept, got %s %v", layout.Type, layout.Options)
+	}
+	if len(layout.Positions) != len(nodes) {
+		t.Errorf("Expected a position for each node, got %d", len(layout.Positions))
+	}
+
+	if patch.AnchorID != "group-001" || patch.Anchor != previous.Positions["group-001"] {
+		t.Errorf("Expected the patch anchored at the expanded group, got %s %+v", patch.AnchorID, patch.Anchor)
+	}
+	if len(patch.Removed) != 1 || patch.Removed[0] != "group-001" {
+		t.Errorf("Expected the expanded group to be removed, got %v", patch.Removed)
+	}
+
+	// 新規ノードは階層順にグループの位置を中心とした行に並ぶ（3ノードで1行2列）
+	expected := map[string]visualization.Position{
+		"dist-101":   {X: 240, Y: 300},
+		"access-001": {X: 360, Y: 300},
+		"access-003": {X: 300, Y: 400},
+	}
+	if len(patch.Added) != len(expected) {
+		t.Fatalf("Expected %d added nodes, got %v", len(expected), patch.Added)
+	}
+	for id, position := range expected {
+		if patch.Added[id] != position {
+			t.Errorf("Expected %s at %+v, got %+v", id, position, patch.Added[id])
+		}
+		if layout.Positions[id] != position {
+			t.Errorf("Expected the layout to place %s at %+v, got %+v", id, position, layout.Positions[id])
+		}
+	}
+}
+
+func TestExpandGroupInTopology_PatchesLayout(t *testing.T) {
+	service := NewVisualizationService(createTestTopology())
+	ctx := context.Background()
+	groupingOpts := visualization.GroupingOptions{
+		Enabled:       true,
+		MinGroupSize:  3,
+		MaxDepth:      1,
+		GroupByPrefix: true,
+		PrefixMinLen:  3,
+	}
+
+	current, err := service.GetVisualTopologyWithGrouping(ctx, "core-001", 1, groupingOpts)
+	if err != nil {
+		t.Fatalf("GetVisualTopologyWithGrouping failed: %v", err)
+	}
+	var group visualization.GroupedVisualNode
+	for _, candidate := range current.Groups {
+		if candidate.Prefix == "dist-" {
+			group = candidate
+		}
+	}
+	if group.ID == "" {
+		t.Fatalf("Expected the dist devices to be grouped, got %+v", current.Groups)
+	}
+	previous := make(map[string]visualization.Position, len(current.Layout.Positions))
+	for id, position := range current.Layout.Positions {
+		previous[id] = position
+	}
+
+	// 展開したノードを再びグルーピングしない
+	expanded, _, _, err := service.ExpandGroupInTopology(ctx, group.ID, "core-001", group.DeviceIDs, *current, visualization.GroupingOptions{}, 1)
+	if err != nil {
+		t.Fatalf("ExpandGroupInTopology failed: %v", err)
+	}
+
+	if expanded.LayoutPatch == nil {
+		t.Fatal("Expected a layout patch when expanding a laid out topology")
+	}
+	patch := expanded.LayoutPatch
+	if patch.AnchorID != group.ID || patch.Anchor != previous[group.ID] {
+		t.Errorf("Expected the patch anchored at the group, got %s %+v", patch.AnchorID, patch.Anchor)
+	}
+	if len(patch.Removed) != 1 || patch.Removed[0] != group.ID {
+		t.Errorf("Expected the group to be removed, got %v", patch.Removed)
+	}
+	for _, id := range group.DeviceIDs {
+		if _, ok := patch.Added[id]; !ok {
+			t.Errorf("Expected group member %s to be added, got %v", id, patch.Added)
+		}
+	}
+	for id, position := range previous {
+		if id == group.ID {
+			continue
+		}
+		if expanded.Layout.Positions[id] != position {
+			t.Errorf("Expected %s to keep %+v, got %+v", id, position, expanded.Layout.Positions[id])
+		}
+	}
+}
+
+func TestExpandGroup_GroupNotFound(t *testing.T) {
+	service := NewVisualizationService(createTestTopology())
+	groupingOpts := visualization.GroupingOptions{Enabled: true, MinGroupSize: 3, MaxDepth: 1, GroupByPrefix: true, PrefixMinLen: 3}
+
+	_, err := service.ExpandGroup(context.Background(), "core-001", "no-such-group", 1, 1, groupingOpts, nil)
+	if !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, got %v", err)
+	}
+}
+
+func TestGetPortTopology(t *testing.T) {
+	repo := createTestTopology()
+	// core-001 と dist-100 の間の並行リンクと、ポート名が不明なリンク
+	repo.AddLink(topology.Link{ID: "core-001_dist-100_2", SourceID: "core-001", TargetID: "dist-100", SourcePort: "Ethernet1/5", TargetPort: "et-0/0/2", Weight: 1.0})
+	repo.AddLink(topology.Link{ID: "core-001_dist-100_3", SourceID: "core-001", TargetID: "dist-100", Weight: 1.0})
+	service := NewVisualizationService(repo)
+	ctx := context.Background()
+
+	result, err := service.GetPortTopology(ctx, "core-001", 1, "")
+	if err != nil {
+		t.Fatalf("GetPortTopology failed: %v", err)
+	}
+	if result.Layout.Type != "port-graph" {
+		t.Errorf("Expected a port-graph layout, got %s", result.Layout.Type)
+	}
+
+	nodes := make(map[string]visualization.VisualNode, len(result.Nodes))
+	for _, node := range result.Nodes {
+		nodes[node.ID] = node
+	}
+	coreEth1 := visualization.PortNodeID("core-001", "Ethernet1/1")
+	port, ok := nodes[coreEth1]
+	if !ok {
+		t.Fatalf("Expected a node for %s, got %v", coreEth1, result.Nodes)
+	}
+	if port.Type != "port" || port.Parent != "core-001" || port.Name != "Ethernet1/1" {
+		t.Errorf("Expected the port to belong to core-001, got %+v", port)
+	}
+	if port.Position.Y != nodes["core-001"].Position.Y+30 {
+		t.Errorf("Expected ports below their device, got %+v (device %+v)", port.Position, nodes["core-001"].Position)
+	}
+	if result.Layout.Positions[coreEth1] != port.Position {
+		t.Errorf("Expected the layout to hold the port position")
+	}
+
+	// 各リンクは対応するポート同士を結ぶ
+	edges := make(map[string]visualization.VisualEdge, len(result.Edges))
+	for _, edge := range result.Edges {
+		edges[edge.ID] = edge
+	}
+	if len(edges) != 9 {
+		t.Errorf("Expected every link as an edge, got %d", len(edges))
+	}
+	parallel := edges["core-001_dist-100_2"]
+	if parallel.Source != visualization.PortNodeID("core-001", "Ethernet1/5") || parallel.Target != visualization.PortNodeID("dist-100", "et-0/0/2") {
+		t.Errorf("Expected the parallel link between its own ports, got %s -> %s", parallel.Source, parallel.Target)
+	}
+	if parallel.Source == edges["core-001_dist-100"].Source {
+		t.Error("Expected parallel links to use different ports")
+	}
+	unknown := edges["core-001_dist-100_3"]
+	if unknown.Source != visualization.PortNodeID("core-001", "unknown-core-001_dist-100_3") || unknown.LocalPort != "" {
+		t.Errorf("Expected a link without port names to get its own port, got %+v", unknown)
+	}
+
+	// ポートの統計はデバイス単位
+	core001Ports := 0
+	for _, node := range result.Nodes {
+		if node.Parent == "core-001" {
+			core001Ports++
+		}
+	}
+	if core001Ports != 6 {
+		t.Errorf("Expected one port per core-001 interface, got %d", core001Ports)
+	}
+	if result.Stats.TotalNodes != len(result.Nodes) {
+		t.Errorf("Expected the total to count ports, got %d of %d", result.Stats.TotalNodes, len(result.Nodes))
+	}
+	if result.Stats.Layers["3"] != 2 {
+		t.Errorf("Expected layer stats per device, got %v", result.Stats.Layers)
+	}
+}
+
+func TestGetPortTopology_Peer(t *testing.T) {
+	repo := createTestTopology()
+	repo.AddLink(topology.Link{ID: "core-001_dist-100_2", SourceID: "dist-100", TargetID: "core-001", SourcePort: "et-0/0/2", TargetPort: "Ethernet1/5", Weight: 1.0})
+	service := NewVisualizationService(repo)
+
+	result, err := service.GetPortTopology(context.Background(), "core-001", 1, "dist-100")
+	if err != nil {
+		t.Fatalf("GetPortTopology failed: %v", err)
+	}
+
+	// 2台間のリンクのみ（向きは問わない）
+	if len(result.Edges) != 2 {
+		t.Errorf("Expected only the links between core-001 and dist-100, got %v", result.Edges)
+	}
+	for _, node := range result.Nodes {
+		owner := node.ID
+		if node.Type == "port" {
+			owner = node.Parent
+		}
+		if owner != "core-001" && owner != "dist-100" {
+			t.Errorf("Expected only the two devices and their ports, got %s", node.ID)
+		}
+	}
+	if len(result.Nodes) != 6 {
+		t.Errorf("Expected 2 devices and 4 ports, got %d nodes", len(result.Nodes))
+	}
+}
+
+func TestGetPortTopology_RootNotFound(t *testing.T) {
+	service := NewVisualizationService(createTestTopology())
+
+	_, err := service.GetPortTopology(context.Background(), "no-such-device", 1, "")
+	if !errors.Is(err, ErrRootDeviceNotFound) {
+		t.Errorf("Expected ErrRootDeviceNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/apperror"
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// DefaultWorkflowHistoryLimit is the number of transitions returned with a device workflow
+const DefaultWorkflowHistoryLimit = 50
+
+var (
+	// ErrInvalidWorkflowTransition is returned for unknown states and transitions that are not allowed
+	ErrInvalidWorkflowTransition = apperror.Validation("invalid_workflow_transition", "invalid workflow transition")
+	// ErrWorkflowDeviceNotFound is returned when the device does not exist
+	ErrWorkflowDeviceNotFound = apperror.NotFound("device_not_found", "device not found")
+	// ErrWorkflowStateChanged is returned when another transition of the device won the race
+	ErrWorkflowStateChanged = apperror.Conflict("workflow_state_changed", "workflow state changed concurrently")
+)
+
+// WorkflowService moves devices through the onboarding workflow. Only the allowed transitions
+// are accepted and every transition is recorded with who made it and why.
+type WorkflowService struct {
+	workflowRepo topology.WorkflowRepository
+	topologyRepo topology.Repository
+}
+
+func NewWorkflowService(workflowRepo topology.WorkflowRepository, topologyRepo topology.Repository) *WorkflowService {
+	return &WorkflowService{
+		workflowRepo: workflowRepo,
+		topologyRepo: topologyRepo,
+	}
+}
+
+// GetDeviceWorkflow returns the workflow state of a device with its allowed transitions and history
+func (s *WorkflowService) GetDeviceWorkflow(ctx context.Context, deviceID string) (*topology.DeviceWorkflow, error) {
+	device, err := s.topologyRepo.GetDevice(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if device == nil {
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowDeviceNotFound, deviceID)
+	}
+
+	history, err := s.workflowRepo.ListWorkflowTransitions(ctx, deviceID, DefaultWorkflowHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	state := topology.EffectiveWorkflowState(*device)
+	return &topology.DeviceWorkflow{
+		DeviceID: deviceID,
+		State:    state,
+		Allowed:  topology.AllowedWorkflowTransitions(state),
+		History:  history,
+	}, nil
+}
+
+// Transition moves a device to the workflow state to and returns the updated workflow
+func (s *WorkflowService) Transition(ctx context.Context, deviceID, to, reason, changedBy string) (*topology.DeviceWorkflow, error) {
+	device, err := s.topologyRepo.GetDevice(ctx, deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	if device == nil {
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowDeviceNotFound, deviceID)
+	}
+
+	from := topology.EffectiveWorkflowState(*device)
+	if err := topology.ValidateWorkflowTransition(from, to); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorkflowTransition, err)
+	}
+
+	moved, err := s.workflowRepo.TransitionDeviceWorkflow(ctx, topology.WorkflowTransition{
+		DeviceID:  deviceID,
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		ChangedBy: changedBy,
+		ChangedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !moved {
+		return nil, fmt.Errorf("%w: %s is no longer %s", ErrWorkflowStateChanged, deviceID, from)
+	}
+
+	return s.GetDeviceWorkflow(ctx, deviceID)
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/visualization"
+)
+
+func TestFileStore_PutOpenDelete(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	object, err := store.Put(ctx, "exports/a.csv", strings.NewReader("id\nleaf-01\n"))
+	if err != nil {
+		t.Fatalf("Failed to put object: %v", err)
+	}
+	if object.Key != "exports/a.csv" || object.Size != 11 {
+		t.Errorf("Expected exports/a.csv of 11 bytes, got %+v", object)
+	}
+
+	r, _, err := store.Open(ctx, "exports/a.csv")
+	if err != nil {
+		t.Fatalf("Failed to open object: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "id\nleaf-01\n" {
+		t.Errorf("Expected the stored content, got %q", data)
+	}
+
+	if _, err := store.Put(ctx, "other/b.json", strings.NewReader("{}")); err != nil {
+		t.Fatalf("Failed to put object: %v", err)
+	}
+	objects, err := store.List(ctx, "exports/")
+	if err != nil {
+		t.Fatalf("Failed to list objects: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "exports/a.csv" {
+		t.Errorf("Expected only exports/a.csv, got %+v", objects)
+	}
+
+	if err := store.Delete(ctx, "exports/a.csv"); err != nil {
+		t.Fatalf("Failed to delete object: %v", err)
+	}
+	if err := store.Delete(ctx, "exports/a.csv"); err != nil {
+		t.Errorf("Expected deleting a missing object to succeed, got %v", err)
+	}
+	if _, _, err := store.Open(ctx, "exports/a.csv"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Expected ErrObjectNotFound, got %v", err)
+	}
+}
+
+func TestFileStore_InvalidKey(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "exports/../../outside", "exports//a"} {
+		if _, err := store.Put(context.Background(), key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey for %q, got %v", key, err)
+		}
+	}
+}
+
+func TestGrant_RoundTrip(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1700000000, 0)
+	token, err := SignGrant(secret, Grant{Key: "exports/a.csv", Filename: "links.csv", ExpiresAt: now.Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("Failed to sign grant: %v", err)
+	}
+
+	grant, err := VerifyGrant(secret, token, now)
+	if err != nil {
+		t.Fatalf("Expected a valid grant, got %v", err)
+	}
+	if grant.Key != "exports/a.csv" || grant.Filename != "links.csv" {
+		t.Errorf("Expected the signed grant, got %+v", grant)
+	}
+
+	if _, err := VerifyGrant(secret, token, now.Add(time.Minute)); !errors.Is(err, ErrGrantExpired) {
+		t.Errorf("Expected ErrGrantExpired, got %v", err)
+	}
+	if _, err := VerifyGrant([]byte("other"), token, now); !errors.Is(err, ErrInvalidGrant) {
+		t.Errorf("Expected ErrInvalidGrant for another secret, got %v", err)
+	}
+	if _, err := VerifyGrant(secret, "x"+token, now); !errors.Is(err, ErrInvalidGrant) {
+		t.Errorf("Expected ErrInvalidGrant for a tampered token, got %v", err)
+	}
+
+	// 同じ秘密鍵で署名した共有リンクのトークンはダウンロードに使えない
+	share, err := visualization.SignShareToken(secret, visualization.ShareClaims{RootDevice: "core-01", ExpiresAt: now.Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("Failed to sign share token: %v", err)
+	}
+	if _, err := VerifyGrant(secret, share, now); !errors.Is(err, ErrInvalidGrant) {
+		t.Errorf("Expected ErrInvalidGrant for a share token, got %v", err)
+	}
+	if _, err := visualization.VerifyShareToken(secret, token, now); !errors.Is(err, visualization.ErrInvalidShareToken) {
+		t.Errorf("Expected a download grant to be rejected as a share token, got %v", err)
+	}
+}
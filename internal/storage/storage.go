@@ -0,0 +1,87 @@
+// Package storage keeps files too large for the database or a synchronous response, such as
+// topology exports, and hands them out through signed, expiring download grants
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/servak/topology-manager/pkg/signedtoken"
+)
+
+var (
+	// ErrObjectNotFound is returned when no object is stored under a key
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrInvalidKey is returned for keys that are empty or leave the store
+	ErrInvalidKey = errors.New("invalid object key")
+	// ErrInvalidGrant is returned for malformed or tampered download grants
+	ErrInvalidGrant = errors.New("invalid download grant")
+	// ErrGrantExpired is returned for download grants past their expiry
+	ErrGrantExpired = errors.New("download grant expired")
+)
+
+// Object describes a stored file
+type Object struct {
+	Key        string    `json:"key"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// ObjectStore stores files under slash-separated keys. Every API instance must use the same store
+// so that a file written by the job runner of one instance can be downloaded from any other.
+type ObjectStore interface {
+	// Put stores the content of r under key, replacing an existing object only once r is fully read
+	Put(ctx context.Context, key string, r io.Reader) (*Object, error)
+	// Open returns the content of an object; the caller closes it
+	Open(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// List returns the objects whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// ValidateKey rejects keys that are empty, absolute or contain . or .. segments
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// Grant is the content of a download token: which object may be downloaded, under which
+// file name and until when. Grants are stateless, like pre-signed object storage URLs.
+type Grant struct {
+	Key         string `json:"key"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	ExpiresAt   int64  `json:"exp"`
+}
+
+// grantPurpose separates the signing key of download grants from other tokens signed with the same secret
+const grantPurpose = "download"
+
+// SignGrant encodes grant into a signed download token
+func SignGrant(secret []byte, grant Grant) (string, error) {
+	return signedtoken.Sign(secret, grantPurpose, grant)
+}
+
+// VerifyGrant checks the signature and expiry of a token and returns its grant
+func VerifyGrant(secret []byte, token string, now time.Time) (*Grant, error) {
+	var grant Grant
+	if err := signedtoken.Verify(secret, grantPurpose, token, &grant); err != nil {
+		return nil, ErrInvalidGrant
+	}
+	if now.Unix() >= grant.ExpiresAt {
+		return nil, ErrGrantExpired
+	}
+
+	return &grant, nil
+}
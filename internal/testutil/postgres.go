@@ -0,0 +1,174 @@
+package testutil
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/repository/postgres"
+	"github.com/servak/topology-manager/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+// PostgresDSNEnv names the environment variable with the URL of an existing PostgreSQL
+// server to run the tests against instead of starting a container
+const PostgresDSNEnv = "TEST_POSTGRES_DSN"
+
+// PostgresImage is the image of the container started when PostgresDSNEnv is not set
+const PostgresImage = "postgres:16-alpine"
+
+const postgresStartTimeout = 60 * time.Second
+
+// postgresServer is the server shared by the tests of a package. Every test gets its own database on it.
+type postgresServer struct {
+	adminDSN    string
+	containerID string
+}
+
+var (
+	postgresOnce   sync.Once
+	sharedPostgres *postgresServer
+	postgresErr    error
+)
+
+// NewPostgresTestSetup creates a test setup backed by a fresh PostgreSQL database with all
+// migrations applied. The database is dropped when the test ends. The test is skipped when
+// neither PostgresDSNEnv is set nor docker is available.
+func NewPostgresTestSetup(t *testing.T) *TestSetup {
+	t.Helper()
+
+	server := postgresForTest(t)
+	ctx := context.Background()
+
+	admin, err := sql.Open("postgres", server.adminDSN)
+	require.NoError(t, err)
+
+	dbName := "tm_test_" + randomSuffix(t)
+	_, err = admin.ExecContext(ctx, "CREATE DATABASE "+dbName)
+	require.NoError(t, err)
+
+	dsn, err := withDatabase(server.adminDSN, dbName)
+	require.NoError(t, err)
+
+	repo, err := postgres.NewPostgresRepository(postgres.Config{DSN: dsn})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		repo.Close()
+		// テスト中の接続が残っていても削除できるよう強制終了する
+		admin.Exec("SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1", dbName)
+		admin.Exec("DROP DATABASE IF EXISTS " + dbName)
+		admin.Close()
+	})
+
+	require.NoError(t, repo.ApplyMigrations(ctx))
+
+	return &TestSetup{
+		Repo:   repo,
+		Logger: logger.New("debug"),
+	}
+}
+
+// RunWithPostgres runs the tests of a package and removes the container started for them.
+// Call it from TestMain of packages that use NewPostgresTestSetup:
+//
+//	func TestMain(m *testing.M) { os.Exit(testutil.RunWithPostgres(m)) }
+func RunWithPostgres(m *testing.M) int {
+	code := m.Run()
+	if sharedPostgres != nil && sharedPostgres.containerID != "" {
+		exec.Command("docker", "rm", "-f", sharedPostgres.containerID).Run()
+	}
+	return code
+}
+
+// postgresForTest returns the shared server, starting it on first use
+func postgresForTest(t *testing.T) *postgresServer {
+	t.Helper()
+
+	postgresOnce.Do(func() {
+		if dsn := os.Getenv(PostgresDSNEnv); dsn != "" {
+			sharedPostgres = &postgresServer{adminDSN: dsn}
+			return
+		}
+		if _, err := exec.LookPath("docker"); err != nil {
+			postgresErr = fmt.Errorf("docker not found and %s not set", PostgresDSNEnv)
+			return
+		}
+		sharedPostgres, postgresErr = startPostgresContainer()
+	})
+
+	if postgresErr != nil {
+		t.Skipf("PostgreSQL not available: %v", postgresErr)
+	}
+	return sharedPostgres
+}
+
+// startPostgresContainer starts a throwaway PostgreSQL container on a random local port and waits until it accepts connections
+func startPostgresContainer() (*postgresServer, error) {
+	out, err := exec.Command("docker", "run", "-d", "--rm",
+		"-e", "POSTGRES_PASSWORD=postgres",
+		"-p", "127.0.0.1::5432",
+		PostgresImage).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start container: %w", err)
+	}
+	containerID := strings.TrimSpace(string(out))
+	server := &postgresServer{containerID: containerID}
+
+	out, err = exec.Command("docker", "port", containerID, "5432/tcp").Output()
+	if err != nil {
+		exec.Command("docker", "rm", "-f", containerID).Run()
+		return nil, fmt.Errorf("failed to get container port: %w", err)
+	}
+	// IPv4とIPv6の両方が出力される場合は先頭を使う
+	hostPort := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	server.adminDSN = fmt.Sprintf("postgres://postgres:postgres@%s/postgres?sslmode=disable", hostPort)
+
+	db, err := sql.Open("postgres", server.adminDSN)
+	if err != nil {
+		exec.Command("docker", "rm", "-f", containerID).Run()
+		return nil, err
+	}
+	defer db.Close()
+
+	deadline := time.Now().Add(postgresStartTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err = db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return server, nil
+		}
+		if time.Now().After(deadline) {
+			exec.Command("docker", "rm", "-f", containerID).Run()
+			return nil, fmt.Errorf("container did not become ready: %w", err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// withDatabase returns dsn pointing at database dbName
+func withDatabase(dsn, dbName string) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", PostgresDSNEnv, err)
+	}
+	u.Path = "/" + dbName
+	return u.String(), nil
+}
+
+func randomSuffix(t *testing.T) string {
+	b := make([]byte, 6)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	return hex.EncodeToString(b)
+}
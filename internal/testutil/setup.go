@@ -59,7 +59,7 @@ func CreateTestDevice(id string) topology.Device {
 		Hardware:     "Test Hardware " + id,
 		LayerID:      &layerID,
 		DeviceType:   "network-switch",
-		ClassifiedBy: "test",
+		ClassifiedBy: "rule:Test Rule",
 		Metadata:     map[string]string{"test": "value", "id": id},
 		LastSeen:     now,
 		CreatedAt:    now,
@@ -106,7 +106,7 @@ func CreateTestClassificationRule(id, name string) classification.Classification
 		Name:          name,
 		Description:   "Test rule: " + name,
 		LogicOperator: "AND",
-		Conditions: []classification.Condition{
+		Conditions: []classification.RuleCondition{
 			{
 				Field:    "hardware",
 				Operator: "contains",
@@ -134,10 +134,8 @@ func (ts *TestSetup) SeedTestData(t *testing.T) {
 		CreateTestDevice("device-003"),
 	}
 	
-	for _, device := range devices {
-		err := ts.Repo.AddDevice(ctx, device)
-		require.NoError(t, err)
-	}
+	err := ts.Repo.BulkAddDevices(ctx, devices)
+	require.NoError(t, err)
 	
 	// Create test links
 	links := []topology.Link{
@@ -145,13 +143,25 @@ func (ts *TestSetup) SeedTestData(t *testing.T) {
 		CreateTestLink("link-002", "device-002", "device-003"),
 	}
 	
-	for _, link := range links {
-		err := ts.Repo.AddLink(ctx, link)
-		require.NoError(t, err)
-	}
+	err = ts.Repo.BulkAddLinks(ctx, links)
+	require.NoError(t, err)
 	
 	// Create test classification rule
 	rule := CreateTestClassificationRule("rule-001", "Test Switch Rule")
-	err := ts.Repo.SaveClassificationRule(ctx, rule)
+	err = ts.Repo.SaveClassificationRule(ctx, rule)
 	require.NoError(t, err)
-}
\ No newline at end of file
+}
+// SeedSiteMetadata tags the seeded devices with site metadata: device-001 is in tokyo,
+// device-003 in osaka and device-002 has none. Call it after SeedTestData.
+func (ts *TestSetup) SeedSiteMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	for id, site := range map[string]string{"device-001": "tokyo", "device-003": "osaka"} {
+		device, err := ts.Repo.GetDevice(ctx, id)
+		require.NoError(t, err)
+		require.NotNil(t, device)
+		device.Metadata = map[string]string{"site": site}
+		device.UpdatedAt = time.Now()
+		require.NoError(t, ts.Repo.UpdateDevice(ctx, *device))
+	}
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// Backfill reconstructs the topology as Prometheus saw it at the given time.
+// The result is returned as a snapshot; the repository is only read to carry over
+// the current classification of known devices.
+func (ps *PrometheusSync) Backfill(ctx context.Context, at time.Time) (*topology.Snapshot, error) {
+	ps.logger.Printf("Backfilling topology at %s...", at.Format(time.RFC3339))
+
+	devices, warnings := ps.metricsExtractor.ExtractDevicesAt(ctx, at)
+	for _, warning := range warnings {
+		ps.logger.Printf("Info: %v", warning)
+	}
+
+	links, warnings := ps.metricsExtractor.ExtractLinksAt(ctx, at)
+	for _, warning := range warnings {
+		ps.logger.Printf("Info: %v", warning)
+	}
+
+	if len(devices) == 0 && len(links) == 0 {
+		return nil, fmt.Errorf("no topology data found in Prometheus at %s", at.Format(time.RFC3339))
+	}
+
+	// LLDPでのみ見えている機器は同期時と同様にプレースホルダーとして追加する
+	known := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		known[device.ID] = true
+	}
+	for _, link := range links {
+		for _, deviceID := range []string{link.SourceID, link.TargetID} {
+			if deviceID == "" || known[deviceID] {
+				continue
+			}
+			known[deviceID] = true
+			devices = append(devices, ps.config.Placeholder.NewPlaceholder(deviceID, at))
+		}
+	}
+
+	// 過去のメトリクスには分類情報がないため、現在の分類を引き継ぐ
+	for i := range devices {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		current, err := ps.repository.GetDevice(ctx, devices[i].ID)
+		if err != nil || current == nil {
+			continue
+		}
+		devices[i].LayerID = current.LayerID
+		if current.Type != "" {
+			devices[i].Type = current.Type
+		}
+	}
+
+	ps.logger.Printf("Backfilled %d devices and %d links at %s", len(devices), len(links), at.Format(time.RFC3339))
+	return &topology.Snapshot{
+		TakenAt: at,
+		Source:  topology.SnapshotSourceBackfill,
+		Devices: devices,
+		Links:   links,
+	}, nil
+}
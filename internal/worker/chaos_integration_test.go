@@ -0,0 +1,218 @@
+//go:build chaos
+
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/faultinject"
+	"github.com/servak/topology-manager/internal/prometheus"
+	"github.com/servak/topology-manager/internal/repository"
+	"github.com/servak/topology-manager/internal/repository/sqlite"
+)
+
+// chaosDevices are reported by snmp_device_info; server-01 is only seen as an LLDP neighbor
+var chaosDevices = map[string]string{
+	"spine-01": "Arista 7800",
+	"spine-02": "Arista 7800",
+	"leaf-01":  "Arista 7050",
+	"leaf-02":  "Arista 7050",
+}
+
+var chaosLinks = []map[string]string{
+	{"instance": "spine-01", "lldpLocalPortId": "Ethernet1", "lldpRemSysName": "leaf-01", "lldpRemPortId": "Ethernet49"},
+	{"instance": "spine-01", "lldpLocalPortId": "Ethernet2", "lldpRemSysName": "leaf-02", "lldpRemPortId": "Ethernet49"},
+	{"instance": "spine-02", "lldpLocalPortId": "Ethernet1", "lldpRemSysName": "leaf-01", "lldpRemPortId": "Ethernet50"},
+	{"instance": "spine-02", "lldpLocalPortId": "Ethernet2", "lldpRemSysName": "leaf-02", "lldpRemPortId": "Ethernet50"},
+	{"instance": "leaf-01", "lldpLocalPortId": "Ethernet1", "lldpRemSysName": "server-01", "lldpRemPortId": "eth0"},
+}
+
+// newFakePrometheus serves the device and LLDP metrics of the chaos topology
+func newFakePrometheus(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+
+		var result []map[string]interface{}
+		switch {
+		case strings.Contains(query, `"snmp_device_info"`):
+			for _, id := range []string{"leaf-01", "leaf-02", "spine-01", "spine-02"} {
+				result = append(result, sample(map[string]string{"instance": id, "sysDescr": chaosDevices[id]}))
+			}
+		case strings.Contains(query, `"snmp_lldp_neighbor_info"`):
+			for _, labels := range chaosLinks {
+				result = append(result, sample(labels))
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data":   map[string]interface{}{"resultType": "vector", "result": result},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func sample(labels map[string]string) map[string]interface{} {
+	return map[string]interface{}{"metric": labels, "value": []interface{}{float64(time.Now().Unix()), "1"}}
+}
+
+func newChaosRepository(t *testing.T) repository.Repository {
+	repo, err := repository.NewRepository(repository.Config{
+		Type:   "sqlite",
+		SQLite: sqlite.Config{Path: filepath.Join(t.TempDir(), "chaos.db")},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if err := repo.Migrate(); err != nil {
+		t.Fatalf("Failed to migrate repository: %v", err)
+	}
+	return repo
+}
+
+// newChaosSync creates a worker whose repository and Prometheus client inject the faults
+func newChaosSync(t *testing.T, repo repository.Repository, faults faultinject.Config) *PrometheusSync {
+	injector := faultinject.New(faults)
+	promClient := prometheus.NewClient(prometheus.Config{
+		URL:       newFakePrometheus(t).URL,
+		Transport: injector.WrapTransport(nil),
+	})
+	metricsConfig := &prometheus.MetricsConfig{
+		MetricsMapping: map[string]prometheus.MetricConfigGroup{
+			"device_info": {Primary: prometheus.MetricMapping{
+				MetricName: "snmp_device_info",
+				Labels:     map[string]string{"device_id": "instance", "hardware": "sysDescr"},
+			}},
+			"lldp_neighbors": {Primary: prometheus.MetricMapping{
+				MetricName: "snmp_lldp_neighbor_info",
+				Labels: map[string]string{
+					"source_device": "instance",
+					"source_port":   "lldpLocalPortId",
+					"target_device": "lldpRemSysName",
+					"target_port":   "lldpRemPortId",
+				},
+			}},
+		},
+	}
+
+	config := DefaultPrometheusSyncConfig()
+	config.EnableAutoClassify = false
+	config.BatchSize = 2 // 複数バッチに分けて部分的な失敗を起こしやすくする
+	logger := log.New(io.Discard, "", 0)
+
+	return NewPrometheusSync(promClient, metricsConfig, injector.WrapRepository(repo), repo, config, logger)
+}
+
+// checkConsistency verifies that no link references a missing device and that no device stored
+// with its Prometheus attributes (recorded in synced) was overwritten by a placeholder since.
+// It returns the stored devices and the number of stored links.
+func checkConsistency(t *testing.T, repo topology.Repository, synced map[string]bool) (devices map[string]topology.Device, links int) {
+	t.Helper()
+	ctx := context.Background()
+
+	stored, _, err := repo.GetDevices(ctx, topology.PaginationOptions{Page: 1, PageSize: 10000, OrderBy: "id", SortDir: "ASC"})
+	if err != nil {
+		t.Fatalf("Failed to list devices: %v", err)
+	}
+	devices = make(map[string]topology.Device, len(stored))
+	for _, device := range stored {
+		devices[device.ID] = device
+	}
+
+	linkIDs := make(map[string]bool)
+	for id, device := range devices {
+		// デバイス情報の同期より先に LLDP 同期が成功した場合はプレースホルダーが先に作られる
+		if hardware, ok := chaosDevices[id]; ok {
+			if device.Hardware == hardware {
+				synced[id] = true
+			} else if synced[id] {
+				t.Errorf("Expected %s to keep hardware %q, got %q", id, hardware, device.Hardware)
+			}
+		}
+
+		deviceLinks, err := repo.GetDeviceLinks(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to list links of %s: %v", id, err)
+		}
+		for _, link := range deviceLinks {
+			if _, ok := devices[link.SourceID]; !ok {
+				t.Errorf("Expected link %s to reference an existing source, got %s", link.ID, link.SourceID)
+			}
+			if _, ok := devices[link.TargetID]; !ok {
+				t.Errorf("Expected link %s to reference an existing target, got %s", link.ID, link.TargetID)
+			}
+			linkIDs[link.ID] = true
+		}
+	}
+	return devices, len(linkIDs)
+}
+
+func TestPrometheusSync_RecoversFromInjectedFaults(t *testing.T) {
+	repo := newChaosRepository(t)
+	sync := newChaosSync(t, repo, faultinject.Config{
+		WriteFailureRate: 0.3,
+		QueryFailureRate: 0.1,
+		QueryDelay:       time.Millisecond,
+		Seed:             42,
+	})
+
+	synced := make(map[string]bool)
+	failedCycles := 0
+	convergedAt := -1
+	// 収束後も失敗が続くサイクルを回し、一貫性が保たれることを確認する
+	for cycle := 0; cycle < 20; cycle++ {
+		if err := sync.RunOnce(context.Background()); err != nil {
+			failedCycles++
+		}
+
+		devices, links := checkConsistency(t, repo, synced)
+		complete := len(synced) == len(chaosDevices) && len(devices) == len(chaosDevices)+1 && links == len(chaosLinks)
+		if complete && convergedAt < 0 {
+			convergedAt = cycle
+		}
+		if !complete && convergedAt >= 0 {
+			t.Errorf("Cycle %d: expected the converged topology to stay complete, got %d devices and %d links", cycle, len(devices), links)
+		}
+	}
+
+	if failedCycles == 0 {
+		t.Error("Expected injected faults to fail at least one sync cycle")
+	}
+	if convergedAt < 0 {
+		t.Fatalf("Expected the topology to converge within 20 cycles, %d cycles failed", failedCycles)
+	}
+
+	devices, _ := checkConsistency(t, repo, synced)
+	if placeholder := devices["server-01"]; placeholder.ID == "" {
+		t.Error("Expected a placeholder for the LLDP-only neighbor server-01")
+	}
+}
+
+func TestPrometheusSync_FailedWritesLeaveNoPartialTopology(t *testing.T) {
+	repo := newChaosRepository(t)
+	sync := newChaosSync(t, repo, faultinject.Config{WriteFailureRate: 1})
+
+	// 同期はフェーズごとのエラーを文字列でまとめて返す
+	err := sync.RunOnce(context.Background())
+	if err == nil || !strings.Contains(err.Error(), faultinject.ErrInjected.Error()) {
+		t.Fatalf("Expected sync to fail with injected faults, got %v", err)
+	}
+
+	devices, links := checkConsistency(t, repo, make(map[string]bool))
+	if len(devices) != 0 || links != 0 {
+		t.Errorf("Expected no devices or links to be written, got %d devices and %d links", len(devices), links)
+	}
+}
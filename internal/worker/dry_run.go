@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// DryRun extracts devices and links from Prometheus like a sync and compares them with the
+// stored topology, applying the ingestion quota, without writing anything
+func (ps *PrometheusSync) DryRun(ctx context.Context) (*topology.SyncDiff, error) {
+	diff := &topology.SyncDiff{
+		GeneratedAt:    time.Now(),
+		Placeholders:   []string{},
+		SkippedDevices: []string{},
+		Warnings:       []string{},
+	}
+
+	storedDevices, err := ps.storedDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]bool, len(storedDevices))
+	for _, device := range storedDevices {
+		stored[device.ID] = true
+	}
+
+	// Phase 1 と同じくデバイスを先に反映した前提で、リンクの端点を判定する
+	var devices []topology.Device
+	known := make(map[string]bool, len(stored))
+	for id := range stored {
+		known[id] = true
+	}
+	if ps.config.EnableDeviceSync {
+		extracted, warnings := ps.metricsExtractor.ExtractDevices(ctx)
+		for _, warning := range warnings {
+			diff.Warnings = append(diff.Warnings, warning.Error())
+		}
+
+		admitted, rejected := ps.config.Quota.AdmitDevices(extracted, stored)
+		diff.SkippedDevices = append(diff.SkippedDevices, rejected...)
+		for _, device := range admitted {
+			known[device.ID] = true
+		}
+		devices = admitted
+	}
+
+	var links []topology.Link
+	reported := make(map[string]bool)
+	if ps.config.EnableLLDPSync {
+		extracted, warnings := ps.metricsExtractor.ExtractLinks(ctx)
+		for _, warning := range warnings {
+			diff.Warnings = append(diff.Warnings, warning.Error())
+		}
+
+		now := time.Now()
+		var placeholders []topology.Device
+		for _, link := range extracted {
+			for _, id := range []string{link.SourceID, link.TargetID} {
+				if id == "" || reported[id] {
+					continue
+				}
+				reported[id] = true
+				if !known[id] {
+					placeholders = append(placeholders, ps.config.Placeholder.NewPlaceholder(id, now))
+				}
+			}
+		}
+
+		admitted, rejected := ps.config.Quota.AdmitDevices(placeholders, known)
+		diff.SkippedDevices = append(diff.SkippedDevices, rejected...)
+		for _, device := range admitted {
+			known[device.ID] = true
+			diff.Placeholders = append(diff.Placeholders, device.ID)
+		}
+		devices = append(devices, admitted...)
+
+		if ps.config.Quota.MaxDevices > 0 {
+			kept := make([]topology.Link, 0, len(extracted))
+			for _, link := range extracted {
+				if known[link.SourceID] && known[link.TargetID] {
+					kept = append(kept, link)
+				}
+			}
+			diff.SkippedLinks = len(extracted) - len(kept)
+			extracted = kept
+		}
+		var dropped int
+		links, dropped = ps.config.Quota.AdmitLinks(extracted)
+		diff.SkippedLinks += dropped
+	}
+	sort.Strings(diff.Placeholders)
+	sort.Strings(diff.SkippedDevices)
+
+	diff.Devices = topology.DiffDevices(storedDevices, devices, reported)
+	if !ps.config.EnableDeviceSync {
+		// デバイス同期が無効なら、報告されないデバイスも更新されないのが通常
+		diff.Devices.Removed = []topology.SyncChange{}
+	}
+
+	storedLinks, err := ps.storedLinks(ctx, storedDevices)
+	if err != nil {
+		return nil, err
+	}
+	diff.Links = topology.DiffLinks(storedLinks, links)
+	if !ps.config.EnableLLDPSync {
+		diff.Links.Removed = []topology.SyncChange{}
+	}
+
+	return diff, nil
+}
+
+// storedLinks returns every stored link of devices
+func (ps *PrometheusSync) storedLinks(ctx context.Context, devices []topology.Device) ([]topology.Link, error) {
+	// 両端のデバイスから同じリンクが返るため ID で重複除去
+	seen := make(map[string]bool)
+	var links []topology.Link
+	for _, device := range devices {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		deviceLinks, err := ps.repository.GetDeviceLinks(ctx, device.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get links for device %s: %w", device.ID, err)
+		}
+		for _, link := range deviceLinks {
+			if !seen[link.ID] {
+				seen[link.ID] = true
+				links = append(links, link)
+			}
+		}
+	}
+	return links, nil
+}
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// findEnrichedPlaceholders returns the IDs of the stored LLDP placeholders that devices, about to be
+// stored, describe for the first time
+func (ps *PrometheusSync) findEnrichedPlaceholders(ctx context.Context, devices []topology.Device) ([]string, error) {
+	stored, err := ps.storedDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ps.config.Placeholder.EnrichedPlaceholders(stored, devices), nil
+}
+
+// reclassifyEnrichedPlaceholders re-runs the classification rules for just the enriched placeholders,
+// which rules could not match while they had no hardware or location
+func (ps *PrometheusSync) reclassifyEnrichedPlaceholders(ctx context.Context, deviceIDs []string) error {
+	if ps.classificationService == nil {
+		return fmt.Errorf("classification service not available")
+	}
+
+	ps.logger.Printf("Re-classifying %d placeholder devices now described by monitoring: %s", len(deviceIDs), sampleIDs(deviceIDs, 5))
+	classifications, err := ps.classificationService.ReclassifyEnrichedPlaceholders(ctx, deviceIDs)
+	if err != nil {
+		return fmt.Errorf("failed to re-classify enriched placeholders: %w", err)
+	}
+
+	for _, c := range classifications {
+		ps.logger.Printf("  - %s → Layer %d (%s) after enrichment", c.DeviceID, c.Layer, c.DeviceType)
+	}
+	return nil
+}
+
+// excludeDevices returns devices without those whose ID is in ids
+func excludeDevices(devices []topology.Device, ids []string) []topology.Device {
+	excluded := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		excluded[id] = true
+	}
+
+	remaining := make([]topology.Device, 0, len(devices))
+	for _, device := range devices {
+		if !excluded[device.ID] {
+			remaining = append(remaining, device)
+		}
+	}
+	return remaining
+}
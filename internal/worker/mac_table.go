@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// syncMACTable infers the switch ports servers hang off from MAC/ARP table metrics and
+// stores the servers and their links with the mac-table provenance
+func (ps *PrometheusSync) syncMACTable(ctx context.Context) error {
+	ps.logger.Println("Starting MAC table synchronization...")
+
+	entries, warnings := ps.metricsExtractor.ExtractMACEntries(ctx)
+	for _, warning := range warnings {
+		ps.logger.Printf("Info: %v", warning)
+	}
+	if err := partialResponse(warnings); err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		ps.logger.Println("No MAC table entries extracted from Prometheus - skipping this cycle")
+		return nil
+	}
+
+	devices, err := ps.storedDevices(ctx)
+	if err != nil {
+		return err
+	}
+	// スイッチ間のポートを除外するため、LLDP で得たリンクと突き合わせる
+	links, err := ps.storedLinks(ctx, devices)
+	if err != nil {
+		return err
+	}
+
+	result := topology.InferServerAttachments(entries, devices, links, ps.config.MACTable, time.Now())
+	ps.logger.Printf("Inferred %d server attachments from %d MAC table entries (%d new servers, %d ambiguous MACs, %d uplink ports skipped)",
+		len(result.Attachments), len(entries), len(result.Devices), len(result.Ambiguous), len(result.SkippedPorts))
+	if len(result.Ambiguous) > 0 {
+		ps.logger.Printf("MACs learned on several server ports were not attached: %s", sampleIDs(result.Ambiguous, 5))
+	}
+
+	newServers, err := ps.admitDevices(ctx, result.Devices)
+	if err != nil {
+		return fmt.Errorf("failed to apply device quota: %w", err)
+	}
+	if err := ps.batchAddDevices(ctx, newServers); err != nil {
+		return fmt.Errorf("failed to add servers: %w", err)
+	}
+
+	serverLinks, err := ps.admitLinks(ctx, result.Links)
+	if err != nil {
+		return fmt.Errorf("failed to apply link quota: %w", err)
+	}
+	if err := ps.batchAddLinks(ctx, serverLinks); err != nil {
+		return fmt.Errorf("failed to add server links: %w", err)
+	}
+
+	ps.logger.Printf("MAC table synchronization completed, processed %d links", len(serverLinks))
+	return nil
+}
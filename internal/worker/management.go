@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// checkManagementReachability tries TCP connections to the SSH and NETCONF ports of the
+// management address of every monitored device and stores the results apart from the
+// data-plane status
+func (ps *PrometheusSync) checkManagementReachability(ctx context.Context) error {
+	ps.logger.Println("Starting management reachability check...")
+
+	devices, err := ps.storedDevices(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 監視から消えたデバイスは管理できなくて当然なので確認しない
+	monitoredSince := time.Now().Add(-ps.config.MaxDeviceAge)
+	monitored := make([]topology.Device, 0, len(devices))
+	for _, device := range devices {
+		if !device.LastSeen.Before(monitoredSince) {
+			monitored = append(monitored, device)
+		}
+	}
+
+	results := probeManagementPlanes(ctx, monitored, ps.config.MgmtCheck)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := ps.managementRepository.SaveManagementReachability(ctx, results); err != nil {
+		return fmt.Errorf("failed to save management reachability: %w", err)
+	}
+
+	var unmanageable []string
+	for _, result := range results {
+		if !result.Manageable() {
+			unmanageable = append(unmanageable, result.DeviceID)
+		}
+	}
+	ps.logger.Printf("Management reachability check completed: %d devices checked, %d unmanageable", len(results), len(unmanageable))
+	if len(unmanageable) > 0 {
+		ps.logger.Printf("Monitored but unmanageable devices: %s", sampleIDs(unmanageable, 5))
+	}
+	return nil
+}
+
+// probeManagementPlanes checks the devices with opts.Workers concurrent workers
+func probeManagementPlanes(ctx context.Context, devices []topology.Device, opts topology.ManagementCheckOptions) []topology.ManagementReachability {
+	opts = opts.WithDefaults()
+	results := make([]topology.ManagementReachability, len(devices))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers && w < len(devices); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = probeManagementPlane(ctx, devices[i], opts)
+			}
+		}()
+	}
+
+	for i := range devices {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	// キャンセルで確認できなかったデバイスは結果に含めない
+	checked := results[:0]
+	for _, result := range results {
+		if !result.CheckedAt.IsZero() {
+			checked = append(checked, result)
+		}
+	}
+	return checked
+}
+
+// probeManagementPlane tries TCP connections to the SSH and NETCONF ports of device
+func probeManagementPlane(ctx context.Context, device topology.Device, opts topology.ManagementCheckOptions) topology.ManagementReachability {
+	result := topology.ManagementReachability{
+		DeviceID: device.ID,
+		Address:  opts.ManagementAddress(device),
+	}
+
+	var err error
+	result.SSH, err = tcpConnect(ctx, result.Address, opts.SSHPort, opts.Timeout)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if opts.NETCONFPort > 0 {
+		result.NETCONF, err = tcpConnect(ctx, result.Address, opts.NETCONFPort, opts.Timeout)
+		if err != nil && !result.SSH {
+			result.Error = err.Error()
+		}
+	}
+	if result.Manageable() {
+		result.Error = ""
+	}
+
+	result.CheckedAt = time.Now()
+	if ctx.Err() != nil {
+		// 停止時の失敗は到達不能として記録しない
+		result.CheckedAt = time.Time{}
+	}
+	return result
+}
+
+// tcpConnect reports whether a TCP connection to host:port is accepted within timeout
+func tcpConnect(ctx context.Context, host string, port int, timeout time.Duration) (bool, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return false, err
+	}
+	conn.Close()
+	return true, nil
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+func TestProbeManagementPlanes(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	sshPort := listener.Addr().(*net.TCPAddr).Port
+
+	// 閉じたポートを NETCONF として使う
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	netconfPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	devices := []topology.Device{
+		{ID: "sw-reachable", Metadata: map[string]string{"mgmt_address": "127.0.0.1"}},
+		{ID: "127.0.0.2:9116"},
+	}
+	opts := topology.ManagementCheckOptions{SSHPort: sshPort, NETCONFPort: netconfPort, Timeout: time.Second}
+
+	results := probeManagementPlanes(context.Background(), devices, opts)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", results)
+	}
+
+	reachable := results[0]
+	if !reachable.SSH || reachable.NETCONF || !reachable.Manageable() || reachable.Error != "" || reachable.Address != "127.0.0.1" {
+		t.Errorf("Expected SSH only on 127.0.0.1 without error, got %+v", reachable)
+	}
+
+	unreachable := results[1]
+	if unreachable.Address != "127.0.0.2" {
+		t.Errorf("Expected the host of the instance ID to be probed, got %s", unreachable.Address)
+	}
+	// リスナーは 127.0.0.1 のみなので 127.0.0.2 への接続は拒否される
+	if unreachable.Manageable() || unreachable.Error == "" {
+		t.Errorf("Expected %s to be unmanageable with an error, got %+v", unreachable.DeviceID, unreachable)
+	}
+	if reachable.CheckedAt.IsZero() || unreachable.CheckedAt.IsZero() {
+		t.Error("Expected check times to be recorded")
+	}
+}
@@ -2,12 +2,15 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/servak/topology-manager/internal/domain/classification"
 	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/domain/visualization"
 	"github.com/servak/topology-manager/internal/prometheus"
 	"github.com/servak/topology-manager/internal/service"
 )
@@ -18,7 +21,17 @@ type PrometheusSync struct {
 	metricsExtractor      *prometheus.MetricsExtractor
 	lldpParser            *prometheus.LLDPParser
 	repository            topology.Repository
+	historyRepository     topology.LinkHistoryRepository
 	classificationService *service.ClassificationService
+	provisioningService   *service.ProvisioningService
+	reservationService    *service.PortReservationService // nil = ポート予約なし
+	layoutService         *service.VisualizationService
+	schemaRepository      topology.SchemaMaintenanceRepository       // nil = オンラインマイグレーション非対応
+	managementRepository  topology.ManagementReachabilityRepository  // nil = 管理プレーンの到達性を記録しない
+	statsRepository       topology.SyncStatsRepository               // nil = 同期サイクルの変化量を記録しない
+	suggestionRepository  classification.SuggestionHistoryRepository // nil = 提案を定期的に生成しない
+	orphanService         *service.OrphanedLinkService               // nil = 削除済みデバイスを記録できない
+	lastObservation       *topology.SyncObservation                  // nil = 起動後まだ同期していない
 	scheduler             *Scheduler
 	logger                *log.Logger
 	config                PrometheusSyncConfig
@@ -30,20 +43,69 @@ type PrometheusSyncConfig struct {
 	LLDPSyncInterval   time.Duration `yaml:"lldp_sync_interval"`
 	DeviceSyncInterval time.Duration `yaml:"device_sync_interval"`
 	CleanupInterval    time.Duration `yaml:"cleanup_interval"`
+	CompactionInterval time.Duration `yaml:"compaction_interval"`
 
 	// Sync behavior
 	EnableLLDPSync     bool `yaml:"enable_lldp_sync"`
 	EnableDeviceSync   bool `yaml:"enable_device_sync"`
 	EnableCleanup      bool `yaml:"enable_cleanup"`
 	EnableAutoClassify bool `yaml:"enable_auto_classify"`
+	EnableCompaction   bool `yaml:"enable_compaction"`
+
+	// Layout precomputation for frequently requested views
+	LayoutPrecomputeInterval time.Duration `yaml:"layout_precompute_interval"`
+	EnableLayoutPrecompute   bool          `yaml:"enable_layout_precompute"`
+	LayoutPrecomputeViews    int           `yaml:"layout_precompute_views"`
+
+	// Online schema migration backfills (rows updated per batch)
+	SchemaBackfillInterval  time.Duration `yaml:"schema_backfill_interval"`
+	EnableSchemaBackfill    bool          `yaml:"enable_schema_backfill"`
+	SchemaBackfillBatchSize int           `yaml:"schema_backfill_batch_size"`
 
 	// Data management
 	MaxDeviceAge time.Duration `yaml:"max_device_age"`
 	MaxLinkAge   time.Duration `yaml:"max_link_age"`
 
+	// Link history retention
+	LinkHistoryRawRetention     time.Duration `yaml:"link_history_raw_retention"`
+	LinkHistorySummaryRetention time.Duration `yaml:"link_history_summary_retention"`
+
+	// Retention of the per-cycle change counts (0 = keep forever)
+	SyncStatsRetention time.Duration `yaml:"sync_stats_retention"`
+
 	// Batch settings
 	BatchSize   int           `yaml:"batch_size"`
 	SyncTimeout time.Duration `yaml:"sync_timeout"`
+
+	// Ingestion limits (0 = unlimited)
+	Quota topology.IngestQuota `yaml:"quota"`
+
+	// Attributes of devices only seen as LLDP neighbors
+	Placeholder topology.PlaceholderDefaults `yaml:"placeholder"`
+
+	// Links still reported for devices deleted through the API, dropped during the grace period
+	OrphanLinks topology.OrphanedLinkPolicy `yaml:"orphan_links"`
+
+	// Metadata tags set on matching devices before they are stored
+	TagRules []classification.TagRule `yaml:"tag_rules"`
+
+	// Metadata required per device type, checked on every device sync
+	MetadataSchema topology.MetadataSchema `yaml:"metadata_schema"`
+
+	// Server-to-port inference from MAC/ARP table metrics
+	EnableMACSync bool                         `yaml:"enable_mac_sync"`
+	MACTable      topology.MACInferenceOptions `yaml:"mac_table"`
+
+	// Management-plane (SSH/NETCONF) reachability of monitored devices
+	EnableMgmtCheck   bool                            `yaml:"enable_mgmt_check"`
+	MgmtCheckInterval time.Duration                   `yaml:"mgmt_check_interval"`
+	MgmtCheck         topology.ManagementCheckOptions `yaml:"mgmt_check"`
+
+	// Scheduled classification rule suggestions, notified from SuggestionNotifyConfidence
+	EnableSuggestions          bool          `yaml:"enable_suggestions"`
+	SuggestionInterval         time.Duration `yaml:"suggestion_interval"`
+	SuggestionNotifyConfidence float64       `yaml:"suggestion_notify_confidence"`
+	SuggestionWebhookURL       string        `yaml:"suggestion_webhook_url"` // empty = notifications are only logged
 }
 
 // DefaultPrometheusSyncConfig returns default configuration
@@ -52,17 +114,39 @@ func DefaultPrometheusSyncConfig() PrometheusSyncConfig {
 		LLDPSyncInterval:   5 * time.Minute,
 		DeviceSyncInterval: 10 * time.Minute,
 		CleanupInterval:    1 * time.Hour,
+		CompactionInterval: 24 * time.Hour,
 		EnableLLDPSync:     true,
 		EnableDeviceSync:   true,
 		EnableCleanup:      true,
 		EnableAutoClassify: true,
+		EnableCompaction:   true,
 		MaxDeviceAge:       24 * time.Hour,
 		MaxLinkAge:         12 * time.Hour,
 		BatchSize:          100,
 		SyncTimeout:        10 * time.Minute,
+
+		LinkHistoryRawRetention:     30 * 24 * time.Hour,
+		LinkHistorySummaryRetention: 365 * 24 * time.Hour,
+		SyncStatsRetention:          30 * 24 * time.Hour,
+
+		LayoutPrecomputeInterval: 15 * time.Minute,
+		EnableLayoutPrecompute:   true,
+		LayoutPrecomputeViews:    20,
+
+		SchemaBackfillInterval:  1 * time.Minute,
+		EnableSchemaBackfill:    true,
+		SchemaBackfillBatchSize: 1000,
+
+		MgmtCheckInterval: 15 * time.Minute,
+
+		SuggestionInterval:         1 * time.Hour,
+		SuggestionNotifyConfidence: classification.DefaultSuggestionNotifyConfidence,
 	}
 }
 
+// layoutPopularityWindow is how far back view requests count towards popularity
+const layoutPopularityWindow = 7 * 24 * time.Hour
+
 // NewPrometheusSync creates a new Prometheus synchronization worker
 func NewPrometheusSync(
 	promClient *prometheus.Client,
@@ -77,16 +161,59 @@ func NewPrometheusSync(
 	}
 
 	metricsExtractor := prometheus.NewMetricsExtractor(promClient, metricsConfig)
-	lldpParser := prometheus.NewLLDPParser(promClient)
+	lldpParser := prometheus.NewLLDPParser(promClient, metricsConfig.DescriptionParser())
 	scheduler := NewScheduler(logger)
 	classificationService := service.NewClassificationService(classificationRepo, repository)
+	classificationService.SetMetadataSchema(config.MetadataSchema)
+
+	// 履歴の圧縮に対応していないリポジトリではコンパクションを行わない
+	historyRepository, _ := repository.(topology.LinkHistoryRepository)
+
+	// 計画デバイスに対応したリポジトリでは同期後に検出デバイスとマージする
+	var provisioningService *service.ProvisioningService
+	if provisioningRepo, ok := repository.(topology.ProvisioningRepository); ok {
+		provisioningService = service.NewProvisioningService(provisioningRepo, repository)
+		provisioningService.SetPlaceholderDefaults(config.Placeholder)
+		provisioningService.SetMetadataSchema(config.MetadataSchema)
+	}
+
+	// ポート予約に対応したリポジトリでは同期したリンクと予約の競合を報告する
+	var reservationService *service.PortReservationService
+	if reservationRepo, ok := repository.(topology.PortReservationRepository); ok {
+		reservationService = service.NewPortReservationService(reservationRepo, repository)
+	}
+
+	// レイアウトキャッシュに対応したリポジトリでは人気ビューのレイアウトを事前計算する
+	var layoutService *service.VisualizationService
+	if _, ok := repository.(visualization.LayoutCacheRepository); ok {
+		layoutService = service.NewVisualizationService(repository)
+	}
+
+	schemaRepository, _ := repository.(topology.SchemaMaintenanceRepository)
+	managementRepository, _ := repository.(topology.ManagementReachabilityRepository)
+	statsRepository, _ := repository.(topology.SyncStatsRepository)
+	suggestionRepository, _ := classificationRepo.(classification.SuggestionHistoryRepository)
+
+	var orphanService *service.OrphanedLinkService
+	if orphanRepo, ok := repository.(topology.OrphanedLinkRepository); ok {
+		orphanService = service.NewOrphanedLinkService(orphanRepo, repository, config.OrphanLinks)
+	}
 
 	return &PrometheusSync{
 		promClient:            promClient,
 		metricsExtractor:      metricsExtractor,
 		lldpParser:            lldpParser,
 		repository:            repository,
+		historyRepository:     historyRepository,
 		classificationService: classificationService,
+		provisioningService:   provisioningService,
+		reservationService:    reservationService,
+		layoutService:         layoutService,
+		schemaRepository:      schemaRepository,
+		managementRepository:  managementRepository,
+		statsRepository:       statsRepository,
+		suggestionRepository:  suggestionRepository,
+		orphanService:         orphanService,
 		scheduler:             scheduler,
 		logger:                logger,
 		config:                config,
@@ -131,6 +258,84 @@ func (ps *PrometheusSync) Start() error {
 		}
 	}
 
+	// Add link history compaction task
+	if ps.config.EnableCompaction && ps.historyRepository != nil {
+		compactionTask := NewTaskBuilder("link_history_compaction", "Link History Compaction").
+			Description("Rolls up old link observations into daily summaries and drops expired partitions").
+			Interval(ps.config.CompactionInterval).
+			Timeout(ps.config.SyncTimeout).
+			Function(ps.compactLinkHistory).
+			Build()
+
+		if err := ps.scheduler.AddTask(compactionTask); err != nil {
+			return fmt.Errorf("failed to add link history compaction task: %w", err)
+		}
+	}
+
+	// Add layout precomputation task
+	if ps.config.EnableLayoutPrecompute && ps.layoutService != nil {
+		layoutTask := NewTaskBuilder("layout_precompute", "Layout Precomputation").
+			Description("Precomputes and caches layouts of frequently requested views").
+			Interval(ps.config.LayoutPrecomputeInterval).
+			Timeout(ps.config.SyncTimeout).
+			Function(ps.precomputeLayouts).
+			Build()
+
+		if err := ps.scheduler.AddTask(layoutTask); err != nil {
+			return fmt.Errorf("failed to add layout precompute task: %w", err)
+		}
+	}
+
+	// Add schema backfill task
+	if ps.config.EnableSchemaBackfill && ps.schemaRepository != nil {
+		backfillTask := NewTaskBuilder("schema_backfill", "Schema Backfill").
+			Description("Fills columns added by expand migrations in small batches so contract migrations can run").
+			Interval(ps.config.SchemaBackfillInterval).
+			Timeout(ps.config.SyncTimeout).
+			Function(ps.runSchemaBackfills).
+			Build()
+
+		if err := ps.scheduler.AddTask(backfillTask); err != nil {
+			return fmt.Errorf("failed to add schema backfill task: %w", err)
+		}
+	}
+
+	// Add management reachability task
+	if ps.config.EnableMgmtCheck {
+		if ps.managementRepository == nil {
+			ps.logger.Println("Warning: management reachability check is not supported by this repository - skipping")
+		} else {
+			mgmtTask := NewTaskBuilder("mgmt_reachability", "Management Reachability Check").
+				Description("Tries SSH and NETCONF connections to the management addresses of monitored devices").
+				Interval(ps.config.MgmtCheckInterval).
+				Timeout(ps.config.SyncTimeout).
+				Function(ps.checkManagementReachability).
+				Build()
+
+			if err := ps.scheduler.AddTask(mgmtTask); err != nil {
+				return fmt.Errorf("failed to add management reachability task: %w", err)
+			}
+		}
+	}
+
+	// Add scheduled suggestion generation task
+	if ps.config.EnableSuggestions {
+		if ps.suggestionRepository == nil {
+			ps.logger.Println("Warning: scheduled suggestion generation is not supported by this repository - skipping")
+		} else {
+			suggestionTask := NewTaskBuilder("suggestion_generation", "Suggestion Generation").
+				Description("Suggests classification rules from manual classifications and notifies new high-confidence suggestions").
+				Interval(ps.config.SuggestionInterval).
+				Timeout(ps.config.SyncTimeout).
+				Function(ps.generateSuggestions).
+				Build()
+
+			if err := ps.scheduler.AddTask(suggestionTask); err != nil {
+				return fmt.Errorf("failed to add suggestion generation task: %w", err)
+			}
+		}
+	}
+
 	// Start the scheduler
 	ps.scheduler.Start()
 
@@ -167,17 +372,27 @@ func (ps *PrometheusSync) SyncNow() error {
 	return nil
 }
 
+// RunOnce runs one complete topology synchronization without starting the scheduler
+func (ps *PrometheusSync) RunOnce(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, ps.config.SyncTimeout)
+	defer cancel()
+	return ps.syncCompleteTopology(ctx)
+}
+
 // Private synchronization methods
 
 func (ps *PrometheusSync) syncCompleteTopology(ctx context.Context) error {
 	ps.logger.Println("Starting complete topology synchronization...")
 
 	var allErrors []error
+	startedAt := time.Now()
+	observation := topology.NewSyncObservation()
 
 	// Step 1: Synchronize device information first
 	if ps.config.EnableDeviceSync {
 		ps.logger.Println("Phase 1: Synchronizing device information...")
-		if err := ps.syncDeviceInfo(ctx); err != nil {
+		if err := ps.syncDeviceInfo(ctx, observation); err != nil {
+			observation.KeepDevices(ps.lastObservation)
 			allErrors = append(allErrors, fmt.Errorf("device sync failed: %w", err))
 			ps.logger.Printf("Device sync failed, but continuing with LLDP sync: %v", err)
 		} else {
@@ -188,7 +403,8 @@ func (ps *PrometheusSync) syncCompleteTopology(ctx context.Context) error {
 	// Step 2: Synchronize LLDP topology (with placeholder device creation)
 	if ps.config.EnableLLDPSync {
 		ps.logger.Println("Phase 2: Synchronizing LLDP topology...")
-		if err := ps.syncLLDPTopology(ctx); err != nil {
+		if err := ps.syncLLDPTopology(ctx, observation); err != nil {
+			observation.KeepLinks(ps.lastObservation)
 			allErrors = append(allErrors, fmt.Errorf("LLDP sync failed: %w", err))
 			ps.logger.Printf("LLDP sync failed: %v", err)
 		} else {
@@ -196,6 +412,27 @@ func (ps *PrometheusSync) syncCompleteTopology(ctx context.Context) error {
 		}
 	}
 
+	// Step 2b: Infer server ports from MAC tables (after LLDP, to skip inter-switch ports)
+	if ps.config.EnableMACSync {
+		ps.logger.Println("Phase 2b: Synchronizing MAC tables...")
+		if err := ps.syncMACTable(ctx); err != nil {
+			allErrors = append(allErrors, fmt.Errorf("MAC table sync failed: %w", err))
+			ps.logger.Printf("MAC table sync failed: %v", err)
+		} else {
+			ps.logger.Println("Phase 2b: MAC table synchronization completed successfully")
+		}
+	}
+
+	// Step 3: Merge planned devices with discovered devices
+	if ps.provisioningService != nil {
+		if err := ps.reconcilePlannedDevices(ctx); err != nil {
+			ps.logger.Printf("Planned device reconciliation failed: %v", err)
+			// Don't return error - this is not critical for data sync
+		}
+	}
+
+	ps.recordSyncStats(ctx, observation, startedAt, len(allErrors))
+
 	if len(allErrors) > 0 {
 		ps.logger.Printf("Complete topology synchronization finished with %d errors", len(allErrors))
 		return fmt.Errorf("topology sync errors: %v", allErrors)
@@ -205,7 +442,7 @@ func (ps *PrometheusSync) syncCompleteTopology(ctx context.Context) error {
 	return nil
 }
 
-func (ps *PrometheusSync) syncLLDPTopology(ctx context.Context) error {
+func (ps *PrometheusSync) syncLLDPTopology(ctx context.Context, observation *topology.SyncObservation) error {
 	ps.logger.Println("Starting LLDP topology synchronization...")
 
 	// Extract links using MetricsExtractor with fallback support
@@ -215,6 +452,10 @@ func (ps *PrometheusSync) syncLLDPTopology(ctx context.Context) error {
 	for _, warning := range warnings {
 		ps.logger.Printf("Info: %v", warning)
 	}
+	if err := partialResponse(warnings); err != nil {
+		return err
+	}
+	observation.AddLinks(links)
 
 	if len(links) == 0 {
 		ps.logger.Println("No links extracted from Prometheus - skipping this cycle")
@@ -223,21 +464,40 @@ func (ps *PrometheusSync) syncLLDPTopology(ctx context.Context) error {
 
 	ps.logger.Printf("Successfully extracted %d links using metrics mapping", len(links))
 
+	// 削除したデバイスを、近隣がまだ報告するリンクからプレースホルダーとして再作成しない
+	links, err := ps.dropOrphanedLinks(ctx, links)
+	if err != nil {
+		return fmt.Errorf("failed to drop links of deleted devices: %w", err)
+	}
+
 	// Ensure all devices referenced by links exist before inserting links
 	if err := ps.ensureReferencedDevicesExist(ctx, links); err != nil {
 		return fmt.Errorf("failed to ensure referenced devices exist: %w", err)
 	}
 
+	// Apply ingestion quota (links to devices over the quota are skipped)
+	links, err = ps.admitLinks(ctx, links)
+	if err != nil {
+		return fmt.Errorf("failed to apply link quota: %w", err)
+	}
+
 	// Batch process links
 	if err := ps.batchAddLinks(ctx, links); err != nil {
 		return fmt.Errorf("failed to add links: %w", err)
 	}
 
+	if ps.reservationService != nil {
+		if err := ps.reportReservationConflicts(ctx, links); err != nil {
+			ps.logger.Printf("Port reservation check failed: %v", err)
+			// Don't return error - links are already stored
+		}
+	}
+
 	ps.logger.Printf("LLDP topology synchronization completed, processed %d links", len(links))
 	return nil
 }
 
-func (ps *PrometheusSync) syncDeviceInfo(ctx context.Context) error {
+func (ps *PrometheusSync) syncDeviceInfo(ctx context.Context, observation *topology.SyncObservation) error {
 	ps.logger.Println("Starting device information synchronization...")
 
 	// Extract devices using MetricsExtractor with fallback support
@@ -247,6 +507,10 @@ func (ps *PrometheusSync) syncDeviceInfo(ctx context.Context) error {
 	for _, warning := range warnings {
 		ps.logger.Printf("Info: %v", warning)
 	}
+	if err := partialResponse(warnings); err != nil {
+		return err
+	}
+	observation.AddDevices(devices)
 
 	if len(devices) == 0 {
 		ps.logger.Println("No devices extracted from Prometheus - skipping this cycle")
@@ -255,6 +519,25 @@ func (ps *PrometheusSync) syncDeviceInfo(ctx context.Context) error {
 
 	ps.logger.Printf("Successfully extracted %d devices using metrics mapping", len(devices))
 
+	// Apply ingestion quota (new devices over the quota are skipped)
+	devices, err := ps.admitDevices(ctx, devices)
+	if err != nil {
+		return fmt.Errorf("failed to apply device quota: %w", err)
+	}
+
+	// 同期はメタデータを置き換えるため、保存する前にタグを付ける
+	ps.applyTagRules(devices)
+	ps.reportMetadataViolations(devices)
+
+	// 更新で上書きされる前に、情報が届いたプレースホルダーを特定する
+	var enriched []string
+	if ps.config.EnableAutoClassify {
+		enriched, err = ps.findEnrichedPlaceholders(ctx, devices)
+		if err != nil {
+			ps.logger.Printf("Failed to check placeholders for enrichment: %v", err)
+		}
+	}
+
 	// Batch process devices
 	if err := ps.batchAddDevices(ctx, devices); err != nil {
 		return fmt.Errorf("failed to add/update devices: %w", err)
@@ -262,6 +545,13 @@ func (ps *PrometheusSync) syncDeviceInfo(ctx context.Context) error {
 
 	// Step 3: Apply auto-classification to newly added devices
 	if ps.config.EnableAutoClassify {
+		if len(enriched) > 0 {
+			if err := ps.reclassifyEnrichedPlaceholders(ctx, enriched); err != nil {
+				ps.logger.Printf("Re-classification of enriched placeholders failed: %v", err)
+			}
+			devices = excludeDevices(devices, enriched)
+		}
+
 		ps.logger.Println("Phase 3: Applying auto-classification to devices...")
 		if err := ps.applyAutoClassification(ctx, devices); err != nil {
 			ps.logger.Printf("Auto-classification failed: %v", err)
@@ -275,19 +565,138 @@ func (ps *PrometheusSync) syncDeviceInfo(ctx context.Context) error {
 	return nil
 }
 
+// partialResponse returns an error when Prometheus only partly answered the queries of a phase.
+// Devices and links missing from a partial response would count as gone, so the phase fails and
+// the cycle keeps what the previous one observed.
+func partialResponse(warnings []error) error {
+	for _, warning := range warnings {
+		if errors.Is(warning, prometheus.ErrPartialResponse) {
+			return fmt.Errorf("skipping this cycle: %w", warning)
+		}
+	}
+	return nil
+}
+
+// recordSyncStats counts the changes since the previous cycle and stores them with the cycle.
+// Failing to store them does not fail the synchronization.
+func (ps *PrometheusSync) recordSyncStats(ctx context.Context, observation *topology.SyncObservation, startedAt time.Time, errors int) {
+	stats := observation.Compare(ps.lastObservation)
+	ps.lastObservation = observation
+	stats.StartedAt = startedAt
+	stats.FinishedAt = time.Now()
+	stats.Errors = errors
+
+	if !stats.Baseline {
+		ps.logger.Printf("Topology changes since the last cycle: devices +%d/-%d, links +%d/-%d",
+			stats.DevicesAppeared, stats.DevicesDisappeared, stats.LinksAdded, stats.LinksRemoved)
+	}
+	if ps.statsRepository == nil {
+		return
+	}
+
+	if err := ps.statsRepository.SaveSyncCycleStats(ctx, stats); err != nil {
+		ps.logger.Printf("Failed to record sync cycle stats: %v", err)
+		return
+	}
+	if ps.config.SyncStatsRetention > 0 {
+		if _, err := ps.statsRepository.DeleteSyncCycleStats(ctx, stats.FinishedAt.Add(-ps.config.SyncStatsRetention)); err != nil {
+			ps.logger.Printf("Failed to expire sync cycle stats: %v", err)
+		}
+	}
+}
+
 func (ps *PrometheusSync) cleanupOldData(ctx context.Context) error {
 	ps.logger.Println("Starting data cleanup...")
 
-	// Note: This is a simplified cleanup implementation
-	// In a real implementation, you would want to:
-	// 1. Find devices/links not seen for MaxDeviceAge/MaxLinkAge
-	// 2. Remove them from the database
-	// 3. Handle cascade deletions properly
+	// Note: devices and links not seen for MaxDeviceAge/MaxLinkAge are not removed yet
+
+	if err := ps.purgeDeletedDevices(ctx); err != nil {
+		return err
+	}
 
 	ps.logger.Println("Data cleanup completed")
 	return nil
 }
 
+// dropOrphanedLinks leaves out the links reported for devices deleted within the grace period
+func (ps *PrometheusSync) dropOrphanedLinks(ctx context.Context, links []topology.Link) ([]topology.Link, error) {
+	if ps.orphanService == nil {
+		return links, nil
+	}
+
+	kept, dropped, err := ps.orphanService.DropOrphanedLinks(ctx, links, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if dropped > 0 {
+		ps.logger.Printf("Dropped %d links still reported for deleted devices (see /api/v1/links/orphaned)", dropped)
+	}
+	return kept, nil
+}
+
+// purgeDeletedDevices forgets the device deletions whose grace period has passed
+func (ps *PrometheusSync) purgeDeletedDevices(ctx context.Context) error {
+	if ps.orphanService == nil {
+		return nil
+	}
+
+	purged, err := ps.orphanService.Purge(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted devices: %w", err)
+	}
+	if purged > 0 {
+		ps.logger.Printf("Forgot %d device deletions older than %v", purged, ps.config.OrphanLinks.WithDefaults().GracePeriod)
+	}
+	return nil
+}
+
+func (ps *PrometheusSync) compactLinkHistory(ctx context.Context) error {
+	ps.logger.Println("Starting link history compaction...")
+
+	result, err := ps.historyRepository.CompactLinkHistory(ctx, topology.LinkHistoryRetention{
+		RawRetention:     ps.config.LinkHistoryRawRetention,
+		SummaryRetention: ps.config.LinkHistorySummaryRetention,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compact link history: %w", err)
+	}
+
+	ps.logger.Printf("Link history compaction completed: %d rows compacted, %d raw rows deleted, %d summaries expired",
+		result.CompactedRows, result.DeletedRawRows, result.DeletedSummaries)
+	for _, name := range result.CreatedPartitions {
+		ps.logger.Printf("  - Created partition %s", name)
+	}
+	for _, name := range result.DroppedPartitions {
+		ps.logger.Printf("  - Dropped partition %s", name)
+	}
+
+	return nil
+}
+
+func (ps *PrometheusSync) precomputeLayouts(ctx context.Context) error {
+	ps.logger.Println("Starting layout precomputation...")
+
+	computed, err := ps.layoutService.PrecomputePopularLayouts(ctx, time.Now().Add(-layoutPopularityWindow), ps.config.LayoutPrecomputeViews)
+	if err != nil {
+		return fmt.Errorf("failed to precompute layouts: %w", err)
+	}
+
+	ps.logger.Printf("Layout precomputation completed: %d layouts cached", computed)
+	return nil
+}
+
+func (ps *PrometheusSync) runSchemaBackfills(ctx context.Context) error {
+	rows, err := ps.schemaRepository.RunSchemaBackfills(ctx, ps.config.SchemaBackfillBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to run schema backfills: %w", err)
+	}
+	// 未完了のバックフィルがない間は毎分ログを出さない
+	if rows > 0 {
+		ps.logger.Printf("Schema backfill updated %d rows", rows)
+	}
+	return nil
+}
+
 func (ps *PrometheusSync) batchAddDevices(ctx context.Context, devices []topology.Device) error {
 	batchSize := ps.config.BatchSize
 	if batchSize <= 0 {
@@ -358,7 +767,12 @@ func (ps *PrometheusSync) ensureReferencedDevicesExist(ctx context.Context, link
 	// Check which devices already exist
 	existingDevices := make(map[string]bool)
 	for _, deviceID := range deviceIDs {
-		if device, err := ps.repository.GetDevice(ctx, deviceID); err == nil && device != nil {
+		device, err := ps.repository.GetDevice(ctx, deviceID)
+		if err != nil {
+			// 読み取りに失敗したデバイスをプレースホルダーで上書きしないよう中断する
+			return fmt.Errorf("failed to check device %s: %w", deviceID, err)
+		}
+		if device != nil {
 			existingDevices[deviceID] = true
 		}
 	}
@@ -369,21 +783,18 @@ func (ps *PrometheusSync) ensureReferencedDevicesExist(ctx context.Context, link
 
 	for _, deviceID := range deviceIDs {
 		if !existingDevices[deviceID] {
-			device := topology.Device{
-				ID:        deviceID,
-				Type:      "unknown",
-				Hardware:  "unknown",
-				LayerID:   nil, // will be set by classification
-				Metadata:  make(map[string]string),
-				LastSeen:  now,
-				CreatedAt: now,
-				UpdatedAt: now,
-			}
-			missingDevices = append(missingDevices, device)
+			missingDevices = append(missingDevices, ps.config.Placeholder.NewPlaceholder(deviceID, now))
 		}
 	}
 
+	// プレースホルダーもデバイス数の上限に含める
+	missingDevices, err := ps.admitDevices(ctx, missingDevices)
+	if err != nil {
+		return err
+	}
+
 	if len(missingDevices) > 0 {
+		ps.applyTagRules(missingDevices)
 		ps.logger.Printf("Creating %d placeholder devices for LLDP-discovered devices not in Prometheus monitoring", len(missingDevices))
 		for _, device := range missingDevices {
 			ps.logger.Printf("  - Creating placeholder for device: %s (likely managed by another team)", device.ID)
@@ -406,6 +817,83 @@ func (ps *PrometheusSync) ensureReferencedDevicesExist(ctx context.Context, link
 	return nil
 }
 
+// reconcilePlannedDevices merges planned devices with discovered ones and reports template mismatches
+func (ps *PrometheusSync) reconcilePlannedDevices(ctx context.Context) error {
+	reconciled, err := ps.provisioningService.ReconcilePlannedDevices(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile planned devices: %w", err)
+	}
+
+	for _, planned := range reconciled {
+		if planned.Status != topology.PlannedDeviceStatusMismatch {
+			continue
+		}
+		ps.logger.Printf("Planned device %s does not match its template:", planned.ID)
+		for _, m := range planned.Mismatches {
+			ps.logger.Printf("  - %s: expected %q, got %q", m.Field, m.Expected, m.Actual)
+		}
+	}
+
+	return nil
+}
+
+// reportReservationConflicts logs reserved ports on which the synced links show a neighbor
+// other than the planned one
+func (ps *PrometheusSync) reportReservationConflicts(ctx context.Context, links []topology.Link) error {
+	conflicts, err := ps.reservationService.FindConflicts(ctx, links)
+	if err != nil {
+		return fmt.Errorf("failed to check port reservations: %w", err)
+	}
+
+	for _, reservation := range conflicts {
+		expected := "no neighbor"
+		if reservation.PeerDevice != "" {
+			expected = reservation.PeerDevice
+			if reservation.PeerPort != "" {
+				expected += " " + reservation.PeerPort
+			}
+		}
+		ps.logger.Printf("Warning: reserved port %s %s (ticket %s) expects %s, discovered %s %s",
+			reservation.DeviceID, reservation.Port, reservation.Ticket, expected, reservation.Neighbor.DeviceID, reservation.Neighbor.Port)
+	}
+
+	return nil
+}
+
+// applyTagRules sets the metadata tags of the configured tag rules on devices
+func (ps *PrometheusSync) applyTagRules(devices []topology.Device) {
+	if len(ps.config.TagRules) == 0 {
+		return
+	}
+	if tagged := ps.classificationService.ApplyTagRules(devices, ps.config.TagRules); tagged > 0 {
+		ps.logger.Printf("Tagged %d of %d devices from %d tag rules", tagged, len(devices), len(ps.config.TagRules))
+	}
+}
+
+// metadataViolationLogLimit is how many devices lacking required metadata are logged per sync
+const metadataViolationLogLimit = 10
+
+// reportMetadataViolations logs the synced devices lacking the metadata required for their device
+// type. Monitoring is the source of truth, so they are stored anyway; an enforced schema only keeps
+// them from being classified as that type.
+func (ps *PrometheusSync) reportMetadataViolations(devices []topology.Device) {
+	if len(ps.config.MetadataSchema.Required) == 0 {
+		return
+	}
+	report := topology.CheckMetadataCompliance(devices, ps.config.MetadataSchema, time.Now())
+	if len(report.Violations) == 0 {
+		return
+	}
+	ps.logger.Printf("Warning: %d of %d checked devices lack required metadata", len(report.Violations), report.CheckedDevices)
+	for i, violation := range report.Violations {
+		if i == metadataViolationLogLimit {
+			ps.logger.Printf("  ... and %d more (see /api/v1/analysis/metadata)", len(report.Violations)-i)
+			break
+		}
+		ps.logger.Printf("  - %s (%s) lacks %s", violation.DeviceID, violation.DeviceType, strings.Join(violation.Missing, ", "))
+	}
+}
+
 // applyAutoClassification applies classification rules to devices
 func (ps *PrometheusSync) applyAutoClassification(ctx context.Context, devices []topology.Device) error {
 	if ps.classificationService == nil {
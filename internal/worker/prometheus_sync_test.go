@@ -0,0 +1,173 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+	"github.com/servak/topology-manager/internal/prometheus"
+	"github.com/servak/topology-manager/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPartialPrometheus serves two switches and their link; while partial is set it reports a
+// partial response (as vmselect does with a vmstorage node down) lacking leaf-02
+func newPartialPrometheus(t *testing.T, partial *bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := float64(time.Now().Unix())
+		var result []map[string]interface{}
+		switch query := r.URL.Query().Get("query"); {
+		case strings.Contains(query, "device_info"):
+			for _, id := range []string{"leaf-01", "leaf-02"} {
+				if *partial && id == "leaf-02" {
+					continue
+				}
+				result = append(result, map[string]interface{}{"metric": map[string]string{"instance": id}, "value": []interface{}{now, "1"}})
+			}
+		case strings.Contains(query, "lldp"):
+			if !*partial {
+				result = append(result, map[string]interface{}{
+					"metric": map[string]string{"instance": "leaf-01", "local_port": "Ethernet1", "remote": "leaf-02", "remote_port": "Ethernet1"},
+					"value":  []interface{}{now, "1"},
+				})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    "success",
+			"isPartial": *partial,
+			"data":      map[string]interface{}{"resultType": "vector", "result": result},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testMetricsConfig maps the device_info and lldp_neighbor_info series served by the test servers
+func testMetricsConfig() *prometheus.MetricsConfig {
+	return &prometheus.MetricsConfig{
+		MetricsMapping: map[string]prometheus.MetricConfigGroup{
+			"device_info": {Primary: prometheus.MetricMapping{
+				MetricName: "device_info",
+				Labels:     map[string]string{"device_id": "instance"},
+			}},
+			"lldp_neighbors": {Primary: prometheus.MetricMapping{
+				MetricName: "lldp_neighbor_info",
+				Labels: map[string]string{
+					"source_device": "instance",
+					"source_port":   "local_port",
+					"target_device": "remote",
+					"target_port":   "remote_port",
+				},
+			}},
+		},
+	}
+}
+
+func TestPrometheusSync_PartialResponseSkipsCycle(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	ctx := context.Background()
+
+	partial := false
+	promClient := prometheus.NewClient(prometheus.Config{URL: newPartialPrometheus(t, &partial).URL})
+	config := DefaultPrometheusSyncConfig()
+	config.EnableAutoClassify = false
+	sync := NewPrometheusSync(promClient, testMetricsConfig(), setup.Repo, setup.Repo, config, log.New(io.Discard, "", 0))
+
+	require.NoError(t, sync.RunOnce(ctx))
+
+	partial = true
+	err := sync.RunOnce(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "partial response")
+
+	// 部分応答で欠けたデバイスとリンクは消えたとみなさない
+	stats, err := setup.Repo.(topology.SyncStatsRepository).ListSyncCycleStats(ctx, time.Time{}, 0)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, 2, stats[1].Errors)
+	assert.Equal(t, 0, stats[1].DevicesDisappeared)
+	assert.Equal(t, 0, stats[1].LinksRemoved)
+	assert.Equal(t, stats[0].Devices, stats[1].Devices)
+}
+
+// newNeighborPrometheus serves leaf-01 and the LLDP entry it keeps for server-01, which is only
+// known as a neighbor
+func newNeighborPrometheus(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := float64(time.Now().Unix())
+		var result []map[string]interface{}
+		switch query := r.URL.Query().Get("query"); {
+		case strings.Contains(query, "device_info"):
+			result = append(result, map[string]interface{}{"metric": map[string]string{"instance": "leaf-01"}, "value": []interface{}{now, "1"}})
+		case strings.Contains(query, "lldp"):
+			result = append(result, map[string]interface{}{
+				"metric": map[string]string{"instance": "leaf-01", "local_port": "Ethernet1", "remote": "server-01", "remote_port": "eth0"},
+				"value":  []interface{}{now, "1"},
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data":   map[string]interface{}{"resultType": "vector", "result": result},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPrometheusSync_DeletedDeviceNotRecreated(t *testing.T) {
+	setup := testutil.NewTestSetup(t)
+	t.Cleanup(setup.Cleanup)
+	ctx := context.Background()
+
+	promClient := prometheus.NewClient(prometheus.Config{URL: newNeighborPrometheus(t).URL})
+	config := DefaultPrometheusSyncConfig()
+	config.EnableAutoClassify = false
+	config.OrphanLinks = topology.OrphanedLinkPolicy{GracePeriod: time.Hour}
+	sync := NewPrometheusSync(promClient, testMetricsConfig(), setup.Repo, setup.Repo, config, log.New(io.Discard, "", 0))
+
+	require.NoError(t, sync.RunOnce(ctx))
+	placeholder, err := setup.Repo.GetDevice(ctx, "server-01")
+	require.NoError(t, err)
+	require.NotNil(t, placeholder, "the neighbor should be added as a placeholder")
+
+	_, err = setup.Repo.(topology.DeviceDeletionRepository).DeleteDevices(ctx, []string{"server-01"}, false)
+	require.NoError(t, err)
+
+	// leaf-01 がまだ報告するリンクからは再作成しない
+	require.NoError(t, sync.RunOnce(ctx))
+	recreated, err := setup.Repo.GetDevice(ctx, "server-01")
+	require.NoError(t, err)
+	assert.Nil(t, recreated)
+	links, err := setup.Repo.GetDeviceLinks(ctx, "leaf-01")
+	require.NoError(t, err)
+	assert.Empty(t, links)
+
+	report, err := sync.orphanService.Report(ctx, time.Now())
+	require.NoError(t, err)
+	require.Len(t, report.Devices, 1)
+	assert.Equal(t, "server-01", report.Devices[0].DeviceID)
+	assert.Len(t, report.Devices[0].OrphanedLinks, 1)
+	assert.NotNil(t, report.Devices[0].LastReportedAt)
+
+	// 猶予期間が過ぎても報告され続けるなら、実在するデバイスとして再作成する
+	purged, err := sync.orphanService.Purge(ctx, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	require.NoError(t, sync.RunOnce(ctx))
+	recreated, err = setup.Repo.GetDevice(ctx, "server-01")
+	require.NoError(t, err)
+	assert.NotNil(t, recreated)
+}
@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// SQLite deployments get these ingestion limits unless they are set explicitly
+const (
+	SQLiteDefaultMaxDevices = 10000
+	SQLiteDefaultMaxLinks   = 50000
+)
+
+// admitDevices applies the device quota. Devices over the quota are skipped with a warning;
+// the rest of the sync continues (partial ingest).
+func (ps *PrometheusSync) admitDevices(ctx context.Context, devices []topology.Device) ([]topology.Device, error) {
+	quota := ps.config.Quota
+	if quota.MaxDevices <= 0 {
+		return devices, nil
+	}
+
+	stored, err := ps.storedDeviceIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	admitted, rejected := quota.AdmitDevices(devices, stored)
+	if len(rejected) > 0 {
+		ps.logger.Printf("WARNING: device quota of %d reached (%d stored): skipped %d new devices (%s). Raise --max-devices or narrow prometheus.filters",
+			quota.MaxDevices, len(stored), len(rejected), sampleIDs(rejected, 5))
+	}
+	return admitted, nil
+}
+
+// admitLinks drops links to devices rejected by the device quota and applies the link quota
+func (ps *PrometheusSync) admitLinks(ctx context.Context, links []topology.Link) ([]topology.Link, error) {
+	quota := ps.config.Quota
+
+	if quota.MaxDevices > 0 {
+		stored, err := ps.storedDeviceIDs(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		kept := make([]topology.Link, 0, len(links))
+		for _, link := range links {
+			if stored[link.SourceID] && stored[link.TargetID] {
+				kept = append(kept, link)
+			}
+		}
+		if skipped := len(links) - len(kept); skipped > 0 {
+			ps.logger.Printf("WARNING: skipped %d links to devices over the device quota of %d", skipped, quota.MaxDevices)
+		}
+		links = kept
+	}
+
+	admitted, dropped := quota.AdmitLinks(links)
+	if dropped > 0 {
+		ps.logger.Printf("WARNING: link quota of %d reached: skipped %d links. Raise --max-links or narrow prometheus.filters",
+			quota.MaxLinks, dropped)
+	}
+	return admitted, nil
+}
+
+// storedDeviceIDs returns the IDs of every device in the repository
+func (ps *PrometheusSync) storedDeviceIDs(ctx context.Context) (map[string]bool, error) {
+	devices, err := ps.storedDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		ids[device.ID] = true
+	}
+	return ids, nil
+}
+
+// storedDevices returns every device in the repository
+func (ps *PrometheusSync) storedDevices(ctx context.Context) ([]topology.Device, error) {
+	const pageSize = 10000
+
+	var all []topology.Device
+	opts := topology.PaginationOptions{PageSize: pageSize, Keyset: true}
+	for {
+		devices, pagination, err := ps.repository.GetDevices(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stored devices: %w", err)
+		}
+		all = append(all, devices...)
+		if pagination == nil || !pagination.HasNext || len(devices) == 0 {
+			return all, nil
+		}
+		opts.After = devices[len(devices)-1].ID
+	}
+}
+
+// sampleIDs formats the first n IDs for log messages
+func sampleIDs(ids []string, n int) string {
+	if len(ids) <= n {
+		return strings.Join(ids, ", ")
+	}
+	return fmt.Sprintf("%s, ... and %d more", strings.Join(ids[:n], ", "), len(ids)-n)
+}
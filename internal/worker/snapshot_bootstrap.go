@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/topology"
+)
+
+// SnapshotBootstrapOptions controls how a snapshot is loaded into the repository
+type SnapshotBootstrapOptions struct {
+	// Lookback is the instant query lookback of Prometheus (0 = DefaultPrometheusLookback)
+	Lookback time.Duration
+	MaxDrift float64
+	// SkipVerify loads the snapshot without comparing it with Prometheus
+	SkipVerify bool
+	// Force loads the snapshot into a repository that already has devices, or when verification fails
+	Force bool
+	// VerifyOnly verifies the snapshot without loading it
+	VerifyOnly bool
+	// Sync runs one incremental sync right after loading
+	Sync bool
+}
+
+// VerifySnapshot compares snapshot with the topology Prometheus reported at its timestamp.
+// Nothing is written.
+func (ps *PrometheusSync) VerifySnapshot(ctx context.Context, snapshot *topology.Snapshot, opts SnapshotBootstrapOptions) (*topology.SnapshotVerification, error) {
+	observed, err := ps.Backfill(ctx, snapshot.TakenAt)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		// データのない時刻は検証結果の問題として報告する
+		observed = nil
+	}
+
+	// クリーンアップより先に同期で更新されないと、取り込んだデータが期限切れで消える
+	maxAge := ps.config.MaxDeviceAge
+	if ps.config.MaxLinkAge > 0 && ps.config.MaxLinkAge < maxAge {
+		maxAge = ps.config.MaxLinkAge
+	}
+
+	verification := topology.VerifySnapshot(snapshot, observed, topology.SnapshotVerifyOptions{
+		Now:      time.Now(),
+		MaxAge:   maxAge,
+		Lookback: opts.Lookback,
+		MaxDrift: opts.MaxDrift,
+	})
+	return &verification, nil
+}
+
+// BootstrapFromSnapshot loads a previously exported snapshot into an empty repository so that
+// incremental sync only has to apply what changed since the snapshot was taken. The snapshot is
+// verified against Prometheus first; devices and links keep their provenance and last seen time,
+// so entries that are no longer reported age out as usual.
+func (ps *PrometheusSync) BootstrapFromSnapshot(ctx context.Context, snapshot *topology.Snapshot, opts SnapshotBootstrapOptions) (*topology.SnapshotBootstrapResult, error) {
+	result := &topology.SnapshotBootstrapResult{}
+
+	if !opts.VerifyOnly {
+		_, page, err := ps.repository.GetDevices(ctx, topology.PaginationOptions{Page: 1, PageSize: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing devices: %w", err)
+		}
+		if page.TotalCount > 0 && !opts.Force {
+			return nil, fmt.Errorf("repository already has %d devices; bootstrap only into an empty repository or use force", page.TotalCount)
+		}
+	}
+
+	if !opts.SkipVerify {
+		ps.logger.Printf("Verifying snapshot taken at %s against Prometheus...", snapshot.TakenAt.Format(time.RFC3339))
+		verification, err := ps.VerifySnapshot(ctx, snapshot, opts)
+		if err != nil {
+			return nil, err
+		}
+		result.Verification = verification
+		if !verification.Consistent() {
+			if !opts.Force || opts.VerifyOnly {
+				return result, fmt.Errorf("snapshot verification failed: %d problems", len(verification.Problems))
+			}
+			ps.logger.Printf("Loading snapshot despite %d verification problems", len(verification.Problems))
+		}
+	}
+	if opts.VerifyOnly {
+		return result, nil
+	}
+
+	ps.logger.Printf("Loading %d devices and %d links from snapshot...", len(snapshot.Devices), len(snapshot.Links))
+	if err := ps.batchAddDevices(ctx, snapshot.Devices); err != nil {
+		return result, err
+	}
+	result.Devices = len(snapshot.Devices)
+	if err := ps.batchAddLinks(ctx, snapshot.Links); err != nil {
+		return result, err
+	}
+	result.Links = len(snapshot.Links)
+
+	if opts.Sync {
+		if err := ps.RunOnce(ctx); err != nil {
+			return result, fmt.Errorf("snapshot loaded but the incremental sync failed: %w", err)
+		}
+		result.Synced = true
+	}
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+// suggestionWebhookTimeout bounds a notification so that an unreachable receiver does not hold the task
+const suggestionWebhookTimeout = 10 * time.Second
+
+// generateSuggestions saves the new rule suggestions and notifies those of at least
+// SuggestionNotifyConfidence. Suggestions already rejected are not generated again.
+func (ps *PrometheusSync) generateSuggestions(ctx context.Context) error {
+	ps.logger.Println("Starting suggestion generation...")
+
+	run, err := ps.classificationService.GenerateNewSuggestions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to generate suggestions: %w", err)
+	}
+	ps.logger.Printf("Suggestion generation completed: %d generated, %d new, %d previously rejected, %d already known",
+		run.Generated, len(run.Created), run.SkippedRejected, run.SkippedExisting)
+
+	high := run.HighConfidence(ps.config.SuggestionNotifyConfidence)
+	if len(high) == 0 {
+		return nil
+	}
+
+	notification := classification.SuggestionNotification{
+		Event:         classification.SuggestionsEvent,
+		CreatedAt:     time.Now(),
+		MinConfidence: ps.config.SuggestionNotifyConfidence,
+		Suggestions:   high,
+	}
+	for _, suggestion := range high {
+		ps.logger.Printf("  - new suggestion %s: %s (confidence %.2f, %d devices)",
+			suggestion.ID, suggestion.Rule.Name, suggestion.Confidence, suggestion.AffectedCount)
+	}
+
+	if ps.config.SuggestionWebhookURL == "" {
+		return nil
+	}
+	// 通知に失敗しても提案は保存済みなので、次回に再送はせずエラーとして記録する
+	if err := postSuggestionNotification(ctx, ps.config.SuggestionWebhookURL, notification); err != nil {
+		return fmt.Errorf("failed to notify %d new suggestions: %w", len(high), err)
+	}
+	ps.logger.Printf("Notified %d new high-confidence suggestions", len(high))
+	return nil
+}
+
+// postSuggestionNotification posts notification as JSON to url
+func postSuggestionNotification(ctx context.Context, url string, notification classification.SuggestionNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, suggestionWebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
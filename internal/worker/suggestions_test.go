@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/servak/topology-manager/internal/domain/classification"
+)
+
+func TestPostSuggestionNotification(t *testing.T) {
+	var received classification.SuggestionNotification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	notification := classification.SuggestionNotification{
+		Event:         classification.SuggestionsEvent,
+		CreatedAt:     time.Now(),
+		MinConfidence: 0.9,
+		Suggestions:   []classification.ClassificationSuggestion{{ID: "s1", Confidence: 0.95}},
+	}
+	if err := postSuggestionNotification(context.Background(), server.URL, notification); err != nil {
+		t.Fatalf("Expected the notification to be delivered, got %v", err)
+	}
+	if received.Event != classification.SuggestionsEvent || len(received.Suggestions) != 1 || received.Suggestions[0].ID != "s1" {
+		t.Errorf("Unexpected notification %+v", received)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := postSuggestionNotification(context.Background(), failing.URL, notification); err == nil {
+		t.Errorf("Expected an error when the webhook fails")
+	}
+}
@@ -76,9 +76,13 @@ func GroupByLongestCommonPrefix(deviceNames []string, minGroupSize int) []Group
 		})
 	}
 
-	// Sort groups by count (descending)
+	// Sort groups by count (descending), then by prefix so that the order
+	// (and the group IDs derived from it) is the same on every call
 	sort.Slice(result, func(i, j int) bool {
-		return result[i].Count > result[j].Count
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Prefix < result[j].Prefix
 	})
 
 	return result
@@ -184,9 +188,12 @@ func GroupByType(deviceTypes map[string]string) []Group {
 		}
 	}
 
-	// Sort by count
+	// Sort by count, then by name
 	sort.Slice(result, func(i, j int) bool {
-		return result[i].Count > result[j].Count
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Prefix < result[j].Prefix
 	})
 
 	return result
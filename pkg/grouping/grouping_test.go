@@ -67,6 +67,18 @@ func TestGroupByLongestCommonPrefix_ExactMinimum(t *testing.T) {
 	}
 }
 
+func TestGroupByLongestCommonPrefix_StableOrder(t *testing.T) {
+	deviceNames := []string{"leaf-01", "spine-01", "leaf-02", "spine-02", "leaf-03", "spine-03"}
+
+	// 同じ大きさのグループもプレフィックス順に並ぶ（グループIDが呼び出しごとに変わらない）
+	for i := 0; i < 20; i++ {
+		groups := GroupByLongestCommonPrefix(deviceNames, 3)
+		if len(groups) != 2 || groups[0].Prefix != "leaf-" || groups[1].Prefix != "spine-" {
+			t.Fatalf("Expected leaf- then spine-, got %+v", groups)
+		}
+	}
+}
+
 func TestLongestCommonPrefix(t *testing.T) {
 	tests := []struct {
 		str1     string
@@ -0,0 +1,44 @@
+package signedtoken
+
+import (
+	"errors"
+	"testing"
+)
+
+type testClaims struct {
+	Key       string `json:"key"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func TestSignVerify(t *testing.T) {
+	secret := []byte("secret")
+	token, err := Sign(secret, "download", testClaims{Key: "exports/a.csv", ExpiresAt: 1700000000})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var claims testClaims
+	if err := Verify(secret, "download", token, &claims); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if claims.Key != "exports/a.csv" || claims.ExpiresAt != 1700000000 {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		secret  []byte
+		purpose string
+		token   string
+	}{
+		{"wrong secret", []byte("other"), "download", token},
+		// 同じ秘密鍵で署名した別用途のトークンは受け付けない
+		{"other purpose", secret, "share", token},
+		{"no signature", secret, "download", "e30"},
+		{"tampered signature", secret, "download", token + "x"},
+	} {
+		if err := Verify(tc.secret, tc.purpose, tc.token, &claims); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected ErrInvalid, got %v", tc.name, err)
+		}
+	}
+}
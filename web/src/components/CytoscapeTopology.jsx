@@ -128,12 +128,12 @@ const CytoscapeTopology = ({ topology, selectedDevice, onDeviceSelect }) => {
           id: `edge-${index}`,
           source: edge.source,
           target: edge.target,
-          label: edge.local_port && edge.remote_port ? 
-            `${edge.local_port} ↔ ${edge.remote_port}` : '',
+          label: edge.label || (edge.local_port && edge.remote_port ?
+            `${edge.local_port} ↔ ${edge.remote_port}` : ''),
           status: edge.status || 'up',
           weight: edge.weight || 1
         },
-        classes: `edge ${edge.status === 'up' ? 'edge-up' : 'edge-down'}`
+        classes: `edge ${edge.status === 'up' ? 'edge-up' : 'edge-down'}${edge.asymmetric ? ' edge-asymmetric' : ''}`
       });
     });
 
@@ -250,6 +250,17 @@ const CytoscapeTopology = ({ topology, selectedDevice, onDeviceSelect }) => {
           }
         },
         
+        // Links seen from only one side (LLDP disabled or filtered)
+        {
+          selector: '.edge-asymmetric',
+          style: {
+            'line-color': '#f39c12',
+            'target-arrow-color': '#f39c12',
+            'line-style': 'dashed',
+            'line-dash-pattern': [6, 3]
+          }
+        },
+        
         // Hover states
         {
           selector: 'node:active',
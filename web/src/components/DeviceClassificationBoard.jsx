@@ -3,6 +3,26 @@ import './DeviceClassificationBoard.css'
 
 // Note: Hierarchy layers are now loaded from the API instead of using defaults
 
+// ルール条件のフィールドと演算子は /api/v1/classification/rules/schema から取得する（取得前・失敗時の既定値）
+const DEFAULT_OPERATORS = ['equals', 'contains', 'starts_with', 'ends_with', 'regex']
+const DEFAULT_RULE_SCHEMA = {
+  fields: ['type', 'hardware', 'name'].map(field => ({ field, operators: DEFAULT_OPERATORS, samples: [] }))
+}
+
+const FIELD_LABELS = {
+  type: 'デバイスタイプ',
+  hardware: 'ハードウェア',
+  name: 'デバイス名'
+}
+
+const OPERATOR_LABELS = {
+  equals: '完全一致',
+  contains: '含む',
+  starts_with: 'で始まる',
+  ends_with: 'で終わる',
+  regex: '正規表現'
+}
+
 function DeviceClassificationBoard() {
   const [unclassifiedDevices, setUnclassifiedDevices] = useState([])
   const [classifiedDevices, setClassifiedDevices] = useState({}) // { layerId: [devices] }
@@ -18,6 +38,8 @@ function DeviceClassificationBoard() {
   const [selectedLayer, setSelectedLayer] = useState(null) // 選択された階層のサイドバー表示用
   const [showLayerManager, setShowLayerManager] = useState(false) // 階層管理表示用
   const [editingLayer, setEditingLayer] = useState(null) // 編集中の階層
+  const [canWrite, setCanWrite] = useState(true) // 読み取り専用モードまたは閲覧ロールでは false
+  const [ruleSchema, setRuleSchema] = useState(DEFAULT_RULE_SCHEMA) // ルール条件に使えるフィールドと演算子
   const [pagination, setPagination] = useState({ // ページネーション情報
     limit: 100,
     offset: 0,
@@ -35,9 +57,11 @@ function DeviceClassificationBoard() {
       // Load hierarchy layers first, then classified devices
       await loadHierarchyLayers()
       await Promise.all([
+        loadCapabilities(),
         loadUnclassifiedDevices(),
         loadClassifiedDevices(),
-        loadClassificationRules()
+        loadClassificationRules(),
+        loadRuleSchema()
       ])
     } catch (err) {
       setError('データの読み込みに失敗しました')
@@ -46,6 +70,18 @@ function DeviceClassificationBoard() {
     }
   }
 
+  const loadCapabilities = async () => {
+    try {
+      const response = await fetch('/api/v1/capabilities')
+      if (!response.ok) return
+      const data = await response.json()
+      setCanWrite(data.can_write)
+    } catch (err) {
+      // 取得できない場合は従来どおり編集可能として扱う
+      console.error('Failed to load capabilities:', err)
+    }
+  }
+
   const loadUnclassifiedDevices = async (limit = 100, offset = 0) => {
     try {
       const response = await fetch(`/api/v1/classification/devices/unclassified?limit=${limit}&offset=${offset}`)
@@ -201,6 +237,18 @@ function DeviceClassificationBoard() {
     }
   }
 
+  const loadRuleSchema = async () => {
+    try {
+      const response = await fetch('/api/v1/classification/rules/schema')
+      if (!response.ok) throw new Error('Failed to load rule schema')
+      const data = await response.json()
+      setRuleSchema(data)
+    } catch (err) {
+      // 取得できない場合は組み込みのフィールドのみ選択できる
+      console.error('Failed to load rule schema:', err)
+    }
+  }
+
   const classifyDevice = async (deviceId, layer, deviceType) => {
     try {
       const response = await fetch('/api/v1/classification/devices', {
@@ -436,6 +484,7 @@ function DeviceClassificationBoard() {
             <span className="stat-value">{classificationRules.filter(r => r.is_active).length}</span>
           </span>
         </div>
+        {canWrite && (
         <div className="board-actions">
           <button 
             onClick={() => setShowLayerManager(!showLayerManager)} 
@@ -457,8 +506,15 @@ function DeviceClassificationBoard() {
             🤖 自動分類実行
           </button>
         </div>
+        )}
       </div>
 
+      {!canWrite && (
+        <div className="alert alert-info">
+          🔒 閲覧専用です。分類と階層はここでは変更できません
+        </div>
+      )}
+
       {error && (
         <div className="alert alert-error">
           ❌ {error}
@@ -656,14 +712,14 @@ function DeviceClassificationBoard() {
         <div className="unclassified-section">
           <div className="section-header">
             <h3>📦 未分類デバイス ({unclassifiedDevices.length}件表示 / 総{pagination.total}件)</h3>
-            <p className="section-description">デバイスを右の階層にドラッグ&ドロップして分類してください</p>
+            {canWrite && <p className="section-description">デバイスを右の階層にドラッグ&ドロップして分類してください</p>}
           </div>
           <div className="device-pool">
             {unclassifiedDevices.map(device => (
               <div
                 key={device.id}
                 className="device-card unclassified"
-                draggable
+                draggable={canWrite}
                 onDragStart={(e) => handleDragStart(e, device)}
               >
                 <div className="device-icon">{getDeviceIcon(device)}</div>
@@ -807,10 +863,11 @@ function DeviceClassificationBoard() {
                           }}
                           className="form-input"
                         >
-                          <option value="type">デバイスタイプ</option>
-                          <option value="hardware">ハードウェア</option>
-                          <option value="name">デバイス名</option>
-                          <option value="instance">インスタンス</option>
+                          {ruleSchema.fields.map(field => (
+                            <option key={field.field} value={field.field}>
+                              {FIELD_LABELS[field.field] || field.field}
+                            </option>
+                          ))}
                         </select>
                         
                         <select
@@ -822,11 +879,11 @@ function DeviceClassificationBoard() {
                           }}
                           className="form-input"
                         >
-                          <option value="equals">完全一致</option>
-                          <option value="contains">含む</option>
-                          <option value="starts_with">で始まる</option>
-                          <option value="ends_with">で終わる</option>
-                          <option value="regex">正規表現</option>
+                          {(ruleSchema.fields.find(field => field.field === condition.field)?.operators || DEFAULT_OPERATORS).map(operator => (
+                            <option key={operator} value={operator}>
+                              {OPERATOR_LABELS[operator] || operator}
+                            </option>
+                          ))}
                         </select>
                         
                         <input
@@ -839,7 +896,13 @@ function DeviceClassificationBoard() {
                           }}
                           className="form-input"
                           placeholder="値を入力"
+                          list={`condition-samples-${index}`}
                         />
+                        <datalist id={`condition-samples-${index}`}>
+                          {ruleSchema.fields.find(field => field.field === condition.field)?.samples?.map(sample => (
+                            <option key={sample.value} value={sample.value}>{sample.count}台</option>
+                          ))}
+                        </datalist>
                         
                         {editingRule.conditions.length > 1 && (
                           <button
@@ -1055,13 +1118,15 @@ function DeviceClassificationBoard() {
                       {classification.is_manual ? '手動' : '自動'}分類
                     </div>
                   </div>
-                  <button
-                    className="unclassify-btn"
-                    onClick={() => handleUnclassifyDevice(classification.device_id)}
-                    title="分類を解除"
-                  >
-                    ×
-                  </button>
+                  {canWrite && (
+                    <button
+                      className="unclassify-btn"
+                      onClick={() => handleUnclassifyDevice(classification.device_id)}
+                      title="分類を解除"
+                    >
+                      ×
+                    </button>
+                  )}
                 </div>
               ))}
               